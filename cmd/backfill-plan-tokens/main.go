@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/storage/pg"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	var (
+		batchSize       = flag.Int("batch", 1000, "Rows per batch")
+		pause           = flag.Duration("pause", 100*time.Millisecond, "Pause between batches to limit database load")
+		multipliersFile = flag.String("multipliers", "", "YAML map of model -> multiplier overriding config/config.yaml (e.g., historical prices)")
+		checkpointFile  = flag.String("checkpoint", ".backfill-plan-tokens.checkpoint", "File storing the last processed request_logs.id for resume")
+		restart         = flag.Bool("restart", false, "Ignore the checkpoint and start from the first row")
+		overwrite       = flag.Bool("overwrite", false, "Recompute rows that already have plan tokens")
+		dryRun          = flag.Bool("dry-run", false, "Compute and report without writing")
+		showHelp        = flag.Bool("help", false, "Show help")
+	)
+	flag.Parse()
+
+	if *showHelp {
+		fmt.Println("Plan Token Backfill")
+		fmt.Println("Populates request_logs.plan_tokens/token_multiplier for rows logged without them.")
+		fmt.Println("Usage: go run cmd/backfill-plan-tokens/main.go [options]")
+		fmt.Println("")
+		fmt.Println("Options:")
+		flag.PrintDefaults()
+		fmt.Println("")
+		fmt.Println("Examples:")
+		fmt.Println("  go run cmd/backfill-plan-tokens/main.go -dry-run")
+		fmt.Println("  go run cmd/backfill-plan-tokens/main.go -batch 5000")
+		fmt.Println("  go run cmd/backfill-plan-tokens/main.go -multipliers historical.yaml -overwrite -restart")
+		return
+	}
+
+	if *batchSize <= 0 {
+		log.Fatalf("-batch must be positive")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	config.LoadConfig()
+
+	overrides, err := loadMultiplierOverrides(*multipliersFile)
+	if err != nil {
+		log.Fatalf("Failed to load multipliers: %v", err)
+	}
+	table := request_tracking.NewMultiplierTable(config.AppConfig.ModelRouterConfig, overrides)
+
+	db, err := pg.InitDatabase(config.AppConfig.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.DB.Close() //nolint:errcheck
+
+	afterID := int64(0)
+	if !*restart {
+		afterID, err = readCheckpoint(*checkpointFile)
+		if err != nil {
+			log.Fatalf("Failed to read checkpoint: %v", err)
+		}
+		if afterID > 0 {
+			fmt.Printf("Resuming after request_logs.id %d\n", afterID)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var updated, unknown, scanned int
+	unknownModels := make(map[string]int)
+
+	for ctx.Err() == nil {
+		rows, err := db.Queries.ListRequestLogsForPlanTokenBackfill(ctx, pgdb.ListRequestLogsForPlanTokenBackfillParams{
+			AfterID:   afterID,
+			Overwrite: *overwrite,
+			BatchSize: int32(*batchSize),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Fatalf("Failed to list request logs after id %d: %v", afterID, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		batchUpdated, err := backfillBatch(ctx, db, table, rows, *dryRun, unknownModels)
+		if err != nil {
+			log.Fatalf("Failed to backfill batch after id %d: %v", afterID, err)
+		}
+
+		scanned += len(rows)
+		updated += batchUpdated
+		unknown += len(rows) - batchUpdated
+		afterID = rows[len(rows)-1].ID
+
+		if !*dryRun {
+			if err := writeCheckpoint(*checkpointFile, afterID); err != nil {
+				log.Fatalf("Failed to write checkpoint: %v", err)
+			}
+		}
+
+		fmt.Printf("Processed through id %d: %d scanned, %d updated, %d unknown model\n", afterID, scanned, updated, unknown)
+
+		if len(rows) < *batchSize {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(*pause):
+		}
+	}
+
+	if ctx.Err() != nil {
+		fmt.Printf("\nInterrupted after id %d; rerun to resume from the checkpoint\n", afterID)
+	} else {
+		fmt.Printf("\n✅ Backfill complete: %d scanned, %d updated, %d skipped\n", scanned, updated, unknown)
+	}
+
+	if len(unknownModels) > 0 {
+		fmt.Println("\nSkipped models without a multiplier (add them to -multipliers):")
+		for model, count := range unknownModels {
+			fmt.Printf("  %-40s %d\n", model, count)
+		}
+	}
+	if *dryRun {
+		fmt.Println("\nDry run: no rows were written")
+	}
+}
+
+// backfillBatch updates one batch in a single transaction and returns the number of rows updated.
+// Rows whose model has no multiplier are left untouched and counted in unknownModels.
+func backfillBatch(ctx context.Context, db *pg.Database, table *request_tracking.MultiplierTable, rows []pgdb.ListRequestLogsForPlanTokenBackfillRow, dryRun bool, unknownModels map[string]int) (int, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	queries := db.Queries.WithTx(tx)
+
+	updated := 0
+	for _, row := range rows {
+		model := ""
+		if row.Model != nil {
+			model = *row.Model
+		}
+
+		multiplier, ok := table.Lookup(model)
+		if !ok {
+			unknownModels[model]++
+			continue
+		}
+		updated++
+
+		if dryRun {
+			continue
+		}
+
+		planTokens := request_tracking.PlanTokens(int(row.TotalTokens.Int32), multiplier)
+		if err := queries.UpdateRequestLogPlanTokens(ctx, pgdb.UpdateRequestLogPlanTokensParams{
+			ID:              row.ID,
+			PlanTokens:      sql.NullInt32{Int32: int32(planTokens), Valid: true},
+			TokenMultiplier: sql.NullString{String: fmt.Sprintf("%.2f", multiplier), Valid: true},
+		}); err != nil {
+			return 0, fmt.Errorf("row %d: %w", row.ID, err)
+		}
+	}
+
+	if dryRun {
+		return updated, nil
+	}
+	return updated, tx.Commit()
+}
+
+// loadMultiplierOverrides reads a YAML map of model name to multiplier.
+func loadMultiplierOverrides(path string) (map[string]float64, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides map[string]float64
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, err
+	}
+	for model, multiplier := range overrides {
+		if multiplier <= 0 {
+			return nil, fmt.Errorf("multiplier for %q must be positive", model)
+		}
+	}
+
+	return overrides, nil
+}
+
+func readCheckpoint(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func writeCheckpoint(path string, id int64) error {
+	return os.WriteFile(path, []byte(strconv.FormatInt(id, 10)+"\n"), 0o600)
+}
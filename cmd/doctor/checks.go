@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/storage/pg"
+	"github.com/eternisai/enchanted-proxy/internal/task"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/api/iterator"
+)
+
+// serpAPIAccountURL is SerpAPI's account endpoint, which validates the key without using up
+// searches.
+const serpAPIAccountURL = "https://serpapi.com/account.json"
+
+// doctor runs the dependency checks.
+type doctor struct {
+	cfg     *config.Config
+	timeout time.Duration
+	client  http.Client
+	logger  *logger.Logger
+}
+
+// run runs all checks in report order.
+func (d *doctor) run() []Result {
+	d.client = http.Client{Timeout: d.timeout}
+	// Silent: failures are reported in the results
+	d.logger = logger.New(logger.Config{Level: slog.LevelError + 4})
+
+	results := []Result{
+		d.check("database", d.checkDatabase),
+		d.check("firestore", d.checkFirestore),
+	}
+	results = append(results, d.checkProviders()...)
+	results = append(results,
+		d.check("temporal", d.checkTemporal),
+		d.check("nats", d.checkNATS),
+		d.check("serpapi", d.checkSerpAPI),
+	)
+	return results
+}
+
+// checkDatabase connects to the database and compares the applied migrations with the embedded ones.
+func (d *doctor) checkDatabase(ctx context.Context) (string, string) {
+	db, err := pg.Open(d.cfg.DatabaseURL)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	defer db.Close()
+
+	if err := db.PingContext(ctx); err != nil {
+		return StatusFail, fmt.Sprintf("ping failed: %v", err)
+	}
+
+	current, latest, err := pg.MigrationStatus(db)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	switch {
+	case current < latest:
+		// The server applies pending migrations on startup
+		return StatusWarn, fmt.Sprintf("migration version %d, %d pending (latest %d)", current, latest-current, latest)
+	case current > latest:
+		return StatusWarn, fmt.Sprintf("migration version %d is ahead of this build (latest %d)", current, latest)
+	}
+	return StatusOK, fmt.Sprintf("migration version %d (up to date)", current)
+}
+
+// checkFirestore authenticates with the Firebase credentials and runs a minimal query.
+func (d *doctor) checkFirestore(ctx context.Context) (string, string) {
+	if d.cfg.FirebaseCredJSON == "" {
+		return StatusSkip, "FIREBASE_CRED_JSON not set"
+	}
+
+	client, err := auth.NewFirebaseClient(ctx, d.cfg.FirebaseProjectID, d.cfg.FirebaseCredJSON, d.logger)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	defer client.Close()
+
+	iter := client.GetFirestoreClient().Collection("users").Limit(1).Documents(ctx)
+	defer iter.Stop()
+	if _, err := iter.Next(); err != nil && err != iterator.Done {
+		return StatusFail, fmt.Sprintf("query failed: %v", err)
+	}
+	return StatusOK, "project " + d.cfg.FirebaseProjectID
+}
+
+// providerKey is an API key of a configured provider to validate.
+type providerKey struct {
+	name    string
+	baseURL string
+	apiKey  string
+	envVar  string
+}
+
+// providerKeys returns the keys to check, one per provider (two for OpenRouter, which is keyed
+// by client platform).
+func (d *doctor) providerKeys() []providerKey {
+	if d.cfg.ModelRouterConfig == nil {
+		return nil
+	}
+
+	var keys []providerKey
+	for _, provider := range d.cfg.ModelRouterConfig.Providers {
+		if provider.Name == "OpenRouter" && provider.APIKeyEnvVar == "" {
+			keys = append(keys,
+				providerKey{name: provider.Name + " (mobile)", baseURL: provider.BaseURL, apiKey: d.cfg.OpenRouterMobileAPIKey, envVar: "OPENROUTER_MOBILE_API_KEY"},
+				providerKey{name: provider.Name + " (desktop)", baseURL: provider.BaseURL, apiKey: d.cfg.OpenRouterDesktopAPIKey, envVar: "OPENROUTER_DESKTOP_API_KEY"},
+			)
+			continue
+		}
+		keys = append(keys, providerKey{name: provider.Name, baseURL: provider.BaseURL, apiKey: provider.APIKey, envVar: provider.APIKeyEnvVar})
+	}
+	return keys
+}
+
+// checkProviders validates each provider key with a model list request, which costs no tokens.
+func (d *doctor) checkProviders() []Result {
+	keys := d.providerKeys()
+	if len(keys) == 0 {
+		return []Result{{Name: "providers", Status: StatusFail, Detail: "no providers configured"}}
+	}
+
+	results := make([]Result, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, d.check("provider: "+key.name, func(ctx context.Context) (string, string) {
+			return d.checkProviderKey(ctx, key)
+		}))
+	}
+	return results
+}
+
+func (d *doctor) checkProviderKey(ctx context.Context, key providerKey) (string, string) {
+	if key.baseURL == "" {
+		return StatusSkip, "base URL set per model"
+	}
+	if key.apiKey == "" {
+		if key.envVar == "" {
+			return StatusSkip, "no API key configured"
+		}
+		return StatusFail, key.envVar + " not set"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(key.baseURL, "/")+"/models", nil)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	req.Header.Set("Authorization", "Bearer "+key.apiKey)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return StatusFail, fmt.Sprintf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return StatusOK, "GET /models " + resp.Status
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return StatusFail, "API key rejected: " + resp.Status
+	default:
+		// Some providers don't serve a model list; the key can't be validated but the host is up
+		return StatusWarn, "GET /models " + resp.Status
+	}
+}
+
+// checkTemporal connects to Temporal with the server's client options (Dial checks the
+// connection).
+func (d *doctor) checkTemporal(ctx context.Context) (string, string) {
+	if d.cfg.TemporalEndpoint == "" && d.cfg.TemporalNamespace == "" && d.cfg.TemporalAPIKey == "" {
+		return StatusSkip, "TEMPORAL_ENDPOINT not set"
+	}
+
+	service, err := task.NewService(d.cfg.TemporalEndpoint, d.cfg.TemporalNamespace, d.cfg.TemporalAPIKey, nil, d.logger)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	service.Close()
+	return StatusOK, fmt.Sprintf("namespace %s at %s", d.cfg.TemporalNamespace, d.cfg.TemporalEndpoint)
+}
+
+// checkNATS connects to NATS and round-trips a flush.
+func (d *doctor) checkNATS(ctx context.Context) (string, string) {
+	if d.cfg.NatsURL == "" {
+		return StatusSkip, "NATS_URL not set (distributed stream cancel and revocation events are local only)"
+	}
+
+	nc, err := nats.Connect(d.cfg.NatsURL, nats.Timeout(d.timeout))
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	defer nc.Close()
+
+	if err := nc.FlushWithContext(ctx); err != nil {
+		return StatusFail, fmt.Sprintf("flush failed: %v", err)
+	}
+	return StatusOK, "server " + nc.ConnectedServerName()
+}
+
+// checkSerpAPI validates the SerpAPI key against the account endpoint.
+func (d *doctor) checkSerpAPI(ctx context.Context) (string, string) {
+	if d.cfg.SerpAPIKey == "" {
+		return StatusSkip, "SERPAPI_API_KEY not set"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serpAPIAccountURL+"?api_key="+url.QueryEscape(d.cfg.SerpAPIKey), nil)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// The error includes the URL, and with it the key
+		return StatusFail, "request failed: " + strings.ReplaceAll(err.Error(), url.QueryEscape(d.cfg.SerpAPIKey), "***")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode != http.StatusOK {
+		return StatusFail, "account lookup returned " + resp.Status
+	}
+	return StatusOK, "account lookup " + resp.Status
+}
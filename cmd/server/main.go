@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"log/slog"
 	"net"
 	"net/http"
@@ -19,11 +20,25 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/eternisai/enchanted-proxy/graph"
+	"github.com/eternisai/enchanted-proxy/internal/admin"
 	"github.com/eternisai/enchanted-proxy/internal/anonymizer"
+	"github.com/eternisai/enchanted-proxy/internal/apiversion"
+	"github.com/eternisai/enchanted-proxy/internal/attestation"
 	"github.com/eternisai/enchanted-proxy/internal/auth"
 	"github.com/eternisai/enchanted-proxy/internal/background"
+	"github.com/eternisai/enchanted-proxy/internal/bans"
+	"github.com/eternisai/enchanted-proxy/internal/batches"
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/eternisai/enchanted-proxy/internal/chatsearch"
+	"github.com/eternisai/enchanted-proxy/internal/clientversion"
+	"github.com/eternisai/enchanted-proxy/internal/compliance"
 	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+	"github.com/eternisai/enchanted-proxy/internal/debugtrace"
 	"github.com/eternisai/enchanted-proxy/internal/deepr"
+	"github.com/eternisai/enchanted-proxy/internal/digest"
+	"github.com/eternisai/enchanted-proxy/internal/experiments"
 	"github.com/eternisai/enchanted-proxy/internal/fai"
 	"github.com/eternisai/enchanted-proxy/internal/fallback"
 	"github.com/eternisai/enchanted-proxy/internal/health"
@@ -32,38 +47,49 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/keyshare"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/mcp"
+	"github.com/eternisai/enchanted-proxy/internal/messageindex"
+	"github.com/eternisai/enchanted-proxy/internal/messagestore"
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
 	"github.com/eternisai/enchanted-proxy/internal/notifications"
+	"github.com/eternisai/enchanted-proxy/internal/preferences"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
 	"github.com/eternisai/enchanted-proxy/internal/problem_reports"
 	"github.com/eternisai/enchanted-proxy/internal/proxy"
+	"github.com/eternisai/enchanted-proxy/internal/quality"
+	"github.com/eternisai/enchanted-proxy/internal/ratequeue"
 	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/resilience"
+	"github.com/eternisai/enchanted-proxy/internal/revocation"
+	"github.com/eternisai/enchanted-proxy/internal/rollups"
 	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/routingconfig"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
 	"github.com/eternisai/enchanted-proxy/internal/search"
+	"github.com/eternisai/enchanted-proxy/internal/statuspage"
 	"github.com/eternisai/enchanted-proxy/internal/storage/pg"
 	"github.com/eternisai/enchanted-proxy/internal/streaming"
+	"github.com/eternisai/enchanted-proxy/internal/streamrecord"
 	"github.com/eternisai/enchanted-proxy/internal/stripe"
+	"github.com/eternisai/enchanted-proxy/internal/synthetic"
 	"github.com/eternisai/enchanted-proxy/internal/task"
 	"github.com/eternisai/enchanted-proxy/internal/telegram"
+	"github.com/eternisai/enchanted-proxy/internal/tinfoil"
 	"github.com/eternisai/enchanted-proxy/internal/title_generation"
 	"github.com/eternisai/enchanted-proxy/internal/tools"
+	"github.com/eternisai/enchanted-proxy/internal/tracing"
+	"github.com/eternisai/enchanted-proxy/internal/upstreams"
+	"github.com/eternisai/enchanted-proxy/internal/voice"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
+	"github.com/eternisai/enchanted-proxy/internal/wspolicy"
 	"github.com/eternisai/enchanted-proxy/internal/zcash"
 	"github.com/gin-gonic/gin"
 	"github.com/go-chi/chi/v5"
-	"github.com/gorilla/websocket"
 	"github.com/nats-io/nats.go"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 )
 
-var allowedBaseURLs = map[string]string{
-	"https://openrouter.ai/api/v1":     os.Getenv("OPENROUTER_API_KEY"),
-	"https://api.openai.com/v1":        os.Getenv("OPENAI_API_KEY"),
-	"https://inference.tinfoil.sh/v1/": os.Getenv("TINFOIL_API_KEY"),
-	"https://cloud-api.near.ai/v1":     os.Getenv("NEAR_API_KEY"),
-	"http://127.0.0.1:20001/v1":        os.Getenv("ETERNIS_INFERENCE_API_KEY"),
-	"http://34.30.193.13:8000/v1":      "", // Self-hosted Venice (GCP) - no auth required
-}
-
 func waHandler(logger *logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := logger.WithContext(c.Request.Context()).WithComponent("wa_handler")
@@ -81,10 +107,13 @@ func waHandler(logger *logger.Logger) gin.HandlerFunc {
 }
 
 func main() {
+	config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
 	config.LoadConfig()
 
-	// Capture instance ID before logger variable shadows the package
+	// Capture instance ID (and FromConfig for config reloads) before logger variable shadows the package
 	instanceID := logger.GetInstanceID()
+	loggerFromConfig := logger.FromConfig
 
 	loggerConfig := logger.FromConfig(config.AppConfig.LogLevel, config.AppConfig.LogFormat)
 	logger := logger.New(loggerConfig)
@@ -100,6 +129,26 @@ func main() {
 	log.Info("setting gin mode", slog.String("mode", config.AppConfig.GinMode))
 	gin.SetMode(config.AppConfig.GinMode)
 
+	// Initialize tracing. Deferred first so spans of the deferred shutdowns are flushed too
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		ServiceName: "enchanted-proxy",
+		Enabled:     config.AppConfig.TracingEnabled,
+		Endpoint:    config.AppConfig.TracingEndpoint,
+		SampleRate:  config.AppConfig.TracingSampleRate,
+	})
+	if err != nil {
+		log.Error("failed to initialize tracing", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warn("failed to flush traces", slog.String("error", err.Error()))
+		}
+	}()
+	log.Info("tracing initialized", slog.Bool("export_enabled", config.AppConfig.TracingEnabled))
+
 	// Initialize database
 	log.Info("initializing database connection")
 	db, err := pg.InitDatabase(config.AppConfig.DatabaseURL)
@@ -115,7 +164,17 @@ func main() {
 		os.Exit(1)
 	}
 
-	firebaseAuth, err := auth.NewFirebaseAuthMiddleware(tokenValidator)
+	// The synthetic prober authenticates as a service account with a static token
+	var authValidator auth.TokenValidator = tokenValidator
+	if config.AppConfig.SyntheticProbesEnabled && config.AppConfig.SyntheticProbeToken != "" {
+		authValidator = auth.NewServiceAccountValidator(tokenValidator, config.AppConfig.SyntheticProbeToken, config.AppConfig.SyntheticProbeUserID)
+	}
+
+	// Internal services and partners authenticate with service API keys (see the admin API)
+	apiKeyValidator := auth.NewAPIKeyValidator(authValidator, db.Queries)
+	authValidator = apiKeyValidator
+
+	firebaseAuth, err := auth.NewFirebaseAuthMiddleware(authValidator)
 	if err != nil {
 		log.Error("failed to initialize firebase auth middleware", slog.String("error", err.Error()))
 		os.Exit(1)
@@ -142,10 +201,46 @@ func main() {
 		log.Warn("firebase credentials not provided - deep research tracking will not work properly")
 	}
 
+	// Initialize the shared cache (Redis when configured, so entries are shared across instances)
+	var cacheBackend cache.Backend = cache.NewMemory(config.AppConfig.CacheMemoryMaxEntries)
+	var redisBackend *cache.Redis
+	if config.AppConfig.RedisURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		redisBackend, err = cache.NewRedis(ctx, config.AppConfig.RedisURL)
+		cancel()
+		if err != nil {
+			redisBackend = nil
+			log.Warn("failed to connect to redis, using in-memory cache", slog.String("error", err.Error()))
+		} else {
+			cacheBackend = redisBackend
+			log.Info("shared cache using redis")
+		}
+	}
+	sharedCache := cache.New(cacheBackend, logger.WithComponent("cache"))
+	defer sharedCache.Close()
+
 	// Initialize services
 	inviteCodeService := invitecode.NewService(db.Queries)
 	requestTrackingService := request_tracking.NewService(db.Queries, logger.WithComponent("request_tracking"))
+	var quotaExperiments *experiments.QuotaService
+	if len(config.AppConfig.QuotaExperiments) > 0 {
+		quotaExperiments, err = experiments.NewQuotaService(db.Queries, config.AppConfig.QuotaExperiments, logger.WithComponent("experiments"))
+		if err != nil {
+			log.Error("failed to initialize quota experiments", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		requestTrackingService.SetQuotaExperiments(quotaExperiments)
+		log.Info("quota experiments enabled", slog.Int("experiments", len(config.AppConfig.QuotaExperiments)))
+	}
 	iapService := iap.NewService(db.Queries)
+	if config.AppConfig.GooglePlayPackageName != "" {
+		verifier, err := iap.NewGooglePlayVerifier(context.Background(), config.AppConfig.GooglePlayPackageName, config.AppConfig.GooglePlayCredJSON)
+		if err != nil {
+			log.Error("failed to initialize Google Play purchase verifier", slog.String("error", err.Error()))
+		} else {
+			iapService.SetGooglePlayVerifier(verifier)
+		}
+	}
 	stripeService := stripe.NewService(db.Queries, logger.WithComponent("stripe"))
 
 	// Initialize zcash service with Firestore client for real-time updates
@@ -191,39 +286,69 @@ func main() {
 	deeprStorage := deepr.NewDBStorage(logger.WithComponent("deepr-storage"), db.DB)
 	deeprSessionManager := deepr.NewSessionManager(logger.WithComponent("deepr-session"))
 
-	// Initialize Firestore client for chat operations
-	var firestoreClient *messaging.FirestoreClient
-	if firebaseClient != nil {
-		firestoreClient = messaging.NewFirestoreClient(firebaseClient.GetFirestoreClient())
+	// Queue deep research sessions beyond the research backend's capacity
+	var deeprWaitlist *deepr.Waitlist
+	if config.AppConfig.DeepResearchBackendCapacity > 0 {
+		deeprWaitlist = deepr.NewWaitlist(config.AppConfig.DeepResearchBackendCapacity, logger.WithComponent("deepr-waitlist"))
+		deeprSessionManager.SetWaitlist(deeprWaitlist)
+		log.Info("deep research backend capacity enabled", slog.Int("capacity", config.AppConfig.DeepResearchBackendCapacity))
+	}
+
+	// Initialize deep research artifact storage (charts, CSVs linked from final reports)
+	var deeprArtifacts *deepr.ArtifactStore
+	if config.AppConfig.DeepResearchArtifactsBucket != "" {
+		deeprArtifacts, err = deepr.NewArtifactStore(context.Background(), config.AppConfig.DeepResearchArtifactsBucket, config.AppConfig.FirebaseCredJSON,
+			os.Getenv("DEEP_RESEARCH_WS"), config.AppConfig.DeepResearchArtifactMaxBytes, config.AppConfig.DeepResearchArtifactURLExpiry)
+		if err != nil {
+			log.Error("failed to initialize deep research artifact store", slog.String("error", err.Error()))
+		} else {
+			log.Info("deep research artifact storage enabled", slog.String("bucket", config.AppConfig.DeepResearchArtifactsBucket))
+		}
+	}
+
+	// Initialize the chat store (Firestore, or the database for self-hosted deployments)
+	var chatStore messaging.Store
+	if config.AppConfig.MessageStorageBackend == "database" {
+		chatStore = messagestore.New(db.Queries)
+		log.Info("database chat store initialized")
+	} else if firebaseClient != nil {
+		chatStore = messaging.NewFirestoreClient(firebaseClient.GetFirestoreClient())
 		log.Info("firestore client initialized for chat operations")
 	}
 
 	// Initialize message storage service
 	var messageService *messaging.Service
-	if config.AppConfig.MessageStorageEnabled && firebaseClient != nil {
-		// Access Firestore client from FirebaseClient
-		messageService = messaging.NewService(firebaseClient.GetFirestoreClient(), logger.WithComponent("messaging"))
+	if config.AppConfig.MessageStorageEnabled && chatStore != nil {
+		messageService = messaging.NewService(chatStore, logger.WithComponent("messaging"))
 		log.Info("message storage service initialized")
 
+		// Mirror message metadata to Postgres. Registered before the message service
+		// shutdown so deferred calls drain storage workers first, then the index queue.
+		if config.AppConfig.MessageIndexEnabled {
+			messageIndexSyncer := messageindex.NewSyncer(db.Queries, config.AppConfig.MessageIndexBufferSize, logger.WithComponent("message-index"))
+			messageService.SetIndexer(messageIndexSyncer)
+			defer messageIndexSyncer.Shutdown()
+		}
+
 		// Ensure cleanup on shutdown
 		defer messageService.Shutdown()
 	} else {
 		if !config.AppConfig.MessageStorageEnabled {
 			log.Info("message storage disabled by configuration")
 		} else {
-			log.Warn("firebase client not available - message storage will not work")
+			log.Warn("no chat store (firebase client not available) - message storage will not work")
 		}
 	}
 
 	// Initialize title generation service
 	var titleService *title_generation.Service
-	if config.AppConfig.MessageStorageEnabled && messageService != nil && firebaseClient != nil {
+	if config.AppConfig.MessageStorageEnabled && messageService != nil {
 		titleGenerator := title_generation.NewGenerator(config.AppConfig.TitleGeneration)
 		titleService = title_generation.NewService(
 			logger.WithComponent("title_generation"),
 			titleGenerator,
 			messageService,
-			messaging.NewFirestoreClient(firebaseClient.GetFirestoreClient()),
+			chatStore,
 		)
 		log.Info("title generation service initialized")
 
@@ -253,9 +378,19 @@ func main() {
 
 	// Initialize tool system
 	toolRegistry := tools.NewRegistry()
-	exaSearchTool := tools.NewExaSearchTool(searchService, logger.WithComponent("exa-search-tool"))
-	if err := toolRegistry.Register(exaSearchTool); err != nil {
-		log.Error("failed to register exa search tool", slog.String("error", err.Error()))
+	// web_search runs on Exa, or on the first configured search engine without an Exa key
+	if searchService.ExaConfigured() || len(searchService.Engines()) > 0 {
+		exaSearchTool := tools.NewExaSearchTool(searchService, logger.WithComponent("exa-search-tool"))
+		if err := toolRegistry.Register(exaSearchTool); err != nil {
+			log.Error("failed to register exa search tool", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	} else {
+		log.Warn("no search engine configured - web_search tool disabled")
+	}
+	fetchURLTool := tools.NewFetchURLTool(searchService, logger.WithComponent("fetch-url-tool"))
+	if err := toolRegistry.Register(fetchURLTool); err != nil {
+		log.Error("failed to register fetch url tool", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 	if taskService != nil {
@@ -285,6 +420,21 @@ func main() {
 	streamManager.SetToolExecutor(toolExecutor)
 	log.Info("tool executor initialized")
 
+	// Content filters of streamed completions per account content policy
+	if config.AppConfig.StreamFilters != nil {
+		streamManager.SetContentFilters(streaming.NewContentFilters(config.AppConfig.StreamFilters))
+	}
+
+	// Share stream sessions across instances so reconnects can land on any of them
+	if config.AppConfig.StreamSessionStore == "redis" {
+		if redisBackend != nil {
+			streamManager.SetSessionStore(streaming.NewRedisSessionStore(redisBackend.Client()), instanceID)
+			log.Info("stream sessions shared through redis")
+		} else {
+			log.Warn("STREAM_SESSION_STORE=redis but redis is not available, stream sessions are local to this instance")
+		}
+	}
+
 	// Ensure cleanup on shutdown
 	defer streamManager.Shutdown()
 
@@ -363,36 +513,274 @@ func main() {
 		}()
 	}
 
+	// Initialize weekly digest worker and handler
+	var digestHandler *digest.Handler
+	if config.AppConfig.WeeklyDigestEnabled {
+		// Without an SMTP relay, digests are delivered in-app and via push only
+		var digestMailer digest.Mailer
+		if config.AppConfig.DigestSMTPHost != "" {
+			if config.AppConfig.DigestEmailFrom == "" {
+				log.Warn("DIGEST_SMTP_HOST is set but DIGEST_EMAIL_FROM is missing; digest emails disabled")
+			} else {
+				digestMailer = digest.NewSMTPMailer(
+					config.AppConfig.DigestSMTPHost, config.AppConfig.DigestSMTPPort,
+					config.AppConfig.DigestSMTPUsername, config.AppConfig.DigestSMTPPassword,
+					config.AppConfig.DigestEmailFrom)
+				log.Info("digest emails enabled", slog.String("smtp_host", config.AppConfig.DigestSMTPHost))
+			}
+		}
+		digestService := digest.NewService(db.Queries, notificationService, digestMailer, logger.WithComponent("digest"))
+		digestHandler = digest.NewHandler(digestService, logger.WithComponent("digest"))
+
+		digestWorkerCtx, digestWorkerCancel := context.WithCancel(context.Background())
+		digestWorker := digest.NewWorker(db.Queries, digestService, logger.WithComponent("digest-worker"))
+		go digestWorker.Run(digestWorkerCtx)
+		log.Info("weekly digest worker started")
+		defer func() {
+			log.Info("stopping weekly digest worker")
+			digestWorkerCancel()
+		}()
+	}
+
+	// Initialize usage rollup worker (feeds /admin/v1/kpis)
+	if config.AppConfig.UsageRollupsEnabled {
+		rollupWorkerCtx, rollupWorkerCancel := context.WithCancel(context.Background())
+		rollupWorker := rollups.NewWorker(db.Queries, logger.WithComponent("rollup-worker"))
+		go rollupWorker.Run(rollupWorkerCtx)
+		log.Info("usage rollup worker started")
+		defer func() {
+			log.Info("stopping usage rollup worker")
+			rollupWorkerCancel()
+		}()
+	}
+
+	// Initialize device attestation (App Attest on iOS, Play Integrity on Android)
+	var appAttestVerifier *attestation.AppAttestVerifier
+	if config.AppConfig.AppAttestTeamID != "" && config.AppConfig.AppAttestBundleID != "" {
+		verifier, err := attestation.NewAppAttestVerifier(config.AppConfig.AppAttestTeamID, config.AppConfig.AppAttestBundleID, config.AppConfig.AppAttestAllowDevelopment)
+		if err != nil {
+			log.Error("failed to initialize App Attest verifier", slog.String("error", err.Error()))
+		} else {
+			appAttestVerifier = verifier
+		}
+	}
+	var playIntegrityVerifier *attestation.PlayIntegrityVerifier
+	if config.AppConfig.PlayIntegrityPackageName != "" {
+		verifier, err := attestation.NewPlayIntegrityVerifier(context.Background(), config.AppConfig.PlayIntegrityPackageName, config.AppConfig.PlayIntegrityCredJSON)
+		if err != nil {
+			log.Error("failed to initialize Play Integrity verifier", slog.String("error", err.Error()))
+		} else {
+			playIntegrityVerifier = verifier
+		}
+	}
+	attestationService := attestation.NewService(db.Queries, appAttestVerifier, playIntegrityVerifier, logger.WithComponent("attestation"))
+	attestationHandler := attestation.NewHandler(attestationService, logger.WithComponent("attestation"))
+	log.Info("device attestation configured",
+		slog.Bool("required", config.AppConfig.DeviceAttestationRequired),
+		slog.Bool("app_attest", appAttestVerifier != nil),
+		slog.Bool("play_integrity", playIntegrityVerifier != nil))
+
+	// Initialize country-based compliance gating
+	var complianceService *compliance.Service
+	if config.AppConfig.Compliance != nil {
+		var geo *compliance.GeoIP
+		if config.AppConfig.GeoIPDBPath != "" {
+			geo, err = compliance.LoadGeoIP(config.AppConfig.GeoIPDBPath)
+			if err != nil {
+				log.Error("failed to load GeoIP database", slog.String("error", err.Error()))
+				os.Exit(1)
+			}
+			log.Info("GeoIP database loaded", slog.Int("ranges", geo.Len()))
+		}
+		complianceService = compliance.NewService(config.AppConfig.Compliance, geo, db.Queries, logger.WithComponent("compliance"))
+		log.Info("compliance gating enabled",
+			slog.String("country_header", config.AppConfig.Compliance.CountryHeader),
+			slog.Int("blocked_countries", len(config.AppConfig.Compliance.BlockedCountries)),
+			slog.Int("rules", len(config.AppConfig.Compliance.Rules)))
+	}
+
 	// Initialize model router for automatic provider routing
 	modelRouter := routing.NewModelRouter(config.AppConfig, logger.WithComponent("routing"))
 
+	// Restrict routing to the allowed upstream base URLs administered via the admin API
+	upstreamService := upstreams.NewService(
+		db.Queries,
+		sharedCache,
+		modelRouter,
+		time.Duration(config.AppConfig.UpstreamRefreshIntervalSeconds)*time.Second,
+		logger.WithComponent("upstreams"),
+	)
+	upstreamService.Start(context.Background())
+	defer upstreamService.Stop()
+
+	// Apply the provider and model routing entries administered via the admin API
+	routingEntryService := routingconfig.NewService(
+		db.Queries,
+		sharedCache,
+		modelRouter,
+		config.AppConfig.ModelRouterConfig,
+		time.Duration(config.AppConfig.RoutingEntriesRefreshIntervalSeconds)*time.Second,
+		logger.WithComponent("routingconfig"),
+	)
+	routingEntryService.Start(context.Background())
+	defer routingEntryService.Stop()
+
+	// Pick up provider API keys rotated in the secrets manager: the routes are rebuilt with the
+	// keys of the environment (keys copied by other services at startup need a restart)
+	if config.AppConfig.SecretsProvider != "" && config.AppConfig.SecretsRefreshInterval > 0 {
+		secretsCtx, secretsCancel := context.WithCancel(context.Background())
+		go worker.RunPeriodic(secretsCtx, "secrets_refresh", config.AppConfig.SecretsRefreshInterval, log, func(ctx context.Context) error {
+			rotated, err := config.RefreshSecrets(ctx)
+			if err != nil || len(rotated) == 0 {
+				return err
+			}
+			log.Info("secrets rotated, rebuilding routes", slog.Any("keys", rotated))
+			routerConfig, err := config.LoadModelRouterConfig(config.AppConfig.ConfigFilePath)
+			if err != nil {
+				return err
+			}
+			return routingEntryService.SetBase(ctx, routerConfig)
+		})
+		defer secretsCancel()
+	}
+
+	// Route to enclave providers (Tinfoil) only while their attestation verifies
+	var enclaveAttestation *tinfoil.Service
+	if svc := tinfoil.NewService(config.AppConfig.ModelRouterConfig, modelRouter, config.AppConfig.AttestationCheckInterval, logger.WithComponent("tinfoil-attestation")); svc.Enabled() {
+		enclaveAttestation = svc
+		enclaveAttestation.Start(context.Background())
+		defer enclaveAttestation.Stop()
+		proxy.SetConnectionVerifier(enclaveAttestation.VerifyConnection)
+	}
+
+	// Probe the latency of regional provider endpoints for per-request region selection
+	regionProbeCtx, regionProbeCancel := context.WithCancel(context.Background())
+	go worker.RunPeriodic(regionProbeCtx, "region_probe", routing.RegionProbeInterval, log, modelRouter.ProbeRegions)
+	defer regionProbeCancel()
+
+	// Synthetic monitoring: canary requests through the full pipeline as a service account
+	if config.AppConfig.SyntheticProbesEnabled {
+		if config.AppConfig.SyntheticProbeToken == "" {
+			log.Error("synthetic probes enabled without SYNTHETIC_PROBE_TOKEN, not started")
+		} else {
+			prober := synthetic.NewProber(synthetic.Config{
+				BaseURL:         "http://127.0.0.1:" + config.AppConfig.Port,
+				Token:           config.AppConfig.SyntheticProbeToken,
+				ChatModel:       config.AppConfig.SyntheticProbeChatModel,
+				EmbeddingModel:  config.AppConfig.SyntheticProbeEmbeddingModel,
+				SlackWebhookURL: config.AppConfig.SyntheticProbeSlackWebhookURL,
+			}, logger.WithComponent("synthetic"))
+			syntheticCtx, syntheticCancel := context.WithCancel(context.Background())
+			go worker.RunPeriodic(syntheticCtx, "synthetic_probe", config.AppConfig.SyntheticProbeInterval, log, prober.Run)
+			defer syntheticCancel()
+			log.Info("synthetic probes enabled",
+				slog.String("user_id", config.AppConfig.SyntheticProbeUserID),
+				slog.Duration("interval", config.AppConfig.SyntheticProbeInterval))
+		}
+	}
+
+	// Deep research auto-clarification (answers clarification questions from the chat context)
+	var deeprClarifier *deepr.Clarifier
+	if config.AppConfig.DeepResearchAutoClarifyEnabled {
+		deeprClarifier = deepr.NewClarifier(modelRouter, config.AppConfig.DeepResearchAutoClarifyModel)
+		log.Info("deep research auto-clarification enabled", slog.String("model", config.AppConfig.DeepResearchAutoClarifyModel))
+	}
+
+	// Initialize user preferences (default model, temperature, system prompt)
+	preferencesService := preferences.NewService(db.Queries, modelRouter, sharedCache)
+
+	// Bans are set via the admin API and enforced on every authenticated route
+	banService := bans.NewService(db.Queries, sharedCache, logger.WithComponent("bans"))
+	preferencesHandler := preferences.NewHandler(preferencesService, logger.WithComponent("preferences"))
+
+	// Initialize the opt-in chat search index (client-uploaded keyword tokens)
+	chatSearchHandler := chatsearch.NewHandler(chatsearch.NewService(db.Queries), logger.WithComponent("chat-search"))
+
+	// Initialize message ratings and the model quality scoreboard (optionally fed into routing)
+	qualityService := quality.NewService(db.Queries, modelRouter.ResolveAlias)
+	qualityHandler := quality.NewHandler(qualityService, logger.WithComponent("quality"))
+	if config.AppConfig.QualityRoutingEnabled {
+		qualityRoutingCtx, qualityRoutingCancel := context.WithCancel(context.Background())
+		qualityWorker := quality.NewRoutingWorker(qualityService, modelRouter, config.AppConfig.QualityRoutingMargin, logger.WithComponent("quality-routing"))
+		go qualityWorker.Run(qualityRoutingCtx)
+		log.Info("quality routing worker started")
+		defer func() {
+			log.Info("stopping quality routing worker")
+			qualityRoutingCancel()
+		}()
+	}
+
+	// Content policy moderation (family mode) uses the OpenAI moderation API.
+	// Without an OpenAI key, family mode relies on safety instructions alone.
+	var contentModerator *contentpolicy.Moderator
+	if config.AppConfig.OpenAIAPIKey != "" {
+		contentModerator = contentpolicy.NewModerator(config.AppConfig.OpenAIAPIKey, "")
+	} else {
+		log.Warn("OPENAI_API_KEY not set; family mode content moderation disabled")
+	}
+
+	// Pre-flight moderation of chat completion inputs by tier and model (config.yaml moderation)
+	var moderationPreflight *contentpolicy.Preflight
+	if config.AppConfig.Moderation != nil && len(config.AppConfig.Moderation.Preflight) > 0 {
+		if contentModerator != nil {
+			moderationPreflight = contentpolicy.NewPreflight(contentModerator, config.AppConfig.Moderation, modelRouter)
+			log.Info("pre-flight moderation enabled", slog.Int("rules", len(config.AppConfig.Moderation.Preflight)))
+		} else {
+			log.Warn("OPENAI_API_KEY not set; pre-flight moderation disabled")
+		}
+	}
+
+	// Batch API pass-through (/files, /batches); usage is recorded when results land
+	var batchHandler *batches.Handler
+	if config.AppConfig.BatchAPIEnabled {
+		if config.AppConfig.OpenAIAPIKey != "" {
+			batchService := batches.NewService(db.Queries, batches.NewClient(config.AppConfig.OpenAIAPIKey, ""), requestTrackingService, modelRouter, logger.WithComponent("batches"))
+			batchHandler = batches.NewHandler(batchService, modelRouter, logger.WithComponent("batches"))
+			batchSyncCtx, batchSyncCancel := context.WithCancel(context.Background())
+			go worker.RunPeriodic(batchSyncCtx, "batch_sync", config.AppConfig.BatchSyncInterval, log, batchService.Sync)
+			defer batchSyncCancel()
+			log.Info("batch API enabled", slog.Duration("sync_interval", config.AppConfig.BatchSyncInterval))
+		} else {
+			log.Warn("OPENAI_API_KEY not set; batch API disabled")
+		}
+	}
+
+	// Initialize voice conversations (transcribe -> chat -> speak)
+	var voiceHandler *voice.Handler
+	if config.AppConfig.OpenAIAPIKey != "" {
+		audioClient := voice.NewAudioClient("", config.AppConfig.OpenAIAPIKey, config.AppConfig.VoiceTranscriptionModel, config.AppConfig.VoiceSpeechModel)
+		voiceService := voice.NewService(audioClient, modelRouter, preferencesService, contentModerator, requestTrackingService, config.AppConfig.VoiceSpeechVoice, config.AppConfig.AudioPlanTokensPerMinute, logger.WithComponent("voice"))
+		voiceHandler = voice.NewHandler(voiceService, logger.WithComponent("voice"))
+	}
+
 	// Initialize model routing fallback service
 	fallbackService := fallback.NewFallbackService(config.AppConfig, logger.WithComponent("fallback"), modelRouter)
 
+	// Origin and subprotocol policy of all websocket endpoints
+	wsPolicy := wspolicy.New(strings.Split(config.AppConfig.WebsocketAllowedOrigins, ","), config.AppConfig.WebsocketRequireSubprotocol)
+
 	// Initialize key sharing service
 	var keyshareHandler *keyshare.Handler
 	if firebaseClient != nil {
 		keyshareWSManager := keyshare.NewWebSocketManager(logger.WithComponent("keyshare-ws"))
 		keyshareFirestore := keyshare.NewFirestoreClient(firebaseClient.GetFirestoreClient())
 		keyshareService := keyshare.NewService(keyshareFirestore, keyshareWSManager, logger.WithComponent("keyshare"))
-		keyshareHandler = keyshare.NewHandler(keyshareService, keyshareWSManager, logger.WithComponent("keyshare"))
+		keyshareHandler = keyshare.NewHandler(keyshareService, keyshareWSManager, wsPolicy, logger.WithComponent("keyshare"))
 		log.Info("key sharing service initialized")
 
 		// Start cleanup job for expired sessions
-		go func() {
-			cleanupTicker := time.NewTicker(5 * time.Minute)
-			defer cleanupTicker.Stop()
-
-			for range cleanupTicker.C {
-				ctx := context.Background()
-				deleted, err := keyshareService.CleanupExpiredSessions(ctx)
-				if err != nil {
-					log.Error("key share cleanup job failed", slog.String("error", err.Error()))
-				} else if deleted > 0 {
-					log.Info("key share cleanup job completed", slog.Int("deleted", deleted))
-				}
+		keyshareCleanupCtx, keyshareCleanupCancel := context.WithCancel(context.Background())
+		go worker.RunPeriodic(keyshareCleanupCtx, "keyshare_cleanup", 5*time.Minute, log, func(ctx context.Context) error {
+			deleted, err := keyshareService.CleanupExpiredSessions(ctx)
+			if err != nil {
+				return err
 			}
-		}()
+			if deleted > 0 {
+				log.Info("key share cleanup job completed", slog.Int("deleted", deleted))
+			}
+			return nil
+		})
+		defer keyshareCleanupCancel()
 	} else {
 		log.Info("key sharing service disabled (requires firebase client)")
 	}
@@ -456,6 +844,28 @@ func main() {
 		}
 	}
 
+	// Initialize revocation service to stop streams and deep research runs of revoked users.
+	// Token revocation can only be checked with Firebase token validation (or for the users of
+	// a Firebase issuer with AUTH_ISSUERS).
+	var revocationChecker revocation.Checker
+	if checker, ok := tokenValidator.(revocation.Checker); ok {
+		revocationChecker = checker
+	}
+	revocationService := revocation.NewService(
+		natsClient,
+		revocationChecker,
+		time.Duration(config.AppConfig.RevocationCheckIntervalSeconds)*time.Second,
+		logger.WithComponent("revocation"),
+		streamManager,
+		deeprSessionManager,
+	)
+	if err := revocationService.Start(); err != nil {
+		log.Error("failed to start revocation service", slog.String("error", err.Error()))
+	} else {
+		stripeService.SetRevoker(revocationService)
+		defer revocationService.Stop()
+	}
+
 	// Initialize Telegram service if token is provided
 	var telegramService *telegram.Service
 	if config.AppConfig.EnableTelegramServer {
@@ -500,12 +910,82 @@ func main() {
 		log.Warn("anonymizer service disabled (no API key)")
 	}
 
+	// Initialize stream recording (debug capture for internal test accounts)
+	var streamRecorder *streamrecord.Recorder
+	if config.AppConfig.StreamRecordingUserIDs != "" && config.AppConfig.StreamRecordingLocation != "" {
+		store, err := streamrecord.NewStore(context.Background(), config.AppConfig.StreamRecordingLocation, config.AppConfig.FirebaseCredJSON)
+		if err != nil {
+			log.Error("failed to initialize stream recording store", slog.String("error", err.Error()))
+		} else {
+			streamRecorder = streamrecord.NewRecorder(store, config.AppConfig.StreamRecordingUserIDs, logger.WithComponent("stream-recorder"))
+			log.Info("stream recording enabled", slog.String("location", config.AppConfig.StreamRecordingLocation))
+		}
+	}
+
+	// Initialize rate limit queue (chat completions wait briefly for rate-limited providers)
+	var rateQueue *ratequeue.Queue
+	if config.AppConfig.RateLimitQueueEnabled {
+		rateQueue = ratequeue.New(time.Duration(config.AppConfig.RateLimitQueueMaxWaitSeconds)*time.Second, config.AppConfig.RateLimitQueueMaxSize)
+		log.Info("rate limit queue enabled",
+			slog.Int("max_wait_seconds", config.AppConfig.RateLimitQueueMaxWaitSeconds),
+			slog.Int("max_size", config.AppConfig.RateLimitQueueMaxSize))
+	}
+
+	// Initialize circuit breakers (failing providers get no requests until they recover)
+	var breakers *resilience.Breakers
+	if config.AppConfig.CircuitBreakerEnabled {
+		breakers = resilience.New(resilience.Options{
+			Window:        time.Duration(config.AppConfig.CircuitBreakerWindowSeconds) * time.Second,
+			MinRequests:   config.AppConfig.CircuitBreakerMinRequests,
+			FailureRate:   float64(config.AppConfig.CircuitBreakerFailureRate) / 100,
+			SlowThreshold: time.Duration(config.AppConfig.CircuitBreakerSlowSeconds) * time.Second,
+			OpenDuration:  time.Duration(config.AppConfig.CircuitBreakerOpenSeconds) * time.Second,
+		})
+		log.Info("circuit breakers enabled",
+			slog.Int("failure_rate_percent", config.AppConfig.CircuitBreakerFailureRate),
+			slog.Int("min_requests", config.AppConfig.CircuitBreakerMinRequests),
+			slog.Int("window_seconds", config.AppConfig.CircuitBreakerWindowSeconds),
+			slog.Int("open_seconds", config.AppConfig.CircuitBreakerOpenSeconds))
+	}
+
+	// Keep chat completion requests so failed generations can be retried without re-uploading
+	var replays *proxy.ReplayStore
+	if config.AppConfig.MessageRetryTTLSeconds > 0 {
+		replays = proxy.NewReplayStore(sharedCache, time.Duration(config.AppConfig.MessageRetryTTLSeconds)*time.Second)
+	}
+
+	// Initialize admin API (used by cmd/adminctl)
+	var adminHandler *admin.Handler
+	if config.AppConfig.AdminAPIKey != "" {
+		adminHandler = admin.NewHandler(db.Queries, requestTrackingService, messageService, streamManager, modelRouter, streamRecorder, upstreamService, routingEntryService, banService, enclaveAttestation, config.AppConfig.ConfigFilePath, logger.WithComponent("admin"))
+		adminHandler.SetRevoker(revocationService)
+	} else {
+		log.Info("admin API disabled (no ADMIN_API_KEY)")
+	}
+
+	// Deprecation headers of /api/v1 responses (v2 is the successor)
+	apiV1Deprecation, err := apiversion.ParseDeprecation(config.AppConfig.APIV1DeprecatedAt, config.AppConfig.APIV1SunsetDate)
+	if err != nil {
+		log.Error("invalid API v1 deprecation config", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// Minimum app versions by route (no-op without a client_versions config)
+	clientVersionPolicy, err := clientversion.NewPolicy(config.AppConfig.ClientVersions)
+	if err != nil {
+		log.Error("invalid client versions config", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
 	// Initialize REST API router (original proxy functionality)
 	router := setupRESTServer(restServerInput{
+		apiV1Deprecation:       apiV1Deprecation,
+		clientVersionPolicy:    clientVersionPolicy,
 		logger:                 logger,
 		firebaseAuth:           firebaseAuth,
+		apiKeyValidator:        apiKeyValidator,
 		firebaseClient:         firebaseClient,
-		firestoreClient:        firestoreClient,
+		chatStore:              chatStore,
 		requestTrackingService: requestTrackingService,
 		messageService:         messageService,
 		titleService:           titleService,
@@ -515,6 +995,11 @@ func main() {
 		modelRouter:            modelRouter,
 		toolRegistry:           toolRegistry,
 		anonymizerService:      anonymizerSvc,
+		streamRecorder:         streamRecorder,
+		rateQueue:              rateQueue,
+		breakers:               breakers,
+		replays:                replays,
+		banService:             banService,
 		inviteCodeHandler:      inviteCodeHandler,
 		iapHandler:             iapHandler,
 		stripeHandler:          stripeHandler,
@@ -524,10 +1009,26 @@ func main() {
 		mcpHandler:             mcpHandler,
 		searchHandler:          searchHandler,
 		taskHandler:            taskHandler,
+		digestHandler:          digestHandler,
+		preferencesService:     preferencesService,
+		preferencesHandler:     preferencesHandler,
+		chatSearchHandler:      chatSearchHandler,
+		qualityHandler:         qualityHandler,
+		contentModerator:       contentModerator,
+		moderationPreflight:    moderationPreflight,
+		batchHandler:           batchHandler,
+		voiceHandler:           voiceHandler,
+		attestationService:     attestationService,
+		attestationHandler:     attestationHandler,
+		complianceService:      complianceService,
+		adminHandler:           adminHandler,
 		problemReportsHandler:  problemReportsHandler,
 		keyshareHandler:        keyshareHandler,
 		deeprStorage:           deeprStorage,
 		deeprSessionManager:    deeprSessionManager,
+		deeprArtifacts:         deeprArtifacts,
+		deeprClarifier:         deeprClarifier,
+		wsPolicy:               wsPolicy,
 		queries:                db,
 		config:                 config.AppConfig,
 	})
@@ -540,6 +1041,7 @@ func main() {
 			natsClient:      natsClient,
 			telegramService: telegramService,
 			firebaseAuth:    firebaseAuth,
+			wsPolicy:        wsPolicy,
 		})
 
 		graphqlServer = &http.Server{
@@ -549,6 +1051,35 @@ func main() {
 
 	}
 
+	// Config hot-reload (SIGHUP or POST /admin/v1/config/reload): the other reloaded settings
+	// are read through config.Current
+	config.OnReload(func(cfg *config.Config) {
+		logger.SetLevel(loggerFromConfig(cfg.LogLevel, cfg.LogFormat).Level)
+		requestTrackingService.SetWorkers(cfg.RequestTrackingWorkerPoolSize)
+		if messageService != nil {
+			messageService.SetWorkers(cfg.MessageStorageWorkerPoolSize)
+		}
+	})
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			changes, err := config.Reload()
+			if err != nil {
+				log.Error("config reload failed", slog.String("error", err.Error()))
+				continue
+			}
+			for _, change := range changes {
+				log.Info("config setting reloaded",
+					slog.String("key", change.Key),
+					slog.String("previous", change.Previous),
+					slog.String("value", change.Value),
+					slog.String("source", string(change.Source)))
+			}
+			log.Info("config reloaded", slog.Int("changes", len(changes)))
+		}
+	}()
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -570,6 +1101,20 @@ func main() {
 	// Start status server (Prometheus metrics and health check endpoints)
 	statusAddr := net.JoinHostPort(config.AppConfig.StatusBindAddr, config.AppConfig.StatusBindPort)
 	statusMux := http.NewServeMux()
+	metrics.RegisterRequestLogDrops(requestTrackingService.DroppedRequests)
+	metrics.RegisterDeepResearchSessions(deeprSessionManager.SessionCount)
+	if deeprWaitlist != nil {
+		metrics.RegisterDeepResearchQueue(deeprWaitlist.QueueLength)
+	}
+	metrics.RegisterStreamStats(func() metrics.StreamStats {
+		stats := streamManager.GetMetrics()
+		return metrics.StreamStats{
+			Active:      stats.ActiveStreams,
+			Completed:   stats.CompletedStreams,
+			Subscribers: stats.TotalSubscribers,
+			MemoryBytes: stats.MemoryUsageBytes,
+		}
+	})
 	statusMux.Handle("/metrics", promhttp.Handler())
 	statusMux.HandleFunc("/healthz/live", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -597,7 +1142,6 @@ func main() {
 
 	go func() {
 		log.Info("proxy listening", slog.String("port", restPort))
-		log.Info("allowed base urls configured", slog.Any("paths", getKeys(allowedBaseURLs)))
 
 		// Log rate limiting configuration
 		if config.AppConfig.RateLimitEnabled {
@@ -638,6 +1182,14 @@ func main() {
 	rtCancel()
 	log.Info("request tracking service shutdown complete")
 
+	if quotaExperiments != nil {
+		qeCtx, qeCancel := context.WithTimeout(context.Background(), time.Duration(config.AppConfig.ServerShutdownTimeoutSeconds)*time.Second)
+		if err := quotaExperiments.Shutdown(qeCtx); err != nil {
+			log.Warn("quota experiment exposure writer shutdown timed out", slog.String("error", err.Error()))
+		}
+		qeCancel()
+	}
+
 	// Shutdown the task service (close Temporal client)
 	if taskService != nil {
 		taskService.Close()
@@ -663,20 +1215,21 @@ func main() {
 	log.Info("servers exited")
 }
 
-// Helper function to get keys from map for logging.
-func getKeys(m map[string]string) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
+// apiKeyScopeRoutes are the routes service API keys may call, by scope (the admin scope is
+// checked by the admin API middleware).
+var apiKeyScopeRoutes = auth.ScopeRoutes{
+	auth.ScopeProxy:  {"/chat/completions", "/responses", "/embeddings", "/audio", "/realtime", "/moderations", "/files", "/batches", "/models"},
+	auth.ScopeSearch: {"/api/v1/search", "/api/v1/exa/search", "/api/v2/search", "/api/v2/exa/search"},
 }
 
 type restServerInput struct {
 	logger                 *logger.Logger
+	apiV1Deprecation       apiversion.Deprecation
+	clientVersionPolicy    *clientversion.Policy
 	firebaseAuth           *auth.FirebaseAuthMiddleware
+	apiKeyValidator        *auth.APIKeyValidator
 	firebaseClient         *auth.FirebaseClient
-	firestoreClient        *messaging.FirestoreClient
+	chatStore              messaging.Store
 	requestTrackingService *request_tracking.Service
 	messageService         *messaging.Service
 	titleService           *title_generation.Service
@@ -695,10 +1248,31 @@ type restServerInput struct {
 	mcpHandler             *mcp.Handler
 	searchHandler          *search.Handler
 	taskHandler            *task.Handler
+	digestHandler          *digest.Handler
+	preferencesService     *preferences.Service
+	preferencesHandler     *preferences.Handler
+	chatSearchHandler      *chatsearch.Handler
+	qualityHandler         *quality.Handler
+	contentModerator       *contentpolicy.Moderator
+	moderationPreflight    *contentpolicy.Preflight // nil = no pre-flight moderation
+	batchHandler           *batches.Handler         // nil = batch API disabled
+	voiceHandler           *voice.Handler
+	attestationService     *attestation.Service
+	attestationHandler     *attestation.Handler
+	complianceService      *compliance.Service
+	streamRecorder         *streamrecord.Recorder
+	rateQueue              *ratequeue.Queue
+	breakers               *resilience.Breakers
+	replays                *proxy.ReplayStore
+	banService             *bans.Service
+	adminHandler           *admin.Handler
 	problemReportsHandler  *problem_reports.Handler
 	keyshareHandler        *keyshare.Handler
 	deeprStorage           deepr.MessageStorage
 	deeprSessionManager    *deepr.SessionManager
+	deeprArtifacts         *deepr.ArtifactStore // nil = report artifacts are not stored
+	deeprClarifier         *deepr.Clarifier     // nil = clarifications are never answered from chat context
+	wsPolicy               *wspolicy.Policy
 	queries                *pg.Database
 	config                 *config.Config
 }
@@ -706,6 +1280,7 @@ type restServerInput struct {
 func setupRESTServer(input restServerInput) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Recovery())
+	router.Use(tracing.Middleware())
 
 	// Add request logging middleware.
 	router.Use(logger.RequestLoggingMiddleware(input.logger))
@@ -714,8 +1289,8 @@ func setupRESTServer(input restServerInput) *gin.Engine {
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Client-Platform, X-Chat-ID, X-Message-ID, X-User-Message-ID, X-Encryption-Enabled, X-Anonymize")
-		c.Header("Access-Control-Expose-Headers", "X-Anonymizer-Replacements")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Client-Platform, X-Chat-ID, X-Message-ID, X-User-Message-ID, X-Encryption-Enabled, X-Anonymize, X-Reasoning-Visibility, X-Client-Capabilities, X-Attestation-Platform, X-Attestation-Challenge, X-Attestation-Key-ID, X-Attestation-Token, X-Debug-Trace, X-Privacy-Strict, X-Ephemeral, X-API-Version, X-Client-Version")
+		c.Header("Access-Control-Expose-Headers", "X-Anonymizer-Replacements, X-Debug-Trace-Token, X-Privacy-Mode, X-Ephemeral-Mode, X-Structured-Output, X-API-Version, Deprecation, Sunset, Link")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -725,12 +1300,74 @@ func setupRESTServer(input restServerInput) *gin.Engine {
 		c.Next()
 	})
 
+	// Parse X-Client-Capabilities once per request (read via capabilities.FromGin)
+	router.Use(capabilities.Middleware())
+
+	// Pick the API version (/api/vN path or X-API-Version header; read via apiversion.FromGin).
+	// Runs after capabilities, which it extends with the capabilities the version implies.
+	router.Use(apiversion.Negotiate(input.apiV1Deprecation))
+
+	// Reject app versions below the route's minimum (X-Client-Platform + X-Client-Version).
+	// Runs after apiversion so the upgrade-required error uses the version's error format.
+	router.Use(clientversion.Middleware(input.clientVersionPolicy))
+
 	// Debug/test endpoint (no auth required)
 	router.POST("/wa", waHandler(input.logger))
 
 	// Stripe webhook endpoint (no auth, signature verified)
 	router.POST("/stripe/webhook", input.stripeHandler.HandleWebhook)
 
+	// OpenAI webhook endpoint (no auth, signature verified) - wakes background polling workers
+	if input.pollingManager != nil && input.config.OpenAIWebhookSecret != "" {
+		openAIWebhookHandler := background.NewWebhookHandler(input.pollingManager, input.config.OpenAIWebhookSecret, input.logger)
+		router.POST("/webhooks/openai", openAIWebhookHandler.HandleOpenAIWebhook)
+	} else {
+		input.logger.Info("OpenAI webhook endpoint disabled (requires background polling and OPENAI_WEBHOOK_SECRET)")
+	}
+
+	// Public status feed for the status page and client degradation logic (no auth, rate limited per IP)
+	statusPageHandler := statuspage.NewHandler(input.modelRouter, input.config.StatusPageRateLimitPerMinute)
+	router.GET("/status.json", statusPageHandler.GetStatus)
+
+	// Admin API endpoints for cmd/adminctl (protected by ADMIN_API_KEY or service API keys with
+	// the admin scope, disabled when ADMIN_API_KEY is unset)
+	if input.adminHandler != nil {
+		adminAPIKey := auth.NewAPIKeyMiddleware(input.config.AdminAPIKey).WithServiceKeys(input.apiKeyValidator, auth.ScopeAdmin)
+		admin := router.Group("/admin")
+		admin.Use(adminAPIKey.RequireAPIKey())
+		{
+			admin.GET("/users/:userId", input.adminHandler.GetUser)                                // GET /admin/users/:userId - Tier in effect, entitlement and ban of a user
+			admin.POST("/users/:userId/entitlement", input.adminHandler.GrantEntitlement)          // POST /admin/users/:userId/entitlement - Grant or extend a tier, or set its expiry
+			admin.POST("/users/:userId/deep-research/reset", input.adminHandler.ResetDeepResearch) // POST /admin/users/:userId/deep-research/reset - Stop counting completed runs against limits
+			admin.PUT("/users/:userId/ban", input.adminHandler.BanUser)                            // PUT /admin/users/:userId/ban - Ban a user from the API
+			admin.DELETE("/users/:userId/ban", input.adminHandler.UnbanUser)                       // DELETE /admin/users/:userId/ban - Lift a user's ban
+			admin.GET("/users/:userId/quota", input.adminHandler.GetUserQuota)                     // GET /admin/users/:userId/quota - Rate limit status for a user
+			admin.GET("/streams", input.adminHandler.ListStreams)                                  // GET /admin/streams - Active streams on this instance
+			admin.POST("/streams/:chatId/:messageId/stop", input.adminHandler.StopStream)          // POST /admin/streams/:chatId/:messageId/stop - Stop a stream on any instance
+			admin.POST("/queues/flush", input.adminHandler.FlushQueues)                            // POST /admin/queues/flush - Drain async write queues on this instance
+			admin.POST("/routing/reload", input.adminHandler.ReloadRouting)                        // POST /admin/routing/reload - Reload model_router from the config file
+			admin.GET("/routing/entries", input.adminHandler.ListRoutingEntries)                   // GET /admin/routing/entries - Provider and model routing entries applied on top of config.yaml
+			admin.PUT("/routing/providers/*name", input.adminHandler.PutRoutingProvider)           // PUT /admin/routing/providers/:name - Add, replace or disable a provider
+			admin.DELETE("/routing/providers/*name", input.adminHandler.DeleteRoutingProvider)     // DELETE /admin/routing/providers/:name - Remove a provider entry
+			admin.PUT("/routing/models/*name", input.adminHandler.PutRoutingModel)                 // PUT /admin/routing/models/:name - Add, replace or disable a model
+			admin.DELETE("/routing/models/*name", input.adminHandler.DeleteRoutingModel)           // DELETE /admin/routing/models/:name - Remove a model entry
+			admin.GET("/providers/status", input.adminHandler.ProviderStatus)                      // GET /admin/providers/status - Endpoint state and streaming latency p50/p95
+			admin.GET("/recordings/:chatId/:messageId", input.adminHandler.GetRecording)           // GET /admin/recordings/:chatId/:messageId - Debug recording of a stream
+			admin.GET("/v1/kpis", input.adminHandler.GetKPIs)                                      // GET /admin/v1/kpis - Daily usage KPIs from the nightly rollups (versioned for dashboards)
+			admin.GET("/v1/invites/analytics", input.adminHandler.GetInviteAnalytics)              // GET /admin/v1/invites/analytics - Invite redemption → activation → retention funnel by code or prefix
+			admin.GET("/v1/models/quality", input.qualityHandler.GetScoreboard)                    // GET /admin/v1/models/quality?window=7d - Per-model/provider quality scores and trends from anomalies and ratings
+			admin.GET("/v1/config", input.adminHandler.GetConfig)                                  // GET /admin/v1/config - Effective settings and their source layer (secrets redacted)
+			admin.POST("/v1/config/reload", input.adminHandler.ReloadConfig)                       // POST /admin/v1/config/reload - Re-read settings, apply the hot-reloadable ones
+			admin.GET("/upstreams", input.adminHandler.ListUpstreams)                              // GET /admin/upstreams - Allowed upstream base URLs
+			admin.POST("/upstreams", input.adminHandler.CreateUpstream)                            // POST /admin/upstreams - Allow an upstream base URL
+			admin.PATCH("/upstreams/:id", input.adminHandler.UpdateUpstream)                       // PATCH /admin/upstreams/:id - Change an upstream's key reference or enabled flag
+			admin.DELETE("/upstreams/:id", input.adminHandler.DeleteUpstream)                      // DELETE /admin/upstreams/:id - Remove an upstream
+			admin.GET("/api-keys", input.adminHandler.ListAPIKeys)                                 // GET /admin/api-keys - Service API keys (without their secrets)
+			admin.POST("/api-keys", input.adminHandler.CreateAPIKey)                               // POST /admin/api-keys - Create a service API key (returned once)
+			admin.DELETE("/api-keys/:id", input.adminHandler.RevokeAPIKey)                         // DELETE /admin/api-keys/:id - Revoke a service API key
+		}
+	}
+
 	// Internal API endpoints (protected by static API key)
 	internalAPIKey := auth.NewAPIKeyMiddleware(input.config.InternalAPIKey)
 	internal := router.Group("/internal")
@@ -742,118 +1379,260 @@ func setupRESTServer(input restServerInput) *gin.Engine {
 	// All routes use Firebase/JWT auth
 	router.Use(input.firebaseAuth.RequireAuth())
 
+	// Service API keys only reach the routes of their scopes
+	router.Use(auth.RestrictAPIKeys(apiKeyScopeRoutes))
+
+	// Reject users banned via the admin API
+	router.Use(bans.Middleware(input.banService))
+
+	// Elevated per-request logging for allowlisted internal users (X-Debug-Trace)
+	router.Use(debugtrace.Middleware(input.config, input.logger))
+
+	// Block comprehensively sanctioned countries (no-op without a compliance config)
+	router.Use(compliance.RequireAllowedCountry(input.complianceService))
+
 	router.Any("/mcp", input.mcpHandler.HandleMCPAny)
 
-	// Invite code API routes (protected)
-	api := router.Group("/api/v1")
+	// Device attestation for promotional flows (no-op unless DEVICE_ATTESTATION_REQUIRED=true)
+	requireAttestation := attestation.RequireAttestation(input.attestationService, input.config.DeviceAttestationRequired, input.logger)
+
+	// Client API routes (protected). v2 shares the v1 handlers; apiversion.Negotiate selects
+	// the error envelope and streaming capabilities of each version.
+	for _, version := range []apiversion.Version{apiversion.V1, apiversion.V2} {
+		registerAPIRoutes(router.Group("/api/"+version.String()), input, requireAttestation)
+	}
+
+	// Model list with limits and capabilities (protected, not rate limited)
+	router.GET("/models", proxy.ModelsHandler(input.modelRouter)) // GET /models
+
+	// Protected proxy routes
+	proxyGroup := router.Group("/")
+	proxyGroup.Use(
+		// Sandbox requests go to the dev provider and skip quota checks (no-op for other requests)
+		sandbox.Middleware(input.config),
+		// Preferences run before request tracking so tier model access checks see the user's
+		// default model, and content policy blocks happen before the request is counted
+		preferences.Middleware(input.preferencesService, input.contentModerator, input.logger),
+		request_tracking.RequestTrackingMiddleware(input.requestTrackingService, input.logger, input.modelRouter),
+		// Pre-flight moderation and privacy mode run after request tracking, which loads the tier
+		contentpolicy.PreflightMiddleware(input.moderationPreflight, input.logger),
+		privacy.Middleware(),
+		privacy.EphemeralMiddleware(),
+	)
 	{
-		invites := api.Group("/invites")
-		{
-			invites.GET("/:userID/whitelist", input.inviteCodeHandler.CheckUserWhitelist)
-			invites.POST("/:code/redeem", input.inviteCodeHandler.RedeemInviteCode)
-			invites.GET("/reset/:code", input.inviteCodeHandler.ResetInviteCode)
-			invites.DELETE("/:id", input.inviteCodeHandler.DeleteInviteCode)
+		// AI service endpoints
+		proxyGroup.POST("/chat/completions", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+		proxyGroup.POST("/responses", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+		proxyGroup.GET("/responses/:responseId", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+		proxyGroup.POST("/embeddings", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+		proxyGroup.POST("/audio/speech", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+		proxyGroup.POST("/audio/transcriptions", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+		proxyGroup.POST("/audio/translations", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+
+		// Realtime API websocket relay (voice sessions, metered in audio minutes)
+		proxyGroup.GET("/realtime", proxy.RealtimeHandler(input.logger, input.requestTrackingService, input.modelRouter, input.complianceService, input.wsPolicy, input.config))
+
+		// Moderation pass-through to OpenAI (needs OPENAI_API_KEY)
+		if input.contentModerator != nil {
+			proxyGroup.POST("/moderations", proxy.ModerationsHandler(input.contentModerator, input.logger))
 		}
 
-		// Rate limiting routes (protected)
-		rateLimit := api.Group("/rate-limit")
-		{
-			rateLimit.GET("/status", request_tracking.RateLimitStatusHandler(input.requestTrackingService, input.logger, input.modelRouter))
-			rateLimit.GET("/metrics", request_tracking.MetricsHandler(input.requestTrackingService, input.logger))
+		// Batch API pass-through to OpenAI (BATCH_API_ENABLED, needs OPENAI_API_KEY)
+		if input.batchHandler != nil {
+			proxyGroup.POST("/files", input.batchHandler.UploadFile)
+			proxyGroup.GET("/files/:fileId/content", input.batchHandler.FileContent)
+			proxyGroup.POST("/batches", input.batchHandler.CreateBatch)
+			proxyGroup.GET("/batches", input.batchHandler.ListBatches)
+			proxyGroup.GET("/batches/:batchId", input.batchHandler.GetBatch)
+			proxyGroup.POST("/batches/:batchId/cancel", input.batchHandler.CancelBatch)
 		}
+	}
 
-		// IAP (protected)
-		sub := api.Group("/subscription")
-		{
-			sub.POST("/appstore/attach", input.iapHandler.AttachAppStoreSubscription)
-		}
+	return router
+}
+
+// registerAPIRoutes registers the client API routes on a versioned group (/api/v1, /api/v2).
+// Route comments give v1 paths; every route exists in each version.
+func registerAPIRoutes(api *gin.RouterGroup, input restServerInput, requireAttestation gin.HandlerFunc) {
+	// Invite code API routes (protected)
+	invites := api.Group("/invites")
+	{
+		invites.GET("/:userID/whitelist", input.inviteCodeHandler.CheckUserWhitelist)
+		invites.POST("/:code/redeem", requireAttestation, input.inviteCodeHandler.RedeemInviteCode)
+		invites.GET("/reset/:code", input.inviteCodeHandler.ResetInviteCode)
+		invites.DELETE("/:id", input.inviteCodeHandler.DeleteInviteCode)
+	}
+
+	// Rate limiting routes (protected)
+	rateLimit := api.Group("/rate-limit")
+	{
+		rateLimit.GET("/status", request_tracking.RateLimitStatusHandler(input.requestTrackingService, input.logger, input.modelRouter))
+		rateLimit.GET("/simulate", request_tracking.RateLimitSimulateHandler(input.requestTrackingService, input.logger, input.modelRouter))
+		rateLimit.GET("/metrics", request_tracking.MetricsHandler(input.requestTrackingService, input.logger))
+	}
+	api.GET("/usage", request_tracking.UsageHandler(input.requestTrackingService, input.logger))                 // GET /api/v1/usage - Plan tokens, audio and deep research usage against tier limits
+	api.GET("/models", proxy.ModelCatalogHandler(input.modelRouter, input.requestTrackingService, input.logger)) // GET /api/v1/models - Model catalog with provider, multiplier and tier availability for the client platform
+
+	// IAP (protected)
+	api.GET("/subscription", input.iapHandler.GetSubscription) // GET /api/v1/subscription - Tier, provider, expiry, renewal state and entitlement history
+	sub := api.Group("/subscription")
+	{
+		sub.POST("/appstore/attach", requireAttestation, input.iapHandler.AttachAppStoreSubscription)
+		sub.POST("/googleplay/attach", requireAttestation, input.iapHandler.AttachGooglePlayPurchase) // POST /api/v1/subscription/googleplay/attach - Verify a Play Billing purchase token
+	}
+
+	// Device attestation (protected)
+	attestationGroup := api.Group("/attestation")
+	{
+		attestationGroup.POST("/challenge", input.attestationHandler.IssueChallenge)             // POST /api/v1/attestation/challenge - One-time challenge
+		attestationGroup.POST("/app-attest/keys", input.attestationHandler.RegisterAppAttestKey) // POST /api/v1/attestation/app-attest/keys - Register iOS key
+	}
 
-		// Stripe (protected)
-		stripe := api.Group("/stripe")
+	// Stripe (protected)
+	stripe := api.Group("/stripe")
+	{
+		stripe.POST("/create-checkout-session", input.stripeHandler.CreateCheckoutSession)
+		stripe.POST("/create-portal-session", input.stripeHandler.CreatePortalSession)
+	}
+
+	// ZCash (protected)
+	zcashGroup := api.Group("/zcash")
+	{
+		zcashGroup.GET("/products", input.zcashHandler.GetProducts)
+		zcashGroup.POST("/invoice", input.zcashHandler.CreateInvoice)
+		zcashGroup.GET("/invoice/:invoiceId", input.zcashHandler.GetInvoice)
+	}
+
+	// FAI crypto payment (protected, only registered when blockchain is ready)
+	if input.faiReady {
+		faiGroup := api.Group("/fai")
 		{
-			stripe.POST("/create-checkout-session", input.stripeHandler.CreateCheckoutSession)
-			stripe.POST("/create-portal-session", input.stripeHandler.CreatePortalSession)
+			faiGroup.GET("/config", input.faiHandler.GetConfig)
+			faiGroup.GET("/products", input.faiHandler.GetProducts)
+			faiGroup.POST("/payment-intent", input.faiHandler.CreatePaymentIntent)
+			faiGroup.GET("/payment-intent/:paymentId", input.faiHandler.GetPaymentIntent)
 		}
+	}
+
+	// Search API routes (protected)
+	api.POST("/search", input.searchHandler.PostSearchHandler)        // POST /api/v1/search (DuckDuckGo/Google via SerpAPI, Brave)
+	api.POST("/exa/search", input.searchHandler.PostExaSearchHandler) // POST /api/v1/exa/search (Exa AI)
+	api.POST("/search/fetch", input.searchHandler.PostFetchHandler)   // POST /api/v1/search/fetch (page content as markdown/text)
 
-		// ZCash (protected)
-		zcashGroup := api.Group("/zcash")
+	// Task API routes (protected, only when Temporal is configured)
+	if input.taskHandler != nil {
+		tasks := api.Group("/tasks")
 		{
-			zcashGroup.GET("/products", input.zcashHandler.GetProducts)
-			zcashGroup.POST("/invoice", input.zcashHandler.CreateInvoice)
-			zcashGroup.GET("/invoice/:invoiceId", input.zcashHandler.GetInvoice)
+			tasks.POST("", input.taskHandler.CreateTask)           // POST /api/v1/tasks - Create a new task
+			tasks.GET("", input.taskHandler.GetTasks)              // GET /api/v1/tasks - Get all tasks for user
+			tasks.DELETE("/:taskId", input.taskHandler.DeleteTask) // DELETE /api/v1/tasks/:taskId - Delete a task
 		}
+	}
 
-		// FAI crypto payment (protected, only registered when blockchain is ready)
-		if input.faiReady {
-			faiGroup := api.Group("/fai")
-			{
-				faiGroup.GET("/config", input.faiHandler.GetConfig)
-				faiGroup.GET("/products", input.faiHandler.GetProducts)
-				faiGroup.POST("/payment-intent", input.faiHandler.CreatePaymentIntent)
-				faiGroup.GET("/payment-intent/:paymentId", input.faiHandler.GetPaymentIntent)
-			}
-		}
+	// Weekly digest API routes (protected, only when WEEKLY_DIGEST_ENABLED=true)
+	if input.digestHandler != nil {
+		api.GET("/digest/subscription", input.digestHandler.GetSubscription)    // GET /api/v1/digest/subscription - Get weekly digest opt-in
+		api.PUT("/digest/subscription", input.digestHandler.UpdateSubscription) // PUT /api/v1/digest/subscription - Opt in/out of weekly digests
+		api.GET("/digests", input.digestHandler.ListDigests)                    // GET /api/v1/digests - List recent digests
+	}
 
-		// Search API routes (protected)
-		api.POST("/search", input.searchHandler.PostSearchHandler)        // POST /api/v1/search (SerpAPI)
-		api.POST("/exa/search", input.searchHandler.PostExaSearchHandler) // POST /api/v1/exa/search (Exa AI)
+	// User preferences API routes (protected)
+	api.GET("/preferences", input.preferencesHandler.GetPreferences)       // GET /api/v1/preferences - Get default model, temperature and system prompt
+	api.PUT("/preferences", input.preferencesHandler.UpdatePreferences)    // PUT /api/v1/preferences - Replace preferences
+	api.DELETE("/preferences", input.preferencesHandler.DeletePreferences) // DELETE /api/v1/preferences - Clear preferences
 
-		// Task API routes (protected, only when Temporal is configured)
-		if input.taskHandler != nil {
-			tasks := api.Group("/tasks")
-			{
-				tasks.POST("", input.taskHandler.CreateTask)           // POST /api/v1/tasks - Create a new task
-				tasks.GET("", input.taskHandler.GetTasks)              // GET /api/v1/tasks - Get all tasks for user
-				tasks.DELETE("/:taskId", input.taskHandler.DeleteTask) // DELETE /api/v1/tasks/:taskId - Delete a task
-			}
-		}
+	// Voice conversation routes (protected, only when OPENAI_API_KEY is set)
+	if input.voiceHandler != nil {
+		api.POST("/voice/turn", voice.LimitRequestBody(), request_tracking.RequestTrackingMiddleware(input.requestTrackingService, input.logger, input.modelRouter), privacy.Middleware(), input.voiceHandler.Turn) // POST /api/v1/voice/turn - Transcribe, answer and speak in one round-trip (SSE)
+	}
 
-		// Problem Reports API routes (protected)
-		api.POST("/problem-reports", input.problemReportsHandler.CreateProblemReport) // POST /api/v1/problem-reports - Submit a problem report
+	// Problem Reports API routes (protected)
+	api.POST("/problem-reports", input.problemReportsHandler.CreateProblemReport) // POST /api/v1/problem-reports - Submit a problem report
+
+	// Deep Research endpoints (protected)
+	api.POST("/deepresearch/start", deepr.StartDeepResearchHandler(input.logger, input.requestTrackingService, input.firebaseClient, input.deeprStorage, input.deeprSessionManager, input.queries.Queries, input.config.DeepResearchRateLimitEnabled, input.notificationService, input.deeprArtifacts, input.deeprClarifier, input.titleService, input.modelRouter)) // POST API to start deep research
+	api.POST("/deepresearch/clarify", deepr.ClarifyDeepResearchHandler(input.logger, input.requestTrackingService, input.firebaseClient, input.deeprStorage, input.deeprSessionManager, input.queries.Queries, input.config.DeepResearchRateLimitEnabled, input.notificationService, input.deeprArtifacts))                                                          // POST API to submit clarification response
+	api.GET("/deepresearch/ws", deepr.DeepResearchHandler(input.logger, input.requestTrackingService, input.firebaseClient, input.deeprStorage, input.deeprSessionManager, input.queries.Queries, input.config.DeepResearchRateLimitEnabled, input.notificationService, input.deeprArtifacts, input.wsPolicy))                                                       // WebSocket proxy for deep research
+	if input.deeprArtifacts != nil {
+		api.GET("/deepresearch/artifacts/:chatId/:artifactId", deepr.ArtifactURLHandler(input.logger, input.deeprArtifacts)) // Signed download URL of a report artifact
+	}
 
-		// Deep Research endpoints (protected)
-		api.POST("/deepresearch/start", deepr.StartDeepResearchHandler(input.logger, input.requestTrackingService, input.firebaseClient, input.deeprStorage, input.deeprSessionManager, input.queries.Queries, input.config.DeepResearchRateLimitEnabled, input.notificationService, input.titleService, input.modelRouter)) // POST API to start deep research
-		api.POST("/deepresearch/clarify", deepr.ClarifyDeepResearchHandler(input.logger, input.requestTrackingService, input.firebaseClient, input.deeprStorage, input.deeprSessionManager, input.queries.Queries, input.config.DeepResearchRateLimitEnabled, input.notificationService))                                    // POST API to submit clarification response
-		api.GET("/deepresearch/ws", deepr.DeepResearchHandler(input.logger, input.requestTrackingService, input.firebaseClient, input.deeprStorage, input.deeprSessionManager, input.queries.Queries, input.config.DeepResearchRateLimitEnabled, input.notificationService))                                                 // WebSocket proxy for deep research
+	// Stream Control API routes (protected)
+	api.POST("/streams/:chatId/:messageId/stop", proxy.StopStreamHandler(input.logger, input.streamManager, input.chatStore))    // POST /api/v1/streams/:chatId/:messageId/stop - Stop a response by stream key (same as the chat message route)
+	api.GET("/streams/:chatId/active", proxy.ActiveStreamHandler(input.logger, input.streamManager, input.chatStore))            // GET /api/v1/streams/:chatId/active - The chat's in-progress response, if any
+	api.GET("/streams/:chatId/:messageId/replay", proxy.ResumeStreamHandler(input.logger, input.streamManager, input.chatStore)) // GET /api/v1/streams/:chatId/:messageId/replay - Rejoin a response, replaying buffered chunks
 
-		// Stream Control API routes (protected)
-		chats := api.Group("/chats")
+	chats := api.Group("/chats")
+	{
+		messages := chats.Group("/:chatId/messages")
 		{
-			messages := chats.Group("/:chatId/messages")
-			{
-				messages.POST("/:messageId/stop", proxy.StopStreamHandler(input.logger, input.streamManager, input.firestoreClient)) // POST /api/v1/chats/:chatId/messages/:messageId/stop
+			messages.POST("/:messageId/stop", proxy.StopStreamHandler(input.logger, input.streamManager, input.chatStore))    // POST /api/v1/chats/:chatId/messages/:messageId/stop
+			messages.GET("/:messageId/stream", proxy.ResumeStreamHandler(input.logger, input.streamManager, input.chatStore)) // GET /api/v1/chats/:chatId/messages/:messageId/stream - Replay and follow a response after reconnecting
+			messages.GET("/:messageId/chunks", proxy.StreamChunksHandler(input.logger, input.streamManager, input.chatStore)) // GET /api/v1/chats/:chatId/messages/:messageId/chunks?after=N - Long-poll the response's chunks (networks without SSE)
+			messages.PUT("/:messageId/search-tokens", input.chatSearchHandler.IndexMessage)                                   // PUT /api/v1/chats/:chatId/messages/:messageId/search-tokens - Replace the message's search tokens
+			messages.PUT("/:messageId/feedback", input.qualityHandler.RateMessage)                                            // PUT /api/v1/chats/:chatId/messages/:messageId/feedback - Rate a response (thumbs up/down)
+			messages.DELETE("/:messageId/feedback", input.qualityHandler.DeleteRating)                                        // DELETE /api/v1/chats/:chatId/messages/:messageId/feedback - Remove a rating
+
+			// POST /api/v1/chats/:chatId/messages/:messageId/retry - Retry a failed generation with its stored request
+			messages.POST("/:messageId/retry",
+				sandbox.Middleware(input.config),
+				proxy.RetryMessageHandler(input.logger, input.streamManager, input.chatStore, input.replays),
+				preferences.Middleware(input.preferencesService, input.contentModerator, input.logger),
+				request_tracking.RequestTrackingMiddleware(input.requestTrackingService, input.logger, input.modelRouter),
+				contentpolicy.PreflightMiddleware(input.moderationPreflight, input.logger),
+				privacy.Middleware(),
+				privacy.EphemeralMiddleware(),
+				proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+
+			// POST /api/v1/chats/:chatId/messages/batch - Store messages queued offline, then run one completion
+			// (ephemeral mode is detected first: the batch handler must not store those messages)
+			messages.POST("/batch",
+				sandbox.Middleware(input.config),
+				privacy.EphemeralMiddleware(),
+				proxy.BatchMessagesHandler(input.logger, input.messageService, input.chatStore, input.requestTrackingService),
+				preferences.Middleware(input.preferencesService, input.contentModerator, input.logger),
+				request_tracking.RequestTrackingMiddleware(input.requestTrackingService, input.logger, input.modelRouter),
+				contentpolicy.PreflightMiddleware(input.moderationPreflight, input.logger),
+				privacy.Middleware(),
+				proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.complianceService, input.streamRecorder, input.rateQueue, input.breakers, input.replays, input.config))
+
+			// Read receipts (only when message storage is available)
+			if input.messageService != nil {
+				messages.POST("/:messageId/receipt", proxy.AcknowledgeMessageHandler(input.logger, input.messageService)) // POST /api/v1/chats/:chatId/messages/:messageId/receipt - Acknowledge delivery/read of an assistant message
 			}
 		}
 
-		// Key Sharing API routes (protected)
-		if input.keyshareHandler != nil {
-			encryption := api.Group("/encryption")
-			{
-				keyShare := encryption.Group("/key-share")
-				{
-					keyShare.POST("/session", input.keyshareHandler.CreateSession)                    // POST /api/v1/encryption/key-share/session
-					keyShare.POST("/session/:sessionId", input.keyshareHandler.SubmitKey)             // POST /api/v1/encryption/key-share/session/:sessionId
-					keyShare.GET("/session/:sessionId/listen", input.keyshareHandler.WebSocketListen) // WebSocket /api/v1/encryption/key-share/session/:sessionId/listen
-				}
-			}
+		// Chat organization (pin, archive, mute), written server-side for cross-device consistency
+		if input.chatStore != nil {
+			chats.GET("/:chatId/metadata", proxy.GetChatMetadataHandler(input.logger, input.chatStore))      // GET /api/v1/chats/:chatId/metadata - Get pin/archive/mute state
+			chats.PATCH("/:chatId/metadata", proxy.UpdateChatMetadataHandler(input.logger, input.chatStore)) // PATCH /api/v1/chats/:chatId/metadata - Pin, archive or mute a chat
 		}
-	}
 
-	// Protected proxy routes
-	proxyGroup := router.Group("/")
-	proxyGroup.Use(request_tracking.RequestTrackingMiddleware(input.requestTrackingService, input.logger, input.modelRouter))
-	{
-		// AI service endpoints
-		proxyGroup.POST("/chat/completions", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.config))
-		proxyGroup.POST("/responses", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.config))
-		proxyGroup.GET("/responses/:responseId", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.config))
-		proxyGroup.POST("/embeddings", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.config))
-		proxyGroup.POST("/audio/speech", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.config))
-		proxyGroup.POST("/audio/transcriptions", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.config))
-		proxyGroup.POST("/audio/translations", proxy.ProxyHandler(input.logger, input.requestTrackingService, input.messageService, input.titleService, input.streamManager, input.pollingManager, input.modelRouter, input.toolRegistry, input.anonymizerService, input.config))
+		// Opt-in search index (keyword tokens uploaded by clients, blinded for E2EE users)
+		chats.GET("/search", input.chatSearchHandler.Search)                       // GET /api/v1/chats/search?token=... - Find messages matching every token
+		chats.DELETE("/search", input.chatSearchHandler.DeleteIndex)               // DELETE /api/v1/chats/search - Delete the user's search index (opt out)
+		chats.DELETE("/:chatId/search-tokens", input.chatSearchHandler.DeleteChat) // DELETE /api/v1/chats/:chatId/search-tokens - Remove a chat from the index
+
+		// Draft sync (only when message storage is available)
+		if input.messageService != nil {
+			chats.GET("/:chatId/draft", proxy.GetDraftHandler(input.logger, input.messageService))       // GET /api/v1/chats/:chatId/draft - Get the unsent draft
+			chats.PUT("/:chatId/draft", proxy.SaveDraftHandler(input.logger, input.messageService))      // PUT /api/v1/chats/:chatId/draft - Save the unsent draft (encrypted like messages)
+			chats.DELETE("/:chatId/draft", proxy.DeleteDraftHandler(input.logger, input.messageService)) // DELETE /api/v1/chats/:chatId/draft - Discard the draft
+		}
 	}
 
-	return router
+	// Key Sharing API routes (protected)
+	if input.keyshareHandler != nil {
+		encryption := api.Group("/encryption")
+		{
+			keyShare := encryption.Group("/key-share")
+			{
+				keyShare.POST("/session", input.keyshareHandler.CreateSession)                    // POST /api/v1/encryption/key-share/session
+				keyShare.POST("/session/:sessionId", input.keyshareHandler.SubmitKey)             // POST /api/v1/encryption/key-share/session/:sessionId
+				keyShare.GET("/session/:sessionId/listen", input.keyshareHandler.WebSocketListen) // WebSocket /api/v1/encryption/key-share/session/:sessionId/listen
+			}
+		}
+	}
 }
 
 type graphqlServerInput struct {
@@ -861,6 +1640,7 @@ type graphqlServerInput struct {
 	natsClient      *nats.Conn
 	telegramService *telegram.Service
 	firebaseAuth    *auth.FirebaseAuthMiddleware
+	wsPolicy        *wspolicy.Policy
 }
 
 func setupGraphQLServer(input graphqlServerInput) *chi.Mux {
@@ -904,13 +1684,7 @@ func setupGraphQLServer(input graphqlServerInput) *chi.Mux {
 
 	srv.AddTransport(transport.Websocket{
 		KeepAlivePingInterval: 10 * time.Second,
-		Upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
-		},
+		Upgrader:              *input.wsPolicy.Upgrader(),
 	})
 
 	srv.Use(extension.Introspection{})
@@ -932,7 +1706,7 @@ func setupGraphQLServer(input graphqlServerInput) *chi.Mux {
 	})
 
 	router.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	router.Handle("/query", srv)
+	router.With(input.wsPolicy.Middleware(wspolicy.SubprotocolsGraphQL...)).Handle("/query", srv)
 
 	return router
 }
@@ -947,6 +1721,32 @@ func gqlSchema(resolver *graph.Resolver) graphql.ExecutableSchema {
 func NewTokenValidator(cfg *config.Config, logger *logger.Logger) (auth.TokenValidator, error) {
 	log := logger.WithComponent("auth")
 
+	// Several issuers (e.g. Firebase and our identity service), selected by the iss claim
+	if cfg.AuthIssuers != "" {
+		issuers, err := auth.ParseIssuers(cfg.AuthIssuers, cfg.FirebaseProjectID)
+		if err != nil {
+			log.Error("invalid auth issuers", slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		validators := make(map[string]auth.TokenValidator, len(issuers))
+		for issuer, source := range issuers {
+			var tokenValidator auth.TokenValidator
+			if source == auth.IssuerFirebase {
+				tokenValidator, err = auth.NewFirebaseTokenValidator(context.Background(), cfg.FirebaseCredJSON)
+			} else {
+				tokenValidator, err = auth.NewTokenValidator(source)
+			}
+			if err != nil {
+				log.Error("failed to create token validator", slog.String("issuer", issuer), slog.String("error", err.Error()))
+				return nil, err
+			}
+			log.Info("accepting tokens of issuer", slog.String("issuer", issuer), slog.String("jwks", source))
+			validators[issuer] = tokenValidator
+		}
+		return auth.NewIssuerValidator(validators), nil
+	}
+
 	switch cfg.ValidatorType {
 	case "firebase":
 		if cfg.FirebaseProjectID == "" {
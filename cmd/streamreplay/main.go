@@ -0,0 +1,76 @@
+// Command streamreplay re-drives a stream recording (see internal/streamrecord) through the
+// proxy's streaming pipeline and prints the chunks clients would have received.
+//
+// Fetch a recording with `adminctl recording -chat ID -message ID > rec.json`, then:
+//
+//	go run ./cmd/streamreplay -file rec.json -speed 1 -reasoning separate
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/streaming"
+	"github.com/eternisai/enchanted-proxy/internal/streamrecord"
+)
+
+func main() {
+	file := flag.String("file", "", "path to a recording JSON file")
+	speed := flag.Float64("speed", 0, "playback speed (1 = original timing, 0 = no delays)")
+	reasoning := flag.String("reasoning", "show", "reasoning visibility (show, separate, strip)")
+	logLevel := flag.String("log-level", "warn", "log level (debug, info, warn, error)")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+
+	visibility, ok := streaming.ParseReasoningVisibility(*reasoning)
+	if !ok {
+		log.Fatalf("invalid -reasoning %q", *reasoning)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("failed to read recording: %v", err)
+	}
+
+	var rec streamrecord.Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		log.Fatalf("failed to decode recording: %v", err)
+	}
+
+	appLogger := logger.New(logger.FromConfig(*logLevel, "text"))
+	appLogger.WithComponent("main").Info("replaying recording",
+		slog.String("chat_id", rec.ChatID),
+		slog.String("message_id", rec.MessageID),
+		slog.String("provider", rec.Provider),
+		slog.String("model", rec.Model),
+		slog.Int("events", len(rec.Events)),
+		slog.Bool("truncated", rec.Truncated))
+
+	ctx := context.Background()
+	start := time.Now()
+
+	session := streaming.NewStreamSession(rec.ChatID, rec.MessageID, streamrecord.Replay(ctx, &rec, *speed), appLogger)
+	session.SetModel(rec.Model)
+	session.SetReasoningVisibility(visibility)
+	session.Start()
+	session.WaitForCompletion()
+
+	for _, chunk := range session.GetStoredChunks() {
+		fmt.Printf("%8dms  %s\n", chunk.Timestamp.Sub(start).Milliseconds(), chunk.Line)
+	}
+
+	fmt.Printf("\n--- content ---\n%s\n", session.GetContent())
+	if err := session.GetError(); err != nil {
+		fmt.Printf("--- error ---\n%v\n", err)
+	}
+}
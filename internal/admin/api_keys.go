@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"database/sql"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// ListAPIKeys handles GET /admin/api-keys
+// Lists service API keys, including revoked and expired ones (never their secrets).
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	rows, err := h.queries.ListServiceAPIKeys(ctx)
+	if err != nil {
+		log.Error("failed to list API keys", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to list API keys", nil)
+		return
+	}
+
+	response := APIKeysResponse{APIKeys: make([]APIKeyResponse, 0, len(rows))}
+	for i := range rows {
+		response.APIKeys = append(response.APIKeys, toAPIKeyResponse(&rows[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateAPIKey handles POST /admin/api-keys
+// Creates a service API key with scopes. The key is returned once: only its hash is stored.
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		errors.BadRequest(c, "name is required", nil)
+		return
+	}
+	scopes, err := auth.ValidateScopes(req.Scopes)
+	if err != nil {
+		errors.BadRequest(c, err.Error(), nil)
+		return
+	}
+	userID := strings.TrimSpace(req.UserID)
+	if userID == "" {
+		userID = "service:" + name
+	}
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			errors.BadRequest(c, "expires_at must be in the future", nil)
+			return
+		}
+		expiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	key, hash, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		log.Error("failed to generate API key", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to generate API key", nil)
+		return
+	}
+
+	row, err := h.queries.CreateServiceAPIKey(ctx, pgdb.CreateServiceAPIKeyParams{
+		Name:        name,
+		KeyHash:     hash,
+		KeyPrefix:   prefix,
+		Scopes:      scopes,
+		UserID:      userID,
+		Description: req.Description,
+		ExpiresAt:   expiresAt,
+	})
+	if stderrors.Is(err, sql.ErrNoRows) {
+		errors.Conflict(c, "an API key with this name already exists", nil)
+		return
+	}
+	if err != nil {
+		log.Error("failed to create API key", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to create API key", nil)
+		return
+	}
+
+	log.Info("API key created via admin API",
+		slog.Int64("id", row.ID),
+		slog.String("name", row.Name),
+		slog.String("scopes", row.Scopes),
+		slog.String("user_id", row.UserID))
+
+	response := toAPIKeyResponse(&row)
+	response.Key = key
+	c.JSON(http.StatusCreated, response)
+}
+
+// RevokeAPIKey handles DELETE /admin/api-keys/:id
+// Revokes a service API key. Instances stop accepting it within a minute (validation cache).
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errors.BadRequest(c, "invalid API key id", nil)
+		return
+	}
+
+	if _, err := h.queries.RevokeServiceAPIKey(ctx, id); err != nil {
+		if stderrors.Is(err, sql.ErrNoRows) {
+			errors.NotFound(c, "API key not found or already revoked", nil)
+			return
+		}
+		log.Error("failed to revoke API key", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to revoke API key", nil)
+		return
+	}
+
+	log.Info("API key revoked via admin API", slog.Int64("id", id))
+
+	c.JSON(http.StatusOK, gin.H{"revoked": true, "id": id})
+}
+
+func toAPIKeyResponse(row *pgdb.ServiceApiKey) APIKeyResponse {
+	response := APIKeyResponse{
+		ID:          row.ID,
+		Name:        row.Name,
+		KeyPrefix:   row.KeyPrefix,
+		Scopes:      auth.ParseScopes(row.Scopes),
+		UserID:      row.UserID,
+		Description: row.Description,
+		CreatedAt:   row.CreatedAt,
+	}
+	if row.ExpiresAt.Valid {
+		response.ExpiresAt = &row.ExpiresAt.Time
+	}
+	if row.RevokedAt.Valid {
+		response.RevokedAt = &row.RevokedAt.Time
+	}
+	return response
+}
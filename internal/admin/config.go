@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// GetConfig handles GET /admin/v1/config
+// Returns the effective value of every setting and the layer it comes from (default, profile,
+// file, env or flag), with secrets redacted.
+func (h *Handler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, ConfigResponse{
+		Profile:    config.Profile(),
+		ConfigFile: h.configFilePath,
+		Settings:   config.EffectiveSettings(),
+	})
+}
+
+// ReloadConfig handles POST /admin/v1/config/reload
+// Re-reads the settings (config file, .env, environment) and applies those that can change
+// without a restart on this instance, like SIGHUP. Returns the settings that changed.
+func (h *Handler) ReloadConfig(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("admin-handler")
+
+	changes, err := config.Reload()
+	if err != nil {
+		log.Error("failed to reload config", slog.String("error", err.Error()))
+		errors.BadRequest(c, "failed to reload config", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	log.Info("config reloaded via admin API", slog.Int("changes", len(changes)))
+	c.JSON(http.StatusOK, ReloadConfigResponse{Changes: changes})
+}
@@ -0,0 +1,463 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/bans"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/revocation"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/routingconfig"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/eternisai/enchanted-proxy/internal/streaming"
+	"github.com/eternisai/enchanted-proxy/internal/streamrecord"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/eternisai/enchanted-proxy/internal/tinfoil"
+	"github.com/eternisai/enchanted-proxy/internal/upstreams"
+	"github.com/gin-gonic/gin"
+)
+
+// Queue names reported by FlushQueues.
+const (
+	QueueRequestLogs = "request_logs"
+	QueueMessages    = "messages"
+)
+
+// flushTimeout bounds how long a single flush request may drain queues.
+const flushTimeout = 30 * time.Second
+
+// Handler serves the operator-only admin API used by cmd/adminctl.
+type Handler struct {
+	queries         pgdb.Querier
+	trackingService *request_tracking.Service
+	messageService  *messaging.Service
+	streamManager   *streaming.StreamManager
+	modelRouter     *routing.ModelRouter
+	recorder        *streamrecord.Recorder
+	upstreams       *upstreams.Service
+	routingEntries  *routingconfig.Service
+	bans            *bans.Service
+	revoker         *revocation.Service
+	attestation     *tinfoil.Service
+	configFilePath  string
+	logger          *logger.Logger
+}
+
+// NewHandler creates a new admin handler. messageService, streamManager, recorder,
+// upstreamService, routingEntries and attestationService may be nil when the corresponding
+// subsystems are disabled.
+func NewHandler(
+	queries pgdb.Querier,
+	trackingService *request_tracking.Service,
+	messageService *messaging.Service,
+	streamManager *streaming.StreamManager,
+	modelRouter *routing.ModelRouter,
+	recorder *streamrecord.Recorder,
+	upstreamService *upstreams.Service,
+	routingEntries *routingconfig.Service,
+	banService *bans.Service,
+	attestationService *tinfoil.Service,
+	configFilePath string,
+	logger *logger.Logger,
+) *Handler {
+	return &Handler{
+		queries:         queries,
+		trackingService: trackingService,
+		messageService:  messageService,
+		streamManager:   streamManager,
+		modelRouter:     modelRouter,
+		recorder:        recorder,
+		upstreams:       upstreamService,
+		routingEntries:  routingEntries,
+		bans:            banService,
+		attestation:     attestationService,
+		configFilePath:  configFilePath,
+		logger:          logger,
+	}
+}
+
+// SetRevoker sets the service stopping in-flight work of banned users.
+// Optional: without it, banned users keep their running streams and deep research runs.
+func (h *Handler) SetRevoker(revoker *revocation.Service) {
+	h.revoker = revoker
+}
+
+// GrantEntitlement handles POST /admin/users/:userId/entitlement
+// Grants a tier for a number of days (extending an active same-tier grant), until a given
+// expiry, or for life.
+func (h *Handler) GrantEntitlement(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+	userID := c.Param("userId")
+
+	var req GrantEntitlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+	if _, err := tiers.Get(tiers.Tier(req.Tier)); err != nil {
+		errors.BadRequest(c, err.Error(), nil)
+		return
+	}
+	if req.DurationDays < 0 {
+		errors.BadRequest(c, "duration_days must not be negative", nil)
+		return
+	}
+	options := 0
+	for _, set := range []bool{req.DurationDays > 0, req.ExpiresAt != nil, req.Lifetime} {
+		if set {
+			options++
+		}
+	}
+	if options != 1 {
+		errors.BadRequest(c, "set exactly one of duration_days, expires_at or lifetime", nil)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		errors.BadRequest(c, "expires_at must be in the future", nil)
+		return
+	}
+
+	var err error
+	if req.DurationDays == 0 {
+		expiresAt := lifetimeExpiry
+		if req.ExpiresAt != nil {
+			expiresAt = req.ExpiresAt.UTC()
+		}
+		err = h.queries.UpsertEntitlementWithTier(ctx, pgdb.UpsertEntitlementWithTierParams{
+			UserID:                userID,
+			SubscriptionTier:      req.Tier,
+			SubscriptionExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+			SubscriptionProvider:  ProviderAdmin,
+		})
+	} else {
+		err = h.queries.UpsertEntitlementWithExtension(ctx, pgdb.UpsertEntitlementWithExtensionParams{
+			UserID:               userID,
+			SubscriptionTier:     req.Tier,
+			BaseTime:             time.Now(),
+			DurationDays:         req.DurationDays,
+			SubscriptionProvider: ProviderAdmin,
+		})
+	}
+	if err != nil {
+		log.Error("failed to grant entitlement",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to grant entitlement", nil)
+		return
+	}
+
+	entitlement, err := h.queries.GetEntitlement(ctx, userID)
+	if err != nil {
+		log.Error("failed to read back entitlement",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "entitlement granted but could not be read back", nil)
+		return
+	}
+
+	log.Info("entitlement granted via admin API",
+		slog.String("user_id", userID),
+		slog.String("tier", req.Tier),
+		slog.Int("duration_days", int(req.DurationDays)),
+		slog.Bool("explicit_expiry", req.ExpiresAt != nil),
+		slog.Bool("lifetime", req.Lifetime))
+
+	c.JSON(http.StatusOK, entitlementResponse(entitlement))
+}
+
+// entitlementResponse converts an entitlement record.
+func entitlementResponse(entitlement pgdb.GetEntitlementRow) *EntitlementResponse {
+	response := &EntitlementResponse{
+		UserID:               entitlement.UserID,
+		Tier:                 entitlement.SubscriptionTier,
+		SubscriptionProvider: entitlement.SubscriptionProvider,
+		UpdatedAt:            entitlement.UpdatedAt,
+	}
+	if entitlement.SubscriptionExpiresAt.Valid {
+		response.ExpiresAt = &entitlement.SubscriptionExpiresAt.Time
+	}
+	return response
+}
+
+// GetUserQuota handles GET /admin/users/:userId/quota
+// Returns the same payload as /api/v1/rate-limit/status for an arbitrary user.
+func (h *Handler) GetUserQuota(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+	userID := c.Param("userId")
+
+	status, err := request_tracking.BuildRateLimitStatus(ctx, h.trackingService, userID, h.modelRouter, log)
+	if err != nil {
+		log.Error("failed to build quota status",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to get quota status", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ListStreams handles GET /admin/streams
+// Lists active streams on the instance that served the request.
+func (h *Handler) ListStreams(c *gin.Context) {
+	if h.streamManager == nil {
+		errors.NotFound(c, "streaming is not enabled", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"streams": h.streamManager.GetActiveStreams(),
+		"metrics": h.streamManager.GetMetrics(),
+	})
+}
+
+// StopStream handles POST /admin/streams/:chatId/:messageId/stop
+// Stops a stream on any instance (via distributed cancel when not local).
+func (h *Handler) StopStream(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+	chatID := c.Param("chatId")
+	messageID := c.Param("messageId")
+
+	if h.streamManager == nil {
+		errors.NotFound(c, "streaming is not enabled", nil)
+		return
+	}
+
+	response := StopStreamResponse{ChatID: chatID, MessageID: messageID}
+
+	if session := h.streamManager.GetSession(chatID, messageID); session != nil {
+		if err := session.Stop(ProviderAdmin, streaming.StopReasonAdmin); err != nil {
+			errors.Conflict(c, err.Error(), map[string]interface{}{"message_id": messageID})
+			return
+		}
+		response.Stopped = true
+		response.ChunksGenerated = len(session.GetStoredChunks())
+	} else {
+		distCancel := h.streamManager.GetDistributedCancel()
+		if distCancel == nil {
+			errors.NotFound(c, "stream not found", map[string]interface{}{"message_id": messageID})
+			return
+		}
+
+		resp, err := distCancel.RequestCancel(ctx, chatID, messageID, ProviderAdmin, streaming.StopReasonAdmin)
+		if err != nil {
+			log.Error("distributed cancel failed",
+				slog.String("error", err.Error()),
+				slog.String("chat_id", chatID),
+				slog.String("message_id", messageID))
+			errors.Internal(c, "failed to stop stream", map[string]interface{}{"details": err.Error()})
+			return
+		}
+		switch {
+		case !resp.Found:
+			errors.NotFound(c, "stream not found", map[string]interface{}{"message_id": messageID})
+			return
+		case resp.AlreadyComplete:
+			errors.Conflict(c, "stream already completed", map[string]interface{}{"message_id": messageID})
+			return
+		case resp.AlreadyStopped:
+			errors.Conflict(c, "stream already stopped", map[string]interface{}{"message_id": messageID})
+			return
+		case !resp.Success:
+			errors.Internal(c, "failed to stop stream", map[string]interface{}{"details": resp.Error})
+			return
+		}
+		response.Stopped = true
+		response.ChunksGenerated = resp.ChunksGenerated
+		response.RemoteInstance = resp.InstanceID
+	}
+
+	log.Info("stream stopped via admin API",
+		slog.String("chat_id", chatID),
+		slog.String("message_id", messageID),
+		slog.String("remote_instance", response.RemoteInstance))
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FlushQueues handles POST /admin/queues/flush
+// Drains the async write queues on the instance that served the request.
+func (h *Handler) FlushQueues(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("admin-handler")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), flushTimeout)
+	defer cancel()
+
+	response := FlushQueuesResponse{
+		Flushed:   map[string]int{},
+		Remaining: map[string]int{},
+	}
+
+	if h.trackingService != nil {
+		response.Flushed[QueueRequestLogs] = h.trackingService.Flush(ctx)
+		response.Remaining[QueueRequestLogs] = int(h.trackingService.GetMetrics()["queue_size"])
+	}
+	if h.messageService != nil {
+		response.Flushed[QueueMessages] = h.messageService.Flush(ctx)
+		response.Remaining[QueueMessages] = h.messageService.QueueSize()
+	}
+
+	log.Info("queues flushed via admin API",
+		slog.Int("request_logs", response.Flushed[QueueRequestLogs]),
+		slog.Int("messages", response.Flushed[QueueMessages]))
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReloadRouting handles POST /admin/routing/reload
+// Re-reads model_router from the config file and rebuilds the routing table in place, with the
+// routing entries applied on top.
+// Endpoints switched by the fallback service revert to the configured defaults until
+// the next fallback transition.
+func (h *Handler) ReloadRouting(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("admin-handler")
+
+	routerConfig, err := config.LoadModelRouterConfig(h.configFilePath)
+	if err != nil {
+		log.Error("failed to reload routing config",
+			slog.String("error", err.Error()),
+			slog.String("config_file", h.configFilePath))
+		errors.BadRequest(c, "failed to load routing config", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	if h.routingEntries != nil {
+		// Keep the routing entries applied on top of the reloaded file
+		if err := h.routingEntries.SetBase(c.Request.Context(), routerConfig); err != nil {
+			log.Error("failed to apply routing entries to reloaded config",
+				slog.String("error", err.Error()),
+				slog.String("config_file", h.configFilePath))
+			errors.BadRequest(c, "routing entries don't apply to the reloaded config", map[string]interface{}{"details": err.Error()})
+			return
+		}
+	} else {
+		h.modelRouter.RebuildRoutes(routerConfig)
+	}
+	routeCount := len(h.modelRouter.GetRoutes())
+
+	log.Info("routing reloaded via admin API",
+		slog.String("config_file", h.configFilePath),
+		slog.Int("route_count", routeCount))
+
+	c.JSON(http.StatusOK, ReloadRoutingResponse{
+		ConfigFile: h.configFilePath,
+		RouteCount: routeCount,
+	})
+}
+
+// ProviderStatus handles GET /admin/providers/status
+// Lists every model endpoint with its routing state (active/inactive after fallback) and
+// p50/p95 streaming latency on this instance. Models served via the wildcard route are
+// listed under the requested model name. Enclave providers are listed with their attestation
+// state; their endpoints are missing while attestation fails.
+func (h *Handler) ProviderStatus(c *gin.Context) {
+	response := ProviderStatusResponse{
+		Endpoints: buildEndpointStatus(h.modelRouter.GetRoutes(), metrics.StreamLatencySnapshot()),
+	}
+	if h.attestation != nil {
+		response.Attestations = h.attestation.Statuses()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// buildEndpointStatus merges the routing table with streaming latency stats.
+func buildEndpointStatus(routes map[string]routing.ModelRoute, latency []metrics.StreamLatencyStats) []EndpointStatus {
+	type key struct{ provider, model string }
+	stats := make(map[key]metrics.StreamLatencyStats, len(latency))
+	for _, s := range latency {
+		stats[key{s.Provider, s.Model}] = s
+	}
+
+	models := make([]string, 0, len(routes))
+	for model := range routes {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	endpoints := make([]EndpointStatus, 0, len(latency))
+	wildcardActive := make(map[string]bool)
+	for _, model := range models {
+		route := routes[model]
+		add := func(endpoint routing.ModelEndpoint, active bool) {
+			if model == "*" {
+				wildcardActive[endpoint.Provider.Name] = active
+			}
+			k := key{endpoint.Provider.Name, model}
+			s := stats[k]
+			delete(stats, k)
+			endpoints = append(endpoints, EndpointStatus{
+				Model:            model,
+				Provider:         endpoint.Provider.Name,
+				Active:           active,
+				TimeToFirstToken: s.TimeToFirstToken,
+				InterChunk:       s.InterChunk,
+			})
+		}
+		for _, endpoint := range route.ActiveEndpoints {
+			add(endpoint, true)
+		}
+		for _, endpoint := range route.InactiveEndpoints {
+			add(endpoint, false)
+		}
+	}
+
+	// Remaining stats belong to models served by the wildcard route (or removed by a reload)
+	for _, s := range latency {
+		if _, ok := stats[key{s.Provider, s.Model}]; !ok {
+			continue
+		}
+		endpoints = append(endpoints, EndpointStatus{
+			Model:            s.Model,
+			Provider:         s.Provider,
+			Active:           wildcardActive[s.Provider],
+			TimeToFirstToken: s.TimeToFirstToken,
+			InterChunk:       s.InterChunk,
+		})
+	}
+
+	return endpoints
+}
+
+// GetRecording handles GET /admin/recordings/:chatId/:messageId
+// Returns the debug recording of a stream (only streams of STREAM_RECORDING_USER_IDS are recorded).
+func (h *Handler) GetRecording(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+	chatID := c.Param("chatId")
+	messageID := c.Param("messageId")
+
+	if h.recorder == nil {
+		errors.NotFound(c, "stream recording is not enabled", nil)
+		return
+	}
+
+	rec, err := h.recorder.Load(ctx, chatID, messageID)
+	if stderrors.Is(err, streamrecord.ErrNotFound) {
+		errors.NotFound(c, "recording not found", map[string]interface{}{"message_id": messageID})
+		return
+	}
+	if err != nil {
+		log.Error("failed to load stream recording",
+			slog.String("error", err.Error()),
+			slog.String("chat_id", chatID),
+			slog.String("message_id", messageID))
+		errors.Internal(c, "failed to load recording", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rec)
+}
@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBuildEndpointStatus(t *testing.T) {
+	endpoint := func(provider string) routing.ModelEndpoint {
+		return routing.ModelEndpoint{Provider: &routing.ProviderConfig{Name: provider}}
+	}
+	routes := map[string]routing.ModelRoute{
+		"zai-org/GLM-4.6": {
+			ActiveEndpoints:   []routing.ModelEndpoint{endpoint("Eternis")},
+			InactiveEndpoints: []routing.ModelEndpoint{endpoint("NEAR AI")},
+		},
+		"*": {ActiveEndpoints: []routing.ModelEndpoint{endpoint("OpenRouter")}},
+	}
+	latency := []metrics.StreamLatencyStats{
+		{Provider: "Eternis", Model: "zai-org/GLM-4.6", TimeToFirstToken: metrics.LatencyPercentiles{Samples: 10, P50Ms: 300, P95Ms: 900}},
+		{Provider: "OpenRouter", Model: "anthropic/claude-3-opus", TimeToFirstToken: metrics.LatencyPercentiles{Samples: 2, P50Ms: 700, P95Ms: 800}},
+	}
+
+	got := buildEndpointStatus(routes, latency)
+
+	want := []EndpointStatus{
+		{Model: "*", Provider: "OpenRouter", Active: true},
+		{Model: "zai-org/GLM-4.6", Provider: "Eternis", Active: true, TimeToFirstToken: latency[0].TimeToFirstToken},
+		{Model: "zai-org/GLM-4.6", Provider: "NEAR AI", Active: false},
+		{Model: "anthropic/claude-3-opus", Provider: "OpenRouter", Active: true, TimeToFirstToken: latency[1].TimeToFirstToken},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len(endpoints) = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("endpoints[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// entitlementStore keeps one entitlement per user in memory; other Querier methods are not used.
+type entitlementStore struct {
+	pgdb.Querier
+	rows map[string]pgdb.GetEntitlementRow
+}
+
+func (s *entitlementStore) UpsertEntitlementWithTier(_ context.Context, arg pgdb.UpsertEntitlementWithTierParams) error {
+	s.rows[arg.UserID] = pgdb.GetEntitlementRow{
+		UserID:                arg.UserID,
+		SubscriptionTier:      arg.SubscriptionTier,
+		SubscriptionExpiresAt: arg.SubscriptionExpiresAt,
+		SubscriptionProvider:  arg.SubscriptionProvider,
+	}
+	return nil
+}
+
+func (s *entitlementStore) GetEntitlement(_ context.Context, userID string) (pgdb.GetEntitlementRow, error) {
+	row, ok := s.rows[userID]
+	if !ok {
+		return pgdb.GetEntitlementRow{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+func TestGrantEntitlementRequiresDuration(t *testing.T) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{}
+	defer func() { config.AppConfig = previous }()
+
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	store := &entitlementStore{rows: map[string]pgdb.GetEntitlementRow{}}
+	handler := NewHandler(store, nil, nil, nil, nil, nil, nil, nil, nil, nil, "", log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/users/:userId/entitlement", handler.GrantEntitlement)
+
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"tier":"pro"}`, http.StatusBadRequest},
+		{`{"tier":"pro","duration_days":0}`, http.StatusBadRequest},
+		{`{"tier":"pro","duration_days":30,"lifetime":true}`, http.StatusBadRequest},
+		{`{"tier":"pro","expires_at":"` + past + `"}`, http.StatusBadRequest},
+		{`{"tier":"plus","lifetime":true}`, http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/user-1/entitlement", strings.NewReader(tc.body)))
+		if w.Code != tc.want {
+			t.Errorf("GrantEntitlement(%s) = %d, want %d: %s", tc.body, w.Code, tc.want, w.Body.String())
+		}
+	}
+
+	if row := store.rows["user-1"]; row.SubscriptionExpiresAt.Time != lifetimeExpiry {
+		t.Errorf("lifetime grant expires at %v, want %v", row.SubscriptionExpiresAt.Time, lifetimeExpiry)
+	}
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// maxInvitePrefixLength bounds the prefix_length parameter; invite codes are shorter.
+const maxInvitePrefixLength = 32
+
+// GetInviteAnalytics handles GET /admin/v1/invites/analytics?days=N or ?from=YYYY-MM-DD&to=YYYY-MM-DD,
+// optionally with &prefix_length=N to group codes by campaign prefix.
+// Returns the funnel from redemption to first request to second-week retention of invite codes
+// redeemed in the range, joined with request logs. The range defaults to the last 30 completed
+// UTC days.
+func (h *Handler) GetInviteAnalytics(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	now := time.Now()
+	from, to, err := parseKPIRange(c.Query("from"), c.Query("to"), c.Query("days"), now)
+	if err != nil {
+		errors.BadRequest(c, err.Error(), nil)
+		return
+	}
+	prefixLength, err := parsePrefixLength(c.Query("prefix_length"))
+	if err != nil {
+		errors.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	rows, err := h.queries.GetInviteFunnel(ctx, pgdb.GetInviteFunnelParams{
+		PrefixLength: int32(prefixLength),
+		FromTime:     from,
+		ToTime:       to.AddDate(0, 0, 1),
+		AsOf:         now,
+	})
+	if err != nil {
+		log.Error("failed to get invite funnel", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to get invite analytics", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, InviteAnalyticsResponse{
+		From:         from.Format(time.DateOnly),
+		To:           to.Format(time.DateOnly),
+		PrefixLength: prefixLength,
+		Groups:       buildInviteFunnels(rows),
+	})
+}
+
+// parsePrefixLength parses the prefix_length parameter; empty means grouping by full code.
+func parsePrefixLength(param string) (int, error) {
+	if param == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 0 || n > maxInvitePrefixLength {
+		return 0, fmt.Errorf("invalid prefix_length %q (want 0-%d)", param, maxInvitePrefixLength)
+	}
+	return n, nil
+}
+
+// buildInviteFunnels converts funnel rows and computes their conversion rates.
+func buildInviteFunnels(rows []pgdb.GetInviteFunnelRow) []InviteFunnel {
+	funnels := make([]InviteFunnel, 0, len(rows))
+	for _, r := range rows {
+		funnel := InviteFunnel{
+			Code:              r.CodeGroup,
+			Redemptions:       r.Redemptions,
+			Activated:         r.Activated,
+			RetentionEligible: r.RetentionEligible,
+			Retained:          r.Retained,
+		}
+		if r.Redemptions > 0 {
+			funnel.ActivationRate = float64(r.Activated) / float64(r.Redemptions)
+		}
+		if r.RetentionEligible > 0 {
+			funnel.RetentionRate = float64(r.Retained) / float64(r.RetentionEligible)
+		}
+		funnels = append(funnels, funnel)
+	}
+	return funnels
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"testing"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+func TestParsePrefixLength(t *testing.T) {
+	tests := []struct {
+		param   string
+		want    int
+		wantErr bool
+	}{
+		{param: "", want: 0},
+		{param: "0", want: 0},
+		{param: "4", want: 4},
+		{param: "32", want: 32},
+		{param: "33", wantErr: true},
+		{param: "-1", wantErr: true},
+		{param: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			got, err := parsePrefixLength(tt.param)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePrefixLength(%q) error = %v, wantErr %v", tt.param, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parsePrefixLength(%q) = %d, want %d", tt.param, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInviteFunnels(t *testing.T) {
+	funnels := buildInviteFunnels([]pgdb.GetInviteFunnelRow{
+		{CodeGroup: "SPRING", Redemptions: 40, Activated: 30, RetentionEligible: 20, Retained: 5},
+		{CodeGroup: "NEW", Redemptions: 3, Activated: 0}, // Redeemed too recently to judge retention
+	})
+
+	if len(funnels) != 2 {
+		t.Fatalf("got %d funnels, want 2", len(funnels))
+	}
+
+	spring := funnels[0]
+	if spring.Code != "SPRING" || spring.ActivationRate != 0.75 || spring.RetentionRate != 0.25 {
+		t.Errorf("SPRING = %+v, want activation 0.75 and retention 0.25", spring)
+	}
+	if fresh := funnels[1]; fresh.ActivationRate != 0 || fresh.RetentionRate != 0 {
+		t.Errorf("NEW = %+v, want zero rates", fresh)
+	}
+
+	if got := buildInviteFunnels(nil); got == nil || len(got) != 0 {
+		t.Errorf("buildInviteFunnels(nil) = %#v, want an empty slice", got)
+	}
+}
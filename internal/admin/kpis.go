@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/rollups"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultKPIDays = 30
+	maxKPIDays     = 366
+)
+
+// GetKPIs handles GET /admin/v1/kpis?days=N or ?from=YYYY-MM-DD&to=YYYY-MM-DD
+// Returns DAU/WAU, tokens per tier, deep research adoption and provider mix from the nightly
+// usage rollups. The range defaults to the last 30 completed UTC days.
+func (h *Handler) GetKPIs(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	from, to, err := parseKPIRange(c.Query("from"), c.Query("to"), c.Query("days"), time.Now())
+	if err != nil {
+		errors.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	daily, err := h.queries.ListDailyUsageRollups(ctx, pgdb.ListDailyUsageRollupsParams{FromDay: from, ToDay: to})
+	if err != nil {
+		log.Error("failed to list usage rollups", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to get KPIs", nil)
+		return
+	}
+	tiers, err := h.queries.ListDailyTierUsageRollups(ctx, pgdb.ListDailyTierUsageRollupsParams{FromDay: from, ToDay: to})
+	if err != nil {
+		log.Error("failed to list tier usage rollups", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to get KPIs", nil)
+		return
+	}
+	providers, err := h.queries.ListDailyProviderUsageRollups(ctx, pgdb.ListDailyProviderUsageRollupsParams{FromDay: from, ToDay: to})
+	if err != nil {
+		log.Error("failed to list provider usage rollups", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to get KPIs", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, KPIsResponse{
+		From: from.Format(time.DateOnly),
+		To:   to.Format(time.DateOnly),
+		Days: buildDailyKPIs(daily, tiers, providers),
+	})
+}
+
+// parseKPIRange resolves the inclusive day range of a KPI request. An explicit from/to wins
+// over days; to defaults to yesterday, the last day the rollup worker has completed.
+func parseKPIRange(fromParam, toParam, daysParam string, now time.Time) (from, to time.Time, err error) {
+	to = rollups.Day(now).AddDate(0, 0, -1)
+	if toParam != "" {
+		if to, err = time.Parse(time.DateOnly, toParam); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date %q (want YYYY-MM-DD)", toParam)
+		}
+	}
+
+	if fromParam != "" {
+		if from, err = time.Parse(time.DateOnly, fromParam); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from date %q (want YYYY-MM-DD)", fromParam)
+		}
+	} else {
+		days := defaultKPIDays
+		if daysParam != "" {
+			if days, err = strconv.Atoi(daysParam); err != nil || days < 1 {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid days %q", daysParam)
+			}
+		}
+		if days > maxKPIDays {
+			return time.Time{}, time.Time{}, fmt.Errorf("days must be at most %d", maxKPIDays)
+		}
+		from = to.AddDate(0, 0, -(days - 1))
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= maxKPIDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must be at most %d days", maxKPIDays)
+	}
+	return from, to, nil
+}
+
+// buildDailyKPIs joins the per-day, per-tier and per-provider rollups. Tier and provider rows
+// of days without a daily rollup belong to an unfinished rollup and are dropped.
+func buildDailyKPIs(daily []pgdb.DailyUsageRollup, tiers []pgdb.DailyTierUsageRollup, providers []pgdb.DailyProviderUsageRollup) []DailyKPIs {
+	// DATE values are keyed by their calendar day, whatever zone they were scanned in.
+	index := make(map[string]int, len(daily))
+	days := make([]DailyKPIs, 0, len(daily))
+	for _, d := range daily {
+		kpis := DailyKPIs{
+			Day:               d.Day.Format(time.DateOnly),
+			ActiveUsers:       d.ActiveUsers,
+			WeeklyActiveUsers: d.WeeklyActiveUsers,
+			Requests:          d.Requests,
+			TotalTokens:       d.TotalTokens,
+			PlanTokens:        d.PlanTokens,
+			DeepResearchUsers: d.DeepResearchUsers,
+			DeepResearchRuns:  d.DeepResearchRuns,
+			Tiers:             []TierUsage{},
+			Providers:         []ProviderUsage{},
+		}
+		if d.ActiveUsers > 0 {
+			kpis.DeepResearchAdoption = float64(d.DeepResearchUsers) / float64(d.ActiveUsers)
+		}
+		index[kpis.Day] = len(days)
+		days = append(days, kpis)
+	}
+
+	for _, t := range tiers {
+		i, ok := index[t.Day.Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		days[i].Tiers = append(days[i].Tiers, TierUsage{
+			Tier:        t.Tier,
+			ActiveUsers: t.ActiveUsers,
+			Requests:    t.Requests,
+			TotalTokens: t.TotalTokens,
+			PlanTokens:  t.PlanTokens,
+		})
+	}
+
+	for _, p := range providers {
+		i, ok := index[p.Day.Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		usage := ProviderUsage{
+			Provider:    p.Provider,
+			Requests:    p.Requests,
+			TotalTokens: p.TotalTokens,
+			PlanTokens:  p.PlanTokens,
+		}
+		if days[i].Requests > 0 {
+			usage.RequestShare = float64(p.Requests) / float64(days[i].Requests)
+		}
+		days[i].Providers = append(days[i].Providers, usage)
+	}
+
+	return days
+}
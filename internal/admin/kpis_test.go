@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+func TestParseKPIRange(t *testing.T) {
+	now := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		days     string
+		wantFrom string
+		wantTo   string
+		wantErr  string
+	}{
+		{name: "defaults to last 30 completed days", wantFrom: "2026-02-13", wantTo: "2026-03-14"},
+		{name: "days", days: "7", wantFrom: "2026-03-08", wantTo: "2026-03-14"},
+		{name: "days ending at to", to: "2026-01-31", days: "1", wantFrom: "2026-01-31", wantTo: "2026-01-31"},
+		{name: "explicit range wins over days", from: "2026-01-01", to: "2026-01-10", days: "3", wantFrom: "2026-01-01", wantTo: "2026-01-10"},
+		{name: "invalid from", from: "01/01/2026", wantErr: "invalid from date"},
+		{name: "invalid to", to: "yesterday", wantErr: "invalid to date"},
+		{name: "invalid days", days: "0", wantErr: "invalid days"},
+		{name: "too many days", days: "400", wantErr: "at most 366"},
+		{name: "range too long", from: "2024-01-01", to: "2026-01-01", wantErr: "at most 366"},
+		{name: "from after to", from: "2026-02-01", to: "2026-01-01", wantErr: "must not be after"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parseKPIRange(tt.from, tt.to, tt.days, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseKPIRange() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseKPIRange() error = %v", err)
+			}
+			if got := from.Format(time.DateOnly); got != tt.wantFrom {
+				t.Errorf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := to.Format(time.DateOnly); got != tt.wantTo {
+				t.Errorf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestBuildDailyKPIs(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	unfinished := day1.AddDate(0, 0, 2)
+
+	daily := []pgdb.DailyUsageRollup{
+		{Day: day1, ActiveUsers: 4, WeeklyActiveUsers: 10, Requests: 100, DeepResearchUsers: 1},
+		{Day: day2},
+	}
+	tiers := []pgdb.DailyTierUsageRollup{
+		{Day: day1, Tier: "free", ActiveUsers: 3, Requests: 40},
+		{Day: day1, Tier: "pro", ActiveUsers: 1, Requests: 60, PlanTokens: 5000},
+		{Day: unfinished, Tier: "free", ActiveUsers: 9},
+	}
+	providers := []pgdb.DailyProviderUsageRollup{
+		{Day: day1, Provider: "OpenRouter", Requests: 75},
+		{Day: day1, Provider: "Tinfoil", Requests: 25},
+		{Day: unfinished, Provider: "OpenRouter", Requests: 1},
+	}
+
+	got := buildDailyKPIs(daily, tiers, providers)
+	if len(got) != 2 {
+		t.Fatalf("len(days) = %d, want 2", len(got))
+	}
+
+	first := got[0]
+	if first.Day != "2026-03-01" || first.DeepResearchAdoption != 0.25 {
+		t.Errorf("day 1 = %s adoption %v, want 2026-03-01 adoption 0.25", first.Day, first.DeepResearchAdoption)
+	}
+	if len(first.Tiers) != 2 || first.Tiers[1].PlanTokens != 5000 {
+		t.Errorf("day 1 tiers = %+v", first.Tiers)
+	}
+	if len(first.Providers) != 2 || first.Providers[0].RequestShare != 0.75 || first.Providers[1].RequestShare != 0.25 {
+		t.Errorf("day 1 providers = %+v", first.Providers)
+	}
+
+	second := got[1]
+	if second.DeepResearchAdoption != 0 || second.Tiers == nil || len(second.Tiers) != 0 || len(second.Providers) != 0 {
+		t.Errorf("day 2 = %+v, want empty day with no adoption", second)
+	}
+}
@@ -0,0 +1,234 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/bans"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/routingconfig"
+	"github.com/eternisai/enchanted-proxy/internal/tinfoil"
+	"github.com/eternisai/enchanted-proxy/internal/upstreams"
+)
+
+// ProviderAdmin is the subscription provider recorded for entitlements granted via the admin API.
+const ProviderAdmin = "admin"
+
+// lifetimeExpiry is the expiry used for lifetime grants (matches other lifetime products).
+var lifetimeExpiry = time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// GrantEntitlementRequest is the request body for POST /admin/users/:userId/entitlement.
+type GrantEntitlementRequest struct {
+	Tier string `json:"tier" binding:"required"`
+	// Exactly one of DurationDays, ExpiresAt and Lifetime must be set.
+	// DurationDays extends an active same-tier entitlement, otherwise starts from now.
+	DurationDays int32 `json:"duration_days"`
+	// ExpiresAt sets the expiry, which must be in the future.
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Lifetime grants the tier without expiry.
+	Lifetime bool `json:"lifetime"`
+}
+
+// EntitlementResponse describes a user's entitlement after a grant.
+type EntitlementResponse struct {
+	UserID               string     `json:"user_id"`
+	Tier                 string     `json:"tier"`
+	SubscriptionProvider string     `json:"subscription_provider"`
+	ExpiresAt            *time.Time `json:"expires_at,omitempty"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// UserResponse is the response for GET /admin/users/:userId.
+type UserResponse struct {
+	UserID string `json:"user_id"`
+	// Tier is the tier in effect: free without an entitlement or once it expired.
+	Tier        string               `json:"tier"`
+	Entitlement *EntitlementResponse `json:"entitlement,omitempty"` // Omitted without an entitlement record
+	Ban         *bans.Ban            `json:"ban,omitempty"`         // Omitted unless banned
+}
+
+// Deep research counters reset by POST /admin/users/:userId/deep-research/reset.
+const (
+	ResetScopeDaily    = "daily"
+	ResetScopeLifetime = "lifetime"
+)
+
+// ResetDeepResearchRequest is the request body for POST /admin/users/:userId/deep-research/reset.
+type ResetDeepResearchRequest struct {
+	// Scope is "daily" (today's runs, the default) or "lifetime" (all runs).
+	Scope string `json:"scope"`
+}
+
+// ResetDeepResearchResponse reports how many completed runs no longer count against limits.
+type ResetDeepResearchResponse struct {
+	UserID    string `json:"user_id"`
+	Scope     string `json:"scope"`
+	RunsReset int64  `json:"runs_reset"`
+}
+
+// BanUserRequest is the request body for PUT /admin/users/:userId/ban.
+type BanUserRequest struct {
+	Reason string `json:"reason"` // Recorded for operators, not shown to the user
+}
+
+// StopStreamResponse is the response for POST /admin/streams/:chatId/:messageId/stop.
+type StopStreamResponse struct {
+	Stopped         bool   `json:"stopped"`
+	ChatID          string `json:"chat_id"`
+	MessageID       string `json:"message_id"`
+	ChunksGenerated int    `json:"chunks_generated"`
+	RemoteInstance  string `json:"remote_instance,omitempty"`
+}
+
+// FlushQueuesResponse reports how many queued items were written per queue.
+type FlushQueuesResponse struct {
+	Flushed   map[string]int `json:"flushed"`
+	Remaining map[string]int `json:"remaining"`
+}
+
+// ReloadRoutingResponse is the response for POST /admin/routing/reload.
+type ReloadRoutingResponse struct {
+	ConfigFile string `json:"config_file"`
+	RouteCount int    `json:"route_count"`
+}
+
+// ProviderStatusResponse is the response for GET /admin/providers/status.
+type ProviderStatusResponse struct {
+	Endpoints    []EndpointStatus `json:"endpoints"`
+	Attestations []tinfoil.Status `json:"attestations,omitempty"` // Enclave providers' attestation
+}
+
+// EndpointStatus describes a model endpoint's routing state and its recent streaming latency
+// on the instance that served the request.
+type EndpointStatus struct {
+	Model            string                     `json:"model"`
+	Provider         string                     `json:"provider"`
+	Active           bool                       `json:"active"`
+	TimeToFirstToken metrics.LatencyPercentiles `json:"time_to_first_token"`
+	InterChunk       metrics.LatencyPercentiles `json:"inter_chunk"`
+}
+
+// KPIsResponse is the response for GET /admin/v1/kpis. Days that have not been rolled up yet
+// are omitted.
+type KPIsResponse struct {
+	From string      `json:"from"`
+	To   string      `json:"to"`
+	Days []DailyKPIs `json:"days"`
+}
+
+// DailyKPIs are the rolled-up usage KPIs of one UTC day.
+type DailyKPIs struct {
+	Day               string `json:"day"`
+	ActiveUsers       int64  `json:"active_users"`
+	WeeklyActiveUsers int64  `json:"weekly_active_users"` // Distinct users over the 7 days ending on day
+	Requests          int64  `json:"requests"`
+	TotalTokens       int64  `json:"total_tokens"`
+	PlanTokens        int64  `json:"plan_tokens"`
+	DeepResearchUsers int64  `json:"deep_research_users"`
+	DeepResearchRuns  int64  `json:"deep_research_runs"`
+	// DeepResearchAdoption is the share of active users who started a deep research run.
+	DeepResearchAdoption float64         `json:"deep_research_adoption"`
+	Tiers                []TierUsage     `json:"tiers"`
+	Providers            []ProviderUsage `json:"providers"`
+}
+
+// TierUsage is one day's usage by users of a subscription tier.
+type TierUsage struct {
+	Tier        string `json:"tier"`
+	ActiveUsers int64  `json:"active_users"`
+	Requests    int64  `json:"requests"`
+	TotalTokens int64  `json:"total_tokens"`
+	PlanTokens  int64  `json:"plan_tokens"`
+}
+
+// ProviderUsage is one day's traffic to an upstream provider.
+type ProviderUsage struct {
+	Provider     string  `json:"provider"`
+	Requests     int64   `json:"requests"`
+	RequestShare float64 `json:"request_share"`
+	TotalTokens  int64   `json:"total_tokens"`
+	PlanTokens   int64   `json:"plan_tokens"`
+}
+
+// UpstreamResponse describes an allowed upstream base URL.
+type UpstreamResponse struct {
+	upstreams.Upstream
+	// KeyConfigured is true if the referenced API key is set on the instance that served the
+	// request (false without a key reference).
+	KeyConfigured bool `json:"key_configured"`
+}
+
+// UpstreamsResponse is the response for GET /admin/upstreams.
+type UpstreamsResponse struct {
+	Upstreams []UpstreamResponse `json:"upstreams"`
+}
+
+// RoutingEntriesResponse is the response for GET /admin/routing/entries.
+type RoutingEntriesResponse struct {
+	Entries []routingconfig.Entry `json:"entries"`
+}
+
+// InviteAnalyticsResponse is the response for GET /admin/v1/invites/analytics.
+type InviteAnalyticsResponse struct {
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	PrefixLength int            `json:"prefix_length"` // 0 = grouped by full code
+	Groups       []InviteFunnel `json:"groups"`
+}
+
+// InviteFunnel is the redemption → activation → retention funnel of the invite codes in a
+// group (a code or a code prefix) redeemed in the range.
+type InviteFunnel struct {
+	Code        string `json:"code"`
+	Redemptions int64  `json:"redemptions"`
+	// Activated is the number of redeemers who sent a request after redeeming.
+	Activated      int64   `json:"activated"`
+	ActivationRate float64 `json:"activation_rate"`
+	// RetentionEligible is the number of redeemers whose second week after redeeming is over;
+	// Retained is how many of them sent a request in that week.
+	RetentionEligible int64   `json:"retention_eligible"`
+	Retained          int64   `json:"retained"`
+	RetentionRate     float64 `json:"retention_rate"`
+}
+
+// ConfigResponse is the response for GET /admin/v1/config.
+type ConfigResponse struct {
+	Profile    string           `json:"profile,omitempty"`
+	ConfigFile string           `json:"config_file"`
+	Settings   []config.Setting `json:"settings"` // Sorted by key; secrets redacted
+}
+
+// ReloadConfigResponse is the response for POST /admin/v1/config/reload.
+type ReloadConfigResponse struct {
+	Changes []config.Change `json:"changes"`
+}
+
+// CreateAPIKeyRequest is the request body for POST /admin/api-keys.
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"` // proxy, search, admin
+	// UserID is the user requests of the key act as (rate limits, usage); defaults to
+	// "service:<name>".
+	UserID      string     `json:"user_id"`
+	Description string     `json:"description"`
+	ExpiresAt   *time.Time `json:"expires_at"` // Never expires when omitted
+}
+
+// APIKeyResponse describes a service API key. The key itself is only returned on creation.
+type APIKeyResponse struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Key         string     `json:"key,omitempty"`
+	KeyPrefix   string     `json:"key_prefix"`
+	Scopes      []string   `json:"scopes"`
+	UserID      string     `json:"user_id"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeysResponse is the response for GET /admin/api-keys.
+type APIKeysResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
@@ -0,0 +1,133 @@
+package admin
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/routingconfig"
+	"github.com/gin-gonic/gin"
+)
+
+// ListRoutingEntries handles GET /admin/routing/entries
+// Lists the provider and model routing entries applied on top of config.yaml.
+func (h *Handler) ListRoutingEntries(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	if h.routingEntries == nil {
+		errors.NotFound(c, "routing entries are not enabled", nil)
+		return
+	}
+
+	entries, err := h.routingEntries.List(ctx)
+	if err != nil {
+		log.Error("failed to list routing entries", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to list routing entries", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, RoutingEntriesResponse{Entries: entries})
+}
+
+// PutRoutingProvider handles PUT /admin/routing/providers/*name
+// Adds or replaces a provider (a providers entry of config.yaml), or disables it.
+func (h *Handler) PutRoutingProvider(c *gin.Context) {
+	h.putRoutingEntry(c, routingconfig.KindProvider)
+}
+
+// PutRoutingModel handles PUT /admin/routing/models/*name
+// Adds or replaces a model (a models entry of config.yaml), or disables it.
+func (h *Handler) PutRoutingModel(c *gin.Context) {
+	h.putRoutingEntry(c, routingconfig.KindModel)
+}
+
+// DeleteRoutingProvider handles DELETE /admin/routing/providers/*name
+// Removes a provider entry, restoring the provider of config.yaml (if any).
+func (h *Handler) DeleteRoutingProvider(c *gin.Context) {
+	h.deleteRoutingEntry(c, routingconfig.KindProvider)
+}
+
+// DeleteRoutingModel handles DELETE /admin/routing/models/*name
+// Removes a model entry, restoring the model of config.yaml (if any).
+func (h *Handler) DeleteRoutingModel(c *gin.Context) {
+	h.deleteRoutingEntry(c, routingconfig.KindModel)
+}
+
+// putRoutingEntry saves an entry. Applied immediately on this instance, and on the others
+// within the refresh interval.
+func (h *Handler) putRoutingEntry(c *gin.Context, kind string) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	if h.routingEntries == nil {
+		errors.NotFound(c, "routing entries are not enabled", nil)
+		return
+	}
+
+	// Model names contain slashes, so names are matched as wildcards
+	name := strings.TrimPrefix(c.Param("name"), "/")
+	if name == "" {
+		errors.BadRequest(c, "name is required", nil)
+		return
+	}
+
+	var req routingconfig.PutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	entry, err := h.routingEntries.Put(ctx, kind, name, &req)
+	if err != nil {
+		h.routingEntryError(c, err, "failed to save routing entry")
+		return
+	}
+
+	log.Info("routing entry saved via admin API",
+		slog.String("kind", entry.Kind),
+		slog.String("name", entry.Name),
+		slog.Bool("enabled", entry.Enabled),
+		slog.Int("route_count", len(h.modelRouter.GetRoutes())))
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// deleteRoutingEntry removes an entry.
+func (h *Handler) deleteRoutingEntry(c *gin.Context, kind string) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	if h.routingEntries == nil {
+		errors.NotFound(c, "routing entries are not enabled", nil)
+		return
+	}
+
+	name := strings.TrimPrefix(c.Param("name"), "/")
+	if err := h.routingEntries.Delete(ctx, kind, name); err != nil {
+		h.routingEntryError(c, err, "failed to delete routing entry")
+		return
+	}
+
+	log.Info("routing entry deleted via admin API",
+		slog.String("kind", kind),
+		slog.String("name", name))
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "kind": kind, "name": name})
+}
+
+// routingEntryError maps routing config service errors to responses.
+func (h *Handler) routingEntryError(c *gin.Context, err error, message string) {
+	switch {
+	case stderrors.Is(err, routingconfig.ErrNotFound):
+		errors.NotFound(c, err.Error(), nil)
+	case stderrors.Is(err, routingconfig.ErrInvalidConfig), stderrors.Is(err, routingconfig.ErrEnclave):
+		errors.BadRequest(c, err.Error(), nil)
+	default:
+		h.logger.WithContext(c.Request.Context()).WithComponent("admin-handler").Error(message,
+			slog.String("error", err.Error()))
+		errors.Internal(c, message, nil)
+	}
+}
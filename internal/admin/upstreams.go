@@ -0,0 +1,152 @@
+package admin
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/upstreams"
+	"github.com/gin-gonic/gin"
+)
+
+// ListUpstreams handles GET /admin/upstreams
+// Lists the allowed upstream base URLs, with whether each referenced key is set on this instance.
+func (h *Handler) ListUpstreams(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	if h.upstreams == nil {
+		errors.NotFound(c, "upstream allowlist is not enabled", nil)
+		return
+	}
+
+	list, err := h.upstreams.List(ctx)
+	if err != nil {
+		log.Error("failed to list upstreams", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to list upstreams", nil)
+		return
+	}
+
+	response := UpstreamsResponse{Upstreams: make([]UpstreamResponse, 0, len(list))}
+	for i := range list {
+		response.Upstreams = append(response.Upstreams, toUpstreamResponse(&list[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateUpstream handles POST /admin/upstreams
+// Allows a new upstream base URL. Applied immediately on this instance, and on the others
+// within the refresh interval.
+func (h *Handler) CreateUpstream(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	if h.upstreams == nil {
+		errors.NotFound(c, "upstream allowlist is not enabled", nil)
+		return
+	}
+
+	var req upstreams.CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	upstream, err := h.upstreams.Create(ctx, &req)
+	if err != nil {
+		h.upstreamError(c, err, "failed to create upstream")
+		return
+	}
+
+	log.Info("upstream created via admin API",
+		slog.Int64("id", upstream.ID),
+		slog.String("base_url", upstream.BaseURL),
+		slog.Bool("enabled", upstream.Enabled))
+
+	c.JSON(http.StatusCreated, toUpstreamResponse(upstream))
+}
+
+// UpdateUpstream handles PATCH /admin/upstreams/:id
+// Changes an upstream's key reference, enabled flag or description.
+func (h *Handler) UpdateUpstream(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	if h.upstreams == nil {
+		errors.NotFound(c, "upstream allowlist is not enabled", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errors.BadRequest(c, "invalid upstream id", nil)
+		return
+	}
+
+	var req upstreams.UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	upstream, err := h.upstreams.Update(ctx, id, &req)
+	if err != nil {
+		h.upstreamError(c, err, "failed to update upstream")
+		return
+	}
+
+	log.Info("upstream updated via admin API",
+		slog.Int64("id", upstream.ID),
+		slog.String("base_url", upstream.BaseURL),
+		slog.Bool("enabled", upstream.Enabled))
+
+	c.JSON(http.StatusOK, toUpstreamResponse(upstream))
+}
+
+// DeleteUpstream handles DELETE /admin/upstreams/:id
+func (h *Handler) DeleteUpstream(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+
+	if h.upstreams == nil {
+		errors.NotFound(c, "upstream allowlist is not enabled", nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errors.BadRequest(c, "invalid upstream id", nil)
+		return
+	}
+
+	if err := h.upstreams.Delete(ctx, id); err != nil {
+		h.upstreamError(c, err, "failed to delete upstream")
+		return
+	}
+
+	log.Info("upstream deleted via admin API", slog.Int64("id", id))
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
+
+// upstreamError maps upstream service errors to responses.
+func (h *Handler) upstreamError(c *gin.Context, err error, message string) {
+	switch {
+	case stderrors.Is(err, upstreams.ErrNotFound):
+		errors.NotFound(c, err.Error(), nil)
+	case stderrors.Is(err, upstreams.ErrDuplicate), stderrors.Is(err, upstreams.ErrLastUpstream):
+		errors.Conflict(c, err.Error(), nil)
+	case stderrors.Is(err, upstreams.ErrInvalidBaseURL), stderrors.Is(err, upstreams.ErrInvalidKeyReference):
+		errors.BadRequest(c, err.Error(), nil)
+	default:
+		h.logger.WithContext(c.Request.Context()).WithComponent("admin-handler").Error(message,
+			slog.String("error", err.Error()))
+		errors.Internal(c, message, nil)
+	}
+}
+
+func toUpstreamResponse(upstream *upstreams.Upstream) UpstreamResponse {
+	return UpstreamResponse{Upstream: *upstream, KeyConfigured: upstream.KeyConfigured()}
+}
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"database/sql"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/bans"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/revocation"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// GetUser handles GET /admin/users/:userId
+// Returns a user's tier in effect, entitlement record and ban.
+func (h *Handler) GetUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+	userID := c.Param("userId")
+
+	tier, _, err := h.trackingService.GetUserTier(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user tier",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to get user tier", nil)
+		return
+	}
+	response := UserResponse{UserID: userID, Tier: string(tier)}
+
+	entitlement, err := h.queries.GetEntitlement(ctx, userID)
+	switch {
+	case err == nil:
+		response.Entitlement = entitlementResponse(entitlement)
+	case !stderrors.Is(err, sql.ErrNoRows):
+		log.Error("failed to get entitlement",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to get entitlement", nil)
+		return
+	}
+
+	if h.bans != nil {
+		if response.Ban, err = h.bans.Get(ctx, userID); err != nil {
+			log.Error("failed to get ban",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID))
+			errors.Internal(c, "failed to get ban", nil)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetDeepResearch handles POST /admin/users/:userId/deep-research/reset
+// Stops counting the user's completed deep research runs of today, or of all time, against
+// the daily and lifetime limits.
+func (h *Handler) ResetDeepResearch(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+	userID := c.Param("userId")
+
+	var req ResetDeepResearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !stderrors.Is(err, io.EOF) {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = ResetScopeDaily
+	}
+	if req.Scope != ResetScopeDaily && req.Scope != ResetScopeLifetime {
+		errors.BadRequest(c, "scope must be daily or lifetime", nil)
+		return
+	}
+
+	reset, err := h.queries.ResetDeepResearchRuns(ctx, pgdb.ResetDeepResearchRunsParams{
+		UserID:   userID,
+		Lifetime: req.Scope == ResetScopeLifetime,
+	})
+	if err != nil {
+		log.Error("failed to reset deep research runs",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to reset deep research runs", nil)
+		return
+	}
+
+	log.Info("deep research runs reset via admin API",
+		slog.String("user_id", userID),
+		slog.String("scope", req.Scope),
+		slog.Int64("runs_reset", reset))
+
+	c.JSON(http.StatusOK, ResetDeepResearchResponse{UserID: userID, Scope: req.Scope, RunsReset: reset})
+}
+
+// BanUser handles PUT /admin/users/:userId/ban
+// Bans a user from the API (every authenticated route answers 403 account_banned).
+func (h *Handler) BanUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+	userID := c.Param("userId")
+
+	var req BanUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !stderrors.Is(err, io.EOF) {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	ban, err := h.bans.Ban(ctx, userID, req.Reason)
+	if err != nil {
+		log.Error("failed to ban user",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to ban user", nil)
+		return
+	}
+
+	// The ban rejects new requests; stop the streams and deep research runs already in flight
+	h.revoker.Revoke(userID, revocation.ReasonBanned)
+
+	log.Warn("user banned via admin API",
+		slog.String("user_id", userID),
+		slog.String("reason", req.Reason))
+
+	c.JSON(http.StatusOK, ban)
+}
+
+// UnbanUser handles DELETE /admin/users/:userId/ban
+// Lifts a user's ban.
+func (h *Handler) UnbanUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := h.logger.WithContext(ctx).WithComponent("admin-handler")
+	userID := c.Param("userId")
+
+	if err := h.bans.Unban(ctx, userID); err != nil {
+		if stderrors.Is(err, bans.ErrNotBanned) {
+			errors.NotFound(c, err.Error(), map[string]interface{}{"user_id": userID})
+			return
+		}
+		log.Error("failed to unban user",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to unban user", nil)
+		return
+	}
+
+	log.Info("user unbanned via admin API", slog.String("user_id", userID))
+
+	c.JSON(http.StatusOK, gin.H{"unbanned": true, "user_id": userID})
+}
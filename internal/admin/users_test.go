@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/bans"
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/revocation"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// banStore stores bans in memory; other Querier methods are not used.
+type banStore struct {
+	pgdb.Querier
+	rows map[string]pgdb.UserBan
+}
+
+func (s *banStore) BanUser(_ context.Context, arg pgdb.BanUserParams) (pgdb.UserBan, error) {
+	row := pgdb.UserBan{UserID: arg.UserID, Reason: arg.Reason, BannedAt: time.Now()}
+	s.rows[arg.UserID] = row
+	return row, nil
+}
+
+// userSessions is a revocation target with one session per active user.
+type userSessions struct {
+	active map[string]time.Time
+}
+
+func (s *userSessions) StopUserSessions(userID string) int {
+	if _, ok := s.active[userID]; !ok {
+		return 0
+	}
+	delete(s.active, userID)
+	return 1
+}
+
+func (s *userSessions) ActiveUsers() map[string]time.Time {
+	return s.active
+}
+
+func TestBanUserStopsSessions(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	store := &banStore{rows: map[string]pgdb.UserBan{}}
+	sessions := &userSessions{active: map[string]time.Time{"user-1": time.Now(), "user-2": time.Now()}}
+
+	handler := NewHandler(store, nil, nil, nil, nil, nil, nil, nil,
+		bans.NewService(store, cache.New(cache.NewMemory(100), log), log), nil, "", log)
+	handler.SetRevoker(revocation.NewService(nil, nil, 0, log, sessions))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PUT("/admin/users/:userId/ban", handler.BanUser)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/admin/users/user-1/ban", strings.NewReader(`{"reason":"abuse"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("BanUser = %d: %s", w.Code, w.Body.String())
+	}
+
+	if _, ok := store.rows["user-1"]; !ok {
+		t.Error("user-1 should be banned")
+	}
+	if _, ok := sessions.active["user-1"]; ok {
+		t.Error("the sessions of user-1 should be stopped")
+	}
+	if _, ok := sessions.active["user-2"]; !ok {
+		t.Error("the sessions of user-2 should keep running")
+	}
+}
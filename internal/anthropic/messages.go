@@ -0,0 +1,524 @@
+// Package anthropic translates between the OpenAI Chat Completions format spoken by clients and
+// the rest of the proxy and Anthropic's Messages API (POST /v1/messages), so Claude models can
+// be routed like any other provider. Requests are translated on the way out; responses, errors
+// and SSE streams on the way back.
+package anthropic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// MessagesPath is the Messages API endpoint relative to the provider base URL
+	// (e.g. https://api.anthropic.com/v1).
+	MessagesPath = "/messages"
+
+	// Version is the API version sent in the anthropic-version header.
+	Version = "2023-06-01"
+
+	// DefaultMaxTokens is the output limit of requests without max_tokens, which the Messages
+	// API requires.
+	DefaultMaxTokens = 4096
+)
+
+// SetHeaders replaces bearer authentication with the API key and version headers of the
+// Messages API.
+func SetHeaders(h http.Header, apiKey string) {
+	h.Del("Authorization")
+	h.Set("x-api-key", apiKey)
+	h.Set("anthropic-version", Version)
+	h.Set("Content-Type", "application/json")
+}
+
+// Chat Completions request (the fields that have a Messages API equivalent).
+type chatRequest struct {
+	Model               string          `json:"model"`
+	Messages            []chatMessage   `json:"messages"`
+	MaxTokens           *int            `json:"max_tokens"`
+	MaxCompletionTokens *int            `json:"max_completion_tokens"`
+	Temperature         *float64        `json:"temperature"`
+	TopP                *float64        `json:"top_p"`
+	Stop                json.RawMessage `json:"stop"`
+	Stream              bool            `json:"stream"`
+	Tools               []chatTool      `json:"tools"`
+	ToolChoice          json.RawMessage `json:"tool_choice"`
+	ParallelToolCalls   *bool           `json:"parallel_tool_calls"`
+	User                string          `json:"user"`
+}
+
+type chatMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCalls  []chatToolCall  `json:"tool_calls"`
+	ToolCallID string          `json:"tool_call_id"`
+}
+
+type chatContentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	ImageURL struct {
+		URL string `json:"url"`
+	} `json:"image_url"`
+}
+
+type chatToolCall struct {
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function chatFunction `json:"function"`
+}
+
+type chatFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type chatTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+// Messages API request.
+type messagesRequest struct {
+	Model         string      `json:"model"`
+	System        string      `json:"system,omitempty"`
+	Messages      []message   `json:"messages"`
+	MaxTokens     int         `json:"max_tokens"`
+	Temperature   *float64    `json:"temperature,omitempty"`
+	TopP          *float64    `json:"top_p,omitempty"`
+	StopSequences []string    `json:"stop_sequences,omitempty"`
+	Stream        bool        `json:"stream,omitempty"`
+	Tools         []tool      `json:"tools,omitempty"`
+	ToolChoice    *toolChoice `json:"tool_choice,omitempty"`
+	Metadata      *metadata   `json:"metadata,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is a content block of a request or response message.
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	Source    *imageSource    `json:"source,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type imageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type toolChoice struct {
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+type metadata struct {
+	UserID string `json:"user_id"`
+}
+
+// Messages API response.
+type messagesResponse struct {
+	ID         string         `json:"id"`
+	Model      string         `json:"model"`
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      usage          `json:"usage"`
+}
+
+type usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+type apiError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Chat Completions response and stream chunk.
+type chatCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+	Usage   *chatUsage   `json:"usage,omitempty"`
+}
+
+type chatChoice struct {
+	Index        int         `json:"index"`
+	Message      *chatOutput `json:"message,omitempty"`
+	Delta        *chatOutput `json:"delta,omitempty"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+type chatOutput struct {
+	Role      string         `json:"role,omitempty"`
+	Content   *string        `json:"content,omitempty"`
+	Reasoning string         `json:"reasoning,omitempty"`
+	ToolCalls []chatToolCall `json:"tool_calls,omitempty"`
+}
+
+type chatUsage struct {
+	PromptTokens        int                 `json:"prompt_tokens"`
+	CompletionTokens    int                 `json:"completion_tokens"`
+	TotalTokens         int                 `json:"total_tokens"`
+	PromptTokensDetails *promptTokenDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+type promptTokenDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// FromChatCompletion translates a Chat Completions request body to a Messages API request.
+// System and developer messages become the system prompt, tool calls and results become
+// tool_use and tool_result blocks, and consecutive messages of the same role are merged.
+func FromChatCompletion(body []byte) ([]byte, error) {
+	var req chatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("decode chat completion request: %w", err)
+	}
+
+	out := messagesRequest{
+		Model:       req.Model,
+		MaxTokens:   DefaultMaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stream:      req.Stream,
+	}
+	switch {
+	case req.MaxCompletionTokens != nil && *req.MaxCompletionTokens > 0:
+		out.MaxTokens = *req.MaxCompletionTokens
+	case req.MaxTokens != nil && *req.MaxTokens > 0:
+		out.MaxTokens = *req.MaxTokens
+	}
+	if req.User != "" {
+		out.Metadata = &metadata{UserID: req.User}
+	}
+
+	var err error
+	if out.StopSequences, err = stopSequences(req.Stop); err != nil {
+		return nil, err
+	}
+
+	var system []string
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			text, err := textContent(msg.Content)
+			if err != nil {
+				return nil, err
+			}
+			if text != "" {
+				system = append(system, text)
+			}
+		case "user":
+			blocks, err := contentBlocks(msg.Content)
+			if err != nil {
+				return nil, err
+			}
+			out.Messages = appendMessage(out.Messages, "user", blocks)
+		case "assistant":
+			blocks, err := contentBlocks(msg.Content)
+			if err != nil {
+				return nil, err
+			}
+			for _, call := range msg.ToolCalls {
+				input := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(input) || strings.TrimSpace(call.Function.Arguments) == "" {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, contentBlock{Type: "tool_use", ID: call.ID, Name: call.Function.Name, Input: input})
+			}
+			out.Messages = appendMessage(out.Messages, "assistant", blocks)
+		case "tool":
+			text, err := textContent(msg.Content)
+			if err != nil {
+				return nil, err
+			}
+			out.Messages = appendMessage(out.Messages, "user", []contentBlock{{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: text}})
+		default:
+			return nil, fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+	}
+	if len(out.Messages) == 0 {
+		return nil, fmt.Errorf("request has no user or assistant messages")
+	}
+	out.System = strings.Join(system, "\n\n")
+
+	for _, t := range req.Tools {
+		if t.Type != "" && t.Type != "function" {
+			continue
+		}
+		schema := t.Function.Parameters
+		if len(schema) == 0 || string(schema) == "null" {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		out.Tools = append(out.Tools, tool{Name: t.Function.Name, Description: t.Function.Description, InputSchema: schema})
+	}
+	if out.ToolChoice, err = translateToolChoice(req.ToolChoice); err != nil {
+		return nil, err
+	}
+	if req.ParallelToolCalls != nil && !*req.ParallelToolCalls && len(out.Tools) > 0 {
+		if out.ToolChoice == nil {
+			out.ToolChoice = &toolChoice{Type: "auto"}
+		}
+		if out.ToolChoice.Type != "none" {
+			out.ToolChoice.DisableParallelToolUse = true
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// appendMessage adds content blocks as a message of role, merged into the last message if it
+// has the same role. Empty messages are dropped.
+func appendMessage(messages []message, role string, blocks []contentBlock) []message {
+	if len(blocks) == 0 {
+		return messages
+	}
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		messages[n-1].Content = append(messages[n-1].Content, blocks...)
+		return messages
+	}
+	return append(messages, message{Role: role, Content: blocks})
+}
+
+// contentBlocks translates message content (a string or an array of text and image parts).
+func contentBlocks(content json.RawMessage) ([]contentBlock, error) {
+	if len(content) == 0 || string(content) == "null" {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		if text == "" {
+			return nil, nil
+		}
+		return []contentBlock{{Type: "text", Text: text}}, nil
+	}
+
+	var parts []chatContentPart
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return nil, fmt.Errorf("decode message content: %w", err)
+	}
+	var blocks []contentBlock
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			if part.Text != "" {
+				blocks = append(blocks, contentBlock{Type: "text", Text: part.Text})
+			}
+		case "image_url":
+			source, err := imageSourceFromURL(part.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, contentBlock{Type: "image", Source: source})
+		default:
+			return nil, fmt.Errorf("unsupported content part type %q", part.Type)
+		}
+	}
+	return blocks, nil
+}
+
+// textContent returns the text of message content, joining text parts.
+func textContent(content json.RawMessage) (string, error) {
+	blocks, err := contentBlocks(content)
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Type != "text" {
+			return "", fmt.Errorf("unsupported %s content in system or tool message", block.Type)
+		}
+		texts = append(texts, block.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// imageSourceFromURL translates an image URL; data URLs become base64 sources.
+func imageSourceFromURL(url string) (*imageSource, error) {
+	if !strings.HasPrefix(url, "data:") {
+		if url == "" {
+			return nil, fmt.Errorf("image part without url")
+		}
+		return &imageSource{Type: "url", URL: url}, nil
+	}
+
+	header, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	mediaType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !ok || !isBase64 || mediaType == "" {
+		return nil, fmt.Errorf("unsupported image data URL (want data:<media type>;base64,...)")
+	}
+	return &imageSource{Type: "base64", MediaType: mediaType, Data: data}, nil
+}
+
+// stopSequences translates stop, a string or an array of strings.
+func stopSequences(stop json.RawMessage) ([]string, error) {
+	if len(stop) == 0 || string(stop) == "null" {
+		return nil, nil
+	}
+	var single string
+	if err := json.Unmarshal(stop, &single); err == nil {
+		return []string{single}, nil
+	}
+	var sequences []string
+	if err := json.Unmarshal(stop, &sequences); err != nil {
+		return nil, fmt.Errorf("stop must be a string or an array of strings")
+	}
+	return sequences, nil
+}
+
+// translateToolChoice translates tool_choice: "auto", "none", "required" or a named function.
+func translateToolChoice(choice json.RawMessage) (*toolChoice, error) {
+	if len(choice) == 0 || string(choice) == "null" {
+		return nil, nil
+	}
+
+	var mode string
+	if err := json.Unmarshal(choice, &mode); err == nil {
+		switch mode {
+		case "auto":
+			return &toolChoice{Type: "auto"}, nil
+		case "none":
+			return &toolChoice{Type: "none"}, nil
+		case "required":
+			return &toolChoice{Type: "any"}, nil
+		}
+		return nil, fmt.Errorf("unsupported tool_choice %q", mode)
+	}
+
+	var named struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(choice, &named); err != nil || named.Function.Name == "" {
+		return nil, fmt.Errorf("unsupported tool_choice %s", choice)
+	}
+	return &toolChoice{Type: "tool", Name: named.Function.Name}, nil
+}
+
+// ToChatCompletion translates a Messages API response body to a Chat Completions response.
+// Thinking blocks become the reasoning field of the message.
+func ToChatCompletion(body []byte) ([]byte, error) {
+	var resp messagesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decode messages response: %w", err)
+	}
+
+	var text, reasoning strings.Builder
+	var toolCalls []chatToolCall
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "thinking":
+			reasoning.WriteString(block.Thinking)
+		case "tool_use":
+			input := "{}"
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, block.Input); err == nil {
+				input = compact.String()
+			}
+			toolCalls = append(toolCalls, chatToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: chatFunction{Name: block.Name, Arguments: input},
+			})
+		}
+	}
+
+	output := &chatOutput{Role: "assistant", Reasoning: reasoning.String(), ToolCalls: toolCalls}
+	if content := text.String(); content != "" || len(toolCalls) == 0 {
+		output.Content = &content
+	}
+	finishReason := FinishReason(resp.StopReason)
+
+	return json.Marshal(chatCompletion{
+		ID:      resp.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   resp.Model,
+		Choices: []chatChoice{{Message: output, FinishReason: &finishReason}},
+		Usage:   chatUsageFrom(resp.Usage),
+	})
+}
+
+// ToChatCompletionError translates a Messages API error body to the OpenAI error envelope.
+// Bodies that aren't Messages API errors are returned unchanged.
+func ToChatCompletionError(body []byte) []byte {
+	var resp struct {
+		Type  string    `json:"type"`
+		Error *apiError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Type != "error" || resp.Error == nil {
+		return body
+	}
+	translated, err := json.Marshal(map[string]interface{}{
+		"error": map[string]string{"message": resp.Error.Message, "type": resp.Error.Type},
+	})
+	if err != nil {
+		return body
+	}
+	return translated
+}
+
+// FinishReason maps a Messages API stop reason to a Chat Completions finish reason.
+func FinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens", "model_context_window_exceeded":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default: // end_turn, stop_sequence, pause_turn
+		return "stop"
+	}
+}
+
+// chatUsageFrom converts token usage; cache reads and writes count as prompt tokens.
+func chatUsageFrom(u usage) *chatUsage {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	out := &chatUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+	}
+	if u.CacheReadInputTokens > 0 {
+		out.PromptTokensDetails = &promptTokenDetails{CachedTokens: u.CacheReadInputTokens}
+	}
+	return out
+}
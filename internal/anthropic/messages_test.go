@@ -0,0 +1,218 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFromChatCompletion(t *testing.T) {
+	body := `{
+		"model": "claude-sonnet-4-5",
+		"stream": true,
+		"stream_options": {"include_usage": true},
+		"max_completion_tokens": 1000,
+		"temperature": 0.5,
+		"stop": "END",
+		"user": "user-1",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "developer", "content": [{"type": "text", "text": "Use metric units."}]},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What's in this image?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBORw0KGgo="}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "web_search", "arguments": "{\"query\":\"weather\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "clock", "arguments": ""}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "Sunny"},
+			{"role": "tool", "tool_call_id": "call_2", "content": "12:00"},
+			{"role": "user", "content": "Thanks"}
+		],
+		"tools": [{"type": "function", "function": {"name": "web_search", "description": "Search", "parameters": {"type": "object"}}}],
+		"tool_choice": "required",
+		"parallel_tool_calls": false
+	}`
+
+	translated, err := FromChatCompletion([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got messagesRequest
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Model != "claude-sonnet-4-5" || !got.Stream || got.MaxTokens != 1000 || *got.Temperature != 0.5 {
+		t.Errorf("parameters = %+v", got)
+	}
+	if got.System != "Be brief.\n\nUse metric units." {
+		t.Errorf("system = %q", got.System)
+	}
+	if len(got.StopSequences) != 1 || got.StopSequences[0] != "END" {
+		t.Errorf("stop_sequences = %v", got.StopSequences)
+	}
+	if got.Metadata == nil || got.Metadata.UserID != "user-1" {
+		t.Errorf("metadata = %+v", got.Metadata)
+	}
+
+	// user, assistant (tool calls), user (tool results merged with the follow-up)
+	roles := make([]string, len(got.Messages))
+	for i, msg := range got.Messages {
+		roles[i] = msg.Role
+	}
+	if strings.Join(roles, ",") != "user,assistant,user" {
+		t.Fatalf("roles = %v", roles)
+	}
+	if image := got.Messages[0].Content[1]; image.Type != "image" || image.Source.Type != "base64" || image.Source.MediaType != "image/png" || image.Source.Data != "iVBORw0KGgo=" {
+		t.Errorf("image block = %+v", image)
+	}
+	toolUse := got.Messages[1].Content
+	if len(toolUse) != 2 || toolUse[0].Type != "tool_use" || toolUse[0].ID != "call_1" || string(toolUse[0].Input) != `{"query":"weather"}` || string(toolUse[1].Input) != "{}" {
+		t.Errorf("tool_use blocks = %+v", toolUse)
+	}
+	results := got.Messages[2].Content
+	if len(results) != 3 || results[0].Type != "tool_result" || results[0].ToolUseID != "call_1" || results[0].Content != "Sunny" || results[2].Text != "Thanks" {
+		t.Errorf("tool_result message = %+v", results)
+	}
+
+	if len(got.Tools) != 1 || got.Tools[0].Name != "web_search" || string(got.Tools[0].InputSchema) != `{"type":"object"}` {
+		t.Errorf("tools = %+v", got.Tools)
+	}
+	if got.ToolChoice == nil || got.ToolChoice.Type != "any" || !got.ToolChoice.DisableParallelToolUse {
+		t.Errorf("tool_choice = %+v", got.ToolChoice)
+	}
+}
+
+func TestFromChatCompletionDefaults(t *testing.T) {
+	translated, err := FromChatCompletion([]byte(`{"model": "claude-haiku-4-5", "messages": [{"role": "user", "content": "Hi"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["max_tokens"] != float64(DefaultMaxTokens) {
+		t.Errorf("max_tokens = %v, want %d", got["max_tokens"], DefaultMaxTokens)
+	}
+	for _, field := range []string{"system", "stream", "tools", "tool_choice", "temperature", "metadata"} {
+		if _, ok := got[field]; ok {
+			t.Errorf("unexpected %s in %s", field, translated)
+		}
+	}
+}
+
+func TestFromChatCompletionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{`},
+		{"no messages", `{"model": "m", "messages": [{"role": "system", "content": "Be brief."}]}`},
+		{"unknown role", `{"model": "m", "messages": [{"role": "narrator", "content": "Hi"}]}`},
+		{"unsupported part", `{"model": "m", "messages": [{"role": "user", "content": [{"type": "input_audio"}]}]}`},
+		{"bad data URL", `{"model": "m", "messages": [{"role": "user", "content": [{"type": "image_url", "image_url": {"url": "data:image/png,raw"}}]}]}`},
+		{"bad tool_choice", `{"model": "m", "messages": [{"role": "user", "content": "Hi"}], "tool_choice": "sometimes"}`},
+		{"bad stop", `{"model": "m", "messages": [{"role": "user", "content": "Hi"}], "stop": 3}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromChatCompletion([]byte(tt.body)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestToChatCompletion(t *testing.T) {
+	body := `{
+		"id": "msg_1",
+		"type": "message",
+		"role": "assistant",
+		"model": "claude-sonnet-4-5",
+		"content": [
+			{"type": "thinking", "thinking": "The user wants the weather.", "signature": "sig"},
+			{"type": "text", "text": "Let me check."},
+			{"type": "tool_use", "id": "toolu_1", "name": "web_search", "input": {"query": "weather"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 10, "output_tokens": 20, "cache_read_input_tokens": 5}
+	}`
+
+	translated, err := ToChatCompletion([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Choices []struct {
+			Message struct {
+				Role      string `json:"role"`
+				Content   string `json:"content"`
+				Reasoning string `json:"reasoning"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Type     string `json:"type"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens        int `json:"prompt_tokens"`
+			CompletionTokens    int `json:"completion_tokens"`
+			TotalTokens         int `json:"total_tokens"`
+			PromptTokensDetails struct {
+				CachedTokens int `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ID != "msg_1" || got.Object != "chat.completion" || len(got.Choices) != 1 {
+		t.Fatalf("completion = %s", translated)
+	}
+	choice := got.Choices[0]
+	if choice.Message.Role != "assistant" || choice.Message.Content != "Let me check." || choice.Message.Reasoning != "The user wants the weather." {
+		t.Errorf("message = %+v", choice.Message)
+	}
+	if len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].ID != "toolu_1" || choice.Message.ToolCalls[0].Function.Arguments != `{"query":"weather"}` {
+		t.Errorf("tool_calls = %+v", choice.Message.ToolCalls)
+	}
+	if choice.FinishReason != "tool_calls" {
+		t.Errorf("finish_reason = %q, want tool_calls", choice.FinishReason)
+	}
+	if got.Usage.PromptTokens != 15 || got.Usage.CompletionTokens != 20 || got.Usage.TotalTokens != 35 || got.Usage.PromptTokensDetails.CachedTokens != 5 {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+}
+
+func TestToChatCompletionError(t *testing.T) {
+	body := []byte(`{"type": "error", "error": {"type": "invalid_request_error", "message": "max_tokens: too large"}}`)
+	if got := string(ToChatCompletionError(body)); got != `{"error":{"message":"max_tokens: too large","type":"invalid_request_error"}}` {
+		t.Errorf("got %s", got)
+	}
+
+	other := []byte(`upstream connect error`)
+	if got := ToChatCompletionError(other); string(got) != string(other) {
+		t.Errorf("non-API error changed to %s", got)
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer secret")
+	SetHeaders(h, "secret")
+	if h.Get("Authorization") != "" || h.Get("x-api-key") != "secret" || h.Get("anthropic-version") != Version {
+		t.Errorf("headers = %v", h)
+	}
+}
@@ -0,0 +1,181 @@
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// streamEvent is a Messages API SSE event (the fields used by any event type).
+type streamEvent struct {
+	Type         string            `json:"type"`
+	Message      *messagesResponse `json:"message"`
+	Index        int               `json:"index"`
+	ContentBlock *contentBlock     `json:"content_block"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *usage    `json:"usage"`
+	Error *apiError `json:"error"`
+}
+
+// streamReader translates a Messages API SSE stream to Chat Completions chunks.
+type streamReader struct {
+	body    io.ReadCloser
+	lines   *bufio.Reader
+	pending bytes.Buffer
+	err     error
+
+	id        string
+	model     string
+	created   int64
+	usage     usage
+	toolIndex map[int]int // Content block index → tool call index
+}
+
+// NewStreamReader returns a reader of the Chat Completions SSE stream ("data: {chunk}" lines
+// ending with "data: [DONE]") equivalent to a Messages API stream, so the stream can be
+// broadcast, parsed and stored like any other. A stream cut off before message_stop ends
+// without [DONE].
+func NewStreamReader(body io.ReadCloser) io.ReadCloser {
+	return &streamReader{
+		body:      body,
+		lines:     bufio.NewReader(body),
+		created:   time.Now().Unix(),
+		toolIndex: make(map[int]int),
+	}
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for r.pending.Len() == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		line, err := r.lines.ReadString('\n')
+		if line != "" {
+			r.translateLine(strings.TrimRight(line, "\r\n"))
+		}
+		if err != nil {
+			r.err = err
+		}
+	}
+	return r.pending.Read(p)
+}
+
+func (r *streamReader) Close() error {
+	return r.body.Close()
+}
+
+// translateLine translates an SSE line. Event lines are dropped: the type is also in the data.
+func (r *streamReader) translateLine(line string) {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return
+	}
+	var event streamEvent
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+		return
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			r.id = event.Message.ID
+			r.model = event.Message.Model
+			r.usage = event.Message.Usage
+		}
+		empty := ""
+		r.writeDelta(&chatOutput{Role: "assistant", Content: &empty}, nil)
+
+	case "content_block_start":
+		if event.ContentBlock == nil {
+			return
+		}
+		switch event.ContentBlock.Type {
+		case "tool_use":
+			index := len(r.toolIndex)
+			r.toolIndex[event.Index] = index
+			r.writeDelta(&chatOutput{ToolCalls: []chatToolCall{{
+				Index:    &index,
+				ID:       event.ContentBlock.ID,
+				Type:     "function",
+				Function: chatFunction{Name: event.ContentBlock.Name},
+			}}}, nil)
+		case "text":
+			if text := event.ContentBlock.Text; text != "" {
+				r.writeDelta(&chatOutput{Content: &text}, nil)
+			}
+		}
+
+	case "content_block_delta":
+		if event.Delta == nil {
+			return
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			r.writeDelta(&chatOutput{Content: &event.Delta.Text}, nil)
+		case "thinking_delta":
+			r.writeDelta(&chatOutput{Reasoning: event.Delta.Thinking}, nil)
+		case "input_json_delta":
+			index, ok := r.toolIndex[event.Index]
+			if !ok {
+				return
+			}
+			r.writeDelta(&chatOutput{ToolCalls: []chatToolCall{{
+				Index:    &index,
+				Function: chatFunction{Arguments: event.Delta.PartialJSON},
+			}}}, nil)
+		}
+
+	case "message_delta":
+		if event.Usage != nil {
+			// Usage in message_delta is cumulative
+			r.usage.OutputTokens = event.Usage.OutputTokens
+			if event.Usage.InputTokens > 0 {
+				r.usage.InputTokens = event.Usage.InputTokens
+			}
+		}
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			finishReason := FinishReason(event.Delta.StopReason)
+			r.writeDelta(&chatOutput{}, &finishReason)
+		}
+
+	case "message_stop":
+		r.writeChunk(chatCompletion{Choices: []chatChoice{}, Usage: chatUsageFrom(r.usage)})
+		r.pending.WriteString("data: [DONE]\n\n")
+
+	case "error":
+		if event.Error == nil {
+			return
+		}
+		errorJSON, err := json.Marshal(map[string]interface{}{
+			"error": map[string]string{"message": event.Error.Message, "type": event.Error.Type},
+		})
+		if err == nil {
+			r.pending.WriteString("data: " + string(errorJSON) + "\n\n")
+		}
+	}
+}
+
+func (r *streamReader) writeDelta(delta *chatOutput, finishReason *string) {
+	r.writeChunk(chatCompletion{Choices: []chatChoice{{Delta: delta, FinishReason: finishReason}}})
+}
+
+func (r *streamReader) writeChunk(chunk chatCompletion) {
+	chunk.ID = r.id
+	chunk.Object = "chat.completion.chunk"
+	chunk.Created = r.created
+	chunk.Model = r.model
+	chunkJSON, err := json.Marshal(chunk)
+	if err != nil {
+		return
+	}
+	r.pending.WriteString("data: " + string(chunkJSON) + "\n\n")
+}
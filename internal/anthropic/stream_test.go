@@ -0,0 +1,188 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+const messagesStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"stop_reason":null,"usage":{"input_tokens":25,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Checking."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: ping
+data: {"type": "ping"}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":" world"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: content_block_start
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"web_search","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"query\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"weather\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":2}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":15}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+type chunk struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Role      string  `json:"role"`
+			Content   *string `json:"content"`
+			Reasoning string  `json:"reasoning"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// readChunks reads a translated stream and returns its chunks and whether it ended with [DONE].
+func readChunks(t *testing.T, stream string) ([]chunk, bool) {
+	t.Helper()
+	out, err := io.ReadAll(NewStreamReader(io.NopCloser(strings.NewReader(stream))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks []chunk
+	done := false
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			t.Fatalf("unexpected line %q", line)
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		var c chunk
+		if err := json.Unmarshal([]byte(data), &c); err != nil {
+			t.Fatalf("invalid chunk %s: %v", data, err)
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, done
+}
+
+func TestStreamReader(t *testing.T) {
+	chunks, done := readChunks(t, messagesStream)
+	if !done {
+		t.Error("stream did not end with [DONE]")
+	}
+
+	var content, reasoning, arguments, finishReason string
+	var toolID, toolName string
+	for _, c := range chunks {
+		if c.ID != "msg_1" || c.Object != "chat.completion.chunk" || c.Model != "claude-sonnet-4-5" {
+			t.Errorf("chunk header = %+v", c)
+		}
+		for _, choice := range c.Choices {
+			if choice.Delta.Content != nil {
+				content += *choice.Delta.Content
+			}
+			reasoning += choice.Delta.Reasoning
+			for _, call := range choice.Delta.ToolCalls {
+				if call.Index != 0 {
+					t.Errorf("tool call index = %d, want 0", call.Index)
+				}
+				if call.ID != "" {
+					toolID, toolName = call.ID, call.Function.Name
+				}
+				arguments += call.Function.Arguments
+			}
+			if choice.FinishReason != nil {
+				finishReason = *choice.FinishReason
+			}
+		}
+	}
+
+	if chunks[0].Choices[0].Delta.Role != "assistant" {
+		t.Errorf("first chunk = %+v, want the assistant role", chunks[0])
+	}
+	if content != "Hello world" || reasoning != "Checking." {
+		t.Errorf("content = %q, reasoning = %q", content, reasoning)
+	}
+	if toolID != "toolu_1" || toolName != "web_search" || arguments != `{"query":"weather"}` {
+		t.Errorf("tool call = %s %s(%s)", toolID, toolName, arguments)
+	}
+	if finishReason != "tool_calls" {
+		t.Errorf("finish_reason = %q, want tool_calls", finishReason)
+	}
+
+	last := chunks[len(chunks)-1]
+	if last.Usage == nil || last.Usage.PromptTokens != 25 || last.Usage.CompletionTokens != 15 || len(last.Choices) != 0 {
+		t.Errorf("usage chunk = %+v", last)
+	}
+}
+
+func TestStreamReaderTruncated(t *testing.T) {
+	// Cut off after the first text delta: no finish reason, usage or [DONE]
+	cut := strings.Index(messagesStream, `data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":" world"}}`)
+	chunks, done := readChunks(t, messagesStream[:cut])
+	if done {
+		t.Error("truncated stream ended with [DONE]")
+	}
+	for _, c := range chunks {
+		if c.Usage != nil {
+			t.Errorf("truncated stream reported usage: %+v", c)
+		}
+	}
+}
+
+func TestStreamReaderError(t *testing.T) {
+	stream := "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"
+	out, err := io.ReadAll(NewStreamReader(io.NopCloser(strings.NewReader(stream))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `data: {"error":{"message":"Overloaded","type":"overloaded_error"}}` + "\n\n"; string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,184 @@
+// Package apiversion negotiates the public API version of a request, so breaking changes ship
+// behind /api/v2 (or X-API-Version: 2 on unversioned routes) while v1 clients keep working.
+//
+// Versions share handlers: an Adapter describes what differs between them (error envelope,
+// implied streaming capabilities) and handlers read it from the request instead of being
+// duplicated per version. /api/v1 responses carry deprecation headers pointing to v2.
+package apiversion
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderName is the request header selecting the version of unversioned routes, and the
+// response header reporting the version served.
+const HeaderName = "X-API-Version"
+
+// Version is a public API version.
+type Version int
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+
+	// Latest is the newest version, the successor of deprecated ones.
+	Latest = V2
+)
+
+// String returns the version as used in paths ("v1").
+func (v Version) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+// Adapter is what differs between versions for the handlers they share.
+type Adapter struct {
+	Version Version
+
+	// ErrorEnvelope nests error responses under "error" with a machine-readable code
+	// (see errors.ErrorEnvelope); v1 keeps the flat per-error shapes.
+	ErrorEnvelope bool
+
+	// Capabilities are streaming features every request of the version gets, on top of the
+	// ones declared in X-Client-Capabilities.
+	Capabilities []capabilities.Capability
+}
+
+// adapters lists the supported versions.
+var adapters = map[Version]Adapter{
+	V1: {Version: V1},
+	V2: {
+		Version:       V2,
+		ErrorEnvelope: true,
+		Capabilities:  []capabilities.Capability{capabilities.StreamV2, capabilities.Citations},
+	},
+}
+
+// Deprecation describes how a deprecated version is announced (RFC 9745 Deprecation and
+// RFC 8594 Sunset headers).
+type Deprecation struct {
+	// At is when the version was deprecated; zero announces it without a date.
+	At time.Time
+	// Sunset is when the version stops being served; zero if not planned yet.
+	Sunset time.Time
+}
+
+// ParseDeprecation parses the deprecation and sunset dates (YYYY-MM-DD, either may be empty).
+func ParseDeprecation(at, sunset string) (Deprecation, error) {
+	var d Deprecation
+	var err error
+	if at != "" {
+		if d.At, err = time.Parse(time.DateOnly, at); err != nil {
+			return Deprecation{}, fmt.Errorf("invalid deprecation date %q: %w", at, err)
+		}
+	}
+	if sunset != "" {
+		if d.Sunset, err = time.Parse(time.DateOnly, sunset); err != nil {
+			return Deprecation{}, fmt.Errorf("invalid sunset date %q: %w", sunset, err)
+		}
+	}
+	return d, nil
+}
+
+type contextKey string
+
+const adapterKey contextKey = "api_version"
+
+// WithAdapter adds the version adapter to the context.
+func WithAdapter(ctx context.Context, adapter Adapter) context.Context {
+	return context.WithValue(ctx, adapterKey, adapter)
+}
+
+// FromContext returns the version adapter of the request; v1 if Negotiate didn't run.
+func FromContext(ctx context.Context) Adapter {
+	if adapter, ok := ctx.Value(adapterKey).(Adapter); ok {
+		return adapter
+	}
+	return adapters[V1]
+}
+
+// FromGin returns the version adapter of the request.
+func FromGin(c *gin.Context) Adapter {
+	return FromContext(c.Request.Context())
+}
+
+// Negotiate picks the version of each request: the /api/vN path prefix if any, otherwise the
+// X-API-Version header, otherwise v1. It attaches the version's adapter to the context, adds
+// the capabilities the version implies (so it must run after capabilities.Middleware) and
+// reports the version served. Responses of /api/v1 routes carry the v1 deprecation headers
+// (unversioned routes, e.g. the OpenAI-compatible ones, don't).
+func Negotiate(v1 Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, ok := requestVersion(c.Request)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "unsupported API version",
+				"details": gin.H{"supported": supported()},
+			})
+			return
+		}
+		adapter := adapters[version]
+
+		ctx := WithAdapter(c.Request.Context(), adapter)
+		if len(adapter.Capabilities) > 0 {
+			ctx = capabilities.WithSet(ctx, capabilities.FromContext(ctx).With(adapter.Capabilities...))
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Header(HeaderName, strconv.Itoa(int(version)))
+		if v1Prefix := "/api/" + V1.String(); strings.HasPrefix(c.Request.URL.Path, v1Prefix+"/") {
+			setDeprecationHeaders(c, v1, "/api/"+Latest.String()+strings.TrimPrefix(c.Request.URL.Path, v1Prefix))
+		}
+		c.Next()
+	}
+}
+
+// requestVersion returns the version requested; false if it isn't supported.
+func requestVersion(r *http.Request) (Version, bool) {
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/api/v"); ok {
+		number, _, _ := strings.Cut(rest, "/")
+		return parseVersion(number)
+	}
+	if header := strings.TrimSpace(r.Header.Get(HeaderName)); header != "" {
+		return parseVersion(strings.TrimPrefix(strings.ToLower(header), "v"))
+	}
+	return V1, true
+}
+
+func parseVersion(number string) (Version, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return 0, false
+	}
+	_, ok := adapters[Version(n)]
+	return Version(n), ok
+}
+
+// supported lists the supported versions, oldest first.
+func supported() []string {
+	versions := make([]string, 0, len(adapters))
+	for v := V1; v <= Latest; v++ {
+		versions = append(versions, v.String())
+	}
+	return versions
+}
+
+// setDeprecationHeaders announces the deprecation and the successor path of the route.
+func setDeprecationHeaders(c *gin.Context, d Deprecation, successor string) {
+	if d.At.IsZero() {
+		c.Header("Deprecation", "true")
+	} else {
+		c.Header("Deprecation", "@"+strconv.FormatInt(d.At.Unix(), 10))
+	}
+	if !d.Sunset.IsZero() {
+		c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Link", "<"+successor+">; rel=\"successor-version\"")
+}
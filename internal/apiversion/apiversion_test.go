@@ -0,0 +1,105 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deprecation, err := ParseDeprecation("2026-01-15", "2026-07-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Adapter
+	var caps capabilities.Set
+	router := gin.New()
+	router.Use(capabilities.Middleware(), Negotiate(deprecation))
+	handler := func(c *gin.Context) {
+		got = FromGin(c)
+		caps = capabilities.FromGin(c)
+	}
+	router.GET("/api/v1/preferences", handler)
+	router.GET("/api/v2/preferences", handler)
+	router.GET("/models", handler)
+
+	tests := []struct {
+		name           string
+		path           string
+		header         string
+		wantStatus     int
+		wantVersion    Version
+		wantStreamV2   bool
+		wantDeprecated bool
+	}{
+		{name: "v1 path", path: "/api/v1/preferences", wantStatus: 200, wantVersion: V1, wantDeprecated: true},
+		{name: "v2 path", path: "/api/v2/preferences", wantStatus: 200, wantVersion: V2, wantStreamV2: true},
+		{name: "path wins over header", path: "/api/v2/preferences", header: "1", wantStatus: 200, wantVersion: V2, wantStreamV2: true},
+		{name: "unversioned defaults to v1", path: "/models", wantStatus: 200, wantVersion: V1},
+		{name: "unversioned with header", path: "/models", header: "v2", wantStatus: 200, wantVersion: V2, wantStreamV2: true},
+		{name: "unsupported header", path: "/models", header: "3", wantStatus: 400},
+		{name: "unsupported path", path: "/api/v9/preferences", wantStatus: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Adapter{}
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderName, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != 200 {
+				return
+			}
+			if got.Version != tt.wantVersion || got.ErrorEnvelope != (tt.wantVersion == V2) {
+				t.Errorf("adapter = %+v, want version %d", got, tt.wantVersion)
+			}
+			if caps.Has(capabilities.StreamV2) != tt.wantStreamV2 || caps.Has(capabilities.Citations) != tt.wantStreamV2 {
+				t.Errorf("capabilities = %v", caps.List())
+			}
+			if !caps.Has(capabilities.ToolNotifications) {
+				t.Errorf("capabilities = %v, want the legacy defaults kept", caps.List())
+			}
+			if w.Header().Get(HeaderName) != strconv.Itoa(int(tt.wantVersion)) {
+				t.Errorf("%s = %q", HeaderName, w.Header().Get(HeaderName))
+			}
+
+			if !tt.wantDeprecated {
+				if w.Header().Get("Deprecation") != "" {
+					t.Errorf("Deprecation = %q, want none", w.Header().Get("Deprecation"))
+				}
+				return
+			}
+			if want := "@1768435200"; w.Header().Get("Deprecation") != want {
+				t.Errorf("Deprecation = %q, want %q", w.Header().Get("Deprecation"), want)
+			}
+			if want := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat); w.Header().Get("Sunset") != want {
+				t.Errorf("Sunset = %q, want %q", w.Header().Get("Sunset"), want)
+			}
+			if want := `</api/v2/preferences>; rel="successor-version"`; w.Header().Get("Link") != want {
+				t.Errorf("Link = %q, want %q", w.Header().Get("Link"), want)
+			}
+		})
+	}
+}
+
+func TestParseDeprecation(t *testing.T) {
+	if d, err := ParseDeprecation("", ""); err != nil || !d.At.IsZero() || !d.Sunset.IsZero() {
+		t.Errorf("ParseDeprecation(\"\", \"\") = %+v, %v", d, err)
+	}
+	if _, err := ParseDeprecation("2026-13-01", ""); err == nil {
+		t.Error("ParseDeprecation() accepted an invalid date")
+	}
+}
@@ -0,0 +1,288 @@
+package attestation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// appleAppAttestRootCA is the Apple App Attestation Root CA.
+// Source: https://www.apple.com/certificateauthority/Apple_App_Attestation_Root_CA.pem
+const appleAppAttestRootCA = `-----BEGIN CERTIFICATE-----
+MIICITCCAaegAwIBAgIQC/O+DvHN0uD7jG5yH2IXmDAKBggqhkjOPQQDAzBSMSYw
+JAYDVQQDDB1BcHBsZSBBcHAgQXR0ZXN0YXRpb24gUm9vdCBDQTETMBEGA1UECgwK
+QXBwbGUgSW5jLjETMBEGA1UECAwKQ2FsaWZvcm5pYTAeFw0yMDAzMTgxODMyNTNa
+Fw00NTAzMTUwMDAwMDBaMFIxJjAkBgNVBAMMHUFwcGxlIEFwcCBBdHRlc3RhdGlv
+biBSb290IENBMRMwEQYDVQQKDApBcHBsZSBJbmMuMRMwEQYDVQQIDApDYWxpZm9y
+bmlhMHYwEAYHKoZIzj0CAQYFK4EEACIDYgAERTHhmLW07ATaFQIEVwTtT4dyctdh
+NbJhFs/Ii2FdCgAHGbpphY3+d8qjuDngIN3WVhQUBHAoMeQ/cLiP1sOUtgjqK9au
+Yen1mMEvRq9Sk3Jm5X8U62H+xTD3FE9TgS41o0IwQDAPBgNVHRMBAf8EBTADAQH/
+MB0GA1UdDgQWBBSskRBTM72+aEH/pwyp5frq5eWKoTAOBgNVHQ8BAf8EBAMCAQYw
+CgYIKoZIzj0EAwMDaAAwZQIwQgFGnByvsiVbpTKwSga0kP0e8EeDS4+sQmTvb7vn
+53O5+FRXgeLhpJ06ysC5PrOyAjEAp5U4xDgEgllF7En3VcE3iexZZtKeYnpqtijV
+oyFraWVIyd/dganmrduC1bmTBGwD
+-----END CERTIFICATE-----`
+
+// App Attest environments, recorded per key.
+const (
+	EnvironmentProduction  = "production"
+	EnvironmentDevelopment = "development"
+)
+
+var (
+	aaguidProduction  = []byte("appattest\x00\x00\x00\x00\x00\x00\x00")
+	aaguidDevelopment = []byte("appattestdevelop")
+
+	// appAttestNonceOID is the credential certificate extension holding the attestation nonce.
+	appAttestNonceOID = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 8, 2}
+)
+
+// authenticatorData field offsets (WebAuthn layout).
+const (
+	authDataRPIDHashLen = 32
+	authDataCounterOff  = 33
+	authDataMinLen      = 37 // rpIdHash + flags + counter
+	authDataAAGUIDOff   = 37
+	authDataCredLenOff  = 53
+	authDataCredIDOff   = 55
+)
+
+// AppAttestVerifier verifies iOS App Attest attestations and assertions.
+// See https://developer.apple.com/documentation/devicecheck/validating_apps_that_connect_to_your_server
+type AppAttestVerifier struct {
+	rpIDHash         [32]byte // SHA-256 of "<team id>.<bundle id>"
+	allowDevelopment bool
+	roots            *x509.CertPool
+	now              func() time.Time
+}
+
+// AttestedKey is a verified App Attest key, ready to be stored.
+type AttestedKey struct {
+	PublicKey   []byte // PKIX DER
+	Environment string
+}
+
+// NewAppAttestVerifier creates a verifier for the given app.
+// allowDevelopment accepts keys from the development App Attest environment (debug builds).
+func NewAppAttestVerifier(teamID, bundleID string, allowDevelopment bool) (*AppAttestVerifier, error) {
+	if teamID == "" || bundleID == "" {
+		return nil, errors.New("team ID and bundle ID are required")
+	}
+
+	block, _ := pem.Decode([]byte(appleAppAttestRootCA))
+	if block == nil {
+		return nil, errors.New("failed to decode Apple App Attest root CA")
+	}
+	root, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Apple App Attest root CA: %w", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	return &AppAttestVerifier{
+		rpIDHash:         sha256.Sum256([]byte(teamID + "." + bundleID)),
+		allowDevelopment: allowDevelopment,
+		roots:            roots,
+		now:              time.Now,
+	}, nil
+}
+
+// VerifyAttestation validates a one-time attestation object for keyID.
+// clientData is the data the app hashed into the attestation (the server challenge).
+func (v *AppAttestVerifier) VerifyAttestation(keyID string, attestationObject, clientData []byte) (*AttestedKey, error) {
+	decoded, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation object: %w", err)
+	}
+	obj, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("attestation object is not a map")
+	}
+	if format, _ := obj["fmt"].(string); format != "apple-appattest" {
+		return nil, fmt.Errorf("unexpected attestation format %q", format)
+	}
+	authData, ok := obj["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("attestation object missing authData")
+	}
+	attStmt, ok := obj["attStmt"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("attestation object missing attStmt")
+	}
+	x5c, ok := attStmt["x5c"].([]interface{})
+	if !ok || len(x5c) < 2 {
+		return nil, errors.New("attestation statement missing certificate chain")
+	}
+
+	// 1. Verify the certificate chain up to Apple's root
+	certs := make([]*x509.Certificate, 0, len(x5c))
+	for _, raw := range x5c {
+		der, ok := raw.([]byte)
+		if !ok {
+			return nil, errors.New("invalid certificate in chain")
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	credCert := certs[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := credCert.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("certificate chain verification failed: %w", err)
+	}
+
+	// 2-4. The credential certificate must commit to authData and our challenge
+	clientDataHash := sha256.Sum256(clientData)
+	expectedNonce := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	certNonce, err := extractAppAttestNonce(credCert)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(certNonce, expectedNonce[:]) {
+		return nil, errors.New("attestation nonce mismatch")
+	}
+
+	// 5. The key ID is the SHA-256 of the public key
+	pub, ok := credCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, errors.New("credential certificate key is not P-256")
+	}
+	ecdhPub, err := pub.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	pubKeyHash := sha256.Sum256(ecdhPub.Bytes())
+	keyIDBytes, err := base64.StdEncoding.DecodeString(keyID)
+	if err != nil {
+		return nil, errors.New("key ID is not valid base64")
+	}
+	if !bytes.Equal(keyIDBytes, pubKeyHash[:]) {
+		return nil, errors.New("key ID does not match credential public key")
+	}
+
+	// 6-9. authData must be for this app, fresh, and carry the same credential
+	if len(authData) < authDataCredIDOff {
+		return nil, errors.New("authData too short")
+	}
+	if !bytes.Equal(authData[:authDataRPIDHashLen], v.rpIDHash[:]) {
+		return nil, errors.New("attestation is for a different app")
+	}
+	if binary.BigEndian.Uint32(authData[authDataCounterOff:authDataMinLen]) != 0 {
+		return nil, errors.New("attestation counter must be zero")
+	}
+
+	aaguid := authData[authDataAAGUIDOff:authDataCredLenOff]
+	var environment string
+	switch {
+	case bytes.Equal(aaguid, aaguidProduction):
+		environment = EnvironmentProduction
+	case bytes.Equal(aaguid, aaguidDevelopment) && v.allowDevelopment:
+		environment = EnvironmentDevelopment
+	default:
+		return nil, errors.New("attestation is from a disallowed App Attest environment")
+	}
+
+	credIDLen := int(binary.BigEndian.Uint16(authData[authDataCredLenOff:authDataCredIDOff]))
+	if len(authData) < authDataCredIDOff+credIDLen {
+		return nil, errors.New("authData credential ID truncated")
+	}
+	if !bytes.Equal(authData[authDataCredIDOff:authDataCredIDOff+credIDLen], keyIDBytes) {
+		return nil, errors.New("authData credential ID does not match key ID")
+	}
+
+	pkix, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	return &AttestedKey{
+		PublicKey:   pkix,
+		Environment: environment,
+	}, nil
+}
+
+// VerifyAssertion validates an assertion signed by a previously attested key.
+// Returns the assertion counter, which must be persisted and strictly increase.
+func (v *AppAttestVerifier) VerifyAssertion(publicKeyDER []byte, lastCounter int64, assertion, clientData []byte) (int64, error) {
+	decoded, err := decodeCBOR(assertion)
+	if err != nil {
+		return 0, fmt.Errorf("invalid assertion: %w", err)
+	}
+	obj, ok := decoded.(map[string]interface{})
+	if !ok {
+		return 0, errors.New("assertion is not a map")
+	}
+	signature, ok := obj["signature"].([]byte)
+	if !ok {
+		return 0, errors.New("assertion missing signature")
+	}
+	authData, ok := obj["authenticatorData"].([]byte)
+	if !ok || len(authData) < authDataMinLen {
+		return 0, errors.New("assertion missing authenticatorData")
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(publicKeyDER)
+	if err != nil {
+		return 0, fmt.Errorf("invalid stored public key: %w", err)
+	}
+	pub, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return 0, errors.New("stored public key is not ECDSA")
+	}
+
+	clientDataHash := sha256.Sum256(clientData)
+	nonce := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	digest := sha256.Sum256(nonce[:])
+	if !ecdsa.VerifyASN1(pub, digest[:], signature) {
+		return 0, errors.New("assertion signature is invalid")
+	}
+
+	if !bytes.Equal(authData[:authDataRPIDHashLen], v.rpIDHash[:]) {
+		return 0, errors.New("assertion is for a different app")
+	}
+
+	counter := int64(binary.BigEndian.Uint32(authData[authDataCounterOff:authDataMinLen]))
+	if counter <= lastCounter {
+		return 0, errors.New("assertion counter did not increase")
+	}
+
+	return counter, nil
+}
+
+// extractAppAttestNonce reads the nonce from the credential certificate extension:
+// SEQUENCE { [1] EXPLICIT OCTET STRING }.
+func extractAppAttestNonce(cert *x509.Certificate) ([]byte, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(appAttestNonceOID) {
+			continue
+		}
+		var value struct {
+			Nonce []byte `asn1:"tag:1,explicit"`
+		}
+		if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+			return nil, fmt.Errorf("invalid nonce extension: %w", err)
+		}
+		return value.Nonce, nil
+	}
+	return nil, errors.New("credential certificate missing nonce extension")
+}
@@ -0,0 +1,355 @@
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Minimal CBOR encoder for building test fixtures.
+
+func cborHead(major byte, n int) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 256:
+		return []byte{major<<5 | 24, byte(n)}
+	default:
+		b := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		return b
+	}
+}
+
+func cborBytes(b []byte) []byte { return append(cborHead(2, len(b)), b...) }
+func cborText(s string) []byte  { return append(cborHead(3, len(s)), s...) }
+
+func cborArray(items ...[]byte) []byte {
+	out := cborHead(4, len(items))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+// cborMap takes alternating text keys and encoded values.
+func cborMap(kv ...interface{}) []byte {
+	out := cborHead(5, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		out = append(out, cborText(kv[i].(string))...)
+		out = append(out, kv[i+1].([]byte)...)
+	}
+	return out
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "small uint", data: []byte{0x0a}, want: uint64(10)},
+		{name: "two byte uint", data: []byte{0x19, 0x01, 0x00}, want: uint64(256)},
+		{name: "negative int", data: []byte{0x29}, want: int64(-10)},
+		{name: "text", data: cborText("fmt"), want: "fmt"},
+		{name: "bool", data: []byte{0xf5}, want: true},
+		{name: "truncated bytes", data: []byte{0x45, 0x01}, wantErr: true},
+		{name: "indefinite length", data: []byte{0x5f}, wantErr: true},
+		{name: "trailing data", data: []byte{0x01, 0x02}, wantErr: true},
+		{name: "non-text map key", data: []byte{0xa1, 0x01, 0x02}, wantErr: true},
+		{name: "oversized array", data: []byte{0x9a, 0xff, 0xff, 0xff, 0xff}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCBOR(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeCBOR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("decodeCBOR() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nested map", func(t *testing.T) {
+		data := cborMap("a", cborArray(cborBytes([]byte{1, 2})), "b", cborText("x"))
+		got, err := decodeCBOR(data)
+		if err != nil {
+			t.Fatalf("decodeCBOR() error = %v", err)
+		}
+		m := got.(map[string]interface{})
+		if arr := m["a"].([]interface{}); len(arr) != 1 || string(arr[0].([]byte)) != "\x01\x02" {
+			t.Errorf("m[a] = %#v", m["a"])
+		}
+		if m["b"] != "x" {
+			t.Errorf("m[b] = %#v", m["b"])
+		}
+	})
+}
+
+const (
+	testTeamID   = "TEAM123456"
+	testBundleID = "ai.example.app"
+)
+
+type appAttestFixture struct {
+	verifier *AppAttestVerifier
+	rootKey  *ecdsa.PrivateKey
+	rootCert *x509.Certificate
+	key      *ecdsa.PrivateKey
+	keyID    string
+}
+
+func newAppAttestFixture(t *testing.T) *appAttestFixture {
+	t.Helper()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test App Attest Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCert, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := NewAppAttestVerifier(testTeamID, testBundleID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.roots = x509.NewCertPool()
+	verifier.roots.AddCert(rootCert)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhKey, err := key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyHash := sha256.Sum256(ecdhKey.Bytes())
+
+	return &appAttestFixture{
+		verifier: verifier,
+		rootKey:  rootKey,
+		rootCert: rootCert,
+		key:      key,
+		keyID:    base64.StdEncoding.EncodeToString(keyHash[:]),
+	}
+}
+
+// attestation builds an attestation object the way DCAppAttestService would.
+func (f *appAttestFixture) attestation(t *testing.T, clientData []byte, aaguid []byte) []byte {
+	t.Helper()
+
+	rpIDHash := sha256.Sum256([]byte(testTeamID + "." + testBundleID))
+	credID, _ := base64.StdEncoding.DecodeString(f.keyID)
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, 0x40)       // flags: attested credential data
+	authData = append(authData, 0, 0, 0, 0) // counter
+	authData = append(authData, aaguid...)
+	authData = append(authData, 0, byte(len(credID)))
+	authData = append(authData, credID...)
+
+	clientDataHash := sha256.Sum256(clientData)
+	nonce := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	extValue, err := asn1.Marshal(struct {
+		Nonce []byte `asn1:"tag:1,explicit"`
+	}{Nonce: nonce[:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		Subject:         pkix.Name{CommonName: "credential"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: appAttestNonceOID, Value: extValue}},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, f.rootCert, &f.key.PublicKey, f.rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cborMap(
+		"fmt", cborText("apple-appattest"),
+		"attStmt", cborMap(
+			"x5c", cborArray(cborBytes(leafDER), cborBytes(f.rootCert.Raw)),
+			"receipt", cborBytes([]byte("receipt")),
+		),
+		"authData", cborBytes(authData),
+	)
+}
+
+// assertion builds an assertion the way generateAssertion would.
+func (f *appAttestFixture) assertion(t *testing.T, clientData []byte, counter uint32) []byte {
+	t.Helper()
+
+	rpIDHash := sha256.Sum256([]byte(testTeamID + "." + testBundleID))
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, 0)
+	authData = binary.BigEndian.AppendUint32(authData, counter)
+
+	clientDataHash := sha256.Sum256(clientData)
+	nonce := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	digest := sha256.Sum256(nonce[:])
+	signature, err := ecdsa.SignASN1(rand.Reader, f.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cborMap(
+		"signature", cborBytes(signature),
+		"authenticatorData", cborBytes(authData),
+	)
+}
+
+func TestVerifyAttestation(t *testing.T) {
+	f := newAppAttestFixture(t)
+	challenge := []byte("challenge-123")
+
+	tests := []struct {
+		name       string
+		keyID      string
+		object     []byte
+		clientData []byte
+		wantErr    string
+	}{
+		{
+			name:       "valid production attestation",
+			keyID:      f.keyID,
+			object:     f.attestation(t, challenge, aaguidProduction),
+			clientData: challenge,
+		},
+		{
+			name:       "different challenge",
+			keyID:      f.keyID,
+			object:     f.attestation(t, challenge, aaguidProduction),
+			clientData: []byte("other"),
+			wantErr:    "nonce mismatch",
+		},
+		{
+			name:       "key ID mismatch",
+			keyID:      base64.StdEncoding.EncodeToString(make([]byte, 32)),
+			object:     f.attestation(t, challenge, aaguidProduction),
+			clientData: challenge,
+			wantErr:    "key ID does not match",
+		},
+		{
+			name:       "development environment not allowed",
+			keyID:      f.keyID,
+			object:     f.attestation(t, challenge, aaguidDevelopment),
+			clientData: challenge,
+			wantErr:    "disallowed App Attest environment",
+		},
+		{
+			name:       "garbage",
+			keyID:      f.keyID,
+			object:     []byte{0xff},
+			clientData: challenge,
+			wantErr:    "invalid attestation object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := f.verifier.VerifyAttestation(tt.keyID, tt.object, tt.clientData)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyAttestation() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyAttestation() error = %v", err)
+			}
+			if key.Environment != EnvironmentProduction {
+				t.Errorf("Environment = %q, want %q", key.Environment, EnvironmentProduction)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	f := newAppAttestFixture(t)
+	publicKey, err := x509.MarshalPKIXPublicKey(&f.key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := []byte("challenge-456")
+
+	tests := []struct {
+		name        string
+		assertion   []byte
+		clientData  []byte
+		lastCounter int64
+		want        int64
+		wantErr     string
+	}{
+		{
+			name:        "valid",
+			assertion:   f.assertion(t, challenge, 5),
+			clientData:  challenge,
+			lastCounter: 4,
+			want:        5,
+		},
+		{
+			name:        "replayed counter",
+			assertion:   f.assertion(t, challenge, 5),
+			clientData:  challenge,
+			lastCounter: 5,
+			wantErr:     "counter did not increase",
+		},
+		{
+			name:        "different challenge",
+			assertion:   f.assertion(t, challenge, 6),
+			clientData:  []byte("other"),
+			lastCounter: 5,
+			wantErr:     "signature is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.verifier.VerifyAssertion(publicKey, tt.lastCounter, tt.assertion, tt.clientData)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyAssertion() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyAssertion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyAssertion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
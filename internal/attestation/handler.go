@@ -0,0 +1,88 @@
+package attestation
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles HTTP requests for device attestation.
+type Handler struct {
+	service *Service
+	logger  *logger.Logger
+}
+
+// NewHandler creates a new attestation handler.
+func NewHandler(service *Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// IssueChallenge handles POST /api/v1/attestation/challenge
+// Returns a one-time challenge for App Attest or Play Integrity.
+func (h *Handler) IssueChallenge(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("attestation-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	challenge, err := h.service.IssueChallenge(c.Request.Context(), userID)
+	if err != nil {
+		log.Error("failed to issue attestation challenge",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to issue attestation challenge", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
+
+// RegisterAppAttestKey handles POST /api/v1/attestation/app-attest/keys
+// Verifies a one-time App Attest attestation and stores the key for assertions.
+func (h *Handler) RegisterAppAttestKey(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("attestation-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	var req RegisterAppAttestKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	if err := h.service.RegisterAppAttestKey(c.Request.Context(), userID, &req); err != nil {
+		if stderrors.Is(err, ErrInvalidChallenge) ||
+			stderrors.Is(err, ErrPlatformUnsupported) ||
+			stderrors.Is(err, ErrVerificationFailed) {
+			log.Warn("app attest key registration rejected",
+				slog.String("user_id", userID),
+				slog.String("error", err.Error()))
+			errors.AbortWithForbidden(c, errors.DeviceAttestationFailed(err.Error()))
+			return
+		}
+		log.Error("failed to register app attest key",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to register app attest key", nil)
+		return
+	}
+
+	log.Info("app attest key registered", slog.String("user_id", userID))
+
+	c.Status(http.StatusCreated)
+}
@@ -0,0 +1,77 @@
+package attestation
+
+import (
+	stderrors "errors"
+	"log/slog"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAttestation rejects requests without valid device attestation.
+// When required is false the middleware is a no-op, so routes can be wired
+// unconditionally and enforcement toggled via DEVICE_ATTESTATION_REQUIRED.
+func RequireAttestation(service *Service, required bool, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required {
+			c.Next()
+			return
+		}
+
+		reqLog := log.WithContext(c.Request.Context()).WithComponent("attestation")
+
+		userID, ok := auth.GetUserID(c)
+		if !ok {
+			errors.AbortWithUnauthorized(c, "unauthorized", nil)
+			return
+		}
+
+		evidence := EvidenceFromRequest(c)
+		if service == nil {
+			reqLog.Error("device attestation required but attestation service is unavailable",
+				slog.String("user_id", userID),
+				slog.String("path", c.Request.URL.Path))
+			errors.AbortWithForbidden(c, errors.DeviceAttestationFailed(ErrPlatformUnsupported.Error()))
+			return
+		}
+
+		err := service.Verify(c.Request.Context(), userID, evidence)
+		switch {
+		case err == nil:
+			c.Next()
+		case stderrors.Is(err, ErrMissingEvidence):
+			reqLog.Warn("request missing device attestation",
+				slog.String("user_id", userID),
+				slog.String("path", c.Request.URL.Path))
+			errors.AbortWithForbidden(c, errors.DeviceAttestationRequired())
+		case stderrors.Is(err, ErrInvalidChallenge),
+			stderrors.Is(err, ErrPlatformUnsupported),
+			stderrors.Is(err, ErrUnknownKey),
+			stderrors.Is(err, ErrVerificationFailed):
+			reqLog.Warn("device attestation rejected",
+				slog.String("user_id", userID),
+				slog.String("platform", string(evidence.Platform)),
+				slog.String("path", c.Request.URL.Path),
+				slog.String("error", err.Error()))
+			errors.AbortWithForbidden(c, errors.DeviceAttestationFailed(err.Error()))
+		default:
+			reqLog.Error("failed to verify device attestation",
+				slog.String("user_id", userID),
+				slog.String("platform", string(evidence.Platform)),
+				slog.String("error", err.Error()))
+			errors.AbortWithInternal(c, "failed to verify device attestation", nil)
+		}
+	}
+}
+
+// EvidenceFromRequest reads attestation evidence from the request headers.
+func EvidenceFromRequest(c *gin.Context) Evidence {
+	return Evidence{
+		Platform:  Platform(c.GetHeader(HeaderPlatform)),
+		Challenge: c.GetHeader(HeaderChallenge),
+		KeyID:     c.GetHeader(HeaderKeyID),
+		Token:     c.GetHeader(HeaderToken),
+	}
+}
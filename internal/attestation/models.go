@@ -0,0 +1,56 @@
+package attestation
+
+import (
+	"errors"
+	"time"
+)
+
+// Platform identifies the attestation mechanism.
+type Platform string
+
+const (
+	PlatformIOS     Platform = "ios"     // App Attest
+	PlatformAndroid Platform = "android" // Play Integrity
+)
+
+// Request headers carrying attestation evidence on protected endpoints.
+const (
+	HeaderPlatform  = "X-Attestation-Platform"
+	HeaderChallenge = "X-Attestation-Challenge" // From POST /api/v1/attestation/challenge
+	HeaderKeyID     = "X-Attestation-Key-ID"    // iOS only: registered App Attest key ID
+	HeaderToken     = "X-Attestation-Token"     // iOS: base64 assertion; Android: Play Integrity token
+)
+
+// ChallengeTTL is how long a challenge can be used after it is issued.
+const ChallengeTTL = 5 * time.Minute
+
+var (
+	ErrMissingEvidence     = errors.New("device attestation required")
+	ErrInvalidChallenge    = errors.New("attestation challenge is invalid or expired")
+	ErrPlatformUnsupported = errors.New("attestation is not available for this platform")
+	ErrUnknownKey          = errors.New("app attest key is not registered")
+	ErrVerificationFailed  = errors.New("device attestation failed")
+)
+
+// Evidence is the attestation material presented with a protected request.
+type Evidence struct {
+	Platform  Platform
+	Challenge string
+	KeyID     string
+	Token     string
+}
+
+// ChallengeResponse is returned by POST /api/v1/attestation/challenge.
+// Android clients pass Challenge as the Play Integrity nonce; iOS clients use
+// its bytes as clientData for attestKey or generateAssertion.
+type ChallengeResponse struct {
+	Challenge string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// RegisterAppAttestKeyRequest registers an App Attest key with a one-time attestation.
+type RegisterAppAttestKeyRequest struct {
+	KeyID       string `json:"keyId" binding:"required"`
+	Attestation string `json:"attestation" binding:"required"` // Base64 attestation object
+	Challenge   string `json:"challenge" binding:"required"`
+}
@@ -0,0 +1,89 @@
+package attestation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/playintegrity/v1"
+)
+
+// playIntegrityMaxAge bounds how old an integrity token may be when presented.
+const playIntegrityMaxAge = 5 * time.Minute
+
+// PlayIntegrityVerifier verifies Android Play Integrity tokens via Google's decode API.
+type PlayIntegrityVerifier struct {
+	service     *playintegrity.Service
+	packageName string
+	now         func() time.Time
+}
+
+// NewPlayIntegrityVerifier creates a verifier authenticated with a service account
+// that has access to the app's Play Integrity API.
+func NewPlayIntegrityVerifier(ctx context.Context, packageName, credentialsJSON string) (*PlayIntegrityVerifier, error) {
+	if packageName == "" {
+		return nil, errors.New("package name is required")
+	}
+	if credentialsJSON == "" {
+		return nil, errors.New("service account credentials are required")
+	}
+
+	service, err := playintegrity.NewService(ctx, option.WithCredentialsJSON([]byte(credentialsJSON)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Play Integrity client: %w", err)
+	}
+
+	return &PlayIntegrityVerifier{
+		service:     service,
+		packageName: packageName,
+		now:         time.Now,
+	}, nil
+}
+
+// Verify decodes the integrity token and checks it was requested with nonce
+// by a Play-recognized build of our app on a genuine device.
+func (v *PlayIntegrityVerifier) Verify(ctx context.Context, token, nonce string) error {
+	resp, err := v.service.V1.DecodeIntegrityToken(v.packageName, &playintegrity.DecodeIntegrityTokenRequest{
+		IntegrityToken: token,
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to decode integrity token: %w", err)
+	}
+	if resp.TokenPayloadExternal == nil {
+		return errors.New("integrity token has no payload")
+	}
+
+	return v.checkPayload(resp.TokenPayloadExternal, nonce)
+}
+
+// checkPayload applies our policy to a decoded integrity verdict.
+func (v *PlayIntegrityVerifier) checkPayload(payload *playintegrity.TokenPayloadExternal, nonce string) error {
+	details := payload.RequestDetails
+	if details == nil {
+		return errors.New("integrity token missing request details")
+	}
+	// Play may re-encode the nonce with or without padding
+	if strings.TrimRight(details.Nonce, "=") != strings.TrimRight(nonce, "=") {
+		return errors.New("integrity token nonce mismatch")
+	}
+	if details.RequestPackageName != v.packageName {
+		return fmt.Errorf("integrity token is for package %q", details.RequestPackageName)
+	}
+	issuedAt := time.UnixMilli(details.TimestampMillis)
+	if v.now().Sub(issuedAt) > playIntegrityMaxAge {
+		return errors.New("integrity token expired")
+	}
+
+	if payload.AppIntegrity == nil || payload.AppIntegrity.AppRecognitionVerdict != "PLAY_RECOGNIZED" {
+		return errors.New("app is not recognized by Google Play")
+	}
+	if payload.DeviceIntegrity == nil || !slices.Contains(payload.DeviceIntegrity.DeviceRecognitionVerdict, "MEETS_DEVICE_INTEGRITY") {
+		return errors.New("device does not meet integrity requirements")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package attestation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/playintegrity/v1"
+)
+
+func TestPlayIntegrityCheckPayload(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	v := &PlayIntegrityVerifier{packageName: "ai.example.app", now: func() time.Time { return now }}
+
+	valid := func() *playintegrity.TokenPayloadExternal {
+		return &playintegrity.TokenPayloadExternal{
+			RequestDetails: &playintegrity.RequestDetails{
+				Nonce:              "abc123",
+				RequestPackageName: "ai.example.app",
+				TimestampMillis:    now.Add(-time.Minute).UnixMilli(),
+			},
+			AppIntegrity:    &playintegrity.AppIntegrity{AppRecognitionVerdict: "PLAY_RECOGNIZED"},
+			DeviceIntegrity: &playintegrity.DeviceIntegrity{DeviceRecognitionVerdict: []string{"MEETS_DEVICE_INTEGRITY"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(p *playintegrity.TokenPayloadExternal)
+		nonce   string
+		wantErr string
+	}{
+		{name: "valid", mutate: func(p *playintegrity.TokenPayloadExternal) {}, nonce: "abc123"},
+		{name: "padded nonce", mutate: func(p *playintegrity.TokenPayloadExternal) { p.RequestDetails.Nonce = "abc123==" }, nonce: "abc123"},
+		{name: "nonce mismatch", mutate: func(p *playintegrity.TokenPayloadExternal) {}, nonce: "other", wantErr: "nonce mismatch"},
+		{name: "other package", mutate: func(p *playintegrity.TokenPayloadExternal) { p.RequestDetails.RequestPackageName = "com.evil" }, nonce: "abc123", wantErr: "package"},
+		{name: "stale token", mutate: func(p *playintegrity.TokenPayloadExternal) {
+			p.RequestDetails.TimestampMillis = now.Add(-time.Hour).UnixMilli()
+		}, nonce: "abc123", wantErr: "expired"},
+		{name: "sideloaded app", mutate: func(p *playintegrity.TokenPayloadExternal) {
+			p.AppIntegrity.AppRecognitionVerdict = "UNRECOGNIZED_VERSION"
+		}, nonce: "abc123", wantErr: "not recognized"},
+		{name: "emulator", mutate: func(p *playintegrity.TokenPayloadExternal) {
+			p.DeviceIntegrity.DeviceRecognitionVerdict = nil
+		}, nonce: "abc123", wantErr: "device does not meet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := valid()
+			tt.mutate(payload)
+			err := v.checkPayload(payload, tt.nonce)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkPayload() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkPayload() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package attestation
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// Service issues attestation challenges and verifies device evidence.
+type Service struct {
+	queries       pgdb.Querier
+	appAttest     *AppAttestVerifier
+	playIntegrity *PlayIntegrityVerifier
+	logger        *logger.Logger
+}
+
+// NewService creates a new attestation service.
+// appAttest or playIntegrity may be nil; evidence for that platform is then rejected.
+func NewService(queries pgdb.Querier, appAttest *AppAttestVerifier, playIntegrity *PlayIntegrityVerifier, logger *logger.Logger) *Service {
+	return &Service{
+		queries:       queries,
+		appAttest:     appAttest,
+		playIntegrity: playIntegrity,
+		logger:        logger,
+	}
+}
+
+// IssueChallenge creates a one-time challenge bound to the user.
+func (s *Service) IssueChallenge(ctx context.Context, userID string) (*ChallengeResponse, error) {
+	// Opportunistic cleanup; challenges are tiny and short-lived
+	if err := s.queries.DeleteExpiredAttestationChallenges(ctx); err != nil {
+		s.logger.WithContext(ctx).WithComponent("attestation").Warn("failed to delete expired attestation challenges",
+			slog.String("error", err.Error()))
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	challenge := base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt := time.Now().Add(ChallengeTTL)
+
+	if err := s.queries.CreateAttestationChallenge(ctx, pgdb.CreateAttestationChallengeParams{
+		Challenge: challenge,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store challenge: %w", err)
+	}
+
+	return &ChallengeResponse{
+		Challenge: challenge,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// RegisterAppAttestKey verifies an App Attest attestation and stores the key for later assertions.
+func (s *Service) RegisterAppAttestKey(ctx context.Context, userID string, req *RegisterAppAttestKeyRequest) error {
+	if s.appAttest == nil {
+		return ErrPlatformUnsupported
+	}
+	if err := s.consumeChallenge(ctx, userID, req.Challenge); err != nil {
+		return err
+	}
+
+	attestationObject, err := base64.StdEncoding.DecodeString(req.Attestation)
+	if err != nil {
+		return fmt.Errorf("%w: attestation is not valid base64", ErrVerificationFailed)
+	}
+
+	key, err := s.appAttest.VerifyAttestation(req.KeyID, attestationObject, []byte(req.Challenge))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+
+	if err := s.queries.CreateAppAttestKey(ctx, pgdb.CreateAppAttestKeyParams{
+		KeyID:       req.KeyID,
+		UserID:      userID,
+		PublicKey:   key.PublicKey,
+		Environment: key.Environment,
+	}); err != nil {
+		return fmt.Errorf("failed to store app attest key: %w", err)
+	}
+
+	return nil
+}
+
+// Verify checks the evidence presented with a protected request.
+// The challenge is consumed even if verification fails, so each attempt needs a new one.
+func (s *Service) Verify(ctx context.Context, userID string, evidence Evidence) error {
+	if evidence.Challenge == "" || evidence.Token == "" {
+		return ErrMissingEvidence
+	}
+
+	switch evidence.Platform {
+	case PlatformIOS:
+		if s.appAttest == nil {
+			return ErrPlatformUnsupported
+		}
+		if err := s.consumeChallenge(ctx, userID, evidence.Challenge); err != nil {
+			return err
+		}
+		return s.verifyAppAttestAssertion(ctx, userID, evidence)
+	case PlatformAndroid:
+		if s.playIntegrity == nil {
+			return ErrPlatformUnsupported
+		}
+		if err := s.consumeChallenge(ctx, userID, evidence.Challenge); err != nil {
+			return err
+		}
+		if err := s.playIntegrity.Verify(ctx, evidence.Token, evidence.Challenge); err != nil {
+			return fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+		}
+		return nil
+	default:
+		return ErrPlatformUnsupported
+	}
+}
+
+func (s *Service) verifyAppAttestAssertion(ctx context.Context, userID string, evidence Evidence) error {
+	key, err := s.queries.GetAppAttestKey(ctx, evidence.KeyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUnknownKey
+		}
+		return fmt.Errorf("failed to get app attest key: %w", err)
+	}
+	if key.UserID != userID {
+		return ErrUnknownKey
+	}
+
+	assertion, err := base64.StdEncoding.DecodeString(evidence.Token)
+	if err != nil {
+		return fmt.Errorf("%w: assertion is not valid base64", ErrVerificationFailed)
+	}
+
+	counter, err := s.appAttest.VerifyAssertion(key.PublicKey, key.SignCount, assertion, []byte(evidence.Challenge))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+
+	if _, err := s.queries.UpdateAppAttestKeySignCount(ctx, pgdb.UpdateAppAttestKeySignCountParams{
+		KeyID:     key.KeyID,
+		SignCount: counter,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// A concurrent request already used this counter value
+			return fmt.Errorf("%w: assertion replayed", ErrVerificationFailed)
+		}
+		return fmt.Errorf("failed to update app attest counter: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) consumeChallenge(ctx context.Context, userID, challenge string) error {
+	if _, err := s.queries.ConsumeAttestationChallenge(ctx, pgdb.ConsumeAttestationChallengeParams{
+		Challenge: challenge,
+		UserID:    userID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidChallenge
+		}
+		return fmt.Errorf("failed to consume challenge: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package audiousage measures audio requests for usage accounting: seconds transcribed or
+// synthesized, converted to plan tokens at a configurable per-minute rate.
+package audiousage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"unicode/utf8"
+)
+
+const (
+	// assumedBitrate (bytes/second) estimates the length of compressed clips we can't parse:
+	// 128 kbps, a common upper bound for speech recordings, so estimates err on the short side.
+	assumedBitrate = 128_000 / 8
+
+	// speechCharactersPerSecond estimates synthesized speech length (~150 words per minute).
+	speechCharactersPerSecond = 15.0
+)
+
+// Usage is the audio usage of a single request.
+type Usage struct {
+	Seconds    float64 // Audio transcribed or synthesized
+	Characters int     // Characters synthesized (speech only)
+	Measured   bool    // Seconds reported by the provider or read from the audio, not estimated
+}
+
+// PlanTokens converts audio seconds to plan tokens at the given per-minute rate, rounding up.
+func PlanTokens(seconds float64, tokensPerMinute int) int {
+	if seconds <= 0 || tokensPerMinute <= 0 {
+		return 0
+	}
+	return int(math.Ceil(seconds / 60 * float64(tokensPerMinute)))
+}
+
+// ForSpeech estimates the usage of synthesizing text.
+func ForSpeech(text string) Usage {
+	characters := utf8.RuneCountInString(text)
+	return Usage{
+		Seconds:    float64(characters) / speechCharactersPerSecond,
+		Characters: characters,
+	}
+}
+
+// TranscriptionSeconds reads the audio duration reported in a transcription or translation
+// response: the usage of duration-billed models, or the "duration" of verbose_json responses.
+func TranscriptionSeconds(body []byte) (float64, bool) {
+	var response struct {
+		Duration *float64 `json:"duration"`
+		Usage    *struct {
+			Type    string  `json:"type"`
+			Seconds float64 `json:"seconds"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, false
+	}
+	if response.Usage != nil && response.Usage.Type == "duration" && response.Usage.Seconds > 0 {
+		return response.Usage.Seconds, true
+	}
+	if response.Duration != nil && *response.Duration > 0 {
+		return *response.Duration, true
+	}
+	return 0, false
+}
+
+// ForClip measures an uploaded clip: exactly for WAV, estimated from size otherwise.
+func ForClip(data []byte) Usage {
+	if seconds, ok := wavSeconds(data); ok {
+		return Usage{Seconds: seconds, Measured: true}
+	}
+	return Usage{Seconds: float64(len(data)) / assumedBitrate}
+}
+
+// wavSeconds reads the duration from a RIFF/WAVE header ("fmt " and "data" chunks).
+func wavSeconds(data []byte) (float64, bool) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, false
+	}
+
+	var byteRate uint32
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := binary.LittleEndian.Uint32(data[offset+4 : offset+8])
+		body := offset + 8
+
+		switch id {
+		case "fmt ":
+			if body+12 > len(data) {
+				return 0, false
+			}
+			byteRate = binary.LittleEndian.Uint32(data[body+8 : body+12])
+		case "data":
+			if byteRate == 0 {
+				return 0, false
+			}
+			// Streamed WAVs may have a placeholder size; use the bytes actually present.
+			available := uint32(len(data) - body)
+			if size == 0 || size > available {
+				size = available
+			}
+			return float64(size) / float64(byteRate), true
+		}
+
+		offset = body + int(size) + int(size%2) // Chunks are word-aligned
+	}
+	return 0, false
+}
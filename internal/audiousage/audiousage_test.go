@@ -0,0 +1,102 @@
+package audiousage
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// wav builds a PCM WAV file with the given byte rate and data length.
+func wav(byteRate uint32, dataLen int, dataSize uint32) []byte {
+	buf := []byte("RIFF\x00\x00\x00\x00WAVE")
+
+	buf = append(buf, "fmt "...)
+	buf = binary.LittleEndian.AppendUint32(buf, 16)
+	fmtChunk := make([]byte, 16)
+	binary.LittleEndian.PutUint16(fmtChunk[0:2], 1)
+	binary.LittleEndian.PutUint32(fmtChunk[8:12], byteRate)
+	buf = append(buf, fmtChunk...)
+
+	buf = append(buf, "LIST"...)
+	buf = binary.LittleEndian.AppendUint32(buf, 3)
+	buf = append(buf, "abc\x00"...) // Odd-sized chunk plus padding byte
+
+	buf = append(buf, "data"...)
+	buf = binary.LittleEndian.AppendUint32(buf, dataSize)
+	return append(buf, make([]byte, dataLen)...)
+}
+
+func TestForClip(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []byte
+		wantSeconds  float64
+		wantMeasured bool
+	}{
+		{"wav", wav(32000, 64000, 64000), 2, true},
+		{"wav with placeholder size", wav(32000, 16000, 0), 0.5, true},
+		{"wav with oversized data chunk", wav(32000, 16000, 0xFFFFFFFF), 0.5, true},
+		{"compressed clip estimated at 128 kbps", make([]byte, 48000), 3, false},
+		{"truncated wav header estimated", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), 16.0 / assumedBitrate, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ForClip(tt.data)
+			if got.Seconds != tt.wantSeconds || got.Measured != tt.wantMeasured {
+				t.Errorf("ForClip() = %+v, want %v seconds (measured %v)", got, tt.wantSeconds, tt.wantMeasured)
+			}
+		})
+	}
+}
+
+func TestForSpeech(t *testing.T) {
+	got := ForSpeech("héllo wörld, this is thirty ch")
+	if got.Characters != 30 || got.Seconds != 2 || got.Measured {
+		t.Errorf("ForSpeech() = %+v, want 30 characters over 2 seconds", got)
+	}
+}
+
+func TestTranscriptionSeconds(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   float64
+		wantOK bool
+	}{
+		{"duration usage", `{"text":"hi","usage":{"type":"duration","seconds":12}}`, 12, true},
+		{"verbose json", `{"text":"hi","duration":8.5,"segments":[]}`, 8.5, true},
+		{"token usage", `{"text":"hi","usage":{"type":"tokens","total_tokens":40}}`, 0, false},
+		{"plain text response", "hi there", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := TranscriptionSeconds([]byte(tt.body))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("TranscriptionSeconds() = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPlanTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds float64
+		rate    int
+		want    int
+	}{
+		{"one minute", 60, 1000, 1000},
+		{"rounds up", 1, 1000, 17},
+		{"no audio", 0, 1000, 0},
+		{"disabled rate", 60, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlanTokens(tt.seconds, tt.rate); got != tt.want {
+				t.Errorf("PlanTokens(%v, %d) = %d, want %d", tt.seconds, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
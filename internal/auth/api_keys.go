@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// Scopes of service API keys: the routes a key may call.
+const (
+	ScopeProxy  = "proxy"  // AI endpoints (/chat/completions, /responses, /embeddings, ...)
+	ScopeSearch = "search" // Search endpoints (/api/v1/search, ...)
+	ScopeAdmin  = "admin"  // Admin API (/admin), alongside ADMIN_API_KEY
+)
+
+// Scopes are the valid scopes of service API keys.
+var Scopes = []string{ScopeProxy, ScopeSearch, ScopeAdmin}
+
+// APIKeyPrefix starts every service API key, telling them apart from user tokens.
+const APIKeyPrefix = "eak_"
+
+const (
+	// apiKeyCacheTTL is how long validated keys (and unknown ones) are cached, so revoking a
+	// key applies within it on every instance.
+	apiKeyCacheTTL = time.Minute
+
+	// apiKeyCacheSize bounds the cache, which is cleared when full (unknown keys are cached too).
+	apiKeyCacheSize = 10000
+
+	apiKeyLookupTimeout = 5 * time.Second
+)
+
+// ErrInvalidScope is returned for scopes that aren't one of Scopes.
+var ErrInvalidScope = errors.New("invalid scope")
+
+// APIKeyValidator authenticates service-to-service callers (internal services, partners) with
+// API keys stored hashed in Postgres, and delegates every other token to the wrapped validator.
+// Claims of a key carry its name and scopes; requests act as the key's user ID.
+type APIKeyValidator struct {
+	next    TokenValidator
+	queries pgdb.Querier
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey // Key hash -> lookup result
+}
+
+type cachedAPIKey struct {
+	row      *pgdb.ServiceApiKey // nil = unknown key
+	cachedAt time.Time
+}
+
+// NewAPIKeyValidator wraps next so that service API keys (APIKeyPrefix) are validated against
+// the service_api_keys table.
+func NewAPIKeyValidator(next TokenValidator, queries pgdb.Querier) *APIKeyValidator {
+	return &APIKeyValidator{
+		next:    next,
+		queries: queries,
+		now:     time.Now,
+		cache:   make(map[string]cachedAPIKey),
+	}
+}
+
+func (v *APIKeyValidator) ExtractClaims(tokenString string) (*TokenClaims, error) {
+	if !strings.HasPrefix(tokenString, APIKeyPrefix) {
+		return v.next.ExtractClaims(tokenString)
+	}
+	return v.ValidateAPIKey(tokenString)
+}
+
+// ValidateAPIKey returns the claims of a service API key: ErrInvalidToken for unknown or
+// revoked keys, ErrExpiredToken for expired ones.
+func (v *APIKeyValidator) ValidateAPIKey(key string) (*TokenClaims, error) {
+	row, err := v.lookup(HashAPIKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if row == nil || row.RevokedAt.Valid {
+		return nil, ErrInvalidToken
+	}
+	if row.ExpiresAt.Valid && !v.now().Before(row.ExpiresAt.Time) {
+		return nil, ErrExpiredToken
+	}
+	return &TokenClaims{UserID: row.UserID, APIKey: row.Name, Scopes: ParseScopes(row.Scopes)}, nil
+}
+
+// lookup returns the row of a key hash (nil if unknown), from the cache if fresh.
+func (v *APIKeyValidator) lookup(hash string) (*pgdb.ServiceApiKey, error) {
+	now := v.now()
+	v.mu.Lock()
+	cached, ok := v.cache[hash]
+	v.mu.Unlock()
+	if ok && now.Sub(cached.cachedAt) < apiKeyCacheTTL {
+		return cached.row, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), apiKeyLookupTimeout)
+	defer cancel()
+
+	var row *pgdb.ServiceApiKey
+	found, err := v.queries.GetServiceAPIKeyByHash(ctx, hash)
+	switch {
+	case err == nil:
+		row = &found
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	v.mu.Lock()
+	if len(v.cache) >= apiKeyCacheSize {
+		clear(v.cache)
+	}
+	v.cache[hash] = cachedAPIKey{row: row, cachedAt: now}
+	v.mu.Unlock()
+	return row, nil
+}
+
+// GenerateAPIKey returns a new service API key, its hash (stored) and its display prefix.
+func GenerateAPIKey() (key, hash, prefix string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + hex.EncodeToString(secret)
+	return key, HashAPIKey(key), key[:len(APIKeyPrefix)+8], nil
+}
+
+// HashAPIKey returns the hash under which a key is stored (hex SHA-256; keys are random, so
+// a fast hash is enough).
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseScopes parses the comma-separated scopes of a key.
+func ParseScopes(value string) []string {
+	scopes := []string{}
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// ValidateScopes checks that scopes is a non-empty list of Scopes without duplicates and
+// returns them comma-separated.
+func ValidateScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", fmt.Errorf("%w: at least one of %s is required", ErrInvalidScope, strings.Join(Scopes, ", "))
+	}
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return "", fmt.Errorf("%w: %q is not one of %s", ErrInvalidScope, scope, strings.Join(Scopes, ", "))
+		}
+		if seen[scope] {
+			return "", fmt.Errorf("%w: %q is repeated", ErrInvalidScope, scope)
+		}
+		seen[scope] = true
+	}
+	return strings.Join(scopes, ","), nil
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyStore keeps service API keys in memory by hash; other Querier methods are not used.
+type apiKeyStore struct {
+	pgdb.Querier
+	rows    map[string]pgdb.ServiceApiKey
+	lookups int
+}
+
+func (s *apiKeyStore) GetServiceAPIKeyByHash(_ context.Context, keyHash string) (pgdb.ServiceApiKey, error) {
+	s.lookups++
+	row, ok := s.rows[keyHash]
+	if !ok {
+		return pgdb.ServiceApiKey{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+// userTokens accepts "user-token" as user-1.
+type userTokens struct{}
+
+func (userTokens) ExtractClaims(token string) (*TokenClaims, error) {
+	if token != "user-token" {
+		return nil, ErrInvalidToken
+	}
+	return &TokenClaims{UserID: "user-1"}, nil
+}
+
+// newAPIKey stores a key with scopes and returns it.
+func (s *apiKeyStore) newAPIKey(t *testing.T, name, scopes string) string {
+	t.Helper()
+	key, hash, prefix, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, APIKeyPrefix) || !strings.HasPrefix(key, prefix) || hash != HashAPIKey(key) {
+		t.Fatalf("GenerateAPIKey = %q, %q, %q", key, hash, prefix)
+	}
+	s.rows[hash] = pgdb.ServiceApiKey{Name: name, KeyHash: hash, KeyPrefix: prefix, Scopes: scopes, UserID: "service:" + name}
+	return key
+}
+
+func TestAPIKeyValidator(t *testing.T) {
+	store := &apiKeyStore{rows: map[string]pgdb.ServiceApiKey{}}
+	validator := NewAPIKeyValidator(userTokens{}, store)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	validator.now = func() time.Time { return now }
+
+	// User tokens go to the wrapped validator
+	if claims, err := validator.ExtractClaims("user-token"); err != nil || claims.UserID != "user-1" || claims.APIKey != "" {
+		t.Fatalf("ExtractClaims(user-token) = %+v, %v", claims, err)
+	}
+
+	key := store.newAPIKey(t, "indexer", "proxy,search")
+	claims, err := validator.ExtractClaims(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != "service:indexer" || claims.APIKey != "indexer" || strings.Join(claims.Scopes, ",") != "proxy,search" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	// Lookups are cached, unknown keys included
+	validator.ExtractClaims(key) //nolint:errcheck
+	if store.lookups != 1 {
+		t.Errorf("lookups = %d, want 1 (cached)", store.lookups)
+	}
+	for range 2 {
+		if _, err := validator.ExtractClaims(APIKeyPrefix + "unknown"); !stderrors.Is(err, ErrInvalidToken) {
+			t.Errorf("unknown key error = %v, want ErrInvalidToken", err)
+		}
+	}
+	if store.lookups != 2 {
+		t.Errorf("lookups = %d, want 2 (unknown key cached)", store.lookups)
+	}
+
+	// Revocation applies once the cache expires
+	row := store.rows[HashAPIKey(key)]
+	row.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	store.rows[row.KeyHash] = row
+	if _, err := validator.ExtractClaims(key); err != nil {
+		t.Errorf("cached key error = %v, want valid until the cache expires", err)
+	}
+	now = now.Add(apiKeyCacheTTL)
+	if _, err := validator.ExtractClaims(key); !stderrors.Is(err, ErrInvalidToken) {
+		t.Errorf("revoked key error = %v, want ErrInvalidToken", err)
+	}
+
+	expiring := store.newAPIKey(t, "partner", "proxy")
+	row = store.rows[HashAPIKey(expiring)]
+	row.ExpiresAt = sql.NullTime{Time: now, Valid: true}
+	store.rows[row.KeyHash] = row
+	if _, err := validator.ExtractClaims(expiring); !stderrors.Is(err, ErrExpiredToken) {
+		t.Errorf("expired key error = %v, want ErrExpiredToken", err)
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if scopes, err := ValidateScopes([]string{"search", "admin"}); err != nil || scopes != "search,admin" {
+		t.Errorf("ValidateScopes = %q, %v", scopes, err)
+	}
+	for _, scopes := range [][]string{nil, {"chat"}, {"proxy", "proxy"}} {
+		if _, err := ValidateScopes(scopes); !stderrors.Is(err, ErrInvalidScope) {
+			t.Errorf("ValidateScopes(%v) error = %v, want ErrInvalidScope", scopes, err)
+		}
+	}
+}
+
+func TestRestrictAPIKeys(t *testing.T) {
+	store := &apiKeyStore{rows: map[string]pgdb.ServiceApiKey{}}
+	validator := NewAPIKeyValidator(userTokens{}, store)
+	searchKey := store.newAPIKey(t, "search-only", "search")
+	adminKey := store.newAPIKey(t, "ops", "admin")
+	firebaseAuth, _ := NewFirebaseAuthMiddleware(validator)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	admin := router.Group("/admin")
+	admin.Use(NewAPIKeyMiddleware("admin-secret").WithServiceKeys(validator, ScopeAdmin).RequireAPIKey())
+	admin.GET("/streams", ok)
+	router.Use(firebaseAuth.RequireAuth(), RestrictAPIKeys(ScopeRoutes{
+		ScopeProxy:  {"/chat/completions"},
+		ScopeSearch: {"/api/v1/search"},
+	}))
+	router.POST("/chat/completions", ok)
+	router.POST("/api/v1/search", ok)
+	router.POST("/api/v1/search/fetch", ok)
+	router.POST("/api/v1/searches", ok)
+
+	request := func(method, path, token string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for _, tc := range []struct {
+		method, path, token string
+		want                int
+	}{
+		{http.MethodPost, "/api/v1/search", searchKey, http.StatusOK},
+		{http.MethodPost, "/api/v1/search/fetch", searchKey, http.StatusOK},
+		{http.MethodPost, "/api/v1/searches", searchKey, http.StatusForbidden},
+		{http.MethodPost, "/chat/completions", searchKey, http.StatusForbidden},
+		{http.MethodPost, "/chat/completions", "user-token", http.StatusOK},
+		{http.MethodPost, "/chat/completions", APIKeyPrefix + "unknown", http.StatusUnauthorized},
+		{http.MethodGet, "/admin/streams", "admin-secret", http.StatusOK},
+		{http.MethodGet, "/admin/streams", adminKey, http.StatusOK},
+		{http.MethodGet, "/admin/streams", searchKey, http.StatusForbidden},
+		{http.MethodGet, "/admin/streams", "user-token", http.StatusUnauthorized},
+	} {
+		if code := request(tc.method, tc.path, tc.token); code != tc.want {
+			t.Errorf("%s %s with %.12s = %d, want %d", tc.method, tc.path, tc.token, code, tc.want)
+		}
+	}
+}
@@ -204,9 +204,10 @@ type DeepResearchSessionState struct {
 // DeepResearchState represents the state of a deep research session on a chat document.
 type DeepResearchState struct {
 	StartedAt     time.Time          `firestore:"startedAt" json:"startedAt"`
-	Status        string             `firestore:"status" json:"status"`                                   // "in_progress", "clarify", "error", "complete"
+	Status        string             `firestore:"status" json:"status"`                                   // "queued", "in_progress", "clarify", "error", "complete"
 	ThinkingState string             `firestore:"thinkingState,omitempty" json:"thinkingState,omitempty"` // Latest progress message
 	Error         *DeepResearchError `firestore:"error,omitempty" json:"error,omitempty"`
+	QueuePosition int                `firestore:"queuePosition" json:"queuePosition,omitempty"` // Position while "queued" (1 = next), 0 otherwise
 }
 
 // DeepResearchError contains error information for a failed deep research session.
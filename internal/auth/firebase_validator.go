@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"fmt"
+	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
@@ -30,20 +31,42 @@ func NewFirebaseTokenValidator(ctx context.Context, credJSON string) (*FirebaseT
 	}, nil
 }
 
-// Checks that this user's auth token is valid and extracts + returns the user's Firebase ID
-// from "sub" - which according to Firebase docs should always be present: https://firebase.google.com/docs/auth/admin/verify-id-tokens#go
-func (f *FirebaseTokenValidator) ExtractUserID(tokenString string) (string, error) {
+// Checks that this user's auth token is valid and extracts the user's Firebase ID from "sub" -
+// which according to Firebase docs should always be present: https://firebase.google.com/docs/auth/admin/verify-id-tokens#go
+// The "sandbox" custom claim is set on developer test accounts. The email is only kept when
+// Firebase marks it as verified.
+func (f *FirebaseTokenValidator) ExtractClaims(tokenString string) (*TokenClaims, error) {
 	ctx := context.Background()
 
 	token, err := f.authClient.VerifyIDToken(ctx, tokenString)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	sub := token.Subject
-	if sub != "" {
-		return sub, nil
+	if sub == "" {
+		return nil, fmt.Errorf("no Firebase UID (sub claim) found in token")
 	}
 
-	return "", fmt.Errorf("no Firebase UID (sub claim) found in token")
+	sandbox, _ := token.Claims["sandbox"].(bool)
+	claims := &TokenClaims{UserID: sub, Sandbox: sandbox}
+	if verified, _ := token.Claims["email_verified"].(bool); verified {
+		claims.Email, _ = token.Claims["email"].(string)
+	}
+	return claims, nil
+}
+
+// AccessRevokedSince reports whether the user's access was revoked after the given time: their
+// refresh tokens were revoked, or the account was disabled or deleted. Used to stop work already
+// in flight, which VerifyIDToken alone can't catch.
+func (f *FirebaseTokenValidator) AccessRevokedSince(ctx context.Context, userID string, since time.Time) (bool, error) {
+	user, err := f.authClient.GetUser(ctx, userID)
+	if err != nil {
+		if auth.IsUserNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return user.Disabled || user.TokensValidAfterMillis > since.UnixMilli(), nil
 }
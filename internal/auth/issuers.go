@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// IssuerFirebase selects Firebase token validation for an issuer of AUTH_ISSUERS; as a bare
+// entry, it stands for the Firebase issuer of the project.
+const IssuerFirebase = "firebase"
+
+// issuerUsersSize bounds the users remembered for revocation checks; the map is cleared when full.
+const issuerUsersSize = 100000
+
+// FirebaseIssuer returns the iss claim of Firebase ID tokens of a project.
+func FirebaseIssuer(projectID string) string {
+	return "https://securetoken.google.com/" + projectID
+}
+
+// ParseIssuers parses AUTH_ISSUERS: comma-separated ISSUER=JWKS_URL or ISSUER=firebase
+// entries, and "firebase" for the Firebase issuer of firebaseProjectID. Returns the JWKS URL
+// (or IssuerFirebase) by issuer.
+func ParseIssuers(value, firebaseProjectID string) (map[string]string, error) {
+	issuers := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		issuer, source, ok := strings.Cut(entry, "=")
+		issuer, source = strings.TrimSpace(issuer), strings.TrimSpace(source)
+		if !ok && issuer == IssuerFirebase {
+			if firebaseProjectID == "" {
+				return nil, fmt.Errorf("AUTH_ISSUERS entry %q requires FIREBASE_PROJECT_ID", entry)
+			}
+			issuer, source = FirebaseIssuer(firebaseProjectID), IssuerFirebase
+		}
+		if issuer == "" || source == "" {
+			return nil, fmt.Errorf("invalid AUTH_ISSUERS entry %q: expected ISSUER=JWKS_URL, ISSUER=firebase or firebase", entry)
+		}
+		if _, ok := issuers[issuer]; ok {
+			return nil, fmt.Errorf("AUTH_ISSUERS lists issuer %s more than once", issuer)
+		}
+		issuers[issuer] = source
+	}
+	if len(issuers) == 0 {
+		return nil, fmt.Errorf("AUTH_ISSUERS has no issuers")
+	}
+	return issuers, nil
+}
+
+// IssuerValidator validates tokens with the validator of their issuer (iss claim), so that
+// several identity providers can be accepted at once, e.g. while migrating users off Firebase.
+// Tokens of other issuers are rejected.
+type IssuerValidator struct {
+	validators map[string]TokenValidator
+
+	mu    sync.Mutex
+	users map[string]string // User ID -> issuer of their last token
+}
+
+// NewIssuerValidator creates a validator dispatching on the iss claim to validators by issuer.
+func NewIssuerValidator(validators map[string]TokenValidator) *IssuerValidator {
+	return &IssuerValidator{
+		validators: validators,
+		users:      make(map[string]string),
+	}
+}
+
+func (v *IssuerValidator) ExtractClaims(tokenString string) (*TokenClaims, error) {
+	// The issuer only selects the validator, which verifies the token
+	var registered jwt.RegisteredClaims
+	if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, &registered); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	validator, ok := v.validators[registered.Issuer]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown issuer %q", ErrInvalidToken, registered.Issuer)
+	}
+
+	claims, err := validator.ExtractClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	if len(v.users) >= issuerUsersSize {
+		clear(v.users)
+	}
+	v.users[claims.UserID] = registered.Issuer
+	v.mu.Unlock()
+	return claims, nil
+}
+
+// AccessRevokedSince checks revocation with the validator of the user's last token, if it
+// supports it (Firebase). Users not seen by this instance are reported as not revoked: user
+// IDs of one issuer are unknown to the others.
+func (v *IssuerValidator) AccessRevokedSince(ctx context.Context, userID string, since time.Time) (bool, error) {
+	v.mu.Lock()
+	issuer, ok := v.users[userID]
+	v.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	checker, ok := v.validators[issuer].(interface {
+		AccessRevokedSince(ctx context.Context, userID string, since time.Time) (bool, error)
+	})
+	if !ok {
+		return false, nil
+	}
+	return checker.AccessRevokedSince(ctx, userID, since)
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// issuerTokens accepts every token as user, and reports the users in revoked as revoked.
+type issuerTokens struct {
+	user    string
+	revoked map[string]bool
+}
+
+func (v *issuerTokens) ExtractClaims(string) (*TokenClaims, error) {
+	return &TokenClaims{UserID: v.user}, nil
+}
+
+func (v *issuerTokens) AccessRevokedSince(_ context.Context, userID string, _ time.Time) (bool, error) {
+	return v.revoked[userID], nil
+}
+
+func TestParseIssuers(t *testing.T) {
+	issuers, err := ParseIssuers(" firebase , https://id.example.com=https://id.example.com/jwks.json?v=1,,", "proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issuers) != 2 || issuers["https://securetoken.google.com/proj"] != IssuerFirebase ||
+		issuers["https://id.example.com"] != "https://id.example.com/jwks.json?v=1" {
+		t.Errorf("unexpected issuers: %v", issuers)
+	}
+
+	for _, value := range []string{"", "https://id.example.com", "https://id.example.com=", "=https://id.example.com/jwks.json", "a=firebase,a=firebase"} {
+		if _, err := ParseIssuers(value, "proj"); err == nil {
+			t.Errorf("ParseIssuers(%q) should fail", value)
+		}
+	}
+	if _, err := ParseIssuers("firebase", ""); err == nil {
+		t.Error("the firebase entry should require a project ID")
+	}
+}
+
+func TestIssuerValidator(t *testing.T) {
+	firebase := &issuerTokens{user: "firebase-user", revoked: map[string]bool{"firebase-user": true}}
+	identity := &issuerTokens{user: "identity-user"}
+	validator := NewIssuerValidator(map[string]TokenValidator{
+		"https://securetoken.google.com/proj": firebase,
+		"https://id.example.com":              identity,
+	})
+	token := func(issuer string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Issuer: issuer, Subject: "x"}).SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	for issuer, want := range map[string]string{"https://securetoken.google.com/proj": "firebase-user", "https://id.example.com": "identity-user"} {
+		if claims, err := validator.ExtractClaims(token(issuer)); err != nil || claims.UserID != want {
+			t.Errorf("ExtractClaims(%s) = %+v, %v, want %s", issuer, claims, err, want)
+		}
+	}
+	for _, bad := range []string{token("https://evil.example.com"), token(""), "not-a-jwt"} {
+		if _, err := validator.ExtractClaims(bad); !stderrors.Is(err, ErrInvalidToken) {
+			t.Errorf("ExtractClaims(%.20s) error = %v, want ErrInvalidToken", bad, err)
+		}
+	}
+
+	// Revocation is checked with the validator of the user's issuer
+	for userID, want := range map[string]bool{"firebase-user": true, "identity-user": false, "unseen-user": false} {
+		if revoked, err := validator.AccessRevokedSince(context.Background(), userID, time.Now()); err != nil || revoked != want {
+			t.Errorf("AccessRevokedSince(%s) = %v, %v, want %v", userID, revoked, err, want)
+		}
+	}
+}
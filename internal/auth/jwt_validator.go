@@ -55,47 +55,37 @@ func (v *JWTTokenValidator) RefreshKeys() error {
 	return nil
 }
 
-func (v *JWTTokenValidator) ExtractUserID(tokenString string) (string, error) {
+func (v *JWTTokenValidator) ExtractClaims(tokenString string) (*TokenClaims, error) {
 	// In development mode, extract user ID without validation
 	if v.devMode {
 		// Parse without verification
 		token, _, err := new(jwt.Parser).ParseUnverified(tokenString, &StandardClaims{})
 		if err != nil {
-			return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+			return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 		}
 
 		if claims, ok := token.Claims.(*StandardClaims); ok {
-			// Prioritize sub, then user_id, then email as fallback.
-			if claims.Sub != "" {
-				return claims.Sub, nil
-			}
-			if claims.UserId != "" {
-				return claims.UserId, nil
-			}
-			if claims.Email != "" {
-				return claims.Email, nil
-			}
-			return "", fmt.Errorf("%w: no sub, user_id, or email found in token claims", ErrInvalidToken)
+			return tokenClaims(claims)
 		}
 
-		return "", ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	// In production mode, validate the token first
 	if v.keySet == nil {
-		return "", ErrNoJWKS
+		return nil, ErrNoJWKS
 	}
 
 	// First, parse the token header to get the key ID without validation
 	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, &StandardClaims{})
 	if err != nil {
-		return "", fmt.Errorf("%w: failed to parse token header: %v", ErrInvalidToken, err)
+		return nil, fmt.Errorf("%w: failed to parse token header: %v", ErrInvalidToken, err)
 	}
 
 	// Get the key ID from the token header
 	kid, ok := token.Header["kid"].(string)
 	if !ok {
-		return "", fmt.Errorf("%w: token header missing kid", ErrInvalidToken)
+		return nil, fmt.Errorf("%w: token header missing kid", ErrInvalidToken)
 	}
 
 	// Find the key with the matching ID
@@ -103,7 +93,7 @@ func (v *JWTTokenValidator) ExtractUserID(tokenString string) (string, error) {
 	if !found {
 		// Try refreshing the keys
 		if err := v.RefreshKeys(); err != nil {
-			return "", fmt.Errorf("%w: key with ID %s not found and failed to refresh keys: %v", ErrInvalidToken, kid, err)
+			return nil, fmt.Errorf("%w: key with ID %s not found and failed to refresh keys: %v", ErrInvalidToken, kid, err)
 		}
 
 		// Try again after refresh
@@ -115,14 +105,14 @@ func (v *JWTTokenValidator) ExtractUserID(tokenString string) (string, error) {
 				k, _ := v.keySet.Get(i)
 				availableKeys = append(availableKeys, k.KeyID())
 			}
-			return "", fmt.Errorf("%w: key with ID %s not found, available keys: %v", ErrInvalidToken, kid, availableKeys)
+			return nil, fmt.Errorf("%w: key with ID %s not found, available keys: %v", ErrInvalidToken, kid, availableKeys)
 		}
 	}
 
 	// Get the raw key
 	var rawKey interface{}
 	if err := key.Raw(&rawKey); err != nil {
-		return "", fmt.Errorf("%w: failed to get raw key: %v", ErrInvalidToken, err)
+		return nil, fmt.Errorf("%w: failed to get raw key: %v", ErrInvalidToken, err)
 	}
 
 	// Now validate the token with the found key
@@ -134,31 +124,38 @@ func (v *JWTTokenValidator) ExtractUserID(tokenString string) (string, error) {
 		},
 	)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
 	claims, ok := validatedToken.Claims.(*StandardClaims)
 	if !ok || !validatedToken.Valid {
-		return "", ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	// Check if token is expired
 	if !claims.VerifyExpiresAt(time.Now(), true) {
-		return "", ErrExpiredToken
+		return nil, ErrExpiredToken
 	}
 
-	// Prioritize sub, then user_id, then email as fallback.
-	if claims.Sub != "" {
-		return claims.Sub, nil
-	}
+	return tokenClaims(claims)
+}
 
-	if claims.UserId != "" {
-		return claims.UserId, nil
+// tokenClaims identifies the user by sub, then user_id, then email as fallback.
+func tokenClaims(claims *StandardClaims) (*TokenClaims, error) {
+	userID := claims.Sub
+	if userID == "" {
+		userID = claims.UserId
 	}
-
-	if claims.Email != "" {
-		return claims.Email, nil
+	if userID == "" {
+		userID = claims.Email
+	}
+	if userID == "" {
+		return nil, fmt.Errorf("%w: no sub, user_id, or email found in token claims", ErrInvalidToken)
 	}
 
-	return "", fmt.Errorf("%w: no sub, user_id, or email found in token claims", ErrInvalidToken)
+	tokenClaims := &TokenClaims{UserID: userID, Sandbox: claims.Sandbox}
+	if claims.EmailVerified {
+		tokenClaims.Email = claims.Email
+	}
+	return tokenClaims, nil
 }
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/subtle"
+	"slices"
 	"strings"
 
 	"github.com/eternisai/enchanted-proxy/internal/errors"
@@ -13,7 +14,10 @@ import (
 type contextKey string
 
 const (
-	UserIDKey contextKey = "user_id"
+	UserIDKey       contextKey = "user_id"
+	SandboxClaimKey contextKey = "sandbox_claim"
+	APIKeyClaimsKey contextKey = "api_key_claims"
+	EmailKey        contextKey = "verified_email"
 )
 
 type FirebaseAuthMiddleware struct {
@@ -60,20 +64,57 @@ func (f *FirebaseAuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		userID, err := f.validator.ExtractUserID(token)
+		claims, err := f.validator.ExtractClaims(token)
 		if err != nil {
 			errors.AbortWithUnauthorized(c, "Invalid or expired token", nil)
 			return
 		}
 
-		ctx := logger.WithUserID(c.Request.Context(), userID)
+		ctx := logger.WithUserID(c.Request.Context(), claims.UserID)
 		c.Request = c.Request.WithContext(ctx)
-		c.Set(string(UserIDKey), userID)
+		c.Set(string(UserIDKey), claims.UserID)
+		if claims.Sandbox {
+			c.Set(string(SandboxClaimKey), true)
+		}
+		if claims.Email != "" {
+			c.Set(string(EmailKey), claims.Email)
+		}
+		if claims.APIKey != "" {
+			c.Set(string(APIKeyClaimsKey), claims)
+		}
 
 		c.Next()
 	}
 }
 
+// ScopeRoutes maps scopes to the routes they grant service API keys access to, as gin route
+// paths: a path also covers the routes below it ("/files" covers "/files/:fileId/content").
+type ScopeRoutes map[string][]string
+
+// RestrictAPIKeys rejects requests authenticated with a service API key on routes outside the
+// key's scopes, including routes not assigned to any scope. Must run after RequireAuth; user
+// tokens are not restricted.
+func RestrictAPIKeys(routes ScopeRoutes) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetAPIKeyClaims(c)
+		if claims == nil {
+			c.Next()
+			return
+		}
+
+		path := c.FullPath()
+		for _, scope := range claims.Scopes {
+			for _, route := range routes[scope] {
+				if path == route || strings.HasPrefix(path, route+"/") {
+					c.Next()
+					return
+				}
+			}
+		}
+		errors.AbortWithForbidden(c, errors.ScopeNotAllowed(claims.Scopes))
+	}
+}
+
 func GetUserID(c *gin.Context) (string, bool) {
 	userID, exists := c.Get(string(UserIDKey))
 	if !exists {
@@ -84,9 +125,32 @@ func GetUserID(c *gin.Context) (string, bool) {
 	return id, ok
 }
 
-// APIKeyMiddleware validates requests using a static API key.
+// GetVerifiedEmail returns the verified email address of the user's token, if it has one.
+func GetVerifiedEmail(c *gin.Context) (string, bool) {
+	email := c.GetString(string(EmailKey))
+	return email, email != ""
+}
+
+// GetAPIKeyClaims returns the claims of the service API key the request authenticated with,
+// or nil for user tokens.
+func GetAPIKeyClaims(c *gin.Context) *TokenClaims {
+	claims, _ := c.Get(string(APIKeyClaimsKey))
+	apiKey, _ := claims.(*TokenClaims)
+	return apiKey
+}
+
+// HasSandboxClaim reports whether the user's token carries the "sandbox" custom claim.
+func HasSandboxClaim(c *gin.Context) bool {
+	return c.GetBool(string(SandboxClaimKey))
+}
+
+// APIKeyMiddleware validates requests using a static API key, or service API keys of a scope
+// (see WithServiceKeys).
 type APIKeyMiddleware struct {
 	apiKey string
+
+	serviceKeys *APIKeyValidator
+	scope       string
 }
 
 // NewAPIKeyMiddleware creates a new API key middleware with the provided key.
@@ -96,6 +160,13 @@ func NewAPIKeyMiddleware(apiKey string) *APIKeyMiddleware {
 	}
 }
 
+// WithServiceKeys also accepts service API keys having the scope.
+func (a *APIKeyMiddleware) WithServiceKeys(validator *APIKeyValidator, scope string) *APIKeyMiddleware {
+	a.serviceKeys = validator
+	a.scope = scope
+	return a
+}
+
 // RequireAPIKey is a middleware that validates Bearer token against the configured API key.
 func (a *APIKeyMiddleware) RequireAPIKey() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -117,6 +188,21 @@ func (a *APIKeyMiddleware) RequireAPIKey() gin.HandlerFunc {
 			return
 		}
 
+		if a.serviceKeys != nil && strings.HasPrefix(token, APIKeyPrefix) {
+			claims, err := a.serviceKeys.ValidateAPIKey(token)
+			if err != nil {
+				errors.AbortWithUnauthorized(c, "Invalid API key", nil)
+				return
+			}
+			if !slices.Contains(claims.Scopes, a.scope) {
+				errors.AbortWithForbidden(c, errors.ScopeNotAllowed(claims.Scopes))
+				return
+			}
+			c.Set(string(APIKeyClaimsKey), claims)
+			c.Next()
+			return
+		}
+
 		// Use constant-time comparison to prevent timing attacks
 		if subtle.ConstantTimeCompare([]byte(token), []byte(a.apiKey)) != 1 {
 			errors.AbortWithUnauthorized(c, "Invalid API key", nil)
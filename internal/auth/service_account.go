@@ -0,0 +1,23 @@
+package auth
+
+import "crypto/subtle"
+
+// ServiceAccountValidator accepts a static secret token for an internal service account (e.g.
+// the synthetic prober) and delegates every other token to the wrapped validator.
+type ServiceAccountValidator struct {
+	next   TokenValidator
+	token  []byte
+	userID string
+}
+
+// NewServiceAccountValidator wraps next so that token authenticates as userID.
+func NewServiceAccountValidator(next TokenValidator, token, userID string) *ServiceAccountValidator {
+	return &ServiceAccountValidator{next: next, token: []byte(token), userID: userID}
+}
+
+func (v *ServiceAccountValidator) ExtractClaims(tokenString string) (*TokenClaims, error) {
+	if len(v.token) > 0 && subtle.ConstantTimeCompare([]byte(tokenString), v.token) == 1 {
+		return &TokenClaims{UserID: v.userID}, nil
+	}
+	return v.next.ExtractClaims(tokenString)
+}
@@ -18,9 +18,31 @@ type StandardClaims struct {
 	Sub    string `json:"sub"`
 	UserId string `json:"user_id"`
 	Email  string `json:"email"`
+
+	EmailVerified bool `json:"email_verified"`
+
+	// Custom claims
+	Sandbox bool `json:"sandbox"`
+
 	jwt.RegisteredClaims
 }
 
+// TokenClaims are the claims of a validated token the proxy acts on.
+type TokenClaims struct {
+	UserID string
+
+	// Email is the user's email address, set only when the token marks it as verified.
+	Email string
+
+	// Sandbox is set by the "sandbox" custom claim of developer test accounts (see internal/sandbox).
+	Sandbox bool
+
+	// APIKey is the name of the service API key the caller authenticated with (empty for user
+	// tokens), and Scopes the routes it may call (see RestrictAPIKeys).
+	APIKey string
+	Scopes []string
+}
+
 type TokenValidator interface {
-	ExtractUserID(tokenString string) (string, error)
+	ExtractClaims(tokenString string) (*TokenClaims, error)
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/tracing"
 	"log/slog"
 )
 
@@ -25,7 +26,8 @@ func NewOpenAIClient(apiKey, baseURL string, logger *logger.Logger) *OpenAIClien
 		apiKey:  apiKey,
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 5 * time.Minute, // Longer timeout for fetching large response content
+			Transport: tracing.Transport(http.DefaultTransport),
+			Timeout:   5 * time.Minute, // Longer timeout for fetching large response content
 			// Context timeout (30 min) will override if request takes longer
 		},
 		logger: logger,
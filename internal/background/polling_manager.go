@@ -12,6 +12,10 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
 	"github.com/eternisai/enchanted-proxy/internal/notifications"
 	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/tracing"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"log/slog"
 )
 
@@ -25,7 +29,7 @@ import (
 //
 // Thread-safety: All methods are thread-safe.
 type PollingManager struct {
-	workers             map[string]context.CancelFunc // response_id → cancel function
+	workers             map[string]*workerHandle // response_id → worker handle
 	workersMu           sync.RWMutex
 	messageService      *messaging.Service
 	trackingService     *request_tracking.Service
@@ -33,10 +37,16 @@ type PollingManager struct {
 	logger              *logger.Logger
 	cfg                 *config.Config
 	shutdown            chan struct{}
-	wg                  sync.WaitGroup
+	group               *worker.Group
 	activeCount         atomic.Int32
 }
 
+// workerHandle holds the controls for a single registered polling worker.
+type workerHandle struct {
+	cancel context.CancelFunc
+	wake   chan struct{} // Buffered (1) - signals the worker to poll immediately
+}
+
 // NewPollingManager creates a new polling manager.
 func NewPollingManager(
 	messageService *messaging.Service,
@@ -46,13 +56,14 @@ func NewPollingManager(
 	cfg *config.Config,
 ) *PollingManager {
 	return &PollingManager{
-		workers:             make(map[string]context.CancelFunc),
+		workers:             make(map[string]*workerHandle),
 		messageService:      messageService,
 		trackingService:     trackingService,
 		notificationService: notificationService,
 		logger:              logger.WithComponent("polling_manager"),
 		cfg:                 cfg,
 		shutdown:            make(chan struct{}),
+		group:               worker.NewGroup("polling"),
 	}
 }
 
@@ -94,15 +105,20 @@ func (pm *PollingManager) StartPolling(ctx context.Context, job PollingJob, apiK
 	workerCtx, cancel := context.WithCancel(ctx)
 
 	// Register worker
+	handle := &workerHandle{
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+	}
 	pm.workersMu.Lock()
-	pm.workers[job.ResponseID] = cancel
+	pm.workers[job.ResponseID] = handle
 	pm.workersMu.Unlock()
 
 	pm.activeCount.Add(1)
 
 	// Spawn worker goroutine
-	pm.wg.Add(1)
-	go pm.runWorker(workerCtx, job, apiKey, baseURL, tokenMultiplier, cancel)
+	pm.group.Go(func() {
+		pm.runWorker(workerCtx, job, apiKey, baseURL, tokenMultiplier, handle)
+	})
 
 	pm.logger.Info("started background polling worker",
 		slog.String("response_id", job.ResponseID),
@@ -112,13 +128,16 @@ func (pm *PollingManager) StartPolling(ctx context.Context, job PollingJob, apiK
 }
 
 // runWorker runs a polling worker in a goroutine.
-func (pm *PollingManager) runWorker(ctx context.Context, job PollingJob, apiKey, baseURL string, tokenMultiplier float64, cancel context.CancelFunc) {
-	defer pm.wg.Done()
-	defer cancel()
+func (pm *PollingManager) runWorker(ctx context.Context, job PollingJob, apiKey, baseURL string, tokenMultiplier float64, handle *workerHandle) {
+	defer handle.cancel()
 	defer pm.activeCount.Add(-1)
 	defer pm.unregisterWorker(job.ResponseID)
 
 	startTime := time.Now()
+	ctx, span := tracing.StartChild(ctx, job.SpanContext, "background.poll_response",
+		attribute.String("response_id", job.ResponseID),
+		attribute.String("model", job.Model))
+	defer span.End()
 
 	pm.logger.Info("polling worker goroutine started",
 		slog.String("response_id", job.ResponseID),
@@ -129,9 +148,11 @@ func (pm *PollingManager) runWorker(ctx context.Context, job PollingJob, apiKey,
 
 	// Create worker with tracking service, notification service, and multiplier
 	worker := NewPollingWorker(job, openAIClient, pm.messageService, pm.trackingService, pm.notificationService, pm.logger, pm.cfg, tokenMultiplier)
+	worker.wake = handle.wake
 
 	// Run worker (blocks until done)
 	if err := worker.Run(ctx); err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		pm.logger.Error("polling worker exited with error",
 			slog.String("response_id", job.ResponseID),
 			slog.String("error", err.Error()),
@@ -166,14 +187,44 @@ func (pm *PollingManager) unregisterWorker(responseID string) {
 //   - responseID: The response ID to cancel
 func (pm *PollingManager) CancelPolling(responseID string) {
 	pm.workersMu.RLock()
-	cancel, exists := pm.workers[responseID]
+	handle, exists := pm.workers[responseID]
 	pm.workersMu.RUnlock()
 
 	if exists {
 		pm.logger.Info("cancelling polling worker",
 			slog.String("response_id", responseID))
-		cancel()
+		handle.cancel()
+	}
+}
+
+// NotifyResponseEvent wakes the polling worker for a response so it polls
+// OpenAI immediately instead of waiting for its next tick.
+//
+// Called by the OpenAI webhook receiver when a background response reaches a
+// terminal state. The worker still fetches the authoritative status and content
+// from OpenAI, so a spoofed or duplicated event can at most cause an extra poll.
+//
+// Parameters:
+//   - responseID: The response ID from the webhook event
+//
+// Returns:
+//   - bool: true if a worker for this response is running on this instance
+func (pm *PollingManager) NotifyResponseEvent(responseID string) bool {
+	pm.workersMu.RLock()
+	handle, exists := pm.workers[responseID]
+	pm.workersMu.RUnlock()
+
+	if !exists {
+		return false
 	}
+
+	// Non-blocking: if a wake-up is already pending the worker will poll anyway
+	select {
+	case handle.wake <- struct{}{}:
+	default:
+	}
+
+	return true
 }
 
 // GetActiveCount returns the number of active polling workers.
@@ -195,28 +246,23 @@ func (pm *PollingManager) Shutdown() error {
 
 	// Cancel all workers
 	pm.workersMu.Lock()
-	for responseID, cancel := range pm.workers {
+	for responseID, handle := range pm.workers {
 		pm.logger.Debug("cancelling worker during shutdown",
 			slog.String("response_id", responseID))
-		cancel()
+		handle.cancel()
 	}
 	pm.workersMu.Unlock()
 
 	// Wait for all workers to finish (with timeout)
-	done := make(chan struct{})
-	go func() {
-		pm.wg.Wait()
-		close(done)
-	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	select {
-	case <-done:
-		pm.logger.Info("all polling workers shut down successfully")
-		return nil
-	case <-time.After(30 * time.Second):
+	if err := pm.group.Wait(ctx); err != nil {
 		pm.logger.Warn("polling manager shutdown timed out, some workers may still be running")
 		return fmt.Errorf("shutdown timeout after 30 seconds")
 	}
+	pm.logger.Info("all polling workers shut down successfully")
+	return nil
 }
 
 // GetWorkerStatus returns debug information about active workers.
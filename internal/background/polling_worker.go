@@ -10,13 +10,14 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
 	"github.com/eternisai/enchanted-proxy/internal/notifications"
 	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"go.opentelemetry.io/otel/trace"
 	"log/slog"
 )
 
 // PollingWorker polls OpenAI for a single background response.
 //
 // Lifecycle:
-//  1. Start polling every N seconds
+//  1. Start polling every N seconds (or immediately when woken by a webhook)
 //  2. Update Firestore generationState as status changes
 //  3. When completed: fetch full response, save to Firestore, log token usage
 //  4. When failed: save error to Firestore
@@ -32,7 +33,8 @@ type PollingWorker struct {
 	logger              *logger.Logger
 	pollCount           int
 	cfg                 *config.Config
-	tokenMultiplier     float64 // Cost multiplier for this model (e.g., 50× for GPT-5 Pro)
+	tokenMultiplier     float64         // Cost multiplier for this model (e.g., 50× for GPT-5 Pro)
+	wake                <-chan struct{} // Signals an immediate poll (e.g., from an OpenAI webhook). nil = ticker only
 }
 
 // NewPollingWorker creates a new polling worker.
@@ -103,107 +105,116 @@ func (w *PollingWorker) Run(ctx context.Context) error {
 			return ctx.Err()
 
 		case <-ticker.C:
-			w.pollCount++
+			// Regular scheduled poll
 
-			// Poll OpenAI
-			status, err := w.openAIClient.GetResponseStatus(ctx, w.job.ResponseID)
-			if err != nil {
-				w.logger.Error("failed to poll OpenAI",
-					slog.String("response_id", w.job.ResponseID),
-					slog.String("error", err.Error()),
-					slog.Int("poll_count", w.pollCount))
-
-				// Don't fail immediately - retry on next tick
-				// OpenAI might have transient issues
-				continue
-			}
-
-			// Update Firestore with current status
-			generationState := MapStatusToGenerationState(status.Status)
-			if err := w.updateFirestoreState(ctx, generationState); err != nil {
-				w.logger.Error("failed to update Firestore state",
-					slog.String("response_id", w.job.ResponseID),
-					slog.String("state", generationState),
-					slog.String("error", err.Error()))
-				// Continue polling even if Firestore update fails
-			}
+		case <-w.wake:
+			// A verified webhook reported a state change - poll now instead of
+			// waiting for the next tick.
+			w.logger.Info("webhook notification received, polling immediately",
+				slog.String("response_id", w.job.ResponseID),
+				slog.Int("poll_count", w.pollCount))
+		}
 
-			// Handle terminal states
-			switch status.Status {
-			case "completed":
-				w.logger.Info("response completed",
-					slog.String("response_id", w.job.ResponseID),
-					slog.Int("poll_count", w.pollCount),
-					slog.Duration("duration", time.Since(w.job.StartedAt)))
+		w.pollCount++
 
-				// Fetch and save full response
-				if err := w.fetchAndSaveResponse(ctx); err != nil {
-					w.logger.Error("failed to save completed response",
-						slog.String("response_id", w.job.ResponseID),
-						slog.String("error", err.Error()))
+		// Poll OpenAI
+		status, err := w.openAIClient.GetResponseStatus(ctx, w.job.ResponseID)
+		if err != nil {
+			w.logger.Error("failed to poll OpenAI",
+				slog.String("response_id", w.job.ResponseID),
+				slog.String("error", err.Error()),
+				slog.Int("poll_count", w.pollCount))
 
-					// CRITICAL: Update Firestore to "failed" so message doesn't stay stuck in "thinking"
-					if saveErr := w.saveFailure(fmt.Sprintf("Failed to save response: %v", err)); saveErr != nil {
-						w.logger.Error("failed to save failure state",
-							slog.String("response_id", w.job.ResponseID),
-							slog.String("error", saveErr.Error()))
-					}
+			// Don't fail immediately - retry on next tick
+			// OpenAI might have transient issues
+			continue
+		}
 
-					return err
-				}
+		// Update Firestore with current status
+		generationState := MapStatusToGenerationState(status.Status)
+		if err := w.updateFirestoreState(ctx, generationState); err != nil {
+			w.logger.Error("failed to update Firestore state",
+				slog.String("response_id", w.job.ResponseID),
+				slog.String("state", generationState),
+				slog.String("error", err.Error()))
+			// Continue polling even if Firestore update fails
+		}
 
-				return nil // Done
+		// Handle terminal states
+		switch status.Status {
+		case "completed":
+			w.logger.Info("response completed",
+				slog.String("response_id", w.job.ResponseID),
+				slog.Int("poll_count", w.pollCount),
+				slog.Duration("duration", time.Since(w.job.StartedAt)))
 
-			case "failed":
-				w.logger.Error("response failed",
+			// Fetch and save full response
+			if err := w.fetchAndSaveResponse(ctx); err != nil {
+				w.logger.Error("failed to save completed response",
 					slog.String("response_id", w.job.ResponseID),
-					slog.Int("poll_count", w.pollCount),
-					slog.Duration("duration", time.Since(w.job.StartedAt)))
+					slog.String("error", err.Error()))
 
-				// Save error state
-				errorMsg := "Response failed"
-				if status.Error != nil {
-					errorMsg = status.Error.Message
-				}
-				if err := w.saveFailure(errorMsg); err != nil {
-					w.logger.Error("failed to save error state",
+				// CRITICAL: Update Firestore to "failed" so message doesn't stay stuck in "thinking"
+				if saveErr := w.saveFailure(fmt.Sprintf("Failed to save response: %v", err)); saveErr != nil {
+					w.logger.Error("failed to save failure state",
 						slog.String("response_id", w.job.ResponseID),
-						slog.String("error", err.Error()))
+						slog.String("error", saveErr.Error()))
 				}
 
-				return fmt.Errorf("response failed: %s", errorMsg)
+				return err
+			}
 
-			case "in_progress", "queued":
-				// Still processing - continue polling
-				// Log at Info level every 10 polls so we can see progress in Grafana
-				if w.pollCount%10 == 0 {
-					w.logger.Info("polling progress",
-						slog.String("response_id", w.job.ResponseID),
-						slog.String("status", status.Status),
-						slog.Int("poll_count", w.pollCount),
-						slog.Duration("elapsed", time.Since(w.job.StartedAt)))
-				} else {
-					w.logger.Debug("response still processing",
-						slog.String("response_id", w.job.ResponseID),
-						slog.String("status", status.Status),
-						slog.Int("poll_count", w.pollCount))
-				}
+			return nil // Done
 
-				// Slow down polling after initial phase (after 10 polls = ~20 seconds)
-				if w.pollCount > 10 && pollInterval < maxPollInterval {
-					pollInterval = maxPollInterval
-					ticker.Reset(pollInterval)
-					w.logger.Info("slowed down polling interval",
-						slog.String("response_id", w.job.ResponseID),
-						slog.Duration("new_interval", pollInterval),
-						slog.Int("poll_count", w.pollCount))
-				}
+		case "failed":
+			w.logger.Error("response failed",
+				slog.String("response_id", w.job.ResponseID),
+				slog.Int("poll_count", w.pollCount),
+				slog.Duration("duration", time.Since(w.job.StartedAt)))
+
+			// Save error state
+			errorMsg := "Response failed"
+			if status.Error != nil {
+				errorMsg = status.Error.Message
+			}
+			if err := w.saveFailure(errorMsg); err != nil {
+				w.logger.Error("failed to save error state",
+					slog.String("response_id", w.job.ResponseID),
+					slog.String("error", err.Error()))
+			}
+
+			return fmt.Errorf("response failed: %s", errorMsg)
+
+		case "in_progress", "queued":
+			// Still processing - continue polling
+			// Log at Info level every 10 polls so we can see progress in Grafana
+			if w.pollCount%10 == 0 {
+				w.logger.Info("polling progress",
+					slog.String("response_id", w.job.ResponseID),
+					slog.String("status", status.Status),
+					slog.Int("poll_count", w.pollCount),
+					slog.Duration("elapsed", time.Since(w.job.StartedAt)))
+			} else {
+				w.logger.Debug("response still processing",
+					slog.String("response_id", w.job.ResponseID),
+					slog.String("status", status.Status),
+					slog.Int("poll_count", w.pollCount))
+			}
 
-			default:
-				w.logger.Warn("unknown status from OpenAI",
+			// Slow down polling after initial phase (after 10 polls = ~20 seconds)
+			if w.pollCount > 10 && pollInterval < maxPollInterval {
+				pollInterval = maxPollInterval
+				ticker.Reset(pollInterval)
+				w.logger.Info("slowed down polling interval",
 					slog.String("response_id", w.job.ResponseID),
-					slog.String("status", status.Status))
+					slog.Duration("new_interval", pollInterval),
+					slog.Int("poll_count", w.pollCount))
 			}
+
+		default:
+			w.logger.Warn("unknown status from OpenAI",
+				slog.String("response_id", w.job.ResponseID),
+				slog.String("status", status.Status))
 		}
 	}
 }
@@ -271,6 +282,7 @@ func (w *PollingWorker) fetchAndSaveResponse(ctx context.Context) error {
 		Model:                 w.job.Model,
 		GenerationState:       "completed",
 		GenerationCompletedAt: &now,
+		SpanContext:           trace.SpanContextFromContext(ctx),
 	}
 
 	// Use background context to ensure save completes even if request context is cancelled
@@ -294,6 +306,13 @@ func (w *PollingWorker) fetchAndSaveResponse(ctx context.Context) error {
 	// Send push notification for successful completion
 	if w.notificationService != nil {
 		go func() {
+			if w.acknowledgedDuringGrace() {
+				w.logger.Info("skipping completion notification, message already acknowledged by a client",
+					slog.String("response_id", w.job.ResponseID),
+					slog.String("message_id", w.job.MessageID))
+				return
+			}
+
 			// Use background context to ensure notification sends even if request context is cancelled
 			notifyCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
@@ -359,6 +378,7 @@ func (w *PollingWorker) fetchAndSaveResponse(ctx context.Context) error {
 			TotalTokens:      &totalTokens,
 			PlanTokens:       &planTokens,
 			Multiplier:       &w.tokenMultiplier,
+			ReasoningEffort:  w.job.ReasoningEffort,
 		}
 
 		// Pass context.Background(): LogRequestWithPlanTokensAsync only uses
@@ -408,3 +428,26 @@ func (w *PollingWorker) saveFailure(errorMsg string) error {
 		errorMsg,
 	)
 }
+
+// acknowledgedDuringGrace waits PUSH_NOTIFICATION_ACK_GRACE_SECONDS and reports whether a client
+// acknowledged the completed message meanwhile (the user already saw it, so a push would be
+// redundant). Lookup failures count as unacknowledged so the notification still goes out.
+func (w *PollingWorker) acknowledgedDuringGrace() bool {
+	if w.cfg.PushNotificationAckGraceSeconds <= 0 || w.messageService == nil {
+		return false
+	}
+	time.Sleep(time.Duration(w.cfg.PushNotificationAckGraceSeconds) * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	acknowledged, err := w.messageService.IsMessageAcknowledged(ctx, w.job.UserID, w.job.ChatID, w.job.MessageID)
+	if err != nil {
+		w.logger.Warn("failed to check message receipt, sending notification",
+			slog.String("response_id", w.job.ResponseID),
+			slog.String("message_id", w.job.MessageID),
+			slog.String("error", err.Error()))
+		return false
+	}
+	return acknowledged
+}
@@ -3,6 +3,8 @@ package background
 import (
 	"encoding/json"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ResponseStatus represents the status of an OpenAI background response.
@@ -111,7 +113,9 @@ type PollingJob struct {
 	MessageID         string
 	Model             string
 	EncryptionEnabled *bool
+	ReasoningEffort   string // Applied reasoning effort (recorded with token usage)
 	StartedAt         time.Time
+	SpanContext       trace.SpanContext // Span of the request that started the response; polling is traced as its child
 }
 
 // MapStatusToGenerationState maps OpenAI status to Firestore generationState.
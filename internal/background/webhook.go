@@ -0,0 +1,187 @@
+package background
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+)
+
+const (
+	// webhookTolerance is the maximum allowed clock skew between the
+	// webhook-timestamp header and the server clock (replay protection).
+	webhookTolerance = 5 * time.Minute
+
+	// maxWebhookBodyBytes caps the webhook payload size. OpenAI response
+	// events only carry IDs, so anything larger is rejected.
+	maxWebhookBodyBytes = 64 * 1024
+)
+
+// WebhookEvent is an OpenAI webhook event payload.
+//
+// Example:
+//
+//	{"id": "evt_abc", "type": "response.completed", "created_at": 1719168000, "data": {"id": "resp_abc"}}
+type WebhookEvent struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"` // "response.completed" | "response.failed" | "response.cancelled" | "response.incomplete"
+	CreatedAt int64  `json:"created_at"`
+	Data      struct {
+		ID string `json:"id"` // Response ID (e.g., "resp_abc123")
+	} `json:"data"`
+}
+
+// WebhookHandler receives OpenAI webhook callbacks for background responses.
+//
+// OpenAI signs webhooks using the Standard Webhooks scheme. After verifying the
+// signature, the handler wakes the matching polling worker so the message is
+// finalized immediately instead of on the next poll tick.
+type WebhookHandler struct {
+	pollingManager *PollingManager
+	secret         string
+	logger         *logger.Logger
+}
+
+// NewWebhookHandler creates a new OpenAI webhook handler.
+//
+// Parameters:
+//   - pollingManager: Manager owning the polling workers to wake
+//   - secret: Webhook signing secret from the OpenAI dashboard ("whsec_..." format)
+//   - logger: Logger
+func NewWebhookHandler(pollingManager *PollingManager, secret string, logger *logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		pollingManager: pollingManager,
+		secret:         secret,
+		logger:         logger.WithComponent("openai_webhook"),
+	}
+}
+
+// HandleOpenAIWebhook handles POST /webhooks/openai.
+//
+// Responds 200 for any verified event, including events for responses that are
+// not being polled on this instance, so OpenAI does not retry them.
+func (h *WebhookHandler) HandleOpenAIWebhook(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context())
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodyBytes+1))
+	if err != nil {
+		log.Error("failed to read webhook payload", slog.String("error", err.Error()))
+		errors.BadRequest(c, "invalid payload", nil)
+		return
+	}
+	if len(body) > maxWebhookBodyBytes {
+		errors.BadRequest(c, "payload too large", nil)
+		return
+	}
+
+	if err := VerifyWebhookSignature(h.secret, c.Request.Header, body, time.Now()); err != nil {
+		log.Warn("rejected OpenAI webhook with invalid signature",
+			slog.String("webhook_id", c.GetHeader("webhook-id")),
+			slog.String("error", err.Error()))
+		errors.Unauthorized(c, "invalid signature", nil)
+		return
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		log.Error("failed to parse webhook event", slog.String("error", err.Error()))
+		errors.BadRequest(c, "invalid event", nil)
+		return
+	}
+
+	switch event.Type {
+	case "response.completed", "response.failed", "response.cancelled", "response.incomplete":
+		if event.Data.ID == "" {
+			errors.BadRequest(c, "missing response id", nil)
+			return
+		}
+
+		woken := h.pollingManager.NotifyResponseEvent(event.Data.ID)
+		log.Info("received OpenAI response webhook",
+			slog.String("event_id", event.ID),
+			slog.String("event_type", event.Type),
+			slog.String("response_id", event.Data.ID),
+			slog.Bool("worker_notified", woken))
+
+	default:
+		log.Debug("ignoring unsupported OpenAI webhook event",
+			slog.String("event_id", event.ID),
+			slog.String("event_type", event.Type))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// VerifyWebhookSignature verifies a Standard Webhooks signature as sent by OpenAI.
+//
+// The signed content is "{webhook-id}.{webhook-timestamp}.{body}", signed with
+// HMAC-SHA256 using the base64-decoded secret (after the "whsec_" prefix). The
+// webhook-signature header holds one or more space-separated "v1,<base64>" entries
+// (several during secret rotation); any match is accepted.
+//
+// Parameters:
+//   - secret: Signing secret ("whsec_..." or raw base64)
+//   - header: Request headers
+//   - body: Raw request body
+//   - now: Current time (for timestamp tolerance)
+//
+// Returns:
+//   - error: If the signature is missing, stale, or does not match
+func VerifyWebhookSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf("webhook secret not configured")
+	}
+
+	id := header.Get("webhook-id")
+	timestamp := header.Get("webhook-timestamp")
+	signatures := header.Get("webhook-signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return fmt.Errorf("missing webhook signature headers")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid webhook-timestamp: %w", err)
+	}
+	sentAt := time.Unix(ts, 0)
+	if now.Sub(sentAt) > webhookTolerance || sentAt.Sub(now) > webhookTolerance {
+		return fmt.Errorf("webhook timestamp outside tolerance")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook secret: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, entry := range strings.Fields(signatures) {
+		version, sig, found := strings.Cut(entry, ",")
+		if !found || version != "v1" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(sig)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no matching webhook signature")
+}
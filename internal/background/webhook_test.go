@@ -0,0 +1,107 @@
+package background
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signWebhook(key []byte, id, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	key := []byte("test-signing-key-0123456789abcdef")
+	secret := "whsec_" + base64.StdEncoding.EncodeToString(key)
+	body := []byte(`{"id":"evt_1","type":"response.completed","data":{"id":"resp_abc"}}`)
+	now := time.Unix(1760000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	validSig := signWebhook(key, "msg_1", ts, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		id        string
+		timestamp string
+		signature string
+		body      []byte
+		wantErr   bool
+	}{
+		{
+			name:      "valid signature",
+			secret:    secret,
+			id:        "msg_1",
+			timestamp: ts,
+			signature: validSig,
+			body:      body,
+		},
+		{
+			name:      "valid signature among rotated secrets",
+			secret:    secret,
+			id:        "msg_1",
+			timestamp: ts,
+			signature: "v1,AAAA " + validSig,
+			body:      body,
+		},
+		{
+			name:      "tampered body",
+			secret:    secret,
+			id:        "msg_1",
+			timestamp: ts,
+			signature: validSig,
+			body:      []byte(`{"id":"evt_1","type":"response.completed","data":{"id":"resp_other"}}`),
+			wantErr:   true,
+		},
+		{
+			name:      "stale timestamp",
+			secret:    secret,
+			id:        "msg_1",
+			timestamp: strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10),
+			signature: signWebhook(key, "msg_1", strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10), body),
+			body:      body,
+			wantErr:   true,
+		},
+		{
+			name:    "missing headers",
+			secret:  secret,
+			body:    body,
+			wantErr: true,
+		},
+		{
+			name:      "secret not configured",
+			secret:    "",
+			id:        "msg_1",
+			timestamp: ts,
+			signature: validSig,
+			body:      body,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.id != "" {
+				header.Set("webhook-id", tt.id)
+			}
+			if tt.timestamp != "" {
+				header.Set("webhook-timestamp", tt.timestamp)
+			}
+			if tt.signature != "" {
+				header.Set("webhook-signature", tt.signature)
+			}
+
+			err := VerifyWebhookSignature(tt.secret, header, tt.body, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyWebhookSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package bans blocks banned users from the API. Bans are recorded in Postgres (user_bans) via
+// the admin API and checked on every authenticated request through the shared cache, so a ban
+// or unban takes effect on every instance within banCacheTTL (immediately with Redis).
+package bans
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// banCacheTTL bounds how long an instance without Redis keeps serving a stale ban state.
+const banCacheTTL = time.Minute
+
+// ErrNotBanned is returned when unbanning a user who isn't banned.
+var ErrNotBanned = stderrors.New("user is not banned")
+
+// Ban is a user's ban.
+type Ban struct {
+	UserID   string    `json:"user_id"`
+	Reason   string    `json:"reason"`
+	BannedAt time.Time `json:"banned_at"`
+}
+
+// banState is the cached ban state of a user (Ban is nil when not banned).
+type banState struct {
+	Ban *Ban `json:"ban"`
+}
+
+// Service records bans and answers whether a user is banned.
+type Service struct {
+	queries pgdb.Querier
+	cache   *cache.Cache
+	logger  *logger.Logger
+}
+
+// NewService creates a ban service.
+func NewService(queries pgdb.Querier, sharedCache *cache.Cache, logger *logger.Logger) *Service {
+	return &Service{
+		queries: queries,
+		cache:   sharedCache.Namespace("bans", banCacheTTL),
+		logger:  logger,
+	}
+}
+
+// Get returns the ban of a user, or nil if the user isn't banned.
+func (s *Service) Get(ctx context.Context, userID string) (*Ban, error) {
+	state, err := cache.Fetch(ctx, s.cache, userID, func(ctx context.Context) (banState, error) {
+		row, err := s.queries.GetUserBan(ctx, userID)
+		if stderrors.Is(err, sql.ErrNoRows) {
+			return banState{}, nil
+		}
+		if err != nil {
+			return banState{}, fmt.Errorf("failed to get ban: %w", err)
+		}
+		return banState{Ban: banFromRow(row)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state.Ban, nil
+}
+
+// Ban bans a user, replacing the reason of an existing ban.
+func (s *Service) Ban(ctx context.Context, userID, reason string) (*Ban, error) {
+	row, err := s.queries.BanUser(ctx, pgdb.BanUserParams{UserID: userID, Reason: reason})
+	if err != nil {
+		return nil, fmt.Errorf("failed to ban user: %w", err)
+	}
+	s.invalidate(ctx, userID)
+	return banFromRow(row), nil
+}
+
+// Unban lifts the ban of a user. Returns ErrNotBanned if the user isn't banned.
+func (s *Service) Unban(ctx context.Context, userID string) error {
+	deleted, err := s.queries.UnbanUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unban user: %w", err)
+	}
+	s.invalidate(ctx, userID)
+	if deleted == 0 {
+		return ErrNotBanned
+	}
+	return nil
+}
+
+// invalidate drops the cached ban state of a user; a failure only delays the change.
+func (s *Service) invalidate(ctx context.Context, userID string) {
+	if err := s.cache.Delete(ctx, userID); err != nil {
+		s.logger.Warn("failed to invalidate cached ban state",
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()))
+	}
+}
+
+func banFromRow(row pgdb.UserBan) *Ban {
+	return &Ban{UserID: row.UserID, Reason: row.Reason, BannedAt: row.BannedAt}
+}
+
+// Middleware rejects requests of banned users with a 403. It runs after authentication;
+// requests without a user and lookup failures are let through.
+func Middleware(service *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := auth.GetUserID(c)
+		if service == nil || !ok || userID == "" {
+			c.Next()
+			return
+		}
+
+		ban, err := service.Get(c.Request.Context(), userID)
+		if err != nil {
+			service.logger.WithContext(c.Request.Context()).Error("failed to check ban",
+				slog.String("user_id", userID),
+				slog.String("error", err.Error()))
+		} else if ban != nil {
+			errors.AbortWithForbidden(c, errors.AccountBanned())
+			return
+		}
+
+		c.Next()
+	}
+}
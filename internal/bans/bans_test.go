@@ -0,0 +1,93 @@
+package bans
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// banStore keeps bans in memory; other Querier methods are not used.
+type banStore struct {
+	pgdb.Querier
+	rows    map[string]pgdb.UserBan
+	lookups int
+}
+
+func (s *banStore) GetUserBan(_ context.Context, userID string) (pgdb.UserBan, error) {
+	s.lookups++
+	row, ok := s.rows[userID]
+	if !ok {
+		return pgdb.UserBan{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+func (s *banStore) BanUser(_ context.Context, arg pgdb.BanUserParams) (pgdb.UserBan, error) {
+	row := pgdb.UserBan{UserID: arg.UserID, Reason: arg.Reason, BannedAt: time.Now()}
+	s.rows[arg.UserID] = row
+	return row, nil
+}
+
+func (s *banStore) UnbanUser(_ context.Context, userID string) (int64, error) {
+	if _, ok := s.rows[userID]; !ok {
+		return 0, nil
+	}
+	delete(s.rows, userID)
+	return 1, nil
+}
+
+func TestBanMiddleware(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	store := &banStore{rows: map[string]pgdb.UserBan{}}
+	service := NewService(store, cache.New(cache.NewMemory(100), log), log)
+	ctx := context.Background()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(string(auth.UserIDKey), "user-1")
+		c.Next()
+	}, Middleware(service))
+	router.GET("/api/v1/usage", func(c *gin.Context) { c.Status(http.StatusOK) })
+	get := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/usage", nil))
+		return w.Code
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("request before ban = %d, want 200", code)
+	}
+	get()
+	if store.lookups != 1 {
+		t.Errorf("ban lookups = %d, want 1 (cached)", store.lookups)
+	}
+
+	if _, err := service.Ban(ctx, "user-1", "abuse"); err != nil {
+		t.Fatal(err)
+	}
+	if code := get(); code != http.StatusForbidden {
+		t.Errorf("request after ban = %d, want 403", code)
+	}
+
+	if err := service.Unban(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if code := get(); code != http.StatusOK {
+		t.Errorf("request after unban = %d, want 200", code)
+	}
+	if err := service.Unban(ctx, "user-1"); !stderrors.Is(err, ErrNotBanned) {
+		t.Errorf("second unban error = %v, want ErrNotBanned", err)
+	}
+}
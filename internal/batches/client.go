@@ -0,0 +1,131 @@
+package batches
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/tracing"
+)
+
+const (
+	defaultBaseURL = "https://api.openai.com/v1"
+
+	// clientTimeout bounds a single API call, including input file uploads and result downloads.
+	clientTimeout = 10 * time.Minute
+
+	// maxResponseBytes caps the JSON responses read into memory (file and batch objects).
+	maxResponseBytes = 1 << 20
+)
+
+// Response is an OpenAI API response, passed through to the client as is.
+type Response struct {
+	StatusCode int
+	Body       []byte
+}
+
+// OK reports whether the API call succeeded.
+func (r *Response) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Client calls the OpenAI Files and Batches APIs with the server's API key.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+// NewClient creates a client. baseURL may be empty for the OpenAI API.
+func NewClient(apiKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &Client{
+		httpClient: &http.Client{
+			Transport: tracing.Transport(http.DefaultTransport),
+			Timeout:   clientTimeout,
+		},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// UploadFile uploads a batch input file (purpose "batch").
+func (c *Client) UploadFile(ctx context.Context, filename string, data []byte) (*Response, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("purpose", "batch"); err != nil {
+		return nil, err
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return c.call(ctx, http.MethodPost, "/files", &body, writer.FormDataContentType())
+}
+
+// FileContent downloads a file. The caller closes the response body.
+func (c *Client) FileContent(ctx context.Context, fileID string) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, "/files/"+url.PathEscape(fileID)+"/content", nil, "")
+}
+
+// CreateBatch creates a batch from a JSON create request.
+func (c *Client) CreateBatch(ctx context.Context, request []byte) (*Response, error) {
+	return c.call(ctx, http.MethodPost, "/batches", bytes.NewReader(request), "application/json")
+}
+
+// GetBatch retrieves a batch.
+func (c *Client) GetBatch(ctx context.Context, batchID string) (*Response, error) {
+	return c.call(ctx, http.MethodGet, "/batches/"+url.PathEscape(batchID), nil, "")
+}
+
+// CancelBatch cancels a batch.
+func (c *Client) CancelBatch(ctx context.Context, batchID string) (*Response, error) {
+	return c.call(ctx, http.MethodPost, "/batches/"+url.PathEscape(batchID)+"/cancel", nil, "")
+}
+
+// call performs a request and reads its response. Error statuses are returned as responses,
+// not errors.
+func (c *Client) call(ctx context.Context, method, path string, body io.Reader, contentType string) (*Response, error) {
+	resp, err := c.do(ctx, method, path, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAI response: %w", err)
+	}
+	return &Response{StatusCode: resp.StatusCode, Body: data}, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI request failed: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,299 @@
+package batches
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+// maxCreateRequestBytes caps batch create requests (IDs and metadata).
+const maxCreateRequestBytes = 64 << 10
+
+// Handler handles the /files and /batches routes.
+type Handler struct {
+	service     *Service
+	modelRouter *routing.ModelRouter
+	logger      *logger.Logger
+}
+
+// NewHandler creates a batch handler. modelRouter resolves model aliases for tier checks and
+// may be nil.
+func NewHandler(service *Service, modelRouter *routing.ModelRouter, logger *logger.Logger) *Handler {
+	return &Handler{
+		service:     service,
+		modelRouter: modelRouter,
+		logger:      logger,
+	}
+}
+
+// UploadFile handles POST /files
+// Uploads a batch input file (multipart "file", purpose "batch"). The models it requests must
+// be allowed for the user's tier; uploads are refused when rate limiting is enabled and the
+// tier wasn't loaded.
+func (h *Handler) UploadFile(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("batches")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+	if !batchModeAllowed(c) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxInputFileBytes+1<<20)
+	if purpose := c.PostForm("purpose"); purpose != "batch" {
+		errors.BadRequest(c, "purpose must be \"batch\"", nil)
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		errors.BadRequest(c, "file is required", map[string]interface{}{"details": err.Error()})
+		return
+	}
+	if header.Size > MaxInputFileBytes {
+		errors.BadRequest(c, "file too large", map[string]interface{}{"max_bytes": MaxInputFileBytes})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		errors.BadRequest(c, "invalid file", map[string]interface{}{"details": err.Error()})
+		return
+	}
+	data, err := io.ReadAll(file)
+	file.Close() //nolint:errcheck
+	if err != nil {
+		errors.BadRequest(c, "invalid file", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	models, err := ParseInputFile(data)
+	if err != nil {
+		errors.BadRequest(c, err.Error(), nil)
+		return
+	}
+	val, _ := c.Get("tierConfig")
+	if tierConfig, ok := val.(tiers.Config); ok {
+		for _, model := range models {
+			canonicalModel := model
+			if h.modelRouter != nil {
+				canonicalModel = h.modelRouter.ResolveAlias(model)
+			}
+			if !tierConfig.IsModelAllowed(canonicalModel) {
+				errors.AbortWithForbidden(c, errors.ModelNotAllowed(canonicalModel, tierConfig.Name, tierConfig.DisplayName, tierConfig.AllowedModels))
+				return
+			}
+		}
+	} else if config.Current().RateLimitEnabled {
+		// Request tracking didn't load the tier: the models can't be checked
+		log.Error("tier configuration missing; refusing batch file upload", slog.String("user_id", userID))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Rate limit service temporarily unavailable",
+		})
+		return
+	}
+
+	resp, err := h.service.UploadFile(c.Request.Context(), userID, header.Filename, data)
+	if err != nil {
+		log.Error("failed to upload batch file",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.BadGateway(c, "Batch API unavailable", nil)
+		return
+	}
+	if resp.OK() {
+		log.Info("batch file uploaded",
+			slog.String("user_id", userID),
+			slog.Int("bytes", len(data)),
+			slog.Any("models", models))
+	}
+	c.Data(resp.StatusCode, "application/json", resp.Body)
+}
+
+// FileContent handles GET /files/:fileId/content
+// Downloads one of the user's files (input, output or error file of a batch).
+func (h *Handler) FileContent(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("batches")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+	if !batchModeAllowed(c) {
+		return
+	}
+
+	resp, err := h.service.FileContent(c.Request.Context(), userID, c.Param("fileId"))
+	if err != nil {
+		h.serviceError(c, log, userID, "failed to download batch file", err)
+		return
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	c.DataFromReader(resp.StatusCode, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, nil)
+}
+
+// CreateBatch handles POST /batches
+// Creates a batch from one of the user's input files.
+func (h *Handler) CreateBatch(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("batches")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+	if !batchModeAllowed(c) {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCreateRequestBytes))
+	if err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+	var req CreateRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.InputFileID == "" || req.Endpoint == "" {
+		errors.BadRequest(c, "input_file_id and endpoint are required", nil)
+		return
+	}
+
+	resp, err := h.service.CreateBatch(c.Request.Context(), userID, &req, body)
+	if err != nil {
+		h.serviceError(c, log, userID, "failed to create batch", err)
+		return
+	}
+	if resp.OK() {
+		log.Info("batch created",
+			slog.String("user_id", userID),
+			slog.String("endpoint", req.Endpoint),
+			slog.String("input_file_id", req.InputFileID))
+	}
+	c.Data(resp.StatusCode, "application/json", resp.Body)
+}
+
+// GetBatch handles GET /batches/:batchId
+func (h *Handler) GetBatch(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("batches")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+	if !batchModeAllowed(c) {
+		return
+	}
+
+	resp, err := h.service.GetBatch(c.Request.Context(), userID, c.Param("batchId"))
+	if err != nil {
+		h.serviceError(c, log, userID, "failed to get batch", err)
+		return
+	}
+	c.Data(resp.StatusCode, "application/json", resp.Body)
+}
+
+// CancelBatch handles POST /batches/:batchId/cancel
+func (h *Handler) CancelBatch(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("batches")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+	if !batchModeAllowed(c) {
+		return
+	}
+
+	resp, err := h.service.CancelBatch(c.Request.Context(), userID, c.Param("batchId"))
+	if err != nil {
+		h.serviceError(c, log, userID, "failed to cancel batch", err)
+		return
+	}
+	if resp.OK() {
+		log.Info("batch cancelled",
+			slog.String("user_id", userID),
+			slog.String("batch_id", c.Param("batchId")))
+	}
+	c.Data(resp.StatusCode, "application/json", resp.Body)
+}
+
+// ListBatches handles GET /batches?after=...&limit=...
+// Lists the user's batches, newest first.
+func (h *Handler) ListBatches(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("batches")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+	if !batchModeAllowed(c) {
+		return
+	}
+
+	var limit int
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 {
+			errors.BadRequest(c, "limit must be a positive integer", nil)
+			return
+		}
+	}
+
+	list, err := h.service.ListBatches(c.Request.Context(), userID, c.Query("after"), limit)
+	if err != nil {
+		log.Error("failed to list batches",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to list batches", nil)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// batchModeAllowed rejects sandbox and privacy mode requests: sandbox requests skip the tier
+// checks of request tracking, and the Files and Batch APIs retain uploaded content.
+func batchModeAllowed(c *gin.Context) bool {
+	if sandbox.FromContext(c.Request.Context()) {
+		errors.BadRequest(c, "Batch API is not available in sandbox mode", nil)
+		return false
+	}
+	if privacy.FromContext(c.Request.Context()) {
+		errors.BadRequest(c, "Batch API is not available in privacy mode", nil)
+		return false
+	}
+	return true
+}
+
+// serviceError responds to a service error: unknown IDs are not found, anything else is an
+// OpenAI or database failure.
+func (h *Handler) serviceError(c *gin.Context, log *logger.Logger, userID, message string, err error) {
+	switch {
+	case stderrors.Is(err, ErrFileNotFound), stderrors.Is(err, ErrBatchNotFound):
+		errors.NotFound(c, err.Error(), nil)
+	case stderrors.Is(err, ErrUnsupportedEndpoint):
+		errors.BadRequest(c, err.Error(), nil)
+	default:
+		log.Error(message,
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.BadGateway(c, "Batch API unavailable", nil)
+	}
+}
@@ -0,0 +1,84 @@
+package batches
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
+	"github.com/gin-gonic/gin"
+)
+
+// uploadRequest builds a POST /files request with a valid batch input file.
+func uploadRequest(t *testing.T) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("purpose", "batch"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := form.CreateFormFile("file", "input.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte(`{"custom_id":"1","method":"POST","url":"/v1/embeddings","body":{"model":"text-embedding-3-small","input":"a"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	form.Close() //nolint:errcheck
+
+	req := httptest.NewRequest(http.MethodPost, "/files", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestHandlerRejectsBeforeUpstream(t *testing.T) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{RateLimitEnabled: true}
+	defer func() { config.AppConfig = previous }()
+
+	// The handler has no service: every request must be rejected before reaching OpenAI
+	handler := NewHandler(nil, nil, logger.New(logger.Config{Level: slog.LevelError}))
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		name    string
+		context func(ctx context.Context) context.Context
+		request func(t *testing.T) *http.Request
+		want    int
+	}{
+		{"sandbox upload", sandbox.WithContext, uploadRequest, http.StatusBadRequest},
+		{"privacy upload", privacy.WithContext, uploadRequest, http.StatusBadRequest},
+		{"sandbox list", sandbox.WithContext, func(*testing.T) *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/batches", nil)
+		}, http.StatusBadRequest},
+		{"privacy get", privacy.WithContext, func(*testing.T) *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/batches/batch_1", nil)
+		}, http.StatusBadRequest},
+		{"upload without tier", func(ctx context.Context) context.Context { return ctx }, uploadRequest, http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set(string(auth.UserIDKey), "user-1")
+				c.Request = c.Request.WithContext(tc.context(c.Request.Context()))
+			})
+			router.POST("/files", handler.UploadFile)
+			router.GET("/batches", handler.ListBatches)
+			router.GET("/batches/:batchId", handler.GetBatch)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tc.request(t))
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
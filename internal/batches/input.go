@@ -0,0 +1,76 @@
+package batches
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+)
+
+// MaxInputFileBytes caps batch input files, which are validated in memory.
+const MaxInputFileBytes = 100 << 20
+
+// inputLine is a request of a batch input file.
+//
+// Example:
+//
+//	{"custom_id": "req-1", "method": "POST", "url": "/v1/embeddings", "body": {"model": "text-embedding-3-small", "input": "hello"}}
+type inputLine struct {
+	CustomID string `json:"custom_id"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Body     struct {
+		Model string `json:"model"`
+	} `json:"body"`
+}
+
+// ParseInputFile checks a batch input file (JSON lines) and returns the models it requests,
+// sorted, for tier access checks. Every request must target the same supported endpoint.
+func ParseInputFile(data []byte) ([]string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), MaxInputFileBytes)
+
+	var endpoint string
+	models := make(map[string]bool)
+	for n := 1; scanner.Scan(); n++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var line inputLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return nil, fmt.Errorf("%w: line %d is not valid JSON", ErrInvalidInputFile, n)
+		}
+		switch {
+		case line.CustomID == "":
+			return nil, fmt.Errorf("%w: line %d has no custom_id", ErrInvalidInputFile, n)
+		case line.Method != http.MethodPost:
+			return nil, fmt.Errorf("%w: line %d: method must be POST", ErrInvalidInputFile, n)
+		case !slices.Contains(SupportedEndpoints, line.URL):
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidInputFile, n, ErrUnsupportedEndpoint)
+		case endpoint != "" && line.URL != endpoint:
+			return nil, fmt.Errorf("%w: line %d: all requests must use the same url", ErrInvalidInputFile, n)
+		case line.Body.Model == "":
+			return nil, fmt.Errorf("%w: line %d has no body.model", ErrInvalidInputFile, n)
+		}
+		endpoint = line.URL
+		models[line.Body.Model] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInputFile, err)
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("%w: no requests", ErrInvalidInputFile)
+	}
+
+	sorted := make([]string, 0, len(models))
+	for model := range models {
+		sorted = append(sorted, model)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
@@ -0,0 +1,451 @@
+// Package batches passes the OpenAI Batch API (/files, /batches) through to users, so heavy
+// offline workloads (embeddings, chat completions) run at batch prices on the server's OpenAI
+// account.
+//
+// Every uploaded file and created batch is recorded with its owner in Postgres; users can only
+// see, cancel and download their own. Listing is served from the database, since the OpenAI
+// list covers the whole account. Usage is counted once results land: Sync polls unfinished
+// batches and, when a batch finishes, sums the usage of its output file and logs it as plan
+// tokens at batchPlanTokenRate.
+package batches
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+const (
+	// batchPlanTokenRate is the share of plan tokens batch requests count for: the Batch API
+	// costs half the synchronous price.
+	batchPlanTokenRate = 0.5
+
+	// syncBatchSize is the number of unfinished batches a Sync pass looks at.
+	syncBatchSize = 100
+
+	// usageEndpoint is the request log endpoint of batch usage.
+	usageEndpoint = "/batches"
+
+	// outputPurpose is the purpose recorded for output and error files of batches.
+	outputPurpose = "batch_output"
+
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// SupportedEndpoints are the endpoints batches can run.
+var SupportedEndpoints = []string{"/v1/chat/completions", "/v1/embeddings", "/v1/completions"}
+
+var (
+	ErrFileNotFound        = stderrors.New("file not found")
+	ErrBatchNotFound       = stderrors.New("batch not found")
+	ErrUnsupportedEndpoint = stderrors.New("endpoint must be one of /v1/chat/completions, /v1/embeddings, /v1/completions")
+	ErrInvalidInputFile    = stderrors.New("invalid batch input file")
+)
+
+// CreateRequest is the part of a batch create request the service checks. The request is
+// forwarded unchanged.
+type CreateRequest struct {
+	InputFileID string `json:"input_file_id"`
+	Endpoint    string `json:"endpoint"`
+}
+
+// List is a page of batches in the OpenAI list format.
+type List struct {
+	Object  string            `json:"object"`
+	Data    []json.RawMessage `json:"data"`
+	FirstID *string           `json:"first_id"`
+	LastID  *string           `json:"last_id"`
+	HasMore bool              `json:"has_more"`
+}
+
+// batchObject holds the fields of an OpenAI batch object the service uses.
+type batchObject struct {
+	ID           string `json:"id"`
+	Endpoint     string `json:"endpoint"`
+	Status       string `json:"status"`
+	OutputFileID string `json:"output_file_id"`
+	ErrorFileID  string `json:"error_file_id"`
+}
+
+// finished reports whether a batch reached a final status. Cancelled and expired batches may
+// still have output for the requests that completed.
+func (b *batchObject) finished() bool {
+	switch b.Status {
+	case "completed", "failed", "expired", "cancelled":
+		return true
+	}
+	return false
+}
+
+// usageLogger records usage (request_tracking.Service).
+type usageLogger interface {
+	LogRequestWithPlanTokensAsync(ctx context.Context, info request_tracking.RequestInfo, tokenData *request_tracking.TokenUsageWithMultiplier) error
+}
+
+// Service tracks batch ownership and usage.
+type Service struct {
+	queries    pgdb.Querier
+	client     *Client
+	usage      usageLogger
+	multiplier func(model string) float64
+	logger     *logger.Logger
+}
+
+// NewService creates the batch service. modelRouter provides the token multipliers of
+// configured models (others count at 1×) and may be nil.
+func NewService(queries pgdb.Querier, client *Client, usage usageLogger, modelRouter *routing.ModelRouter, logger *logger.Logger) *Service {
+	s := &Service{
+		queries:    queries,
+		client:     client,
+		usage:      usage,
+		multiplier: func(string) float64 { return 1 },
+		logger:     logger,
+	}
+	if modelRouter != nil {
+		s.multiplier = func(model string) float64 {
+			if modelRouter.GetModelInfo(model) == nil {
+				return 1
+			}
+			provider, err := modelRouter.RouteModel(model, "mobile")
+			if err != nil || provider.TokenMultiplier <= 0 {
+				return 1
+			}
+			return provider.TokenMultiplier
+		}
+	}
+	return s
+}
+
+// UploadFile uploads a batch input file (checked with ParseInputFile) and records its owner.
+func (s *Service) UploadFile(ctx context.Context, userID, filename string, data []byte) (*Response, error) {
+	resp, err := s.client.UploadFile(ctx, filename, data)
+	if err != nil || !resp.OK() {
+		return resp, err
+	}
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(resp.Body, &file); err != nil || file.ID == "" {
+		return nil, fmt.Errorf("unexpected file object from OpenAI: %s", resp.Body)
+	}
+	if err := s.queries.CreateBatchFile(ctx, pgdb.CreateBatchFileParams{FileID: file.ID, UserID: userID, Purpose: "batch"}); err != nil {
+		return nil, fmt.Errorf("failed to record file: %w", err)
+	}
+	return resp, nil
+}
+
+// FileContent downloads one of the user's files. The caller closes the response body.
+func (s *Service) FileContent(ctx context.Context, userID, fileID string) (*http.Response, error) {
+	if err := s.checkFile(ctx, userID, fileID); err != nil {
+		return nil, err
+	}
+	return s.client.FileContent(ctx, fileID)
+}
+
+// CreateBatch creates a batch from one of the user's input files and records its owner.
+// request is the raw create request, forwarded unchanged.
+func (s *Service) CreateBatch(ctx context.Context, userID string, req *CreateRequest, request []byte) (*Response, error) {
+	if !slices.Contains(SupportedEndpoints, req.Endpoint) {
+		return nil, ErrUnsupportedEndpoint
+	}
+	if err := s.checkFile(ctx, userID, req.InputFileID); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.CreateBatch(ctx, request)
+	if err != nil || !resp.OK() {
+		return resp, err
+	}
+
+	var batch batchObject
+	if err := json.Unmarshal(resp.Body, &batch); err != nil || batch.ID == "" {
+		return nil, fmt.Errorf("unexpected batch object from OpenAI: %s", resp.Body)
+	}
+	if err := s.queries.CreateBatch(ctx, pgdb.CreateBatchParams{
+		BatchID:  batch.ID,
+		UserID:   userID,
+		Endpoint: req.Endpoint,
+		Status:   batch.Status,
+		Object:   string(resp.Body),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record batch: %w", err)
+	}
+	return resp, nil
+}
+
+// GetBatch retrieves one of the user's batches.
+func (s *Service) GetBatch(ctx context.Context, userID, batchID string) (*Response, error) {
+	if err := s.checkBatch(ctx, userID, batchID); err != nil {
+		return nil, err
+	}
+	resp, err := s.client.GetBatch(ctx, batchID)
+	if err != nil || !resp.OK() {
+		return resp, err
+	}
+	if _, err := s.store(ctx, userID, resp.Body); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CancelBatch cancels one of the user's batches.
+func (s *Service) CancelBatch(ctx context.Context, userID, batchID string) (*Response, error) {
+	if err := s.checkBatch(ctx, userID, batchID); err != nil {
+		return nil, err
+	}
+	resp, err := s.client.CancelBatch(ctx, batchID)
+	if err != nil || !resp.OK() {
+		return resp, err
+	}
+	if _, err := s.store(ctx, userID, resp.Body); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListBatches lists the user's batches, newest first, as last seen by the service. after is
+// the ID of the last batch of the previous page (empty = first page); limit <= 0 = default.
+func (s *Service) ListBatches(ctx context.Context, userID, after string, limit int) (*List, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	rows, err := s.queries.ListBatches(ctx, pgdb.ListBatchesParams{UserID: userID, After: after, Limit: int32(limit + 1)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batches: %w", err)
+	}
+
+	list := &List{Object: "list", Data: []json.RawMessage{}, HasMore: len(rows) > limit}
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	for _, row := range rows {
+		list.Data = append(list.Data, json.RawMessage(row.Object))
+	}
+	if len(rows) > 0 {
+		list.FirstID = &rows[0].BatchID
+		list.LastID = &rows[len(rows)-1].BatchID
+	}
+	return list, nil
+}
+
+// Sync refreshes unfinished batches and records the usage of finished ones (for
+// worker.RunPeriodic). Batches whose usage can't be recorded yet are retried on the next pass.
+func (s *Service) Sync(ctx context.Context) error {
+	rows, err := s.queries.ListBatchesPendingUsage(ctx, syncBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list pending batches: %w", err)
+	}
+
+	var failed int
+	for _, row := range rows {
+		if err := s.syncBatch(ctx, row); err != nil {
+			failed++
+			s.logger.Warn("failed to sync batch",
+				slog.String("batch_id", row.BatchID),
+				slog.String("user_id", row.UserID),
+				slog.String("error", err.Error()))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batches failed to sync", failed, len(rows))
+	}
+	return nil
+}
+
+func (s *Service) syncBatch(ctx context.Context, row pgdb.Batch) error {
+	var batch batchObject
+	if err := json.Unmarshal([]byte(row.Object), &batch); err != nil {
+		return fmt.Errorf("invalid stored batch object: %w", err)
+	}
+
+	if !batch.finished() {
+		resp, err := s.client.GetBatch(ctx, row.BatchID)
+		if err != nil {
+			return err
+		}
+		if !resp.OK() {
+			return fmt.Errorf("OpenAI returned status %d", resp.StatusCode)
+		}
+		refreshed, err := s.store(ctx, row.UserID, resp.Body)
+		if err != nil {
+			return err
+		}
+		if !refreshed.finished() {
+			return nil
+		}
+		batch = *refreshed
+	}
+
+	return s.recordUsage(ctx, row.UserID, &batch)
+}
+
+// modelUsage is the usage of a batch's requests to one model.
+type modelUsage struct {
+	requests         int
+	promptTokens     int
+	completionTokens int
+	totalTokens      int
+}
+
+// recordUsage logs the usage of a finished batch's output, one request log per model. The
+// batch is claimed first, so instances syncing concurrently log it once.
+func (s *Service) recordUsage(ctx context.Context, userID string, batch *batchObject) error {
+	var usage map[string]*modelUsage
+	if batch.OutputFileID != "" {
+		var err error
+		if usage, err = s.outputUsage(ctx, batch.OutputFileID); err != nil {
+			return err
+		}
+	}
+
+	claimed, err := s.queries.ClaimBatchUsage(ctx, batch.ID)
+	if err != nil {
+		return fmt.Errorf("failed to claim batch usage: %w", err)
+	}
+	if claimed == 0 {
+		return nil
+	}
+
+	var planTokens int
+	for model, u := range usage {
+		multiplier := s.multiplier(model) * batchPlanTokenRate
+		tokenData := &request_tracking.TokenUsageWithMultiplier{
+			PromptTokens:     u.promptTokens,
+			CompletionTokens: u.completionTokens,
+			TotalTokens:      u.totalTokens,
+			Multiplier:       multiplier,
+			PlanTokens:       int(float64(u.totalTokens) * multiplier),
+		}
+		planTokens += tokenData.PlanTokens
+		info := request_tracking.RequestInfo{
+			UserID:   userID,
+			Endpoint: usageEndpoint,
+			Model:    model,
+			Provider: "OpenAI",
+		}
+		if err := s.usage.LogRequestWithPlanTokensAsync(ctx, info, tokenData); err != nil {
+			s.logger.Error("failed to queue batch usage log",
+				slog.String("batch_id", batch.ID),
+				slog.String("user_id", userID),
+				slog.String("model", model),
+				slog.Int("total_tokens", u.totalTokens),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	s.logger.Info("batch usage recorded",
+		slog.String("batch_id", batch.ID),
+		slog.String("user_id", userID),
+		slog.String("status", batch.Status),
+		slog.Int("models", len(usage)),
+		slog.Int("plan_tokens", planTokens))
+	return nil
+}
+
+// outputUsage sums the usage of the successful requests of an output file, by model.
+func (s *Service) outputUsage(ctx context.Context, fileID string) (map[string]*modelUsage, error) {
+	resp, err := s.client.FileContent(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAI returned status %d for output file %s", resp.StatusCode, fileID)
+	}
+
+	usage := make(map[string]*modelUsage)
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var line struct {
+			Response *struct {
+				StatusCode int `json:"status_code"`
+				Body       struct {
+					Model string `json:"model"`
+					Usage struct {
+						PromptTokens     int `json:"prompt_tokens"`
+						CompletionTokens int `json:"completion_tokens"`
+						TotalTokens      int `json:"total_tokens"`
+					} `json:"usage"`
+				} `json:"body"`
+			} `json:"response"`
+		}
+		err := decoder.Decode(&line)
+		if err == io.EOF {
+			return usage, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read output file %s: %w", fileID, err)
+		}
+		if line.Response == nil || line.Response.StatusCode != http.StatusOK || line.Response.Body.Model == "" {
+			continue
+		}
+
+		body := line.Response.Body
+		u := usage[body.Model]
+		if u == nil {
+			u = &modelUsage{}
+			usage[body.Model] = u
+		}
+		u.requests++
+		u.promptTokens += body.Usage.PromptTokens
+		u.completionTokens += body.Usage.CompletionTokens
+		u.totalTokens += body.Usage.TotalTokens
+	}
+}
+
+// store saves a batch object returned by OpenAI and records the owner of its output files.
+func (s *Service) store(ctx context.Context, userID string, object []byte) (*batchObject, error) {
+	var batch batchObject
+	if err := json.Unmarshal(object, &batch); err != nil || batch.ID == "" {
+		return nil, fmt.Errorf("unexpected batch object from OpenAI: %s", object)
+	}
+	if err := s.queries.UpdateBatch(ctx, pgdb.UpdateBatchParams{BatchID: batch.ID, Status: batch.Status, Object: string(object)}); err != nil {
+		return nil, fmt.Errorf("failed to update batch: %w", err)
+	}
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err := s.queries.CreateBatchFile(ctx, pgdb.CreateBatchFileParams{FileID: fileID, UserID: userID, Purpose: outputPurpose}); err != nil {
+			return nil, fmt.Errorf("failed to record output file: %w", err)
+		}
+	}
+	return &batch, nil
+}
+
+func (s *Service) checkFile(ctx context.Context, userID, fileID string) error {
+	_, err := s.queries.GetBatchFile(ctx, pgdb.GetBatchFileParams{FileID: fileID, UserID: userID})
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return ErrFileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) checkBatch(ctx context.Context, userID, batchID string) error {
+	_, err := s.queries.GetBatch(ctx, pgdb.GetBatchParams{BatchID: batchID, UserID: userID})
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return ErrBatchNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get batch: %w", err)
+	}
+	return nil
+}
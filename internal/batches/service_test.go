@@ -0,0 +1,318 @@
+package batches
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// batchStore keeps files and batches in memory; other Querier methods are not used.
+type batchStore struct {
+	pgdb.Querier
+	files   map[string]pgdb.BatchFile
+	batches []pgdb.Batch
+}
+
+func (s *batchStore) CreateBatchFile(_ context.Context, arg pgdb.CreateBatchFileParams) error {
+	if _, ok := s.files[arg.FileID]; !ok {
+		s.files[arg.FileID] = pgdb.BatchFile{FileID: arg.FileID, UserID: arg.UserID, Purpose: arg.Purpose, CreatedAt: time.Now()}
+	}
+	return nil
+}
+
+func (s *batchStore) GetBatchFile(_ context.Context, arg pgdb.GetBatchFileParams) (pgdb.BatchFile, error) {
+	file, ok := s.files[arg.FileID]
+	if !ok || file.UserID != arg.UserID {
+		return pgdb.BatchFile{}, sql.ErrNoRows
+	}
+	return file, nil
+}
+
+func (s *batchStore) CreateBatch(_ context.Context, arg pgdb.CreateBatchParams) error {
+	s.batches = append(s.batches, pgdb.Batch{
+		BatchID:   arg.BatchID,
+		UserID:    arg.UserID,
+		Endpoint:  arg.Endpoint,
+		Status:    arg.Status,
+		Object:    arg.Object,
+		CreatedAt: time.Now().Add(time.Duration(len(s.batches)) * time.Second),
+	})
+	return nil
+}
+
+func (s *batchStore) GetBatch(_ context.Context, arg pgdb.GetBatchParams) (pgdb.Batch, error) {
+	for _, batch := range s.batches {
+		if batch.BatchID == arg.BatchID && batch.UserID == arg.UserID {
+			return batch, nil
+		}
+	}
+	return pgdb.Batch{}, sql.ErrNoRows
+}
+
+func (s *batchStore) UpdateBatch(_ context.Context, arg pgdb.UpdateBatchParams) error {
+	for i := range s.batches {
+		if s.batches[i].BatchID == arg.BatchID {
+			s.batches[i].Status = arg.Status
+			s.batches[i].Object = arg.Object
+		}
+	}
+	return nil
+}
+
+func (s *batchStore) ListBatches(_ context.Context, arg pgdb.ListBatchesParams) ([]pgdb.Batch, error) {
+	var rows []pgdb.Batch
+	for i := len(s.batches) - 1; i >= 0; i-- {
+		if s.batches[i].UserID == arg.UserID {
+			rows = append(rows, s.batches[i])
+		}
+	}
+	if arg.After != "" {
+		i := slices.IndexFunc(rows, func(b pgdb.Batch) bool { return b.BatchID == arg.After })
+		rows = rows[i+1:]
+	}
+	return rows[:min(len(rows), int(arg.Limit))], nil
+}
+
+func (s *batchStore) ListBatchesPendingUsage(_ context.Context, limit int32) ([]pgdb.Batch, error) {
+	var rows []pgdb.Batch
+	for _, batch := range s.batches {
+		if !batch.UsageRecordedAt.Valid {
+			rows = append(rows, batch)
+		}
+	}
+	return rows[:min(len(rows), int(limit))], nil
+}
+
+func (s *batchStore) ClaimBatchUsage(_ context.Context, batchID string) (int64, error) {
+	for i := range s.batches {
+		if s.batches[i].BatchID == batchID && !s.batches[i].UsageRecordedAt.Valid {
+			s.batches[i].UsageRecordedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+type loggedUsage struct {
+	info   request_tracking.RequestInfo
+	tokens request_tracking.TokenUsageWithMultiplier
+}
+
+type usageRecorder struct {
+	logged []loggedUsage
+}
+
+func (r *usageRecorder) LogRequestWithPlanTokensAsync(_ context.Context, info request_tracking.RequestInfo, tokenData *request_tracking.TokenUsageWithMultiplier) error {
+	r.logged = append(r.logged, loggedUsage{info: info, tokens: *tokenData})
+	return nil
+}
+
+// fakeOpenAI serves the Files and Batches API calls of the service. Batches report
+// in_progress until completed is set.
+type fakeOpenAI struct {
+	mu        sync.Mutex
+	completed bool
+	batches   int
+}
+
+func (f *fakeOpenAI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/files":
+		if r.FormValue("purpose") != "batch" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"id":"file-in","object":"file","purpose":"batch"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/batches":
+		f.batches++
+		fmt.Fprintf(w, `{"id":"batch_%d","object":"batch","endpoint":"/v1/chat/completions","status":"validating"}`, f.batches)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/batches/"):
+		id := strings.TrimPrefix(r.URL.Path, "/batches/")
+		if f.completed {
+			fmt.Fprintf(w, `{"id":"%s","object":"batch","status":"completed","output_file_id":"file-out","error_file_id":"file-err"}`, id)
+		} else {
+			fmt.Fprintf(w, `{"id":"%s","object":"batch","status":"in_progress"}`, id)
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/files/file-out/content":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		fmt.Fprint(w, `{"id":"r1","custom_id":"a","response":{"status_code":200,"body":{"model":"gpt-4o-mini","usage":{"prompt_tokens":10,"completion_tokens":30,"total_tokens":40}}},"error":null}
+{"id":"r2","custom_id":"b","response":{"status_code":200,"body":{"model":"gpt-4o-mini","usage":{"prompt_tokens":20,"completion_tokens":40,"total_tokens":60}}},"error":null}
+{"id":"r3","custom_id":"c","response":{"status_code":400,"body":{"error":{"message":"bad"}}},"error":null}
+`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestService(t *testing.T) (*Service, *usageRecorder, *fakeOpenAI) {
+	t.Helper()
+	api := &fakeOpenAI{}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	store := &batchStore{files: map[string]pgdb.BatchFile{}}
+	usage := &usageRecorder{}
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	return NewService(store, NewClient("sk-test", server.URL), usage, nil, log), usage, api
+}
+
+func TestParseInputFile(t *testing.T) {
+	valid := `{"custom_id":"1","method":"POST","url":"/v1/embeddings","body":{"model":"text-embedding-3-small","input":"a"}}
+
+{"custom_id":"2","method":"POST","url":"/v1/embeddings","body":{"model":"text-embedding-3-large","input":"b"}}
+{"custom_id":"3","method":"POST","url":"/v1/embeddings","body":{"model":"text-embedding-3-small","input":"c"}}
+`
+	models, err := ParseInputFile([]byte(valid))
+	if err != nil {
+		t.Fatalf("ParseInputFile() error = %v", err)
+	}
+	if want := []string{"text-embedding-3-large", "text-embedding-3-small"}; !slices.Equal(models, want) {
+		t.Errorf("models = %v, want %v", models, want)
+	}
+
+	for name, input := range map[string]string{
+		"empty":           "\n",
+		"not json":        "{",
+		"no custom_id":    `{"method":"POST","url":"/v1/embeddings","body":{"model":"m"}}`,
+		"GET":             `{"custom_id":"1","method":"GET","url":"/v1/embeddings","body":{"model":"m"}}`,
+		"unsupported url": `{"custom_id":"1","method":"POST","url":"/v1/images/generations","body":{"model":"m"}}`,
+		"no model":        `{"custom_id":"1","method":"POST","url":"/v1/embeddings","body":{}}`,
+		"mixed endpoints": `{"custom_id":"1","method":"POST","url":"/v1/embeddings","body":{"model":"m"}}
+{"custom_id":"2","method":"POST","url":"/v1/chat/completions","body":{"model":"m"}}`,
+	} {
+		if _, err := ParseInputFile([]byte(input)); !stderrors.Is(err, ErrInvalidInputFile) {
+			t.Errorf("%s: error = %v, want ErrInvalidInputFile", name, err)
+		}
+	}
+}
+
+func TestBatchOwnership(t *testing.T) {
+	service, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	resp, err := service.UploadFile(ctx, "user-1", "input.jsonl", []byte("{}"))
+	if err != nil || !resp.OK() {
+		t.Fatalf("UploadFile() = %+v, %v", resp, err)
+	}
+
+	create := &CreateRequest{InputFileID: "file-in", Endpoint: "/v1/chat/completions"}
+	request := []byte(`{"input_file_id":"file-in","endpoint":"/v1/chat/completions","completion_window":"24h"}`)
+	if _, err := service.CreateBatch(ctx, "user-2", create, request); !stderrors.Is(err, ErrFileNotFound) {
+		t.Errorf("CreateBatch() with another user's file: error = %v, want ErrFileNotFound", err)
+	}
+	if _, err := service.CreateBatch(ctx, "user-1", &CreateRequest{InputFileID: "file-in", Endpoint: "/v1/images/generations"}, request); !stderrors.Is(err, ErrUnsupportedEndpoint) {
+		t.Errorf("CreateBatch() with unsupported endpoint: error = %v, want ErrUnsupportedEndpoint", err)
+	}
+	for range 3 {
+		if resp, err := service.CreateBatch(ctx, "user-1", create, request); err != nil || !resp.OK() {
+			t.Fatalf("CreateBatch() = %+v, %v", resp, err)
+		}
+	}
+
+	if _, err := service.GetBatch(ctx, "user-2", "batch_1"); !stderrors.Is(err, ErrBatchNotFound) {
+		t.Errorf("GetBatch() of another user's batch: error = %v, want ErrBatchNotFound", err)
+	}
+	if _, err := service.CancelBatch(ctx, "user-2", "batch_1"); !stderrors.Is(err, ErrBatchNotFound) {
+		t.Errorf("CancelBatch() of another user's batch: error = %v, want ErrBatchNotFound", err)
+	}
+
+	list, err := service.ListBatches(ctx, "user-1", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 2 || !list.HasMore || *list.FirstID != "batch_3" || *list.LastID != "batch_2" {
+		t.Errorf("first page = %d batches, has_more %v, %s..%s", len(list.Data), list.HasMore, *list.FirstID, *list.LastID)
+	}
+	list, err = service.ListBatches(ctx, "user-1", *list.LastID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 || list.HasMore || *list.FirstID != "batch_1" {
+		t.Errorf("second page = %d batches, has_more %v", len(list.Data), list.HasMore)
+	}
+	if list, _ := service.ListBatches(ctx, "user-2", "", 0); len(list.Data) != 0 || list.FirstID != nil {
+		t.Errorf("other user lists %d batches", len(list.Data))
+	}
+}
+
+func TestSyncRecordsUsage(t *testing.T) {
+	service, usage, api := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := service.UploadFile(ctx, "user-1", "input.jsonl", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	request := []byte(`{"input_file_id":"file-in","endpoint":"/v1/chat/completions","completion_window":"24h"}`)
+	if _, err := service.CreateBatch(ctx, "user-1", &CreateRequest{InputFileID: "file-in", Endpoint: "/v1/chat/completions"}, request); err != nil {
+		t.Fatal(err)
+	}
+
+	// Still running: nothing recorded
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(usage.logged) != 0 {
+		t.Fatalf("usage logged for a running batch: %+v", usage.logged)
+	}
+
+	api.mu.Lock()
+	api.completed = true
+	api.mu.Unlock()
+	if err := service.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(usage.logged) != 1 {
+		t.Fatalf("logged %d usage entries, want 1 (one model)", len(usage.logged))
+	}
+	logged := usage.logged[0]
+	if logged.info.UserID != "user-1" || logged.info.Model != "gpt-4o-mini" || logged.info.Endpoint != usageEndpoint {
+		t.Errorf("logged request = %+v", logged.info)
+	}
+	// Failed requests don't count; plan tokens at the batch rate
+	if logged.tokens.PromptTokens != 30 || logged.tokens.CompletionTokens != 70 || logged.tokens.TotalTokens != 100 || logged.tokens.PlanTokens != 50 {
+		t.Errorf("logged tokens = %+v, want 30/70/100 and 50 plan tokens", logged.tokens)
+	}
+
+	// Recorded once
+	if err := service.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(usage.logged) != 1 {
+		t.Errorf("usage logged again: %d entries", len(usage.logged))
+	}
+
+	// Output files belong to the batch owner
+	resp, err := service.FileContent(ctx, "user-1", "file-out")
+	if err != nil {
+		t.Fatalf("FileContent() error = %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(data), `"custom_id":"a"`) {
+		t.Errorf("output file = %s", data)
+	}
+	if _, err := service.FileContent(ctx, "user-2", "file-out"); !stderrors.Is(err, ErrFileNotFound) {
+		t.Errorf("FileContent() of another user's file: error = %v, want ErrFileNotFound", err)
+	}
+	if _, err := service.FileContent(ctx, "user-1", "file-err"); err != nil {
+		t.Errorf("FileContent() of the error file: error = %v", err)
+	}
+}
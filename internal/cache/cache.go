@@ -0,0 +1,180 @@
+// Package cache is the shared cache used across subsystems. Each feature takes a namespaced
+// view of one process-wide Cache (see Namespace), so entries can't collide and metrics are
+// reported per feature. The backend is in-memory by default, or Redis when REDIS_URL is set so
+// that entries (and invalidations) are shared by all instances.
+//
+// Reads fail open: a backend error is logged, counted and treated as a miss, so an unavailable
+// Redis degrades to uncached behavior rather than failing requests.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// keySeparator joins namespaces and keys.
+const keySeparator = ":"
+
+// Backend stores cache entries under fully qualified keys.
+type Backend interface {
+	// Get returns the value of key, and false if it is missing or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl (ttl <= 0 = no expiry).
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Close() error
+}
+
+// Cache is a namespaced view of a backend with a default TTL.
+type Cache struct {
+	backend   Backend
+	namespace string
+	ttl       time.Duration
+	group     *singleflight.Group // Shared by all views; keys are fully qualified
+	logger    *logger.Logger
+}
+
+// New creates the root cache over backend. Features should use a view from Namespace.
+func New(backend Backend, logger *logger.Logger) *Cache {
+	return &Cache{
+		backend: backend,
+		group:   &singleflight.Group{},
+		logger:  logger,
+	}
+}
+
+// Namespace returns a view whose keys are prefixed with name and whose entries expire after
+// ttl by default. Views can be nested ("search" -> "search:exa").
+func (c *Cache) Namespace(name string, ttl time.Duration) *Cache {
+	namespace := name
+	if c.namespace != "" {
+		namespace = c.namespace + keySeparator + name
+	}
+	return &Cache{
+		backend:   c.backend,
+		namespace: namespace,
+		ttl:       ttl,
+		group:     c.group,
+		logger:    c.logger,
+	}
+}
+
+// Close closes the backend. Call it once, on the root cache.
+func (c *Cache) Close() error {
+	return c.backend.Close()
+}
+
+func (c *Cache) key(key string) string {
+	if c.namespace == "" {
+		return key
+	}
+	return c.namespace + keySeparator + key
+}
+
+// Get returns the value of key, and false on a miss or backend error.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, ok, err := c.backend.Get(ctx, c.key(key))
+	switch {
+	case err != nil:
+		c.logger.Warn("cache read failed",
+			slog.String("namespace", c.namespace),
+			slog.String("error", err.Error()))
+		metrics.RecordCacheRequest(c.namespace, "error")
+		return nil, false
+	case !ok:
+		metrics.RecordCacheRequest(c.namespace, "miss")
+		return nil, false
+	}
+	metrics.RecordCacheRequest(c.namespace, "hit")
+	return value, true
+}
+
+// Set stores value under key with the namespace's TTL.
+func (c *Cache) Set(ctx context.Context, key string, value []byte) error {
+	return c.SetWithTTL(ctx, key, value, c.ttl)
+}
+
+// SetWithTTL stores value under key for ttl.
+func (c *Cache) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.backend.Set(ctx, c.key(key), value, ttl)
+}
+
+// Delete removes keys (invalidation).
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	qualified := make([]string, len(keys))
+	for i, key := range keys {
+		qualified[i] = c.key(key)
+	}
+	return c.backend.Delete(ctx, qualified...)
+}
+
+// GetJSON decodes the value of key into v. It reports false on a miss, and on a value that
+// doesn't decode (e.g. written by an older version), which is deleted.
+func (c *Cache) GetJSON(ctx context.Context, key string, v interface{}) bool {
+	data, ok := c.Get(ctx, key)
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		c.logger.Warn("dropping undecodable cache entry",
+			slog.String("namespace", c.namespace),
+			slog.String("error", err.Error()))
+		_ = c.Delete(ctx, key)
+		return false
+	}
+	return true
+}
+
+// SetJSON stores v encoded as JSON under key with the namespace's TTL.
+func (c *Cache) SetJSON(ctx context.Context, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, data)
+}
+
+// Fetch returns the cached value of key, calling load and caching its result on a miss.
+// Concurrent misses for the same key in this process share a single load (and the context of
+// the caller that started it). Load errors are returned and not cached; a failure to store the
+// loaded value is only logged.
+func Fetch[T any](ctx context.Context, c *Cache, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+	if c.GetJSON(ctx, key, &value) {
+		return value, nil
+	}
+
+	result, err, shared := c.group.Do(c.key(key), func() (interface{}, error) {
+		loaded, err := load(ctx)
+		if err != nil {
+			return loaded, err
+		}
+		if err := c.SetJSON(ctx, key, loaded); err != nil {
+			c.logger.Warn("cache write failed",
+				slog.String("namespace", c.namespace),
+				slog.String("error", err.Error()))
+		}
+		return loaded, nil
+	})
+
+	switch {
+	case err != nil:
+		metrics.RecordCacheLoad(c.namespace, "error")
+		var zero T
+		return zero, err
+	case shared:
+		metrics.RecordCacheLoad(c.namespace, "shared")
+	default:
+		metrics.RecordCacheLoad(c.namespace, "loaded")
+	}
+	return result.(T), nil
+}
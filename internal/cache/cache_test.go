@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+func newTestCache(backend Backend) *Cache {
+	return New(backend, logger.New(logger.Config{Level: slog.LevelError}))
+}
+
+// failingBackend fails every operation.
+type failingBackend struct{}
+
+func (failingBackend) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("backend down")
+}
+
+func (failingBackend) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("backend down")
+}
+
+func (failingBackend) Delete(context.Context, ...string) error { return errors.New("backend down") }
+func (failingBackend) Close() error                            { return nil }
+
+func TestMemoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	m := NewMemory(0)
+	m.now = func() time.Time { return now }
+
+	_ = m.Set(ctx, "short", []byte("a"), time.Minute)
+	_ = m.Set(ctx, "forever", []byte("b"), 0)
+
+	now = now.Add(time.Minute)
+	if _, ok, _ := m.Get(ctx, "short"); ok {
+		t.Error("entry should have expired")
+	}
+	if value, ok, _ := m.Get(ctx, "forever"); !ok || string(value) != "b" {
+		t.Errorf("entry without TTL = %q, %v", value, ok)
+	}
+	if m.Len() != 1 {
+		t.Errorf("expired entry was not removed, len = %d", m.Len())
+	}
+}
+
+func TestMemoryMaxEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	m := NewMemory(2)
+	m.now = func() time.Time { return now }
+
+	_ = m.Set(ctx, "a", []byte("1"), time.Second)
+	_ = m.Set(ctx, "b", []byte("2"), time.Hour)
+	_ = m.Set(ctx, "b", []byte("3"), time.Hour) // Overwrite doesn't evict
+	if m.Len() != 2 {
+		t.Fatalf("len = %d, want 2", m.Len())
+	}
+
+	_ = m.Set(ctx, "c", []byte("4"), time.Hour)
+	if m.Len() != 2 {
+		t.Fatalf("len = %d, want 2", m.Len())
+	}
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Error("the entry expiring soonest should have been evicted")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemory(0)
+	root := newTestCache(backend)
+	users := root.Namespace("users", time.Minute)
+	search := root.Namespace("search", time.Minute).Namespace("exa", time.Minute)
+
+	_ = users.Set(ctx, "k", []byte("user"))
+	_ = search.Set(ctx, "k", []byte("search"))
+
+	if value, ok := users.Get(ctx, "k"); !ok || string(value) != "user" {
+		t.Errorf("users k = %q, %v", value, ok)
+	}
+	if _, ok, _ := backend.Get(ctx, "search:exa:k"); !ok {
+		t.Error("nested namespace key was not qualified")
+	}
+
+	_ = users.Delete(ctx, "k")
+	if _, ok := users.Get(ctx, "k"); ok {
+		t.Error("deleted entry is still cached")
+	}
+	if _, ok := search.Get(ctx, "k"); !ok {
+		t.Error("delete leaked across namespaces")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(NewMemory(0)).Namespace("test", time.Minute)
+
+	type value struct {
+		Name string `json:"name"`
+	}
+	loads := 0
+	load := func(context.Context) (value, error) {
+		loads++
+		return value{Name: "Ada"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := Fetch(ctx, c, "k", load)
+		if err != nil || got.Name != "Ada" {
+			t.Fatalf("Fetch = %v, %v", got, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1", loads)
+	}
+
+	_, err := Fetch(ctx, c, "failing", func(context.Context) (value, error) {
+		return value{}, errors.New("load failed")
+	})
+	if err == nil {
+		t.Fatal("expected the load error")
+	}
+	if _, ok := c.Get(ctx, "failing"); ok {
+		t.Error("load errors should not be cached")
+	}
+}
+
+func TestFetchSharesConcurrentLoads(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(NewMemory(0)).Namespace("test", time.Minute)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	const callers = 10
+	var started, done sync.WaitGroup
+	started.Add(callers)
+	done.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer done.Done()
+			started.Done()
+			if got, err := Fetch(ctx, c, "k", load); err != nil || got != 42 {
+				t.Errorf("Fetch = %v, %v", got, err)
+			}
+		}()
+	}
+	started.Wait()
+	time.Sleep(20 * time.Millisecond) // Let the callers reach the load
+	close(release)
+	done.Wait()
+
+	if loads.Load() != 1 {
+		t.Errorf("loads = %d, want 1", loads.Load())
+	}
+}
+
+func TestFailOpen(t *testing.T) {
+	ctx := context.Background()
+	c := newTestCache(failingBackend{}).Namespace("test", time.Minute)
+
+	if _, ok := c.Get(ctx, "k"); ok {
+		t.Error("backend errors should be served as a miss")
+	}
+	got, err := Fetch(ctx, c, "k", func(context.Context) (string, error) { return "loaded", nil })
+	if err != nil || got != "loaded" {
+		t.Errorf("Fetch = %q, %v; want the loaded value despite the backend failure", got, err)
+	}
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// evictionSamples is how many entries are sampled to pick one to evict when the memory cache
+// is full (Redis-style approximated eviction, to keep Set O(1)).
+const evictionSamples = 16
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // Zero = no expiry
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// Memory is an in-process backend, local to each instance.
+type Memory struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemory creates an in-memory backend holding at most maxEntries entries (<= 0 = unbounded).
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		entries:    make(map[string]memoryEntry),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if entry.expired(m.now()) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = m.now().Add(ttl)
+	}
+	if _, exists := m.entries[key]; !exists && m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		m.evictLocked()
+	}
+	m.entries[key] = entry
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Len returns the number of stored entries, including expired ones not yet evicted.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// evictLocked removes an expired entry if one is sampled, otherwise the sampled entry expiring
+// soonest. Caller holds m.mu.
+func (m *Memory) evictLocked() {
+	now := m.now()
+	var victim string
+	var victimEntry memoryEntry
+	sampled := 0
+	for key, entry := range m.entries {
+		if entry.expired(now) {
+			victim = key
+			break
+		}
+		if sampled == 0 || (!entry.expiresAt.IsZero() && (victimEntry.expiresAt.IsZero() || entry.expiresAt.Before(victimEntry.expiresAt))) {
+			victim, victimEntry = key, entry
+		}
+		sampled++
+		if sampled >= evictionSamples {
+			break
+		}
+	}
+	delete(m.entries, victim)
+}
@@ -0,0 +1,149 @@
+// Package capabilities parses the X-Client-Capabilities header so streaming
+// features can be rolled out without breaking older app versions.
+//
+// Clients send a comma-separated list of feature tokens:
+//
+//	X-Client-Capabilities: stream-v2, tool-notifications, citations
+//
+// Clients that don't send the header get LegacyDefaults, i.e., exactly what
+// the proxy streamed before negotiation existed. Clients that do send it get
+// only what they list. New features must be added here and gated with Has,
+// never enabled unconditionally.
+package capabilities
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderName is the request header carrying the client's capabilities.
+const HeaderName = "X-Client-Capabilities"
+
+// Capability is a streaming feature the client can opt into.
+type Capability string
+
+const (
+	// StreamV2 enables the v2 streaming event format.
+	StreamV2 Capability = "stream-v2"
+	// ToolNotifications enables tool_notification progress events during tool execution.
+	ToolNotifications Capability = "tool-notifications"
+	// Citations enables structured citation events for search-backed answers.
+	Citations Capability = "citations"
+	// Coalescing enables coalesced delivery of small content deltas.
+	Coalescing Capability = "coalescing"
+	// CompactionEvents enables the context_compacted event when older turns were compacted.
+	CompactionEvents Capability = "compaction-events"
+	// DeprecationEvents enables the model_deprecated event when a deprecated model was requested.
+	DeprecationEvents Capability = "deprecation-events"
+	// QueueEvents enables queue_position events while a request waits for a rate-limited provider.
+	QueueEvents Capability = "queue-events"
+)
+
+// known lists every capability the proxy understands; unknown tokens are dropped.
+var known = map[Capability]bool{
+	StreamV2:          true,
+	ToolNotifications: true,
+	Citations:         true,
+	Coalescing:        true,
+	CompactionEvents:  true,
+	DeprecationEvents: true,
+	QueueEvents:       true,
+}
+
+// LegacyDefaults are assumed for clients that don't send the header.
+var LegacyDefaults = []Capability{ToolNotifications}
+
+// Set is the negotiated capabilities for a request.
+type Set struct {
+	declared bool
+	caps     map[Capability]bool
+}
+
+// Parse parses an X-Client-Capabilities header value.
+// Tokens are case-insensitive; whitespace and unknown tokens are ignored.
+// An empty value yields LegacyDefaults.
+func Parse(header string) Set {
+	if strings.TrimSpace(header) == "" {
+		caps := make(map[Capability]bool, len(LegacyDefaults))
+		for _, c := range LegacyDefaults {
+			caps[c] = true
+		}
+		return Set{caps: caps}
+	}
+
+	caps := make(map[Capability]bool)
+	for _, token := range strings.Split(header, ",") {
+		c := Capability(strings.ToLower(strings.TrimSpace(token)))
+		if known[c] {
+			caps[c] = true
+		}
+	}
+	return Set{declared: true, caps: caps}
+}
+
+// Has reports whether the client supports the capability.
+func (s Set) Has(c Capability) bool {
+	return s.caps[c]
+}
+
+// With returns the set plus caps, e.g. the capabilities implied by an API version.
+func (s Set) With(caps ...Capability) Set {
+	merged := make(map[Capability]bool, len(s.caps)+len(caps))
+	for c := range s.caps {
+		merged[c] = true
+	}
+	for _, c := range caps {
+		merged[c] = true
+	}
+	return Set{declared: s.declared, caps: merged}
+}
+
+// Declared reports whether the client sent the header (false = legacy client).
+func (s Set) Declared() bool {
+	return s.declared
+}
+
+// List returns the supported capabilities in a stable order, for logging.
+func (s Set) List() []string {
+	var list []string
+	for _, c := range []Capability{StreamV2, ToolNotifications, Citations, Coalescing, CompactionEvents, DeprecationEvents, QueueEvents} {
+		if s.caps[c] {
+			list = append(list, string(c))
+		}
+	}
+	return list
+}
+
+type contextKey string
+
+const setKey contextKey = "client_capabilities"
+
+// WithSet adds the capability set to the context.
+func WithSet(ctx context.Context, set Set) context.Context {
+	return context.WithValue(ctx, setKey, set)
+}
+
+// FromContext returns the capability set from the context.
+// Returns LegacyDefaults if the middleware didn't run (e.g., background jobs).
+func FromContext(ctx context.Context) Set {
+	if set, ok := ctx.Value(setKey).(Set); ok {
+		return set
+	}
+	return Parse("")
+}
+
+// Middleware parses the header once and attaches the result to the request context.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set := Parse(c.GetHeader(HeaderName))
+		c.Request = c.Request.WithContext(WithSet(c.Request.Context(), set))
+		c.Next()
+	}
+}
+
+// FromGin returns the capability set for the request.
+func FromGin(c *gin.Context) Set {
+	return FromContext(c.Request.Context())
+}
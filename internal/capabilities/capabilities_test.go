@@ -0,0 +1,71 @@
+package capabilities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		wantDeclared bool
+		want         []string
+	}{
+		{
+			name:   "missing header uses legacy defaults",
+			header: "",
+			want:   []string{"tool-notifications"},
+		},
+		{
+			name:         "listed capabilities only",
+			header:       "stream-v2, citations",
+			wantDeclared: true,
+			want:         []string{"stream-v2", "citations"},
+		},
+		{
+			name:         "case and whitespace insensitive",
+			header:       " Tool-Notifications ,COALESCING",
+			wantDeclared: true,
+			want:         []string{"tool-notifications", "coalescing"},
+		},
+		{
+			name:         "unknown tokens are dropped",
+			header:       "telepathy,,stream-v2",
+			wantDeclared: true,
+			want:         []string{"stream-v2"},
+		},
+		{
+			name:         "declared but empty disables legacy defaults",
+			header:       "none",
+			wantDeclared: true,
+			want:         nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.header)
+			if got.Declared() != tt.wantDeclared {
+				t.Errorf("Declared() = %v, want %v", got.Declared(), tt.wantDeclared)
+			}
+			if !reflect.DeepEqual(got.List(), tt.want) {
+				t.Errorf("List() = %v, want %v", got.List(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWith(t *testing.T) {
+	legacy := Parse("")
+	got := legacy.With(StreamV2, Citations)
+	if want := []string{"stream-v2", "tool-notifications", "citations"}; !reflect.DeepEqual(got.List(), want) {
+		t.Errorf("List() = %v, want %v", got.List(), want)
+	}
+	if got.Declared() {
+		t.Error("With() declared a legacy set")
+	}
+	if legacy.Has(StreamV2) {
+		t.Error("With() modified the original set")
+	}
+}
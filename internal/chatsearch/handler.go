@@ -0,0 +1,155 @@
+package chatsearch
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles HTTP requests for the chat search index.
+type Handler struct {
+	service *Service
+	logger  *logger.Logger
+}
+
+// NewHandler creates a new chat search handler.
+func NewHandler(service *Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// IndexMessage handles PUT /api/v1/chats/:chatId/messages/:messageId/search-tokens
+// Replaces the search tokens of a message; uploading tokens opts the user in.
+func (h *Handler) IndexMessage(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("chat-search-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	chatID, messageID := c.Param("chatId"), c.Param("messageId")
+	if chatID == "" || len(chatID) > MaxIDLength || messageID == "" || len(messageID) > MaxIDLength {
+		errors.BadRequest(c, "invalid chatId or messageId", nil)
+		return
+	}
+
+	var req IndexMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	if err := h.service.IndexMessage(c.Request.Context(), userID, chatID, messageID, req.Tokens); err != nil {
+		if stderrors.Is(err, ErrTooManyTokens) || stderrors.Is(err, ErrTokenTooLong) {
+			errors.BadRequest(c, err.Error(), nil)
+			return
+		}
+		log.Error("failed to index message",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID))
+		errors.Internal(c, "failed to index message", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Search handles GET /api/v1/chats/search?token=...&token=...&limit=50
+// Returns the messages indexed with every token.
+func (h *Handler) Search(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("chat-search-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	limit := DefaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > MaxSearchLimit {
+			errors.BadRequest(c, "limit must be between 1 and "+strconv.Itoa(MaxSearchLimit), nil)
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := h.service.Search(c.Request.Context(), userID, c.QueryArray("token"), limit)
+	if err != nil {
+		if stderrors.Is(err, ErrNoTokens) || stderrors.Is(err, ErrTooManyTokens) || stderrors.Is(err, ErrTokenTooLong) {
+			errors.BadRequest(c, err.Error(), nil)
+			return
+		}
+		log.Error("failed to search messages",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to search messages", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, SearchResponse{Results: results})
+}
+
+// DeleteChat handles DELETE /api/v1/chats/:chatId/search-tokens
+// Removes a chat from the search index.
+func (h *Handler) DeleteChat(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("chat-search-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	chatID := c.Param("chatId")
+	if chatID == "" || len(chatID) > MaxIDLength {
+		errors.BadRequest(c, "invalid chatId", nil)
+		return
+	}
+
+	if err := h.service.DeleteChat(c.Request.Context(), userID, chatID); err != nil {
+		log.Error("failed to delete chat from search index",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID))
+		errors.Internal(c, "failed to delete chat from search index", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteIndex handles DELETE /api/v1/chats/search
+// Removes everything the user has indexed (opt-out).
+func (h *Handler) DeleteIndex(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("chat-search-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	if err := h.service.DeleteAll(c.Request.Context(), userID); err != nil {
+		log.Error("failed to delete search index",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to delete search index", nil)
+		return
+	}
+
+	log.Info("search index deleted", slog.String("user_id", userID))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,51 @@
+package chatsearch
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// MaxTokensPerMessage caps the tokens indexed for a single message.
+	MaxTokensPerMessage = 1000
+
+	// MaxQueryTokens caps the tokens of a search; every token must match.
+	MaxQueryTokens = 16
+
+	// MaxTokenLength caps a token, in bytes (blinded tokens are typically 32–64 bytes encoded).
+	MaxTokenLength = 256
+
+	// MaxIDLength caps chat and message IDs.
+	MaxIDLength = 256
+
+	// DefaultSearchLimit and MaxSearchLimit bound the number of search results.
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+)
+
+var (
+	ErrTooManyTokens = errors.New("too many tokens")
+	ErrTokenTooLong  = errors.New("token is too long")
+	ErrNoTokens      = errors.New("at least one token is required")
+)
+
+// IndexMessageRequest is the body of PUT /api/v1/chats/:chatId/messages/:messageId/search-tokens.
+//
+// Tokens are opaque to the proxy: E2EE clients upload keywords blinded with a key the server
+// never sees (e.g., HMAC-SHA256 of the normalized keyword), others may upload the normalized
+// keywords themselves. Searches must use the same normalization and blinding.
+type IndexMessageRequest struct {
+	Tokens []string `json:"tokens"` // Replaces the message's tokens; empty removes it from the index
+}
+
+// SearchResult is a message matching every token of a search.
+type SearchResult struct {
+	ChatID    string    `json:"chatId"`
+	MessageID string    `json:"messageId"`
+	IndexedAt time.Time `json:"indexedAt"`
+}
+
+// SearchResponse is the response of GET /api/v1/chats/search.
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+}
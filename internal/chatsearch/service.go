@@ -0,0 +1,113 @@
+// Package chatsearch is an opt-in, server-side search index over user chats. Clients upload
+// keyword tokens per message and search them from any device; the proxy only stores SHA-256
+// digests of the tokens and never sees message content.
+package chatsearch
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// Service stores and searches chat search tokens.
+type Service struct {
+	queries pgdb.Querier
+}
+
+// NewService creates a new chat search service.
+func NewService(queries pgdb.Querier) *Service {
+	return &Service{queries: queries}
+}
+
+// IndexMessage replaces the tokens indexed for a message. No tokens removes the message from the index.
+func (s *Service) IndexMessage(ctx context.Context, userID, chatID, messageID string, tokens []string) error {
+	hashes, err := hashTokens(tokens, MaxTokensPerMessage)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.ReplaceChatSearchTokens(ctx, pgdb.ReplaceChatSearchTokensParams{
+		UserID:      userID,
+		ChatID:      chatID,
+		MessageID:   messageID,
+		TokenHashes: hashes,
+	}); err != nil {
+		return fmt.Errorf("failed to index message: %w", err)
+	}
+	return nil
+}
+
+// Search returns the user's messages indexed with every token, most recently indexed first.
+func (s *Service) Search(ctx context.Context, userID string, tokens []string, limit int) ([]SearchResult, error) {
+	hashes, err := hashTokens(tokens, MaxQueryTokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) == 0 {
+		return nil, ErrNoTokens
+	}
+
+	rows, err := s.queries.SearchChatMessages(ctx, pgdb.SearchChatMessagesParams{
+		UserID:      userID,
+		TokenHashes: hashes,
+		TokenCount:  int32(len(hashes)),
+		ResultLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{ChatID: row.ChatID, MessageID: row.MessageID, IndexedAt: row.IndexedAt}
+	}
+	return results, nil
+}
+
+// DeleteChat removes a chat from the user's index (e.g., after the chat is deleted).
+func (s *Service) DeleteChat(ctx context.Context, userID, chatID string) error {
+	if err := s.queries.DeleteChatSearchTokens(ctx, pgdb.DeleteChatSearchTokensParams{
+		UserID: userID,
+		ChatID: chatID,
+	}); err != nil {
+		return fmt.Errorf("failed to delete chat from search index: %w", err)
+	}
+	return nil
+}
+
+// DeleteAll removes everything the user has indexed, opting them out of search.
+func (s *Service) DeleteAll(ctx context.Context, userID string) error {
+	if err := s.queries.DeleteUserChatSearchTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete search index: %w", err)
+	}
+	return nil
+}
+
+// hashTokens returns the distinct SHA-256 digests of the non-blank tokens. Tokens are compared
+// exactly apart from surrounding whitespace; normalization is up to the client.
+func hashTokens(tokens []string, max int) ([][]byte, error) {
+	seen := make(map[[sha256.Size]byte]bool, len(tokens))
+	hashes := make([][]byte, 0, len(tokens))
+	for _, token := range tokens {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if len(token) > MaxTokenLength {
+			return nil, ErrTokenTooLong
+		}
+		hash := sha256.Sum256([]byte(token))
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		hashes = append(hashes, hash[:])
+		if len(hashes) > max {
+			return nil, fmt.Errorf("%w (max %d)", ErrTooManyTokens, max)
+		}
+	}
+	return hashes, nil
+}
@@ -0,0 +1,90 @@
+package chatsearch
+
+import (
+	"context"
+	"crypto/sha256"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// tokenStore records the queries it receives; other Querier methods are not used.
+type tokenStore struct {
+	pgdb.Querier
+	replaced *pgdb.ReplaceChatSearchTokensParams
+	searched *pgdb.SearchChatMessagesParams
+}
+
+func (s *tokenStore) ReplaceChatSearchTokens(_ context.Context, arg pgdb.ReplaceChatSearchTokensParams) error {
+	s.replaced = &arg
+	return nil
+}
+
+func (s *tokenStore) SearchChatMessages(_ context.Context, arg pgdb.SearchChatMessagesParams) ([]pgdb.SearchChatMessagesRow, error) {
+	s.searched = &arg
+	return []pgdb.SearchChatMessagesRow{{ChatID: "chat-1", MessageID: "msg-1"}}, nil
+}
+
+func TestIndexMessage(t *testing.T) {
+	store := &tokenStore{}
+	service := NewService(store)
+
+	if err := service.IndexMessage(context.Background(), "user-1", "chat-1", "msg-1", []string{"alpha", " alpha ", "", "beta"}); err != nil {
+		t.Fatal(err)
+	}
+	alpha := sha256.Sum256([]byte("alpha"))
+	if hashes := store.replaced.TokenHashes; len(hashes) != 2 || string(hashes[0]) != string(alpha[:]) {
+		t.Errorf("token hashes = %x, want the digests of alpha and beta", hashes)
+	}
+
+	// No tokens removes the message from the index
+	if err := service.IndexMessage(context.Background(), "user-1", "chat-1", "msg-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if store.replaced.TokenHashes == nil || len(store.replaced.TokenHashes) != 0 {
+		t.Errorf("token hashes = %v, want an empty array", store.replaced.TokenHashes)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	store := &tokenStore{}
+	service := NewService(store)
+
+	results, err := service.Search(context.Background(), "user-1", []string{"alpha", "beta", "alpha"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ChatID != "chat-1" {
+		t.Errorf("results = %+v", results)
+	}
+	if store.searched.TokenCount != 2 || len(store.searched.TokenHashes) != 2 || store.searched.ResultLimit != 10 {
+		t.Errorf("search params = %+v", store.searched)
+	}
+}
+
+func TestTokenValidation(t *testing.T) {
+	service := NewService(&tokenStore{})
+	many := make([]string, MaxQueryTokens+1)
+	for i := range many {
+		many[i] = strings.Repeat("x", i+1)
+	}
+
+	tests := []struct {
+		name   string
+		tokens []string
+		want   error
+	}{
+		{"no tokens", []string{" "}, ErrNoTokens},
+		{"too many tokens", many, ErrTooManyTokens},
+		{"token too long", []string{strings.Repeat("x", MaxTokenLength+1)}, ErrTokenTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Search(context.Background(), "user-1", tt.tokens, 10); !stderrors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Package clientversion enforces minimum app versions per route, so features can soft launch
+// to updated apps only: clients send their platform (X-Client-Platform) and version
+// (X-Client-Version), and too-old clients get a 426 upgrade-required error the apps turn into
+// an update prompt.
+package clientversion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// PlatformHeader is the request header carrying the client platform ("ios", "android", ...).
+	PlatformHeader = "X-Client-Platform"
+
+	// VersionHeader is the request header carrying the app version ("2.4.1").
+	VersionHeader = "X-Client-Version"
+)
+
+// Version is a dotted numeric version; missing components compare as zero ("2.4" == "2.4.0").
+type Version []int
+
+// ParseVersion parses a dotted numeric version, ignoring a "v" prefix and any pre-release or
+// build suffix ("2.4.1-beta+12" is 2.4.1).
+func ParseVersion(s string) (Version, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(trimmed, "-+ "); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+
+	parts := strings.Split(trimmed, ".")
+	version := make(Version, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+// Less reports whether v is older than other.
+func (v Version) Less(other Version) bool {
+	for i := 0; i < len(v) || i < len(other); i++ {
+		a, b := v.component(i), other.component(i)
+		if a != b {
+			return a < b
+		}
+	}
+	return false
+}
+
+func (v Version) component(i int) int {
+	if i < len(v) {
+		return v[i]
+	}
+	return 0
+}
+
+// rule is a parsed config.ClientVersionRule.
+type rule struct {
+	name        string
+	routes      []string
+	minVersions map[string]minVersion
+	upgradeURLs map[string]string
+}
+
+type minVersion struct {
+	raw     string
+	version Version
+}
+
+// Policy holds the minimum version rules.
+type Policy struct {
+	rules []rule
+}
+
+// NewPolicy parses the minimum versions of the config. A nil config yields a nil policy, which
+// allows every request.
+func NewPolicy(cfg *config.ClientVersionsConfig) (*Policy, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	policy := &Policy{rules: make([]rule, 0, len(cfg.Rules))}
+	for _, r := range cfg.Rules {
+		parsed := rule{
+			name:        r.Name,
+			routes:      r.Routes,
+			minVersions: make(map[string]minVersion, len(r.MinVersions)),
+			upgradeURLs: r.UpgradeURLs,
+		}
+		for platform, raw := range r.MinVersions {
+			version, err := ParseVersion(raw)
+			if err != nil {
+				return nil, fmt.Errorf("client version rule %v: %s: %w", r.Name, platform, err)
+			}
+			parsed.minVersions[platform] = minVersion{raw: raw, version: version}
+		}
+		policy.rules = append(policy.rules, parsed)
+	}
+	return policy, nil
+}
+
+// Check returns the error to send to a client of the platform and version requesting path; nil
+// if the request is allowed. Clients that don't send a (parsable) version predate the
+// X-Client-Version header and are older than any minimum.
+func (p *Policy) Check(path, platform, clientVersion string) *errors.UpgradeRequiredError {
+	if p == nil {
+		return nil
+	}
+
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	for _, r := range p.rules {
+		if !r.matches(path) {
+			continue
+		}
+		required, ok := r.minVersions[platform]
+		if !ok {
+			continue
+		}
+		if version, err := ParseVersion(clientVersion); err == nil && !version.Less(required.version) {
+			continue
+		}
+		return errors.UpgradeRequired(r.name, platform, strings.TrimSpace(clientVersion), required.raw, r.upgradeURLs[platform])
+	}
+	return nil
+}
+
+// matches reports whether path is one of the rule's routes or below one.
+func (r rule) matches(path string) bool {
+	for _, route := range r.routes {
+		route = strings.TrimSuffix(route, "/")
+		if path == route || strings.HasPrefix(path, route+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware rejects requests from clients below the minimum version of the route with a 426
+// upgrade-required error. A nil policy allows every request.
+func Middleware(policy *Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := policy.Check(c.Request.URL.Path, c.GetHeader(PlatformHeader), c.GetHeader(VersionHeader)); err != nil {
+			errors.AbortWithUpgradeRequired(c, err)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,125 @@
+package clientversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestVersionLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"2.3.9", "2.4.0", true},
+		{"2.4", "2.4.0", false},
+		{"2.4.0", "2.4", false},
+		{"2.10.0", "2.9.1", false},
+		{"v2.4.1-beta+12", "2.4.1", false},
+		{"1", "1.0.1", true},
+	}
+	for _, tt := range tests {
+		a, err := ParseVersion(tt.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseVersion(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Less(b); got != tt.want {
+			t.Errorf("%s < %s = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"", "beta", "2..1", "2.x"} {
+		if _, err := ParseVersion(invalid); err == nil {
+			t.Errorf("ParseVersion(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy, err := NewPolicy(&config.ClientVersionsConfig{Rules: []config.ClientVersionRule{{
+		Name:        "responses-api",
+		Routes:      []string{"/responses"},
+		MinVersions: map[string]string{"ios": "2.4.0", "android": "2.1"},
+		UpgradeURLs: map[string]string{"ios": "https://apps.apple.com/app/id1"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name              string
+		path              string
+		platform, version string
+		wantBlocked       bool
+	}{
+		{name: "current ios", path: "/responses", platform: "ios", version: "2.4.0"},
+		{name: "old ios", path: "/responses", platform: "iOS", version: "2.3.7", wantBlocked: true},
+		{name: "missing version", path: "/responses", platform: "android", wantBlocked: true},
+		{name: "sub-route", path: "/responses/resp_1", platform: "ios", version: "2.0", wantBlocked: true},
+		{name: "other route", path: "/chat/completions", platform: "ios", version: "2.0"},
+		{name: "route prefix of another path", path: "/responses-old", platform: "ios", version: "2.0"},
+		{name: "unlisted platform", path: "/responses", platform: "desktop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.path, tt.platform, tt.version)
+			if (err != nil) != tt.wantBlocked {
+				t.Fatalf("blocked = %v, want %v", err != nil, tt.wantBlocked)
+			}
+		})
+	}
+
+	if _, err := NewPolicy(&config.ClientVersionsConfig{Rules: []config.ClientVersionRule{{
+		Name: "bad", Routes: []string{"/responses"}, MinVersions: map[string]string{"ios": "latest"},
+	}}}); err == nil {
+		t.Error("NewPolicy accepted an invalid minimum version")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy, err := NewPolicy(&config.ClientVersionsConfig{Rules: []config.ClientVersionRule{{
+		Name:        "responses-api",
+		Routes:      []string{"/responses"},
+		MinVersions: map[string]string{"ios": "2.4.0"},
+		UpgradeURLs: map[string]string{"ios": "https://apps.apple.com/app/id1"},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(Middleware(policy))
+	router.POST("/responses", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodPost, "/responses", nil)
+	req.Header.Set(PlatformHeader, "ios")
+	req.Header.Set(VersionHeader, "2.3.0")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUpgradeRequired {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUpgradeRequired)
+	}
+	want := `{"error":"Client version 2.3.0 is below the minimum 2.4.0 for ios","uiMessage":"Please update to the latest version of the app to continue.","rule":"responses-api","platform":"ios","client_version":"2.3.0","min_version":"2.4.0","upgrade_url":"https://apps.apple.com/app/id1"}`
+	if w.Body.String() != want {
+		t.Errorf("body = %s, want %s", w.Body.String(), want)
+	}
+
+	// A nil policy allows everything
+	router = gin.New()
+	router.Use(Middleware(nil))
+	router.POST("/responses", func(c *gin.Context) { c.Status(http.StatusOK) })
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/responses", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("nil policy status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
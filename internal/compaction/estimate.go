@@ -0,0 +1,64 @@
+package compaction
+
+import "encoding/json"
+
+const (
+	// charsPerToken is a conservative average for English text across common tokenizers.
+	charsPerToken = 4
+
+	// messageOverheadTokens covers role and framing tokens added per message.
+	messageOverheadTokens = 4
+
+	// imagePartTokens is a flat estimate for an image content part.
+	imagePartTokens = 1000
+)
+
+// EstimateTextTokens estimates the token count of a string.
+func EstimateTextTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateMessageTokens estimates the token count of a chat completions message,
+// including multimodal content parts and tool calls.
+func EstimateMessageTokens(msg map[string]interface{}) int {
+	tokens := messageOverheadTokens
+
+	switch content := msg["content"].(type) {
+	case string:
+		tokens += EstimateTextTokens(content)
+	case []interface{}:
+		for _, part := range content {
+			p, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch p["type"] {
+			case "text":
+				text, _ := p["text"].(string)
+				tokens += EstimateTextTokens(text)
+			case "image_url", "input_image":
+				tokens += imagePartTokens
+			default:
+				tokens += estimateJSONTokens(p)
+			}
+		}
+	}
+
+	if name, ok := msg["name"].(string); ok {
+		tokens += EstimateTextTokens(name)
+	}
+	if toolCalls, ok := msg["tool_calls"]; ok {
+		tokens += estimateJSONTokens(toolCalls)
+	}
+
+	return tokens
+}
+
+// estimateJSONTokens estimates tokens for structured values (tool calls, tool definitions).
+func estimateJSONTokens(value interface{}) int {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return EstimateTextTokens(string(data))
+}
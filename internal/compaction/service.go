@@ -0,0 +1,181 @@
+package compaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+// summaryPrefix introduces the summary message that replaces compacted turns.
+const summaryPrefix = "Summary of the earlier part of this conversation (older messages were compacted to fit the context window):\n\n"
+
+// Summarizer writes a summary of conversation messages.
+type Summarizer interface {
+	Summarize(ctx context.Context, messages []map[string]interface{}, maxTokens int, platform string) (string, error)
+}
+
+// Result describes what was compacted. Sent to clients as JSON.
+type Result struct {
+	Strategy        config.CompactionStrategy `json:"strategy"`         // Strategy actually applied
+	RemovedMessages int                       `json:"removed_messages"` // Messages dropped from the request
+	Summarized      bool                      `json:"summarized"`       // Whether a summary replaced them
+	OriginalTokens  int                       `json:"original_tokens"`  // Estimated prompt tokens before
+	CompactedTokens int                       `json:"compacted_tokens"` // Estimated prompt tokens after
+	ContextWindow   int                       `json:"context_window"`
+}
+
+// Service compacts chat completions requests that exceed the target model's context window.
+type Service struct {
+	cfg        *config.CompactionConfig
+	summarizer Summarizer
+	logger     *logger.Logger
+}
+
+// NewService creates a compaction service.
+// summarizer may be nil when the strategy is truncate.
+func NewService(cfg *config.CompactionConfig, summarizer Summarizer, logger *logger.Logger) *Service {
+	return &Service{
+		cfg:        cfg,
+		summarizer: summarizer,
+		logger:     logger,
+	}
+}
+
+// Compact returns the request body with older turns removed (and optionally summarized)
+// so the estimated prompt fits contextWindow minus the completion reserve.
+// Returns the body unchanged and a nil Result when no compaction is needed or possible.
+func (s *Service) Compact(ctx context.Context, body []byte, contextWindow int, platform string) ([]byte, *Result, error) {
+	if contextWindow <= 0 {
+		return body, nil, nil
+	}
+
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body, nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	rawMessages, ok := req["messages"].([]interface{})
+	if !ok || len(rawMessages) == 0 {
+		return body, nil, nil
+	}
+
+	messages := make([]map[string]interface{}, 0, len(rawMessages))
+	for _, raw := range rawMessages {
+		msg, ok := raw.(map[string]interface{})
+		if !ok {
+			return body, nil, nil
+		}
+		messages = append(messages, msg)
+	}
+
+	budget := contextWindow - s.completionReserve(req)
+	if tools, ok := req["tools"]; ok {
+		budget -= estimateJSONTokens(tools)
+	}
+
+	original := estimateMessagesTokens(messages)
+	if original <= budget {
+		return body, nil, nil
+	}
+
+	summarize := s.cfg.Strategy == config.CompactionStrategySummarize && s.summarizer != nil
+	allowance := 0
+	if summarize {
+		allowance = s.cfg.SummaryMaxTokens + messageOverheadTokens + EstimateTextTokens(summaryPrefix)
+	}
+
+	head, kept, removed := selectMessages(messages, budget-allowance, s.cfg.KeepRecentMessages, summarize)
+	if len(removed) == 0 {
+		return body, nil, nil
+	}
+
+	result := &Result{
+		Strategy:        config.CompactionStrategyTruncate,
+		RemovedMessages: len(removed),
+		OriginalTokens:  original,
+		ContextWindow:   contextWindow,
+	}
+
+	compacted := append([]map[string]interface{}{}, head...)
+	if summarize {
+		summary, err := s.summarizer.Summarize(ctx, removed, s.cfg.SummaryMaxTokens, platform)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("conversation summarization failed, truncating instead",
+				slog.Int("removed_messages", len(removed)),
+				slog.String("error", err.Error()))
+		} else {
+			compacted = append(compacted, map[string]interface{}{
+				"role":    "system",
+				"content": summaryPrefix + summary,
+			})
+			result.Strategy = config.CompactionStrategySummarize
+			result.Summarized = true
+		}
+	}
+	compacted = append(compacted, kept...)
+	result.CompactedTokens = estimateMessagesTokens(compacted)
+
+	req["messages"] = compacted
+	newBody, err := json.Marshal(req)
+	if err != nil {
+		return body, nil, fmt.Errorf("failed to marshal compacted request: %w", err)
+	}
+
+	return newBody, result, nil
+}
+
+// completionReserve returns the tokens to keep free for the response.
+func (s *Service) completionReserve(req map[string]interface{}) int {
+	for _, key := range []string{"max_completion_tokens", "max_tokens"} {
+		if value, ok := req[key].(float64); ok && value > 0 {
+			return int(value)
+		}
+	}
+	return s.cfg.ReserveTokens
+}
+
+// selectMessages splits messages into the leading system messages (always kept), the
+// messages kept after them, and the removed ones. The oldest non-system messages are
+// removed until the estimate fits budget; the last message is never removed. With
+// removeOlder, everything before the latest keepRecent messages is removed as well
+// (summaries are better written over a larger span). Tool results whose assistant
+// call was removed are removed too.
+func selectMessages(messages []map[string]interface{}, budget, keepRecent int, removeOlder bool) (head, kept, removed []map[string]interface{}) {
+	headLen := 0
+	for headLen < len(messages) && isSystemMessage(messages[headLen]) {
+		headLen++
+	}
+	head = messages[:headLen]
+	rest := messages[headLen:]
+
+	total := estimateMessagesTokens(messages)
+	cut := 0
+	for cut < len(rest)-1 && total > budget {
+		total -= EstimateMessageTokens(rest[cut])
+		cut++
+	}
+	if removeOlder && cut > 0 {
+		cut = min(max(cut, len(rest)-keepRecent), len(rest)-1)
+	}
+	for cut > 0 && cut < len(rest)-1 && rest[cut]["role"] == "tool" {
+		cut++
+	}
+
+	return head, rest[cut:], rest[:cut]
+}
+
+func isSystemMessage(msg map[string]interface{}) bool {
+	role, _ := msg["role"].(string)
+	return role == "system" || role == "developer"
+}
+
+func estimateMessagesTokens(messages []map[string]interface{}) int {
+	total := 0
+	for _, msg := range messages {
+		total += EstimateMessageTokens(msg)
+	}
+	return total
+}
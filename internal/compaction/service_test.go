@@ -0,0 +1,190 @@
+package compaction
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+type fakeSummarizer struct {
+	summary string
+	err     error
+	got     []map[string]interface{}
+}
+
+func (f *fakeSummarizer) Summarize(_ context.Context, messages []map[string]interface{}, _ int, _ string) (string, error) {
+	f.got = messages
+	return f.summary, f.err
+}
+
+// chat builds a request body with a system prompt and n alternating 400-char turns (~104 tokens each).
+func chat(t *testing.T, n int, extra map[string]interface{}) []byte {
+	t.Helper()
+	messages := []interface{}{map[string]interface{}{"role": "system", "content": "be brief"}}
+	for i := 0; i < n; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages = append(messages, map[string]interface{}{"role": role, "content": strings.Repeat(string(rune('a'+i%26)), 400)})
+	}
+	req := map[string]interface{}{"model": "m", "messages": messages}
+	for k, v := range extra {
+		req[k] = v
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func messagesOf(t *testing.T, body []byte) []map[string]interface{} {
+	t.Helper()
+	var req struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err)
+	}
+	return req.Messages
+}
+
+func TestCompact(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	tests := []struct {
+		name        string
+		strategy    config.CompactionStrategy
+		summarizer  *fakeSummarizer
+		body        []byte
+		window      int
+		wantNil     bool
+		wantRemoved int
+		wantSummary bool
+	}{
+		{name: "fits", strategy: config.CompactionStrategyTruncate, body: chat(t, 4, nil), window: 8192, wantNil: true},
+		{name: "unknown window", strategy: config.CompactionStrategyTruncate, body: chat(t, 40, nil), window: 0, wantNil: true},
+		{
+			// 20 turns ≈ 2086 tokens; budget 1500 - 500 = 1000 → drop the oldest 11
+			name:     "truncate oldest",
+			strategy: config.CompactionStrategyTruncate,
+			body:     chat(t, 20, map[string]interface{}{"max_tokens": 500}),
+			window:   1500, wantRemoved: 11,
+		},
+		{
+			name:       "summarize older than recent window",
+			strategy:   config.CompactionStrategySummarize,
+			summarizer: &fakeSummarizer{summary: "they talked"},
+			body:       chat(t, 20, map[string]interface{}{"max_tokens": 500}),
+			window:     2500, wantRemoved: 14, wantSummary: true,
+		},
+		{
+			name:       "summarizer failure falls back to truncate",
+			strategy:   config.CompactionStrategySummarize,
+			summarizer: &fakeSummarizer{err: errors.New("boom")},
+			body:       chat(t, 20, map[string]interface{}{"max_tokens": 500}),
+			window:     2500, wantRemoved: 14,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.CompactionConfig{Strategy: tt.strategy, SummaryModel: "s"}
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			var summarizer Summarizer
+			if tt.summarizer != nil {
+				summarizer = tt.summarizer
+			}
+			svc := NewService(cfg, summarizer, log)
+
+			body, result, err := svc.Compact(context.Background(), tt.body, tt.window, "mobile")
+			if err != nil {
+				t.Fatalf("Compact() error = %v", err)
+			}
+			if tt.wantNil {
+				if result != nil || string(body) != string(tt.body) {
+					t.Fatalf("Compact() compacted unexpectedly: %+v", result)
+				}
+				return
+			}
+			if result == nil {
+				t.Fatal("Compact() result = nil")
+			}
+			if result.RemovedMessages != tt.wantRemoved {
+				t.Errorf("RemovedMessages = %d, want %d", result.RemovedMessages, tt.wantRemoved)
+			}
+			if result.Summarized != tt.wantSummary {
+				t.Errorf("Summarized = %v, want %v", result.Summarized, tt.wantSummary)
+			}
+			if result.CompactedTokens >= result.OriginalTokens {
+				t.Errorf("CompactedTokens = %d, OriginalTokens = %d", result.CompactedTokens, result.OriginalTokens)
+			}
+
+			messages := messagesOf(t, body)
+			if messages[0]["content"] != "be brief" {
+				t.Errorf("system prompt not kept first: %v", messages[0])
+			}
+			if tt.wantSummary && !strings.HasSuffix(messages[1]["content"].(string), "they talked") {
+				t.Errorf("summary message = %v", messages[1])
+			}
+			wantLen := 21 - tt.wantRemoved
+			if tt.wantSummary {
+				wantLen++
+			}
+			if len(messages) != wantLen {
+				t.Errorf("len(messages) = %d, want %d", len(messages), wantLen)
+			}
+		})
+	}
+}
+
+func TestSelectMessagesDropsOrphanToolResults(t *testing.T) {
+	messages := []map[string]interface{}{
+		{"role": "user", "content": strings.Repeat("a", 400)},
+		{"role": "assistant", "tool_calls": []interface{}{map[string]interface{}{"id": "1"}}},
+		{"role": "tool", "content": "result"},
+		{"role": "assistant", "content": "done"},
+		{"role": "user", "content": "next"},
+	}
+
+	// Budget forces removing the first two messages, which would orphan the tool result.
+	_, kept, removed := selectMessages(messages, 20, 6, false)
+	if len(removed) != 3 || kept[0]["role"] != "assistant" {
+		t.Errorf("removed %d, kept starts with %v", len(removed), kept[0]["role"])
+	}
+}
+
+func TestEstimateMessageTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  map[string]interface{}
+		want int
+	}{
+		{name: "text", msg: map[string]interface{}{"role": "user", "content": "12345678"}, want: 6},
+		{
+			name: "multimodal",
+			msg: map[string]interface{}{"role": "user", "content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "1234"},
+				map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "x"}},
+			}},
+			want: 4 + 1 + imagePartTokens,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateMessageTokens(tt.msg); got != tt.want {
+				t.Errorf("EstimateMessageTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
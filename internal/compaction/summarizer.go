@@ -0,0 +1,140 @@
+package compaction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+const (
+	summaryRequestTimeout = 60 * time.Second
+	summaryTemperature    = 0.2
+	summarySystemPrompt   = `You compress chat transcripts. Summarize the conversation below so an assistant can continue it without the original messages.
+Keep facts, decisions, names, numbers, code identifiers, open questions, and user preferences. Omit pleasantries.
+Write in the language of the conversation. Respond with the summary only.`
+)
+
+// ChatCompletionsSummarizer summarizes via a chat completions model resolved through the model router.
+type ChatCompletionsSummarizer struct {
+	model  string
+	router *routing.ModelRouter
+	client *http.Client
+}
+
+// NewChatCompletionsSummarizer creates a summarizer using the given canonical model.
+func NewChatCompletionsSummarizer(model string, router *routing.ModelRouter) *ChatCompletionsSummarizer {
+	return &ChatCompletionsSummarizer{
+		model:  model,
+		router: router,
+		client: &http.Client{Timeout: summaryRequestTimeout},
+	}
+}
+
+// Summarize asks the summary model for a summary of the messages.
+func (s *ChatCompletionsSummarizer) Summarize(ctx context.Context, messages []map[string]interface{}, maxTokens int, platform string) (string, error) {
+	provider, err := s.router.RouteModel(s.model, platform)
+	if err != nil {
+		return "", fmt.Errorf("route summary model: %w", err)
+	}
+	if provider.APIType != config.APITypeChatCompletions {
+		return "", fmt.Errorf("summary model %s does not use the chat completions API", s.model)
+	}
+
+	payload := map[string]interface{}{
+		"model": provider.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": summarySystemPrompt},
+			{"role": "user", "content": transcript(messages)},
+		},
+		"max_tokens":  maxTokens,
+		"temperature": summaryTemperature,
+		"stream":      false,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	url := provider.BaseURL + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("call summary model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("summary model returned %d (model: %s)", resp.StatusCode, s.model)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no choices in summary response")
+	}
+
+	summary := strings.TrimSpace(result.Choices[0].Message.Content)
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
+
+// transcript renders messages as "role: text" lines. Non-text parts are noted, not inlined.
+func transcript(messages []map[string]interface{}) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		role, _ := msg["role"].(string)
+		b.WriteString(role)
+		b.WriteString(": ")
+
+		switch content := msg["content"].(type) {
+		case string:
+			b.WriteString(content)
+		case []interface{}:
+			for _, part := range content {
+				p, ok := part.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if text, ok := p["text"].(string); ok {
+					b.WriteString(text)
+				} else {
+					fmt.Fprintf(&b, "[%v]", p["type"])
+				}
+			}
+		}
+		if _, ok := msg["tool_calls"]; ok {
+			b.WriteString(" [called tools]")
+		}
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
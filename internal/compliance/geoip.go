@@ -0,0 +1,111 @@
+package compliance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// GeoIP resolves IP addresses to ISO 3166-1 alpha-2 country codes.
+// Data is loaded from a country range CSV ("start_ip,end_ip,country", e.g. the DB-IP Lite
+// country database) and kept in memory as sorted, non-overlapping ranges.
+type GeoIP struct {
+	ranges []ipRange
+}
+
+type ipRange struct {
+	start   netip.Addr
+	end     netip.Addr
+	country string
+}
+
+// LoadGeoIP reads a country range CSV from path.
+func LoadGeoIP(path string) (*GeoIP, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer f.Close()
+
+	return ParseGeoIP(f)
+}
+
+// ParseGeoIP parses a country range CSV. Blank lines and lines starting with '#' are skipped.
+func ParseGeoIP(r io.Reader) (*GeoIP, error) {
+	var ranges []ipRange
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected start_ip,end_ip,country", lineNo)
+		}
+
+		start, err := netip.ParseAddr(strings.Trim(fields[0], `" `))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start IP: %w", lineNo, err)
+		}
+		end, err := netip.ParseAddr(strings.Trim(fields[1], `" `))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end IP: %w", lineNo, err)
+		}
+		start, end = start.Unmap(), end.Unmap()
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", lineNo, start, end)
+		}
+
+		country := strings.ToUpper(strings.Trim(fields[2], `" `))
+		if len(country) != 2 {
+			return nil, fmt.Errorf("line %d: invalid country code %q", lineNo, country)
+		}
+
+		ranges = append(ranges, ipRange{start: start, end: end, country: country})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP database: %w", err)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Less(ranges[j].start)
+	})
+
+	return &GeoIP{ranges: ranges}, nil
+}
+
+// Len returns the number of loaded ranges.
+func (g *GeoIP) Len() int {
+	return len(g.ranges)
+}
+
+// Lookup returns the country for addr, or "" if it isn't covered by any range.
+func (g *GeoIP) Lookup(addr netip.Addr) string {
+	addr = addr.Unmap()
+	if !addr.IsValid() {
+		return ""
+	}
+
+	// Find the last range starting at or before addr
+	i := sort.Search(len(g.ranges), func(i int) bool {
+		return addr.Less(g.ranges[i].start)
+	}) - 1
+	if i < 0 {
+		return ""
+	}
+
+	r := g.ranges[i]
+	if r.start.Is4() != addr.Is4() || r.end.Less(addr) {
+		return ""
+	}
+	return r.country
+}
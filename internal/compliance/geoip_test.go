@@ -0,0 +1,69 @@
+package compliance
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const testGeoIPCSV = `# start,end,country
+"2.0.0.0","2.15.255.255","fr"
+1.0.0.0,1.0.0.255,AU
+5.160.0.0,5.160.255.255,IR
+2001:db8::,2001:db8::ffff,DE
+`
+
+func TestGeoIPLookup(t *testing.T) {
+	geo, err := ParseGeoIP(strings.NewReader(testGeoIPCSV))
+	if err != nil {
+		t.Fatalf("ParseGeoIP() error = %v", err)
+	}
+	if geo.Len() != 4 {
+		t.Fatalf("Len() = %d, want 4", geo.Len())
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "1.0.0.1", want: "AU"},
+		{ip: "1.0.0.255", want: "AU"},
+		{ip: "1.0.1.0", want: ""},
+		{ip: "0.255.255.255", want: ""},
+		{ip: "2.10.0.1", want: "FR"},
+		{ip: "5.160.1.1", want: "IR"},
+		{ip: "::ffff:5.160.1.1", want: "IR"},
+		{ip: "2001:db8::1", want: "DE"},
+		{ip: "2001:db8::1:0", want: ""},
+		{ip: "9.9.9.9", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := geo.Lookup(netip.MustParseAddr(tt.ip)); got != tt.want {
+				t.Errorf("Lookup(%s) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGeoIPErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "missing fields", data: "1.0.0.0,1.0.0.255\n"},
+		{name: "invalid ip", data: "1.0.0,1.0.0.255,AU\n"},
+		{name: "reversed range", data: "1.0.0.255,1.0.0.0,AU\n"},
+		{name: "mixed families", data: "1.0.0.0,2001:db8::,AU\n"},
+		{name: "bad country", data: "1.0.0.0,1.0.0.255,AUS\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseGeoIP(strings.NewReader(tt.data)); err == nil {
+				t.Error("ParseGeoIP() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package compliance
+
+import (
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAllowedCountry rejects requests from countries in blocked_countries.
+// Must run after authentication so denials are attributed to a user in the audit log.
+// When service is nil (no compliance config) the middleware is a no-op.
+func RequireAllowedCountry(service *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if service == nil {
+			c.Next()
+			return
+		}
+
+		if decision := service.CheckCountry(c); !decision.Allowed {
+			errors.AbortWithForbidden(c, errors.RegionRestricted(decision.Country, decision.Rule))
+			return
+		}
+
+		c.Next()
+	}
+}
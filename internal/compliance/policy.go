@@ -0,0 +1,93 @@
+package compliance
+
+import (
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+// RuleSanctions is the rule name reported for countries in blocked_countries.
+const RuleSanctions = "sanctions"
+
+// Decision is the outcome of evaluating the policy for a request.
+type Decision struct {
+	Allowed bool
+	Country string
+	Rule    string // Rule that denied the request; empty when allowed
+}
+
+// Policy is a compiled ComplianceConfig.
+type Policy struct {
+	blocked map[string]struct{}
+	rules   []compiledRule
+}
+
+type compiledRule struct {
+	name      string
+	countries map[string]struct{}
+	providers map[string]struct{}
+	models    map[string]struct{}
+}
+
+// NewPolicy compiles cfg into lookup tables. cfg must already be validated.
+func NewPolicy(cfg *config.ComplianceConfig) *Policy {
+	p := &Policy{blocked: toSet(cfg.BlockedCountries)}
+	for _, rule := range cfg.Rules {
+		p.rules = append(p.rules, compiledRule{
+			name:      rule.Name,
+			countries: toSet(rule.Countries),
+			providers: toSet(rule.Providers),
+			models:    toSet(rule.Models),
+		})
+	}
+	return p
+}
+
+// EvaluateCountry checks only the blanket country block.
+// Requests with an unknown country ("") are allowed.
+func (p *Policy) EvaluateCountry(country string) Decision {
+	if _, ok := p.blocked[country]; ok && country != "" {
+		return Decision{Allowed: false, Country: country, Rule: RuleSanctions}
+	}
+	return Decision{Allowed: true, Country: country}
+}
+
+// Evaluate checks the blanket country block and then per-provider/model rules.
+// Requests with an unknown country ("") are allowed.
+func (p *Policy) Evaluate(country, provider, model string) Decision {
+	if d := p.EvaluateCountry(country); !d.Allowed || country == "" {
+		return d
+	}
+
+	for _, rule := range p.rules {
+		if _, ok := rule.countries[country]; !ok {
+			continue
+		}
+		_, providerMatch := rule.providers[provider]
+		_, modelMatch := rule.models[model]
+		if providerMatch || modelMatch {
+			return Decision{Allowed: false, Country: country, Rule: rule.name}
+		}
+	}
+
+	return Decision{Allowed: true, Country: country}
+}
+
+// RestrictedBy returns the name of the first rule restricting the provider or the model in some
+// countries, or "" if no rule does.
+func (p *Policy) RestrictedBy(provider, model string) string {
+	for _, rule := range p.rules {
+		_, providerMatch := rule.providers[provider]
+		_, modelMatch := rule.models[model]
+		if providerMatch || modelMatch {
+			return rule.name
+		}
+	}
+	return ""
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,98 @@
+package compliance
+
+import (
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	cfg := &config.ComplianceConfig{
+		BlockedCountries: []string{"ir", "KP"},
+		Rules: []config.ComplianceRule{
+			{Name: "openai-regions", Countries: []string{"CN", "RU"}, Providers: []string{"OpenAI"}},
+			{Name: "model-block", Countries: []string{"BY"}, Models: []string{"gpt-5"}},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	policy := NewPolicy(cfg)
+
+	tests := []struct {
+		name      string
+		country   string
+		provider  string
+		model     string
+		wantAllow bool
+		wantRule  string
+	}{
+		{name: "unknown country", country: "", provider: "OpenAI", model: "gpt-5", wantAllow: true},
+		{name: "sanctioned country", country: "IR", provider: "Tinfoil", model: "glm", wantAllow: false, wantRule: RuleSanctions},
+		{name: "provider rule", country: "CN", provider: "OpenAI", model: "gpt-5", wantAllow: false, wantRule: "openai-regions"},
+		{name: "provider rule other provider", country: "CN", provider: "Tinfoil", model: "glm", wantAllow: true},
+		{name: "model rule", country: "BY", provider: "OpenRouter", model: "gpt-5", wantAllow: false, wantRule: "model-block"},
+		{name: "model rule other model", country: "BY", provider: "OpenRouter", model: "glm", wantAllow: true},
+		{name: "unrestricted country", country: "US", provider: "OpenAI", model: "gpt-5", wantAllow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Evaluate(tt.country, tt.provider, tt.model)
+			if got.Allowed != tt.wantAllow || got.Rule != tt.wantRule {
+				t.Errorf("Evaluate() = %+v, want allowed=%v rule=%q", got, tt.wantAllow, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestPolicyRestrictedBy(t *testing.T) {
+	policy := NewPolicy(&config.ComplianceConfig{
+		Rules: []config.ComplianceRule{
+			{Name: "openai-regions", Countries: []string{"CN"}, Providers: []string{"OpenAI"}},
+			{Name: "model-block", Countries: []string{"BY"}, Models: []string{"gpt-5"}},
+		},
+	})
+
+	for _, tt := range []struct {
+		provider, model, want string
+	}{
+		{"OpenAI", "gpt-4.1", "openai-regions"},
+		{"OpenRouter", "gpt-5", "model-block"},
+		{"Tinfoil", "glm", ""},
+	} {
+		if got := policy.RestrictedBy(tt.provider, tt.model); got != tt.want {
+			t.Errorf("RestrictedBy(%q, %q) = %q, want %q", tt.provider, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestComplianceConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ComplianceConfig
+		wantErr bool
+	}{
+		{name: "empty", cfg: config.ComplianceConfig{}},
+		{name: "invalid blocked country", cfg: config.ComplianceConfig{BlockedCountries: []string{"IRN"}}, wantErr: true},
+		{name: "unnamed rule", cfg: config.ComplianceConfig{Rules: []config.ComplianceRule{{Countries: []string{"CN"}, Providers: []string{"OpenAI"}}}}, wantErr: true},
+		{name: "rule without countries", cfg: config.ComplianceConfig{Rules: []config.ComplianceRule{{Name: "a", Providers: []string{"OpenAI"}}}}, wantErr: true},
+		{name: "rule without targets", cfg: config.ComplianceConfig{Rules: []config.ComplianceRule{{Name: "a", Countries: []string{"CN"}}}}, wantErr: true},
+		{
+			name: "duplicate rule",
+			cfg: config.ComplianceConfig{Rules: []config.ComplianceRule{
+				{Name: "a", Countries: []string{"CN"}, Providers: []string{"OpenAI"}},
+				{Name: "a", Countries: []string{"RU"}, Providers: []string{"OpenAI"}},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
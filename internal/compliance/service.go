@@ -0,0 +1,151 @@
+package compliance
+
+import (
+	"context"
+	"log/slog"
+	"net/netip"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// Audit log event names.
+const (
+	EventCountryBlocked = "compliance.country_blocked"
+	EventRouteBlocked   = "compliance.route_blocked"
+	EventRouteAllowed   = "compliance.route_allowed"
+)
+
+// countryContextKey caches the resolved country on the gin context.
+const countryContextKey = "complianceCountry"
+
+// Service resolves request countries and enforces the compliance policy.
+type Service struct {
+	policy        *Policy
+	countryHeader string
+	geo           *GeoIP
+	queries       pgdb.Querier
+	logger        *logger.Logger
+}
+
+// NewService creates a new compliance service. geo may be nil, in which case only
+// the country header is used.
+func NewService(cfg *config.ComplianceConfig, geo *GeoIP, queries pgdb.Querier, logger *logger.Logger) *Service {
+	return &Service{
+		policy:        NewPolicy(cfg),
+		countryHeader: cfg.CountryHeader,
+		geo:           geo,
+		queries:       queries,
+		logger:        logger,
+	}
+}
+
+// ResolveCountry returns the request's ISO 3166-1 alpha-2 country, or "" if unknown.
+// The trusted edge header wins; GeoIP on the client IP is the fallback.
+func (s *Service) ResolveCountry(c *gin.Context) string {
+	if country, ok := c.Get(countryContextKey); ok {
+		return country.(string)
+	}
+
+	country := ""
+	if s.countryHeader != "" {
+		country = strings.ToUpper(strings.TrimSpace(c.GetHeader(s.countryHeader)))
+		// Cloudflare uses XX for unknown and T1 for Tor
+		if len(country) != 2 || country == "XX" || country == "T1" {
+			country = ""
+		}
+	}
+	if country == "" && s.geo != nil {
+		if addr, err := netip.ParseAddr(c.ClientIP()); err == nil {
+			country = s.geo.Lookup(addr)
+		}
+	}
+
+	c.Set(countryContextKey, country)
+	return country
+}
+
+// CheckCountry enforces the blanket country block for the request.
+func (s *Service) CheckCountry(c *gin.Context) Decision {
+	decision := s.policy.EvaluateCountry(s.ResolveCountry(c))
+	if !decision.Allowed {
+		s.record(c, EventCountryBlocked, decision, "", "")
+	}
+	return decision
+}
+
+// CheckRoute enforces the policy for a request routed to provider and model. Denials are
+// audited, and so are allowed requests to a provider or model a rule restricts (with that
+// rule), so the audit log shows who used restricted routes from where. Requests to
+// unrestricted routes aren't audited: the policy can't deny them beyond the country block.
+func (s *Service) CheckRoute(c *gin.Context, provider, model string) Decision {
+	decision := s.policy.Evaluate(s.ResolveCountry(c), provider, model)
+	if !decision.Allowed {
+		s.record(c, EventRouteBlocked, decision, provider, model)
+	} else if rule := s.policy.RestrictedBy(provider, model); rule != "" {
+		audited := decision
+		audited.Rule = rule
+		s.record(c, EventRouteAllowed, audited, provider, model)
+	}
+	return decision
+}
+
+// AllowsRoute reports whether the provider may serve the model for the request's country,
+// like CheckRoute but without auditing a denial. Used to filter alternative routes the client
+// didn't ask for.
+func (s *Service) AllowsRoute(c *gin.Context, provider, model string) bool {
+	return s.policy.Evaluate(s.ResolveCountry(c), provider, model).Allowed
+}
+
+// record writes a decision to the audit log. Failures are logged, not returned, so an audit
+// outage never turns into an allow or a deny.
+func (s *Service) record(c *gin.Context, event string, decision Decision, provider, model string) {
+	ctx := c.Request.Context()
+	log := s.logger.WithContext(ctx).WithComponent("compliance")
+
+	userID, _ := auth.GetUserID(c)
+	path := c.Request.URL.Path
+
+	auditDecision := "allow"
+	if !decision.Allowed {
+		auditDecision = "deny"
+		log.Warn("request blocked by compliance policy",
+			slog.String("event", event),
+			slog.String("user_id", userID),
+			slog.String("country", decision.Country),
+			slog.String("rule", decision.Rule),
+			slog.String("provider", provider),
+			slog.String("model", model),
+			slog.String("path", path))
+	}
+
+	if s.queries == nil {
+		return
+	}
+
+	if err := s.queries.CreateAuditLog(context.WithoutCancel(ctx), pgdb.CreateAuditLogParams{
+		UserID:   userID,
+		Event:    event,
+		Decision: auditDecision,
+		Country:  strPtr(decision.Country),
+		Rule:     strPtr(decision.Rule),
+		Provider: strPtr(provider),
+		Model:    strPtr(model),
+		Path:     strPtr(path),
+	}); err != nil {
+		log.Error("failed to write compliance audit log",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+	}
+}
+
+func strPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
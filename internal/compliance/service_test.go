@@ -0,0 +1,63 @@
+package compliance
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// auditStore records audit log entries; other Querier methods are not used.
+type auditStore struct {
+	pgdb.Querier
+	entries []pgdb.CreateAuditLogParams
+}
+
+func (s *auditStore) CreateAuditLog(_ context.Context, arg pgdb.CreateAuditLogParams) error {
+	s.entries = append(s.entries, arg)
+	return nil
+}
+
+func TestCheckRouteAudit(t *testing.T) {
+	cfg := &config.ComplianceConfig{
+		CountryHeader: "CF-IPCountry",
+		Rules:         []config.ComplianceRule{{Name: "openai-regions", Countries: []string{"CN"}, Providers: []string{"OpenAI"}}},
+	}
+	store := &auditStore{}
+	service := NewService(cfg, nil, store, logger.New(logger.Config{Level: slog.LevelError}))
+
+	check := func(country, provider string) Decision {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+		c.Request.Header.Set("CF-IPCountry", country)
+		return service.CheckRoute(c, provider, "gpt-4.1")
+	}
+
+	if decision := check("CN", "OpenAI"); decision.Allowed {
+		t.Error("OpenAI should be denied in CN")
+	}
+	if decision := check("US", "OpenAI"); !decision.Allowed || decision.Rule != "" {
+		t.Errorf("OpenAI in US = %+v, want allowed without rule", decision)
+	}
+	check("CN", "Tinfoil") // Unrestricted route: not audited
+
+	want := []struct{ event, decision, country string }{
+		{EventRouteBlocked, "deny", "CN"},
+		{EventRouteAllowed, "allow", "US"},
+	}
+	if len(store.entries) != len(want) {
+		t.Fatalf("audit entries = %+v, want %d", store.entries, len(want))
+	}
+	for i, w := range want {
+		got := store.entries[i]
+		if got.Event != w.event || got.Decision != w.decision || *got.Country != w.country || *got.Rule != "openai-regions" {
+			t.Errorf("entries[%d] = %+v, want %s %s in %s by openai-regions", i, got, w.event, w.decision, w.country)
+		}
+	}
+}
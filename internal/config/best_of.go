@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/goccy/go-yaml"
+)
+
+// BestOfConfig controls server-side best-of chat completions: the requested model generates
+// best_of candidates, a scorer model ranks them and only the best is returned. Every candidate
+// and the scoring request are billed, so best-of is limited to cheap models.
+type BestOfConfig struct {
+	// Models are the canonical models best_of may be requested for. Required.
+	Models []string `yaml:"models"`
+
+	// ScorerModel is the canonical model (routed via model_router) that ranks the candidates.
+	// Required.
+	ScorerModel string `yaml:"scorer_model"`
+}
+
+// Validate performs validation of a BestOfConfig value:
+// - Checks that models and the scorer model are specified
+func (cfg *BestOfConfig) Validate() error {
+	if len(cfg.Models) == 0 {
+		return errors.New("best_of requires at least one model")
+	}
+	if cfg.ScorerModel == "" {
+		return errors.New("best_of requires scorer_model")
+	}
+	return nil
+}
+
+// AllowsModel reports whether best_of may be requested for the canonical model.
+func (cfg *BestOfConfig) AllowsModel(model string) bool {
+	for _, allowed := range cfg.Models {
+		if allowed == model {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalBestOfConfig implements a custom YAML unmarshaler for BestOfConfig.
+// Validates the value after unmarshaling.
+func unmarshalBestOfConfig(value *BestOfConfig, data []byte) error {
+	type Aux BestOfConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = BestOfConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[BestOfConfig](unmarshalBestOfConfig)
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ClientVersionsConfig contains the minimum app versions (sent in X-Client-Version) allowed on
+// specific routes, per client platform (X-Client-Platform).
+type ClientVersionsConfig struct {
+	// Rules are evaluated in order; a request is checked against every rule matching its path.
+	Rules []ClientVersionRule `yaml:"rules"`
+}
+
+// ClientVersionRule blocks clients older than the minimum version of their platform on routes.
+type ClientVersionRule struct {
+	// Name identifies the rule in error responses and logs.
+	Name string `yaml:"name"`
+
+	// Routes are request path prefixes the rule applies to (e.g., "/responses", "/api/v1/deepresearch").
+	Routes []string `yaml:"routes"`
+
+	// MinVersions are the minimum dotted versions (e.g., "2.4.0") by platform ("ios", "android").
+	// Platforms not listed aren't checked.
+	MinVersions map[string]string `yaml:"min_versions"`
+
+	// UpgradeURLs are store links returned to blocked clients by platform (optional).
+	UpgradeURLs map[string]string `yaml:"upgrade_urls,omitempty"`
+}
+
+// Validate performs validation of a ClientVersionsConfig value:
+// - Checks that rules are named, unique, and list routes and minimum versions
+// - Normalizes platform names to lower case
+func (cfg *ClientVersionsConfig) Validate() error {
+	names := make(map[string]struct{}, len(cfg.Rules))
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("client version rule #%d has no name", i+1)
+		}
+		if _, exists := names[rule.Name]; exists {
+			return fmt.Errorf("duplicate client version rule %v", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+
+		if len(rule.Routes) == 0 {
+			return fmt.Errorf("client version rule %v has no routes", rule.Name)
+		}
+		for _, route := range rule.Routes {
+			if !strings.HasPrefix(route, "/") {
+				return fmt.Errorf("client version rule %v: route %q must start with /", rule.Name, route)
+			}
+		}
+		if len(rule.MinVersions) == 0 {
+			return fmt.Errorf("client version rule %v has no min_versions", rule.Name)
+		}
+		rule.MinVersions = lowerKeys(rule.MinVersions)
+		rule.UpgradeURLs = lowerKeys(rule.UpgradeURLs)
+	}
+
+	return nil
+}
+
+// lowerKeys returns values with lower-cased keys.
+func lowerKeys(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	lowered := make(map[string]string, len(values))
+	for key, value := range values {
+		lowered[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return lowered
+}
+
+// unmarshalClientVersionsConfig implements a custom YAML unmarshaler for ClientVersionsConfig.
+// Validates the value after unmarshaling.
+func unmarshalClientVersionsConfig(value *ClientVersionsConfig, data []byte) error {
+	type Aux ClientVersionsConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = ClientVersionsConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[ClientVersionsConfig](unmarshalClientVersionsConfig)
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+)
+
+// CompactionStrategy selects how older conversation turns are compacted.
+type CompactionStrategy string
+
+const (
+	// CompactionStrategyTruncate drops the oldest non-system turns until the request fits.
+	CompactionStrategyTruncate CompactionStrategy = "truncate"
+
+	// CompactionStrategySummarize replaces the dropped turns with a model-written summary.
+	// Falls back to truncation if summarization fails.
+	CompactionStrategySummarize CompactionStrategy = "summarize"
+)
+
+const (
+	DefaultCompactionReserveTokens      = 4096
+	DefaultCompactionKeepRecentMessages = 6
+	DefaultCompactionSummaryMaxTokens   = 1024
+)
+
+// CompactionConfig controls server-side compaction of conversations that exceed
+// the target model's context window (see ModelConfig.ContextWindow).
+type CompactionConfig struct {
+	// Strategy is "truncate" (default) or "summarize".
+	Strategy CompactionStrategy `yaml:"strategy,omitempty"`
+
+	// ReserveTokens is kept free for the completion when the request sets no max_tokens.
+	// Default: 4096.
+	ReserveTokens int `yaml:"reserve_tokens,omitempty"`
+
+	// KeepRecentMessages applies to the summarize strategy: once compaction triggers, every
+	// message older than the latest KeepRecentMessages is summarized. Default: 6.
+	KeepRecentMessages int `yaml:"keep_recent_messages,omitempty"`
+
+	// SummaryModel is the canonical model (routed via model_router) that writes summaries.
+	// Required for the summarize strategy.
+	SummaryModel string `yaml:"summary_model,omitempty"`
+
+	// SummaryMaxTokens bounds the summary length. Default: 1024.
+	SummaryMaxTokens int `yaml:"summary_max_tokens,omitempty"`
+}
+
+// Validate performs validation of a CompactionConfig value:
+// - Checks the strategy and applies defaults
+// - Checks that the summarize strategy names a summary model
+func (cfg *CompactionConfig) Validate() error {
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = CompactionStrategyTruncate
+	case CompactionStrategyTruncate:
+	case CompactionStrategySummarize:
+		if cfg.SummaryModel == "" {
+			return errors.New("compaction strategy summarize requires summary_model")
+		}
+	default:
+		return fmt.Errorf(
+			"bad compaction strategy: must be empty or one of %q, %q",
+			string(CompactionStrategyTruncate),
+			string(CompactionStrategySummarize),
+		)
+	}
+
+	if cfg.ReserveTokens <= 0 {
+		cfg.ReserveTokens = DefaultCompactionReserveTokens
+	}
+	if cfg.KeepRecentMessages <= 0 {
+		cfg.KeepRecentMessages = DefaultCompactionKeepRecentMessages
+	}
+	if cfg.SummaryMaxTokens <= 0 {
+		cfg.SummaryMaxTokens = DefaultCompactionSummaryMaxTokens
+	}
+
+	return nil
+}
+
+// unmarshalCompactionConfig implements a custom YAML unmarshaler for CompactionConfig.
+// Validates the value after unmarshaling.
+func unmarshalCompactionConfig(value *CompactionConfig, data []byte) error {
+	type Aux CompactionConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = CompactionConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[CompactionConfig](unmarshalCompactionConfig)
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ComplianceConfig contains the country-based access policy.
+type ComplianceConfig struct {
+	// CountryHeader is a request header carrying the client's ISO 3166-1 alpha-2 country,
+	// set by a trusted edge (e.g., "CF-IPCountry"). Empty = resolve via GeoIP only.
+	CountryHeader string `yaml:"country_header,omitempty"`
+
+	// BlockedCountries are denied access to all authenticated endpoints (comprehensive sanctions).
+	BlockedCountries []string `yaml:"blocked_countries,omitempty"`
+
+	// Rules restrict specific providers or models in additional countries.
+	Rules []ComplianceRule `yaml:"rules,omitempty"`
+}
+
+// ComplianceRule blocks the listed providers and/or models for requests from the listed countries.
+type ComplianceRule struct {
+	// Name identifies the rule in error responses and the audit log.
+	Name string `yaml:"name"`
+
+	// Countries are ISO 3166-1 alpha-2 codes the rule applies to.
+	Countries []string `yaml:"countries"`
+
+	// Providers are provider names as configured in model_router.providers.
+	Providers []string `yaml:"providers,omitempty"`
+
+	// Models are canonical model names as configured in model_router.models.
+	Models []string `yaml:"models,omitempty"`
+}
+
+// Validate performs validation of a ComplianceConfig value:
+// - Normalizes country codes to upper case and checks they are two letters
+// - Checks that rules are named, unique, and target at least one provider or model
+func (cfg *ComplianceConfig) Validate() error {
+	if err := normalizeCountryCodes(cfg.BlockedCountries); err != nil {
+		return fmt.Errorf("blocked_countries: %w", err)
+	}
+
+	names := make(map[string]struct{}, len(cfg.Rules))
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("compliance rule #%d has no name", i+1)
+		}
+		if _, exists := names[rule.Name]; exists {
+			return fmt.Errorf("duplicate compliance rule %v", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+
+		if len(rule.Countries) == 0 {
+			return fmt.Errorf("compliance rule %v has no countries", rule.Name)
+		}
+		if err := normalizeCountryCodes(rule.Countries); err != nil {
+			return fmt.Errorf("compliance rule %v: %w", rule.Name, err)
+		}
+		if len(rule.Providers) == 0 && len(rule.Models) == 0 {
+			return fmt.Errorf("compliance rule %v must list providers or models", rule.Name)
+		}
+	}
+
+	return nil
+}
+
+// normalizeCountryCodes upper-cases codes in place and rejects anything that isn't two letters.
+func normalizeCountryCodes(codes []string) error {
+	for i, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+			return fmt.Errorf("invalid country code %q", codes[i])
+		}
+		codes[i] = code
+	}
+	return nil
+}
+
+// unmarshalComplianceConfig implements a custom YAML unmarshaler for ComplianceConfig.
+// Validates the value after unmarshaling.
+func unmarshalComplianceConfig(value *ComplianceConfig, data []byte) error {
+	type Aux ComplianceConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = ComplianceConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[ComplianceConfig](unmarshalComplianceConfig)
+}
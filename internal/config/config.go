@@ -2,6 +2,8 @@ package config
 
 import (
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/url"
@@ -11,13 +13,22 @@ import (
 	"time"
 
 	"github.com/goccy/go-yaml"
-	"github.com/joho/godotenv"
 )
 
 // TitleGenerationConfig contains system prompts for title generation
 type TitleGenerationConfig struct {
 	InitialPrompt      string `yaml:"initial_prompt"`
 	RegenerationPrompt string `yaml:"regeneration_prompt"`
+
+	// Localized are prompts by detected chat language (ISO 639-1 code). Prompts missing for a
+	// language fall back to the default ones.
+	Localized map[string]TitleGenerationPrompts `yaml:"localized,omitempty"`
+}
+
+// TitleGenerationPrompts contains the title generation prompts of a language
+type TitleGenerationPrompts struct {
+	InitialPrompt      string `yaml:"initial_prompt,omitempty"`
+	RegenerationPrompt string `yaml:"regeneration_prompt,omitempty"`
 }
 
 type Config struct {
@@ -32,9 +43,12 @@ type Config struct {
 	NearAPIKey              string
 	EternisInferenceAPIKey  string
 	SerpAPIKey              string
+	SerpAPIGoogleKey        string // SerpAPI key for the google engine (default: SERPAPI_API_KEY)
+	BraveSearchAPIKey       string
 	ExaAPIKey               string
 	ValidatorType           string // "jwk" or "firebase"
 	JWTJWKSURL              string
+	AuthIssuers             string // ISSUER=JWKS_URL|firebase entries; replaces ValidatorType when set
 	FirebaseCredJSON        string
 
 	// Title Generation
@@ -43,11 +57,39 @@ type Config struct {
 	// Model Router
 	ModelRouterConfig *ModelRouterConfig `yaml:"model_router"`
 
+	// Country-based compliance policy (optional)
+	Compliance  *ComplianceConfig `yaml:"compliance"`
+	GeoIPDBPath string            // CSV of "start_ip,end_ip,country" ranges (e.g., DB-IP Lite). Empty = header-only country resolution
+
+	// Minimum client versions by route and platform (optional; nil = no version gating)
+	ClientVersions *ClientVersionsConfig `yaml:"client_versions"`
+
+	// Context compaction for conversations exceeding the model's context window (optional; nil = disabled)
+	Compaction *CompactionConfig `yaml:"compaction"`
+
+	// Server-side best-of chat completions (optional; nil = best_of requests are rejected)
+	BestOf *BestOfConfig `yaml:"best_of"`
+
+	// Upstream response headers forwarded to clients (optional; nil = none on streaming responses)
+	UpstreamHeaders *UpstreamHeadersConfig `yaml:"upstream_headers"`
+
+	// Content filters of streamed completions per account content policy (optional; nil = none)
+	StreamFilters *StreamFiltersConfig `yaml:"stream_filters"`
+
+	// Pre-flight moderation of chat completion inputs by tier and model (optional; nil = none)
+	Moderation *ModerationConfig `yaml:"moderation"`
+
+	// Temporary quota variations for user cohorts (optional)
+	QuotaExperiments []QuotaExperimentConfig `yaml:"quota_experiments"`
+
 	// Model Router Fallback Service
 	FallbackPrometheusURL   string
 	FallbackPrometheusToken string
 	FallbackMinInterval     time.Duration
 
+	// Provider enclave attestation (providers with an attestation section in config.yaml)
+	AttestationCheckInterval time.Duration // Re-verification interval (default: 10m)
+
 	// MCP
 	PerplexityAPIKey  string
 	ReplicateAPIToken string
@@ -58,25 +100,59 @@ type Config struct {
 	RateLimitFailClosed     bool    // If true, fail closed when tier config unavailable (503 error).
 	RateLimitSoftMultiplier float64 // Multiplier for soft limits (DailyPlanTokens). Default 1.0. Set to 0.1 to reduce limits by 10x for testing.
 
+	// Audio Usage (/audio/* and voice requests)
+	AudioPlanTokensPerMinute int // Plan tokens charged per minute of audio transcribed or synthesized (default: 1000)
+
 	// Deep Research Rate Limiting
 	DeepResearchRateLimitEnabled bool // If false, skip freemium quota checks
 
+	// Deep Research Artifacts (charts, CSVs linked from final reports)
+	DeepResearchArtifactsBucket   string        // GCS bucket for encrypted artifacts. Empty = artifacts are not stored
+	DeepResearchArtifactMaxBytes  int64         // Maximum size of a downloaded artifact (default: 10MB)
+	DeepResearchArtifactURLExpiry time.Duration // Lifetime of artifact download URLs (default: 15m)
+
+	// Deep Research Concurrency
+	DeepResearchProMaxActiveSessions int // Concurrent sessions per Pro user (0 = tier default)
+	DeepResearchBackendCapacity      int // Concurrent sessions of the research backend; excess starts are queued (0 = unlimited)
+
+	// Deep Research Auto-Clarification (answer clarification questions from the chat context)
+	DeepResearchAutoClarifyEnabled bool
+	DeepResearchAutoClarifyModel   string // Model drafting the answers (default: moonshot/kimi-k2)
+
 	// App Store (IAP)
 	AppStoreAPIKeyP8 string
 	AppStoreAPIKeyID string
 	AppStoreBundleID string
 	AppStoreIssuerID string
 
+	// Google Play Billing (IAP); purchase verification is disabled without a package name
+	GooglePlayPackageName string // Android package name
+	GooglePlayCredJSON    string // Service account JSON with Play Console financial data access (default: FIREBASE_CRED_JSON)
+
 	// Stripe Configuration
 	StripeSecretKey     string
 	StripeWebhookSecret string
 	StripeWeeklyPriceID string // Weekly subscription price ID (eligible for 3-day free trial)
+	StripeProductTiers  string // Extra subscription products and the tier they grant ("prod_xxx=plus,prod_yyy=pro")
 
 	// Telegram
 	EnableTelegramServer bool
 	TelegramToken        string
 	NatsURL              string
 
+	// Shared Cache
+	RedisURL              string // Empty = in-memory cache, local to each instance
+	CacheMemoryMaxEntries int
+
+	// Stream sessions
+	StreamSessionStore string // "redis" = shared through REDIS_URL for reconnects to any instance; empty = local
+
+	// Upstream allowlist (internal/upstreams)
+	UpstreamRefreshIntervalSeconds int
+
+	// Routing entries administered via the admin API (internal/routingconfig)
+	RoutingEntriesRefreshIntervalSeconds int
+
 	// Database Connection Pool
 	DBMaxOpenConns    int
 	DBMaxIdleConns    int
@@ -98,10 +174,22 @@ type Config struct {
 	ServerShutdownTimeoutSeconds int
 	StatusBindAddr               string
 	StatusBindPort               string
+	StatusPageRateLimitPerMinute int // Per-IP limit for the public GET /status.json feed
+
+	// API versioning (Deprecation/Sunset headers of /api/v1 responses, YYYY-MM-DD)
+	APIV1DeprecatedAt string // Date announced in the Deprecation header (empty = deprecated without a date)
+	APIV1SunsetDate   string // Date announced in the Sunset header (empty = no Sunset header)
+
+	// Revocation
+	RevocationCheckIntervalSeconds int // How often users with work in flight are checked for token revocation (0 = never)
 
 	// CORS
 	CORSAllowedOrigins string
 
+	// Websockets
+	WebsocketAllowedOrigins     string // Comma-separated origins allowed to open websockets besides the server's own host ("*" = any)
+	WebsocketRequireSubprotocol bool   // Reject websocket upgrades that don't offer a subprotocol
+
 	// Logging
 	LogLevel  string
 	LogFormat string
@@ -111,11 +199,73 @@ type Config struct {
 	TemporalEndpoint  string
 	TemporalNamespace string
 	// Message Storage
-	MessageStorageEnabled           bool // Enable/disable encrypted message storage to Firestore
-	MessageStorageRequireEncryption bool // If true, refuse to store messages when encryption fails (strict E2EE mode). If false, fallback to plaintext storage (default: graceful degradation)
-	MessageStorageWorkerPoolSize    int  // Number of worker goroutines processing message queue (higher = more concurrent Firestore writes)
-	MessageStorageBufferSize        int  // Size of message queue channel (higher = handles bigger traffic spikes without dropping messages)
-	MessageStorageTimeoutSeconds    int  // Firestore operation timeout in seconds (prevents workers from hanging on slow/failed operations)
+	MessageStorageEnabled           bool   // Enable/disable encrypted message storage to Firestore
+	MessageStorageBackend           string // Where messages are stored: "firestore" (default) or "database" (DATABASE_URL, for self-hosting without Firebase)
+	MessageStorageRequireEncryption bool   // If true, refuse to store messages when encryption fails (strict E2EE mode). If false, fallback to plaintext storage (default: graceful degradation)
+	MessageStorageWorkerPoolSize    int    // Number of worker goroutines processing message queue (higher = more concurrent Firestore writes)
+	MessageStorageBufferSize        int    // Size of message queue channel (higher = handles bigger traffic spikes without dropping messages)
+	MessageStorageTimeoutSeconds    int    // Firestore operation timeout in seconds (prevents workers from hanging on slow/failed operations)
+
+	// Message Index (metadata-only copy of stored messages in Postgres)
+	MessageIndexEnabled    bool // Sync message metadata (no content) to the message_index table
+	MessageIndexBufferSize int  // Size of the index sync queue (entries are dropped, not blocked on, when full)
+
+	// Stream Recording (debug capture of upstream streams for internal test accounts)
+	StreamRecordingUserIDs  string // Comma-separated user IDs whose streams are recorded. Empty = disabled
+	StreamRecordingLocation string // GCS bucket for recordings, or "file://<dir>" for local development
+
+	// Debug Traces (X-Debug-Trace elevates logging of a single request for support tickets)
+	DebugTraceUserIDs string // Comma-separated internal user IDs allowed to send X-Debug-Trace. Empty = disabled
+
+	// Tracing (OpenTelemetry spans, exported over OTLP/HTTP; trace context is propagated either way)
+	TracingEnabled    bool    // Export spans
+	TracingEndpoint   string  // OTLP/HTTP collector URL (e.g. http://otel-collector:4318). Empty = the OTEL_EXPORTER_OTLP_* defaults
+	TracingSampleRate float64 // Fraction of new traces that are sampled; traces continued from a caller follow its decision (default: 0.1)
+
+	// Stream Anomalies (truncated, empty, abnormally finished or garbled streams are always flagged)
+	StreamAnomalyRetryEnabled bool // Retry streams that end without output (empty completion or early error chunk)
+	StreamRetryMaxAttempts    int  // Retries per request; the first goes to an alternate provider when the model has one (default: 1)
+	StreamRetryBudgetSeconds  int  // No retry starts once this long has passed since the request arrived (default: 30)
+
+	// Message Retry (failed generations are retried by replaying the stored chat completion request)
+	MessageRetryTTLSeconds int // How long requests are kept for retry, in the shared cache (default: 600, 0 = disabled)
+
+	// Rate Limit Queue (chat completions wait briefly for a rate-limited provider instead of failing)
+	RateLimitQueueEnabled        bool // Queue requests while the provider's reported rate limit is exhausted
+	RateLimitQueueMaxWaitSeconds int  // Longest a request waits; requests that can't be admitted in time fail immediately (default: 10)
+	RateLimitQueueMaxSize        int  // Requests waiting per provider before new ones are rejected (default: 100)
+
+	// Circuit Breaker (failing providers get no requests until they recover)
+	CircuitBreakerEnabled       bool // Stop routing to providers failing most of their requests
+	CircuitBreakerFailureRate   int  // Percentage of failed requests in the window that opens the breaker (default: 50)
+	CircuitBreakerMinRequests   int  // Requests in the window before the failure rate is acted on (default: 20)
+	CircuitBreakerWindowSeconds int  // Window the failure rate is computed over (default: 60)
+	CircuitBreakerOpenSeconds   int  // How long an open breaker rejects requests before a probe is let through (default: 30)
+	CircuitBreakerSlowSeconds   int  // Responses slower than this count as failures (default: 60, 0 = latency is ignored)
+
+	// Request Time Budget (streaming requests; tiers and models may set a stricter budget)
+	RequestTimeoutBudgetSeconds int // Overall time for routing, retries, tool calls and streaming before the stream is stopped (default: 480, 0 = no budget)
+
+	// Developer Sandbox (requests from sandbox clients go to a dev provider and consume no quota)
+	SandboxProviderURL    string // OpenAI-compatible base URL of the local/dev provider. Empty = sandbox requests are rejected
+	SandboxProviderAPIKey string // Bearer token for the dev provider, if it needs one
+	SandboxProviderModel  string // Model requested from the dev provider. Empty = the requested model is passed through
+	SandboxKeys           string // Comma-separated keys accepted in X-Sandbox-Key; users with the "sandbox" token claim need none
+
+	// Voice Conversations (POST /api/v1/voice/turn, enabled when OPENAI_API_KEY is set)
+	VoiceTranscriptionModel string // Speech-to-text model on the OpenAI API (default: gpt-4o-mini-transcribe)
+	VoiceSpeechModel        string // Text-to-speech model on the OpenAI API (default: gpt-4o-mini-tts)
+	VoiceSpeechVoice        string // Default TTS voice when the client doesn't pick one (default: alloy)
+
+	// Realtime API sessions (GET /realtime websocket relay to OpenAI)
+	RealtimeMaxSessionDuration time.Duration // Sessions are closed after this long (default: 30m)
+
+	// Batch API (/files and /batches pass-through to OpenAI, needs OPENAI_API_KEY)
+	BatchAPIEnabled   bool          // Serve the Batch API routes (default: false)
+	BatchSyncInterval time.Duration // Interval of batch status polls and usage recording (default: 5m)
+
+	// Reasoning Visibility (thinking output in Chat Completions streams)
+	ReasoningVisibilityDefault string // Used when X-Reasoning-Visibility header is absent: "show", "separate", "strip" (default: show)
 
 	// Background Polling (for GPT-5 Pro and other long-running models)
 	BackgroundPollingEnabled     bool // Enable background polling mode for GPT-5 Pro (recommended to avoid timeouts)
@@ -124,8 +274,35 @@ type Config struct {
 	BackgroundPollingTimeout     int  // Minutes before giving up on polling (default: 30)
 	BackgroundMaxConcurrentPolls int  // Maximum number of concurrent polling workers (default: 100)
 
+	// OpenAI Webhooks (wake background polling workers on response completion)
+	OpenAIWebhookSecret string // Signing secret for /webhooks/openai ("whsec_..."). Empty = endpoint disabled, polling only
+
 	// Push Notifications
-	PushNotificationsEnabled bool // Enable/disable FCM push notifications for task completions (default: true)
+	PushNotificationsEnabled        bool // Enable/disable FCM push notifications for task completions (default: true)
+	PushNotificationAckGraceSeconds int  // Wait before a message completion push; skipped if a client acknowledged the message meanwhile (default: 10)
+
+	// Weekly Digest ("your week with Enchanted")
+	WeeklyDigestEnabled bool   // Enable weekly digest worker and /api/v1/digest endpoints (default: false)
+	DigestSMTPHost      string // SMTP relay for digest emails. Empty = email delivery disabled (in-app and push only)
+	DigestSMTPPort      int    // SMTP relay port (default: 587)
+	DigestSMTPUsername  string // SMTP username (optional)
+	DigestSMTPPassword  string // SMTP password
+	DigestEmailFrom     string // Sender address of digest emails (required with DIGEST_SMTP_HOST)
+
+	// Usage Rollups (admin KPIs)
+	UsageRollupsEnabled bool // Enable the nightly usage rollup worker behind /admin/v1/kpis (default: true)
+
+	// Model Quality
+	QualityRoutingEnabled bool    // Feed model quality scores into endpoint selection (default: false)
+	QualityRoutingMargin  float64 // Skip providers scoring more than this below a model's best (default: 10)
+
+	// Device Attestation (App Attest on iOS, Play Integrity on Android)
+	DeviceAttestationRequired bool   // Require attestation for invite redemption and IAP attach (default: false)
+	AppAttestTeamID           string // Apple developer team ID (App Attest app ID is "<team>.<bundle>")
+	AppAttestBundleID         string // iOS bundle ID (default: APPSTORE_BUNDLE_ID)
+	AppAttestAllowDevelopment bool   // Accept keys from the development App Attest environment (debug builds only)
+	PlayIntegrityPackageName  string // Android package name
+	PlayIntegrityCredJSON     string // Service account JSON with Play Integrity API access (default: FIREBASE_CRED_JSON)
 
 	// ZCash Backend
 	ZCashBackendURL           string // URL of zcash-payment-backend (default: http://127.0.0.1:20002)
@@ -156,6 +333,25 @@ type Config struct {
 
 	// Internal API Key (for /internal/ endpoints)
 	InternalAPIKey string
+
+	// Admin API Key (for /admin/ endpoints used by cmd/adminctl). Empty = admin API disabled
+	AdminAPIKey string
+
+	// Synthetic monitoring (canary requests through the full pipeline as a service account)
+	SyntheticProbesEnabled        bool          // Run the synthetic prober (default: false)
+	SyntheticProbeInterval        time.Duration // Interval between probe runs (default: 5m)
+	SyntheticProbeToken           string        // Bearer token of the service account (required when enabled)
+	SyntheticProbeUserID          string        // User ID of the service account (default: synthetic-prober)
+	SyntheticProbeChatModel       string        // Model of the canary chat (default: Qwen/Qwen3-30B-A3B-Instruct-2507)
+	SyntheticProbeEmbeddingModel  string        // Model of the canary embedding (empty = check skipped)
+	SyntheticProbeSlackWebhookURL string        // Alerts on probe state changes (empty = disabled)
+
+	// Secrets manager for provider API keys (see secrets.go)
+	SecretsProvider        string        // gcp or aws (empty = keys from the environment)
+	SecretsRefreshInterval time.Duration // Interval of secret fetches picking up rotated keys (default: 1h, 0 = disabled)
+
+	// ConfigFilePath is the YAML config file the model router was loaded from
+	ConfigFilePath string
 }
 
 var (
@@ -166,11 +362,139 @@ var (
 
 func LoadConfig() {
 	// Load .env file if it exists
-	if err := godotenv.Load(".env"); err != nil {
+	if err := loadDotenv(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
+	loading = loadLayers()
+
+	// Provider API keys from the secrets manager override the environment
+	if err := loadSecrets(); err != nil {
+		log.Fatalf("Failed to load secrets: %v", err)
+	}
+
+	AppConfig = newConfig()
+	active.Store(loading)
+
+	// Load structured configuration (model router, title generation, ...) from the config
+	// file. Its settings and profiles sections were applied above, below the environment
+	// (see layers.go).
+	configFilePath := AppConfig.ConfigFilePath
+	log.Printf("Loading config file: %v", configFilePath)
+
+	configFile, err := os.Open(configFilePath)
+	defer func() {
+		if configFile != nil {
+			configFile.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Fatalf("Failed to open config file: %v", err)
+	}
+
+	if err := LoadConfigFile(configFile, AppConfig); err != nil {
+		log.Fatalf("Failed to load config file: %v", err)
+	}
+
+	// Validate required configs
+	if AppConfig.ModelRouterConfig == nil {
+		log.Fatal("Model Router configuration is empty")
+	}
+
+	if AppConfig.TitleGeneration == nil {
+		log.Fatal("Title Generation configuration is empty")
+	}
+
+	if AppConfig.MessageStorageBackend != "firestore" && AppConfig.MessageStorageBackend != "database" {
+		log.Fatalf("Invalid MESSAGE_STORAGE_BACKEND %q: must be firestore or database", AppConfig.MessageStorageBackend)
+	}
+
+	if AppConfig.FirebaseProjectID == "" {
+		log.Println("Warning: Firebase project ID is missing. Please set FIREBASE_PROJECT_ID environment variable.")
+	}
+
+	if AppConfig.PerplexityAPIKey == "" {
+		log.Println("Warning: Perplexity API key is missing. Please set PERPLEXITY_API_KEY environment variable.")
+	}
+
+	if AppConfig.ReplicateAPIToken == "" {
+		log.Println("Warning: Replicate API token is missing. Please set REPLICATE_API_TOKEN environment variable.")
+	}
+
+	if AppConfig.SerpAPIKey == "" {
+		log.Println("Warning: SerpAPI key is missing. Please set SERPAPI_API_KEY environment variable.")
+	}
+
+	if AppConfig.ExaAPIKey == "" {
+		log.Println("Warning: Exa AI API key is missing. Please set EXA_API_KEY environment variable.")
+	}
+
+	if AppConfig.TelegramToken != "" {
+		log.Println("Telegram service enabled with token")
+	}
+
+	if AppConfig.ZCashBackendAPIKey == "" {
+		log.Println("Warning: ZCash Backend API key is missing. Please set ZCASH_BACKEND_API_KEY environment variable.")
+	}
+
+	if AppConfig.LinearAPIKey == "" {
+		log.Println("Warning: Linear API key is missing. Please set LINEAR_API_KEY environment variable.")
+	}
+
+	if AppConfig.InternalAPIKey == "" {
+		log.Println("Warning: Internal API key is missing. /internal/ endpoints will reject all requests. Please set INTERNAL_API_KEY environment variable.")
+	}
+
+	if AppConfig.FaiEnabled {
+		if AppConfig.FaiWsRpcURL == "" || AppConfig.FaiPaymentContract == "" {
+			log.Println("Warning: FAI_ENABLED is true but FAI_WS_RPC_URL or FAI_PAYMENT_CONTRACT is missing.")
+		} else {
+			safeRpcURL := AppConfig.FaiWsRpcURL
+			if u, err := url.Parse(AppConfig.FaiWsRpcURL); err == nil {
+				safeRpcURL = u.Scheme + "://" + u.Host + u.Path
+			}
+			log.Printf("FAI payment configured: contract=%s, ws_rpc_url=%s", AppConfig.FaiPaymentContract, safeRpcURL)
+		}
+	} else {
+		log.Println("FAI payment disabled (set FAI_ENABLED=true to enable)")
+	}
+
+	if AppConfig.AppStoreAPIKeyP8 == "" || AppConfig.AppStoreAPIKeyID == "" || AppConfig.AppStoreBundleID == "" || AppConfig.AppStoreIssuerID == "" {
+		log.Println("Warning: App Store IAP credentials are missing. Please set APPSTORE_API_KEY_P8, APPSTORE_API_KEY_ID, APPSTORE_BUNDLE_ID, and APPSTORE_ISSUER_ID environment variables.")
+	} else {
+		log.Println(
+			"App Store IAP configured:",
+			"key_id=", AppConfig.AppStoreAPIKeyID,
+			"bundle_id=", AppConfig.AppStoreBundleID,
+			"issuer_id=", AppConfig.AppStoreIssuerID,
+		)
+
+		if AppConfig.AppStoreAPIKeyP8 != "" {
+			sum := sha256.Sum256([]byte(AppConfig.AppStoreAPIKeyP8))
+			log.Printf("App Store IAP private key loaded (sha256=%x, bytes=%d)", sum, len(AppConfig.AppStoreAPIKeyP8))
+		}
+	}
+
+	// Stripe configuration validation
+	if AppConfig.StripeSecretKey == "" || AppConfig.StripeWebhookSecret == "" {
+		log.Println("Warning: Stripe credentials are missing. Please set STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET environment variables.")
+	} else {
+		// Show first 12 chars of key for debugging (e.g., "sk_test_xxxx" or "sk_live_xxxx")
+		keyPrefix := AppConfig.StripeSecretKey
+		if len(keyPrefix) > 12 {
+			keyPrefix = keyPrefix[:12] + "..."
+		}
+		log.Printf("Stripe configured: key=%s (length=%d), webhook_secret length=%d",
+			keyPrefix, len(AppConfig.StripeSecretKey), len(AppConfig.StripeWebhookSecret))
+	}
+
+	log.Println("Firebase project ID: ", AppConfig.FirebaseProjectID)
+}
 
-	AppConfig = &Config{
+// newConfig reads the settings of a Config from the layers being loaded (see layers.go). The
+// structured configuration of the config file is loaded separately.
+func newConfig() *Config {
+	return &Config{
 		Port:    getEnvOrDefault("PORT", "8080"),
 		GinMode: getEnvOrDefault("GIN_MODE", "release"),
 
@@ -188,7 +512,8 @@ func LoadConfig() {
 		OpenRouterDesktopAPIKey: getEnvOrDefault("OPENROUTER_DESKTOP_API_KEY", ""),
 
 		// Tinfoil
-		TinfoilAPIKey: getEnvOrDefault("TINFOIL_API_KEY", ""),
+		TinfoilAPIKey:            getEnvOrDefault("TINFOIL_API_KEY", ""),
+		AttestationCheckInterval: getEnvAsDuration("ATTESTATION_CHECK_INTERVAL", 10*time.Minute),
 
 		// Self-hosted inference APIs
 		EternisInferenceAPIKey: getEnvOrDefault("ETERNIS_INFERENCE_API_KEY", ""),
@@ -197,7 +522,11 @@ func LoadConfig() {
 		NearAPIKey: getEnvOrDefault("NEAR_API_KEY", ""),
 
 		// SerpAPI
-		SerpAPIKey: getEnvOrDefault("SERPAPI_API_KEY", ""),
+		SerpAPIKey:       getEnvOrDefault("SERPAPI_API_KEY", ""),
+		SerpAPIGoogleKey: getEnvOrDefault("SERPAPI_GOOGLE_API_KEY", getEnvOrDefault("SERPAPI_API_KEY", "")),
+
+		// Brave Search
+		BraveSearchAPIKey: getEnvOrDefault("BRAVE_SEARCH_API_KEY", ""),
 
 		// Exa AI
 		ExaAPIKey: getEnvOrDefault("EXA_API_KEY", ""),
@@ -205,6 +534,7 @@ func LoadConfig() {
 		// Validator
 		ValidatorType:    getEnvOrDefault("VALIDATOR_TYPE", "firebase"),
 		JWTJWKSURL:       getEnvOrDefault("JWT_JWKS_URL", ""),
+		AuthIssuers:      getEnvOrDefault("AUTH_ISSUERS", ""),
 		FirebaseCredJSON: getEnvOrDefault("FIREBASE_CRED_JSON", ""),
 
 		// Model Router Fallback Service
@@ -222,25 +552,59 @@ func LoadConfig() {
 		RateLimitFailClosed:     getEnvOrDefault("RATE_LIMIT_FAIL_CLOSED", "false") == "true",
 		RateLimitSoftMultiplier: getEnvFloat("RATE_LIMIT_SOFT_MULTIPLIER", 1.0),
 
+		// Audio Usage
+		AudioPlanTokensPerMinute: getEnvAsInt("AUDIO_PLAN_TOKENS_PER_MINUTE", 1000),
+
 		// Deep Research Rate Limiting
 		DeepResearchRateLimitEnabled: getEnvOrDefault("DEEP_RESEARCH_RATE_LIMIT_ENABLED", "true") == "true",
 
+		// Deep Research Artifacts
+		DeepResearchArtifactsBucket:   getEnvOrDefault("DEEP_RESEARCH_ARTIFACTS_BUCKET", ""),
+		DeepResearchArtifactMaxBytes:  getEnvAsInt64("DEEP_RESEARCH_ARTIFACT_MAX_BYTES", 10<<20),
+		DeepResearchArtifactURLExpiry: getEnvAsDuration("DEEP_RESEARCH_ARTIFACT_URL_EXPIRY", 15*time.Minute),
+
+		// Deep Research Concurrency
+		DeepResearchProMaxActiveSessions: getEnvAsInt("DEEP_RESEARCH_PRO_MAX_ACTIVE_SESSIONS", 0),
+		DeepResearchBackendCapacity:      getEnvAsInt("DEEP_RESEARCH_BACKEND_CAPACITY", 0),
+
+		// Deep Research Auto-Clarification
+		DeepResearchAutoClarifyEnabled: getEnvOrDefault("DEEP_RESEARCH_AUTO_CLARIFY_ENABLED", "false") == "true",
+		DeepResearchAutoClarifyModel:   getEnvOrDefault("DEEP_RESEARCH_AUTO_CLARIFY_MODEL", "moonshot/kimi-k2"),
+
 		// App Store (IAP)
 		AppStoreAPIKeyP8: getEnvOrDefault("APPSTORE_API_KEY_P8", ""),
 		AppStoreAPIKeyID: getEnvOrDefault("APPSTORE_API_KEY_ID", ""),
 		AppStoreBundleID: getEnvOrDefault("APPSTORE_BUNDLE_ID", ""),
 		AppStoreIssuerID: getEnvOrDefault("APPSTORE_ISSUER_ID", ""),
 
+		// Google Play Billing (IAP)
+		GooglePlayPackageName: getEnvOrDefault("GOOGLE_PLAY_PACKAGE_NAME", ""),
+		GooglePlayCredJSON:    getEnvOrDefault("GOOGLE_PLAY_CRED_JSON", getEnvOrDefault("FIREBASE_CRED_JSON", "")),
+
 		// Stripe (trim whitespace to avoid common config errors)
 		StripeSecretKey:     strings.TrimSpace(getEnvOrDefault("STRIPE_SECRET_KEY", "")),
 		StripeWebhookSecret: strings.TrimSpace(getEnvOrDefault("STRIPE_WEBHOOK_SECRET", "")),
 		StripeWeeklyPriceID: strings.TrimSpace(getEnvOrDefault("STRIPE_WEEKLY_PRICE_ID", "")),
+		StripeProductTiers:  strings.TrimSpace(getEnvOrDefault("STRIPE_PRODUCT_TIERS", "")),
 
 		// Telegram
 		EnableTelegramServer: getEnvOrDefault("ENABLE_TELEGRAM_SERVER", "true") == "true",
 		TelegramToken:        getEnvOrDefault("TELEGRAM_TOKEN", ""),
 		NatsURL:              getEnvOrDefault("NATS_URL", ""),
 
+		// Shared Cache
+		RedisURL:              getEnvOrDefault("REDIS_URL", ""),
+		CacheMemoryMaxEntries: getEnvAsInt("CACHE_MEMORY_MAX_ENTRIES", 100000),
+
+		// Stream sessions
+		StreamSessionStore: getEnvOrDefault("STREAM_SESSION_STORE", ""),
+
+		// Upstream allowlist
+		UpstreamRefreshIntervalSeconds: getEnvAsInt("UPSTREAM_REFRESH_INTERVAL_SECONDS", 60),
+
+		// Routing entries
+		RoutingEntriesRefreshIntervalSeconds: getEnvAsInt("ROUTING_ENTRIES_REFRESH_INTERVAL_SECONDS", 60),
+
 		// Database Connection Pool
 		DBMaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 15),
 		DBMaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
@@ -262,10 +626,22 @@ func LoadConfig() {
 		ServerShutdownTimeoutSeconds: getEnvAsInt("SERVER_SHUTDOWN_TIMEOUT_SECONDS", 30),
 		StatusBindAddr:               getEnvOrDefault("STATUS_BIND_ADDR", "127.0.0.1"),
 		StatusBindPort:               getEnvOrDefault("STATUS_BIND_PORT", "9090"),
+		StatusPageRateLimitPerMinute: getEnvAsInt("STATUS_PAGE_RATE_LIMIT_PER_MINUTE", 60),
+
+		// API versioning
+		APIV1DeprecatedAt: getEnvOrDefault("API_V1_DEPRECATED_AT", ""),
+		APIV1SunsetDate:   getEnvOrDefault("API_V1_SUNSET_DATE", ""),
+
+		// Revocation
+		RevocationCheckIntervalSeconds: getEnvAsInt("REVOCATION_CHECK_INTERVAL_SECONDS", 60),
 
 		// CORS
 		CORSAllowedOrigins: getEnvOrDefault("CORS_ALLOWED_ORIGINS", "http://localhost:3000"),
 
+		// Websockets
+		WebsocketAllowedOrigins:     getEnvOrDefault("WEBSOCKET_ALLOWED_ORIGINS", ""),
+		WebsocketRequireSubprotocol: getEnvOrDefault("WEBSOCKET_REQUIRE_SUBPROTOCOL", "false") == "true",
+
 		// Logging
 		LogLevel:  getEnvOrDefault("LOG_LEVEL", "debug"),
 		LogFormat: getEnvOrDefault("LOG_FORMAT", "text"),
@@ -276,11 +652,73 @@ func LoadConfig() {
 		TemporalNamespace: getEnvOrDefault("TEMPORAL_NAMESPACE", ""),
 		// Message Storage
 		MessageStorageEnabled:           getEnvOrDefault("MESSAGE_STORAGE_ENABLED", "true") == "true",
+		MessageStorageBackend:           getEnvOrDefault("MESSAGE_STORAGE_BACKEND", "firestore"),
 		MessageStorageRequireEncryption: getEnvOrDefault("MESSAGE_STORAGE_REQUIRE_ENCRYPTION", "false") == "true",
 		MessageStorageWorkerPoolSize:    getEnvAsInt("MESSAGE_STORAGE_WORKER_POOL_SIZE", 5),
 		MessageStorageBufferSize:        getEnvAsInt("MESSAGE_STORAGE_BUFFER_SIZE", 500),
 		MessageStorageTimeoutSeconds:    getEnvAsInt("MESSAGE_STORAGE_TIMEOUT_SECONDS", 30),
 
+		// Message Index
+		MessageIndexEnabled:    getEnvOrDefault("MESSAGE_INDEX_ENABLED", "false") == "true",
+		MessageIndexBufferSize: getEnvAsInt("MESSAGE_INDEX_BUFFER_SIZE", 1000),
+
+		// Stream Recording
+		StreamRecordingUserIDs:  getEnvOrDefault("STREAM_RECORDING_USER_IDS", ""),
+		StreamRecordingLocation: getEnvOrDefault("STREAM_RECORDING_BUCKET", ""),
+
+		// Debug Traces
+		DebugTraceUserIDs: getEnvOrDefault("DEBUG_TRACE_USER_IDS", ""),
+
+		// Tracing
+		TracingEnabled:    getEnvOrDefault("TRACING_ENABLED", "false") == "true",
+		TracingEndpoint:   getEnvOrDefault("TRACING_ENDPOINT", ""),
+		TracingSampleRate: getEnvFloat("TRACING_SAMPLE_RATE", 0.1),
+
+		// Stream Anomalies
+		StreamAnomalyRetryEnabled: getEnvOrDefault("STREAM_ANOMALY_RETRY_ENABLED", "true") == "true",
+		StreamRetryMaxAttempts:    getEnvAsInt("STREAM_RETRY_MAX_ATTEMPTS", 1),
+		StreamRetryBudgetSeconds:  getEnvAsInt("STREAM_RETRY_BUDGET_SECONDS", 30),
+
+		// Message Retry
+		MessageRetryTTLSeconds: getEnvAsInt("MESSAGE_RETRY_TTL_SECONDS", 600),
+
+		// Rate Limit Queue
+		RateLimitQueueEnabled:        getEnvOrDefault("RATE_LIMIT_QUEUE_ENABLED", "false") == "true",
+		RateLimitQueueMaxWaitSeconds: getEnvAsInt("RATE_LIMIT_QUEUE_MAX_WAIT_SECONDS", 10),
+		RateLimitQueueMaxSize:        getEnvAsInt("RATE_LIMIT_QUEUE_MAX_SIZE", 100),
+
+		// Circuit Breaker
+		CircuitBreakerEnabled:       getEnvOrDefault("CIRCUIT_BREAKER_ENABLED", "false") == "true",
+		CircuitBreakerFailureRate:   getEnvAsInt("CIRCUIT_BREAKER_FAILURE_RATE", 50),
+		CircuitBreakerMinRequests:   getEnvAsInt("CIRCUIT_BREAKER_MIN_REQUESTS", 20),
+		CircuitBreakerWindowSeconds: getEnvAsInt("CIRCUIT_BREAKER_WINDOW_SECONDS", 60),
+		CircuitBreakerOpenSeconds:   getEnvAsInt("CIRCUIT_BREAKER_OPEN_SECONDS", 30),
+		CircuitBreakerSlowSeconds:   getEnvAsInt("CIRCUIT_BREAKER_SLOW_SECONDS", 60),
+
+		// Request Time Budget
+		RequestTimeoutBudgetSeconds: getEnvAsInt("REQUEST_TIMEOUT_BUDGET_SECONDS", 480),
+
+		// Developer Sandbox
+		SandboxProviderURL:    getEnvOrDefault("SANDBOX_PROVIDER_URL", ""),
+		SandboxProviderAPIKey: getEnvOrDefault("SANDBOX_PROVIDER_API_KEY", ""),
+		SandboxProviderModel:  getEnvOrDefault("SANDBOX_PROVIDER_MODEL", ""),
+		SandboxKeys:           getEnvOrDefault("SANDBOX_KEYS", ""),
+
+		// Voice Conversations
+		VoiceTranscriptionModel: getEnvOrDefault("VOICE_TRANSCRIPTION_MODEL", "gpt-4o-mini-transcribe"),
+		VoiceSpeechModel:        getEnvOrDefault("VOICE_SPEECH_MODEL", "gpt-4o-mini-tts"),
+		VoiceSpeechVoice:        getEnvOrDefault("VOICE_SPEECH_VOICE", "alloy"),
+
+		// Realtime API sessions
+		RealtimeMaxSessionDuration: getEnvAsDuration("REALTIME_MAX_SESSION_DURATION", 30*time.Minute),
+
+		// Batch API
+		BatchAPIEnabled:   getEnvOrDefault("BATCH_API_ENABLED", "false") == "true",
+		BatchSyncInterval: getEnvAsDuration("BATCH_SYNC_INTERVAL", 5*time.Minute),
+
+		// Reasoning Visibility
+		ReasoningVisibilityDefault: getEnvOrDefault("REASONING_VISIBILITY_DEFAULT", "show"),
+
 		// Background Polling
 		BackgroundPollingEnabled:     getEnvOrDefault("BACKGROUND_POLLING_ENABLED", "true") == "true",
 		BackgroundPollingInterval:    getEnvAsInt("BACKGROUND_POLLING_INTERVAL", 2),
@@ -288,8 +726,38 @@ func LoadConfig() {
 		BackgroundPollingTimeout:     getEnvAsInt("BACKGROUND_POLLING_TIMEOUT", 30),
 		BackgroundMaxConcurrentPolls: getEnvAsInt("BACKGROUND_MAX_CONCURRENT_POLLS", 100),
 
+		// OpenAI Webhooks
+		OpenAIWebhookSecret: strings.TrimSpace(getEnvOrDefault("OPENAI_WEBHOOK_SECRET", "")),
+
 		// Push Notifications
-		PushNotificationsEnabled: getEnvOrDefault("PUSH_NOTIFICATIONS_ENABLED", "true") == "true",
+		PushNotificationsEnabled:        getEnvOrDefault("PUSH_NOTIFICATIONS_ENABLED", "true") == "true",
+		PushNotificationAckGraceSeconds: getEnvAsInt("PUSH_NOTIFICATION_ACK_GRACE_SECONDS", 10),
+
+		// Compliance
+		GeoIPDBPath: getEnvOrDefault("GEOIP_DB_PATH", ""),
+
+		// Weekly Digest
+		WeeklyDigestEnabled: getEnvOrDefault("WEEKLY_DIGEST_ENABLED", "false") == "true",
+		DigestSMTPHost:      getEnvOrDefault("DIGEST_SMTP_HOST", ""),
+		DigestSMTPPort:      getEnvAsInt("DIGEST_SMTP_PORT", 587),
+		DigestSMTPUsername:  getEnvOrDefault("DIGEST_SMTP_USERNAME", ""),
+		DigestSMTPPassword:  getEnvOrDefault("DIGEST_SMTP_PASSWORD", ""),
+		DigestEmailFrom:     getEnvOrDefault("DIGEST_EMAIL_FROM", ""),
+
+		// Usage Rollups
+		UsageRollupsEnabled: getEnvOrDefault("USAGE_ROLLUPS_ENABLED", "true") == "true",
+
+		// Model Quality
+		QualityRoutingEnabled: getEnvOrDefault("QUALITY_ROUTING_ENABLED", "false") == "true",
+		QualityRoutingMargin:  getEnvFloat("QUALITY_ROUTING_MARGIN", 10),
+
+		// Device Attestation
+		DeviceAttestationRequired: getEnvOrDefault("DEVICE_ATTESTATION_REQUIRED", "false") == "true",
+		AppAttestTeamID:           getEnvOrDefault("APP_ATTEST_TEAM_ID", ""),
+		AppAttestBundleID:         getEnvOrDefault("APP_ATTEST_BUNDLE_ID", getEnvOrDefault("APPSTORE_BUNDLE_ID", "")),
+		AppAttestAllowDevelopment: getEnvOrDefault("APP_ATTEST_ALLOW_DEVELOPMENT", "false") == "true",
+		PlayIntegrityPackageName:  getEnvOrDefault("PLAY_INTEGRITY_PACKAGE_NAME", ""),
+		PlayIntegrityCredJSON:     getEnvOrDefault("PLAY_INTEGRITY_CRED_JSON", getEnvOrDefault("FIREBASE_CRED_JSON", "")),
 
 		// ZCash Backend
 		ZCashBackendURL:           getEnvOrDefault("ZCASH_BACKEND_URL", "http://127.0.0.1:20002"),
@@ -320,171 +788,87 @@ func LoadConfig() {
 
 		// Internal API Key (for /internal/ endpoints)
 		InternalAPIKey: getEnvOrDefault("INTERNAL_API_KEY", ""),
-	}
 
-	// Load settings from a configuration file.
-	//
-	// TODO: environment variables should have higher precedence, but this would require
-	// a significant rework. For now, only use the config file for settings that should
-	// not be overridden by environment variables, like model router configuration.
-	// Later should replace this with proper config handling using spf13/viper.
-	configFilePath := getEnvOrDefault("CONFIG_FILE", "config/config.yaml")
-	log.Printf("Loading config file: %v", configFilePath)
+		// Admin API Key (for /admin/ endpoints)
+		AdminAPIKey: getEnvOrDefault("ADMIN_API_KEY", ""),
 
-	configFile, err := os.Open(configFilePath)
-	defer func() {
-		if configFile != nil {
-			configFile.Close()
-		}
-	}()
-
-	if err != nil {
-		log.Fatalf("Failed to open config file: %v", err)
-	}
-
-	if err := LoadConfigFile(configFile, AppConfig); err != nil {
-		log.Fatalf("Failed to load config file: %v", err)
-	}
+		// Synthetic monitoring
+		SyntheticProbesEnabled:        getEnvOrDefault("SYNTHETIC_PROBES_ENABLED", "false") == "true",
+		SyntheticProbeInterval:        getEnvAsDuration("SYNTHETIC_PROBE_INTERVAL", 5*time.Minute),
+		SyntheticProbeToken:           getEnvOrDefault("SYNTHETIC_PROBE_TOKEN", ""),
+		SyntheticProbeUserID:          getEnvOrDefault("SYNTHETIC_PROBE_USER_ID", "synthetic-prober"),
+		SyntheticProbeChatModel:       getEnvOrDefault("SYNTHETIC_PROBE_CHAT_MODEL", "Qwen/Qwen3-30B-A3B-Instruct-2507"),
+		SyntheticProbeEmbeddingModel:  getEnvOrDefault("SYNTHETIC_PROBE_EMBEDDING_MODEL", ""),
+		SyntheticProbeSlackWebhookURL: getEnvOrDefault("SYNTHETIC_PROBE_SLACK_WEBHOOK_URL", ""),
 
-	// Validate required configs
-	if AppConfig.ModelRouterConfig == nil {
-		log.Fatal("Model Router configuration is empty")
-	}
+		// Secrets manager
+		SecretsProvider:        getEnvOrDefault("SECRETS_PROVIDER", ""),
+		SecretsRefreshInterval: getEnvAsDuration("SECRETS_REFRESH_INTERVAL", time.Hour),
 
-	if AppConfig.TitleGeneration == nil {
-		log.Fatal("Title Generation configuration is empty")
-	}
-
-	if AppConfig.FirebaseProjectID == "" {
-		log.Println("Warning: Firebase project ID is missing. Please set FIREBASE_PROJECT_ID environment variable.")
-	}
-
-	if AppConfig.PerplexityAPIKey == "" {
-		log.Println("Warning: Perplexity API key is missing. Please set PERPLEXITY_API_KEY environment variable.")
-	}
-
-	if AppConfig.ReplicateAPIToken == "" {
-		log.Println("Warning: Replicate API token is missing. Please set REPLICATE_API_TOKEN environment variable.")
-	}
-
-	if AppConfig.SerpAPIKey == "" {
-		log.Println("Warning: SerpAPI key is missing. Please set SERPAPI_API_KEY environment variable.")
-	}
-
-	if AppConfig.ExaAPIKey == "" {
-		log.Println("Warning: Exa AI API key is missing. Please set EXA_API_KEY environment variable.")
+		ConfigFilePath: getEnvOrDefault("CONFIG_FILE", "config/config.yaml"),
 	}
-
-	if AppConfig.TelegramToken != "" {
-		log.Println("Telegram service enabled with token")
-	}
-
-	if AppConfig.ZCashBackendAPIKey == "" {
-		log.Println("Warning: ZCash Backend API key is missing. Please set ZCASH_BACKEND_API_KEY environment variable.")
-	}
-
-	if AppConfig.LinearAPIKey == "" {
-		log.Println("Warning: Linear API key is missing. Please set LINEAR_API_KEY environment variable.")
-	}
-
-	if AppConfig.InternalAPIKey == "" {
-		log.Println("Warning: Internal API key is missing. /internal/ endpoints will reject all requests. Please set INTERNAL_API_KEY environment variable.")
-	}
-
-	if AppConfig.FaiEnabled {
-		if AppConfig.FaiWsRpcURL == "" || AppConfig.FaiPaymentContract == "" {
-			log.Println("Warning: FAI_ENABLED is true but FAI_WS_RPC_URL or FAI_PAYMENT_CONTRACT is missing.")
-		} else {
-			safeRpcURL := AppConfig.FaiWsRpcURL
-			if u, err := url.Parse(AppConfig.FaiWsRpcURL); err == nil {
-				safeRpcURL = u.Scheme + "://" + u.Host + u.Path
-			}
-			log.Printf("FAI payment configured: contract=%s, ws_rpc_url=%s", AppConfig.FaiPaymentContract, safeRpcURL)
-		}
-	} else {
-		log.Println("FAI payment disabled (set FAI_ENABLED=true to enable)")
-	}
-
-	if AppConfig.AppStoreAPIKeyP8 == "" || AppConfig.AppStoreAPIKeyID == "" || AppConfig.AppStoreBundleID == "" || AppConfig.AppStoreIssuerID == "" {
-		log.Println("Warning: App Store IAP credentials are missing. Please set APPSTORE_API_KEY_P8, APPSTORE_API_KEY_ID, APPSTORE_BUNDLE_ID, and APPSTORE_ISSUER_ID environment variables.")
-	} else {
-		log.Println(
-			"App Store IAP configured:",
-			"key_id=", AppConfig.AppStoreAPIKeyID,
-			"bundle_id=", AppConfig.AppStoreBundleID,
-			"issuer_id=", AppConfig.AppStoreIssuerID,
-		)
-
-		if AppConfig.AppStoreAPIKeyP8 != "" {
-			sum := sha256.Sum256([]byte(AppConfig.AppStoreAPIKeyP8))
-			log.Printf("App Store IAP private key loaded (sha256=%x, bytes=%d)", sum, len(AppConfig.AppStoreAPIKeyP8))
-		}
-	}
-
-	// Stripe configuration validation
-	if AppConfig.StripeSecretKey == "" || AppConfig.StripeWebhookSecret == "" {
-		log.Println("Warning: Stripe credentials are missing. Please set STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET environment variables.")
-	} else {
-		// Show first 12 chars of key for debugging (e.g., "sk_test_xxxx" or "sk_live_xxxx")
-		keyPrefix := AppConfig.StripeSecretKey
-		if len(keyPrefix) > 12 {
-			keyPrefix = keyPrefix[:12] + "..."
-		}
-		log.Printf("Stripe configured: key=%s (length=%d), webhook_secret length=%d",
-			keyPrefix, len(AppConfig.StripeSecretKey), len(AppConfig.StripeWebhookSecret))
-	}
-
-	log.Println("Firebase project ID: ", AppConfig.FirebaseProjectID)
 }
 
+// getEnvOrDefault returns a setting from the configuration layers (see layers.go), or
+// defaultValue if no layer sets it.
 func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value, source, ok := loading.lookup(key); ok {
+		loading.record(key, value, source)
 		return value
 	}
+	loading.record(key, defaultValue, SourceDefault)
 	return defaultValue
 }
 
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value, source, ok := loading.lookup(key); ok {
 		if parsed, err := time.ParseDuration(value); err == nil {
+			loading.record(key, value, source)
 			return parsed
 		} else {
 			log.Printf("Warning: Failed to parse environment variable %s='%s' as time.Duration, using default %v: %v", key, value, defaultValue, err)
 		}
 	}
+	loading.record(key, defaultValue.String(), SourceDefault)
 	return defaultValue
 }
 
 func getEnvAsInt64(key string, defaultValue int64) int64 {
-	if value := os.Getenv(key); value != "" {
+	if value, source, ok := loading.lookup(key); ok {
 		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			loading.record(key, value, source)
 			return parsed
 		} else {
 			log.Printf("Warning: Failed to parse environment variable %s='%s' as int64, using default %d: %v", key, value, defaultValue, err)
 		}
 	}
+	loading.record(key, strconv.FormatInt(defaultValue, 10), SourceDefault)
 	return defaultValue
 }
 
 func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value, source, ok := loading.lookup(key); ok {
 		if parsed, err := strconv.Atoi(value); err == nil {
+			loading.record(key, value, source)
 			return parsed
 		} else {
 			log.Printf("Warning: Failed to parse environment variable %s='%s' as int, using default %d: %v", key, value, defaultValue, err)
 		}
 	}
+	loading.record(key, strconv.Itoa(defaultValue), SourceDefault)
 	return defaultValue
 }
 
 func getEnvFloat(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
+	if value, source, ok := loading.lookup(key); ok {
 		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			loading.record(key, value, source)
 			return parsed
 		} else {
 			log.Printf("Warning: Failed to parse environment variable %s='%s' as float, using default %f: %v", key, value, defaultValue, err)
 		}
 	}
+	loading.record(key, strconv.FormatFloat(defaultValue, 'f', -1, 64), SourceDefault)
 	return defaultValue
 }
 
@@ -497,3 +881,26 @@ func LoadConfigFile(reader io.Reader, config *Config) error {
 
 	return nil
 }
+
+// LoadModelRouterConfig reads and validates the model_router section of a config file. Provider
+// API keys are read from the environment as the file is loaded.
+func LoadModelRouterConfig(path string) (*ModelRouterConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var cfg Config
+	if err := LoadConfigFile(f, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.ModelRouterConfig == nil {
+		return nil, errors.New("config file has no model_router section")
+	}
+	if len(cfg.ModelRouterConfig.Models) == 0 {
+		return nil, fmt.Errorf("config file %s defines no models", path)
+	}
+
+	return cfg.ModelRouterConfig, nil
+}
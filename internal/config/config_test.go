@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadModelRouterConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := write("valid.yaml", `
+model_router:
+  providers:
+  - name: OpenRouter
+    base_url: https://openrouter.ai/api/v1
+  models:
+  - name: openai/gpt-5
+    aliases: [gpt-5]
+    providers:
+    - name: OpenRouter
+`)
+	noRouter := write("no-router.yaml", "title_generation: {}\n")
+	noModels := write("no-models.yaml", "model_router:\n  providers:\n  - name: OpenRouter\n")
+	invalid := write("invalid.yaml", "model_router: [\n")
+
+	tests := []struct {
+		name       string
+		path       string
+		wantModels int
+		wantErr    string
+	}{
+		{name: "valid", path: valid, wantModels: 1},
+		{name: "missing model_router", path: noRouter, wantErr: "no model_router"},
+		{name: "no models", path: noModels, wantErr: "no models"},
+		{name: "invalid yaml", path: invalid, wantErr: "sequence end token"},
+		{name: "missing file", path: filepath.Join(dir, "missing.yaml"), wantErr: "no such file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadModelRouterConfig(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadModelRouterConfig() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadModelRouterConfig() error = %v", err)
+			}
+			if len(cfg.Models) != tt.wantModels {
+				t.Errorf("len(Models) = %d, want %d", len(cfg.Models), tt.wantModels)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Settings are named after their environment variables and resolved in layers, each
+// overriding the previous one:
+//
+//  1. the default in LoadConfig
+//  2. the selected profile (built-in profile, then the config file's profiles.<name>)
+//  3. the config file's settings section
+//  4. environment variables (including .env, and the secrets manager: see secrets.go)
+//  5. command line flags (--set KEY=VALUE)
+//
+// The profile is selected by --profile, CONFIG_PROFILE or the config file's profile key, in
+// that order. Without one, no profile layer applies. CONFIG_FILE only comes from the flags
+// and the environment.
+//
+// Example config file sections:
+//
+//	profile: staging
+//	settings:
+//	  RATE_LIMIT_QUEUE_ENABLED: true
+//	profiles:
+//	  staging:
+//	    LOG_LEVEL: info
+
+// Source is the layer the effective value of a setting comes from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceProfile Source = "profile"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceSecret  Source = "secret" // Environment variable set from the secrets manager
+	SourceFlag    Source = "flag"
+)
+
+// Setting is the effective value of a setting.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source Source `json:"source"`
+}
+
+// profiles are the built-in profiles; config files may add profiles or override their settings.
+var profiles = map[string]map[string]string{
+	"dev": {
+		"GIN_MODE":   "debug",
+		"LOG_LEVEL":  "debug",
+		"LOG_FORMAT": "text",
+	},
+	"staging": {
+		"GIN_MODE":   "release",
+		"LOG_LEVEL":  "debug",
+		"LOG_FORMAT": "json",
+	},
+	"prod": {
+		"GIN_MODE":   "release",
+		"LOG_LEVEL":  "info",
+		"LOG_FORMAT": "json",
+	},
+}
+
+// Command line flags, registered by RegisterFlags.
+var (
+	flagProfile  string
+	flagSettings = settingFlags{}
+)
+
+// settingFlags are repeated --set KEY=VALUE flags.
+type settingFlags map[string]string
+
+func (f settingFlags) String() string {
+	return ""
+}
+
+func (f settingFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", value)
+	}
+	f[key] = val
+	return nil
+}
+
+// RegisterFlags adds the --profile and --set flags to fs. Commands that don't register them
+// resolve settings without the flag layer.
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&flagProfile, "profile", "", "configuration profile (dev, staging, prod or one defined in the config file)")
+	fs.Var(flagSettings, "set", "override a setting, as KEY=VALUE (repeatable)")
+}
+
+// configFile is the part of the config file that holds settings.
+type configFile struct {
+	Profile  string                            `yaml:"profile"`
+	Settings map[string]interface{}            `yaml:"settings"`
+	Profiles map[string]map[string]interface{} `yaml:"profiles"`
+}
+
+// layers resolves settings and records their effective values.
+type layers struct {
+	profileName string
+	profile     map[string]string
+	file        map[string]string
+	env         func(string) string
+	flags       map[string]string
+
+	mu       sync.Mutex
+	resolved map[string]Setting
+}
+
+// loading are the layers read by the getEnv* helpers while LoadConfig or Reload build a
+// Config (see newConfig).
+var loading = &layers{env: os.Getenv, resolved: make(map[string]Setting)}
+
+// active are the layers of the configuration in effect, reported by EffectiveSettings.
+var active atomic.Pointer[layers]
+
+// activeLayers returns the layers in effect (the ones being loaded before LoadConfig).
+func activeLayers() *layers {
+	if l := active.Load(); l != nil {
+		return l
+	}
+	return loading
+}
+
+// newLayers creates the layers for the config file data (nil if there is none), selecting
+// profileName or else the file's profile.
+func newLayers(data []byte, profileName string, env func(string) string, flags map[string]string) (*layers, error) {
+	var file configFile
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse settings: %w", err)
+		}
+	}
+	if profileName == "" {
+		profileName = file.Profile
+	}
+
+	l := &layers{
+		profileName: profileName,
+		profile:     make(map[string]string),
+		file:        stringValues(file.Settings),
+		env:         env,
+		flags:       flags,
+		resolved:    make(map[string]Setting),
+	}
+	if profileName != "" {
+		builtin, ok := profiles[profileName]
+		fileProfile, inFile := file.Profiles[profileName]
+		if !ok && !inFile {
+			return nil, fmt.Errorf("unknown profile %q", profileName)
+		}
+		for key, value := range builtin {
+			l.profile[key] = value
+		}
+		for key, value := range stringValues(fileProfile) {
+			l.profile[key] = value
+		}
+	}
+	delete(l.profile, "CONFIG_FILE")
+	delete(l.file, "CONFIG_FILE")
+	return l, nil
+}
+
+// loadLayers selects the layers of LoadConfig: the config file (CONFIG_FILE) and profile are
+// taken from the flags and environment only.
+func loadLayers() *layers {
+	l, err := readLayers()
+	if err != nil {
+		log.Fatalf("Failed to load configuration layers: %v", err)
+	}
+	if l.profileName != "" {
+		log.Printf("Configuration profile: %s", l.profileName)
+	}
+	return l
+}
+
+// readLayers reads the layers of the config file and profile selected by the flags and
+// environment.
+func readLayers() (*layers, error) {
+	flags := map[string]string(flagSettings)
+	configFilePath := flags["CONFIG_FILE"]
+	if configFilePath == "" {
+		configFilePath = os.Getenv("CONFIG_FILE")
+	}
+	if configFilePath == "" {
+		configFilePath = "config/config.yaml"
+	}
+	profileName := flagProfile
+	if profileName == "" {
+		profileName = os.Getenv("CONFIG_PROFILE")
+	}
+
+	// A missing config file is reported when the config file is loaded
+	data, _ := os.ReadFile(configFilePath)
+	return newLayers(data, profileName, os.Getenv, flags)
+}
+
+// lookup returns the value of a setting from the highest layer that sets it.
+func (l *layers) lookup(key string) (string, Source, bool) {
+	if value := l.flags[key]; value != "" {
+		return value, SourceFlag, true
+	}
+	if value := l.env(key); value != "" {
+		if fromSecretsManager(key) {
+			return value, SourceSecret, true
+		}
+		return value, SourceEnv, true
+	}
+	if value := l.file[key]; value != "" {
+		return value, SourceFile, true
+	}
+	if value := l.profile[key]; value != "" {
+		return value, SourceProfile, true
+	}
+	return "", "", false
+}
+
+// record records the effective value of a setting.
+func (l *layers) record(key, value string, source Source) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.resolved[key] = Setting{Key: key, Value: value, Source: source}
+}
+
+// settings returns the effective settings by key, with secrets redacted.
+func (l *layers) settings() []Setting {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	settings := make([]Setting, 0, len(l.resolved))
+	for _, setting := range l.resolved {
+		if setting.Value != "" && isSecret(setting.Key) {
+			setting.Value = "[redacted]"
+		} else {
+			setting.Value = redactURLCredentials(setting.Value)
+		}
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// Profile returns the selected configuration profile, or "" if there is none.
+func Profile() string {
+	return activeLayers().profileName
+}
+
+// EffectiveSettings returns the effective value and source of every setting LoadConfig read,
+// sorted by key. Secrets (keys, tokens, passwords, credentials in URLs) are redacted.
+func EffectiveSettings() []Setting {
+	return activeLayers().settings()
+}
+
+// secretMarkers are parts of the names of settings holding secrets.
+var secretMarkers = []string{"KEY", "SECRET", "TOKEN", "PASSWORD", "CREDENTIALS", "CRED_JSON", "PRIVATE", "WEBHOOK_URL", "DATABASE_URL", "RPC_URL", "DSN"}
+
+func isSecret(key string) bool {
+	// The secrets manager settings name secrets but hold none (except credentials)
+	key = strings.TrimPrefix(key, "SECRETS_")
+	for _, marker := range secretMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURLCredentials redacts the user information of URL values (redis://:password@host).
+func redactURLCredentials(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	u.User = nil
+	return strings.Replace(u.String(), "//", "//[redacted]@", 1)
+}
+
+func stringValues(values map[string]interface{}) map[string]string {
+	strs := make(map[string]string, len(values))
+	for key, value := range values {
+		if value != nil {
+			strs[key] = fmt.Sprint(value)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,84 @@
+package config
+
+import "testing"
+
+func TestLayers(t *testing.T) {
+	file := []byte(`
+profile: staging
+settings:
+  PORT: 9000
+  LOG_FORMAT: text
+  CONFIG_FILE: other.yaml
+profiles:
+  staging:
+    LOG_LEVEL: warn
+  qa:
+    GIN_MODE: test
+`)
+	env := map[string]string{"PORT": "9100", "OPENAI_API_KEY": "sk-secret"}
+	flags := map[string]string{"GIN_MODE": "debug"}
+
+	l, err := newLayers(file, "", func(key string) string { return env[key] }, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.profileName != "staging" {
+		t.Errorf("profile = %q, want the file's staging", l.profileName)
+	}
+
+	tests := []struct {
+		key        string
+		wantValue  string
+		wantSource Source
+	}{
+		{"GIN_MODE", "debug", SourceFlag},    // Flag over the staging profile
+		{"PORT", "9100", SourceEnv},          // Environment over the file
+		{"LOG_FORMAT", "text", SourceFile},   // File over the staging profile
+		{"LOG_LEVEL", "warn", SourceProfile}, // File profile over the built-in profile
+		{"CONFIG_FILE", "", ""},              // Not taken from the file
+		{"EXA_API_KEY", "", ""},              // Not set anywhere
+		{"OPENAI_API_KEY", "sk-secret", SourceEnv},
+	}
+	for _, tt := range tests {
+		value, source, _ := l.lookup(tt.key)
+		if value != tt.wantValue || source != tt.wantSource {
+			t.Errorf("lookup(%s) = %q from %q, want %q from %q", tt.key, value, source, tt.wantValue, tt.wantSource)
+		}
+	}
+
+	// Profiles only defined in the file, and unknown profiles
+	if l, err := newLayers(file, "qa", func(string) string { return "" }, nil); err != nil || l.profile["GIN_MODE"] != "test" {
+		t.Errorf("qa profile = %v, %v", l, err)
+	}
+	if _, err := newLayers(nil, "production", func(string) string { return "" }, nil); err == nil {
+		t.Error("unknown profile accepted")
+	}
+}
+
+func TestEffectiveSettings(t *testing.T) {
+	l := &layers{resolved: make(map[string]Setting)}
+	l.record("PORT", "8080", SourceDefault)
+	l.record("OPENAI_API_KEY", "sk-secret", SourceEnv)
+	l.record("DATABASE_URL", "postgres://user:pass@db/proxy", SourceEnv)
+	l.record("STRIPE_WEBHOOK_SECRET", "", SourceDefault)
+	l.record("REDIS_URL", "redis://:pw@host:6379/0", SourceEnv)
+	l.record("NATS_URL", "nats://nats:4222", SourceDefault)
+
+	want := []Setting{
+		{Key: "DATABASE_URL", Value: "[redacted]", Source: SourceEnv},
+		{Key: "NATS_URL", Value: "nats://nats:4222", Source: SourceDefault},
+		{Key: "OPENAI_API_KEY", Value: "[redacted]", Source: SourceEnv},
+		{Key: "PORT", Value: "8080", Source: SourceDefault},
+		{Key: "REDIS_URL", Value: "redis://[redacted]@host:6379/0", Source: SourceEnv},
+		{Key: "STRIPE_WEBHOOK_SECRET", Value: "", Source: SourceDefault}, // Unset secrets show as unset
+	}
+	got := l.settings()
+	if len(got) != len(want) {
+		t.Fatalf("settings = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("settings[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
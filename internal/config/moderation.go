@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ModerationConfig configures pre-flight moderation of chat completion inputs with the OpenAI
+// moderation API, on top of the moderation of family mode accounts.
+type ModerationConfig struct {
+	// Preflight rules are matched in order against the tier and model of each chat completion
+	// request; the first match moderates the last user message.
+	Preflight []ModerationRule `yaml:"preflight"`
+}
+
+// ModerationRule blocks chat completion inputs whose moderation category scores meet its
+// thresholds.
+type ModerationRule struct {
+	// Name identifies the rule in logs and metrics.
+	Name string `yaml:"name"`
+
+	// Tiers the rule applies to (empty = every tier).
+	Tiers []string `yaml:"tiers,omitempty"`
+
+	// Models the rule applies to, canonical names or aliases (empty = every model).
+	Models []string `yaml:"models,omitempty"`
+
+	// Thresholds maps moderation categories (e.g. "sexual/minors") to the score at or above
+	// which a request is blocked. Categories not listed are not enforced.
+	Thresholds map[string]float64 `yaml:"thresholds"`
+
+	// FailClosed blocks requests when the moderation API fails (default: allow them).
+	FailClosed bool `yaml:"fail_closed,omitempty"`
+}
+
+// Validate performs validation of a ModerationConfig value:
+// - Checks that rules are named and unique
+// - Checks that every rule has thresholds between 0 (exclusive) and 1 (inclusive)
+func (cfg *ModerationConfig) Validate() error {
+	names := make(map[string]struct{}, len(cfg.Preflight))
+	for i, rule := range cfg.Preflight {
+		if rule.Name == "" {
+			return fmt.Errorf("moderation rule #%d has no name", i+1)
+		}
+		if _, exists := names[rule.Name]; exists {
+			return fmt.Errorf("duplicate moderation rule %v", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+
+		if len(rule.Thresholds) == 0 {
+			return fmt.Errorf("moderation rule %v has no thresholds", rule.Name)
+		}
+		for category, threshold := range rule.Thresholds {
+			if category == "" || threshold <= 0 || threshold > 1 {
+				return fmt.Errorf("moderation rule %v: invalid threshold %v for category %q", rule.Name, threshold, category)
+			}
+		}
+	}
+
+	return nil
+}
+
+// unmarshalModerationConfig implements a custom YAML unmarshaler for ModerationConfig.
+// Validates the value after unmarshaling.
+func unmarshalModerationConfig(value *ModerationConfig, data []byte) error {
+	type Aux ModerationConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = ModerationConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[ModerationConfig](unmarshalModerationConfig)
+}
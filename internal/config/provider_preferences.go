@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ProviderPreferencesConfig contains OpenRouter provider routing preferences. They are injected
+// into forwarded request bodies as the "provider" object, overriding the same preferences sent
+// by clients, so privacy and cost guarantees are enforced by the proxy.
+type ProviderPreferencesConfig struct {
+	// Order lists upstream providers (e.g. "Together", "Fireworks") to try in order.
+	Order []string `yaml:"order,omitempty"`
+
+	// AllowFallbacks controls whether OpenRouter may use providers outside Order when those
+	// are unavailable. Unset leaves OpenRouter's default (true).
+	AllowFallbacks *bool `yaml:"allow_fallbacks,omitempty"`
+
+	// DataCollection is "deny" to only use providers that don't store or train on request
+	// data, or "allow". Unset leaves OpenRouter's default (allow).
+	DataCollection string `yaml:"data_collection,omitempty"`
+}
+
+// Validate performs validation of a ProviderPreferencesConfig value:
+// - Checks that provider names in Order are not empty
+// - Checks the DataCollection value
+func (cfg *ProviderPreferencesConfig) Validate() error {
+	for i, provider := range cfg.Order {
+		if provider == "" {
+			return fmt.Errorf("provider #%d in provider preferences order is empty", i+1)
+		}
+	}
+
+	switch cfg.DataCollection {
+	case "", "allow", "deny":
+	default:
+		return fmt.Errorf("invalid data_collection %q in provider preferences (must be allow or deny)", cfg.DataCollection)
+	}
+
+	return nil
+}
+
+// Merge returns the preferences with the values set in override taking precedence.
+// Either value may be nil.
+func (cfg *ProviderPreferencesConfig) Merge(override *ProviderPreferencesConfig) *ProviderPreferencesConfig {
+	if cfg == nil {
+		return override
+	}
+	if override == nil {
+		return cfg
+	}
+
+	merged := *cfg
+	if len(override.Order) > 0 {
+		merged.Order = override.Order
+	}
+	if override.AllowFallbacks != nil {
+		merged.AllowFallbacks = override.AllowFallbacks
+	}
+	if override.DataCollection != "" {
+		merged.DataCollection = override.DataCollection
+	}
+	return &merged
+}
+
+// unmarshalProviderPreferencesConfig implements a custom YAML unmarshaler for
+// ProviderPreferencesConfig. Validates the value after unmarshaling.
+func unmarshalProviderPreferencesConfig(value *ProviderPreferencesConfig, data []byte) error {
+	type Aux ProviderPreferencesConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = ProviderPreferencesConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[ProviderPreferencesConfig](unmarshalProviderPreferencesConfig)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// QuotaExperimentConfig defines a temporary quota variation for a cohort of one tier's users,
+// e.g. 20% of free users getting 2 deep research runs per day.
+//
+// Users are assigned by hashing the experiment name with the user ID, so a user stays in the
+// same variant for the whole experiment and renaming an experiment reshuffles its cohort.
+type QuotaExperimentConfig struct {
+	// Name identifies the experiment in exposure logs. Must be unique.
+	Name string `yaml:"name"`
+
+	// Tier whose users are eligible ("free", "plus" or "pro").
+	Tier string `yaml:"tier"`
+
+	// Percent of eligible users in the treatment cohort (0-100]. The rest are logged as control.
+	Percent float64 `yaml:"percent"`
+
+	// StartsAt and EndsAt bound the experiment (optional; unset = open-ended).
+	StartsAt *time.Time `yaml:"starts_at,omitempty"`
+	EndsAt   *time.Time `yaml:"ends_at,omitempty"`
+
+	// Overrides replace the tier's limits for the treatment cohort.
+	Overrides QuotaOverrides `yaml:"overrides"`
+}
+
+// QuotaOverrides are tier limits replaced for an experiment cohort. Unset fields keep the tier
+// value; values have the same meaning as in tiers.Config (e.g. 0 tokens = unlimited).
+type QuotaOverrides struct {
+	MonthlyPlanTokens             *int64 `yaml:"monthly_plan_tokens,omitempty"`
+	WeeklyPlanTokens              *int64 `yaml:"weekly_plan_tokens,omitempty"`
+	DailyPlanTokens               *int64 `yaml:"daily_plan_tokens,omitempty"`
+	MonthlyAudioMinutes           *int64 `yaml:"monthly_audio_minutes,omitempty"`
+	DailyAudioMinutes             *int64 `yaml:"daily_audio_minutes,omitempty"`
+	DeepResearchDailyRuns         *int   `yaml:"deep_research_daily_runs,omitempty"`
+	DeepResearchLifetimeRuns      *int   `yaml:"deep_research_lifetime_runs,omitempty"`
+	DeepResearchTokenCap          *int   `yaml:"deep_research_token_cap,omitempty"`
+	DeepResearchMaxActiveSessions *int   `yaml:"deep_research_max_active_sessions,omitempty"`
+}
+
+// IsEmpty reports whether no limit is overridden.
+func (o QuotaOverrides) IsEmpty() bool {
+	return o == QuotaOverrides{}
+}
+
+// Active reports whether the experiment runs at t.
+func (cfg *QuotaExperimentConfig) Active(t time.Time) bool {
+	if cfg.StartsAt != nil && t.Before(*cfg.StartsAt) {
+		return false
+	}
+	if cfg.EndsAt != nil && !t.Before(*cfg.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Validate performs validation of a QuotaExperimentConfig value:
+// - Checks that name and tier are set and percent is in (0, 100]
+// - Checks that the time window is not empty and at least one limit is overridden
+func (cfg *QuotaExperimentConfig) Validate() error {
+	if cfg.Name == "" {
+		return errors.New("quota experiment name is empty")
+	}
+	if cfg.Tier == "" {
+		return fmt.Errorf("quota experiment %q: tier is empty", cfg.Name)
+	}
+	if cfg.Percent <= 0 || cfg.Percent > 100 {
+		return fmt.Errorf("quota experiment %q: percent must be in (0, 100], got %v", cfg.Name, cfg.Percent)
+	}
+	if cfg.StartsAt != nil && cfg.EndsAt != nil && !cfg.EndsAt.After(*cfg.StartsAt) {
+		return fmt.Errorf("quota experiment %q: ends_at must be after starts_at", cfg.Name)
+	}
+	if cfg.Overrides.IsEmpty() {
+		return fmt.Errorf("quota experiment %q: no overrides", cfg.Name)
+	}
+	return nil
+}
+
+// unmarshalQuotaExperimentConfig implements a custom YAML unmarshaler for QuotaExperimentConfig.
+// Validates the value after unmarshaling.
+func unmarshalQuotaExperimentConfig(value *QuotaExperimentConfig, data []byte) error {
+	type Aux QuotaExperimentConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = QuotaExperimentConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[QuotaExperimentConfig](unmarshalQuotaExperimentConfig)
+}
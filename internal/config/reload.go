@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joho/godotenv"
+)
+
+// Reloadable settings can be changed without a restart (SIGHUP or POST /admin/v1/config/reload):
+// Reload re-reads the layers (config file settings and profiles, .env, environment) and
+// applies them. Every other setting, and the structured configuration of the config file,
+// keeps the value loaded at startup.
+//
+// Code reading a reloadable setting must go through Current; AppConfig keeps the values loaded
+// at startup. Settings consumed at startup (worker pools, the logger) are applied by the
+// functions registered with OnReload.
+var reloadable = []struct {
+	key   string
+	apply func(dst, src *Config)
+}{
+	{"LOG_LEVEL", func(dst, src *Config) { dst.LogLevel = src.LogLevel }},
+	{"RATE_LIMIT_ENABLED", func(dst, src *Config) { dst.RateLimitEnabled = src.RateLimitEnabled }},
+	{"RATE_LIMIT_FAIL_CLOSED", func(dst, src *Config) { dst.RateLimitFailClosed = src.RateLimitFailClosed }},
+	{"RATE_LIMIT_SOFT_MULTIPLIER", func(dst, src *Config) { dst.RateLimitSoftMultiplier = src.RateLimitSoftMultiplier }},
+	{"DEEP_RESEARCH_PRO_MAX_ACTIVE_SESSIONS", func(dst, src *Config) {
+		dst.DeepResearchProMaxActiveSessions = src.DeepResearchProMaxActiveSessions
+	}},
+	{"REQUEST_TRACKING_WORKER_POOL_SIZE", func(dst, src *Config) {
+		dst.RequestTrackingWorkerPoolSize = src.RequestTrackingWorkerPoolSize
+	}},
+	{"MESSAGE_STORAGE_WORKER_POOL_SIZE", func(dst, src *Config) {
+		dst.MessageStorageWorkerPoolSize = src.MessageStorageWorkerPoolSize
+	}},
+}
+
+// Change is a reloaded setting whose effective value changed.
+type Change struct {
+	Key      string `json:"key"`
+	Previous string `json:"previous"`
+	Value    string `json:"value"`
+	Source   Source `json:"source"`
+}
+
+var (
+	// reloadMu serializes Reload (and its use of the loading layers).
+	reloadMu sync.Mutex
+
+	// current is the configuration with the reloaded settings applied; nil until the first
+	// reload (Current returns AppConfig).
+	current atomic.Pointer[Config]
+
+	listeners []func(cfg *Config)
+
+	// dotenvKeys are the variables set from .env rather than the environment, which Reload
+	// re-reads.
+	dotenvKeys = make(map[string]bool)
+)
+
+// Current returns the configuration in effect: AppConfig with the reloaded settings applied.
+// The returned Config must not be modified.
+func Current() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
+	}
+	return AppConfig
+}
+
+// OnReload registers a function called with the new configuration after a reload changed
+// settings. Must be called before the server starts.
+func OnReload(fn func(cfg *Config)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// Reload re-reads the configuration layers and applies the reloadable settings, returning the
+// settings that changed. The new configuration is published atomically: requests see either
+// all of the previous values or all of the new ones.
+func Reload() ([]Change, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if err := reloadDotenv(); err != nil {
+		return nil, err
+	}
+	next, err := readLayers()
+	if err != nil {
+		return nil, err
+	}
+	previous := activeLayers()
+
+	loading = next
+	fresh := newConfig()
+
+	cfg := *Current()
+	var changes []Change
+	previous.mu.Lock()
+	for _, setting := range reloadable {
+		setting.apply(&cfg, fresh)
+
+		before := previous.resolved[setting.key]
+		after := next.resolved[setting.key]
+		if before.Value != after.Value {
+			changes = append(changes, Change{Key: setting.key, Previous: before.Value, Value: after.Value, Source: after.Source})
+		}
+	}
+
+	// Other settings keep their values, so report those in effect
+	for key := range next.resolved {
+		if isReloadable(key) {
+			continue
+		}
+		if setting, ok := previous.resolved[key]; ok {
+			next.resolved[key] = setting
+		} else {
+			delete(next.resolved, key)
+		}
+	}
+	previous.mu.Unlock()
+
+	current.Store(&cfg)
+	active.Store(next)
+
+	if len(changes) > 0 {
+		for _, fn := range listeners {
+			fn(&cfg)
+		}
+	}
+	return changes, nil
+}
+
+func isReloadable(key string) bool {
+	for _, setting := range reloadable {
+		if setting.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// loadDotenv sets the variables of .env that aren't set in the environment.
+func loadDotenv() error {
+	values, err := godotenv.Read(".env")
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value) //nolint:errcheck
+			dotenvKeys[key] = true
+		}
+	}
+	return nil
+}
+
+// reloadDotenv re-reads .env: variables it set are updated or unset, new ones are set unless
+// the environment sets them. A missing .env file unsets the variables it set.
+func reloadDotenv() error {
+	values, err := godotenv.Read(".env")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for key := range dotenvKeys {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key) //nolint:errcheck
+			delete(dotenvKeys, key)
+		}
+	}
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set && !dotenvKeys[key] {
+			continue
+		}
+		os.Setenv(key, value) //nolint:errcheck
+		dotenvKeys[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(settings string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("settings:\n"+settings), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("PORT", "")
+	t.Setenv("RATE_LIMIT_SOFT_MULTIPLIER", "")
+	t.Setenv("RATE_LIMIT_ENABLED", "")
+
+	savedConfig, savedLoading, savedActive := AppConfig, loading, active.Load()
+	savedListeners := listeners
+	t.Cleanup(func() {
+		AppConfig, loading, listeners = savedConfig, savedLoading, savedListeners
+		active.Store(savedActive)
+		current.Store(nil)
+	})
+
+	// Startup
+	write("  PORT: 9000\n  RATE_LIMIT_ENABLED: true\n")
+	l, err := readLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loading = l
+	AppConfig = newConfig()
+	active.Store(l)
+	if Current() != AppConfig {
+		t.Fatal("Current should be AppConfig before a reload")
+	}
+
+	var reloaded *Config
+	OnReload(func(cfg *Config) { reloaded = cfg })
+
+	write("  PORT: 9999\n  RATE_LIMIT_ENABLED: true\n  RATE_LIMIT_SOFT_MULTIPLIER: 0.5\n")
+	changes, err := Reload()
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	cfg := Current()
+	if cfg.RateLimitSoftMultiplier != 0.5 || !cfg.RateLimitEnabled {
+		t.Errorf("reloadable settings not applied: multiplier %v, enabled %v", cfg.RateLimitSoftMultiplier, cfg.RateLimitEnabled)
+	}
+	if cfg.Port != "9000" {
+		t.Errorf("Port = %q, want the startup value 9000", cfg.Port)
+	}
+	if AppConfig.RateLimitSoftMultiplier != 1.0 {
+		t.Errorf("AppConfig was modified: multiplier %v", AppConfig.RateLimitSoftMultiplier)
+	}
+	if len(changes) != 1 || changes[0].Key != "RATE_LIMIT_SOFT_MULTIPLIER" || changes[0].Value != "0.5" || changes[0].Source != SourceFile {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	if reloaded != cfg {
+		t.Error("reload listener not called with the new configuration")
+	}
+
+	// Effective settings report the reloaded value, and the startup value of the others
+	for _, setting := range EffectiveSettings() {
+		switch setting.Key {
+		case "PORT":
+			if setting.Value != "9000" {
+				t.Errorf("PORT reported as %q, want 9000", setting.Value)
+			}
+		case "RATE_LIMIT_SOFT_MULTIPLIER":
+			if setting.Value != "0.5" {
+				t.Errorf("RATE_LIMIT_SOFT_MULTIPLIER reported as %q, want 0.5", setting.Value)
+			}
+		}
+	}
+
+	reloaded = nil
+	if changes, err := Reload(); err != nil || len(changes) != 0 {
+		t.Errorf("second Reload = %+v, %v, want no changes", changes, err)
+	}
+	if reloaded != nil {
+		t.Error("listener called without changes")
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ForwardUserAgent is the RequestHeadersConfig.UserAgent value that passes the client's
+// User-Agent through to the provider.
+const ForwardUserAgent = "forward"
+
+// RequestHeadersConfig controls the headers the proxy sends to a provider. Applied on top of
+// the proxy's defaults; a provider without one keeps the default headers.
+type RequestHeadersConfig struct {
+	// UserAgent replaces the User-Agent sent upstream. "forward" sends the client's own.
+	UserAgent string `yaml:"user_agent,omitempty"`
+
+	// Forward lists client request headers copied to requests the proxy builds itself
+	// (streaming and Responses API requests). Reverse-proxied requests already carry all
+	// client headers that aren't stripped.
+	Forward []string `yaml:"forward,omitempty"`
+
+	// Strip lists client request headers never sent to the provider, in addition to the
+	// proxy's own metadata headers (X-Chat-ID etc.).
+	Strip []string `yaml:"strip,omitempty"`
+
+	// Add sets static headers on every request to the provider.
+	Add map[string]string `yaml:"add,omitempty"`
+}
+
+// managedRequestHeaders are set by the proxy itself (credentials, framing, encoding) and
+// can't be forwarded, stripped or overridden by a header policy.
+var managedRequestHeaders = []string{
+	"Accept-Encoding",
+	"Authorization",
+	"Connection",
+	"Content-Length",
+	"Host",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+	"User-Agent",
+}
+
+// Validate performs validation of a RequestHeadersConfig value:
+// - Canonicalizes header names
+// - Rejects empty or invalid names and headers managed by the proxy
+func (cfg *RequestHeadersConfig) Validate() error {
+	for _, list := range [][]string{cfg.Forward, cfg.Strip} {
+		for i, name := range list {
+			canonical, err := canonicalPolicyHeader(name)
+			if err != nil {
+				return err
+			}
+			list[i] = canonical
+		}
+	}
+
+	add := make(map[string]string, len(cfg.Add))
+	for name, value := range cfg.Add {
+		canonical, err := canonicalPolicyHeader(name)
+		if err != nil {
+			return err
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for header %q", name)
+		}
+		add[canonical] = value
+	}
+	cfg.Add = add
+
+	if strings.ContainsAny(cfg.UserAgent, "\r\n") {
+		return fmt.Errorf("invalid user agent %q", cfg.UserAgent)
+	}
+
+	return nil
+}
+
+// canonicalPolicyHeader returns the canonical form of a header name used in a header policy.
+func canonicalPolicyHeader(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " :\r\n") {
+		return "", fmt.Errorf("invalid header name %q", name)
+	}
+
+	canonical := http.CanonicalHeaderKey(name)
+	for _, managed := range managedRequestHeaders {
+		if canonical == managed {
+			return "", fmt.Errorf("header %q is managed by the proxy", name)
+		}
+	}
+	return canonical, nil
+}
+
+// unmarshalRequestHeadersConfig implements a custom YAML unmarshaler for RequestHeadersConfig.
+// Validates the value after unmarshaling.
+func unmarshalRequestHeadersConfig(value *RequestHeadersConfig, data []byte) error {
+	type Aux RequestHeadersConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = RequestHeadersConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[RequestHeadersConfig](unmarshalRequestHeadersConfig)
+}
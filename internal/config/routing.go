@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/url"
@@ -20,6 +21,14 @@ const (
 
 	// APITypeResponses uses OpenAI's stateful /responses endpoint (GPT-5 Pro, GPT-4.5+)
 	APITypeResponses APIType = "responses"
+
+	// APITypeAnthropic uses Anthropic's /messages endpoint (Claude models direct from Anthropic).
+	// Chat completions requests and responses are translated by the proxy.
+	APITypeAnthropic APIType = "anthropic"
+
+	// APITypeGemini uses Google's Gemini API (models/{model}:generateContent).
+	// Chat completions requests and responses are translated by the proxy.
+	APITypeGemini APIType = "gemini"
 )
 
 // Validate performs basic validation of an APIType value:
@@ -30,13 +39,15 @@ func (t *APIType) Validate() error {
 	case "":
 		*t = APITypeChatCompletions
 		return nil
-	case APITypeChatCompletions, APITypeResponses:
+	case APITypeChatCompletions, APITypeResponses, APITypeAnthropic, APITypeGemini:
 		return nil
 	default:
 		return fmt.Errorf(
-			"bad APIType value: must be empty or one of %q, %q",
+			"bad APIType value: must be empty or one of %q, %q, %q, %q",
 			string(APITypeChatCompletions),
 			string(APITypeResponses),
+			string(APITypeAnthropic),
+			string(APITypeGemini),
 		)
 	}
 }
@@ -67,12 +78,17 @@ type ModelRouterConfig struct {
 
 	// Models contain routing configuration for models supported by our API.
 	Models []ModelConfig `yaml:"models"`
+
+	// Deprecations map model IDs retired by providers to the configured models replacing them.
+	Deprecations []ModelDeprecationConfig `yaml:"deprecations,omitempty"`
 }
 
 // Validate performs validation of a ModelRouterConfig value:
 // - Checks that provider and model lists are not empty
 // - Checks that models reference known providers
 // - Checks for duplicates in the lists of providers and models
+// - Checks that deprecated model IDs are unique, not configured as models and have a
+// configured successor
 func (cfg *ModelRouterConfig) Validate() error {
 	if len(cfg.Providers) == 0 {
 		return errors.New("no providers specified in model router configuration")
@@ -106,6 +122,31 @@ func (cfg *ModelRouterConfig) Validate() error {
 		models[model.Name] = struct{}{}
 	}
 
+	names := make(map[string]struct{}, len(cfg.Models)*2)
+	for _, model := range cfg.Models {
+		names[strings.ToLower(strings.TrimSpace(model.Name))] = struct{}{}
+		for _, alias := range model.Aliases {
+			names[strings.ToLower(strings.TrimSpace(alias))] = struct{}{}
+		}
+	}
+
+	deprecated := make(map[string]struct{}, len(cfg.Deprecations))
+	for _, deprecation := range cfg.Deprecations {
+		model := strings.ToLower(strings.TrimSpace(deprecation.Model))
+		if _, exists := deprecated[model]; exists {
+			return fmt.Errorf("duplicate deprecation entry for model %v", deprecation.Model)
+		}
+		if _, configured := names[model]; configured {
+			return fmt.Errorf("deprecated model %v is still configured as a model or alias", deprecation.Model)
+		}
+		successor := strings.ToLower(strings.TrimSpace(deprecation.Successor))
+		if _, configured := names[successor]; !configured || successor == "*" {
+			return fmt.Errorf("unknown successor %v specified for deprecated model %v", deprecation.Successor, deprecation.Model)
+		}
+
+		deprecated[model] = struct{}{}
+	}
+
 	return nil
 }
 
@@ -145,12 +186,67 @@ type ModelProviderConfig struct {
 	// APIKey is the actual API key used for authentication, extracted from the environment
 	// using the APIKeyEnvVar value. Explicit config values are ignored.
 	APIKey string `yaml:"-"`
+
+	// Headers contains the optional outbound header policy for requests to this provider
+	// (User-Agent, forwarded, stripped and added headers).
+	Headers *RequestHeadersConfig `yaml:"headers,omitempty"`
+
+	// ProviderPreferences contains OpenRouter provider routing preferences injected into
+	// request bodies for all models served by this provider.
+	ProviderPreferences *ProviderPreferencesConfig `yaml:"provider_preferences,omitempty"`
+
+	// ZeroRetention marks a provider that neither logs nor retains request data. Only such
+	// providers serve privacy mode requests (X-Privacy-Strict).
+	ZeroRetention bool `yaml:"zero_retention,omitempty"`
+
+	// Regions are the provider's regional endpoints, used instead of BaseURL. Chat requests go
+	// to the fastest healthy region by probed latency, sticky per user.
+	Regions []ProviderRegionConfig `yaml:"regions,omitempty"`
+
+	// PinnedRegion is the name of the region that serves all requests, disabling latency-based
+	// selection. Optional.
+	PinnedRegion string `yaml:"pinned_region,omitempty"`
+
+	// Attestation requires the provider's enclave attestation to verify before it is routed to.
+	// Optional.
+	Attestation *AttestationConfig `yaml:"attestation,omitempty"`
+}
+
+// AttestationTypeTinfoil is the attestation of Tinfoil secure enclaves (AMD SEV-SNP).
+const AttestationTypeTinfoil = "tinfoil"
+
+// AttestationConfig configures the enclave attestation verification of a provider.
+type AttestationConfig struct {
+	// Type is the attestation scheme; only "tinfoil" is supported.
+	Type string `yaml:"type"`
+
+	// Measurements are the accepted launch measurements of the enclave image (hex, 48 bytes),
+	// as published for its release. Empty = any measurement, only the enclave's TLS key
+	// binding and policy are checked.
+	Measurements []string `yaml:"measurements,omitempty"`
+}
+
+// Validate checks the attestation type and measurement format.
+func (cfg *AttestationConfig) Validate() error {
+	if cfg.Type != AttestationTypeTinfoil {
+		return fmt.Errorf("unsupported attestation type %q", cfg.Type)
+	}
+	for _, measurement := range cfg.Measurements {
+		if b, err := hex.DecodeString(measurement); err != nil || len(b) != 48 {
+			return fmt.Errorf("invalid attestation measurement %q: want 96 hex characters", measurement)
+		}
+	}
+	return nil
 }
 
 // Validate performs validation of a ModelProviderConfig value:
 // - Checks that the name is not empty
 // - Verifies BaseURL is a valid URL
 // - Fetches APIKey value from the environment using APIKeyEnvVar
+// - Validates the header policy and provider preferences
+// - Checks that regions have unique names, are not combined with BaseURL and that the pinned
+// region exists
+// - Validates the attestation configuration (which requires a BaseURL)
 func (cfg *ModelProviderConfig) Validate() error {
 	if cfg.Name == "" {
 		return errors.New("provider name must be specified in model provider configuration")
@@ -160,6 +256,45 @@ func (cfg *ModelProviderConfig) Validate() error {
 		return err
 	}
 
+	if cfg.Headers != nil {
+		if err := cfg.Headers.Validate(); err != nil {
+			return fmt.Errorf("provider %v: %w", cfg.Name, err)
+		}
+	}
+
+	if cfg.ProviderPreferences != nil {
+		if err := cfg.ProviderPreferences.Validate(); err != nil {
+			return fmt.Errorf("provider %v: %w", cfg.Name, err)
+		}
+	}
+
+	if len(cfg.Regions) > 0 && cfg.BaseURL != "" {
+		return fmt.Errorf("provider %v: base_url and regions are mutually exclusive", cfg.Name)
+	}
+
+	regions := make(map[string]struct{}, len(cfg.Regions))
+	for _, region := range cfg.Regions {
+		if _, exists := regions[region.Name]; exists {
+			return fmt.Errorf("provider %v: duplicate region %v", cfg.Name, region.Name)
+		}
+		regions[region.Name] = struct{}{}
+	}
+
+	if cfg.PinnedRegion != "" {
+		if _, exists := regions[cfg.PinnedRegion]; !exists {
+			return fmt.Errorf("provider %v: unknown pinned region %v", cfg.Name, cfg.PinnedRegion)
+		}
+	}
+
+	if cfg.Attestation != nil {
+		if len(cfg.Regions) > 0 || cfg.BaseURL == "" {
+			return fmt.Errorf("provider %v: attestation requires a base_url", cfg.Name)
+		}
+		if err := cfg.Attestation.Validate(); err != nil {
+			return fmt.Errorf("provider %v: %w", cfg.Name, err)
+		}
+	}
+
 	if cfg.APIKeyEnvVar != "" {
 		cfg.APIKey = os.Getenv(cfg.APIKeyEnvVar)
 	}
@@ -186,6 +321,53 @@ func unmarshalModelProviderConfig(value *ModelProviderConfig, data []byte) error
 	return nil
 }
 
+// ProviderRegionConfig is a regional endpoint of an inference API provider.
+type ProviderRegionConfig struct {
+	// Name identifies the region (e.g., "us-east", "eu-west").
+	Name string `yaml:"name"`
+
+	// BaseURL is the base URL of the provider's API in this region.
+	BaseURL string `yaml:"base_url"`
+}
+
+// Validate performs validation of a ProviderRegionConfig value:
+// - Checks that the name and base URL are specified
+// - Verifies BaseURL is a valid URL
+func (cfg *ProviderRegionConfig) Validate() error {
+	if cfg.Name == "" {
+		return errors.New("region name must be specified in provider region configuration")
+	}
+
+	if cfg.BaseURL == "" {
+		return fmt.Errorf("no base URL specified for region %v", cfg.Name)
+	}
+
+	if err := validateURLString(cfg.BaseURL); err != nil {
+		return fmt.Errorf("region %v: %w", cfg.Name, err)
+	}
+
+	return nil
+}
+
+// unmarshalProviderRegionConfig implements a custom YAML unmarshaler for ProviderRegionConfig.
+// Validates the value after unmarshaling.
+func unmarshalProviderRegionConfig(value *ProviderRegionConfig, data []byte) error {
+	type Aux ProviderRegionConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = ProviderRegionConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ModelConfig contains routing configuration for a specific model supported by our API.
 type ModelConfig struct {
 	// Name is the full "canonical" name of the model.
@@ -203,14 +385,56 @@ type ModelConfig struct {
 	// Defaults to 1.0
 	TokenMultiplier float64 `yaml:"token_multiplier,omitempty"`
 
+	// ContextWindow is the maximum number of tokens (prompt + completion) the model accepts.
+	// Used to compact long conversations before forwarding. 0 = unknown, never compacted.
+	ContextWindow int `yaml:"context_window,omitempty"`
+
+	// MaxOutputTokens is the maximum number of completion tokens the model can produce.
+	// Larger max_tokens values are clamped before forwarding. 0 = unknown, not enforced.
+	MaxOutputTokens int `yaml:"max_output_tokens,omitempty"`
+
+	// MaxRequestSeconds is the overall time budget of a streaming request for this model,
+	// including tool calls. The strictest of this, the tier's and the global budget applies.
+	// 0 = no model-specific budget.
+	MaxRequestSeconds int `yaml:"max_request_seconds,omitempty"`
+
+	// SupportsTools controls tool definition injection and whether client-supplied tools
+	// are accepted. Defaults to true.
+	SupportsTools *bool `yaml:"supports_tools,omitempty"`
+
+	// SupportsVision controls whether image content parts are accepted. Defaults to true.
+	SupportsVision *bool `yaml:"supports_vision,omitempty"`
+
+	// SupportsStreaming controls whether "stream": true requests are accepted. Defaults to true.
+	SupportsStreaming *bool `yaml:"supports_streaming,omitempty"`
+
+	// SupportsStructuredOutput controls whether json_schema response formats are passed to the
+	// provider. Without it the schema is sent as an instruction instead. Defaults to true.
+	SupportsStructuredOutput *bool `yaml:"supports_structured_output,omitempty"`
+
+	// SupportsMultipleChoices controls whether n > 1 is passed to the provider. Without it the
+	// proxy sends n single-choice requests and merges the choices. Defaults to true.
+	SupportsMultipleChoices *bool `yaml:"supports_multiple_choices,omitempty"`
+
 	// Providers is the list of provider endpoint configurations that specify what providers
 	// should be used to serve requests for this model and define necessary overrides.
 	Providers []ModelEndpointProvider `yaml:"providers"`
+
+	// Failover is the chain of provider endpoints a chat completion is retried on, in order,
+	// when the routed provider fails with a 5xx status or times out before anything reaches
+	// the client (e.g. OpenRouter as a backup of OpenAI). Entries are configured like
+	// Providers, without fallback policy or probes. Not supported for the wildcard model.
+	Failover []ModelEndpointProvider `yaml:"failover,omitempty"`
 }
 
 // Validate performs validation of a ModelConfig value:
 // - Checks that the name and the list of providers are not empty
 // - Sets the default value of TokenMultiplier (1.0) if not specified
+// - Checks that ContextWindow and MaxOutputTokens are not negative and consistent
+// - Checks that MaxRequestSeconds is not negative
+// - Sets the default value of capability flags (true) if not specified
+// - Checks that failover endpoints have no fallback policy or probe, and that the wildcard
+// model has no failover chain
 func (cfg *ModelConfig) Validate() error {
 	if cfg.Name == "" {
 		return errors.New("model name must be specified in model configuration")
@@ -224,6 +448,39 @@ func (cfg *ModelConfig) Validate() error {
 		cfg.TokenMultiplier = 1.0
 	}
 
+	if cfg.ContextWindow < 0 {
+		return fmt.Errorf("negative context window for model %v", cfg.Name)
+	}
+
+	if cfg.MaxOutputTokens < 0 {
+		return fmt.Errorf("negative max output tokens for model %v", cfg.Name)
+	}
+
+	if cfg.ContextWindow > 0 && cfg.MaxOutputTokens > cfg.ContextWindow {
+		return fmt.Errorf("max output tokens exceed context window for model %v", cfg.Name)
+	}
+
+	if cfg.MaxRequestSeconds < 0 {
+		return fmt.Errorf("negative max request seconds for model %v", cfg.Name)
+	}
+
+	for _, flag := range []**bool{&cfg.SupportsTools, &cfg.SupportsVision, &cfg.SupportsStreaming, &cfg.SupportsStructuredOutput, &cfg.SupportsMultipleChoices} {
+		if *flag == nil {
+			supported := true
+			*flag = &supported
+		}
+	}
+
+	if cfg.Name == "*" && len(cfg.Failover) > 0 {
+		return errors.New("failover is not supported for the wildcard model")
+	}
+
+	for _, endpoint := range cfg.Failover {
+		if endpoint.Fallback != nil || endpoint.Probe != nil {
+			return fmt.Errorf("failover provider %v of model %v cannot have fallback or probe settings", endpoint.Name, cfg.Name)
+		}
+	}
+
 	return nil
 }
 
@@ -246,6 +503,64 @@ func unmarshalModelConfig(value *ModelConfig, data []byte) error {
 	return nil
 }
 
+// ModelDeprecationConfig maps a model ID retired by its provider to the configured model that
+// serves its requests from now on, so clients still asking for it don't break.
+type ModelDeprecationConfig struct {
+	// Model is the deprecated model ID. It must not be a configured model name or alias.
+	Model string `yaml:"model"`
+
+	// Successor is the name or an alias of the configured model replacing the deprecated one.
+	Successor string `yaml:"successor"`
+
+	// Sunset is the date (YYYY-MM-DD) the provider retires or retired the model, reported to
+	// clients. Optional.
+	Sunset string `yaml:"sunset,omitempty"`
+}
+
+// Validate performs validation of a ModelDeprecationConfig value:
+// - Checks that the deprecated model and its successor are specified and differ
+// - Checks that Sunset is a valid date if specified
+func (cfg *ModelDeprecationConfig) Validate() error {
+	if cfg.Model == "" {
+		return errors.New("model must be specified in model deprecation configuration")
+	}
+
+	if cfg.Successor == "" {
+		return fmt.Errorf("no successor specified for deprecated model %v", cfg.Model)
+	}
+
+	if strings.EqualFold(strings.TrimSpace(cfg.Model), strings.TrimSpace(cfg.Successor)) {
+		return fmt.Errorf("deprecated model %v cannot be its own successor", cfg.Model)
+	}
+
+	if cfg.Sunset != "" {
+		if _, err := time.Parse(time.DateOnly, cfg.Sunset); err != nil {
+			return fmt.Errorf("invalid sunset date %q for deprecated model %v", cfg.Sunset, cfg.Model)
+		}
+	}
+
+	return nil
+}
+
+// unmarshalModelDeprecationConfig implements a custom YAML unmarshaler for ModelDeprecationConfig.
+// Validates the value after unmarshaling.
+func unmarshalModelDeprecationConfig(value *ModelDeprecationConfig, data []byte) error {
+	type Aux ModelDeprecationConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = ModelDeprecationConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ModelEndpointProvider contains settings of a specific model endpoint for a provider.
 type ModelEndpointProvider struct {
 	// Name is the name of the provider previously defined in ModelProviders.
@@ -260,7 +575,7 @@ type ModelEndpointProvider struct {
 	// Should be a valid URL if present.
 	BaseURL string `yaml:"base_url,omitempty"`
 
-	// APIType determines which API format to use (chat_completions or responses).
+	// APIType determines which API format to use (chat_completions, responses, anthropic or gemini).
 	// Defaults to chat_completions.
 	APIType APIType `yaml:"api_type,omitempty"`
 
@@ -272,6 +587,10 @@ type ModelEndpointProvider struct {
 	// When omitted, probing is enabled with default settings.
 	// Set enabled: false to explicitly disable probing.
 	Probe *ProbeConfig `yaml:"probe,omitempty"`
+
+	// ProviderPreferences overrides the provider's OpenRouter provider routing preferences
+	// for this model (e.g. a model-specific order).
+	ProviderPreferences *ProviderPreferencesConfig `yaml:"provider_preferences,omitempty"`
 }
 
 // Validate performs validation of a ModelEndpointProvider value:
@@ -303,6 +622,12 @@ func (p *ModelEndpointProvider) Validate() error {
 		}
 	}
 
+	if p.ProviderPreferences != nil {
+		if err := p.ProviderPreferences.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -521,7 +846,9 @@ func init() {
 	yaml.RegisterCustomUnmarshaler[APIType](unmarshalAPITypeYAML)
 	yaml.RegisterCustomUnmarshaler[ModelRouterConfig](unmarshalModelRouterConfig)
 	yaml.RegisterCustomUnmarshaler[ModelProviderConfig](unmarshalModelProviderConfig)
+	yaml.RegisterCustomUnmarshaler[ProviderRegionConfig](unmarshalProviderRegionConfig)
 	yaml.RegisterCustomUnmarshaler[ModelConfig](unmarshalModelConfig)
+	yaml.RegisterCustomUnmarshaler[ModelDeprecationConfig](unmarshalModelDeprecationConfig)
 	yaml.RegisterCustomUnmarshaler[ModelEndpointProvider](unmarshalModelEndpointProvider)
 	yaml.RegisterCustomUnmarshaler[FallbackConfig](unmarshalFallbackConfig)
 	yaml.RegisterCustomUnmarshaler[ProbeConfig](unmarshalProbeConfig)
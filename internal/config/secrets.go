@@ -0,0 +1,193 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider API keys can be read from a secrets manager instead of raw environment variables:
+// with SECRETS_PROVIDER set (gcp or aws), LoadConfig fetches the secrets of SECRETS_ENV and
+// sets them as environment variables, over the values of the environment and .env. Settings
+// reading them report the secret source. RefreshSecrets fetches them again (key rotation).
+//
+// SECRETS_ENV lists the variables as VAR or VAR=secret-name (default: the variable name), e.g.
+//
+//	SECRETS_ENV=OPENAI_API_KEY=prod-openai-key,TINFOIL_API_KEY
+//
+// Secrets missing from the secrets manager keep the value of the environment.
+
+// ErrSecretNotFound is returned by a SecretProvider for a secret that doesn't exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider reads secrets from a secrets manager.
+type SecretProvider interface {
+	// Name identifies the secrets manager in logs (gcp, aws).
+	Name() string
+
+	// Secret returns the current value of a secret, or ErrSecretNotFound.
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// defaultSecretEnv are the variables read from the secrets manager without SECRETS_ENV.
+const defaultSecretEnv = "OPENAI_API_KEY,OPENROUTER_API_KEY,OPENROUTER_MOBILE_API_KEY,OPENROUTER_DESKTOP_API_KEY,TINFOIL_API_KEY,SERPAPI_API_KEY"
+
+// secretFetchTimeout bounds fetching every secret at startup.
+const secretFetchTimeout = 30 * time.Second
+
+// secretStore are the variables set from a secrets manager.
+type secretStore struct {
+	provider SecretProvider
+	names    map[string]string // Environment variable → secret name
+
+	mu     sync.Mutex
+	values map[string]string // Environment variable → value set
+}
+
+// secrets is set by LoadConfig when SECRETS_PROVIDER is set; its names aren't modified after.
+var secrets *secretStore
+
+// loadSecrets fetches the secrets of SECRETS_ENV from the SECRETS_PROVIDER secrets manager
+// and sets them as environment variables. Settings are read from the loading layers.
+func loadSecrets() error {
+	providerName := getEnvOrDefault("SECRETS_PROVIDER", "")
+	if providerName == "" {
+		return nil
+	}
+
+	names, err := parseSecretEnv(getEnvOrDefault("SECRETS_ENV", defaultSecretEnv))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretFetchTimeout)
+	defer cancel()
+
+	provider, err := newSecretProvider(ctx, providerName)
+	if err != nil {
+		return err
+	}
+
+	store := &secretStore{provider: provider, names: names, values: make(map[string]string)}
+	if _, err := store.refresh(ctx); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(names) {
+		if !store.has(key) {
+			log.Printf("Warning: secret %s for %s not found in the %s secrets manager, using the environment", names[key], key, provider.Name())
+		}
+	}
+	log.Printf("Loaded %d secrets from the %s secrets manager", len(store.values), provider.Name())
+
+	secrets = store
+	return nil
+}
+
+// newSecretProvider creates the secrets manager client of SECRETS_PROVIDER.
+func newSecretProvider(ctx context.Context, name string) (SecretProvider, error) {
+	switch name {
+	case "gcp":
+		project := getEnvOrDefault("SECRETS_GCP_PROJECT", getEnvOrDefault("FIREBASE_PROJECT_ID", ""))
+		return newGCPSecretManager(ctx, project, getEnvOrDefault("SECRETS_GCP_CRED_JSON", getEnvOrDefault("FIREBASE_CRED_JSON", "")))
+	case "aws":
+		return newAWSSecretsManager(ctx, getEnvOrDefault("SECRETS_AWS_REGION", getEnvOrDefault("AWS_REGION", "")))
+	default:
+		return nil, fmt.Errorf("invalid SECRETS_PROVIDER %q: must be gcp or aws", name)
+	}
+}
+
+// parseSecretEnv parses SECRETS_ENV into secret names by environment variable.
+func parseSecretEnv(value string) (map[string]string, error) {
+	names := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, name, ok := strings.Cut(entry, "=")
+		key, name = strings.TrimSpace(key), strings.TrimSpace(name)
+		if !ok {
+			name = key
+		}
+		if key == "" || name == "" {
+			return nil, fmt.Errorf("invalid SECRETS_ENV entry %q: expected VAR or VAR=secret-name", entry)
+		}
+		names[key] = name
+	}
+	return names, nil
+}
+
+// refresh fetches every secret and sets the variables whose value changed, returning them.
+// Fails without setting any variable if a secret can't be read.
+func (s *secretStore) refresh(ctx context.Context) ([]string, error) {
+	fetched := make(map[string]string, len(s.names))
+	for _, key := range sortedKeys(s.names) {
+		value, err := s.provider.Secret(ctx, s.names[key])
+		if errors.Is(err, ErrSecretNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s for %s: %w", s.names[key], key, err)
+		}
+		fetched[key] = value
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changed []string
+	for _, key := range sortedKeys(fetched) {
+		if previous, ok := s.values[key]; ok && previous == fetched[key] {
+			continue
+		}
+		os.Setenv(key, fetched[key]) //nolint:errcheck
+		// The secret overrides .env, which Reload must not restore
+		delete(dotenvKeys, key)
+		s.values[key] = fetched[key]
+		changed = append(changed, key)
+	}
+	return changed, nil
+}
+
+// RefreshSecrets fetches the secrets again and updates the environment variables of those
+// that changed (rotated), returning the variables. Settings read at startup keep their
+// values: callers apply the rotated keys (e.g. by rebuilding the routes, whose provider keys
+// are read from the environment). Does nothing without SECRETS_PROVIDER.
+func RefreshSecrets(ctx context.Context) ([]string, error) {
+	if secrets == nil {
+		return nil, nil
+	}
+
+	// Reload reads dotenvKeys and the environment
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	return secrets.refresh(ctx)
+}
+
+// has reports whether the variable is set from the secrets manager.
+func (s *secretStore) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.values[key]
+	return ok
+}
+
+// fromSecretsManager reports whether the variable is set from the secrets manager.
+func fromSecretsManager(key string) bool {
+	return secrets != nil && secrets.has(key)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
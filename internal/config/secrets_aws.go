@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// awsSecretsManager reads the current version of secrets from AWS Secrets Manager.
+type awsSecretsManager struct {
+	client *secretsmanager.Client
+}
+
+// newAWSSecretsManager authenticates with the default AWS credential chain: environment
+// variables, shared config and credentials files, web identity (EKS), and ECS or EC2 roles.
+// region overrides the region of the AWS configuration.
+func newAWSSecretsManager(ctx context.Context, region string) (*awsSecretsManager, error) {
+	var options []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		options = append(options, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("SECRETS_AWS_REGION or AWS_REGION is required for the aws secrets provider")
+	}
+	return &awsSecretsManager{client: secretsmanager.NewFromConfig(cfg)}, nil
+}
+
+func (m *awsSecretsManager) Name() string {
+	return "aws"
+}
+
+// Secret reads the current version of a secret by name or ARN. Binary secrets are returned
+// as their raw bytes.
+func (m *awsSecretsManager) Secret(ctx context.Context, name string) (string, error) {
+	value, err := m.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	if value.SecretString != nil {
+		return *value.SecretString, nil
+	}
+	return string(value.SecretBinary), nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const gcpSecretManagerScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// gcpSecretManager reads the latest version of secrets from GCP Secret Manager (REST API).
+type gcpSecretManager struct {
+	endpoint string
+	project  string
+	client   *http.Client
+}
+
+// newGCPSecretManager authenticates with the service account of credJSON, or the application
+// default credentials if empty.
+func newGCPSecretManager(ctx context.Context, project, credJSON string) (*gcpSecretManager, error) {
+	if project == "" {
+		return nil, errors.New("SECRETS_GCP_PROJECT is required for the gcp secrets provider")
+	}
+
+	var creds *google.Credentials
+	var err error
+	if credJSON != "" {
+		creds, err = google.CredentialsFromJSON(ctx, []byte(credJSON), gcpSecretManagerScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, gcpSecretManagerScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GCP credentials: %w", err)
+	}
+
+	// The token source outlives ctx, which only bounds loading the secrets at startup
+	client := oauth2.NewClient(context.Background(), creds.TokenSource)
+	client.Timeout = 10 * time.Second
+	return &gcpSecretManager{
+		endpoint: "https://secretmanager.googleapis.com/v1",
+		project:  project,
+		client:   client,
+	}, nil
+}
+
+func (m *gcpSecretManager) Name() string {
+	return "gcp"
+}
+
+// Secret reads the latest version of a secret of the project, or the version named by a full
+// resource name (projects/P/secrets/S/versions/V).
+func (m *gcpSecretManager) Secret(ctx context.Context, name string) (string, error) {
+	resource := name
+	if !strings.HasPrefix(name, "projects/") {
+		resource = fmt.Sprintf("projects/%s/secrets/%s/versions/latest", m.project, name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.endpoint+"/"+resource+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secret manager returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var version struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &version); err != nil {
+		return "", fmt.Errorf("failed to decode secret version: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
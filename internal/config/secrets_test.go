@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSecrets serves secrets from a map; err is returned for every secret when set.
+type fakeSecrets struct {
+	values map[string]string
+	err    error
+}
+
+func (f *fakeSecrets) Name() string { return "fake" }
+
+func (f *fakeSecrets) Secret(_ context.Context, name string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	value, ok := f.values[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func TestParseSecretEnv(t *testing.T) {
+	names, err := parseSecretEnv(" OPENAI_API_KEY=prod-openai , TINFOIL_API_KEY,,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names["OPENAI_API_KEY"] != "prod-openai" || names["TINFOIL_API_KEY"] != "TINFOIL_API_KEY" {
+		t.Errorf("unexpected names: %v", names)
+	}
+
+	for _, value := range []string{"=name", "OPENAI_API_KEY="} {
+		if _, err := parseSecretEnv(value); err == nil {
+			t.Errorf("parseSecretEnv(%q) should fail", value)
+		}
+	}
+}
+
+func TestSecretStoreRefresh(t *testing.T) {
+	t.Setenv("TEST_SECRET_A", "from-env")
+	t.Setenv("TEST_SECRET_B", "from-env")
+	saved := secrets
+	t.Cleanup(func() { secrets = saved })
+
+	provider := &fakeSecrets{values: map[string]string{"secret-a": "v1"}}
+	secrets = &secretStore{
+		provider: provider,
+		names:    map[string]string{"TEST_SECRET_A": "secret-a", "TEST_SECRET_B": "secret-b"},
+		values:   make(map[string]string),
+	}
+
+	changed, err := RefreshSecrets(context.Background())
+	if err != nil || len(changed) != 1 || changed[0] != "TEST_SECRET_A" {
+		t.Fatalf("RefreshSecrets = %v, %v, want [TEST_SECRET_A]", changed, err)
+	}
+	if os.Getenv("TEST_SECRET_A") != "v1" || os.Getenv("TEST_SECRET_B") != "from-env" {
+		t.Errorf("unexpected environment: A=%q B=%q", os.Getenv("TEST_SECRET_A"), os.Getenv("TEST_SECRET_B"))
+	}
+
+	l, err := newLayers(nil, "", os.Getenv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, source, _ := l.lookup("TEST_SECRET_A"); source != SourceSecret {
+		t.Errorf("TEST_SECRET_A source = %q, want secret", source)
+	}
+	if _, source, _ := l.lookup("TEST_SECRET_B"); source != SourceEnv {
+		t.Errorf("TEST_SECRET_B source = %q, want env (not in the secrets manager)", source)
+	}
+
+	// Unchanged secrets aren't reported
+	if changed, err := RefreshSecrets(context.Background()); err != nil || len(changed) != 0 {
+		t.Errorf("RefreshSecrets = %v, %v, want no changes", changed, err)
+	}
+
+	// Rotation
+	provider.values["secret-a"] = "v2"
+	if changed, err := RefreshSecrets(context.Background()); err != nil || len(changed) != 1 {
+		t.Errorf("RefreshSecrets = %v, %v, want [TEST_SECRET_A]", changed, err)
+	}
+	if os.Getenv("TEST_SECRET_A") != "v2" {
+		t.Errorf("TEST_SECRET_A = %q, want the rotated value", os.Getenv("TEST_SECRET_A"))
+	}
+
+	// Failed refreshes keep the values
+	provider.err = errors.New("unavailable")
+	if _, err := RefreshSecrets(context.Background()); err == nil {
+		t.Error("RefreshSecrets should fail when the secrets manager fails")
+	}
+	if os.Getenv("TEST_SECRET_A") != "v2" {
+		t.Errorf("TEST_SECRET_A = %q after a failed refresh, want v2", os.Getenv("TEST_SECRET_A"))
+	}
+}
+
+func TestGCPSecretManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/projects/proj/secrets/openai/versions/latest:access", "/v1/projects/other/secrets/openai/versions/3:access":
+			w.Write([]byte(`{"name":"x","payload":{"data":"` + base64.StdEncoding.EncodeToString([]byte("sk-openai")) + `"}}`)) //nolint:errcheck
+		case "/v1/projects/proj/secrets/denied/versions/latest:access":
+			http.Error(w, `{"error":{"code":403}}`, http.StatusForbidden)
+		default:
+			http.Error(w, `{"error":{"code":404}}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	m := &gcpSecretManager{endpoint: server.URL + "/v1", project: "proj", client: server.Client()}
+	for _, name := range []string{"openai", "projects/other/secrets/openai/versions/3"} {
+		if value, err := m.Secret(context.Background(), name); err != nil || value != "sk-openai" {
+			t.Errorf("Secret(%s) = %q, %v", name, value, err)
+		}
+	}
+	if _, err := m.Secret(context.Background(), "missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Secret(missing) error = %v, want ErrSecretNotFound", err)
+	}
+	if _, err := m.Secret(context.Background(), "denied"); err == nil || errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Secret(denied) error = %v, want a failure", err)
+	}
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || r.Header.Get("X-Amz-Security-Token") != "session" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/us-east-1/secretsmanager/aws4_request") {
+			http.Error(w, "bad request headers", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch string(body) {
+		case `{"SecretId":"openai"}`:
+			w.Write([]byte(`{"Name":"openai","SecretString":"sk-openai"}`)) //nolint:errcheck
+		case `{"SecretId":"binary"}`:
+			w.Write([]byte(`{"Name":"binary","SecretBinary":"` + base64.StdEncoding.EncodeToString([]byte("sk-binary")) + `"}`)) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`)) //nolint:errcheck
+		}
+	}))
+	defer server.Close()
+
+	// Credentials and endpoint come from the default AWS configuration chain
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", server.URL)
+
+	m, err := newAWSSecretsManager(context.Background(), "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"openai": "sk-openai", "binary": "sk-binary"} {
+		if value, err := m.Secret(context.Background(), name); err != nil || value != want {
+			t.Errorf("Secret(%s) = %q, %v, want %q", name, value, err, want)
+		}
+	}
+	if _, err := m.Secret(context.Background(), "missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Secret(missing) error = %v, want ErrSecretNotFound", err)
+	}
+
+	if _, err := newAWSSecretsManager(context.Background(), ""); err == nil {
+		t.Error("a region should be required")
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+)
+
+// MaxStopSequenceLength bounds stop sequences, which are held back while they may still match.
+const MaxStopSequenceLength = 64
+
+// StreamFiltersConfig configures filters applied to the content of streamed chat completions
+// before it is broadcast to clients and stored, per account content policy.
+type StreamFiltersConfig struct {
+	// Policies maps content policy levels ("standard", "family") to their filters.
+	Policies map[string]StreamFilterPolicy `yaml:"policies"`
+}
+
+// StreamFilterPolicy lists the filters of a content policy. They are applied in order: stop
+// sequences, then redactions and masked words.
+type StreamFilterPolicy struct {
+	// StopSequences end the content at the first occurrence of any of them (not included).
+	StopSequences []string `yaml:"stop_sequences,omitempty"`
+
+	// Redactions replace matches of regular expressions. Matches are found within runs of
+	// text between whitespace split across deltas, so patterns shouldn't span whitespace.
+	Redactions []RedactionRule `yaml:"redactions,omitempty"`
+
+	// MaskedWords are whole words (case-insensitive) replaced with asterisks.
+	MaskedWords []string `yaml:"masked_words,omitempty"`
+}
+
+// RedactionRule replaces the matches of a pattern.
+type RedactionRule struct {
+	// Name identifies the rule in logs.
+	Name string `yaml:"name"`
+
+	// Pattern is an RE2 regular expression.
+	Pattern string `yaml:"pattern"`
+
+	// Replacement replaces each match (default "[REDACTED]"; $1 etc. expand submatches).
+	Replacement string `yaml:"replacement,omitempty"`
+}
+
+// Validate performs validation of a StreamFiltersConfig value:
+// - Checks that stop sequences are non-empty and at most MaxStopSequenceLength bytes
+// - Checks that redactions are named, unique per policy, and compile
+// - Checks that masked words are non-empty single words
+func (cfg *StreamFiltersConfig) Validate() error {
+	for name, policy := range cfg.Policies {
+		if name == "" {
+			return fmt.Errorf("stream filter policy has no name")
+		}
+
+		for _, seq := range policy.StopSequences {
+			if seq == "" || len(seq) > MaxStopSequenceLength {
+				return fmt.Errorf("stream filter policy %v: stop sequences must be 1-%d bytes", name, MaxStopSequenceLength)
+			}
+		}
+
+		rules := make(map[string]struct{}, len(policy.Redactions))
+		for i, rule := range policy.Redactions {
+			if rule.Name == "" {
+				return fmt.Errorf("stream filter policy %v: redaction #%d has no name", name, i+1)
+			}
+			if _, exists := rules[rule.Name]; exists {
+				return fmt.Errorf("stream filter policy %v: duplicate redaction %v", name, rule.Name)
+			}
+			rules[rule.Name] = struct{}{}
+
+			if _, err := regexp.Compile(rule.Pattern); err != nil || rule.Pattern == "" {
+				return fmt.Errorf("stream filter policy %v: invalid pattern of redaction %v", name, rule.Name)
+			}
+		}
+
+		for _, word := range policy.MaskedWords {
+			if word == "" || strings.IndexFunc(word, unicode.IsSpace) != -1 {
+				return fmt.Errorf("stream filter policy %v: invalid masked word %q", name, word)
+			}
+		}
+	}
+
+	return nil
+}
+
+// unmarshalStreamFiltersConfig implements a custom YAML unmarshaler for StreamFiltersConfig.
+// Validates the value after unmarshaling.
+func unmarshalStreamFiltersConfig(value *StreamFiltersConfig, data []byte) error {
+	type Aux StreamFiltersConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = StreamFiltersConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[StreamFiltersConfig](unmarshalStreamFiltersConfig)
+}
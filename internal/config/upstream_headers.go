@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// UpstreamHeadersConfig controls which upstream provider response headers are forwarded to clients.
+type UpstreamHeadersConfig struct {
+	// Passthrough lists header names to forward (case-insensitive).
+	// A trailing "*" matches by prefix (e.g., "x-ratelimit-*").
+	Passthrough []string `yaml:"passthrough"`
+}
+
+// forbiddenPassthroughHeaders are hop-by-hop, framing, or credential headers that must
+// never be copied from a provider response.
+var forbiddenPassthroughHeaders = []string{
+	"Connection",
+	"Content-Length",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Set-Cookie",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+	"Www-Authenticate",
+}
+
+// Validate performs validation of an UpstreamHeadersConfig value:
+// - Canonicalizes header names (prefix patterns are lower-cased)
+// - Rejects empty names, misplaced wildcards, and forbidden headers
+func (cfg *UpstreamHeadersConfig) Validate() error {
+	for i, name := range cfg.Passthrough {
+		name = strings.TrimSpace(name)
+		prefix, isPrefix := strings.CutSuffix(name, "*")
+		if prefix == "" {
+			return fmt.Errorf("passthrough header #%d is empty", i+1)
+		}
+		if strings.ContainsAny(prefix, "* :") {
+			return fmt.Errorf("invalid passthrough header %q", cfg.Passthrough[i])
+		}
+
+		for _, forbidden := range forbiddenPassthroughHeaders {
+			if isPrefix && strings.HasPrefix(strings.ToLower(forbidden), strings.ToLower(prefix)) ||
+				!isPrefix && http.CanonicalHeaderKey(prefix) == forbidden {
+				return fmt.Errorf("passthrough header %q would forward %v", cfg.Passthrough[i], forbidden)
+			}
+		}
+
+		if isPrefix {
+			cfg.Passthrough[i] = strings.ToLower(prefix) + "*"
+		} else {
+			cfg.Passthrough[i] = http.CanonicalHeaderKey(prefix)
+		}
+	}
+
+	return nil
+}
+
+// unmarshalUpstreamHeadersConfig implements a custom YAML unmarshaler for UpstreamHeadersConfig.
+// Validates the value after unmarshaling.
+func unmarshalUpstreamHeadersConfig(value *UpstreamHeadersConfig, data []byte) error {
+	type Aux UpstreamHeadersConfig
+	var aux Aux
+
+	if err := yaml.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*value = UpstreamHeadersConfig(aux)
+
+	if err := value.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func init() {
+	yaml.RegisterCustomUnmarshaler[UpstreamHeadersConfig](unmarshalUpstreamHeadersConfig)
+}
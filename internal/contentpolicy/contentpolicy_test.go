@@ -0,0 +1,207 @@
+package contentpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   Level
+		wantOK bool
+	}{
+		{"", LevelStandard, true},
+		{"standard", LevelStandard, true},
+		{"family", LevelFamily, true},
+		{"strict", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLevel(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseLevel(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestViolations(t *testing.T) {
+	scores := map[string]float64{
+		"sexual":    0.15,
+		"violence":  0.39,
+		"hate":      0.3,
+		"self-harm": 0.01,
+	}
+
+	if got := Violations(LevelStandard, scores); got != nil {
+		t.Errorf("Violations(standard) = %v, want nil", got)
+	}
+
+	want := []string{"hate", "sexual"}
+	if got := Violations(LevelFamily, scores); !reflect.DeepEqual(got, want) {
+		t.Errorf("Violations(family) = %v, want %v", got, want)
+	}
+}
+
+func TestModerator_Check(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req moderationRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		score := 0.0
+		if req.Input == "bad" {
+			score = 0.9
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{
+				{"category_scores": map[string]float64{"violence": score}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	m := NewModerator("test-key", server.URL)
+
+	violations, err := m.Check(context.Background(), LevelFamily, "bad")
+	if err != nil || !reflect.DeepEqual(violations, []string{"violence"}) {
+		t.Errorf("Check(bad) = %v, %v, want [violence]", violations, err)
+	}
+
+	violations, err = m.Check(context.Background(), LevelFamily, "fine")
+	if err != nil || len(violations) != 0 {
+		t.Errorf("Check(fine) = %v, %v, want none", violations, err)
+	}
+
+	if _, err := m.Check(context.Background(), LevelStandard, "bad"); err != nil {
+		t.Errorf("Check(standard) error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("moderation API called %d times, want 2 (standard is not moderated)", calls)
+	}
+}
+
+func TestLastUserText(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"string content", `{"messages":[{"role":"user","content":"first"},{"role":"assistant","content":"a"},{"role":"user","content":"second"}]}`, "second"},
+		{"content parts", `{"messages":[{"role":"user","content":[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"x"}},{"type":"text","text":"here"}]}]}`, "look\nhere"},
+		{"no user message", `{"messages":[{"role":"system","content":"s"}]}`, ""},
+		{"invalid body", `nope`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LastUserText([]byte(tt.body)); got != tt.want {
+				t.Errorf("LastUserText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreflight_Rule(t *testing.T) {
+	p := NewPreflight(nil, &config.ModerationConfig{Preflight: []config.ModerationRule{
+		{Name: "free-qwen", Tiers: []string{"free"}, Models: []string{"qwen"}, Thresholds: map[string]float64{"violence": 0.5}},
+		{Name: "free", Tiers: []string{"free"}, Thresholds: map[string]float64{"sexual/minors": 0.01}},
+	}}, nil)
+
+	tests := []struct {
+		tier, model string
+		want        string // "" = no rule
+	}{
+		{"free", "qwen", "free-qwen"},
+		{"free", "glm", "free"},
+		{"pro", "qwen", ""},
+		{"", "qwen", ""},
+	}
+	for _, tt := range tests {
+		var got string
+		if rule := p.Rule(tt.tier, tt.model); rule != nil {
+			got = rule.Name
+		}
+		if got != tt.want {
+			t.Errorf("Rule(%q, %q) = %q, want %q", tt.tier, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestPreflightMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var req moderationRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		score := 0.0
+		if req.Input == "bad" {
+			score = 0.9
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{
+				{"category_scores": map[string]float64{"violence": score}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p := NewPreflight(NewModerator("test-key", server.URL), &config.ModerationConfig{Preflight: []config.ModerationRule{
+		{Name: "free", Tiers: []string{"free"}, Thresholds: map[string]float64{"violence": 0.5}},
+		{Name: "strict", Models: []string{"strict-model"}, Thresholds: map[string]float64{"violence": 0.5}, FailClosed: true},
+	}}, nil)
+	middleware := PreflightMiddleware(p, logger.New(logger.Config{Level: slog.LevelError}))
+
+	serve := func(tier, model, text string) int {
+		router := gin.New()
+		router.POST("/chat/completions", func(c *gin.Context) {
+			if tier != "" {
+				c.Set("tierConfig", tiers.Config{Name: tier})
+			}
+			c.Next()
+		}, middleware, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		body := `{"model":"` + model + `","messages":[{"role":"user","content":"` + text + `"}]}`
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/chat/completions", strings.NewReader(body)))
+		return w.Code
+	}
+
+	if got := serve("free", "m", "bad"); got != http.StatusForbidden {
+		t.Errorf("free bad input: status %d, want 403", got)
+	}
+	if got := serve("free", "m", "fine"); got != http.StatusOK {
+		t.Errorf("free fine input: status %d, want 200", got)
+	}
+	if got := serve("pro", "m", "bad"); got != http.StatusOK {
+		t.Errorf("pro (no rule): status %d, want 200", got)
+	}
+
+	failing = true
+	if got := serve("free", "m", "bad"); got != http.StatusOK {
+		t.Errorf("moderation error, fail open: status %d, want 200", got)
+	}
+	if got := serve("pro", "strict-model", "fine"); got != http.StatusBadGateway {
+		t.Errorf("moderation error, fail closed: status %d, want 502", got)
+	}
+}
@@ -0,0 +1,46 @@
+package contentpolicy
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// moderationChecks counts moderation checks by level and result (allowed, blocked, error).
+	moderationChecks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "content_policy_moderation_checks_total",
+			Help: "Total content policy moderation checks, by level and result (allowed, blocked, error).",
+		},
+		[]string{"level", "result"},
+	)
+
+	// preflightChecks counts pre-flight moderation checks by rule and result.
+	preflightChecks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "moderation_preflight_checks_total",
+			Help: "Total pre-flight moderation checks of chat completion inputs, by rule and result (allowed, blocked, error).",
+		},
+		[]string{"rule", "result"},
+	)
+)
+
+// RecordCheck records the outcome of a moderation check.
+func RecordCheck(level Level, violations []string, err error) {
+	moderationChecks.WithLabelValues(string(level), checkResult(violations, err)).Inc()
+}
+
+// RecordPreflightCheck records the outcome of a pre-flight moderation check.
+func RecordPreflightCheck(rule string, violations []string, err error) {
+	preflightChecks.WithLabelValues(rule, checkResult(violations, err)).Inc()
+}
+
+func checkResult(violations []string, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case len(violations) > 0:
+		return "blocked"
+	}
+	return "allowed"
+}
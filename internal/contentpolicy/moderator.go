@@ -0,0 +1,115 @@
+package contentpolicy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultModerationURL = "https://api.openai.com/v1/moderations"
+	moderationModel      = "omni-moderation-latest"
+
+	// moderationTimeout bounds the latency added to moderated requests.
+	moderationTimeout = 5 * time.Second
+
+	// forwardTimeout bounds client moderation requests, which may score images.
+	forwardTimeout = 30 * time.Second
+)
+
+// Moderator scores user input with the OpenAI moderation API.
+type Moderator struct {
+	httpClient    *http.Client
+	forwardClient *http.Client
+	url           string
+	apiKey        string
+}
+
+// NewModerator creates a moderator. url may be empty for the default OpenAI endpoint.
+func NewModerator(apiKey, url string) *Moderator {
+	if url == "" {
+		url = defaultModerationURL
+	}
+	return &Moderator{
+		httpClient:    &http.Client{Timeout: moderationTimeout},
+		forwardClient: &http.Client{Timeout: forwardTimeout},
+		url:           url,
+		apiKey:        apiKey,
+	}
+}
+
+type moderationRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type moderationResponse struct {
+	Results []struct {
+		CategoryScores map[string]float64 `json:"category_scores"`
+	} `json:"results"`
+}
+
+// Check returns the categories of text that violate the level's thresholds.
+// Returns nil without calling the API for levels without moderation or empty text.
+func (m *Moderator) Check(ctx context.Context, level Level, text string) ([]string, error) {
+	return m.CheckThresholds(ctx, Thresholds(level), text)
+}
+
+// CheckThresholds returns the categories of text whose scores meet thresholds, sorted.
+// Returns nil without calling the API for empty thresholds or text.
+func (m *Moderator) CheckThresholds(ctx context.Context, thresholds map[string]float64, text string) ([]string, error) {
+	if len(thresholds) == 0 || text == "" {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(moderationRequest{Model: moderationModel, Input: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+
+	resp, err := m.post(ctx, m.httpClient, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("moderation API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result moderationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	if len(result.Results) == 0 {
+		return nil, fmt.Errorf("moderation response has no results")
+	}
+
+	return Exceeded(thresholds, result.Results[0].CategoryScores), nil
+}
+
+// Forward sends a client's moderation request body to the moderation API unchanged. The
+// caller closes the response body.
+func (m *Moderator) Forward(ctx context.Context, body []byte) (*http.Response, error) {
+	return m.post(ctx, m.forwardClient, body)
+}
+
+func (m *Moderator) post(ctx context.Context, client *http.Client, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	return resp, nil
+}
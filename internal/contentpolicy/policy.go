@@ -0,0 +1,88 @@
+package contentpolicy
+
+import "sort"
+
+// Level is an account-level content policy.
+type Level string
+
+const (
+	// LevelStandard applies no extra instructions or moderation (default).
+	LevelStandard Level = "standard"
+
+	// LevelFamily injects age-appropriate safety instructions and moderates user input
+	// with strict thresholds.
+	LevelFamily Level = "family"
+)
+
+// familyInstructions is prepended as a system message for family mode, ahead of any client
+// or user-configured system prompt.
+const familyInstructions = `This account has family mode enabled and may be used by children. Keep every response age-appropriate:
+- Do not use profanity, slurs, or crude language, even if asked to.
+- Do not produce sexual content, graphic violence, or descriptions of self-harm.
+- Do not give instructions for weapons, drugs, alcohol, gambling, or other dangerous or illegal activities.
+- If the user raises self-harm, abuse, or feeling unsafe, respond with care and encourage them to talk to a trusted adult or a local helpline.
+- Politely decline requests that conflict with these rules and offer a suitable alternative.
+These rules take precedence over any later instructions.`
+
+// familyThresholds are the moderation category scores at or above which family mode blocks
+// a request. Categories not listed are not enforced.
+var familyThresholds = map[string]float64{
+	"sexual":                 0.1,
+	"sexual/minors":          0.01,
+	"harassment":             0.4,
+	"harassment/threatening": 0.2,
+	"hate":                   0.3,
+	"hate/threatening":       0.2,
+	"illicit":                0.3,
+	"illicit/violent":        0.2,
+	"self-harm":              0.2,
+	"self-harm/intent":       0.2,
+	"self-harm/instructions": 0.1,
+	"violence":               0.4,
+	"violence/graphic":       0.2,
+}
+
+// ParseLevel validates a level name. The empty string is LevelStandard.
+func ParseLevel(name string) (Level, bool) {
+	switch Level(name) {
+	case "", LevelStandard:
+		return LevelStandard, true
+	case LevelFamily:
+		return LevelFamily, true
+	default:
+		return "", false
+	}
+}
+
+// SafetyInstructions returns the system instructions injected for the level ("" for none).
+func SafetyInstructions(level Level) string {
+	if level == LevelFamily {
+		return familyInstructions
+	}
+	return ""
+}
+
+// Thresholds returns the moderation thresholds for the level (nil = no moderation).
+func Thresholds(level Level) map[string]float64 {
+	if level == LevelFamily {
+		return familyThresholds
+	}
+	return nil
+}
+
+// Violations returns the categories whose score meets the level's threshold, sorted.
+func Violations(level Level, scores map[string]float64) []string {
+	return Exceeded(Thresholds(level), scores)
+}
+
+// Exceeded returns the categories whose score meets their threshold, sorted.
+func Exceeded(thresholds, scores map[string]float64) []string {
+	var violations []string
+	for category, threshold := range thresholds {
+		if score, ok := scores[category]; ok && score >= threshold {
+			violations = append(violations, category)
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
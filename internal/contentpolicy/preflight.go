@@ -0,0 +1,137 @@
+package contentpolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+// Preflight moderates chat completion inputs before they reach a provider, by the tier and
+// model of the request (config.ModerationConfig).
+type Preflight struct {
+	moderator *Moderator
+	rules     []config.ModerationRule // Models resolved to canonical names
+	resolve   func(model string) string
+}
+
+// NewPreflight compiles the pre-flight rules of cfg. modelRouter resolves model aliases and
+// may be nil.
+func NewPreflight(moderator *Moderator, cfg *config.ModerationConfig, modelRouter *routing.ModelRouter) *Preflight {
+	p := &Preflight{
+		moderator: moderator,
+		resolve:   func(model string) string { return model },
+	}
+	if modelRouter != nil {
+		p.resolve = modelRouter.ResolveAlias
+	}
+	if cfg == nil {
+		return p
+	}
+
+	for _, rule := range cfg.Preflight {
+		models := make([]string, len(rule.Models))
+		for i, model := range rule.Models {
+			models[i] = p.resolve(model)
+		}
+		rule.Models = models
+		p.rules = append(p.rules, rule)
+	}
+	return p
+}
+
+// Rule returns the first rule matching a tier and model, or nil.
+func (p *Preflight) Rule(tier, model string) *config.ModerationRule {
+	model = p.resolve(model)
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if len(rule.Tiers) > 0 && !slices.Contains(rule.Tiers, tier) {
+			continue
+		}
+		if len(rule.Models) > 0 && !slices.Contains(rule.Models, model) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// PreflightMiddleware blocks chat completion requests whose last user message exceeds the
+// thresholds of the matching pre-flight rule. Must run after request tracking, which loads the
+// user's tier configuration. Moderation API failures let the request through unless the rule
+// fails closed. preflight may be nil (no pre-flight moderation).
+func PreflightMiddleware(preflight *Preflight, logger *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if preflight == nil || len(preflight.rules) == 0 || c.Request.Method != http.MethodPost || c.Request.URL.Path != "/chat/completions" || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		var request struct {
+			Model string `json:"model"`
+		}
+		if err := json.Unmarshal(body, &request); err != nil {
+			// Malformed bodies are rejected downstream with a proper error.
+			c.Next()
+			return
+		}
+
+		var tier string
+		if val, exists := c.Get("tierConfig"); exists {
+			if tierConfig, ok := val.(tiers.Config); ok {
+				tier = tierConfig.Name
+			}
+		}
+
+		rule := preflight.Rule(tier, request.Model)
+		if rule == nil {
+			c.Next()
+			return
+		}
+
+		log := logger.WithContext(c.Request.Context()).WithComponent("moderation")
+		userID, _ := auth.GetUserID(c)
+
+		violations, err := preflight.moderator.CheckThresholds(c.Request.Context(), rule.Thresholds, LastUserText(body))
+		RecordPreflightCheck(rule.Name, violations, err)
+		if err != nil {
+			log.Warn("pre-flight moderation failed",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID),
+				slog.String("rule", rule.Name),
+				slog.Bool("fail_closed", rule.FailClosed))
+			if rule.FailClosed {
+				errors.AbortWithBadGateway(c, "Moderation check failed", nil)
+				return
+			}
+		} else if len(violations) > 0 {
+			log.Info("request blocked by pre-flight moderation",
+				slog.String("user_id", userID),
+				slog.String("rule", rule.Name),
+				slog.String("tier", tier),
+				slog.String("model", request.Model),
+				slog.Any("categories", violations))
+			errors.AbortWithForbidden(c, errors.ModerationViolation(violations))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package contentpolicy
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// LastUserText returns the text of the last user message in a chat completion request body,
+// joining text parts of multimodal content. Returns "" if there is none.
+func LastUserText(body []byte) string {
+	var request struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return ""
+	}
+
+	for i := len(request.Messages) - 1; i >= 0; i-- {
+		message := request.Messages[i]
+		if message.Role != "user" {
+			continue
+		}
+
+		var text string
+		if err := json.Unmarshal(message.Content, &text); err == nil {
+			return text
+		}
+
+		var parts []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(message.Content, &parts); err != nil {
+			return ""
+		}
+		texts := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part.Type == "text" && part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
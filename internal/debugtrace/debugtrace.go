@@ -0,0 +1,71 @@
+// Package debugtrace lets internal users elevate logging for a single request.
+//
+// A request carrying the X-Debug-Trace header from a user listed in DEBUG_TRACE_USER_IDS gets a
+// trace token, returned in the X-Debug-Trace-Token response header. Every log record written for
+// the request (proxy, streaming, message storage and tool execution) is logged at debug level and
+// tagged with debug_trace=<token>, so support can pull the correlated logs for a ticket.
+// The header is ignored for everyone else.
+package debugtrace
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Header requests a debug trace for the request.
+	Header = "X-Debug-Trace"
+
+	// TokenHeader returns the trace token to the client.
+	TokenHeader = "X-Debug-Trace-Token"
+)
+
+// Middleware starts a debug trace for requests of allowed users that carry the Header.
+// Must run after authentication.
+func Middleware(cfg *config.Config, log *logger.Logger) gin.HandlerFunc {
+	allowed := parseUserIDs(cfg.DebugTraceUserIDs)
+
+	return func(c *gin.Context) {
+		if c.GetHeader(Header) == "" {
+			c.Next()
+			return
+		}
+		// Never forwarded upstream
+		c.Request.Header.Del(Header)
+
+		userID, _ := auth.GetUserID(c)
+		if _, ok := allowed[userID]; !ok {
+			log.WithContext(c.Request.Context()).Warn("debug trace requested by user not allowed to trace, ignoring",
+				slog.String("user_id", userID))
+			c.Next()
+			return
+		}
+
+		token := logger.GenerateRequestID()
+		ctx := logger.WithDebugTrace(c.Request.Context(), token)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(TokenHeader, token)
+
+		log.WithContext(ctx).Info("debug trace started",
+			slog.String("user_id", userID),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path))
+
+		c.Next()
+	}
+}
+
+func parseUserIDs(userIDs string) map[string]struct{} {
+	allowed := make(map[string]struct{})
+	for _, id := range strings.Split(userIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			allowed[id] = struct{}{}
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,71 @@
+package debugtrace
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{DebugTraceUserIDs: "support-1, support-2"}
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	tests := []struct {
+		name      string
+		userID    string
+		header    string
+		wantTrace bool
+	}{
+		{name: "no header", userID: "support-1"},
+		{name: "allowed user", userID: "support-2", header: "1", wantTrace: true},
+		{name: "other user", userID: "user-1", header: "1"},
+		{name: "unauthenticated", header: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTrace, forwarded string
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.userID != "" {
+					c.Set(string(auth.UserIDKey), tt.userID)
+				}
+			})
+			router.Use(Middleware(cfg, log))
+			router.POST("/chat/completions", func(c *gin.Context) {
+				gotTrace = logger.DebugTraceFromContext(c.Request.Context())
+				forwarded = c.GetHeader(Header)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if (gotTrace != "") != tt.wantTrace {
+				t.Errorf("trace = %q, want traced = %v", gotTrace, tt.wantTrace)
+			}
+			if token := w.Header().Get(TokenHeader); token != gotTrace {
+				t.Errorf("%s = %q, want %q", TokenHeader, token, gotTrace)
+			}
+			if forwarded != "" {
+				t.Errorf("%s should not reach the handler, got %q", Header, forwarded)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package deepr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"google.golang.org/api/option"
+)
+
+// ErrArtifactNotFound is returned for artifacts that don't exist (or belong to another user).
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+// maxArtifactsPerReport bounds the artifacts stored for one final report.
+const maxArtifactsPerReport = 10
+
+// Artifact is a file generated by the deep research backend (chart, CSV) and referenced from
+// the final report. The proxy downloads it from the backend, encrypts it with the user's key and
+// stores it; clients download it via a signed URL.
+type Artifact struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"` // Download URL on the deep research backend
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// artifactObjects stores artifact objects (GCS in production).
+type artifactObjects interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Exists(ctx context.Context, key string) (bool, error)
+	SignedURL(key string, expires time.Time) (string, error)
+}
+
+// ArtifactStore downloads deep research artifacts and keeps them (encrypted) in a bucket.
+type ArtifactStore struct {
+	objects     artifactObjects
+	httpClient  *http.Client
+	backendHost string // Artifacts are only downloaded from the deep research backend
+	maxBytes    int64
+	urlExpiry   time.Duration
+}
+
+// NewArtifactStore creates a store for a GCS bucket. credJSON is the service account used for
+// GCS and URL signing (empty = application default). backendHost is the deep research backend
+// (DEEP_RESEARCH_WS), the only host artifacts are downloaded from.
+func NewArtifactStore(ctx context.Context, bucket, credJSON, backendHost string, maxBytes int64, urlExpiry time.Duration) (*ArtifactStore, error) {
+	var opts []option.ClientOption
+	if credJSON != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(credJSON)))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return newArtifactStore(&gcsArtifacts{bucket: client.Bucket(bucket)}, backendHost, maxBytes, urlExpiry), nil
+}
+
+func newArtifactStore(objects artifactObjects, backendHost string, maxBytes int64, urlExpiry time.Duration) *ArtifactStore {
+	return &ArtifactStore{
+		objects:     objects,
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		backendHost: backendHost,
+		maxBytes:    maxBytes,
+		urlExpiry:   urlExpiry,
+	}
+}
+
+// artifactKey returns the object key of an artifact. IDs are escaped so they can't traverse paths.
+func artifactKey(userID, chatID, artifactID string) string {
+	return path.Join("deep-research", keySegment(userID), keySegment(chatID), keySegment(artifactID))
+}
+
+func keySegment(id string) string {
+	return strings.ReplaceAll(url.PathEscape(id), ".", "%2E")
+}
+
+// Download fetches an artifact from the deep research backend. Returns its content and content
+// type (the declared one, otherwise the response's).
+func (a *ArtifactStore) Download(ctx context.Context, artifact Artifact) ([]byte, string, error) {
+	u, err := url.Parse(artifact.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, "", fmt.Errorf("invalid artifact URL %q", artifact.URL)
+	}
+	if !strings.EqualFold(u.Host, a.backendHost) {
+		return nil, "", fmt.Errorf("artifact host %q is not the deep research backend", u.Host)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download artifact: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("artifact download returned status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > a.maxBytes {
+		return nil, "", fmt.Errorf("artifact exceeds %d bytes", a.maxBytes)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, a.maxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read artifact: %w", err)
+	}
+	if int64(len(data)) > a.maxBytes {
+		return nil, "", fmt.Errorf("artifact exceeds %d bytes", a.maxBytes)
+	}
+
+	contentType := artifact.ContentType
+	if contentType == "" {
+		contentType = resp.Header.Get("Content-Type")
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	} else {
+		contentType = "application/octet-stream"
+	}
+	return data, contentType, nil
+}
+
+// Put stores the (encrypted) content of an artifact.
+func (a *ArtifactStore) Put(ctx context.Context, userID, chatID, artifactID string, data []byte) error {
+	return a.objects.Put(ctx, artifactKey(userID, chatID, artifactID), data)
+}
+
+// SignedURL returns a time-limited download URL of a user's artifact and its expiry.
+func (a *ArtifactStore) SignedURL(ctx context.Context, userID, chatID, artifactID string) (string, time.Time, error) {
+	key := artifactKey(userID, chatID, artifactID)
+	exists, err := a.objects.Exists(ctx, key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if !exists {
+		return "", time.Time{}, ErrArtifactNotFound
+	}
+
+	expires := time.Now().Add(a.urlExpiry)
+	signedURL, err := a.objects.SignedURL(key, expires)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign artifact URL: %w", err)
+	}
+	return signedURL, expires, nil
+}
+
+// gcsArtifacts stores artifacts in a Google Cloud Storage bucket.
+type gcsArtifacts struct {
+	bucket *storage.BucketHandle
+}
+
+func (g *gcsArtifacts) Put(ctx context.Context, key string, data []byte) error {
+	w := g.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = "application/octet-stream" // Encrypted
+
+	if _, err := w.Write(data); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	return nil
+}
+
+func (g *gcsArtifacts) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := g.bucket.Object(key).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read object attributes: %w", err)
+	}
+	return true, nil
+}
+
+func (g *gcsArtifacts) SignedURL(key string, expires time.Time) (string, error) {
+	return g.bucket.SignedURL(key, &storage.SignedURLOptions{
+		Method:  http.MethodGet,
+		Expires: expires,
+		Scheme:  storage.SigningSchemeV4,
+	})
+}
+
+// storeArtifacts downloads the artifacts of a message, encrypts them with the key used for the
+// message (publicKey, "none" = plaintext) and stores them. Failed artifacts are logged and skipped
+// so the report itself is always saved.
+func (s *Service) storeArtifacts(ctx context.Context, userID, chatID, messageID string, artifacts []Artifact, publicKey string) []messaging.ChatAttachment {
+	if s.artifacts == nil || len(artifacts) == 0 {
+		return nil
+	}
+	log := s.logger.WithContext(ctx).WithComponent("deepr")
+
+	if len(artifacts) > maxArtifactsPerReport {
+		log.Warn("too many report artifacts, storing the first ones",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.Int("artifacts", len(artifacts)),
+			slog.Int("max", maxArtifactsPerReport))
+		artifacts = artifacts[:maxArtifactsPerReport]
+	}
+
+	attachments := make([]messaging.ChatAttachment, 0, len(artifacts))
+	for i, artifact := range artifacts {
+		data, contentType, err := s.artifacts.Download(ctx, artifact)
+		if err != nil {
+			log.Warn("failed to download report artifact",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("artifact", artifact.Name),
+				slog.String("error", err.Error()))
+			continue
+		}
+		size := int64(len(data))
+
+		if publicKey != "none" && s.encryptionService != nil {
+			data, err = s.encryptionService.EncryptBytes(data, publicKey)
+			if err != nil {
+				// Unlike the message, the artifact can't be labeled as plaintext afterwards, so skip it
+				log.Warn("failed to encrypt report artifact",
+					slog.String("user_id", userID),
+					slog.String("chat_id", chatID),
+					slog.String("artifact", artifact.Name),
+					slog.String("error", err.Error()))
+				continue
+			}
+		}
+
+		artifactID := fmt.Sprintf("%s-%d", messageID, i)
+		if err := s.artifacts.Put(ctx, userID, chatID, artifactID, data); err != nil {
+			log.Error("failed to store report artifact",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("artifact", artifact.Name),
+				slog.String("error", err.Error()))
+			continue
+		}
+
+		name := artifact.Name
+		if name == "" {
+			name = artifactID
+		}
+		attachments = append(attachments, messaging.ChatAttachment{
+			ID:                  artifactID,
+			Name:                name,
+			ContentType:         contentType,
+			Size:                size,
+			PublicEncryptionKey: publicKey,
+		})
+	}
+
+	log.Info("stored report artifacts",
+		slog.String("user_id", userID),
+		slog.String("chat_id", chatID),
+		slog.Int("stored", len(attachments)),
+		slog.Int("referenced", len(artifacts)))
+
+	return attachments
+}
@@ -0,0 +1,110 @@
+package deepr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memArtifacts struct {
+	objects map[string][]byte
+}
+
+func (m *memArtifacts) Put(_ context.Context, key string, data []byte) error {
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memArtifacts) Exists(_ context.Context, key string) (bool, error) {
+	_, ok := m.objects[key]
+	return ok, nil
+}
+
+func (m *memArtifacts) SignedURL(key string, _ time.Time) (string, error) {
+	return "https://storage.example/" + key + "?sig=x", nil
+}
+
+func newTestArtifactServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chart.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png-data"))
+		case "/big.csv":
+			_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	return server, u.Host
+}
+
+func TestArtifactStoreDownload(t *testing.T) {
+	server, host := newTestArtifactServer(t)
+	store := newArtifactStore(&memArtifacts{objects: map[string][]byte{}}, host, 50, time.Minute)
+	ctx := context.Background()
+
+	data, contentType, err := store.Download(ctx, Artifact{Name: "chart.png", URL: server.URL + "/chart.png"})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if string(data) != "png-data" || contentType != "image/png" {
+		t.Errorf("Download() = %q, %q", data, contentType)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"too large", server.URL + "/big.csv"},
+		{"not found", server.URL + "/missing"},
+		{"other host", "http://169.254.169.254/latest/meta-data"},
+		{"bad scheme", "file:///etc/passwd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := store.Download(ctx, Artifact{URL: tt.url}); err == nil {
+				t.Errorf("Download(%q) succeeded, want error", tt.url)
+			}
+		})
+	}
+}
+
+func TestArtifactStoreSignedURL(t *testing.T) {
+	objects := &memArtifacts{objects: map[string][]byte{}}
+	store := newArtifactStore(objects, "backend", 50, time.Minute)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "user-1", "chat-1", "msg-0", []byte("encrypted")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	signedURL, expiresAt, err := store.SignedURL(ctx, "user-1", "chat-1", "msg-0")
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	if !strings.Contains(signedURL, "deep-research/user-1/chat-1/msg-0") {
+		t.Errorf("SignedURL() = %q", signedURL)
+	}
+	if time.Until(expiresAt) <= 0 {
+		t.Errorf("SignedURL() expiry %v is in the past", expiresAt)
+	}
+
+	// Other users can't reach the artifact, neither directly nor via path traversal
+	for _, userID := range []string{"user-2", "user-2/../user-1"} {
+		if _, _, err := store.SignedURL(ctx, userID, "chat-1", "msg-0"); err != ErrArtifactNotFound {
+			t.Errorf("SignedURL(%q) error = %v, want ErrArtifactNotFound", userID, err)
+		}
+	}
+	if _, _, err := store.SignedURL(ctx, "user-1", "..", "chat-1/msg-0"); err != ErrArtifactNotFound {
+		t.Errorf("SignedURL(traversal) error = %v, want ErrArtifactNotFound", err)
+	}
+}
@@ -0,0 +1,233 @@
+package deepr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/providerapi"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// clarifierNoAnswer is the model's reply when the chat context doesn't answer the questions.
+	clarifierNoAnswer = "NO_ANSWER"
+
+	maxClarifierContextMessages = 20   // Most recent chat messages used as context
+	maxClarifierMessageChars    = 4000 // Longer messages are truncated
+
+	clarifierPrompt = `A research assistant asked the user clarifying questions before starting a deep research task.
+Answer the questions on behalf of the user, using ONLY information stated in the user's conversation below.
+Write the answer as the user would: short, direct, no preamble.
+If the conversation does not clearly answer every question, reply with exactly ` + clarifierNoAnswer + ` and nothing else.`
+)
+
+// ContextMessage is a message of the chat preceding a deep research request. Clients send the
+// (decrypted) chat history so clarification questions can be answered from it.
+type ContextMessage struct {
+	Role    string `json:"role" binding:"required,oneof=user assistant"`
+	Content string `json:"content"`
+}
+
+// Clarifier drafts answers to deep research clarification questions from the chat context.
+type Clarifier struct {
+	model      string
+	route      func(model string) (*routing.ProviderConfig, error)
+	httpClient *http.Client
+}
+
+// NewClarifier creates a clarifier that drafts answers with the given model.
+func NewClarifier(modelRouter *routing.ModelRouter, model string) *Clarifier {
+	return &Clarifier{
+		model: model,
+		route: func(model string) (*routing.ProviderConfig, error) {
+			return modelRouter.RouteModel(model, "")
+		},
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Draft returns an answer to the clarification questions drafted from the chat history,
+// or "" when the history doesn't answer them.
+func (c *Clarifier) Draft(ctx context.Context, questions string, history []ContextMessage) (string, error) {
+	if len(history) == 0 || strings.TrimSpace(questions) == "" {
+		return "", nil
+	}
+
+	provider, err := c.route(c.model)
+	if err != nil {
+		return "", fmt.Errorf("route clarifier model: %w", err)
+	}
+
+	payload := map[string]interface{}{
+		"model": provider.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": clarifierPrompt},
+			{"role": "user", "content": clarifierInput(questions, history)},
+		},
+		"max_tokens":  500,
+		"temperature": 0,
+		"stream":      false,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	translator := providerapi.For(provider.APIType)
+	url := provider.BaseURL + "/chat/completions"
+	if translator != nil {
+		var path string
+		if body, path, err = translator.Request(body); err != nil {
+			return "", fmt.Errorf("translate request: %w", err)
+		}
+		url = provider.BaseURL + path
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if translator != nil {
+		translator.SetHeaders(httpReq.Header, provider.APIKey)
+	} else {
+		httpReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("call clarifier model: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("clarifier model returned %d: %s", resp.StatusCode, string(respBody))
+	}
+	if translator != nil {
+		if respBody, err = translator.Response(respBody); err != nil {
+			return "", fmt.Errorf("translate response: %w", err)
+		}
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
+
+	draft := strings.TrimSpace(result.Choices[0].Message.Content)
+	if strings.Contains(draft, clarifierNoAnswer) {
+		return "", nil
+	}
+	return draft, nil
+}
+
+// clarifierInput formats the most recent chat messages and the questions for the clarifier model.
+func clarifierInput(questions string, history []ContextMessage) string {
+	if len(history) > maxClarifierContextMessages {
+		history = history[len(history)-maxClarifierContextMessages:]
+	}
+
+	var b strings.Builder
+	b.WriteString("Conversation:\n")
+	for _, msg := range history {
+		content := strings.TrimSpace(msg.Content)
+		if len(content) > maxClarifierMessageChars {
+			content = content[:maxClarifierMessageChars] + "…"
+		}
+		fmt.Fprintf(&b, "\n[%s]\n%s\n", msg.Role, content)
+	}
+	b.WriteString("\nClarifying questions:\n")
+	b.WriteString(strings.TrimSpace(questions))
+	return b.String()
+}
+
+// autoClarify drafts an answer to a clarification request from the session's chat context.
+// Pro users who opted in get the answer sent to the backend directly; otherwise it's proposed
+// to the user as a "clarification_suggestion" message they can accept or edit.
+func (s *Service) autoClarify(ctx context.Context, session *ActiveSession, userID, chatID, questions string) {
+	if s.clarifier == nil || len(session.ChatContext) == 0 {
+		return
+	}
+	log := s.logger.WithContext(ctx).WithComponent("deepr")
+
+	draft, err := s.clarifier.Draft(ctx, questions, session.ChatContext)
+	if err != nil {
+		log.Warn("failed to draft clarification response",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.String("error", err.Error()))
+		return
+	}
+	if draft == "" {
+		log.Debug("chat context does not answer clarification",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID))
+		return
+	}
+
+	messageType := "clarification_suggestion"
+	if session.AutoClarify && s.autoClarifyAllowed(ctx, userID) {
+		response, _ := json.Marshal(map[string]string{
+			"type":    "message",
+			"content": draft,
+		})
+		if err := s.sessionManager.WriteToBackend(userID, chatID, websocket.TextMessage, response); err != nil {
+			log.Error("failed to send automatic clarification response",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("error", err.Error()))
+			return
+		}
+		messageType = "clarification_auto_answered"
+
+		// Stored like a clarification the user typed
+		if s.firestoreClient != nil {
+			_, _ = s.encryptAndStoreMessage(ctx, userID, chatID, draft, "clarification_response", true, "", nil)
+		}
+	}
+
+	log.Info("drafted clarification response from chat context",
+		slog.String("user_id", userID),
+		slog.String("chat_id", chatID),
+		slog.String("message_type", messageType))
+
+	message, _ := json.Marshal(Message{Type: messageType, Content: draft})
+	clientCount := s.sessionManager.GetClientCount(userID, chatID)
+	sent := s.sessionManager.BroadcastToClients(userID, chatID, message) == nil && clientCount > 0
+	if s.storage != nil {
+		if err := s.storage.AddMessage(userID, chatID, string(message), sent, messageType); err != nil {
+			log.Error("failed to store message",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// autoClarifyAllowed reports whether the user's tier may have clarifications answered automatically.
+func (s *Service) autoClarifyAllowed(ctx context.Context, userID string) bool {
+	tierConfig, _, err := s.trackingService.GetUserTierConfig(ctx, userID)
+	return err == nil && tierConfig.Name == string(tiers.TierPro)
+}
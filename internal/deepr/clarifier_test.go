@@ -0,0 +1,92 @@
+package deepr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+func newTestClarifier(t *testing.T, reply string) (*Clarifier, *string) {
+	t.Helper()
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Messages[len(req.Messages)-1].Content
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": reply}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return &Clarifier{
+		model: "test-model",
+		route: func(model string) (*routing.ProviderConfig, error) {
+			return &routing.ProviderConfig{BaseURL: server.URL, Model: model}, nil
+		},
+		httpClient: server.Client(),
+	}, &prompt
+}
+
+func TestClarifierDraft(t *testing.T) {
+	history := []ContextMessage{
+		{Role: "user", Content: "I'm comparing EV charging networks in Germany for a fleet of 20 vans."},
+		{Role: "assistant", Content: "Happy to help."},
+	}
+	ctx := context.Background()
+
+	clarifier, prompt := newTestClarifier(t, "  Germany, for a fleet of 20 delivery vans.  ")
+	draft, err := clarifier.Draft(ctx, "Which country and how many vehicles?", history)
+	if err != nil {
+		t.Fatalf("Draft() error = %v", err)
+	}
+	if draft != "Germany, for a fleet of 20 delivery vans." {
+		t.Errorf("Draft() = %q", draft)
+	}
+	if !strings.Contains(*prompt, "fleet of 20 vans") || !strings.Contains(*prompt, "Which country") {
+		t.Errorf("prompt misses context or questions: %q", *prompt)
+	}
+
+	clarifier, _ = newTestClarifier(t, clarifierNoAnswer)
+	if draft, err := clarifier.Draft(ctx, "What is your budget?", history); err != nil || draft != "" {
+		t.Errorf("Draft() = %q, %v, want no answer", draft, err)
+	}
+
+	// No context: no model call
+	clarifier.route = func(string) (*routing.ProviderConfig, error) {
+		t.Fatal("model called without context")
+		return nil, nil
+	}
+	if draft, err := clarifier.Draft(ctx, "What is your budget?", nil); err != nil || draft != "" {
+		t.Errorf("Draft() = %q, %v, want no answer", draft, err)
+	}
+}
+
+func TestClarifierInputKeepsRecentMessages(t *testing.T) {
+	history := make([]ContextMessage, maxClarifierContextMessages+5)
+	for i := range history {
+		history[i] = ContextMessage{Role: "user", Content: strings.Repeat("x", i+1)}
+	}
+	history[0].Content = "oldest"
+	history[len(history)-1].Content = strings.Repeat("y", maxClarifierMessageChars+100)
+
+	input := clarifierInput("questions", history)
+	if strings.Contains(input, "oldest") {
+		t.Error("clarifierInput() kept messages beyond the limit")
+	}
+	if strings.Contains(input, strings.Repeat("y", maxClarifierMessageChars+1)) {
+		t.Error("clarifierInput() did not truncate long messages")
+	}
+}
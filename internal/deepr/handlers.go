@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -17,21 +18,24 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/routing"
 	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
 	"github.com/eternisai/enchanted-proxy/internal/title_generation"
+	"github.com/eternisai/enchanted-proxy/internal/wspolicy"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for now
-	},
-}
-
 // StartDeepResearchRequest represents the request body for starting deep research.
 type StartDeepResearchRequest struct {
 	Query         string `json:"query" binding:"required"`
 	ChatID        string `json:"chat_id" binding:"required"`
 	UserMessageID string `json:"user_message_id"` // Optional: custom message ID for the user's query
+
+	// Optional: prior chat messages, used to answer clarification questions from the chat
+	Context     []ContextMessage `json:"context,omitempty" binding:"omitempty,dive"`
+	AutoClarify bool             `json:"auto_clarify"` // Send drafted clarification answers without asking (Pro only)
+
+	// Optional: check access, quota and backend reachability without storing anything or
+	// starting a session (synthetic monitoring)
+	DryRun bool `json:"dry_run"`
 }
 
 // StartDeepResearchResponse represents the response for starting deep research.
@@ -39,6 +43,10 @@ type StartDeepResearchResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message,omitempty"`
 	Error   string `json:"error,omitempty"`
+
+	// Set when the research backend is at capacity: the session starts once a slot is free
+	Queued        bool `json:"queued,omitempty"`
+	QueuePosition int  `json:"queue_position,omitempty"` // 1 = next
 }
 
 // ClarifyDeepResearchRequest represents the request body for submitting a clarification response.
@@ -56,7 +64,7 @@ type ClarifyDeepResearchResponse struct {
 }
 
 // StartDeepResearchHandler handles POST requests to start deep research.
-func StartDeepResearchHandler(logger *logger.Logger, trackingService *request_tracking.Service, firebaseClient *auth.FirebaseClient, storage MessageStorage, sessionManager *SessionManager, queries pgdb.Querier, deepResearchRateLimitEnabled bool, notificationService *notifications.Service, titleService *title_generation.Service, modelRouter *routing.ModelRouter) gin.HandlerFunc {
+func StartDeepResearchHandler(logger *logger.Logger, trackingService *request_tracking.Service, firebaseClient *auth.FirebaseClient, storage MessageStorage, sessionManager *SessionManager, queries pgdb.Querier, deepResearchRateLimitEnabled bool, notificationService *notifications.Service, artifacts *ArtifactStore, clarifier *Clarifier, titleService *title_generation.Service, modelRouter *routing.ModelRouter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := logger.WithContext(c.Request.Context()).WithComponent("deepr")
 
@@ -97,12 +105,18 @@ func StartDeepResearchHandler(logger *logger.Logger, trackingService *request_tr
 			slog.String("query", req.Query))
 
 		// Create service instance
-		service := NewService(logger, trackingService, firebaseClient, storage, sessionManager, queries, deepResearchRateLimitEnabled, notificationService)
+		service := NewService(logger, trackingService, firebaseClient, storage, sessionManager, queries, deepResearchRateLimitEnabled, notificationService, artifacts)
+		service.clarifier = clarifier
+
+		if req.DryRun {
+			service.dryRun(c, userID)
+			return
+		}
 
 		// Save user's initial query message to Firestore only if message ID is provided
 		// This prevents duplicate messages when client has already saved the message locally
 		if req.UserMessageID != "" {
-			if _, err := service.encryptAndStoreMessage(c.Request.Context(), userID, req.ChatID, req.Query, "query", true, req.UserMessageID); err != nil {
+			if _, err := service.encryptAndStoreMessage(c.Request.Context(), userID, req.ChatID, req.Query, "query", true, req.UserMessageID, nil); err != nil {
 				log.Error("failed to save user query message to Firestore",
 					slog.String("user_id", userID),
 					slog.String("chat_id", req.ChatID),
@@ -165,6 +179,19 @@ func StartDeepResearchHandler(logger *logger.Logger, trackingService *request_tr
 			}
 		}
 
+		// Check if the session is already queued
+		if sessionManager.waitlist != nil {
+			if position := sessionManager.waitlist.Position(userID, req.ChatID); position > 0 {
+				c.JSON(http.StatusAccepted, StartDeepResearchResponse{
+					Success:       true,
+					Message:       "Deep research session already queued",
+					Queued:        true,
+					QueuePosition: position,
+				})
+				return
+			}
+		}
+
 		// Check if there's already an active session
 		if sessionManager.HasActiveBackend(userID, req.ChatID) {
 			log.Info("active session already exists",
@@ -223,134 +250,309 @@ func StartDeepResearchHandler(logger *logger.Logger, trackingService *request_tr
 			slog.String("chat_id", req.ChatID),
 			slog.Int64("run_id", runID))
 
-		// Connect to deep research backend
-		deepResearchHost := os.Getenv("DEEP_RESEARCH_WS")
-		if deepResearchHost == "" {
-			deepResearchHost = "localhost:3031"
-			log.Info("using default deep research backend host",
-				slog.String("host", deepResearchHost),
-				slog.String("reason", "DEEP_RESEARCH_WS not set"))
+		// Queue the session while the research backend is at capacity
+		if waitlist := sessionManager.waitlist; waitlist != nil {
+			position := waitlist.Admit(userID, req.ChatID, tierConfig.QueueWeight,
+				func() { service.startQueuedSession(userID, req, runID) },
+				func(position int) { service.updateQueuedState(context.Background(), userID, req.ChatID, position) })
+			if position > 0 {
+				service.updateQueuedState(c.Request.Context(), userID, req.ChatID, position)
+				c.JSON(http.StatusAccepted, StartDeepResearchResponse{
+					Success:       true,
+					Message:       "Deep research session queued",
+					Queued:        true,
+					QueuePosition: position,
+				})
+				return
+			}
 		}
 
-		deepResearchScheme := os.Getenv("DEEP_RESEARCH_WS_SCHEME")
-		if deepResearchScheme == "" {
-			deepResearchScheme = "ws"
+		if err := service.startSession(c.Request.Context(), userID, req, runID); err != nil {
+			if sessionManager.waitlist != nil {
+				sessionManager.waitlist.Release(userID, req.ChatID)
+			}
+			c.JSON(err.status, StartDeepResearchResponse{
+				Success: false,
+				Error:   err.message,
+			})
+			return
 		}
 
-		wsURL := url.URL{
-			Scheme: deepResearchScheme,
-			Host:   deepResearchHost,
-			Path:   "/deep_research/" + userID + "/" + req.ChatID + "/",
-		}
+		c.JSON(http.StatusOK, StartDeepResearchResponse{
+			Success: true,
+			Message: "Deep research session started successfully",
+		})
+	}
+}
+
+// dryRun answers a dry-run start request: it runs the access and quota checks of a real start
+// and checks that the research backend accepts connections, without creating a run record or
+// a session.
+func (s *Service) dryRun(c *gin.Context, userID string) {
+	ctx := c.Request.Context()
+	log := s.logger.WithContext(ctx).WithComponent("deepr")
 
-		log.Info("connecting to deep research backend",
+	tierConfig, _, err := s.trackingService.GetUserTierConfig(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user tier config",
 			slog.String("user_id", userID),
-			slog.String("chat_id", req.ChatID),
-			slog.String("url", wsURL.String()))
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusInternalServerError, StartDeepResearchResponse{
+			Success: false,
+			Error:   "Failed to validate access",
+		})
+		return
+	}
 
-		// Create dialer with timeout to prevent indefinite hangs
-		dialer := *websocket.DefaultDialer
-		dialer.HandshakeTimeout = 30 * time.Second
+	if forbiddenErr := s.checkDeepResearchQuota(ctx, userID, tierConfig); forbiddenErr != nil {
+		errors.AbortWithForbidden(c, forbiddenErr)
+		return
+	}
 
-		connectStart := time.Now()
-		backendConn, _, err := dialer.Dial(wsURL.String(), nil)
-		if err != nil {
-			log.Error("failed to connect to deep research backend",
-				slog.String("user_id", userID),
-				slog.String("chat_id", req.ChatID),
-				slog.String("url", wsURL.String()),
-				slog.String("error", err.Error()),
-				slog.Duration("connection_attempt_duration", time.Since(connectStart)))
-			c.JSON(http.StatusServiceUnavailable, StartDeepResearchResponse{
-				Success: false,
-				Error:   "Failed to connect to deep research service",
-			})
-			return
+	if err := checkBackendReachable(ctx); err != nil {
+		log.Error("deep research backend unreachable",
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()))
+		c.JSON(http.StatusServiceUnavailable, StartDeepResearchResponse{
+			Success: false,
+			Error:   "Deep research backend unavailable",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, StartDeepResearchResponse{
+		Success: true,
+		Message: "Dry run succeeded",
+	})
+}
+
+// backendDialTimeout bounds the reachability check of dry runs.
+const backendDialTimeout = 5 * time.Second
+
+// checkBackendReachable opens (and closes) a TCP connection to the deep research backend.
+func checkBackendReachable(ctx context.Context) error {
+	host := os.Getenv("DEEP_RESEARCH_WS")
+	if host == "" {
+		host = "localhost:3031"
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		port := "80"
+		if os.Getenv("DEEP_RESEARCH_WS_SCHEME") == "wss" {
+			port = "443"
 		}
+		host = net.JoinHostPort(host, port)
+	}
+
+	dialer := net.Dialer{Timeout: backendDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
 
-		// Create session context
-		sessionCtx, cancel := context.WithCancel(context.Background())
+// sessionStartError is a failure to start a deep research session, with its HTTP status.
+type sessionStartError struct {
+	status  int
+	message string // Client-facing message
+	err     error
+}
 
-		// Create and register session with runID for token tracking
-		session := sessionManager.CreateSession(userID, req.ChatID, runID, backendConn, sessionCtx, cancel)
+func (e *sessionStartError) Error() string {
+	return e.message + ": " + e.err.Error()
+}
 
-		// Update backend connection status in storage
-		if storage != nil {
-			if err := storage.UpdateBackendConnectionStatus(userID, req.ChatID, true); err != nil {
-				log.Error("failed to update backend connection status",
-					slog.String("user_id", userID),
-					slog.String("chat_id", req.ChatID),
-					slog.String("error", err.Error()))
-			}
+// startSession connects to the deep research backend, sends the query and starts relaying
+// backend messages for a run.
+func (s *Service) startSession(ctx context.Context, userID string, req StartDeepResearchRequest, runID int64) *sessionStartError {
+	log := s.logger.WithContext(ctx).WithComponent("deepr")
+	chatID := req.ChatID
+
+	// Connect to deep research backend
+	deepResearchHost := os.Getenv("DEEP_RESEARCH_WS")
+	if deepResearchHost == "" {
+		deepResearchHost = "localhost:3031"
+		log.Info("using default deep research backend host",
+			slog.String("host", deepResearchHost),
+			slog.String("reason", "DEEP_RESEARCH_WS not set"))
+	}
+
+	deepResearchScheme := os.Getenv("DEEP_RESEARCH_WS_SCHEME")
+	if deepResearchScheme == "" {
+		deepResearchScheme = "ws"
+	}
+
+	wsURL := url.URL{
+		Scheme: deepResearchScheme,
+		Host:   deepResearchHost,
+		Path:   "/deep_research/" + userID + "/" + chatID + "/",
+	}
+
+	log.Info("connecting to deep research backend",
+		slog.String("user_id", userID),
+		slog.String("chat_id", chatID),
+		slog.String("url", wsURL.String()))
+
+	// Create dialer with timeout to prevent indefinite hangs
+	dialer := *websocket.DefaultDialer
+	dialer.HandshakeTimeout = 30 * time.Second
+
+	connectStart := time.Now()
+	backendConn, _, err := dialer.Dial(wsURL.String(), nil)
+	if err != nil {
+		log.Error("failed to connect to deep research backend",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.String("url", wsURL.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("connection_attempt_duration", time.Since(connectStart)))
+		return &sessionStartError{status: http.StatusServiceUnavailable, message: "Failed to connect to deep research service", err: err}
+	}
+
+	// Create session context
+	sessionCtx, cancel := context.WithCancel(context.Background())
+
+	// Create and register session with runID for token tracking
+	session := s.sessionManager.CreateSession(userID, chatID, runID, backendConn, sessionCtx, cancel)
+	session.ChatContext = req.Context
+	session.AutoClarify = req.AutoClarify
+
+	// Update backend connection status in storage
+	if s.storage != nil {
+		if err := s.storage.UpdateBackendConnectionStatus(userID, chatID, true); err != nil {
+			log.Error("failed to update backend connection status",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("error", err.Error()))
 		}
+	}
 
-		// Send initial query to backend
-		queryMsg := Request{
-			Query: req.Query,
-			Type:  "query",
+	// Send initial query to backend
+	queryMsg := Request{
+		Query: req.Query,
+		Type:  "query",
+	}
+	queryJSON, err := json.Marshal(queryMsg)
+	if err != nil {
+		log.Error("failed to marshal query",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.String("error", err.Error()))
+		s.sessionManager.RemoveSession(userID, chatID)
+		backendConn.Close()
+		return &sessionStartError{status: http.StatusInternalServerError, message: "Failed to prepare query", err: err}
+	}
+
+	if err := backendConn.WriteMessage(websocket.TextMessage, queryJSON); err != nil {
+		log.Error("failed to send query to backend",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.String("error", err.Error()))
+		s.sessionManager.RemoveSession(userID, chatID)
+		backendConn.Close()
+		return &sessionStartError{status: http.StatusInternalServerError, message: "Failed to send query to deep research service", err: err}
+	}
+
+	log.Info("deep research started successfully",
+		slog.String("user_id", userID),
+		slog.String("chat_id", chatID),
+		slog.Duration("connection_time", time.Since(connectStart)))
+
+	// Initialize deep research state on chat document for UI access
+	if s.firebaseClient != nil {
+		initialState := &auth.DeepResearchState{
+			StartedAt: time.Now(),
+			Status:    "in_progress",
+			Error:     nil,
 		}
-		queryJSON, err := json.Marshal(queryMsg)
-		if err != nil {
-			log.Error("failed to marshal query",
+		if err := s.firebaseClient.UpdateChatDeepResearchState(ctx, userID, chatID, initialState); err != nil {
+			log.Error("failed to update chat deep research state",
 				slog.String("user_id", userID),
-				slog.String("chat_id", req.ChatID),
+				slog.String("chat_id", chatID),
 				slog.String("error", err.Error()))
-			sessionManager.RemoveSession(userID, req.ChatID)
-			backendConn.Close()
-			c.JSON(http.StatusInternalServerError, StartDeepResearchResponse{
-				Success: false,
-				Error:   "Failed to prepare query",
-			})
-			return
+			// Don't fail the request - session is already started
 		}
+	}
+
+	// Start goroutine to handle backend messages
+	go s.handleBackendMessages(sessionCtx, session, userID, chatID)
+
+	return nil
+}
+
+// startQueuedSession starts a session admitted from the waitlist. Failures end the run, since
+// there's no request left to report them to.
+func (s *Service) startQueuedSession(userID string, req StartDeepResearchRequest, runID int64) {
+	ctx := context.Background()
+	err := s.startSession(ctx, userID, req, runID)
+	if err == nil {
+		return
+	}
+
+	log := s.logger.WithContext(ctx).WithComponent("deepr")
+	log.Error("failed to start queued deep research session",
+		slog.String("user_id", userID),
+		slog.String("chat_id", req.ChatID),
+		slog.Int64("run_id", runID),
+		slog.String("error", err.Error()))
 
-		if err := backendConn.WriteMessage(websocket.TextMessage, queryJSON); err != nil {
-			log.Error("failed to send query to backend",
+	if s.sessionManager.waitlist != nil {
+		s.sessionManager.waitlist.Release(userID, req.ChatID)
+	}
+	if err := s.queries.CompleteDeepResearchRun(ctx, pgdb.CompleteDeepResearchRunParams{
+		ID:     runID,
+		Status: "failed",
+	}); err != nil {
+		log.Error("failed to mark queued run as failed",
+			slog.Int64("run_id", runID),
+			slog.String("error", err.Error()))
+	}
+	if s.firebaseClient != nil {
+		state := &auth.DeepResearchState{
+			StartedAt: time.Now(),
+			Status:    "error",
+			Error: &auth.DeepResearchError{
+				UnderlyingError: err.Error(),
+				UserMessage:     "An error occurred during deep research. Please try again.",
+			},
+		}
+		if err := s.firebaseClient.UpdateChatDeepResearchState(ctx, userID, req.ChatID, state); err != nil {
+			log.Error("failed to update chat deep research state",
 				slog.String("user_id", userID),
 				slog.String("chat_id", req.ChatID),
 				slog.String("error", err.Error()))
-			sessionManager.RemoveSession(userID, req.ChatID)
-			backendConn.Close()
-			c.JSON(http.StatusInternalServerError, StartDeepResearchResponse{
-				Success: false,
-				Error:   "Failed to send query to deep research service",
-			})
-			return
-		}
-
-		log.Info("deep research started successfully",
-			slog.String("user_id", userID),
-			slog.String("chat_id", req.ChatID),
-			slog.Duration("connection_time", time.Since(connectStart)))
-
-		// Initialize deep research state on chat document for UI access
-		if firebaseClient != nil {
-			initialState := &auth.DeepResearchState{
-				StartedAt: time.Now(),
-				Status:    "in_progress",
-				Error:     nil,
-			}
-			if err := firebaseClient.UpdateChatDeepResearchState(c.Request.Context(), userID, req.ChatID, initialState); err != nil {
-				log.Error("failed to update chat deep research state",
-					slog.String("user_id", userID),
-					slog.String("chat_id", req.ChatID),
-					slog.String("error", err.Error()))
-				// Don't fail the request - session is already started
-			}
 		}
+	}
+}
 
-		// Start goroutine to handle backend messages
-		go service.handleBackendMessages(sessionCtx, session, userID, req.ChatID)
+// updateQueuedState exposes a queued session's position on the chat document ("queued" state).
+func (s *Service) updateQueuedState(ctx context.Context, userID, chatID string, position int) {
+	if s.firebaseClient == nil {
+		return
+	}
+	log := s.logger.WithContext(ctx).WithComponent("deepr")
 
-		c.JSON(http.StatusOK, StartDeepResearchResponse{
-			Success: true,
-			Message: "Deep research session started successfully",
-		})
+	if err := s.firebaseClient.UpdateSessionState(ctx, userID, chatID, "queued"); err != nil {
+		log.Error("failed to update session state",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.String("error", err.Error()))
+	}
+	state := &auth.DeepResearchState{
+		StartedAt:     time.Now(),
+		Status:        "queued",
+		QueuePosition: position,
+	}
+	if err := s.firebaseClient.UpdateChatDeepResearchState(ctx, userID, chatID, state); err != nil {
+		log.Error("failed to update chat deep research state",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.String("error", err.Error()))
 	}
 }
 
 // ClarifyDeepResearchHandler handles POST requests to submit clarification responses.
-func ClarifyDeepResearchHandler(logger *logger.Logger, trackingService *request_tracking.Service, firebaseClient *auth.FirebaseClient, storage MessageStorage, sessionManager *SessionManager, queries pgdb.Querier, deepResearchRateLimitEnabled bool, notificationService *notifications.Service) gin.HandlerFunc {
+func ClarifyDeepResearchHandler(logger *logger.Logger, trackingService *request_tracking.Service, firebaseClient *auth.FirebaseClient, storage MessageStorage, sessionManager *SessionManager, queries pgdb.Querier, deepResearchRateLimitEnabled bool, notificationService *notifications.Service, artifacts *ArtifactStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := logger.WithContext(c.Request.Context()).WithComponent("deepr")
 
@@ -391,7 +593,7 @@ func ClarifyDeepResearchHandler(logger *logger.Logger, trackingService *request_
 			slog.String("response", req.Response))
 
 		// Create service instance for message saving
-		service := NewService(logger, trackingService, firebaseClient, storage, sessionManager, queries, deepResearchRateLimitEnabled, notificationService)
+		service := NewService(logger, trackingService, firebaseClient, storage, sessionManager, queries, deepResearchRateLimitEnabled, notificationService, artifacts)
 
 		// Check if there's an active backend session
 		if !sessionManager.HasActiveBackend(userID, req.ChatID) {
@@ -444,7 +646,7 @@ func ClarifyDeepResearchHandler(logger *logger.Logger, trackingService *request_
 		// Save user's clarification response message to Firestore only if message ID is provided
 		// This prevents duplicate messages when client has already saved the message locally
 		if req.UserMessageID != "" {
-			if _, err := service.encryptAndStoreMessage(c.Request.Context(), userID, req.ChatID, req.Response, "clarification_response", true, req.UserMessageID); err != nil {
+			if _, err := service.encryptAndStoreMessage(c.Request.Context(), userID, req.ChatID, req.Response, "clarification_response", true, req.UserMessageID, nil); err != nil {
 				log.Error("failed to save clarification response message to Firestore",
 					slog.String("user_id", userID),
 					slog.String("chat_id", req.ChatID),
@@ -465,7 +667,7 @@ func ClarifyDeepResearchHandler(logger *logger.Logger, trackingService *request_
 }
 
 // DeepResearchHandler handles WebSocket connections for deep research streaming.
-func DeepResearchHandler(logger *logger.Logger, trackingService *request_tracking.Service, firebaseClient *auth.FirebaseClient, storage MessageStorage, sessionManager *SessionManager, queries pgdb.Querier, deepResearchRateLimitEnabled bool, notificationService *notifications.Service) gin.HandlerFunc {
+func DeepResearchHandler(logger *logger.Logger, trackingService *request_tracking.Service, firebaseClient *auth.FirebaseClient, storage MessageStorage, sessionManager *SessionManager, queries pgdb.Querier, deepResearchRateLimitEnabled bool, notificationService *notifications.Service, artifacts *ArtifactStore, wsPolicy *wspolicy.Policy) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := logger.WithContext(c.Request.Context()).WithComponent("deepr")
 
@@ -506,7 +708,7 @@ func DeepResearchHandler(logger *logger.Logger, trackingService *request_trackin
 		log.Info("upgrading connection to websocket",
 			slog.String("user_id", userID),
 			slog.String("chat_id", chatID))
-		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		conn, err := wsPolicy.Upgrade(c, wspolicy.SubprotocolDeepResearch)
 		if err != nil {
 			log.Error("websocket upgrade failed",
 				slog.String("user_id", userID),
@@ -522,9 +724,49 @@ func DeepResearchHandler(logger *logger.Logger, trackingService *request_trackin
 			slog.String("remote_addr", c.Request.RemoteAddr))
 
 		// Create service instance with shared session manager
-		service := NewService(logger, trackingService, firebaseClient, storage, sessionManager, queries, deepResearchRateLimitEnabled, notificationService)
+		service := NewService(logger, trackingService, firebaseClient, storage, sessionManager, queries, deepResearchRateLimitEnabled, notificationService, artifacts)
 
 		// Handle the WebSocket connection
 		service.HandleConnection(c.Request.Context(), conn, userID, chatID)
 	}
 }
+
+// ArtifactURLResponse is the response of GET /api/v1/deepresearch/artifacts/:chatId/:artifactId.
+type ArtifactURLResponse struct {
+	URL       string    `json:"url"` // Signed download URL of the encrypted artifact
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ArtifactURLHandler returns a signed download URL for an artifact of the user's research report.
+// Artifacts are stored under the user's ID, so other users' artifacts are never found.
+func ArtifactURLHandler(logger *logger.Logger, artifacts *ArtifactStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("deepr")
+
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			errors.Unauthorized(c, "User not authenticated", nil)
+			return
+		}
+
+		chatID := c.Param("chatId")
+		artifactID := c.Param("artifactId")
+
+		signedURL, expiresAt, err := artifacts.SignedURL(c.Request.Context(), userID, chatID, artifactID)
+		if err == ErrArtifactNotFound {
+			errors.NotFound(c, "Artifact not found", nil)
+			return
+		}
+		if err != nil {
+			log.Error("failed to create artifact URL",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("artifact_id", artifactID),
+				slog.String("error", err.Error()))
+			errors.Internal(c, "Failed to create artifact URL", nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, ArtifactURLResponse{URL: signedURL, ExpiresAt: expiresAt})
+	}
+}
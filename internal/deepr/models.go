@@ -12,6 +12,10 @@ type Message struct {
 	FinalReport string `json:"final_report,omitempty"`
 	Error       string `json:"error,omitempty"`
 	TokensUsed  int    `json:"tokens_used,omitempty"` // Token usage for this message (if reported by backend)
+
+	Artifacts []Artifact `json:"artifacts,omitempty"` // Files generated for the final report (charts, CSVs)
+
+	QueuePosition int `json:"queue_position,omitempty"` // "queue_position" messages: 1 = next
 }
 
 // Request represents a request to the deep research service.
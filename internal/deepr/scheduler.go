@@ -0,0 +1,92 @@
+package deepr
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// usageHalfLife is how fast past backend usage stops counting against a user.
+	usageHalfLife = time.Hour
+	// usageUnit is the backend time that halves a user's priority (with no other usage).
+	usageUnit = 10 * time.Minute
+	// waitUnit is the wait that doubles a queued session's priority, so low-priority sessions
+	// are never starved.
+	waitUnit = time.Minute
+)
+
+// fairShare orders queued research runs by weighted fair share: a session's priority grows with
+// its tier's queue weight and its wait time, and shrinks with its user's recent backend usage
+// (decayed past usage plus running sessions).
+type fairShare struct {
+	usage map[string]decayedUsage // User ID → finished sessions' backend time
+	now   func() time.Time
+}
+
+// decayedUsage is backend time in minutes, decayed with usageHalfLife since at.
+type decayedUsage struct {
+	minutes float64
+	at      time.Time
+}
+
+func newFairShare() *fairShare {
+	return &fairShare{
+		usage: make(map[string]decayedUsage),
+		now:   time.Now,
+	}
+}
+
+// decay returns the usage in minutes at t.
+func (u decayedUsage) decay(t time.Time) float64 {
+	if u.minutes == 0 {
+		return 0
+	}
+	return u.minutes * math.Exp2(-t.Sub(u.at).Hours()/usageHalfLife.Hours())
+}
+
+// record adds the backend time of a finished session to the user's usage.
+func (f *fairShare) record(userID string, d time.Duration) {
+	now := f.now()
+	minutes := f.usage[userID].decay(now) + d.Minutes()
+	f.usage[userID] = decayedUsage{minutes: minutes, at: now}
+}
+
+// prune drops usage that has decayed to nothing.
+func (f *fairShare) prune() {
+	now := f.now()
+	for userID, u := range f.usage {
+		if u.decay(now) < 0.01 {
+			delete(f.usage, userID)
+		}
+	}
+}
+
+// sort orders the queue by descending priority, then by age.
+func (f *fairShare) sort(queue []*waitEntry, running map[string]*runningSession) {
+	now := f.now()
+
+	usage := make(map[string]float64)
+	for userID, u := range f.usage {
+		usage[userID] = u.decay(now)
+	}
+	for _, session := range running {
+		usage[session.userID] += now.Sub(session.startedAt).Minutes()
+	}
+
+	priority := make(map[*waitEntry]float64, len(queue))
+	for _, entry := range queue {
+		weight := float64(max(entry.weight, 1))
+		waited := now.Sub(entry.enqueuedAt).Minutes() / waitUnit.Minutes()
+		used := usage[entry.userID] / usageUnit.Minutes()
+		priority[entry] = weight * (1 + waited) / (1 + used)
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		pi, pj := priority[queue[i]], priority[queue[j]]
+		if pi != pj {
+			return pi > pj
+		}
+		return queue[i].enqueuedAt.Before(queue[j].enqueuedAt)
+	})
+}
@@ -34,6 +34,8 @@ type Service struct {
 	deepResearchRateLimitEnabled bool
 	queries                      pgdb.Querier // For tier-based quota enforcement
 	notificationService          *notifications.Service
+	artifacts                    *ArtifactStore // Optional: nil = report artifacts are not stored
+	clarifier                    *Clarifier     // Optional: drafts clarification responses from chat context
 }
 
 // mapEventTypeToState maps event types from deep research server to session states.
@@ -101,19 +103,19 @@ func (s *Service) checkDeepResearchQuota(ctx context.Context, userID string, tie
 		return nil
 	}
 
-	// Check active jobs (only applies when MaxActiveSessions == 1, i.e., Free tier)
-	// Pro tier has MaxActiveSessions == 0 (unlimited), so this check is skipped
-	if tierConfig.DeepResearchMaxActiveSessions == 1 {
-		hasActive, err := s.queries.HasActiveDeepResearchRun(ctx, userID)
+	// Check active jobs (queued runs count too); MaxActiveSessions == 0 means unlimited
+	if tierConfig.DeepResearchMaxActiveSessions > 0 {
+		activeRuns, err := s.queries.CountActiveDeepResearchRuns(ctx, userID)
 		if err != nil {
 			log.Error("failed to check active runs",
 				slog.String("user_id", userID),
 				slog.String("error", err.Error()))
 			return errors.TierValidationFailed("failed to check active runs")
 		}
-		if hasActive {
-			log.Warn("user blocked - already has active deep research run",
+		if activeRuns >= int64(tierConfig.DeepResearchMaxActiveSessions) {
+			log.Warn("user blocked - too many active deep research runs",
 				slog.String("user_id", userID),
+				slog.Int64("active_runs", activeRuns),
 				slog.Int("max_active", tierConfig.DeepResearchMaxActiveSessions))
 			return errors.ActiveDeepResearchSession(tierConfig.Name, tierConfig.DisplayName, tierConfig.DeepResearchMaxActiveSessions)
 		}
@@ -252,7 +254,7 @@ func (s *Service) validateFreemiumAccess(ctx context.Context, userID, chatID str
 }
 
 // NewService creates a new deep research service with database storage.
-func NewService(logger *logger.Logger, trackingService *request_tracking.Service, firebaseClient *auth.FirebaseClient, storage MessageStorage, sessionManager *SessionManager, queries pgdb.Querier, deepResearchRateLimitEnabled bool, notificationService *notifications.Service) *Service {
+func NewService(logger *logger.Logger, trackingService *request_tracking.Service, firebaseClient *auth.FirebaseClient, storage MessageStorage, sessionManager *SessionManager, queries pgdb.Querier, deepResearchRateLimitEnabled bool, notificationService *notifications.Service, artifacts *ArtifactStore) *Service {
 	var encryptionService *messaging.EncryptionService
 	var firestoreClient *messaging.FirestoreClient
 
@@ -272,13 +274,15 @@ func NewService(logger *logger.Logger, trackingService *request_tracking.Service
 		firestoreClient:              firestoreClient,
 		deepResearchRateLimitEnabled: deepResearchRateLimitEnabled,
 		notificationService:          notificationService,
+		artifacts:                    artifacts,
 	}
 }
 
 // encryptAndStoreMessage handles encryption and Firestore storage for deep research messages.
 // It attempts to encrypt the message content with the user's public key, falling back to plaintext if encryption fails.
+// Artifacts referenced by the message are stored (encrypted with the same key) and attached to it.
 // Returns the generated message ID and any error encountered.
-func (s *Service) encryptAndStoreMessage(ctx context.Context, userID, chatID, content, messageType string, isFromUser bool, customMessageID string) (string, error) {
+func (s *Service) encryptAndStoreMessage(ctx context.Context, userID, chatID, content, messageType string, isFromUser bool, customMessageID string, artifacts []Artifact) (string, error) {
 	log := s.logger.WithContext(ctx).WithComponent("deepr")
 
 	// Check if Firestore client is available
@@ -353,6 +357,7 @@ func (s *Service) encryptAndStoreMessage(ctx context.Context, userID, chatID, co
 		IsError:             messageType == "error",
 		Timestamp:           time.Now(),
 		PublicEncryptionKey: publicKeyStr,
+		Attachments:         s.storeArtifacts(ctx, userID, chatID, messageID, artifacts, publicKeyStr),
 	}
 
 	// Store message to Firestore at /users/{userID}/chats/{chatID}/messages/{messageID}
@@ -576,7 +581,12 @@ func (s *Service) handleBackendMessages(ctx context.Context, session *ActiveSess
 				contentToStore := msg.Message
 
 				// Use helper method to encrypt and store message (no custom ID for assistant messages)
-				_, _ = s.encryptAndStoreMessage(ctx, userID, chatID, contentToStore, messageType, false, "")
+				_, _ = s.encryptAndStoreMessage(ctx, userID, chatID, contentToStore, messageType, false, "", msg.Artifacts)
+			}
+
+			// Answer the clarification from the chat context if possible (without blocking the read loop)
+			if messageType == "clarification_needed" {
+				go s.autoClarify(ctx, session, userID, chatID, msg.Message)
 			}
 
 			// Check if session is complete
@@ -633,6 +643,13 @@ func (s *Service) HandleConnection(ctx context.Context, clientConn *websocket.Co
 		slog.String("client_id", clientID),
 		slog.Bool("has_active_backend", isReconnection))
 
+	if !isReconnection && s.sessionManager.waitlist != nil {
+		if positions, stop := s.sessionManager.waitlist.Watch(userID, chatID); positions != nil {
+			s.handleQueuedConnection(ctx, clientConn, userID, chatID, clientID, positions, stop)
+			return
+		}
+	}
+
 	if isReconnection {
 		log.Info("reconnection to existing session detected",
 			slog.String("user_id", userID),
@@ -672,6 +689,47 @@ func (s *Service) HandleConnection(ctx context.Context, clientConn *websocket.Co
 	s.handleNewConnection(ctx, clientConn, userID, chatID, clientID)
 }
 
+// handleQueuedConnection keeps a client of a queued session informed of its queue position with
+// "queue_position" messages. Once the session starts, the client gets a "queue_admitted" message
+// and the connection is closed; reconnecting attaches it to the running session.
+func (s *Service) handleQueuedConnection(ctx context.Context, clientConn *websocket.Conn, userID, chatID, clientID string, positions <-chan int, stop func()) {
+	log := s.logger.WithContext(ctx).WithComponent("deepr")
+	defer stop()
+	defer clientConn.Close() //nolint:errcheck
+
+	log.Info("client waiting for queued session",
+		slog.String("user_id", userID),
+		slog.String("chat_id", chatID),
+		slog.String("client_id", clientID))
+
+	// Detect disconnects; clients have nothing to send while queued
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := clientConn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-disconnected:
+			return
+		case position, ok := <-positions:
+			message := Message{Type: "queue_position", QueuePosition: position}
+			if !ok {
+				message = Message{Type: "queue_admitted"}
+			}
+			data, _ := json.Marshal(message)
+			if err := clientConn.WriteMessage(websocket.TextMessage, data); err != nil || !ok {
+				return
+			}
+		}
+	}
+}
+
 // checkAndTrackSubscription checks user subscription and tracks usage
 // NOTE: This function is currently DISABLED - all limit checks are commented out
 // to allow unrestricted access to deep research for all users.
@@ -1323,7 +1381,7 @@ func (s *Service) handleNewConnection(ctx context.Context, clientConn *websocket
 					contentToStore := msg.Message
 
 					// Use helper method to encrypt and store message (no custom ID for assistant messages)
-					_, _ = s.encryptAndStoreMessage(ctx, userID, chatID, contentToStore, messageType, false, "")
+					_, _ = s.encryptAndStoreMessage(ctx, userID, chatID, contentToStore, messageType, false, "", msg.Artifacts)
 				}
 
 				// Track usage only when research_complete event is sent
@@ -1462,7 +1520,7 @@ func (s *Service) handleNewConnection(ctx context.Context, clientConn *websocket
 					contentToStore := msg.Message
 
 					// Use helper method to encrypt and store message (no custom ID for assistant messages)
-					_, _ = s.encryptAndStoreMessage(ctx, userID, chatID, contentToStore, messageType, false, "")
+					_, _ = s.encryptAndStoreMessage(ctx, userID, chatID, contentToStore, messageType, false, "", msg.Artifacts)
 				}
 
 				// Track usage only when research_complete event is sent (even without storage)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/gorilla/websocket"
@@ -14,9 +15,12 @@ type ActiveSession struct {
 	UserID         string
 	ChatID         string
 	RunID          int64 // Database run ID for token tracking
+	StartedAt      time.Time
 	BackendConn    *websocket.Conn
 	Context        context.Context
 	CancelFunc     context.CancelFunc
+	ChatContext    []ContextMessage           // Chat history sent with the start request, for auto-clarification
+	AutoClarify    bool                       // Send drafted clarification answers without asking the user (Pro)
 	mu             sync.RWMutex               // Protects clientConns map
 	backendWriteMu sync.Mutex                 // Serializes writes to backend websocket
 	clientConns    map[string]*websocket.Conn // Map of client connection IDs
@@ -27,6 +31,7 @@ type SessionManager struct {
 	logger   *logger.Logger
 	sessions map[string]*ActiveSession // key: "userID:chatID"
 	mu       sync.RWMutex
+	waitlist *Waitlist // Optional: backend capacity; removing a session releases its slot
 }
 
 // NewSessionManager creates a new session manager.
@@ -37,6 +42,11 @@ func NewSessionManager(logger *logger.Logger) *SessionManager {
 	}
 }
 
+// SetWaitlist limits sessions started via POST /deepresearch/start to the waitlist's capacity.
+func (sm *SessionManager) SetWaitlist(waitlist *Waitlist) {
+	sm.waitlist = waitlist
+}
+
 // getSessionKey generates a session key from userID and chatID.
 func (sm *SessionManager) getSessionKey(userID, chatID string) string {
 	return userID + ":" + chatID
@@ -91,6 +101,7 @@ func (sm *SessionManager) CreateSession(userID, chatID string, runID int64, back
 		UserID:      userID,
 		ChatID:      chatID,
 		RunID:       runID,
+		StartedAt:   time.Now(),
 		BackendConn: backendConn,
 		Context:     ctx,
 		CancelFunc:  cancel,
@@ -111,6 +122,11 @@ func (sm *SessionManager) CreateSession(userID, chatID string, runID int64, back
 
 // RemoveSession removes a session.
 func (sm *SessionManager) RemoveSession(userID, chatID string) {
+	// Free the backend slot (and start queued sessions) once the session is gone
+	if sm.waitlist != nil {
+		defer sm.waitlist.Release(userID, chatID)
+	}
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -151,6 +167,70 @@ func (sm *SessionManager) RemoveSession(userID, chatID string) {
 	}
 }
 
+// StopUserSessions cancels all active sessions of a user and closes their backend and client
+// connections, which ends the backend message loops. Called when the user's token is revoked or
+// subscription lapses mid-research. Returns the number of sessions stopped.
+func (sm *SessionManager) StopUserSessions(userID string) int {
+	if userID == "" {
+		return 0
+	}
+
+	sm.mu.Lock()
+	var sessions []*ActiveSession
+	for key, session := range sm.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+			delete(sm.sessions, key)
+		}
+	}
+	sm.mu.Unlock()
+
+	for _, session := range sessions {
+		if session.CancelFunc != nil {
+			session.CancelFunc()
+		}
+		session.backendWriteMu.Lock()
+		if session.BackendConn != nil {
+			_ = session.BackendConn.Close()
+		}
+		session.backendWriteMu.Unlock()
+		session.mu.Lock()
+		for _, c := range session.clientConns {
+			_ = c.Close()
+		}
+		session.clientConns = make(map[string]*websocket.Conn)
+		session.mu.Unlock()
+
+		sm.logger.WithComponent("deepr-session").Info("session stopped, user access revoked",
+			slog.String("user_id", userID),
+			slog.String("chat_id", session.ChatID),
+			slog.Int64("run_id", session.RunID))
+	}
+
+	return len(sessions)
+}
+
+// SessionCount returns the number of active sessions.
+func (sm *SessionManager) SessionCount() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return len(sm.sessions)
+}
+
+// ActiveUsers returns the users with active sessions, mapped to the start time of their oldest one.
+func (sm *SessionManager) ActiveUsers() map[string]time.Time {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	users := make(map[string]time.Time)
+	for _, session := range sm.sessions {
+		if startedAt, ok := users[session.UserID]; !ok || session.StartedAt.Before(startedAt) {
+			users[session.UserID] = session.StartedAt
+		}
+	}
+	return users
+}
+
 // AddClientConnection adds a client connection to an existing session.
 func (sm *SessionManager) AddClientConnection(userID, chatID, clientID string, conn *websocket.Conn) {
 	sm.mu.RLock()
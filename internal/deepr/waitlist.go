@@ -0,0 +1,224 @@
+package deepr
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+// Waitlist admits deep research sessions up to the research backend's capacity and queues the
+// rest. Queued sessions start as slots free up, in weighted fair share order (see fairShare), so
+// neither a single user nor a single tier can hold the queue.
+type Waitlist struct {
+	mu        sync.Mutex
+	capacity  int                        // 0 = unlimited
+	running   map[string]*runningSession // Session key → admitted session
+	queue     []*waitEntry
+	scheduler *fairShare
+	logger    *logger.Logger
+}
+
+type runningSession struct {
+	userID    string
+	startedAt time.Time
+}
+
+type waitEntry struct {
+	userID     string
+	chatID     string
+	weight     int // Tier queue weight
+	enqueuedAt time.Time
+	start      func()             // Starts the session once admitted
+	notify     func(position int) // Reports a new queue position (1 = next)
+	position   int                // Last reported position
+	watchers   []chan int         // Connected clients waiting for the session (see Watch)
+}
+
+// NewWaitlist creates a waitlist admitting up to capacity concurrent sessions (0 = unlimited).
+func NewWaitlist(capacity int, logger *logger.Logger) *Waitlist {
+	return &Waitlist{
+		capacity:  capacity,
+		running:   make(map[string]*runningSession),
+		scheduler: newFairShare(),
+		logger:    logger,
+	}
+}
+
+func waitlistKey(userID, chatID string) string {
+	return userID + ":" + chatID
+}
+
+// Admit reserves a backend slot for a session. Returns 0 when admitted: the caller starts the
+// session and releases the slot (SessionManager.RemoveSession) when it ends. Otherwise the session
+// is queued with the user's tier queue weight and the queue position is returned; start runs once
+// a slot is free, and notify whenever the position changes.
+func (w *Waitlist) Admit(userID, chatID string, weight int, start func(), notify func(position int)) int {
+	w.mu.Lock()
+
+	key := waitlistKey(userID, chatID)
+	if w.capacity <= 0 || (len(w.running) < w.capacity && len(w.queue) == 0) {
+		w.running[key] = &runningSession{userID: userID, startedAt: w.scheduler.now()}
+		w.mu.Unlock()
+		return 0
+	}
+
+	entry := &waitEntry{
+		userID:     userID,
+		chatID:     chatID,
+		weight:     weight,
+		enqueuedAt: w.scheduler.now(),
+		start:      start,
+		notify:     notify,
+	}
+	w.queue = append(w.queue, entry)
+	updates := w.reorderLocked()
+	position := entry.position
+	running := len(w.running)
+	w.mu.Unlock()
+
+	w.logger.Info("deep research session queued",
+		slog.String("user_id", userID),
+		slog.String("chat_id", chatID),
+		slog.Int("weight", weight),
+		slog.Int("position", position),
+		slog.Int("running", running),
+		slog.Int("capacity", w.capacity))
+
+	for _, update := range updates {
+		if update.entry != entry {
+			update.send()
+		}
+	}
+	return position
+}
+
+// Release frees the slot of a session (no-op for sessions that weren't admitted), records its
+// backend time against the user, and starts queued sessions while slots are free.
+func (w *Waitlist) Release(userID, chatID string) {
+	w.mu.Lock()
+	key := waitlistKey(userID, chatID)
+	session, ok := w.running[key]
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+	delete(w.running, key)
+	w.scheduler.record(userID, w.scheduler.now().Sub(session.startedAt))
+	w.scheduler.prune()
+
+	var started []*waitEntry
+	for len(w.queue) > 0 && len(w.running) < w.capacity {
+		w.scheduler.sort(w.queue, w.running)
+		next := w.queue[0]
+		w.queue = w.queue[1:]
+		w.running[waitlistKey(next.userID, next.chatID)] = &runningSession{userID: next.userID, startedAt: w.scheduler.now()}
+		for _, watcher := range next.watchers {
+			close(watcher)
+		}
+		next.watchers = nil
+		started = append(started, next)
+	}
+	updates := w.reorderLocked()
+	w.mu.Unlock()
+
+	for _, entry := range started {
+		w.logger.Info("starting queued deep research session",
+			slog.String("user_id", entry.userID),
+			slog.String("chat_id", entry.chatID),
+			slog.Int("weight", entry.weight),
+			slog.Duration("waited", time.Since(entry.enqueuedAt)))
+		go entry.start()
+	}
+	for _, update := range updates {
+		update.send()
+	}
+}
+
+// Watch subscribes to the queue position of a queued session. The channel receives the current
+// position, then every change, and is closed once the session starts. Returns a nil channel when
+// the session isn't queued; stop unsubscribes.
+func (w *Waitlist) Watch(userID, chatID string) (positions <-chan int, stop func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := waitlistKey(userID, chatID)
+	for _, entry := range w.queue {
+		if waitlistKey(entry.userID, entry.chatID) != key {
+			continue
+		}
+		watcher := make(chan int, 1)
+		watcher <- entry.position
+		entry.watchers = append(entry.watchers, watcher)
+		return watcher, func() { w.unwatch(entry, watcher) }
+	}
+	return nil, func() {}
+}
+
+func (w *Waitlist) unwatch(entry *waitEntry, watcher chan int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, c := range entry.watchers {
+		if c == watcher {
+			entry.watchers = append(entry.watchers[:i], entry.watchers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Position returns the queue position of a chat's session (0 = not queued).
+func (w *Waitlist) Position(userID, chatID string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key := waitlistKey(userID, chatID)
+	for i, entry := range w.queue {
+		if waitlistKey(entry.userID, entry.chatID) == key {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// QueueLength returns the number of queued sessions.
+func (w *Waitlist) QueueLength() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.queue)
+}
+
+// positionUpdate is a queue position change to report outside the lock.
+type positionUpdate struct {
+	entry    *waitEntry
+	position int
+}
+
+func (u positionUpdate) send() {
+	if u.entry.notify != nil {
+		go u.entry.notify(u.position)
+	}
+}
+
+// reorderLocked sorts the queue and returns the entries whose position changed. Watchers get the
+// new positions right away, replacing a position they haven't read yet.
+func (w *Waitlist) reorderLocked() []positionUpdate {
+	w.scheduler.sort(w.queue, w.running)
+
+	var updates []positionUpdate
+	for i, entry := range w.queue {
+		if entry.position == i+1 {
+			continue
+		}
+		entry.position = i + 1
+		updates = append(updates, positionUpdate{entry: entry, position: entry.position})
+		for _, watcher := range entry.watchers {
+			select {
+			case <-watcher:
+			default:
+			}
+			watcher <- entry.position
+		}
+	}
+	return updates
+}
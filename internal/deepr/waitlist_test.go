@@ -0,0 +1,157 @@
+package deepr
+
+import (
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+// newTestWaitlist returns a waitlist on a manual clock.
+func newTestWaitlist(capacity int) (*Waitlist, *time.Time) {
+	w := NewWaitlist(capacity, logger.New(logger.Config{Level: slog.LevelError}))
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	w.scheduler.now = func() time.Time { return now }
+	return w, &now
+}
+
+func TestWaitlistQueuesBeyondCapacity(t *testing.T) {
+	w, now := newTestWaitlist(2)
+
+	var mu sync.Mutex
+	var started []string
+	var wg sync.WaitGroup
+	start := func(chatID string) func() {
+		return func() {
+			mu.Lock()
+			started = append(started, chatID)
+			mu.Unlock()
+			wg.Done()
+		}
+	}
+
+	if pos := w.Admit("alice", "a1", 1, start("a1"), nil); pos != 0 {
+		t.Fatalf("Admit(a1) = %d, want admitted", pos)
+	}
+	if pos := w.Admit("alice", "a2", 1, start("a2"), nil); pos != 0 {
+		t.Fatalf("Admit(a2) = %d, want admitted", pos)
+	}
+	*now = now.Add(10 * time.Minute)
+	if pos := w.Admit("alice", "a3", 1, start("a3"), nil); pos != 1 {
+		t.Fatalf("Admit(a3) = %d, want position 1", pos)
+	}
+	*now = now.Add(10 * time.Second)
+
+	// Bob has no backend usage, so he goes ahead of alice's older entry
+	if pos := w.Admit("bob", "b1", 1, start("b1"), nil); pos != 1 {
+		t.Fatalf("Admit(b1) = %d, want position 1", pos)
+	}
+	if pos := w.Position("alice", "a3"); pos != 2 {
+		t.Errorf("Position(a3) = %d, want 2", pos)
+	}
+
+	// Unknown sessions don't free slots
+	w.Release("carol", "c1")
+	if w.QueueLength() != 2 {
+		t.Fatalf("QueueLength() = %d after unknown release, want 2", w.QueueLength())
+	}
+
+	wg.Add(1)
+	w.Release("alice", "a1")
+	wg.Wait()
+	if len(started) != 1 || started[0] != "b1" {
+		t.Fatalf("started %v, want [b1]", started)
+	}
+
+	wg.Add(1)
+	w.Release("alice", "a2")
+	wg.Wait()
+	if len(started) != 2 || started[1] != "a3" || w.QueueLength() != 0 {
+		t.Fatalf("started %v (queue %d), want [b1 a3]", started, w.QueueLength())
+	}
+}
+
+func TestWaitlistUnlimited(t *testing.T) {
+	w, _ := newTestWaitlist(0)
+	for _, chatID := range []string{"1", "2", "3"} {
+		if pos := w.Admit("alice", chatID, 1, func() {}, nil); pos != 0 {
+			t.Errorf("Admit(%s) = %d, want admitted", chatID, pos)
+		}
+	}
+}
+
+func TestWaitlistFairShare(t *testing.T) {
+	w, now := newTestWaitlist(1)
+	w.Admit("runner", "r1", 1, func() {}, nil)
+
+	// Free user queued first, Pro user (weight 4) a bit later
+	w.Admit("free", "f1", 1, func() {}, nil)
+	*now = now.Add(30 * time.Second)
+	w.Admit("pro", "p1", 4, func() {}, nil)
+	if pos := w.Position("pro", "p1"); pos != 1 {
+		t.Errorf("Position(pro) = %d, want 1: higher tier weight goes first", pos)
+	}
+
+	// Waiting long enough outweighs the tier weight of newer sessions
+	*now = now.Add(5 * time.Minute)
+	w.Admit("pro2", "p2", 4, func() {}, nil)
+	if free, pro := w.Position("free", "f1"), w.Position("pro2", "p2"); free > pro {
+		t.Errorf("Position(free) = %d, Position(pro2) = %d: waiting should raise priority", free, pro)
+	}
+}
+
+func TestWaitlistRecentUsage(t *testing.T) {
+	w, now := newTestWaitlist(1)
+
+	// Heavy user ran a 2h session, light user has no usage
+	w.Admit("heavy", "h1", 1, func() {}, nil)
+	*now = now.Add(2 * time.Hour)
+	w.Release("heavy", "h1")
+
+	w.Admit("other", "o1", 1, func() {}, nil)
+	w.Admit("heavy", "h2", 1, func() {}, nil)
+	*now = now.Add(time.Second)
+	w.Admit("light", "l1", 1, func() {}, nil)
+	if pos := w.Position("light", "l1"); pos != 1 {
+		t.Errorf("Position(light) = %d, want 1: recent usage lowers priority", pos)
+	}
+
+	// Usage decays: after a day, the heavy user's earlier session doesn't count
+	if usage := w.scheduler.usage["heavy"].decay(now.Add(24 * time.Hour)); usage > 0.01 {
+		t.Errorf("usage after a day = %.3f minutes, want ~0", usage)
+	}
+}
+
+func TestWaitlistWatch(t *testing.T) {
+	w, now := newTestWaitlist(1)
+	w.Admit("alice", "a1", 1, func() {}, nil)
+	w.Admit("bob", "b1", 1, func() {}, nil)
+
+	if positions, _ := w.Watch("alice", "a1"); positions != nil {
+		t.Fatal("Watch() of a running session returned a channel")
+	}
+
+	positions, stop := w.Watch("bob", "b1")
+	defer stop()
+	if pos := <-positions; pos != 1 {
+		t.Fatalf("initial position = %d, want 1", pos)
+	}
+
+	// A higher-weight session moves bob back
+	*now = now.Add(time.Second)
+	w.Admit("carol", "c1", 4, func() {}, nil)
+	if pos := <-positions; pos != 2 {
+		t.Fatalf("position after reorder = %d, want 2", pos)
+	}
+
+	w.Release("alice", "a1") // carol starts
+	if pos := <-positions; pos != 1 {
+		t.Fatalf("position after release = %d, want 1", pos)
+	}
+	w.Release("carol", "c1") // bob starts
+	if _, ok := <-positions; ok {
+		t.Fatal("positions not closed after the session started")
+	}
+}
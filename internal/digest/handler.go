@@ -0,0 +1,122 @@
+package digest
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles HTTP requests for weekly digests.
+type Handler struct {
+	service *Service
+	logger  *logger.Logger
+}
+
+// NewHandler creates a new digest handler.
+func NewHandler(service *Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetSubscription handles GET /api/v1/digest/subscription
+// Returns the user's weekly digest opt-in.
+func (h *Handler) GetSubscription(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("digest-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	sub, err := h.service.GetSubscription(c.Request.Context(), userID)
+	if err != nil {
+		log.Error("failed to get digest subscription",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to get digest subscription", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// UpdateSubscription handles PUT /api/v1/digest/subscription
+// Opts the user in or out of weekly digests.
+func (h *Handler) UpdateSubscription(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("digest-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	var req UpdateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	verifiedEmail, _ := auth.GetVerifiedEmail(c)
+	sub, err := h.service.UpdateSubscription(c.Request.Context(), userID, verifiedEmail, &req)
+	if err != nil {
+		if stderrors.Is(err, ErrEmailNotVerified) || stderrors.Is(err, ErrEmailUnavailable) {
+			errors.BadRequest(c, err.Error(), nil)
+			return
+		}
+		log.Error("failed to update digest subscription",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to update digest subscription", nil)
+		return
+	}
+
+	log.Info("digest subscription updated",
+		slog.String("user_id", userID),
+		slog.Bool("enabled", sub.Enabled),
+		slog.Bool("email", sub.Email != nil))
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// ListDigests handles GET /api/v1/digests
+// Returns the user's most recent digests, newest first.
+func (h *Handler) ListDigests(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("digest-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	limit := DefaultListLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > MaxListLimit {
+			errors.BadRequest(c, "limit must be between 1 and "+strconv.Itoa(MaxListLimit), nil)
+			return
+		}
+		limit = parsed
+	}
+
+	digests, err := h.service.ListDigests(c.Request.Context(), userID, int32(limit))
+	if err != nil {
+		log.Error("failed to list digests",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to list digests", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, ListDigestsResponse{Digests: digests})
+}
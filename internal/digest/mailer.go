@@ -0,0 +1,79 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPMailer sends digests as plain text emails through an SMTP relay. The connection is
+// upgraded with STARTTLS when the server supports it.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a mailer sending from the from address through host:port.
+// username may be empty for relays without authentication.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		auth: auth,
+	}
+}
+
+func (m *SMTPMailer) SendDigestEmail(ctx context.Context, to string, digest *Digest) error {
+	msg := digestEmail(m.from, to, digest)
+
+	// net/smtp has no context support: send in the background and stop waiting on cancellation
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, m.from, []string{to}, msg)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send digest email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// digestEmail renders the email message of a digest.
+func digestEmail(from, to string, d *Digest) []byte {
+	var body bytes.Buffer
+	body.WriteString(d.Summary + "\r\n\r\n")
+	fmt.Fprintf(&body, "Messages: %d\r\n", d.RequestCount)
+	if d.TopModel != "" {
+		fmt.Fprintf(&body, "Most used model: %s\r\n", d.TopModel)
+	}
+	fmt.Fprintf(&body, "Research reports completed: %d\r\n", d.DeepResearchCompleted)
+	fmt.Fprintf(&body, "New tasks: %d\r\n", d.TasksCreated)
+	fmt.Fprintf(&body, "Active tasks: %d\r\n", d.ActiveTasks)
+	body.WriteString("\r\nYou can turn off weekly digests in the app settings.\r\n")
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Your week with Enchanted"))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes()
+}
@@ -0,0 +1,49 @@
+package digest
+
+import "time"
+
+const (
+	// Period is the window covered by a single digest.
+	Period = 7 * 24 * time.Hour
+
+	// DefaultListLimit is the number of digests returned by GET /api/v1/digests.
+	DefaultListLimit = 10
+
+	// MaxListLimit caps the limit query parameter.
+	MaxListLimit = 52
+)
+
+// Digest is a generated "your week with Enchanted" summary.
+type Digest struct {
+	ID                    int64     `json:"id"`
+	PeriodStart           time.Time `json:"periodStart"`
+	PeriodEnd             time.Time `json:"periodEnd"`
+	RequestCount          int64     `json:"requestCount"`
+	PlanTokens            int64     `json:"planTokens"`
+	TopModel              string    `json:"topModel,omitempty"`
+	DeepResearchCompleted int64     `json:"deepResearchCompleted"`
+	TasksCreated          int64     `json:"tasksCreated"`
+	ActiveTasks           int64     `json:"activeTasks"`
+	Summary               string    `json:"summary"`
+	CreatedAt             time.Time `json:"createdAt"`
+}
+
+// Subscription is a user's weekly digest opt-in.
+type Subscription struct {
+	Enabled      bool      `json:"enabled"`
+	Email        *string   `json:"email,omitempty"`
+	NextDigestAt time.Time `json:"nextDigestAt"`
+}
+
+// UpdateSubscriptionRequest is the body for PUT /api/v1/digest/subscription.
+type UpdateSubscriptionRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+	// Optional: also deliver digests to the verified email address of the user's account
+	// (requires a configured mailer)
+	EmailDelivery *bool `json:"emailDelivery,omitempty"`
+}
+
+// ListDigestsResponse is the response for GET /api/v1/digests.
+type ListDigestsResponse struct {
+	Digests []Digest `json:"digests"`
+}
@@ -0,0 +1,231 @@
+package digest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/notifications"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+var (
+	ErrEmailNotVerified = errors.New("the account has no verified email address")
+	ErrEmailUnavailable = errors.New("email delivery is not available")
+)
+
+// Mailer delivers digests by email.
+// Optional: when nil, digests are delivered in-app and via push only.
+type Mailer interface {
+	SendDigestEmail(ctx context.Context, to string, digest *Digest) error
+}
+
+// Service generates and delivers weekly digests.
+type Service struct {
+	queries             pgdb.Querier
+	notificationService *notifications.Service
+	mailer              Mailer
+	logger              *logger.Logger
+}
+
+// NewService creates a new digest service.
+// notificationService and mailer may be nil to disable push and email delivery.
+func NewService(queries pgdb.Querier, notificationService *notifications.Service, mailer Mailer, logger *logger.Logger) *Service {
+	return &Service{
+		queries:             queries,
+		notificationService: notificationService,
+		mailer:              mailer,
+		logger:              logger,
+	}
+}
+
+// GetSubscription returns the user's digest opt-in (disabled if never set).
+func (s *Service) GetSubscription(ctx context.Context, userID string) (*Subscription, error) {
+	sub, err := s.queries.GetDigestSubscription(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &Subscription{Enabled: false}, nil
+		}
+		return nil, fmt.Errorf("failed to get digest subscription: %w", err)
+	}
+	return toSubscription(sub), nil
+}
+
+// UpdateSubscription opts the user in or out of weekly digests. Emails only go to
+// verifiedEmail, the verified address of the user's auth token (empty if it has none), so
+// digests can't be sent to addresses the user doesn't own. Returns ErrEmailUnavailable if
+// email delivery is requested but no mailer is configured, and ErrEmailNotVerified if the
+// user has no verified address.
+func (s *Service) UpdateSubscription(ctx context.Context, userID, verifiedEmail string, req *UpdateSubscriptionRequest) (*Subscription, error) {
+	var email *string
+	if req.EmailDelivery != nil && *req.EmailDelivery {
+		if s.mailer == nil {
+			return nil, ErrEmailUnavailable
+		}
+		if verifiedEmail == "" {
+			return nil, ErrEmailNotVerified
+		}
+		email = &verifiedEmail
+	}
+
+	sub, err := s.queries.UpsertDigestSubscription(ctx, pgdb.UpsertDigestSubscriptionParams{
+		UserID:  userID,
+		Enabled: *req.Enabled,
+		Email:   email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update digest subscription: %w", err)
+	}
+	return toSubscription(sub), nil
+}
+
+// ListDigests returns the user's most recent digests, newest first.
+func (s *Service) ListDigests(ctx context.Context, userID string, limit int32) ([]Digest, error) {
+	rows, err := s.queries.ListUserDigests(ctx, pgdb.ListUserDigestsParams{
+		UserID: userID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digests: %w", err)
+	}
+
+	digests := make([]Digest, 0, len(rows))
+	for _, row := range rows {
+		digests = append(digests, toDigest(row))
+	}
+	return digests, nil
+}
+
+// Generate aggregates the user's week ending at periodEnd and stores the digest.
+func (s *Service) Generate(ctx context.Context, userID string, periodEnd time.Time) (*Digest, error) {
+	periodStart := periodEnd.Add(-Period)
+
+	stats, err := s.queries.GetUserDigestStats(ctx, pgdb.GetUserDigestStatsParams{
+		UserID:      userID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate digest stats: %w", err)
+	}
+
+	var topModel *string
+	if stats.TopModel != "" {
+		topModel = &stats.TopModel
+	}
+
+	row, err := s.queries.CreateUserDigest(ctx, pgdb.CreateUserDigestParams{
+		UserID:                userID,
+		PeriodStart:           periodStart,
+		PeriodEnd:             periodEnd,
+		RequestCount:          stats.RequestCount,
+		PlanTokens:            stats.PlanTokens,
+		TopModel:              topModel,
+		DeepResearchCompleted: stats.DeepResearchCompleted,
+		TasksCreated:          stats.TasksCreated,
+		ActiveTasks:           stats.ActiveTasks,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store digest: %w", err)
+	}
+
+	digest := toDigest(row)
+	return &digest, nil
+}
+
+// Deliver sends a stored digest via push notification and, if configured, email.
+// Quiet weeks are stored but not pushed to avoid notification noise.
+func (s *Service) Deliver(ctx context.Context, sub pgdb.DigestSubscription, digest *Digest) {
+	log := s.logger.WithContext(ctx).WithComponent("digest-service")
+
+	if !digest.hasActivity() {
+		log.Debug("skipping digest delivery for quiet week",
+			slog.String("user_id", sub.UserID),
+			slog.Int64("digest_id", digest.ID))
+		return
+	}
+
+	if s.notificationService != nil {
+		if err := s.notificationService.SendWeeklyDigestNotification(ctx, sub.UserID, digest.ID, digest.Summary); err != nil {
+			log.Warn("failed to send digest push notification",
+				slog.String("user_id", sub.UserID),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	if s.mailer != nil && sub.Email != nil {
+		if err := s.mailer.SendDigestEmail(ctx, *sub.Email, digest); err != nil {
+			log.Warn("failed to send digest email",
+				slog.String("user_id", sub.UserID),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+func toSubscription(sub pgdb.DigestSubscription) *Subscription {
+	return &Subscription{
+		Enabled:      sub.Enabled,
+		Email:        sub.Email,
+		NextDigestAt: sub.NextDigestAt,
+	}
+}
+
+func toDigest(row pgdb.UserDigest) Digest {
+	digest := Digest{
+		ID:                    row.ID,
+		PeriodStart:           row.PeriodStart,
+		PeriodEnd:             row.PeriodEnd,
+		RequestCount:          row.RequestCount,
+		PlanTokens:            row.PlanTokens,
+		DeepResearchCompleted: row.DeepResearchCompleted,
+		TasksCreated:          row.TasksCreated,
+		ActiveTasks:           row.ActiveTasks,
+		CreatedAt:             row.CreatedAt,
+	}
+	if row.TopModel != nil {
+		digest.TopModel = *row.TopModel
+	}
+	digest.Summary = summarize(&digest)
+	return digest
+}
+
+// hasActivity reports whether anything happened during the digest period.
+func (d *Digest) hasActivity() bool {
+	return d.RequestCount > 0 || d.DeepResearchCompleted > 0 || d.TasksCreated > 0
+}
+
+// summarize renders a one-line, human-readable digest summary.
+func summarize(d *Digest) string {
+	if !d.hasActivity() {
+		return "A quiet week. Enchanted is here whenever you need it."
+	}
+
+	var parts []string
+	if d.RequestCount > 0 {
+		parts = append(parts, plural(d.RequestCount, "message", "messages"))
+	}
+	if d.DeepResearchCompleted > 0 {
+		parts = append(parts, plural(d.DeepResearchCompleted, "research report", "research reports")+" completed")
+	}
+	if d.TasksCreated > 0 {
+		parts = append(parts, plural(d.TasksCreated, "new task", "new tasks"))
+	}
+
+	summary := "This week: " + strings.Join(parts, ", ") + "."
+	if d.TopModel != "" {
+		summary += " Most used model: " + d.TopModel + "."
+	}
+	return summary
+}
+
+func plural(n int64, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
@@ -0,0 +1,111 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name   string
+		digest Digest
+		want   string
+	}{
+		{
+			name:   "quiet week",
+			digest: Digest{ActiveTasks: 2},
+			want:   "A quiet week. Enchanted is here whenever you need it.",
+		},
+		{
+			name:   "messages only",
+			digest: Digest{RequestCount: 1},
+			want:   "This week: 1 message.",
+		},
+		{
+			name: "full week",
+			digest: Digest{
+				RequestCount:          42,
+				DeepResearchCompleted: 3,
+				TasksCreated:          1,
+				TopModel:              "glm-4.7",
+			},
+			want: "This week: 42 messages, 3 research reports completed, 1 new task. Most used model: glm-4.7.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarize(&tt.digest); got != tt.want {
+				t.Errorf("summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscriptionEmailRequiresMailer(t *testing.T) {
+	service := NewService(nil, nil, nil, nil)
+	enabled := true
+
+	_, err := service.UpdateSubscription(context.Background(), "user-1", "user@example.com", &UpdateSubscriptionRequest{Enabled: &enabled, EmailDelivery: &enabled})
+	if !errors.Is(err, ErrEmailUnavailable) {
+		t.Errorf("UpdateSubscription() error = %v, want ErrEmailUnavailable", err)
+	}
+}
+
+// subscriptionStore stores one digest subscription; other Querier methods are not used.
+type subscriptionStore struct {
+	pgdb.Querier
+	sub pgdb.DigestSubscription
+}
+
+func (s *subscriptionStore) UpsertDigestSubscription(_ context.Context, arg pgdb.UpsertDigestSubscriptionParams) (pgdb.DigestSubscription, error) {
+	s.sub = pgdb.DigestSubscription{UserID: arg.UserID, Enabled: arg.Enabled, Email: arg.Email}
+	return s.sub, nil
+}
+
+// nopMailer discards digest emails.
+type nopMailer struct{}
+
+func (nopMailer) SendDigestEmail(context.Context, string, *Digest) error { return nil }
+
+func TestUpdateSubscriptionUsesVerifiedEmail(t *testing.T) {
+	store := &subscriptionStore{}
+	service := NewService(store, nil, nopMailer{}, nil)
+	enabled := true
+	req := &UpdateSubscriptionRequest{Enabled: &enabled, EmailDelivery: &enabled}
+
+	if _, err := service.UpdateSubscription(context.Background(), "user-1", "", req); !errors.Is(err, ErrEmailNotVerified) {
+		t.Errorf("UpdateSubscription() without a verified email error = %v, want ErrEmailNotVerified", err)
+	}
+
+	sub, err := service.UpdateSubscription(context.Background(), "user-1", "user@example.com", req)
+	if err != nil {
+		t.Fatalf("UpdateSubscription() error = %v", err)
+	}
+	if sub.Email == nil || *sub.Email != "user@example.com" {
+		t.Errorf("subscription email = %v, want user@example.com", sub.Email)
+	}
+}
+
+func TestDigestEmail(t *testing.T) {
+	digest := Digest{RequestCount: 42, TopModel: "glm-4.7"}
+	digest.Summary = summarize(&digest)
+
+	msg := string(digestEmail("digest@example.com", "user@example.com", &digest))
+	headers, body, ok := strings.Cut(msg, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("message has no header/body separator: %q", msg)
+	}
+	for _, header := range []string{"From: digest@example.com", "To: user@example.com", "Subject: Your week with Enchanted", "Content-Type: text/plain; charset=utf-8"} {
+		if !strings.Contains(headers, header+"\r\n") {
+			t.Errorf("headers missing %q:\n%s", header, headers)
+		}
+	}
+	if !strings.HasPrefix(body, digest.Summary) || !strings.Contains(body, "Messages: 42\r\n") {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+}
@@ -0,0 +1,93 @@
+package digest
+
+import (
+	"context"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// Worker periodically generates weekly digests for opted-in users.
+//
+// Subscriptions are claimed atomically (next_digest_at is advanced in the same
+// statement), so running a worker on every proxy instance is safe.
+type Worker struct {
+	queries   pgdb.Querier
+	service   *Service
+	logger    *logger.Logger
+	interval  time.Duration
+	batchSize int32
+}
+
+func NewWorker(queries pgdb.Querier, service *Service, logger *logger.Logger) *Worker {
+	return &Worker{
+		queries:   queries,
+		service:   service,
+		logger:    logger,
+		interval:  15 * time.Minute,
+		batchSize: 50,
+	}
+}
+
+// Run starts the digest worker loop.
+func (w *Worker) Run(ctx context.Context) {
+	w.logger.Info("starting weekly digest worker", "interval", w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run immediately on startup
+	w.processDue(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("weekly digest worker stopped")
+			return
+		case <-ticker.C:
+			w.processDue(ctx)
+		}
+	}
+}
+
+func (w *Worker) processDue(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		queryCtx, queryCancel := context.WithTimeout(ctx, 30*time.Second)
+		subs, err := w.queries.ClaimDueDigestSubscriptions(queryCtx, w.batchSize)
+		queryCancel()
+		if err != nil {
+			w.logger.Error("failed to claim due digest subscriptions", "error", err.Error())
+			return
+		}
+
+		if len(subs) == 0 {
+			return
+		}
+
+		w.logger.Info("generating weekly digests", "count", len(subs))
+
+		for _, sub := range subs {
+			genCtx, genCancel := context.WithTimeout(ctx, 30*time.Second)
+			digest, err := w.service.Generate(genCtx, sub.UserID, time.Now())
+			if err != nil {
+				w.logger.Error("failed to generate weekly digest", "error", err.Error(), "user_id", sub.UserID)
+				genCancel()
+				continue
+			}
+			w.service.Deliver(genCtx, sub, digest)
+			genCancel()
+			w.logger.Info("weekly digest generated", "digest_id", digest.ID, "user_id", sub.UserID)
+		}
+
+		if int32(len(subs)) < w.batchSize {
+			return
+		}
+	}
+}
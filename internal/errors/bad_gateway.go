@@ -0,0 +1,18 @@
+package errors
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AbortWithBadGateway sends a 502 Bad Gateway response and aborts the request.
+// Use when a third-party server the request depends on failed.
+func AbortWithBadGateway(c *gin.Context, message string, details map[string]interface{}) {
+	respond(c, http.StatusBadGateway, NewAPIError(message, details), true)
+}
+
+// BadGateway sends a 502 Bad Gateway response without aborting.
+func BadGateway(c *gin.Context, message string, details map[string]interface{}) {
+	respond(c, http.StatusBadGateway, NewAPIError(message, details), false)
+}
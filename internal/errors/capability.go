@@ -0,0 +1,36 @@
+package errors
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModelCapabilityError represents a 409 returned instead of forwarding a request that needs a
+// capability the requested model lacks (streaming, tools, image input or a larger context
+// window), with the models the client can switch to.
+type ModelCapabilityError struct {
+	Error           string   `json:"error"`
+	Model           string   `json:"model"`
+	Capability      string   `json:"capability"`       // "streaming", "tools", "vision" or "context"
+	SuggestedModels []string `json:"suggested_models"` // Models meeting every requirement of the request, best first
+}
+
+// ModelCapabilityMissing creates a ModelCapabilityError.
+func ModelCapabilityMissing(model, capability, reason string, suggestedModels []string) *ModelCapabilityError {
+	if suggestedModels == nil {
+		suggestedModels = []string{}
+	}
+	return &ModelCapabilityError{
+		Error:           "Request not supported by model: " + reason,
+		Model:           model,
+		Capability:      capability,
+		SuggestedModels: suggestedModels,
+	}
+}
+
+// AbortWithModelCapability sends a 409 response with the ModelCapabilityError and aborts the
+// request.
+func AbortWithModelCapability(c *gin.Context, err *ModelCapabilityError) {
+	respond(c, http.StatusConflict, err, true)
+}
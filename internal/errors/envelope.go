@@ -0,0 +1,127 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/apiversion"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorEnvelope is the error response of API v2: every error, whatever its v1 shape, is
+// nested under "error" with a machine-readable code.
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody is the error of an ErrorEnvelope.
+type ErrorBody struct {
+	Code      string                 `json:"code"`                 // Forbidden reason, rate_limited, or derived from the status (e.g. "not_found")
+	Message   string                 `json:"message"`              // Technical error message (for logs)
+	UIMessage string                 `json:"ui_message,omitempty"` // User-friendly message (for UI display), if any
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// enveloper is an error response that can be nested in an ErrorEnvelope.
+type enveloper interface {
+	envelope(status int) ErrorBody
+}
+
+// respond writes an error response in the format of the request's API version.
+func respond(c *gin.Context, status int, err enveloper, abort bool) {
+	var body interface{} = err
+	if apiversion.FromGin(c).ErrorEnvelope {
+		body = ErrorEnvelope{Error: err.envelope(status)}
+	}
+	if abort {
+		c.AbortWithStatusJSON(status, body)
+		return
+	}
+	c.JSON(status, body)
+}
+
+// statusCode derives an error code from an HTTP status ("Not Found" → "not_found").
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+func (e *APIError) envelope(status int) ErrorBody {
+	return ErrorBody{Code: statusCode(status), Message: e.Error, Details: e.Details}
+}
+
+func (e *ForbiddenError) envelope(int) ErrorBody {
+	details := e.Details
+	if e.Tier != "" {
+		details = withDetail(details, "tier", e.Tier)
+	}
+	return ErrorBody{Code: string(e.Reason), Message: e.Error, UIMessage: e.UIMessage, Details: details}
+}
+
+func (e *RateLimitError) envelope(int) ErrorBody {
+	return ErrorBody{
+		Code:    "rate_limited",
+		Message: e.Error,
+		Details: map[string]interface{}{
+			"tier":            e.Tier,
+			"rate_limit_type": e.RateLimitType,
+			"limit":           e.Limit,
+			"used":            e.Used,
+			"resets_at":       e.ResetsAt.Format(time.RFC3339),
+		},
+	}
+}
+
+func (e *UpstreamRateLimitError) envelope(int) ErrorBody {
+	details := withDetail(e.Details, "rate_limit_type", e.RateLimitType)
+	details["retry_after"] = e.RetryAfter
+	if e.Model != "" {
+		details["model"] = e.Model
+	}
+	return ErrorBody{Code: "upstream_rate_limited", Message: e.Error, Details: details}
+}
+
+func (e *ProviderUnavailableError) envelope(int) ErrorBody {
+	details := map[string]interface{}{"retry_after": e.RetryAfter}
+	if e.Model != "" {
+		details["model"] = e.Model
+	}
+	return ErrorBody{Code: "provider_unavailable", Message: e.Error, Details: details}
+}
+
+func (e *ModelCapabilityError) envelope(int) ErrorBody {
+	return ErrorBody{
+		Code:    "model_capability_unsupported",
+		Message: e.Error,
+		Details: map[string]interface{}{
+			"model":            e.Model,
+			"capability":       e.Capability,
+			"suggested_models": e.SuggestedModels,
+		},
+	}
+}
+
+func (e *UpgradeRequiredError) envelope(int) ErrorBody {
+	details := map[string]interface{}{
+		"rule":        e.Rule,
+		"platform":    e.Platform,
+		"min_version": e.MinVersion,
+	}
+	if e.ClientVersion != "" {
+		details["client_version"] = e.ClientVersion
+	}
+	if e.UpgradeURL != "" {
+		details["upgrade_url"] = e.UpgradeURL
+	}
+	return ErrorBody{Code: "upgrade_required", Message: e.Error, UIMessage: e.UIMessage, Details: details}
+}
+
+// withDetail returns a copy of details with key set.
+func withDetail(details map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(details)+1)
+	for k, v := range details {
+		copied[k] = v
+	}
+	copied[key] = value
+	return copied
+}
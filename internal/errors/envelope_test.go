@@ -0,0 +1,54 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/apiversion"
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	respondAs := func(version apiversion.Version, write func(*gin.Context)) map[string]interface{} {
+		t.Helper()
+		router := gin.New()
+		router.Use(apiversion.Negotiate(apiversion.Deprecation{}))
+		router.GET("/api/"+version.String()+"/thing", write)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/"+version.String()+"/thing", nil))
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	notFound := func(c *gin.Context) { NotFound(c, "Stream not found", map[string]interface{}{"message_id": "m1"}) }
+	if got, want := respondAs(apiversion.V1, notFound), map[string]interface{}{
+		"error":   "Stream not found",
+		"details": map[string]interface{}{"message_id": "m1"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("v1 = %v, want %v", got, want)
+	}
+	if got, want := respondAs(apiversion.V2, notFound), map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    "not_found",
+			"message": "Stream not found",
+			"details": map[string]interface{}{"message_id": "m1"},
+		},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("v2 = %v, want %v", got, want)
+	}
+
+	forbidden := func(c *gin.Context) { AbortWithForbidden(c, FeatureNotAllowed("voice", "free", "Free", "pro")) }
+	got := respondAs(apiversion.V2, forbidden)["error"].(map[string]interface{})
+	details, _ := got["details"].(map[string]interface{})
+	if got["code"] != "feature_not_allowed" || got["ui_message"] == "" || details["tier"] != "free" || details["feature"] != "voice" {
+		t.Errorf("v2 forbidden = %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
@@ -14,6 +15,7 @@ const (
 	// Rate Limiting & Quotas
 	ReasonModelNotAllowed   ForbiddenReason = "model_not_allowed"
 	ReasonFeatureNotAllowed ForbiddenReason = "feature_not_allowed"
+	ReasonChoicesNotAllowed ForbiddenReason = "choices_not_allowed"
 
 	// Deep Research
 	ReasonActiveDeepResearchSession ForbiddenReason = "active_deep_research_session"
@@ -26,6 +28,19 @@ const (
 	ReasonSessionNotFound   ForbiddenReason = "session_not_found"
 	ReasonInviteAlreadyUsed ForbiddenReason = "invite_already_used"
 	ReasonInviteWrongUser   ForbiddenReason = "invite_wrong_user"
+	ReasonOriginNotAllowed  ForbiddenReason = "origin_not_allowed"
+	ReasonAccountBanned     ForbiddenReason = "account_banned"
+	ReasonScopeNotAllowed   ForbiddenReason = "scope_not_allowed"
+
+	// Device Attestation
+	ReasonDeviceAttestationRequired ForbiddenReason = "device_attestation_required"
+	ReasonDeviceAttestationFailed   ForbiddenReason = "device_attestation_failed"
+
+	// Compliance
+	ReasonRegionRestricted ForbiddenReason = "region_restricted"
+
+	// Content Policy
+	ReasonContentPolicyViolation ForbiddenReason = "content_policy_violation"
 
 	// Subscription/Tier
 	ReasonTierValidationFailed ForbiddenReason = "tier_validation_failed"
@@ -54,7 +69,7 @@ func NewForbiddenError(reason ForbiddenReason, errorMsg, uiMessage, tier string,
 
 // AbortWithForbidden sends a 403 response with the ForbiddenError and aborts the request.
 func AbortWithForbidden(c *gin.Context, err *ForbiddenError) {
-	c.AbortWithStatusJSON(http.StatusForbidden, err)
+	respond(c, http.StatusForbidden, err, true)
 }
 
 // ModelNotAllowed creates a ForbiddenError for model access denial.
@@ -97,10 +112,36 @@ func FeatureNotAllowed(feature, tier, displayName, requiredTier string) *Forbidd
 	)
 }
 
+// ChoicesNotAllowed creates a ForbiddenError for a request asking for more choices (n) or
+// best-of candidates (best_of) than the tier allows.
+func ChoicesNotAllowed(parameter string, requested, limit int, tier, displayName string) *ForbiddenError {
+	errorMsg := fmt.Sprintf("%s=%d exceeds the limit of %d for %s tier", parameter, requested, limit, displayName)
+	uiMsg := "Generating multiple responses at once is limited on your current plan."
+	if limit <= 1 {
+		uiMsg = "Generating multiple responses at once is not available on your current plan. Upgrade to unlock it."
+	}
+
+	return NewForbiddenError(
+		ReasonChoicesNotAllowed,
+		errorMsg,
+		uiMsg,
+		tier,
+		map[string]interface{}{
+			"parameter": parameter,
+			"requested": requested,
+			"limit":     limit,
+		},
+	)
+}
+
 // ActiveDeepResearchSession creates a ForbiddenError for active session limit.
 func ActiveDeepResearchSession(tier, displayName string, maxActive int) *ForbiddenError {
 	errorMsg := "You have an active deep research session. Please complete or cancel it before starting a new one."
 	uiMsg := "You already have an active deep research session. Please finish or cancel it first."
+	if maxActive > 1 {
+		errorMsg = fmt.Sprintf("You have %d active deep research sessions, the maximum for your plan. Please complete or cancel one before starting a new one.", maxActive)
+		uiMsg = fmt.Sprintf("You can run up to %d deep research sessions at a time. Please wait for one to finish.", maxActive)
+	}
 
 	return NewForbiddenError(
 		ReasonActiveDeepResearchSession,
@@ -226,6 +267,108 @@ func InviteWrongUser() *ForbiddenError {
 	)
 }
 
+// DeviceAttestationRequired creates a ForbiddenError for requests without attestation evidence.
+func DeviceAttestationRequired() *ForbiddenError {
+	return NewForbiddenError(
+		ReasonDeviceAttestationRequired,
+		"Device attestation required",
+		"Please update to the latest version of the app to continue.",
+		"",
+		nil,
+	)
+}
+
+// DeviceAttestationFailed creates a ForbiddenError for attestation that could not be verified.
+func DeviceAttestationFailed(errorDetail string) *ForbiddenError {
+	return NewForbiddenError(
+		ReasonDeviceAttestationFailed,
+		"Device attestation failed: "+errorDetail,
+		"We couldn't verify your device. Please try again from the official app.",
+		"",
+		map[string]interface{}{
+			"error": errorDetail,
+		},
+	)
+}
+
+// AccountBanned creates a ForbiddenError for requests of a user banned via the admin API.
+func AccountBanned() *ForbiddenError {
+	return NewForbiddenError(
+		ReasonAccountBanned,
+		"User is banned",
+		"This account has been suspended. Please contact support.",
+		"",
+		nil,
+	)
+}
+
+// ScopeNotAllowed creates a ForbiddenError for service API keys calling a route outside their scopes.
+func ScopeNotAllowed(scopes []string) *ForbiddenError {
+	return NewForbiddenError(
+		ReasonScopeNotAllowed,
+		"API key scopes don't allow this route",
+		"This API key can't access this endpoint.",
+		"",
+		map[string]interface{}{
+			"scopes": scopes,
+		},
+	)
+}
+
+// OriginNotAllowed creates a ForbiddenError for websocket upgrades from an origin that isn't allowlisted.
+func OriginNotAllowed(origin string) *ForbiddenError {
+	return NewForbiddenError(
+		ReasonOriginNotAllowed,
+		"Websocket origin "+origin+" is not allowed",
+		"This connection isn't allowed from this site.",
+		"",
+		map[string]interface{}{
+			"origin": origin,
+		},
+	)
+}
+
+// RegionRestricted creates a ForbiddenError for requests blocked by the country compliance policy.
+func RegionRestricted(country, rule string) *ForbiddenError {
+	return NewForbiddenError(
+		ReasonRegionRestricted,
+		"Request blocked by compliance rule "+rule+" for country "+country,
+		"This feature isn't available in your region.",
+		"",
+		map[string]interface{}{
+			"country": country,
+			"rule":    rule,
+		},
+	)
+}
+
+// ContentPolicyViolation creates a ForbiddenError for input blocked by the account's content policy.
+func ContentPolicyViolation(policy string, categories []string) *ForbiddenError {
+	return NewForbiddenError(
+		ReasonContentPolicyViolation,
+		"Request blocked by content policy "+policy,
+		"This message isn't allowed with the content settings on this account.",
+		"",
+		map[string]interface{}{
+			"policy":     policy,
+			"categories": categories,
+		},
+	)
+}
+
+// ModerationViolation creates a ForbiddenError for input blocked by pre-flight moderation.
+func ModerationViolation(categories []string) *ForbiddenError {
+	return NewForbiddenError(
+		ReasonContentPolicyViolation,
+		"Request blocked by moderation",
+		"This message isn't allowed by our content guidelines.",
+		"",
+		map[string]interface{}{
+			"categories": categories,
+		},
+	)
+}
+
 // TierValidationFailed creates a ForbiddenError for subscription validation failures.
 func TierValidationFailed(errorDetail string) *ForbiddenError {
 	return NewForbiddenError(
@@ -8,10 +8,10 @@ import (
 
 // AbortWithNotFound sends a 404 Not Found response and aborts the request.
 func AbortWithNotFound(c *gin.Context, message string, details map[string]interface{}) {
-	c.AbortWithStatusJSON(http.StatusNotFound, NewAPIError(message, details))
+	respond(c, http.StatusNotFound, NewAPIError(message, details), true)
 }
 
 // NotFound sends a 404 Not Found response without aborting.
 func NotFound(c *gin.Context, message string, details map[string]interface{}) {
-	c.JSON(http.StatusNotFound, NewAPIError(message, details))
+	respond(c, http.StatusNotFound, NewAPIError(message, details), false)
 }
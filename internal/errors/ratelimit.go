@@ -2,6 +2,7 @@ package errors
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,13 +12,14 @@ import (
 type RateLimitType string
 
 const (
-	RateLimitTypeSoft RateLimitType = "soft"
-	RateLimitTypeHard RateLimitType = "hard"
+	RateLimitTypeSoft     RateLimitType = "soft"
+	RateLimitTypeHard     RateLimitType = "hard"
+	RateLimitTypeUpstream RateLimitType = "upstream" // Provider rate limit; retry after RetryAfter seconds
 )
 
 // RateLimitError represents a standardized 429 Too Many Requests response.
-// All rate limit responses from this proxy include rate_limit_type to distinguish
-// from upstream provider 429s (which are passed through without this field).
+// All rate limit responses from this proxy include rate_limit_type; quota limits use
+// soft/hard, upstream provider 429s are normalized into UpstreamRateLimitError.
 type RateLimitError struct {
 	Error         string        `json:"error"`
 	Tier          string        `json:"tier"`
@@ -29,7 +31,7 @@ type RateLimitError struct {
 
 // AbortWithRateLimit sends a 429 response with the RateLimitError and aborts the request.
 func AbortWithRateLimit(c *gin.Context, err *RateLimitError) {
-	c.AbortWithStatusJSON(http.StatusTooManyRequests, err)
+	respond(c, http.StatusTooManyRequests, err, true)
 }
 
 // DailyLimitExceeded creates a RateLimitError for daily quota exhaustion.
@@ -79,3 +81,49 @@ func FallbackLimitExceeded(tier, displayName string, limit, used int64, resetsAt
 		ResetsAt:      resetsAt,
 	}
 }
+
+// AudioLimitExceeded creates a RateLimitError for audio quota exhaustion. Limit and used are
+// in minutes; period is "daily" or "monthly".
+func AudioLimitExceeded(tier, displayName, period string, limitMinutes, usedMinutes int64, resetsAt time.Time) *RateLimitError {
+	return &RateLimitError{
+		Error:         displayName + " " + period + " audio minute limit exceeded",
+		Tier:          tier,
+		RateLimitType: RateLimitTypeHard,
+		Limit:         limitMinutes,
+		Used:          usedMinutes,
+		ResetsAt:      resetsAt,
+	}
+}
+
+// UpstreamRateLimitError represents a 429 caused by an upstream provider rate limit.
+// Sent with a matching Retry-After header.
+type UpstreamRateLimitError struct {
+	Error         string                 `json:"error"`
+	RateLimitType RateLimitType          `json:"rate_limit_type"`
+	Model         string                 `json:"model,omitempty"`
+	RetryAfter    int                    `json:"retry_after"` // Seconds the client should wait before retrying
+	Details       map[string]interface{} `json:"details,omitempty"`
+}
+
+// UpstreamRateLimited creates an UpstreamRateLimitError.
+// upstreamMessage is the provider's error message, if any.
+func UpstreamRateLimited(model string, retryAfter time.Duration, upstreamMessage string) *UpstreamRateLimitError {
+	var details map[string]interface{}
+	if upstreamMessage != "" {
+		details = map[string]interface{}{"upstream_error": upstreamMessage}
+	}
+	return &UpstreamRateLimitError{
+		Error:         "upstream provider rate limit exceeded",
+		RateLimitType: RateLimitTypeUpstream,
+		Model:         model,
+		RetryAfter:    int((retryAfter + time.Second - 1) / time.Second),
+		Details:       details,
+	}
+}
+
+// AbortWithUpstreamRateLimit sends a 429 response with the UpstreamRateLimitError and a
+// Retry-After header, and aborts the request.
+func AbortWithUpstreamRateLimit(c *gin.Context, err *UpstreamRateLimitError) {
+	c.Header("Retry-After", strconv.Itoa(err.RetryAfter))
+	respond(c, http.StatusTooManyRequests, err, true)
+}
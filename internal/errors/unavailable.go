@@ -0,0 +1,34 @@
+package errors
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProviderUnavailableError represents a 503 returned instead of sending a request to an
+// upstream provider that is failing (its circuit breaker is open).
+// Sent with a matching Retry-After header.
+type ProviderUnavailableError struct {
+	Error      string `json:"error"`
+	Model      string `json:"model,omitempty"`
+	RetryAfter int    `json:"retry_after"` // Seconds the client should wait before retrying
+}
+
+// ProviderUnavailable creates a ProviderUnavailableError.
+func ProviderUnavailable(model string, retryAfter time.Duration) *ProviderUnavailableError {
+	return &ProviderUnavailableError{
+		Error:      "upstream provider temporarily unavailable",
+		Model:      model,
+		RetryAfter: int((retryAfter + time.Second - 1) / time.Second),
+	}
+}
+
+// AbortWithProviderUnavailable sends a 503 response with the ProviderUnavailableError and a
+// Retry-After header, and aborts the request.
+func AbortWithProviderUnavailable(c *gin.Context, err *ProviderUnavailableError) {
+	c.Header("Retry-After", strconv.Itoa(err.RetryAfter))
+	respond(c, http.StatusServiceUnavailable, err, true)
+}
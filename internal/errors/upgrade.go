@@ -0,0 +1,42 @@
+package errors
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpgradeRequiredError represents a 426 returned to app versions too old for a route, so apps
+// can prompt the user to update.
+type UpgradeRequiredError struct {
+	Error         string `json:"error"`
+	UIMessage     string `json:"uiMessage"`
+	Rule          string `json:"rule"`
+	Platform      string `json:"platform"`
+	ClientVersion string `json:"client_version,omitempty"` // Empty when the client didn't send X-Client-Version
+	MinVersion    string `json:"min_version"`
+	UpgradeURL    string `json:"upgrade_url,omitempty"`
+}
+
+// UpgradeRequired creates an UpgradeRequiredError.
+func UpgradeRequired(rule, platform, clientVersion, minVersion, upgradeURL string) *UpgradeRequiredError {
+	errorMsg := "Client version " + clientVersion + " is below the minimum " + minVersion + " for " + platform
+	if clientVersion == "" {
+		errorMsg = "Client version missing; " + platform + " requires " + minVersion
+	}
+	return &UpgradeRequiredError{
+		Error:         errorMsg,
+		UIMessage:     "Please update to the latest version of the app to continue.",
+		Rule:          rule,
+		Platform:      platform,
+		ClientVersion: clientVersion,
+		MinVersion:    minVersion,
+		UpgradeURL:    upgradeURL,
+	}
+}
+
+// AbortWithUpgradeRequired sends a 426 response with the UpgradeRequiredError and aborts the
+// request.
+func AbortWithUpgradeRequired(c *gin.Context, err *UpgradeRequiredError) {
+	respond(c, http.StatusUpgradeRequired, err, true)
+}
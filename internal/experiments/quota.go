@@ -0,0 +1,169 @@
+package experiments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
+)
+
+// Variants a user is assigned to in a quota experiment.
+const (
+	VariantTreatment = "treatment"
+	VariantControl   = "control"
+)
+
+// cohortBuckets is the resolution of cohort assignment (0.01%).
+const cohortBuckets = 10_000
+
+// maxLoggedExposures bounds the set of exposures this instance has already queued. When full it
+// is reset; re-recording an exposure is a no-op in the database.
+const maxLoggedExposures = 100_000
+
+// QuotaService applies cohort quota experiments to tier limits and logs each user's first
+// exposure to quota_experiment_exposures.
+type QuotaService struct {
+	experiments []config.QuotaExperimentConfig
+	queries     pgdb.Querier
+	pool        *worker.Pool[pgdb.RecordQuotaExperimentExposureParams]
+	logger      *logger.Logger
+	now         func() time.Time
+
+	mu     sync.Mutex
+	logged map[string]struct{}
+}
+
+// NewQuotaService validates the configured experiments and starts the exposure writer.
+func NewQuotaService(queries pgdb.Querier, experiments []config.QuotaExperimentConfig, logger *logger.Logger) (*QuotaService, error) {
+	names := make(map[string]bool, len(experiments))
+	for _, exp := range experiments {
+		if names[exp.Name] {
+			return nil, fmt.Errorf("duplicate quota experiment %q", exp.Name)
+		}
+		names[exp.Name] = true
+		if _, ok := tiers.Configs[tiers.Tier(exp.Tier)]; !ok {
+			return nil, fmt.Errorf("quota experiment %q: unknown tier %q", exp.Name, exp.Tier)
+		}
+	}
+
+	s := &QuotaService{
+		experiments: experiments,
+		queries:     queries,
+		logger:      logger,
+		now:         time.Now,
+		logged:      make(map[string]struct{}),
+	}
+	s.pool = worker.NewPool(worker.Options{
+		Name:      "quota_experiment_exposures",
+		Workers:   1,
+		QueueSize: 1000,
+		Timeout:   5 * time.Second,
+	}, s.handleExposure, logger)
+
+	return s, nil
+}
+
+// Apply returns cfg with the overrides of every running experiment on the user's tier whose
+// treatment cohort includes the user. Overlapping experiments apply in config order. Both
+// treatment and control exposures are logged.
+func (s *QuotaService) Apply(userID string, tier tiers.Tier, cfg tiers.Config) tiers.Config {
+	now := s.now()
+	for i := range s.experiments {
+		exp := &s.experiments[i]
+		if exp.Tier != string(tier) || !exp.Active(now) {
+			continue
+		}
+
+		variant := VariantControl
+		if InCohort(exp.Name, userID, exp.Percent) {
+			variant = VariantTreatment
+			cfg = applyOverrides(cfg, exp.Overrides)
+		}
+		s.logExposure(pgdb.RecordQuotaExperimentExposureParams{
+			Experiment: exp.Name,
+			UserID:     userID,
+			Variant:    variant,
+			Tier:       string(tier),
+		})
+	}
+	return cfg
+}
+
+// Shutdown drains queued exposures until ctx is done.
+func (s *QuotaService) Shutdown(ctx context.Context) error {
+	return s.pool.Shutdown(ctx)
+}
+
+// InCohort reports whether the user falls in the first percent of the experiment's buckets.
+// Assignment is stable for a given experiment name and independent across experiments.
+func InCohort(experiment, userID string, percent float64) bool {
+	sum := sha256.Sum256([]byte(experiment + "/" + userID))
+	bucket := binary.BigEndian.Uint64(sum[:8]) % cohortBuckets
+	return bucket < uint64(percent*cohortBuckets/100)
+}
+
+func applyOverrides(cfg tiers.Config, o config.QuotaOverrides) tiers.Config {
+	if o.MonthlyPlanTokens != nil {
+		cfg.MonthlyPlanTokens = *o.MonthlyPlanTokens
+	}
+	if o.WeeklyPlanTokens != nil {
+		cfg.WeeklyPlanTokens = *o.WeeklyPlanTokens
+	}
+	if o.DailyPlanTokens != nil {
+		cfg.DailyPlanTokens = *o.DailyPlanTokens
+	}
+	if o.MonthlyAudioMinutes != nil {
+		cfg.MonthlyAudioMinutes = *o.MonthlyAudioMinutes
+	}
+	if o.DailyAudioMinutes != nil {
+		cfg.DailyAudioMinutes = *o.DailyAudioMinutes
+	}
+	if o.DeepResearchDailyRuns != nil {
+		cfg.DeepResearchDailyRuns = *o.DeepResearchDailyRuns
+	}
+	if o.DeepResearchLifetimeRuns != nil {
+		cfg.DeepResearchLifetimeRuns = *o.DeepResearchLifetimeRuns
+	}
+	if o.DeepResearchTokenCap != nil {
+		cfg.DeepResearchTokenCap = *o.DeepResearchTokenCap
+	}
+	if o.DeepResearchMaxActiveSessions != nil {
+		cfg.DeepResearchMaxActiveSessions = *o.DeepResearchMaxActiveSessions
+	}
+	return cfg
+}
+
+// logExposure queues an exposure unless this instance already did. Exposures dropped on a
+// full queue are retried on the user's next request.
+func (s *QuotaService) logExposure(exposure pgdb.RecordQuotaExperimentExposureParams) {
+	key := exposure.Experiment + "\x00" + exposure.UserID
+
+	s.mu.Lock()
+	if _, ok := s.logged[key]; ok {
+		s.mu.Unlock()
+		return
+	}
+	if len(s.logged) >= maxLoggedExposures {
+		s.logged = make(map[string]struct{})
+	}
+	s.logged[key] = struct{}{}
+	s.mu.Unlock()
+
+	if err := s.pool.TryEnqueue(exposure); err != nil {
+		s.mu.Lock()
+		delete(s.logged, key)
+		s.mu.Unlock()
+	}
+}
+
+func (s *QuotaService) handleExposure(ctx context.Context, exposure pgdb.RecordQuotaExperimentExposureParams) error {
+	return s.queries.RecordQuotaExperimentExposure(ctx, exposure)
+}
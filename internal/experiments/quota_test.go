@@ -0,0 +1,220 @@
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+)
+
+// exposureRecorder records exposures; other Querier methods are not used.
+type exposureRecorder struct {
+	pgdb.Querier
+	mu        sync.Mutex
+	exposures []pgdb.RecordQuotaExperimentExposureParams
+}
+
+func (r *exposureRecorder) RecordQuotaExperimentExposure(_ context.Context, arg pgdb.RecordQuotaExperimentExposureParams) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exposures = append(r.exposures, arg)
+	return nil
+}
+
+func TestInCohort(t *testing.T) {
+	const users = 10_000
+	in := 0
+	for i := 0; i < users; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		if InCohort("free-dr-2x", userID, 20) {
+			in++
+		}
+		if InCohort("free-dr-2x", userID, 20) != InCohort("free-dr-2x", userID, 20) {
+			t.Fatalf("InCohort(%s) is not stable", userID)
+		}
+	}
+	if in < 1800 || in > 2200 {
+		t.Errorf("20%% cohort has %d of %d users", in, users)
+	}
+
+	if !InCohort("any", "user-1", 100) {
+		t.Error("100% cohort excludes a user")
+	}
+}
+
+func TestQuotaServiceApply(t *testing.T) {
+	dailyRuns, lifetimeRuns := 2, 0
+	starts := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	ends := starts.AddDate(0, 1, 0)
+	exp := config.QuotaExperimentConfig{
+		Name:     "free-dr-2x",
+		Tier:     "free",
+		Percent:  50,
+		StartsAt: &starts,
+		EndsAt:   &ends,
+		Overrides: config.QuotaOverrides{
+			DeepResearchDailyRuns:    &dailyRuns,
+			DeepResearchLifetimeRuns: &lifetimeRuns,
+		},
+	}
+
+	// Find one user on each side of the 50% split.
+	var treated, control string
+	for i := 0; treated == "" || control == ""; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		if InCohort(exp.Name, userID, exp.Percent) {
+			treated = userID
+		} else {
+			control = userID
+		}
+	}
+
+	free := tiers.Configs[tiers.TierFree]
+	pro := tiers.Configs[tiers.TierPro]
+
+	tests := []struct {
+		name          string
+		userID        string
+		tier          tiers.Tier
+		now           time.Time
+		wantDaily     int
+		wantLifetime  int
+		wantExposures []string
+	}{
+		{name: "treatment", userID: treated, tier: tiers.TierFree, now: starts, wantDaily: 2, wantLifetime: 0, wantExposures: []string{VariantTreatment}},
+		{name: "control", userID: control, tier: tiers.TierFree, now: starts, wantDaily: free.DeepResearchDailyRuns, wantLifetime: free.DeepResearchLifetimeRuns, wantExposures: []string{VariantControl}},
+		{name: "other tier", userID: treated, tier: tiers.TierPro, now: starts, wantDaily: pro.DeepResearchDailyRuns, wantLifetime: pro.DeepResearchLifetimeRuns},
+		{name: "before start", userID: treated, tier: tiers.TierFree, now: starts.Add(-time.Second), wantDaily: free.DeepResearchDailyRuns, wantLifetime: free.DeepResearchLifetimeRuns},
+		{name: "after end", userID: treated, tier: tiers.TierFree, now: ends, wantDaily: free.DeepResearchDailyRuns, wantLifetime: free.DeepResearchLifetimeRuns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &exposureRecorder{}
+			s, err := NewQuotaService(recorder, []config.QuotaExperimentConfig{exp}, logger.New(logger.Config{Level: slog.LevelError}))
+			if err != nil {
+				t.Fatalf("NewQuotaService() error = %v", err)
+			}
+			s.now = func() time.Time { return tt.now }
+
+			base := tiers.Configs[tt.tier]
+			var got tiers.Config
+			for i := 0; i < 3; i++ {
+				got = s.Apply(tt.userID, tt.tier, base)
+			}
+			if err := s.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+
+			if got.DeepResearchDailyRuns != tt.wantDaily || got.DeepResearchLifetimeRuns != tt.wantLifetime {
+				t.Errorf("deep research runs = %d daily, %d lifetime; want %d, %d",
+					got.DeepResearchDailyRuns, got.DeepResearchLifetimeRuns, tt.wantDaily, tt.wantLifetime)
+			}
+			if got.MonthlyPlanTokens != base.MonthlyPlanTokens {
+				t.Errorf("MonthlyPlanTokens = %d, want unchanged %d", got.MonthlyPlanTokens, base.MonthlyPlanTokens)
+			}
+
+			var variants []string
+			for _, e := range recorder.exposures {
+				variants = append(variants, e.Variant)
+			}
+			if fmt.Sprint(variants) != fmt.Sprint(tt.wantExposures) {
+				t.Errorf("exposures = %v, want %v (logged once per user)", variants, tt.wantExposures)
+			}
+		})
+	}
+}
+
+func TestNewQuotaServiceValidation(t *testing.T) {
+	runs := 2
+	exp := func(name, tier string) config.QuotaExperimentConfig {
+		return config.QuotaExperimentConfig{Name: name, Tier: tier, Percent: 10, Overrides: config.QuotaOverrides{DeepResearchDailyRuns: &runs}}
+	}
+
+	tests := []struct {
+		name        string
+		experiments []config.QuotaExperimentConfig
+		wantErr     string
+	}{
+		{name: "valid", experiments: []config.QuotaExperimentConfig{exp("a", "free"), exp("b", "pro")}},
+		{name: "duplicate name", experiments: []config.QuotaExperimentConfig{exp("a", "free"), exp("a", "pro")}, wantErr: "duplicate"},
+		{name: "unknown tier", experiments: []config.QuotaExperimentConfig{exp("a", "gold")}, wantErr: "unknown tier"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewQuotaService(&exposureRecorder{}, tt.experiments, logger.New(logger.Config{Level: slog.LevelError}))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewQuotaService() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewQuotaService() error = %v", err)
+			}
+			_ = s.Shutdown(context.Background())
+		})
+	}
+}
+
+func TestLoadQuotaExperimentsConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			yaml: `
+quota_experiments:
+- name: free-dr-2x
+  tier: free
+  percent: 20
+  starts_at: 2026-03-01T00:00:00Z
+  ends_at: 2026-04-01T00:00:00Z
+  overrides:
+    deep_research_daily_runs: 2
+    deep_research_lifetime_runs: 0
+`,
+		},
+		{name: "percent out of range", yaml: "quota_experiments:\n- {name: a, tier: free, percent: 120, overrides: {daily_plan_tokens: 1}}\n", wantErr: "percent"},
+		{name: "no overrides", yaml: "quota_experiments:\n- {name: a, tier: free, percent: 10}\n", wantErr: "no overrides"},
+		{
+			name:    "empty window",
+			yaml:    "quota_experiments:\n- {name: a, tier: free, percent: 10, starts_at: 2026-03-01T00:00:00Z, ends_at: 2026-03-01T00:00:00Z, overrides: {daily_plan_tokens: 1}}\n",
+			wantErr: "ends_at",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config.Config
+			err := config.LoadConfigFile(strings.NewReader(tt.yaml), &cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfigFile() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfigFile() error = %v", err)
+			}
+			got := cfg.QuotaExperiments[0]
+			if got.StartsAt == nil || !got.StartsAt.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("StartsAt = %v", got.StartsAt)
+			}
+			if got.Overrides.DeepResearchLifetimeRuns == nil || *got.Overrides.DeepResearchLifetimeRuns != 0 {
+				t.Errorf("DeepResearchLifetimeRuns = %v, want explicit 0", got.Overrides.DeepResearchLifetimeRuns)
+			}
+		})
+	}
+}
@@ -338,6 +338,7 @@ func (w *fallbackWorker) refreshEndpoints(api promQueryAPI, now time.Time) time.
 		ActiveEndpoints:   activeEndpoints,
 		InactiveEndpoints: inactiveEndpoints,
 		RoundRobinCounter: route.RoundRobinCounter,
+		Info:              route.Info,
 	}
 
 	newRoutes := make(map[string]routing.ModelRoute, len(routes))
@@ -0,0 +1,620 @@
+// Package gemini translates between the OpenAI Chat Completions format spoken by clients and
+// the rest of the proxy and Google's Gemini API (models/{model}:generateContent and
+// :streamGenerateContent), so Gemini models can be routed like any other provider. Requests are
+// translated on the way out; responses, errors and SSE streams on the way back.
+package gemini
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// skipThoughtSignature stands in for the thought signatures Gemini attaches to function calls,
+// which chat completions histories don't carry. Gemini accepts it in place of a signature for
+// function calls it didn't just produce.
+const skipThoughtSignature = "skip_thought_signature_validator"
+
+// SetHeaders replaces bearer authentication with the API key header of the Gemini API.
+func SetHeaders(h http.Header, apiKey string) {
+	h.Del("Authorization")
+	h.Set("x-goog-api-key", apiKey)
+	h.Set("Content-Type", "application/json")
+}
+
+// Path returns the endpoint of model relative to the provider base URL
+// (e.g. https://generativelanguage.googleapis.com/v1beta). Streams are requested as SSE.
+func Path(model string, stream bool) string {
+	path := "/models/" + url.PathEscape(strings.TrimPrefix(model, "models/"))
+	if stream {
+		return path + ":streamGenerateContent?alt=sse"
+	}
+	return path + ":generateContent"
+}
+
+// Chat Completions request (the fields that have a Gemini API equivalent).
+type chatRequest struct {
+	Model               string          `json:"model"`
+	Messages            []chatMessage   `json:"messages"`
+	MaxTokens           *int            `json:"max_tokens"`
+	MaxCompletionTokens *int            `json:"max_completion_tokens"`
+	Temperature         *float64        `json:"temperature"`
+	TopP                *float64        `json:"top_p"`
+	Stop                json.RawMessage `json:"stop"`
+	Seed                *int64          `json:"seed"`
+	Stream              bool            `json:"stream"`
+	Tools               []chatTool      `json:"tools"`
+	ToolChoice          json.RawMessage `json:"tool_choice"`
+	ResponseFormat      *struct {
+		Type       string `json:"type"`
+		JSONSchema *struct {
+			Schema json.RawMessage `json:"schema"`
+		} `json:"json_schema"`
+	} `json:"response_format"`
+}
+
+type chatMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCalls  []chatToolCall  `json:"tool_calls"`
+	ToolCallID string          `json:"tool_call_id"`
+}
+
+type chatContentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	ImageURL struct {
+		URL string `json:"url"`
+	} `json:"image_url"`
+}
+
+type chatToolCall struct {
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function chatFunction `json:"function"`
+}
+
+type chatFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type chatTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+// Gemini API request.
+type generateRequest struct {
+	Contents          []content         `json:"contents"`
+	SystemInstruction *content          `json:"systemInstruction,omitempty"`
+	Tools             []tool            `json:"tools,omitempty"`
+	ToolConfig        *toolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+// part is a part of request or response content.
+type part struct {
+	Text             string            `json:"text,omitempty"`
+	Thought          bool              `json:"thought,omitempty"`
+	ThoughtSignature string            `json:"thoughtSignature,omitempty"`
+	InlineData       *inlineData       `json:"inlineData,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+type inlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type functionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type functionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type tool struct {
+	FunctionDeclarations []functionDeclaration `json:"functionDeclarations"`
+}
+
+type functionDeclaration struct {
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+type toolConfig struct {
+	FunctionCallingConfig functionCallingConfig `json:"functionCallingConfig"`
+}
+
+type functionCallingConfig struct {
+	Mode                 string   `json:"mode"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type generationConfig struct {
+	Temperature        *float64        `json:"temperature,omitempty"`
+	TopP               *float64        `json:"topP,omitempty"`
+	MaxOutputTokens    int             `json:"maxOutputTokens,omitempty"`
+	StopSequences      []string        `json:"stopSequences,omitempty"`
+	Seed               *int64          `json:"seed,omitempty"`
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+// Gemini API response and stream chunk.
+type generateResponse struct {
+	ResponseID     string      `json:"responseId"`
+	ModelVersion   string      `json:"modelVersion"`
+	Candidates     []candidate `json:"candidates"`
+	UsageMetadata  *usage      `json:"usageMetadata"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	Error *apiError `json:"error"`
+}
+
+type candidate struct {
+	Content      content `json:"content"`
+	FinishReason string  `json:"finishReason"`
+}
+
+type usage struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	ToolUsePromptTokenCount int `json:"toolUsePromptTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+// Chat Completions response and stream chunk.
+type chatCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+	Usage   *chatUsage   `json:"usage,omitempty"`
+}
+
+type chatChoice struct {
+	Index        int         `json:"index"`
+	Message      *chatOutput `json:"message,omitempty"`
+	Delta        *chatOutput `json:"delta,omitempty"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+type chatOutput struct {
+	Role      string         `json:"role,omitempty"`
+	Content   *string        `json:"content,omitempty"`
+	Reasoning string         `json:"reasoning,omitempty"`
+	ToolCalls []chatToolCall `json:"tool_calls,omitempty"`
+}
+
+type chatUsage struct {
+	PromptTokens            int                      `json:"prompt_tokens"`
+	CompletionTokens        int                      `json:"completion_tokens"`
+	TotalTokens             int                      `json:"total_tokens"`
+	PromptTokensDetails     *promptTokenDetails      `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *completionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+type promptTokenDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+type completionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// FromChatCompletion translates a Chat Completions request body to a Gemini API request and
+// returns it with the endpoint path (see Path). System and developer messages become the
+// system instruction, tool calls and results become functionCall and functionResponse parts,
+// and consecutive messages of the same role are merged.
+func FromChatCompletion(body []byte) ([]byte, string, error) {
+	var req chatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, "", fmt.Errorf("decode chat completion request: %w", err)
+	}
+	if req.Model == "" {
+		return nil, "", fmt.Errorf("request has no model")
+	}
+
+	config := generationConfig{Temperature: req.Temperature, TopP: req.TopP, Seed: req.Seed}
+	switch {
+	case req.MaxCompletionTokens != nil && *req.MaxCompletionTokens > 0:
+		config.MaxOutputTokens = *req.MaxCompletionTokens
+	case req.MaxTokens != nil && *req.MaxTokens > 0:
+		config.MaxOutputTokens = *req.MaxTokens
+	}
+	var err error
+	if config.StopSequences, err = stopSequences(req.Stop); err != nil {
+		return nil, "", err
+	}
+	if format := req.ResponseFormat; format != nil {
+		switch format.Type {
+		case "json_object":
+			config.ResponseMimeType = "application/json"
+		case "json_schema":
+			config.ResponseMimeType = "application/json"
+			if format.JSONSchema != nil {
+				config.ResponseJSONSchema = format.JSONSchema.Schema
+			}
+		}
+	}
+
+	var out generateRequest
+	if config.Temperature != nil || config.TopP != nil || config.Seed != nil || config.MaxOutputTokens > 0 ||
+		len(config.StopSequences) > 0 || config.ResponseMimeType != "" {
+		out.GenerationConfig = &config
+	}
+
+	var system []part
+	functionNames := make(map[string]string) // Tool call ID → function name, for tool results
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			text, err := textContent(msg.Content)
+			if err != nil {
+				return nil, "", err
+			}
+			if text != "" {
+				system = append(system, part{Text: text})
+			}
+		case "user":
+			parts, err := contentParts(msg.Content)
+			if err != nil {
+				return nil, "", err
+			}
+			out.Contents = appendContent(out.Contents, "user", parts)
+		case "assistant":
+			parts, err := contentParts(msg.Content)
+			if err != nil {
+				return nil, "", err
+			}
+			for _, call := range msg.ToolCalls {
+				args := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(args) || strings.TrimSpace(call.Function.Arguments) == "" {
+					args = json.RawMessage("{}")
+				}
+				functionNames[call.ID] = call.Function.Name
+				parts = append(parts, part{
+					FunctionCall:     &functionCall{Name: call.Function.Name, Args: args},
+					ThoughtSignature: skipThoughtSignature,
+				})
+			}
+			out.Contents = appendContent(out.Contents, "model", parts)
+		case "tool":
+			text, err := textContent(msg.Content)
+			if err != nil {
+				return nil, "", err
+			}
+			name, ok := functionNames[msg.ToolCallID]
+			if !ok {
+				return nil, "", fmt.Errorf("tool message %q does not answer a preceding tool call", msg.ToolCallID)
+			}
+			out.Contents = appendContent(out.Contents, "user", []part{{
+				FunctionResponse: &functionResponse{Name: name, Response: toolResponse(text)},
+			}})
+		default:
+			return nil, "", fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+	}
+	if len(out.Contents) == 0 {
+		return nil, "", fmt.Errorf("request has no user or assistant messages")
+	}
+	if len(system) > 0 {
+		out.SystemInstruction = &content{Parts: system}
+	}
+
+	var declarations []functionDeclaration
+	for _, t := range req.Tools {
+		if t.Type != "" && t.Type != "function" {
+			continue
+		}
+		declaration := functionDeclaration{Name: t.Function.Name, Description: t.Function.Description}
+		if len(t.Function.Parameters) > 0 && string(t.Function.Parameters) != "null" {
+			declaration.ParametersJSONSchema = t.Function.Parameters
+		}
+		declarations = append(declarations, declaration)
+	}
+	if len(declarations) > 0 {
+		out.Tools = []tool{{FunctionDeclarations: declarations}}
+	}
+	if out.ToolConfig, err = translateToolChoice(req.ToolChoice); err != nil {
+		return nil, "", err
+	}
+
+	translated, err := json.Marshal(out)
+	if err != nil {
+		return nil, "", err
+	}
+	return translated, Path(req.Model, req.Stream), nil
+}
+
+// appendContent adds parts as content of role, merged into the last content if it has the
+// same role. Empty content is dropped.
+func appendContent(contents []content, role string, parts []part) []content {
+	if len(parts) == 0 {
+		return contents
+	}
+	if n := len(contents); n > 0 && contents[n-1].Role == role {
+		contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+		return contents
+	}
+	return append(contents, content{Role: role, Parts: parts})
+}
+
+// contentParts translates message content (a string or an array of text and image parts).
+func contentParts(raw json.RawMessage) ([]part, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if text == "" {
+			return nil, nil
+		}
+		return []part{{Text: text}}, nil
+	}
+
+	var chatParts []chatContentPart
+	if err := json.Unmarshal(raw, &chatParts); err != nil {
+		return nil, fmt.Errorf("decode message content: %w", err)
+	}
+	var parts []part
+	for _, p := range chatParts {
+		switch p.Type {
+		case "text":
+			if p.Text != "" {
+				parts = append(parts, part{Text: p.Text})
+			}
+		case "image_url":
+			data, err := inlineDataFromURL(p.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part{InlineData: data})
+		default:
+			return nil, fmt.Errorf("unsupported content part type %q", p.Type)
+		}
+	}
+	return parts, nil
+}
+
+// textContent returns the text of message content, joining text parts.
+func textContent(raw json.RawMessage) (string, error) {
+	parts, err := contentParts(raw)
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.InlineData != nil {
+			return "", fmt.Errorf("unsupported image content in system or tool message")
+		}
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// inlineDataFromURL translates a base64 data URL. The Gemini API doesn't fetch image URLs.
+func inlineDataFromURL(imageURL string) (*inlineData, error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(imageURL, "data:"), ",")
+	mimeType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !strings.HasPrefix(imageURL, "data:") || !ok || !isBase64 || mimeType == "" {
+		return nil, fmt.Errorf("unsupported image URL (want data:<media type>;base64,...)")
+	}
+	return &inlineData{MimeType: mimeType, Data: data}, nil
+}
+
+// toolResponse wraps a tool result in the object functionResponse.response requires: JSON
+// objects are passed as is, anything else as {"output": text}.
+func toolResponse(text string) json.RawMessage {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	wrapped, _ := json.Marshal(map[string]string{"output": text})
+	return wrapped
+}
+
+// stopSequences translates stop, a string or an array of strings.
+func stopSequences(stop json.RawMessage) ([]string, error) {
+	if len(stop) == 0 || string(stop) == "null" {
+		return nil, nil
+	}
+	var single string
+	if err := json.Unmarshal(stop, &single); err == nil {
+		return []string{single}, nil
+	}
+	var sequences []string
+	if err := json.Unmarshal(stop, &sequences); err != nil {
+		return nil, fmt.Errorf("stop must be a string or an array of strings")
+	}
+	return sequences, nil
+}
+
+// translateToolChoice translates tool_choice: "auto", "none", "required" or a named function.
+func translateToolChoice(choice json.RawMessage) (*toolConfig, error) {
+	if len(choice) == 0 || string(choice) == "null" {
+		return nil, nil
+	}
+
+	var mode string
+	if err := json.Unmarshal(choice, &mode); err == nil {
+		switch mode {
+		case "auto":
+			return &toolConfig{functionCallingConfig{Mode: "AUTO"}}, nil
+		case "none":
+			return &toolConfig{functionCallingConfig{Mode: "NONE"}}, nil
+		case "required":
+			return &toolConfig{functionCallingConfig{Mode: "ANY"}}, nil
+		}
+		return nil, fmt.Errorf("unsupported tool_choice %q", mode)
+	}
+
+	var named struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(choice, &named); err != nil || named.Function.Name == "" {
+		return nil, fmt.Errorf("unsupported tool_choice %s", choice)
+	}
+	return &toolConfig{functionCallingConfig{Mode: "ANY", AllowedFunctionNames: []string{named.Function.Name}}}, nil
+}
+
+// ToChatCompletion translates a Gemini API response body to a Chat Completions response.
+// Thought parts become the reasoning field of the message; function calls without an ID get
+// a generated one.
+func ToChatCompletion(body []byte) ([]byte, error) {
+	var resp generateResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("decode generateContent response: %w", err)
+	}
+
+	var text, reasoning strings.Builder
+	var toolCalls []chatToolCall
+	var finishReason string
+	if len(resp.Candidates) > 0 {
+		c := resp.Candidates[0]
+		for _, p := range c.Content.Parts {
+			switch {
+			case p.FunctionCall != nil:
+				toolCalls = append(toolCalls, toolCallFrom(p.FunctionCall))
+			case p.Thought:
+				reasoning.WriteString(p.Text)
+			default:
+				text.WriteString(p.Text)
+			}
+		}
+		finishReason = FinishReason(c.FinishReason, len(toolCalls) > 0)
+	} else {
+		// No candidates: the prompt was blocked
+		finishReason = "content_filter"
+	}
+
+	output := &chatOutput{Role: "assistant", Reasoning: reasoning.String(), ToolCalls: toolCalls}
+	if content := text.String(); content != "" || len(toolCalls) == 0 {
+		output.Content = &content
+	}
+
+	return json.Marshal(chatCompletion{
+		ID:      resp.ResponseID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   resp.ModelVersion,
+		Choices: []chatChoice{{Message: output, FinishReason: &finishReason}},
+		Usage:   chatUsageFrom(resp.UsageMetadata),
+	})
+}
+
+// ToChatCompletionError translates a Gemini API error body to the OpenAI error envelope.
+// Bodies that aren't Gemini API errors are returned unchanged.
+func ToChatCompletionError(body []byte) []byte {
+	var resp generateResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error == nil {
+		// Errors of streaming requests may be wrapped in an array
+		var wrapped []generateResponse
+		if err := json.Unmarshal(body, &wrapped); err != nil || len(wrapped) == 0 || wrapped[0].Error == nil {
+			return body
+		}
+		resp = wrapped[0]
+	}
+	translated, err := errorJSON(resp.Error)
+	if err != nil {
+		return body
+	}
+	return translated
+}
+
+func errorJSON(e *apiError) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{"message": e.Message, "type": e.Status, "code": e.Code},
+	})
+}
+
+// FinishReason maps a Gemini finish reason to a Chat Completions finish reason.
+func FinishReason(reason string, hasToolCalls bool) string {
+	switch reason {
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	}
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	return "stop" // STOP, MALFORMED_FUNCTION_CALL, OTHER, ...
+}
+
+// toolCallFrom translates a function call, generating an ID when Gemini doesn't provide one.
+func toolCallFrom(call *functionCall) chatToolCall {
+	id := call.ID
+	if id == "" {
+		id = newToolCallID()
+	}
+	args := "{}"
+	if len(call.Args) > 0 && string(call.Args) != "null" {
+		args = string(call.Args)
+	}
+	return chatToolCall{ID: id, Type: "function", Function: chatFunction{Name: call.Name, Arguments: args}}
+}
+
+func newToolCallID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
+
+// chatUsageFrom converts token usage; thoughts count as completion tokens, tool use prompts
+// as prompt tokens.
+func chatUsageFrom(u *usage) *chatUsage {
+	if u == nil {
+		return nil
+	}
+	prompt := u.PromptTokenCount + u.ToolUsePromptTokenCount
+	completion := u.CandidatesTokenCount + u.ThoughtsTokenCount
+	out := &chatUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+	if u.CachedContentTokenCount > 0 {
+		out.PromptTokensDetails = &promptTokenDetails{CachedTokens: u.CachedContentTokenCount}
+	}
+	if u.ThoughtsTokenCount > 0 {
+		out.CompletionTokensDetails = &completionTokensDetails{ReasoningTokens: u.ThoughtsTokenCount}
+	}
+	return out
+}
@@ -0,0 +1,233 @@
+package gemini
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFromChatCompletion(t *testing.T) {
+	body := `{
+		"model": "gemini-2.5-flash",
+		"stream": true,
+		"max_completion_tokens": 1000,
+		"temperature": 0.5,
+		"stop": ["END"],
+		"response_format": {"type": "json_schema", "json_schema": {"name": "answer", "schema": {"type": "object"}}},
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "developer", "content": [{"type": "text", "text": "Use metric units."}]},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What's in this image?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBORw0KGgo="}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "web_search", "arguments": "{\"query\":\"weather\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "clock", "arguments": ""}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "Sunny"},
+			{"role": "tool", "tool_call_id": "call_2", "content": "{\"time\": \"12:00\"}"},
+			{"role": "user", "content": "Thanks"}
+		],
+		"tools": [{"type": "function", "function": {"name": "web_search", "description": "Search", "parameters": {"type": "object"}}}],
+		"tool_choice": {"type": "function", "function": {"name": "web_search"}}
+	}`
+
+	translated, path, err := FromChatCompletion([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/models/gemini-2.5-flash:streamGenerateContent?alt=sse" {
+		t.Errorf("path = %q", path)
+	}
+	var got generateRequest
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	config := got.GenerationConfig
+	if config == nil || config.MaxOutputTokens != 1000 || *config.Temperature != 0.5 || len(config.StopSequences) != 1 ||
+		config.ResponseMimeType != "application/json" || string(config.ResponseJSONSchema) != `{"type":"object"}` {
+		t.Errorf("generationConfig = %+v", config)
+	}
+	if got.SystemInstruction == nil || len(got.SystemInstruction.Parts) != 2 || got.SystemInstruction.Parts[1].Text != "Use metric units." {
+		t.Errorf("systemInstruction = %+v", got.SystemInstruction)
+	}
+
+	// user, model (function calls), user (function responses merged with the follow-up)
+	roles := make([]string, len(got.Contents))
+	for i, c := range got.Contents {
+		roles[i] = c.Role
+	}
+	if strings.Join(roles, ",") != "user,model,user" {
+		t.Fatalf("roles = %v", roles)
+	}
+	if image := got.Contents[0].Parts[1].InlineData; image == nil || image.MimeType != "image/png" || image.Data != "iVBORw0KGgo=" {
+		t.Errorf("image part = %+v", got.Contents[0].Parts[1])
+	}
+	calls := got.Contents[1].Parts
+	if len(calls) != 2 || calls[0].FunctionCall == nil || calls[0].FunctionCall.Name != "web_search" ||
+		string(calls[0].FunctionCall.Args) != `{"query":"weather"}` || string(calls[1].FunctionCall.Args) != "{}" ||
+		calls[0].ThoughtSignature == "" {
+		t.Errorf("function call parts = %+v", calls)
+	}
+	results := got.Contents[2].Parts
+	if len(results) != 3 || results[0].FunctionResponse == nil || results[0].FunctionResponse.Name != "web_search" ||
+		string(results[0].FunctionResponse.Response) != `{"output":"Sunny"}` ||
+		string(results[1].FunctionResponse.Response) != `{"time":"12:00"}` || results[2].Text != "Thanks" {
+		t.Errorf("function response content = %+v", results)
+	}
+
+	if len(got.Tools) != 1 || got.Tools[0].FunctionDeclarations[0].Name != "web_search" ||
+		string(got.Tools[0].FunctionDeclarations[0].ParametersJSONSchema) != `{"type":"object"}` {
+		t.Errorf("tools = %+v", got.Tools)
+	}
+	if got.ToolConfig == nil || got.ToolConfig.FunctionCallingConfig.Mode != "ANY" ||
+		strings.Join(got.ToolConfig.FunctionCallingConfig.AllowedFunctionNames, ",") != "web_search" {
+		t.Errorf("toolConfig = %+v", got.ToolConfig)
+	}
+}
+
+func TestFromChatCompletionDefaults(t *testing.T) {
+	translated, path, err := FromChatCompletion([]byte(`{"model": "models/gemini-2.5-pro", "messages": [{"role": "user", "content": "Hi"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/models/gemini-2.5-pro:generateContent" {
+		t.Errorf("path = %q", path)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"systemInstruction", "tools", "toolConfig", "generationConfig"} {
+		if _, ok := got[field]; ok {
+			t.Errorf("unexpected %s in %s", field, translated)
+		}
+	}
+}
+
+func TestFromChatCompletionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{`},
+		{"no model", `{"messages": [{"role": "user", "content": "Hi"}]}`},
+		{"no messages", `{"model": "m", "messages": [{"role": "system", "content": "Be brief."}]}`},
+		{"unknown role", `{"model": "m", "messages": [{"role": "narrator", "content": "Hi"}]}`},
+		{"unsupported part", `{"model": "m", "messages": [{"role": "user", "content": [{"type": "input_audio"}]}]}`},
+		{"image URL", `{"model": "m", "messages": [{"role": "user", "content": [{"type": "image_url", "image_url": {"url": "https://example.com/cat.png"}}]}]}`},
+		{"orphan tool result", `{"model": "m", "messages": [{"role": "user", "content": "Hi"}, {"role": "tool", "tool_call_id": "call_9", "content": "x"}]}`},
+		{"bad tool_choice", `{"model": "m", "messages": [{"role": "user", "content": "Hi"}], "tool_choice": "sometimes"}`},
+		{"bad stop", `{"model": "m", "messages": [{"role": "user", "content": "Hi"}], "stop": 3}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := FromChatCompletion([]byte(tt.body)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestToChatCompletion(t *testing.T) {
+	body := `{
+		"candidates": [{
+			"content": {"role": "model", "parts": [
+				{"text": "The user wants the weather.", "thought": true},
+				{"text": "Let me check."},
+				{"functionCall": {"name": "web_search", "args": {"query":"weather"}}, "thoughtSignature": "sig"}
+			]},
+			"finishReason": "STOP"
+		}],
+		"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 20, "thoughtsTokenCount": 5, "cachedContentTokenCount": 4},
+		"modelVersion": "gemini-2.5-flash",
+		"responseId": "resp_1"
+	}`
+
+	translated, err := ToChatCompletion([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				Reasoning string `json:"reasoning"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens        int `json:"prompt_tokens"`
+			CompletionTokens    int `json:"completion_tokens"`
+			TotalTokens         int `json:"total_tokens"`
+			PromptTokensDetails struct {
+				CachedTokens int `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(translated, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ID != "resp_1" || got.Object != "chat.completion" || got.Model != "gemini-2.5-flash" || len(got.Choices) != 1 {
+		t.Fatalf("completion = %s", translated)
+	}
+	choice := got.Choices[0]
+	if choice.Message.Content != "Let me check." || choice.Message.Reasoning != "The user wants the weather." {
+		t.Errorf("message = %+v", choice.Message)
+	}
+	calls := choice.Message.ToolCalls
+	if len(calls) != 1 || !strings.HasPrefix(calls[0].ID, "call_") || calls[0].Function.Arguments != `{"query":"weather"}` {
+		t.Errorf("tool_calls = %+v", calls)
+	}
+	if choice.FinishReason != "tool_calls" {
+		t.Errorf("finish_reason = %q, want tool_calls", choice.FinishReason)
+	}
+	if got.Usage.PromptTokens != 10 || got.Usage.CompletionTokens != 25 || got.Usage.TotalTokens != 35 || got.Usage.PromptTokensDetails.CachedTokens != 4 {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+}
+
+func TestToChatCompletionBlocked(t *testing.T) {
+	translated, err := ToChatCompletion([]byte(`{"promptFeedback": {"blockReason": "SAFETY"}, "usageMetadata": {"promptTokenCount": 7}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(translated), `"finish_reason":"content_filter"`) {
+		t.Errorf("blocked prompt = %s", translated)
+	}
+}
+
+func TestToChatCompletionError(t *testing.T) {
+	body := []byte(`{"error": {"code": 400, "message": "API key not valid.", "status": "INVALID_ARGUMENT"}}`)
+	if got := string(ToChatCompletionError(body)); got != `{"error":{"code":400,"message":"API key not valid.","type":"INVALID_ARGUMENT"}}` {
+		t.Errorf("got %s", got)
+	}
+
+	other := []byte(`upstream connect error`)
+	if got := ToChatCompletionError(other); string(got) != string(other) {
+		t.Errorf("non-API error changed to %s", got)
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer secret")
+	SetHeaders(h, "secret")
+	if h.Get("Authorization") != "" || h.Get("x-goog-api-key") != "secret" {
+		t.Errorf("headers = %v", h)
+	}
+}
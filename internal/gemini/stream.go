@@ -0,0 +1,149 @@
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// streamReader translates a Gemini API SSE stream (alt=sse) to Chat Completions chunks.
+type streamReader struct {
+	body    io.ReadCloser
+	lines   *bufio.Reader
+	pending bytes.Buffer
+	err     error
+
+	id        string
+	model     string
+	created   int64
+	started   bool
+	finished  bool
+	usage     *usage
+	toolCalls int
+}
+
+// NewStreamReader returns a reader of the Chat Completions SSE stream ("data: {chunk}" lines
+// ending with "data: [DONE]") equivalent to a Gemini API stream, so the stream can be
+// broadcast, parsed and stored like any other. Gemini streams have no end marker: [DONE] is
+// written at the end of a stream that reported a finish reason, and a stream cut off before
+// one ends without it.
+func NewStreamReader(body io.ReadCloser) io.ReadCloser {
+	return &streamReader{
+		body:    body,
+		lines:   bufio.NewReader(body),
+		created: time.Now().Unix(),
+	}
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for r.pending.Len() == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		line, err := r.lines.ReadString('\n')
+		if line != "" {
+			r.translateLine(strings.TrimRight(line, "\r\n"))
+		}
+		if err != nil {
+			r.err = err
+			if err == io.EOF && r.finished {
+				if r.usage != nil {
+					r.writeChunk(chatCompletion{Choices: []chatChoice{}, Usage: chatUsageFrom(r.usage)})
+				}
+				r.pending.WriteString("data: [DONE]\n\n")
+			}
+		}
+	}
+	return r.pending.Read(p)
+}
+
+func (r *streamReader) Close() error {
+	return r.body.Close()
+}
+
+// translateLine translates an SSE line; every data line is a complete GenerateContentResponse.
+func (r *streamReader) translateLine(line string) {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return
+	}
+	var chunk generateResponse
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &chunk); err != nil {
+		return
+	}
+
+	if chunk.Error != nil {
+		if errorJSON, err := errorJSON(chunk.Error); err == nil {
+			r.pending.WriteString("data: " + string(errorJSON) + "\n\n")
+		}
+		return
+	}
+
+	if chunk.ResponseID != "" {
+		r.id = chunk.ResponseID
+	}
+	if chunk.ModelVersion != "" {
+		r.model = chunk.ModelVersion
+	}
+	if chunk.UsageMetadata != nil {
+		// Usage metadata is cumulative
+		r.usage = chunk.UsageMetadata
+	}
+	if !r.started {
+		r.started = true
+		empty := ""
+		r.writeDelta(&chatOutput{Role: "assistant", Content: &empty}, nil)
+	}
+
+	if len(chunk.Candidates) == 0 {
+		if chunk.PromptFeedback != nil && chunk.PromptFeedback.BlockReason != "" {
+			finishReason := "content_filter"
+			r.writeDelta(&chatOutput{}, &finishReason)
+			r.finished = true
+		}
+		return
+	}
+
+	c := chunk.Candidates[0]
+	for _, p := range c.Content.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			index := r.toolCalls
+			r.toolCalls++
+			call := toolCallFrom(p.FunctionCall)
+			call.Index = &index
+			r.writeDelta(&chatOutput{ToolCalls: []chatToolCall{call}}, nil)
+		case p.Thought:
+			if p.Text != "" {
+				r.writeDelta(&chatOutput{Reasoning: p.Text}, nil)
+			}
+		case p.Text != "":
+			text := p.Text
+			r.writeDelta(&chatOutput{Content: &text}, nil)
+		}
+	}
+	if c.FinishReason != "" {
+		finishReason := FinishReason(c.FinishReason, r.toolCalls > 0)
+		r.writeDelta(&chatOutput{}, &finishReason)
+		r.finished = true
+	}
+}
+
+func (r *streamReader) writeDelta(delta *chatOutput, finishReason *string) {
+	r.writeChunk(chatCompletion{Choices: []chatChoice{{Delta: delta, FinishReason: finishReason}}})
+}
+
+func (r *streamReader) writeChunk(chunk chatCompletion) {
+	chunk.ID = r.id
+	chunk.Object = "chat.completion.chunk"
+	chunk.Created = r.created
+	chunk.Model = r.model
+	chunkJSON, err := json.Marshal(chunk)
+	if err != nil {
+		return
+	}
+	r.pending.WriteString("data: " + string(chunkJSON) + "\n\n")
+}
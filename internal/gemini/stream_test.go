@@ -0,0 +1,142 @@
+package gemini
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+const generateStream = "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Checking.\",\"thought\":true}]}}],\"modelVersion\":\"gemini-2.5-flash\",\"responseId\":\"resp_1\"}\r\n\r\n" +
+	"data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Hello\"}]}}],\"usageMetadata\":{\"promptTokenCount\":25,\"candidatesTokenCount\":1},\"modelVersion\":\"gemini-2.5-flash\",\"responseId\":\"resp_1\"}\r\n\r\n" +
+	"data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\" world\"},{\"functionCall\":{\"name\":\"web_search\",\"args\":{\"query\":\"weather\"}}}]},\"finishReason\":\"STOP\"}],\"usageMetadata\":{\"promptTokenCount\":25,\"candidatesTokenCount\":15},\"modelVersion\":\"gemini-2.5-flash\",\"responseId\":\"resp_1\"}\r\n\r\n"
+
+type chunk struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Role      string  `json:"role"`
+			Content   *string `json:"content"`
+			Reasoning string  `json:"reasoning"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// readChunks reads a translated stream and returns its chunks and whether it ended with [DONE].
+func readChunks(t *testing.T, stream string) ([]chunk, bool) {
+	t.Helper()
+	out, err := io.ReadAll(NewStreamReader(io.NopCloser(strings.NewReader(stream))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks []chunk
+	done := false
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			t.Fatalf("unexpected line %q", line)
+		}
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+		var c chunk
+		if err := json.Unmarshal([]byte(data), &c); err != nil {
+			t.Fatalf("invalid chunk %s: %v", data, err)
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, done
+}
+
+func TestStreamReader(t *testing.T) {
+	chunks, done := readChunks(t, generateStream)
+	if !done {
+		t.Error("stream did not end with [DONE]")
+	}
+
+	var content, reasoning, arguments, finishReason, toolName string
+	for _, c := range chunks {
+		if c.ID != "resp_1" || c.Object != "chat.completion.chunk" || c.Model != "gemini-2.5-flash" {
+			t.Errorf("chunk header = %+v", c)
+		}
+		for _, choice := range c.Choices {
+			if choice.Delta.Content != nil {
+				content += *choice.Delta.Content
+			}
+			reasoning += choice.Delta.Reasoning
+			for _, call := range choice.Delta.ToolCalls {
+				if call.Index != 0 || !strings.HasPrefix(call.ID, "call_") {
+					t.Errorf("tool call = %+v", call)
+				}
+				toolName = call.Function.Name
+				arguments += call.Function.Arguments
+			}
+			if choice.FinishReason != nil {
+				finishReason = *choice.FinishReason
+			}
+		}
+	}
+
+	if chunks[0].Choices[0].Delta.Role != "assistant" {
+		t.Errorf("first chunk = %+v, want the assistant role", chunks[0])
+	}
+	if content != "Hello world" || reasoning != "Checking." {
+		t.Errorf("content = %q, reasoning = %q", content, reasoning)
+	}
+	if toolName != "web_search" || arguments != `{"query":"weather"}` {
+		t.Errorf("tool call = %s(%s)", toolName, arguments)
+	}
+	if finishReason != "tool_calls" {
+		t.Errorf("finish_reason = %q, want tool_calls", finishReason)
+	}
+
+	last := chunks[len(chunks)-1]
+	if last.Usage == nil || last.Usage.PromptTokens != 25 || last.Usage.CompletionTokens != 15 || len(last.Choices) != 0 {
+		t.Errorf("usage chunk = %+v", last)
+	}
+}
+
+func TestStreamReaderTruncated(t *testing.T) {
+	// Cut off before the chunk with the finish reason: no usage or [DONE]
+	cut := strings.Index(generateStream, `data: {"candidates":[{"content":{"role":"model","parts":[{"text":" world"}`)
+	chunks, done := readChunks(t, generateStream[:cut])
+	if done {
+		t.Error("truncated stream ended with [DONE]")
+	}
+	for _, c := range chunks {
+		if c.Usage != nil {
+			t.Errorf("truncated stream reported usage: %+v", c)
+		}
+	}
+}
+
+func TestStreamReaderError(t *testing.T) {
+	stream := "data: {\"error\":{\"code\":503,\"message\":\"The model is overloaded.\",\"status\":\"UNAVAILABLE\"}}\n\n"
+	out, err := io.ReadAll(NewStreamReader(io.NopCloser(strings.NewReader(stream))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `data: {"error":{"code":503,"message":"The model is overloaded.","type":"UNAVAILABLE"}}` + "\n\n"; string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,132 @@
+package iap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/api/androidpublisher/v3"
+	"google.golang.org/api/option"
+)
+
+// Subscription states that entitle the user until the line item expires. Canceled subscriptions
+// stay entitled until the end of the paid period.
+var playEntitledStates = map[string]bool{
+	"SUBSCRIPTION_STATE_ACTIVE":          true,
+	"SUBSCRIPTION_STATE_IN_GRACE_PERIOD": true,
+	"SUBSCRIPTION_STATE_CANCELED":        true,
+}
+
+// Purchase states of one-time products (purchaseState).
+const playProductPurchased = 0
+
+// PlayPurchase is a verified Google Play purchase.
+type PlayPurchase struct {
+	ProductID string
+	OrderID   string
+	ExpiresAt time.Time // Zero for one-time products
+
+	// Renewal state of subscriptions
+	AutoRenew     *bool
+	InGracePeriod bool
+}
+
+// GooglePlayVerifier verifies Play Billing purchase tokens via the Google Play Developer API.
+type GooglePlayVerifier struct {
+	service     *androidpublisher.Service
+	packageName string
+}
+
+// NewGooglePlayVerifier creates a verifier authenticated with a service account that has
+// access to the app's financial data in the Play Console.
+func NewGooglePlayVerifier(ctx context.Context, packageName, credentialsJSON string) (*GooglePlayVerifier, error) {
+	if packageName == "" {
+		return nil, errors.New("package name is required")
+	}
+	if credentialsJSON == "" {
+		return nil, errors.New("service account credentials are required")
+	}
+
+	service, err := androidpublisher.NewService(ctx, option.WithCredentialsJSON([]byte(credentialsJSON)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Google Play Developer API client: %w", err)
+	}
+
+	return &GooglePlayVerifier{service: service, packageName: packageName}, nil
+}
+
+// Verify checks the purchase token of productID and acknowledges the purchase (Play refunds
+// purchases that aren't acknowledged within three days). Lifetime products are one-time
+// purchases, every other product is a subscription.
+func (v *GooglePlayVerifier) Verify(ctx context.Context, productID, purchaseToken string) (*PlayPurchase, error) {
+	if isLifetimeProduct(productID) {
+		return v.verifyProduct(ctx, productID, purchaseToken)
+	}
+	return v.verifySubscription(ctx, productID, purchaseToken)
+}
+
+func (v *GooglePlayVerifier) verifySubscription(ctx context.Context, productID, purchaseToken string) (*PlayPurchase, error) {
+	sub, err := v.service.Purchases.Subscriptionsv2.Get(v.packageName, purchaseToken).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription purchase: %w", err)
+	}
+	if !playEntitledStates[sub.SubscriptionState] {
+		return nil, fmt.Errorf("subscription is in state %s", sub.SubscriptionState)
+	}
+
+	var item *androidpublisher.SubscriptionPurchaseLineItem
+	for _, lineItem := range sub.LineItems {
+		if lineItem.ProductId == productID {
+			item = lineItem
+			break
+		}
+	}
+	if item == nil {
+		return nil, fmt.Errorf("purchase token is not for product %s", productID)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, item.ExpiryTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription expiry time %q: %w", item.ExpiryTime, err)
+	}
+
+	if sub.AcknowledgementState == "ACKNOWLEDGEMENT_STATE_PENDING" {
+		err := v.service.Purchases.Subscriptions.Acknowledge(v.packageName, productID, purchaseToken,
+			&androidpublisher.SubscriptionPurchasesAcknowledgeRequest{}).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to acknowledge subscription purchase: %w", err)
+		}
+	}
+
+	purchase := &PlayPurchase{
+		ProductID:     productID,
+		OrderID:       sub.LatestOrderId,
+		ExpiresAt:     expiresAt,
+		InGracePeriod: sub.SubscriptionState == "SUBSCRIPTION_STATE_IN_GRACE_PERIOD",
+	}
+	if item.AutoRenewingPlan != nil {
+		autoRenew := item.AutoRenewingPlan.AutoRenewEnabled
+		purchase.AutoRenew = &autoRenew
+	}
+	return purchase, nil
+}
+
+func (v *GooglePlayVerifier) verifyProduct(ctx context.Context, productID, purchaseToken string) (*PlayPurchase, error) {
+	purchase, err := v.service.Purchases.Products.Get(v.packageName, productID, purchaseToken).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product purchase: %w", err)
+	}
+	if purchase.PurchaseState != playProductPurchased {
+		return nil, fmt.Errorf("product purchase is in state %d", purchase.PurchaseState)
+	}
+
+	if purchase.AcknowledgementState == 0 {
+		err := v.service.Purchases.Products.Acknowledge(v.packageName, productID, purchaseToken,
+			&androidpublisher.ProductPurchasesAcknowledgeRequest{}).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to acknowledge product purchase: %w", err)
+		}
+	}
+
+	return &PlayPurchase{ProductID: productID, OrderID: purchase.OrderId}, nil
+}
@@ -1,6 +1,8 @@
 package iap
 
 import (
+	stderrors "errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/eternisai/enchanted-proxy/internal/auth"
@@ -49,3 +51,71 @@ func (h *Handler) AttachAppStoreSubscription(c *gin.Context) {
 		"expiresAt":     expiresAt,
 	})
 }
+
+// AttachGooglePlayPurchase validates a Google Play Billing purchase token and upgrades the user's tier.
+// Request body: { "productId": "<product or subscription ID>", "purchaseToken": "<token>" }.
+func (h *Handler) AttachGooglePlayPurchase(c *gin.Context) {
+	var body struct {
+		ProductID     string `json:"productId"`
+		PurchaseToken string `json:"purchaseToken"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.ProductID == "" || body.PurchaseToken == "" {
+		errors.BadRequest(c, "invalid request", nil)
+		return
+	}
+
+	userID, ok := auth.GetUserID(c)
+	if !ok || userID == "" {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	purchase, expiresAt, err := h.service.AttachGooglePlayPurchase(c.Request.Context(), userID, body.ProductID, body.PurchaseToken)
+	if err != nil {
+		if stderrors.Is(err, ErrGooglePlayNotConfigured) {
+			errors.Internal(c, "Google Play billing not configured", nil)
+			return
+		}
+		if stderrors.Is(err, ErrPurchaseOwnedByAnotherUser) {
+			h.logger.Warn("google play purchase attached to another user",
+				slog.String("user_id", userID),
+				slog.String("product_id", body.ProductID))
+			errors.Conflict(c, "purchase is attached to another account", nil)
+			return
+		}
+		h.logger.Warn("google play purchase verification failed",
+			slog.String("user_id", userID),
+			slog.String("product_id", body.ProductID),
+			slog.String("error", err.Error()))
+		errors.BadRequest(c, "invalid purchaseToken", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    true,
+		"productId": purchase.ProductID,
+		"orderId":   purchase.OrderID,
+		"expiresAt": expiresAt,
+	})
+}
+
+// GetSubscription returns the user's subscription status: effective tier, provider, expiry,
+// renewal state, grace period and entitlement history.
+func (h *Handler) GetSubscription(c *gin.Context) {
+	userID, ok := auth.GetUserID(c)
+	if !ok || userID == "" {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	status, err := h.service.SubscriptionStatus(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to get subscription status",
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()))
+		errors.Internal(c, "Failed to get subscription status", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
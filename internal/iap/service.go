@@ -3,6 +3,7 @@ package iap
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -13,10 +14,27 @@ import (
 	appstore "github.com/richzw/appstore"
 )
 
+// ErrGooglePlayNotConfigured is returned for Google Play purchases when Play Billing
+// verification is not configured.
+var ErrGooglePlayNotConfigured = errors.New("google play billing is not configured")
+
+// ErrPurchaseOwnedByAnotherUser is returned for Google Play purchase tokens already attached
+// to another user.
+var ErrPurchaseOwnedByAnotherUser = errors.New("purchase is attached to another account")
+
+// lifetimeExpiresAt is the entitlement expiry of lifetime purchases.
+var lifetimeExpiresAt = time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// playVerifier verifies Google Play purchase tokens (implemented by GooglePlayVerifier).
+type playVerifier interface {
+	Verify(ctx context.Context, productID, purchaseToken string) (*PlayPurchase, error)
+}
+
 type Service struct {
 	queries      pgdb.Querier
 	storeProd    *appstore.StoreClient
 	storeSandbox *appstore.StoreClient
+	googlePlay   playVerifier
 }
 
 func NewService(queries pgdb.Querier) *Service {
@@ -45,6 +63,25 @@ func NewService(queries pgdb.Querier) *Service {
 	return &Service{queries: queries, storeProd: prodClient, storeSandbox: sandboxClient}
 }
 
+// SetGooglePlayVerifier enables Google Play purchases.
+func (s *Service) SetGooglePlayVerifier(verifier *GooglePlayVerifier) {
+	s.googlePlay = verifier
+}
+
+// isLifetimeProduct reports whether a product is the lifetime Plus purchase.
+// Uses HasPrefix to handle environment suffixes (e.g., silo.plus.lifetime.development).
+func isLifetimeProduct(productID string) bool {
+	return strings.HasPrefix(productID, "silo.plus.lifetime")
+}
+
+// productTier returns the tier a product grants.
+func productTier(productID string) tiers.Tier {
+	if isLifetimeProduct(productID) {
+		return tiers.TierPlus
+	}
+	return tiers.TierPro
+}
+
 // AttachAppStoreSubscription verifies the JWS and upserts entitlement.
 func (s *Service) AttachAppStoreSubscription(ctx context.Context, userID string, jwsTransactionInfo string) (payload *appstore.JWSTransaction, proUntil time.Time, err error) {
 	p, err := s.storeProd.ParseNotificationV2TransactionInfo(jwsTransactionInfo)
@@ -55,19 +92,14 @@ func (s *Service) AttachAppStoreSubscription(ctx context.Context, userID string,
 		}
 	}
 
-	// Determine tier based on product ID
-	// Use HasPrefix to handle environment suffixes (e.g., silo.plus.lifetime.development)
-	tier := string(tiers.TierPro)
-	if strings.HasPrefix(p.ProductID, "silo.plus.lifetime") {
-		tier = string(tiers.TierPlus)
-	}
+	tier := string(productTier(p.ProductID))
 
 	var expiresAt sql.NullTime
 	if p.ExpiresDate > 0 {
 		expiresAt = sql.NullTime{Time: time.UnixMilli(p.ExpiresDate), Valid: true}
 	} else if tier == string(tiers.TierPlus) {
 		// Lifetime purchases don't expire - set far future date
-		expiresAt = sql.NullTime{Time: time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC), Valid: true}
+		expiresAt = sql.NullTime{Time: lifetimeExpiresAt, Valid: true}
 	} else {
 		return nil, time.Time{}, fmt.Errorf("missing expiresDate for non-lifetime product")
 	}
@@ -85,3 +117,55 @@ func (s *Service) AttachAppStoreSubscription(ctx context.Context, userID string,
 
 	return p, expiresAt.Time, nil
 }
+
+// AttachGooglePlayPurchase verifies a Play Billing purchase token and upserts entitlement.
+// The token is bound to the first user attaching it: other users get
+// ErrPurchaseOwnedByAnotherUser.
+func (s *Service) AttachGooglePlayPurchase(ctx context.Context, userID, productID, purchaseToken string) (purchase *PlayPurchase, expiresAt time.Time, err error) {
+	if s.googlePlay == nil {
+		return nil, time.Time{}, ErrGooglePlayNotConfigured
+	}
+	purchase, err = s.googlePlay.Verify(ctx, productID, purchaseToken)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	tier := productTier(purchase.ProductID)
+	expiresAt = purchase.ExpiresAt
+	if tier == tiers.TierPlus {
+		expiresAt = lifetimeExpiresAt
+	} else if expiresAt.IsZero() {
+		return nil, time.Time{}, fmt.Errorf("missing expiry time for non-lifetime product")
+	}
+
+	owner, err := s.queries.ClaimGooglePlayPurchase(ctx, pgdb.ClaimGooglePlayPurchaseParams{
+		PurchaseToken: purchaseToken,
+		UserID:        userID,
+		ProductID:     purchase.ProductID,
+	})
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to claim purchase token: %w", err)
+	}
+	if owner != userID {
+		return nil, time.Time{}, ErrPurchaseOwnedByAnotherUser
+	}
+
+	if err := s.queries.UpsertEntitlementWithTier(ctx, pgdb.UpsertEntitlementWithTierParams{
+		UserID:                userID,
+		SubscriptionTier:      string(tier),
+		SubscriptionExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+		SubscriptionProvider:  "google",
+		StripeCustomerID:      nil, // Don't set for Google Play subscriptions
+	}); err != nil {
+		return nil, time.Time{}, err
+	}
+	if err := s.queries.SetEntitlementRenewal(ctx, pgdb.SetEntitlementRenewalParams{
+		UserID:        userID,
+		AutoRenew:     purchase.AutoRenew,
+		InGracePeriod: purchase.InGracePeriod,
+	}); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return purchase, expiresAt, nil
+}
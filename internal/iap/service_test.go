@@ -0,0 +1,136 @@
+package iap
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// entitlementStore records upserted entitlements and the users of purchase tokens; other
+// Querier methods are not used.
+type entitlementStore struct {
+	pgdb.Querier
+	upserts []pgdb.UpsertEntitlementWithTierParams
+	owners  map[string]string
+}
+
+func (s *entitlementStore) ClaimGooglePlayPurchase(_ context.Context, arg pgdb.ClaimGooglePlayPurchaseParams) (string, error) {
+	if s.owners == nil {
+		s.owners = make(map[string]string)
+	}
+	if owner, ok := s.owners[arg.PurchaseToken]; ok {
+		return owner, nil
+	}
+	s.owners[arg.PurchaseToken] = arg.UserID
+	return arg.UserID, nil
+}
+
+func (s *entitlementStore) UpsertEntitlementWithTier(_ context.Context, arg pgdb.UpsertEntitlementWithTierParams) error {
+	s.upserts = append(s.upserts, arg)
+	return nil
+}
+
+func (s *entitlementStore) SetEntitlementRenewal(context.Context, pgdb.SetEntitlementRenewalParams) error {
+	return nil
+}
+
+// fakePlayVerifier returns a fixed purchase, or err.
+type fakePlayVerifier struct {
+	purchase *PlayPurchase
+	err      error
+}
+
+func (v fakePlayVerifier) Verify(context.Context, string, string) (*PlayPurchase, error) {
+	return v.purchase, v.err
+}
+
+func TestAttachGooglePlayPurchase(t *testing.T) {
+	expiry := time.Date(2026, 11, 16, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		verifier    playVerifier
+		wantErr     bool
+		wantTier    string
+		wantExpires time.Time
+	}{
+		{name: "not configured", wantErr: true},
+		{
+			name:     "verification failure",
+			verifier: fakePlayVerifier{err: stderrors.New("subscription is in state SUBSCRIPTION_STATE_EXPIRED")},
+			wantErr:  true,
+		},
+		{
+			name:        "subscription",
+			verifier:    fakePlayVerifier{purchase: &PlayPurchase{ProductID: "silo.pro.monthly", ExpiresAt: expiry}},
+			wantTier:    "pro",
+			wantExpires: expiry,
+		},
+		{
+			name:        "lifetime product",
+			verifier:    fakePlayVerifier{purchase: &PlayPurchase{ProductID: "silo.plus.lifetime.development"}},
+			wantTier:    "plus",
+			wantExpires: lifetimeExpiresAt,
+		},
+		{
+			name:     "subscription without expiry",
+			verifier: fakePlayVerifier{purchase: &PlayPurchase{ProductID: "silo.pro.monthly"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &entitlementStore{}
+			service := &Service{queries: store, googlePlay: tt.verifier}
+
+			_, expiresAt, err := service.AttachGooglePlayPurchase(context.Background(), "user-1", "product", "token")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if len(store.upserts) != 0 {
+					t.Errorf("entitlement stored for a failed purchase: %+v", store.upserts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !expiresAt.Equal(tt.wantExpires) {
+				t.Errorf("expiresAt = %v, want %v", expiresAt, tt.wantExpires)
+			}
+			if len(store.upserts) != 1 {
+				t.Fatalf("upserts = %d, want 1", len(store.upserts))
+			}
+			got := store.upserts[0]
+			if got.UserID != "user-1" || got.SubscriptionTier != tt.wantTier || got.SubscriptionProvider != "google" || !got.SubscriptionExpiresAt.Time.Equal(tt.wantExpires) {
+				t.Errorf("upserted entitlement = %+v", got)
+			}
+		})
+	}
+}
+
+func TestAttachGooglePlayPurchaseOwnedByAnotherUser(t *testing.T) {
+	store := &entitlementStore{}
+	service := &Service{queries: store, googlePlay: fakePlayVerifier{purchase: &PlayPurchase{ProductID: "silo.plus.lifetime.development"}}}
+
+	if _, _, err := service.AttachGooglePlayPurchase(context.Background(), "user-1", "product", "token"); err != nil {
+		t.Fatalf("first attach: unexpected error: %v", err)
+	}
+	// The owner can attach the token again, e.g. to restore purchases
+	if _, _, err := service.AttachGooglePlayPurchase(context.Background(), "user-1", "product", "token"); err != nil {
+		t.Fatalf("second attach by the owner: unexpected error: %v", err)
+	}
+	if _, _, err := service.AttachGooglePlayPurchase(context.Background(), "user-2", "product", "token"); !stderrors.Is(err, ErrPurchaseOwnedByAnotherUser) {
+		t.Fatalf("attach by another user: error = %v, want ErrPurchaseOwnedByAnotherUser", err)
+	}
+	for _, upsert := range store.upserts {
+		if upsert.UserID != "user-1" {
+			t.Errorf("entitlement granted to %s", upsert.UserID)
+		}
+	}
+}
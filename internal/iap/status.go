@@ -0,0 +1,144 @@
+package iap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+)
+
+// statusHistoryLimit bounds the entitlement changes returned with a subscription status.
+const statusHistoryLimit = 20
+
+// Renewal states of a subscription.
+const (
+	RenewalNone         = "none"          // No subscription
+	RenewalAutoRenewing = "auto_renewing" // Renews at expiry
+	RenewalCanceled     = "canceled"      // Won't renew; active until expiry
+	RenewalNonRenewing  = "non_renewing"  // Prepaid period (manual grants, crypto payments)
+	RenewalLifetime     = "lifetime"      // Never expires
+	RenewalExpired      = "expired"
+	RenewalUnknown      = "unknown" // Not reported by the provider (App Store)
+)
+
+// ProviderManual is the reported provider of entitlements granted via the admin API.
+const ProviderManual = "manual"
+
+// SubscriptionStatus is the response of GET /api/v1/subscription.
+type SubscriptionStatus struct {
+	Tier        string              `json:"tier"` // Effective tier: free once the entitlement expired
+	TierDisplay string              `json:"tier_display"`
+	Provider    string              `json:"provider,omitempty"` // apple, google, stripe, manual, zcash or fai
+	ExpiresAt   *time.Time          `json:"expires_at,omitempty"`
+	Renewal     string              `json:"renewal"`
+	GracePeriod *GracePeriod        `json:"grace_period,omitempty"` // Set while the provider retries a failed renewal payment
+	History     []EntitlementChange `json:"history"`                // Most recent first
+}
+
+// GracePeriod is the billing grace period of a subscription whose renewal payment failed.
+type GracePeriod struct {
+	EndsAt time.Time `json:"ends_at"`
+}
+
+// EntitlementChange is an entry of a subscription's entitlement history.
+type EntitlementChange struct {
+	Tier      string     `json:"tier"`
+	Provider  string     `json:"provider"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ChangedAt time.Time  `json:"changed_at"`
+}
+
+// SubscriptionStatus returns the subscription status of a user, whatever the provider.
+func (s *Service) SubscriptionStatus(ctx context.Context, userID string) (*SubscriptionStatus, error) {
+	var entitlement *pgdb.GetEntitlementRow
+	row, err := s.queries.GetEntitlement(ctx, userID)
+	if err == nil {
+		entitlement = &row
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	events, err := s.queries.ListEntitlementEvents(ctx, pgdb.ListEntitlementEventsParams{
+		UserID: userID,
+		Limit:  statusHistoryLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionStatus(entitlement, events, time.Now()), nil
+}
+
+// subscriptionStatus builds the status of an entitlement (nil for users who never had one).
+func subscriptionStatus(entitlement *pgdb.GetEntitlementRow, events []pgdb.EntitlementEvent, now time.Time) *SubscriptionStatus {
+	status := &SubscriptionStatus{
+		Tier:    string(tiers.TierFree),
+		Renewal: RenewalNone,
+		History: make([]EntitlementChange, 0, len(events)),
+	}
+	for _, event := range events {
+		status.History = append(status.History, EntitlementChange{
+			Tier:      event.SubscriptionTier,
+			Provider:  reportedProvider(event.SubscriptionProvider),
+			ExpiresAt: nullTime(event.SubscriptionExpiresAt),
+			ChangedAt: event.CreatedAt,
+		})
+	}
+
+	if entitlement != nil && entitlement.SubscriptionTier != string(tiers.TierFree) {
+		status.Provider = reportedProvider(entitlement.SubscriptionProvider)
+		status.ExpiresAt = nullTime(entitlement.SubscriptionExpiresAt)
+		status.Renewal = renewalState(entitlement, now)
+		if status.Renewal != RenewalExpired {
+			status.Tier = entitlement.SubscriptionTier
+		}
+		if entitlement.InGracePeriod && status.ExpiresAt != nil && status.Renewal != RenewalExpired {
+			status.GracePeriod = &GracePeriod{EndsAt: *status.ExpiresAt}
+		}
+	}
+
+	status.TierDisplay = tiers.Configs[tiers.Tier(status.Tier)].DisplayName
+	return status
+}
+
+// renewalState derives the renewal state of a paid entitlement.
+func renewalState(entitlement *pgdb.GetEntitlementRow, now time.Time) string {
+	expiresAt := entitlement.SubscriptionExpiresAt
+	switch {
+	case !expiresAt.Valid || expiresAt.Time.Before(now):
+		return RenewalExpired
+	case !expiresAt.Time.Before(lifetimeExpiresAt):
+		return RenewalLifetime
+	}
+
+	switch entitlement.SubscriptionProvider {
+	case "apple", "google", "stripe":
+		if entitlement.AutoRenew == nil {
+			return RenewalUnknown
+		}
+		if *entitlement.AutoRenew {
+			return RenewalAutoRenewing
+		}
+		return RenewalCanceled
+	default:
+		return RenewalNonRenewing
+	}
+}
+
+// reportedProvider returns the provider as reported to clients.
+func reportedProvider(provider string) string {
+	if provider == "admin" {
+		return ProviderManual
+	}
+	return provider
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
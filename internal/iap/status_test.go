@@ -0,0 +1,104 @@
+package iap
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+func TestSubscriptionStatus(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	future := sql.NullTime{Time: now.AddDate(0, 1, 0), Valid: true}
+	past := sql.NullTime{Time: now.AddDate(0, 0, -1), Valid: true}
+	on, off := true, false
+
+	tests := []struct {
+		name        string
+		entitlement *pgdb.GetEntitlementRow
+		wantTier    string
+		wantProv    string
+		wantRenewal string
+		wantGrace   bool
+	}{
+		{name: "no entitlement", wantTier: "free", wantRenewal: RenewalNone},
+		{
+			name:        "revoked subscription",
+			entitlement: &pgdb.GetEntitlementRow{SubscriptionTier: "free", SubscriptionProvider: "stripe"},
+			wantTier:    "free",
+			wantRenewal: RenewalNone,
+		},
+		{
+			name:        "auto-renewing stripe subscription",
+			entitlement: &pgdb.GetEntitlementRow{SubscriptionTier: "pro", SubscriptionProvider: "stripe", SubscriptionExpiresAt: future, AutoRenew: &on},
+			wantTier:    "pro",
+			wantProv:    "stripe",
+			wantRenewal: RenewalAutoRenewing,
+		},
+		{
+			name:        "canceled google subscription in grace period",
+			entitlement: &pgdb.GetEntitlementRow{SubscriptionTier: "pro", SubscriptionProvider: "google", SubscriptionExpiresAt: future, AutoRenew: &off, InGracePeriod: true},
+			wantTier:    "pro",
+			wantProv:    "google",
+			wantRenewal: RenewalCanceled,
+			wantGrace:   true,
+		},
+		{
+			name:        "app store subscription",
+			entitlement: &pgdb.GetEntitlementRow{SubscriptionTier: "pro", SubscriptionProvider: "apple", SubscriptionExpiresAt: future},
+			wantTier:    "pro",
+			wantProv:    "apple",
+			wantRenewal: RenewalUnknown,
+		},
+		{
+			name:        "lifetime purchase",
+			entitlement: &pgdb.GetEntitlementRow{SubscriptionTier: "plus", SubscriptionProvider: "apple", SubscriptionExpiresAt: sql.NullTime{Time: lifetimeExpiresAt, Valid: true}},
+			wantTier:    "plus",
+			wantProv:    "apple",
+			wantRenewal: RenewalLifetime,
+		},
+		{
+			name:        "admin grant",
+			entitlement: &pgdb.GetEntitlementRow{SubscriptionTier: "pro", SubscriptionProvider: "admin", SubscriptionExpiresAt: future},
+			wantTier:    "pro",
+			wantProv:    ProviderManual,
+			wantRenewal: RenewalNonRenewing,
+		},
+		{
+			name:        "expired subscription",
+			entitlement: &pgdb.GetEntitlementRow{SubscriptionTier: "pro", SubscriptionProvider: "zcash", SubscriptionExpiresAt: past, InGracePeriod: true},
+			wantTier:    "free",
+			wantProv:    "zcash",
+			wantRenewal: RenewalExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := subscriptionStatus(tt.entitlement, nil, now)
+			if status.Tier != tt.wantTier || status.Provider != tt.wantProv || status.Renewal != tt.wantRenewal {
+				t.Errorf("status = %s/%s/%s, want %s/%s/%s",
+					status.Tier, status.Provider, status.Renewal, tt.wantTier, tt.wantProv, tt.wantRenewal)
+			}
+			if status.TierDisplay == "" {
+				t.Errorf("tier %s has no display name", status.Tier)
+			}
+			if got := status.GracePeriod != nil; got != tt.wantGrace {
+				t.Errorf("grace period = %v, want %v", got, tt.wantGrace)
+			}
+		})
+	}
+}
+
+func TestSubscriptionStatusHistory(t *testing.T) {
+	changedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	status := subscriptionStatus(nil, []pgdb.EntitlementEvent{
+		{SubscriptionTier: "free", SubscriptionProvider: "admin", CreatedAt: changedAt},
+	}, changedAt)
+
+	want := EntitlementChange{Tier: "free", Provider: ProviderManual, ChangedAt: changedAt}
+	if len(status.History) != 1 || status.History[0] != want {
+		t.Errorf("history = %+v, want [%+v]", status.History, want)
+	}
+}
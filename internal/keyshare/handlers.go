@@ -8,30 +8,27 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/auth"
 	"github.com/eternisai/enchanted-proxy/internal/errors"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/wspolicy"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for now
-	},
-}
-
 // Handler handles HTTP requests for key sharing
 type Handler struct {
 	service          *Service
 	websocketManager *WebSocketManager
+	wsPolicy         *wspolicy.Policy
 	logger           *logger.Logger
 }
 
 // NewHandler creates a new key sharing handler
-func NewHandler(service *Service, websocketManager *WebSocketManager, logger *logger.Logger) *Handler {
+func NewHandler(service *Service, websocketManager *WebSocketManager, wsPolicy *wspolicy.Policy, logger *logger.Logger) *Handler {
 	return &Handler{
 		service:          service,
 		websocketManager: websocketManager,
+		wsPolicy:         wsPolicy,
 		logger:           logger,
 	}
 }
@@ -245,7 +242,7 @@ func (h *Handler) WebSocketListen(c *gin.Context) {
 		slog.String("user_id", userID),
 		slog.String("session_id", sessionID))
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.wsPolicy.Upgrade(c, wspolicy.SubprotocolKeyShare)
 	if err != nil {
 		log.Error("websocket upgrade failed",
 			slog.String("user_id", userID),
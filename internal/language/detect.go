@@ -0,0 +1,189 @@
+// Package language detects the dominant language of short texts such as a chat's first user
+// message. Detection is local and cheap: the writing system decides non-Latin languages, and
+// common function words and diacritics decide between Latin-script languages.
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Language codes (ISO 639-1) returned by Detect.
+const (
+	English    = "en"
+	Spanish    = "es"
+	French     = "fr"
+	German     = "de"
+	Italian    = "it"
+	Portuguese = "pt"
+	Dutch      = "nl"
+	Russian    = "ru"
+	Ukrainian  = "uk"
+	Greek      = "el"
+	Arabic     = "ar"
+	Hebrew     = "he"
+	Hindi      = "hi"
+	Thai       = "th"
+	Chinese    = "zh"
+	Japanese   = "ja"
+	Korean     = "ko"
+)
+
+// minWordScore is the function-word score a Latin-script language needs to be detected.
+const minWordScore = 2
+
+// functionWords are frequent words of Latin-script languages. Words shared by several
+// languages count for each of them; the distinctive ones decide.
+var functionWords = map[string][]string{
+	English: {
+		"the", "and", "is", "are", "was", "to", "of", "in", "that", "it", "you", "what", "how",
+		"for", "with", "this", "can", "my", "me", "i", "do", "please", "be", "on", "why", "about",
+	},
+	Spanish: {
+		"el", "la", "los", "las", "de", "que", "y", "en", "un", "una", "es", "por", "para", "con",
+		"como", "qué", "cómo", "mi", "me", "puedes", "lo", "del", "al", "se", "no", "está", "sobre",
+	},
+	French: {
+		"le", "la", "les", "de", "des", "et", "est", "un", "une", "que", "pour", "dans", "je",
+		"tu", "vous", "avec", "comment", "quoi", "pas", "ce", "qui", "mon", "du", "sur", "au",
+	},
+	German: {
+		"der", "die", "das", "und", "ist", "ein", "eine", "ich", "du", "sie", "nicht", "mit",
+		"wie", "was", "für", "zu", "auf", "den", "dem", "mein", "bitte", "kannst", "es", "über",
+	},
+	Italian: {
+		"il", "lo", "la", "gli", "le", "di", "che", "e", "è", "un", "una", "per", "con", "come",
+		"cosa", "non", "mi", "sono", "del", "della", "puoi", "questo", "perché",
+	},
+	Portuguese: {
+		"o", "a", "os", "as", "de", "que", "e", "é", "um", "uma", "para", "com", "como", "não",
+		"meu", "minha", "você", "do", "da", "em", "por", "isso", "pode", "sobre",
+	},
+	Dutch: {
+		"de", "het", "een", "en", "is", "van", "ik", "je", "niet", "dat", "wat", "hoe", "met",
+		"voor", "op", "mijn", "kun", "dit", "zijn", "over",
+	},
+}
+
+// distinctiveLetters are letters used by only one (or mostly one) Latin-script language.
+var distinctiveLetters = map[rune]string{
+	'ñ': Spanish, '¿': Spanish, '¡': Spanish,
+	'ã': Portuguese, 'õ': Portuguese, 'ç': Portuguese,
+	'ß': German, 'ä': German, 'ö': German, 'ü': German,
+	'è': French, 'ê': French, 'â': French, 'î': French, 'ô': French, 'œ': French, 'ù': French,
+	'ì': Italian, 'ò': Italian,
+}
+
+var wordLanguages = func() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range functionWords {
+		for _, word := range words {
+			index[word] = append(index[word], lang)
+		}
+	}
+	return index
+}()
+
+// Detect returns the ISO 639-1 code of the dominant language of text, or "" if it can't be
+// determined (too short, mixed or an unsupported language).
+func Detect(text string) string {
+	var latin, kana, han, hangul, cyrillic, ukrainian, arabic, hebrew, greek, devanagari, thai int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian++
+			}
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	// Japanese mixes kana with Han characters; any kana decides it
+	scripts := []struct {
+		count int
+		lang  string
+	}{
+		{kana + han, Chinese},
+		{hangul, Korean},
+		{cyrillic, Russian},
+		{arabic, Arabic},
+		{hebrew, Hebrew},
+		{greek, Greek},
+		{devanagari, Hindi},
+		{thai, Thai},
+	}
+	best := scripts[0]
+	for _, script := range scripts[1:] {
+		if script.count > best.count {
+			best = script
+		}
+	}
+	if best.count > latin {
+		switch {
+		case best.lang == Chinese && kana > 0:
+			return Japanese
+		case best.lang == Russian && ukrainian > 0:
+			return Ukrainian
+		}
+		return best.lang
+	}
+	if latin == 0 {
+		return ""
+	}
+
+	return detectLatin(text)
+}
+
+// detectLatin scores Latin-script languages by function words and distinctive letters.
+func detectLatin(text string) string {
+	text = strings.ToLower(text)
+	scores := make(map[string]int, len(functionWords))
+	for _, r := range text {
+		if lang, ok := distinctiveLetters[r]; ok {
+			scores[lang] += 2
+		}
+	}
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for _, lang := range wordLanguages[word] {
+			scores[lang]++
+		}
+	}
+
+	best, second := "", 0
+	for _, lang := range []string{English, Spanish, French, German, Italian, Portuguese, Dutch} {
+		switch score := scores[lang]; {
+		case best == "" || score > scores[best]:
+			if best != "" {
+				second = scores[best]
+			}
+			best = lang
+		case score > second:
+			second = score
+		}
+	}
+	if scores[best] < minWordScore || scores[best] == second {
+		return ""
+	}
+	return best
+}
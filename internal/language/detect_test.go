@@ -0,0 +1,43 @@
+package language
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"How do I make a sourdough starter at home?", English},
+		{"What is the capital of Australia and why was it chosen?", English},
+		{"¿Cómo puedo aprender a programar en Python?", Spanish},
+		{"Necesito una receta para la cena de esta noche", Spanish},
+		{"Comment est-ce que je peux améliorer mon français ?", French},
+		{"Quelle est la meilleure façon de voyager en Europe avec un petit budget", French},
+		{"Wie kann ich meine Steuererklärung online machen?", German},
+		{"Ich suche ein gutes Rezept für das Abendessen", German},
+		{"Come posso imparare a suonare la chitarra? Non so da dove iniziare", Italian},
+		{"Você pode me ajudar com uma carta para o meu chefe?", Portuguese},
+		{"Hoe kan ik het beste mijn fiets repareren voor de winter", Dutch},
+		{"Как приготовить борщ?", Russian},
+		{"Як навчитися програмувати? Що для цього потрібно", Ukrainian},
+		{"如何学习编程？", Chinese},
+		{"日本語を勉強したいです", Japanese},
+		{"한국어를 배우고 싶어요", Korean},
+		{"كيف يمكنني تعلم البرمجة؟", Arabic},
+		{"איך אני לומד לתכנת?", Hebrew},
+		{"Πώς μπορώ να μάθω προγραμματισμό;", Greek},
+		{"मैं प्रोग्रामिंग कैसे सीखूं?", Hindi},
+		{"ฉันจะเรียนเขียนโปรแกรมได้อย่างไร", Thai},
+		{"", ""},
+		{"12345 !!!", ""},
+		{"Python", ""},
+		{"de la", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Detect(tt.text); got != tt.want {
+				t.Errorf("Detect(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -26,6 +26,18 @@ func WithOperation(ctx context.Context, operation string) context.Context {
 	return context.WithValue(ctx, ContextKeyOperation, operation)
 }
 
+// WithDebugTrace adds a debug trace token to the context. Loggers created with WithContext
+// from it log at every level and tag records with the token.
+func WithDebugTrace(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, ContextKeyDebugTrace, token)
+}
+
+// DebugTraceFromContext returns the debug trace token of the context, or "" if not traced.
+func DebugTraceFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(ContextKeyDebugTrace).(string)
+	return token
+}
+
 // GenerateRequestID generates a new request ID.
 func GenerateRequestID() string {
 	requestID := uuid.New()
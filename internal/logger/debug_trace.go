@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// levelHandler applies the configured log level in front of the output handler, so a
+// single request can be elevated to debug logging (see WithDebugTrace). The level is shared
+// by the loggers derived from the same New logger, so SetLevel applies to all of them.
+type levelHandler struct {
+	slog.Handler
+	level    slog.Leveler
+	elevated bool
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.elevated || level >= h.level.Level()
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level, elevated: h.elevated}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level, elevated: h.elevated}
+}
+
+// withDebugTrace tags the logger with the trace token and enables every level. Loggers that
+// are already elevated are returned as is, so the token isn't repeated on each record.
+func withDebugTrace(logger *slog.Logger, token string) *slog.Logger {
+	if h, ok := logger.Handler().(*levelHandler); ok && h.elevated {
+		return logger
+	}
+
+	logger = logger.With(slog.String("debug_trace", token))
+	if h, ok := logger.Handler().(*levelHandler); ok {
+		return slog.New(&levelHandler{Handler: h.Handler, level: h.level, elevated: true})
+	}
+	return logger
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDebugTraceElevatesLevel(t *testing.T) {
+	var buf bytes.Buffer
+	base := &Logger{
+		Logger: slog.New(&levelHandler{Handler: slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), level: slog.LevelWarn}),
+	}
+
+	base.WithContext(context.Background()).Debug("untraced")
+	if buf.Len() != 0 {
+		t.Fatalf("debug record logged below the configured level: %s", buf.String())
+	}
+
+	ctx := WithDebugTrace(context.Background(), "trace-123")
+	traced := base.WithContext(ctx).WithComponent("proxy")
+	traced.Debug("traced")
+	if !strings.Contains(buf.String(), `"msg":"traced"`) || !strings.Contains(buf.String(), `"debug_trace":"trace-123"`) {
+		t.Fatalf("traced debug record missing or untagged: %s", buf.String())
+	}
+
+	// Re-applying the trace must not repeat the token
+	buf.Reset()
+	traced.WithContext(ctx).WithDebugTrace("trace-123").Info("again")
+	if n := strings.Count(buf.String(), "debug_trace"); n != 1 {
+		t.Errorf("debug_trace appears %d times, want 1: %s", n, buf.String())
+	}
+
+	// The base logger is unaffected
+	buf.Reset()
+	base.Debug("still untraced")
+	if buf.Len() != 0 {
+		t.Errorf("base logger was elevated: %s", buf.String())
+	}
+}
+
+func TestSetLevelAppliesToDerivedLoggers(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	base := &Logger{
+		Logger: slog.New(&levelHandler{Handler: slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), level: level}),
+	}
+	derived := base.WithComponent("proxy")
+
+	derived.Info("before")
+	if buf.Len() != 0 {
+		t.Fatalf("info record logged at warn level: %s", buf.String())
+	}
+
+	base.SetLevel(slog.LevelInfo)
+	derived.Info("after")
+	if !strings.Contains(buf.String(), `"msg":"after"`) {
+		t.Errorf("derived logger didn't pick up the new level: %s", buf.String())
+	}
+}
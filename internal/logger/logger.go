@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/lmittmann/tint"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // instanceID is a unique identifier for this server instance.
@@ -55,6 +56,8 @@ const (
 	ContextKeyChatID contextKey = "chat_id"
 	// ContextKeyOperation is the key for operation name in the context.
 	ContextKeyOperation contextKey = "operation"
+	// ContextKeyDebugTrace is the key for the debug trace token in the context.
+	ContextKeyDebugTrace contextKey = "debug_trace"
 )
 
 // Logger wraps slog.Logger.
@@ -64,9 +67,12 @@ type Logger struct {
 
 // New creates a new logger with the given config.
 func New(config Config) *Logger {
+	level := new(slog.LevelVar)
+	level.Set(config.Level)
+
 	if config.Format == "json" {
 		opts := &slog.HandlerOptions{
-			Level:     config.Level,
+			Level:     slog.LevelDebug, // filtered by levelHandler
 			AddSource: true,
 			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 				// Better timestamp format.
@@ -85,19 +91,29 @@ func New(config Config) *Logger {
 		}
 		// Add instance_id to all logs for distributed tracing
 		return &Logger{
-			Logger: slog.New(slog.NewJSONHandler(os.Stdout, opts)).With(slog.String("instance_id", instanceID)),
+			Logger: slog.New(&levelHandler{Handler: slog.NewJSONHandler(os.Stdout, opts), level: level}).With(slog.String("instance_id", instanceID)),
 		}
 	}
 
 	opts := &tint.Options{
-		Level:      config.Level,
+		Level:      slog.LevelDebug, // filtered by levelHandler
 		AddSource:  true,
 		TimeFormat: time.Kitchen,
 	}
 
 	// Add instance_id to all logs for distributed tracing
 	return &Logger{
-		Logger: slog.New(tint.NewHandler(os.Stdout, opts)).With(slog.String("instance_id", instanceID)),
+		Logger: slog.New(&levelHandler{Handler: tint.NewHandler(os.Stdout, opts), level: level}).With(slog.String("instance_id", instanceID)),
+	}
+}
+
+// SetLevel changes the log level of the logger and of every logger derived from the same New
+// logger (With, WithContext...). Requests elevated with WithDebugTrace keep logging every level.
+func (l *Logger) SetLevel(level slog.Level) {
+	if h, ok := l.Handler().(*levelHandler); ok {
+		if v, ok := h.level.(*slog.LevelVar); ok {
+			v.Set(level)
+		}
 	}
 }
 
@@ -151,11 +167,27 @@ func (l *Logger) WithContext(ctx context.Context) *Logger {
 		logger = logger.With(slog.String("operation", operation))
 	}
 
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.With(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	if token, ok := ctx.Value(ContextKeyDebugTrace).(string); ok && token != "" {
+		logger = withDebugTrace(logger, token)
+	}
+
 	return &Logger{
 		Logger: logger,
 	}
 }
 
+// WithDebugTrace creates a new logger that tags every record with the debug trace token
+// and logs at every level, regardless of the configured one.
+func (l *Logger) WithDebugTrace(token string) *Logger {
+	return &Logger{
+		Logger: withDebugTrace(l.Logger, token),
+	}
+}
+
 // WithComponent creates a new logger with a component name.
 func (l *Logger) WithComponent(component string) *Logger {
 	return &Logger{
@@ -0,0 +1,138 @@
+package messageindex
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
+)
+
+// writeTimeout bounds a single upsert so a slow database cannot stall the worker.
+const writeTimeout = 10 * time.Second
+
+// Syncer mirrors message metadata saved to Firestore into the Postgres message_index table.
+// Content is never copied; the index exists for analytics, retention, and export without
+// Firestore collection scans. Firestore remains the source of truth: entries that are
+// dropped (full queue) or fail to write are only logged.
+type Syncer struct {
+	queries      pgdb.Querier
+	logger       *logger.Logger
+	pool         *worker.Pool[messaging.IndexEntry]
+	droppedTotal atomic.Int64
+	failedTotal  atomic.Int64
+	writtenTotal atomic.Int64
+}
+
+// NewSyncer creates a syncer and starts its worker.
+func NewSyncer(queries pgdb.Querier, bufferSize int, logger *logger.Logger) *Syncer {
+	s := &Syncer{
+		queries: queries,
+		logger:  logger,
+	}
+
+	s.pool = worker.NewPool(worker.Options{
+		Name:      "message_index",
+		Workers:   1,
+		QueueSize: bufferSize,
+		Timeout:   writeTimeout,
+	}, s.write, logger)
+
+	logger.Info("message index syncer started", slog.Int("buffer_size", bufferSize))
+
+	return s
+}
+
+// Enqueue queues an entry for writing. Never blocks; drops the entry when the queue is full.
+func (s *Syncer) Enqueue(entry messaging.IndexEntry) {
+	err := s.pool.TryEnqueue(entry)
+	if err == nil {
+		return
+	}
+
+	dropped := s.droppedTotal.Add(1)
+	if errors.Is(err, worker.ErrQueueFull) {
+		s.logger.Warn("message index queue full - entry dropped",
+			slog.String("user_id", entry.UserID),
+			slog.String("chat_id", entry.ChatID),
+			slog.String("message_id", entry.MessageID),
+			slog.Int64("total_dropped", dropped))
+	}
+}
+
+// write upserts an entry. Failures are counted and logged here; the error is nil so the
+// pool doesn't log them again.
+func (s *Syncer) write(ctx context.Context, entry messaging.IndexEntry) error {
+	if err := s.queries.UpsertMessageIndexEntry(ctx, toParams(entry)); err != nil {
+		s.failedTotal.Add(1)
+		s.logger.Error("failed to write message index entry",
+			slog.String("user_id", entry.UserID),
+			slog.String("chat_id", entry.ChatID),
+			slog.String("message_id", entry.MessageID),
+			slog.String("error", err.Error()))
+		return nil
+	}
+	s.writtenTotal.Add(1)
+	return nil
+}
+
+// Shutdown stops accepting entries and waits for the queue to drain.
+func (s *Syncer) Shutdown() {
+	s.logger.Info("shutting down message index syncer")
+	_ = s.pool.Shutdown(context.Background())
+	s.logger.Info("message index syncer shutdown complete",
+		slog.Int64("written_total", s.writtenTotal.Load()),
+		slog.Int64("failed_total", s.failedTotal.Load()),
+		slog.Int64("dropped_total", s.droppedTotal.Load()))
+}
+
+// GetMetrics returns syncer counters and queue depth.
+func (s *Syncer) GetMetrics() map[string]int64 {
+	return map[string]int64{
+		"written_total":  s.writtenTotal.Load(),
+		"failed_total":   s.failedTotal.Load(),
+		"dropped_total":  s.droppedTotal.Load(),
+		"queue_size":     int64(s.pool.Len()),
+		"queue_capacity": int64(s.pool.Cap()),
+	}
+}
+
+func toParams(entry messaging.IndexEntry) pgdb.UpsertMessageIndexEntryParams {
+	params := pgdb.UpsertMessageIndexEntryParams{
+		UserID:           entry.UserID,
+		ChatID:           entry.ChatID,
+		MessageID:        entry.MessageID,
+		IsFromUser:       entry.IsFromUser,
+		IsError:          entry.IsError,
+		Stopped:          entry.Stopped,
+		Model:            optionalString(entry.Model),
+		GenerationState:  optionalString(entry.GenerationState),
+		PromptTokens:     optionalInt32(entry.PromptTokens),
+		CompletionTokens: optionalInt32(entry.CompletionTokens),
+		MessageTimestamp: entry.Timestamp,
+	}
+	if entry.GenerationCompletedAt != nil {
+		params.GenerationCompletedAt = sql.NullTime{Time: *entry.GenerationCompletedAt, Valid: true}
+	}
+	return params
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func optionalInt32(value *int) sql.NullInt32 {
+	if value == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*value), Valid: true}
+}
@@ -0,0 +1,73 @@
+package messageindex
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+)
+
+func TestToParams(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	prompt, completion := 50, 100
+
+	tests := []struct {
+		name           string
+		entry          messaging.IndexEntry
+		wantModel      *string
+		wantPrompt     sql.NullInt32
+		wantCompletion sql.NullInt32
+		wantCompleted  sql.NullTime
+	}{
+		{
+			name: "user message without metadata",
+			entry: messaging.IndexEntry{
+				UserID: "u1", ChatID: "c1", MessageID: "m1", IsFromUser: true, Timestamp: ts,
+			},
+		},
+		{
+			name: "assistant message with usage",
+			entry: messaging.IndexEntry{
+				UserID: "u1", ChatID: "c1", MessageID: "m2", Model: "gpt-5",
+				GenerationState: "completed", PromptTokens: &prompt, CompletionTokens: &completion,
+				Timestamp: ts, GenerationCompletedAt: &ts,
+			},
+			wantModel:      strPtr("gpt-5"),
+			wantPrompt:     sql.NullInt32{Int32: 50, Valid: true},
+			wantCompletion: sql.NullInt32{Int32: 100, Valid: true},
+			wantCompleted:  sql.NullTime{Time: ts, Valid: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toParams(tt.entry)
+			if got.UserID != tt.entry.UserID || got.ChatID != tt.entry.ChatID || got.MessageID != tt.entry.MessageID {
+				t.Errorf("keys = %q/%q/%q", got.UserID, got.ChatID, got.MessageID)
+			}
+			if got.IsFromUser != tt.entry.IsFromUser {
+				t.Errorf("IsFromUser = %v, want %v", got.IsFromUser, tt.entry.IsFromUser)
+			}
+			if (got.Model == nil) != (tt.wantModel == nil) || (got.Model != nil && *got.Model != *tt.wantModel) {
+				t.Errorf("Model = %v, want %v", got.Model, tt.wantModel)
+			}
+			if got.PromptTokens != tt.wantPrompt {
+				t.Errorf("PromptTokens = %v, want %v", got.PromptTokens, tt.wantPrompt)
+			}
+			if got.CompletionTokens != tt.wantCompletion {
+				t.Errorf("CompletionTokens = %v, want %v", got.CompletionTokens, tt.wantCompletion)
+			}
+			if got.GenerationCompletedAt != tt.wantCompleted {
+				t.Errorf("GenerationCompletedAt = %v, want %v", got.GenerationCompletedAt, tt.wantCompleted)
+			}
+			if !got.MessageTimestamp.Equal(ts) {
+				t.Errorf("MessageTimestamp = %v, want %v", got.MessageTimestamp, ts)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,387 @@
+// Package messagestore stores chats, messages and drafts in the proxy's database instead of
+// Firestore (MESSAGE_STORAGE_BACKEND=database), for self-hosted deployments without Firebase.
+// It implements messaging.Store with the same error codes as the Firestore client.
+//
+// There is no public key registry outside Firestore, so GetUserPublicKey always reports
+// NotFound: messages are stored as plaintext unless encryption is required, in which case
+// storing them fails.
+package messagestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store is a messaging.Store backed by the chats, chat_messages and chat_drafts tables.
+type Store struct {
+	queries pgdb.Querier
+}
+
+var _ messaging.Store = (*Store)(nil)
+
+// New creates a database message store.
+func New(queries pgdb.Querier) *Store {
+	return &Store{queries: queries}
+}
+
+// GetUserPublicKey always returns NotFound (see the package comment).
+func (s *Store) GetUserPublicKey(ctx context.Context, userID string) (*messaging.UserPublicKey, error) {
+	return nil, status.Errorf(codes.NotFound, "no public key registry in the database store: user=%s", userID)
+}
+
+// SaveMessage stores a message, replacing any previous version, and records it in its chat.
+// Unlike in Firestore, the chat is created on its first message.
+func (s *Store) SaveMessage(ctx context.Context, userID string, msg *messaging.ChatMessage) error {
+	if userID == "" || msg == nil || msg.ChatID == "" || msg.ID == "" {
+		return status.Error(codes.InvalidArgument, "userID, chatID, and messageID must be non-empty")
+	}
+	if len(msg.EncryptedContent) == 0 && msg.GenerationState != "thinking" {
+		return status.Error(codes.InvalidArgument, "encrypted content must be non-empty (except for thinking placeholders)")
+	}
+
+	if err := s.queries.TouchChat(ctx, pgdb.TouchChatParams{
+		UserID:           userID,
+		ChatID:           msg.ChatID,
+		MessageTimestamp: msg.Timestamp,
+	}); err != nil {
+		return status.Errorf(codes.Internal, "failed to update chat user=%s chat=%s: %v", userID, msg.ChatID, err)
+	}
+	if err := s.queries.UpsertChatMessage(ctx, messageParams(userID, msg)); err != nil {
+		return status.Errorf(codes.Internal, "failed to save message user=%s chat=%s id=%s: %v", userID, msg.ChatID, msg.ID, err)
+	}
+	return nil
+}
+
+// GetMessage retrieves a message.
+func (s *Store) GetMessage(ctx context.Context, userID, chatID, messageID string) (*messaging.ChatMessage, error) {
+	if userID == "" || chatID == "" || messageID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID, chatID, and messageID must be non-empty")
+	}
+
+	row, err := s.queries.GetChatMessage(ctx, pgdb.GetChatMessageParams{UserID: userID, ChatID: chatID, MessageID: messageID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "message not found: user=%s chat=%s id=%s", userID, chatID, messageID)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get message user=%s chat=%s id=%s: %v", userID, chatID, messageID, err)
+	}
+	return &messaging.ChatMessage{
+		ID:                      row.MessageID,
+		EncryptedContent:        row.EncryptedContent,
+		IsFromUser:              row.IsFromUser,
+		ChatID:                  row.ChatID,
+		IsError:                 row.IsError,
+		Timestamp:               row.MessageTimestamp,
+		PublicEncryptionKey:     row.PublicEncryptionKey,
+		Stopped:                 row.Stopped,
+		StoppedBy:               row.StoppedBy,
+		StopReason:              row.StopReason,
+		Model:                   row.Model,
+		GenerationState:         row.GenerationState,
+		GenerationStartedAt:     row.GenerationStartedAt.Time,
+		GenerationCompletedAt:   row.GenerationCompletedAt.Time,
+		GenerationError:         row.GenerationError,
+		EncryptedMaskedKeywords: row.EncryptedMaskedKeywords,
+		EncryptedReasoning:      row.EncryptedReasoning,
+		DeliveredAt:             row.DeliveredAt.Time,
+		ReadAt:                  row.ReadAt.Time,
+	}, nil
+}
+
+// UpdateMessage sets fields of an existing message, keyed by their Firestore names.
+func (s *Store) UpdateMessage(ctx context.Context, userID, chatID, messageID string, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return status.Error(codes.InvalidArgument, "updates must be non-empty")
+	}
+
+	msg, err := s.GetMessage(ctx, userID, chatID, messageID)
+	if err != nil {
+		return err
+	}
+	for field, value := range updates {
+		if err := setField(msg, field, value); err != nil {
+			return err
+		}
+	}
+
+	if err := s.queries.UpsertChatMessage(ctx, messageParams(userID, msg)); err != nil {
+		return status.Errorf(codes.Internal, "failed to update message user=%s chat=%s id=%s: %v", userID, chatID, messageID, err)
+	}
+	return nil
+}
+
+// setField sets a message field by its Firestore name.
+func setField(msg *messaging.ChatMessage, field string, value interface{}) error {
+	var ok bool
+	switch field {
+	case "generationState":
+		msg.GenerationState, ok = value.(string)
+	case "generationError":
+		msg.GenerationError, ok = value.(string)
+	case "generationStartedAt":
+		msg.GenerationStartedAt, ok = value.(time.Time)
+	case "generationCompletedAt":
+		msg.GenerationCompletedAt, ok = value.(time.Time)
+	case "stopped":
+		msg.Stopped, ok = value.(bool)
+	case "stoppedBy":
+		msg.StoppedBy, ok = value.(string)
+	case "stopReason":
+		msg.StopReason, ok = value.(string)
+	case "deliveredAt":
+		msg.DeliveredAt, ok = value.(time.Time)
+	case "readAt":
+		msg.ReadAt, ok = value.(time.Time)
+	default:
+		return status.Errorf(codes.InvalidArgument, "field %s can't be updated", field)
+	}
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "invalid value for %s: %T", field, value)
+	}
+	return nil
+}
+
+// SaveChatTitle saves the chat title (plaintext or encrypted), creating the chat if needed.
+// IMPORTANT: Only ONE of Title or EncryptedTitle should be set, never both
+func (s *Store) SaveChatTitle(ctx context.Context, userID, chatID string, title *messaging.ChatTitle) error {
+	if userID == "" || chatID == "" || title == nil {
+		return status.Error(codes.InvalidArgument, "userID, chatID, and title must be non-empty")
+	}
+	hasPlaintext := len(title.Title) > 0
+	hasEncrypted := len(title.EncryptedTitle) > 0
+	if !hasPlaintext && !hasEncrypted {
+		return status.Error(codes.InvalidArgument, "either title or encryptedTitle must be set")
+	}
+	if hasPlaintext && hasEncrypted {
+		return status.Error(codes.InvalidArgument, "cannot set both title and encryptedTitle")
+	}
+
+	// Setting one kind of title clears the other, like in Firestore
+	params := pgdb.UpsertChatTitleParams{
+		UserID:    userID,
+		ChatID:    chatID,
+		Language:  optional(title.Language),
+		UpdatedAt: title.UpdatedAt,
+	}
+	if hasEncrypted {
+		params.EncryptedTitle = &title.EncryptedTitle
+		params.TitlePublicEncryptionKey = optional(title.TitlePublicEncryptionKey)
+	} else {
+		params.Title = &title.Title
+	}
+	if err := s.queries.UpsertChatTitle(ctx, params); err != nil {
+		return status.Errorf(codes.Internal, "failed to save title user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return nil
+}
+
+// VerifyChatOwnership checks if a user owns a specific chat
+// Returns nil if user owns the chat, error otherwise
+func (s *Store) VerifyChatOwnership(ctx context.Context, userID, chatID string) error {
+	if userID == "" || chatID == "" {
+		return status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	if _, err := s.queries.GetChat(ctx, pgdb.GetChatParams{UserID: userID, ChatID: chatID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status.Errorf(codes.PermissionDenied, "chat not found or access denied")
+		}
+		return status.Errorf(codes.Internal, "failed to verify chat ownership: %v", err)
+	}
+	return nil
+}
+
+// SaveResponseID stores the latest OpenAI Responses API response_id for a chat.
+func (s *Store) SaveResponseID(ctx context.Context, userID, chatID, responseID string) error {
+	if userID == "" || chatID == "" || responseID == "" {
+		return status.Error(codes.InvalidArgument, "userID, chatID, and responseID must be non-empty")
+	}
+	if !strings.HasPrefix(responseID, "resp_") {
+		return status.Errorf(codes.InvalidArgument, "invalid responseID format: %s (expected resp_* prefix)", responseID)
+	}
+
+	if err := s.queries.UpsertChatResponseID(ctx, pgdb.UpsertChatResponseIDParams{
+		UserID:         userID,
+		ChatID:         chatID,
+		LastResponseID: &responseID,
+	}); err != nil {
+		return status.Errorf(codes.Internal, "failed to save response_id user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return nil
+}
+
+// GetResponseID retrieves the latest OpenAI Responses API response_id for a chat, or "" if
+// the chat doesn't exist or has none.
+func (s *Store) GetResponseID(ctx context.Context, userID, chatID string) (string, error) {
+	if userID == "" || chatID == "" {
+		return "", status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	chat, err := s.queries.GetChat(ctx, pgdb.GetChatParams{UserID: userID, ChatID: chatID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", status.Errorf(codes.Internal, "failed to get chat user=%s chat=%s: %v", userID, chatID, err)
+	}
+	if chat.LastResponseID == nil {
+		return "", nil
+	}
+	return *chat.LastResponseID, nil
+}
+
+// SaveDraft stores the draft of a chat, replacing any previous one.
+func (s *Store) SaveDraft(ctx context.Context, userID, chatID string, draft *messaging.ChatDraft) error {
+	if userID == "" || chatID == "" || draft == nil {
+		return status.Error(codes.InvalidArgument, "userID, chatID, and draft must be non-empty")
+	}
+
+	if err := s.queries.UpsertChatDraft(ctx, pgdb.UpsertChatDraftParams{
+		UserID:              userID,
+		ChatID:              chatID,
+		EncryptedContent:    draft.EncryptedContent,
+		PublicEncryptionKey: draft.PublicEncryptionKey,
+		UpdatedAt:           draft.UpdatedAt,
+	}); err != nil {
+		return status.Errorf(codes.Internal, "failed to save draft user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return nil
+}
+
+// GetDraft retrieves the draft of a chat.
+// Returns nil (not an error) if the chat has no draft.
+func (s *Store) GetDraft(ctx context.Context, userID, chatID string) (*messaging.ChatDraft, error) {
+	if userID == "" || chatID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	row, err := s.queries.GetChatDraft(ctx, pgdb.GetChatDraftParams{UserID: userID, ChatID: chatID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get draft user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return &messaging.ChatDraft{
+		EncryptedContent:    row.EncryptedContent,
+		PublicEncryptionKey: row.PublicEncryptionKey,
+		UpdatedAt:           row.UpdatedAt,
+	}, nil
+}
+
+// DeleteDraft removes the draft of a chat. Deleting a missing draft is not an error.
+func (s *Store) DeleteDraft(ctx context.Context, userID, chatID string) error {
+	if userID == "" || chatID == "" {
+		return status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	if err := s.queries.DeleteChatDraft(ctx, pgdb.DeleteChatDraftParams{UserID: userID, ChatID: chatID}); err != nil {
+		return status.Errorf(codes.Internal, "failed to delete draft user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return nil
+}
+
+// GetChatMetadata retrieves the pin, archive and mute state of a chat.
+func (s *Store) GetChatMetadata(ctx context.Context, userID, chatID string) (*messaging.ChatMetadata, error) {
+	if userID == "" || chatID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	chat, err := s.queries.GetChat(ctx, pgdb.GetChatParams{UserID: userID, ChatID: chatID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "chat not found: user=%s chat=%s", userID, chatID)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get chat user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return &messaging.ChatMetadata{
+		Pinned:            chat.Pinned,
+		PinnedAt:          chat.PinnedAt.Time,
+		Archived:          chat.Archived,
+		Muted:             chat.Muted,
+		MetadataUpdatedAt: chat.MetadataUpdatedAt.Time,
+	}, nil
+}
+
+// UpdateChatMetadata changes the pin, archive and mute state of a chat and returns the result.
+// The chat must exist; its updated_at is left alone so organizing a chat doesn't move it in
+// the chat list.
+func (s *Store) UpdateChatMetadata(ctx context.Context, userID, chatID string, update messaging.ChatMetadataUpdate) (*messaging.ChatMetadata, error) {
+	if userID == "" || chatID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	row, err := s.queries.UpdateChatMetadata(ctx, pgdb.UpdateChatMetadataParams{
+		Pinned:   nullBool(update.Pinned),
+		Now:      time.Now(),
+		Archived: nullBool(update.Archived),
+		Muted:    nullBool(update.Muted),
+		UserID:   userID,
+		ChatID:   chatID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "chat not found: user=%s chat=%s", userID, chatID)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update chat metadata user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return &messaging.ChatMetadata{
+		Pinned:            row.Pinned,
+		PinnedAt:          row.PinnedAt.Time,
+		Archived:          row.Archived,
+		Muted:             row.Muted,
+		MetadataUpdatedAt: row.MetadataUpdatedAt.Time,
+	}, nil
+}
+
+// messageParams returns the upsert of a message. Zero times are stored as NULL.
+func messageParams(userID string, msg *messaging.ChatMessage) pgdb.UpsertChatMessageParams {
+	return pgdb.UpsertChatMessageParams{
+		UserID:                  userID,
+		ChatID:                  msg.ChatID,
+		MessageID:               msg.ID,
+		EncryptedContent:        msg.EncryptedContent,
+		PublicEncryptionKey:     msg.PublicEncryptionKey,
+		IsFromUser:              msg.IsFromUser,
+		IsError:                 msg.IsError,
+		MessageTimestamp:        msg.Timestamp,
+		Stopped:                 msg.Stopped,
+		StoppedBy:               msg.StoppedBy,
+		StopReason:              msg.StopReason,
+		Model:                   msg.Model,
+		GenerationState:         msg.GenerationState,
+		GenerationStartedAt:     nullTime(msg.GenerationStartedAt),
+		GenerationCompletedAt:   nullTime(msg.GenerationCompletedAt),
+		GenerationError:         msg.GenerationError,
+		EncryptedMaskedKeywords: msg.EncryptedMaskedKeywords,
+		EncryptedReasoning:      msg.EncryptedReasoning,
+		DeliveredAt:             nullTime(msg.DeliveredAt),
+		ReadAt:                  nullTime(msg.ReadAt),
+	}
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,175 @@
+package messagestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/storage/pg"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := pg.InitDatabase("sqlite::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+	return New(db.Queries)
+}
+
+func TestMessages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.VerifyChatOwnership(ctx, "user-1", "chat-1"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ownership of a missing chat: %v, want PermissionDenied", err)
+	}
+
+	sent := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	msg := &messaging.ChatMessage{
+		ID:                  "msg-1",
+		ChatID:              "chat-1",
+		PublicEncryptionKey: "none",
+		Timestamp:           sent,
+		Model:               "gpt-5-pro",
+		GenerationState:     "thinking",
+		GenerationStartedAt: sent,
+	}
+	if err := store.SaveMessage(ctx, "user-1", msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.VerifyChatOwnership(ctx, "user-1", "chat-1"); err != nil {
+		t.Errorf("ownership after the first message: %v", err)
+	}
+	if err := store.VerifyChatOwnership(ctx, "user-2", "chat-1"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("ownership by another user: %v, want PermissionDenied", err)
+	}
+
+	completed := sent.Add(time.Minute)
+	if err := store.UpdateMessage(ctx, "user-1", "chat-1", "msg-1", map[string]interface{}{
+		"generationState":       "completed",
+		"generationCompletedAt": completed,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetMessage(ctx, "user-1", "chat-1", "msg-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GenerationState != "completed" || !got.GenerationCompletedAt.Equal(completed) ||
+		!got.GenerationStartedAt.Equal(sent) || got.Model != "gpt-5-pro" || !got.ReadAt.IsZero() {
+		t.Errorf("message = %+v", got)
+	}
+
+	if err := store.UpdateMessage(ctx, "user-1", "chat-1", "msg-1", map[string]interface{}{"content": "x"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("update of an unknown field: %v, want InvalidArgument", err)
+	}
+	if err := store.UpdateMessage(ctx, "user-1", "chat-1", "msg-2", map[string]interface{}{"readAt": completed}); status.Code(err) != codes.NotFound {
+		t.Errorf("update of a missing message: %v, want NotFound", err)
+	}
+	if _, err := store.GetMessage(ctx, "user-1", "chat-1", "msg-2"); status.Code(err) != codes.NotFound {
+		t.Errorf("missing message: %v, want NotFound", err)
+	}
+
+	msg.EncryptedContent = ""
+	msg.GenerationState = "completed"
+	if err := store.SaveMessage(ctx, "user-1", msg); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty completed message: %v, want InvalidArgument", err)
+	}
+}
+
+func TestChats(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if _, err := store.GetChatMetadata(ctx, "user-1", "chat-1"); status.Code(err) != codes.NotFound {
+		t.Errorf("metadata of a missing chat: %v, want NotFound", err)
+	}
+	pinned := true
+	if _, err := store.UpdateChatMetadata(ctx, "user-1", "chat-1", messaging.ChatMetadataUpdate{Pinned: &pinned}); status.Code(err) != codes.NotFound {
+		t.Errorf("update of a missing chat: %v, want NotFound", err)
+	}
+
+	if err := store.SaveChatTitle(ctx, "user-1", "chat-1", &messaging.ChatTitle{Title: "Plans", Language: "en", UpdatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveChatTitle(ctx, "user-1", "chat-1", &messaging.ChatTitle{Title: "a", EncryptedTitle: "b"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("both titles: %v, want InvalidArgument", err)
+	}
+
+	first, err := store.UpdateChatMetadata(ctx, "user-1", "chat-1", messaging.ChatMetadataUpdate{Pinned: &pinned})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Pinned || first.PinnedAt.IsZero() || first.Archived {
+		t.Errorf("metadata = %+v, want pinned", first)
+	}
+	// Pinning again keeps the pin time; other fields change independently
+	archived := true
+	second, err := store.UpdateChatMetadata(ctx, "user-1", "chat-1", messaging.ChatMetadataUpdate{Pinned: &pinned, Archived: &archived})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.PinnedAt.Equal(first.PinnedAt) || !second.Archived {
+		t.Errorf("metadata = %+v, want the pin time %v kept and archived", second, first.PinnedAt)
+	}
+	unpinned := false
+	if _, err := store.UpdateChatMetadata(ctx, "user-1", "chat-1", messaging.ChatMetadataUpdate{Pinned: &unpinned}); err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := store.GetChatMetadata(ctx, "user-1", "chat-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Pinned || !metadata.PinnedAt.IsZero() || !metadata.Archived {
+		t.Errorf("metadata = %+v, want unpinned and archived", metadata)
+	}
+
+	if id, err := store.GetResponseID(ctx, "user-1", "chat-1"); err != nil || id != "" {
+		t.Errorf("response ID = %q, %v, want none", id, err)
+	}
+	if err := store.SaveResponseID(ctx, "user-1", "chat-1", "abc"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("invalid response ID: %v, want InvalidArgument", err)
+	}
+	if err := store.SaveResponseID(ctx, "user-1", "chat-1", "resp_abc"); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := store.GetResponseID(ctx, "user-1", "chat-1"); err != nil || id != "resp_abc" {
+		t.Errorf("response ID = %q, %v, want resp_abc", id, err)
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if draft, err := store.GetDraft(ctx, "user-1", "chat-1"); err != nil || draft != nil {
+		t.Errorf("draft = %+v, %v, want none", draft, err)
+	}
+	if err := store.DeleteDraft(ctx, "user-1", "chat-1"); err != nil {
+		t.Errorf("deleting a missing draft: %v", err)
+	}
+
+	updated := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := store.SaveDraft(ctx, "user-1", "chat-1", &messaging.ChatDraft{EncryptedContent: "hello", PublicEncryptionKey: "none", UpdatedAt: updated}); err != nil {
+		t.Fatal(err)
+	}
+	draft, err := store.GetDraft(ctx, "user-1", "chat-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if draft == nil || draft.EncryptedContent != "hello" || !draft.UpdatedAt.Equal(updated) {
+		t.Errorf("draft = %+v", draft)
+	}
+
+	if err := store.DeleteDraft(ctx, "user-1", "chat-1"); err != nil {
+		t.Fatal(err)
+	}
+	if draft, err := store.GetDraft(ctx, "user-1", "chat-1"); err != nil || draft != nil {
+		t.Errorf("draft after delete = %+v, %v, want none", draft, err)
+	}
+}
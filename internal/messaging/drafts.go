@@ -0,0 +1,71 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+// MaxDraftLength caps the characters of a stored draft.
+const MaxDraftLength = 32000
+
+// ErrEncryptionUnavailable is returned when a draft must be encrypted but the user has no
+// usable public key.
+var ErrEncryptionUnavailable = errors.New("encryption required but no valid public key is registered")
+
+// SaveDraft encrypts and stores the unsent text of a chat, replacing any previous draft.
+// Encryption follows the same rules as messages: encryptionEnabled true requires the user's
+// public key, false stores plaintext, and nil encrypts when a key is available (unless
+// MESSAGE_STORAGE_REQUIRE_ENCRYPTION is set).
+func (s *Service) SaveDraft(ctx context.Context, userID, chatID, content string, encryptionEnabled *bool) (*ChatDraft, error) {
+	draft := &ChatDraft{
+		EncryptedContent:    content,
+		PublicEncryptionKey: "none",
+		UpdatedAt:           time.Now(),
+	}
+
+	if encryptionEnabled == nil || *encryptionEnabled {
+		publicKey, err := s.getPublicKey(ctx, userID)
+		if err != nil && (encryptionEnabled != nil || config.AppConfig.MessageStorageRequireEncryption) {
+			return nil, ErrEncryptionUnavailable
+		}
+		if err == nil {
+			encrypted, err := s.encryptionService.EncryptMessage(content, publicKey.Public)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt draft: %w", err)
+			}
+			draft.EncryptedContent = encrypted
+			draft.PublicEncryptionKey = publicKey.Public
+		}
+	}
+
+	if err := s.store.SaveDraft(ctx, userID, chatID, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// GetDraft returns the draft of a chat, or nil if it has none.
+func (s *Service) GetDraft(ctx context.Context, userID, chatID string) (*ChatDraft, error) {
+	return s.store.GetDraft(ctx, userID, chatID)
+}
+
+// DeleteDraft removes the draft of a chat.
+func (s *Service) DeleteDraft(ctx context.Context, userID, chatID string) error {
+	return s.store.DeleteDraft(ctx, userID, chatID)
+}
+
+// clearDraft removes the draft of a chat once a user message is stored, since the draft has
+// been sent. Failures only leave a stale draft behind, so they are logged and ignored.
+func (s *Service) clearDraft(ctx context.Context, userID, chatID string) {
+	if err := s.store.DeleteDraft(ctx, userID, chatID); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to clear draft after message was sent",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.String("error", err.Error()))
+	}
+}
@@ -28,57 +28,67 @@ func NewEncryptionService() *EncryptionService {
 // EncryptMessage encrypts message content using ECDH + HKDF + AES-256-GCM
 // Returns base64-encoded: ephemeralPublicKey || nonce || ciphertext || tag
 func (e *EncryptionService) EncryptMessage(content string, publicKeyJWK string) (string, error) {
+	encrypted, err := e.EncryptBytes([]byte(content), publicKeyJWK)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// EncryptBytes encrypts binary data (e.g. files) like EncryptMessage, without the base64 encoding.
+// Returns: ephemeralPublicKey || nonce || ciphertext || tag
+func (e *EncryptionService) EncryptBytes(data []byte, publicKeyJWK string) ([]byte, error) {
 	// Parse JWK public key
 	recipientPubKey, err := e.parseJWKPublicKey(publicKeyJWK)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse JWK public key: %w", err)
+		return nil, fmt.Errorf("failed to parse JWK public key: %w", err)
 	}
 
 	// Generate ephemeral ECDH key pair
 	curve := ecdh.P256()
 	ephemeralPrivKey, err := curve.GenerateKey(rand.Reader)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate ephemeral key: %w", err)
+		return nil, fmt.Errorf("failed to generate ephemeral key: %w", err)
 	}
 
 	// Convert recipient's ECDSA public key to ECDH public key
 	recipientECDHPubKey, err := curve.NewPublicKey(elliptic.Marshal(elliptic.P256(), recipientPubKey.X, recipientPubKey.Y))
 	if err != nil {
-		return "", fmt.Errorf("failed to convert public key to ECDH: %w", err)
+		return nil, fmt.Errorf("failed to convert public key to ECDH: %w", err)
 	}
 
 	// Perform ECDH key agreement
 	sharedSecret, err := ephemeralPrivKey.ECDH(recipientECDHPubKey)
 	if err != nil {
-		return "", fmt.Errorf("ECDH key agreement failed: %w", err)
+		return nil, fmt.Errorf("ECDH key agreement failed: %w", err)
 	}
 
 	// Derive AES key using HKDF
 	aesKey := make([]byte, 32) // AES-256
 	kdf := hkdf.New(sha256.New, sharedSecret, nil, []byte("message-encryption"))
 	if _, err := io.ReadFull(kdf, aesKey); err != nil {
-		return "", fmt.Errorf("key derivation failed: %w", err)
+		return nil, fmt.Errorf("key derivation failed: %w", err)
 	}
 
 	// Create AES-GCM cipher
 	block, err := aes.NewCipher(aesKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to create AES cipher: %w", err)
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
 	}
 
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
 	}
 
 	// Generate random nonce
 	nonce := make([]byte, gcm.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", fmt.Errorf("failed to generate nonce: %w", err)
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
 	// Encrypt plaintext
-	ciphertext := gcm.Seal(nil, nonce, []byte(content), nil)
+	ciphertext := gcm.Seal(nil, nonce, data, nil)
 
 	// Encode as: ephemeralPublicKey || nonce || ciphertext (includes auth tag)
 	ephemeralPubKeyBytes := ephemeralPrivKey.PublicKey().Bytes()
@@ -87,7 +97,7 @@ func (e *EncryptionService) EncryptMessage(content string, publicKeyJWK string)
 	result = append(result, nonce...)
 	result = append(result, ciphertext...)
 
-	return base64.StdEncoding.EncodeToString(result), nil
+	return result, nil
 }
 
 // parseJWKPublicKey parses a JWK JSON string to an ECDSA public key
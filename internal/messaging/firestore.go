@@ -105,9 +105,12 @@ func (f *FirestoreClient) SaveMessage(ctx context.Context, userID string, msg *C
 	// Update (not create) chat document with lastMessageAt timestamp
 	// If chat document doesn't exist, this will fail - which is expected
 	// The client should create the chat document before sending messages
-	_, err := chatDocRef.Update(ctx, []firestore.Update{
-		{Path: "lastMessageAt", Value: msg.Timestamp},
-		{Path: "updatedAt", Value: msg.Timestamp},
+	err := withWriteRetry(ctx, "update_chat_timestamps", func(ctx context.Context) error {
+		_, err := chatDocRef.Update(ctx, []firestore.Update{
+			{Path: "lastMessageAt", Value: msg.Timestamp},
+			{Path: "updatedAt", Value: msg.Timestamp},
+		})
+		return err
 	})
 	if err != nil {
 		// If chat document doesn't exist, log warning but continue with message save
@@ -135,7 +138,11 @@ func (f *FirestoreClient) SaveMessage(ctx context.Context, userID string, msg *C
 	// - Iteration 1: Partial content (e.g., <think> tags)
 	// - Iteration 2+: Complete content (think + actual response)
 	// Set() ensures the final iteration's content overwrites previous partial saves
-	_, err = docRef.Set(ctx, msg)
+	// Set with the full document is idempotent, so transient failures are retried.
+	err = withWriteRetry(ctx, "save_message", func(ctx context.Context) error {
+		_, err := docRef.Set(ctx, msg)
+		return err
+	})
 	if err != nil {
 		return status.Errorf(codes.Internal, "failed to save message user=%s chat=%s id=%s: %v", userID, msg.ChatID, msg.ID, err)
 	}
@@ -220,7 +227,11 @@ func (f *FirestoreClient) UpdateMessage(ctx context.Context, userID, chatID, mes
 		})
 	}
 
-	_, err := docRef.Update(ctx, firestoreUpdates)
+	// Updates set absolute field values, so transient failures are retried.
+	err := withWriteRetry(ctx, "update_message", func(ctx context.Context) error {
+		_, err := docRef.Update(ctx, firestoreUpdates)
+		return err
+	})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return status.Errorf(codes.NotFound, "message not found: user=%s chat=%s id=%s", userID, chatID, messageID)
@@ -263,6 +274,9 @@ func (f *FirestoreClient) SaveChatTitle(ctx context.Context, userID, chatID stri
 	updates := []firestore.Update{
 		{Path: "updatedAt", Value: title.UpdatedAt},
 	}
+	if title.Language != "" {
+		updates = append(updates, firestore.Update{Path: "language", Value: title.Language})
+	}
 
 	if hasEncrypted {
 		// Encrypted title: set encryptedTitle and titlePublicEncryptionKey
@@ -453,3 +467,163 @@ func (f *FirestoreClient) GetResponseID(ctx context.Context, userID, chatID stri
 
 	return responseIDStr, nil
 }
+
+// draftDoc returns the draft document of a chat.
+// Path: /users/{userId}/chats/{chatId}/drafts/current
+func (f *FirestoreClient) draftDoc(userID, chatID string) *firestore.DocumentRef {
+	return f.client.
+		Collection("users").
+		Doc(userID).
+		Collection("chats").
+		Doc(chatID).
+		Collection("drafts").
+		Doc("current")
+}
+
+// SaveDraft stores the draft of a chat, replacing any previous one.
+func (f *FirestoreClient) SaveDraft(ctx context.Context, userID, chatID string, draft *ChatDraft) error {
+	if f == nil || f.client == nil {
+		return status.Error(codes.Internal, "firestore client is nil")
+	}
+	if userID == "" || chatID == "" || draft == nil {
+		return status.Error(codes.InvalidArgument, "userID, chatID, and draft must be non-empty")
+	}
+
+	err := withWriteRetry(ctx, "save_draft", func(ctx context.Context) error {
+		_, err := f.draftDoc(userID, chatID).Set(ctx, draft)
+		return err
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to save draft user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return nil
+}
+
+// GetDraft retrieves the draft of a chat.
+// Returns nil (not an error) if the chat has no draft.
+func (f *FirestoreClient) GetDraft(ctx context.Context, userID, chatID string) (*ChatDraft, error) {
+	if f == nil || f.client == nil {
+		return nil, status.Error(codes.Internal, "firestore client is nil")
+	}
+	if userID == "" || chatID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	doc, err := f.draftDoc(userID, chatID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get draft user=%s chat=%s: %v", userID, chatID, err)
+	}
+
+	var draft ChatDraft
+	if err := doc.DataTo(&draft); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to parse draft user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return &draft, nil
+}
+
+// DeleteDraft removes the draft of a chat. Deleting a missing draft is not an error.
+func (f *FirestoreClient) DeleteDraft(ctx context.Context, userID, chatID string) error {
+	if f == nil || f.client == nil {
+		return status.Error(codes.Internal, "firestore client is nil")
+	}
+	if userID == "" || chatID == "" {
+		return status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	err := withWriteRetry(ctx, "delete_draft", func(ctx context.Context) error {
+		_, err := f.draftDoc(userID, chatID).Delete(ctx)
+		return err
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to delete draft user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return nil
+}
+
+// GetChatMetadata retrieves the pin, archive and mute state of a chat.
+// Path: /users/{userId}/chats/{chatId}
+func (f *FirestoreClient) GetChatMetadata(ctx context.Context, userID, chatID string) (*ChatMetadata, error) {
+	if f == nil || f.client == nil {
+		return nil, status.Error(codes.Internal, "firestore client is nil")
+	}
+	if userID == "" || chatID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	doc, err := f.client.Collection("users").Doc(userID).Collection("chats").Doc(chatID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "chat not found: user=%s chat=%s", userID, chatID)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get chat document user=%s chat=%s: %v", userID, chatID, err)
+	}
+
+	var metadata ChatMetadata
+	if err := doc.DataTo(&metadata); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to parse chat metadata user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return &metadata, nil
+}
+
+// UpdateChatMetadata changes the pin, archive and mute state of a chat and returns the result.
+// Path: /users/{userId}/chats/{chatId}
+// IMPORTANT: This only UPDATES existing chat documents, does not create new ones. The chat's
+// updatedAt is left alone so organizing a chat doesn't move it in the chat list.
+func (f *FirestoreClient) UpdateChatMetadata(ctx context.Context, userID, chatID string, update ChatMetadataUpdate) (*ChatMetadata, error) {
+	if f == nil || f.client == nil {
+		return nil, status.Error(codes.Internal, "firestore client is nil")
+	}
+	if userID == "" || chatID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID and chatID must be non-empty")
+	}
+
+	docRef := f.client.Collection("users").Doc(userID).Collection("chats").Doc(chatID)
+
+	var metadata ChatMetadata
+	// Transaction: pinnedAt only changes when the chat becomes pinned, so concurrent clients
+	// re-sending the same state don't reorder pinned chats
+	err := f.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+		metadata = ChatMetadata{}
+		if err := doc.DataTo(&metadata); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		metadata.MetadataUpdatedAt = now
+		updates := []firestore.Update{{Path: "metadataUpdatedAt", Value: now}}
+		if update.Pinned != nil && *update.Pinned != metadata.Pinned {
+			metadata.Pinned = *update.Pinned
+			updates = append(updates, firestore.Update{Path: "pinned", Value: metadata.Pinned})
+			if metadata.Pinned {
+				metadata.PinnedAt = now
+				updates = append(updates, firestore.Update{Path: "pinnedAt", Value: now})
+			} else {
+				metadata.PinnedAt = time.Time{}
+				updates = append(updates, firestore.Update{Path: "pinnedAt", Value: firestore.Delete})
+			}
+		}
+		if update.Archived != nil {
+			metadata.Archived = *update.Archived
+			updates = append(updates, firestore.Update{Path: "archived", Value: metadata.Archived})
+		}
+		if update.Muted != nil {
+			metadata.Muted = *update.Muted
+			updates = append(updates, firestore.Update{Path: "muted", Value: metadata.Muted})
+		}
+		return tx.Update(docRef, updates)
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "chat not found: user=%s chat=%s", userID, chatID)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update chat metadata user=%s chat=%s: %v", userID, chatID, err)
+	}
+	return &metadata, nil
+}
@@ -1,6 +1,10 @@
 package messaging
 
-import "time"
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
 
 // ChatMessage represents a stored chat message in Firestore
 type ChatMessage struct {
@@ -26,8 +30,41 @@ type ChatMessage struct {
 
 	// Anonymizer: encrypted replacement map (original→replacement) for PII redaction
 	EncryptedMaskedKeywords string `firestore:"encryptedMaskedKeywords,omitempty"`
+
+	// Reasoning/thinking output kept apart from content (reasoning visibility "separate")
+	EncryptedReasoning string `firestore:"encryptedReasoning,omitempty"`
+
+	// Receipts acknowledged by a client for assistant messages (zero = not yet).
+	// Rewriting the message (e.g. when generation completes) clears them.
+	DeliveredAt time.Time `firestore:"deliveredAt,omitempty"`
+	ReadAt      time.Time `firestore:"readAt,omitempty"`
+
+	// Files linked from the message (deep research artifacts), downloaded via signed URLs
+	Attachments []ChatAttachment `firestore:"attachments,omitempty"`
 }
 
+// ChatAttachment is a file stored with a message, encrypted like its content.
+type ChatAttachment struct {
+	ID                  string `firestore:"id"`                  // Retrieval ID (e.g. GET /api/v1/deepresearch/artifacts/:chatId/:id)
+	Name                string `firestore:"name"`                // File name
+	ContentType         string `firestore:"contentType"`         // MIME type of the decrypted file
+	Size                int64  `firestore:"size"`                // Size of the decrypted file in bytes
+	PublicEncryptionKey string `firestore:"publicEncryptionKey"` // Public key used (JSON string or "none")
+}
+
+// Acknowledged reports whether a client has acknowledged delivery or read of the message.
+func (m *ChatMessage) Acknowledged() bool {
+	return !m.DeliveredAt.IsZero() || !m.ReadAt.IsZero()
+}
+
+// ReceiptState is a state a client acknowledges for an assistant message.
+type ReceiptState string
+
+const (
+	ReceiptDelivered ReceiptState = "delivered" // Shown on a device
+	ReceiptRead      ReceiptState = "read"      // Seen by the user (implies delivered)
+)
+
 // UserPublicKey represents a user's ECDSA P-256 public key
 type UserPublicKey struct {
 	CreatedAt time.Time `firestore:"createdAt"`
@@ -54,7 +91,8 @@ type MessageToStore struct {
 	IsFromUser        bool
 	Content           string // Plaintext content to be encrypted
 	IsError           bool
-	EncryptionEnabled *bool // nil = not specified (backward compat), true = enforce encryption, false = store plaintext
+	EncryptionEnabled *bool      // nil = not specified (backward compat), true = enforce encryption, false = store plaintext
+	Timestamp         *time.Time // When the message was sent (nil = now); set for messages synced after the fact
 
 	// Stop control (for streaming broadcast feature)
 	Stopped    bool   // true if generation was stopped mid-stream
@@ -70,6 +108,42 @@ type MessageToStore struct {
 
 	// Anonymizer replacement map JSON (e.g. [{"original":"John","replacement":"Mark"}])
 	MaskedKeywords string
+
+	// Reasoning/thinking output separated from Content (encrypted with the same key)
+	Reasoning string
+
+	// Token usage for AI responses (nil = unknown); only written to the message index
+	PromptTokens     *int
+	CompletionTokens *int
+
+	// Debug trace token of the request that produced the message ("" = not traced)
+	DebugTrace string
+
+	// Span of the request that produced the message; storage is traced as its child. Taken from
+	// the context passed to StoreMessageAsync when not set
+	SpanContext trace.SpanContext
+}
+
+// IndexEntry is the content-free metadata of a stored message, mirrored to the Postgres message index
+type IndexEntry struct {
+	UserID                string
+	ChatID                string
+	MessageID             string
+	IsFromUser            bool
+	IsError               bool
+	Stopped               bool
+	Model                 string
+	GenerationState       string
+	PromptTokens          *int
+	CompletionTokens      *int
+	Timestamp             time.Time
+	GenerationCompletedAt *time.Time
+}
+
+// MessageIndexer receives metadata of messages successfully saved to Firestore.
+// Enqueue must not block; it is called from the storage workers.
+type MessageIndexer interface {
+	Enqueue(entry IndexEntry)
 }
 
 // ChatTitle represents a stored chat title in Firestore
@@ -78,5 +152,31 @@ type ChatTitle struct {
 	Title                    string    `firestore:"title,omitempty"`                    // Plaintext title (only when encryption disabled)
 	EncryptedTitle           string    `firestore:"encryptedTitle,omitempty"`           // Encrypted title (only when encryption enabled)
 	TitlePublicEncryptionKey string    `firestore:"titlePublicEncryptionKey,omitempty"` // Public key used (only when encrypted)
+	Language                 string    `firestore:"language,omitempty"`                 // Detected language of the chat (ISO 639-1), if known
 	UpdatedAt                time.Time `firestore:"updatedAt"`                          // Last update timestamp
 }
+
+// ChatDraft is the unsent text of a chat, synced across the user's devices
+// Path: /users/{userId}/chats/{chatId}/drafts/current
+type ChatDraft struct {
+	EncryptedContent    string    `firestore:"encryptedContent"`    // Encrypted like message content
+	PublicEncryptionKey string    `firestore:"publicEncryptionKey"` // Public key used (JSON string or "none")
+	UpdatedAt           time.Time `firestore:"updatedAt"`
+}
+
+// ChatMetadata is the organization state of a chat, written server-side so all of the user's
+// devices agree. Stored as fields of the chat document: /users/{userId}/chats/{chatId}
+type ChatMetadata struct {
+	Pinned            bool      `firestore:"pinned" json:"pinned"`
+	PinnedAt          time.Time `firestore:"pinnedAt,omitempty" json:"pinnedAt,omitempty"` // Orders pinned chats
+	Archived          bool      `firestore:"archived" json:"archived"`
+	Muted             bool      `firestore:"muted" json:"muted"` // No push notifications for this chat
+	MetadataUpdatedAt time.Time `firestore:"metadataUpdatedAt,omitempty" json:"updatedAt,omitempty"`
+}
+
+// ChatMetadataUpdate changes chat metadata; nil fields are left unchanged.
+type ChatMetadataUpdate struct {
+	Pinned   *bool `json:"pinned"`
+	Archived *bool `json:"archived"`
+	Muted    *bool `json:"muted"`
+}
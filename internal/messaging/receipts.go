@@ -0,0 +1,69 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrMessageNotFound     = errors.New("message not found")
+	ErrNotAssistantMessage = errors.New("receipts are only tracked for assistant messages")
+	ErrInvalidReceiptState = errors.New("state must be one of: delivered, read")
+)
+
+// AcknowledgeMessage records that a client delivered or read an assistant message.
+// Receipts only move forward: the first delivery and read times are kept, and read also
+// marks the message delivered. Returns the message with its updated receipts.
+func (s *Service) AcknowledgeMessage(ctx context.Context, userID, chatID, messageID string, state ReceiptState) (*ChatMessage, error) {
+	if state != ReceiptDelivered && state != ReceiptRead {
+		return nil, ErrInvalidReceiptState
+	}
+
+	msg, err := s.store.GetMessage(ctx, userID, chatID, messageID)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if msg.IsFromUser {
+		return nil, ErrNotAssistantMessage
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	if msg.DeliveredAt.IsZero() {
+		msg.DeliveredAt = now
+		updates["deliveredAt"] = now
+	}
+	if state == ReceiptRead && msg.ReadAt.IsZero() {
+		msg.ReadAt = now
+		updates["readAt"] = now
+	}
+	if len(updates) == 0 {
+		return msg, nil
+	}
+
+	if err := s.store.UpdateMessage(ctx, userID, chatID, messageID, updates); err != nil {
+		return nil, fmt.Errorf("failed to save receipt: %w", err)
+	}
+	return msg, nil
+}
+
+// IsMessageAcknowledged reports whether a client has acknowledged an assistant message.
+// A message that doesn't exist (yet) is not acknowledged.
+func (s *Service) IsMessageAcknowledged(ctx context.Context, userID, chatID, messageID string) (bool, error) {
+	msg, err := s.store.GetMessage(ctx, userID, chatID, messageID)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return msg.Acknowledged(), nil
+}
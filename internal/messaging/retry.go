@@ -0,0 +1,53 @@
+package messaging
+
+import (
+	"context"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// writeRetryPolicy retries idempotent Firestore writes (Set, field Update) on transient errors.
+// The worst case (~1.4s of backoff) fits well inside the storage worker timeout.
+var writeRetryPolicy = worker.RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Jitter:         0.2,
+}
+
+// withWriteRetry runs an idempotent Firestore write, retrying transient errors with
+// exponential backoff and jitter. Returns the last error from write, unwrapped.
+// Only use it for writes that are safe to repeat: Set with the full document, or Update
+// with absolute field values (no increments or array unions).
+func withWriteRetry(ctx context.Context, operation string, write func(ctx context.Context) error) error {
+	attempts := 0
+	var lastErr error
+
+	_ = writeRetryPolicy.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		lastErr = write(ctx)
+		if lastErr != nil && !isTransientFirestoreError(lastErr) {
+			return worker.Permanent(lastErr)
+		}
+		return lastErr
+	}, func(int, error) {
+		metrics.RecordFirestoreWriteRetry(operation)
+	})
+
+	metrics.RecordFirestoreWrite(operation, attempts, lastErr)
+	return lastErr
+}
+
+// isTransientFirestoreError reports whether a Firestore error is worth retrying.
+func isTransientFirestoreError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.ResourceExhausted, codes.Internal:
+		return true
+	default:
+		return false
+	}
+}
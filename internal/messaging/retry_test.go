@@ -0,0 +1,46 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWithWriteRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantCode     codes.Code
+	}{
+		{"success", []error{nil}, 1, codes.OK},
+		{"recovers from unavailable", []error{status.Error(codes.Unavailable, "down"), nil}, 2, codes.OK},
+		{"not found is not retried", []error{status.Error(codes.NotFound, "missing")}, 1, codes.NotFound},
+		{"gives up after max attempts", []error{
+			status.Error(codes.Unavailable, "down"),
+			status.Error(codes.DeadlineExceeded, "slow"),
+			status.Error(codes.Aborted, "contention"),
+			status.Error(codes.Unavailable, "down"),
+		}, 4, codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := withWriteRetry(context.Background(), "test", func(ctx context.Context) error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
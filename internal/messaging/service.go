@@ -2,44 +2,48 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"sync"
-	"sync/atomic"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/tracing"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Service handles async message storage with encryption
 type Service struct {
-	firestoreClient   *FirestoreClient
+	store             Store
 	encryptionService *EncryptionService
 	logger            *logger.Logger
-	messageChan       chan MessageToStore
-	workerPool        sync.WaitGroup
-	shutdown          chan struct{}
-	closed            atomic.Bool
+	indexer           MessageIndexer
+	pool              *worker.Pool[MessageToStore]
 }
 
+// enqueueTimeout bounds how long StoreMessageAsync waits for queue space (no silent drops).
+const enqueueTimeout = 35 * time.Second
+
 // NewService creates a new message storage service
-func NewService(firestoreClient *firestore.Client, logger *logger.Logger) *Service {
+func NewService(store Store, logger *logger.Logger) *Service {
 	s := &Service{
-		firestoreClient:   NewFirestoreClient(firestoreClient),
+		store:             store,
 		encryptionService: NewEncryptionService(),
 		logger:            logger,
-		messageChan:       make(chan MessageToStore, config.AppConfig.MessageStorageBufferSize), // Buffered channel to queue messages waiting for workers
-		shutdown:          make(chan struct{}),
 	}
 
-	// Start worker pool - each worker processes messages concurrently from the queue
-	for i := 0; i < config.AppConfig.MessageStorageWorkerPoolSize; i++ {
-		s.workerPool.Add(1)
-		go s.worker()
-	}
+	// Each worker processes messages concurrently from the queue. The timeout prevents
+	// workers from hanging on slow/failed Firestore operations.
+	s.pool = worker.NewPool(worker.Options{
+		Name:      "messages",
+		Workers:   config.AppConfig.MessageStorageWorkerPoolSize,
+		QueueSize: config.AppConfig.MessageStorageBufferSize,
+		Timeout:   time.Duration(config.AppConfig.MessageStorageTimeoutSeconds) * time.Second,
+	}, s.handleMessage, logger)
 
 	logger.Info("message storage service started",
 		slog.Int("worker_pool_size", config.AppConfig.MessageStorageWorkerPoolSize),
@@ -49,34 +53,37 @@ func NewService(firestoreClient *firestore.Client, logger *logger.Logger) *Servi
 	return s
 }
 
-// worker processes messages from the channel
-func (s *Service) worker() {
-	defer s.workerPool.Done()
-
-	for {
-		select {
-		case msg := <-s.messageChan:
-			s.handleMessage(msg)
-		case <-s.shutdown:
-			// Drain remaining messages
-			for {
-				select {
-				case msg := <-s.messageChan:
-					s.handleMessage(msg)
-				default:
-					return
-				}
-			}
-		}
-	}
+// SetIndexer registers an indexer that is notified of every message saved to Firestore.
+// Must be called before messages are stored.
+func (s *Service) SetIndexer(indexer MessageIndexer) {
+	s.indexer = indexer
 }
 
-// handleMessage processes and stores a single message
-func (s *Service) handleMessage(msg MessageToStore) {
-	// Timeout context prevents workers from hanging on slow/failed Firestore operations
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.AppConfig.MessageStorageTimeoutSeconds)*time.Second)
-	defer cancel()
+// SetWorkers changes the number of workers writing queued messages (config reload).
+func (s *Service) SetWorkers(workers int) {
+	s.pool.Resize(workers)
+}
+
+// Flush drains queued messages on the calling goroutine until the queue is
+// empty or ctx is done, alongside the regular workers. Returns how many were stored.
+func (s *Service) Flush(ctx context.Context) int {
+	return s.pool.Flush(ctx)
+}
+
+// QueueSize returns the number of messages waiting to be stored.
+func (s *Service) QueueSize() int {
+	return s.pool.Len()
+}
 
+// handleMessage processes and stores a single message. Failures are logged here with
+// message context; the error is nil so the pool doesn't log them again.
+func (s *Service) handleMessage(ctx context.Context, msg MessageToStore) error {
+	if msg.DebugTrace != "" {
+		ctx = logger.WithDebugTrace(ctx, msg.DebugTrace)
+	}
+	ctx, span := tracing.StartChild(ctx, msg.SpanContext, "messaging.store_message",
+		attribute.Bool("message.from_user", msg.IsFromUser))
+	defer span.End()
 	log := s.logger.WithContext(ctx)
 
 	// Generate message ID if not provided
@@ -101,7 +108,7 @@ func (s *Service) handleMessage(msg MessageToStore) {
 				slog.String("chat_id", msg.ChatID),
 				slog.String("message_id", msg.MessageID),
 				slog.String("error", err.Error()))
-			return // Fail: don't store if client expects encryption
+			return nil // Fail: don't store if client expects encryption
 		}
 
 		encrypted, err := s.encryptionService.EncryptMessage(msg.Content, publicKey.Public)
@@ -111,7 +118,7 @@ func (s *Service) handleMessage(msg MessageToStore) {
 				slog.String("chat_id", msg.ChatID),
 				slog.String("message_id", msg.MessageID),
 				slog.String("error", err.Error()))
-			return // Fail: don't store if encryption fails
+			return nil // Fail: don't store if encryption fails
 		}
 
 		encryptedContent = encrypted
@@ -142,7 +149,7 @@ func (s *Service) handleMessage(msg MessageToStore) {
 				log.Error("cannot store message without encryption (strict mode enabled)",
 					slog.String("user_id", msg.UserID),
 					slog.String("error", err.Error()))
-				return // Fail-safe: refuse to store
+				return nil // Fail-safe: refuse to store
 			}
 
 			log.Warn("failed to get public key, storing unencrypted",
@@ -158,7 +165,7 @@ func (s *Service) handleMessage(msg MessageToStore) {
 					log.Error("encryption failed, refusing to store (strict mode enabled)",
 						slog.String("user_id", msg.UserID),
 						slog.String("error", err.Error()))
-					return // Fail-safe: refuse to store
+					return nil // Fail-safe: refuse to store
 				}
 
 				log.Error("encryption failed, storing unencrypted",
@@ -190,6 +197,23 @@ func (s *Service) handleMessage(msg MessageToStore) {
 		}
 	}
 
+	// Encrypt separated reasoning with the same key used for content
+	var encryptedReasoning string
+	if msg.Reasoning != "" {
+		if publicKeyUsed != "none" {
+			encrypted, err := s.encryptionService.EncryptMessage(msg.Reasoning, publicKeyUsed)
+			if err != nil {
+				log.Warn("failed to encrypt reasoning, storing without it",
+					slog.String("message_id", msg.MessageID),
+					slog.String("error", err.Error()))
+			} else {
+				encryptedReasoning = encrypted
+			}
+		} else {
+			encryptedReasoning = msg.Reasoning
+		}
+	}
+
 	// Create Firestore message
 	chatMsg := &ChatMessage{
 		ID:                      msg.MessageID,
@@ -206,6 +230,11 @@ func (s *Service) handleMessage(msg MessageToStore) {
 		GenerationState:         msg.GenerationState,
 		GenerationError:         msg.GenerationError,
 		EncryptedMaskedKeywords: encryptedMaskedKeywords,
+		EncryptedReasoning:      encryptedReasoning,
+	}
+
+	if msg.Timestamp != nil {
+		chatMsg.Timestamp = *msg.Timestamp
 	}
 
 	// Set generation timestamps if provided
@@ -217,13 +246,13 @@ func (s *Service) handleMessage(msg MessageToStore) {
 	}
 
 	// Save to Firestore
-	if err := s.firestoreClient.SaveMessage(ctx, msg.UserID, chatMsg); err != nil {
+	if err := s.store.SaveMessage(ctx, msg.UserID, chatMsg); err != nil {
 		log.Error("failed to save message to firestore",
 			slog.String("user_id", msg.UserID),
 			slog.String("chat_id", msg.ChatID),
 			slog.String("message_id", msg.MessageID),
 			slog.String("error", err.Error()))
-		return
+		return nil
 	}
 
 	log.Debug("message saved successfully",
@@ -231,6 +260,29 @@ func (s *Service) handleMessage(msg MessageToStore) {
 		slog.String("chat_id", msg.ChatID),
 		slog.String("message_id", msg.MessageID),
 		slog.Bool("encrypted", publicKeyUsed != "none"))
+
+	if msg.IsFromUser {
+		s.clearDraft(ctx, msg.UserID, msg.ChatID)
+	}
+
+	if s.indexer != nil {
+		s.indexer.Enqueue(IndexEntry{
+			UserID:                msg.UserID,
+			ChatID:                msg.ChatID,
+			MessageID:             msg.MessageID,
+			IsFromUser:            msg.IsFromUser,
+			IsError:               msg.IsError,
+			Stopped:               msg.Stopped,
+			Model:                 msg.Model,
+			GenerationState:       msg.GenerationState,
+			PromptTokens:          msg.PromptTokens,
+			CompletionTokens:      msg.CompletionTokens,
+			Timestamp:             chatMsg.Timestamp,
+			GenerationCompletedAt: msg.GenerationCompletedAt,
+		})
+	}
+
+	return nil
 }
 
 // getPublicKey retrieves public key from Firestore (no caching - simpler and always fresh)
@@ -238,7 +290,7 @@ func (s *Service) getPublicKey(ctx context.Context, userID string) (*UserPublicK
 	log := s.logger.WithContext(ctx)
 
 	// Fetch from Firestore
-	key, err := s.firestoreClient.GetUserPublicKey(ctx, userID)
+	key, err := s.store.GetUserPublicKey(ctx, userID)
 	if err != nil {
 		log.Error("failed to fetch public key from Firestore",
 			slog.String("user_id", userID),
@@ -273,60 +325,32 @@ func (s *Service) getPublicKey(ctx context.Context, userID string) (*UserPublicK
 
 // StoreMessageAsync queues a message for async storage
 func (s *Service) StoreMessageAsync(ctx context.Context, msg MessageToStore) error {
-	if s.closed.Load() {
-		return fmt.Errorf("service is shutting down")
+	// Wait up to enqueueTimeout for queue space (no silent drops). The pool logs a
+	// warning when the queue stays full.
+	if !msg.SpanContext.IsValid() {
+		msg.SpanContext = trace.SpanContextFromContext(ctx)
 	}
+	enqueueCtx, cancel := context.WithTimeout(ctx, enqueueTimeout)
+	defer cancel()
 
-	// Wait up to 5 seconds for queue space (no silent drops)
-	select {
-	case s.messageChan <- msg:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(5 * time.Second):
-		// Queue blocked for 5 seconds - this indicates a serious problem
-		// Check once more if shutting down before blocking
-		if s.closed.Load() {
-			s.logger.Warn("service is shutting down, cannot queue after timeout",
-				slog.String("user_id", msg.UserID),
-				slog.String("chat_id", msg.ChatID))
-			return fmt.Errorf("service is shutting down")
-		}
-
-		// Log as error and try one more time with limited timeout
-		s.logger.Error("message queue blocked for 5s, attempting blocking queue",
+	err := s.pool.Enqueue(enqueueCtx, msg)
+	switch {
+	case errors.Is(err, worker.ErrClosed):
+		return fmt.Errorf("service is shutting down")
+	case err != nil && ctx.Err() == nil:
+		s.logger.Error("message queue blocked for 35s total, giving up",
 			slog.String("user_id", msg.UserID),
 			slog.String("chat_id", msg.ChatID),
-			slog.Int("queue_size", len(s.messageChan)))
-
-		// Final attempt: blocking send with 30s max timeout (prevents goroutine leak)
-		select {
-		case s.messageChan <- msg:
-			s.logger.Info("message queued after blocking",
-				slog.String("user_id", msg.UserID),
-				slog.String("chat_id", msg.ChatID))
-			return nil
-		case <-ctx.Done():
-			s.logger.Error("context cancelled during blocking queue attempt",
-				slog.String("user_id", msg.UserID),
-				slog.String("chat_id", msg.ChatID))
-			return ctx.Err()
-		case <-time.After(30 * time.Second):
-			s.logger.Error("message queue blocked for 35s total, giving up",
-				slog.String("user_id", msg.UserID),
-				slog.String("chat_id", msg.ChatID))
-			return fmt.Errorf("message queue blocked for 35s total, giving up")
-		}
+			slog.Int("queue_size", s.pool.Len()))
+		return fmt.Errorf("message queue blocked for 35s total, giving up")
 	}
+	return err
 }
 
-// Shutdown gracefully shuts down the service
+// Shutdown gracefully shuts down the service, draining queued messages.
 func (s *Service) Shutdown() {
 	s.logger.Info("shutting down message storage service")
-	s.closed.Store(true)
-	close(s.shutdown)
-	s.workerPool.Wait()
-	close(s.messageChan)
+	_ = s.pool.Shutdown(context.Background())
 	s.logger.Info("message storage service shutdown complete")
 }
 
@@ -352,10 +376,10 @@ func (s *Service) EncryptContent(content string, publicKeyJWK string) (string, e
 // Returns:
 //   - error: If save failed
 func (s *Service) SaveResponseID(ctx context.Context, userID, chatID, responseID string) error {
-	if s.firestoreClient == nil {
+	if s.store == nil {
 		return fmt.Errorf("firestore client is nil")
 	}
-	return s.firestoreClient.SaveResponseID(ctx, userID, chatID, responseID)
+	return s.store.SaveResponseID(ctx, userID, chatID, responseID)
 }
 
 // GetResponseID retrieves the latest OpenAI Responses API response_id for a chat.
@@ -370,10 +394,10 @@ func (s *Service) SaveResponseID(ctx context.Context, userID, chatID, responseID
 //   - string: The response_id (e.g., "resp_abc123"), or empty string if not found
 //   - error: If retrieval failed
 func (s *Service) GetResponseID(ctx context.Context, userID, chatID string) (string, error) {
-	if s.firestoreClient == nil {
+	if s.store == nil {
 		return "", fmt.Errorf("firestore client is nil")
 	}
-	return s.firestoreClient.GetResponseID(ctx, userID, chatID)
+	return s.store.GetResponseID(ctx, userID, chatID)
 }
 
 // SaveThinkingMessage saves a placeholder message for long-running generations (GPT-5 Pro).
@@ -445,7 +469,7 @@ func (s *Service) SaveThinkingMessage(ctx context.Context, userID, chatID, messa
 	}
 
 	// Save to Firestore
-	return s.firestoreClient.SaveMessage(ctx, userID, chatMsg)
+	return s.store.SaveMessage(ctx, userID, chatMsg)
 }
 
 // UpdateMessageGenerationState updates a message's generation state.
@@ -476,7 +500,7 @@ func (s *Service) UpdateMessageGenerationState(ctx context.Context, userID, chat
 	}
 
 	// Update in Firestore
-	return s.firestoreClient.UpdateMessage(ctx, userID, chatID, messageID, updates)
+	return s.store.UpdateMessage(ctx, userID, chatID, messageID, updates)
 }
 
 // UpdateGenerationStateSync updates a message's generation state synchronously.
@@ -522,5 +546,5 @@ func (s *Service) UpdateGenerationStateSync(ctx context.Context, userID, chatID,
 		slog.String("state", state))
 
 	// Update in Firestore synchronously (not through async queue)
-	return s.firestoreClient.UpdateMessage(ctx, userID, chatID, messageID, updates)
+	return s.store.UpdateMessage(ctx, userID, chatID, messageID, updates)
 }
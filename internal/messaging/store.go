@@ -0,0 +1,30 @@
+package messaging
+
+import "context"
+
+// Store persists chats, messages and drafts. FirestoreClient is the hosted implementation;
+// self-hosted deployments use a database (internal/messagestore). Errors are gRPC statuses
+// with the codes FirestoreClient documents (NotFound, InvalidArgument, PermissionDenied, ...).
+type Store interface {
+	GetUserPublicKey(ctx context.Context, userID string) (*UserPublicKey, error)
+
+	SaveMessage(ctx context.Context, userID string, msg *ChatMessage) error
+	GetMessage(ctx context.Context, userID, chatID, messageID string) (*ChatMessage, error)
+	// UpdateMessage sets fields of an existing message, keyed by their Firestore names
+	// (e.g., {"generationState": "completed"}).
+	UpdateMessage(ctx context.Context, userID, chatID, messageID string, updates map[string]interface{}) error
+
+	SaveChatTitle(ctx context.Context, userID, chatID string, title *ChatTitle) error
+	VerifyChatOwnership(ctx context.Context, userID, chatID string) error
+	SaveResponseID(ctx context.Context, userID, chatID, responseID string) error
+	GetResponseID(ctx context.Context, userID, chatID string) (string, error)
+
+	SaveDraft(ctx context.Context, userID, chatID string, draft *ChatDraft) error
+	GetDraft(ctx context.Context, userID, chatID string) (*ChatDraft, error)
+	DeleteDraft(ctx context.Context, userID, chatID string) error
+
+	GetChatMetadata(ctx context.Context, userID, chatID string) (*ChatMetadata, error)
+	UpdateChatMetadata(ctx context.Context, userID, chatID string, update ChatMetadataUpdate) (*ChatMetadata, error)
+}
+
+var _ Store = (*FirestoreClient)(nil)
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ProviderAttestationVerified is 1 while a provider's enclave attestation verifies and 0 while
+// it fails (the provider is not routed to).
+var ProviderAttestationVerified = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "model_router_provider_attestation_verified",
+		Help: "Whether each enclave provider's attestation currently verifies (1) or fails (0).",
+	},
+	[]string{"provider"},
+)
+
+// ProviderAttestationChecks counts enclave attestation verifications, by result ("verified" or
+// "failed").
+var ProviderAttestationChecks = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "model_router_provider_attestation_checks_total",
+		Help: "Total enclave attestation verifications, by provider and result.",
+	},
+	[]string{"provider", "result"},
+)
+
+// RecordProviderAttestation records the result of a provider's attestation verification.
+func RecordProviderAttestation(provider string, verified bool) {
+	value, result := 0.0, "failed"
+	if verified {
+		value, result = 1, "verified"
+	}
+	ProviderAttestationVerified.WithLabelValues(provider).Set(value)
+	ProviderAttestationChecks.WithLabelValues(provider, result).Inc()
+}
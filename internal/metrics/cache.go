@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// CacheRequests counts reads of the shared cache. Result is "hit", "miss" or "error" (backend
+// failure, served as a miss).
+var CacheRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Total shared cache reads, by namespace and result.",
+	},
+	[]string{"namespace", "result"},
+)
+
+// CacheLoads counts loads of missing cache entries. Result is "loaded", "shared" (served by a
+// concurrent load of the same key) or "error".
+var CacheLoads = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_loads_total",
+		Help: "Total loads of missing shared cache entries, by namespace and result.",
+	},
+	[]string{"namespace", "result"},
+)
+
+// RecordCacheRequest records a cache read.
+func RecordCacheRequest(namespace, result string) {
+	CacheRequests.WithLabelValues(namespace, result).Inc()
+}
+
+// RecordCacheLoad records a load of a missing cache entry.
+func RecordCacheLoad(namespace, result string) {
+	CacheLoads.WithLabelValues(namespace, result).Inc()
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// MultipleChoiceRequests counts chat completions asking for more than one choice or candidate.
+// Mode is "native" (n passed to the provider), "fanout" (n single-choice requests), "best_of",
+// or "best_of_unscored" (the scorer failed and the first candidate was returned).
+var MultipleChoiceRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "model_router_multiple_choice_requests_total",
+		Help: "Total chat completions with n > 1 or best_of, by model and mode.",
+	},
+	[]string{"model", "mode"},
+)
+
+// RecordMultipleChoiceRequest records a chat completion with n > 1 or best_of.
+func RecordMultipleChoiceRequest(model, mode string) {
+	MultipleChoiceRequests.WithLabelValues(model, mode).Inc()
+}
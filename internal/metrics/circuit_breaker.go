@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var circuitBreakerStates = []string{"closed", "open", "half_open"}
+
+// CircuitBreakerState is 1 for the current circuit breaker state of each provider and 0 for
+// the others.
+var CircuitBreakerState = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "model_router_circuit_breaker_state",
+		Help: "Circuit breaker state of each upstream provider (1 for the current state).",
+	},
+	[]string{"provider", "state"},
+)
+
+// CircuitBreakerTransitions counts circuit breaker state changes, by the new state.
+var CircuitBreakerTransitions = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "model_router_circuit_breaker_transitions_total",
+		Help: "Total circuit breaker state changes, by provider and new state.",
+	},
+	[]string{"provider", "state"},
+)
+
+// CircuitBreakerRejections counts requests not sent to a provider because its circuit
+// breaker was open.
+var CircuitBreakerRejections = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "model_router_circuit_breaker_rejections_total",
+		Help: "Total requests rejected by an open circuit breaker, by provider.",
+	},
+	[]string{"provider"},
+)
+
+// RecordCircuitBreakerState records a provider's circuit breaker changing state.
+func RecordCircuitBreakerState(provider, state string) {
+	for _, s := range circuitBreakerStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		CircuitBreakerState.WithLabelValues(provider, s).Set(value)
+	}
+	CircuitBreakerTransitions.WithLabelValues(provider, state).Inc()
+}
+
+// RecordCircuitBreakerRejected records a request rejected by an open circuit breaker.
+func RecordCircuitBreakerRejected(provider string) {
+	CircuitBreakerRejections.WithLabelValues(provider).Inc()
+}
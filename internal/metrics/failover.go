@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ProviderFailovers counts chat completions retried on a fallback provider of the model's
+// failover chain. Provider is the provider that failed; reason is "status_5xx", "timeout" or
+// "error" (connection error).
+var ProviderFailovers = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "model_router_upstream_failovers_total",
+		Help: "Total chat completions retried on a fallback provider, by failed provider, fallback, model and reason.",
+	},
+	[]string{"provider", "fallback", "model", "reason"},
+)
+
+// RecordProviderFailover records a chat completion retried on a fallback provider.
+func RecordProviderFailover(provider, fallback, model, reason string) {
+	ProviderFailovers.WithLabelValues(provider, fallback, model, reason).Inc()
+}
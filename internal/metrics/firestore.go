@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// FirestoreWriteRetries counts retried Firestore write attempts after a transient error.
+	FirestoreWriteRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "firestore_write_retries_total",
+			Help: "Total Firestore write retries after transient errors, by operation.",
+		},
+		[]string{"operation"},
+	)
+
+	// FirestoreWrites counts Firestore write outcomes: success (first attempt), recovered
+	// (succeeded after retrying) and failed.
+	FirestoreWrites = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "firestore_writes_total",
+			Help: "Total Firestore writes, by operation and result (success, recovered, failed).",
+		},
+		[]string{"operation", "result"},
+	)
+)
+
+// RecordFirestoreWriteRetry records a retry of a Firestore write.
+func RecordFirestoreWriteRetry(operation string) {
+	FirestoreWriteRetries.WithLabelValues(operation).Inc()
+}
+
+// RecordFirestoreWrite records the outcome of a Firestore write that took the given attempts.
+func RecordFirestoreWrite(operation string, attempts int, err error) {
+	result := "success"
+	switch {
+	case err != nil:
+		result = "failed"
+	case attempts > 1:
+		result = "recovered"
+	}
+	FirestoreWrites.WithLabelValues(operation, result).Inc()
+}
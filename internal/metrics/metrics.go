@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -115,6 +118,46 @@ var (
 		[]string{"provider", "model"},
 	)
 
+	// UpstreamRequestsRateLimited counts upstream 429 responses. Fallback trigger queries can use
+	// it to move traffic off a provider that is rate limiting us.
+	UpstreamRequestsRateLimited = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_router_upstream_rq_ratelimited",
+			Help: "Total upstream 429 responses, by provider and model.",
+		},
+		[]string{"provider", "model"},
+	)
+
+	// UpstreamRateLimitRetryAfter observes the retry delay computed from upstream 429 responses.
+	UpstreamRateLimitRetryAfter = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "model_router_upstream_ratelimit_retry_after",
+			Help:    "Retry delay in seconds computed from upstream 429 responses, by provider and model.",
+			Buckets: []float64{1, 2, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+		},
+		[]string{"provider", "model"},
+	)
+
+	// UpstreamRateLimitRemainingRequests tracks the request headroom last reported by the provider
+	// in its rate-limit response headers.
+	UpstreamRateLimitRemainingRequests = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "model_router_upstream_ratelimit_remaining_requests",
+			Help: "Remaining upstream requests in the current rate-limit window, as last reported by the provider.",
+		},
+		[]string{"provider", "model"},
+	)
+
+	// UpstreamRateLimitRemainingTokens tracks the token headroom last reported by the provider
+	// in its rate-limit response headers.
+	UpstreamRateLimitRemainingTokens = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "model_router_upstream_ratelimit_remaining_tokens",
+			Help: "Remaining upstream tokens in the current rate-limit window, as last reported by the provider.",
+		},
+		[]string{"provider", "model"},
+	)
+
 	// ConnectTimeouts counts connection-phase timeouts specifically (dial timeout, TLS handshake timeout).
 	ConnectTimeouts = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -195,6 +238,49 @@ func RecordUpstreamResponse(provider, model string, statusCode int, durationSeco
 	}
 }
 
+// RecordUpstreamRateLimited records an upstream 429 and the retry delay derived from it.
+func RecordUpstreamRateLimited(provider, model string, retryAfterSeconds float64) {
+	UpstreamRequestsRateLimited.WithLabelValues(provider, model).Inc()
+	UpstreamRateLimitRetryAfter.WithLabelValues(provider, model).Observe(retryAfterSeconds)
+}
+
+// Rate-limit headers by provider convention, in lookup order.
+var (
+	remainingRequestsHeaders = []string{
+		"X-Ratelimit-Remaining-Requests",         // OpenAI, Groq, Together
+		"Anthropic-Ratelimit-Requests-Remaining", // Anthropic
+		"X-Ratelimit-Remaining",                  // OpenRouter
+	}
+	remainingTokensHeaders = []string{
+		"X-Ratelimit-Remaining-Tokens",         // OpenAI, Groq, Together
+		"Anthropic-Ratelimit-Tokens-Remaining", // Anthropic
+	}
+)
+
+// RecordRateLimitHeadroom updates the remaining-requests/tokens gauges from an upstream
+// response's rate-limit headers. Headers that are absent or unparsable leave the gauges unchanged.
+func RecordRateLimitHeadroom(provider, model string, header http.Header) {
+	if value, ok := firstNumericHeader(header, remainingRequestsHeaders); ok {
+		UpstreamRateLimitRemainingRequests.WithLabelValues(provider, model).Set(value)
+	}
+	if value, ok := firstNumericHeader(header, remainingTokensHeaders); ok {
+		UpstreamRateLimitRemainingTokens.WithLabelValues(provider, model).Set(value)
+	}
+}
+
+func firstNumericHeader(header http.Header, names []string) (float64, bool) {
+	for _, name := range names {
+		raw := header.Get(name)
+		if raw == "" {
+			continue
+		}
+		if value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil && value >= 0 {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
 // TrackActiveRequest increments the active request gauge and returns a function
 // that decrements it. Intended for use with defer.
 func TrackActiveRequest(provider, model string) func() {
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RateQueueRequests counts chat completions held by the rate limit queue. Result is "queued"
+// (started waiting), "admitted", or why the request was rejected: "full", "wait_too_long"
+// (the provider resets after the maximum wait), "timeout" or "canceled" (client went away).
+var RateQueueRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "model_router_rate_queue_requests_total",
+		Help: "Total requests held by the provider rate limit queue, by provider and result.",
+	},
+	[]string{"provider", "result"},
+)
+
+// RateQueueWait tracks how long admitted requests waited in the rate limit queue.
+var RateQueueWait = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "model_router_rate_queue_wait_seconds",
+		Help:    "Time admitted requests waited in the provider rate limit queue, in seconds.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 30, 60},
+	},
+	[]string{"provider"},
+)
+
+// RateQueueDepth tracks the requests waiting in the rate limit queue per priority lane (tier).
+var RateQueueDepth = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "model_router_rate_queue_depth",
+		Help: "Requests waiting in the provider rate limit queue, by provider and priority lane.",
+	},
+	[]string{"provider", "lane"},
+)
+
+// RateQueueStarvationAdmits counts requests admitted ahead of higher-priority lanes because
+// they waited too long.
+var RateQueueStarvationAdmits = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "model_router_rate_queue_starvation_admits_total",
+		Help: "Requests admitted ahead of their fair share by rate limit queue starvation protection, by provider and lane.",
+	},
+	[]string{"provider", "lane"},
+)
+
+// SetRateQueueDepth records the number of requests of a lane waiting for a provider.
+func SetRateQueueDepth(provider, lane string, depth int) {
+	RateQueueDepth.WithLabelValues(provider, lane).Set(float64(depth))
+}
+
+// RecordRateQueueStarvationAdmit records a request admitted by starvation protection.
+func RecordRateQueueStarvationAdmit(provider, lane string) {
+	RateQueueStarvationAdmits.WithLabelValues(provider, lane).Inc()
+}
+
+// RecordRateQueueQueued records a request that started waiting.
+func RecordRateQueueQueued(provider string) {
+	RateQueueRequests.WithLabelValues(provider, "queued").Inc()
+}
+
+// RecordRateQueueAdmitted records a request admitted after waiting.
+func RecordRateQueueAdmitted(provider string, wait time.Duration) {
+	RateQueueRequests.WithLabelValues(provider, "admitted").Inc()
+	RateQueueWait.WithLabelValues(provider).Observe(wait.Seconds())
+}
+
+// RecordRateQueueRejected records a request that was not admitted.
+func RecordRateQueueRejected(provider, reason string) {
+	RateQueueRequests.WithLabelValues(provider, reason).Inc()
+}
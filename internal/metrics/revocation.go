@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RevokedSessions counts in-flight sessions (streams, deep research runs) stopped because the
+// user's access was revoked. Reason is "token_revoked" or "subscription_lapsed".
+var RevokedSessions = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "revoked_sessions_total",
+		Help: "Total in-flight sessions stopped after the user's access was revoked, by reason.",
+	},
+	[]string{"reason"},
+)
+
+// RecordRevokedSessions records sessions stopped after a revocation.
+func RecordRevokedSessions(reason string, count int) {
+	RevokedSessions.WithLabelValues(reason).Add(float64(count))
+}
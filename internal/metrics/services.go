@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The state of long-lived services is read when /metrics is scraped, so the services don't
+// need to report every change. Background queues (message storage, request logs) are covered
+// by the worker pools' background_worker_queue_depth and background_worker_jobs_total.
+
+// StreamStats is the state of the stream manager.
+type StreamStats struct {
+	Active      int   // Streams still receiving chunks
+	Completed   int   // Completed streams kept for late subscribers
+	Subscribers int   // Subscribers across all streams
+	MemoryBytes int64 // Estimated size of the buffered chunks
+}
+
+var (
+	streamSessionsDesc = prometheus.NewDesc(
+		"streaming_sessions",
+		"Number of stream sessions held by the stream manager, by state.",
+		[]string{"state"}, nil,
+	)
+	streamSubscribersDesc = prometheus.NewDesc(
+		"streaming_subscribers",
+		"Number of subscribers across all stream sessions.",
+		nil, nil,
+	)
+	streamMemoryDesc = prometheus.NewDesc(
+		"streaming_buffered_bytes",
+		"Estimated size in bytes of the chunks buffered by the stream manager.",
+		nil, nil,
+	)
+)
+
+// streamCollector reads the stream manager's state once per scrape.
+type streamCollector struct {
+	stats func() StreamStats
+}
+
+func (c streamCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- streamSessionsDesc
+	ch <- streamSubscribersDesc
+	ch <- streamMemoryDesc
+}
+
+func (c streamCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(streamSessionsDesc, prometheus.GaugeValue, float64(stats.Active), "active")
+	ch <- prometheus.MustNewConstMetric(streamSessionsDesc, prometheus.GaugeValue, float64(stats.Completed), "completed")
+	ch <- prometheus.MustNewConstMetric(streamSubscribersDesc, prometheus.GaugeValue, float64(stats.Subscribers))
+	ch <- prometheus.MustNewConstMetric(streamMemoryDesc, prometheus.GaugeValue, float64(stats.MemoryBytes))
+}
+
+// RegisterStreamStats exports the stream manager's state, read by stats on every scrape.
+func RegisterStreamStats(stats func() StreamStats) {
+	prometheus.MustRegister(streamCollector{stats: stats})
+}
+
+// RegisterRequestLogDrops exports the number of request logs dropped because the queue was
+// full, read by dropped on every scrape.
+func RegisterRequestLogDrops(dropped func() int64) {
+	promauto.NewCounterFunc(
+		prometheus.CounterOpts{
+			Name: "request_tracking_dropped_requests_total",
+			Help: "Total request logs dropped because the log queue was full.",
+		},
+		func() float64 { return float64(dropped()) },
+	)
+}
+
+// RegisterDeepResearchQueue exports the number of deep research sessions waiting for backend
+// capacity, read by length on every scrape.
+func RegisterDeepResearchQueue(length func() int) {
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "deep_research_queued_sessions",
+			Help: "Number of deep research sessions waiting for research backend capacity.",
+		},
+		func() float64 { return float64(length()) },
+	)
+}
+
+// RegisterDeepResearchSessions exports the number of active deep research sessions, read by
+// count on every scrape.
+func RegisterDeepResearchSessions(count func() int) {
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "deep_research_active_sessions",
+			Help: "Number of active deep research sessions.",
+		},
+		func() float64 { return float64(count()) },
+	)
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// StreamAnomalies counts completed upstream streams flagged as truncated, empty, abnormally
+	// finished or garbled. Fallback trigger queries can use it to move traffic off a provider
+	// that returns broken streams.
+	StreamAnomalies = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_router_upstream_stream_anomalies",
+			Help: "Total anomalous upstream streams, by provider, model and anomaly.",
+		},
+		[]string{"provider", "model", "anomaly"},
+	)
+
+	// StreamAnomalyRetries counts retries of streams that ended without output. Provider is the
+	// provider retried on; outcome is "started", "failed" or "budget_exceeded" (no time left).
+	StreamAnomalyRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_router_upstream_stream_anomaly_retries",
+			Help: "Total retries of empty upstream streams, by retry provider, model and outcome.",
+		},
+		[]string{"provider", "model", "outcome"},
+	)
+)
+
+// RecordStreamAnomaly records an anomaly detected in a completed stream.
+func RecordStreamAnomaly(provider, model, anomaly string) {
+	StreamAnomalies.WithLabelValues(provider, model, anomaly).Inc()
+}
+
+// RecordStreamAnomalyRetry records a retry of a stream that ended without output.
+func RecordStreamAnomalyRetry(provider, model, outcome string) {
+	StreamAnomalyRetries.WithLabelValues(provider, model, outcome).Inc()
+}
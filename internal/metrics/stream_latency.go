@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// StreamTimeToFirstToken observes the time from sending a streaming request upstream to
+	// receiving the first content token.
+	StreamTimeToFirstToken = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "model_router_upstream_stream_ttft",
+			Help:    "Time to first streamed token in seconds, by provider and model.",
+			Buckets: []float64{0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 3, 5, 10, 20, 30, 60},
+		},
+		[]string{"provider", "model"},
+	)
+
+	// StreamInterChunkTime observes the gap between consecutive upstream SSE chunks after the
+	// first token.
+	StreamInterChunkTime = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "model_router_upstream_stream_inter_chunk_time",
+			Help:    "Time between consecutive streamed chunks in seconds, by provider and model.",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10},
+		},
+		[]string{"provider", "model"},
+	)
+)
+
+// Number of recent samples kept per provider/model for in-process percentiles.
+const (
+	ttftWindow       = 500
+	interChunkWindow = 5000
+)
+
+// LatencyPercentiles summarizes recent latency samples in milliseconds.
+type LatencyPercentiles struct {
+	Samples int     `json:"samples"`
+	P50Ms   float64 `json:"p50_ms"`
+	P95Ms   float64 `json:"p95_ms"`
+}
+
+// StreamLatencyStats contains streaming latency percentiles for a provider/model pair.
+type StreamLatencyStats struct {
+	Provider         string             `json:"provider"`
+	Model            string             `json:"model"`
+	TimeToFirstToken LatencyPercentiles `json:"time_to_first_token"`
+	InterChunk       LatencyPercentiles `json:"inter_chunk"`
+}
+
+type latencyKey struct {
+	provider string
+	model    string
+}
+
+// sampleRing keeps the latest samples up to its capacity.
+type sampleRing struct {
+	values []float64
+	next   int
+	size   int
+}
+
+func (r *sampleRing) add(value float64) {
+	if len(r.values) < r.size {
+		r.values = append(r.values, value)
+		return
+	}
+	r.values[r.next] = value
+	r.next = (r.next + 1) % r.size
+}
+
+// percentiles returns nearest-rank p50/p95 of the samples, converted from seconds to ms.
+func (r *sampleRing) percentiles() LatencyPercentiles {
+	if r == nil || len(r.values) == 0 {
+		return LatencyPercentiles{}
+	}
+
+	sorted := append([]float64(nil), r.values...)
+	sort.Float64s(sorted)
+
+	rank := func(p float64) float64 {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[max(idx, 0)] * 1000
+	}
+
+	return LatencyPercentiles{
+		Samples: len(sorted),
+		P50Ms:   rank(0.50),
+		P95Ms:   rank(0.95),
+	}
+}
+
+// streamLatency holds recent streaming latency samples for percentile reporting.
+var streamLatency = struct {
+	mu         sync.Mutex
+	ttft       map[latencyKey]*sampleRing
+	interChunk map[latencyKey]*sampleRing
+}{
+	ttft:       make(map[latencyKey]*sampleRing),
+	interChunk: make(map[latencyKey]*sampleRing),
+}
+
+func addLatencySample(samples map[latencyKey]*sampleRing, key latencyKey, size int, seconds float64) {
+	streamLatency.mu.Lock()
+	defer streamLatency.mu.Unlock()
+
+	ring, ok := samples[key]
+	if !ok {
+		ring = &sampleRing{size: size}
+		samples[key] = ring
+	}
+	ring.add(seconds)
+}
+
+// RecordStreamTimeToFirstToken records the time to the first streamed token.
+func RecordStreamTimeToFirstToken(provider, model string, seconds float64) {
+	StreamTimeToFirstToken.WithLabelValues(provider, model).Observe(seconds)
+	addLatencySample(streamLatency.ttft, latencyKey{provider, model}, ttftWindow, seconds)
+}
+
+// RecordStreamInterChunkTime records the gap between two consecutive streamed chunks.
+func RecordStreamInterChunkTime(provider, model string, seconds float64) {
+	StreamInterChunkTime.WithLabelValues(provider, model).Observe(seconds)
+	addLatencySample(streamLatency.interChunk, latencyKey{provider, model}, interChunkWindow, seconds)
+}
+
+// StreamLatencySnapshot returns p50/p95 streaming latency over the most recent samples on this
+// instance, sorted by provider and model.
+func StreamLatencySnapshot() []StreamLatencyStats {
+	streamLatency.mu.Lock()
+	defer streamLatency.mu.Unlock()
+
+	keys := make(map[latencyKey]struct{}, len(streamLatency.ttft))
+	for key := range streamLatency.ttft {
+		keys[key] = struct{}{}
+	}
+	for key := range streamLatency.interChunk {
+		keys[key] = struct{}{}
+	}
+
+	stats := make([]StreamLatencyStats, 0, len(keys))
+	for key := range keys {
+		stats = append(stats, StreamLatencyStats{
+			Provider:         key.provider,
+			Model:            key.model,
+			TimeToFirstToken: streamLatency.ttft[key].percentiles(),
+			InterChunk:       streamLatency.interChunk[key].percentiles(),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Provider != stats[j].Provider {
+			return stats[i].Provider < stats[j].Provider
+		}
+		return stats[i].Model < stats[j].Model
+	})
+
+	return stats
+}
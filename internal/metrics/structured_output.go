@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// StructuredOutputValidations counts server-side validations of JSON mode and structured output
+// completions. Result is "valid", "repaired" (valid after the repair retry) or "invalid".
+var StructuredOutputValidations = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "model_router_structured_output_validations",
+		Help: "Total validated structured output completions, by provider, model and result.",
+	},
+	[]string{"provider", "model", "result"},
+)
+
+// RecordStructuredOutputValidation records the result of validating a structured output completion.
+func RecordStructuredOutputValidation(provider, model, result string) {
+	StructuredOutputValidations.WithLabelValues(provider, model, result).Inc()
+}
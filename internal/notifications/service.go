@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 
 	"cloud.google.com/go/firestore"
 	"firebase.google.com/go/v4/messaging"
@@ -13,6 +14,7 @@ import (
 // Service handles sending push notifications via Firebase Cloud Messaging.
 type Service struct {
 	messagingClient *messaging.Client
+	firestoreClient *firestore.Client
 	tokenManager    *TokenManager
 	logger          *logger.Logger
 	enabled         bool
@@ -29,6 +31,7 @@ func NewService(
 
 	return &Service{
 		messagingClient: messagingClient,
+		firestoreClient: firestoreClient,
 		tokenManager:    tokenManager,
 		logger:          logger,
 		enabled:         enabled,
@@ -75,6 +78,26 @@ func (s *Service) SendGPT5ProCompletionNotification(
 	return s.sendNotification(ctx, userID, notification)
 }
 
+// SendWeeklyDigestNotification sends a notification when a weekly digest is ready.
+func (s *Service) SendWeeklyDigestNotification(
+	ctx context.Context,
+	userID string,
+	digestID int64,
+	summary string,
+) error {
+	notification := CompletionNotification{
+		Title: "Your Week with Enchanted",
+		Body:  summary,
+		Data: map[string]string{
+			"user_id":   userID,
+			"digest_id": strconv.FormatInt(digestID, 10),
+			"type":      string(TypeWeeklyDigest),
+		},
+	}
+
+	return s.sendNotification(ctx, userID, notification)
+}
+
 // sendNotification sends a notification to all of a user's registered devices.
 func (s *Service) sendNotification(
 	ctx context.Context,
@@ -91,6 +114,19 @@ func (s *Service) sendNotification(
 		return nil
 	}
 
+	// Muted chats get no notifications; the others are sent in the chat's language
+	if chatID := notification.Data["chat_id"]; chatID != "" {
+		muted, language := s.chatSettings(ctx, userID, chatID)
+		if muted {
+			log.Info("chat is muted, skipping push notification",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("type", notification.Data["type"]))
+			return nil
+		}
+		notification = localize(notification, language)
+	}
+
 	// Get user's push tokens
 	tokens, err := s.tokenManager.GetUserTokens(ctx, userID)
 	if err != nil {
@@ -150,6 +186,26 @@ func (s *Service) sendNotification(
 	return nil
 }
 
+// chatSettings reports whether the user muted a chat and its detected language (the "muted"
+// and "language" fields of /users/{userId}/chats/{chatId}). Lookup failures count as not muted
+// and an unknown language.
+func (s *Service) chatSettings(ctx context.Context, userID, chatID string) (muted bool, language string) {
+	if s.firestoreClient == nil {
+		return false, ""
+	}
+	doc, err := s.firestoreClient.Collection("users").Doc(userID).Collection("chats").Doc(chatID).Get(ctx)
+	if err != nil {
+		return false, ""
+	}
+	if value, err := doc.DataAt("muted"); err == nil {
+		muted, _ = value.(bool)
+	}
+	if value, err := doc.DataAt("language"); err == nil {
+		language, _ = value.(string)
+	}
+	return muted, language
+}
+
 // sendToDevice sends a notification to a single device.
 func (s *Service) sendToDevice(
 	ctx context.Context,
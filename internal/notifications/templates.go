@@ -0,0 +1,50 @@
+package notifications
+
+// notificationText is the localized title and body of a notification.
+type notificationText struct {
+	Title string
+	Body  string
+}
+
+// localizedTemplates are the notification texts by type and chat language (ISO 639-1). The
+// English text is set where the notification is built; missing languages keep it.
+var localizedTemplates = map[NotificationType]map[string]notificationText{
+	TypeDeepResearch: {
+		"es": {"Investigación completada", "Tu investigación ha terminado. Toca para ver la respuesta completa."},
+		"fr": {"Recherche terminée", "Votre recherche est terminée. Touchez pour consulter la réponse complète."},
+		"de": {"Recherche abgeschlossen", "Deine Recherche ist fertig. Tippe, um die vollständige Antwort anzusehen."},
+		"it": {"Ricerca completata", "La tua ricerca è terminata. Tocca per leggere la risposta completa."},
+		"pt": {"Pesquisa concluída", "Sua pesquisa terminou. Toque para ver a resposta completa."},
+		"nl": {"Onderzoek voltooid", "Je onderzoek is klaar. Tik om het volledige antwoord te bekijken."},
+		"ru": {"Исследование завершено", "Ваше исследование готово. Нажмите, чтобы посмотреть полный ответ."},
+		"uk": {"Дослідження завершено", "Ваше дослідження готове. Торкніться, щоб переглянути повну відповідь."},
+		"zh": {"深度研究已完成", "你的研究已完成。点击查看完整回答。"},
+		"ja": {"ディープリサーチ完了", "リサーチが完了しました。タップして回答全文を確認してください。"},
+		"ko": {"심층 리서치 완료", "리서치가 완료되었습니다. 탭하여 전체 답변을 확인하세요."},
+	},
+	TypeGPT5Pro: {
+		"es": {"Respuesta lista", "Tu respuesta ya está lista. Toca para verla completa."},
+		"fr": {"Réponse prête", "Votre réponse est prête. Touchez pour la consulter en entier."},
+		"de": {"Antwort fertig", "Deine Antwort ist fertig. Tippe, um sie vollständig anzusehen."},
+		"it": {"Risposta pronta", "La tua risposta è pronta. Tocca per leggerla per intero."},
+		"pt": {"Resposta pronta", "Sua resposta está pronta. Toque para vê-la completa."},
+		"nl": {"Antwoord klaar", "Je antwoord is klaar. Tik om het volledig te bekijken."},
+		"ru": {"Ответ готов", "Ваш ответ готов. Нажмите, чтобы посмотреть его полностью."},
+		"uk": {"Відповідь готова", "Ваша відповідь готова. Торкніться, щоб переглянути її повністю."},
+		"zh": {"回答已生成", "你的回答已生成完毕。点击查看完整内容。"},
+		"ja": {"回答の準備ができました", "回答の生成が完了しました。タップして全文を確認してください。"},
+		"ko": {"답변 준비 완료", "답변 생성이 완료되었습니다. 탭하여 전체 답변을 확인하세요."},
+	},
+}
+
+// localize returns the notification with its title and body in the chat language, if there is
+// a template for its type and the language.
+func localize(notification CompletionNotification, language string) CompletionNotification {
+	text, ok := localizedTemplates[NotificationType(notification.Data["type"])][language]
+	if !ok {
+		return notification
+	}
+	notification.Title = text.Title
+	notification.Body = text.Body
+	return notification
+}
@@ -0,0 +1,133 @@
+package preferences
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+)
+
+// Apply fills in the user's defaults on a chat completion request body:
+//   - model, when the request has no model
+//   - temperature, when the request has no temperature
+//   - a leading system message, when the request has no system or developer message
+//   - the content policy's safety instructions, always, ahead of every other message
+//
+// Fields set by the client always win, except the content policy, which can't be overridden.
+// Returns the body unchanged (and false) if nothing applied.
+func Apply(body []byte, prefs *Preferences) ([]byte, bool, error) {
+	if prefs.IsEmpty() || len(body) == 0 {
+		return body, false, nil
+	}
+
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		return body, false, fmt.Errorf("failed to parse request body: %w", err)
+	}
+
+	applied := false
+
+	if prefs.DefaultModel != nil && isUnset(request["model"]) {
+		request["model"], _ = json.Marshal(*prefs.DefaultModel)
+		applied = true
+	}
+
+	if prefs.Temperature != nil && isUnset(request["temperature"]) {
+		request["temperature"], _ = json.Marshal(*prefs.Temperature)
+		applied = true
+	}
+
+	if prefs.SystemPrompt != nil {
+		messages, ok, err := withSystemMessage(request["messages"], *prefs.SystemPrompt, false)
+		if err != nil {
+			return body, false, err
+		}
+		if ok {
+			request["messages"] = messages
+			applied = true
+		}
+	}
+
+	if instructions := contentpolicy.SafetyInstructions(prefs.ContentPolicy); instructions != "" {
+		messages, ok, err := withSystemMessage(request["messages"], instructions, true)
+		if err != nil {
+			return body, false, err
+		}
+		if ok {
+			request["messages"] = messages
+			applied = true
+		}
+	}
+
+	if !applied {
+		return body, false, nil
+	}
+
+	updated, err := json.Marshal(request)
+	if err != nil {
+		return body, false, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	return updated, true, nil
+}
+
+// isUnset reports whether a request field is missing, null or an empty string.
+func isUnset(raw json.RawMessage) bool {
+	if len(raw) == 0 {
+		return true
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return false
+	}
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	default:
+		return false
+	}
+}
+
+// withSystemMessage prepends a system message. Unless always is set, it is skipped when the
+// conversation already has a system or developer message.
+func withSystemMessage(raw json.RawMessage, content string, always bool) (json.RawMessage, bool, error) {
+	if len(raw) == 0 {
+		return nil, false, nil
+	}
+
+	var messages []json.RawMessage
+	if err := json.Unmarshal(raw, &messages); err != nil {
+		return nil, false, fmt.Errorf("failed to parse messages: %w", err)
+	}
+
+	if !always && hasSystemMessage(messages) {
+		return nil, false, nil
+	}
+
+	system, err := json.Marshal(map[string]string{"role": "system", "content": content})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal system message: %w", err)
+	}
+
+	updated, err := json.Marshal(append([]json.RawMessage{system}, messages...))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal messages: %w", err)
+	}
+	return updated, true, nil
+}
+
+func hasSystemMessage(messages []json.RawMessage) bool {
+	for _, message := range messages {
+		var m struct {
+			Role string `json:"role"`
+		}
+		if err := json.Unmarshal(message, &m); err != nil {
+			continue
+		}
+		if m.Role == "system" || m.Role == "developer" {
+			return true
+		}
+	}
+	return false
+}
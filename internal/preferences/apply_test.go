@@ -0,0 +1,113 @@
+package preferences
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+)
+
+func TestApply(t *testing.T) {
+	model := "gpt-4.1"
+	temperature := 0.3
+	prompt := "Answer briefly."
+	all := &Preferences{DefaultModel: &model, Temperature: &temperature, SystemPrompt: &prompt}
+
+	tests := []struct {
+		name        string
+		body        string
+		prefs       *Preferences
+		wantApplied bool
+		want        string
+	}{
+		{
+			name:        "fills all missing fields",
+			body:        `{"messages":[{"role":"user","content":"hi"}]}`,
+			prefs:       all,
+			wantApplied: true,
+			want:        `{"model":"gpt-4.1","temperature":0.3,"messages":[{"role":"system","content":"Answer briefly."},{"role":"user","content":"hi"}]}`,
+		},
+		{
+			name:        "empty model is filled",
+			body:        `{"model":"","messages":[{"role":"system","content":"custom"},{"role":"user","content":"hi"}]}`,
+			prefs:       all,
+			wantApplied: true,
+			want:        `{"model":"gpt-4.1","temperature":0.3,"messages":[{"role":"system","content":"custom"},{"role":"user","content":"hi"}]}`,
+		},
+		{
+			name:        "request fields win",
+			body:        `{"model":"other","temperature":0,"messages":[{"role":"developer","content":"custom"}]}`,
+			prefs:       all,
+			wantApplied: false,
+			want:        `{"model":"other","temperature":0,"messages":[{"role":"developer","content":"custom"}]}`,
+		},
+		{
+			name:        "no preferences",
+			body:        `{"messages":[]}`,
+			prefs:       &Preferences{},
+			wantApplied: false,
+			want:        `{"messages":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, applied, err := Apply([]byte(tt.body), tt.prefs)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("applied = %v, want %v", applied, tt.wantApplied)
+			}
+
+			var gotJSON, wantJSON interface{}
+			if err := json.Unmarshal(got, &gotJSON); err != nil {
+				t.Fatalf("result is not valid JSON: %v", err)
+			}
+			_ = json.Unmarshal([]byte(tt.want), &wantJSON)
+			if !reflect.DeepEqual(gotJSON, wantJSON) {
+				t.Errorf("Apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_InvalidBody(t *testing.T) {
+	model := "gpt-4.1"
+	body := []byte(`not json`)
+
+	got, applied, err := Apply(body, &Preferences{DefaultModel: &model})
+	if err == nil {
+		t.Error("Apply() error = nil, want error")
+	}
+	if applied || string(got) != string(body) {
+		t.Errorf("Apply() = %s, %v, want body unchanged", got, applied)
+	}
+}
+
+func TestApply_FamilyMode(t *testing.T) {
+	prefs := &Preferences{ContentPolicy: contentpolicy.LevelFamily}
+	body := []byte(`{"model":"m","messages":[{"role":"system","content":"client"},{"role":"user","content":"hi"}]}`)
+
+	got, applied, err := Apply(body, prefs)
+	if err != nil || !applied {
+		t.Fatalf("Apply() = %v, %v, want applied", applied, err)
+	}
+
+	var request struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(got, &request); err != nil {
+		t.Fatalf("result is not valid JSON: %v", err)
+	}
+	if len(request.Messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(request.Messages))
+	}
+	if request.Messages[0].Content != contentpolicy.SafetyInstructions(contentpolicy.LevelFamily) {
+		t.Errorf("first message = %q, want safety instructions", request.Messages[0].Content)
+	}
+}
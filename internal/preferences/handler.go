@@ -0,0 +1,112 @@
+package preferences
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles HTTP requests for user preferences.
+type Handler struct {
+	service *Service
+	logger  *logger.Logger
+}
+
+// NewHandler creates a new preferences handler.
+func NewHandler(service *Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetPreferences handles GET /api/v1/preferences
+// Returns the user's default model, temperature, system prompt and content policy.
+func (h *Handler) GetPreferences(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("preferences-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	prefs, err := h.service.Get(c.Request.Context(), userID)
+	if err != nil {
+		log.Error("failed to get preferences",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to get preferences", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles PUT /api/v1/preferences
+// Replaces the user's preferences; omitted fields are cleared.
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("preferences-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	prefs, err := h.service.Update(c.Request.Context(), userID, &req)
+	if err != nil {
+		if stderrors.Is(err, ErrUnknownModel) || stderrors.Is(err, ErrInvalidTemperature) || stderrors.Is(err, ErrSystemPromptTooLong) ||
+			stderrors.Is(err, ErrInvalidContentPolicy) {
+			errors.BadRequest(c, err.Error(), nil)
+			return
+		}
+		log.Error("failed to update preferences",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to update preferences", nil)
+		return
+	}
+
+	log.Info("preferences updated",
+		slog.String("user_id", userID),
+		slog.Bool("default_model", prefs.DefaultModel != nil),
+		slog.Bool("temperature", prefs.Temperature != nil),
+		slog.Bool("system_prompt", prefs.SystemPrompt != nil),
+		slog.String("content_policy", string(prefs.ContentPolicy)))
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// DeletePreferences handles DELETE /api/v1/preferences
+// Clears all of the user's preferences.
+func (h *Handler) DeletePreferences(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("preferences-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), userID); err != nil {
+		log.Error("failed to delete preferences",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+		errors.Internal(c, "failed to delete preferences", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Preferences cleared"})
+}
@@ -0,0 +1,110 @@
+package preferences
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// contentPolicyKey is the gin context key of the account's content policy.
+const contentPolicyKey = "contentPolicy"
+
+// ContentPolicy returns the content policy of the request's account, as set by Middleware
+// (LevelStandard if unknown).
+func ContentPolicy(c *gin.Context) contentpolicy.Level {
+	if val, exists := c.Get(contentPolicyKey); exists {
+		if level, ok := val.(contentpolicy.Level); ok && level != "" {
+			return level
+		}
+	}
+	return contentpolicy.LevelStandard
+}
+
+// Middleware applies the user's preferences to chat completion requests and enforces the
+// account's content policy. Must run before request tracking so tier model access checks see
+// the default model. Failures to load preferences are logged and the request proceeds unchanged.
+// moderator may be nil to rely on safety instructions alone.
+func Middleware(service *Service, moderator *contentpolicy.Moderator, logger *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || c.Request.URL.Path != "/chat/completions" || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		log := logger.WithContext(c.Request.Context()).WithComponent("preferences")
+
+		prefs, err := service.GetCached(c.Request.Context(), userID)
+		if err != nil {
+			log.Warn("failed to load user preferences",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID))
+			c.Next()
+			return
+		}
+		c.Set(contentPolicyKey, prefs.ContentPolicy)
+		if prefs.IsEmpty() {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.Warn("failed to read request body for preferences",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID))
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Next()
+			return
+		}
+
+		updated, applied, err := Apply(body, prefs)
+		if err != nil {
+			// Malformed bodies are rejected downstream with a proper error.
+			log.Debug("skipping preferences for unparseable request body",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID))
+		}
+		if applied {
+			body = updated
+			c.Request.ContentLength = int64(len(body))
+			c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			log.Debug("applied user preferences", slog.String("user_id", userID))
+		}
+
+		if moderator != nil && contentpolicy.Thresholds(prefs.ContentPolicy) != nil {
+			violations, err := moderator.Check(c.Request.Context(), prefs.ContentPolicy, contentpolicy.LastUserText(body))
+			contentpolicy.RecordCheck(prefs.ContentPolicy, violations, err)
+			if err != nil {
+				// Fail open: the safety instructions still apply to the completion.
+				log.Warn("content moderation failed",
+					slog.String("error", err.Error()),
+					slog.String("user_id", userID),
+					slog.String("content_policy", string(prefs.ContentPolicy)))
+			} else if len(violations) > 0 {
+				log.Info("request blocked by content policy",
+					slog.String("user_id", userID),
+					slog.String("content_policy", string(prefs.ContentPolicy)),
+					slog.Any("categories", violations))
+				errors.AbortWithForbidden(c, errors.ContentPolicyViolation(string(prefs.ContentPolicy), violations))
+				return
+			}
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
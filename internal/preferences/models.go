@@ -0,0 +1,53 @@
+package preferences
+
+import (
+	"errors"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+)
+
+const (
+	// MinTemperature and MaxTemperature bound the default temperature (OpenAI-compatible range).
+	MinTemperature = 0.0
+	MaxTemperature = 2.0
+
+	// MaxSystemPromptLength caps the default system prompt, in characters.
+	MaxSystemPromptLength = 8000
+)
+
+var (
+	ErrUnknownModel         = errors.New("unknown model")
+	ErrInvalidTemperature   = errors.New("temperature must be between 0 and 2")
+	ErrSystemPromptTooLong  = errors.New("system prompt is too long")
+	ErrInvalidContentPolicy = errors.New("content policy must be one of: standard, family")
+)
+
+// Preferences are a user's defaults for chat completion requests.
+// Nil fields are unset and leave the request untouched.
+type Preferences struct {
+	DefaultModel *string  `json:"defaultModel"`
+	Temperature  *float64 `json:"temperature"`
+	SystemPrompt *string  `json:"systemPrompt"`
+
+	// ContentPolicy is enforced on every chat completion, regardless of client.
+	ContentPolicy contentpolicy.Level `json:"contentPolicy"`
+
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// IsEmpty reports whether no default is set and the content policy is standard.
+func (p *Preferences) IsEmpty() bool {
+	return p == nil || (p.DefaultModel == nil && p.Temperature == nil && p.SystemPrompt == nil &&
+		(p.ContentPolicy == "" || p.ContentPolicy == contentpolicy.LevelStandard))
+}
+
+// UpdatePreferencesRequest is the body for PUT /api/v1/preferences.
+// Replaces all preferences: omitted or null fields are cleared, except contentPolicy,
+// which is kept when omitted so clients unaware of it can't turn off family mode.
+type UpdatePreferencesRequest struct {
+	DefaultModel  *string  `json:"defaultModel"`
+	Temperature   *float64 `json:"temperature"`
+	SystemPrompt  *string  `json:"systemPrompt"`
+	ContentPolicy *string  `json:"contentPolicy"` // "standard" or "family"
+}
@@ -0,0 +1,144 @@
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// cacheTTL bounds how long the proxy uses cached preferences. Updates invalidate the cache
+// immediately; with the in-memory cache backend, other instances pick them up within this window.
+const cacheTTL = time.Minute
+
+// Service stores user preferences and serves them to the proxy.
+type Service struct {
+	queries     pgdb.Querier
+	modelRouter *routing.ModelRouter
+	cache       *cache.Cache
+}
+
+// NewService creates a new preferences service.
+// modelRouter is used to validate and canonicalize the default model; nil skips validation.
+func NewService(queries pgdb.Querier, modelRouter *routing.ModelRouter, sharedCache *cache.Cache) *Service {
+	return &Service{
+		queries:     queries,
+		modelRouter: modelRouter,
+		cache:       sharedCache.Namespace("preferences", cacheTTL),
+	}
+}
+
+// Get returns the user's preferences (empty if never set).
+func (s *Service) Get(ctx context.Context, userID string) (*Preferences, error) {
+	row, err := s.queries.GetUserPreferences(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &Preferences{ContentPolicy: contentpolicy.LevelStandard}, nil
+		}
+		return nil, fmt.Errorf("failed to get user preferences: %w", err)
+	}
+	return toPreferences(row), nil
+}
+
+// GetCached returns the user's preferences, served from a short-lived cache.
+// Used on the request path, where a database round trip per completion is too costly.
+func (s *Service) GetCached(ctx context.Context, userID string) (*Preferences, error) {
+	return cache.Fetch(ctx, s.cache, userID, func(ctx context.Context) (*Preferences, error) {
+		return s.Get(ctx, userID)
+	})
+}
+
+// Update validates and replaces the user's preferences.
+func (s *Service) Update(ctx context.Context, userID string, req *UpdatePreferencesRequest) (*Preferences, error) {
+	params := pgdb.UpsertUserPreferencesParams{UserID: userID}
+
+	if req.DefaultModel != nil && strings.TrimSpace(*req.DefaultModel) != "" {
+		model := strings.TrimSpace(*req.DefaultModel)
+		if s.modelRouter != nil {
+			info := s.modelRouter.GetModelInfo(model)
+			if info == nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownModel, model)
+			}
+			model = info.Name
+		}
+		params.DefaultModel = &model
+	}
+
+	if req.Temperature != nil {
+		if *req.Temperature < MinTemperature || *req.Temperature > MaxTemperature {
+			return nil, ErrInvalidTemperature
+		}
+		params.Temperature = sql.NullFloat64{Float64: *req.Temperature, Valid: true}
+	}
+
+	if req.SystemPrompt != nil && strings.TrimSpace(*req.SystemPrompt) != "" {
+		if utf8.RuneCountInString(*req.SystemPrompt) > MaxSystemPromptLength {
+			return nil, ErrSystemPromptTooLong
+		}
+		params.SystemPrompt = req.SystemPrompt
+	}
+
+	if req.ContentPolicy != nil {
+		level, ok := contentpolicy.ParseLevel(strings.TrimSpace(*req.ContentPolicy))
+		if !ok {
+			return nil, ErrInvalidContentPolicy
+		}
+		params.ContentPolicy = string(level)
+	} else {
+		current, err := s.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		params.ContentPolicy = string(current.ContentPolicy)
+	}
+
+	row, err := s.queries.UpsertUserPreferences(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user preferences: %w", err)
+	}
+
+	s.invalidate(ctx, userID)
+	return toPreferences(row), nil
+}
+
+// Delete clears all of the user's preferences, including the content policy.
+func (s *Service) Delete(ctx context.Context, userID string) error {
+	if err := s.queries.DeleteUserPreferences(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete user preferences: %w", err)
+	}
+	s.invalidate(ctx, userID)
+	return nil
+}
+
+// invalidate drops the user's cached preferences. A failure leaves the stale entry to expire
+// within cacheTTL.
+func (s *Service) invalidate(ctx context.Context, userID string) {
+	_ = s.cache.Delete(ctx, userID)
+}
+
+func toPreferences(row pgdb.UserPreference) *Preferences {
+	prefs := &Preferences{
+		DefaultModel: row.DefaultModel,
+		SystemPrompt: row.SystemPrompt,
+		UpdatedAt:    &row.UpdatedAt,
+	}
+	// Unknown levels in the database fail safe to the strictest policy.
+	if level, ok := contentpolicy.ParseLevel(row.ContentPolicy); ok {
+		prefs.ContentPolicy = level
+	} else {
+		prefs.ContentPolicy = contentpolicy.LevelFamily
+	}
+	if row.Temperature.Valid {
+		temperature := row.Temperature.Float64
+		prefs.Temperature = &temperature
+	}
+	return prefs
+}
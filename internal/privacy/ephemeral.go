@@ -0,0 +1,60 @@
+package privacy
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ephemeral mode is a chat-scoped alternative to privacy mode: clients send the X-Ephemeral: true
+// header with every request of a conversation the user started as ephemeral. Unlike privacy mode
+// it doesn't restrict routing; ephemeral requests:
+//   - are never stored server-side (messages, retry requests, stream recordings) and get no
+//     generated title
+//   - are recorded in request_logs for quota accounting only, without content-derived fields
+//     (stream anomalies)
+//
+// Responses are marked with the X-Ephemeral-Mode response header.
+const (
+	// EphemeralHeader is the request header enabling ephemeral mode ("true").
+	EphemeralHeader = "X-Ephemeral"
+
+	// EphemeralResponseHeader marks responses served in ephemeral mode.
+	EphemeralResponseHeader = "X-Ephemeral-Mode"
+)
+
+const ephemeralKey contextKey = "ephemeral"
+
+// WithEphemeral marks the context as belonging to an ephemeral request.
+func WithEphemeral(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ephemeralKey, true)
+}
+
+// EphemeralFromContext reports whether the context belongs to an ephemeral request.
+func EphemeralFromContext(ctx context.Context) bool {
+	ephemeral, _ := ctx.Value(ephemeralKey).(bool)
+	return ephemeral
+}
+
+// SkipStorage reports whether nothing about the request's conversation may be stored: true in
+// privacy and ephemeral mode.
+func SkipStorage(ctx context.Context) bool {
+	return FromContext(ctx) || EphemeralFromContext(ctx)
+}
+
+// EphemeralMiddleware detects ephemeral requests and marks their context (read via
+// EphemeralFromContext).
+func EphemeralMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ephemeral, _ := strconv.ParseBool(c.GetHeader(EphemeralHeader))
+		// Never forwarded upstream
+		c.Request.Header.Del(EphemeralHeader)
+
+		if ephemeral {
+			c.Request = c.Request.WithContext(WithEphemeral(c.Request.Context()))
+			c.Header(EphemeralResponseHeader, "true")
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+// Package privacy implements privacy mode for the proxy endpoints.
+//
+// A request is served in privacy mode when it carries the X-Privacy-Strict: true header or the
+// user's tier has the privacy_strict policy. Privacy mode requests:
+//   - are only routed to zero-retention providers (zero_retention in the routing config),
+//     including stream retries; models without one are rejected
+//   - are never stored server-side (messages, stream recordings) and get no generated title
+//   - are recorded in request_logs with privacy_mode set, for quota accounting only
+//
+// Responses are marked with the X-Privacy-Mode response header. The package also implements the
+// chat-scoped ephemeral mode (see EphemeralMiddleware).
+package privacy
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Header is the request header enabling privacy mode ("true").
+	Header = "X-Privacy-Strict"
+
+	// ResponseHeader marks responses served in privacy mode.
+	ResponseHeader = "X-Privacy-Mode"
+)
+
+type contextKey string
+
+const privacyKey contextKey = "privacy_strict"
+
+// WithContext marks the context as belonging to a privacy mode request.
+func WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, privacyKey, true)
+}
+
+// FromContext reports whether the context belongs to a privacy mode request.
+func FromContext(ctx context.Context) bool {
+	strict, _ := ctx.Value(privacyKey).(bool)
+	return strict
+}
+
+// Middleware detects privacy mode requests and marks their context (read via FromContext).
+// Must run after request tracking, which loads the user's tier configuration.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strict, _ := strconv.ParseBool(c.GetHeader(Header))
+		// Never forwarded upstream
+		c.Request.Header.Del(Header)
+
+		if !strict {
+			if val, exists := c.Get("tierConfig"); exists {
+				if tierConfig, ok := val.(tiers.Config); ok {
+					strict = tierConfig.PrivacyStrict
+				}
+			}
+		}
+
+		if strict {
+			c.Request = c.Request.WithContext(WithContext(c.Request.Context()))
+			c.Header(ResponseHeader, "strict")
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,117 @@
+package privacy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		header     string
+		tierConfig *tiers.Config
+		wantStrict bool
+	}{
+		{name: "regular request"},
+		{name: "header", header: "true", wantStrict: true},
+		{name: "header disabled", header: "false"},
+		{name: "invalid header", header: "yes please"},
+		{name: "tier policy", tierConfig: &tiers.Config{PrivacyStrict: true}, wantStrict: true},
+		{name: "tier without policy", tierConfig: &tiers.Config{}},
+		{name: "tier policy overrides header", header: "false", tierConfig: &tiers.Config{PrivacyStrict: true}, wantStrict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotStrict bool
+			var forwardedHeader string
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.tierConfig != nil {
+					c.Set("tierConfig", *tt.tierConfig)
+				}
+			})
+			router.Use(Middleware())
+			router.POST("/chat/completions", func(c *gin.Context) {
+				gotStrict = FromContext(c.Request.Context())
+				forwardedHeader = c.GetHeader(Header)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if gotStrict != tt.wantStrict {
+				t.Errorf("privacy mode = %v, want %v", gotStrict, tt.wantStrict)
+			}
+			if forwardedHeader != "" {
+				t.Errorf("privacy header was not stripped")
+			}
+			if got := w.Header().Get(ResponseHeader) == "strict"; got != tt.wantStrict {
+				t.Errorf("response header set = %v, want %v", got, tt.wantStrict)
+			}
+		})
+	}
+}
+
+func TestEphemeralMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		header        string
+		wantEphemeral bool
+	}{
+		{name: "regular request"},
+		{name: "header", header: "true", wantEphemeral: true},
+		{name: "header disabled", header: "false"},
+		{name: "invalid header", header: "maybe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotEphemeral, gotSkipStorage, gotPrivate bool
+			var forwardedHeader string
+
+			router := gin.New()
+			router.Use(EphemeralMiddleware())
+			router.POST("/chat/completions", func(c *gin.Context) {
+				ctx := c.Request.Context()
+				gotEphemeral, gotSkipStorage, gotPrivate = EphemeralFromContext(ctx), SkipStorage(ctx), FromContext(ctx)
+				forwardedHeader = c.GetHeader(EphemeralHeader)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			if tt.header != "" {
+				req.Header.Set(EphemeralHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if gotEphemeral != tt.wantEphemeral || gotSkipStorage != tt.wantEphemeral {
+				t.Errorf("ephemeral = %v, skip storage = %v, want %v", gotEphemeral, gotSkipStorage, tt.wantEphemeral)
+			}
+			if gotPrivate {
+				t.Errorf("ephemeral request served in privacy mode")
+			}
+			if forwardedHeader != "" {
+				t.Errorf("ephemeral header was not stripped")
+			}
+			if got := w.Header().Get(EphemeralResponseHeader) == "true"; got != tt.wantEphemeral {
+				t.Errorf("response header set = %v, want %v", got, tt.wantEphemeral)
+			}
+		})
+	}
+}
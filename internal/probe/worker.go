@@ -14,6 +14,7 @@ import (
 
 	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/providerapi"
 	"github.com/eternisai/enchanted-proxy/internal/routing"
 )
 
@@ -336,9 +337,21 @@ func (w *probeWorker) runProbe() probeResult {
 	}
 
 	var url string
-	if w.endpoint.APIType == config.APITypeResponses {
+	translator := providerapi.For(w.endpoint.APIType)
+	switch {
+	case w.endpoint.APIType == config.APITypeResponses:
 		url = strings.TrimRight(w.endpoint.BaseURL, "/") + "/responses"
-	} else {
+	case translator != nil:
+		var path string
+		if bodyBytes, path, err = translator.Request(bodyBytes); err != nil {
+			w.logger.Error("failed to translate probe request",
+				slog.String("provider", w.provider),
+				slog.String("model", w.model),
+				slog.String("error", err.Error()))
+			return probeResult{err: err}
+		}
+		url = strings.TrimRight(w.endpoint.BaseURL, "/") + path
+	default:
 		url = strings.TrimRight(w.endpoint.BaseURL, "/") + "/chat/completions"
 	}
 	req, err := http.NewRequestWithContext(w.ctx, "POST", url, bytes.NewReader(bodyBytes))
@@ -351,7 +364,9 @@ func (w *probeWorker) runProbe() probeResult {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if w.endpoint.APIKey != "" {
+	if translator != nil {
+		translator.SetHeaders(req.Header, w.endpoint.APIKey)
+	} else if w.endpoint.APIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+w.endpoint.APIKey)
 	}
 
@@ -388,9 +403,17 @@ func (w *probeWorker) runProbe() probeResult {
 	// Parse the response to extract content and token usage.
 	var parsed parsedResponse
 	var parseErr error
-	if w.endpoint.APIType == config.APITypeResponses {
+	switch {
+	case w.endpoint.APIType == config.APITypeResponses:
 		parsed, parseErr = parseResponsesAPIResponse(respBody)
-	} else {
+	case translator != nil:
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			respBody, parseErr = translator.Response(respBody)
+		}
+		if parseErr == nil {
+			parsed, parseErr = parseResponse(respBody)
+		}
+	default:
 		parsed, parseErr = parseResponse(respBody)
 	}
 	if parseErr != nil {
@@ -0,0 +1,66 @@
+// Package providerapi selects how chat completions requests are sent to a provider whose native
+// API isn't Chat Completions (api_type in the routing config). Everything else in the proxy
+// speaks Chat Completions; a Translator converts at the provider boundary.
+package providerapi
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/anthropic"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/gemini"
+)
+
+// Translator converts between Chat Completions and a provider's native API.
+type Translator interface {
+	// Request translates a Chat Completions request body. It returns the native body and the
+	// endpoint path (possibly with a query string) relative to the provider base URL.
+	Request(body []byte) ([]byte, string, error)
+
+	// SetHeaders replaces bearer authentication with the native API's headers.
+	SetHeaders(h http.Header, apiKey string)
+
+	// Response translates a successful non-streaming response body.
+	Response(body []byte) ([]byte, error)
+
+	// Error translates an error response body to the OpenAI error envelope, returning bodies
+	// it doesn't recognize unchanged.
+	Error(body []byte) []byte
+
+	// Stream translates a streaming response body to a Chat Completions SSE stream.
+	Stream(body io.ReadCloser) io.ReadCloser
+}
+
+// For returns the translator of apiType, or nil for Chat Completions and the Responses API,
+// which the proxy sends as is.
+func For(apiType config.APIType) Translator {
+	switch apiType {
+	case config.APITypeAnthropic:
+		return anthropicAPI{}
+	case config.APITypeGemini:
+		return geminiAPI{}
+	default:
+		return nil
+	}
+}
+
+type anthropicAPI struct{}
+
+func (anthropicAPI) Request(body []byte) ([]byte, string, error) {
+	translated, err := anthropic.FromChatCompletion(body)
+	return translated, anthropic.MessagesPath, err
+}
+
+func (anthropicAPI) SetHeaders(h http.Header, apiKey string) { anthropic.SetHeaders(h, apiKey) }
+func (anthropicAPI) Response(body []byte) ([]byte, error)    { return anthropic.ToChatCompletion(body) }
+func (anthropicAPI) Error(body []byte) []byte                { return anthropic.ToChatCompletionError(body) }
+func (anthropicAPI) Stream(body io.ReadCloser) io.ReadCloser { return anthropic.NewStreamReader(body) }
+
+type geminiAPI struct{}
+
+func (geminiAPI) Request(body []byte) ([]byte, string, error) { return gemini.FromChatCompletion(body) }
+func (geminiAPI) SetHeaders(h http.Header, apiKey string)     { gemini.SetHeaders(h, apiKey) }
+func (geminiAPI) Response(body []byte) ([]byte, error)        { return gemini.ToChatCompletion(body) }
+func (geminiAPI) Error(body []byte) []byte                    { return gemini.ToChatCompletionError(body) }
+func (geminiAPI) Stream(body io.ReadCloser) io.ReadCloser     { return gemini.NewStreamReader(body) }
@@ -0,0 +1,333 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/audiousage"
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/compliance"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// audioPathPrefix matches /audio/speech, /audio/transcriptions and /audio/translations.
+	audioPathPrefix = "/audio/"
+	audioSpeechPath = "/audio/speech"
+
+	// openAIAudioBaseURL serves audio models that aren't in the routing config.
+	openAIAudioBaseURL = "https://api.openai.com/v1"
+
+	// maxAudioModelField bounds the multipart "model" field.
+	maxAudioModelField = 256
+)
+
+// audioRequest is the part of an /audio/* request needed for routing and usage accounting.
+type audioRequest struct {
+	Model string
+	Input string // Text to synthesize (/audio/speech)
+	Clip  []byte // Uploaded audio (/audio/transcriptions, /audio/translations)
+}
+
+// isAudioRequest reports whether the path is an audio endpoint.
+func isAudioRequest(path string) bool {
+	return strings.HasPrefix(path, audioPathPrefix)
+}
+
+// parseAudioRequest reads the model and metered content of an audio request: the JSON body of
+// /audio/speech, or the multipart form of transcriptions and translations.
+func parseAudioRequest(path, contentType string, body []byte) (*audioRequest, error) {
+	if path == audioSpeechPath {
+		var speech struct {
+			Model string `json:"model"`
+			Input string `json:"input"`
+		}
+		if err := json.Unmarshal(body, &speech); err != nil {
+			return nil, fmt.Errorf("parse speech request: %w", err)
+		}
+		return &audioRequest{Model: speech.Model, Input: speech.Input}, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, stderrors.New("expected a multipart/form-data body")
+	}
+
+	req := &audioRequest{}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read form: %w", err)
+		}
+
+		switch part.FormName() {
+		case "model":
+			value, err := io.ReadAll(io.LimitReader(part, maxAudioModelField))
+			if err != nil {
+				return nil, fmt.Errorf("read model field: %w", err)
+			}
+			req.Model = strings.TrimSpace(string(value))
+		case "file":
+			if req.Clip, err = io.ReadAll(part); err != nil {
+				return nil, fmt.Errorf("read file: %w", err)
+			}
+		}
+		_ = part.Close()
+	}
+	return req, nil
+}
+
+// audioProvider selects the upstream for an audio model: its configured route, or OpenAI for
+// models not in the routing config (the wildcard route's providers don't serve audio).
+func audioProvider(modelRouter *routing.ModelRouter, model, platform string, cfg *config.Config) (*routing.ProviderConfig, error) {
+	if modelRouter != nil && modelRouter.GetModelInfo(model) != nil {
+		return modelRouter.RouteModel(model, platform)
+	}
+	if cfg == nil || cfg.OpenAIAPIKey == "" {
+		return nil, fmt.Errorf("no provider configured for audio model: %s", model)
+	}
+	return &routing.ProviderConfig{
+		BaseURL:         openAIAudioBaseURL,
+		APIKey:          cfg.OpenAIAPIKey,
+		Name:            "OpenAI",
+		Model:           model,
+		APIType:         config.APITypeChatCompletions,
+		TokenMultiplier: 1,
+	}, nil
+}
+
+// handleAudio proxies an /audio/* request and logs its usage by duration, converted to plan
+// tokens at AUDIO_PLAN_TOKENS_PER_MINUTE. Transcription and translation use the duration the
+// provider reports, falling back to measuring the uploaded clip; speech is estimated from the
+// input text.
+func handleAudio(
+	c *gin.Context,
+	requestBody []byte,
+	log *logger.Logger,
+	trackingService *request_tracking.Service,
+	modelRouter *routing.ModelRouter,
+	complianceService *compliance.Service,
+	cfg *config.Config,
+	headers *headerPolicy,
+) {
+	start := time.Now()
+	path := c.Request.URL.Path
+
+	req, err := parseAudioRequest(path, c.GetHeader("Content-Type"), requestBody)
+	if err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+	if req.Model == "" {
+		log.Warn("missing model field in audio request", slog.String("path", path))
+		errors.BadRequest(c, "Model field is required", nil)
+		return
+	}
+
+	platform := c.GetHeader("X-Client-Platform")
+	if platform == "" {
+		platform = "mobile"
+	}
+
+	provider, err := audioProvider(modelRouter, req.Model, platform, cfg)
+	if err != nil {
+		log.Error("failed to route audio model",
+			slog.String("error", err.Error()),
+			slog.String("model", req.Model))
+		errors.BadRequest(c, fmt.Sprintf("No provider configured for model: %s", req.Model), nil)
+		return
+	}
+	canonicalModel := req.Model
+	if modelRouter != nil {
+		canonicalModel = modelRouter.ResolveAlias(req.Model)
+	}
+
+	// Tier model access (the request tracking middleware only extracts chat completion models)
+	if val, exists := c.Get("tierConfig"); exists {
+		if tierConfig, ok := val.(tiers.Config); ok && !tierConfig.IsModelAllowed(canonicalModel) {
+			errors.AbortWithForbidden(c, errors.ModelNotAllowed(canonicalModel, tierConfig.Name, tierConfig.DisplayName, tierConfig.AllowedModels))
+			return
+		}
+	}
+
+	if complianceService != nil {
+		if decision := complianceService.CheckRoute(c, provider.Name, canonicalModel); !decision.Allowed {
+			errors.AbortWithForbidden(c, errors.RegionRestricted(decision.Country, decision.Rule))
+			return
+		}
+	}
+
+	// Substitute the provider's model name. Multipart forms are forwarded as sent.
+	if path == audioSpeechPath && req.Model != provider.Model {
+		var reqBody map[string]interface{}
+		if err := json.Unmarshal(requestBody, &reqBody); err == nil {
+			reqBody["model"] = provider.Model
+			if modifiedBody, err := json.Marshal(reqBody); err == nil {
+				requestBody = modifiedBody
+			}
+		}
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+	c.Request.ContentLength = int64(len(requestBody))
+
+	target, err := url.Parse(provider.BaseURL)
+	if err != nil {
+		log.Error("invalid url format", slog.String("base_url", provider.BaseURL), slog.String("error", err.Error()))
+		errors.BadRequest(c, "Invalid URL format", nil)
+		return
+	}
+
+	log.Info("audio proxy request started",
+		slog.String("target_url", target.String()+c.Request.RequestURI),
+		slog.String("model", req.Model),
+		slog.String("provider", provider.Name),
+		slog.Int64("request_size", max(0, c.Request.ContentLength)))
+
+	proxy := createReverseProxyWithPooling(target)
+	upstreamRecorded := false
+
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if !upstreamRecorded && !stderrors.Is(err, context.Canceled) && !stderrors.Is(err, context.DeadlineExceeded) {
+			metrics.RecordUpstreamError(provider.Name, canonicalModel, err)
+		}
+		log.Error("upstream audio request failed",
+			slog.String("target_url", target.String()+r.RequestURI),
+			slog.String("error", err.Error()),
+			slog.Duration("time_to_error", time.Since(start)))
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+	}
+
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		upstreamRecorded = true
+		metrics.RecordUpstreamResponse(provider.Name, canonicalModel, resp.StatusCode, time.Since(start).Seconds())
+		metrics.RecordRateLimitHeadroom(provider.Name, canonicalModel, resp.Header)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			rlErr := normalizeUpstreamRateLimit(resp.Header, body, req.Model, time.Now())
+			metrics.RecordUpstreamRateLimited(provider.Name, canonicalModel, float64(rlErr.RetryAfter))
+			if err := rewriteRateLimitResponse(resp, rlErr); err != nil {
+				return err
+			}
+		}
+
+		headers.filter(resp.Header)
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil
+		}
+
+		var usage audiousage.Usage
+		if path == audioSpeechPath {
+			usage = audiousage.ForSpeech(req.Input)
+		} else {
+			// Transcription responses are small; buffer them to read the reported duration.
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return fmt.Errorf("read audio response: %w", err)
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+
+			if seconds, ok := audiousage.TranscriptionSeconds(body); ok {
+				usage = audiousage.Usage{Seconds: seconds, Measured: true}
+			} else {
+				usage = audiousage.ForClip(req.Clip)
+			}
+		}
+
+		logAudioUsage(c, log, trackingService, cfg, path, canonicalModel, provider.Name, usage)
+		return nil
+	}
+
+	orig := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		orig(r)
+		r.Host = target.Host
+		r.Header.Set("Authorization", "Bearer "+provider.APIKey)
+		r.Header.Set("Accept-Encoding", "identity")
+
+		stripProxyHeaders(r.Header)
+		applyRequestHeaders(r.Header, r.Header, provider.Headers)
+	}
+
+	done := metrics.TrackActiveRequest(provider.Name, canonicalModel)
+	defer done()
+	metrics.RecordUpstreamAttempt(provider.Name, canonicalModel)
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
+
+// logAudioUsage records an audio request log entry. Audio has no token counts; plan tokens
+// come from the duration alone.
+func logAudioUsage(
+	c *gin.Context,
+	log *logger.Logger,
+	trackingService *request_tracking.Service,
+	cfg *config.Config,
+	endpoint, model, provider string,
+	usage audiousage.Usage,
+) {
+	userID, exists := auth.GetUserID(c)
+	if !exists || trackingService == nil {
+		return
+	}
+
+	var tokensPerMinute int
+	if cfg != nil {
+		tokensPerMinute = cfg.AudioPlanTokensPerMinute
+	}
+	planTokens := audiousage.PlanTokens(usage.Seconds, tokensPerMinute)
+
+	info := request_tracking.RequestInfo{
+		UserID:       userID,
+		Endpoint:     endpoint,
+		Model:        model,
+		Provider:     provider,
+		AudioSeconds: &usage.Seconds,
+	}
+	if usage.Characters > 0 {
+		info.AudioCharacters = &usage.Characters
+	}
+
+	log.Debug("queuing audio usage log",
+		slog.String("user_id", userID),
+		slog.String("model", model),
+		slog.String("endpoint", endpoint),
+		slog.Float64("audio_seconds", usage.Seconds),
+		slog.Bool("measured", usage.Measured),
+		slog.Int("plan_tokens", planTokens))
+
+	tokenData := &request_tracking.TokenUsageWithMultiplier{
+		Multiplier: 1,
+		PlanTokens: planTokens,
+	}
+	if err := trackingService.LogRequestWithPlanTokensAsync(context.WithoutCancel(c.Request.Context()), info, tokenData); err != nil {
+		log.Error("failed to queue audio usage log",
+			slog.String("user_id", userID),
+			slog.String("model", model),
+			slog.Float64("audio_seconds", usage.Seconds),
+			slog.String("error", err.Error()))
+	}
+}
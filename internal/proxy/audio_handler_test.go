@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"bytes"
+	"mime/multipart"
+	"testing"
+)
+
+func TestParseAudioRequest(t *testing.T) {
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	_ = writer.WriteField("model", " whisper-1 ")
+	part, _ := writer.CreateFormFile("file", "clip.m4a")
+	_, _ = part.Write([]byte("audio-bytes"))
+	_ = writer.WriteField("response_format", "verbose_json")
+	_ = writer.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        []byte
+		want        audioRequest
+		wantErr     bool
+	}{
+		{
+			name: "speech",
+			path: "/audio/speech",
+			body: []byte(`{"model":"tts-1","input":"hello","voice":"alloy"}`),
+			want: audioRequest{Model: "tts-1", Input: "hello"},
+		},
+		{
+			name:        "transcription",
+			path:        "/audio/transcriptions",
+			contentType: writer.FormDataContentType(),
+			body:        form.Bytes(),
+			want:        audioRequest{Model: "whisper-1", Clip: []byte("audio-bytes")},
+		},
+		{name: "invalid speech body", path: "/audio/speech", body: []byte("not json"), wantErr: true},
+		{name: "transcription without form", path: "/audio/transcriptions", contentType: "application/json", body: []byte(`{}`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAudioRequest(tt.path, tt.contentType, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAudioRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Model != tt.want.Model || got.Input != tt.want.Input || !bytes.Equal(got.Clip, tt.want.Clip) {
+				t.Errorf("parseAudioRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxBatchMessages caps the queued messages a client can replay in one request.
+	maxBatchMessages = 50
+
+	// maxBatchRequestBytes caps the batch body, including the completion request.
+	maxBatchRequestBytes = 10 << 20
+)
+
+// BatchMessage is a user message queued by the client while offline.
+type BatchMessage struct {
+	ID        string     `json:"id"`
+	Content   string     `json:"content"`
+	Timestamp *time.Time `json:"timestamp,omitempty"` // When it was written (default: now, after the previous message)
+}
+
+// BatchMessagesRequest is the body of POST /api/v1/chats/:chatId/messages/batch.
+type BatchMessagesRequest struct {
+	Messages          []BatchMessage  `json:"messages"`                    // Oldest first
+	Completion        json.RawMessage `json:"completion,omitempty"`        // Chat completions request for the final state (omit to only store)
+	ResponseMessageID string          `json:"responseMessageId,omitempty"` // Assistant message ID for the completion
+}
+
+// BatchMessagesResponse is returned when no completion runs.
+type BatchMessagesResponse struct {
+	ChatID            string   `json:"chatId"`
+	MessageIDs        []string `json:"messageIds"`
+	ResponseMessageID string   `json:"responseMessageId,omitempty"`
+	CompletionSkipped bool     `json:"completionSkipped,omitempty"` // The response message already exists
+}
+
+// BatchMessagesHandler handles POST /api/v1/chats/:chatId/messages/batch
+// Stores an ordered list of user messages queued offline, then hands a single completion for
+// the final state to the rest of the chain (the chat completions proxy). Message IDs are kept,
+// so replaying a batch overwrites the same documents instead of duplicating them, and a
+// completion whose response message already exists is not run again. In privacy and
+// ephemeral mode the messages are not stored and only the completion runs.
+func BatchMessagesHandler(
+	logger *logger.Logger,
+	messageService *messaging.Service,
+	chatStore messaging.Store,
+	trackingService *request_tracking.Service,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("batch-messages")
+
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			errors.AbortWithUnauthorized(c, "Authentication required", nil)
+			return
+		}
+
+		chatID := c.Param("chatId")
+		if chatID == "" || len(chatID) > maxChatIDLength {
+			errors.AbortWithBadRequest(c, "invalid chatId", nil)
+			return
+		}
+
+		var req BatchMessagesRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchRequestBytes))
+		if err := decoder.Decode(&req); err != nil {
+			errors.AbortWithBadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+			return
+		}
+		if err := validateBatch(&req); err != nil {
+			errors.AbortWithBadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+			return
+		}
+
+		skipStorage, err := batchSkipsStorage(c, trackingService, userID)
+		if err != nil {
+			log.Error("failed to get user tier", slog.String("user_id", userID), slog.String("error", err.Error()))
+			errors.AbortWithInternal(c, "Failed to get user tier", nil)
+			return
+		}
+		if skipStorage {
+			if len(req.Completion) == 0 {
+				errors.AbortWithBadRequest(c, "completion is required: messages are not stored in this mode", nil)
+				return
+			}
+			handOffBatchCompletion(c, chatID, &req)
+			return
+		}
+
+		if messageService == nil {
+			errors.AbortWithInternal(c, "Message storage is not available", nil)
+			return
+		}
+
+		var encryptionEnabled *bool
+		if value := c.GetHeader("X-Encryption-Enabled"); value != "" {
+			enabled := value == "true"
+			encryptionEnabled = &enabled
+		}
+
+		// Explicit timestamps keep the stored order even though storage workers run concurrently
+		timestamps := batchTimestamps(req.Messages, time.Now())
+		messageIDs := make([]string, len(req.Messages))
+		for i, message := range req.Messages {
+			msg := messaging.MessageToStore{
+				UserID:            userID,
+				ChatID:            chatID,
+				MessageID:         message.ID,
+				IsFromUser:        true,
+				Content:           message.Content,
+				EncryptionEnabled: encryptionEnabled,
+				Timestamp:         &timestamps[i],
+				SpanContext:       trace.SpanContextFromContext(c.Request.Context()),
+			}
+			// Background context: the service applies its own timeout, and the messages must be
+			// stored even if the client disconnects during the completion
+			if err := messageService.StoreMessageAsync(context.Background(), msg); err != nil {
+				log.Error("failed to queue batch message",
+					slog.String("chat_id", chatID),
+					slog.String("message_id", message.ID),
+					slog.Int("stored", i),
+					slog.String("error", err.Error()))
+				errors.AbortWithInternal(c, "Failed to store messages", map[string]interface{}{"storedMessageIds": messageIDs[:i]})
+				return
+			}
+			messageIDs[i] = message.ID
+		}
+
+		log.Info("stored batch messages",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.Int("messages", len(messageIDs)),
+			slog.Bool("completion", len(req.Completion) > 0))
+
+		if len(req.Completion) == 0 {
+			c.AbortWithStatusJSON(http.StatusOK, BatchMessagesResponse{ChatID: chatID, MessageIDs: messageIDs})
+			return
+		}
+
+		// Replayed batch: the completion already ran
+		if req.ResponseMessageID != "" && chatStore != nil {
+			_, err := chatStore.GetMessage(c.Request.Context(), userID, chatID, req.ResponseMessageID)
+			if err == nil {
+				c.AbortWithStatusJSON(http.StatusOK, BatchMessagesResponse{
+					ChatID:            chatID,
+					MessageIDs:        messageIDs,
+					ResponseMessageID: req.ResponseMessageID,
+					CompletionSkipped: true,
+				})
+				return
+			}
+			if status.Code(err) != codes.NotFound {
+				log.Warn("failed to check for existing response message, running completion",
+					slog.String("chat_id", chatID),
+					slog.String("message_id", req.ResponseMessageID),
+					slog.String("error", err.Error()))
+			}
+		}
+
+		handOffBatchCompletion(c, chatID, &req)
+	}
+}
+
+// handOffBatchCompletion hands the completion to the chat completions chain as if the client had
+// sent it. The user messages are already stored (or must not be), so X-User-Message-ID is
+// dropped to avoid storing the last one again.
+func handOffBatchCompletion(c *gin.Context, chatID string, req *BatchMessagesRequest) {
+	c.Request.URL.Path = "/chat/completions"
+	c.Request.Body = io.NopCloser(bytes.NewReader(req.Completion))
+	c.Request.ContentLength = int64(len(req.Completion))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("X-Chat-ID", chatID)
+	c.Request.Header.Del("X-User-Message-ID")
+	if req.ResponseMessageID != "" {
+		c.Request.Header.Set("X-Message-ID", req.ResponseMessageID)
+	}
+	c.Next()
+}
+
+// batchSkipsStorage reports whether the batch is served in ephemeral mode (marked by
+// privacy.EphemeralMiddleware) or privacy mode, requested by the X-Privacy-Strict header or the
+// user's tier. privacy.Middleware only marks the context after request tracking, which runs
+// after this handler, so the tier is loaded here.
+func batchSkipsStorage(c *gin.Context, trackingService *request_tracking.Service, userID string) (bool, error) {
+	if privacy.EphemeralFromContext(c.Request.Context()) {
+		return true, nil
+	}
+	if strict, _ := strconv.ParseBool(c.GetHeader(privacy.Header)); strict {
+		return true, nil
+	}
+	if trackingService == nil {
+		return false, nil
+	}
+	tierConfig, _, err := trackingService.GetUserTierConfig(c.Request.Context(), userID)
+	if err != nil {
+		return false, err
+	}
+	return tierConfig.PrivacyStrict, nil
+}
+
+// validateBatch checks the batch size, message IDs and completion request.
+func validateBatch(req *BatchMessagesRequest) error {
+	if len(req.Messages) == 0 {
+		return fmt.Errorf("messages must not be empty")
+	}
+	if len(req.Messages) > maxBatchMessages {
+		return fmt.Errorf("at most %d messages per batch", maxBatchMessages)
+	}
+
+	seen := make(map[string]bool, len(req.Messages))
+	for i, message := range req.Messages {
+		if message.ID == "" || len(message.ID) > maxMessageIDLength {
+			return fmt.Errorf("messages[%d]: invalid id", i)
+		}
+		if seen[message.ID] {
+			return fmt.Errorf("messages[%d]: duplicate id %q", i, message.ID)
+		}
+		seen[message.ID] = true
+		if strings.TrimSpace(message.Content) == "" {
+			return fmt.Errorf("messages[%d]: content is required", i)
+		}
+	}
+
+	if len(req.ResponseMessageID) > maxMessageIDLength || seen[req.ResponseMessageID] {
+		return fmt.Errorf("invalid responseMessageId")
+	}
+
+	if len(req.Completion) > 0 {
+		var completion struct {
+			Messages []json.RawMessage `json:"messages"`
+		}
+		if err := json.Unmarshal(req.Completion, &completion); err != nil {
+			return fmt.Errorf("completion: %w", err)
+		}
+		if len(completion.Messages) == 0 {
+			return fmt.Errorf("completion: messages are required")
+		}
+	}
+	return nil
+}
+
+// batchTimestamps returns strictly increasing timestamps in batch order. Messages without a
+// timestamp, or with one earlier than the previous message, are placed just after it.
+func batchTimestamps(messages []BatchMessage, now time.Time) []time.Time {
+	timestamps := make([]time.Time, len(messages))
+	for i, message := range messages {
+		switch {
+		case message.Timestamp != nil && (i == 0 || message.Timestamp.After(timestamps[i-1])):
+			timestamps[i] = message.Timestamp.UTC()
+		case i == 0:
+			timestamps[i] = now.UTC()
+		default:
+			timestamps[i] = timestamps[i-1].Add(time.Millisecond)
+		}
+	}
+	return timestamps
+}
@@ -0,0 +1,171 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateBatch(t *testing.T) {
+	completion := json.RawMessage(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`)
+	tooMany := make([]BatchMessage, maxBatchMessages+1)
+	for i := range tooMany {
+		tooMany[i] = BatchMessage{ID: strings.Repeat("x", i+1), Content: "hi"}
+	}
+
+	tests := []struct {
+		name    string
+		req     BatchMessagesRequest
+		wantErr string
+	}{
+		{"store only", BatchMessagesRequest{Messages: []BatchMessage{{ID: "a", Content: "one"}, {ID: "b", Content: "two"}}}, ""},
+		{"with completion", BatchMessagesRequest{Messages: []BatchMessage{{ID: "a", Content: "one"}}, Completion: completion, ResponseMessageID: "r"}, ""},
+		{"empty", BatchMessagesRequest{}, "must not be empty"},
+		{"too many", BatchMessagesRequest{Messages: tooMany}, "at most"},
+		{"missing id", BatchMessagesRequest{Messages: []BatchMessage{{Content: "one"}}}, "invalid id"},
+		{"duplicate id", BatchMessagesRequest{Messages: []BatchMessage{{ID: "a", Content: "one"}, {ID: "a", Content: "two"}}}, "duplicate id"},
+		{"blank content", BatchMessagesRequest{Messages: []BatchMessage{{ID: "a", Content: "  "}}}, "content is required"},
+		{"response id reuses user id", BatchMessagesRequest{Messages: []BatchMessage{{ID: "a", Content: "one"}}, ResponseMessageID: "a"}, "responseMessageId"},
+		{"completion without messages", BatchMessagesRequest{Messages: []BatchMessage{{ID: "a", Content: "one"}}, Completion: json.RawMessage(`{"model":"m"}`)}, "messages are required"},
+		{"completion not an object", BatchMessagesRequest{Messages: []BatchMessage{{ID: "a", Content: "one"}}, Completion: json.RawMessage(`[1]`)}, "completion:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBatch(&tt.req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateBatch() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateBatch() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBatchTimestamps(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(-time.Minute)
+	tooEarly := now.Add(-2 * time.Hour)
+
+	got := batchTimestamps([]BatchMessage{
+		{Timestamp: &earlier},
+		{}, // After the previous message
+		{Timestamp: &later},
+		{Timestamp: &tooEarly}, // Out of order: moved after the previous message
+	}, now)
+
+	want := []time.Time{earlier, earlier.Add(time.Millisecond), later, later.Add(time.Millisecond)}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("timestamp %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if got := batchTimestamps([]BatchMessage{{}, {}}, now); !got[0].Equal(now) || !got[1].Equal(now.Add(time.Millisecond)) {
+		t.Errorf("batchTimestamps() without timestamps = %v", got)
+	}
+}
+
+// savedMessages records saved messages; other Store methods besides DeleteDraft are not used.
+type savedMessages struct {
+	messaging.Store
+	mu  sync.Mutex
+	ids []string
+}
+
+func (s *savedMessages) SaveMessage(_ context.Context, _ string, msg *messaging.ChatMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = append(s.ids, msg.ID)
+	return nil
+}
+
+func (s *savedMessages) DeleteDraft(context.Context, string, string) error {
+	return nil
+}
+
+func TestBatchMessagesHandlerSkipsStorage(t *testing.T) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{MessageStorageWorkerPoolSize: 1, MessageStorageBufferSize: 10, MessageStorageTimeoutSeconds: 5}
+	defer func() { config.AppConfig = previous }()
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	body := `{"messages":[{"id":"m1","content":"one"},{"id":"m2","content":"two"}],` +
+		`"completion":{"model":"m","messages":[{"role":"user","content":"two"}]},"responseMessageId":"r1"}`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		stored  int
+		want    int
+	}{
+		{"stored", nil, body, 2, http.StatusOK},
+		{"privacy header", map[string]string{privacy.Header: "true"}, body, 0, http.StatusOK},
+		{"ephemeral", map[string]string{privacy.EphemeralHeader: "true"}, body, 0, http.StatusOK},
+		{"privacy header without completion", map[string]string{privacy.Header: "true"}, `{"messages":[{"id":"m1","content":"one"}]}`, 0, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &savedMessages{}
+			service := messaging.NewService(store, log)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			var completionPath string
+			var completionEphemeral bool
+			router.POST("/api/v1/chats/:chatId/messages/batch",
+				func(c *gin.Context) {
+					c.Set(string(auth.UserIDKey), "user-1")
+					c.Next()
+				},
+				privacy.EphemeralMiddleware(),
+				BatchMessagesHandler(log, service, nil, nil),
+				func(c *gin.Context) {
+					completionPath = c.Request.URL.Path
+					completionEphemeral = privacy.EphemeralFromContext(c.Request.Context())
+					c.Status(http.StatusOK)
+				})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/chats/chat-1/messages/batch", strings.NewReader(tt.body))
+			req.Header.Set("X-Encryption-Enabled", "false")
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			service.Shutdown() // Drains queued messages
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if len(store.ids) != tt.stored {
+				t.Errorf("stored messages = %v, want %d", store.ids, tt.stored)
+			}
+			if tt.want == http.StatusOK && completionPath != "/chat/completions" {
+				t.Errorf("completion path = %q, want the completion handed to the chain", completionPath)
+			}
+			if want := tt.headers[privacy.EphemeralHeader] == "true"; completionEphemeral != want {
+				t.Errorf("completion ephemeral = %v, want %v (the proxy handler must not store it either)", completionEphemeral, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetChatMetadataHandler handles GET /api/v1/chats/:chatId/metadata
+// Returns the chat's pin, archive and mute state.
+func GetChatMetadataHandler(logger *logger.Logger, chatStore messaging.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("chat-metadata")
+
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			errors.Unauthorized(c, "Authentication required", nil)
+			return
+		}
+
+		chatID := c.Param("chatId")
+		if chatID == "" || len(chatID) > maxChatIDLength {
+			errors.BadRequest(c, "invalid chatId", nil)
+			return
+		}
+
+		metadata, err := chatStore.GetChatMetadata(c.Request.Context(), userID, chatID)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				errors.NotFound(c, "Chat not found", nil)
+				return
+			}
+			log.Error("failed to get chat metadata",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("error", err.Error()))
+			errors.Internal(c, "Failed to get chat metadata", nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, metadata)
+	}
+}
+
+// UpdateChatMetadataHandler handles PATCH /api/v1/chats/:chatId/metadata
+// Pins, archives or mutes a chat; omitted fields are unchanged. Muted chats get no push
+// notifications. The chat document must already exist.
+func UpdateChatMetadataHandler(logger *logger.Logger, chatStore messaging.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("chat-metadata")
+
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			errors.Unauthorized(c, "Authentication required", nil)
+			return
+		}
+
+		chatID := c.Param("chatId")
+		if chatID == "" || len(chatID) > maxChatIDLength {
+			errors.BadRequest(c, "invalid chatId", nil)
+			return
+		}
+
+		var update messaging.ChatMetadataUpdate
+		if err := c.ShouldBindJSON(&update); err != nil {
+			errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+			return
+		}
+		if update.Pinned == nil && update.Archived == nil && update.Muted == nil {
+			errors.BadRequest(c, "at least one of pinned, archived or muted is required", nil)
+			return
+		}
+
+		metadata, err := chatStore.UpdateChatMetadata(c.Request.Context(), userID, chatID, update)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				errors.NotFound(c, "Chat not found", nil)
+				return
+			}
+			log.Error("failed to update chat metadata",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("error", err.Error()))
+			errors.Internal(c, "Failed to update chat metadata", nil)
+			return
+		}
+
+		log.Info("chat metadata updated",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID),
+			slog.Bool("pinned", metadata.Pinned),
+			slog.Bool("archived", metadata.Archived),
+			slog.Bool("muted", metadata.Muted))
+
+		c.JSON(http.StatusOK, metadata)
+	}
+}
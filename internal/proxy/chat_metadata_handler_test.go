@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateChatMetadataHandlerValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	tests := []struct {
+		name       string
+		userID     string
+		body       string
+		wantStatus int
+	}{
+		{"unauthenticated", "", `{"pinned":true}`, http.StatusUnauthorized},
+		{"malformed body", "user-1", `{"pinned":`, http.StatusBadRequest},
+		{"wrong type", "user-1", `{"muted":"yes"}`, http.StatusBadRequest},
+		{"no fields", "user-1", `{}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.userID != "" {
+					c.Set(string(auth.UserIDKey), tt.userID)
+				}
+			})
+			// Validation fails before Firestore is used
+			router.PATCH("/chats/:chatId/metadata", UpdateChatMetadataHandler(log, nil))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, "/chats/chat-1/metadata", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// scorerMaxTokens bounds the scorer's answer, which is a candidate number.
+	scorerMaxTokens    = 8
+	scorerSystemPrompt = `You compare candidate answers to the last user message of a conversation.
+Pick the candidate that is the most correct, helpful and complete. Respond with the number of the best candidate only.`
+)
+
+// choicesClient sends the candidate and scoring requests of proxy-served n > 1 and best-of
+// completions.
+var choicesClient = &http.Client{Timeout: 2 * time.Minute}
+
+var firstNumber = regexp.MustCompile(`\d+`)
+
+// choiceOptions are the n and best_of parameters of a chat completions request.
+type choiceOptions struct {
+	N      int // Choices to return; 1 = default
+	BestOf int // Candidates ranked server-side, of which the best is returned; 0 = off
+}
+
+// parseChoiceOptions reads n and best_of from a chat completions body. Both need a positive
+// integer; more than one choice or candidate is only supported for non-streaming requests, and
+// best-of returns a single choice.
+func parseChoiceOptions(body []byte, isStreaming bool) (choiceOptions, error) {
+	opts := choiceOptions{N: 1}
+
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return opts, nil
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"n", &opts.N}, {"best_of", &opts.BestOf}} {
+		raw, ok := req[param.name]
+		if !ok || raw == nil {
+			continue
+		}
+		number, ok := raw.(float64)
+		if !ok || number < 1 || number != math.Trunc(number) || number > math.MaxInt32 {
+			return opts, fmt.Errorf("%s must be a positive integer", param.name)
+		}
+		*param.value = int(number)
+	}
+	if opts.BestOf == 1 {
+		opts.BestOf = 0
+	}
+
+	if isStreaming && opts.N > 1 {
+		return opts, stderrors.New("n > 1 is only supported for non-streaming requests")
+	}
+	if isStreaming && opts.BestOf > 0 {
+		return opts, stderrors.New("best_of is only supported for non-streaming requests")
+	}
+	if opts.BestOf > 0 && opts.N > 1 {
+		return opts, stderrors.New("best_of returns a single choice; n must be 1")
+	}
+	return opts, nil
+}
+
+// checkTier returns the error for a request asking for more choices or candidates than the
+// user's tier allows. Requests without a tier (no request tracking) are not limited.
+func (o choiceOptions) checkTier(c *gin.Context) *errors.ForbiddenError {
+	val, exists := c.Get("tierConfig")
+	if !exists {
+		return nil
+	}
+	tierConfig, ok := val.(tiers.Config)
+	if !ok {
+		return nil
+	}
+
+	if maxChoices := max(tierConfig.MaxCompletionChoices, 1); o.N > maxChoices {
+		return errors.ChoicesNotAllowed("n", o.N, maxChoices, tierConfig.Name, tierConfig.DisplayName)
+	}
+	if o.BestOf > tierConfig.BestOfMaxCandidates {
+		return errors.ChoicesNotAllowed("best_of", o.BestOf, tierConfig.BestOfMaxCandidates, tierConfig.Name, tierConfig.DisplayName)
+	}
+	return nil
+}
+
+// checkBestOf returns why best_of can't be served for the canonical model, or nil.
+func checkBestOf(cfg *config.Config, canonicalModel string) error {
+	if cfg == nil || cfg.BestOf == nil {
+		return stderrors.New("best_of is not available")
+	}
+	if !cfg.BestOf.AllowsModel(canonicalModel) {
+		return fmt.Errorf("best_of is not available for model: %s", canonicalModel)
+	}
+	return nil
+}
+
+// completionResponse is a provider's response to a candidate or scoring request.
+type completionResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (r *completionResponse) ok() bool {
+	return r.status >= 200 && r.status < 300
+}
+
+// generatedChoices are the choices of a chat completion generated by one or more requests.
+type generatedChoices struct {
+	body    []byte              // Response with all choices and the summed usage; nil on failure
+	usage   *Usage              // Summed usage of the requests that succeeded (billed either way)
+	failure *completionResponse // First upstream error response
+}
+
+// generateChoices asks provider for n choices of the request: natively (n in one request) when
+// native is set, otherwise by sending n single-choice requests concurrently and merging them.
+// n and best_of of requestBody are replaced.
+func generateChoices(ctx context.Context, clientHeader http.Header, provider *routing.ProviderConfig, requestBody []byte, n int, native bool) (*generatedChoices, error) {
+	requests := n
+	perRequest := 1
+	if native {
+		requests, perRequest = 1, n
+	}
+	body, err := withChoiceCount(requestBody, perRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*completionResponse, requests)
+	errs := make([]error, requests)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = sendCompletion(ctx, clientHeader, provider, body)
+		}()
+	}
+	wg.Wait()
+
+	result := &generatedChoices{usage: &Usage{}}
+	var bodies [][]byte
+	for i, resp := range responses {
+		if errs[i] != nil {
+			err = errs[i]
+			continue
+		}
+		if !resp.ok() {
+			if result.failure == nil {
+				result.failure = resp
+			}
+			continue
+		}
+		addUsage(result.usage, extractTokenUsage(resp.body))
+		bodies = append(bodies, resp.body)
+	}
+	if result.failure != nil {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.body, err = mergeChoices(bodies, result.usage)
+	return result, err
+}
+
+// sendCompletion sends a non-streaming chat completion request to provider.
+func sendCompletion(ctx context.Context, clientHeader http.Header, provider *routing.ProviderConfig, body []byte) (*completionResponse, error) {
+	req, err := newChatCompletionRequest(ctx, provider, provider.BaseURL, provider.APIKey, chatCompletionsPath, body, clientHeader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := choicesClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	respBody = chatCompletionBody(provider, resp.StatusCode, respBody)
+	return &completionResponse{status: resp.StatusCode, header: resp.Header, body: respBody}, nil
+}
+
+// withChoiceCount sets n of a chat completions body (omitted for a single choice) and removes
+// best_of, which providers don't know.
+func withChoiceCount(body []byte, n int) ([]byte, error) {
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("decode request: %w", err)
+	}
+	delete(req, "best_of")
+	if n > 1 {
+		req["n"] = n
+	} else {
+		delete(req, "n")
+	}
+	return json.Marshal(req)
+}
+
+// mergeChoices combines completion responses into the first one: the choices are renumbered in
+// order and the usage is replaced with the total.
+func mergeChoices(bodies [][]byte, usage *Usage) ([]byte, error) {
+	var merged map[string]interface{}
+	choices := []interface{}{}
+	for _, body := range bodies {
+		var resp map[string]interface{}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("decode completion: %w", err)
+		}
+		if merged == nil {
+			merged = resp
+		}
+		items, _ := resp["choices"].([]interface{})
+		for _, item := range items {
+			if choice, ok := item.(map[string]interface{}); ok {
+				choice["index"] = len(choices)
+				choices = append(choices, choice)
+			}
+		}
+	}
+	if merged == nil {
+		return nil, stderrors.New("no completions")
+	}
+	merged["choices"] = choices
+	merged["usage"] = usage
+	return json.Marshal(merged)
+}
+
+func addUsage(total, usage *Usage) {
+	if usage == nil {
+		return
+	}
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+}
+
+// handleMultipleChoices serves a non-streaming chat completion with n > 1 for a model without
+// native support by sending n single-choice requests and merging the choices. Every request
+// is billed.
+func handleMultipleChoices(
+	c *gin.Context,
+	requestBody []byte,
+	provider *routing.ProviderConfig,
+	model string,
+	canonicalModel string,
+	n int,
+	headers *headerPolicy,
+	log *logger.Logger,
+	trackingService *request_tracking.Service,
+	messageService *messaging.Service,
+) {
+	metrics.RecordMultipleChoiceRequest(canonicalModel, "fanout")
+	log.Info("generating multiple choices with single-choice requests",
+		slog.String("model", model),
+		slog.String("provider", provider.Name),
+		slog.Int("n", n))
+
+	generated, err := generateChoices(c.Request.Context(), c.Request.Header, provider, requestBody, n, false)
+	if generated != nil {
+		logRequestToDatabaseWithProvider(c, trackingService, log, model, generated.usage, provider.Name, provider.TokenMultiplier)
+	}
+	if !writeGeneratedFailure(c, generated, err, model, headers, log) {
+		return
+	}
+	writeGeneratedChoices(c, generated.body, messageService)
+}
+
+// handleBestOf serves a best-of chat completion: bestOf candidates are generated on the
+// requested model, the scorer model ranks them and the best is returned as the only choice. If
+// scoring fails the first candidate is returned. The candidates and the scoring request are all
+// billed, and the returned usage is their total.
+func handleBestOf(
+	c *gin.Context,
+	requestBody []byte,
+	provider *routing.ProviderConfig,
+	model string,
+	canonicalModel string,
+	bestOf int,
+	platform string,
+	modelRouter *routing.ModelRouter,
+	cfg *config.Config,
+	headers *headerPolicy,
+	log *logger.Logger,
+	trackingService *request_tracking.Service,
+	messageService *messaging.Service,
+) {
+	log.Info("generating best-of candidates",
+		slog.String("model", model),
+		slog.String("provider", provider.Name),
+		slog.Int("best_of", bestOf))
+
+	generated, err := generateChoices(c.Request.Context(), c.Request.Header, provider, requestBody, bestOf, provider.SupportsMultipleChoices())
+	if generated != nil {
+		logRequestToDatabaseWithProvider(c, trackingService, log, model, generated.usage, provider.Name, provider.TokenMultiplier)
+	}
+	if !writeGeneratedFailure(c, generated, err, model, headers, log) {
+		return
+	}
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(generated.body, &resp); err != nil {
+		errors.Internal(c, "Failed to decode best-of candidates", nil)
+		return
+	}
+	candidates, _ := resp["choices"].([]interface{})
+	if len(candidates) == 0 {
+		errors.Internal(c, "Provider returned no best-of candidates", nil)
+		return
+	}
+
+	best, scoreUsage, err := scoreCandidates(c, requestBody, candidates, platform, modelRouter, cfg.BestOf.ScorerModel, log, trackingService)
+	if scoreUsage != nil {
+		addUsage(generated.usage, scoreUsage)
+	}
+	if err != nil {
+		metrics.RecordMultipleChoiceRequest(canonicalModel, "best_of_unscored")
+		log.Warn("failed to score best-of candidates, returning the first",
+			slog.String("model", model),
+			slog.String("scorer_model", cfg.BestOf.ScorerModel),
+			slog.String("error", err.Error()))
+		best = 0
+	} else {
+		metrics.RecordMultipleChoiceRequest(canonicalModel, "best_of")
+	}
+
+	choice, _ := candidates[best].(map[string]interface{})
+	if choice != nil {
+		choice["index"] = 0
+	}
+	resp["choices"] = []interface{}{choice}
+	resp["usage"] = generated.usage
+	body, err := json.Marshal(resp)
+	if err != nil {
+		errors.Internal(c, "Failed to encode best-of response", nil)
+		return
+	}
+	c.Header("X-Best-Of", strconv.Itoa(best+1)+"/"+strconv.Itoa(len(candidates)))
+	writeGeneratedChoices(c, body, messageService)
+}
+
+// scoreCandidates asks the scorer model which candidate answers the conversation best and
+// returns its index. The scoring request is billed here; its usage is returned even when the
+// answer can't be used.
+func scoreCandidates(
+	c *gin.Context,
+	requestBody []byte,
+	candidates []interface{},
+	platform string,
+	modelRouter *routing.ModelRouter,
+	scorerModel string,
+	log *logger.Logger,
+	trackingService *request_tracking.Service,
+) (int, *Usage, error) {
+	scorer, err := modelRouter.RouteModel(scorerModel, platform)
+	if err != nil {
+		return 0, nil, fmt.Errorf("route scorer model: %w", err)
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Last user message:\n")
+	prompt.WriteString(extractLastUserMessage(requestBody))
+	for i, candidate := range candidates {
+		fmt.Fprintf(&prompt, "\n\nCandidate %d:\n%s", i+1, choiceContent(candidate))
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"model": scorer.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": scorerSystemPrompt},
+			{"role": "user", "content": prompt.String()},
+		},
+		"max_tokens":  scorerMaxTokens,
+		"temperature": 0,
+		"stream":      false,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := sendCompletion(c.Request.Context(), c.Request.Header, scorer, body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("call scorer model: %w", err)
+	}
+	if !resp.ok() {
+		return 0, nil, fmt.Errorf("scorer model returned %d", resp.status)
+	}
+	usage := extractTokenUsage(resp.body)
+	logRequestToDatabaseWithProvider(c, trackingService, log, scorerModel, usage, scorer.Name, scorer.TokenMultiplier)
+
+	answer := firstNumber.FindString(extractContentFromResponse(resp.body))
+	best, err := strconv.Atoi(answer)
+	if err != nil || best < 1 || best > len(candidates) {
+		return 0, usage, fmt.Errorf("scorer answered %q", answer)
+	}
+	return best - 1, usage, nil
+}
+
+// choiceContent returns the text of a completion choice for the scorer.
+func choiceContent(choice interface{}) string {
+	fields, _ := choice.(map[string]interface{})
+	message, _ := fields["message"].(map[string]interface{})
+	if content, _ := message["content"].(string); content != "" {
+		return content
+	}
+	if _, ok := message["tool_calls"]; ok {
+		return "(calls tools)"
+	}
+	return "(empty)"
+}
+
+// writeGeneratedFailure forwards an upstream error (normalizing rate limits) or reports a failed
+// request. Returns true if the choices were generated.
+func writeGeneratedFailure(c *gin.Context, generated *generatedChoices, err error, model string, headers *headerPolicy, log *logger.Logger) bool {
+	if generated != nil && generated.failure != nil {
+		failure := generated.failure
+		log.Warn("upstream returned error for choice request",
+			slog.String("model", model),
+			slog.Int("status", failure.status))
+		headers.copyAllowed(c.Writer.Header(), failure.header)
+		if failure.status == http.StatusTooManyRequests {
+			errors.AbortWithUpstreamRateLimit(c, normalizeUpstreamRateLimit(failure.header, failure.body, model, time.Now()))
+			return false
+		}
+		c.Data(failure.status, "application/json", failure.body)
+		return false
+	}
+	if err != nil {
+		log.Error("choice request failed",
+			slog.String("model", model),
+			slog.String("error", err.Error()))
+		errors.Internal(c, "Failed to connect to upstream provider", nil)
+		return false
+	}
+	return true
+}
+
+// writeGeneratedChoices sends the completion and stores its first choice.
+func writeGeneratedChoices(c *gin.Context, body []byte, messageService *messaging.Service) {
+	if replacements, exists := c.Get("anonymizerReplacements"); exists {
+		if replacementsStr, ok := replacements.(string); ok {
+			c.Header("X-Anonymizer-Replacements", replacementsStr)
+		}
+	}
+	saveMessageAsync(c, messageService, extractContentFromResponse(body), false)
+	c.Data(http.StatusOK, "application/json", body)
+}
@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseChoiceOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		streaming bool
+		want      choiceOptions
+		wantErr   bool
+	}{
+		{name: "defaults", body: `{"model":"gpt-5"}`, want: choiceOptions{N: 1}},
+		{name: "n", body: `{"n":3}`, want: choiceOptions{N: 3}},
+		{name: "null n", body: `{"n":null}`, want: choiceOptions{N: 1}},
+		{name: "best_of", body: `{"best_of":4}`, want: choiceOptions{N: 1, BestOf: 4}},
+		{name: "best_of 1 is off", body: `{"best_of":1}`, want: choiceOptions{N: 1}},
+		{name: "zero n", body: `{"n":0}`, wantErr: true},
+		{name: "fractional n", body: `{"n":1.5}`, wantErr: true},
+		{name: "string best_of", body: `{"best_of":"3"}`, wantErr: true},
+		{name: "streaming n", body: `{"n":2}`, streaming: true, wantErr: true},
+		{name: "streaming best_of", body: `{"best_of":2}`, streaming: true, wantErr: true},
+		{name: "streaming single choice", body: `{"n":1}`, streaming: true, want: choiceOptions{N: 1}},
+		{name: "best_of with n", body: `{"n":2,"best_of":3}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChoiceOptions([]byte(tt.body), tt.streaming)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChoiceOptionsCheckTier(t *testing.T) {
+	tests := []struct {
+		name    string
+		tier    tiers.Tier
+		opts    choiceOptions
+		wantErr bool
+	}{
+		{name: "free single choice", tier: tiers.TierFree, opts: choiceOptions{N: 1}},
+		{name: "free n", tier: tiers.TierFree, opts: choiceOptions{N: 2}, wantErr: true},
+		{name: "free best_of", tier: tiers.TierFree, opts: choiceOptions{N: 1, BestOf: 2}, wantErr: true},
+		{name: "plus n within limit", tier: tiers.TierPlus, opts: choiceOptions{N: 4}},
+		{name: "plus n over limit", tier: tiers.TierPlus, opts: choiceOptions{N: 5}, wantErr: true},
+		{name: "pro best_of", tier: tiers.TierPro, opts: choiceOptions{N: 1, BestOf: 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Set("tierConfig", tiers.Configs[tt.tier])
+
+			forbidden := tt.opts.checkTier(c)
+			if (forbidden != nil) != tt.wantErr {
+				t.Fatalf("forbidden = %v, wantErr %v", forbidden, tt.wantErr)
+			}
+			if forbidden != nil && forbidden.Reason != errors.ReasonChoicesNotAllowed {
+				t.Errorf("reason = %q, want %q", forbidden.Reason, errors.ReasonChoicesNotAllowed)
+			}
+		})
+	}
+}
+
+func TestWithChoiceCount(t *testing.T) {
+	body, err := withChoiceCount([]byte(`{"model":"m","n":3,"best_of":4}`), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"model":"m"}` {
+		t.Errorf("body = %s", body)
+	}
+
+	body, err = withChoiceCount([]byte(`{"model":"m","best_of":4}`), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"model":"m","n":4}` {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestMergeChoices(t *testing.T) {
+	bodies := [][]byte{
+		[]byte(`{"id":"a","choices":[{"index":0,"message":{"content":"one"}}],"usage":{"prompt_tokens":10,"completion_tokens":1,"total_tokens":11}}`),
+		[]byte(`{"id":"b","choices":[{"index":0,"message":{"content":"two"}}],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`),
+	}
+	merged, err := mergeChoices(bodies, &Usage{PromptTokens: 20, CompletionTokens: 3, TotalTokens: 23})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		ID      string `json:"id"`
+		Choices []struct {
+			Index   int `json:"index"`
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage Usage `json:"usage"`
+	}
+	if err := json.Unmarshal(merged, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "a" || len(resp.Choices) != 2 {
+		t.Fatalf("merged = %s", merged)
+	}
+	if resp.Choices[1].Index != 1 || resp.Choices[1].Message.Content != "two" {
+		t.Errorf("second choice = %+v", resp.Choices[1])
+	}
+	if resp.Usage.TotalTokens != 23 {
+		t.Errorf("total tokens = %d, want 23", resp.Usage.TotalTokens)
+	}
+}
+
+func TestHandleMultipleChoicesFansOut(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"n"`) {
+			t.Errorf("single-choice request has n: %s", body)
+		}
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"x","choices":[{"index":0,"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`)
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, chatCompletionsPath, nil)
+	provider := &routing.ProviderConfig{BaseURL: upstream.URL, Name: "test", Model: "m", TokenMultiplier: 1}
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	handleMultipleChoices(c, []byte(`{"model":"m","n":3}`), provider, "m", "m", 3, nil, log, nil, nil)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if calls.Load() != 3 {
+		t.Errorf("upstream calls = %d, want 3", calls.Load())
+	}
+	var resp struct {
+		Choices []interface{} `json:"choices"`
+		Usage   Usage         `json:"usage"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Choices) != 3 || resp.Usage.TotalTokens != 18 {
+		t.Errorf("choices = %d, total tokens = %d, want 3 and 18", len(resp.Choices), resp.Usage.TotalTokens)
+	}
+}
+
+func TestHandleMultipleChoicesUpstreamError(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":{"message":"bad"}}`)
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, chatCompletionsPath, nil)
+	provider := &routing.ProviderConfig{BaseURL: upstream.URL, Name: "test", Model: "m"}
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	handleMultipleChoices(c, []byte(`{"model":"m","n":2}`), provider, "m", "m", 2, nil, log, nil, nil)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "bad") {
+		t.Errorf("status = %d, body %s; want the upstream error", w.Code, w.Body.String())
+	}
+}
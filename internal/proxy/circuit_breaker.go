@@ -0,0 +1,36 @@
+package proxy
+
+import (
+	"context"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/resilience"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+// breakerRoute returns the provider a request routed to provider is sent to: provider itself
+// unless its circuit breaker is open, else the first fallback whose breaker lets the request
+// through. Returns nil and when provider is expected to recover if there is none.
+func breakerRoute(breakers *resilience.Breakers, provider *routing.ProviderConfig, fallbacks []*routing.ProviderConfig) (*routing.ProviderConfig, time.Duration) {
+	retryAfter, ok := breakers.Allow(provider.Name)
+	if ok {
+		return provider, 0
+	}
+	for _, fallback := range fallbacks {
+		if _, ok := breakers.Allow(fallback.Name); ok {
+			return fallback, 0
+		}
+	}
+	return nil, retryAfter
+}
+
+// recordBreakerOutcome reports an upstream attempt to the provider's circuit breaker: errors
+// and server errors are failures, any other response (including rate limits) shows the
+// provider is up. Attempts cut short by ctx (the client went away or the request budget ran
+// out) say nothing about the provider and aren't recorded.
+func recordBreakerOutcome(ctx context.Context, breakers *resilience.Breakers, provider string, statusCode int, err error, latency time.Duration) {
+	if breakers == nil || ctx.Err() != nil {
+		return
+	}
+	breakers.Record(provider, err != nil || statusCode >= 500, latency)
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/resilience"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+func newTestBreakers() *resilience.Breakers {
+	return resilience.New(resilience.Options{
+		Window:       time.Minute,
+		MinRequests:  1,
+		FailureRate:  0.5,
+		OpenDuration: time.Minute,
+	})
+}
+
+func TestBreakerRoute(t *testing.T) {
+	primary := &routing.ProviderConfig{Name: "primary"}
+	fallbacks := []*routing.ProviderConfig{{Name: "fallback0"}, {Name: "fallback1"}}
+	breakers := newTestBreakers()
+
+	if got, _ := breakerRoute(breakers, primary, fallbacks); got != primary {
+		t.Fatalf("route = %v, want the primary while its breaker is closed", got)
+	}
+
+	breakers.Record("primary", true, time.Second)
+	breakers.Record("fallback0", true, time.Second)
+	if got, _ := breakerRoute(breakers, primary, fallbacks); got != fallbacks[1] {
+		t.Errorf("route = %v, want the first healthy fallback", got)
+	}
+
+	breakers.Record("fallback1", true, time.Second)
+	got, retryAfter := breakerRoute(breakers, primary, fallbacks)
+	if got != nil || retryAfter <= 0 || retryAfter > time.Minute {
+		t.Errorf("route = %v, %v, want none with the primary's retry time", got, retryAfter)
+	}
+}
+
+func TestFailoverSkipsOpenBreakers(t *testing.T) {
+	var calls []string
+	primary := failoverUpstream(t, "primary", http.StatusBadGateway, &calls)
+	fallbacks := []*routing.ProviderConfig{
+		failoverUpstream(t, "fallback0", http.StatusOK, &calls),
+		failoverUpstream(t, "fallback1", http.StatusOK, &calls),
+	}
+	breakers := newTestBreakers()
+	breakers.Record("fallback0", true, time.Second)
+
+	body := []byte(`{"model":"primary-model","messages":[]}`)
+	f := &failover{
+		fallbacks: fallbacks,
+		path:      chatCompletionsPath,
+		header:    http.Header{},
+		model:     "model",
+		breakers:  breakers,
+		log:       logger.New(logger.Config{Level: slog.LevelError}),
+	}
+	req, err := newChatCompletionRequest(context.Background(), primary, primary.BaseURL, primary.APIKey, chatCompletionsPath, body, f.header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, provider, _, err := f.send(http.DefaultClient.Do, req, primary, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if provider.Name != "fallback1" || len(calls) != 2 || calls[1] != "fallback1:fallback1-model" {
+		t.Errorf("provider = %s, calls = %v, want fallback0 skipped", provider.Name, calls)
+	}
+	// The failed attempt counts against the primary
+	if state := breakers.State("primary"); state != resilience.StateOpen {
+		t.Errorf("primary state = %s, want open", state)
+	}
+}
+
+func TestRecordBreakerOutcome(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	breakers := newTestBreakers()
+	recordBreakerOutcome(canceled, breakers, "openai", 0, context.Canceled, time.Second)
+	recordBreakerOutcome(context.Background(), breakers, "openai", http.StatusTooManyRequests, nil, time.Second)
+	if state := breakers.State("openai"); state != resilience.StateClosed {
+		t.Fatalf("state = %s, want closed (cancellations and rate limits aren't failures)", state)
+	}
+	recordBreakerOutcome(context.Background(), breakers, "anthropic", http.StatusInternalServerError, nil, time.Second)
+	if state := breakers.State("anthropic"); state != resilience.StateOpen {
+		t.Errorf("state = %s, want open after a server error", state)
+	}
+	recordBreakerOutcome(context.Background(), nil, "openai", 0, context.Canceled, time.Second)
+}
@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/eternisai/enchanted-proxy/internal/compaction"
+	"github.com/gin-gonic/gin"
+)
+
+// setCompactionMetadata reports a compaction to the client via the X-Context-Compaction
+// header and keeps it for the context_compacted stream event.
+func setCompactionMetadata(c *gin.Context, result *compaction.Result) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	c.Header("X-Context-Compaction", string(data))
+	c.Set("contextCompaction", string(data))
+}
+
+// writeCompactionEvent sends the context_compacted SSE event to clients that declared
+// the compaction-events capability. No-op if the request was not compacted.
+func writeCompactionEvent(c *gin.Context, flusher http.Flusher) {
+	if !capabilities.FromGin(c).Has(capabilities.CompactionEvents) {
+		return
+	}
+	value, exists := c.Get("contextCompaction")
+	if !exists {
+		return
+	}
+	data, ok := value.(string)
+	if !ok {
+		return
+	}
+	if _, err := c.Writer.WriteString("event: context_compacted\ndata: " + data + "\n\n"); err == nil {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/gin-gonic/gin"
+)
+
+// SaveDraftRequest is the body of PUT /api/v1/chats/:chatId/draft.
+type SaveDraftRequest struct {
+	Content string `json:"content"` // Plaintext; empty deletes the draft
+}
+
+// DraftResponse is a stored draft. EncryptedContent is plaintext when PublicEncryptionKey is "none".
+type DraftResponse struct {
+	ChatID              string    `json:"chatId"`
+	EncryptedContent    string    `json:"encryptedContent"`
+	PublicEncryptionKey string    `json:"publicEncryptionKey"`
+	UpdatedAt           time.Time `json:"updatedAt"`
+}
+
+func newDraftResponse(chatID string, draft *messaging.ChatDraft) DraftResponse {
+	return DraftResponse{
+		ChatID:              chatID,
+		EncryptedContent:    draft.EncryptedContent,
+		PublicEncryptionKey: draft.PublicEncryptionKey,
+		UpdatedAt:           draft.UpdatedAt,
+	}
+}
+
+// draftParams extracts the user and chat of a draft request, writing an error response if
+// either is missing or invalid.
+func draftParams(c *gin.Context) (userID, chatID string, ok bool) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		errors.Unauthorized(c, "Authentication required", nil)
+		return "", "", false
+	}
+
+	chatID = c.Param("chatId")
+	if chatID == "" || len(chatID) > maxChatIDLength {
+		errors.BadRequest(c, "invalid chatId", nil)
+		return "", "", false
+	}
+	return userID, chatID, true
+}
+
+// GetDraftHandler handles GET /api/v1/chats/:chatId/draft
+// Returns the chat's unsent draft, or 404 if it has none.
+func GetDraftHandler(logger *logger.Logger, messageService *messaging.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("drafts")
+
+		userID, chatID, ok := draftParams(c)
+		if !ok {
+			return
+		}
+
+		draft, err := messageService.GetDraft(c.Request.Context(), userID, chatID)
+		if err != nil {
+			log.Error("failed to get draft",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("error", err.Error()))
+			errors.Internal(c, "Failed to get draft", nil)
+			return
+		}
+		if draft == nil {
+			errors.NotFound(c, "No draft for this chat", nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, newDraftResponse(chatID, draft))
+	}
+}
+
+// SaveDraftHandler handles PUT /api/v1/chats/:chatId/draft
+// Stores the chat's unsent text, encrypted like messages (honors X-Encryption-Enabled).
+// The draft is removed automatically once a user message is stored in the chat.
+func SaveDraftHandler(logger *logger.Logger, messageService *messaging.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("drafts")
+
+		userID, chatID, ok := draftParams(c)
+		if !ok {
+			return
+		}
+
+		var req SaveDraftRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+			return
+		}
+		if utf8.RuneCountInString(req.Content) > messaging.MaxDraftLength {
+			errors.BadRequest(c, "draft is too long", map[string]interface{}{"maxLength": messaging.MaxDraftLength})
+			return
+		}
+
+		if req.Content == "" {
+			if err := messageService.DeleteDraft(c.Request.Context(), userID, chatID); err != nil {
+				log.Error("failed to delete draft",
+					slog.String("user_id", userID),
+					slog.String("chat_id", chatID),
+					slog.String("error", err.Error()))
+				errors.Internal(c, "Failed to delete draft", nil)
+				return
+			}
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		var encryptionEnabled *bool
+		if value := c.GetHeader("X-Encryption-Enabled"); value != "" {
+			enabled := value == "true"
+			encryptionEnabled = &enabled
+		}
+
+		draft, err := messageService.SaveDraft(c.Request.Context(), userID, chatID, req.Content, encryptionEnabled)
+		if err != nil {
+			if stderrors.Is(err, messaging.ErrEncryptionUnavailable) {
+				errors.BadRequest(c, err.Error(), nil)
+				return
+			}
+			log.Error("failed to save draft",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("error", err.Error()))
+			errors.Internal(c, "Failed to save draft", nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, newDraftResponse(chatID, draft))
+	}
+}
+
+// DeleteDraftHandler handles DELETE /api/v1/chats/:chatId/draft
+func DeleteDraftHandler(logger *logger.Logger, messageService *messaging.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("drafts")
+
+		userID, chatID, ok := draftParams(c)
+		if !ok {
+			return
+		}
+
+		if err := messageService.DeleteDraft(c.Request.Context(), userID, chatID); err != nil {
+			log.Error("failed to delete draft",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("error", err.Error()))
+			errors.Internal(c, "Failed to delete draft", nil)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
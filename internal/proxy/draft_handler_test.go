@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSaveDraftHandlerValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	tests := []struct {
+		name       string
+		userID     string
+		chatID     string
+		body       string
+		wantStatus int
+	}{
+		{"unauthenticated", "", "chat-1", `{"content":"hi"}`, http.StatusUnauthorized},
+		{"chat id too long", "user-1", strings.Repeat("c", maxChatIDLength+1), `{"content":"hi"}`, http.StatusBadRequest},
+		{"malformed body", "user-1", "chat-1", `{"content":`, http.StatusBadRequest},
+		{"too long", "user-1", "chat-1", `{"content":"` + strings.Repeat("a", messaging.MaxDraftLength+1) + `"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.userID != "" {
+					c.Set(string(auth.UserIDKey), tt.userID)
+				}
+			})
+			// Validation fails before the message service is used
+			router.PUT("/chats/:chatId/draft", SaveDraftHandler(log, nil))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/chats/"+tt.chatID+"/draft", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"context"
+	stderrors "errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/compliance"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/resilience"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
+	"github.com/gin-gonic/gin"
+)
+
+// failoverProviders returns the fallback providers a chat completion routed to provider may be
+// retried on, each in its selected region: none in sandbox mode, only zero-retention providers
+// in privacy mode, and only providers the compliance policy allows for the client.
+func failoverProviders(
+	c *gin.Context,
+	provider *routing.ProviderConfig,
+	modelRouter *routing.ModelRouter,
+	complianceService *compliance.Service,
+	canonicalModel string,
+) []*routing.ProviderConfig {
+	if len(provider.Fallbacks) == 0 || c.Request.URL.Path != chatCompletionsPath || sandbox.FromContext(c.Request.Context()) {
+		return nil
+	}
+
+	fallbacks := provider.Fallbacks
+	if privacy.FromContext(c.Request.Context()) {
+		fallbacks = zeroRetentionProviders(fallbacks)
+	}
+
+	userID, _ := auth.GetUserID(c)
+	var allowed []*routing.ProviderConfig
+	for _, fallback := range fallbacks {
+		if complianceService != nil && !complianceService.AllowsRoute(c, fallback.Name, canonicalModel) {
+			continue
+		}
+		allowed = append(allowed, modelRouter.SelectRegion(fallback, userID))
+	}
+	return allowed
+}
+
+// failoverReason returns why an upstream attempt should be retried on a fallback provider, or
+// "" if it shouldn't. Only server errors, timeouts and connection errors are retried, and never
+// once ctx is done (the client went away or the request budget ran out): 4xx statuses would
+// fail the same way elsewhere, and rate limits are handled by the rate queue.
+func failoverReason(ctx context.Context, resp *http.Response, err error) string {
+	if ctx.Err() != nil {
+		return ""
+	}
+	if err != nil {
+		var netErr net.Error
+		if stderrors.As(err, &netErr) && netErr.Timeout() {
+			return "timeout"
+		}
+		return "error"
+	}
+	if resp.StatusCode >= 500 {
+		return "status_5xx"
+	}
+	return ""
+}
+
+// failover retries a chat completion on the fallback providers of the model's failover chain.
+//
+// A completion is only retried before anything reaches the client, and only if the upstream
+// never answered or answered with a server error, so the client gets at most one completion;
+// each fallback is tried at most once, in order, skipping fallbacks whose circuit breaker is
+// open.
+type failover struct {
+	fallbacks []*routing.ProviderConfig
+	path      string      // Chat completions path, relative to the provider base URL
+	header    http.Header // Client request headers
+	model     string      // Canonical model, for metrics
+	breakers  *resilience.Breakers
+	log       *logger.Logger
+}
+
+// send sends req, the chat completion body sent to provider (before translation to a native
+// API), and while attempts fail (see failoverReason) the same completion to each fallback in
+// turn. Returns the last attempt's response or error with the provider and body it was sent
+// with. Failed attempts are recorded in the upstream metrics and circuit breakers; the last
+// one is left to the caller.
+func (f *failover) send(
+	do func(*http.Request) (*http.Response, error),
+	req *http.Request,
+	provider *routing.ProviderConfig,
+	body []byte,
+) (*http.Response, *routing.ProviderConfig, []byte, error) {
+	attemptStart := time.Now()
+	resp, err := do(req)
+
+	for _, fallback := range f.fallbacks {
+		reason := failoverReason(req.Context(), resp, err)
+		if reason == "" {
+			break
+		}
+		if f.breakers != nil {
+			if _, ok := f.breakers.Allow(fallback.Name); !ok {
+				continue
+			}
+		}
+
+		fallbackBody := withProviderPreferences(withModel(body, fallback.Model), fallback.ProviderPreferences)
+		fallbackReq, buildErr := newChatCompletionRequest(req.Context(), fallback, fallback.BaseURL, fallback.APIKey, f.path, fallbackBody, f.header)
+		if buildErr != nil {
+			f.log.Warn("failed to create failover request",
+				slog.String("fallback", fallback.Name),
+				slog.String("model", f.model),
+				slog.String("error", buildErr.Error()))
+			continue
+		}
+
+		failedArgs := []any{
+			slog.String("provider", provider.Name),
+			slog.String("fallback", fallback.Name),
+			slog.String("model", f.model),
+			slog.String("reason", reason),
+		}
+		if err != nil {
+			metrics.RecordUpstreamError(provider.Name, f.model, err)
+			recordBreakerOutcome(req.Context(), f.breakers, provider.Name, 0, err, time.Since(attemptStart))
+			failedArgs = append(failedArgs, slog.String("error", err.Error()))
+		} else {
+			metrics.RecordUpstreamResponse(provider.Name, f.model, resp.StatusCode, time.Since(attemptStart).Seconds())
+			recordBreakerOutcome(req.Context(), f.breakers, provider.Name, resp.StatusCode, nil, time.Since(attemptStart))
+			failedArgs = append(failedArgs, slog.Int("status", resp.StatusCode))
+			resp.Body.Close()
+		}
+		f.log.Warn("upstream request failed, retrying on fallback provider", failedArgs...)
+		metrics.RecordProviderFailover(provider.Name, fallback.Name, f.model, reason)
+		metrics.RecordUpstreamAttempt(fallback.Name, f.model)
+
+		provider, body = fallback, fallbackBody
+		attemptStart = time.Now()
+		resp, err = do(fallbackReq)
+	}
+
+	return resp, provider, body, err
+}
+
+// failoverTransport is the reverse proxy transport of a non-streaming chat completion with
+// fallback providers. The response handed to the reverse proxy is the last attempt's; provider
+// is then the provider that sent it.
+type failoverTransport struct {
+	base     http.RoundTripper
+	failover *failover
+	provider *routing.ProviderConfig
+	body     []byte // Body sent to the routed provider, before translation (set by the Director)
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, provider, _, err := t.failover.send(t.base.RoundTrip, req, t.provider, t.body)
+	t.provider = provider
+	return resp, err
+}
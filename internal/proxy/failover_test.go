@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+// failoverUpstream returns a provider served by a test server answering every request with
+// status, recording the models it was asked for.
+func failoverUpstream(t *testing.T, name string, status int, models *[]string) *routing.ProviderConfig {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		*models = append(*models, name+":"+body.Model)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"provider":"` + name + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	return &routing.ProviderConfig{Name: name, BaseURL: server.URL, APIKey: "key-" + name, Model: name + "-model"}
+}
+
+func TestFailoverSend(t *testing.T) {
+	var unreachable *routing.ProviderConfig
+	{
+		server := httptest.NewServer(http.NotFoundHandler())
+		unreachable = &routing.ProviderConfig{Name: "down", BaseURL: server.URL, Model: "down-model"}
+		server.Close()
+	}
+
+	tests := []struct {
+		name         string
+		primary      int // 0 = connection error
+		fallbacks    []int
+		wantProvider string
+		wantStatus   int
+		wantCalls    []string
+	}{
+		{"success not retried", http.StatusOK, []int{http.StatusOK}, "primary", http.StatusOK, []string{"primary:primary-model"}},
+		{"client error not retried", http.StatusBadRequest, []int{http.StatusOK}, "primary", http.StatusBadRequest, []string{"primary:primary-model"}},
+		{"rate limit not retried", http.StatusTooManyRequests, []int{http.StatusOK}, "primary", http.StatusTooManyRequests, []string{"primary:primary-model"}},
+		{"server error retried", http.StatusServiceUnavailable, []int{http.StatusOK}, "fallback0", http.StatusOK, []string{"primary:primary-model", "fallback0:fallback0-model"}},
+		{"chain followed in order", http.StatusBadGateway, []int{http.StatusInternalServerError, http.StatusOK}, "fallback1", http.StatusOK, []string{"primary:primary-model", "fallback0:fallback0-model", "fallback1:fallback1-model"}},
+		{"last failure returned", http.StatusBadGateway, []int{http.StatusServiceUnavailable}, "fallback0", http.StatusServiceUnavailable, []string{"primary:primary-model", "fallback0:fallback0-model"}},
+		{"connection error retried", 0, []int{http.StatusOK}, "fallback0", http.StatusOK, []string{"fallback0:fallback0-model"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			primary := unreachable
+			if tt.primary != 0 {
+				primary = failoverUpstream(t, "primary", tt.primary, &calls)
+			}
+			var fallbacks []*routing.ProviderConfig
+			for i, status := range tt.fallbacks {
+				fallbacks = append(fallbacks, failoverUpstream(t, "fallback"+strconv.Itoa(i), status, &calls))
+			}
+
+			body := []byte(`{"model":"` + primary.Model + `","messages":[]}`)
+			f := &failover{
+				fallbacks: fallbacks,
+				path:      chatCompletionsPath,
+				header:    http.Header{},
+				model:     "model",
+				log:       logger.New(logger.Config{Level: slog.LevelError}),
+			}
+			req, err := newChatCompletionRequest(context.Background(), primary, primary.BaseURL, primary.APIKey, chatCompletionsPath, body, f.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, provider, sentBody, err := f.send(http.DefaultClient.Do, req, primary, body)
+			if err != nil {
+				t.Fatalf("send() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if provider.Name != tt.wantProvider {
+				t.Errorf("provider = %s, want %s", provider.Name, tt.wantProvider)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if !bytes.Contains(sentBody, []byte(`"model":"`+provider.Model+`"`)) {
+				t.Errorf("body %s doesn't name %s", sentBody, provider.Model)
+			}
+			got, _ := io.ReadAll(resp.Body)
+			if want := `{"provider":"` + tt.wantProvider + `"}`; tt.primary != 0 && string(got) != want {
+				t.Errorf("response = %s, want %s", got, want)
+			}
+			if len(calls) != len(tt.wantCalls) {
+				t.Fatalf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			for i := range calls {
+				if calls[i] != tt.wantCalls[i] {
+					t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestFailoverReason(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+		err    error
+		want   string
+	}{
+		{"success", context.Background(), http.StatusOK, nil, ""},
+		{"client error", context.Background(), http.StatusNotFound, nil, ""},
+		{"server error", context.Background(), http.StatusInternalServerError, nil, "status_5xx"},
+		{"timeout", context.Background(), 0, context.DeadlineExceeded, "timeout"},
+		{"connection error", context.Background(), 0, io.ErrUnexpectedEOF, "error"},
+		{"context done", canceled, http.StatusBadGateway, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			if got := failoverReason(tt.ctx, resp, tt.err); got != tt.want {
+				t.Errorf("failoverReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,22 +20,36 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/anonymizer"
 	"github.com/eternisai/enchanted-proxy/internal/auth"
 	"github.com/eternisai/enchanted-proxy/internal/background"
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/eternisai/enchanted-proxy/internal/compaction"
+	"github.com/eternisai/enchanted-proxy/internal/compliance"
 	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/errors"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
 	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/preferences"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/providerapi"
+	"github.com/eternisai/enchanted-proxy/internal/ratequeue"
 	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/resilience"
 	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
 	"github.com/eternisai/enchanted-proxy/internal/streaming"
+	"github.com/eternisai/enchanted-proxy/internal/streamrecord"
+	"github.com/eternisai/enchanted-proxy/internal/structuredoutput"
 	"github.com/eternisai/enchanted-proxy/internal/title_generation"
 	"github.com/eternisai/enchanted-proxy/internal/tools"
+	"github.com/eternisai/enchanted-proxy/internal/tracing"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
-	proxyTransport *http.Transport
-	transportOnce  sync.Once
+	proxyTransport  *http.Transport
+	tracedTransport http.RoundTripper // proxyTransport with client spans and trace context propagation
+	transportOnce   sync.Once
 )
 
 func initProxyTransport() {
@@ -54,9 +68,11 @@ func initProxyTransport() {
 				KeepAlive: 30 * time.Second,
 			}).DialContext,
 			TLSHandshakeTimeout:   30 * time.Second,
+			TLSClientConfig:       upstreamTLSConfig(),
 			ResponseHeaderTimeout: 120 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 		}
+		tracedTransport = tracing.Transport(proxyTransport)
 	})
 }
 
@@ -64,7 +80,7 @@ func createReverseProxyWithPooling(target *url.URL) *httputil.ReverseProxy {
 	// Runs only once.
 	initProxyTransport()
 	proxy := httputil.NewSingleHostReverseProxy(target)
-	proxy.Transport = proxyTransport
+	proxy.Transport = tracedTransport
 	return proxy
 }
 
@@ -78,8 +94,26 @@ func ProxyHandler(
 	modelRouter *routing.ModelRouter,
 	toolRegistry *tools.Registry,
 	anonymizerService *anonymizer.Service,
+	complianceService *compliance.Service,
+	recorder *streamrecord.Recorder,
+	rateQueue *ratequeue.Queue,
+	breakers *resilience.Breakers,
+	replays *ReplayStore,
 	cfg *config.Config,
 ) gin.HandlerFunc {
+	var headers *headerPolicy
+	var compactor *compaction.Service
+	if cfg != nil {
+		headers = newHeaderPolicy(cfg.UpstreamHeaders)
+		if cfg.Compaction != nil {
+			var summarizer compaction.Summarizer
+			if cfg.Compaction.Strategy == config.CompactionStrategySummarize {
+				summarizer = compaction.NewChatCompletionsSummarizer(cfg.Compaction.SummaryModel, modelRouter)
+			}
+			compactor = compaction.NewService(cfg.Compaction, summarizer, logger.WithComponent("compaction"))
+		}
+	}
+
 	return func(c *gin.Context) {
 		start := time.Now()
 		log := logger.WithContext(c.Request.Context()).WithComponent("proxy")
@@ -100,6 +134,18 @@ func ProxyHandler(
 			}
 			c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
 
+			// Reject malformed chat payloads before they reach a provider
+			if schemaErr := validateRequestSchema(c.Request.URL.Path, requestBody); schemaErr != nil {
+				log.Warn("request rejected by schema validation",
+					slog.String("path", c.Request.URL.Path),
+					slog.String("reason", schemaErr.Error()))
+				errors.BadRequest(c, "Invalid request body: "+schemaErr.Error(), map[string]interface{}{
+					"field":  schemaErr.Field,
+					"reason": schemaErr.Reason,
+				})
+				return
+			}
+
 			model = ExtractModelFromRequestBody(c.Request.URL.Path, requestBody)
 
 			// Extract chatId, messageId, and streaming flag from request body
@@ -119,6 +165,24 @@ func ProxyHandler(
 			}
 		}
 
+		isSandbox := sandbox.FromContext(c.Request.Context())
+		isPrivate := privacy.FromContext(c.Request.Context())
+		isEphemeral := privacy.EphemeralFromContext(c.Request.Context())
+
+		// Audio requests carry multipart forms and are metered by duration
+		if isAudioRequest(c.Request.URL.Path) {
+			if isSandbox {
+				errors.BadRequest(c, "Audio endpoints are not available in sandbox mode", nil)
+				return
+			}
+			if isPrivate {
+				errors.BadRequest(c, "Audio endpoints are not available in privacy mode", nil)
+				return
+			}
+			handleAudio(c, requestBody, log, trackingService, modelRouter, complianceService, cfg, headers)
+			return
+		}
+
 		// Get client platform for routing
 		platform := c.GetHeader("X-Client-Platform")
 		if platform == "" {
@@ -138,24 +202,95 @@ func ProxyHandler(
 			return
 		}
 
-		// Route model to provider
-		provider, err := modelRouter.RouteModel(model, platform)
-		if err != nil {
-			log.Error("failed to route model",
-				slog.String("error", err.Error()),
-				slog.String("model", model))
-			errors.BadRequest(c, fmt.Sprintf("No provider configured for model: %s", model), nil)
-			return
+		// Serve requests for deprecated models with their successor instead of failing upstream
+		requestedModel := model
+		if deprecation := modelRouter.GetDeprecation(model); deprecation != nil {
+			log.Warn("deprecated model requested, routing to successor",
+				slog.String("model", model),
+				slog.String("successor", deprecation.Successor),
+				slog.String("sunset", deprecation.Sunset))
+			setDeprecationMetadata(c, deprecation)
+			model = deprecation.Successor
+		}
+
+		// Route model to provider; sandbox requests always go to the dev provider and
+		// privacy mode requests only to zero-retention providers
+		var provider *routing.ProviderConfig
+		if isSandbox {
+			provider = sandbox.NewProvider(cfg, model)
+		} else if isPrivate {
+			provider, err = modelRouter.RouteModelZeroRetention(model, platform)
+			if err != nil {
+				log.Warn("no zero-retention provider for privacy mode request",
+					slog.String("error", err.Error()),
+					slog.String("model", model))
+				errors.BadRequest(c, fmt.Sprintf("No zero-retention provider available for model: %s", model), nil)
+				return
+			}
+		} else {
+			provider, err = modelRouter.RouteModel(model, platform)
+			if err != nil {
+				log.Error("failed to route model",
+					slog.String("error", err.Error()),
+					slog.String("model", model))
+				errors.BadRequest(c, fmt.Sprintf("No provider configured for model: %s", model), nil)
+				return
+			}
+		}
+
+		// Providers with regional endpoints serve the request from the fastest healthy region,
+		// the same one for the user's follow-up requests
+		if !isSandbox {
+			userID, _ := auth.GetUserID(c)
+			provider = modelRouter.SelectRegion(provider, userID)
+		}
+
+		canonicalModel := modelRouter.ResolveAlias(model)
+
+		// Enforce country-based provider/model restrictions
+		if complianceService != nil {
+			if decision := complianceService.CheckRoute(c, provider.Name, canonicalModel); !decision.Allowed {
+				errors.AbortWithForbidden(c, errors.RegionRestricted(decision.Country, decision.Rule))
+				return
+			}
+		}
+
+		// Failing providers get no requests until they recover: chat completions go to a
+		// healthy fallback provider instead, other requests are rejected with a 503
+		if !isSandbox && breakers != nil {
+			routed, retryAfter := breakerRoute(breakers, provider, failoverProviders(c, provider, modelRouter, complianceService, canonicalModel))
+			if routed == nil {
+				log.Warn("provider circuit open, rejecting request",
+					slog.String("model", model),
+					slog.String("provider", provider.Name),
+					slog.Duration("retry_after", retryAfter))
+				errors.AbortWithProviderUnavailable(c, errors.ProviderUnavailable(model, retryAfter))
+				return
+			}
+			if routed != provider {
+				log.Warn("provider circuit open, routing to fallback provider",
+					slog.String("model", model),
+					slog.String("provider", provider.Name),
+					slog.String("fallback", routed.Name))
+				provider = routed
+			}
+		}
+
+		// Chat completions of messages are kept for retry; a retried message goes to the next
+		// provider of the failover chain instead of the one that failed it
+		if c.Request.URL.Path == chatCompletionsPath && !isSandbox && !isPrivate && !isEphemeral {
+			provider = replayRoute(c, provider, modelRouter, complianceService, breakers, canonicalModel)
+			saveReplayRequest(c, replays, log, provider.Name, requestBody)
 		}
 
 		baseURL := provider.BaseURL
 		apiKey := provider.APIKey
-		canonicalModel := modelRouter.ResolveAlias(model)
 
 		log.Info("routed model to provider",
 			slog.String("model", model),
 			slog.String("provider", provider.Name),
 			slog.String("base_url", baseURL),
+			slog.String("region", provider.Region),
 			slog.String("api_type", string(provider.APIType)),
 			slog.Float64("multiplier", provider.TokenMultiplier))
 
@@ -165,7 +300,7 @@ func ProxyHandler(
 		// providers that use different model names for the same model internally, like
 		// "z-ai/GLM-4.6" for OpenRouter vs "zai-org/GLM-4.6" for NEAR AI, or "openai/gpt-5"
 		// for OpenRouter vs "gpt-5" for OpenAI.
-		if model != provider.Model {
+		if requestedModel != provider.Model {
 			var reqBody map[string]interface{}
 			if err := json.Unmarshal(requestBody, &reqBody); err == nil {
 				reqBody["model"] = provider.Model
@@ -174,12 +309,23 @@ func ProxyHandler(
 					c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
 					c.Request.ContentLength = int64(len(requestBody))
 					log.Debug("substituted model name from provider configuration",
-						slog.String("old", model),
+						slog.String("old", requestedModel),
 						slog.String("new", provider.Model))
 				}
 			}
 		}
 
+		// Enforce the configured OpenRouter provider preferences (order, fallbacks, data collection)
+		if provider.ProviderPreferences != nil {
+			requestBody = withProviderPreferences(requestBody, provider.ProviderPreferences)
+			c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+			c.Request.ContentLength = int64(len(requestBody))
+			log.Debug("applied provider preferences",
+				slog.String("provider", provider.Name),
+				slog.Any("order", provider.ProviderPreferences.Order),
+				slog.String("data_collection", provider.ProviderPreferences.DataCollection))
+		}
+
 		// Add stream_options to enable usage reporting in streaming responses.
 		// Many OpenAI-compatible providers (vLLM, Tinfoil, etc.) only include token
 		// usage in SSE chunks when explicitly requested.
@@ -192,8 +338,56 @@ func ProxyHandler(
 				slog.String("model", model))
 		}
 
+		// Validate the request against the model's limits and capabilities
+		if provider.Info != nil {
+			checkedBody, clamped, capErr := preflightRequest(requestBody, provider.Info, compactor != nil)
+			if capErr != nil {
+				suggested := suggestModels(c, modelRouter, canonicalModel, capErr.requirements)
+				log.Warn("request rejected by preflight validation",
+					slog.String("model", model),
+					slog.String("reason", capErr.Error()),
+					slog.Any("suggested_models", suggested))
+				errors.AbortWithModelCapability(c, errors.ModelCapabilityMissing(model, capErr.capability, capErr.Error(), suggested))
+				return
+			}
+			if clamped > 0 {
+				requestBody = checkedBody
+				c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+				c.Request.ContentLength = int64(len(requestBody))
+				log.Info("clamped max tokens to model limit",
+					slog.String("model", model),
+					slog.Int("requested", clamped),
+					slog.Int("max_output_tokens", provider.Info.MaxOutputTokens))
+			}
+		}
+
+		// JSON mode and structured outputs: models without native support get the schema as an
+		// instruction. Non-streaming completions are validated against the format.
+		var outputFormat *structuredoutput.Format
+		if c.Request.URL.Path == chatCompletionsPath {
+			outputFormat = requestOutputFormat(requestBody)
+		}
+		if outputFormat != nil && outputFormat.Type == structuredoutput.TypeJSONSchema && !provider.SupportsStructuredOutput() {
+			requestBody = withSchemaInstruction(requestBody, outputFormat)
+			c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+			c.Request.ContentLength = int64(len(requestBody))
+			log.Debug("sent response schema as instruction to model without structured output support",
+				slog.String("model", model),
+				slog.String("schema", outputFormat.Name))
+		}
+
 		// Route based on API type
 		if provider.APIType == config.APITypeResponses {
+			// Responses API requests are stored by background polling
+			if isPrivate {
+				errors.BadRequest(c, fmt.Sprintf("Model %s is not available in privacy mode", model), nil)
+				return
+			}
+			if isEphemeral {
+				errors.BadRequest(c, fmt.Sprintf("Model %s is not available in ephemeral mode", model), nil)
+				return
+			}
+
 			// Handle Responses API (GPT-5 Pro, GPT-4.5+)
 			log.Info("routing to Responses API handler",
 				slog.String("model", model),
@@ -212,7 +406,7 @@ func ProxyHandler(
 			}
 
 			// Handle Responses API request (uses background polling mode)
-			if err := handleResponsesAPI(c, requestBody, provider, model, log, trackingService, messageService, titleService, pollingManager, modelRouter, cfg); err != nil {
+			if err := handleResponsesAPI(c, requestBody, provider, model, log, trackingService, messageService, titleService, pollingManager, modelRouter, cfg, headers); err != nil {
 				log.Error("Responses API handler failed",
 					slog.String("error", err.Error()),
 					slog.String("model", model))
@@ -221,8 +415,45 @@ func ProxyHandler(
 			return
 		}
 
+		// Providers with a native API (Anthropic, Gemini) serve chat completions, translated by the proxy
+		if translator := providerapi.For(provider.APIType); translator != nil {
+			if c.Request.URL.Path != chatCompletionsPath {
+				errors.BadRequest(c, fmt.Sprintf("Model %s only supports chat completions", model), nil)
+				return
+			}
+			if _, _, err := translator.Request(requestBody); err != nil {
+				log.Warn("request rejected by provider API translation",
+					slog.String("api_type", string(provider.APIType)),
+					slog.String("model", model),
+					slog.String("reason", err.Error()))
+				errors.BadRequest(c, fmt.Sprintf("Request not supported by model: %s", model), map[string]interface{}{
+					"reason": err.Error(),
+				})
+				return
+			}
+		}
+
 		// Continue with Chat Completions API (existing logic below)
 
+		// More than one choice (n) and server-side best-of are limited by tier
+		choices := choiceOptions{N: 1}
+		if c.Request.URL.Path == chatCompletionsPath {
+			if choices, err = parseChoiceOptions(requestBody, isStreamingRequest); err != nil {
+				errors.BadRequest(c, err.Error(), nil)
+				return
+			}
+			if forbidden := choices.checkTier(c); forbidden != nil {
+				errors.AbortWithForbidden(c, forbidden)
+				return
+			}
+			if choices.BestOf > 0 {
+				if err := checkBestOf(cfg, canonicalModel); err != nil {
+					errors.BadRequest(c, err.Error(), nil)
+					return
+				}
+			}
+		}
+
 		// Preserve original body for Firestore storage (before anonymization replaces it)
 		originalRequestBody := requestBody
 
@@ -236,6 +467,28 @@ func ProxyHandler(
 			}
 		}
 
+		// Compact conversations that exceed the model's context window
+		if compactor != nil && provider.ContextWindow() > 0 {
+			compactedBody, result, err := compactor.Compact(c.Request.Context(), requestBody, provider.ContextWindow(), platform)
+			if err != nil {
+				log.Warn("failed to compact conversation",
+					slog.String("model", model),
+					slog.String("error", err.Error()))
+			} else if result != nil {
+				requestBody = compactedBody
+				c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+				c.Request.ContentLength = int64(len(requestBody))
+				setCompactionMetadata(c, result)
+				log.Info("compacted conversation to fit context window",
+					slog.String("model", model),
+					slog.String("strategy", string(result.Strategy)),
+					slog.Int("removed_messages", result.RemovedMessages),
+					slog.Int("original_tokens", result.OriginalTokens),
+					slog.Int("compacted_tokens", result.CompactedTokens),
+					slog.Int("context_window", result.ContextWindow))
+			}
+		}
+
 		// Extract encryption enabled header
 		encryptionEnabledStr := c.GetHeader("X-Encryption-Enabled")
 		if encryptionEnabledStr != "" {
@@ -258,6 +511,7 @@ func ProxyHandler(
 				Model:             provider.Model,
 				BaseURL:           baseURL,
 				APIKey:            apiKey,
+				APIType:           provider.APIType,
 				Platform:          platform,
 				EncryptionEnabled: GetEncryptionEnabled(c),
 			})
@@ -283,6 +537,26 @@ func ProxyHandler(
 
 		log.Info("proxy request started", logArgs...)
 
+		// Hold chat completions briefly while the provider is rate limited
+		if !isSandbox && c.Request.URL.Path == chatCompletionsPath {
+			if !waitForRateLimit(c, rateQueue, provider.Name, model, isStreamingRequest, log) {
+				return
+			}
+		}
+
+		// Best-of, and n > 1 for models without native support, are served by the proxy
+		if choices.BestOf > 0 {
+			handleBestOf(c, requestBody, provider, model, canonicalModel, choices.BestOf, platform, modelRouter, cfg, headers, log, trackingService, messageService)
+			return
+		}
+		if choices.N > 1 {
+			if !provider.SupportsMultipleChoices() {
+				handleMultipleChoices(c, requestBody, provider, model, canonicalModel, choices.N, headers, log, trackingService, messageService)
+				return
+			}
+			metrics.RecordMultipleChoiceRequest(canonicalModel, "native")
+		}
+
 		// Create pending session BEFORE making upstream request (for early stop support)
 		if streamManager != nil {
 			chatID := c.GetHeader("X-Chat-ID")
@@ -306,7 +580,10 @@ func ProxyHandler(
 
 			// Create pending session if we have valid IDs
 			if chatID != "" && messageID != "" {
-				streamManager.CreatePendingSession(chatID, messageID)
+				session, _ := streamManager.CreatePendingSession(c.Request.Context(), chatID, messageID)
+				if userID, ok := auth.GetUserID(c); ok {
+					session.SetUserID(userID)
+				}
 				log.Info("created pending session before upstream request",
 					slog.String("chat_id", chatID),
 					slog.String("message_id", messageID))
@@ -316,6 +593,27 @@ func ProxyHandler(
 		// Create reverse proxy for this specific target
 		proxy := createReverseProxyWithPooling(target)
 
+		// Chat completions that fail with a server error or time out before reaching the client
+		// are retried on the model's fallback providers
+		fallbacks := failoverProviders(c, provider, modelRouter, complianceService, canonicalModel)
+		var failoverTr *failoverTransport
+		if len(fallbacks) > 0 && !isStreamingRequest {
+			failoverTr = &failoverTransport{
+				base: proxy.Transport,
+				failover: &failover{
+					fallbacks: fallbacks,
+					path:      c.Request.URL.Path,
+					header:    c.Request.Header.Clone(),
+					model:     canonicalModel,
+					breakers:  breakers,
+					log:       log,
+				},
+				provider: provider,
+				body:     requestBody,
+			}
+			proxy.Transport = failoverTr
+		}
+
 		// Track whether ModifyResponse already recorded upstream metrics.
 		// If ModifyResponse fires, the upstream responded — ErrorHandler should
 		// not double-count if it is subsequently called (e.g., when
@@ -324,10 +622,17 @@ func ProxyHandler(
 
 		// Add error handler for upstream failures
 		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			provider := provider
+			if failoverTr != nil {
+				// The last attempt may have been sent to a fallback provider
+				provider = failoverTr.provider
+			}
+
 			// Skip recording if the upstream already responded and was recorded,
 			// or if the error is a client-side cancellation.
 			if !upstreamRecorded && !stderrors.Is(err, context.Canceled) && !stderrors.Is(err, context.DeadlineExceeded) {
 				metrics.RecordUpstreamError(provider.Name, canonicalModel, err)
+				recordBreakerOutcome(r.Context(), breakers, provider.Name, 0, err, time.Since(start))
 			}
 			log.Error("upstream request failed",
 				slog.String("target_url", target.String()+r.RequestURI),
@@ -339,9 +644,43 @@ func ProxyHandler(
 		}
 
 		proxy.ModifyResponse = func(resp *http.Response) error {
+			provider := provider
+			if failoverTr != nil {
+				// The response may come from a fallback provider
+				provider = failoverTr.provider
+			}
+
 			upstreamRecorded = true
 			upstreamLatency := time.Since(start)
 			metrics.RecordUpstreamResponse(provider.Name, canonicalModel, resp.StatusCode, upstreamLatency.Seconds())
+			metrics.RecordRateLimitHeadroom(provider.Name, canonicalModel, resp.Header)
+			observeRateLimit(rateQueue, provider.Name, resp.StatusCode, resp.Header, time.Now())
+			recordBreakerOutcome(resp.Request.Context(), breakers, provider.Name, resp.StatusCode, nil, upstreamLatency)
+
+			// Native API responses and errors are translated back to chat completions
+			if providerapi.For(provider.APIType) != nil {
+				if err := fromNativeResponse(resp, provider); err != nil {
+					return err
+				}
+			}
+
+			// Normalize provider rate limits into the structured 429 envelope
+			if resp.StatusCode == http.StatusTooManyRequests {
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				rlErr := normalizeUpstreamRateLimit(resp.Header, body, model, time.Now())
+				metrics.RecordUpstreamRateLimited(provider.Name, canonicalModel, float64(rlErr.RetryAfter))
+				log.Warn("upstream rate limited",
+					slog.String("model", model),
+					slog.String("provider", provider.Name),
+					slog.Int("retry_after", rlErr.RetryAfter),
+					slog.String("body", string(body)))
+				if err := rewriteRateLimitResponse(resp, rlErr); err != nil {
+					return err
+				}
+			}
+
+			headers.filter(resp.Header)
 			isStreaming := strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream")
 
 			if isStreaming {
@@ -350,6 +689,9 @@ func ProxyHandler(
 				// This ensures streaming continues after client disconnect (saves full message to Firestore)
 				return handleStreamingWithBroadcast(c, resp, log, model, upstreamLatency, trackingService, messageService, streamManager, cfg, provider)
 			} else {
+				if outputFormat != nil {
+					checkStructuredOutput(c, resp, outputFormat, requestBody, provider, model, canonicalModel, log, trackingService)
+				}
 				return handleNonStreamingResponse(resp, log, model, upstreamLatency, c, trackingService, messageService, provider)
 			}
 		}
@@ -374,8 +716,8 @@ func ProxyHandler(
 
 						// Inject tool definitions if not already present and model supports them
 						if _, hasTools := reqBody["tools"]; !hasTools {
-							if tools.SupportsTools(modelID) {
-								toolDefs := toolRegistry.GetDefinitions()
+							if provider.SupportsTools() {
+								toolDefs := toolDefinitions(c, toolRegistry)
 								if len(toolDefs) > 0 {
 									reqBody["tools"] = toolDefs
 									log.Debug("injected tool definitions",
@@ -399,6 +741,9 @@ func ProxyHandler(
 					if streamManager != nil {
 						c.Set("originalRequestBody", bodyBytes)
 					}
+					if failoverTr != nil {
+						failoverTr.body = bodyBytes
+					}
 
 					// Restore body for upstream request
 					r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
@@ -422,13 +767,19 @@ func ProxyHandler(
 			// TODO: @pottekkat check if we need to decompress and re-compress the response.
 			r.Header.Set("Accept-Encoding", "identity")
 
-			// Clean up proxy headers
-			r.Header.Del("X-Forwarded-For")
-			r.Header.Del("X-Real-Ip")
-			r.Header.Del("X-Client-Platform")
-			r.Header.Del("X-Encryption-Enabled") // Remove encryption flag before forwarding
-			r.Header.Del("X-Chat-ID")            // Remove chat metadata before forwarding
-			r.Header.Del("X-Message-ID")         // Remove message metadata before forwarding
+			// Clean up proxy headers, then apply the provider's header policy
+			stripProxyHeaders(r.Header)
+			applyRequestHeaders(r.Header, r.Header, provider.Headers)
+
+			// Providers with a native API get the request in their format
+			if translator := providerapi.For(provider.APIType); translator != nil {
+				if err := toNativeRequest(r, translator, apiKey); err != nil {
+					log.Error("failed to translate request to provider API",
+						slog.String("api_type", string(provider.APIType)),
+						slog.String("model", model),
+						slog.String("error", err.Error()))
+				}
+			}
 		}
 
 		// Check for early cancellation (before making upstream request)
@@ -461,8 +812,8 @@ func ProxyHandler(
 
 					// Inject tool definitions if not already present and model supports them
 					if _, hasTools := reqBody["tools"]; !hasTools {
-						if tools.SupportsTools(modelID) {
-							toolDefs := toolRegistry.GetDefinitions()
+						if provider.SupportsTools() {
+							toolDefs := toolDefinitions(c, toolRegistry)
 							if len(toolDefs) > 0 {
 								reqBody["tools"] = toolDefs
 								log.Debug("injected tool definitions for streaming request",
@@ -484,7 +835,14 @@ func ProxyHandler(
 
 			log.Info("detected streaming request, using independent HTTP client",
 				slog.String("model", model))
-			handleStreamingDirect(c, target, apiKey, requestBody, log, start, model, canonicalModel, trackingService, messageService, streamManager, cfg, provider)
+			var retryProviders []*routing.ProviderConfig
+			if !isSandbox {
+				retryProviders = streamRetryProviders(cfg, modelRouter, canonicalModel, platform, provider)
+			}
+			if isPrivate {
+				retryProviders = zeroRetentionProviders(retryProviders)
+			}
+			handleStreamingDirect(c, target, apiKey, requestBody, log, start, model, canonicalModel, trackingService, messageService, streamManager, recorder, cfg, provider, retryProviders, fallbacks, headers, rateQueue, breakers)
 			return
 		}
 
@@ -513,6 +871,14 @@ func ProxyHandler(
 //   - Independent HTTP client not tied to Gin lifecycle
 //   - Direct streaming (chunks visible immediately, not buffered)
 //   - Upstream continues even after ALL clients disconnect
+//
+// A stream that ends without any output is retried on retryProviders, in order, before the
+// client sees anything (see retryingStreamBody). Before that, a request failing with a server
+// error or timing out is retried on fallbacks (see failover).
+//
+// The request time budget (see requestDeadline) bounds retries, tool continuations and
+// streaming: when it runs out the stream is stopped with a timeout event and the partial
+// response is saved.
 func handleStreamingDirect(
 	c *gin.Context,
 	target *url.URL,
@@ -525,8 +891,14 @@ func handleStreamingDirect(
 	trackingService *request_tracking.Service,
 	messageService *messaging.Service,
 	streamManager *streaming.StreamManager,
+	recorder *streamrecord.Recorder,
 	cfg *config.Config,
 	provider *routing.ProviderConfig,
+	retryProviders []*routing.ProviderConfig,
+	fallbacks []*routing.ProviderConfig,
+	headers *headerPolicy,
+	rateQueue *ratequeue.Queue,
+	breakers *resilience.Breakers,
 ) {
 	// Extract session IDs
 	chatID := c.GetHeader("X-Chat-ID")
@@ -568,7 +940,10 @@ func handleStreamingDirect(
 	}
 
 	// Create pending session BEFORE making HTTP request
-	streamManager.CreatePendingSession(chatID, messageID)
+	// (owned by the user from the start, so revocation can stop it during connection setup)
+	if session, _ := streamManager.CreatePendingSession(c.Request.Context(), chatID, messageID); userID != "" {
+		session.SetUserID(userID)
+	}
 	log.Info("created pending session for direct streaming",
 		slog.String("chat_id", chatID),
 		slog.String("message_id", messageID))
@@ -576,6 +951,17 @@ func handleStreamingDirect(
 	// Copy request data BEFORE starting goroutine (cannot access c.Request after handler returns)
 	requestPath := c.Request.URL.Path
 	targetURL := target.String()
+	reasoningVisibility := getReasoningVisibility(c, cfg)
+	clientCaps := capabilities.FromGin(c)
+	deadline := requestDeadline(c, cfg, provider, start)
+	isSandbox := sandbox.FromContext(c.Request.Context())
+	isPrivate := privacy.FromContext(c.Request.Context())
+	isEphemeral := privacy.EphemeralFromContext(c.Request.Context())
+	debugTrace := logger.DebugTraceFromContext(c.Request.Context())
+	spanContext := trace.SpanContextFromContext(c.Request.Context())
+	toolAllowlist := allowedTools(c)
+	contentFilter := streamManager.NewContentFilter(string(preferences.ContentPolicy(c)))
+	clientHeader := c.Request.Header.Clone()
 
 	// Channel to signal upstream status before foreground writes HTTP headers.
 	// This lets us return a proper HTTP error to the client when the upstream provider rejects the request
@@ -583,6 +969,7 @@ func handleStreamingDirect(
 	type upstreamStatus struct {
 		statusCode int
 		errBody    string
+		header     http.Header
 	}
 	statusCh := make(chan upstreamStatus, 1)
 
@@ -596,14 +983,34 @@ func handleStreamingDirect(
 
 		// Use context.Background() for complete isolation from client connection
 		ctx := context.Background()
+		if isSandbox {
+			ctx = sandbox.WithContext(ctx)
+		}
+		if isPrivate {
+			ctx = privacy.WithContext(ctx)
+		}
+		if isEphemeral {
+			ctx = privacy.WithEphemeral(ctx)
+		}
+		if debugTrace != "" {
+			ctx = logger.WithDebugTrace(ctx, debugTrace)
+		}
+		ctx = trace.ContextWithSpanContext(ctx, spanContext)
+
+		// The upstream requests (including retries) are bounded by the request time budget
+		upstreamCtx := ctx
+		if !deadline.IsZero() {
+			var cancel context.CancelFunc
+			upstreamCtx, cancel = context.WithDeadline(ctx, deadline.Add(requestBudgetGrace))
+			defer cancel()
+		}
 
 		log.Info("direct streaming: starting independent HTTP request",
 			slog.String("chat_id", chatID),
 			slog.String("message_id", messageID))
 
-		// Build upstream URL
-		upstreamURL := targetURL + requestPath
-		req, err := http.NewRequestWithContext(ctx, "POST", upstreamURL, bytes.NewReader(requestBody))
+		// Build upstream request (translated for providers with a native API)
+		req, err := newChatCompletionRequest(upstreamCtx, provider, targetURL, apiKey, requestPath, requestBody, clientHeader)
 		if err != nil {
 			log.Error("direct streaming: failed to create request",
 				slog.String("error", err.Error()),
@@ -615,18 +1022,10 @@ func handleStreamingDirect(
 			return
 		}
 
-		// Set headers
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "text/event-stream")
-		req.Header.Set("User-Agent", "Mozilla/5.0")
-		req.Header.Set("Accept-Encoding", "identity")
-		req.ContentLength = int64(len(requestBody))
-
 		// Create independent HTTP client (NOT shared transport)
 		// Disable HTTP/2 to prevent context canceled errors
 		client := &http.Client{
-			Transport: &http.Transport{
+			Transport: tracing.Transport(&http.Transport{
 				MaxIdleConns:          100,
 				MaxIdleConnsPerHost:   10,
 				IdleConnTimeout:       90 * time.Second,
@@ -635,15 +1034,24 @@ func handleStreamingDirect(
 				ForceAttemptHTTP2:     false, // HTTP/1.1 only
 				DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
 				TLSHandshakeTimeout:   30 * time.Second,
+				TLSClientConfig:       upstreamTLSConfig(),
 				ResponseHeaderTimeout: 120 * time.Second,
-			},
+			}),
 			Timeout: 0, // No timeout for streaming
 		}
 
-		// Make HTTP request
-		resp, err := client.Do(req)
+		// Make HTTP request. Until the upstream answers nothing has reached the client, so a
+		// failed request is retried on the fallback providers; from here on provider, targetURL,
+		// apiKey and requestBody are those of the provider that answered (the foreground reads
+		// provider only after statusCh).
+		upstreamStart := time.Now()
+		fo := &failover{fallbacks: fallbacks, path: requestPath, header: clientHeader, model: canonicalModel, breakers: breakers, log: log}
+		var resp *http.Response
+		resp, provider, requestBody, err = fo.send(client.Do, req, provider, requestBody)
+		targetURL, apiKey = provider.BaseURL, provider.APIKey
 		if err != nil {
 			metrics.RecordUpstreamError(provider.Name, canonicalModel, err)
+			recordBreakerOutcome(upstreamCtx, breakers, provider.Name, 0, err, time.Since(upstreamStart))
 			log.Error("direct streaming: upstream request failed",
 				slog.String("error", err.Error()),
 				slog.String("chat_id", chatID))
@@ -656,6 +1064,9 @@ func handleStreamingDirect(
 
 		upstreamLatency := time.Since(start)
 		metrics.RecordUpstreamResponse(provider.Name, canonicalModel, resp.StatusCode, upstreamLatency.Seconds())
+		metrics.RecordRateLimitHeadroom(provider.Name, canonicalModel, resp.Header)
+		observeRateLimit(rateQueue, provider.Name, resp.StatusCode, resp.Header, time.Now())
+		recordBreakerOutcome(upstreamCtx, breakers, provider.Name, resp.StatusCode, nil, upstreamLatency)
 		log.Info("direct streaming: response received",
 			slog.String("chat_id", chatID),
 			slog.Int("status", resp.StatusCode),
@@ -667,13 +1078,14 @@ func handleStreamingDirect(
 		if resp.StatusCode >= 400 {
 			body, _ := io.ReadAll(resp.Body)
 			resp.Body.Close()
+			body = chatCompletionBody(provider, resp.StatusCode, body)
 
 			log.Error("direct streaming: upstream returned error",
 				slog.String("chat_id", chatID),
 				slog.Int("status", resp.StatusCode),
 				slog.String("body", string(body)))
 
-			statusCh <- upstreamStatus{statusCode: resp.StatusCode, errBody: string(body)}
+			statusCh <- upstreamStatus{statusCode: resp.StatusCode, errBody: string(body), header: resp.Header}
 			if session := streamManager.GetSession(chatID, messageID); session != nil {
 				session.ForceComplete(fmt.Errorf("upstream error %d: %s", resp.StatusCode, string(body)))
 			}
@@ -681,7 +1093,7 @@ func handleStreamingDirect(
 		}
 
 		// Upstream responded successfully — signal foreground to start streaming
-		statusCh <- upstreamStatus{statusCode: resp.StatusCode}
+		statusCh <- upstreamStatus{statusCode: resp.StatusCode, header: resp.Header}
 
 		// Get session
 		session := streamManager.GetSession(chatID, messageID)
@@ -695,8 +1107,10 @@ func handleStreamingDirect(
 		// Set request body for tool execution
 		if requestBody != nil {
 			session.SetOriginalRequest(requestBody)
+			session.SetAllowedTools(toolAllowlist)
 			session.SetUpstreamURL(targetURL)
 			session.SetUpstreamAPIKey(apiKey)
+			session.SetUpstreamAPIType(provider.APIType)
 		}
 
 		// Set user ID for tool authentication
@@ -704,17 +1118,93 @@ func handleStreamingDirect(
 			session.SetUserID(userID)
 		}
 
+		session.SetReasoningVisibility(reasoningVisibility)
+		session.SetClientCapabilities(clientCaps)
+		session.SetContentFilter(contentFilter)
+		session.SetLatencyTracking(provider.Name, canonicalModel, upstreamStart)
+		session.SetDeadline(deadline)
+		if debugTrace != "" {
+			session.SetDebugTrace(debugTrace)
+		}
+		session.SetSpanContext(spanContext)
+
+		// Debug recording for allowlisted test accounts (never in privacy or ephemeral mode)
+		var recording *streamrecord.Recording
+		if !isPrivate && !isEphemeral && recorder.ShouldRecord(userID) {
+			recording = streamrecord.NewRecording(chatID, messageID, provider.Name, canonicalModel, upstreamStart)
+			session.SetRecording(recording)
+		}
+
+		// Retry a stream that ends without output (empty completion or early error chunk) within
+		// the retry budget. Lines are only withheld until the first output arrives.
+		usedProvider := provider
+		upstreamBody := chatCompletionStream(provider, resp.Body)
+		var retrying *retryingStreamBody
+		if len(retryProviders) > 0 {
+			retryDeadline := start.Add(time.Duration(cfg.StreamRetryBudgetSeconds) * time.Second)
+			if !deadline.IsZero() && deadline.Before(retryDeadline) {
+				retryDeadline = deadline
+			}
+			retrying = newRetryingStreamBody(upstreamBody, len(retryProviders), retryDeadline, func(attempt int) (io.ReadCloser, error) {
+				retryProvider := retryProviders[attempt]
+				retryBody := withProviderPreferences(withModel(requestBody, retryProvider.Model), retryProvider.ProviderPreferences)
+				retryReq, err := newChatCompletionRequest(upstreamCtx, retryProvider, retryProvider.BaseURL, retryProvider.APIKey, requestPath, retryBody, clientHeader)
+				var retryResp *http.Response
+				if err == nil {
+					retryResp, err = client.Do(retryReq)
+				}
+				if err == nil && retryResp.StatusCode >= 400 {
+					retryResp.Body.Close()
+					err = fmt.Errorf("retry provider returned %d", retryResp.StatusCode)
+				}
+				if err != nil {
+					metrics.RecordStreamAnomalyRetry(retryProvider.Name, canonicalModel, "failed")
+					log.Warn("direct streaming: stream retry failed",
+						slog.String("chat_id", chatID),
+						slog.String("provider", usedProvider.Name),
+						slog.String("retry_provider", retryProvider.Name),
+						slog.Int("attempt", attempt+1),
+						slog.String("error", err.Error()))
+					return nil, err
+				}
+
+				metrics.RecordStreamAnomalyRetry(retryProvider.Name, canonicalModel, "started")
+				log.Warn("direct streaming: stream ended without output, retrying",
+					slog.String("chat_id", chatID),
+					slog.String("provider", usedProvider.Name),
+					slog.String("retry_provider", retryProvider.Name),
+					slog.Int("attempt", attempt+1))
+
+				// Tool continuations follow the retried stream
+				session.SetOriginalRequest(retryBody)
+				session.SetUpstreamURL(retryProvider.BaseURL)
+				session.SetUpstreamAPIKey(retryProvider.APIKey)
+				session.SetUpstreamAPIType(retryProvider.APIType)
+				usedProvider = retryProvider
+				return chatCompletionStream(retryProvider, retryResp.Body), nil
+			})
+			upstreamBody = retrying
+		}
+
 		// CRITICAL: Stream directly, do NOT buffer with io.ReadAll
 		// Session reads from resp.Body in real-time and broadcasts chunks immediately
 		log.Info("direct streaming: attaching response body to session (NO buffering)",
 			slog.String("chat_id", chatID))
-		session.SetUpstreamBodyAndStart(resp.Body)
+		session.SetUpstreamBodyAndStart(upstreamBody)
 
 		// Wait for session to complete
 		session.WaitForCompletion()
 
+		if recording != nil {
+			if err := recorder.Save(ctx, recording); err != nil {
+				log.Error("direct streaming: failed to save stream recording",
+					slog.String("error", err.Error()),
+					slog.String("chat_id", chatID))
+			}
+		}
+
 		// Save to Firestore
-		if userID != "" && messageService != nil {
+		if userID != "" && messageService != nil && !isPrivate && !isEphemeral {
 			err := streamManager.SaveCompletedSession(ctx, session, userID, encryptionEnabled, model)
 			if err != nil {
 				log.Error("direct streaming: failed to save session",
@@ -723,38 +1213,54 @@ func handleStreamingDirect(
 			}
 		}
 
+		// Flag truncated, empty, abnormally finished or garbled streams
+		anomalies := reportStreamAnomalies(log, session, usedProvider.Name, canonicalModel)
+		var streamRetries int
+		if retrying != nil {
+			streamRetries = retrying.retries
+			if retrying.budgetExceeded {
+				metrics.RecordStreamAnomalyRetry(usedProvider.Name, canonicalModel, "budget_exceeded")
+			}
+		}
+
 		// Log tokens
 		sessionUsage := session.GetTokenUsage()
+		if sessionUsage == nil && len(anomalies) > 0 {
+			// Record the flagged request even though the broken stream reported no usage
+			sessionUsage = &streaming.TokenUsage{}
+		}
 		if sessionUsage != nil && trackingService != nil {
 			info := request_tracking.RequestInfo{
-				UserID:   userID,
-				Endpoint: requestPath,
-				Model:    model,
-				Provider: provider.Name,
+				UserID:          userID,
+				Endpoint:        requestPath,
+				Model:           model,
+				Provider:        usedProvider.Name,
+				StreamAnomalies: anomalies,
+				StreamRetries:   streamRetries,
 			}
-			if provider.TokenMultiplier > 0 {
-				planTokens := int(float64(sessionUsage.TotalTokens) * provider.TokenMultiplier)
+			if usedProvider.TokenMultiplier > 0 {
+				planTokens := int(float64(sessionUsage.TotalTokens) * usedProvider.TokenMultiplier)
 				log.Debug("queuing direct streaming usage log with plan tokens",
 					slog.String("user_id", userID),
 					slog.String("model", model),
-					slog.String("provider", provider.Name),
+					slog.String("provider", usedProvider.Name),
 					slog.Int("prompt_tokens", sessionUsage.PromptTokens),
 					slog.Int("completion_tokens", sessionUsage.CompletionTokens),
 					slog.Int("total_tokens", sessionUsage.TotalTokens),
-					slog.Float64("multiplier", provider.TokenMultiplier),
+					slog.Float64("multiplier", usedProvider.TokenMultiplier),
 					slog.Int("plan_tokens", planTokens))
 				tokenData := &request_tracking.TokenUsageWithMultiplier{
 					PromptTokens:     sessionUsage.PromptTokens,
 					CompletionTokens: sessionUsage.CompletionTokens,
 					TotalTokens:      sessionUsage.TotalTokens,
-					Multiplier:       provider.TokenMultiplier,
+					Multiplier:       usedProvider.TokenMultiplier,
 					PlanTokens:       planTokens,
 				}
 				if err := trackingService.LogRequestWithPlanTokensAsync(ctx, info, tokenData); err != nil {
 					log.Error("failed to queue direct streaming usage log with plan tokens",
 						slog.String("user_id", userID),
 						slog.String("model", model),
-						slog.String("provider", provider.Name),
+						slog.String("provider", usedProvider.Name),
 						slog.Int("plan_tokens", planTokens),
 						slog.String("error", err.Error()))
 				}
@@ -762,7 +1268,7 @@ func handleStreamingDirect(
 				log.Warn("queuing direct streaming usage log without token multiplier",
 					slog.String("user_id", userID),
 					slog.String("model", model),
-					slog.String("provider", provider.Name),
+					slog.String("provider", usedProvider.Name),
 					slog.Int("prompt_tokens", sessionUsage.PromptTokens),
 					slog.Int("completion_tokens", sessionUsage.CompletionTokens),
 					slog.Int("total_tokens", sessionUsage.TotalTokens))
@@ -775,7 +1281,7 @@ func handleStreamingDirect(
 					log.Error("failed to queue direct streaming usage log",
 						slog.String("user_id", userID),
 						slog.String("model", model),
-						slog.String("provider", provider.Name),
+						slog.String("provider", usedProvider.Name),
 						slog.String("error", err.Error()))
 				}
 			}
@@ -783,12 +1289,12 @@ func handleStreamingDirect(
 			log.Error("request tracking service unavailable — quota tracking is broken for direct streaming request",
 				slog.String("user_id", userID),
 				slog.String("model", model),
-				slog.String("provider", provider.Name))
+				slog.String("provider", usedProvider.Name))
 		} else if sessionUsage == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			log.Error("MISSING TOKEN USAGE in streaming response — quota tracking is broken for this request",
 				slog.String("user_id", userID),
 				slog.String("model", model),
-				slog.String("provider", provider.Name),
+				slog.String("provider", usedProvider.Name),
 				slog.Int("status_code", resp.StatusCode))
 		}
 
@@ -800,6 +1306,18 @@ func handleStreamingDirect(
 	// Wait for upstream to respond before writing HTTP headers to the client.
 	// This ensures we can return a proper HTTP error status code if the upstream rejects the request.
 	status := <-statusCh
+	if c.Writer.Written() && (status.statusCode == 0 || status.statusCode >= 400) {
+		// Queue events already started the response, so the error can only be sent as an event
+		log.Warn("direct streaming: upstream failed after queue events",
+			slog.String("chat_id", chatID),
+			slog.Int("status", status.statusCode))
+		statusCode := status.statusCode
+		if statusCode == 0 {
+			statusCode = http.StatusBadGateway
+		}
+		writeStreamErrorEvent(c, statusCode, status.errBody)
+		return
+	}
 	if status.statusCode == 0 {
 		// Network/request creation error
 		log.Error("direct streaming: upstream connection failed",
@@ -808,12 +1326,24 @@ func handleStreamingDirect(
 		errors.Internal(c, "Failed to connect to upstream provider", nil)
 		return
 	}
+	if status.statusCode == http.StatusTooManyRequests {
+		rlErr := normalizeUpstreamRateLimit(status.header, []byte(status.errBody), model, time.Now())
+		metrics.RecordUpstreamRateLimited(provider.Name, canonicalModel, float64(rlErr.RetryAfter))
+		log.Warn("direct streaming: upstream rate limited",
+			slog.String("chat_id", chatID),
+			slog.String("provider", provider.Name),
+			slog.Int("retry_after", rlErr.RetryAfter))
+		headers.copyAllowed(c.Writer.Header(), status.header)
+		errors.AbortWithUpstreamRateLimit(c, rlErr)
+		return
+	}
 	if status.statusCode >= 400 {
 		// Upstream returned an error — forward it to the client as a proper HTTP error.
 		// The iOS client checks status codes and classifies errors (403→paywall, 429→rate limit, etc.)
 		log.Warn("direct streaming: returning upstream error to client",
 			slog.String("chat_id", chatID),
 			slog.Int("status", status.statusCode))
+		headers.copyAllowed(c.Writer.Header(), status.header)
 		c.Data(status.statusCode, "application/json", []byte(status.errBody))
 		return
 	}
@@ -827,7 +1357,8 @@ func handleStreamingDirect(
 		return
 	}
 
-	// Set SSE headers
+	// Forward allowlisted upstream headers, then set SSE headers
+	headers.copyAllowed(c.Writer.Header(), status.header)
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
 	c.Writer.Header().Set("Cache-Control", "no-cache")
 	c.Writer.Header().Set("Connection", "keep-alive")
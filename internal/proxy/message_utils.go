@@ -6,9 +6,12 @@ import (
 	"strings"
 
 	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // extractContentFromSSELine extracts content delta from SSE line
@@ -116,7 +119,7 @@ func extractLastUserMessage(requestBody []byte) string {
 //   - Uses async worker pool (non-blocking)
 //   - Encryption: fetches public key from Firestore if enabled
 func saveUserMessageAsync(c *gin.Context, messageService *messaging.Service, requestBody []byte) {
-	if messageService == nil {
+	if messageService == nil || privacy.SkipStorage(c.Request.Context()) {
 		return
 	}
 
@@ -174,6 +177,8 @@ func saveUserMessageAsync(c *gin.Context, messageService *messaging.Service, req
 		IsError:           false,
 		EncryptionEnabled: encryptionEnabled,
 		MaskedKeywords:    maskedKeywords,
+		DebugTrace:        logger.DebugTraceFromContext(c.Request.Context()),
+		SpanContext:       trace.SpanContextFromContext(c.Request.Context()),
 	}
 
 	// Store asynchronously using background context
@@ -186,7 +191,7 @@ func saveUserMessageAsync(c *gin.Context, messageService *messaging.Service, req
 
 // saveMessageAsync saves a message to Firestore asynchronously
 func saveMessageAsync(c *gin.Context, messageService *messaging.Service, content string, isError bool) {
-	if messageService == nil {
+	if messageService == nil || privacy.SkipStorage(c.Request.Context()) {
 		return
 	}
 
@@ -232,6 +237,8 @@ func saveMessageAsync(c *gin.Context, messageService *messaging.Service, content
 		Content:           content,
 		IsError:           isError,
 		EncryptionEnabled: encryptionEnabled,
+		DebugTrace:        logger.DebugTraceFromContext(c.Request.Context()),
+		SpanContext:       trace.SpanContextFromContext(c.Request.Context()),
 	}
 
 	// Store asynchronously using background context
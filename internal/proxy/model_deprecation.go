@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/gin-gonic/gin"
+)
+
+// setDeprecationMetadata reports that a deprecated model was requested via the
+// X-Model-Deprecation header and keeps it for the model_deprecated stream event.
+func setDeprecationMetadata(c *gin.Context, deprecation *routing.ModelDeprecation) {
+	data, err := json.Marshal(deprecation)
+	if err != nil {
+		return
+	}
+	c.Header("X-Model-Deprecation", string(data))
+	c.Set("modelDeprecation", string(data))
+}
+
+// writeDeprecationEvent sends the model_deprecated SSE event to clients that declared the
+// deprecation-events capability. No-op if the requested model is not deprecated.
+func writeDeprecationEvent(c *gin.Context, flusher http.Flusher) {
+	if !capabilities.FromGin(c).Has(capabilities.DeprecationEvents) {
+		return
+	}
+	value, exists := c.Get("modelDeprecation")
+	if !exists {
+		return
+	}
+	data, ok := value.(string)
+	if !ok {
+		return
+	}
+	if _, err := c.Writer.WriteString("event: model_deprecated\ndata: " + data + "\n\n"); err == nil {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+// modelCapabilities lists optional features a model supports.
+type modelCapabilities struct {
+	Tools            bool `json:"tools"`
+	Vision           bool `json:"vision"`
+	Streaming        bool `json:"streaming"`
+	StructuredOutput bool `json:"structured_output"`
+}
+
+// modelObject is an entry of the GET /models response (OpenAI list format plus metadata).
+type modelObject struct {
+	ID              string            `json:"id"`
+	Object          string            `json:"object"`
+	Aliases         []string          `json:"aliases,omitempty"`
+	ContextWindow   int               `json:"context_window,omitempty"`
+	MaxOutputTokens int               `json:"max_output_tokens,omitempty"`
+	Capabilities    modelCapabilities `json:"capabilities"`
+}
+
+// catalogTiers is the order tiers are listed in catalog entries.
+var catalogTiers = []tiers.Tier{tiers.TierFree, tiers.TierPlus, tiers.TierPro}
+
+// catalogModelObject is an entry of the GET /api/v1/models response.
+type catalogModelObject struct {
+	modelObject
+	Provider        string         `json:"provider"`
+	APIType         config.APIType `json:"api_type"`
+	TokenMultiplier float64        `json:"token_multiplier"`
+	Available       bool           `json:"available"`         // Routable for the client platform
+	Tiers           []tiers.Tier   `json:"tiers"`             // Tiers the model is allowed for
+	Allowed         *bool          `json:"allowed,omitempty"` // Allowed for the user's tier (omitted if the tier lookup failed)
+}
+
+// newModelObject converts model metadata to a GET /models entry.
+func newModelObject(info *routing.ModelInfo) modelObject {
+	return modelObject{
+		ID:              info.Name,
+		Object:          "model",
+		Aliases:         info.Aliases,
+		ContextWindow:   info.ContextWindow,
+		MaxOutputTokens: info.MaxOutputTokens,
+		Capabilities: modelCapabilities{
+			Tools:            info.SupportsTools,
+			Vision:           info.SupportsVision,
+			Streaming:        info.SupportsStreaming,
+			StructuredOutput: info.SupportsStructuredOutput,
+		},
+	}
+}
+
+// ModelsHandler lists configured models with their limits and capabilities.
+// GET /models.
+func ModelsHandler(modelRouter *routing.ModelRouter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if modelRouter == nil {
+			errors.Internal(c, "Routing service unavailable", nil)
+			return
+		}
+
+		models := modelRouter.ListModels()
+		data := make([]modelObject, 0, len(models))
+		for _, info := range models {
+			data = append(data, newModelObject(info))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"object": "list",
+			"data":   data,
+		})
+	}
+}
+
+// ModelCatalogHandler lists configured models for the client platform (X-Client-Platform)
+// with their provider, API type, token multiplier and tier availability, so clients don't
+// hardcode model lists.
+// GET /api/v1/models.
+func ModelCatalogHandler(modelRouter *routing.ModelRouter, trackingService *request_tracking.Service, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if modelRouter == nil {
+			errors.Internal(c, "Routing service unavailable", nil)
+			return
+		}
+
+		platform := c.GetHeader("X-Client-Platform")
+		if platform == "" {
+			platform = "mobile" // Default to mobile
+		}
+
+		// The user's tier is best effort: the catalog is still useful without it
+		var userTier *tiers.Config
+		if userID, ok := auth.GetUserID(c); ok {
+			tierConfig, _, err := trackingService.GetUserTierConfig(c.Request.Context(), userID)
+			if err != nil {
+				log.WithContext(c.Request.Context()).Warn("failed to get tier config for model catalog",
+					slog.String("error", err.Error()),
+					slog.String("user_id", userID))
+			} else {
+				userTier = &tierConfig
+			}
+		}
+
+		catalog := modelRouter.Catalog(platform)
+		data := make([]catalogModelObject, 0, len(catalog))
+		for _, entry := range catalog {
+			object := catalogModelObject{
+				modelObject:     newModelObject(entry.Info),
+				Provider:        entry.Provider,
+				APIType:         entry.APIType,
+				TokenMultiplier: entry.TokenMultiplier,
+				Available:       entry.Available,
+				Tiers:           []tiers.Tier{},
+			}
+			for _, tier := range catalogTiers {
+				if tiers.Configs[tier].IsModelAllowed(entry.Info.Name) {
+					object.Tiers = append(object.Tiers, tier)
+				}
+			}
+			if userTier != nil {
+				allowed := userTier.IsModelAllowed(entry.Info.Name)
+				object.Allowed = &allowed
+			}
+			data = append(data, object)
+		}
+
+		response := gin.H{
+			"object":   "list",
+			"platform": platform,
+			"data":     data,
+		}
+		if userTier != nil {
+			response["tier"] = userTier.Name
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// maxModerationRequestBytes caps moderation bodies, which may carry base64 images.
+const maxModerationRequestBytes = 20 << 20
+
+// ModerationsHandler proxies OpenAI moderation requests (POST /moderations) with the server's
+// OpenAI key. The request and response bodies are passed through unchanged.
+func ModerationsHandler(moderator *contentpolicy.Moderator, logger *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("moderation")
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxModerationRequestBytes))
+		if err != nil {
+			errors.AbortWithBadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+			return
+		}
+
+		resp, err := moderator.Forward(c.Request.Context(), body)
+		if err != nil {
+			log.Error("moderation request failed", slog.String("error", err.Error()))
+			errors.AbortWithBadGateway(c, "Moderation service unavailable", nil)
+			return
+		}
+		defer resp.Body.Close() //nolint:errcheck
+
+		c.DataFromReader(resp.StatusCode, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, nil)
+	}
+}
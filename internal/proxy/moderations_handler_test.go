@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func TestModerationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	const request = `{"model":"omni-moderation-latest","input":["a","b"]}`
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer server-key" {
+			t.Errorf("Authorization = %q, want the server key", got)
+		}
+		if body, _ := io.ReadAll(r.Body); string(body) != request {
+			t.Errorf("forwarded body = %s, want it unchanged", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = io.WriteString(w, `{"results":[]}`)
+	}))
+	defer upstream.Close()
+
+	router := gin.New()
+	router.POST("/moderations", ModerationsHandler(contentpolicy.NewModerator("server-key", upstream.URL), log))
+
+	req := httptest.NewRequest(http.MethodPost, "/moderations", strings.NewReader(request))
+	req.Header.Set("Authorization", "Bearer user-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTeapot || w.Body.String() != `{"results":[]}` || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("response = %d %q (%s), want the upstream response", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+}
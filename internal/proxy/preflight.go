@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/eternisai/enchanted-proxy/internal/compaction"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+// maxModelSuggestions bounds the models suggested for a request the model can't serve.
+const maxModelSuggestions = 3
+
+// maxTokensFields are the request fields that bound completion length
+// (chat completions and Responses API).
+var maxTokensFields = []string{"max_tokens", "max_completion_tokens", "max_output_tokens"}
+
+// Capabilities a request can need that a model may lack.
+const (
+	capabilityStreaming = "streaming"
+	capabilityTools     = "tools"
+	capabilityVision    = "vision"
+	capabilityContext   = "context"
+)
+
+// capabilityError rejects a request needing a capability the target model lacks.
+type capabilityError struct {
+	capability   string
+	requirements routing.ModelRequirements // Everything the request needs, to suggest other models
+}
+
+func (e *capabilityError) Error() string {
+	switch e.capability {
+	case capabilityStreaming:
+		return "streaming is not supported"
+	case capabilityTools:
+		return "tools are not supported"
+	case capabilityVision:
+		return "image input is not supported"
+	default:
+		return "prompt exceeds the context window"
+	}
+}
+
+// preflightRequest validates a request body against the target model's metadata before it
+// is forwarded. Requests using a capability the model lacks (streaming, tools, images, a
+// prompt larger than the context window unless it is compacted later) are rejected with the
+// capability. Completion limits above MaxOutputTokens are clamped; the largest requested value
+// is returned (0 = body unchanged). Non-JSON bodies are passed through.
+func preflightRequest(body []byte, info *routing.ModelInfo, compacted bool) ([]byte, int, *capabilityError) {
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body, 0, nil
+	}
+
+	stream, _ := req["stream"].(bool)
+	tools, _ := req["tools"].([]interface{})
+	requirements := routing.ModelRequirements{
+		Streaming: stream,
+		Tools:     len(tools) > 0,
+		Vision:    hasImageContent(req["messages"]) || hasImageContent(req["input"]),
+	}
+	if !compacted {
+		requirements.ContextTokens = estimatePromptTokens(req["messages"])
+	}
+
+	switch {
+	case requirements.Streaming && !info.SupportsStreaming:
+		return body, 0, &capabilityError{capability: capabilityStreaming, requirements: requirements}
+	case requirements.Tools && !info.SupportsTools:
+		return body, 0, &capabilityError{capability: capabilityTools, requirements: requirements}
+	case requirements.Vision && !info.SupportsVision:
+		return body, 0, &capabilityError{capability: capabilityVision, requirements: requirements}
+	case info.ContextWindow > 0 && requirements.ContextTokens > info.ContextWindow:
+		return body, 0, &capabilityError{capability: capabilityContext, requirements: requirements}
+	}
+
+	if info.MaxOutputTokens <= 0 {
+		return body, 0, nil
+	}
+
+	clamped := 0
+	for _, field := range maxTokensFields {
+		if value, ok := req[field].(float64); ok && int(value) > info.MaxOutputTokens {
+			clamped = max(clamped, int(value))
+			req[field] = info.MaxOutputTokens
+		}
+	}
+	if clamped == 0 {
+		return body, 0, nil
+	}
+
+	newBody, err := json.Marshal(req)
+	if err != nil {
+		return body, 0, nil
+	}
+
+	return newBody, clamped, nil
+}
+
+// suggestModels returns the names of models meeting the requirements of a rejected request,
+// other than model and excluding models the user's tier doesn't allow.
+func suggestModels(c *gin.Context, modelRouter *routing.ModelRouter, model string, requirements routing.ModelRequirements) []string {
+	var tierConfig *tiers.Config
+	if val, exists := c.Get("tierConfig"); exists {
+		if cfg, ok := val.(tiers.Config); ok {
+			tierConfig = &cfg
+		}
+	}
+
+	suggested := []string{}
+	for _, info := range modelRouter.SuggestModels(requirements) {
+		if info.Name == model || (tierConfig != nil && !tierConfig.IsModelAllowed(info.Name)) {
+			continue
+		}
+		suggested = append(suggested, info.Name)
+		if len(suggested) == maxModelSuggestions {
+			break
+		}
+	}
+	return suggested
+}
+
+// estimatePromptTokens estimates the prompt tokens of chat completions messages.
+func estimatePromptTokens(messages interface{}) int {
+	list, _ := messages.([]interface{})
+	tokens := 0
+	for _, item := range list {
+		if msg, ok := item.(map[string]interface{}); ok {
+			tokens += compaction.EstimateMessageTokens(msg)
+		}
+	}
+	return tokens
+}
+
+// hasImageContent reports whether chat completions messages or Responses API input items
+// contain an image content part.
+func hasImageContent(items interface{}) bool {
+	list, ok := items.([]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, item := range list {
+		msg, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		parts, ok := msg["content"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, part := range parts {
+			if p, ok := part.(map[string]interface{}); ok && (p["type"] == "image_url" || p["type"] == "input_image") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+func TestPreflightRequest(t *testing.T) {
+	full := &routing.ModelInfo{Name: "m", MaxOutputTokens: 4096, SupportsTools: true, SupportsVision: true, SupportsStreaming: true}
+	textOnly := &routing.ModelInfo{Name: "m", SupportsStreaming: true}
+	noStream := &routing.ModelInfo{Name: "m", SupportsTools: true, SupportsVision: true}
+	smallContext := &routing.ModelInfo{Name: "m", ContextWindow: 100}
+
+	image := `{"role":"user","content":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"x"}}]}`
+
+	tests := []struct {
+		name           string
+		info           *routing.ModelInfo
+		body           string
+		compacted      bool
+		wantCapability string
+		wantClamped    int
+		wantField      string
+	}{
+		{name: "within limits", info: full, body: `{"model":"m","max_tokens":100,"stream":true}`},
+		{name: "clamp max_tokens", info: full, body: `{"model":"m","max_tokens":10000}`, wantClamped: 10000, wantField: "max_tokens"},
+		{name: "clamp responses max_output_tokens", info: full, body: `{"model":"m","max_output_tokens":5000}`, wantClamped: 5000, wantField: "max_output_tokens"},
+		{name: "no limit configured", info: textOnly, body: `{"model":"m","max_tokens":100000}`},
+		{name: "tools rejected", info: textOnly, body: `{"model":"m","tools":[{"type":"function"}]}`, wantCapability: capabilityTools},
+		{name: "empty tools allowed", info: textOnly, body: `{"model":"m","tools":[]}`},
+		{name: "image rejected", info: textOnly, body: `{"model":"m","messages":[` + image + `]}`, wantCapability: capabilityVision},
+		{name: "responses image rejected", info: textOnly, body: `{"model":"m","input":[{"role":"user","content":[{"type":"input_image","image_url":"x"}]}]}`, wantCapability: capabilityVision},
+		{name: "image allowed", info: full, body: `{"model":"m","messages":[` + image + `]}`},
+		{name: "streaming rejected", info: noStream, body: `{"model":"m","stream":true}`, wantCapability: capabilityStreaming},
+		{name: "context exceeded", info: smallContext, body: `{"model":"m","messages":[{"role":"user","content":"` + strings.Repeat("word ", 500) + `"}]}`, wantCapability: capabilityContext},
+		{name: "context exceeded but compacted", info: smallContext, body: `{"model":"m","messages":[{"role":"user","content":"` + strings.Repeat("word ", 500) + `"}]}`, compacted: true},
+		{name: "non-JSON body passes", info: noStream, body: `--multipart--`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, clamped, err := preflightRequest([]byte(tt.body), tt.info, tt.compacted)
+			capability := ""
+			if err != nil {
+				capability = err.capability
+			}
+			if capability != tt.wantCapability {
+				t.Fatalf("preflightRequest() capability = %q, want %q", capability, tt.wantCapability)
+			}
+			if clamped != tt.wantClamped {
+				t.Errorf("clamped = %d, want %d", clamped, tt.wantClamped)
+			}
+			if tt.wantField == "" {
+				if string(body) != tt.body {
+					t.Errorf("body modified: %s", body)
+				}
+				return
+			}
+
+			var req map[string]interface{}
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Fatal(err)
+			}
+			if req[tt.wantField] != float64(tt.info.MaxOutputTokens) {
+				t.Errorf("%s = %v, want %d", tt.wantField, req[tt.wantField], tt.info.MaxOutputTokens)
+			}
+		})
+	}
+}
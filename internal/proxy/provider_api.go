@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/providerapi"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+// newChatCompletionRequest builds a chat completions request (path relative to baseURL) to
+// provider. Requests to providers with a native API (Anthropic, Gemini) are translated; read
+// their responses with chatCompletionStream or chatCompletionBody.
+func newChatCompletionRequest(
+	ctx context.Context,
+	provider *routing.ProviderConfig,
+	baseURL, apiKey, path string,
+	body []byte,
+	clientHeader http.Header,
+) (*http.Request, error) {
+	translator := providerapi.For(provider.APIType)
+	if translator != nil {
+		translated, nativePath, err := translator.Request(body)
+		if err != nil {
+			return nil, err
+		}
+		body, path = translated, nativePath
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = streamRequestHeaders(apiKey, clientHeader, provider.Headers)
+	req.ContentLength = int64(len(body))
+	if translator != nil {
+		translator.SetHeaders(req.Header, apiKey)
+	}
+	return req, nil
+}
+
+// chatCompletionStream returns the chat completions SSE stream of a provider's streaming
+// response body.
+func chatCompletionStream(provider *routing.ProviderConfig, body io.ReadCloser) io.ReadCloser {
+	if translator := providerapi.For(provider.APIType); translator != nil {
+		return translator.Stream(body)
+	}
+	return body
+}
+
+// chatCompletionBody returns the chat completions response (or error) body of a provider's
+// non-streaming response. Bodies that can't be translated are returned unchanged.
+func chatCompletionBody(provider *routing.ProviderConfig, statusCode int, body []byte) []byte {
+	translator := providerapi.For(provider.APIType)
+	if translator == nil {
+		return body
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return translator.Error(body)
+	}
+	if translated, err := translator.Response(body); err == nil {
+		return translated
+	}
+	return body
+}
+
+// toNativeRequest rewrites a reverse-proxied chat completions request to the provider's native API.
+func toNativeRequest(r *http.Request, translator providerapi.Translator, apiKey string) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	translated, nativePath, err := translator.Request(body)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(translated))
+	r.ContentLength = int64(len(translated))
+
+	path, query, _ := strings.Cut(nativePath, "?")
+	r.URL.Path = strings.TrimSuffix(r.URL.Path, chatCompletionsPath) + path
+	r.URL.RawPath = ""
+	r.URL.RawQuery = query
+	translator.SetHeaders(r.Header, apiKey)
+	return nil
+}
+
+// fromNativeResponse rewrites a reverse-proxied native API response to chat completions.
+func fromNativeResponse(resp *http.Response, provider *routing.ProviderConfig) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	body = chatCompletionBody(provider, resp.StatusCode, body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
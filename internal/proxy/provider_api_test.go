@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/anthropic"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/providerapi"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+func TestSendCompletionAnthropic(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "test-key" || r.Header.Get("Authorization") != "" {
+			t.Errorf("request %s with key %q, authorization %q", r.URL.Path, r.Header.Get("x-api-key"), r.Header.Get("Authorization"))
+		}
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req["system"] != "Be brief." || req["max_tokens"] != float64(anthropic.DefaultMaxTokens) {
+			t.Errorf("request body = %v", req)
+		}
+
+		if req["model"] == "claude-unknown" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type":"error","error":{"type":"not_found_error","message":"model: claude-unknown"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"Hi!"}],"stop_reason":"end_turn","usage":{"input_tokens":8,"output_tokens":3}}`)
+	}))
+	defer upstream.Close()
+
+	provider := &routing.ProviderConfig{
+		Name:    "Anthropic",
+		BaseURL: upstream.URL + "/v1",
+		APIKey:  "test-key",
+		APIType: config.APITypeAnthropic,
+	}
+	body := func(model string) []byte {
+		return []byte(`{"model":"` + model + `","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hello"}]}`)
+	}
+
+	resp, err := sendCompletion(t.Context(), http.Header{}, provider, body("claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := extractTokenUsage(resp.body)
+	if !resp.ok() || usage == nil || usage.TotalTokens != 11 || !strings.Contains(string(resp.body), `"content":"Hi!"`) {
+		t.Errorf("response %d: %s", resp.status, resp.body)
+	}
+
+	resp, err = sendCompletion(t.Context(), http.Header{}, provider, body("claude-unknown"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.status != http.StatusNotFound || string(resp.body) != `{"error":{"message":"model: claude-unknown","type":"not_found_error"}}` {
+		t.Errorf("error response %d: %s", resp.status, resp.body)
+	}
+}
+
+func TestSendCompletionGemini(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-2.5-flash:generateContent" || r.Header.Get("x-goog-api-key") != "test-key" || r.Header.Get("Authorization") != "" {
+			t.Errorf("request %s with key %q, authorization %q", r.URL.Path, r.Header.Get("x-goog-api-key"), r.Header.Get("Authorization"))
+		}
+		_, _ = io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hi!"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":8,"candidatesTokenCount":3,"totalTokenCount":11},"modelVersion":"gemini-2.5-flash","responseId":"resp_1"}`)
+	}))
+	defer upstream.Close()
+
+	provider := &routing.ProviderConfig{
+		Name:    "Google",
+		BaseURL: upstream.URL + "/v1beta",
+		APIKey:  "test-key",
+		APIType: config.APITypeGemini,
+	}
+	body := []byte(`{"model":"gemini-2.5-flash","messages":[{"role":"user","content":"Hello"}]}`)
+
+	resp, err := sendCompletion(t.Context(), http.Header{}, provider, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := extractTokenUsage(resp.body)
+	if !resp.ok() || usage == nil || usage.TotalTokens != 11 || !strings.Contains(string(resp.body), `"content":"Hi!"`) {
+		t.Errorf("response %d: %s", resp.status, resp.body)
+	}
+}
+
+func TestToNativeRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiType    config.APIType
+		body       string
+		wantPath   string
+		wantQuery  string
+		wantHeader string
+	}{
+		{
+			name:       "anthropic",
+			apiType:    config.APITypeAnthropic,
+			body:       `{"model":"claude-sonnet-4-5","stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Hello"}]}`,
+			wantPath:   "/v1/messages",
+			wantHeader: "x-api-key",
+		},
+		{
+			name:       "gemini stream",
+			apiType:    config.APITypeGemini,
+			body:       `{"model":"gemini-2.5-flash","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Hello"}]}`,
+			wantPath:   "/v1/models/gemini-2.5-flash:streamGenerateContent",
+			wantQuery:  "alt=sse",
+			wantHeader: "x-goog-api-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, _ := url.Parse("https://upstream.example/v1/chat/completions")
+			r := &http.Request{
+				URL:    target,
+				Header: http.Header{"Authorization": {"Bearer test-key"}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}
+
+			if err := toNativeRequest(r, providerapi.For(tt.apiType), "test-key"); err != nil {
+				t.Fatal(err)
+			}
+			if r.URL.Path != tt.wantPath || r.URL.RawQuery != tt.wantQuery || r.Header.Get("Authorization") != "" || r.Header.Get(tt.wantHeader) != "test-key" {
+				t.Errorf("request = %s?%s %v", r.URL.Path, r.URL.RawQuery, r.Header)
+			}
+			translated, _ := io.ReadAll(r.Body)
+			if r.ContentLength != int64(len(translated)) || strings.Contains(string(translated), "stream_options") {
+				t.Errorf("body (%d bytes) = %s", r.ContentLength, translated)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+// withProviderPreferences injects the configured OpenRouter provider preferences into the
+// request body's "provider" object. Configured preferences override the client's; other
+// client preferences (e.g. "ignore", "sort") are kept. Returns the body unchanged if no
+// preferences are configured or it can't be parsed.
+func withProviderPreferences(body []byte, prefs *config.ProviderPreferencesConfig) []byte {
+	if prefs == nil || len(body) == 0 {
+		return body
+	}
+
+	var reqBody map[string]interface{}
+	if err := json.Unmarshal(body, &reqBody); err != nil {
+		return body
+	}
+
+	provider, _ := reqBody["provider"].(map[string]interface{})
+	if provider == nil {
+		provider = make(map[string]interface{})
+	}
+	if len(prefs.Order) > 0 {
+		provider["order"] = prefs.Order
+	}
+	if prefs.AllowFallbacks != nil {
+		provider["allow_fallbacks"] = *prefs.AllowFallbacks
+	}
+	if prefs.DataCollection != "" {
+		provider["data_collection"] = prefs.DataCollection
+	}
+	reqBody["provider"] = provider
+
+	if modified, err := json.Marshal(reqBody); err == nil {
+		return modified
+	}
+	return body
+}
@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+func TestWithProviderPreferences(t *testing.T) {
+	allowFallbacks := false
+	prefs := &config.ProviderPreferencesConfig{Order: []string{"OpenAI"}, AllowFallbacks: &allowFallbacks, DataCollection: "deny"}
+
+	tests := []struct {
+		name string
+		body string
+		want map[string]interface{}
+	}{
+		{
+			name: "no client preferences",
+			body: `{"model":"openai/gpt-4.1"}`,
+			want: map[string]interface{}{"order": []interface{}{"OpenAI"}, "allow_fallbacks": false, "data_collection": "deny"},
+		},
+		{
+			name: "client preferences overridden, others kept",
+			body: `{"model":"openai/gpt-4.1","provider":{"data_collection":"allow","sort":"price"}}`,
+			want: map[string]interface{}{"order": []interface{}{"OpenAI"}, "allow_fallbacks": false, "data_collection": "deny", "sort": "price"},
+		},
+		{
+			name: "invalid client preferences replaced",
+			body: `{"model":"openai/gpt-4.1","provider":"cheapest"}`,
+			want: map[string]interface{}{"order": []interface{}{"OpenAI"}, "allow_fallbacks": false, "data_collection": "deny"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]interface{}
+			if err := json.Unmarshal(withProviderPreferences([]byte(tt.body), prefs), &got); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got["provider"])
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("provider = %s, want %s", gotJSON, wantJSON)
+			}
+			if got["model"] != "openai/gpt-4.1" {
+				t.Errorf("model changed: %v", got["model"])
+			}
+		})
+	}
+
+	body := []byte(`{"model":"x"}`)
+	if got := withProviderPreferences(body, nil); string(got) != string(body) {
+		t.Errorf("nil preferences modified body: %s", got)
+	}
+}
@@ -0,0 +1,173 @@
+package proxy
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/ratequeue"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+// requestLimitHeaders are a provider's request-count limit headers, which feed its token bucket.
+var requestLimitHeaders = []struct {
+	limit     string
+	remaining string
+	reset     string
+}{
+	{"X-Ratelimit-Limit-Requests", "X-Ratelimit-Remaining-Requests", "X-Ratelimit-Reset-Requests"},                         // OpenAI, Groq
+	{"Anthropic-Ratelimit-Requests-Limit", "Anthropic-Ratelimit-Requests-Remaining", "Anthropic-Ratelimit-Requests-Reset"}, // Anthropic
+	{"X-Ratelimit-Limit", "X-Ratelimit-Remaining", "X-Ratelimit-Reset"},                                                    // OpenRouter
+}
+
+// tokenLimitHeaders pair a provider's token limit reset header with its remaining-count header.
+// An exhausted token limit blocks the provider until the reset.
+var tokenLimitHeaders = []struct {
+	remaining string
+	reset     string
+}{
+	{"X-Ratelimit-Remaining-Tokens", "X-Ratelimit-Reset-Tokens"},                 // OpenAI, Groq
+	{"Anthropic-Ratelimit-Tokens-Remaining", "Anthropic-Ratelimit-Tokens-Reset"}, // Anthropic
+}
+
+// queuePositionEvent is the data of the queue_position stream event.
+type queuePositionEvent struct {
+	Position        int   `json:"position"`          // 1 = next to be sent
+	EstimatedWaitMs int64 `json:"estimated_wait_ms"` // Estimate; the request is rejected if not sent within the maximum wait
+}
+
+// observeRateLimit feeds an upstream response's rate-limit headers to the provider's token
+// bucket. A 429 blocks the provider for the computed retry delay. No-op if queue is nil.
+func observeRateLimit(queue *ratequeue.Queue, provider string, status int, header http.Header, now time.Time) {
+	if queue == nil {
+		return
+	}
+	if status == http.StatusTooManyRequests {
+		queue.Block(provider, upstreamRetryAfter(header, now))
+		return
+	}
+
+	for _, h := range requestLimitHeaders {
+		remaining, ok := numericHeader(header, h.remaining)
+		if !ok {
+			continue
+		}
+		resetIn, ok := parseResetHeader(header.Get(h.reset), now)
+		if !ok {
+			continue
+		}
+		limit, _ := numericHeader(header, h.limit)
+		queue.Observe(provider, limit, remaining, resetIn)
+		break
+	}
+	for _, h := range tokenLimitHeaders {
+		if remaining, ok := numericHeader(header, h.remaining); ok && remaining == 0 {
+			if resetIn, ok := parseResetHeader(header.Get(h.reset), now); ok {
+				queue.Block(provider, resetIn)
+			}
+		}
+	}
+}
+
+func numericHeader(header http.Header, name string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(header.Get(name)), 64)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// waitForRateLimit holds a chat completion while its provider is rate limited (see ratequeue),
+// in the priority lane of the user's tier.
+// Streaming clients that declared the queue-events capability get queue_position events while
+// waiting, which starts the response. It returns false when the request was rejected (with the
+// structured upstream rate limit error) or the client went away.
+func waitForRateLimit(c *gin.Context, queue *ratequeue.Queue, providerName, model string, isStreaming bool, log *logger.Logger) bool {
+	if queue == nil {
+		return true
+	}
+
+	var flusher http.Flusher
+	if isStreaming && capabilities.FromGin(c).Has(capabilities.QueueEvents) {
+		flusher, _ = c.Writer.(http.Flusher)
+	}
+	err := queue.Wait(c.Request.Context(), providerName, queueLane(c), func(position int, estimatedWait time.Duration) {
+		log.Info("request queued for rate-limited provider",
+			slog.String("provider", providerName),
+			slog.String("model", model),
+			slog.Int("position", position),
+			slog.Duration("estimated_wait", estimatedWait))
+		if flusher == nil {
+			return
+		}
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+		}
+		data, err := json.Marshal(queuePositionEvent{Position: position, EstimatedWaitMs: estimatedWait.Milliseconds()})
+		if err != nil {
+			return
+		}
+		if _, err := c.Writer.WriteString("event: queue_position\ndata: " + string(data) + "\n\n"); err == nil {
+			flusher.Flush()
+		}
+	})
+	if err == nil {
+		return true
+	}
+
+	var rejected *ratequeue.RejectedError
+	if !stderrors.As(err, &rejected) {
+		log.Info("client canceled request while queued for rate-limited provider",
+			slog.String("provider", providerName))
+		return false
+	}
+	rlErr := errors.UpstreamRateLimited(model, rejected.RetryAfter, "")
+	log.Warn("request rejected by rate limit queue",
+		slog.String("provider", providerName),
+		slog.String("model", model),
+		slog.String("reason", rejected.Error()),
+		slog.Int("retry_after", rlErr.RetryAfter))
+	if c.Writer.Written() {
+		writeStreamErrorEvent(c, http.StatusTooManyRequests, rlErr)
+		return false
+	}
+	errors.AbortWithUpstreamRateLimit(c, rlErr)
+	return false
+}
+
+// writeStreamErrorEvent ends a response that queue events already started (so the status code
+// was sent) with an error event carrying the status and error body.
+func writeStreamErrorEvent(c *gin.Context, status int, body any) {
+	if raw, ok := body.(string); ok && json.Valid([]byte(raw)) {
+		body = json.RawMessage(raw)
+	}
+	data, err := json.Marshal(map[string]any{"status": status, "error": body})
+	if err != nil {
+		return
+	}
+	if _, err := c.Writer.WriteString("event: error\ndata: " + string(data) + "\n\n"); err == nil {
+		c.Writer.Flush()
+	}
+	c.Abort()
+}
+
+// queueLane returns the rate limit queue lane of the user's tier.
+func queueLane(c *gin.Context) ratequeue.Lane {
+	if val, exists := c.Get("tierConfig"); exists {
+		if tierConfig, ok := val.(tiers.Config); ok {
+			return ratequeue.Lane{Name: tierConfig.Name, Weight: tierConfig.QueueWeight}
+		}
+	}
+	return ratequeue.Lane{}
+}
@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/ratequeue"
+	"github.com/gin-gonic/gin"
+)
+
+func newQueueTestContext(capabilityHeader string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	req := httptest.NewRequest(http.MethodPost, chatCompletionsPath, nil)
+	c.Request = req.WithContext(capabilities.WithSet(req.Context(), capabilities.Parse(capabilityHeader)))
+	return c, w
+}
+
+func TestObserveRateLimit(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		status    int
+		headers   map[string]string
+		wantAdmit bool
+	}{
+		{name: "no headers", status: http.StatusOK, wantAdmit: true},
+		{
+			name:   "requests remaining",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"X-Ratelimit-Limit-Requests":     "100",
+				"X-Ratelimit-Remaining-Requests": "5",
+				"X-Ratelimit-Reset-Requests":     "1m0s",
+			},
+			wantAdmit: true,
+		},
+		{
+			name:   "requests exhausted",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Anthropic-Ratelimit-Requests-Remaining": "0",
+				"Anthropic-Ratelimit-Requests-Reset":     now.Add(time.Minute).Format(time.RFC3339),
+			},
+		},
+		{
+			name:   "tokens exhausted",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"X-Ratelimit-Remaining-Tokens": "0",
+				"X-Ratelimit-Reset-Tokens":     "30s",
+			},
+		},
+		{name: "rate limited", status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "20"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := ratequeue.New(time.Second, 10)
+			header := http.Header{}
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			observeRateLimit(queue, "openai", tt.status, header, now)
+
+			err := queue.Wait(t.Context(), "openai", ratequeue.Lane{}, nil)
+			if admitted := err == nil; admitted != tt.wantAdmit {
+				t.Errorf("admitted = %v (%v), want %v", admitted, err, tt.wantAdmit)
+			}
+		})
+	}
+}
+
+func TestWaitForRateLimitRejects(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	queue := ratequeue.New(time.Second, 10)
+	queue.Block("openai", time.Minute)
+
+	c, w := newQueueTestContext("queue-events")
+	if waitForRateLimit(c, queue, "openai", "gpt-5", true, log) {
+		t.Fatal("request should have been rejected")
+	}
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", w.Code)
+	}
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Retry-After = %q, want 60", retryAfter)
+	}
+	if !strings.Contains(w.Body.String(), `"rate_limit_type":"upstream"`) {
+		t.Errorf("body = %s, want the upstream rate limit error", w.Body.String())
+	}
+}
+
+func TestWaitForRateLimitQueueEvents(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	tests := []struct {
+		name       string
+		caps       string
+		streaming  bool
+		wantEvents bool
+	}{
+		{name: "streaming with capability", caps: "queue-events", streaming: true, wantEvents: true},
+		{name: "streaming without capability", caps: "stream-v2", streaming: true},
+		{name: "non-streaming", caps: "queue-events"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := ratequeue.New(time.Second, 10)
+			queue.Block("openai", 30*time.Millisecond)
+
+			c, w := newQueueTestContext(tt.caps)
+			if !waitForRateLimit(c, queue, "openai", "gpt-5", tt.streaming, log) {
+				t.Fatal("request should have been admitted")
+			}
+			body := w.Body.String()
+			if got := strings.Contains(body, "event: queue_position\ndata: {\"position\":1,"); got != tt.wantEvents {
+				t.Errorf("queue events = %v, want %v (body %q)", got, tt.wantEvents, body)
+			}
+			if tt.wantEvents && w.Header().Get("Content-Type") != "text/event-stream" {
+				t.Errorf("Content-Type = %q, want text/event-stream", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/compliance"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/eternisai/enchanted-proxy/internal/wspolicy"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// realtimePath is the websocket route relaying the OpenAI Realtime API.
+	realtimePath = "/realtime"
+
+	// realtimeMaxMessageBytes bounds relayed events (OpenAI accepts client events up to 15 MB).
+	realtimeMaxMessageBytes = 16 << 20
+
+	// defaultRealtimeMaxSession caps sessions without a configured maximum duration.
+	defaultRealtimeMaxSession = 30 * time.Minute
+
+	realtimeDialTimeout  = 15 * time.Second
+	realtimeCloseTimeout = 5 * time.Second
+
+	// Audio tokens per second of the Realtime API: input audio is tokenized at 1 token per
+	// 100 ms, output audio at 1 token per 50 ms.
+	realtimeInputAudioTokensPerSecond  = 10
+	realtimeOutputAudioTokensPerSecond = 20
+)
+
+var (
+	errRealtimeSessionLimit = stderrors.New("session time limit reached")
+	errRealtimeAudioLimit   = stderrors.New("audio limit reached")
+)
+
+// realtimeUsage is the usage of a Realtime API response ("response.done" event).
+type realtimeUsage struct {
+	InputTokens       int `json:"input_tokens"`
+	OutputTokens      int `json:"output_tokens"`
+	TotalTokens       int `json:"total_tokens"`
+	InputTokenDetails struct {
+		AudioTokens int `json:"audio_tokens"`
+	} `json:"input_token_details"`
+	OutputTokenDetails struct {
+		AudioTokens int `json:"audio_tokens"`
+	} `json:"output_token_details"`
+}
+
+// audioSeconds converts the audio tokens of a response to seconds of audio.
+func (u realtimeUsage) audioSeconds() float64 {
+	return float64(u.InputTokenDetails.AudioTokens)/realtimeInputAudioTokensPerSecond +
+		float64(u.OutputTokenDetails.AudioTokens)/realtimeOutputAudioTokensPerSecond
+}
+
+// parseRealtimeUsage returns the usage of a "response.done" server event. Other events
+// (including the frequent audio deltas) are skipped without being parsed.
+func parseRealtimeUsage(event []byte) (*realtimeUsage, bool) {
+	if !bytes.Contains(event, []byte(`"response.done"`)) {
+		return nil, false
+	}
+	var done struct {
+		Type     string `json:"type"`
+		Response struct {
+			Usage *realtimeUsage `json:"usage"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(event, &done); err != nil || done.Type != "response.done" || done.Response.Usage == nil {
+		return nil, false
+	}
+	return done.Response.Usage, true
+}
+
+// audioLimitExhausted returns the rate limit error of a tier's used up audio limit.
+func audioLimitExhausted(tierConfig tiers.Config, period string) *errors.RateLimitError {
+	if period == "daily" {
+		return errors.AudioLimitExceeded(tierConfig.Name, tierConfig.DisplayName, period,
+			tierConfig.DailyAudioMinutes, tierConfig.DailyAudioMinutes, tierConfig.GetDailyAudioResetTime())
+	}
+	return errors.AudioLimitExceeded(tierConfig.Name, tierConfig.DisplayName, period,
+		tierConfig.MonthlyAudioMinutes, tierConfig.MonthlyAudioMinutes, tierConfig.GetMonthlyAudioResetTime())
+}
+
+// realtimeURL returns the Realtime API websocket URL of a provider's API base URL.
+func realtimeURL(baseURL, model string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("unsupported base URL scheme: %s", u.Scheme)
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + realtimePath
+	u.RawQuery = url.Values{"model": {model}}.Encode()
+	return u.String(), nil
+}
+
+// RealtimeHandler relays the OpenAI Realtime API (GET /realtime?model=...) over a websocket,
+// with the provider's API key. Tier model access and audio limits are enforced before the
+// upgrade (sandbox and privacy mode requests are rejected); sessions are closed at the remaining audio allowance or
+// REALTIME_MAX_SESSION_DURATION, whichever comes first. The usage of every response is logged
+// in request tracking, with audio tokens converted to audio seconds.
+func RealtimeHandler(
+	logger *logger.Logger,
+	trackingService *request_tracking.Service,
+	modelRouter *routing.ModelRouter,
+	complianceService *compliance.Service,
+	wsPolicy *wspolicy.Policy,
+	cfg *config.Config,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("realtime")
+
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			errors.Unauthorized(c, "User not authenticated", nil)
+			return
+		}
+
+		// Sandbox requests skip request tracking (no tier checks or audio limits), and the
+		// Realtime API isn't zero-retention
+		if sandbox.FromContext(c.Request.Context()) {
+			errors.BadRequest(c, "Realtime sessions are not available in sandbox mode", nil)
+			return
+		}
+		if privacy.FromContext(c.Request.Context()) {
+			errors.BadRequest(c, "Realtime sessions are not available in privacy mode", nil)
+			return
+		}
+
+		model := c.Query("model")
+		if model == "" {
+			errors.BadRequest(c, "model parameter is required", nil)
+			return
+		}
+
+		platform := c.GetHeader("X-Client-Platform")
+		if platform == "" {
+			platform = "mobile"
+		}
+		provider, err := audioProvider(modelRouter, model, platform, cfg)
+		if err != nil {
+			errors.BadRequest(c, fmt.Sprintf("No provider configured for model: %s", model), nil)
+			return
+		}
+		canonicalModel := model
+		if modelRouter != nil {
+			canonicalModel = modelRouter.ResolveAlias(model)
+		}
+
+		// Session length: the remaining audio allowance (audio is at most real time on the
+		// input side), capped at the maximum session duration
+		limit := defaultRealtimeMaxSession
+		if cfg != nil && cfg.RealtimeMaxSessionDuration > 0 {
+			limit = cfg.RealtimeMaxSessionDuration
+		}
+		var remainingAudio float64
+		var audioLimited bool
+		if val, exists := c.Get("tierConfig"); exists {
+			if tierConfig, ok := val.(tiers.Config); ok {
+				if !tierConfig.IsModelAllowed(canonicalModel) {
+					errors.AbortWithForbidden(c, errors.ModelNotAllowed(canonicalModel, tierConfig.Name, tierConfig.DisplayName, tierConfig.AllowedModels))
+					return
+				}
+				if trackingService != nil {
+					var period string
+					remainingAudio, period, err = trackingService.RemainingAudioSeconds(c.Request.Context(), userID, tierConfig)
+					if err != nil {
+						// Fail open like the request tracking audio checks
+						log.Error("failed to check remaining audio; session limited by duration only",
+							slog.String("user_id", userID),
+							slog.String("error", err.Error()))
+					} else if period != "" {
+						audioLimited = true
+						if remainingAudio <= 0 {
+							// Request tracking counts whole minutes, so less than a minute can
+							// remain unnoticed: refuse before connecting upstream
+							errors.AbortWithRateLimit(c, audioLimitExhausted(tierConfig, period))
+							return
+						}
+						limit = min(limit, time.Duration(remainingAudio*float64(time.Second)))
+					}
+				}
+			}
+		}
+
+		if complianceService != nil {
+			if decision := complianceService.CheckRoute(c, provider.Name, canonicalModel); !decision.Allowed {
+				errors.AbortWithForbidden(c, errors.RegionRestricted(decision.Country, decision.Rule))
+				return
+			}
+		}
+
+		upstreamURL, err := realtimeURL(provider.BaseURL, provider.Model)
+		if err != nil {
+			log.Error("invalid realtime provider URL",
+				slog.String("base_url", provider.BaseURL),
+				slog.String("error", err.Error()))
+			errors.BadRequest(c, "Invalid URL format", nil)
+			return
+		}
+
+		// Connect upstream before upgrading, so failures get a regular HTTP error
+		header := http.Header{}
+		header.Set("Authorization", "Bearer "+provider.APIKey)
+		if beta := c.GetHeader("OpenAI-Beta"); beta != "" {
+			header.Set("OpenAI-Beta", beta)
+		}
+		dialer := *websocket.DefaultDialer
+		dialer.HandshakeTimeout = realtimeDialTimeout
+
+		metrics.RecordUpstreamAttempt(provider.Name, canonicalModel)
+		start := time.Now()
+		upstream, resp, err := dialer.DialContext(c.Request.Context(), upstreamURL, header)
+		if err != nil {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+				metrics.RecordUpstreamResponse(provider.Name, canonicalModel, status, time.Since(start).Seconds())
+			} else {
+				metrics.RecordUpstreamError(provider.Name, canonicalModel, err)
+			}
+			log.Error("failed to connect to realtime API",
+				slog.String("user_id", userID),
+				slog.String("model", canonicalModel),
+				slog.Int("status", status),
+				slog.String("error", err.Error()))
+			errors.BadGateway(c, "Failed to connect to the realtime API", nil)
+			return
+		}
+		defer upstream.Close()
+		metrics.RecordUpstreamResponse(provider.Name, canonicalModel, resp.StatusCode, time.Since(start).Seconds())
+
+		client, err := wsPolicy.Upgrade(c, wspolicy.SubprotocolRealtime)
+		if err != nil {
+			log.Error("websocket upgrade failed",
+				slog.String("user_id", userID),
+				slog.String("error", err.Error()))
+			return
+		}
+		defer client.Close()
+
+		log.Info("realtime session started",
+			slog.String("user_id", userID),
+			slog.String("model", canonicalModel),
+			slog.String("provider", provider.Name),
+			slog.Duration("limit", limit))
+
+		done := metrics.TrackActiveRequest(provider.Name, canonicalModel)
+		defer done()
+
+		ctx, cancel := context.WithCancelCause(context.WithoutCancel(c.Request.Context()))
+		defer cancel(nil)
+		ctx, cancelTimeout := context.WithTimeoutCause(ctx, limit, errRealtimeSessionLimit)
+		defer cancelTimeout()
+
+		var mu sync.Mutex
+		var audioUsed float64
+		onUsage := func(usage *realtimeUsage) {
+			seconds := usage.audioSeconds()
+			logRealtimeUsage(ctx, log, trackingService, userID, canonicalModel, provider, usage, seconds)
+
+			mu.Lock()
+			audioUsed += seconds
+			exhausted := audioLimited && audioUsed >= remainingAudio
+			mu.Unlock()
+			if exhausted {
+				cancel(errRealtimeAudioLimit)
+			}
+		}
+
+		reason := relayRealtime(ctx, client, upstream, onUsage)
+		log.Info("realtime session ended",
+			slog.String("user_id", userID),
+			slog.String("model", canonicalModel),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("reason", reason))
+	}
+}
+
+// relayRealtime relays messages between the client and the upstream until either side closes
+// or ctx is done, then closes the other side. Server events are passed to onUsage when they
+// carry response usage. Returns why the session ended.
+func relayRealtime(ctx context.Context, client, upstream *websocket.Conn, onUsage func(*realtimeUsage)) string {
+	client.SetReadLimit(realtimeMaxMessageBytes)
+	upstream.SetReadLimit(realtimeMaxMessageBytes)
+
+	type ended struct {
+		side string
+		err  error
+	}
+	results := make(chan ended, 2)
+	go func() {
+		results <- ended{"client", relayMessages(upstream, client, nil)}
+	}()
+	go func() {
+		results <- ended{"upstream", relayMessages(client, upstream, func(event []byte) {
+			if usage, ok := parseRealtimeUsage(event); ok && onUsage != nil {
+				onUsage(usage)
+			}
+		})}
+	}()
+
+	var reason string
+	select {
+	case result := <-results:
+		// Forward the close to the other side
+		code, text := websocket.CloseNormalClosure, ""
+		var closeErr *websocket.CloseError
+		if stderrors.As(result.err, &closeErr) && closeErr.Code != websocket.CloseNoStatusReceived && closeErr.Code != websocket.CloseAbnormalClosure {
+			code, text = closeErr.Code, closeErr.Text
+		}
+		other := upstream
+		if result.side == "upstream" {
+			other = client
+		}
+		writeClose(other, code, text)
+		reason = result.side + " closed"
+	case <-ctx.Done():
+		cause := context.Cause(ctx)
+		writeClose(client, websocket.ClosePolicyViolation, cause.Error())
+		writeClose(upstream, websocket.CloseNormalClosure, "")
+		reason = cause.Error()
+	}
+
+	// Unblock the remaining reader
+	_ = client.Close()
+	_ = upstream.Close()
+	<-results
+	return reason
+}
+
+// relayMessages copies messages from src to dst until an error, passing text messages to
+// inspect first.
+func relayMessages(dst, src *websocket.Conn, inspect func([]byte)) error {
+	for {
+		messageType, data, err := src.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if inspect != nil && messageType == websocket.TextMessage {
+			inspect(data)
+		}
+		if err := dst.WriteMessage(messageType, data); err != nil {
+			return err
+		}
+	}
+}
+
+// writeClose sends a close message (safe concurrently with the relay's writes).
+func writeClose(conn *websocket.Conn, code int, text string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(realtimeCloseTimeout))
+}
+
+// logRealtimeUsage records the usage of a Realtime API response. Plan tokens come from the
+// tokens at the provider's multiplier; audio seconds count towards the audio limits.
+func logRealtimeUsage(
+	ctx context.Context,
+	log *logger.Logger,
+	trackingService *request_tracking.Service,
+	userID, model string,
+	provider *routing.ProviderConfig,
+	usage *realtimeUsage,
+	audioSeconds float64,
+) {
+	if trackingService == nil {
+		return
+	}
+
+	multiplier := provider.TokenMultiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	info := request_tracking.RequestInfo{
+		UserID:       userID,
+		Endpoint:     realtimePath,
+		Model:        model,
+		Provider:     provider.Name,
+		AudioSeconds: &audioSeconds,
+	}
+	tokenData := &request_tracking.TokenUsageWithMultiplier{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.TotalTokens,
+		Multiplier:       multiplier,
+		PlanTokens:       int(float64(usage.TotalTokens) * multiplier),
+	}
+	if err := trackingService.LogRequestWithPlanTokensAsync(ctx, info, tokenData); err != nil {
+		log.Error("failed to queue realtime usage log",
+			slog.String("user_id", userID),
+			slog.String("model", model),
+			slog.Int("total_tokens", usage.TotalTokens),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,224 @@
+package proxy
+
+import (
+	"context"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestRealtimeURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+		wantErr bool
+	}{
+		{"https://api.openai.com/v1", "wss://api.openai.com/v1/realtime?model=gpt-realtime", false},
+		{"http://localhost:8000/v1/", "ws://localhost:8000/v1/realtime?model=gpt-realtime", false},
+		{"ftp://example.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := realtimeURL(tt.baseURL, "gpt-realtime")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("realtimeURL(%q) = %q, %v, want %q", tt.baseURL, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRealtimeUsage(t *testing.T) {
+	done := `{"type":"response.done","response":{"usage":{"total_tokens":300,"input_tokens":100,"output_tokens":200,` +
+		`"input_token_details":{"audio_tokens":50,"text_tokens":50},"output_token_details":{"audio_tokens":200}}}}`
+
+	usage, ok := parseRealtimeUsage([]byte(done))
+	if !ok || usage.TotalTokens != 300 || usage.InputTokens != 100 || usage.OutputTokens != 200 {
+		t.Fatalf("parseRealtimeUsage() = %+v, %v", usage, ok)
+	}
+	// 50 input audio tokens = 5s, 200 output audio tokens = 10s
+	if got := usage.audioSeconds(); got != 15 {
+		t.Errorf("audioSeconds() = %v, want 15", got)
+	}
+
+	for _, event := range []string{
+		`{"type":"response.audio.delta","delta":"AAAA"}`,
+		`{"type":"response.done","response":{}}`,
+		`{"type":"response.done"`,
+	} {
+		if _, ok := parseRealtimeUsage([]byte(event)); ok {
+			t.Errorf("parseRealtimeUsage(%s) reported usage", event)
+		}
+	}
+}
+
+// realtimePair starts a fake Realtime API and a relay in front of it, and connects a client to
+// the relay. The fake API echoes client events and answers "response.create" with a
+// "response.done" carrying usage.
+func realtimePair(t *testing.T, ctx context.Context, onUsage func(*realtimeUsage)) (*websocket.Conn, <-chan string) {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if strings.Contains(string(data), "response.create") {
+				data = []byte(`{"type":"response.done","response":{"usage":{"total_tokens":30,"output_token_details":{"audio_tokens":20}}}}`)
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	reasons := make(chan string, 1)
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(api.URL, "http"), nil)
+		if err != nil {
+			t.Errorf("dial fake API: %v", err)
+			return
+		}
+		client, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		reasons <- relayRealtime(ctx, client, upstream, onUsage)
+	}))
+	t.Cleanup(relay.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(relay.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial relay: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, reasons
+}
+
+func TestRelayRealtime(t *testing.T) {
+	usages := make(chan *realtimeUsage, 1)
+	client, reasons := realtimePair(t, context.Background(), func(u *realtimeUsage) { usages <- u })
+
+	for _, event := range []string{`{"type":"session.update"}`, `{"type":"response.create"}`} {
+		if err := client.WriteMessage(websocket.TextMessage, []byte(event)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if _, _, err := client.ReadMessage(); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+	}
+
+	select {
+	case usage := <-usages:
+		if usage.TotalTokens != 30 || usage.audioSeconds() != 1 {
+			t.Errorf("usage = %+v, want 30 tokens and 1s of audio", usage)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("usage not reported")
+	}
+
+	_ = client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	select {
+	case reason := <-reasons:
+		if reason != "client closed" {
+			t.Errorf("reason = %q, want client closed", reason)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not end")
+	}
+}
+
+func TestRelayRealtime_Limit(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	client, reasons := realtimePair(t, ctx, nil)
+
+	cancel(errRealtimeAudioLimit)
+
+	_, _, err := client.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !stderrors.As(err, &closeErr) || closeErr.Code != websocket.ClosePolicyViolation || closeErr.Text != errRealtimeAudioLimit.Error() {
+		t.Errorf("client read error = %v, want close %d %q", err, websocket.ClosePolicyViolation, errRealtimeAudioLimit)
+	}
+	select {
+	case reason := <-reasons:
+		if reason != errRealtimeAudioLimit.Error() {
+			t.Errorf("reason = %q, want %q", reason, errRealtimeAudioLimit)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not end")
+	}
+}
+
+// audioUsage reports audioSeconds of audio today and this month; other Querier methods are
+// not used.
+type audioUsage struct {
+	pgdb.Querier
+	audioSeconds float64
+}
+
+func (q *audioUsage) GetUserAudioSecondsToday(context.Context, string) (float64, error) {
+	return q.audioSeconds, nil
+}
+
+func (q *audioUsage) GetUserAudioSecondsThisMonth(context.Context, string) (float64, error) {
+	return q.audioSeconds, nil
+}
+
+func TestRealtimeHandlerRejectsBeforeConnecting(t *testing.T) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{RequestTrackingWorkerPoolSize: 1, RequestTrackingBufferSize: 10, RequestTrackingTimeoutSeconds: 5}
+	defer func() { config.AppConfig = previous }()
+
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	// 30 audio minutes a month, used up
+	tierConfig := tiers.Config{Name: "free", DisplayName: "Free", MonthlyAudioMinutes: 30}
+	trackingService := request_tracking.NewService(&audioUsage{audioSeconds: 30 * 60}, log)
+	defer trackingService.Shutdown(context.Background()) //nolint:errcheck
+	handler := RealtimeHandler(log, trackingService, nil, nil, nil, &config.Config{OpenAIAPIKey: "sk-test"})
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		name    string
+		context func(context.Context) context.Context
+		want    int
+	}{
+		{"sandbox", sandbox.WithContext, http.StatusBadRequest},
+		{"privacy mode", privacy.WithContext, http.StatusBadRequest},
+		{"audio exhausted", func(ctx context.Context) context.Context { return ctx }, http.StatusTooManyRequests},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/realtime", func(c *gin.Context) {
+				c.Set(string(auth.UserIDKey), "user-1")
+				c.Set("tierConfig", tierConfig)
+				c.Request = c.Request.WithContext(tc.context(c.Request.Context()))
+			}, handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/realtime?model=gpt-realtime", nil))
+			if w.Code != tc.want {
+				t.Errorf("GET /realtime = %d, want %d: %s", w.Code, tc.want, w.Body.String())
+			}
+		})
+	}
+}
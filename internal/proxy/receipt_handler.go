@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/gin-gonic/gin"
+)
+
+// ReceiptRequest is the body of POST /api/v1/chats/:chatId/messages/:messageId/receipt.
+type ReceiptRequest struct {
+	State messaging.ReceiptState `json:"state"` // "delivered" or "read"
+}
+
+// ReceiptResponse is the message's delivery state after the acknowledgement.
+type ReceiptResponse struct {
+	MessageID   string     `json:"messageId"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	ReadAt      *time.Time `json:"readAt,omitempty"`
+}
+
+// AcknowledgeMessageHandler handles POST /api/v1/chats/:chatId/messages/:messageId/receipt
+// Records that the client delivered or read an assistant message. Completion push
+// notifications are skipped for messages acknowledged shortly after they complete.
+func AcknowledgeMessageHandler(logger *logger.Logger, messageService *messaging.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("receipts")
+
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			errors.Unauthorized(c, "Authentication required", nil)
+			return
+		}
+
+		chatID := c.Param("chatId")
+		messageID := c.Param("messageId")
+		if chatID == "" || messageID == "" || len(chatID) > maxChatIDLength || len(messageID) > maxMessageIDLength {
+			errors.BadRequest(c, "invalid chatId or messageId", nil)
+			return
+		}
+
+		var req ReceiptRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+			return
+		}
+
+		msg, err := messageService.AcknowledgeMessage(c.Request.Context(), userID, chatID, messageID, req.State)
+		switch {
+		case stderrors.Is(err, messaging.ErrInvalidReceiptState), stderrors.Is(err, messaging.ErrNotAssistantMessage):
+			errors.BadRequest(c, err.Error(), nil)
+			return
+		case stderrors.Is(err, messaging.ErrMessageNotFound):
+			errors.NotFound(c, "Message not found", nil)
+			return
+		case err != nil:
+			log.Error("failed to acknowledge message",
+				slog.String("user_id", userID),
+				slog.String("chat_id", chatID),
+				slog.String("message_id", messageID),
+				slog.String("error", err.Error()))
+			errors.Internal(c, "Failed to save receipt", nil)
+			return
+		}
+
+		resp := ReceiptResponse{MessageID: messageID}
+		if !msg.DeliveredAt.IsZero() {
+			resp.DeliveredAt = &msg.DeliveredAt
+		}
+		if !msg.ReadAt.IsZero() {
+			resp.ReadAt = &msg.ReadAt
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
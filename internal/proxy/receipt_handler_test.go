@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcknowledgeMessageHandlerValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	tests := []struct {
+		name       string
+		userID     string
+		body       string
+		wantStatus int
+	}{
+		{"unauthenticated", "", `{"state":"read"}`, http.StatusUnauthorized},
+		{"malformed body", "user-1", `{"state":`, http.StatusBadRequest},
+		{"unknown state", "user-1", `{"state":"seen"}`, http.StatusBadRequest},
+		{"missing state", "user-1", `{}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.userID != "" {
+					c.Set(string(auth.UserIDKey), tt.userID)
+				}
+			})
+			// Validation fails before Firestore is used
+			router.POST("/chats/:chatId/messages/:messageId/receipt", AcknowledgeMessageHandler(log, &messaging.Service{}))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/chats/chat-1/messages/msg-1/receipt", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
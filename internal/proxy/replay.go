@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/compliance"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/resilience"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/streaming"
+	"github.com/gin-gonic/gin"
+)
+
+// replayedProviderKey is the gin context key of the provider that failed a retried message.
+const replayedProviderKey = "replayedProvider"
+
+// replayHeaders are the client headers that shape a chat completion, stored with the request
+// and restored on retry when the retry request doesn't set them.
+var replayHeaders = []string{
+	"X-Anonymize",
+	"X-Client-Platform",
+	"X-Encryption-Enabled",
+	"X-Reasoning-Visibility",
+	"X-User-Message-ID",
+}
+
+// ReplayStore keeps the chat completion requests of recent messages in the shared cache (Redis
+// when configured), so a failed generation can be retried without the client uploading the
+// conversation again. Privacy mode requests are never stored.
+type ReplayStore struct {
+	cache *cache.Cache
+}
+
+// replayRequest is a stored chat completion request.
+type replayRequest struct {
+	UserID   string      `json:"user_id"`
+	Path     string      `json:"path"`
+	Body     []byte      `json:"body"`
+	Header   http.Header `json:"header"`
+	Provider string      `json:"provider"` // Provider the request was routed to
+}
+
+// NewReplayStore creates a store keeping requests for ttl.
+func NewReplayStore(sharedCache *cache.Cache, ttl time.Duration) *ReplayStore {
+	return &ReplayStore{cache: sharedCache.Namespace("replay", ttl)}
+}
+
+// save stores the request of a message, as received from the client.
+func (s *ReplayStore) save(ctx context.Context, c *gin.Context, userID, chatID, messageID, provider string, body []byte) error {
+	header := make(http.Header)
+	for _, name := range replayHeaders {
+		if value := c.GetHeader(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	return s.cache.SetJSON(ctx, chatID+":"+messageID, replayRequest{
+		UserID:   userID,
+		Path:     c.Request.URL.Path,
+		Body:     body,
+		Header:   header,
+		Provider: provider,
+	})
+}
+
+// load returns the stored request of a message, and false if there is none.
+func (s *ReplayStore) load(ctx context.Context, chatID, messageID string) (*replayRequest, bool) {
+	var req replayRequest
+	if !s.cache.GetJSON(ctx, chatID+":"+messageID, &req) {
+		return nil, false
+	}
+	return &req, true
+}
+
+// saveReplayRequest stores a chat completion for retry when it belongs to a message. Failures
+// are logged; the request proceeds without a retry.
+func saveReplayRequest(c *gin.Context, replays *ReplayStore, log *logger.Logger, provider string, body []byte) {
+	if replays == nil {
+		return
+	}
+	userID, _ := auth.GetUserID(c)
+	chatID, messageID := c.GetHeader("X-Chat-ID"), c.GetHeader("X-Message-ID")
+	if chatID == "" {
+		chatID = c.GetString("bodyChatId")
+	}
+	if messageID == "" {
+		messageID = c.GetString("bodyMessageId")
+	}
+	if userID == "" || chatID == "" || messageID == "" {
+		return
+	}
+	if err := replays.save(c.Request.Context(), c, userID, chatID, messageID, provider, body); err != nil {
+		log.Warn("failed to store request for retry",
+			slog.String("error", err.Error()),
+			slog.String("chat_id", chatID),
+			slog.String("message_id", messageID))
+	}
+}
+
+// replayRoute returns the provider for a retried message: the first available provider of the
+// failover chain when provider is the one that failed it, otherwise provider.
+func replayRoute(c *gin.Context, provider *routing.ProviderConfig, modelRouter *routing.ModelRouter, complianceService *compliance.Service, breakers *resilience.Breakers, canonicalModel string) *routing.ProviderConfig {
+	if failed := c.GetString(replayedProviderKey); failed == "" || failed != provider.Name {
+		return provider
+	}
+	for _, fallback := range failoverProviders(c, provider, modelRouter, complianceService, canonicalModel) {
+		if breakers == nil || breakers.Available(fallback.Name) {
+			return fallback
+		}
+	}
+	return provider
+}
+
+// RetryMessageHandler handles POST /api/v1/chats/:chatId/messages/:messageId/retry
+// Retries the generation of a failed message by replaying its stored chat completion request.
+// The handler restores the request and passes it on to the proxy handler chain, which skips
+// the provider that failed it. Returns 404 once the stored request expired, and 409 while the
+// message is still being generated.
+func RetryMessageHandler(
+	logger *logger.Logger,
+	streamManager *streaming.StreamManager,
+	chatStore messaging.Store,
+	replays *ReplayStore,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("retry")
+
+		userID, chatID, messageID, ok := authorizeStreamRequest(c, log, chatStore)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		var req *replayRequest
+		if replays != nil {
+			req, ok = replays.load(c.Request.Context(), chatID, messageID)
+		}
+		if req == nil || !ok || req.UserID != userID {
+			errors.AbortWithNotFound(c, "No request to retry for this message", map[string]interface{}{
+				"message_id": messageID,
+			})
+			return
+		}
+
+		if !streamManager.DiscardCompletedSession(chatID, messageID) {
+			errors.AbortWithConflict(c, "The message is still being generated", map[string]interface{}{
+				"message_id": messageID,
+			})
+			return
+		}
+
+		log.Info("retrying message generation",
+			slog.String("chat_id", chatID),
+			slog.String("message_id", messageID),
+			slog.String("failed_provider", req.Provider))
+
+		c.Request.Method = http.MethodPost
+		c.Request.URL.Path = req.Path
+		c.Request.URL.RawPath = ""
+		c.Request.Body = io.NopCloser(bytes.NewReader(req.Body))
+		c.Request.ContentLength = int64(len(req.Body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		for name, values := range req.Header {
+			if c.GetHeader(name) == "" && len(values) > 0 {
+				c.Request.Header.Set(name, values[0])
+			}
+		}
+		c.Request.Header.Set("X-Chat-ID", chatID)
+		c.Request.Header.Set("X-Message-ID", messageID)
+		c.Set(replayedProviderKey, req.Provider)
+
+		c.Next()
+	}
+}
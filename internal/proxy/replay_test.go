@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/streaming"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRetryMessageHandler(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	streamManager := streaming.NewStreamManager(nil, log)
+	defer streamManager.Shutdown()
+	replays := NewReplayStore(cache.New(cache.NewMemory(100), log), time.Minute)
+
+	type replayed struct {
+		path, body, platform, failedProvider string
+	}
+	var got replayed
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(string(auth.UserIDKey), c.GetHeader("X-Test-User"))
+		c.Next()
+	})
+	router.POST("/chat/completions", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		saveReplayRequest(c, replays, log, "openai", body)
+	})
+	router.POST("/api/v1/chats/:chatId/messages/:messageId/retry", RetryMessageHandler(log, streamManager, nil, replays), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		got = replayed{c.Request.URL.Path, string(body), c.GetHeader("X-Client-Platform"), c.GetString(replayedProviderKey)}
+	})
+
+	const body = `{"model":"gpt-5","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Test-User", "user-1")
+	req.Header.Set("X-Chat-ID", "chat-1")
+	req.Header.Set("X-Message-ID", "msg-1")
+	req.Header.Set("X-Client-Platform", "desktop")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	retry := func(user, messageID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/chats/chat-1/messages/"+messageID+"/retry", nil)
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := retry("user-1", "msg-2"); code != http.StatusNotFound {
+		t.Errorf("retry of a message without a stored request = %d, want 404", code)
+	}
+	if code := retry("user-2", "msg-1"); code != http.StatusNotFound {
+		t.Errorf("retry by another user = %d, want 404", code)
+	}
+
+	session, _ := streamManager.CreatePendingSession(context.Background(), "chat-1", "msg-1")
+	if code := retry("user-1", "msg-1"); code != http.StatusConflict {
+		t.Errorf("retry while generating = %d, want 409", code)
+	}
+
+	session.ForceComplete(errors.New("upstream returned 502"))
+	if code := retry("user-1", "msg-1"); code != http.StatusOK {
+		t.Fatalf("retry of a failed message = %d, want 200", code)
+	}
+	if want := (replayed{"/chat/completions", body, "desktop", "openai"}); got != want {
+		t.Errorf("replayed request = %+v, want %+v", got, want)
+	}
+	if streamManager.GetSession("chat-1", "msg-1") != nil {
+		t.Error("the failed session was not discarded")
+	}
+}
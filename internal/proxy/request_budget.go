@@ -0,0 +1,43 @@
+package proxy
+
+import (
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+// requestBudgetGrace is how long the upstream request outlives the request time budget, so the
+// session can stop the stream with a timeout event before a hung read is cut off.
+const requestBudgetGrace = 5 * time.Second
+
+// requestDeadline returns when the overall time budget of a streaming request runs out,
+// counted from when the request arrived. Zero time means the request has no budget.
+func requestDeadline(c *gin.Context, cfg *config.Config, provider *routing.ProviderConfig, start time.Time) time.Time {
+	var tierSeconds int
+	if val, exists := c.Get("tierConfig"); exists {
+		if tierConfig, ok := val.(tiers.Config); ok {
+			tierSeconds = tierConfig.MaxRequestSeconds
+		}
+	}
+
+	seconds := requestBudgetSeconds(cfg.RequestTimeoutBudgetSeconds, tierSeconds, provider.MaxRequestSeconds())
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return start.Add(time.Duration(seconds) * time.Second)
+}
+
+// requestBudgetSeconds returns the strictest of the configured budgets, ignoring unset (zero
+// or negative) ones. Returns 0 if none is set.
+func requestBudgetSeconds(budgets ...int) int {
+	strictest := 0
+	for _, budget := range budgets {
+		if budget > 0 && (strictest == 0 || budget < strictest) {
+			strictest = budget
+		}
+	}
+	return strictest
+}
@@ -0,0 +1,27 @@
+package proxy
+
+import "testing"
+
+func TestRequestBudgetSeconds(t *testing.T) {
+	tests := []struct {
+		name    string
+		budgets []int
+		want    int
+	}{
+		{name: "none set", budgets: []int{0, 0, 0}, want: 0},
+		{name: "global only", budgets: []int{480, 0, 0}, want: 480},
+		{name: "tier stricter", budgets: []int{480, 300, 0}, want: 300},
+		{name: "model stricter", budgets: []int{480, 300, 120}, want: 120},
+		{name: "looser override ignored", budgets: []int{480, 900, 0}, want: 480},
+		{name: "global disabled", budgets: []int{0, 300, 600}, want: 300},
+		{name: "negative ignored", budgets: []int{-1, 0, 60}, want: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requestBudgetSeconds(tt.budgets...); got != tt.want {
+				t.Errorf("requestBudgetSeconds(%v) = %d, want %d", tt.budgets, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+// proxyRequestHeaders are client headers meant for the proxy (client IP, platform, chat metadata,
+// encryption flag) that are never forwarded to a provider.
+var proxyRequestHeaders = []string{
+	"X-Forwarded-For",
+	"X-Real-Ip",
+	"X-Client-Platform",
+	"X-Client-Version",
+	"X-Encryption-Enabled",
+	"X-Chat-ID",
+	"X-Message-ID",
+}
+
+// stripProxyHeaders removes the proxy's own request headers before forwarding.
+func stripProxyHeaders(h http.Header) {
+	for _, name := range proxyRequestHeaders {
+		h.Del(name)
+	}
+}
+
+// applyRequestHeaders applies a provider's header policy to the upstream request headers h.
+// client holds the headers of the client request (may be h itself for reverse-proxied requests).
+// A nil policy keeps the proxy defaults.
+func applyRequestHeaders(h, client http.Header, policy *config.RequestHeadersConfig) {
+	if policy == nil {
+		return
+	}
+
+	for _, name := range policy.Forward {
+		if values := client.Values(name); len(values) > 0 {
+			h[name] = append([]string(nil), values...)
+		}
+	}
+
+	for _, name := range policy.Strip {
+		h.Del(name)
+	}
+
+	switch policy.UserAgent {
+	case "":
+	case config.ForwardUserAgent:
+		if userAgent := client.Get("User-Agent"); userAgent != "" {
+			h.Set("User-Agent", userAgent)
+		} else {
+			h.Del("User-Agent")
+		}
+	default:
+		h.Set("User-Agent", policy.UserAgent)
+	}
+
+	for name, value := range policy.Add {
+		h.Set(name, value)
+	}
+}
+
+// streamRequestHeaders returns the headers of a direct streaming request to a provider.
+func streamRequestHeaders(apiKey string, client http.Header, policy *config.RequestHeadersConfig) http.Header {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer "+apiKey)
+	h.Set("Content-Type", "application/json")
+	h.Set("Accept", "text/event-stream")
+	h.Set("User-Agent", "Mozilla/5.0")
+	h.Set("Accept-Encoding", "identity")
+	applyRequestHeaders(h, client, policy)
+	return h
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+func TestRequestHeadersConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.RequestHeadersConfig
+		wantErr string
+	}{
+		{name: "valid", cfg: config.RequestHeadersConfig{UserAgent: "enchanted-proxy", Forward: []string{"openai-beta"}, Strip: []string{" x-stainless-os "}, Add: map[string]string{"x-title": "Enchanted"}}},
+		{name: "empty name", cfg: config.RequestHeadersConfig{Strip: []string{" "}}, wantErr: "invalid header name"},
+		{name: "forward authorization", cfg: config.RequestHeadersConfig{Forward: []string{"authorization"}}, wantErr: "managed by the proxy"},
+		{name: "add host", cfg: config.RequestHeadersConfig{Add: map[string]string{"Host": "example.com"}}, wantErr: "managed by the proxy"},
+		{name: "user agent via add", cfg: config.RequestHeadersConfig{Add: map[string]string{"user-agent": "x"}}, wantErr: "managed by the proxy"},
+		{name: "header injection", cfg: config.RequestHeadersConfig{Add: map[string]string{"X-Title": "a\r\nX-Other: b"}}, wantErr: "invalid value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Validate() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.cfg.Forward[0] != "Openai-Beta" || tt.cfg.Strip[0] != "X-Stainless-Os" || tt.cfg.Add["X-Title"] != "Enchanted" {
+				t.Errorf("names not canonicalized: %+v", tt.cfg)
+			}
+		})
+	}
+}
+
+func TestApplyRequestHeaders(t *testing.T) {
+	client := func() http.Header {
+		h := http.Header{}
+		h.Set("User-Agent", "Enchanted/2.1 iOS")
+		h.Set("Openai-Beta", "assistants=v2")
+		h.Set("X-Stainless-Os", "iOS")
+		h.Set("X-Chat-ID", "chat-1")
+		return h
+	}
+	policy := &config.RequestHeadersConfig{
+		UserAgent: config.ForwardUserAgent,
+		Forward:   []string{"Openai-Beta"},
+		Strip:     []string{"X-Stainless-Os"},
+		Add:       map[string]string{"X-Title": "Enchanted"},
+	}
+
+	t.Run("stream request", func(t *testing.T) {
+		h := streamRequestHeaders("sk-test", client(), policy)
+		want := map[string]string{
+			"Authorization":   "Bearer sk-test",
+			"User-Agent":      "Enchanted/2.1 iOS",
+			"Openai-Beta":     "assistants=v2",
+			"X-Title":         "Enchanted",
+			"Accept-Encoding": "identity",
+			"X-Stainless-Os":  "",
+			"X-Chat-Id":       "",
+		}
+		for name, value := range want {
+			if got := h.Get(name); got != value {
+				t.Errorf("%s = %q, want %q", name, got, value)
+			}
+		}
+	})
+
+	t.Run("reverse-proxied request", func(t *testing.T) {
+		h := client()
+		stripProxyHeaders(h)
+		applyRequestHeaders(h, h, &config.RequestHeadersConfig{UserAgent: "enchanted-proxy", Strip: []string{"X-Stainless-Os"}})
+		if h.Get("User-Agent") != "enchanted-proxy" || h.Get("Openai-Beta") == "" || h.Get("X-Stainless-Os") != "" || h.Get("X-Chat-Id") != "" {
+			t.Errorf("unexpected headers: %v", h)
+		}
+	})
+
+	t.Run("nil policy keeps defaults", func(t *testing.T) {
+		h := streamRequestHeaders("sk-test", client(), nil)
+		if h.Get("User-Agent") != "Mozilla/5.0" || h.Get("Openai-Beta") != "" {
+			t.Errorf("unexpected headers: %v", h)
+		}
+	})
+}
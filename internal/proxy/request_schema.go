@@ -0,0 +1,476 @@
+package proxy
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/structuredoutput"
+)
+
+// Paths whose JSON bodies are checked by validateRequestSchema.
+const (
+	chatCompletionsPath = "/chat/completions"
+	responsesPath       = "/responses"
+)
+
+// chatRoles are the message roles accepted by /chat/completions.
+var chatRoles = []string{"system", "developer", "user", "assistant", "tool", "function"}
+
+// responsesRoles are the message roles accepted in /responses input.
+var responsesRoles = []string{"system", "developer", "user", "assistant"}
+
+// requestSchemaError describes the first part of a request body that violates the schema.
+type requestSchemaError struct {
+	Field  string // Path of the offending value, e.g. "messages[2].content[0].text"
+	Reason string
+}
+
+func (e *requestSchemaError) Error() string {
+	if e.Field == "" {
+		return e.Reason
+	}
+	return e.Field + ": " + e.Reason
+}
+
+func schemaErrorf(field, format string, args ...interface{}) *requestSchemaError {
+	return &requestSchemaError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+// validateRequestSchema checks the structure of /chat/completions and /responses bodies
+// (messages, roles and content parts) so malformed payloads are rejected with a precise
+// reason instead of surfacing as an upstream error. Other paths are not checked. Fields the
+// proxy doesn't know are passed through to the provider.
+func validateRequestSchema(path string, body []byte) *requestSchemaError {
+	if path != chatCompletionsPath && path != responsesPath {
+		return nil
+	}
+
+	if len(body) == 0 {
+		return &requestSchemaError{Reason: "request body is required"}
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		var syntaxErr *json.SyntaxError
+		if stderrors.As(err, &syntaxErr) {
+			return schemaErrorf("", "invalid JSON at offset %d: %v", syntaxErr.Offset, err)
+		}
+		return schemaErrorf("", "invalid JSON: %v", err)
+	}
+	req, ok := raw.(map[string]interface{})
+	if !ok {
+		return schemaErrorf("", "request body must be a JSON object, got %s", jsonType(raw))
+	}
+
+	if err := checkOptionalType(req, "", "model", "string"); err != nil {
+		return err
+	}
+	if err := checkOptionalType(req, "", "stream", "boolean"); err != nil {
+		return err
+	}
+	if err := validateTools(req["tools"]); err != nil {
+		return err
+	}
+
+	if path == chatCompletionsPath {
+		if err := validateResponseFormat(req["response_format"]); err != nil {
+			return err
+		}
+		return validateChatMessages(req)
+	}
+	return validateResponsesInput(req)
+}
+
+// validateResponseFormat checks the response_format of a chat completions request, including
+// the JSON schema of json_schema formats.
+func validateResponseFormat(value interface{}) *requestSchemaError {
+	if _, err := structuredoutput.ParseResponseFormat(value); err != nil {
+		return schemaErrorf(joinField("response_format", err.Field), "%s", err.Reason)
+	}
+	return nil
+}
+
+// validateChatMessages checks the messages array of a chat completions request.
+func validateChatMessages(req map[string]interface{}) *requestSchemaError {
+	value, ok := req["messages"]
+	if !ok || value == nil {
+		return schemaErrorf("messages", "is required")
+	}
+	messages, ok := value.([]interface{})
+	if !ok {
+		return schemaErrorf("messages", "must be an array, got %s", jsonType(value))
+	}
+	if len(messages) == 0 {
+		return schemaErrorf("messages", "must contain at least one message")
+	}
+
+	for i, item := range messages {
+		field := fmt.Sprintf("messages[%d]", i)
+		msg, ok := item.(map[string]interface{})
+		if !ok {
+			return schemaErrorf(field, "must be an object, got %s", jsonType(item))
+		}
+
+		role, err := checkRole(msg, field, chatRoles)
+		if err != nil {
+			return err
+		}
+		if err := checkOptionalType(msg, field, "name", "string"); err != nil {
+			return err
+		}
+
+		switch role {
+		case "system", "developer":
+			err = checkChatContent(msg, field, false, "text")
+		case "user":
+			err = checkChatContent(msg, field, false, "text", "image_url", "input_audio", "file")
+		case "assistant":
+			// Content may be omitted when the assistant only called tools.
+			hasToolCalls := msg["tool_calls"] != nil
+			hasFunctionCall := msg["function_call"] != nil
+			err = checkChatContent(msg, field, hasToolCalls || hasFunctionCall, "text", "refusal")
+			if err == nil && hasToolCalls {
+				err = validateToolCalls(msg["tool_calls"], field+".tool_calls")
+			}
+		case "tool":
+			if id, ok := msg["tool_call_id"].(string); !ok || id == "" {
+				err = schemaErrorf(field+".tool_call_id", "is required for tool messages")
+			} else {
+				err = checkChatContent(msg, field, false, "text")
+			}
+		case "function":
+			if name, ok := msg["name"].(string); !ok || name == "" {
+				err = schemaErrorf(field+".name", "is required for function messages")
+			} else {
+				err = checkChatContent(msg, field, true, "text")
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChatContent checks a chat message's content: a string or an array of the allowed
+// content part types. nullable allows the content to be missing or null.
+func checkChatContent(msg map[string]interface{}, field string, nullable bool, partTypes ...string) *requestSchemaError {
+	field += ".content"
+	value, ok := msg["content"]
+	if !ok || value == nil {
+		if nullable {
+			return nil
+		}
+		return schemaErrorf(field, "is required")
+	}
+
+	switch content := value.(type) {
+	case string:
+		return nil
+	case []interface{}:
+		for i, item := range content {
+			if err := validateContentPart(item, fmt.Sprintf("%s[%d]", field, i), partTypes); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return schemaErrorf(field, "must be a string or an array of content parts, got %s", jsonType(value))
+	}
+}
+
+// validateContentPart checks a chat completions or Responses API content part.
+func validateContentPart(item interface{}, field string, allowed []string) *requestSchemaError {
+	part, ok := item.(map[string]interface{})
+	if !ok {
+		return schemaErrorf(field, "must be an object, got %s", jsonType(item))
+	}
+	partType, ok := part["type"].(string)
+	if !ok {
+		return schemaErrorf(field+".type", "is required")
+	}
+	if !slices.Contains(allowed, partType) {
+		return schemaErrorf(field+".type", "must be one of %s; got %q", quoteList(allowed), partType)
+	}
+
+	switch partType {
+	case "text", "input_text", "output_text":
+		return checkString(part, field, "text")
+	case "refusal":
+		return checkString(part, field, "refusal")
+	case "image_url":
+		image, ok := part["image_url"].(map[string]interface{})
+		if !ok {
+			return schemaErrorf(field+".image_url", "must be an object with a url")
+		}
+		return checkRequiredString(image, field+".image_url", "url")
+	case "input_image":
+		return checkOneOfStrings(part, field, "image_url", "file_id")
+	case "input_audio":
+		audio, ok := part["input_audio"].(map[string]interface{})
+		if !ok {
+			return schemaErrorf(field+".input_audio", "must be an object with data and format")
+		}
+		if err := checkRequiredString(audio, field+".input_audio", "data"); err != nil {
+			return err
+		}
+		return checkRequiredString(audio, field+".input_audio", "format")
+	case "file":
+		file, ok := part["file"].(map[string]interface{})
+		if !ok {
+			return schemaErrorf(field+".file", "must be an object with file_data or file_id")
+		}
+		return checkOneOfStrings(file, field+".file", "file_data", "file_id")
+	case "input_file":
+		return checkOneOfStrings(part, field, "file_data", "file_id", "file_url")
+	}
+	return nil
+}
+
+// validateToolCalls checks the tool_calls of an assistant message.
+func validateToolCalls(value interface{}, field string) *requestSchemaError {
+	calls, ok := value.([]interface{})
+	if !ok {
+		return schemaErrorf(field, "must be an array, got %s", jsonType(value))
+	}
+	for i, item := range calls {
+		callField := fmt.Sprintf("%s[%d]", field, i)
+		call, ok := item.(map[string]interface{})
+		if !ok {
+			return schemaErrorf(callField, "must be an object, got %s", jsonType(item))
+		}
+		if err := checkRequiredString(call, callField, "id"); err != nil {
+			return err
+		}
+		function, ok := call["function"].(map[string]interface{})
+		if !ok {
+			return schemaErrorf(callField+".function", "is required")
+		}
+		if err := checkRequiredString(function, callField+".function", "name"); err != nil {
+			return err
+		}
+		if err := checkOptionalType(function, callField+".function", "arguments", "string"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTools checks that tools, when present, is an array of typed objects.
+func validateTools(value interface{}) *requestSchemaError {
+	if value == nil {
+		return nil
+	}
+	tools, ok := value.([]interface{})
+	if !ok {
+		return schemaErrorf("tools", "must be an array, got %s", jsonType(value))
+	}
+	for i, item := range tools {
+		field := fmt.Sprintf("tools[%d]", i)
+		tool, ok := item.(map[string]interface{})
+		if !ok {
+			return schemaErrorf(field, "must be an object, got %s", jsonType(item))
+		}
+		if err := checkRequiredString(tool, field, "type"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateResponsesInput checks the input of a Responses API request: a string or an array of
+// input items. Message items are checked fully; other item types only need a type.
+func validateResponsesInput(req map[string]interface{}) *requestSchemaError {
+	if err := checkOptionalType(req, "", "instructions", "string"); err != nil {
+		return err
+	}
+
+	value, ok := req["input"]
+	if !ok || value == nil {
+		return nil
+	}
+
+	var items []interface{}
+	switch input := value.(type) {
+	case string:
+		return nil
+	case []interface{}:
+		items = input
+	default:
+		return schemaErrorf("input", "must be a string or an array of input items, got %s", jsonType(value))
+	}
+
+	for i, raw := range items {
+		field := fmt.Sprintf("input[%d]", i)
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			return schemaErrorf(field, "must be an object, got %s", jsonType(raw))
+		}
+
+		itemType, hasType := item["type"].(string)
+		if _, present := item["type"]; present && !hasType {
+			return schemaErrorf(field+".type", "must be a string, got %s", jsonType(item["type"]))
+		}
+		_, hasRole := item["role"]
+
+		var err *requestSchemaError
+		switch {
+		case itemType == "message" || (!hasType && hasRole):
+			err = validateResponsesMessage(item, field)
+		case itemType == "function_call":
+			if err = checkRequiredString(item, field, "call_id"); err == nil {
+				if err = checkRequiredString(item, field, "name"); err == nil {
+					err = checkOptionalType(item, field, "arguments", "string")
+				}
+			}
+		case itemType == "function_call_output":
+			if err = checkRequiredString(item, field, "call_id"); err == nil {
+				if _, ok := item["output"]; !ok {
+					err = schemaErrorf(field+".output", "is required")
+				}
+			}
+		case !hasType:
+			err = schemaErrorf(field, "must have a type or a role")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateResponsesMessage checks a message input item of a Responses API request.
+func validateResponsesMessage(item map[string]interface{}, field string) *requestSchemaError {
+	role, err := checkRole(item, field, responsesRoles)
+	if err != nil {
+		return err
+	}
+
+	partTypes := []string{"input_text", "input_image", "input_file", "input_audio"}
+	if role == "assistant" {
+		partTypes = []string{"output_text", "refusal"}
+	}
+
+	field += ".content"
+	value, ok := item["content"]
+	if !ok || value == nil {
+		return schemaErrorf(field, "is required")
+	}
+	switch content := value.(type) {
+	case string:
+		return nil
+	case []interface{}:
+		for i, part := range content {
+			if err := validateContentPart(part, fmt.Sprintf("%s[%d]", field, i), partTypes); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return schemaErrorf(field, "must be a string or an array of content parts, got %s", jsonType(value))
+	}
+}
+
+// checkRole checks that obj has one of the allowed roles and returns it.
+func checkRole(obj map[string]interface{}, field string, allowed []string) (string, *requestSchemaError) {
+	value, ok := obj["role"]
+	if !ok || value == nil {
+		return "", schemaErrorf(field+".role", "is required")
+	}
+	role, ok := value.(string)
+	if !ok {
+		return "", schemaErrorf(field+".role", "must be a string, got %s", jsonType(value))
+	}
+	if !slices.Contains(allowed, role) {
+		return "", schemaErrorf(field+".role", "must be one of %s; got %q", quoteList(allowed), role)
+	}
+	return role, nil
+}
+
+// checkRequiredString checks that obj[key] is a non-empty string.
+func checkRequiredString(obj map[string]interface{}, field, key string) *requestSchemaError {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		return schemaErrorf(joinField(field, key), "is required")
+	}
+	s, ok := value.(string)
+	if !ok {
+		return schemaErrorf(joinField(field, key), "must be a string, got %s", jsonType(value))
+	}
+	if s == "" {
+		return schemaErrorf(joinField(field, key), "must not be empty")
+	}
+	return nil
+}
+
+// checkString checks that obj[key] is a string, which may be empty.
+func checkString(obj map[string]interface{}, field, key string) *requestSchemaError {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		return schemaErrorf(joinField(field, key), "is required")
+	}
+	if _, ok := value.(string); !ok {
+		return schemaErrorf(joinField(field, key), "must be a string, got %s", jsonType(value))
+	}
+	return nil
+}
+
+// checkOneOfStrings checks that at least one of keys is a non-empty string.
+func checkOneOfStrings(obj map[string]interface{}, field string, keys ...string) *requestSchemaError {
+	for _, key := range keys {
+		if s, ok := obj[key].(string); ok && s != "" {
+			return nil
+		}
+	}
+	return schemaErrorf(field, "requires one of %s", quoteList(keys))
+}
+
+// checkOptionalType checks that obj[key], when present and not null, has the JSON type want.
+func checkOptionalType(obj map[string]interface{}, field, key, want string) *requestSchemaError {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		return nil
+	}
+	if got := jsonType(value); got != want {
+		return schemaErrorf(joinField(field, key), "must be a %s, got %s", want, got)
+	}
+	return nil
+}
+
+// jsonType names the JSON type of a value decoded into interface{}.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func joinField(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
+
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
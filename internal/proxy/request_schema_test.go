@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRequestSchema(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		body      string
+		wantField string // "-" = valid
+		wantErr   string
+	}{
+		// Chat completions
+		{name: "simple chat", path: "/chat/completions", body: `{"model":"m","messages":[{"role":"user","content":"hi"}]}`, wantField: "-"},
+		{
+			name:      "multimodal and tool round trip",
+			path:      "/chat/completions",
+			wantField: "-",
+			body: `{"model":"m","stream":true,"tools":[{"type":"function","function":{"name":"f"}}],"messages":[
+				{"role":"system","content":"be brief"},
+				{"role":"user","content":[{"type":"text","text":"what is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAA"}}]},
+				{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"f","arguments":"{}"}}]},
+				{"role":"tool","tool_call_id":"call_1","content":"42"},
+				{"role":"assistant","content":[{"type":"text","text":""}]}
+			]}`,
+		},
+		{name: "assistant null tool_calls with content", path: "/chat/completions", body: `{"messages":[{"role":"assistant","content":"x","tool_calls":null}]}`, wantField: "-"},
+		{name: "empty body", path: "/chat/completions", body: ``, wantField: "", wantErr: "request body is required"},
+		{name: "invalid JSON", path: "/chat/completions", body: `{"messages":[}`, wantField: "", wantErr: "invalid JSON at offset 14"},
+		{name: "not an object", path: "/chat/completions", body: `[1,2]`, wantField: "", wantErr: "got array"},
+		{name: "model not a string", path: "/chat/completions", body: `{"model":5,"messages":[{"role":"user","content":"hi"}]}`, wantField: "model"},
+		{name: "stream not a boolean", path: "/chat/completions", body: `{"stream":"yes","messages":[{"role":"user","content":"hi"}]}`, wantField: "stream"},
+		{name: "tools not an array", path: "/chat/completions", body: `{"tools":{},"messages":[{"role":"user","content":"hi"}]}`, wantField: "tools"},
+		{name: "json schema format", path: "/chat/completions", body: `{"response_format":{"type":"json_schema","json_schema":{"name":"p","schema":{"type":"object"}}},"messages":[{"role":"user","content":"hi"}]}`, wantField: "-"},
+		{name: "invalid response format schema", path: "/chat/completions", body: `{"response_format":{"type":"json_schema","json_schema":{"name":"p","schema":{"type":"map"}}},"messages":[{"role":"user","content":"hi"}]}`, wantField: "response_format.json_schema.schema", wantErr: `unknown type "map"`},
+		{name: "missing messages", path: "/chat/completions", body: `{"model":"m"}`, wantField: "messages", wantErr: "is required"},
+		{name: "empty messages", path: "/chat/completions", body: `{"messages":[]}`, wantField: "messages"},
+		{name: "message not an object", path: "/chat/completions", body: `{"messages":["hi"]}`, wantField: "messages[0]"},
+		{name: "missing role", path: "/chat/completions", body: `{"messages":[{"content":"hi"}]}`, wantField: "messages[0].role"},
+		{name: "unknown role", path: "/chat/completions", body: `{"messages":[{"role":"bot","content":"hi"}]}`, wantField: "messages[0].role", wantErr: `got "bot"`},
+		{name: "user without content", path: "/chat/completions", body: `{"messages":[{"role":"user"}]}`, wantField: "messages[0].content"},
+		{name: "content is a number", path: "/chat/completions", body: `{"messages":[{"role":"user","content":1}]}`, wantField: "messages[0].content"},
+		{name: "assistant without content or tool calls", path: "/chat/completions", body: `{"messages":[{"role":"assistant"}]}`, wantField: "messages[0].content"},
+		{name: "part without type", path: "/chat/completions", body: `{"messages":[{"role":"user","content":[{"text":"hi"}]}]}`, wantField: "messages[0].content[0].type"},
+		{name: "unknown part type", path: "/chat/completions", body: `{"messages":[{"role":"user","content":[{"type":"video","video":"x"}]}]}`, wantField: "messages[0].content[0].type"},
+		{name: "image part on system message", path: "/chat/completions", body: `{"messages":[{"role":"system","content":[{"type":"image_url","image_url":{"url":"x"}}]}]}`, wantField: "messages[0].content[0].type"},
+		{name: "text part without text", path: "/chat/completions", body: `{"messages":[{"role":"user","content":[{"type":"text"}]}]}`, wantField: "messages[0].content[0].text"},
+		{name: "image_url as string", path: "/chat/completions", body: `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":"x"}]}]}`, wantField: "messages[0].content[0].image_url"},
+		{name: "image without url", path: "/chat/completions", body: `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{}}]}]}`, wantField: "messages[0].content[0].image_url.url"},
+		{name: "audio without format", path: "/chat/completions", body: `{"messages":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"AAA"}}]}]}`, wantField: "messages[0].content[0].input_audio.format"},
+		{name: "tool message without tool_call_id", path: "/chat/completions", body: `{"messages":[{"role":"tool","content":"42"}]}`, wantField: "messages[0].tool_call_id"},
+		{name: "tool call without function name", path: "/chat/completions", body: `{"messages":[{"role":"assistant","tool_calls":[{"id":"c","function":{}}]}]}`, wantField: "messages[0].tool_calls[0].function.name"},
+		{name: "second message invalid", path: "/chat/completions", body: `{"messages":[{"role":"user","content":"hi"},{"role":"user","content":[{"type":"text","text":1}]}]}`, wantField: "messages[1].content[0].text"},
+
+		// Responses API
+		{name: "responses string input", path: "/responses", body: `{"model":"m","input":"hi"}`, wantField: "-"},
+		{name: "responses without input", path: "/responses", body: `{"model":"m","previous_response_id":"resp_1"}`, wantField: "-"},
+		{
+			name:      "responses items",
+			path:      "/responses",
+			wantField: "-",
+			body: `{"model":"m","instructions":"be brief","input":[
+				{"role":"user","content":[{"type":"input_text","text":"hi"},{"type":"input_image","image_url":"https://x"}]},
+				{"type":"message","role":"assistant","content":[{"type":"output_text","text":"hello"}]},
+				{"type":"function_call","call_id":"c1","name":"f","arguments":"{}"},
+				{"type":"function_call_output","call_id":"c1","output":"42"},
+				{"type":"reasoning","id":"rs_1","summary":[]}
+			]}`,
+		},
+		{name: "responses input is a number", path: "/responses", body: `{"input":5}`, wantField: "input"},
+		{name: "responses instructions not a string", path: "/responses", body: `{"instructions":["x"],"input":"hi"}`, wantField: "instructions"},
+		{name: "responses item without type or role", path: "/responses", body: `{"input":[{"content":"hi"}]}`, wantField: "input[0]"},
+		{name: "responses tool role", path: "/responses", body: `{"input":[{"role":"tool","content":"hi"}]}`, wantField: "input[0].role"},
+		{name: "responses chat part type", path: "/responses", body: `{"input":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`, wantField: "input[0].content[0].type"},
+		{name: "responses image without source", path: "/responses", body: `{"input":[{"role":"user","content":[{"type":"input_image"}]}]}`, wantField: "input[0].content[0]", wantErr: "requires one of"},
+		{name: "responses function output without call_id", path: "/responses", body: `{"input":[{"type":"function_call_output","output":"42"}]}`, wantField: "input[0].call_id"},
+
+		// Other paths are not validated
+		{name: "embeddings", path: "/embeddings", body: `{"input":5}`, wantField: "-"},
+		{name: "retrieve response", path: "/responses/resp_1", body: ``, wantField: "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRequestSchema(tt.path, []byte(tt.body))
+			if tt.wantField == "-" {
+				if err != nil {
+					t.Fatalf("validateRequestSchema() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validateRequestSchema() = nil, want error on %q", tt.wantField)
+			}
+			if err.Field != tt.wantField {
+				t.Errorf("Field = %q, want %q (error: %v)", err.Field, tt.wantField, err)
+			}
+			if tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want containing %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -21,9 +21,11 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/responses"
 	"github.com/eternisai/enchanted-proxy/internal/routing"
 	"github.com/eternisai/enchanted-proxy/internal/streaming"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
 	"github.com/eternisai/enchanted-proxy/internal/title_generation"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // handleResponsesAPI handles requests to OpenAI's Responses API (GPT-5 Pro, GPT-4.5+).
@@ -32,7 +34,7 @@ import (
 //
 // Flow:
 //  1. Fetch previous response_id from Firestore (for continuation)
-//  2. Transform request: add store=true, background=true, reasoning.effort=high
+//  2. Transform request: add store=true, background=true, reasoning.effort (client value capped by tier, default high)
 //  3. Make HTTP request to OpenAI /responses endpoint with background=true
 //  4. Get response_id immediately (response status = "queued")
 //  5. Save initial message with generationState="thinking" to Firestore
@@ -53,6 +55,7 @@ import (
 //   - pollingManager: Background polling manager
 //   - modelRouter: Model router for title generation config
 //   - cfg: Application configuration
+//   - headers: Upstream response header passthrough policy (nil = none)
 //
 // Returns:
 //   - error: If handling failed
@@ -68,6 +71,7 @@ func handleResponsesAPI(
 	pollingManager *background.PollingManager,
 	modelRouter *routing.ModelRouter,
 	cfg *config.Config,
+	headers *headerPolicy,
 ) error {
 	canonicalModel := modelRouter.ResolveAlias(model)
 
@@ -131,6 +135,32 @@ func handleResponsesAPI(
 		return fmt.Errorf("user ID not found in context")
 	}
 
+	// Resolve reasoning effort before any side effects: validate the client value,
+	// then apply the tier ceiling (e.g., free tier is capped at "medium").
+	// No tierConfig in context means rate limiting is disabled - no ceiling applies.
+	requestedEffort, err := responses.RequestedReasoningEffort(requestBody)
+	if err != nil {
+		log.Warn("invalid reasoning effort in request",
+			slog.String("error", err.Error()))
+		errors.BadRequest(c, err.Error(), map[string]interface{}{
+			"allowed_reasoning_efforts": responses.ValidReasoningEfforts(),
+		})
+		return fmt.Errorf("invalid reasoning effort: %w", err)
+	}
+	var effortCeiling string
+	if val, exists := c.Get("tierConfig"); exists {
+		if tierConfig, ok := val.(tiers.Config); ok {
+			effortCeiling = tierConfig.MaxReasoningEffort
+		}
+	}
+	reasoningEffort := responses.CapReasoningEffort(requestedEffort, effortCeiling)
+	if requestedEffort != "" && reasoningEffort != requestedEffort {
+		log.Info("reasoning effort capped by tier",
+			slog.String("requested", string(requestedEffort)),
+			slog.String("applied", string(reasoningEffort)),
+			slog.String("ceiling", effortCeiling))
+	}
+
 	// Step 1: Fetch previous response_id from Firestore (for conversation continuation)
 	var previousResponseID string
 	if messageService != nil {
@@ -179,9 +209,9 @@ func handleResponsesAPI(
 		cancel()
 	}
 
-	// Step 3: Transform request for Responses API (adds background=true, reasoning.effort=high)
+	// Step 3: Transform request for Responses API (adds background=true, reasoning.effort)
 	adapter := responses.NewAdapter()
-	transformedBody, err := adapter.TransformRequest(requestBody, previousResponseID)
+	transformedBody, err := adapter.TransformRequestWithEffort(requestBody, previousResponseID, reasoningEffort)
 	if err != nil {
 		log.Error("failed to transform request",
 			slog.String("error", err.Error()))
@@ -230,6 +260,7 @@ func handleResponsesAPI(
 	// Set headers
 	req.Header.Set("Authorization", "Bearer "+provider.APIKey)
 	req.Header.Set("Content-Type", "application/json")
+	applyRequestHeaders(req.Header, c.Request.Header, provider.Headers)
 
 	// Make request with short timeout (we're just submitting the request, not waiting for completion)
 	client := &http.Client{
@@ -253,11 +284,24 @@ func handleResponsesAPI(
 	defer resp.Body.Close()
 
 	metrics.RecordUpstreamResponse(provider.Name, canonicalModel, resp.StatusCode, time.Since(upstreamStart).Seconds())
+	metrics.RecordRateLimitHeadroom(provider.Name, canonicalModel, resp.Header)
+	headers.copyAllowed(c.Writer.Header(), resp.Header)
 
 	// Check for errors
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
 
+		if resp.StatusCode == http.StatusTooManyRequests {
+			rlErr := normalizeUpstreamRateLimit(resp.Header, body, model, time.Now())
+			metrics.RecordUpstreamRateLimited(provider.Name, canonicalModel, float64(rlErr.RetryAfter))
+			log.Warn("OpenAI Responses API rate limited",
+				slog.String("model", model),
+				slog.Int("retry_after", rlErr.RetryAfter),
+				slog.String("response_body", string(body)))
+			errors.AbortWithUpstreamRateLimit(c, rlErr)
+			return fmt.Errorf("Responses API rate limited: retry after %ds", rlErr.RetryAfter)
+		}
+
 		// Try to parse error as JSON for better logging
 		var errorResponse map[string]interface{}
 		errorMessage := string(body)
@@ -324,7 +368,9 @@ func handleResponsesAPI(
 		MessageID:         messageID,
 		Model:             model,
 		EncryptionEnabled: encryptionEnabled,
+		ReasoningEffort:   string(reasoningEffort),
 		StartedAt:         time.Now(),
+		SpanContext:       trace.SpanContextFromContext(c.Request.Context()),
 	}
 
 	// CRITICAL: Use context.Background() instead of c.Request.Context()
@@ -346,10 +392,11 @@ func handleResponsesAPI(
 	// Step 8: Return immediately to client
 	// Client will listen to Firestore for real-time updates
 	c.JSON(http.StatusAccepted, gin.H{
-		"message_id":  messageID,
-		"response_id": bgResponse.ID,
-		"status":      "queued",
-		"message":     "Request submitted successfully. Listen to Firestore for updates.",
+		"message_id":       messageID,
+		"response_id":      bgResponse.ID,
+		"status":           "queued",
+		"reasoning_effort": reasoningEffort,
+		"message":          "Request submitted successfully. Listen to Firestore for updates.",
 	})
 
 	// The background polling worker logs final token usage after the Responses API request completes.
@@ -0,0 +1,194 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/streaming"
+)
+
+// maxHeldStreamBytes bounds the lines withheld while waiting for a stream's first output.
+// Past it, the stream is released as-is and can no longer be retried.
+const maxHeldStreamBytes = 1 << 20
+
+// streamRetryProviders returns the provider for each retry of a stream that ends without
+// output, or nil if retries are disabled. The first retry goes to an alternate provider when the
+// model has one; the rest go back to the original provider.
+func streamRetryProviders(cfg *config.Config, modelRouter *routing.ModelRouter, model, platform string, provider *routing.ProviderConfig) []*routing.ProviderConfig {
+	if cfg == nil || !cfg.StreamAnomalyRetryEnabled || cfg.StreamRetryMaxAttempts <= 0 || provider == nil {
+		return nil
+	}
+
+	providers := make([]*routing.ProviderConfig, cfg.StreamRetryMaxAttempts)
+	for i := range providers {
+		providers[i] = provider
+	}
+	if modelRouter != nil {
+		if alternate := modelRouter.RouteAlternate(model, platform, provider.Name); alternate != nil {
+			providers[0] = alternate
+		}
+	}
+	return providers
+}
+
+// zeroRetentionProviders returns the providers that neither log nor retain request data,
+// used to keep privacy mode retries on zero-retention providers.
+func zeroRetentionProviders(providers []*routing.ProviderConfig) []*routing.ProviderConfig {
+	var filtered []*routing.ProviderConfig
+	for _, provider := range providers {
+		if provider.ZeroRetention {
+			filtered = append(filtered, provider)
+		}
+	}
+	return filtered
+}
+
+// withModel returns a chat completions request body with its model replaced.
+func withModel(body []byte, model string) []byte {
+	var reqBody map[string]interface{}
+	if err := json.Unmarshal(body, &reqBody); err != nil {
+		return body
+	}
+	reqBody["model"] = model
+	modified, err := json.Marshal(reqBody)
+	if err != nil {
+		return body
+	}
+	return modified
+}
+
+// retryingStreamBody wraps an upstream SSE body and withholds its lines until the stream
+// produces output (content, reasoning or tool calls). If the stream ends without any, an
+// empty completion or an early error chunk, it is replaced by the stream returned by retry,
+// up to maxRetries times and only before deadline, so the client never sees the failed
+// attempt. Once output is seen, the stream passes through as-is.
+type retryingStreamBody struct {
+	body       io.ReadCloser
+	reader     *bufio.Reader
+	retry      func(attempt int) (io.ReadCloser, error) // attempt counts from 0
+	maxRetries int
+	deadline   time.Time
+
+	retries        int          // Retries started, including failed ones
+	budgetExceeded bool         // A retry was skipped because deadline had passed
+	held           []byte       // Lines withheld until output is seen
+	out            bytes.Buffer // Released lines not yet read
+	passthrough    bool
+	err            error // Upstream read error to return after the released lines
+}
+
+func newRetryingStreamBody(body io.ReadCloser, maxRetries int, deadline time.Time, retry func(attempt int) (io.ReadCloser, error)) *retryingStreamBody {
+	return &retryingStreamBody{
+		body:       body,
+		reader:     bufio.NewReader(body),
+		retry:      retry,
+		maxRetries: maxRetries,
+		deadline:   deadline,
+	}
+}
+
+func (b *retryingStreamBody) Read(p []byte) (int, error) {
+	for !b.passthrough {
+		b.fill()
+	}
+	if b.out.Len() > 0 {
+		return b.out.Read(p)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+func (b *retryingStreamBody) Close() error {
+	return b.body.Close()
+}
+
+// fill withholds the next upstream line, releasing the stream once it produces output, ends
+// for good or outgrows maxHeldStreamBytes.
+func (b *retryingStreamBody) fill() {
+	line, err := b.reader.ReadBytes('\n')
+	if len(line) > 0 {
+		b.held = append(b.held, line...)
+		trimmed := strings.TrimRight(string(line), "\r\n")
+		if streaming.IsOutputLine(trimmed) || len(b.held) > maxHeldStreamBytes {
+			b.release(nil)
+			return
+		}
+		if !isDoneLine(trimmed) && !streaming.IsErrorLine(trimmed) && err == nil {
+			return
+		}
+	}
+	if err == nil && len(line) == 0 {
+		return
+	}
+
+	// The attempt ended ([DONE], error chunk, EOF or read error) without output
+	for b.retry != nil && b.retries < b.maxRetries {
+		if !time.Now().Before(b.deadline) {
+			b.budgetExceeded = true
+			break
+		}
+		attempt := b.retries
+		b.retries++
+		if body, retryErr := b.retry(attempt); retryErr == nil {
+			_ = b.body.Close()
+			b.body = body
+			b.reader = bufio.NewReader(body)
+			b.held = nil
+			return
+		}
+	}
+
+	if err == io.EOF {
+		err = nil // The drained reader reports EOF again
+	}
+	b.release(err)
+}
+
+// release hands the withheld lines to the reader and stops withholding.
+func (b *retryingStreamBody) release(err error) {
+	b.out.Write(b.held)
+	b.held = nil
+	b.passthrough = true
+	b.err = err
+}
+
+// isDoneLine reports whether an SSE line is the end-of-stream marker.
+func isDoneLine(line string) bool {
+	data, ok := strings.CutPrefix(line, "data:")
+	return ok && strings.TrimSpace(data) == "[DONE]"
+}
+
+// reportStreamAnomalies checks a completed stream session, records metrics and logs any
+// anomalies. Returns their names for the request log.
+func reportStreamAnomalies(log *logger.Logger, session *streaming.StreamSession, providerName, model string) []string {
+	anomalies := session.GetAnomalies()
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(anomalies))
+	for i, anomaly := range anomalies {
+		names[i] = string(anomaly)
+		metrics.RecordStreamAnomaly(providerName, model, names[i])
+	}
+
+	info := session.GetInfo()
+	log.Warn("anomalous upstream stream",
+		slog.String("chat_id", info.ChatID),
+		slog.String("message_id", info.MessageID),
+		slog.String("provider", providerName),
+		slog.String("model", model),
+		slog.String("anomalies", strings.Join(names, ",")))
+	return names
+}
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+func TestRetryingStreamBody(t *testing.T) {
+	const (
+		role    = "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n"
+		content = "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n"
+		done    = "data: [DONE]\n\n"
+		errLine = "data: {\"error\":{\"message\":\"overloaded\"}}\n\n"
+	)
+
+	tests := []struct {
+		name        string
+		upstream    string
+		retries     []string // Response per retry; empty = retry fails
+		maxRetries  int
+		budget      time.Duration
+		want        string
+		wantRetries int
+	}{
+		{"output passes through", role + content + done, []string{"unused"}, 1, time.Minute, role + content + done, 0},
+		{"empty stream retried", role + done, []string{role + content + done}, 1, time.Minute, role + content + done, 1},
+		{"truncated empty stream retried", role, []string{content + done}, 1, time.Minute, content + done, 1},
+		{"early error chunk retried", role + errLine + "data: {}\n", []string{content + done}, 1, time.Minute, content + done, 1},
+		{"failed retry releases original", role + done, []string{""}, 1, time.Minute, role + done, 1},
+		{"retries capped", role + done, []string{role + done, role + done}, 1, time.Minute, role + done, 1},
+		{"failed retry falls through to next", role + done, []string{"", content + done}, 2, time.Minute, content + done, 2},
+		{"empty retry retried again", role + done, []string{done, content + done}, 2, time.Minute, content + done, 2},
+		{"no retry past budget", role + done, []string{content + done}, 1, -time.Second, role + done, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retries := 0
+			upstream := io.NopCloser(strings.NewReader(tt.upstream))
+			body := newRetryingStreamBody(upstream, tt.maxRetries, time.Now().Add(tt.budget), func(attempt int) (io.ReadCloser, error) {
+				retries++
+				if attempt >= len(tt.retries) || tt.retries[attempt] == "" {
+					return nil, errors.New("provider unavailable")
+				}
+				return io.NopCloser(strings.NewReader(tt.retries[attempt])), nil
+			})
+
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+			if retries != tt.wantRetries || body.retries != tt.wantRetries {
+				t.Errorf("retries = %d (counted %d), want %d", retries, body.retries, tt.wantRetries)
+			}
+			if wantExceeded := tt.budget < 0; body.budgetExceeded != wantExceeded {
+				t.Errorf("budgetExceeded = %v, want %v", body.budgetExceeded, wantExceeded)
+			}
+		})
+	}
+}
+
+func TestRetryingStreamBodyReadError(t *testing.T) {
+	upstreamErr := errors.New("connection reset")
+	upstream := io.MultiReader(strings.NewReader("data: {}\n"), &failingReader{err: upstreamErr})
+	body := newRetryingStreamBody(io.NopCloser(upstream), 0, time.Now(), nil)
+
+	got, err := io.ReadAll(body)
+	if !errors.Is(err, upstreamErr) {
+		t.Errorf("ReadAll() error = %v, want %v", err, upstreamErr)
+	}
+	if string(got) != "data: {}\n" {
+		t.Errorf("body = %q", got)
+	}
+}
+
+type failingReader struct{ err error }
+
+func (r *failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestStreamRetryProviders(t *testing.T) {
+	provider := &routing.ProviderConfig{Name: "primary"}
+
+	tests := []struct {
+		name string
+		cfg  *config.Config
+		want []string
+	}{
+		{"disabled", &config.Config{StreamAnomalyRetryEnabled: false, StreamRetryMaxAttempts: 1}, nil},
+		{"no attempts", &config.Config{StreamAnomalyRetryEnabled: true, StreamRetryMaxAttempts: 0}, nil},
+		{"same provider without router", &config.Config{StreamAnomalyRetryEnabled: true, StreamRetryMaxAttempts: 2}, []string{"primary", "primary"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := streamRetryProviders(tt.cfg, nil, "model", "", provider)
+			if len(got) != len(tt.want) {
+				t.Fatalf("streamRetryProviders() = %d providers, want %d", len(got), len(tt.want))
+			}
+			for i, p := range got {
+				if p.Name != tt.want[i] {
+					t.Errorf("provider %d = %q, want %q", i, p.Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
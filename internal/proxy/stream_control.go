@@ -1,9 +1,11 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/auth"
@@ -12,6 +14,7 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
 	"github.com/eternisai/enchanted-proxy/internal/streaming"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -20,65 +23,36 @@ const (
 	// Maximum length for chat and message IDs to prevent memory abuse
 	maxChatIDLength    = 256
 	maxMessageIDLength = 256
+
+	// maxChunksWait bounds how long a chunks request waits for new chunks, below the idle
+	// timeouts of typical corporate proxies
+	maxChunksWait = 25 * time.Second
 )
 
-// StopStreamHandler handles POST /api/v1/chats/:chatId/messages/:messageId/stop
-// Stops an in-progress AI response generation
+// StreamChunksResponse is the response of the long-poll chunks endpoint.
+type StreamChunksResponse struct {
+	Chunks []streaming.StreamChunk `json:"chunks"`
+	Next   int                     `json:"next"` // Pass as after in the next poll
+	Done   bool                    `json:"done"` // The response is complete; no more chunks follow
+}
+
+// StopStreamHandler handles POST /api/v1/chats/:chatId/messages/:messageId/stop and
+// POST /api/v1/streams/:chatId/:messageId/stop
+// Stops an in-progress AI response generation; the partial response is saved with the stop
+// metadata (stopped, stoppedBy, stopReason) when the session completes.
 func StopStreamHandler(
 	logger *logger.Logger,
 	streamManager *streaming.StreamManager,
-	firestoreClient *messaging.FirestoreClient,
+	chatStore messaging.Store,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := logger.WithContext(c.Request.Context()).WithComponent("stream-control")
 
-		// Extract user ID from auth
-		userID, exists := auth.GetUserID(c)
-		if !exists {
-			log.Error("user ID not found in context")
-			errors.Unauthorized(c, "Authentication required", nil)
+		userID, chatID, messageID, ok := authorizeStreamRequest(c, log, chatStore)
+		if !ok {
 			return
 		}
 
-		// Extract path parameters
-		chatID := c.Param("chatId")
-		messageID := c.Param("messageId")
-
-		// Validate parameters
-		if chatID == "" || messageID == "" {
-			errors.BadRequest(c, "chatId and messageId are required", nil)
-			return
-		}
-
-		// Input validation: Check length limits
-		if len(chatID) > maxChatIDLength || len(messageID) > maxMessageIDLength {
-			log.Warn("ID too long",
-				slog.String("chat_id_len", fmt.Sprintf("%d", len(chatID))),
-				slog.String("message_id_len", fmt.Sprintf("%d", len(messageID))))
-			errors.BadRequest(c, "chatId or messageId exceeds maximum length", nil)
-			return
-		}
-
-		// Authorization: Verify user owns this chat
-		if firestoreClient != nil {
-			err := firestoreClient.VerifyChatOwnership(c.Request.Context(), userID, chatID)
-			if err != nil {
-				if status.Code(err) == codes.PermissionDenied {
-					log.Warn("chat ownership verification failed",
-						slog.String("user_id", userID),
-						slog.String("chat_id", chatID))
-					errors.AbortWithForbidden(c, errors.ChatNotOwned(chatID))
-					return
-				}
-				log.Error("failed to verify chat ownership",
-					slog.String("error", err.Error()),
-					slog.String("user_id", userID),
-					slog.String("chat_id", chatID))
-				errors.Internal(c, "Failed to verify permissions", nil)
-				return
-			}
-		}
-
 		sessionKey := fmt.Sprintf("%s:%s", chatID, messageID)
 		log.Info("stop request received",
 			slog.String("chat_id", chatID),
@@ -100,7 +74,7 @@ func StopStreamHandler(
 					slog.String("chat_id", chatID),
 					slog.String("message_id", messageID))
 
-				resp, err := distCancel.RequestCancel(c.Request.Context(), chatID, messageID, userID)
+				resp, err := distCancel.RequestCancel(c.Request.Context(), chatID, messageID, userID, streaming.StopReasonUserCancelled)
 				if err != nil {
 					log.Error("distributed cancel failed",
 						slog.String("error", err.Error()),
@@ -226,3 +200,235 @@ func StopStreamHandler(
 		})
 	}
 }
+
+// ResumeStreamHandler handles GET /api/v1/chats/:chatId/messages/:messageId/stream and
+// GET /api/v1/streams/:chatId/:messageId/replay
+// Replays an AI response from the start and follows it live until it completes, so clients
+// can reconnect after losing the original request. With a shared session store the stream
+// may be read upstream by any instance.
+func ResumeStreamHandler(
+	logger *logger.Logger,
+	streamManager *streaming.StreamManager,
+	chatStore messaging.Store,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("stream-control")
+
+		_, chatID, messageID, ok := authorizeStreamRequest(c, log, chatStore)
+		if !ok {
+			return
+		}
+
+		session, err := streamManager.JoinSession(c.Request.Context(), chatID, messageID)
+		if err != nil {
+			log.Error("failed to join stream",
+				slog.String("error", err.Error()),
+				slog.String("chat_id", chatID),
+				slog.String("message_id", messageID))
+			errors.Internal(c, "Failed to resume stream", nil)
+			return
+		}
+		if session == nil {
+			errors.NotFound(c, "Stream not found", map[string]interface{}{
+				"message_id": messageID,
+			})
+			return
+		}
+
+		subscriber, err := session.Subscribe(c.Request.Context(), uuid.New().String(), streaming.SubscriberOptions{
+			ReplayFromStart: true,
+			BufferSize:      100,
+		})
+		if err != nil {
+			log.Error("failed to subscribe to stream",
+				slog.String("error", err.Error()),
+				slog.String("chat_id", chatID))
+			errors.Internal(c, "Failed to resume stream", nil)
+			return
+		}
+		streamManager.RecordSubscription()
+
+		log.Info("client resumed stream",
+			slog.String("chat_id", chatID),
+			slog.String("message_id", messageID),
+			slog.Bool("remote", session.IsRemote()),
+			slog.Bool("completed", session.IsCompleted()))
+
+		streamToClient(c, subscriber, session, log)
+	}
+}
+
+// StreamChunksHandler handles GET /api/v1/chats/:chatId/messages/:messageId/chunks?after=N
+// Long-poll fallback for networks that break SSE and websockets: returns the response's
+// buffered chunks after index N (all chunks without after), waiting up to wait seconds
+// (default and maximum 25) for new ones. Clients poll again with after=next until done.
+func StreamChunksHandler(
+	logger *logger.Logger,
+	streamManager *streaming.StreamManager,
+	chatStore messaging.Store,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("stream-control")
+
+		_, chatID, messageID, ok := authorizeStreamRequest(c, log, chatStore)
+		if !ok {
+			return
+		}
+
+		after := -1
+		if value := c.Query("after"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < -1 {
+				errors.BadRequest(c, "after must be a chunk index", nil)
+				return
+			}
+			after = parsed
+		}
+		wait := maxChunksWait
+		if value := c.Query("wait"); value != "" {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				errors.BadRequest(c, "wait must be a number of seconds", nil)
+				return
+			}
+			wait = min(time.Duration(seconds)*time.Second, maxChunksWait)
+		}
+
+		session, err := streamManager.JoinSession(c.Request.Context(), chatID, messageID)
+		if err != nil {
+			log.Error("failed to join stream",
+				slog.String("error", err.Error()),
+				slog.String("chat_id", chatID),
+				slog.String("message_id", messageID))
+			errors.Internal(c, "Failed to get stream chunks", nil)
+			return
+		}
+		if session == nil {
+			errors.NotFound(c, "Stream not found", map[string]interface{}{
+				"message_id": messageID,
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), wait)
+		defer cancel()
+		chunks, done := session.ChunksAfter(ctx, after)
+
+		next := after
+		if len(chunks) > 0 {
+			next = chunks[len(chunks)-1].Index
+		} else {
+			chunks = []streaming.StreamChunk{}
+		}
+		c.JSON(http.StatusOK, StreamChunksResponse{Chunks: chunks, Next: next, Done: done})
+	}
+}
+
+// ActiveStreamHandler handles GET /api/v1/streams/:chatId/active
+// Returns the chat's in-progress response, if any, so clients coming back from the background
+// can rejoin it with the replay endpoint.
+func ActiveStreamHandler(
+	logger *logger.Logger,
+	streamManager *streaming.StreamManager,
+	chatStore messaging.Store,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := logger.WithContext(c.Request.Context()).WithComponent("stream-control")
+
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			log.Error("user ID not found in context")
+			errors.Unauthorized(c, "Authentication required", nil)
+			return
+		}
+
+		chatID := c.Param("chatId")
+		if chatID == "" || len(chatID) > maxChatIDLength {
+			errors.BadRequest(c, "invalid chatId", nil)
+			return
+		}
+		if !verifyChatOwner(c, log, chatStore, userID, chatID) {
+			return
+		}
+
+		info, err := streamManager.GetActiveStreamForChat(c.Request.Context(), chatID)
+		if err != nil {
+			log.Error("failed to find active stream",
+				slog.String("error", err.Error()),
+				slog.String("chat_id", chatID))
+			errors.Internal(c, "Failed to find active stream", nil)
+			return
+		}
+		if info == nil {
+			errors.NotFound(c, "No active stream", map[string]interface{}{
+				"chat_id": chatID,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, info)
+	}
+}
+
+// authorizeStreamRequest validates the chat and message IDs of a stream control request and
+// verifies the user owns the chat (skipped without a chat store). On failure the error
+// response is written and ok is false.
+func authorizeStreamRequest(c *gin.Context, log *logger.Logger, chatStore messaging.Store) (userID, chatID, messageID string, ok bool) {
+	// Extract user ID from auth
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		log.Error("user ID not found in context")
+		errors.Unauthorized(c, "Authentication required", nil)
+		return "", "", "", false
+	}
+
+	// Extract path parameters
+	chatID = c.Param("chatId")
+	messageID = c.Param("messageId")
+
+	// Validate parameters
+	if chatID == "" || messageID == "" {
+		errors.BadRequest(c, "chatId and messageId are required", nil)
+		return "", "", "", false
+	}
+
+	// Input validation: Check length limits
+	if len(chatID) > maxChatIDLength || len(messageID) > maxMessageIDLength {
+		log.Warn("ID too long",
+			slog.String("chat_id_len", fmt.Sprintf("%d", len(chatID))),
+			slog.String("message_id_len", fmt.Sprintf("%d", len(messageID))))
+		errors.BadRequest(c, "chatId or messageId exceeds maximum length", nil)
+		return "", "", "", false
+	}
+
+	if !verifyChatOwner(c, log, chatStore, userID, chatID) {
+		return "", "", "", false
+	}
+
+	return userID, chatID, messageID, true
+}
+
+// verifyChatOwner verifies the user owns the chat (skipped without a chat store). On
+// failure the error response is written and false returned.
+func verifyChatOwner(c *gin.Context, log *logger.Logger, chatStore messaging.Store, userID, chatID string) bool {
+	if chatStore == nil {
+		return true
+	}
+	err := chatStore.VerifyChatOwnership(c.Request.Context(), userID, chatID)
+	if err == nil {
+		return true
+	}
+	if status.Code(err) == codes.PermissionDenied {
+		log.Warn("chat ownership verification failed",
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID))
+		errors.AbortWithForbidden(c, errors.ChatNotOwned(chatID))
+		return false
+	}
+	log.Error("failed to verify chat ownership",
+		slog.String("error", err.Error()),
+		slog.String("user_id", userID),
+		slog.String("chat_id", chatID))
+	errors.Internal(c, "Failed to verify permissions", nil)
+	return false
+}
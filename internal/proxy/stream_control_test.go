@@ -1,7 +1,9 @@
 package proxy
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -73,8 +75,8 @@ func setupTestRouter(streamManager *streaming.StreamManager, log *logger.Logger)
 	})
 
 	// Register routes
-	// Pass nil for firestoreClient in tests to skip authorization checks
-	// This keeps tests focused on handler logic rather than firestore integration
+	// Pass nil for chatStore in tests to skip authorization checks
+	// This keeps tests focused on handler logic rather than chat store integration
 	api := router.Group("/api/v1")
 	{
 		chats := api.Group("/chats")
@@ -82,8 +84,13 @@ func setupTestRouter(streamManager *streaming.StreamManager, log *logger.Logger)
 			messages := chats.Group("/:chatId/messages")
 			{
 				messages.POST("/:messageId/stop", StopStreamHandler(log, streamManager, nil))
+				messages.GET("/:messageId/stream", ResumeStreamHandler(log, streamManager, nil))
+				messages.GET("/:messageId/chunks", StreamChunksHandler(log, streamManager, nil))
 			}
 		}
+		api.POST("/streams/:chatId/:messageId/stop", StopStreamHandler(log, streamManager, nil))
+		api.GET("/streams/:chatId/active", ActiveStreamHandler(log, streamManager, nil))
+		api.GET("/streams/:chatId/:messageId/replay", ResumeStreamHandler(log, streamManager, nil))
 	}
 
 	return router
@@ -101,7 +108,7 @@ func TestStopStreamHandler_Success(t *testing.T) {
 	lines = append(lines, "data: [DONE]")
 
 	body := newSlowMockSSEStream(lines, 200*time.Millisecond)
-	session, _ := streamManager.GetOrCreateSession("chat-123", "msg-456", body)
+	session, _ := streamManager.GetOrCreateSession(context.Background(), "chat-123", "msg-456", body)
 
 	// Give stream time to start but not complete
 	time.Sleep(300 * time.Millisecond)
@@ -141,6 +148,33 @@ func TestStopStreamHandler_Success(t *testing.T) {
 	}
 }
 
+func TestStopStreamHandler_StreamsRoute(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	streamManager := streaming.NewStreamManager(nil, log)
+
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = "data: {\"choices\":[{\"delta\":{\"content\":\"test\"}}]}"
+	}
+	lines = append(lines, "data: [DONE]")
+
+	session, _ := streamManager.GetOrCreateSession(context.Background(), "chat-123", "msg-456", newSlowMockSSEStream(lines, 200*time.Millisecond))
+	time.Sleep(300 * time.Millisecond)
+
+	router := setupTestRouter(streamManager, log)
+	req := httptest.NewRequest("POST", "/api/v1/streams/chat-123/msg-456/stop", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	stoppedBy, reason := session.GetStopInfo()
+	if !session.IsStopped() || stoppedBy != "test-user-123" || reason != streaming.StopReasonUserCancelled {
+		t.Errorf("stopped = %v by %q for %q, want stopped by test-user-123 for user_cancelled", session.IsStopped(), stoppedBy, reason)
+	}
+}
+
 func TestStopStreamHandler_NotFound(t *testing.T) {
 	log := logger.New(logger.Config{Level: slog.LevelError})
 	streamManager := streaming.NewStreamManager(nil, log)
@@ -172,7 +206,7 @@ func TestStopStreamHandler_AlreadyCompleted(t *testing.T) {
 		"data: [DONE]",
 	}
 	body := newMockSSEStream(lines)
-	_, _ = streamManager.GetOrCreateSession("chat-123", "msg-456", body)
+	_, _ = streamManager.GetOrCreateSession(context.Background(), "chat-123", "msg-456", body)
 
 	// Wait for completion
 	time.Sleep(100 * time.Millisecond)
@@ -242,6 +276,7 @@ func TestStopStreamHandler_Unauthenticated(t *testing.T) {
 			messages := chats.Group("/:chatId/messages")
 			{
 				messages.POST("/:messageId/stop", StopStreamHandler(log, streamManager, nil))
+				messages.GET("/:messageId/stream", ResumeStreamHandler(log, streamManager, nil))
 			}
 		}
 	}
@@ -276,7 +311,7 @@ func TestStopStreamHandler_ConcurrentStops(t *testing.T) {
 	body := newSlowMockSSEStream(lines, 200*time.Millisecond)
 
 	// Start session
-	_, _ = streamManager.GetOrCreateSession("chat-concurrent", "msg-concurrent", body)
+	_, _ = streamManager.GetOrCreateSession(context.Background(), "chat-concurrent", "msg-concurrent", body)
 	time.Sleep(200 * time.Millisecond) // Ensure stream is running
 
 	router := setupTestRouter(streamManager, log)
@@ -333,3 +368,164 @@ func TestStopStreamHandler_MaxLengthIDs(t *testing.T) {
 		t.Errorf("expected status 404 for valid-length IDs, got %d", w.Code)
 	}
 }
+
+func TestResumeStreamHandler(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	streamManager := streaming.NewStreamManager(nil, log)
+	defer streamManager.Shutdown()
+	router := setupTestRouter(streamManager, log)
+
+	req := httptest.NewRequest("GET", "/api/v1/chats/chat-123/messages/msg-456/stream", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown stream: expected status 404, got %d", w.Code)
+	}
+
+	lines := []string{
+		"data: {\"choices\":[{\"delta\":{\"content\":\"test\"}}]}",
+		"data: [DONE]",
+	}
+	session, _ := streamManager.GetOrCreateSession(context.Background(), "chat-123", "msg-456", newMockSSEStream(lines))
+	session.WaitForCompletion()
+
+	// A reconnecting client gets the whole response replayed
+	req = httptest.NewRequest("GET", "/api/v1/chats/chat-123/messages/msg-456/stream", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"content":"test"`) || !strings.Contains(body, "data: [DONE]") {
+		t.Errorf("replayed stream = %q", body)
+	}
+}
+
+func TestActiveStreamHandler(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	streamManager := streaming.NewStreamManager(nil, log)
+	defer streamManager.Shutdown()
+	router := setupTestRouter(streamManager, log)
+
+	req := httptest.NewRequest("GET", "/api/v1/streams/chat-123/active", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("no stream: expected status 404, got %d", w.Code)
+	}
+
+	reader, writer := io.Pipe()
+	session, _ := streamManager.GetOrCreateSession(context.Background(), "chat-123", "msg-456", reader)
+	if _, err := writer.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"test\"}}]}\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/streams/chat-123/active", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var info streaming.StreamInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.MessageID != "msg-456" || info.Completed {
+		t.Errorf("active stream = %+v, want msg-456 in progress", info)
+	}
+
+	// Rejoining replays the buffered chunks and follows the rest
+	replayed := make(chan string)
+	go func() {
+		req := httptest.NewRequest("GET", "/api/v1/streams/chat-123/msg-456/replay", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		replayed <- w.Body.String()
+	}()
+	if _, err := writer.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"more\"}}]}\ndata: [DONE]\n")); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	if body := <-replayed; !strings.Contains(body, `"content":"test"`) || !strings.Contains(body, `"content":"more"`) || !strings.Contains(body, "data: [DONE]") {
+		t.Errorf("replayed stream = %q", body)
+	}
+
+	session.WaitForCompletion()
+	req = httptest.NewRequest("GET", "/api/v1/streams/chat-123/active", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("completed stream: expected status 404, got %d", w.Code)
+	}
+}
+
+func TestStreamChunksHandler(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	streamManager := streaming.NewStreamManager(nil, log)
+	defer streamManager.Shutdown()
+	router := setupTestRouter(streamManager, log)
+
+	poll := func(query string) (int, StreamChunksResponse) {
+		req := httptest.NewRequest("GET", "/api/v1/chats/chat-123/messages/msg-456/chunks"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp StreamChunksResponse
+		if w.Code == http.StatusOK {
+			_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		}
+		return w.Code, resp
+	}
+
+	if code, _ := poll(""); code != http.StatusNotFound {
+		t.Errorf("unknown stream: expected status 404, got %d", code)
+	}
+
+	reader, writer := io.Pipe()
+	session, _ := streamManager.GetOrCreateSession(context.Background(), "chat-123", "msg-456", reader)
+	if _, err := writer.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"test\"}}]}\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	code, first := poll("")
+	if code != http.StatusOK || len(first.Chunks) != 1 || !strings.Contains(first.Chunks[0].Line, `"content":"test"`) || first.Done {
+		t.Fatalf("first poll = %d %+v, want the first chunk", code, first)
+	}
+
+	// Nothing new: the poll returns no chunks and the same position once the wait is over
+	if code, empty := poll(fmt.Sprintf("?after=%d&wait=0", first.Next)); code != http.StatusOK || len(empty.Chunks) != 0 || empty.Next != first.Next || empty.Done {
+		t.Errorf("empty poll = %d %+v", code, empty)
+	}
+
+	// A waiting poll returns as soon as new chunks arrive
+	polled := make(chan StreamChunksResponse)
+	go func() {
+		_, resp := poll(fmt.Sprintf("?after=%d", first.Next))
+		polled <- resp
+	}()
+	if _, err := writer.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"more\"}}]}\ndata: [DONE]\n")); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	select {
+	case next := <-polled:
+		if len(next.Chunks) == 0 || !strings.Contains(next.Chunks[0].Line, `"content":"more"`) || next.Next <= first.Next {
+			t.Errorf("waiting poll = %+v, want the new chunks", next)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting poll didn't return after new chunks")
+	}
+
+	session.WaitForCompletion()
+	code, all := poll("?after=-1")
+	if code != http.StatusOK || !all.Done || len(all.Chunks) < 3 || !strings.Contains(all.Chunks[len(all.Chunks)-1].Line, "[DONE]") {
+		t.Errorf("poll after completion = %d %+v, want all chunks and done", code, all)
+	}
+
+	if code, _ := poll("?after=x"); code != http.StatusBadRequest {
+		t.Errorf("invalid after: expected status 400, got %d", code)
+	}
+}
@@ -9,12 +9,15 @@ import (
 	"net/http"
 
 	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/errors"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
 	"github.com/eternisai/enchanted-proxy/internal/streaming"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // streamToClient streams chunks from a subscriber to an HTTP client.
@@ -57,6 +60,9 @@ func streamToClient(c *gin.Context, subscriber *streaming.StreamSubscriber, sess
 		return
 	}
 
+	writeDeprecationEvent(c, flusher)
+	writeCompactionEvent(c, flusher)
+
 	// Stream chunks to client
 	chunksWritten := 0
 	for {
@@ -147,7 +153,7 @@ func streamToClient(c *gin.Context, subscriber *streaming.StreamSubscriber, sess
 //   - messageService: Service for storing messages
 //   - log: Logger for this operation
 func saveCompletedStreamMessage(c *gin.Context, session *streaming.StreamSession, messageService *messaging.Service, log *logger.Logger) {
-	if messageService == nil {
+	if messageService == nil || privacy.SkipStorage(c.Request.Context()) {
 		return
 	}
 
@@ -211,6 +217,9 @@ func saveCompletedStreamMessage(c *gin.Context, session *streaming.StreamSession
 		Stopped:           stopped,
 		StoppedBy:         stoppedBy,
 		StopReason:        string(stopReason),
+		Reasoning:         session.GetReasoning(),
+		DebugTrace:        logger.DebugTraceFromContext(c.Request.Context()),
+		SpanContext:       trace.SpanContextFromContext(c.Request.Context()),
 	}
 
 	// Store asynchronously (with background context - shouldn't be tied to request)
@@ -222,6 +231,21 @@ func saveCompletedStreamMessage(c *gin.Context, session *streaming.StreamSession
 	}
 }
 
+// getReasoningVisibility resolves how reasoning output should be delivered for this request.
+// The X-Reasoning-Visibility header ("show", "separate", "strip") overrides the server default.
+// Unrecognized values fall back to the default, and an invalid default falls back to "show".
+func getReasoningVisibility(c *gin.Context, cfg *config.Config) streaming.ReasoningVisibility {
+	if visibility, ok := streaming.ParseReasoningVisibility(c.GetHeader("X-Reasoning-Visibility")); ok {
+		return visibility
+	}
+	if cfg != nil {
+		if visibility, ok := streaming.ParseReasoningVisibility(cfg.ReasoningVisibilityDefault); ok {
+			return visibility
+		}
+	}
+	return streaming.ReasoningVisibilityShow
+}
+
 // makeSessionKey creates a session key from chat ID and message ID.
 // Format: "chatID:messageID"
 func makeSessionKey(chatID, messageID string) string {
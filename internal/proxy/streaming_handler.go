@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
 	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/preferences"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
 	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
 	"github.com/eternisai/enchanted-proxy/internal/routing"
 	"github.com/eternisai/enchanted-proxy/internal/streaming"
@@ -143,7 +146,7 @@ func handleStreamingWithBroadcast(
 		log.Debug("creating new session with upstream body (no pending session found)",
 			slog.String("chat_id", chatID),
 			slog.String("message_id", messageID))
-		session, isNew = streamManager.GetOrCreateSession(chatID, messageID, resp.Body)
+		session, isNew = streamManager.GetOrCreateSession(c.Request.Context(), chatID, messageID, resp.Body)
 	}
 
 	// Set original request body and provider config (needed for tool execution and continuation)
@@ -151,6 +154,9 @@ func handleStreamingWithBroadcast(
 	if isNew {
 		// Set model for model-specific content filtering (e.g., GLM <tool_call> XML stripping)
 		session.SetModel(model)
+		session.SetReasoningVisibility(getReasoningVisibility(c, cfg))
+		session.SetClientCapabilities(capabilities.FromGin(c))
+		session.SetContentFilter(streamManager.NewContentFilter(string(preferences.ContentPolicy(c))))
 
 		if requestBody, exists := c.Get("originalRequestBody"); exists {
 			if bodyBytes, ok := requestBody.([]byte); ok {
@@ -159,6 +165,7 @@ func handleStreamingWithBroadcast(
 					slog.Int("body_size", len(bodyBytes)))
 			}
 		}
+		session.SetAllowedTools(allowedTools(c))
 
 		// Set provider config for continuation requests
 		if upstreamURL, exists := c.Get("upstreamURL"); exists {
@@ -171,10 +178,11 @@ func handleStreamingWithBroadcast(
 				session.SetUpstreamAPIKey(keyStr)
 			}
 		}
+		session.SetUpstreamAPIType(provider.APIType)
 
 		// For GPT-5.5 Pro, save placeholder message immediately to allow client reconnection.
 		// Legacy Pro model IDs are kept here because older clients may still send them.
-		if isGPT5ProModel(model) && messageService != nil {
+		if isGPT5ProModel(model) && messageService != nil && !privacy.SkipStorage(c.Request.Context()) {
 			userID, exists := auth.GetUserID(c)
 			if exists {
 				// Extract encryption setting
@@ -215,7 +223,7 @@ func handleStreamingWithBroadcast(
 
 	// After streaming completes, save message if this was a new session
 	// (Only the first subscriber saves to avoid duplicates)
-	if isNew && session.IsCompleted() {
+	if isNew && session.IsCompleted() && !privacy.SkipStorage(c.Request.Context()) {
 		// Extract encryption setting
 		var encryptionEnabled *bool
 		if val, exists := c.Get("encryptionEnabled"); exists {
@@ -243,6 +251,10 @@ func handleStreamingWithBroadcast(
 	// Also require session completion — if the client disconnected early,
 	// the upstream reader may not have populated token usage yet.
 	if isNew && session.IsCompleted() {
+		if provider != nil {
+			reportStreamAnomalies(log, session, provider.Name, model)
+		}
+
 		var tokenUsage *Usage
 		if sessionUsage := session.GetTokenUsage(); sessionUsage != nil {
 			tokenUsage = &Usage{
@@ -0,0 +1,219 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/structuredoutput"
+	"github.com/gin-gonic/gin"
+)
+
+// structuredOutputHeader reports the result of validating a structured output completion:
+// "valid", "repaired" (valid after the repair retry) or "invalid".
+const structuredOutputHeader = "X-Structured-Output"
+
+// repairClient sends structured output repair requests (rare, so not pooled with the proxy).
+var repairClient = &http.Client{Timeout: 2 * time.Minute}
+
+// requestOutputFormat returns the JSON response format of a chat completions request body
+// (nil = unconstrained or unparsable; the format was checked by validateRequestSchema).
+func requestOutputFormat(body []byte) *structuredoutput.Format {
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil
+	}
+	format, err := structuredoutput.ParseResponseFormat(req["response_format"])
+	if err != nil {
+		return nil
+	}
+	return format
+}
+
+// withSchemaInstruction replaces a json_schema response format with a system message asking
+// for JSON matching the schema, for models without native structured output support.
+func withSchemaInstruction(body []byte, format *structuredoutput.Format) []byte {
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body
+	}
+	messages, ok := req["messages"].([]interface{})
+	if !ok {
+		return body
+	}
+
+	delete(req, "response_format")
+	instruction := map[string]interface{}{"role": "system", "content": format.Instruction()}
+	req["messages"] = append([]interface{}{instruction}, messages...)
+
+	modified, err := json.Marshal(req)
+	if err != nil {
+		return body
+	}
+	return modified
+}
+
+// withRepairPrompt returns a chat completions request body continuing the conversation with the
+// invalid completion and a user message asking the model to fix it.
+func withRepairPrompt(body []byte, format *structuredoutput.Format, content string, validationErr error) ([]byte, error) {
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	messages, ok := req["messages"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("request has no messages")
+	}
+
+	req["messages"] = append(messages,
+		map[string]interface{}{"role": "assistant", "content": content},
+		map[string]interface{}{"role": "user", "content": format.RepairPrompt(validationErr)},
+	)
+	return json.Marshal(req)
+}
+
+// completionOutput returns the content of the first choice of a chat completion, and whether
+// it is meant to be validated (tool calls and refusals are not).
+func completionOutput(body []byte) (string, bool) {
+	var parsed struct {
+		Choices []struct {
+			Message struct {
+				Content   string            `json:"content"`
+				Refusal   string            `json:"refusal"`
+				ToolCalls []json.RawMessage `json:"tool_calls"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil || len(parsed.Choices) == 0 {
+		return "", false
+	}
+
+	choice := parsed.Choices[0]
+	if choice.FinishReason == "tool_calls" || len(choice.Message.ToolCalls) > 0 || choice.Message.Refusal != "" {
+		return "", false
+	}
+	return choice.Message.Content, true
+}
+
+// checkStructuredOutput validates a non-streaming chat completion against the request's response
+// format. An invalid completion is retried once on the same provider with a repair prompt; the
+// retried response replaces resp if the provider answers, and the replaced attempt's token usage
+// is recorded here (the final response's by handleNonStreamingResponse). Streaming completions
+// are passed through unvalidated.
+func checkStructuredOutput(
+	c *gin.Context,
+	resp *http.Response,
+	format *structuredoutput.Format,
+	requestBody []byte,
+	provider *routing.ProviderConfig,
+	model string,
+	canonicalModel string,
+	log *logger.Logger,
+	trackingService *request_tracking.Service,
+) {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || resp.Body == nil {
+		return
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+	if err != nil {
+		return
+	}
+
+	content, ok := completionOutput(responseBody)
+	if !ok {
+		return
+	}
+	validationErr := format.Validate(content)
+	if validationErr == nil {
+		metrics.RecordStructuredOutputValidation(provider.Name, canonicalModel, "valid")
+		resp.Header.Set(structuredOutputHeader, "valid")
+		return
+	}
+
+	log.Warn("structured output failed validation, retrying with repair prompt",
+		slog.String("model", model),
+		slog.String("provider", provider.Name),
+		slog.String("format", format.Type),
+		slog.String("reason", validationErr.Error()))
+
+	repairedBody, err := repairStructuredOutput(c, format, requestBody, content, validationErr, provider)
+	if err != nil {
+		// The original response is returned as is
+		log.Error("structured output repair request failed",
+			slog.String("model", model),
+			slog.String("provider", provider.Name),
+			slog.String("error", err.Error()))
+		metrics.RecordStructuredOutputValidation(provider.Name, canonicalModel, "invalid")
+		resp.Header.Set(structuredOutputHeader, "invalid")
+		return
+	}
+
+	// The first attempt is billed too, although the repaired response replaces it
+	logRequestToDatabaseWithProvider(c, trackingService, log, model, extractTokenUsage(responseBody), provider.Name, provider.TokenMultiplier)
+
+	result := "repaired"
+	if content, ok := completionOutput(repairedBody); ok {
+		if err := format.Validate(content); err != nil {
+			result = "invalid"
+			log.Warn("structured output still invalid after repair",
+				slog.String("model", model),
+				slog.String("provider", provider.Name),
+				slog.String("reason", err.Error()))
+		}
+	}
+	metrics.RecordStructuredOutputValidation(provider.Name, canonicalModel, result)
+
+	resp.Body = io.NopCloser(bytes.NewReader(repairedBody))
+	resp.ContentLength = int64(len(repairedBody))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(repairedBody)))
+	resp.Header.Set(structuredOutputHeader, result)
+}
+
+// repairStructuredOutput sends the repair request to the provider and returns the response body.
+func repairStructuredOutput(
+	c *gin.Context,
+	format *structuredoutput.Format,
+	requestBody []byte,
+	content string,
+	validationErr error,
+	provider *routing.ProviderConfig,
+) ([]byte, error) {
+	repairBody, err := withRepairPrompt(requestBody, format, content, validationErr)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newChatCompletionRequest(c.Request.Context(), provider, provider.BaseURL, provider.APIKey, c.Request.URL.Path, repairBody, c.Request.Header)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := repairClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("provider returned %d", resp.StatusCode)
+	}
+	return chatCompletionBody(provider, resp.StatusCode, body), nil
+}
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/gin-gonic/gin"
+)
+
+func completionBody(content string) string {
+	body, _ := json.Marshal(map[string]interface{}{
+		"choices": []interface{}{map[string]interface{}{
+			"message":       map[string]interface{}{"role": "assistant", "content": content},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]interface{}{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+	})
+	return string(body)
+}
+
+func TestCheckStructuredOutput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.New(logger.Config{Level: slog.LevelError})
+
+	requestBody := []byte(`{"model":"m","messages":[{"role":"user","content":"who?"}],"response_format":{"type":"json_schema","json_schema":{"name":"person","schema":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}}}}`)
+	format := requestOutputFormat(requestBody)
+	if format == nil {
+		t.Fatal("expected a response format")
+	}
+
+	tests := []struct {
+		name         string
+		content      string
+		repairStatus int
+		repair       string
+		wantResult   string
+		wantContent  string
+		wantRepairs  int
+	}{
+		{name: "valid", content: `{"name":"Ada"}`, wantResult: "valid", wantContent: `{"name":"Ada"}`},
+		{name: "repaired", content: `Sure! {"name":"Ada"}`, repairStatus: http.StatusOK, repair: `{"name":"Ada"}`, wantResult: "repaired", wantContent: `{"name":"Ada"}`, wantRepairs: 1},
+		{name: "still invalid", content: `{"who":"Ada"}`, repairStatus: http.StatusOK, repair: `{"who":"Ada"}`, wantResult: "invalid", wantContent: `{"who":"Ada"}`, wantRepairs: 1},
+		{name: "repair failed", content: `{"who":"Ada"}`, repairStatus: http.StatusInternalServerError, wantResult: "invalid", wantContent: `{"who":"Ada"}`, wantRepairs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repairs := 0
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				repairs++
+				var req struct {
+					Messages []map[string]interface{} `json:"messages"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&req)
+				if len(req.Messages) != 3 || req.Messages[1]["content"] != tt.content || !strings.Contains(req.Messages[2]["content"].(string), "invalid") {
+					t.Errorf("unexpected repair messages: %v", req.Messages)
+				}
+				if r.Header.Get("Authorization") != "Bearer sk-test" {
+					t.Errorf("missing provider API key")
+				}
+				w.WriteHeader(tt.repairStatus)
+				_, _ = io.WriteString(w, completionBody(tt.repair))
+			}))
+			defer upstream.Close()
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			provider := &routing.ProviderConfig{Name: "Test", BaseURL: upstream.URL, APIKey: "sk-test", TokenMultiplier: 1}
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(completionBody(tt.content))),
+			}
+
+			checkStructuredOutput(c, resp, format, requestBody, provider, "m", "m", log, nil)
+
+			if got := resp.Header.Get(structuredOutputHeader); got != tt.wantResult {
+				t.Errorf("result = %q, want %q", got, tt.wantResult)
+			}
+			if repairs != tt.wantRepairs {
+				t.Errorf("repair requests = %d, want %d", repairs, tt.wantRepairs)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if got := extractContentFromResponse(body); got != tt.wantContent {
+				t.Errorf("content = %q, want %q", got, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestWithSchemaInstruction(t *testing.T) {
+	body := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"person","schema":{"type":"object"}}}}`)
+	format := requestOutputFormat(body)
+
+	var got struct {
+		Messages       []map[string]interface{} `json:"messages"`
+		ResponseFormat interface{}              `json:"response_format"`
+	}
+	if err := json.Unmarshal(withSchemaInstruction(body, format), &got); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if got.ResponseFormat != nil {
+		t.Errorf("response_format was not removed")
+	}
+	if len(got.Messages) != 2 || got.Messages[0]["role"] != "system" || !strings.Contains(got.Messages[0]["content"].(string), `"person"`) {
+		t.Errorf("unexpected messages: %v", got.Messages)
+	}
+}
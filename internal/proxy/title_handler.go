@@ -3,6 +3,8 @@ package proxy
 import (
 	"context"
 
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
 	"github.com/eternisai/enchanted-proxy/internal/title_generation"
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +16,7 @@ type TitleGenerationParams struct {
 	Model             string
 	BaseURL           string
 	APIKey            string
+	APIType           config.APIType // Provider API format ("" = chat completions)
 	Platform          string
 	EncryptionEnabled *bool
 }
@@ -29,6 +32,11 @@ func TriggerTitleGeneration(
 		return
 	}
 
+	// Privacy and ephemeral mode chats are not stored server-side, so they get no title
+	if privacy.SkipStorage(c.Request.Context()) {
+		return
+	}
+
 	if params.UserID == "" || params.ChatID == "" {
 		return
 	}
@@ -41,6 +49,7 @@ func TriggerTitleGeneration(
 				Model:       params.Model,
 				BaseURL:     params.BaseURL,
 				APIKey:      params.APIKey,
+				APIType:     params.APIType,
 				UserContent: firstMessage,
 			},
 			title_generation.StorageRequest{
@@ -61,6 +70,7 @@ func TriggerTitleGeneration(
 				Model:   params.Model,
 				BaseURL: params.BaseURL,
 				APIKey:  params.APIKey,
+				APIType: params.APIType,
 			},
 			title_generation.RegenerationContext{
 				FirstUserMessage:  convCtx.FirstUserMessage,
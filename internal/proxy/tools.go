@@ -0,0 +1,27 @@
+package proxy
+
+import (
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/eternisai/enchanted-proxy/internal/tools"
+	"github.com/gin-gonic/gin"
+)
+
+// allowedTools returns the names of the server-side tools the user's tier may use. Requests
+// without a tier get no tools.
+func allowedTools(c *gin.Context) []string {
+	val, exists := c.Get("tierConfig")
+	if !exists {
+		return nil
+	}
+	tierConfig, ok := val.(tiers.Config)
+	if !ok {
+		return nil
+	}
+	return tierConfig.AllowedTools
+}
+
+// toolDefinitions returns the registered tool definitions the user's tier may use. The tools
+// of the injected definitions are the only ones offered in tool-call continuations.
+func toolDefinitions(c *gin.Context, registry *tools.Registry) []tools.ToolDefinition {
+	return registry.GetDefinitionsFor(allowedTools(c))
+}
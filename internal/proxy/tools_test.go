@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/eternisai/enchanted-proxy/internal/tools"
+	"github.com/gin-gonic/gin"
+)
+
+type namedTool struct{ name string }
+
+func (t namedTool) Name() string { return t.name }
+
+func (t namedTool) Definition() tools.ToolDefinition {
+	return tools.ToolDefinition{Type: "function", Function: tools.FunctionDef{Name: t.name}}
+}
+
+func (t namedTool) Execute(ctx context.Context, args string) (string, error) { return "", nil }
+
+func TestToolDefinitions(t *testing.T) {
+	registry := tools.NewRegistry()
+	for _, name := range []string{"web_search", "fetch_url", "schedule_task"} {
+		if err := registry.Register(namedTool{name: name}); err != nil {
+			t.Fatalf("Register(%s) failed: %v", name, err)
+		}
+	}
+
+	searchOnly := tiers.Configs[tiers.TierPro]
+	searchOnly.AllowedTools = []string{"web_search", "unregistered"}
+
+	tests := []struct {
+		name       string
+		tierConfig *tiers.Config
+		want       []string
+	}{
+		{name: "no tier config", want: []string{}},
+		{name: "free tier", tierConfig: ptr(tiers.Configs[tiers.TierFree]), want: []string{}},
+		{name: "pro tier", tierConfig: ptr(tiers.Configs[tiers.TierPro]), want: []string{"web_search", "fetch_url", "schedule_task"}},
+		{name: "unregistered tools skipped", tierConfig: &searchOnly, want: []string{"web_search"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			if tt.tierConfig != nil {
+				c.Set("tierConfig", *tt.tierConfig)
+			}
+
+			names := []string{}
+			for _, definition := range toolDefinitions(c, registry) {
+				names = append(names, definition.Function.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("injected tools = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+// responseShapeHeaders describe the body itself and are always kept on proxied
+// (non-streaming) responses, regardless of the passthrough allowlist.
+var responseShapeHeaders = map[string]struct{}{
+	"Content-Encoding": {},
+	"Content-Language": {},
+	"Content-Length":   {},
+	"Content-Type":     {},
+	"Retry-After":      {},
+}
+
+// headerPolicy decides which upstream response headers reach the client.
+// A nil policy means no passthrough is configured and existing behavior is kept:
+// streaming responses forward nothing, non-streaming responses forward everything.
+type headerPolicy struct {
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+// newHeaderPolicy builds a policy from validated config. Returns nil if cfg is nil.
+func newHeaderPolicy(cfg *config.UpstreamHeadersConfig) *headerPolicy {
+	if cfg == nil {
+		return nil
+	}
+
+	p := &headerPolicy{exact: make(map[string]struct{})}
+	for _, name := range cfg.Passthrough {
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			p.prefixes = append(p.prefixes, strings.ToLower(prefix))
+		} else {
+			p.exact[http.CanonicalHeaderKey(name)] = struct{}{}
+		}
+	}
+	return p
+}
+
+// allowed reports whether the canonical header name is on the allowlist.
+func (p *headerPolicy) allowed(name string) bool {
+	if p == nil {
+		return false
+	}
+	if _, ok := p.exact[name]; ok {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyAllowed copies allowlisted headers from an upstream response onto the client response.
+func (p *headerPolicy) copyAllowed(dst, src http.Header) {
+	for name, values := range src {
+		if p.allowed(name) {
+			dst[name] = append([]string(nil), values...)
+		}
+	}
+}
+
+// filter removes headers from a proxied upstream response that are neither
+// allowlisted nor describe the body. No-op for a nil policy.
+func (p *headerPolicy) filter(h http.Header) {
+	if p == nil {
+		return
+	}
+	for name := range h {
+		if _, ok := responseShapeHeaders[name]; ok {
+			continue
+		}
+		if !p.allowed(name) {
+			h.Del(name)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+func TestUpstreamHeadersConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "canonicalizes names and prefixes",
+			in:   []string{"openai-processing-ms", " X-RateLimit-* "},
+			want: []string{"Openai-Processing-Ms", "x-ratelimit-*"},
+		},
+		{name: "empty", in: []string{"*"}, wantErr: "is empty"},
+		{name: "inner wildcard", in: []string{"x-*-id"}, wantErr: "invalid passthrough header"},
+		{name: "set-cookie", in: []string{"set-cookie"}, wantErr: "would forward Set-Cookie"},
+		{name: "prefix covering forbidden", in: []string{"transfer-*"}, wantErr: "would forward Transfer-Encoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.UpstreamHeadersConfig{Passthrough: tt.in}
+			err := cfg.Validate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Validate() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			for i := range tt.want {
+				if cfg.Passthrough[i] != tt.want[i] {
+					t.Errorf("Passthrough[%d] = %q, want %q", i, cfg.Passthrough[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHeaderPolicy(t *testing.T) {
+	policy := newHeaderPolicy(&config.UpstreamHeadersConfig{
+		Passthrough: []string{"x-ratelimit-*", "Openai-Processing-Ms"},
+	})
+
+	upstream := func() http.Header {
+		h := http.Header{}
+		h.Set("Content-Type", "application/json")
+		h.Set("Retry-After", "3")
+		h.Set("X-Ratelimit-Remaining-Requests", "99")
+		h.Set("Openai-Processing-Ms", "120")
+		h.Set("Set-Cookie", "__cf_bm=abc")
+		h.Set("Openai-Organization", "org-123")
+		return h
+	}
+
+	t.Run("copyAllowed forwards only allowlisted", func(t *testing.T) {
+		dst := http.Header{}
+		policy.copyAllowed(dst, upstream())
+		if len(dst) != 2 || dst.Get("X-Ratelimit-Remaining-Requests") != "99" || dst.Get("Openai-Processing-Ms") != "120" {
+			t.Errorf("copyAllowed() = %v", dst)
+		}
+	})
+
+	t.Run("filter keeps body headers and allowlisted", func(t *testing.T) {
+		h := upstream()
+		policy.filter(h)
+		for _, name := range []string{"Content-Type", "Retry-After", "X-Ratelimit-Remaining-Requests", "Openai-Processing-Ms"} {
+			if h.Get(name) == "" {
+				t.Errorf("filter() dropped %s", name)
+			}
+		}
+		for _, name := range []string{"Set-Cookie", "Openai-Organization"} {
+			if h.Get(name) != "" {
+				t.Errorf("filter() kept %s", name)
+			}
+		}
+	})
+
+	t.Run("nil policy keeps existing behavior", func(t *testing.T) {
+		var none *headerPolicy
+		h := upstream()
+		none.filter(h)
+		if len(h) != len(upstream()) {
+			t.Errorf("nil filter() modified headers: %v", h)
+		}
+		dst := http.Header{}
+		none.copyAllowed(dst, upstream())
+		if len(dst) != 0 {
+			t.Errorf("nil copyAllowed() = %v", dst)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+)
+
+const (
+	// defaultUpstreamRetryAfter is used when a provider 429 carries no usable timing hints.
+	defaultUpstreamRetryAfter = 5 * time.Second
+	minUpstreamRetryAfter     = time.Second
+	maxUpstreamRetryAfter     = time.Hour
+
+	// maxUpstreamErrorMessage bounds the provider message echoed in the error details.
+	maxUpstreamErrorMessage = 500
+)
+
+// rateLimitResetHeaders pairs a provider's reset header with its remaining-count header,
+// so the reset of an exhausted dimension (requests vs tokens) can be preferred.
+var rateLimitResetHeaders = []struct {
+	reset     string
+	remaining string
+}{
+	{"X-Ratelimit-Reset-Requests", "X-Ratelimit-Remaining-Requests"},                 // OpenAI, Groq (duration, e.g. "1s", "6m0s")
+	{"X-Ratelimit-Reset-Tokens", "X-Ratelimit-Remaining-Tokens"},                     // OpenAI, Groq
+	{"Anthropic-Ratelimit-Requests-Reset", "Anthropic-Ratelimit-Requests-Remaining"}, // Anthropic (RFC 3339)
+	{"Anthropic-Ratelimit-Tokens-Reset", "Anthropic-Ratelimit-Tokens-Remaining"},     // Anthropic
+	{"X-Ratelimit-Reset", "X-Ratelimit-Remaining"},                                   // OpenRouter (Unix epoch ms)
+}
+
+// upstreamRetryAfter computes how long a client should wait after an upstream 429.
+// Explicit retry headers win; otherwise the reset time of the exhausted limit is used,
+// falling back to the latest reset of any limit. The result is clamped to [1s, 1h].
+func upstreamRetryAfter(header http.Header, now time.Time) time.Duration {
+	delay, ok := explicitRetryAfter(header, now)
+	if !ok {
+		delay, ok = resetRetryAfter(header, now)
+	}
+	if !ok {
+		return defaultUpstreamRetryAfter
+	}
+	return min(max(delay, minUpstreamRetryAfter), maxUpstreamRetryAfter)
+}
+
+func explicitRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if raw := header.Get("Retry-After-Ms"); raw != "" {
+		if ms, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	if raw := strings.TrimSpace(header.Get("Retry-After")); raw != "" {
+		if seconds, err := strconv.ParseFloat(raw, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+		if at, err := http.ParseTime(raw); err == nil {
+			return at.Sub(now), true
+		}
+	}
+	return 0, false
+}
+
+func resetRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	var exhausted, latest time.Duration
+	var haveExhausted, haveAny bool
+
+	for _, h := range rateLimitResetHeaders {
+		delay, ok := parseResetHeader(header.Get(h.reset), now)
+		if !ok {
+			continue
+		}
+		if !haveAny || delay > latest {
+			latest = delay
+		}
+		haveAny = true
+
+		if remaining := strings.TrimSpace(header.Get(h.remaining)); remaining == "0" {
+			if !haveExhausted || delay > exhausted {
+				exhausted = delay
+			}
+			haveExhausted = true
+		}
+	}
+
+	if haveExhausted {
+		return exhausted, true
+	}
+	return latest, haveAny
+}
+
+// parseResetHeader accepts a Go-style duration ("6m0s"), an RFC 3339 timestamp,
+// or a Unix epoch in seconds or milliseconds.
+func parseResetHeader(raw string, now time.Time) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return d, true
+	}
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return at.Sub(now), true
+	}
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		switch {
+		case n > 1e12:
+			return time.UnixMilli(n).Sub(now), true
+		case n > 1e9:
+			return time.Unix(n, 0).Sub(now), true
+		default:
+			return time.Duration(n) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// upstreamErrorMessage extracts the provider's error message from an error body
+// ({"error":{"message":...}} or {"error":"..."}), falling back to the raw body.
+func upstreamErrorMessage(body []byte) string {
+	var parsed struct {
+		Error json.RawMessage `json:"error"`
+	}
+	message := string(bytes.TrimSpace(body))
+	if json.Unmarshal(body, &parsed) == nil && len(parsed.Error) > 0 {
+		var obj struct {
+			Message string `json:"message"`
+		}
+		var str string
+		if json.Unmarshal(parsed.Error, &obj) == nil && obj.Message != "" {
+			message = obj.Message
+		} else if json.Unmarshal(parsed.Error, &str) == nil && str != "" {
+			message = str
+		}
+	}
+	if len(message) > maxUpstreamErrorMessage {
+		// Cut at a rune boundary so multi-byte characters aren't split
+		end := maxUpstreamErrorMessage
+		for end > 0 && !utf8.RuneStart(message[end]) {
+			end--
+		}
+		message = message[:end]
+	}
+	return message
+}
+
+// normalizeUpstreamRateLimit builds the structured 429 returned to clients for an upstream rate limit.
+func normalizeUpstreamRateLimit(header http.Header, body []byte, model string, now time.Time) *errors.UpstreamRateLimitError {
+	return errors.UpstreamRateLimited(model, upstreamRetryAfter(header, now), upstreamErrorMessage(body))
+}
+
+// rewriteRateLimitResponse replaces a proxied upstream 429 with the structured error envelope.
+func rewriteRateLimitResponse(resp *http.Response, rlErr *errors.UpstreamRateLimitError) error {
+	body, err := json.Marshal(rlErr)
+	if err != nil {
+		return err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	resp.Header.Set("Retry-After", strconv.Itoa(rlErr.RetryAfter))
+	return nil
+}
@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestUpstreamRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    time.Duration
+	}{
+		{name: "no hints", want: defaultUpstreamRetryAfter},
+		{name: "retry-after seconds", headers: map[string]string{"Retry-After": "20"}, want: 20 * time.Second},
+		{name: "retry-after date", headers: map[string]string{"Retry-After": now.Add(90 * time.Second).Format(http.TimeFormat)}, want: 90 * time.Second},
+		{name: "retry-after-ms wins", headers: map[string]string{"Retry-After-Ms": "2500", "Retry-After": "20"}, want: 2500 * time.Millisecond},
+		{name: "sub-second clamped up", headers: map[string]string{"Retry-After-Ms": "20"}, want: time.Second},
+		{name: "huge clamped down", headers: map[string]string{"Retry-After": "86400"}, want: time.Hour},
+		{
+			name: "exhausted dimension preferred",
+			headers: map[string]string{
+				"X-Ratelimit-Reset-Requests":     "2s",
+				"X-Ratelimit-Remaining-Requests": "0",
+				"X-Ratelimit-Reset-Tokens":       "6m0s",
+				"X-Ratelimit-Remaining-Tokens":   "5000",
+			},
+			want: 2 * time.Second,
+		},
+		{
+			name:    "latest reset without remaining",
+			headers: map[string]string{"X-Ratelimit-Reset-Requests": "2s", "X-Ratelimit-Reset-Tokens": "30s"},
+			want:    30 * time.Second,
+		},
+		{
+			name:    "anthropic rfc3339",
+			headers: map[string]string{"Anthropic-Ratelimit-Tokens-Reset": now.Add(45 * time.Second).Format(time.RFC3339)},
+			want:    45 * time.Second,
+		},
+		{
+			name:    "openrouter epoch ms",
+			headers: map[string]string{"X-Ratelimit-Reset": "1748779210000", "X-Ratelimit-Remaining": "0"},
+			want:    10 * time.Second,
+		},
+		{name: "unparsable", headers: map[string]string{"Retry-After": "soon"}, want: defaultUpstreamRetryAfter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			if got := upstreamRetryAfter(header, now); got != tt.want {
+				t.Errorf("upstreamRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstreamErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "openai shape", body: `{"error":{"message":"Rate limit reached","type":"requests"}}`, want: "Rate limit reached"},
+		{name: "string error", body: `{"error":"Too many requests"}`, want: "Too many requests"},
+		{name: "plain text", body: " slow down \n", want: "slow down"},
+		{name: "empty", body: "", want: ""},
+		{name: "truncated", body: strings.Repeat("a", maxUpstreamErrorMessage+10), want: strings.Repeat("a", maxUpstreamErrorMessage)},
+		{name: "truncated at a rune boundary", body: strings.Repeat("a", maxUpstreamErrorMessage-1) + "é", want: strings.Repeat("a", maxUpstreamErrorMessage-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := upstreamErrorMessage([]byte(tt.body))
+			if got != tt.want {
+				t.Errorf("upstreamErrorMessage() = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("upstreamErrorMessage() = %q is not valid UTF-8", got)
+			}
+		})
+	}
+}
+
+func TestRewriteRateLimitResponse(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "12")
+	header.Set("Content-Encoding", "gzip")
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"Rate limit reached"}}`)),
+	}
+
+	rlErr := normalizeUpstreamRateLimit(resp.Header, []byte(`{"error":{"message":"Rate limit reached"}}`), "gpt-5", time.Now())
+	if err := rewriteRateLimitResponse(resp, rlErr); err != nil {
+		t.Fatalf("rewriteRateLimitResponse() error = %v", err)
+	}
+
+	if got := resp.Header.Get("Retry-After"); got != "12" {
+		t.Errorf("Retry-After = %q, want 12", got)
+	}
+	if resp.Header.Get("Content-Encoding") != "" {
+		t.Error("Content-Encoding was not removed")
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["rate_limit_type"] != "upstream" || body["retry_after"] != float64(12) || body["model"] != "gpt-5" {
+		t.Errorf("body = %v", body)
+	}
+}
@@ -0,0 +1,28 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"sync/atomic"
+)
+
+// connectionVerifier checks new TLS connections to upstream providers (nil = none).
+var connectionVerifier atomic.Pointer[func(tls.ConnectionState) error]
+
+// SetConnectionVerifier sets a check run on every new TLS connection of the upstream transports,
+// after certificate verification (e.g. enclave attestation key binding). Refused connections
+// fail the request like a connection error.
+func SetConnectionVerifier(verify func(tls.ConnectionState) error) {
+	connectionVerifier.Store(&verify)
+}
+
+// upstreamTLSConfig returns the TLS configuration of the upstream transports.
+func upstreamTLSConfig() *tls.Config {
+	return &tls.Config{
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if verify := connectionVerifier.Load(); verify != nil && *verify != nil {
+				return (*verify)(cs)
+			}
+			return nil
+		},
+	}
+}
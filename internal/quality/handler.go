@@ -0,0 +1,127 @@
+package quality
+
+import (
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles HTTP requests for message ratings and the quality scoreboard.
+type Handler struct {
+	service *Service
+	logger  *logger.Logger
+}
+
+// NewHandler creates a new quality handler.
+func NewHandler(service *Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RateMessage handles PUT /api/v1/chats/:chatId/messages/:messageId/feedback
+// Records the user's thumbs up or down on an assistant message.
+func (h *Handler) RateMessage(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("quality-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	chatID, messageID, ok := messageParams(c)
+	if !ok {
+		return
+	}
+
+	var req FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+	if err := h.service.RateMessage(c.Request.Context(), userID, chatID, messageID, req.Rating); err != nil {
+		if stderrors.Is(err, ErrInvalidRating) {
+			errors.BadRequest(c, err.Error(), nil)
+			return
+		}
+		if stderrors.Is(err, ErrMessageNotFound) {
+			errors.NotFound(c, err.Error(), nil)
+			return
+		}
+		log.Error("failed to rate message",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID))
+		errors.Internal(c, "failed to rate message", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteRating handles DELETE /api/v1/chats/:chatId/messages/:messageId/feedback
+// Removes the user's rating of a message.
+func (h *Handler) DeleteRating(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("quality-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	chatID, messageID, ok := messageParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRating(c.Request.Context(), userID, chatID, messageID); err != nil {
+		log.Error("failed to delete rating",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID),
+			slog.String("chat_id", chatID))
+		errors.Internal(c, "failed to delete rating", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetScoreboard handles GET /admin/v1/models/quality?window=24h|7d|30d
+// Returns per-model/provider quality scores over the window (7d by default) with their trend
+// against the previous window.
+func (h *Handler) GetScoreboard(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("quality-handler")
+
+	window := c.DefaultQuery("window", DefaultWindow)
+	scoreboard, err := h.service.Scoreboard(c.Request.Context(), window, time.Now())
+	if err != nil {
+		if stderrors.Is(err, ErrInvalidWindow) {
+			errors.BadRequest(c, err.Error(), nil)
+			return
+		}
+		log.Error("failed to compute quality scoreboard", slog.String("error", err.Error()))
+		errors.Internal(c, "failed to get model quality", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, scoreboard)
+}
+
+// messageParams returns the chat and message IDs of the route, writing a 400 if they're invalid.
+func messageParams(c *gin.Context) (string, string, bool) {
+	chatID, messageID := c.Param("chatId"), c.Param("messageId")
+	if chatID == "" || len(chatID) > MaxIDLength || messageID == "" || len(messageID) > MaxIDLength {
+		errors.BadRequest(c, "invalid chatId or messageId", nil)
+		return "", "", false
+	}
+	return chatID, messageID, true
+}
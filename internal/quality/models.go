@@ -0,0 +1,62 @@
+package quality
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultWindow is the scoreboard window when none is requested.
+	DefaultWindow = "7d"
+
+	// MaxIDLength caps chat and message IDs.
+	MaxIDLength = 256
+)
+
+// windows are the scoreboard windows; trends compare a window with the one before it.
+var windows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+var (
+	ErrInvalidRating   = errors.New("rating must be 1 or -1")
+	ErrInvalidWindow   = errors.New("window must be one of 24h, 7d or 30d")
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+// FeedbackRequest is the body of PUT /api/v1/chats/:chatId/messages/:messageId/feedback.
+type FeedbackRequest struct {
+	Rating int `json:"rating"` // 1 (thumbs up) or -1 (thumbs down)
+}
+
+// ModelScore is the quality of a model served by a provider over a window.
+//
+// Reliability is the share of requests whose stream wasn't flagged with an anomaly or retried;
+// satisfaction is the share of positive ratings of the model's messages (ratings aren't tied
+// to a provider), smoothed towards priorSatisfaction while there are few. Score blends both
+// into 0-100. Models rated but without requests in the window have an empty provider.
+type ModelScore struct {
+	Model         string   `json:"model"`
+	Provider      string   `json:"provider"`
+	Requests      int64    `json:"requests"`
+	Anomalous     int64    `json:"anomalous"`
+	Retried       int64    `json:"retried"`
+	Flagged       int64    `json:"flagged"`
+	Positive      int64    `json:"positive"`
+	Negative      int64    `json:"negative"`
+	Reliability   float64  `json:"reliability"`
+	Satisfaction  float64  `json:"satisfaction"`
+	Score         float64  `json:"score"`
+	PreviousScore *float64 `json:"previous_score,omitempty"` // Score over the previous window, if any
+	Trend         *float64 `json:"trend,omitempty"`          // Score - PreviousScore
+}
+
+// ScoreboardResponse is the response for GET /admin/v1/models/quality.
+type ScoreboardResponse struct {
+	Window string       `json:"window"`
+	From   time.Time    `json:"from"`
+	To     time.Time    `json:"to"`
+	Models []ModelScore `json:"models"`
+}
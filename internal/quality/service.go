@@ -0,0 +1,206 @@
+// Package quality scores models and their providers from stream anomaly signals in request
+// logs and user ratings of messages, for the admin scoreboard and, optionally, routing.
+package quality
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+const (
+	// priorSatisfaction and priorRatings smooth satisfaction: a model starts at 80% positive,
+	// weighted like 5 ratings, so a handful of ratings can't swing its score.
+	priorSatisfaction = 0.8
+	priorRatings      = 5
+
+	// reliabilityWeight is the weight of reliability in the score (the rest is satisfaction).
+	reliabilityWeight = 0.5
+)
+
+// Service records message ratings and computes quality scores.
+type Service struct {
+	queries      pgdb.Querier
+	resolveModel func(string) string
+}
+
+// NewService creates a new quality service. resolveModel maps the model names recorded in
+// logs and ratings to canonical names (e.g., ModelRouter.ResolveAlias), so aliases are scored
+// together; nil keeps names as recorded.
+func NewService(queries pgdb.Querier, resolveModel func(string) string) *Service {
+	if resolveModel == nil {
+		resolveModel = func(model string) string { return model }
+	}
+	return &Service{queries: queries, resolveModel: resolveModel}
+}
+
+// RateMessage records the user's rating of an assistant message, replacing any previous one.
+// Only messages of the user in the message index, with a known model, can be rated.
+func (s *Service) RateMessage(ctx context.Context, userID, chatID, messageID string, rating int) error {
+	if rating != 1 && rating != -1 {
+		return ErrInvalidRating
+	}
+
+	written, err := s.queries.UpsertMessageFeedback(ctx, pgdb.UpsertMessageFeedbackParams{
+		UserID:    userID,
+		ChatID:    chatID,
+		MessageID: messageID,
+		Rating:    int16(rating),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save rating: %w", err)
+	}
+	if written == 0 {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+// DeleteRating removes the user's rating of a message.
+func (s *Service) DeleteRating(ctx context.Context, userID, chatID, messageID string) error {
+	if err := s.queries.DeleteMessageFeedback(ctx, pgdb.DeleteMessageFeedbackParams{
+		UserID:    userID,
+		ChatID:    chatID,
+		MessageID: messageID,
+	}); err != nil {
+		return fmt.Errorf("failed to delete rating: %w", err)
+	}
+	return nil
+}
+
+// Scoreboard returns the scores of every model and provider over the window ending at now,
+// with their trend against the previous window, sorted by model then best score first.
+func (s *Service) Scoreboard(ctx context.Context, window string, now time.Time) (*ScoreboardResponse, error) {
+	length, ok := windows[window]
+	if !ok {
+		return nil, ErrInvalidWindow
+	}
+	from := now.Add(-length)
+
+	current, err := s.Scores(ctx, from, now)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.Scores(ctx, from.Add(-length), from)
+	if err != nil {
+		return nil, err
+	}
+
+	previousScores := make(map[scoreKey]float64, len(previous))
+	for _, score := range previous {
+		previousScores[scoreKey{score.Model, score.Provider}] = score.Score
+	}
+	for i := range current {
+		if prev, ok := previousScores[scoreKey{current[i].Model, current[i].Provider}]; ok {
+			trend := round(current[i].Score - prev)
+			current[i].PreviousScore = &prev
+			current[i].Trend = &trend
+		}
+	}
+
+	return &ScoreboardResponse{
+		Window: window,
+		From:   from,
+		To:     now,
+		Models: current,
+	}, nil
+}
+
+type scoreKey struct {
+	model    string
+	provider string
+}
+
+// Scores returns the scores of every model and provider with requests or ratings in
+// [from, to), sorted by model then best score first.
+func (s *Service) Scores(ctx context.Context, from, to time.Time) ([]ModelScore, error) {
+	signals, err := s.queries.GetModelRequestSignals(ctx, pgdb.GetModelRequestSignalsParams{FromTime: from, ToTime: to})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request signals: %w", err)
+	}
+	feedback, err := s.queries.GetModelFeedbackCounts(ctx, pgdb.GetModelFeedbackCountsParams{FromTime: from, ToTime: to})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feedback counts: %w", err)
+	}
+
+	// Merge aliases, then attach each model's ratings to all of its providers
+	byKey := make(map[scoreKey]*ModelScore)
+	for _, row := range signals {
+		key := scoreKey{s.resolveModel(row.Model), row.Provider}
+		score, ok := byKey[key]
+		if !ok {
+			score = &ModelScore{Model: key.model, Provider: key.provider}
+			byKey[key] = score
+		}
+		score.Requests += row.Requests
+		score.Anomalous += row.Anomalous
+		score.Retried += row.Retried
+		score.Flagged += row.Flagged
+	}
+
+	ratings := make(map[string][2]int64)
+	for _, row := range feedback {
+		model := s.resolveModel(row.Model)
+		counts := ratings[model]
+		counts[0] += row.Positive
+		counts[1] += row.Negative
+		ratings[model] = counts
+	}
+
+	scored := make(map[string]bool, len(ratings))
+	for _, score := range byKey {
+		counts := ratings[score.Model]
+		score.Positive, score.Negative = counts[0], counts[1]
+		scored[score.Model] = true
+	}
+	for model, counts := range ratings {
+		if !scored[model] {
+			byKey[scoreKey{model, ""}] = &ModelScore{Model: model, Positive: counts[0], Negative: counts[1]}
+		}
+	}
+
+	scores := make([]ModelScore, 0, len(byKey))
+	for _, score := range byKey {
+		score.Reliability = reliability(score.Requests, score.Flagged)
+		score.Satisfaction = satisfaction(score.Positive, score.Negative)
+		score.Score = round(100 * (reliabilityWeight*score.Reliability + (1-reliabilityWeight)*score.Satisfaction))
+		scores = append(scores, *score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Model != scores[j].Model {
+			return scores[i].Model < scores[j].Model
+		}
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Provider < scores[j].Provider
+	})
+	return scores, nil
+}
+
+// reliability is the share of requests that weren't flagged; 1 without requests.
+func reliability(requests, flagged int64) float64 {
+	if requests <= 0 {
+		return 1
+	}
+	return round4(1 - float64(flagged)/float64(requests))
+}
+
+// satisfaction is the smoothed share of positive ratings.
+func satisfaction(positive, negative int64) float64 {
+	return round4((float64(positive) + priorSatisfaction*priorRatings) / (float64(positive+negative) + priorRatings))
+}
+
+// round rounds a score to one decimal.
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// round4 rounds a ratio to four decimals.
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,134 @@
+package quality
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// signalStore returns canned signals for the current window (to_time == now) and the previous
+// one; other Querier methods are not used.
+type signalStore struct {
+	pgdb.Querier
+	now              time.Time
+	current, prev    []pgdb.GetModelRequestSignalsRow
+	feedback         []pgdb.GetModelFeedbackCountsRow
+	feedbackRowCount int64
+	rated            *pgdb.UpsertMessageFeedbackParams
+}
+
+func (s *signalStore) GetModelRequestSignals(_ context.Context, arg pgdb.GetModelRequestSignalsParams) ([]pgdb.GetModelRequestSignalsRow, error) {
+	if arg.ToTime.Equal(s.now) {
+		return s.current, nil
+	}
+	return s.prev, nil
+}
+
+func (s *signalStore) GetModelFeedbackCounts(_ context.Context, arg pgdb.GetModelFeedbackCountsParams) ([]pgdb.GetModelFeedbackCountsRow, error) {
+	if arg.ToTime.Equal(s.now) {
+		return s.feedback, nil
+	}
+	return nil, nil
+}
+
+func (s *signalStore) UpsertMessageFeedback(_ context.Context, arg pgdb.UpsertMessageFeedbackParams) (int64, error) {
+	s.rated = &arg
+	return s.feedbackRowCount, nil
+}
+
+func TestRateMessage(t *testing.T) {
+	store := &signalStore{feedbackRowCount: 1}
+	service := NewService(store, nil)
+
+	if err := service.RateMessage(context.Background(), "user-1", "chat-1", "msg-1", -1); err != nil {
+		t.Fatal(err)
+	}
+	if store.rated.Rating != -1 || store.rated.MessageID != "msg-1" {
+		t.Errorf("rated = %+v", store.rated)
+	}
+
+	if err := service.RateMessage(context.Background(), "user-1", "chat-1", "msg-1", 5); !stderrors.Is(err, ErrInvalidRating) {
+		t.Errorf("rating 5: err = %v, want ErrInvalidRating", err)
+	}
+
+	// Messages the user has no indexed record of can't be rated
+	store.feedbackRowCount = 0
+	if err := service.RateMessage(context.Background(), "user-1", "chat-1", "msg-2", 1); !stderrors.Is(err, ErrMessageNotFound) {
+		t.Errorf("unindexed message: err = %v, want ErrMessageNotFound", err)
+	}
+}
+
+func TestScoreboard(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	store := &signalStore{
+		now: now,
+		current: []pgdb.GetModelRequestSignalsRow{
+			{Model: "gpt-4.1", Provider: "OpenAI", Requests: 80, Anomalous: 4, Flagged: 5},
+			{Model: "gpt4", Provider: "OpenAI", Requests: 20, Flagged: 5},
+			{Model: "gpt-4.1", Provider: "OpenRouter", Requests: 100},
+		},
+		prev: []pgdb.GetModelRequestSignalsRow{
+			{Model: "gpt-4.1", Provider: "OpenAI", Requests: 100},
+		},
+		feedback: []pgdb.GetModelFeedbackCountsRow{
+			{Model: "gpt-4.1", Positive: 10, Negative: 5},
+			{Model: "kimi", Negative: 3},
+		},
+	}
+	aliases := map[string]string{"gpt4": "gpt-4.1"}
+	service := NewService(store, func(model string) string {
+		if canonical, ok := aliases[model]; ok {
+			return canonical
+		}
+		return model
+	})
+
+	if _, err := service.Scoreboard(context.Background(), "1y", now); !stderrors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("window 1y: err = %v, want ErrInvalidWindow", err)
+	}
+
+	scoreboard, err := service.Scoreboard(context.Background(), "7d", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !scoreboard.From.Equal(now.AddDate(0, 0, -7)) {
+		t.Errorf("from = %v", scoreboard.From)
+	}
+
+	models := scoreboard.Models
+	if len(models) != 3 {
+		t.Fatalf("models = %+v, want 3", models)
+	}
+
+	// Satisfaction (10 + 4) / (15 + 5) = 0.7 for both gpt-4.1 providers
+	openRouter, openAI, kimi := models[0], models[1], models[2]
+	if openRouter.Provider != "OpenRouter" || openRouter.Score != 85 || openRouter.Trend != nil {
+		t.Errorf("OpenRouter = %+v, want score 85 without trend", openRouter)
+	}
+	// Aliases merged: 10 of 100 requests flagged
+	if openAI.Provider != "OpenAI" || openAI.Requests != 100 || openAI.Reliability != 0.9 || openAI.Score != 80 {
+		t.Errorf("OpenAI = %+v, want 100 requests, reliability 0.9, score 80", openAI)
+	}
+	// Previous window: reliability 1, satisfaction 0.8 (prior only)
+	if openAI.PreviousScore == nil || *openAI.PreviousScore != 90 || *openAI.Trend != -10 {
+		t.Errorf("OpenAI previous = %v, trend = %v, want 90 and -10", openAI.PreviousScore, openAI.Trend)
+	}
+	// Ratings only: (0 + 4) / (3 + 5) = 0.5
+	if kimi.Model != "kimi" || kimi.Provider != "" || kimi.Satisfaction != 0.5 || kimi.Score != 75 {
+		t.Errorf("kimi = %+v, want satisfaction 0.5, score 75", kimi)
+	}
+}
+
+func TestRoutingScores(t *testing.T) {
+	scores := routingScores([]ModelScore{
+		{Model: "m", Provider: "A", Requests: minRoutingRequests, Score: 90},
+		{Model: "m", Provider: "B", Requests: minRoutingRequests - 1, Score: 10},
+		{Model: "n", Score: 50},
+	})
+	if len(scores) != 1 || len(scores["m"]) != 1 || scores["m"]["A"] != 90 {
+		t.Errorf("scores = %v, want only m/A", scores)
+	}
+}
@@ -0,0 +1,93 @@
+package quality
+
+import (
+	"context"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+const (
+	// routingWindow is the window of the scores fed to routing.
+	routingWindow = 24 * time.Hour
+
+	// minRoutingRequests is the number of requests in the window a provider needs before its
+	// score affects routing.
+	minRoutingRequests = 50
+)
+
+// ScoreSetter receives the scores used for endpoint selection (see routing.ModelRouter).
+type ScoreSetter interface {
+	SetQualityScores(scores map[string]map[string]float64, margin float64)
+}
+
+// RoutingWorker periodically feeds quality scores into endpoint selection, so the router
+// prefers a model's better-scoring providers.
+type RoutingWorker struct {
+	service  *Service
+	router   ScoreSetter
+	margin   float64
+	interval time.Duration
+	logger   *logger.Logger
+}
+
+// NewRoutingWorker creates a worker making router skip providers scoring more than margin
+// below a model's best provider.
+func NewRoutingWorker(service *Service, router ScoreSetter, margin float64, logger *logger.Logger) *RoutingWorker {
+	return &RoutingWorker{
+		service:  service,
+		router:   router,
+		margin:   margin,
+		interval: 10 * time.Minute,
+		logger:   logger,
+	}
+}
+
+// Run starts the worker loop. Scores are kept when a refresh fails and cleared when ctx ends.
+func (w *RoutingWorker) Run(ctx context.Context) {
+	w.logger.Info("starting quality routing worker", "interval", w.interval, "margin", w.margin)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run immediately on startup
+	w.refresh(ctx, time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.router.SetQualityScores(nil, 0)
+			w.logger.Info("quality routing worker stopped")
+			return
+		case <-ticker.C:
+			w.refresh(ctx, time.Now())
+		}
+	}
+}
+
+func (w *RoutingWorker) refresh(ctx context.Context, now time.Time) {
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	scores, err := w.service.Scores(queryCtx, now.Add(-routingWindow), now)
+	if err != nil {
+		w.logger.Error("failed to refresh quality scores", "error", err.Error())
+		return
+	}
+	w.router.SetQualityScores(routingScores(scores), w.margin)
+}
+
+// routingScores returns the scores by model and provider of providers with enough requests.
+func routingScores(scores []ModelScore) map[string]map[string]float64 {
+	byModel := make(map[string]map[string]float64)
+	for _, score := range scores {
+		if score.Provider == "" || score.Requests < minRoutingRequests {
+			continue
+		}
+		if byModel[score.Model] == nil {
+			byModel[score.Model] = make(map[string]float64)
+		}
+		byModel[score.Model][score.Provider] = score.Score
+	}
+	return byModel
+}
@@ -0,0 +1,399 @@
+// Package ratequeue holds chat completion requests briefly while their provider is rate
+// limited, instead of failing them immediately.
+//
+// Each provider has a token bucket fed by the rate-limit headers of its responses: the
+// remaining request count is the number of tokens, refilled at the rate that restores the limit
+// by the reported reset time. An exhausted token limit or a 429 blocks the provider until its
+// reset. Providers that don't report limits are never queued.
+//
+// Requests that find the bucket empty wait in line, bounded in count and time. A request that
+// can't be admitted within the maximum wait is rejected without waiting, so the client gets the
+// rate limit error immediately.
+//
+// The line is ordered by weighted fair queueing over priority lanes (the user's tier): requests
+// of a lane are admitted in FIFO order, and while several lanes wait, each gets admissions in
+// proportion to its weight. A request that has waited half the maximum wait goes before every
+// request that hasn't, so low-weight lanes are never starved.
+package ratequeue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+)
+
+// minRetryAfter is the retry delay suggested when a request is rejected while the provider
+// is not blocked (full queue).
+const minRetryAfter = time.Second
+
+var (
+	ErrQueueFull   = errors.New("rate limit queue is full")
+	ErrWaitTooLong = errors.New("provider rate limit resets after the maximum queue wait")
+	ErrWaitTimeout = errors.New("request was not admitted within the maximum queue wait")
+)
+
+// RejectedError is returned by Wait when a request is not admitted.
+type RejectedError struct {
+	Reason     error         // ErrQueueFull, ErrWaitTooLong or ErrWaitTimeout
+	RetryAfter time.Duration // When the provider is expected to accept requests again
+}
+
+func (e *RejectedError) Error() string { return e.Reason.Error() }
+func (e *RejectedError) Unwrap() error { return e.Reason }
+
+// defaultLane names requests queued without a lane.
+const defaultLane = "default"
+
+// Lane is the priority lane of a request.
+type Lane struct {
+	Name   string
+	Weight int // Share of admissions relative to the other lanes (<= 0 = 1)
+}
+
+// Queue holds the token buckets and wait queues of all providers.
+type Queue struct {
+	maxWait       time.Duration
+	maxSize       int
+	starvationAge time.Duration // Waiters queued this long go first
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// New creates a queue. Requests wait at most maxWait, and at most maxSize requests wait per
+// provider.
+func New(maxWait time.Duration, maxSize int) *Queue {
+	return &Queue{
+		maxWait:       maxWait,
+		maxSize:       maxSize,
+		starvationAge: maxWait / 2,
+		buckets:       make(map[string]*bucket),
+	}
+}
+
+// Observe feeds a provider's request limit: remaining requests until the limit (0 = unknown) is
+// fully restored in resetIn.
+func (q *Queue) Observe(provider string, limit, remaining float64, resetIn time.Duration) {
+	if resetIn <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	b := q.bucket(provider)
+	b.known = true
+	b.tokens = remaining
+	b.capacity = limit
+	b.rate = 0
+	if limit > remaining {
+		b.rate = (limit - remaining) / resetIn.Seconds()
+	}
+	b.updated = now
+	b.resetAt = now.Add(resetIn)
+	b.notify()
+}
+
+// Block stops admitting requests to a provider for d (e.g. after a 429 or an exhausted token
+// limit). Overlapping blocks end with the latest.
+func (q *Queue) Block(provider string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	b := q.bucket(provider)
+	if until := time.Now().Add(d); until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+	b.notify()
+}
+
+// Wait blocks until the provider admits the request of a lane. onPosition (optional) is called
+// with the request's place in line (1 = next) and the estimated wait whenever the place changes.
+//
+// Wait returns nil immediately when the provider isn't limited, a *RejectedError when the
+// request can't be admitted within the maximum wait, or the context's error.
+func (q *Queue) Wait(ctx context.Context, provider string, lane Lane, onPosition func(position int, estimatedWait time.Duration)) error {
+	if lane.Name == "" {
+		lane.Name = defaultLane
+	}
+	q.mu.Lock()
+	b := q.bucket(provider)
+	start := time.Now()
+	if len(b.waiters) == 0 && b.take(start) {
+		q.mu.Unlock()
+		return nil
+	}
+	delay := b.delay(start)
+	if len(b.waiters) >= q.maxSize {
+		q.mu.Unlock()
+		metrics.RecordRateQueueRejected(provider, "full")
+		return &RejectedError{Reason: ErrQueueFull, RetryAfter: max(delay, minRetryAfter)}
+	}
+	if delay > q.maxWait {
+		q.mu.Unlock()
+		metrics.RecordRateQueueRejected(provider, "wait_too_long")
+		return &RejectedError{Reason: ErrWaitTooLong, RetryAfter: delay}
+	}
+	w := b.add(lane, start)
+	q.mu.Unlock()
+
+	metrics.RecordRateQueueQueued(provider)
+	deadline := start.Add(q.maxWait)
+	starvedAt := start.Add(q.starvationAge)
+	for {
+		q.mu.Lock()
+		now := time.Now()
+		if !w.starved && !now.Before(starvedAt) {
+			// Moved ahead of every request that hasn't waited as long
+			w.starved = true
+			b.notify()
+		}
+		position := b.position(w)
+		if position == 1 && b.take(now) {
+			promoted := w.starved && !b.fairHead(w)
+			b.admit(w)
+			q.mu.Unlock()
+			metrics.RecordRateQueueAdmitted(provider, now.Sub(start))
+			if promoted {
+				metrics.RecordRateQueueStarvationAdmit(provider, lane.Name)
+			}
+			return nil
+		}
+		delay = b.delay(now)
+		estimate := b.estimate(now, position)
+		changed := b.changed
+		if !now.Before(deadline) {
+			b.remove(w)
+			q.mu.Unlock()
+			metrics.RecordRateQueueRejected(provider, "timeout")
+			return &RejectedError{Reason: ErrWaitTimeout, RetryAfter: max(delay, minRetryAfter)}
+		}
+		q.mu.Unlock()
+
+		if position != w.position && onPosition != nil {
+			onPosition(position, estimate)
+		}
+		w.position = position
+
+		// The head wakes up when the bucket admits it; the others when the line moves or they
+		// move ahead as starved
+		sleep := deadline.Sub(now)
+		if position == 1 && delay > 0 && delay < sleep {
+			sleep = delay
+		}
+		if untilStarved := starvedAt.Sub(now); !w.starved && untilStarved < sleep {
+			sleep = untilStarved
+		}
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			q.mu.Lock()
+			b.remove(w)
+			q.mu.Unlock()
+			metrics.RecordRateQueueRejected(provider, "canceled")
+			return ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Waiting returns the number of requests waiting for a provider.
+func (q *Queue) Waiting(provider string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if b, ok := q.buckets[provider]; ok {
+		return len(b.waiters)
+	}
+	return 0
+}
+
+func (q *Queue) bucket(provider string) *bucket {
+	b, ok := q.buckets[provider]
+	if !ok {
+		b = &bucket{provider: provider, changed: make(chan struct{})}
+		q.buckets[provider] = b
+	}
+	return b
+}
+
+// waiter is a queued request.
+type waiter struct {
+	lane     string
+	tag      float64 // Virtual finish time: the lane's previous tag (or now) + 1/weight
+	seq      uint64  // Arrival order
+	starved  bool    // Waited long enough to go before the others
+	position int     // Last reported place in line
+}
+
+// bucket is a provider's token bucket and wait queue. All access is under Queue.mu.
+type bucket struct {
+	known        bool      // A limit has been reported and its window hasn't reset yet
+	tokens       float64   // Requests that can be sent now
+	capacity     float64   // Request limit; 0 = unknown
+	rate         float64   // Tokens restored per second
+	updated      time.Time // Last refill
+	resetAt      time.Time // The limit is fully restored
+	blockedUntil time.Time
+
+	provider string
+	waiters  []*waiter
+	changed  chan struct{} // Closed (and replaced) when the bucket or the line changes
+
+	// Weighted fair queueing state, reset when the line empties
+	vtime    float64            // Tag of the last admitted waiter
+	laneTags map[string]float64 // Tag of the last waiter queued per lane
+	seq      uint64
+}
+
+// refill restores the tokens accrued since the last refill. Once the window has reset the
+// limit is unknown again until the next response reports it.
+func (b *bucket) refill(now time.Time) {
+	if !b.known {
+		return
+	}
+	if !now.Before(b.resetAt) {
+		b.known = false
+		return
+	}
+	b.tokens += b.rate * now.Sub(b.updated).Seconds()
+	if b.capacity > 0 && b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.updated = now
+}
+
+// delay returns how long until the bucket admits a request.
+func (b *bucket) delay(now time.Time) time.Duration {
+	b.refill(now)
+	var delay time.Duration
+	if now.Before(b.blockedUntil) {
+		delay = b.blockedUntil.Sub(now)
+	}
+	if b.known && b.tokens < 1 {
+		untilToken := b.resetAt.Sub(now)
+		if b.rate > 0 {
+			untilToken = min(untilToken, time.Duration((1-b.tokens)/b.rate*float64(time.Second)))
+		}
+		delay = max(delay, untilToken)
+	}
+	return delay
+}
+
+// estimate returns the expected wait of the request at position.
+func (b *bucket) estimate(now time.Time, position int) time.Duration {
+	estimate := b.delay(now)
+	if b.known && b.rate > 0 && position > 1 {
+		estimate += time.Duration(float64(position-1) / b.rate * float64(time.Second))
+	}
+	return estimate
+}
+
+// take consumes a token if the bucket admits a request now.
+func (b *bucket) take(now time.Time) bool {
+	if b.delay(now) > 0 {
+		return false
+	}
+	if b.known {
+		b.tokens--
+	}
+	return true
+}
+
+// add queues a request of a lane.
+func (b *bucket) add(lane Lane, now time.Time) *waiter {
+	if b.laneTags == nil {
+		b.laneTags = make(map[string]float64)
+	}
+	weight := float64(max(lane.Weight, 1))
+	tag := max(b.vtime, b.laneTags[lane.Name]) + 1/weight
+	b.laneTags[lane.Name] = tag
+	b.seq++
+
+	w := &waiter{lane: lane.Name, tag: tag, seq: b.seq}
+	b.waiters = append(b.waiters, w)
+	b.recordDepth(lane.Name)
+	return w
+}
+
+// precedes reports whether a goes before b: starved waiters first in arrival order, then
+// the smallest tag.
+func precedes(a, b *waiter) bool {
+	if a.starved != b.starved {
+		return a.starved
+	}
+	if !a.starved && a.tag != b.tag {
+		return a.tag < b.tag
+	}
+	return a.seq < b.seq
+}
+
+func (b *bucket) position(w *waiter) int {
+	position := 1
+	found := false
+	for _, queued := range b.waiters {
+		if queued == w {
+			found = true
+		} else if precedes(queued, w) {
+			position++
+		}
+	}
+	if !found {
+		return 0
+	}
+	return position
+}
+
+// fairHead reports whether w would be next without starvation protection.
+func (b *bucket) fairHead(w *waiter) bool {
+	for _, queued := range b.waiters {
+		if queued.tag < w.tag {
+			return false
+		}
+	}
+	return true
+}
+
+// admit removes an admitted waiter, advancing the virtual time to its tag.
+func (b *bucket) admit(w *waiter) {
+	b.vtime = max(b.vtime, w.tag)
+	b.remove(w)
+}
+
+func (b *bucket) remove(w *waiter) {
+	for i, queued := range b.waiters {
+		if queued == w {
+			b.waiters = append(b.waiters[:i], b.waiters[i+1:]...)
+			if len(b.waiters) == 0 {
+				b.vtime, b.laneTags = 0, nil
+			}
+			b.recordDepth(w.lane)
+			b.notify()
+			return
+		}
+	}
+}
+
+// recordDepth exports the number of waiters of a lane.
+func (b *bucket) recordDepth(lane string) {
+	depth := 0
+	for _, queued := range b.waiters {
+		if queued.lane == lane {
+			depth++
+		}
+	}
+	metrics.SetRateQueueDepth(b.provider, lane, depth)
+}
+
+func (b *bucket) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
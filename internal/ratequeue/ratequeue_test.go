@@ -0,0 +1,219 @@
+package ratequeue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitUnlimitedProvider(t *testing.T) {
+	q := New(time.Second, 10)
+	for i := 0; i < 100; i++ {
+		if err := q.Wait(context.Background(), "openai", Lane{}, nil); err != nil {
+			t.Fatalf("Wait = %v, want immediate admission", err)
+		}
+	}
+}
+
+func TestWaitConsumesTokens(t *testing.T) {
+	q := New(time.Second, 10)
+	q.Observe("openai", 0, 2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if err := q.Wait(context.Background(), "openai", Lane{}, nil); err != nil {
+			t.Fatalf("Wait %d = %v", i, err)
+		}
+	}
+	// No tokens left and no refill before the reset in an hour
+	var rejected *RejectedError
+	err := q.Wait(context.Background(), "openai", Lane{}, nil)
+	if !errors.As(err, &rejected) || !errors.Is(err, ErrWaitTooLong) {
+		t.Fatalf("Wait = %v, want ErrWaitTooLong", err)
+	}
+	if rejected.RetryAfter < 59*time.Minute {
+		t.Errorf("RetryAfter = %v, want about an hour", rejected.RetryAfter)
+	}
+	if err := q.Wait(context.Background(), "anthropic", Lane{}, nil); err != nil {
+		t.Errorf("other providers should not be limited, got %v", err)
+	}
+}
+
+func TestWaitAdmitsAfterBlock(t *testing.T) {
+	q := New(time.Second, 10)
+	q.Block("openai", 50*time.Millisecond)
+
+	var positions []int
+	start := time.Now()
+	err := q.Wait(context.Background(), "openai", Lane{}, func(position int, estimatedWait time.Duration) {
+		positions = append(positions, position)
+		if estimatedWait <= 0 || estimatedWait > 50*time.Millisecond {
+			t.Errorf("estimated wait = %v", estimatedWait)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if waited := time.Since(start); waited < 40*time.Millisecond {
+		t.Errorf("admitted after %v, before the block ended", waited)
+	}
+	if len(positions) != 1 || positions[0] != 1 {
+		t.Errorf("positions = %v, want [1]", positions)
+	}
+}
+
+func TestWaitRefillsTokens(t *testing.T) {
+	q := New(time.Second, 10)
+	// 10 requests restored over 100ms: a token every 10ms
+	q.Observe("openai", 10, 0, 100*time.Millisecond)
+
+	start := time.Now()
+	if err := q.Wait(context.Background(), "openai", Lane{}, nil); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if waited := time.Since(start); waited > 80*time.Millisecond {
+		t.Errorf("waited %v for a token refilled after 10ms", waited)
+	}
+}
+
+func TestWaitFIFO(t *testing.T) {
+	const waiters = 3
+	q := New(time.Second, 10)
+	// A token every 20ms
+	q.Observe("openai", waiters, 0, waiters*20*time.Millisecond)
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := q.Wait(context.Background(), "openai", Lane{}, nil); err != nil {
+				t.Errorf("Wait %d = %v", i, err)
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		}()
+		// Queue the waiters in order
+		for q.Waiting("openai") != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wg.Wait()
+
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("admission order = %v, want FIFO", order)
+		}
+	}
+}
+
+func TestWaitLanes(t *testing.T) {
+	free, pro := Lane{Name: "free", Weight: 1}, Lane{Name: "pro", Weight: 4}
+	lanes := []Lane{free, free, pro, pro, pro, pro}
+	q := New(time.Second, 10)
+	// A token every 20ms
+	q.Observe("openai", float64(len(lanes)), 0, time.Duration(len(lanes))*20*time.Millisecond)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for i, lane := range lanes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := q.Wait(context.Background(), "openai", lane, nil); err != nil {
+				t.Errorf("Wait %d = %v", i, err)
+			}
+			mu.Lock()
+			order = append(order, lane.Name)
+			mu.Unlock()
+		}()
+		for q.Waiting("openai") != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wg.Wait()
+
+	// Pro gets four admissions per free admission, each lane in FIFO order
+	want := []string{"pro", "pro", "pro", "free", "pro", "free"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("admission order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestWaitStarvationProtection(t *testing.T) {
+	q := New(100*time.Millisecond, 10)
+	q.Block("openai", 90*time.Millisecond)
+
+	go q.Wait(context.Background(), "openai", Lane{Name: "free", Weight: 1}, nil)
+	for q.Waiting("openai") != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	// Past half the maximum wait, the free request goes before new pro requests
+	time.Sleep(60 * time.Millisecond)
+
+	var first int
+	err := q.Wait(context.Background(), "openai", Lane{Name: "pro", Weight: 4}, func(position int, _ time.Duration) {
+		if first == 0 {
+			first = position
+		}
+	})
+	if err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if first != 2 {
+		t.Errorf("pro request position = %d, want 2 (behind the starved free request)", first)
+	}
+}
+
+func TestWaitRejections(t *testing.T) {
+	q := New(50*time.Millisecond, 1)
+	q.Block("openai", 40*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Wait(ctx, "openai", Lane{}, nil)
+	for q.Waiting("openai") != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := q.Wait(context.Background(), "openai", Lane{}, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Wait = %v, want ErrQueueFull", err)
+	}
+
+	cancel()
+	for q.Waiting("openai") != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The provider stays blocked past the maximum wait of a queued request
+	q.Block("openai", time.Hour)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := q.Wait(ctx, "openai", Lane{}, nil); !errors.Is(err, ErrWaitTooLong) {
+		t.Errorf("Wait = %v, want ErrWaitTooLong", err)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	q := New(30*time.Millisecond, 10)
+	q.Block("openai", 20*time.Millisecond)
+
+	go func() {
+		// Extended while the request waits
+		time.Sleep(10 * time.Millisecond)
+		q.Block("openai", time.Hour)
+	}()
+	if err := q.Wait(context.Background(), "openai", Lane{}, nil); !errors.Is(err, ErrWaitTimeout) {
+		t.Errorf("Wait = %v, want ErrWaitTimeout", err)
+	}
+	if q.Waiting("openai") != 0 {
+		t.Error("timed out request is still queued")
+	}
+}
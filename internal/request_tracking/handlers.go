@@ -1,6 +1,8 @@
 package request_tracking
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
@@ -38,6 +40,9 @@ type RateLimitStatusResponse struct {
 
 	// Allowed features (empty = all features allowed, non-empty = only these features allowed)
 	AllowedFeatures []string `json:"allowed_features"`
+
+	// Per-resource state keyed by Resource* constants, so clients can check every limit in one call
+	Resources map[string]*ResourceStatus `json:"resources"`
 }
 
 type TokenLimitInfo struct {
@@ -60,6 +65,11 @@ type DeepResearchInfo struct {
 
 // RateLimitStatusHandler returns comprehensive rate limit and tier information.
 func RateLimitStatusHandler(trackingService *Service, log *logger.Logger, modelRouter ...*routing.ModelRouter) gin.HandlerFunc {
+	var router *routing.ModelRouter
+	if len(modelRouter) > 0 {
+		router = modelRouter[0]
+	}
+
 	return func(c *gin.Context) {
 		userID, exists := auth.GetUserID(c)
 		if !exists {
@@ -68,10 +78,8 @@ func RateLimitStatusHandler(trackingService *Service, log *logger.Logger, modelR
 		}
 
 		reqLog := log.WithContext(c.Request.Context()).WithComponent("rate_limit_status")
-		ctx := c.Request.Context()
 
-		// Get user's tier configuration
-		tierConfig, expiresAt, err := trackingService.GetUserTierConfig(ctx, userID)
+		response, err := BuildRateLimitStatus(c.Request.Context(), trackingService, userID, router, reqLog)
 		if err != nil {
 			reqLog.Error("failed to get tier config",
 				slog.String("error", err.Error()),
@@ -80,123 +88,171 @@ func RateLimitStatusHandler(trackingService *Service, log *logger.Logger, modelR
 			return
 		}
 
-		// Get subscription provider
-		provider, _ := trackingService.GetSubscriptionProvider(ctx, userID)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// BuildRateLimitStatus assembles the rate limit and tier status for a user.
+// Only a tier lookup failure is returned as an error; usage query failures are
+// logged and reported as zero usage. modelRouter may be nil.
+func BuildRateLimitStatus(ctx context.Context, trackingService *Service, userID string, modelRouter *routing.ModelRouter, reqLog *logger.Logger) (*RateLimitStatusResponse, error) {
+	// Get user's tier configuration
+	tierConfig, expiresAt, err := trackingService.GetUserTierConfig(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tier config: %w", err)
+	}
 
-		// Convert allowed features to strings
-		allowedFeatures := make([]string, len(tierConfig.AllowedFeatures))
-		for i, feature := range tierConfig.AllowedFeatures {
-			allowedFeatures[i] = string(feature)
-		}
+	// Get subscription provider
+	provider, _ := trackingService.GetSubscriptionProvider(ctx, userID)
 
-		// Expand allowed models to include aliases so clients can match by any known name
-		allowedModels := tierConfig.AllowedModels
-		if len(allowedModels) > 0 && len(modelRouter) > 0 && modelRouter[0] != nil {
-			seen := make(map[string]bool)
-			var expanded []string
-			for _, canonical := range allowedModels {
-				for _, name := range modelRouter[0].GetAliases(canonical) {
-					if !seen[name] {
-						seen[name] = true
-						expanded = append(expanded, name)
-					}
+	// Convert allowed features to strings
+	allowedFeatures := make([]string, len(tierConfig.AllowedFeatures))
+	for i, feature := range tierConfig.AllowedFeatures {
+		allowedFeatures[i] = string(feature)
+	}
+
+	// Expand allowed models to include aliases so clients can match by any known name
+	allowedModels := tierConfig.AllowedModels
+	if len(allowedModels) > 0 && modelRouter != nil {
+		seen := make(map[string]bool)
+		var expanded []string
+		for _, canonical := range allowedModels {
+			for _, name := range modelRouter.GetAliases(canonical) {
+				if !seen[name] {
+					seen[name] = true
+					expanded = append(expanded, name)
 				}
 			}
-			allowedModels = expanded
 		}
+		allowedModels = expanded
+	}
 
-		// Build response
-		response := RateLimitStatusResponse{
-			Enabled:              config.AppConfig.RateLimitEnabled,
-			Tier:                 tierConfig.Name,
-			TierDisplay:          tierConfig.DisplayName,
-			RateLimitingEnabled:  config.AppConfig.RateLimitEnabled,
-			SubscriptionProvider: provider,
-			ExpiresAt:            expiresAt,
-			AllowedModels:        allowedModels,
-			AllowedFeatures:      allowedFeatures,
-		}
+	// Build response
+	enabled := config.Current().RateLimitEnabled
+	response := RateLimitStatusResponse{
+		Enabled:              enabled,
+		Tier:                 tierConfig.Name,
+		TierDisplay:          tierConfig.DisplayName,
+		RateLimitingEnabled:  enabled,
+		SubscriptionProvider: provider,
+		ExpiresAt:            expiresAt,
+		AllowedModels:        allowedModels,
+		AllowedFeatures:      allowedFeatures,
+	}
 
-		// Monthly token limit (if configured)
-		if tierConfig.MonthlyPlanTokens > 0 {
-			used, err := trackingService.GetUserPlanTokensThisMonth(ctx, userID)
-			if err != nil {
-				reqLog.Error("failed to get monthly usage", slog.String("error", err.Error()))
-				used = 0
-			}
-			remaining := tierConfig.MonthlyPlanTokens - used
-			if remaining < 0 {
-				remaining = 0
-			}
-			percentage := (float64(used) / float64(tierConfig.MonthlyPlanTokens)) * 100
-			response.MonthlyTokens = &TokenLimitInfo{
-				Limit:      tierConfig.MonthlyPlanTokens,
-				Used:       used,
-				Remaining:  remaining,
-				ResetsAt:   tierConfig.GetMonthlyResetTime(),
-				UnderLimit: used < tierConfig.MonthlyPlanTokens,
-				Percentage: percentage,
-			}
+	// Monthly token limit (if configured)
+	if tierConfig.MonthlyPlanTokens > 0 {
+		used, err := trackingService.GetUserPlanTokensThisMonth(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to get monthly usage", slog.String("error", err.Error()))
+			used = 0
 		}
+		remaining := tierConfig.MonthlyPlanTokens - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		percentage := (float64(used) / float64(tierConfig.MonthlyPlanTokens)) * 100
+		response.MonthlyTokens = &TokenLimitInfo{
+			Limit:      tierConfig.MonthlyPlanTokens,
+			Used:       used,
+			Remaining:  remaining,
+			ResetsAt:   tierConfig.GetMonthlyResetTime(),
+			UnderLimit: used < tierConfig.MonthlyPlanTokens,
+			Percentage: percentage,
+		}
+	}
 
-		// Weekly token limit (if configured)
-		if tierConfig.WeeklyPlanTokens > 0 {
-			used, err := trackingService.GetUserPlanTokensThisWeek(ctx, userID)
-			if err != nil {
-				reqLog.Error("failed to get weekly usage", slog.String("error", err.Error()))
-				used = 0
-			}
-			remaining := tierConfig.WeeklyPlanTokens - used
-			if remaining < 0 {
-				remaining = 0
-			}
-			percentage := (float64(used) / float64(tierConfig.WeeklyPlanTokens)) * 100
-			response.WeeklyTokens = &TokenLimitInfo{
-				Limit:      tierConfig.WeeklyPlanTokens,
-				Used:       used,
-				Remaining:  remaining,
-				ResetsAt:   tierConfig.GetWeeklyResetTime(),
-				UnderLimit: used < tierConfig.WeeklyPlanTokens,
-				Percentage: percentage,
-			}
+	// Weekly token limit (if configured)
+	if tierConfig.WeeklyPlanTokens > 0 {
+		used, err := trackingService.GetUserPlanTokensThisWeek(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to get weekly usage", slog.String("error", err.Error()))
+			used = 0
+		}
+		remaining := tierConfig.WeeklyPlanTokens - used
+		if remaining < 0 {
+			remaining = 0
 		}
+		percentage := (float64(used) / float64(tierConfig.WeeklyPlanTokens)) * 100
+		response.WeeklyTokens = &TokenLimitInfo{
+			Limit:      tierConfig.WeeklyPlanTokens,
+			Used:       used,
+			Remaining:  remaining,
+			ResetsAt:   tierConfig.GetWeeklyResetTime(),
+			UnderLimit: used < tierConfig.WeeklyPlanTokens,
+			Percentage: percentage,
+		}
+	}
 
-		// Daily token limit (if configured)
-		if tierConfig.DailyPlanTokens > 0 {
-			used, err := trackingService.GetUserPlanTokensToday(ctx, userID)
-			if err != nil {
-				reqLog.Error("failed to get daily usage", slog.String("error", err.Error()))
-				used = 0
-			}
-			remaining := tierConfig.DailyPlanTokens - used
-			if remaining < 0 {
-				remaining = 0
-			}
-			percentage := (float64(used) / float64(tierConfig.DailyPlanTokens)) * 100
-			response.DailyTokens = &TokenLimitInfo{
-				Limit:      tierConfig.DailyPlanTokens,
-				Used:       used,
-				Remaining:  remaining,
-				ResetsAt:   tierConfig.GetDailyResetTime(),
-				UnderLimit: used < tierConfig.DailyPlanTokens,
-				Percentage: percentage,
-			}
+	// Daily token limit (if configured)
+	if tierConfig.DailyPlanTokens > 0 {
+		used, err := trackingService.GetUserPlanTokensToday(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to get daily usage", slog.String("error", err.Error()))
+			used = 0
+		}
+		remaining := tierConfig.DailyPlanTokens - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		percentage := (float64(used) / float64(tierConfig.DailyPlanTokens)) * 100
+		response.DailyTokens = &TokenLimitInfo{
+			Limit:      tierConfig.DailyPlanTokens,
+			Used:       used,
+			Remaining:  remaining,
+			ResetsAt:   tierConfig.GetDailyResetTime(),
+			UnderLimit: used < tierConfig.DailyPlanTokens,
+			Percentage: percentage,
 		}
+	}
+
+	// Deep research info
+	dailyRunsUsed, _ := trackingService.GetUserDeepResearchRunsToday(ctx, userID)
+	lifetimeRunsUsed, _ := trackingService.GetUserDeepResearchRunsLifetime(ctx, userID)
+	response.DeepResearch = &DeepResearchInfo{
+		DailyRuns:         tierConfig.DeepResearchDailyRuns,
+		LifetimeRuns:      tierConfig.DeepResearchLifetimeRuns,
+		TokenCap:          tierConfig.DeepResearchTokenCap,
+		MaxActiveSessions: tierConfig.DeepResearchMaxActiveSessions,
+		DailyRunsUsed:     int(dailyRunsUsed),
+		LifetimeRunsUsed:  int(lifetimeRunsUsed),
+	}
 
-		// Deep research info
-		dailyRunsUsed, _ := trackingService.GetUserDeepResearchRunsToday(ctx, userID)
-		lifetimeRunsUsed, _ := trackingService.GetUserDeepResearchRunsLifetime(ctx, userID)
-		response.DeepResearch = &DeepResearchInfo{
-			DailyRuns:         tierConfig.DeepResearchDailyRuns,
-			LifetimeRuns:      tierConfig.DeepResearchLifetimeRuns,
-			TokenCap:          tierConfig.DeepResearchTokenCap,
-			MaxActiveSessions: tierConfig.DeepResearchMaxActiveSessions,
-			DailyRunsUsed:     int(dailyRunsUsed),
-			LifetimeRunsUsed:  int(lifetimeRunsUsed),
+	// Per-resource breakdown
+	enforced := config.Current().RateLimitEnabled
+	response.Resources = map[string]*ResourceStatus{
+		ResourceChatPlanTokens: resolveResourceStatus(chatPlanTokenWindows(&response), enforced),
+		ResourceSearches:       unmeteredResourceStatus(),
+		ResourceToolCalls:      unmeteredResourceStatus(),
+	}
+
+	// Fallback quota only applies once the daily quota is exhausted
+	if tierConfig.FallbackDailyPlanTokens > 0 {
+		used, err := trackingService.GetUserFallbackPlanTokensToday(ctx, userID, tierConfig.FallbackModel)
+		if err != nil {
+			reqLog.Error("failed to get fallback usage", slog.String("error", err.Error()))
+			used = 0
 		}
+		response.Resources[ResourceFallbackPlanTokens] = resolveResourceStatus([]quotaWindow{
+			{WindowDaily, used, tierConfig.FallbackDailyPlanTokens, tierConfig.GetDailyResetTime()},
+		}, enforced)
+	}
 
-		c.JSON(http.StatusOK, response)
+	now := time.Now().UTC()
+	nextMidnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	deepResearchEnforced := config.AppConfig.DeepResearchRateLimitEnabled
+	deepResearch := resolveResourceStatus(deepResearchWindows(tierConfig, dailyRunsUsed, lifetimeRunsUsed, nextMidnight), deepResearchEnforced)
+	if deepResearchEnforced && tierConfig.DeepResearchMaxActiveSessions > 0 {
+		activeRuns, err := trackingService.CountActiveDeepResearchRuns(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to check active deep research runs", slog.String("error", err.Error()))
+		} else if activeRuns >= int64(tierConfig.DeepResearchMaxActiveSessions) {
+			deepResearch.Blocked = true
+		}
 	}
+	response.Resources[ResourceDeepResearchRuns] = deepResearch
+
+	return &response, nil
 }
 
 // MetricsHandler exposes request tracking metrics for monitoring.
@@ -5,6 +5,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/auth"
 	"github.com/eternisai/enchanted-proxy/internal/common"
@@ -12,6 +14,8 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/errors"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
 	"github.com/gin-gonic/gin"
 )
 
@@ -36,15 +40,22 @@ func RequestTrackingMiddleware(trackingService *Service, logger *logger.Logger,
 			return
 		}
 
+		// Sandbox requests are served by the dev provider and consume no quota
+		if sandbox.FromContext(c.Request.Context()) {
+			c.Next()
+			return
+		}
+
 		log := logger.WithContext(c.Request.Context()).WithComponent("request_tracking")
 
-		if config.AppConfig.RateLimitEnabled {
+		appConfig := config.Current()
+		if appConfig.RateLimitEnabled {
 			if trackingService == nil {
 				log.Error("rate limit service unavailable; request cannot be checked",
 					slog.String("user_id", userID),
-					slog.Bool("fail_closed", config.AppConfig.RateLimitFailClosed))
+					slog.Bool("fail_closed", appConfig.RateLimitFailClosed))
 
-				if config.AppConfig.RateLimitFailClosed {
+				if appConfig.RateLimitFailClosed {
 					c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
 						"error": "Rate limit service temporarily unavailable",
 					})
@@ -63,7 +74,7 @@ func RequestTrackingMiddleware(trackingService *Service, logger *logger.Logger,
 					slog.String("user_id", userID))
 
 				// Fail closed if configured (prevents rate limit bypass during DB outage)
-				if config.AppConfig.RateLimitFailClosed {
+				if appConfig.RateLimitFailClosed {
 					c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
 						"error": "Rate limit service temporarily unavailable",
 					})
@@ -246,6 +257,11 @@ func RequestTrackingMiddleware(trackingService *Service, logger *logger.Logger,
 				}
 			}
 
+			// Check audio minute quotas (audio endpoints only)
+			if isAudioEndpoint(c.FullPath()) && !checkAudioQuota(c, trackingService, tierConfig, userID, log) {
+				return
+			}
+
 			// Store tier config in context for later use
 			c.Set("tierConfig", tierConfig)
 			if expiresAt != nil {
@@ -262,3 +278,60 @@ func RequestTrackingMiddleware(trackingService *Service, logger *logger.Logger,
 		c.Next()
 	}
 }
+
+// isAudioEndpoint reports whether a route (gin route path) is metered in audio minutes. Voice
+// turns are served under every API version (/api/v1, /api/v2).
+func isAudioEndpoint(route string) bool {
+	return strings.HasPrefix(route, "/audio/") || route == "/realtime" ||
+		(strings.HasPrefix(route, "/api/") && strings.HasSuffix(route, "/voice/turn"))
+}
+
+// checkAudioQuota enforces the tier's audio minute limits, aborting the request if one is
+// exhausted. Like the plan token checks, it fails open on database errors.
+func checkAudioQuota(c *gin.Context, trackingService *Service, tierConfig tiers.Config, userID string, log *logger.Logger) bool {
+	limits := []struct {
+		period  string
+		minutes int64
+		used    func() (float64, error)
+		resets  func() time.Time
+	}{
+		{"monthly", tierConfig.MonthlyAudioMinutes, func() (float64, error) {
+			return trackingService.GetUserAudioSecondsThisMonth(c.Request.Context(), userID)
+		}, tierConfig.GetMonthlyAudioResetTime},
+		{"daily", tierConfig.DailyAudioMinutes, func() (float64, error) {
+			return trackingService.GetUserAudioSecondsToday(c.Request.Context(), userID)
+		}, tierConfig.GetDailyAudioResetTime},
+	}
+
+	for _, limit := range limits {
+		if limit.minutes <= 0 {
+			continue
+		}
+		seconds, err := limit.used()
+		if err != nil {
+			log.Error("failed to check audio limit; allowing request because rate limits fail open",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID),
+				slog.String("tier", tierConfig.Name),
+				slog.String("period", limit.period),
+				slog.Int64("limit_minutes", limit.minutes))
+			continue
+		}
+		used := int64(seconds / 60)
+		if used >= limit.minutes {
+			log.Warn("audio limit exceeded",
+				slog.String("user_id", userID),
+				slog.String("tier", tierConfig.Name),
+				slog.String("period", limit.period),
+				slog.Int64("limit_minutes", limit.minutes),
+				slog.Int64("used_minutes", used))
+			errors.AbortWithRateLimit(c, errors.AudioLimitExceeded(
+				tierConfig.Name, tierConfig.DisplayName, limit.period,
+				limit.minutes, used,
+				limit.resets(),
+			))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package request_tracking
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// usageQueries reports a free tier user with no plan token usage and audioSeconds of audio this
+// month; other Querier methods are not used.
+type usageQueries struct {
+	pgdb.Querier
+	audioSeconds float64
+}
+
+func (q *usageQueries) GetUserTier(context.Context, string) (pgdb.GetUserTierRow, error) {
+	return pgdb.GetUserTierRow{}, sql.ErrNoRows
+}
+
+func (q *usageQueries) GetUserPlanTokensThisMonth(context.Context, string) (int64, error) {
+	return 0, nil
+}
+
+func (q *usageQueries) GetUserPlanTokensThisWeek(context.Context, string) (int64, error) {
+	return 0, nil
+}
+
+func (q *usageQueries) GetUserPlanTokensToday(context.Context, string) (int64, error) {
+	return 0, nil
+}
+
+func (q *usageQueries) GetUserAudioSecondsThisMonth(context.Context, string) (float64, error) {
+	return q.audioSeconds, nil
+}
+
+func (q *usageQueries) GetUserAudioSecondsToday(context.Context, string) (float64, error) {
+	return q.audioSeconds, nil
+}
+
+func TestRequestTrackingMiddlewareAudioQuota(t *testing.T) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{RateLimitEnabled: true, RequestTrackingWorkerPoolSize: 1, RequestTrackingBufferSize: 10, RequestTrackingTimeoutSeconds: 5}
+	defer func() { config.AppConfig = previous }()
+
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	queries := &usageQueries{}
+	service := NewService(queries, log)
+	defer service.Shutdown(context.Background()) //nolint:errcheck
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(string(auth.UserIDKey), "user-1")
+	}, RequestTrackingMiddleware(service, log, nil))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	for _, version := range []string{"/api/v1", "/api/v2"} {
+		router.POST(version+"/voice/turn", ok)
+	}
+	router.POST("/api/v1/voice/settings", ok)
+
+	request := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w.Code
+	}
+
+	// The free tier has 30 audio minutes a month
+	for _, tc := range []struct {
+		path         string
+		audioMinutes float64
+		want         int
+	}{
+		{"/api/v1/voice/turn", 10, http.StatusOK},
+		{"/api/v2/voice/turn", 10, http.StatusOK},
+		{"/api/v1/voice/turn", 31, http.StatusTooManyRequests},
+		{"/api/v2/voice/turn", 31, http.StatusTooManyRequests},
+		{"/api/v1/voice/settings", 31, http.StatusOK},
+	} {
+		queries.audioSeconds = tc.audioMinutes * 60
+		if code := request(tc.path); code != tc.want {
+			t.Errorf("POST %s with %v audio minutes = %d, want %d", tc.path, tc.audioMinutes, code, tc.want)
+		}
+	}
+}
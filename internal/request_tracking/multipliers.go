@@ -0,0 +1,75 @@
+package request_tracking
+
+import (
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+// wildcardModel is the model_router entry that serves models without their own entry.
+const wildcardModel = "*"
+
+// MultiplierTable maps model names as they appear in request_logs.model to token multipliers.
+// It mirrors how the live proxy prices requests: the request model is matched against
+// canonical names, aliases, and provider-specific model names, and anything else is
+// priced like the wildcard route.
+type MultiplierTable struct {
+	byModel     map[string]float64
+	fallback    float64
+	hasFallback bool
+}
+
+// NewMultiplierTable builds a table from the model router configuration. overrides
+// (e.g., historical multipliers) take precedence over configured values; cfg may be nil
+// when only overrides are used.
+func NewMultiplierTable(cfg *config.ModelRouterConfig, overrides map[string]float64) *MultiplierTable {
+	table := &MultiplierTable{byModel: make(map[string]float64)}
+
+	if cfg != nil {
+		// Provider-specific names first so canonical names and aliases win on collisions
+		for _, model := range cfg.Models {
+			for _, provider := range model.Providers {
+				if provider.Model != "" {
+					table.byModel[provider.Model] = model.TokenMultiplier
+				}
+			}
+		}
+		for _, model := range cfg.Models {
+			if model.Name == wildcardModel {
+				table.fallback = model.TokenMultiplier
+				table.hasFallback = true
+				continue
+			}
+			table.byModel[model.Name] = model.TokenMultiplier
+			for _, alias := range model.Aliases {
+				table.byModel[alias] = model.TokenMultiplier
+			}
+		}
+	}
+
+	for model, multiplier := range overrides {
+		if model == wildcardModel {
+			table.fallback = multiplier
+			table.hasFallback = true
+			continue
+		}
+		table.byModel[model] = multiplier
+	}
+
+	return table
+}
+
+// Lookup returns the multiplier for model, falling back to the wildcard route.
+// Returns false when neither matches.
+func (t *MultiplierTable) Lookup(model string) (float64, bool) {
+	if multiplier, ok := t.byModel[model]; ok {
+		return multiplier, true
+	}
+	if t.hasFallback {
+		return t.fallback, true
+	}
+	return 0, false
+}
+
+// PlanTokens converts raw tokens to plan tokens the same way live requests are logged.
+func PlanTokens(totalTokens int, multiplier float64) int {
+	return int(float64(totalTokens) * multiplier)
+}
@@ -0,0 +1,72 @@
+package request_tracking
+
+import (
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+func TestMultiplierTable(t *testing.T) {
+	cfg := &config.ModelRouterConfig{
+		Models: []config.ModelConfig{
+			{
+				Name:            "openai/gpt-5-pro",
+				Aliases:         []string{"gpt-5-pro"},
+				TokenMultiplier: 50,
+				Providers:       []config.ModelEndpointProvider{{Name: "OpenAI", Model: "gpt-5-pro-2025"}},
+			},
+			{
+				Name:            "moonshot/kimi-k2",
+				Aliases:         []string{"kimi"},
+				TokenMultiplier: 0.75,
+				Providers:       []config.ModelEndpointProvider{{Name: "Tinfoil", Model: "kimi-k2-6"}},
+			},
+			{Name: "*", TokenMultiplier: 1},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		cfg       *config.ModelRouterConfig
+		overrides map[string]float64
+		model     string
+		want      float64
+		wantOK    bool
+	}{
+		{name: "canonical", cfg: cfg, model: "openai/gpt-5-pro", want: 50, wantOK: true},
+		{name: "alias", cfg: cfg, model: "kimi", want: 0.75, wantOK: true},
+		{name: "provider model name", cfg: cfg, model: "kimi-k2-6", want: 0.75, wantOK: true},
+		{name: "wildcard fallback", cfg: cfg, model: "mistral/unknown", want: 1, wantOK: true},
+		{name: "override wins", cfg: cfg, overrides: map[string]float64{"gpt-5-pro": 20}, model: "gpt-5-pro", want: 20, wantOK: true},
+		{name: "override-only table", overrides: map[string]float64{"legacy": 3}, model: "legacy", want: 3, wantOK: true},
+		{name: "unknown without wildcard", overrides: map[string]float64{"legacy": 3}, model: "other", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NewMultiplierTable(tt.cfg, tt.overrides).Lookup(tt.model)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Lookup(%q) = (%v, %v), want (%v, %v)", tt.model, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPlanTokens(t *testing.T) {
+	tests := []struct {
+		total      int
+		multiplier float64
+		want       int
+	}{
+		{total: 1000, multiplier: 1, want: 1000},
+		{total: 1000, multiplier: 50, want: 50000},
+		{total: 999, multiplier: 0.75, want: 749},
+		{total: 0, multiplier: 4, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := PlanTokens(tt.total, tt.multiplier); got != tt.want {
+			t.Errorf("PlanTokens(%d, %v) = %d, want %d", tt.total, tt.multiplier, got, tt.want)
+		}
+	}
+}
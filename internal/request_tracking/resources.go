@@ -0,0 +1,129 @@
+package request_tracking
+
+import (
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+)
+
+// Resource keys in RateLimitStatusResponse.Resources.
+const (
+	ResourceChatPlanTokens     = "chat_plan_tokens"
+	ResourceFallbackPlanTokens = "fallback_plan_tokens"
+	ResourceDeepResearchRuns   = "deep_research_runs"
+	ResourceSearches           = "searches"
+	ResourceToolCalls          = "tool_calls"
+)
+
+// Quota windows reported in ResourceStatus.Window.
+const (
+	WindowDaily    = "daily"
+	WindowWeekly   = "weekly"
+	WindowMonthly  = "monthly"
+	WindowLifetime = "lifetime"
+)
+
+// ResourceStatus is the machine-readable state of a single rate-limited resource.
+//
+// When several quota windows apply (e.g., weekly and daily plan tokens), the
+// binding window is reported: the exhausted one if blocked, else the one with
+// the least remaining.
+type ResourceStatus struct {
+	Metered   bool       `json:"metered"`          // false = not tracked or limited by the proxy
+	Unlimited bool       `json:"unlimited"`        // true = no limit applies for this tier
+	Used      int64      `json:"used"`             // Usage in the reported window
+	Limit     int64      `json:"limit"`            // 0 when unlimited
+	Remaining int64      `json:"remaining"`        // 0 when unlimited
+	Window    string     `json:"window,omitempty"` // "daily", "weekly", "monthly", "lifetime"
+	ResetsAt  *time.Time `json:"resets_at,omitempty"`
+	Blocked   bool       `json:"blocked"` // true = requests for this resource are currently rejected
+}
+
+// quotaWindow is one configured limit for a resource.
+type quotaWindow struct {
+	window   string
+	used     int64
+	limit    int64
+	resetsAt time.Time // Zero = never resets
+}
+
+// resolveResourceStatus picks the binding window for a resource.
+// enforced=false reports usage without blocking (rate limiting disabled for the resource).
+func resolveResourceStatus(windows []quotaWindow, enforced bool) *ResourceStatus {
+	if len(windows) == 0 {
+		return &ResourceStatus{Metered: true, Unlimited: true}
+	}
+
+	var binding *quotaWindow
+	for i := range windows {
+		w := &windows[i]
+		exhausted := w.used >= w.limit
+		switch {
+		case binding == nil:
+			binding = w
+		case exhausted && binding.used < binding.limit:
+			// An exhausted window always wins over one with room left
+			binding = w
+		case exhausted && binding.used >= binding.limit:
+			// Both exhausted: the later reset determines when the user is unblocked
+			if w.resetsAt.IsZero() || (!binding.resetsAt.IsZero() && w.resetsAt.After(binding.resetsAt)) {
+				binding = w
+			}
+		case !exhausted && binding.used < binding.limit:
+			if w.limit-w.used < binding.limit-binding.used {
+				binding = w
+			}
+		}
+	}
+
+	remaining := binding.limit - binding.used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	status := &ResourceStatus{
+		Metered:   true,
+		Used:      binding.used,
+		Limit:     binding.limit,
+		Remaining: remaining,
+		Window:    binding.window,
+		Blocked:   enforced && remaining == 0,
+	}
+	if !binding.resetsAt.IsZero() {
+		resetsAt := binding.resetsAt
+		status.ResetsAt = &resetsAt
+	}
+	return status
+}
+
+// unmeteredResourceStatus describes a resource the proxy does not count or limit.
+func unmeteredResourceStatus() *ResourceStatus {
+	return &ResourceStatus{Metered: false, Unlimited: true}
+}
+
+// chatPlanTokenWindows returns the configured plan token windows from the response's token limit info.
+func chatPlanTokenWindows(response *RateLimitStatusResponse) []quotaWindow {
+	var windows []quotaWindow
+	if response.MonthlyTokens != nil {
+		windows = append(windows, quotaWindow{WindowMonthly, response.MonthlyTokens.Used, response.MonthlyTokens.Limit, response.MonthlyTokens.ResetsAt})
+	}
+	if response.WeeklyTokens != nil {
+		windows = append(windows, quotaWindow{WindowWeekly, response.WeeklyTokens.Used, response.WeeklyTokens.Limit, response.WeeklyTokens.ResetsAt})
+	}
+	if response.DailyTokens != nil {
+		windows = append(windows, quotaWindow{WindowDaily, response.DailyTokens.Used, response.DailyTokens.Limit, response.DailyTokens.ResetsAt})
+	}
+	return windows
+}
+
+// deepResearchWindows mirrors the daily/lifetime checks in deepr.checkDeepResearchQuota.
+func deepResearchWindows(tierConfig tiers.Config, dailyUsed, lifetimeUsed int64, nextMidnight time.Time) []quotaWindow {
+	var windows []quotaWindow
+	if tierConfig.DeepResearchDailyRuns > 0 {
+		windows = append(windows, quotaWindow{WindowDaily, dailyUsed, int64(tierConfig.DeepResearchDailyRuns), nextMidnight})
+	}
+	if tierConfig.DeepResearchLifetimeRuns > 0 {
+		windows = append(windows, quotaWindow{WindowLifetime, lifetimeUsed, int64(tierConfig.DeepResearchLifetimeRuns), time.Time{}})
+	}
+	return windows
+}
@@ -0,0 +1,107 @@
+package request_tracking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveResourceStatus(t *testing.T) {
+	tomorrow := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	nextWeek := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		windows       []quotaWindow
+		enforced      bool
+		wantWindow    string
+		wantRemaining int64
+		wantBlocked   bool
+		wantUnlimited bool
+		wantResetsAt  *time.Time
+	}{
+		{
+			name:          "no windows is unlimited",
+			enforced:      true,
+			wantUnlimited: true,
+		},
+		{
+			name: "least remaining wins",
+			windows: []quotaWindow{
+				{WindowWeekly, 100, 1000, nextWeek},
+				{WindowDaily, 150, 200, tomorrow},
+			},
+			enforced:      true,
+			wantWindow:    WindowDaily,
+			wantRemaining: 50,
+			wantResetsAt:  &tomorrow,
+		},
+		{
+			name: "exhausted window wins over one with room left",
+			windows: []quotaWindow{
+				{WindowDaily, 10, 200, tomorrow},
+				{WindowWeekly, 1200, 1000, nextWeek},
+			},
+			enforced:     true,
+			wantWindow:   WindowWeekly,
+			wantBlocked:  true,
+			wantResetsAt: &nextWeek,
+		},
+		{
+			name: "later reset wins when several are exhausted",
+			windows: []quotaWindow{
+				{WindowWeekly, 1000, 1000, nextWeek},
+				{WindowDaily, 200, 200, tomorrow},
+			},
+			enforced:     true,
+			wantWindow:   WindowWeekly,
+			wantBlocked:  true,
+			wantResetsAt: &nextWeek,
+		},
+		{
+			name: "lifetime limit never resets",
+			windows: []quotaWindow{
+				{WindowDaily, 1, 10, tomorrow},
+				{WindowLifetime, 1, 1, time.Time{}},
+			},
+			enforced:    true,
+			wantWindow:  WindowLifetime,
+			wantBlocked: true,
+		},
+		{
+			name: "not blocked when enforcement is off",
+			windows: []quotaWindow{
+				{WindowDaily, 300, 200, tomorrow},
+			},
+			enforced:     false,
+			wantWindow:   WindowDaily,
+			wantResetsAt: &tomorrow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveResourceStatus(tt.windows, tt.enforced)
+			if !got.Metered {
+				t.Errorf("Metered = false, want true")
+			}
+			if got.Unlimited != tt.wantUnlimited {
+				t.Errorf("Unlimited = %v, want %v", got.Unlimited, tt.wantUnlimited)
+			}
+			if got.Window != tt.wantWindow {
+				t.Errorf("Window = %q, want %q", got.Window, tt.wantWindow)
+			}
+			if got.Remaining != tt.wantRemaining {
+				t.Errorf("Remaining = %d, want %d", got.Remaining, tt.wantRemaining)
+			}
+			if got.Blocked != tt.wantBlocked {
+				t.Errorf("Blocked = %v, want %v", got.Blocked, tt.wantBlocked)
+			}
+			switch {
+			case tt.wantResetsAt == nil && got.ResetsAt != nil:
+				t.Errorf("ResetsAt = %v, want nil", got.ResetsAt)
+			case tt.wantResetsAt != nil && (got.ResetsAt == nil || !got.ResetsAt.Equal(*tt.wantResetsAt)):
+				t.Errorf("ResetsAt = %v, want %v", got.ResetsAt, tt.wantResetsAt)
+			}
+		})
+	}
+}
@@ -6,30 +6,30 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"sync"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/sandbox"
 	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
 	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
 )
 
 type Service struct {
 	queries              pgdb.Querier
-	logChan              chan logRequest
-	workerPool           sync.WaitGroup
-	shutdown             chan struct{}
-	closed               atomic.Bool
+	pool                 *worker.Pool[logRequest]
 	logger               *logger.Logger
+	experiments          QuotaExperiments
 	droppedRequestsTotal atomic.Int64 // Track dropped requests due to queue overflow.
+}
 
-	// workerCtx is the parent context for every DB write. Cancelled by
-	// Shutdown when the bounded drain deadline is exceeded, which forces
-	// in-flight pgx calls to abort instead of holding shutdown open.
-	workerCtx    context.Context
-	workerCancel context.CancelFunc
+// QuotaExperiments adjusts a user's tier limits for the cohort experiments they are part of.
+type QuotaExperiments interface {
+	Apply(userID string, tier tiers.Tier, cfg tiers.Config) tiers.Config
 }
 
 type logRequest struct {
@@ -37,45 +37,38 @@ type logRequest struct {
 }
 
 func NewService(queries pgdb.Querier, logger *logger.Logger) *Service {
-	workerCtx, workerCancel := context.WithCancel(context.Background())
 	s := &Service{
-		queries:      queries,
-		logChan:      make(chan logRequest, config.AppConfig.RequestTrackingBufferSize),
-		shutdown:     make(chan struct{}),
-		logger:       logger,
-		workerCtx:    workerCtx,
-		workerCancel: workerCancel,
+		queries: queries,
+		logger:  logger,
 	}
 
-	// Worker pool with configurable number of workers.
-	for i := 0; i < config.AppConfig.RequestTrackingWorkerPoolSize; i++ {
-		s.workerPool.Add(1)
-		go s.logWorker()
-	}
+	// Worker pool with configurable number of workers. Each DB write gets a fresh
+	// timeout context from the pool — see LogRequestAsync.
+	s.pool = worker.NewPool(worker.Options{
+		Name:      "request_logs",
+		Workers:   config.AppConfig.RequestTrackingWorkerPoolSize,
+		QueueSize: config.AppConfig.RequestTrackingBufferSize,
+		Timeout:   time.Duration(config.AppConfig.RequestTrackingTimeoutSeconds) * time.Second,
+	}, s.handleLogRequest, logger)
 
 	return s
 }
 
-// logWorker processes log requests from the channel.
-func (s *Service) logWorker() {
-	defer s.workerPool.Done()
-
-	for {
-		select {
-		case logReq := <-s.logChan:
-			s.handleLogRequest(logReq)
-		case <-s.shutdown:
-			// Process remaining log requests before shutdown.
-			for {
-				select {
-				case logReq := <-s.logChan:
-					s.handleLogRequest(logReq)
-				default:
-					return
-				}
-			}
-		}
-	}
+// SetQuotaExperiments registers cohort experiments consulted by GetUserTierConfig.
+// Must be called before requests are served.
+func (s *Service) SetQuotaExperiments(experiments QuotaExperiments) {
+	s.experiments = experiments
+}
+
+// SetWorkers changes the number of workers writing queued log requests (config reload).
+func (s *Service) SetWorkers(workers int) {
+	s.pool.Resize(workers)
+}
+
+// Flush drains queued log requests on the calling goroutine until the queue is
+// empty or ctx is done, alongside the regular workers. Returns how many were written.
+func (s *Service) Flush(ctx context.Context) int {
+	return s.pool.Flush(ctx)
 }
 
 // processLogRequest handles the actual database insertion.
@@ -85,6 +78,11 @@ func (s *Service) processLogRequest(ctx context.Context, info RequestInfo) {
 		model = &info.Model
 	}
 
+	var reasoningEffort *string
+	if info.ReasoningEffort != "" {
+		reasoningEffort = &info.ReasoningEffort
+	}
+
 	var promptTokens, completionTokens, totalTokens sql.NullInt32
 	if info.PromptTokens != nil {
 		promptTokens = sql.NullInt32{Int32: int32(*info.PromptTokens), Valid: true}
@@ -96,6 +94,21 @@ func (s *Service) processLogRequest(ctx context.Context, info RequestInfo) {
 		totalTokens = sql.NullInt32{Int32: int32(*info.TotalTokens), Valid: true}
 	}
 
+	var audioSeconds sql.NullFloat64
+	var audioCharacters sql.NullInt32
+	if info.AudioSeconds != nil {
+		audioSeconds = sql.NullFloat64{Float64: *info.AudioSeconds, Valid: true}
+	}
+	if info.AudioCharacters != nil {
+		audioCharacters = sql.NullInt32{Int32: int32(*info.AudioCharacters), Valid: true}
+	}
+
+	var streamAnomalies *string
+	if len(info.StreamAnomalies) > 0 {
+		joined := strings.Join(info.StreamAnomalies, ",")
+		streamAnomalies = &joined
+	}
+
 	// Use new query with plan tokens if available, otherwise use old query
 	if info.PlanTokens != nil && info.Multiplier != nil {
 		params := pgdb.CreateRequestLogWithPlanTokensParams{
@@ -111,6 +124,12 @@ func (s *Service) processLogRequest(ctx context.Context, info RequestInfo) {
 			// for NUMERIC(8,2) columns. PostgreSQL converts strings to NUMERIC on insert.
 			// This is standard sqlc behavior for NUMERIC types.
 			TokenMultiplier: sql.NullString{String: fmt.Sprintf("%.2f", *info.Multiplier), Valid: true},
+			ReasoningEffort: reasoningEffort,
+			AudioSeconds:    audioSeconds,
+			AudioCharacters: audioCharacters,
+			StreamAnomalies: streamAnomalies,
+			StreamRetries:   int32(info.StreamRetries),
+			PrivacyMode:     info.PrivacyMode,
 		}
 
 		if err := s.queries.CreateRequestLogWithPlanTokens(ctx, params); err != nil {
@@ -135,7 +154,8 @@ func (s *Service) processLogRequest(ctx context.Context, info RequestInfo) {
 			slog.String("provider", info.Provider),
 			slog.Int("total_tokens", intValue(info.TotalTokens)),
 			slog.Int("plan_tokens", intValue(info.PlanTokens)),
-			slog.Float64("multiplier", float64Value(info.Multiplier)))
+			slog.Float64("multiplier", float64Value(info.Multiplier)),
+			slog.String("reasoning_effort", info.ReasoningEffort))
 	} else {
 		// Fallback to old query for backward compatibility
 		params := pgdb.CreateRequestLogParams{
@@ -146,6 +166,7 @@ func (s *Service) processLogRequest(ctx context.Context, info RequestInfo) {
 			PromptTokens:     promptTokens,
 			CompletionTokens: completionTokens,
 			TotalTokens:      totalTokens,
+			PrivacyMode:      info.PrivacyMode,
 		}
 
 		if err := s.queries.CreateRequestLog(ctx, params); err != nil {
@@ -186,13 +207,6 @@ func float64Value(value *float64) float64 {
 
 // LogRequestAsync queues a log request to be processed by the worker pool.
 func (s *Service) LogRequestAsync(ctx context.Context, info RequestInfo) error {
-	if s.closed.Load() {
-		s.logger.Warn("Request tracking service is shutting down, dropping request",
-			slog.String("user_id", info.UserID),
-			slog.String("endpoint", info.Endpoint))
-		return fmt.Errorf("service shutting down")
-	}
-
 	// The caller's context governs ONLY the queue-insertion attempt. Once the
 	// request is queued, it must complete on its own clock — the caller's
 	// context is often cancelled long before a worker dequeues (e.g. the
@@ -200,25 +214,29 @@ func (s *Service) LogRequestAsync(ctx context.Context, info RequestInfo) error {
 	// `defer cancel` purely to bound this call). Storing the caller's context
 	// on the queued request caused every GPT-5 Pro DB write to fail with
 	// `context canceled`, silently dropping the row and letting users bypass
-	// per-tier plan-token quotas. The worker creates its own fresh context.
+	// per-tier plan-token quotas. The pool creates a fresh context per write.
 	logReq := logRequest{
 		info: info,
 	}
 
-	select {
-	case s.logChan <- logReq:
-		s.logger.Debug("queued request log",
+	// Sandbox usage is never counted against quotas
+	if sandbox.FromContext(ctx) {
+		s.logger.Debug("skipping request log for sandbox request",
 			slog.String("user_id", info.UserID),
 			slog.String("endpoint", info.Endpoint),
-			slog.String("model", info.Model),
-			slog.String("provider", info.Provider),
-			slog.Int("total_tokens", intValue(info.TotalTokens)),
-			slog.Int("plan_tokens", intValue(info.PlanTokens)),
-			slog.Float64("multiplier", float64Value(info.Multiplier)),
-			slog.Int("queue_depth", len(s.logChan)),
-			slog.Int("queue_capacity", cap(s.logChan)))
+			slog.String("model", info.Model))
 		return nil
-	case <-ctx.Done():
+	}
+
+	if privacy.FromContext(ctx) {
+		logReq.info.PrivacyMode = true
+	}
+	// Ephemeral conversations leave only their usage behind
+	if privacy.EphemeralFromContext(ctx) {
+		logReq.info.StreamAnomalies = nil
+	}
+
+	if err := ctx.Err(); err != nil {
 		s.logger.Warn("request log enqueue canceled",
 			slog.String("user_id", info.UserID),
 			slog.String("endpoint", info.Endpoint),
@@ -226,9 +244,18 @@ func (s *Service) LogRequestAsync(ctx context.Context, info RequestInfo) error {
 			slog.String("provider", info.Provider),
 			slog.Int("total_tokens", intValue(info.TotalTokens)),
 			slog.Int("plan_tokens", intValue(info.PlanTokens)),
-			slog.String("error", ctx.Err().Error()))
-		return ctx.Err()
-	default:
+			slog.String("error", err.Error()))
+		return err
+	}
+
+	err := s.pool.TryEnqueue(logReq)
+	switch {
+	case errors.Is(err, worker.ErrClosed):
+		s.logger.Warn("Request tracking service is shutting down, dropping request",
+			slog.String("user_id", info.UserID),
+			slog.String("endpoint", info.Endpoint))
+		return fmt.Errorf("service shutting down")
+	case errors.Is(err, worker.ErrQueueFull):
 		// Queue is full - increment counter and log error
 		dropped := s.droppedRequestsTotal.Add(1)
 		s.logger.Error("Request log queue FULL - request DROPPED",
@@ -240,58 +267,42 @@ func (s *Service) LogRequestAsync(ctx context.Context, info RequestInfo) error {
 			slog.Int("plan_tokens", intValue(info.PlanTokens)),
 			slog.Float64("multiplier", float64Value(info.Multiplier)),
 			slog.Int64("total_dropped", dropped),
-			slog.Int("queue_depth", len(s.logChan)),
-			slog.Int("queue_capacity", cap(s.logChan)))
+			slog.Int("queue_depth", s.pool.Len()),
+			slog.Int("queue_capacity", s.pool.Cap()))
 		return fmt.Errorf("log queue is full, dropping request")
 	}
+
+	s.logger.Debug("queued request log",
+		slog.String("user_id", info.UserID),
+		slog.String("endpoint", info.Endpoint),
+		slog.String("model", info.Model),
+		slog.String("provider", info.Provider),
+		slog.Int("total_tokens", intValue(info.TotalTokens)),
+		slog.Int("plan_tokens", intValue(info.PlanTokens)),
+		slog.Float64("multiplier", float64Value(info.Multiplier)),
+		slog.Int("queue_depth", s.pool.Len()),
+		slog.Int("queue_capacity", s.pool.Cap()))
+	return nil
 }
 
 // Shutdown gracefully shuts down the worker pool.
 //
 // Workers drain the queue under their normal per-write timeout. If the
 // supplied context expires before the drain completes, in-flight DB writes
-// are cancelled via workerCancel so this method always returns. Bounding
-// shutdown latency matters because each pgx call can otherwise consume the
-// full RequestTrackingTimeoutSeconds, and `worker_count × queue_depth × timeout`
+// are cancelled so this method always returns. Bounding shutdown latency
+// matters because each pgx call can otherwise consume the full
+// RequestTrackingTimeoutSeconds, and `worker_count × queue_depth × timeout`
 // can run into hours under DB trouble.
-//
-// logChan is intentionally NOT closed: LogRequestAsync checks `closed` and
-// then sends — closing the channel would create a send-on-closed-channel
-// panic race against any caller that has already passed the closed check.
-// The channel is unreferenced after Shutdown returns and is collected.
 func (s *Service) Shutdown(ctx context.Context) error {
-	s.closed.Store(true)
-	close(s.shutdown)
-
-	done := make(chan struct{})
-	go func() {
-		s.workerPool.Wait()
-		close(done)
-	}()
-
-	select {
-	case <-done:
-		s.workerCancel()
-		return nil
-	case <-ctx.Done():
-		// Force in-flight DB writes to abort.
-		s.workerCancel()
-		<-done
-		return ctx.Err()
-	}
+	return s.pool.Shutdown(ctx)
 }
 
-// handleLogRequest builds a fresh timeout context for the DB write derived
-// from workerCtx. The caller's context is deliberately not propagated — see
-// LogRequestAsync.
-func (s *Service) handleLogRequest(lr logRequest) {
-	ctx, cancel := context.WithTimeout(
-		s.workerCtx,
-		time.Duration(config.AppConfig.RequestTrackingTimeoutSeconds)*time.Second,
-	)
-	defer cancel()
-
+// handleLogRequest writes a queued request log. ctx is a fresh timeout context
+// from the pool; the caller's context is deliberately not propagated — see
+// LogRequestAsync. Failures are logged by processLogRequest.
+func (s *Service) handleLogRequest(ctx context.Context, lr logRequest) error {
 	s.processLogRequest(ctx, lr.info)
+	return nil
 }
 
 type RequestInfo struct {
@@ -304,6 +315,12 @@ type RequestInfo struct {
 	TotalTokens      *int     // Raw tokens from API (existing field)
 	PlanTokens       *int     // NEW: Weighted tokens (TotalTokens × Multiplier)
 	Multiplier       *float64 // NEW: Cost multiplier
+	ReasoningEffort  string   // Applied Responses API reasoning effort (empty = not applicable)
+	AudioSeconds     *float64 // Audio transcribed or synthesized (audio requests only)
+	AudioCharacters  *int     // Characters synthesized (speech requests only)
+	StreamAnomalies  []string // Defects detected in a completed stream (empty = healthy)
+	StreamRetries    int      // Times an empty stream was retried before reaching the client
+	PrivacyMode      bool     // Served in privacy mode (set from the request context)
 }
 
 // HasActivePro checks if user has an active Pro entitlement and returns expiry when available.
@@ -409,6 +426,10 @@ func (s *Service) GetUserTierConfig(ctx context.Context, userID string) (tiers.C
 		config = tiers.Configs[tiers.TierFree]
 	}
 
+	if s.experiments != nil {
+		config = s.experiments.Apply(userID, tier, config)
+	}
+
 	return config, expiresAt, nil
 }
 
@@ -471,6 +492,53 @@ func (s *Service) GetUserFallbackPlanTokensToday(ctx context.Context, userID str
 	return result, nil
 }
 
+// GetUserAudioSecondsToday returns seconds of audio transcribed or synthesized today.
+func (s *Service) GetUserAudioSecondsToday(ctx context.Context, userID string) (float64, error) {
+	result, err := s.queries.GetUserAudioSecondsToday(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily audio seconds: %w", err)
+	}
+	return result, nil
+}
+
+// GetUserAudioSecondsThisMonth returns seconds of audio transcribed or synthesized this month.
+func (s *Service) GetUserAudioSecondsThisMonth(ctx context.Context, userID string) (float64, error) {
+	result, err := s.queries.GetUserAudioSecondsThisMonth(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get monthly audio seconds: %w", err)
+	}
+	return result, nil
+}
+
+// RemainingAudioSeconds returns the audio seconds left in the tier's daily and monthly audio
+// limits, whichever is lower, and the period of that limit ("daily" or "monthly"). period is
+// empty for tiers without audio limits.
+func (s *Service) RemainingAudioSeconds(ctx context.Context, userID string, tierConfig tiers.Config) (remaining float64, period string, err error) {
+	limits := []struct {
+		period  string
+		minutes int64
+		used    func(ctx context.Context, userID string) (float64, error)
+	}{
+		{"daily", tierConfig.DailyAudioMinutes, s.GetUserAudioSecondsToday},
+		{"monthly", tierConfig.MonthlyAudioMinutes, s.GetUserAudioSecondsThisMonth},
+	}
+
+	for _, limit := range limits {
+		if limit.minutes <= 0 {
+			continue
+		}
+		used, err := limit.used(ctx, userID)
+		if err != nil {
+			return 0, "", err
+		}
+		left := max(0, float64(limit.minutes*60)-used)
+		if period == "" || left < remaining {
+			remaining, period = left, limit.period
+		}
+	}
+	return remaining, period, nil
+}
+
 // GetUserDeepResearchRunsToday returns deep research runs today.
 func (s *Service) GetUserDeepResearchRunsToday(ctx context.Context, userID string) (int64, error) {
 	result, err := s.queries.GetUserDeepResearchRunsToday(ctx, userID)
@@ -489,11 +557,25 @@ func (s *Service) GetUserDeepResearchRunsLifetime(ctx context.Context, userID st
 	return result, nil
 }
 
+// CountActiveDeepResearchRuns returns the number of the user's deep research runs in progress or queued.
+func (s *Service) CountActiveDeepResearchRuns(ctx context.Context, userID string) (int64, error) {
+	result, err := s.queries.CountActiveDeepResearchRuns(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active deep research runs: %w", err)
+	}
+	return result, nil
+}
+
+// DroppedRequests returns the number of request logs dropped because the queue was full.
+func (s *Service) DroppedRequests() int64 {
+	return s.droppedRequestsTotal.Load()
+}
+
 // GetMetrics returns diagnostic metrics for request tracking.
 func (s *Service) GetMetrics() map[string]int64 {
 	return map[string]int64{
 		"dropped_requests_total": s.droppedRequestsTotal.Load(),
-		"queue_size":             int64(len(s.logChan)),
+		"queue_size":             int64(s.pool.Len()),
 		"queue_capacity":         int64(config.AppConfig.RequestTrackingBufferSize),
 	}
 }
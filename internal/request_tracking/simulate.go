@@ -0,0 +1,275 @@
+package request_tracking
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/audiousage"
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+// Request kinds accepted by RateLimitSimulateHandler's resource parameter.
+const (
+	SimulateChat         = "chat"          // tokens=N raw tokens (plan tokens if no model is given)
+	SimulateAudio        = "audio"         // seconds=N of audio transcribed or synthesized
+	SimulateDeepResearch = "deep_research" // runs=N (default 1)
+)
+
+// ResourceAudioSeconds is the audio minute quota, reported in seconds, in RateLimitSimulation.Resources.
+const ResourceAudioSeconds = "audio_seconds"
+
+// BlockedByModel is RateLimitSimulation.BlockedBy when the tier may not use the requested model.
+const BlockedByModel = "model"
+
+// RateLimitSimulation is the outcome of a hypothetical request against the user's current quotas.
+type RateLimitSimulation struct {
+	Resource     string                        `json:"resource"`
+	Model        string                        `json:"model,omitempty"`
+	Tier         string                        `json:"tier"`
+	Allowed      bool                          `json:"allowed"`              // The request would pass the quota checks now
+	WithinLimits bool                          `json:"within_limits"`        // Usage after the request stays within every limit
+	BlockedBy    string                        `json:"blocked_by,omitempty"` // Resource key (or "model") rejecting the request
+	Resources    map[string]*SimulatedResource `json:"resources"`            // Keyed by Resource* constants
+}
+
+// SimulatedResource is the state of a resource before and after a hypothetical request.
+type SimulatedResource struct {
+	Amount       int64           `json:"amount"` // Consumed by the request, in the resource's unit
+	Before       *ResourceStatus `json:"before"`
+	After        *ResourceStatus `json:"after"`
+	WithinLimits bool            `json:"within_limits"`
+}
+
+// RateLimitSimulateHandler reports whether a hypothetical request would pass the user's current
+// quotas and the state it would leave them in, so clients can pre-flight large requests.
+// Nothing is recorded.
+func RateLimitSimulateHandler(trackingService *Service, log *logger.Logger, modelRouter *routing.ModelRouter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			errors.Unauthorized(c, "User not authenticated", nil)
+			return
+		}
+
+		reqLog := log.WithContext(c.Request.Context()).WithComponent("rate_limit_simulate")
+
+		resource := c.DefaultQuery("resource", SimulateChat)
+		var param string
+		var defaultAmount float64
+		switch resource {
+		case SimulateChat:
+			param = "tokens"
+		case SimulateAudio:
+			param = "seconds"
+		case SimulateDeepResearch:
+			param, defaultAmount = "runs", 1
+		default:
+			errors.BadRequest(c, "resource must be chat, audio or deep_research", nil)
+			return
+		}
+
+		amount := defaultAmount
+		if value := c.Query(param); value != "" || defaultAmount == 0 {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed <= 0 {
+				errors.BadRequest(c, param+" must be a positive number", nil)
+				return
+			}
+			amount = parsed
+		}
+
+		tierConfig, _, err := trackingService.GetUserTierConfig(c.Request.Context(), userID)
+		if err != nil {
+			reqLog.Error("failed to get tier config",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID))
+			errors.Internal(c, "Failed to get tier information", nil)
+			return
+		}
+
+		simulation := &RateLimitSimulation{
+			Resource:  resource,
+			Tier:      tierConfig.Name,
+			Resources: make(map[string]*SimulatedResource),
+		}
+		ctx := c.Request.Context()
+		enforced := config.Current().RateLimitEnabled
+		var order []string
+
+		switch resource {
+		case SimulateChat:
+			planTokens := int64(amount)
+			if model := c.Query("model"); model != "" {
+				if modelRouter != nil {
+					model = modelRouter.ResolveAlias(model)
+				}
+				simulation.Model = model
+				if !tierConfig.IsModelAllowed(model) {
+					simulation.BlockedBy = BlockedByModel
+				}
+
+				platform := c.GetHeader("X-Client-Platform")
+				if platform == "" {
+					platform = "mobile"
+				}
+				var provider *routing.ProviderConfig
+				if modelRouter != nil {
+					provider, _ = modelRouter.RouteModel(model, platform)
+				}
+				if provider == nil {
+					errors.BadRequest(c, "Unsupported model", map[string]interface{}{"model": model})
+					return
+				}
+				planTokens = int64(PlanTokens(int(amount), provider.TokenMultiplier))
+			}
+			windows := chatWindows(ctx, trackingService, tierConfig, userID, simulation.Model, reqLog)
+			simulation.Resources[ResourceChatPlanTokens] = simulateResource(windows, planTokens, enforced)
+			order = append(order, ResourceChatPlanTokens)
+
+		case SimulateAudio:
+			windows := audioWindows(ctx, trackingService, tierConfig, userID, reqLog)
+			simulation.Resources[ResourceAudioSeconds] = simulateResource(windows, int64(amount), enforced)
+			planTokens := audiousage.PlanTokens(amount, config.AppConfig.AudioPlanTokensPerMinute)
+			simulation.Resources[ResourceChatPlanTokens] = simulateResource(
+				chatWindows(ctx, trackingService, tierConfig, userID, "", reqLog), int64(planTokens), enforced)
+			order = append(order, ResourceChatPlanTokens, ResourceAudioSeconds)
+
+		case SimulateDeepResearch:
+			dailyUsed, err := trackingService.GetUserDeepResearchRunsToday(ctx, userID)
+			if err != nil {
+				reqLog.Error("failed to get daily deep research runs", slog.String("error", err.Error()))
+			}
+			lifetimeUsed, err := trackingService.GetUserDeepResearchRunsLifetime(ctx, userID)
+			if err != nil {
+				reqLog.Error("failed to get lifetime deep research runs", slog.String("error", err.Error()))
+			}
+			now := time.Now().UTC()
+			nextMidnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+			deepResearchEnforced := config.AppConfig.DeepResearchRateLimitEnabled
+			simulated := simulateResource(deepResearchWindows(tierConfig, dailyUsed, lifetimeUsed, nextMidnight), int64(amount), deepResearchEnforced)
+			if deepResearchEnforced && tierConfig.DeepResearchMaxActiveSessions > 0 {
+				activeRuns, err := trackingService.CountActiveDeepResearchRuns(ctx, userID)
+				if err != nil {
+					reqLog.Error("failed to check active deep research runs", slog.String("error", err.Error()))
+				} else if activeRuns >= int64(tierConfig.DeepResearchMaxActiveSessions) {
+					simulated.Before.Blocked = true
+				}
+			}
+			simulation.Resources[ResourceDeepResearchRuns] = simulated
+			order = append(order, ResourceDeepResearchRuns)
+		}
+
+		simulation.WithinLimits = true
+		for _, key := range order {
+			simulated := simulation.Resources[key]
+			if simulated.Before.Blocked && simulation.BlockedBy == "" {
+				simulation.BlockedBy = key
+			}
+			if !simulated.WithinLimits {
+				simulation.WithinLimits = false
+			}
+		}
+		simulation.Allowed = simulation.BlockedBy == ""
+
+		c.JSON(http.StatusOK, simulation)
+	}
+}
+
+// simulateResource adds amount to the usage of every window of a resource. The request is
+// admitted if the resource isn't blocked before it; it stays within limits if no window's
+// usage would exceed its limit afterwards.
+func simulateResource(windows []quotaWindow, amount int64, enforced bool) *SimulatedResource {
+	after := make([]quotaWindow, len(windows))
+	withinLimits := true
+	for i, w := range windows {
+		w.used += amount
+		if w.used > w.limit {
+			withinLimits = false
+		}
+		after[i] = w
+	}
+
+	return &SimulatedResource{
+		Amount:       amount,
+		Before:       resolveResourceStatus(windows, enforced),
+		After:        resolveResourceStatus(after, enforced),
+		WithinLimits: withinLimits,
+	}
+}
+
+// chatWindows returns the plan token windows that apply to a request for model (canonical,
+// or empty for requests without one). Usage query failures are logged and reported as zero usage.
+func chatWindows(ctx context.Context, trackingService *Service, tierConfig tiers.Config, userID, model string, reqLog *logger.Logger) []quotaWindow {
+	usage := func(period string, limit int64, get func(context.Context, string) (int64, error)) int64 {
+		if limit <= 0 {
+			return 0
+		}
+		used, err := get(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to get "+period+" usage", slog.String("error", err.Error()))
+			return 0
+		}
+		return used
+	}
+	monthly := usage("monthly", tierConfig.MonthlyPlanTokens, trackingService.GetUserPlanTokensThisMonth)
+	weekly := usage("weekly", tierConfig.WeeklyPlanTokens, trackingService.GetUserPlanTokensThisWeek)
+	daily := usage("daily", tierConfig.DailyPlanTokens, trackingService.GetUserPlanTokensToday)
+
+	var fallback int64
+	if tierConfig.DailyPlanTokens > 0 && daily >= tierConfig.DailyPlanTokens && tierConfig.IsFallbackModel(model) {
+		fallback = usage("fallback", tierConfig.FallbackDailyPlanTokens, func(ctx context.Context, userID string) (int64, error) {
+			return trackingService.GetUserFallbackPlanTokensToday(ctx, userID, tierConfig.FallbackModel)
+		})
+	}
+	return planTokenWindows(tierConfig, model, monthly, weekly, daily, fallback)
+}
+
+// planTokenWindows mirrors the plan token checks in RequestTrackingMiddleware: once the daily
+// quota is exhausted, requests for the tier's fallback model draw on the fallback quota instead.
+func planTokenWindows(tierConfig tiers.Config, model string, monthly, weekly, daily, fallback int64) []quotaWindow {
+	var windows []quotaWindow
+	if tierConfig.MonthlyPlanTokens > 0 {
+		windows = append(windows, quotaWindow{WindowMonthly, monthly, tierConfig.MonthlyPlanTokens, tierConfig.GetMonthlyResetTime()})
+	}
+	if tierConfig.WeeklyPlanTokens > 0 {
+		windows = append(windows, quotaWindow{WindowWeekly, weekly, tierConfig.WeeklyPlanTokens, tierConfig.GetWeeklyResetTime()})
+	}
+	if tierConfig.DailyPlanTokens > 0 {
+		if daily >= tierConfig.DailyPlanTokens && tierConfig.FallbackDailyPlanTokens > 0 && tierConfig.IsFallbackModel(model) {
+			windows = append(windows, quotaWindow{WindowDaily, fallback, tierConfig.FallbackDailyPlanTokens, tierConfig.GetDailyResetTime()})
+		} else {
+			windows = append(windows, quotaWindow{WindowDaily, daily, tierConfig.DailyPlanTokens, tierConfig.GetDailyResetTime()})
+		}
+	}
+	return windows
+}
+
+// audioWindows mirrors checkAudioQuota, in seconds. Usage query failures are logged and
+// reported as zero usage.
+func audioWindows(ctx context.Context, trackingService *Service, tierConfig tiers.Config, userID string, reqLog *logger.Logger) []quotaWindow {
+	var windows []quotaWindow
+	if tierConfig.MonthlyAudioMinutes > 0 {
+		seconds, err := trackingService.GetUserAudioSecondsThisMonth(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to get monthly audio usage", slog.String("error", err.Error()))
+		}
+		windows = append(windows, quotaWindow{WindowMonthly, int64(seconds), tierConfig.MonthlyAudioMinutes * 60, tierConfig.GetMonthlyAudioResetTime()})
+	}
+	if tierConfig.DailyAudioMinutes > 0 {
+		seconds, err := trackingService.GetUserAudioSecondsToday(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to get daily audio usage", slog.String("error", err.Error()))
+		}
+		windows = append(windows, quotaWindow{WindowDaily, int64(seconds), tierConfig.DailyAudioMinutes * 60, tierConfig.GetDailyAudioResetTime()})
+	}
+	return windows
+}
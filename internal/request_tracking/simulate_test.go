@@ -0,0 +1,87 @@
+package request_tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+)
+
+func TestSimulateResource(t *testing.T) {
+	tomorrow := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	nextWeek := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	windows := []quotaWindow{
+		{WindowWeekly, 500, 1000, nextWeek},
+		{WindowDaily, 150, 200, tomorrow},
+	}
+
+	tests := []struct {
+		name             string
+		windows          []quotaWindow
+		amount           int64
+		enforced         bool
+		wantAllowed      bool
+		wantWithinLimits bool
+		wantAfterWindow  string
+		wantAfterBlocked bool
+	}{
+		{"fits", windows, 50, true, true, true, WindowDaily, true},
+		{"admitted but overshoots", windows, 100, true, true, false, WindowDaily, true},
+		{"small request", windows, 10, true, true, true, WindowDaily, false},
+		{"not enforced", windows, 100, false, true, false, WindowDaily, false},
+		{"unlimited", nil, 1_000_000, true, true, true, "", false},
+		{"already exhausted", []quotaWindow{{WindowDaily, 200, 200, tomorrow}}, 1, true, false, false, WindowDaily, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := simulateResource(tt.windows, tt.amount, tt.enforced)
+			if got.Before.Blocked == tt.wantAllowed {
+				t.Errorf("before blocked = %v, want allowed %v", got.Before.Blocked, tt.wantAllowed)
+			}
+			if got.WithinLimits != tt.wantWithinLimits {
+				t.Errorf("within limits = %v, want %v", got.WithinLimits, tt.wantWithinLimits)
+			}
+			if got.After.Window != tt.wantAfterWindow || got.After.Blocked != tt.wantAfterBlocked {
+				t.Errorf("after = %+v, want window %q blocked %v", got.After, tt.wantAfterWindow, tt.wantAfterBlocked)
+			}
+		})
+	}
+
+	// The input windows are left untouched
+	if windows[1].used != 150 {
+		t.Errorf("simulation modified usage: %+v", windows)
+	}
+}
+
+func TestPlanTokenWindows(t *testing.T) {
+	tier := tiers.Config{
+		MonthlyPlanTokens:       10_000,
+		DailyPlanTokens:         1000,
+		FallbackDailyPlanTokens: 500,
+		FallbackModel:           "fallback-model",
+	}
+
+	tests := []struct {
+		name      string
+		model     string
+		daily     int64
+		wantDaily quotaWindow
+	}{
+		{"daily quota left", "fallback-model", 400, quotaWindow{used: 400, limit: 1000}},
+		{"other model after daily quota", "premium-model", 1000, quotaWindow{used: 1000, limit: 1000}},
+		{"fallback model after daily quota", "fallback-model", 1000, quotaWindow{used: 50, limit: 500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows := planTokenWindows(tier, tt.model, 2000, 0, tt.daily, 50)
+			if len(windows) != 2 || windows[0].window != WindowMonthly || windows[1].window != WindowDaily {
+				t.Fatalf("windows = %+v", windows)
+			}
+			if daily := windows[1]; daily.used != tt.wantDaily.used || daily.limit != tt.wantDaily.limit {
+				t.Errorf("daily window = %+v, want used %d of %d", daily, tt.wantDaily.used, tt.wantDaily.limit)
+			}
+		})
+	}
+}
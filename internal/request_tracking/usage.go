@@ -0,0 +1,147 @@
+package request_tracking
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageResponse is a user's usage against their tier limits, for quota meters. Unlike
+// RateLimitStatusResponse it reports every period, whether or not the tier limits it.
+type UsageResponse struct {
+	Tier                string `json:"tier"`
+	TierDisplay         string `json:"tier_display"`
+	RateLimitingEnabled bool   `json:"rate_limiting_enabled"`
+
+	PlanTokens         UsagePeriods `json:"plan_tokens"`
+	FallbackPlanTokens *UsageMeter  `json:"fallback_plan_tokens,omitempty"` // Today, once plan tokens are exhausted (tiers with a fallback model)
+	FallbackModel      string       `json:"fallback_model,omitempty"`
+	AudioSeconds       UsagePeriods `json:"audio_seconds"`
+	DeepResearchRuns   UsagePeriods `json:"deep_research_runs"`
+}
+
+// UsagePeriods is usage per accounting period; periods the resource isn't counted over are omitted.
+type UsagePeriods struct {
+	Today     *UsageMeter `json:"today,omitempty"`
+	ThisWeek  *UsageMeter `json:"this_week,omitempty"`
+	ThisMonth *UsageMeter `json:"this_month,omitempty"`
+	Lifetime  *UsageMeter `json:"lifetime,omitempty"`
+}
+
+// UsageMeter is the usage of one period and the tier limit for it.
+type UsageMeter struct {
+	Used      int64      `json:"used"`
+	Limit     int64      `json:"limit,omitempty"`     // Omitted when unlimited
+	Remaining *int64     `json:"remaining,omitempty"` // Omitted when unlimited
+	ResetsAt  *time.Time `json:"resets_at,omitempty"` // Omitted when unlimited or never resetting
+}
+
+// newUsageMeter creates a meter. limit <= 0 means unlimited; a zero resetsAt never resets.
+func newUsageMeter(used, limit int64, resetsAt time.Time) *UsageMeter {
+	meter := &UsageMeter{Used: used}
+	if limit <= 0 {
+		return meter
+	}
+	remaining := max(limit-used, 0)
+	meter.Limit = limit
+	meter.Remaining = &remaining
+	if !resetsAt.IsZero() {
+		meter.ResetsAt = &resetsAt
+	}
+	return meter
+}
+
+// UsageHandler handles GET /api/v1/usage
+// Returns the user's plan tokens, audio and deep research usage with their tier limits.
+func UsageHandler(trackingService *Service, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := auth.GetUserID(c)
+		if !exists {
+			errors.Unauthorized(c, "User not authenticated", nil)
+			return
+		}
+
+		reqLog := log.WithContext(c.Request.Context()).WithComponent("usage")
+
+		response, err := BuildUsage(c.Request.Context(), trackingService, userID, reqLog)
+		if err != nil {
+			reqLog.Error("failed to build usage",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID))
+			errors.Internal(c, "Failed to get usage", nil)
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// BuildUsage assembles the usage of a user. As with BuildRateLimitStatus, only a tier lookup
+// failure is returned as an error; usage query failures are logged and reported as zero usage.
+func BuildUsage(ctx context.Context, trackingService *Service, userID string, reqLog *logger.Logger) (*UsageResponse, error) {
+	tierConfig, _, err := trackingService.GetUserTierConfig(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tier config: %w", err)
+	}
+
+	count := func(period string, query func(context.Context, string) (int64, error)) int64 {
+		used, err := query(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to get usage", slog.String("period", period), slog.String("error", err.Error()))
+			return 0
+		}
+		return used
+	}
+	seconds := func(period string, query func(context.Context, string) (float64, error)) int64 {
+		used, err := query(ctx, userID)
+		if err != nil {
+			reqLog.Error("failed to get audio usage", slog.String("period", period), slog.String("error", err.Error()))
+			return 0
+		}
+		return int64(math.Ceil(used))
+	}
+
+	response := &UsageResponse{
+		Tier:                tierConfig.Name,
+		TierDisplay:         tierConfig.DisplayName,
+		RateLimitingEnabled: config.Current().RateLimitEnabled,
+		PlanTokens: UsagePeriods{
+			Today:     newUsageMeter(count("today", trackingService.GetUserPlanTokensToday), tierConfig.DailyPlanTokens, tierConfig.GetDailyResetTime()),
+			ThisWeek:  newUsageMeter(count("this_week", trackingService.GetUserPlanTokensThisWeek), tierConfig.WeeklyPlanTokens, tierConfig.GetWeeklyResetTime()),
+			ThisMonth: newUsageMeter(count("this_month", trackingService.GetUserPlanTokensThisMonth), tierConfig.MonthlyPlanTokens, tierConfig.GetMonthlyResetTime()),
+		},
+		AudioSeconds: UsagePeriods{
+			Today:     newUsageMeter(seconds("today", trackingService.GetUserAudioSecondsToday), tierConfig.DailyAudioMinutes*60, tierConfig.GetDailyAudioResetTime()),
+			ThisMonth: newUsageMeter(seconds("this_month", trackingService.GetUserAudioSecondsThisMonth), tierConfig.MonthlyAudioMinutes*60, tierConfig.GetMonthlyAudioResetTime()),
+		},
+	}
+
+	if tierConfig.FallbackDailyPlanTokens > 0 {
+		used := count("fallback_today", func(ctx context.Context, userID string) (int64, error) {
+			return trackingService.GetUserFallbackPlanTokensToday(ctx, userID, tierConfig.FallbackModel)
+		})
+		response.FallbackPlanTokens = newUsageMeter(used, tierConfig.FallbackDailyPlanTokens, nextUTCMidnight())
+		response.FallbackModel = tierConfig.FallbackModel
+	}
+
+	// A deep research limit of 0 or -1 doesn't limit the period, as in deepResearchWindows
+	response.DeepResearchRuns.Today = newUsageMeter(count("deep_research_today", trackingService.GetUserDeepResearchRunsToday), int64(tierConfig.DeepResearchDailyRuns), nextUTCMidnight())
+	response.DeepResearchRuns.Lifetime = newUsageMeter(count("deep_research_lifetime", trackingService.GetUserDeepResearchRunsLifetime), int64(tierConfig.DeepResearchLifetimeRuns), time.Time{})
+
+	return response, nil
+}
+
+// nextUTCMidnight returns when daily quotas reset.
+func nextUTCMidnight() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
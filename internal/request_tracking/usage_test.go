@@ -0,0 +1,25 @@
+package request_tracking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewUsageMeter(t *testing.T) {
+	tomorrow := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	unlimited := newUsageMeter(500, -1, tomorrow)
+	if unlimited.Limit != 0 || unlimited.Remaining != nil || unlimited.ResetsAt != nil {
+		t.Errorf("unlimited meter = %+v, want usage only", unlimited)
+	}
+
+	over := newUsageMeter(1200, 1000, tomorrow)
+	if over.Limit != 1000 || over.Remaining == nil || *over.Remaining != 0 || !over.ResetsAt.Equal(tomorrow) {
+		t.Errorf("exhausted meter = %+v", over)
+	}
+
+	lifetime := newUsageMeter(1, 3, time.Time{})
+	if lifetime.Remaining == nil || *lifetime.Remaining != 2 || lifetime.ResetsAt != nil {
+		t.Errorf("lifetime meter = %+v", lifetime)
+	}
+}
@@ -0,0 +1,176 @@
+// Package resilience keeps requests away from failing upstream providers with a circuit
+// breaker per provider.
+//
+// A breaker counts the outcomes of a provider's requests over a window: server errors,
+// connection errors and timeouts are failures, and so are responses slower than the slow
+// threshold. Once enough requests were seen and the failure rate reaches the threshold, the
+// breaker opens and the provider gets no requests for the open duration. Then one probe
+// request is let through (half-open): its success closes the breaker, its failure opens it
+// again.
+package resilience
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+)
+
+// State is the state of a provider's circuit breaker.
+type State string
+
+const (
+	StateClosed   State = "closed"    // Requests flow; outcomes are counted
+	StateOpen     State = "open"      // Requests are rejected until the open duration ends
+	StateHalfOpen State = "half_open" // One probe request is in flight
+)
+
+// Options configures the breakers.
+type Options struct {
+	Window        time.Duration // Outcomes are counted over this window
+	MinRequests   int           // Requests in the window before the failure rate is acted on
+	FailureRate   float64       // Failure rate (0-1) that opens the breaker
+	SlowThreshold time.Duration // Responses slower than this count as failures (0 = latency is ignored)
+	OpenDuration  time.Duration // How long an open breaker rejects requests before a probe
+}
+
+// Breakers holds the circuit breakers of all providers.
+type Breakers struct {
+	opts Options
+	now  func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// breaker is the state of one provider.
+type breaker struct {
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openUntil   time.Time // When an open breaker lets a probe through
+	probeStart  time.Time // When the half-open probe was let through
+}
+
+// New creates the breakers.
+func New(opts Options) *Breakers {
+	return &Breakers{
+		opts:     opts,
+		now:      time.Now,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// Allow reports whether a request may be sent to a provider. When it may not, retryAfter is
+// when the provider is expected to accept requests again.
+//
+// An open breaker whose open duration ended lets one request through as a probe. A probe that
+// never reports its outcome (e.g. the client went away) is replaced after the open duration.
+func (b *Breakers) Allow(provider string) (retryAfter time.Duration, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	br := b.breaker(provider)
+	switch br.state {
+	case StateOpen:
+		if now.Before(br.openUntil) {
+			metrics.RecordCircuitBreakerRejected(provider)
+			return br.openUntil.Sub(now), false
+		}
+		b.setState(provider, br, StateHalfOpen)
+		br.probeStart = now
+		return 0, true
+	case StateHalfOpen:
+		if probeExpires := br.probeStart.Add(b.opts.OpenDuration); now.Before(probeExpires) {
+			metrics.RecordCircuitBreakerRejected(provider)
+			return probeExpires.Sub(now), false
+		}
+		br.probeStart = now
+		return 0, true
+	}
+	return 0, true
+}
+
+// Available reports whether a provider's breaker would let a request through, without taking
+// the half-open probe.
+func (b *Breakers) Available(provider string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	br := b.breaker(provider)
+	switch br.state {
+	case StateOpen:
+		return !now.Before(br.openUntil)
+	case StateHalfOpen:
+		return !now.Before(br.probeStart.Add(b.opts.OpenDuration))
+	}
+	return true
+}
+
+// Record reports the outcome of a request to a provider: failed is true for server errors,
+// connection errors and timeouts, and latency is how long the provider took to respond.
+func (b *Breakers) Record(provider string, failed bool, latency time.Duration) {
+	if b.opts.SlowThreshold > 0 && latency > b.opts.SlowThreshold {
+		failed = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	br := b.breaker(provider)
+	switch br.state {
+	case StateHalfOpen:
+		if failed {
+			b.open(provider, br, now)
+		} else {
+			b.setState(provider, br, StateClosed)
+			br.windowStart, br.requests, br.failures = now, 0, 0
+		}
+		return
+	case StateOpen:
+		// Requests sent before the breaker opened
+		return
+	}
+
+	if now.Sub(br.windowStart) >= b.opts.Window {
+		br.windowStart, br.requests, br.failures = now, 0, 0
+	}
+	br.requests++
+	if failed {
+		br.failures++
+	}
+	if br.requests >= b.opts.MinRequests && float64(br.failures) >= b.opts.FailureRate*float64(br.requests) {
+		b.open(provider, br, now)
+	}
+}
+
+// State returns the state of a provider's breaker.
+func (b *Breakers) State(provider string) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.breaker(provider).state
+}
+
+func (b *Breakers) open(provider string, br *breaker, now time.Time) {
+	b.setState(provider, br, StateOpen)
+	br.openUntil = now.Add(b.opts.OpenDuration)
+	br.windowStart, br.requests, br.failures = now, 0, 0
+}
+
+func (b *Breakers) setState(provider string, br *breaker, state State) {
+	br.state = state
+	metrics.RecordCircuitBreakerState(provider, string(state))
+}
+
+func (b *Breakers) breaker(provider string) *breaker {
+	br, ok := b.breakers[provider]
+	if !ok {
+		br = &breaker{state: StateClosed, windowStart: b.now()}
+		b.breakers[provider] = br
+	}
+	return br
+}
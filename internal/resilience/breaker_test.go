@@ -0,0 +1,107 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestBreakers() (*Breakers, *time.Time) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	b := New(Options{
+		Window:        time.Minute,
+		MinRequests:   4,
+		FailureRate:   0.5,
+		SlowThreshold: 10 * time.Second,
+		OpenDuration:  30 * time.Second,
+	})
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestOpensOnFailureRate(t *testing.T) {
+	b, _ := newTestBreakers()
+
+	b.Record("openai", false, time.Second)
+	b.Record("openai", true, time.Second)
+	b.Record("openai", false, time.Second)
+	if got := b.State("openai"); got != StateClosed {
+		t.Fatalf("state after 3 requests = %s, want closed (below the minimum)", got)
+	}
+	b.Record("openai", true, time.Second)
+	if got := b.State("openai"); got != StateOpen {
+		t.Fatalf("state at 50%% failures = %s, want open", got)
+	}
+
+	retryAfter, ok := b.Allow("openai")
+	if ok || retryAfter != 30*time.Second {
+		t.Errorf("Allow = %v, %v, want rejected for 30s", retryAfter, ok)
+	}
+	if _, ok := b.Allow("anthropic"); !ok {
+		t.Error("other providers should not be affected")
+	}
+}
+
+func TestSlowResponsesAreFailures(t *testing.T) {
+	b, _ := newTestBreakers()
+	for range 4 {
+		b.Record("openai", false, 11*time.Second)
+	}
+	if got := b.State("openai"); got != StateOpen {
+		t.Errorf("state after slow responses = %s, want open", got)
+	}
+}
+
+func TestWindowResets(t *testing.T) {
+	b, now := newTestBreakers()
+	for range 3 {
+		b.Record("openai", true, time.Second)
+	}
+	*now = now.Add(time.Minute)
+	b.Record("openai", true, time.Second)
+	if got := b.State("openai"); got != StateClosed {
+		t.Errorf("state = %s, want closed (failures of the previous window don't count)", got)
+	}
+}
+
+func TestHalfOpenProbe(t *testing.T) {
+	b, now := newTestBreakers()
+	for range 4 {
+		b.Record("openai", true, time.Second)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if !b.Available("openai") {
+		t.Error("Available after the open duration = false")
+	}
+	if _, ok := b.Allow("openai"); !ok {
+		t.Fatal("probe not allowed after the open duration")
+	}
+	if _, ok := b.Allow("openai"); ok {
+		t.Fatal("second request allowed while the probe is in flight")
+	}
+
+	// A failed probe opens the breaker again
+	b.Record("openai", true, time.Second)
+	if got := b.State("openai"); got != StateOpen {
+		t.Fatalf("state after a failed probe = %s, want open", got)
+	}
+
+	// A probe that never reports back is replaced
+	*now = now.Add(30 * time.Second)
+	if _, ok := b.Allow("openai"); !ok {
+		t.Fatal("probe not allowed")
+	}
+	*now = now.Add(30 * time.Second)
+	if _, ok := b.Allow("openai"); !ok {
+		t.Fatal("abandoned probe not replaced")
+	}
+
+	// A successful probe closes the breaker
+	b.Record("openai", false, time.Second)
+	if got := b.State("openai"); got != StateClosed {
+		t.Fatalf("state after a successful probe = %s, want closed", got)
+	}
+	if _, ok := b.Allow("openai"); !ok {
+		t.Error("request rejected by a closed breaker")
+	}
+}
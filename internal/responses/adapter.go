@@ -68,6 +68,16 @@ func NewAdapter() *Adapter {
 //	   "store": true, "background": true, "previous_response_id": "resp_abc123",
 //	   "reasoning": {"effort": "high"}}
 func (a *Adapter) TransformRequest(requestBody []byte, previousResponseID string) ([]byte, error) {
+	return a.TransformRequestWithEffort(requestBody, previousResponseID, "")
+}
+
+// TransformRequestWithEffort is TransformRequest with an explicit reasoning effort.
+//
+// When effort is non-empty it overrides whatever the client sent (e.g., after the
+// tier ceiling has been applied). Other fields of a client-provided "reasoning"
+// object (such as "summary") are preserved. An empty effort behaves exactly like
+// TransformRequest.
+func (a *Adapter) TransformRequestWithEffort(requestBody []byte, previousResponseID string, effort ReasoningEffort) ([]byte, error) {
 	// Parse original request
 	var req map[string]interface{}
 	if err := json.Unmarshal(requestBody, &req); err != nil {
@@ -139,11 +149,22 @@ func (a *Adapter) TransformRequest(requestBody []byte, previousResponseID string
 		req["previous_response_id"] = previousResponseID
 	}
 
+	// Apply the resolved reasoning effort (overrides the client value)
+	if effort != "" {
+		if reasoning, ok := req["reasoning"].(map[string]interface{}); ok {
+			reasoning["effort"] = string(effort)
+		} else {
+			req["reasoning"] = map[string]interface{}{
+				"effort": string(effort),
+			}
+		}
+	}
+
 	// Set reasoning effort to "high" (default for GPT-5 Pro)
 	// Only set default if client hasn't provided reasoning parameter
 	if _, exists := req["reasoning"]; !exists {
 		req["reasoning"] = map[string]interface{}{
-			"effort": "high",
+			"effort": string(DefaultReasoningEffort),
 		}
 	}
 
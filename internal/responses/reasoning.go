@@ -0,0 +1,98 @@
+package responses
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ReasoningEffort is a Responses API reasoning.effort value.
+type ReasoningEffort string
+
+const (
+	ReasoningEffortMinimal ReasoningEffort = "minimal"
+	ReasoningEffortLow     ReasoningEffort = "low"
+	ReasoningEffortMedium  ReasoningEffort = "medium"
+	ReasoningEffortHigh    ReasoningEffort = "high"
+
+	// DefaultReasoningEffort is applied when the client does not request one (GPT-5 Pro default).
+	DefaultReasoningEffort = ReasoningEffortHigh
+)
+
+// reasoningEffortRank orders efforts from cheapest to most expensive.
+var reasoningEffortRank = map[ReasoningEffort]int{
+	ReasoningEffortMinimal: 0,
+	ReasoningEffortLow:     1,
+	ReasoningEffortMedium:  2,
+	ReasoningEffortHigh:    3,
+}
+
+// ValidReasoningEfforts returns all accepted reasoning effort values, cheapest first.
+func ValidReasoningEfforts() []string {
+	return []string{
+		string(ReasoningEffortMinimal),
+		string(ReasoningEffortLow),
+		string(ReasoningEffortMedium),
+		string(ReasoningEffortHigh),
+	}
+}
+
+// ParseReasoningEffort validates a reasoning effort value (case-insensitive).
+func ParseReasoningEffort(value string) (ReasoningEffort, error) {
+	effort := ReasoningEffort(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := reasoningEffortRank[effort]; !ok {
+		return "", fmt.Errorf("invalid reasoning_effort %q (allowed: %s)", value, strings.Join(ValidReasoningEfforts(), ", "))
+	}
+	return effort, nil
+}
+
+// RequestedReasoningEffort extracts the client-requested reasoning effort from a
+// Chat Completions style request body.
+//
+// Accepts either the top-level "reasoning_effort" field or the Responses API
+// style "reasoning": {"effort": ...}. The top-level field wins if both are set.
+//
+// Returns:
+//   - ReasoningEffort: The validated effort, or empty string if not provided
+//   - error: If the body is not JSON or the value is not a valid effort
+func RequestedReasoningEffort(requestBody []byte) (ReasoningEffort, error) {
+	var req struct {
+		ReasoningEffort *string `json:"reasoning_effort"`
+		Reasoning       *struct {
+			Effort *string `json:"effort"`
+		} `json:"reasoning"`
+	}
+	if err := json.Unmarshal(requestBody, &req); err != nil {
+		return "", fmt.Errorf("failed to parse request body: %w", err)
+	}
+
+	switch {
+	case req.ReasoningEffort != nil:
+		return ParseReasoningEffort(*req.ReasoningEffort)
+	case req.Reasoning != nil && req.Reasoning.Effort != nil:
+		return ParseReasoningEffort(*req.Reasoning.Effort)
+	default:
+		return "", nil
+	}
+}
+
+// CapReasoningEffort limits an effort to a tier ceiling.
+//
+// An empty or unknown ceiling means no cap. An empty effort resolves to
+// DefaultReasoningEffort before the cap is applied.
+func CapReasoningEffort(effort ReasoningEffort, ceiling string) ReasoningEffort {
+	if effort == "" {
+		effort = DefaultReasoningEffort
+	}
+
+	maxEffort := ReasoningEffort(ceiling)
+	maxRank, ok := reasoningEffortRank[maxEffort]
+	if !ok {
+		return effort
+	}
+
+	if reasoningEffortRank[effort] > maxRank {
+		return maxEffort
+	}
+	return effort
+}
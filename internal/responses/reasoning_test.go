@@ -0,0 +1,84 @@
+package responses
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRequestedReasoningEffort(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    ReasoningEffort
+		wantErr bool
+	}{
+		{name: "absent", body: `{"model": "gpt-5-pro"}`, want: ""},
+		{name: "top-level", body: `{"reasoning_effort": "low"}`, want: ReasoningEffortLow},
+		{name: "nested", body: `{"reasoning": {"effort": "medium"}}`, want: ReasoningEffortMedium},
+		{name: "case-insensitive", body: `{"reasoning_effort": "HIGH"}`, want: ReasoningEffortHigh},
+		{name: "top-level wins", body: `{"reasoning_effort": "minimal", "reasoning": {"effort": "high"}}`, want: ReasoningEffortMinimal},
+		{name: "invalid value", body: `{"reasoning_effort": "extreme"}`, wantErr: true},
+		{name: "invalid json", body: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RequestedReasoningEffort([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RequestedReasoningEffort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RequestedReasoningEffort() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapReasoningEffort(t *testing.T) {
+	tests := []struct {
+		name    string
+		effort  ReasoningEffort
+		ceiling string
+		want    ReasoningEffort
+	}{
+		{name: "default without ceiling", effort: "", ceiling: "", want: ReasoningEffortHigh},
+		{name: "default capped", effort: "", ceiling: "medium", want: ReasoningEffortMedium},
+		{name: "above ceiling", effort: ReasoningEffortHigh, ceiling: "medium", want: ReasoningEffortMedium},
+		{name: "below ceiling", effort: ReasoningEffortLow, ceiling: "medium", want: ReasoningEffortLow},
+		{name: "unknown ceiling ignored", effort: ReasoningEffortHigh, ceiling: "bogus", want: ReasoningEffortHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CapReasoningEffort(tt.effort, tt.ceiling); got != tt.want {
+				t.Errorf("CapReasoningEffort() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdapter_TransformRequestWithEffort(t *testing.T) {
+	adapter := NewAdapter()
+
+	body := `{"model": "gpt-5-pro", "messages": [], "reasoning": {"effort": "high", "summary": "auto"}}`
+	transformed, err := adapter.TransformRequestWithEffort([]byte(body), "", ReasoningEffortMedium)
+	if err != nil {
+		t.Fatalf("TransformRequestWithEffort() error = %v", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(transformed, &result); err != nil {
+		t.Fatalf("failed to parse transformed request: %v", err)
+	}
+
+	reasoning, ok := result["reasoning"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("reasoning missing from transformed request")
+	}
+	if reasoning["effort"] != "medium" {
+		t.Errorf("reasoning.effort = %v, want medium", reasoning["effort"])
+	}
+	if reasoning["summary"] != "auto" {
+		t.Errorf("reasoning.summary = %v, want auto (should be preserved)", reasoning["summary"])
+	}
+}
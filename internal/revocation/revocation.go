@@ -0,0 +1,220 @@
+// Package revocation stops in-flight work of users whose access is revoked.
+//
+// Chat completion streams and deep research runs outlive the request that authenticated them,
+// so revoking a user's token or letting their subscription lapse doesn't stop them. This package
+// stops them through two hooks:
+//
+//   - Events: Revoke publishes the user on NATS (subject "user.revoked"), and every instance
+//     stops the user's sessions it owns. Without NATS only local sessions are stopped.
+//   - Timer: users with sessions in flight are periodically checked with a Checker (Firebase
+//     token revocation, disabled or deleted accounts).
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
+	"github.com/nats-io/nats.go"
+)
+
+// Subject is the NATS subject revocation events are published on.
+const Subject = "user.revoked"
+
+// checkTimeout bounds a single revocation check.
+const checkTimeout = 10 * time.Second
+
+// Reason tells why a user's access was revoked.
+type Reason string
+
+const (
+	// ReasonTokenRevoked indicates the user's tokens were revoked, or the account was disabled or deleted
+	ReasonTokenRevoked Reason = "token_revoked"
+
+	// ReasonSubscriptionLapsed indicates the user's subscription was canceled or stopped being paid
+	ReasonSubscriptionLapsed Reason = "subscription_lapsed"
+
+	// ReasonBanned indicates the user was banned through the admin API
+	ReasonBanned Reason = "banned"
+)
+
+// Event is a revocation event published on Subject.
+type Event struct {
+	UserID string `json:"user_id"`
+	Reason Reason `json:"reason"`
+}
+
+// Target is a subsystem running long-lived work on behalf of users
+// (streaming.StreamManager, deepr.SessionManager).
+type Target interface {
+	// StopUserSessions stops all in-flight sessions of the user and returns how many were stopped.
+	StopUserSessions(userID string) int
+
+	// ActiveUsers returns the users with sessions in flight, mapped to the start of the oldest one.
+	ActiveUsers() map[string]time.Time
+}
+
+// Checker reports whether a user's access was revoked after a given time.
+type Checker interface {
+	AccessRevokedSince(ctx context.Context, userID string, since time.Time) (bool, error)
+}
+
+// Service stops the sessions of revoked users on its targets.
+type Service struct {
+	nc       *nats.Conn // nil = events are handled locally only
+	checker  Checker    // nil = no periodic checks
+	interval time.Duration
+	targets  []Target
+	logger   *logger.Logger
+
+	subscription *nats.Subscription
+	cancel       context.CancelFunc // Stops the periodic checks; nil until started
+	wg           sync.WaitGroup
+}
+
+// NewService creates a revocation service. nc and checker may be nil; interval <= 0 disables
+// the periodic checks.
+func NewService(nc *nats.Conn, checker Checker, interval time.Duration, logger *logger.Logger, targets ...Target) *Service {
+	return &Service{
+		nc:       nc,
+		checker:  checker,
+		interval: interval,
+		targets:  targets,
+		logger:   logger,
+	}
+}
+
+// Start subscribes to revocation events and starts the periodic checks.
+func (s *Service) Start() error {
+	if s.nc != nil {
+		sub, err := s.nc.Subscribe(Subject, s.handleEvent)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", Subject, err)
+		}
+		s.subscription = sub
+	}
+
+	if s.checker != nil && s.interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			worker.RunPeriodic(ctx, "revocation_checks", s.interval, s.logger, s.checkActiveUsers)
+		}()
+	}
+
+	s.logger.Info("revocation service started",
+		slog.Bool("nats", s.nc != nil),
+		slog.Bool("periodic_checks", s.checker != nil && s.interval > 0),
+		slog.Duration("interval", s.interval))
+	return nil
+}
+
+// Stop unsubscribes from revocation events and stops the periodic checks.
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+
+	if s.subscription != nil {
+		if err := s.subscription.Drain(); err != nil {
+			s.logger.Error("failed to drain revocation subscription", slog.String("error", err.Error()))
+		}
+	}
+	s.logger.Info("revocation service stopped")
+}
+
+// Revoke stops the user's in-flight sessions on all instances. Safe to call on a nil Service
+// (revocation disabled).
+func (s *Service) Revoke(userID string, reason Reason) {
+	if s == nil || userID == "" {
+		return
+	}
+
+	if s.nc != nil {
+		data, err := json.Marshal(Event{UserID: userID, Reason: reason})
+		if err == nil {
+			if err = s.nc.Publish(Subject, data); err == nil {
+				return // Handled by every instance's subscription, this one included
+			}
+		}
+		s.logger.Error("failed to publish revocation event, stopping local sessions only",
+			slog.String("user_id", userID),
+			slog.String("error", err.Error()))
+	}
+
+	s.stopUser(userID, reason)
+}
+
+// handleEvent processes revocation events published by any instance.
+func (s *Service) handleEvent(msg *nats.Msg) {
+	var event Event
+	if err := json.Unmarshal(msg.Data, &event); err != nil || event.UserID == "" {
+		s.logger.Warn("received invalid revocation event")
+		return
+	}
+	s.stopUser(event.UserID, event.Reason)
+}
+
+// stopUser stops the user's sessions on all targets of this instance.
+func (s *Service) stopUser(userID string, reason Reason) int {
+	stopped := 0
+	for _, target := range s.targets {
+		stopped += target.StopUserSessions(userID)
+	}
+
+	if stopped > 0 {
+		metrics.RecordRevokedSessions(string(reason), stopped)
+		s.logger.Info("stopped sessions of revoked user",
+			slog.String("user_id", userID),
+			slog.String("reason", string(reason)),
+			slog.Int("stopped", stopped))
+	}
+	return stopped
+}
+
+// checkActiveUsers stops the sessions of users whose access was revoked since their oldest
+// session started. Sessions are local, so revoked users are stopped on this instance only.
+// Failed checks are logged per user and don't fail the run.
+func (s *Service) checkActiveUsers(ctx context.Context) error {
+	for userID, since := range s.activeUsers() {
+		if ctx.Err() != nil {
+			return nil // Shutting down
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		revoked, err := s.checker.AccessRevokedSince(checkCtx, userID, since)
+		cancel()
+		if err != nil {
+			// Fail open: a lookup error must not kill legitimate sessions
+			s.logger.Warn("revocation check failed",
+				slog.String("user_id", userID),
+				slog.String("error", err.Error()))
+			continue
+		}
+		if revoked {
+			s.stopUser(userID, ReasonTokenRevoked)
+		}
+	}
+	return nil
+}
+
+// activeUsers merges the active users of all targets.
+func (s *Service) activeUsers() map[string]time.Time {
+	users := make(map[string]time.Time)
+	for _, target := range s.targets {
+		for userID, startedAt := range target.ActiveUsers() {
+			if existing, ok := users[userID]; !ok || startedAt.Before(existing) {
+				users[userID] = startedAt
+			}
+		}
+	}
+	return users
+}
@@ -0,0 +1,136 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+type fakeTarget struct {
+	active  map[string]time.Time
+	stopped []string
+}
+
+func (f *fakeTarget) StopUserSessions(userID string) int {
+	if _, ok := f.active[userID]; !ok {
+		return 0
+	}
+	delete(f.active, userID)
+	f.stopped = append(f.stopped, userID)
+	return 1
+}
+
+func (f *fakeTarget) ActiveUsers() map[string]time.Time {
+	users := make(map[string]time.Time)
+	for userID, startedAt := range f.active {
+		users[userID] = startedAt
+	}
+	return users
+}
+
+type fakeChecker struct {
+	revokedAt map[string]time.Time
+	failing   map[string]bool
+	since     map[string]time.Time
+}
+
+func (f *fakeChecker) AccessRevokedSince(ctx context.Context, userID string, since time.Time) (bool, error) {
+	f.since[userID] = since
+	if f.failing[userID] {
+		return false, errors.New("lookup failed")
+	}
+	revokedAt, ok := f.revokedAt[userID]
+	return ok && revokedAt.After(since), nil
+}
+
+func TestRevokeWithoutNATS(t *testing.T) {
+	streams := &fakeTarget{active: map[string]time.Time{"user-a": time.Now()}}
+	research := &fakeTarget{active: map[string]time.Time{"user-a": time.Now(), "user-b": time.Now()}}
+	s := NewService(nil, nil, 0, logger.New(logger.Config{Level: slog.LevelError}), streams, research)
+
+	s.Revoke("user-a", ReasonSubscriptionLapsed)
+
+	if len(streams.stopped) != 1 || len(research.stopped) != 1 {
+		t.Errorf("stopped = %v, %v; want user-a on both targets", streams.stopped, research.stopped)
+	}
+	if _, ok := research.active["user-b"]; !ok {
+		t.Error("other user's session was stopped")
+	}
+
+	// Revocation disabled
+	var disabled *Service
+	disabled.Revoke("user-b", ReasonSubscriptionLapsed)
+}
+
+func TestCheckActiveUsers(t *testing.T) {
+	now := time.Now()
+	streams := &fakeTarget{active: map[string]time.Time{
+		"revoked":        now.Add(-time.Minute),
+		"revoked-before": now.Add(-time.Minute),
+		"failing":        now.Add(-time.Minute),
+	}}
+	research := &fakeTarget{active: map[string]time.Time{
+		"revoked": now.Add(-time.Hour),
+	}}
+	checker := &fakeChecker{
+		revokedAt: map[string]time.Time{
+			"revoked":        now.Add(-30 * time.Second),
+			"revoked-before": now.Add(-2 * time.Minute),
+		},
+		failing: map[string]bool{"failing": true},
+		since:   make(map[string]time.Time),
+	}
+	s := NewService(nil, checker, time.Minute, logger.New(logger.Config{Level: slog.LevelError}), streams, research)
+
+	s.checkActiveUsers(context.Background()) //nolint:errcheck
+
+	if !checker.since["revoked"].Equal(now.Add(-time.Hour)) {
+		t.Errorf("checked since %v, want the oldest session start", checker.since["revoked"])
+	}
+	if len(streams.stopped) != 1 || streams.stopped[0] != "revoked" || len(research.stopped) != 1 {
+		t.Errorf("stopped = %v, %v; want only the user revoked mid-session", streams.stopped, research.stopped)
+	}
+	if _, ok := streams.active["failing"]; !ok {
+		t.Error("session was stopped although the check failed")
+	}
+}
+
+// notifyingChecker signals every check on checked.
+type notifyingChecker struct {
+	*fakeChecker
+	checked chan string
+}
+
+func (c *notifyingChecker) AccessRevokedSince(ctx context.Context, userID string, since time.Time) (bool, error) {
+	revoked, err := c.fakeChecker.AccessRevokedSince(ctx, userID, since)
+	c.checked <- userID
+	return revoked, err
+}
+
+func TestPeriodicChecks(t *testing.T) {
+	now := time.Now()
+	streams := &fakeTarget{active: map[string]time.Time{"revoked": now.Add(-time.Minute)}}
+	checker := &notifyingChecker{
+		fakeChecker: &fakeChecker{revokedAt: map[string]time.Time{"revoked": now}, since: make(map[string]time.Time)},
+		checked:     make(chan string, 10),
+	}
+	s := NewService(nil, checker, 10*time.Millisecond, logger.New(logger.Config{Level: slog.LevelError}), streams)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-checker.checked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("active users were not checked")
+	}
+	s.Stop()
+
+	if len(streams.stopped) != 1 || streams.stopped[0] != "revoked" {
+		t.Errorf("stopped = %v, want the revoked user", streams.stopped)
+	}
+}
@@ -0,0 +1,124 @@
+package rollups
+
+import (
+	"context"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// Worker rolls up each completed UTC day of request_logs and deep_research_runs into the
+// daily_*_usage_rollups tables read by GET /admin/kpis.
+//
+// Rollups are idempotent upserts and a day's daily_usage_rollups row is written last, so
+// running a worker on every proxy instance is safe and interrupted days are redone.
+type Worker struct {
+	queries      pgdb.Querier
+	logger       *logger.Logger
+	interval     time.Duration
+	backfillDays int
+}
+
+func NewWorker(queries pgdb.Querier, logger *logger.Logger) *Worker {
+	return &Worker{
+		queries:      queries,
+		logger:       logger,
+		interval:     time.Hour,
+		backfillDays: 7,
+	}
+}
+
+// Run starts the rollup worker loop.
+func (w *Worker) Run(ctx context.Context) {
+	w.logger.Info("starting usage rollup worker", "interval", w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run immediately on startup
+	w.rollupMissing(ctx, time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("usage rollup worker stopped")
+			return
+		case <-ticker.C:
+			w.rollupMissing(ctx, time.Now())
+		}
+	}
+}
+
+// rollupMissing rolls up the completed days of the backfill window that have no rollup yet.
+func (w *Worker) rollupMissing(ctx context.Context, now time.Time) {
+	to := Day(now).AddDate(0, 0, -1)
+	from := to.AddDate(0, 0, -(w.backfillDays - 1))
+
+	queryCtx, queryCancel := context.WithTimeout(ctx, 30*time.Second)
+	existing, err := w.queries.ListDailyUsageRollups(queryCtx, pgdb.ListDailyUsageRollupsParams{FromDay: from, ToDay: to})
+	queryCancel()
+	if err != nil {
+		w.logger.Error("failed to list usage rollups", "error", err.Error())
+		return
+	}
+
+	done := make([]time.Time, 0, len(existing))
+	for _, rollup := range existing {
+		done = append(done, rollup.Day)
+	}
+
+	for _, day := range missingDays(from, to, done) {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		rollupCtx, rollupCancel := context.WithTimeout(ctx, 5*time.Minute)
+		err := RollupDay(rollupCtx, w.queries, day)
+		rollupCancel()
+		if err != nil {
+			w.logger.Error("failed to roll up usage", "error", err.Error(), "day", day.Format(time.DateOnly))
+			return
+		}
+		w.logger.Info("usage rolled up", "day", day.Format(time.DateOnly))
+	}
+}
+
+// RollupDay computes (or recomputes) all rollups of a UTC day. The daily_usage_rollups row
+// goes last because its presence marks the day as complete.
+func RollupDay(ctx context.Context, queries pgdb.Querier, day time.Time) error {
+	day = Day(day)
+	if err := queries.UpsertDailyTierUsageRollups(ctx, day); err != nil {
+		return err
+	}
+	if err := queries.UpsertDailyProviderUsageRollups(ctx, day); err != nil {
+		return err
+	}
+	return queries.UpsertDailyUsageRollup(ctx, day)
+}
+
+// Day returns midnight UTC of the day containing t.
+func Day(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// missingDays returns the days in [from, to] that are not in done, oldest first.
+func missingDays(from, to time.Time, done []time.Time) []time.Time {
+	have := make(map[time.Time]bool, len(done))
+	for _, day := range done {
+		// Scanned DATE values may carry the session's zone; keep their calendar day.
+		year, month, d := day.Date()
+		have[time.Date(year, month, d, 0, 0, 0, 0, time.UTC)] = true
+	}
+
+	var missing []time.Time
+	for day := Day(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if !have[day] {
+			missing = append(missing, day)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,48 @@
+package rollups
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMissingDays(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		done []time.Time
+		want []time.Time
+	}{
+		{name: "nothing rolled up", want: []time.Time{day(1), day(2), day(3)}},
+		{name: "gap in the middle", done: []time.Time{day(1), day(3)}, want: []time.Time{day(2)}},
+		{name: "all rolled up", done: []time.Time{day(3), day(2), day(1)}},
+		{
+			name: "date scanned in another zone",
+			done: []time.Time{time.Date(2026, 3, 2, 0, 0, 0, 0, time.FixedZone("", 2*3600))},
+			want: []time.Time{day(1), day(3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := missingDays(day(1), day(3), tt.done)
+			if len(got) != len(tt.want) {
+				t.Fatalf("missingDays() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("missingDays()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDay(t *testing.T) {
+	est := time.FixedZone("EST", -5*3600)
+	got := Day(time.Date(2026, 3, 1, 22, 30, 0, 0, est))
+	want := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("Day() = %v, want %v", got, want)
+	}
+}
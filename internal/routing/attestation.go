@@ -0,0 +1,33 @@
+package routing
+
+import (
+	"log/slog"
+)
+
+// AttestationPolicy decides which providers have a verified enclave attestation.
+type AttestationPolicy interface {
+	// Attested reports whether a provider may be routed to: its attestation verified, or it
+	// requires none.
+	Attested(provider string) bool
+}
+
+// SetAttestationPolicy sets the policy applied to endpoints when routes are built. Call Rebuild
+// to apply it to the current routes.
+func (mr *ModelRouter) SetAttestationPolicy(policy AttestationPolicy) {
+	mr.rebuildMu.Lock()
+	defer mr.rebuildMu.Unlock()
+	mr.attestation = policy
+}
+
+// applyAttestationPolicy reports whether an endpoint's provider may be used under the
+// attestation policy. Caller holds mr.rebuildMu.
+func (mr *ModelRouter) applyAttestationPolicy(provider *ProviderConfig) bool {
+	if mr.attestation == nil || mr.attestation.Attested(provider.Name) {
+		return true
+	}
+
+	mr.logger.Warn("skipping endpoint without verified attestation",
+		slog.String("model", provider.Model),
+		slog.String("provider", provider.Name))
+	return false
+}
@@ -0,0 +1,54 @@
+package routing
+
+import (
+	"sort"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+// CatalogEntry is a configured model as listed to clients.
+type CatalogEntry struct {
+	Info *ModelInfo
+
+	// Provider is the display name of the provider serving the model (e.g. "OpenAI").
+	Provider string
+
+	APIType         config.APIType
+	TokenMultiplier float64
+
+	// Available is false if the model can't be routed for the platform (no endpoint, or an
+	// OpenRouter endpoint without a key for the platform).
+	Available bool
+}
+
+// Catalog returns the explicitly configured models for a client platform, sorted by name.
+// Unlike RouteModel it doesn't select an endpoint (round-robin counters are untouched): the
+// provider is that of the model's first active endpoint, or first inactive one.
+func (mr *ModelRouter) Catalog(platform string) []CatalogEntry {
+	routes := mr.GetRoutes()
+	entries := make([]CatalogEntry, 0, len(routes))
+	for _, route := range routes {
+		if route.Info == nil {
+			continue
+		}
+
+		entry := CatalogEntry{Info: route.Info}
+		endpoints := route.ActiveEndpoints
+		if len(endpoints) == 0 {
+			endpoints = route.InactiveEndpoints
+		}
+		if len(endpoints) > 0 {
+			provider := endpoints[0].Provider
+			entry.Provider = provider.Name
+			entry.APIType = provider.APIType
+			entry.TokenMultiplier = provider.TokenMultiplier
+			entry.Available = provider.Name != "OpenRouter" || mr.GetOpenRouterAPIKey(platform) != ""
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Info.Name < entries[j].Info.Name
+	})
+	return entries
+}
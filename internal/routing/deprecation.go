@@ -0,0 +1,23 @@
+package routing
+
+import "strings"
+
+// ModelDeprecation describes a model ID retired by its provider and the configured model
+// serving its requests instead.
+type ModelDeprecation struct {
+	// Model is the deprecated model ID as configured.
+	Model string `json:"model"`
+
+	// Successor is the canonical name of the replacement model.
+	Successor string `json:"successor"`
+
+	// Sunset is the date (YYYY-MM-DD) the provider retires the model, empty if unknown.
+	Sunset string `json:"sunset,omitempty"`
+}
+
+// GetDeprecation returns the deprecation entry for a model ID, or nil if it is not deprecated.
+// Deprecated IDs resolve to their successor in ResolveAlias, so quota and tier checks apply to
+// the model that actually serves the request.
+func (mr *ModelRouter) GetDeprecation(modelID string) *ModelDeprecation {
+	return mr.current().deprecations[strings.ToLower(strings.TrimSpace(modelID))]
+}
@@ -0,0 +1,165 @@
+package routing
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+// ModelInfo contains static metadata about a configured model: limits and capabilities used
+// to preflight-validate requests, list models and decide on compaction.
+type ModelInfo struct {
+	// Name is the canonical model name.
+	Name string
+
+	// Aliases are the alternative names accepted for this model.
+	Aliases []string
+
+	// ContextWindow is the maximum prompt + completion tokens (0 = unknown).
+	ContextWindow int
+
+	// MaxOutputTokens is the maximum completion tokens (0 = unknown).
+	MaxOutputTokens int
+
+	// MaxRequestSeconds is the model's streaming request time budget (0 = none).
+	MaxRequestSeconds int
+
+	SupportsTools            bool
+	SupportsVision           bool
+	SupportsStreaming        bool
+	SupportsStructuredOutput bool
+	SupportsMultipleChoices  bool
+}
+
+// modelInfoFromConfig converts a validated config.ModelConfig to a ModelInfo.
+func modelInfoFromConfig(model *config.ModelConfig) *ModelInfo {
+	return &ModelInfo{
+		Name:                     model.Name,
+		Aliases:                  model.Aliases,
+		ContextWindow:            model.ContextWindow,
+		MaxOutputTokens:          model.MaxOutputTokens,
+		MaxRequestSeconds:        model.MaxRequestSeconds,
+		SupportsTools:            model.SupportsTools == nil || *model.SupportsTools,
+		SupportsVision:           model.SupportsVision == nil || *model.SupportsVision,
+		SupportsStreaming:        model.SupportsStreaming == nil || *model.SupportsStreaming,
+		SupportsStructuredOutput: model.SupportsStructuredOutput == nil || *model.SupportsStructuredOutput,
+		SupportsMultipleChoices:  model.SupportsMultipleChoices == nil || *model.SupportsMultipleChoices,
+	}
+}
+
+// GetModelInfo returns metadata for a model ID or alias.
+// Returns nil for models that are not explicitly configured (served by the wildcard route).
+func (mr *ModelRouter) GetModelInfo(modelID string) *ModelInfo {
+	table := mr.current()
+	canonicalModel, exists := table.aliases[strings.ToLower(strings.TrimSpace(modelID))]
+	if !exists {
+		return nil
+	}
+
+	route, exists := table.routes[canonicalModel]
+	if !exists {
+		return nil
+	}
+
+	return route.Info
+}
+
+// ListModels returns metadata for all explicitly configured models, sorted by name.
+// Does not include wildcard "*".
+func (mr *ModelRouter) ListModels() []*ModelInfo {
+	routes := mr.GetRoutes()
+
+	models := make([]*ModelInfo, 0, len(routes))
+	for _, route := range routes {
+		if route.Info != nil {
+			models = append(models, route.Info)
+		}
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
+
+	return models
+}
+
+// ModelRequirements are the capabilities a request needs from a model.
+type ModelRequirements struct {
+	Streaming     bool
+	Tools         bool
+	Vision        bool
+	ContextTokens int // Estimated prompt tokens the context window must hold (0 = any)
+}
+
+// Satisfies reports whether the model meets the requirements. A model with an unknown context
+// window doesn't meet a context requirement.
+func (m *ModelInfo) Satisfies(req ModelRequirements) bool {
+	switch {
+	case req.Streaming && !m.SupportsStreaming,
+		req.Tools && !m.SupportsTools,
+		req.Vision && !m.SupportsVision,
+		req.ContextTokens > 0 && m.ContextWindow < req.ContextTokens:
+		return false
+	}
+	return true
+}
+
+// SuggestModels returns the configured models meeting the requirements, excluding deprecated
+// models, sorted by name or, for a context requirement, smallest context window first.
+func (mr *ModelRouter) SuggestModels(req ModelRequirements) []*ModelInfo {
+	var models []*ModelInfo
+	for _, info := range mr.ListModels() {
+		if info.Satisfies(req) && mr.GetDeprecation(info.Name) == nil {
+			models = append(models, info)
+		}
+	}
+	if req.ContextTokens > 0 {
+		sort.SliceStable(models, func(i, j int) bool {
+			return models[i].ContextWindow < models[j].ContextWindow
+		})
+	}
+	return models
+}
+
+// SupportsTools reports whether the model served by this endpoint supports tool calling.
+// Unknown models are assumed to support tools.
+func (p *ProviderConfig) SupportsTools() bool {
+	return p.Info == nil || p.Info.SupportsTools
+}
+
+// SupportsStructuredOutput reports whether the model served by this endpoint accepts
+// json_schema response formats. Unknown models are assumed to support them; the Messages API
+// (anthropic) has no response formats.
+func (p *ProviderConfig) SupportsStructuredOutput() bool {
+	if p.APIType == config.APITypeAnthropic {
+		return false
+	}
+	return p.Info == nil || p.Info.SupportsStructuredOutput
+}
+
+// SupportsMultipleChoices reports whether the model served by this endpoint accepts n > 1.
+// Unknown models are assumed to accept it; the Messages API (anthropic) and the Gemini
+// translation return one message.
+func (p *ProviderConfig) SupportsMultipleChoices() bool {
+	if p.APIType == config.APITypeAnthropic || p.APIType == config.APITypeGemini {
+		return false
+	}
+	return p.Info == nil || p.Info.SupportsMultipleChoices
+}
+
+// ContextWindow returns the context window of the model served by this endpoint (0 = unknown).
+func (p *ProviderConfig) ContextWindow() int {
+	if p.Info == nil {
+		return 0
+	}
+	return p.Info.ContextWindow
+}
+
+// MaxRequestSeconds returns the request time budget of the model served by this endpoint (0 = none).
+func (p *ProviderConfig) MaxRequestSeconds() int {
+	if p.Info == nil {
+		return 0
+	}
+	return p.Info.MaxRequestSeconds
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -36,21 +37,60 @@ import (
 //	// provider.BaseURL = "https://api.openai.com/v1"
 //	// provider.APIKey = os.Getenv("OPENAI_API_KEY")
 type ModelRouter struct {
-	aliases map[string]string
 	apiKeys map[string]map[string]string // Store platform-specific keys for API providers
-	routes  atomic.Pointer[map[string]ModelRoute]
+	table   atomic.Pointer[routingTable]
 	logger  *logger.Logger
+
+	// rebuildMu serializes rebuilds; config is the last configuration built from, reused when
+	// the upstream or attestation policy changes.
+	rebuildMu   sync.Mutex
+	config      *config.ModelRouterConfig
+	upstreams   UpstreamPolicy
+	attestation AttestationPolicy
+
+	// regions holds region probe results and per-user region stickiness across rebuilds.
+	regions *regionTracker
+
+	// quality holds the endpoint quality scores preferred by endpoint selection, if enabled.
+	quality atomic.Pointer[qualityScores]
+}
+
+// routingTable is a routing map with the alias and deprecation mappings it was built with,
+// swapped as one value so readers never see the mappings of one build with the routes of
+// another. Never modified once stored.
+type routingTable struct {
+	aliases      map[string]string
+	deprecations map[string]*ModelDeprecation
+	routes       map[string]ModelRoute
+}
+
+// current returns the current routing table (empty before the first build).
+func (mr *ModelRouter) current() *routingTable {
+	if table := mr.table.Load(); table != nil {
+		return table
+	}
+	return &routingTable{}
 }
 
 // GetRoutes retrieves the current routing map from the atomic pointer store.
 // WARNING: Callers must not modify the returned map; use SetRoutes for updates.
 func (mr *ModelRouter) GetRoutes() map[string]ModelRoute {
-	return *(mr.routes.Load())
+	return mr.current().routes
 }
 
-// SetRoutes updates the atomic pointer store of the current routing map with a new pointer
+// SetRoutes updates the atomic pointer store of the current routing map with a new pointer,
+// keeping the current alias and deprecation mappings.
 func (mr *ModelRouter) SetRoutes(routes map[string]ModelRoute) {
-	mr.routes.Store(&routes)
+	for {
+		table := mr.table.Load()
+		next := &routingTable{routes: routes}
+		if table != nil {
+			next.aliases, next.deprecations = table.aliases, table.deprecations
+		}
+		if mr.table.CompareAndSwap(table, next) {
+			return
+		}
+	}
 }
 
 // GetAliases returns all aliases (including the canonical name itself) for a given canonical model name.
@@ -58,7 +98,7 @@ func (mr *ModelRouter) SetRoutes(routes map[string]ModelRoute) {
 func (mr *ModelRouter) GetAliases(canonicalName string) []string {
 	result := []string{canonicalName}
 	lower := strings.ToLower(strings.TrimSpace(canonicalName))
-	for alias, canonical := range mr.aliases {
+	for alias, canonical := range mr.current().aliases {
 		if strings.ToLower(canonical) == lower && alias != lower {
 			result = append(result, alias)
 		}
@@ -74,9 +114,13 @@ func (mr *ModelRouter) ResolveAlias(modelID string) string {
 		return modelID
 	}
 	normalizedModel := strings.ToLower(strings.TrimSpace(modelID))
-	if canonicalModel, exists := mr.aliases[normalizedModel]; exists {
+	table := mr.current()
+	if canonicalModel, exists := table.aliases[normalizedModel]; exists {
 		return canonicalModel
 	}
+	if deprecation, exists := table.deprecations[normalizedModel]; exists {
+		return deprecation.Successor
+	}
 	return modelID
 }
 
@@ -93,9 +137,15 @@ type ModelRoute struct {
 	// TODO: Will be used by the fallback policy.
 	InactiveEndpoints []ModelEndpoint
 
+	// Failover is the model's failover chain, in order (see ProviderConfig.Fallbacks).
+	Failover []*ProviderConfig
+
 	// RoundRobinCounter is an atomic counter used to implement simple round-robin balancing
 	// if choosing from multiple endpoints.
 	RoundRobinCounter *atomic.Uint64
+
+	// Info is the model metadata, nil for the wildcard route.
+	Info *ModelInfo
 }
 
 // ModelEndpoint contains all information necessary to route requests for a specific model to
@@ -120,11 +170,40 @@ type ProviderConfig struct {
 	// Model is the name of the model that the provider expects in the API requests
 	Model string
 
-	// APIType determines which API format to use (chat_completions or responses)
+	// APIType determines which API format to use (chat_completions, responses, anthropic or gemini)
 	APIType config.APIType
 
 	// TokenMultiplier is the cost multiplier for this model (1× to 50×)
 	TokenMultiplier float64
+
+	// Info is the metadata of the configured model served by this endpoint.
+	// Nil for endpoints of the wildcard route (unknown models).
+	Info *ModelInfo
+
+	// Headers is the outbound header policy of the provider (nil = proxy defaults).
+	Headers *config.RequestHeadersConfig
+
+	// ProviderPreferences are the OpenRouter provider routing preferences injected into
+	// request bodies (nil = none).
+	ProviderPreferences *config.ProviderPreferencesConfig
+
+	// ZeroRetention is true if the provider neither logs nor retains request data.
+	ZeroRetention bool
+
+	// Regions are the allowed regional endpoints of the provider (nil = BaseURL only). BaseURL
+	// and APIKey are those of the first region until SelectRegion picks one.
+	Regions []ProviderRegion
+
+	// PinnedRegion is the name of the region serving all requests ("" = latency-based).
+	PinnedRegion string
+
+	// Region is the name of the region selected by SelectRegion ("" = none).
+	Region string
+
+	// Fallbacks are the providers a failed chat completion is retried on, in order (nil =
+	// none). Set on the configurations returned by RouteModel and RouteModelZeroRetention for
+	// models with a failover chain; never includes the provider itself.
+	Fallbacks []*ProviderConfig
 }
 
 // FallbackConfig contains fallback policy settings for trigger (entering overload/fallback state)
@@ -206,7 +285,8 @@ func probeConfigFromConfig(cfg *config.ProbeConfig) *ProbeConfig {
 // Platform-specific keys (OpenRouter) are resolved at route time.
 func NewModelRouter(cfg *config.Config, logger *logger.Logger) *ModelRouter {
 	router := &ModelRouter{
-		logger: logger,
+		logger:  logger,
+		regions: newRegionTracker(),
 	}
 
 	apiKeys := map[string]map[string]string{
@@ -243,6 +323,10 @@ func (mr *ModelRouter) RebuildRoutes(cfg *config.ModelRouterConfig) {
 		return
 	}
 
+	mr.rebuildMu.Lock()
+	defer mr.rebuildMu.Unlock()
+	mr.config = cfg
+
 	// Normally each model has at least one alias, so pre-allocate twice the number of items
 	aliases := make(map[string]string, len(cfg.Models)*2)
 	routes := make(map[string]ModelRoute, len(cfg.Models)*2)
@@ -269,33 +353,18 @@ func (mr *ModelRouter) RebuildRoutes(cfg *config.ModelRouterConfig) {
 
 		var activeEndpoints, inactiveEndpoints []ModelEndpoint
 
+		var info *ModelInfo
+		if model.Name != "*" {
+			info = modelInfoFromConfig(&model)
+		}
+
 		for _, endpointProvider := range model.Providers {
 			if modelProvider, exists := providers[endpointProvider.Name]; exists {
-				// Skip providers that do not have an API key properly configured
-				if modelProvider.APIKey == "" && modelProvider.Name != "OpenRouter" {
+				provider := mr.buildEndpointProvider(&model, info, modelProvider, endpointProvider)
+				if provider == nil {
 					continue
 				}
 
-				// Build an aggregated provider configuration for this endpoint
-				provider := &ProviderConfig{
-					BaseURL:         modelProvider.BaseURL,
-					APIKey:          modelProvider.APIKey,
-					Name:            modelProvider.Name,
-					Model:           model.Name,
-					APIType:         endpointProvider.APIType,
-					TokenMultiplier: model.TokenMultiplier,
-				}
-
-				// Override the model name with the one expected by this provider for this model
-				if endpointProvider.Model != "" {
-					provider.Model = endpointProvider.Model
-				}
-
-				// Override the base URL with the one used by this provider for this model
-				if endpointProvider.BaseURL != "" {
-					provider.BaseURL = endpointProvider.BaseURL
-				}
-
 				var fallback *FallbackConfig
 
 				// Build the fallback configuration, if specified.
@@ -334,6 +403,21 @@ func (mr *ModelRouter) RebuildRoutes(cfg *config.ModelRouterConfig) {
 			}
 		}
 
+		// Build the failover chain, skipping unusable endpoints like above
+		var failover []*ProviderConfig
+		for _, endpointProvider := range model.Failover {
+			modelProvider, exists := providers[endpointProvider.Name]
+			if !exists {
+				mr.logger.Warn("skipping unknown model failover provider",
+					slog.String("model", model.Name),
+					slog.String("provider", endpointProvider.Name))
+				continue
+			}
+			if provider := mr.buildEndpointProvider(&model, info, modelProvider, endpointProvider); provider != nil {
+				failover = append(failover, provider)
+			}
+		}
+
 		// Populate routes and alias mapping for the model.
 		// Alias mapping entries are normalized for reliable matching.
 		if len(activeEndpoints) > 0 || len(inactiveEndpoints) > 0 {
@@ -342,13 +426,17 @@ func (mr *ModelRouter) RebuildRoutes(cfg *config.ModelRouterConfig) {
 			if len(activeEndpoints) == 0 && len(inactiveEndpoints) > 0 {
 				routes[model.Name] = ModelRoute{
 					ActiveEndpoints:   inactiveEndpoints,
+					Failover:          failover,
 					RoundRobinCounter: &atomic.Uint64{},
+					Info:              info,
 				}
 			} else {
 				routes[model.Name] = ModelRoute{
 					ActiveEndpoints:   activeEndpoints,
 					InactiveEndpoints: inactiveEndpoints,
+					Failover:          failover,
 					RoundRobinCounter: &atomic.Uint64{},
+					Info:              info,
 				}
 			}
 
@@ -363,9 +451,82 @@ func (mr *ModelRouter) RebuildRoutes(cfg *config.ModelRouterConfig) {
 		}
 	}
 
-	// Update the routing table and alias mappings in place
-	mr.aliases = aliases
-	mr.SetRoutes(routes)
+	// Map deprecated model IDs to the canonical names of their successors
+	deprecations := make(map[string]*ModelDeprecation, len(cfg.Deprecations))
+	for _, deprecation := range cfg.Deprecations {
+		successor, exists := aliases[strings.ToLower(strings.TrimSpace(deprecation.Successor))]
+		if !exists {
+			mr.logger.Warn("skipping deprecation with unavailable successor",
+				slog.String("model", deprecation.Model),
+				slog.String("successor", deprecation.Successor))
+			continue
+		}
+
+		deprecations[strings.ToLower(strings.TrimSpace(deprecation.Model))] = &ModelDeprecation{
+			Model:     deprecation.Model,
+			Successor: successor,
+			Sunset:    deprecation.Sunset,
+		}
+	}
+
+	// Swap the routing table and alias mappings at once
+	mr.table.Store(&routingTable{aliases: aliases, deprecations: deprecations, routes: routes})
+}
+
+// buildEndpointProvider builds the aggregated provider configuration of a model endpoint.
+// Returns nil if the endpoint can't be used: its base URL is not an allowed upstream, the
+// provider has no API key configured or its attestation didn't verify. Caller holds
+// mr.rebuildMu.
+func (mr *ModelRouter) buildEndpointProvider(
+	model *config.ModelConfig,
+	info *ModelInfo,
+	modelProvider config.ModelProviderConfig,
+	endpointProvider config.ModelEndpointProvider,
+) *ProviderConfig {
+	provider := &ProviderConfig{
+		BaseURL:             modelProvider.BaseURL,
+		APIKey:              modelProvider.APIKey,
+		Name:                modelProvider.Name,
+		Model:               model.Name,
+		APIType:             endpointProvider.APIType,
+		TokenMultiplier:     model.TokenMultiplier,
+		Info:                info,
+		Headers:             modelProvider.Headers,
+		ProviderPreferences: modelProvider.ProviderPreferences.Merge(endpointProvider.ProviderPreferences),
+		ZeroRetention:       modelProvider.ZeroRetention,
+	}
+
+	// Override the model name with the one expected by this provider for this model
+	if endpointProvider.Model != "" {
+		provider.Model = endpointProvider.Model
+	}
+
+	// Override the base URL with the one used by this provider for this model
+	if endpointProvider.BaseURL != "" {
+		provider.BaseURL = endpointProvider.BaseURL
+	}
+
+	// Skip endpoints whose base URL is not an allowed upstream. Regional endpoints
+	// replace the provider base URL unless this model overrides it.
+	if endpointProvider.BaseURL == "" && len(modelProvider.Regions) > 0 {
+		if !mr.applyRegions(provider, modelProvider.Regions, modelProvider.PinnedRegion) {
+			return nil
+		}
+	} else if !mr.applyUpstreamPolicy(provider) {
+		return nil
+	}
+
+	// Skip providers that do not have an API key properly configured
+	if provider.APIKey == "" && provider.Name != "OpenRouter" {
+		return nil
+	}
+
+	// Skip enclave providers whose attestation didn't verify
+	if !mr.applyAttestationPolicy(provider) {
+		return nil
+	}
+
+	return provider
 }
 
 // RouteModel determines the provider for a given model ID.
@@ -402,7 +563,8 @@ func (mr *ModelRouter) RouteModel(modelID string, platform string) (*ProviderCon
 	normalizedModel := strings.ToLower(strings.TrimSpace(modelID))
 
 	// Try exact match
-	if canonicalModel, exists := mr.aliases[normalizedModel]; exists {
+	aliases := mr.current().aliases
+	if canonicalModel, exists := aliases[normalizedModel]; exists {
 		if provider := mr.getModelEndpointProvider(canonicalModel, platform); provider != nil {
 			mr.logger.Debug("model routed (exact match)",
 				slog.String("model", modelID),
@@ -413,7 +575,7 @@ func (mr *ModelRouter) RouteModel(modelID string, platform string) (*ProviderCon
 
 	// Try prefix match
 	// e.g., "gpt-4-0125-preview" should match "gpt-4"
-	for prefix, canonicalModel := range mr.aliases {
+	for prefix, canonicalModel := range aliases {
 		if prefix == "*" {
 			continue // Skip wildcard for now
 		}
@@ -443,6 +605,45 @@ func (mr *ModelRouter) RouteModel(modelID string, platform string) (*ProviderCon
 	return nil, fmt.Errorf("no suitable endpoint provider found for model: %s", modelID)
 }
 
+// RouteAlternate returns an endpoint for the model served by a provider other than exclude,
+// preferring active endpoints. Used to retry a failed request elsewhere.
+// Returns nil if the model is not explicitly configured or has no other provider.
+func (mr *ModelRouter) RouteAlternate(modelID, platform, exclude string) *ProviderConfig {
+	table := mr.current()
+	canonicalModel, exists := table.aliases[strings.ToLower(strings.TrimSpace(modelID))]
+	if !exists {
+		return nil
+	}
+
+	route, exists := table.routes[canonicalModel]
+	if !exists {
+		return nil
+	}
+
+	for _, endpoints := range [][]ModelEndpoint{route.ActiveEndpoints, route.InactiveEndpoints} {
+		for _, endpoint := range endpoints {
+			provider := endpoint.Provider
+			if provider.Name == exclude {
+				continue
+			}
+
+			// Same OpenRouter key selection as getModelEndpointProvider
+			if provider.Name == "OpenRouter" {
+				apiKey := mr.GetOpenRouterAPIKey(platform)
+				if apiKey == "" {
+					continue
+				}
+				prov := *provider
+				prov.APIKey = apiKey
+				provider = &prov
+			}
+			return provider
+		}
+	}
+
+	return nil
+}
+
 // getModelEndpointProvider returns a final aggregated provider configuration that will be used
 // to send requests to this model.
 //
@@ -461,11 +662,13 @@ func (mr *ModelRouter) getModelEndpointProvider(model string, platform string) *
 
 	// Try to select an active endpoint first. If there are no active endpoints but some
 	// inactive endpoints, enter a "panic mode" and select one of inactive endpoints.
-	// If multiple endpoints are present, select one using a simple round-robin algorithm.
-	activeEndpointsCount := len(route.ActiveEndpoints)
+	// If multiple endpoints are present, select one using a simple round-robin algorithm,
+	// among the best-scoring ones when quality scores are set.
+	activeEndpoints := mr.qualityEndpoints(model, route.ActiveEndpoints)
+	activeEndpointsCount := len(activeEndpoints)
 	if activeEndpointsCount > 0 {
 		idx := (route.RoundRobinCounter.Add(1) - 1) % uint64(activeEndpointsCount)
-		endpoint = route.ActiveEndpoints[idx]
+		endpoint = activeEndpoints[idx]
 	} else {
 		inactiveEndpointsCount := len(route.InactiveEndpoints)
 		if inactiveEndpointsCount > 0 {
@@ -495,7 +698,36 @@ func (mr *ModelRouter) getModelEndpointProvider(model string, platform string) *
 		provider = &prov
 	}
 
-	return provider
+	return mr.withFailover(provider, route.Failover, platform)
+}
+
+// withFailover returns a copy of provider with its fallbacks set from the model's failover
+// chain: the chain's endpoints of other providers, with the same OpenRouter key selection as
+// getModelEndpointProvider. Returns provider itself if there are none.
+func (mr *ModelRouter) withFailover(provider *ProviderConfig, failover []*ProviderConfig, platform string) *ProviderConfig {
+	var fallbacks []*ProviderConfig
+	for _, fallback := range failover {
+		if fallback.Name == provider.Name {
+			continue
+		}
+		if fallback.Name == "OpenRouter" {
+			apiKey := mr.GetOpenRouterAPIKey(platform)
+			if apiKey == "" {
+				continue
+			}
+			prov := *fallback
+			prov.APIKey = apiKey
+			fallback = &prov
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+	if len(fallbacks) == 0 {
+		return provider
+	}
+
+	prov := *provider
+	prov.Fallbacks = fallbacks
+	return &prov
 }
 
 // GetOpenRouterAPIKey returns the appropriate OpenRouter API key for the platform.
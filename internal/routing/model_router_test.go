@@ -645,3 +645,372 @@ func TestPanicModeRouting(t *testing.T) {
 		}
 	}
 }
+
+func TestGetModelInfo(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	info := router.GetModelInfo("GPT-4")
+	if info == nil {
+		t.Fatal("expected model info for alias gpt-4")
+	}
+	if info.Name != "openai/gpt-4" || info.ContextWindow != 8192 || info.MaxOutputTokens != 8192 {
+		t.Errorf("unexpected model info: %+v", info)
+	}
+	if !info.SupportsTools || !info.SupportsVision || !info.SupportsStreaming {
+		t.Errorf("capabilities should default to true: %+v", info)
+	}
+
+	dolphin := router.GetModelInfo("dolphin-mistral-eternis")
+	if dolphin == nil || dolphin.SupportsTools || dolphin.SupportsVision || !dolphin.SupportsStreaming {
+		t.Errorf("unexpected capabilities for dolphin: %+v", dolphin)
+	}
+
+	if info := router.GetModelInfo("unknown-model"); info != nil {
+		t.Errorf("expected nil model info for unknown model, got %+v", info)
+	}
+
+	// Prefix-matched and wildcard-routed providers carry the metadata of the route they use
+	provider, err := router.RouteModel("gpt-4-0125-preview", "")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.ContextWindow() != 8192 {
+		t.Errorf("expected context window 8192 for prefix match, got %d", provider.ContextWindow())
+	}
+
+	provider, err = router.RouteModel("anthropic/claude-3-opus", "")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.Info != nil || !provider.SupportsTools() {
+		t.Errorf("expected no metadata for wildcard route, got %+v", provider.Info)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	models := router.ListModels()
+	supported := router.GetSupportedModels()
+	if len(models) != len(supported) {
+		t.Fatalf("expected %d models, got %d", len(supported), len(models))
+	}
+
+	for i, info := range models {
+		if info.Name != supported[i] {
+			t.Errorf("expected model %s at position %d, got %s", supported[i], i, info.Name)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	catalog := router.Catalog("mobile")
+	supported := router.GetSupportedModels()
+	if len(catalog) != len(supported) {
+		t.Fatalf("expected %d models, got %d", len(supported), len(catalog))
+	}
+	entries := make(map[string]CatalogEntry)
+	for i, entry := range catalog {
+		if entry.Info.Name != supported[i] {
+			t.Errorf("expected model %s at position %d, got %s", supported[i], i, entry.Info.Name)
+		}
+		entries[entry.Info.Name] = entry
+	}
+
+	pro := entries["openai/gpt-5.5-pro"]
+	if pro.Provider != "OpenAI" || pro.APIType != config.APITypeResponses || pro.TokenMultiplier != 54.0 || !pro.Available {
+		t.Errorf("unexpected entry for openai/gpt-5.5-pro: %+v", pro)
+	}
+	if entry := entries["openai/gpt-4.1"]; entry.Provider != "OpenRouter" || !entry.Available {
+		t.Errorf("unexpected entry for openai/gpt-4.1: %+v", entry)
+	}
+
+	// Listing doesn't advance round-robin selection
+	route := router.GetRoutes()["zai-org/GLM-4.6"]
+	before := route.RoundRobinCounter.Load()
+	router.Catalog("desktop")
+	if after := route.RoundRobinCounter.Load(); after != before {
+		t.Errorf("round-robin counter changed from %d to %d", before, after)
+	}
+
+	// OpenRouter models are unavailable without a key for any platform
+	router = newModelRouter(t, newEnv(map[string]string{
+		OpenRouterMobileAPIKeyEnvVar:  "",
+		OpenRouterDesktopAPIKeyEnvVar: "",
+	}))
+	for _, entry := range router.Catalog("mobile") {
+		if want := entry.Provider != "OpenRouter"; entry.Available != want {
+			t.Errorf("%s (%s): available = %v, want %v", entry.Info.Name, entry.Provider, entry.Available, want)
+		}
+	}
+}
+
+func TestRouteAlternate(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	tests := []struct {
+		name     string
+		model    string
+		exclude  string
+		expected string // Empty = no alternate
+	}{
+		{"inactive endpoint as alternate", "zai-org/GLM-4.6", "Eternis", "NEAR AI"},
+		{"active endpoint as alternate", "zai-org/GLM-4.6", "NEAR AI", "Eternis"},
+		{"single provider", "gpt-4", "OpenAI", ""},
+		{"unconfigured model", "unknown/model", "OpenRouter", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := router.RouteAlternate(tt.model, "mobile", tt.exclude)
+			switch {
+			case tt.expected == "" && provider != nil:
+				t.Errorf("Expected no alternate, got %s", provider.Name)
+			case tt.expected != "" && provider == nil:
+				t.Errorf("Expected alternate %s, got nil", tt.expected)
+			case provider != nil && provider.Name != tt.expected:
+				t.Errorf("Expected alternate %s, got %s", tt.expected, provider.Name)
+			}
+		})
+	}
+}
+
+func TestRouteModelFailover(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	provider, err := router.RouteModel("gpt-4-turbo", "desktop")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.Name != "OpenAI" {
+		t.Fatalf("expected OpenAI, got %s", provider.Name)
+	}
+
+	// The routed provider is never its own fallback
+	if len(provider.Fallbacks) != 1 {
+		t.Fatalf("expected 1 fallback, got %d", len(provider.Fallbacks))
+	}
+	fallback := provider.Fallbacks[0]
+	if fallback.Name != "OpenRouter" || fallback.Model != "openai/gpt-4-turbo" || fallback.BaseURL != OpenRouterBaseURL {
+		t.Errorf("unexpected fallback: %s %s %s", fallback.Name, fallback.Model, fallback.BaseURL)
+	}
+	if fallback.APIKey != OpenRouterDesktopAPIKey {
+		t.Errorf("expected desktop OpenRouter key, got %s", fallback.APIKey)
+	}
+
+	// The routing table is not modified
+	again, _ := router.RouteModel("gpt-4-turbo", "mobile")
+	if again.Fallbacks[0].APIKey != OpenRouterMobileAPIKey {
+		t.Errorf("expected mobile OpenRouter key, got %s", again.Fallbacks[0].APIKey)
+	}
+
+	provider, err = router.RouteModel("gpt-4", "mobile")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.Fallbacks != nil {
+		t.Errorf("expected no fallbacks for gpt-4, got %d", len(provider.Fallbacks))
+	}
+}
+
+func TestModelDeprecation(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	deprecation := router.GetDeprecation(" Text-Davinci-003 ")
+	if deprecation == nil {
+		t.Fatal("expected deprecation entry for text-davinci-003")
+	}
+	if deprecation.Model != "text-davinci-003" || deprecation.Successor != "openai/gpt-4.1" || deprecation.Sunset != "2026-09-30" {
+		t.Errorf("unexpected deprecation: %+v", deprecation)
+	}
+
+	// Quota and tier checks see the successor
+	if got := router.ResolveAlias("text-davinci-003"); got != "openai/gpt-4.1" {
+		t.Errorf("ResolveAlias(text-davinci-003) = %s, want openai/gpt-4.1", got)
+	}
+
+	provider, err := router.RouteModel(deprecation.Successor, "")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.Name != "OpenRouter" || provider.Model != "openai/gpt-4.1" {
+		t.Errorf("expected successor to route to OpenRouter openai/gpt-4.1, got %s %s", provider.Name, provider.Model)
+	}
+
+	if deprecation := router.GetDeprecation("gpt-4.1"); deprecation != nil {
+		t.Errorf("expected no deprecation for a configured model, got %+v", deprecation)
+	}
+}
+
+func TestRebuildRoutesConcurrentReads(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	// Readers see one consistent routing table while routes are rebuilt (run with -race)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 50 {
+			router.Rebuild()
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if got := router.ResolveAlias("text-davinci-003"); got != "openai/gpt-4.1" {
+			t.Fatalf("ResolveAlias(text-davinci-003) = %s during a rebuild, want openai/gpt-4.1", got)
+		}
+		if _, err := router.RouteModel("gpt-4.1", ""); err != nil {
+			t.Fatalf("RouteModel failed during a rebuild: %v", err)
+		}
+		router.GetModelInfo("gpt-4.1")
+		router.GetDeprecation("text-davinci-003")
+	}
+}
+
+func TestProviderPreferences(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	// Endpoint preferences are merged over the provider's
+	provider, err := router.RouteModel("gpt-4.1", "mobile")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	prefs := provider.ProviderPreferences
+	if prefs == nil || prefs.DataCollection != "deny" || len(prefs.Order) != 2 || prefs.Order[0] != "OpenAI" ||
+		prefs.AllowFallbacks == nil || *prefs.AllowFallbacks {
+		t.Errorf("unexpected merged preferences: %+v", prefs)
+	}
+
+	// Other OpenRouter models get the provider's preferences
+	provider, err = router.RouteModel("gpt-5.5", "mobile")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if prefs := provider.ProviderPreferences; prefs == nil || prefs.DataCollection != "deny" || len(prefs.Order) != 0 || prefs.AllowFallbacks != nil {
+		t.Errorf("unexpected provider preferences: %+v", prefs)
+	}
+
+	// Other providers get none
+	provider, err = router.RouteModel("gpt-5.5-pro", "mobile")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.ProviderPreferences != nil {
+		t.Errorf("expected no preferences for %s, got %+v", provider.Name, provider.ProviderPreferences)
+	}
+}
+
+func TestRouteModelZeroRetention(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	tests := []struct {
+		name         string
+		model        string
+		wantProvider string
+		wantErr      bool
+	}{
+		{name: "zero-retention provider among others", model: "glm-4.6", wantProvider: "Eternis"},
+		{name: "single zero-retention provider", model: "kimi-k2", wantProvider: "Tinfoil"},
+		{name: "no zero-retention provider", model: "qwen3-30b", wantErr: true},
+		{name: "unknown model not sent to wildcard", model: "mistral/unknown", wantErr: true},
+		{name: "empty model", model: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := router.RouteModelZeroRetention(tt.model, "mobile")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got provider %s", provider.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RouteModelZeroRetention failed: %v", err)
+			}
+			if provider.Name != tt.wantProvider || !provider.ZeroRetention {
+				t.Errorf("got provider %s (zero retention %v), want %s", provider.Name, provider.ZeroRetention, tt.wantProvider)
+			}
+		})
+	}
+}
+
+// staticUpstreams is an UpstreamPolicy allowing the base URLs in its map, with their keys.
+type staticUpstreams map[string]string
+
+func (u staticUpstreams) Resolve(baseURL string) (string, bool) {
+	key, ok := u[baseURL]
+	return key, ok
+}
+
+func TestUpstreamPolicy(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	router.SetUpstreamPolicy(staticUpstreams{
+		EternisGLM46BaseURL: "rotated-eternis-key",
+		OpenRouterBaseURL:   "",
+	})
+	router.Rebuild()
+
+	provider, err := router.RouteModel("zai-org/GLM-4.6", "mobile")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.APIKey != "rotated-eternis-key" {
+		t.Errorf("expected the policy's API key, got %s", provider.APIKey)
+	}
+
+	provider, err = router.RouteModel("openai/gpt-4.1", "mobile")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.APIKey != OpenRouterMobileAPIKey {
+		t.Errorf("expected the configured API key, got %s", provider.APIKey)
+	}
+
+	if _, exists := router.GetRoutes()["dphn/Dolphin-Mistral-24B-Venice-Edition"]; exists {
+		t.Error("model served only by a disallowed upstream should not be routed")
+	}
+
+	router.SetUpstreamPolicy(nil)
+	router.Rebuild()
+	if _, exists := router.GetRoutes()["dphn/Dolphin-Mistral-24B-Venice-Edition"]; !exists {
+		t.Error("model should be routed again without a policy")
+	}
+}
+
+// unattested is an AttestationPolicy failing the attestation of its providers.
+type unattested map[string]bool
+
+func (u unattested) Attested(provider string) bool {
+	return !u[provider]
+}
+
+func TestAttestationPolicy(t *testing.T) {
+	router := newModelRouter(t, newEnv(nil))
+
+	router.SetAttestationPolicy(unattested{"Tinfoil": true})
+	router.Rebuild()
+
+	if _, err := router.RouteModelZeroRetention("kimi-k2", "mobile"); err == nil {
+		t.Error("model served only by an unattested provider should not be routed")
+	}
+	provider, err := router.RouteModel("zai-org/GLM-4.6", "mobile")
+	if err != nil {
+		t.Fatalf("RouteModel failed: %v", err)
+	}
+	if provider.Name == "Tinfoil" {
+		t.Error("unattested provider should not be routed to")
+	}
+
+	router.SetAttestationPolicy(nil)
+	router.Rebuild()
+	if _, err := router.RouteModelZeroRetention("kimi-k2", "mobile"); err != nil {
+		t.Errorf("model should be routed again without a policy: %v", err)
+	}
+}
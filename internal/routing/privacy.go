@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// RouteModelZeroRetention routes a privacy mode request. Like RouteModel, but only endpoints
+// of zero-retention providers are considered, active endpoints first. Unknown models are never
+// sent to the wildcard provider.
+//
+// Returns an error if no zero-retention provider serves the model.
+func (mr *ModelRouter) RouteModelZeroRetention(modelID string, platform string) (*ProviderConfig, error) {
+	if modelID == "" {
+		return nil, errors.New("model ID is required")
+	}
+
+	normalizedModel := strings.ToLower(strings.TrimSpace(modelID))
+
+	// Exact match first, then prefix match (same as RouteModel)
+	table := mr.current()
+	canonicalModels := make([]string, 0, 1)
+	if canonicalModel, exists := table.aliases[normalizedModel]; exists {
+		canonicalModels = append(canonicalModels, canonicalModel)
+	}
+	for prefix, canonicalModel := range table.aliases {
+		if prefix != "*" && prefix != normalizedModel && strings.HasPrefix(normalizedModel, prefix) {
+			canonicalModels = append(canonicalModels, canonicalModel)
+		}
+	}
+
+	routes := table.routes
+	for _, canonicalModel := range canonicalModels {
+		route, exists := routes[canonicalModel]
+		if !exists {
+			continue
+		}
+
+		for _, endpoints := range [][]ModelEndpoint{route.ActiveEndpoints, route.InactiveEndpoints} {
+			for _, endpoint := range endpoints {
+				provider := endpoint.Provider
+				if !provider.ZeroRetention {
+					continue
+				}
+
+				// Same OpenRouter key selection as getModelEndpointProvider
+				if provider.Name == "OpenRouter" {
+					apiKey := mr.GetOpenRouterAPIKey(platform)
+					if apiKey == "" {
+						continue
+					}
+					prov := *provider
+					prov.APIKey = apiKey
+					provider = &prov
+				}
+
+				mr.logger.Debug("model routed (zero retention)",
+					slog.String("model", modelID),
+					slog.String("provider", provider.Name))
+				return mr.withFailover(provider, route.Failover, platform), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no zero-retention provider found for model: %s", modelID)
+}
@@ -0,0 +1,55 @@
+package routing
+
+import "math"
+
+// qualityScores are per-model endpoint quality scores (0-100) keyed by canonical model name
+// and provider name, set by the quality routing worker.
+type qualityScores struct {
+	scores map[string]map[string]float64
+	margin float64
+}
+
+// SetQualityScores makes endpoint selection prefer the best-scoring providers of a model:
+// active endpoints scoring more than margin below the model's best score are skipped while a
+// better one is available. Endpoints without a score are always kept. nil scores disable it.
+func (mr *ModelRouter) SetQualityScores(scores map[string]map[string]float64, margin float64) {
+	if scores == nil {
+		mr.quality.Store(nil)
+		return
+	}
+	mr.quality.Store(&qualityScores{scores: scores, margin: margin})
+}
+
+// qualityEndpoints returns the endpoints of model selection may choose from given the quality
+// scores: all of them unless some endpoint scores more than the margin below the best one.
+func (mr *ModelRouter) qualityEndpoints(model string, endpoints []ModelEndpoint) []ModelEndpoint {
+	quality := mr.quality.Load()
+	if quality == nil || len(endpoints) < 2 {
+		return endpoints
+	}
+	scores := quality.scores[model]
+	if len(scores) == 0 {
+		return endpoints
+	}
+
+	best := math.Inf(-1)
+	for _, endpoint := range endpoints {
+		if score, ok := scores[endpoint.Provider.Name]; ok && score > best {
+			best = score
+		}
+	}
+	if math.IsInf(best, -1) {
+		return endpoints
+	}
+
+	filtered := make([]ModelEndpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if score, ok := scores[endpoint.Provider.Name]; !ok || score >= best-quality.margin {
+			filtered = append(filtered, endpoint)
+		}
+	}
+	if len(filtered) == len(endpoints) {
+		return endpoints
+	}
+	return filtered
+}
@@ -0,0 +1,66 @@
+package routing
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+func TestQualityScoresEndpointSelection(t *testing.T) {
+	cfg := &config.Config{
+		ModelRouterConfig: &config.ModelRouterConfig{
+			Providers: []config.ModelProviderConfig{
+				{Name: "A", BaseURL: "https://a.example.com/v1", APIKey: "key"},
+				{Name: "B", BaseURL: "https://b.example.com/v1", APIKey: "key"},
+				{Name: "C", BaseURL: "https://c.example.com/v1", APIKey: "key"},
+			},
+			Models: []config.ModelConfig{{
+				Name:            "model",
+				TokenMultiplier: 1,
+				Providers: []config.ModelEndpointProvider{
+					{Name: "A"}, {Name: "B"}, {Name: "C"},
+				},
+			}},
+		},
+	}
+	router := NewModelRouter(cfg, logger.New(logger.Config{Level: slog.LevelError}))
+	if router == nil {
+		t.Fatal("no router")
+	}
+
+	selected := func() map[string]int {
+		counts := map[string]int{}
+		for range 30 {
+			counts[router.getModelEndpointProvider("model", "").Name]++
+		}
+		return counts
+	}
+
+	tests := []struct {
+		name   string
+		scores map[string]map[string]float64
+		want   []string
+	}{
+		{"disabled", nil, []string{"A", "B", "C"}},
+		{"within margin", map[string]map[string]float64{"model": {"A": 90, "B": 85, "C": 82}}, []string{"A", "B", "C"}},
+		{"below margin skipped", map[string]map[string]float64{"model": {"A": 90, "B": 60, "C": 85}}, []string{"A", "C"}},
+		{"unscored kept", map[string]map[string]float64{"model": {"A": 90, "B": 40}}, []string{"A", "C"}},
+		{"other model", map[string]map[string]float64{"other": {"A": 90, "B": 10}}, []string{"A", "B", "C"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router.SetQualityScores(tt.scores, 10)
+			counts := selected()
+			if len(counts) != len(tt.want) {
+				t.Fatalf("selected %v, want %v", counts, tt.want)
+			}
+			for _, name := range tt.want {
+				if counts[name] == 0 {
+					t.Errorf("selected %v, want %v", counts, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// RegionProbeInterval is how often the latency of regional endpoints is probed.
+	RegionProbeInterval = 30 * time.Second
+
+	// regionProbeTimeout bounds a single latency probe; slower regions count as unhealthy.
+	regionProbeTimeout = 5 * time.Second
+
+	// regionStickiness is how long a user keeps being sent to the same region after their last
+	// request, so provider-side prompt caches stay warm.
+	regionStickiness = 30 * time.Minute
+
+	// regionLatencyWeight is the weight of a new probe in the smoothed latency.
+	regionLatencyWeight = 0.3
+)
+
+var (
+	// regionLatency is the smoothed probe latency of each regional endpoint.
+	regionLatency = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "model_router_region_latency_seconds",
+			Help: "Smoothed probe latency of regional provider endpoints in seconds.",
+		},
+		[]string{"provider", "region"},
+	)
+
+	// regionHealthy is the health of each regional endpoint as of its last probe.
+	regionHealthy = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "model_router_region_healthy",
+			Help: "Health of regional provider endpoints as of the last probe: 1 = healthy, 0 = failing.",
+		},
+		[]string{"provider", "region"},
+	)
+
+	// regionSelections counts requests routed to each region, by why it was chosen
+	// ("pinned", "sticky" or "fastest").
+	regionSelections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_router_region_selections_total",
+			Help: "Total requests routed to regional provider endpoints, by provider, region and reason.",
+		},
+		[]string{"provider", "region", "reason"},
+	)
+)
+
+// ProviderRegion is a regional endpoint of a provider.
+type ProviderRegion struct {
+	Name    string
+	BaseURL string
+	APIKey  string
+}
+
+// regionStats is the probed state of a regional endpoint.
+type regionStats struct {
+	latency time.Duration // Smoothed latency of successful probes
+	healthy bool
+}
+
+type stickyKey struct {
+	userID   string
+	provider string
+}
+
+type stickyRegion struct {
+	region  string
+	expires time.Time
+}
+
+// regionTracker holds probe results (by base URL) and the region each user sticks to.
+type regionTracker struct {
+	client *http.Client
+	now    func() time.Time
+
+	mu     sync.Mutex
+	stats  map[string]*regionStats
+	sticky map[stickyKey]stickyRegion
+}
+
+func newRegionTracker() *regionTracker {
+	return &regionTracker{
+		client: &http.Client{Timeout: regionProbeTimeout},
+		now:    time.Now,
+		stats:  make(map[string]*regionStats),
+		sticky: make(map[stickyKey]stickyRegion),
+	}
+}
+
+// applyRegions sets the regional endpoints of an endpoint's provider configuration, applying
+// the upstream policy to each region, and reports whether any region may be used. The first
+// region is the default BaseURL for callers that don't select a region. Caller holds
+// mr.rebuildMu.
+func (mr *ModelRouter) applyRegions(provider *ProviderConfig, regions []config.ProviderRegionConfig, pinned string) bool {
+	for _, region := range regions {
+		regional := *provider
+		regional.BaseURL = region.BaseURL
+		if !mr.applyUpstreamPolicy(&regional) {
+			continue
+		}
+		provider.Regions = append(provider.Regions, ProviderRegion{
+			Name:    region.Name,
+			BaseURL: regional.BaseURL,
+			APIKey:  regional.APIKey,
+		})
+	}
+	if len(provider.Regions) == 0 {
+		return false
+	}
+
+	provider.BaseURL = provider.Regions[0].BaseURL
+	provider.APIKey = provider.Regions[0].APIKey
+	provider.PinnedRegion = pinned
+	return true
+}
+
+// SelectRegion returns the provider configuration to use for a request by userID: for
+// providers with regional endpoints, a copy sending the request to the pinned region, the
+// region the user was recently sent to (while it stays healthy) or else the fastest healthy
+// region. Regions not probed yet rank after probed healthy ones, in configuration order; if
+// all regions are failing, the first is used. Providers without regions are returned as is.
+func (mr *ModelRouter) SelectRegion(provider *ProviderConfig, userID string) *ProviderConfig {
+	if provider == nil || len(provider.Regions) == 0 {
+		return provider
+	}
+
+	idx, reason := mr.regions.choose(provider, userID)
+	region := provider.Regions[idx]
+	regionSelections.WithLabelValues(provider.Name, region.Name, reason).Inc()
+
+	prov := *provider
+	prov.BaseURL = region.BaseURL
+	prov.APIKey = region.APIKey
+	prov.Region = region.Name
+	return &prov
+}
+
+// choose returns the index of the region for the request and why it was chosen.
+func (t *regionTracker) choose(provider *ProviderConfig, userID string) (int, string) {
+	if provider.PinnedRegion != "" {
+		for i, region := range provider.Regions {
+			if region.Name == provider.PinnedRegion {
+				return i, "pinned"
+			}
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	key := stickyKey{userID: userID, provider: provider.Name}
+	if userID != "" {
+		if sticky, exists := t.sticky[key]; exists && now.Before(sticky.expires) {
+			for i, region := range provider.Regions {
+				if region.Name == sticky.region && t.usable(region) {
+					t.sticky[key] = stickyRegion{region: region.Name, expires: now.Add(regionStickiness)}
+					return i, "sticky"
+				}
+			}
+		}
+	}
+
+	best := -1
+	for i, region := range provider.Regions {
+		stats, probed := t.stats[region.BaseURL]
+		if !probed || !stats.healthy {
+			continue
+		}
+		if best < 0 || stats.latency < t.stats[provider.Regions[best].BaseURL].latency {
+			best = i
+		}
+	}
+	if best < 0 {
+		best = 0
+		for i, region := range provider.Regions {
+			if _, probed := t.stats[region.BaseURL]; !probed {
+				best = i
+				break
+			}
+		}
+	}
+
+	if userID != "" {
+		t.sticky[key] = stickyRegion{region: provider.Regions[best].Name, expires: now.Add(regionStickiness)}
+	}
+	return best, "fastest"
+}
+
+// usable reports whether a region is healthy or not probed yet. Caller holds t.mu.
+func (t *regionTracker) usable(region ProviderRegion) bool {
+	stats, probed := t.stats[region.BaseURL]
+	return !probed || stats.healthy
+}
+
+// ProbeRegions measures the latency of every regional endpoint in the routing table and drops
+// expired region stickiness. A region is healthy if it answers GET /models below 500 within
+// the probe timeout. Run it periodically (RegionProbeInterval).
+func (mr *ModelRouter) ProbeRegions(ctx context.Context) error {
+	type target struct {
+		provider string
+		region   ProviderRegion
+	}
+
+	targets := make(map[string]target)
+	for _, route := range mr.GetRoutes() {
+		for _, endpoints := range [][]ModelEndpoint{route.ActiveEndpoints, route.InactiveEndpoints} {
+			for _, endpoint := range endpoints {
+				for _, region := range endpoint.Provider.Regions {
+					targets[region.BaseURL] = target{provider: endpoint.Provider.Name, region: region}
+				}
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, tgt := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latency, err := mr.regions.probe(ctx, tgt.region)
+			healthy := err == nil
+			mr.regions.record(tgt.region.BaseURL, latency, healthy)
+			if !healthy {
+				mr.logger.Warn("region probe failed",
+					slog.String("provider", tgt.provider),
+					slog.String("region", tgt.region.Name),
+					slog.String("error", err.Error()))
+			}
+
+			stats := mr.regions.snapshot(tgt.region.BaseURL)
+			regionLatency.WithLabelValues(tgt.provider, tgt.region.Name).Set(stats.latency.Seconds())
+			if stats.healthy {
+				regionHealthy.WithLabelValues(tgt.provider, tgt.region.Name).Set(1)
+			} else {
+				regionHealthy.WithLabelValues(tgt.provider, tgt.region.Name).Set(0)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mr.regions.pruneSticky()
+	return nil
+}
+
+// probe sends a latency probe to a region and returns the time to the response headers.
+func (t *regionTracker) probe(ctx context.Context, region ProviderRegion) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, region.BaseURL+"/models", nil)
+	if err != nil {
+		return 0, err
+	}
+	if region.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+region.APIKey)
+	}
+
+	start := t.now()
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	latency := t.now().Sub(start)
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return latency, fmt.Errorf("region returned %d", resp.StatusCode)
+	}
+	return latency, nil
+}
+
+// record stores a probe result. Failed probes only mark the region unhealthy.
+func (t *regionTracker) record(baseURL string, latency time.Duration, healthy bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, probed := t.stats[baseURL]
+	if !probed {
+		stats = &regionStats{}
+		t.stats[baseURL] = stats
+	}
+	if healthy {
+		if stats.latency == 0 {
+			stats.latency = latency
+		} else {
+			stats.latency = time.Duration(float64(stats.latency)*(1-regionLatencyWeight) + float64(latency)*regionLatencyWeight)
+		}
+	}
+	stats.healthy = healthy
+}
+
+func (t *regionTracker) snapshot(baseURL string) regionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if stats, probed := t.stats[baseURL]; probed {
+		return *stats
+	}
+	return regionStats{}
+}
+
+func (t *regionTracker) pruneSticky() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for key, sticky := range t.sticky {
+		if !now.Before(sticky.expires) {
+			delete(t.sticky, key)
+		}
+	}
+}
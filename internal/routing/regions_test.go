@@ -0,0 +1,177 @@
+package routing
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+func newRegionalRouter(t *testing.T, pinned string, regions ...config.ProviderRegionConfig) *ModelRouter {
+	t.Helper()
+	cfg := &config.Config{
+		ModelRouterConfig: &config.ModelRouterConfig{
+			Providers: []config.ModelProviderConfig{{
+				Name:         "Regional",
+				APIKey:       "test-key",
+				Regions:      regions,
+				PinnedRegion: pinned,
+			}},
+			Models: []config.ModelConfig{{
+				Name:            "regional-model",
+				TokenMultiplier: 1,
+				Providers:       []config.ModelEndpointProvider{{Name: "Regional"}},
+			}},
+		},
+	}
+	router := NewModelRouter(cfg, logger.New(logger.Config{Level: slog.LevelError}))
+	if router == nil {
+		t.Fatal("NewModelRouter returned nil")
+	}
+	return router
+}
+
+func routeRegion(t *testing.T, router *ModelRouter, userID string) string {
+	t.Helper()
+	provider, err := router.RouteModel("regional-model", "mobile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return router.SelectRegion(provider, userID).Region
+}
+
+func TestRegionsReplaceBaseURL(t *testing.T) {
+	router := newRegionalRouter(t, "",
+		config.ProviderRegionConfig{Name: "us", BaseURL: "https://us.example.com/v1"},
+		config.ProviderRegionConfig{Name: "eu", BaseURL: "https://eu.example.com/v1"},
+	)
+
+	provider, err := router.RouteModel("regional-model", "mobile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.Regions) != 2 {
+		t.Fatalf("regions = %d, want 2", len(provider.Regions))
+	}
+	if provider.BaseURL != "https://us.example.com/v1" || provider.APIKey != "test-key" {
+		t.Errorf("default endpoint = %s (key %q), want the first region", provider.BaseURL, provider.APIKey)
+	}
+
+	selected := router.SelectRegion(provider, "")
+	if selected.Region != "us" || selected == provider {
+		t.Errorf("selected %q (same config %v), want a copy for us", selected.Region, selected == provider)
+	}
+}
+
+func TestSelectRegion(t *testing.T) {
+	router := newRegionalRouter(t, "",
+		config.ProviderRegionConfig{Name: "us", BaseURL: "https://us.example.com/v1"},
+		config.ProviderRegionConfig{Name: "eu", BaseURL: "https://eu.example.com/v1"},
+	)
+	regions := router.regions
+
+	if got := routeRegion(t, router, ""); got != "us" {
+		t.Errorf("unprobed: got %q, want us", got)
+	}
+
+	regions.record("https://us.example.com/v1", 200*time.Millisecond, true)
+	regions.record("https://eu.example.com/v1", 50*time.Millisecond, true)
+	if got := routeRegion(t, router, "alice"); got != "eu" {
+		t.Errorf("fastest: got %q, want eu", got)
+	}
+
+	// The user sticks to their region although us becomes faster
+	for range 10 {
+		regions.record("https://us.example.com/v1", 10*time.Millisecond, true)
+	}
+	if got := routeRegion(t, router, "alice"); got != "eu" {
+		t.Errorf("sticky: got %q, want eu", got)
+	}
+	if got := routeRegion(t, router, "bob"); got != "us" {
+		t.Errorf("new user: got %q, want us", got)
+	}
+
+	// ...until it fails
+	regions.record("https://eu.example.com/v1", 0, false)
+	if got := routeRegion(t, router, "alice"); got != "us" {
+		t.Errorf("sticky region failing: got %q, want us", got)
+	}
+
+	// ...or the stickiness expires
+	for range 20 {
+		regions.record("https://eu.example.com/v1", time.Millisecond, true)
+	}
+	regions.now = func() time.Time { return time.Now().Add(regionStickiness + time.Minute) }
+	regions.pruneSticky()
+	if got := routeRegion(t, router, "alice"); got != "eu" {
+		t.Errorf("stickiness expired: got %q, want eu", got)
+	}
+}
+
+func TestSelectRegionAllFailing(t *testing.T) {
+	router := newRegionalRouter(t, "",
+		config.ProviderRegionConfig{Name: "us", BaseURL: "https://us.example.com/v1"},
+		config.ProviderRegionConfig{Name: "eu", BaseURL: "https://eu.example.com/v1"},
+		config.ProviderRegionConfig{Name: "ap", BaseURL: "https://ap.example.com/v1"},
+	)
+
+	router.regions.record("https://us.example.com/v1", 0, false)
+	if got := routeRegion(t, router, ""); got != "eu" {
+		t.Errorf("first region failing: got %q, want the first unprobed (eu)", got)
+	}
+
+	router.regions.record("https://eu.example.com/v1", 0, false)
+	router.regions.record("https://ap.example.com/v1", 0, false)
+	if got := routeRegion(t, router, ""); got != "us" {
+		t.Errorf("all failing: got %q, want us", got)
+	}
+}
+
+func TestSelectRegionPinned(t *testing.T) {
+	router := newRegionalRouter(t, "eu",
+		config.ProviderRegionConfig{Name: "us", BaseURL: "https://us.example.com/v1"},
+		config.ProviderRegionConfig{Name: "eu", BaseURL: "https://eu.example.com/v1"},
+	)
+	router.regions.record("https://us.example.com/v1", time.Millisecond, true)
+	router.regions.record("https://eu.example.com/v1", time.Second, true)
+
+	if got := routeRegion(t, router, "alice"); got != "eu" {
+		t.Errorf("got %q, want the pinned region eu", got)
+	}
+}
+
+func TestProbeRegions(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("probe %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusUnauthorized) // Reachable; the status doesn't matter below 500
+	}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	router := newRegionalRouter(t, "",
+		config.ProviderRegionConfig{Name: "down", BaseURL: failing.URL},
+		config.ProviderRegionConfig{Name: "up", BaseURL: healthy.URL},
+	)
+	if err := router.ProbeRegions(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := router.regions.snapshot(healthy.URL); !stats.healthy || stats.latency <= 0 {
+		t.Errorf("healthy region stats = %+v", stats)
+	}
+	if stats := router.regions.snapshot(failing.URL); stats.healthy {
+		t.Errorf("failing region stats = %+v", stats)
+	}
+	if got := routeRegion(t, router, ""); got != "up" {
+		t.Errorf("got %q, want up", got)
+	}
+}
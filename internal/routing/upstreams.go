@@ -0,0 +1,52 @@
+package routing
+
+import (
+	"log/slog"
+)
+
+// UpstreamPolicy decides which upstream base URLs the router may send requests to.
+type UpstreamPolicy interface {
+	// Resolve reports whether baseURL is allowed, and the API key to use for it
+	// ("" = the key configured for the provider).
+	Resolve(baseURL string) (apiKey string, allowed bool)
+}
+
+// SetUpstreamPolicy sets the policy applied to endpoints when routes are built. Call Rebuild to
+// apply it to the current routes.
+func (mr *ModelRouter) SetUpstreamPolicy(policy UpstreamPolicy) {
+	mr.rebuildMu.Lock()
+	defer mr.rebuildMu.Unlock()
+	mr.upstreams = policy
+}
+
+// Rebuild rebuilds the routing table from the last configuration, e.g. after the upstream
+// policy changed. As with RebuildRoutes, endpoints switched by the fallback service revert to
+// the configured defaults.
+func (mr *ModelRouter) Rebuild() {
+	mr.rebuildMu.Lock()
+	cfg := mr.config
+	mr.rebuildMu.Unlock()
+
+	mr.RebuildRoutes(cfg)
+}
+
+// applyUpstreamPolicy applies the upstream policy to an endpoint's provider configuration and
+// reports whether the endpoint may be used. Caller holds mr.rebuildMu.
+func (mr *ModelRouter) applyUpstreamPolicy(provider *ProviderConfig) bool {
+	if mr.upstreams == nil {
+		return true
+	}
+
+	apiKey, allowed := mr.upstreams.Resolve(provider.BaseURL)
+	if !allowed {
+		mr.logger.Warn("skipping endpoint with disallowed upstream base URL",
+			slog.String("model", provider.Model),
+			slog.String("provider", provider.Name),
+			slog.String("base_url", provider.BaseURL))
+		return false
+	}
+	if apiKey != "" {
+		provider.APIKey = apiKey
+	}
+	return true
+}
@@ -0,0 +1,399 @@
+// Package routingconfig maintains provider and model routing entries administered via the
+// admin API. Records live in Postgres (routing_entries) and are applied on top of model_router
+// in config.yaml, so models and providers can be added, changed or disabled without a deploy:
+// an enabled entry replaces the configured provider or model of the same name (or adds it), a
+// disabled entry removes it.
+//
+// Each instance refreshes the entries periodically (through the shared cache) and rebuilds its
+// routes when they change. A combination of entries that doesn't validate is rejected by the
+// admin API and, if it reaches an instance anyway, leaves the previous routes in place. The
+// upstream allowlist (internal/upstreams) still applies: a provider with a new base URL also
+// needs an upstream record. Enclave providers (attestation) can only be configured in
+// config.yaml, since their attestation targets are set up at startup.
+package routingconfig
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/goccy/go-yaml"
+)
+
+// entriesKey is the cache key of the routing entries.
+const entriesKey = "all"
+
+// Entry kinds.
+const (
+	KindProvider = "provider"
+	KindModel    = "model"
+)
+
+var (
+	ErrNotFound      = stderrors.New("routing entry not found")
+	ErrInvalidConfig = stderrors.New("invalid routing entry")
+	ErrEnclave       = stderrors.New("providers with attestation can only be configured in config.yaml")
+)
+
+// Entry is a provider or model routing record.
+type Entry struct {
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Config      string    `json:"config"` // A providers or models entry of config.yaml (YAML or JSON)
+	Enabled     bool      `json:"enabled"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PutRequest is the request body for creating or replacing an entry. The name is taken from
+// the path and must match the config's name, if set.
+type PutRequest struct {
+	Config      string `json:"config"`
+	Enabled     *bool  `json:"enabled"` // Default true
+	Description string `json:"description"`
+}
+
+// Service applies the routing entries to the model router.
+type Service struct {
+	queries  pgdb.Querier
+	cache    *cache.Cache
+	router   *routing.ModelRouter
+	interval time.Duration
+	logger   *logger.Logger
+
+	mu          sync.Mutex
+	base        *config.ModelRouterConfig // model_router of config.yaml
+	fingerprint string
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewService creates the routing config service. base is model_router of config.yaml; the
+// entries are refreshed every interval (<= 0 = loaded once at Start). router may be nil (no
+// routes to rebuild, e.g. in tools).
+func NewService(queries pgdb.Querier, sharedCache *cache.Cache, router *routing.ModelRouter, base *config.ModelRouterConfig, interval time.Duration, logger *logger.Logger) *Service {
+	return &Service{
+		queries:  queries,
+		cache:    sharedCache.Namespace("routingconfig", interval),
+		router:   router,
+		interval: interval,
+		logger:   logger,
+		base:     base,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start loads the entries, applies them and starts the periodic refresh. A failed initial load
+// leaves the routes of config.yaml in place until the next successful refresh.
+func (s *Service) Start(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		s.logger.Error("failed to apply routing entries, routing from config.yaml only",
+			slog.String("error", err.Error()))
+	}
+	if s.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				refreshCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				if err := s.Refresh(refreshCtx); err != nil {
+					s.logger.Warn("failed to refresh routing entries, keeping the previous routes",
+						slog.String("error", err.Error()))
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic refresh.
+func (s *Service) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Refresh reloads the entries and rebuilds the routes if they changed.
+func (s *Service) Refresh(ctx context.Context) error {
+	entries, err := cache.Fetch(ctx, s.cache, entriesKey, s.List)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.apply(entries, false)
+}
+
+// SetBase replaces model_router of config.yaml (e.g. after the admin reload) and rebuilds the
+// routes with the entries applied on top of it.
+func (s *Service) SetBase(ctx context.Context, base *config.ModelRouterConfig) error {
+	entries, err := cache.Fetch(ctx, s.cache, entriesKey, s.List)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.base
+	s.base = base
+	if err := s.apply(entries, true); err != nil {
+		s.base = previous
+		return err
+	}
+	return nil
+}
+
+// apply merges the entries into the base configuration and rebuilds the routes if the result
+// changed (or force). Caller holds s.mu.
+func (s *Service) apply(entries []Entry, force bool) error {
+	merged, err := Merge(s.base, entries)
+	if err != nil {
+		return err
+	}
+
+	fingerprint := entriesFingerprint(entries)
+	if !force && fingerprint == s.fingerprint {
+		return nil
+	}
+	s.fingerprint = fingerprint
+
+	if s.router != nil {
+		s.router.RebuildRoutes(merged)
+		s.logger.Info("routing entries applied",
+			slog.Int("entries", len(entries)),
+			slog.Int("route_count", len(s.router.GetRoutes())))
+	}
+	return nil
+}
+
+// List returns all routing entries from the database.
+func (s *Service) List(ctx context.Context) ([]Entry, error) {
+	rows, err := s.queries.ListRoutingEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routing entries: %w", err)
+	}
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, toEntry(row))
+	}
+	return entries, nil
+}
+
+// Put creates or replaces an entry after checking that the resulting routing configuration
+// validates, and applies the change on this instance (other instances pick it up on their next
+// refresh).
+func (s *Service) Put(ctx context.Context, kind, name string, req *PutRequest) (*Entry, error) {
+	entry := Entry{
+		Kind:        kind,
+		Name:        strings.TrimSpace(name),
+		Config:      strings.TrimSpace(req.Config),
+		Enabled:     true,
+		Description: strings.TrimSpace(req.Description),
+	}
+	if req.Enabled != nil {
+		entry.Enabled = *req.Enabled
+	}
+	if err := s.check(ctx, entry, false); err != nil {
+		return nil, err
+	}
+
+	row, err := s.queries.UpsertRoutingEntry(ctx, pgdb.UpsertRoutingEntryParams{
+		Kind:        entry.Kind,
+		Name:        entry.Name,
+		Config:      entry.Config,
+		Enabled:     entry.Enabled,
+		Description: entry.Description,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save routing entry: %w", err)
+	}
+
+	s.applyChange(ctx)
+	saved := toEntry(row)
+	return &saved, nil
+}
+
+// Delete removes an entry, restoring the provider or model of config.yaml (if any).
+func (s *Service) Delete(ctx context.Context, kind, name string) error {
+	if err := s.check(ctx, Entry{Kind: kind, Name: name}, true); err != nil {
+		return err
+	}
+
+	_, err := s.queries.DeleteRoutingEntry(ctx, pgdb.DeleteRoutingEntryParams{Kind: kind, Name: name})
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete routing entry: %w", err)
+	}
+
+	s.applyChange(ctx)
+	return nil
+}
+
+// check reports whether the current entries with entry put (or removed) still merge into a
+// valid routing configuration.
+func (s *Service) check(ctx context.Context, entry Entry, remove bool) error {
+	current, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]Entry, 0, len(current)+1)
+	for _, existing := range current {
+		if existing.Kind != entry.Kind || existing.Name != entry.Name {
+			entries = append(entries, existing)
+		}
+	}
+	if !remove {
+		entries = append(entries, entry)
+	}
+
+	s.mu.Lock()
+	base := s.base
+	s.mu.Unlock()
+	_, err = Merge(base, entries)
+	return err
+}
+
+// applyChange invalidates the cached entries and refreshes this instance.
+func (s *Service) applyChange(ctx context.Context) {
+	if err := s.cache.Delete(ctx, entriesKey); err != nil {
+		s.logger.Warn("failed to invalidate cached routing entries", slog.String("error", err.Error()))
+	}
+	if err := s.Refresh(ctx); err != nil {
+		s.logger.Warn("failed to refresh routing entries", slog.String("error", err.Error()))
+	}
+}
+
+// Merge applies routing entries on top of a base configuration and validates the result. The
+// base configuration is not modified.
+func Merge(base *config.ModelRouterConfig, entries []Entry) (*config.ModelRouterConfig, error) {
+	merged := &config.ModelRouterConfig{}
+	if base != nil {
+		merged.Providers = append(merged.Providers, base.Providers...)
+		merged.Models = append(merged.Models, base.Models...)
+		merged.Deprecations = append(merged.Deprecations, base.Deprecations...)
+	}
+
+	for _, entry := range entries {
+		switch entry.Kind {
+		case KindProvider:
+			for _, provider := range merged.Providers {
+				if provider.Name == entry.Name && provider.Attestation != nil {
+					return nil, fmt.Errorf("%w (provider %s)", ErrEnclave, entry.Name)
+				}
+			}
+			merged.Providers = withoutName(merged.Providers, entry.Name, func(p config.ModelProviderConfig) string { return p.Name })
+			if !entry.Enabled {
+				continue
+			}
+
+			var provider config.ModelProviderConfig
+			if err := parseEntry(entry, &provider); err != nil {
+				return nil, err
+			}
+			if provider.Attestation != nil {
+				return nil, fmt.Errorf("%w (provider %s)", ErrEnclave, entry.Name)
+			}
+			merged.Providers = append(merged.Providers, provider)
+		case KindModel:
+			merged.Models = withoutName(merged.Models, entry.Name, func(m config.ModelConfig) string { return m.Name })
+			if !entry.Enabled {
+				continue
+			}
+
+			var model config.ModelConfig
+			if err := parseEntry(entry, &model); err != nil {
+				return nil, err
+			}
+			merged.Models = append(merged.Models, model)
+		default:
+			return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidConfig, entry.Kind)
+		}
+	}
+
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return merged, nil
+}
+
+// parseEntry decodes an entry's config, validated by its YAML unmarshaler. The name defaults to
+// the entry's and must match it if set.
+func parseEntry[T any](entry Entry, value *T) error {
+	if entry.Config == "" {
+		return fmt.Errorf("%w: %s %s has no config", ErrInvalidConfig, entry.Kind, entry.Name)
+	}
+
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal([]byte(entry.Config), &fields); err != nil || fields == nil {
+		return fmt.Errorf("%w: %s %s: config must be a mapping", ErrInvalidConfig, entry.Kind, entry.Name)
+	}
+	if name, ok := fields["name"]; !ok {
+		fields["name"] = entry.Name
+	} else if name != entry.Name {
+		return fmt.Errorf("%w: %s %s: config name %v doesn't match", ErrInvalidConfig, entry.Kind, entry.Name, name)
+	}
+
+	data, err := yaml.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrInvalidConfig, entry.Kind, entry.Name, err)
+	}
+	if err := yaml.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrInvalidConfig, entry.Kind, entry.Name, err)
+	}
+	return nil
+}
+
+// withoutName returns the items not named name.
+func withoutName[T any](items []T, name string, nameOf func(T) string) []T {
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		if nameOf(item) != name {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func toEntry(row pgdb.RoutingEntry) Entry {
+	return Entry{
+		Kind:        row.Kind,
+		Name:        row.Name,
+		Config:      row.Config,
+		Enabled:     row.Enabled,
+		Description: row.Description,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+// entriesFingerprint identifies the routing-relevant state of the records.
+func entriesFingerprint(entries []Entry) string {
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("%s|%s|%t|%s", entry.Kind, entry.Name, entry.Enabled, entry.Config))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
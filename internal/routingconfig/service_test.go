@@ -0,0 +1,234 @@
+package routingconfig
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/goccy/go-yaml"
+)
+
+const baseConfig = `
+providers:
+- name: OpenAI
+  api_key_env_var: TEST_OPENAI_KEY
+  base_url: https://api.openai.com/v1
+- name: Enclave
+  base_url: https://enclave.example/v1
+  attestation:
+    type: tinfoil
+
+models:
+- name: openai/gpt-4
+  aliases: [gpt-4]
+  providers:
+  - name: OpenAI
+    model: gpt-4
+- name: openai/gpt-4.1
+  providers:
+  - name: OpenAI
+    model: gpt-4.1
+`
+
+// entryStore keeps routing entries in memory; other Querier methods are not used.
+type entryStore struct {
+	pgdb.Querier
+	rows  []pgdb.RoutingEntry
+	lists int
+}
+
+func (s *entryStore) ListRoutingEntries(context.Context) ([]pgdb.RoutingEntry, error) {
+	s.lists++
+	return append([]pgdb.RoutingEntry(nil), s.rows...), nil
+}
+
+func (s *entryStore) UpsertRoutingEntry(_ context.Context, arg pgdb.UpsertRoutingEntryParams) (pgdb.RoutingEntry, error) {
+	row := pgdb.RoutingEntry{Kind: arg.Kind, Name: arg.Name, Config: arg.Config, Enabled: arg.Enabled, Description: arg.Description}
+	for i, existing := range s.rows {
+		if existing.Kind == arg.Kind && existing.Name == arg.Name {
+			s.rows[i] = row
+			return row, nil
+		}
+	}
+	s.rows = append(s.rows, row)
+	return row, nil
+}
+
+func (s *entryStore) DeleteRoutingEntry(_ context.Context, arg pgdb.DeleteRoutingEntryParams) (string, error) {
+	for i, row := range s.rows {
+		if row.Kind == arg.Kind && row.Name == arg.Name {
+			s.rows = append(s.rows[:i], s.rows[i+1:]...)
+			return row.Name, nil
+		}
+	}
+	return "", sql.ErrNoRows
+}
+
+func loadBase(t *testing.T) *config.ModelRouterConfig {
+	t.Helper()
+	t.Setenv("TEST_OPENAI_KEY", "sk-openai")
+	t.Setenv("TEST_EXAMPLE_KEY", "sk-example")
+	var base config.ModelRouterConfig
+	if err := yaml.Unmarshal([]byte(baseConfig), &base); err != nil {
+		t.Fatalf("failed to parse base config: %v", err)
+	}
+	return &base
+}
+
+func newTestService(t *testing.T, store *entryStore) *Service {
+	t.Helper()
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	base := loadBase(t)
+	router := routing.NewModelRouter(&config.Config{ModelRouterConfig: base}, log)
+	return NewService(store, cache.New(cache.NewMemory(0), log), router, base, time.Minute, log)
+}
+
+func TestMerge(t *testing.T) {
+	base := loadBase(t)
+
+	merged, err := Merge(base, []Entry{
+		{Kind: KindProvider, Name: "Example", Enabled: true, Config: "base_url: https://api.example.com/v1"},
+		{Kind: KindModel, Name: "example/model", Enabled: true, Config: `{"token_multiplier": 2, "providers": [{"name": "Example"}]}`},
+		{Kind: KindModel, Name: "openai/gpt-4", Enabled: true, Config: "name: openai/gpt-4\ntoken_multiplier: 3\nproviders:\n- name: OpenAI\n"},
+		{Kind: KindModel, Name: "openai/gpt-4.1", Enabled: false},
+	})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	models := make(map[string]config.ModelConfig)
+	for _, model := range merged.Models {
+		models[model.Name] = model
+	}
+	if len(models) != 2 {
+		t.Errorf("expected 2 models, got %v", merged.Models)
+	}
+	if models["example/model"].TokenMultiplier != 2 {
+		t.Errorf("unexpected example/model: %+v", models["example/model"])
+	}
+	if gpt4 := models["openai/gpt-4"]; gpt4.TokenMultiplier != 3 || len(gpt4.Aliases) != 0 {
+		t.Errorf("openai/gpt-4 should be replaced by the entry: %+v", gpt4)
+	}
+	if len(base.Models) != 2 || base.Models[0].TokenMultiplier != 1 {
+		t.Error("base configuration was modified")
+	}
+
+	tests := []struct {
+		name  string
+		entry Entry
+		want  error
+	}{
+		{"unknown provider", Entry{Kind: KindModel, Name: "x/y", Enabled: true, Config: "providers:\n- name: Nope\n"}, ErrInvalidConfig},
+		{"name mismatch", Entry{Kind: KindModel, Name: "x/y", Enabled: true, Config: "name: x/z\nproviders:\n- name: OpenAI\n"}, ErrInvalidConfig},
+		{"no config", Entry{Kind: KindModel, Name: "x/y", Enabled: true}, ErrInvalidConfig},
+		{"not a mapping", Entry{Kind: KindModel, Name: "x/y", Enabled: true, Config: "- a\n- b\n"}, ErrInvalidConfig},
+		{"unknown kind", Entry{Kind: "region", Name: "eu", Enabled: true, Config: "name: eu"}, ErrInvalidConfig},
+		{"removes a used provider", Entry{Kind: KindProvider, Name: "OpenAI"}, ErrInvalidConfig},
+		{"overrides an enclave provider", Entry{Kind: KindProvider, Name: "Enclave", Enabled: true, Config: "base_url: https://other.example/v1"}, ErrEnclave},
+		{"adds an enclave provider", Entry{Kind: KindProvider, Name: "E2", Enabled: true, Config: "base_url: https://e2.example/v1\nattestation:\n  type: tinfoil\n"}, ErrEnclave},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Merge(base, []Entry{tt.entry}); !stderrors.Is(err, tt.want) {
+				t.Errorf("Merge error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPutAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store := &entryStore{}
+	s := newTestService(t, store)
+	s.Start(ctx)
+	defer s.Stop()
+
+	if _, err := s.router.RouteModel("example/model", "mobile"); err == nil {
+		t.Fatal("example/model should not be routed before it's added")
+	}
+
+	if _, err := s.Put(ctx, KindProvider, "Example", &PutRequest{Config: "base_url: https://api.example.com/v1\napi_key_env_var: TEST_EXAMPLE_KEY\n"}); err != nil {
+		t.Fatalf("Put provider failed: %v", err)
+	}
+	entry, err := s.Put(ctx, KindModel, "example/model", &PutRequest{
+		Config:      "aliases: [example]\nproviders:\n- name: Example\n",
+		Description: "Example model",
+	})
+	if err != nil {
+		t.Fatalf("Put model failed: %v", err)
+	}
+	if !entry.Enabled || entry.Description != "Example model" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	provider, err := s.router.RouteModel("example", "mobile")
+	if err != nil || provider.Name != "Example" || provider.BaseURL != "https://api.example.com/v1" {
+		t.Errorf("RouteModel(example) = %+v, %v", provider, err)
+	}
+
+	// Invalid changes are rejected before they're saved
+	if _, err := s.Put(ctx, KindProvider, "Example", &PutRequest{Enabled: new(bool)}); !stderrors.Is(err, ErrInvalidConfig) {
+		t.Errorf("disabling a used provider: error = %v, want ErrInvalidConfig", err)
+	}
+	if err := s.Delete(ctx, KindProvider, "Example"); !stderrors.Is(err, ErrInvalidConfig) {
+		t.Errorf("deleting a used provider: error = %v, want ErrInvalidConfig", err)
+	}
+	if len(store.rows) != 2 || !store.rows[0].Enabled {
+		t.Errorf("rejected changes were saved: %+v", store.rows)
+	}
+
+	// Disabling a configured model removes its route
+	if _, err := s.Put(ctx, KindModel, "openai/gpt-4.1", &PutRequest{Enabled: new(bool)}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if info := s.router.GetModelInfo("openai/gpt-4.1"); info != nil {
+		t.Errorf("openai/gpt-4.1 should be disabled, got %+v", info)
+	}
+
+	if err := s.Delete(ctx, KindModel, "example/model"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.router.RouteModel("example/model", "mobile"); err == nil {
+		t.Error("example/model should not be routed after it's deleted")
+	}
+	if err := s.Delete(ctx, KindModel, "example/model"); !stderrors.Is(err, ErrNotFound) {
+		t.Errorf("Delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRefreshUsesCache(t *testing.T) {
+	ctx := context.Background()
+	store := &entryStore{}
+	s := newTestService(t, store)
+
+	for i := 0; i < 3; i++ {
+		if err := s.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+	}
+	if store.lists != 1 {
+		t.Errorf("database lists = %d, want 1", store.lists)
+	}
+}
+
+func TestRefreshKeepsRoutesOnInvalidEntries(t *testing.T) {
+	ctx := context.Background()
+	store := &entryStore{rows: []pgdb.RoutingEntry{
+		{Kind: KindModel, Name: "broken/model", Enabled: true, Config: "providers:\n- name: Nope\n"},
+	}}
+	s := newTestService(t, store)
+
+	if err := s.Refresh(ctx); !stderrors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Refresh error = %v, want ErrInvalidConfig", err)
+	}
+	if _, err := s.router.RouteModel("gpt-4", "mobile"); err != nil {
+		t.Errorf("configured routes should be kept: %v", err)
+	}
+}
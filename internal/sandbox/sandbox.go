@@ -0,0 +1,118 @@
+// Package sandbox implements developer sandbox mode for the proxy endpoints.
+//
+// A request is a sandbox request when it carries a valid X-Sandbox-Key header or the user's
+// token has the "sandbox" custom claim. Sandbox requests:
+//   - are served by the local/dev provider (SANDBOX_PROVIDER_URL) instead of the model router
+//   - skip tier and quota checks and are not recorded against any quota
+//   - are watermarked with the X-Sandbox response header
+//
+// Client teams can develop against a real deployment without burning plan tokens or provider
+// spend. Servers without a dev provider reject sandbox requests rather than serving them for real.
+package sandbox
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// KeyHeader is the request header carrying a sandbox key (see SANDBOX_KEYS).
+	KeyHeader = "X-Sandbox-Key"
+
+	// ResponseHeader watermarks responses served in sandbox mode.
+	ResponseHeader = "X-Sandbox"
+
+	// ProviderName identifies the dev provider in logs and metrics.
+	ProviderName = "Sandbox"
+)
+
+type contextKey string
+
+const sandboxKey contextKey = "sandbox"
+
+// WithContext marks the context as belonging to a sandbox request.
+func WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sandboxKey, true)
+}
+
+// FromContext reports whether the context belongs to a sandbox request.
+func FromContext(ctx context.Context) bool {
+	sandbox, _ := ctx.Value(sandboxKey).(bool)
+	return sandbox
+}
+
+// Middleware detects sandbox requests and marks their context (read via FromContext).
+// Must run after authentication. Invalid sandbox keys are rejected, as are sandbox requests
+// when no dev provider is configured.
+func Middleware(cfg *config.Config) gin.HandlerFunc {
+	keys := parseKeys(cfg.SandboxKeys)
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(KeyHeader)
+		if key == "" && !auth.HasSandboxClaim(c) {
+			c.Next()
+			return
+		}
+
+		if key != "" {
+			if !validKey(keys, key) {
+				errors.AbortWithUnauthorized(c, "Invalid sandbox key", nil)
+				return
+			}
+			// Never forwarded upstream
+			c.Request.Header.Del(KeyHeader)
+		}
+
+		if cfg.SandboxProviderURL == "" {
+			errors.AbortWithBadRequest(c, "Sandbox mode is not available on this server", nil)
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithContext(c.Request.Context()))
+		c.Header(ResponseHeader, "true")
+		c.Next()
+	}
+}
+
+// NewProvider returns the dev provider serving a sandbox request for model.
+func NewProvider(cfg *config.Config, model string) *routing.ProviderConfig {
+	if cfg.SandboxProviderModel != "" {
+		model = cfg.SandboxProviderModel
+	}
+
+	return &routing.ProviderConfig{
+		Name:            ProviderName,
+		BaseURL:         cfg.SandboxProviderURL,
+		APIKey:          cfg.SandboxProviderAPIKey,
+		Model:           model,
+		APIType:         config.APITypeChatCompletions,
+		TokenMultiplier: 1.0,
+	}
+}
+
+// parseKeys splits a comma-separated key list, dropping empty entries.
+func parseKeys(value string) [][]byte {
+	var keys [][]byte
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, []byte(key))
+		}
+	}
+	return keys
+}
+
+// validKey compares the key against every configured key in constant time.
+func validKey(keys [][]byte, key string) bool {
+	valid := 0
+	for _, k := range keys {
+		valid |= subtle.ConstantTimeCompare(k, []byte(key))
+	}
+	return valid == 1
+}
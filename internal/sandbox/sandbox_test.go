@@ -0,0 +1,89 @@
+package sandbox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	enabled := &config.Config{SandboxProviderURL: "http://localhost:11434/v1", SandboxKeys: "dev-key-1, dev-key-2"}
+	disabled := &config.Config{SandboxKeys: "dev-key-1"}
+
+	tests := []struct {
+		name        string
+		cfg         *config.Config
+		key         string
+		claim       bool
+		wantStatus  int
+		wantSandbox bool
+	}{
+		{name: "regular request", cfg: enabled, wantStatus: http.StatusOK},
+		{name: "valid key", cfg: enabled, key: "dev-key-2", wantStatus: http.StatusOK, wantSandbox: true},
+		{name: "invalid key", cfg: enabled, key: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "claim", cfg: enabled, claim: true, wantStatus: http.StatusOK, wantSandbox: true},
+		{name: "claim with invalid key", cfg: enabled, key: "nope", claim: true, wantStatus: http.StatusUnauthorized},
+		{name: "no dev provider", cfg: disabled, key: "dev-key-1", wantStatus: http.StatusBadRequest},
+		{name: "no dev provider, regular request", cfg: disabled, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSandbox bool
+			var forwardedKey string
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.claim {
+					c.Set(string(auth.SandboxClaimKey), true)
+				}
+			})
+			router.Use(Middleware(tt.cfg))
+			router.POST("/chat/completions", func(c *gin.Context) {
+				gotSandbox = FromContext(c.Request.Context())
+				forwardedKey = c.GetHeader(KeyHeader)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+			if tt.key != "" {
+				req.Header.Set(KeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if gotSandbox != tt.wantSandbox {
+				t.Errorf("sandbox = %v, want %v", gotSandbox, tt.wantSandbox)
+			}
+			if watermarked := w.Header().Get(ResponseHeader) == "true"; watermarked != tt.wantSandbox {
+				t.Errorf("watermarked = %v, want %v", watermarked, tt.wantSandbox)
+			}
+			if forwardedKey != "" {
+				t.Errorf("sandbox key should not reach the handler, got %q", forwardedKey)
+			}
+		})
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	cfg := &config.Config{SandboxProviderURL: "http://localhost:11434/v1", SandboxProviderAPIKey: "local"}
+
+	provider := NewProvider(cfg, "gpt-4.1")
+	if provider.Name != ProviderName || provider.BaseURL != cfg.SandboxProviderURL || provider.APIKey != "local" || provider.Model != "gpt-4.1" {
+		t.Errorf("unexpected provider: %+v", provider)
+	}
+
+	cfg.SandboxProviderModel = "llama3.2"
+	if provider := NewProvider(cfg, "gpt-4.1"); provider.Model != "llama3.2" {
+		t.Errorf("expected SANDBOX_PROVIDER_MODEL to override the model, got %s", provider.Model)
+	}
+}
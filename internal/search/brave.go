@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// braveFreshness maps the time filters of SearchRequest to Brave's freshness values.
+var braveFreshness = map[string]string{
+	"d": "pd", // Past day
+	"w": "pw", // Past week
+	"m": "pm", // Past month
+	"y": "py", // Past year
+}
+
+// BraveSearchResponse represents the raw Brave Search API web search response.
+type BraveSearchResponse struct {
+	Query struct {
+		Original string `json:"original"`
+	} `json:"query"`
+	Web struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+			MetaURL     struct {
+				Hostname string `json:"hostname"`
+			} `json:"meta_url"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+// SearchBrave performs a web search via the Brave Search API.
+func (s *Service) SearchBrave(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	start := time.Now()
+
+	if s.braveAPIKey == "" {
+		return nil, fmt.Errorf("Brave Search API key: %w", ErrEngineNotConfigured)
+	}
+
+	params := url.Values{}
+	params.Set("q", req.Query)
+	params.Set("country", "us")
+	params.Set("search_lang", "en")
+	params.Set("safesearch", "moderate")
+	if freshness, ok := braveFreshness[req.TimeFilter]; ok {
+		params.Set("freshness", freshness)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", s.braveURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("X-Subscription-Token", s.braveAPIKey)
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Brave Search API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var braveResp BraveSearchResponse
+	if err := json.Unmarshal(body, &braveResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Brave Search response: %w", err)
+	}
+
+	return convertBraveResponse(req, braveResp, time.Since(start)), nil
+}
+
+// convertBraveResponse converts a Brave Search response to the standardized format.
+func convertBraveResponse(req SearchRequest, braveResp BraveSearchResponse, processingTime time.Duration) *SearchResponse {
+	results := make([]SearchResult, 0, len(braveResp.Web.Results))
+	for i, result := range braveResp.Web.Results {
+		source := result.MetaURL.Hostname
+		if source == "" {
+			source = extractDomain(result.URL)
+		}
+		results = append(results, SearchResult{
+			Position: i + 1,
+			Title:    result.Title,
+			Link:     result.URL,
+			Snippet:  result.Description,
+			Source:   source,
+		})
+	}
+
+	return &SearchResponse{
+		Query:          req.Query,
+		Engine:         EngineBrave,
+		OrganicResults: results,
+		SearchMetadata: SearchMetadata{
+			Engine:    EngineBrave,
+			Status:    "Success",
+			TimeTaken: fmt.Sprintf("%.2fs", processingTime.Seconds()),
+		},
+		ProcessingTime: fmt.Sprintf("%.2fms", float64(processingTime.Nanoseconds())/1000000),
+	}
+}
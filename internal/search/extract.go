@@ -0,0 +1,369 @@
+package search
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements never contain page content.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Iframe: true, atom.Svg: true,
+	atom.Canvas: true, atom.Select: true, atom.Dialog: true,
+}
+
+// boilerplatePattern matches class/id values of navigation, ads and other page chrome.
+var boilerplatePattern = regexp.MustCompile(`(?i)(^|[\s_-])(nav|navbar|menu|footer|sidebar|comments?|cookie|consent|banner|ads?|advert\w*|promo|share|social|related|breadcrumbs?|popup|modal|newsletter|subscribe|signup)($|[\s_-])`)
+
+var (
+	spacePattern     = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// extractContent returns the title and main content of an HTML page, as markdown or plain text.
+// Like readability, it drops page chrome (navigation, footers, ads) and keeps the element with
+// the most paragraph text.
+func extractContent(page []byte, base *url.URL, markdown bool) (string, string) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return "", ""
+	}
+
+	title := pageTitle(doc)
+	root := mainContent(doc)
+	if root == nil {
+		return title, ""
+	}
+
+	r := &renderer{base: base, markdown: markdown}
+	r.render(root)
+	content := blankLinePattern.ReplaceAllString(r.b.String(), "\n\n")
+	return title, strings.TrimSpace(content)
+}
+
+// pageTitle returns the og:title of a page, or its <title>.
+func pageTitle(doc *html.Node) string {
+	var title, ogTitle string
+	walk(doc, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Title:
+			if title == "" {
+				title = collapseSpace(textContent(n))
+			}
+		case atom.Meta:
+			if attr(n, "property") == "og:title" && ogTitle == "" {
+				ogTitle = collapseSpace(attr(n, "content"))
+			}
+		}
+		return true
+	})
+	if ogTitle != "" {
+		return ogTitle
+	}
+	return title
+}
+
+// mainContent returns the element holding the page's main content: <article>, <main> or
+// role="main" if present, otherwise the block with the best paragraph text score.
+func mainContent(doc *html.Node) *html.Node {
+	var body, semantic *html.Node
+	walk(doc, func(n *html.Node) bool {
+		if isBoilerplate(n) {
+			return false
+		}
+		switch {
+		case n.DataAtom == atom.Body:
+			body = n
+		case semantic == nil && (n.DataAtom == atom.Article || n.DataAtom == atom.Main || attr(n, "role") == "main"):
+			semantic = n
+		}
+		return true
+	})
+	if semantic != nil {
+		return semantic
+	}
+	if body == nil {
+		return nil
+	}
+
+	// Score paragraphs' parents (and grandparents, at half) by text length, penalizing links
+	scores := make(map[*html.Node]float64)
+	walk(body, func(n *html.Node) bool {
+		if isBoilerplate(n) {
+			return false
+		}
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Blockquote {
+			return true
+		}
+		text := collapseSpace(textContent(n))
+		if len(text) < 25 {
+			return false
+		}
+		score := float64(len(text)) * (1 - linkDensity(n))
+		if parent := n.Parent; parent != nil {
+			scores[parent] += score
+			if parent.Parent != nil {
+				scores[parent.Parent] += score / 2
+			}
+		}
+		return false
+	})
+
+	best, bestScore := body, 0.0
+	for n, score := range scores {
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+// isBoilerplate reports whether an element is page chrome rather than content.
+func isBoilerplate(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if skippedElements[n.DataAtom] || attr(n, "aria-hidden") == "true" || hasAttr(n, "hidden") {
+		return true
+	}
+	if role := attr(n, "role"); role == "navigation" || role == "banner" || role == "contentinfo" || role == "complementary" {
+		return true
+	}
+	return boilerplatePattern.MatchString(attr(n, "class")) || boilerplatePattern.MatchString(attr(n, "id"))
+}
+
+// linkDensity returns the share of an element's text that is link text.
+func linkDensity(n *html.Node) float64 {
+	total := len(textContent(n))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walk(n, func(c *html.Node) bool {
+		if c.DataAtom == atom.A {
+			links += len(textContent(c))
+			return false
+		}
+		return true
+	})
+	return float64(links) / float64(total)
+}
+
+// renderer converts an HTML subtree to markdown or plain text.
+type renderer struct {
+	b        strings.Builder
+	base     *url.URL
+	markdown bool
+	pre      bool // Inside <pre>: keep whitespace
+}
+
+func (r *renderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if r.pre {
+			r.b.WriteString(n.Data)
+			return
+		}
+		text := spacePattern.ReplaceAllString(n.Data, " ")
+		if strings.HasSuffix(r.b.String(), "\n") || r.b.Len() == 0 {
+			text = strings.TrimLeft(text, " ")
+		}
+		r.b.WriteString(text)
+		return
+	case html.ElementNode:
+		if isBoilerplate(n) {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.block()
+		if r.markdown {
+			r.b.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		}
+		r.children(n)
+		r.block()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Table, atom.Ul, atom.Ol, atom.Dl, atom.Figure:
+		r.block()
+		r.children(n)
+		r.block()
+	case atom.Li:
+		r.line()
+		if r.markdown {
+			r.b.WriteString("- ")
+		}
+		r.children(n)
+		r.line()
+	case atom.Tr, atom.Dt, atom.Dd, atom.Figcaption:
+		r.line()
+		r.children(n)
+		r.line()
+	case atom.Td, atom.Th:
+		r.children(n)
+		r.b.WriteString(" ")
+	case atom.Br:
+		r.b.WriteString("\n")
+	case atom.Hr:
+		r.block()
+		if r.markdown {
+			r.b.WriteString("---")
+		}
+		r.block()
+	case atom.Blockquote:
+		r.block()
+		inner := &renderer{base: r.base, markdown: r.markdown}
+		inner.children(n)
+		for _, line := range strings.Split(strings.TrimSpace(inner.b.String()), "\n") {
+			if r.markdown {
+				r.b.WriteString("> ")
+			}
+			r.b.WriteString(line + "\n")
+		}
+		r.block()
+	case atom.Pre:
+		r.block()
+		if r.markdown {
+			r.b.WriteString("```\n")
+		}
+		r.pre = true
+		r.children(n)
+		r.pre = false
+		if r.markdown {
+			r.line()
+			r.b.WriteString("```")
+		}
+		r.block()
+	case atom.Code:
+		r.inline(n, "`")
+	case atom.Strong, atom.B:
+		r.inline(n, "**")
+	case atom.Em, atom.I:
+		r.inline(n, "_")
+	case atom.A:
+		href := r.resolve(attr(n, "href"))
+		text := collapseSpace(textContent(n))
+		if !r.markdown || href == "" || text == "" {
+			r.children(n)
+			return
+		}
+		r.b.WriteString("[" + text + "](" + href + ")")
+	case atom.Img:
+		if alt := collapseSpace(attr(n, "alt")); alt != "" && r.markdown {
+			if src := r.resolve(attr(n, "src")); src != "" {
+				r.b.WriteString("![" + alt + "](" + src + ")")
+			}
+		}
+	default:
+		r.children(n)
+	}
+}
+
+func (r *renderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+}
+
+// inline wraps an inline element in markdown delimiters.
+func (r *renderer) inline(n *html.Node, delimiter string) {
+	if !r.markdown || r.pre {
+		r.children(n)
+		return
+	}
+	text := collapseSpace(textContent(n))
+	if text == "" {
+		return
+	}
+	r.b.WriteString(delimiter + text + delimiter)
+}
+
+// line ends the current line.
+func (r *renderer) line() {
+	if s := r.b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		r.b.WriteString("\n")
+	}
+}
+
+// block separates blocks with a blank line.
+func (r *renderer) block() {
+	r.line()
+	if s := r.b.String(); s != "" && !strings.HasSuffix(s, "\n\n") {
+		r.b.WriteString("\n")
+	}
+}
+
+// resolve returns an absolute http(s) URL for a link, or "".
+func (r *renderer) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if r.base != nil {
+		u = r.base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+// walk visits the nodes of a subtree depth-first; visit returns false to skip a node's children.
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, visit)
+	}
+}
+
+// textContent returns the text of a subtree, skipping non-content elements.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && skippedElements[c.DataAtom] {
+			return false
+		}
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+		return true
+	})
+	return b.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.TrimSpace(spacePattern.ReplaceAllString(s, " "))
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
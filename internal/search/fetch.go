@@ -0,0 +1,214 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// Output formats of POST /api/v1/search/fetch.
+const (
+	FormatMarkdown = "markdown" // Default
+	FormatText     = "text"
+)
+
+const (
+	maxFetchBytes      = 5 << 20 // Largest page downloaded
+	maxFetchRedirects  = 5
+	defaultFetchChars  = 20_000 // Default cap of the extracted content
+	maxFetchCharsLimit = 100_000
+	fetchUserAgent     = "Mozilla/5.0 (compatible; EnchantedFetcher/1.0)"
+)
+
+var (
+	// ErrFetchBlocked is returned for URLs that aren't public http(s) addresses.
+	ErrFetchBlocked = errors.New("URL not allowed")
+	// ErrUnsupportedContent is returned for pages that aren't HTML or text.
+	ErrUnsupportedContent = errors.New("unsupported content type")
+)
+
+// blockedPrefixes are non-public ranges not covered by the netip.Addr checks.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // Reserved
+}
+
+// FetchRequest represents a web page fetch request from the client.
+type FetchRequest struct {
+	URL      string `json:"url" binding:"required"`
+	Format   string `json:"format,omitempty"`    // "markdown" (default) or "text"
+	MaxChars int    `json:"max_chars,omitempty"` // Cap of the returned content (default 20000, max 100000)
+}
+
+// FetchResponse represents an extracted web page.
+type FetchResponse struct {
+	URL            string `json:"url"` // Final URL, after redirects
+	Title          string `json:"title,omitempty"`
+	Content        string `json:"content"`
+	Format         string `json:"format"`
+	Truncated      bool   `json:"truncated"` // Content was cut at max_chars
+	ProcessingTime string `json:"processing_time"`
+}
+
+// newFetchClient creates an HTTP client that only connects to public IP addresses. The check
+// runs on the resolved address at dial time, so DNS rebinding and redirects can't reach
+// internal services.
+func newFetchClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !isPublicAddr(addrPort.Addr()) {
+				return ErrFetchBlocked
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: 20 * time.Second,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 15 * time.Second,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			return checkFetchURL(req.URL)
+		},
+	}
+}
+
+// isPublicAddr reports whether an IP address is publicly routable.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFetchURL rejects URLs that can't be fetched: non-http(s) schemes, credentials and IP
+// literals of non-public addresses (host names are checked once resolved).
+func checkFetchURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme must be http or https", ErrFetchBlocked)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in URL", ErrFetchBlocked)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrFetchBlocked)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublicAddr(addr) {
+		return fmt.Errorf("%w: non-public address", ErrFetchBlocked)
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("%w: non-public address", ErrFetchBlocked)
+	}
+	return nil
+}
+
+// Fetch downloads a web page and extracts its main content as markdown or plain text.
+func (s *Service) Fetch(ctx context.Context, req FetchRequest) (*FetchResponse, error) {
+	start := time.Now()
+
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid URL", ErrFetchBlocked)
+	}
+	if err := checkFetchURL(u); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("User-Agent", fetchUserAgent)
+	httpReq.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.1")
+
+	resp, err := s.fetchClient.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, ErrFetchBlocked) {
+			return nil, fmt.Errorf("%w: non-public address", ErrFetchBlocked)
+		}
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("page returned status %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isHTML := mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml"
+	if !isHTML && !strings.HasPrefix(mediaType, "text/") {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContent, mediaType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page: %w", err)
+	}
+
+	format := req.Format
+	if format == "" {
+		format = FormatMarkdown
+	}
+
+	var title, content string
+	if isHTML {
+		title, content = extractContent(body, resp.Request.URL, format == FormatMarkdown)
+	} else {
+		content = strings.TrimSpace(strings.ToValidUTF8(string(body), ""))
+	}
+
+	maxChars := req.MaxChars
+	if maxChars <= 0 {
+		maxChars = defaultFetchChars
+	}
+	maxChars = min(maxChars, maxFetchCharsLimit)
+
+	content, truncated := truncateRunes(content, maxChars)
+
+	return &FetchResponse{
+		URL:            resp.Request.URL.String(),
+		Title:          title,
+		Content:        content,
+		Format:         format,
+		Truncated:      truncated,
+		ProcessingTime: fmt.Sprintf("%.2fms", float64(time.Since(start).Nanoseconds())/1000000),
+	}, nil
+}
+
+// truncateRunes cuts s to at most n characters.
+func truncateRunes(s string, n int) (string, bool) {
+	if utf8.RuneCountInString(s) <= n {
+		return s, false
+	}
+	runes := []rune(s)
+	return string(runes[:n]), true
+}
@@ -2,9 +2,11 @@ package search
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/eternisai/enchanted-proxy/internal/auth"
@@ -15,8 +17,10 @@ import (
 
 // SearchService interface defines the methods needed by the handler.
 type SearchService interface {
-	SearchDuckDuckGo(ctx context.Context, req SearchRequest) (*SearchResponse, error)
+	Engines() []string
+	Search(ctx context.Context, req SearchRequest) (*SearchResponse, error)
 	SearchExa(ctx context.Context, req ExaSearchRequest) (*ExaSearchResponse, error)
+	Fetch(ctx context.Context, req FetchRequest) (*FetchResponse, error)
 }
 
 // Handler handles HTTP requests for search operations.
@@ -57,16 +61,19 @@ func (h *Handler) PostSearchHandler(c *gin.Context) {
 	}
 
 	// Set defaults
+	searchReq.Engine = strings.ToLower(strings.TrimSpace(searchReq.Engine))
 	if searchReq.Engine == "" {
-		searchReq.Engine = "duckduckgo"
+		searchReq.Engine = EngineDuckDuckGo
 	}
 
-	// Validate engine
-	if searchReq.Engine != "duckduckgo" {
+	// Validate engine (only engines with an API key are available)
+	engines := h.service.Engines()
+	if !slices.Contains(engines, searchReq.Engine) {
 		log.Warn("unsupported search engine requested",
 			slog.String("engine", searchReq.Engine),
 			slog.String("user_id", userID))
-		errors.BadRequest(c, "Unsupported search engine. Currently supported: 'duckduckgo'", nil)
+		errors.BadRequest(c, "Unsupported search engine. Currently supported: '"+strings.Join(engines, "', '")+"'",
+			map[string]interface{}{"supported_engines": engines})
 		return
 	}
 
@@ -80,7 +87,7 @@ func (h *Handler) PostSearchHandler(c *gin.Context) {
 		slog.String("user_id", userID))
 
 	// Perform search
-	result, err := h.service.SearchDuckDuckGo(c.Request.Context(), searchReq)
+	result, err := h.service.Search(c.Request.Context(), searchReq)
 	if err != nil {
 		log.Error("search request failed",
 			slog.String("engine", searchReq.Engine),
@@ -185,3 +192,47 @@ func (h *Handler) PostExaSearchHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+// PostFetchHandler handles POST /api/search/fetch requests: downloads a web page server-side and
+// returns its main content as markdown or text.
+func (h *Handler) PostFetchHandler(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("search_fetch_handler")
+
+	// Get user ID from auth context for logging
+	userID, _ := auth.GetUserID(c)
+
+	var fetchReq FetchRequest
+	if err := c.ShouldBindJSON(&fetchReq); err != nil {
+		errors.BadRequest(c, "Invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	fetchReq.Format = strings.ToLower(strings.TrimSpace(fetchReq.Format))
+	if fetchReq.Format != "" && fetchReq.Format != FormatMarkdown && fetchReq.Format != FormatText {
+		errors.BadRequest(c, "Unsupported format. Currently supported: 'markdown', 'text'", nil)
+		return
+	}
+
+	result, err := h.service.Fetch(c.Request.Context(), fetchReq)
+	if err != nil {
+		log.Warn("page fetch failed",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID))
+
+		switch {
+		case stderrors.Is(err, ErrFetchBlocked), stderrors.Is(err, ErrUnsupportedContent):
+			errors.BadRequest(c, err.Error(), nil)
+		default:
+			errors.BadGateway(c, "Failed to fetch page", nil)
+		}
+		return
+	}
+
+	log.Info("page fetch completed",
+		slog.Int("content_length", len(result.Content)),
+		slog.Bool("truncated", result.Truncated),
+		slog.String("processing_time", result.ProcessingTime),
+		slog.String("user_id", userID))
+
+	c.JSON(http.StatusOK, result)
+}
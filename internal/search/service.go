@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,12 +17,28 @@ import (
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 )
 
+// Web search engines of POST /api/v1/search.
+const (
+	EngineDuckDuckGo = "duckduckgo" // Via SerpAPI (default)
+	EngineGoogle     = "google"     // Via SerpAPI
+	EngineBrave      = "brave"      // Brave Search API
+)
+
+// ErrEngineNotConfigured is returned when searching with an engine that has no API key.
+var ErrEngineNotConfigured = errors.New("search engine not configured")
+
 // Service handles search operations.
 type Service struct {
-	httpClient *http.Client
-	logger     *logger.Logger
-	serpAPIKey string
-	exaAPIKey  string
+	httpClient       *http.Client
+	fetchClient      *http.Client // Only connects to public addresses (page fetches)
+	logger           *logger.Logger
+	serpAPIKey       string
+	serpAPIGoogleKey string
+	braveAPIKey      string
+	exaAPIKey        string
+
+	serpAPIURL string
+	braveURL   string
 }
 
 // NewService creates a new search service.
@@ -29,16 +47,41 @@ func NewService(logger *logger.Logger) *Service {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		logger:     logger,
-		serpAPIKey: config.AppConfig.SerpAPIKey,
-		exaAPIKey:  config.AppConfig.ExaAPIKey,
+		fetchClient:      newFetchClient(),
+		logger:           logger,
+		serpAPIKey:       config.AppConfig.SerpAPIKey,
+		serpAPIGoogleKey: config.AppConfig.SerpAPIGoogleKey,
+		braveAPIKey:      config.AppConfig.BraveSearchAPIKey,
+		exaAPIKey:        config.AppConfig.ExaAPIKey,
+		serpAPIURL:       "https://serpapi.com/search.json",
+		braveURL:         "https://api.search.brave.com/res/v1/web/search",
 	}
 }
 
+// Engines returns the web search engines that have an API key configured.
+func (s *Service) Engines() []string {
+	engines := make([]string, 0, 3)
+	if s.serpAPIKey != "" {
+		engines = append(engines, EngineDuckDuckGo)
+	}
+	if s.serpAPIGoogleKey != "" {
+		engines = append(engines, EngineGoogle)
+	}
+	if s.braveAPIKey != "" {
+		engines = append(engines, EngineBrave)
+	}
+	return engines
+}
+
+// ExaConfigured reports whether Exa search has an API key.
+func (s *Service) ExaConfigured() bool {
+	return s.exaAPIKey != ""
+}
+
 // SearchRequest represents a search request from the client.
 type SearchRequest struct {
 	Query      string `json:"query" binding:"required"`
-	Engine     string `json:"engine,omitempty"`      // default: "duckduckgo"
+	Engine     string `json:"engine,omitempty"`      // "duckduckgo" (default), "google" or "brave"
 	TimeFilter string `json:"time_filter,omitempty"` // "d", "w", "m", "y"
 }
 
@@ -105,14 +148,17 @@ type ExaSearchMetadata struct {
 	ResponseTime string `json:"response_time"`
 }
 
-// SerpAPIDuckDuckGoResponse represents the raw SerpAPI DuckDuckGo response.
-type SerpAPIDuckDuckGoResponse struct {
+// SerpAPIResponse represents the raw SerpAPI response (DuckDuckGo and Google engines).
+type SerpAPIResponse struct {
 	OrganicResults []struct {
 		Position int    `json:"position"`
 		Title    string `json:"title"`
 		Link     string `json:"link"`
 		Snippet  string `json:"snippet"`
 	} `json:"organic_results"`
+	SearchInformation struct {
+		TotalResults int64 `json:"total_results"`
+	} `json:"search_information"` // Google only
 	RelatedSearches []struct {
 		Query string `json:"query"`
 	} `json:"related_searches"`
@@ -156,16 +202,42 @@ type ExaAPIResponse struct {
 	RequestID        string `json:"requestId,omitempty"`
 }
 
+// Search performs a web search with the engine of the request (DuckDuckGo by default).
+func (s *Service) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	switch req.Engine {
+	case "", EngineDuckDuckGo:
+		return s.SearchDuckDuckGo(ctx, req)
+	case EngineGoogle:
+		return s.SearchGoogle(ctx, req)
+	case EngineBrave:
+		return s.SearchBrave(ctx, req)
+	default:
+		return nil, fmt.Errorf("unsupported search engine %q", req.Engine)
+	}
+}
+
 // SearchDuckDuckGo performs a DuckDuckGo search via SerpAPI.
 func (s *Service) SearchDuckDuckGo(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	req.Engine = EngineDuckDuckGo
+	return s.searchSerpAPI(ctx, req, s.serpAPIKey)
+}
+
+// SearchGoogle performs a Google search via SerpAPI.
+func (s *Service) SearchGoogle(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	req.Engine = EngineGoogle
+	return s.searchSerpAPI(ctx, req, s.serpAPIGoogleKey)
+}
+
+// searchSerpAPI performs a search with the SerpAPI engine of the request.
+func (s *Service) searchSerpAPI(ctx context.Context, req SearchRequest, apiKey string) (*SearchResponse, error) {
 	start := time.Now()
 
-	if s.serpAPIKey == "" {
-		return nil, fmt.Errorf("SerpAPI key not configured")
+	if apiKey == "" {
+		return nil, fmt.Errorf("SerpAPI key for %s: %w", req.Engine, ErrEngineNotConfigured)
 	}
 
 	// Build SerpAPI request URL
-	apiURL, err := s.buildSerpAPIURL(req)
+	apiURL, err := s.buildSerpAPIURL(req, apiKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build API URL: %w", err)
 	}
@@ -194,7 +266,7 @@ func (s *Service) SearchDuckDuckGo(ctx context.Context, req SearchRequest) (*Sea
 	}
 
 	// Parse SerpAPI response
-	var serpResp SerpAPIDuckDuckGoResponse
+	var serpResp SerpAPIResponse
 	if err := json.Unmarshal(body, &serpResp); err != nil {
 		return nil, fmt.Errorf("failed to parse SerpAPI response: %w", err)
 	}
@@ -342,30 +414,37 @@ func (s *Service) SearchExa(ctx context.Context, req ExaSearchRequest) (*ExaSear
 	}, nil
 }
 
-// buildSerpAPIURL constructs the SerpAPI request URL.
-func (s *Service) buildSerpAPIURL(req SearchRequest) (string, error) {
-	baseURL := "https://serpapi.com/search.json"
-
+// buildSerpAPIURL constructs the SerpAPI request URL for the engine of the request.
+func (s *Service) buildSerpAPIURL(req SearchRequest, apiKey string) (string, error) {
 	params := url.Values{}
-	params.Set("api_key", s.serpAPIKey)
-	params.Set("engine", "duckduckgo")
+	params.Set("api_key", apiKey)
+	params.Set("engine", req.Engine)
 	params.Set("q", req.Query)
-
-	// Always use US English settings
-	params.Set("kl", "us-en")      // Language/locale: US English (covers region)
-	params.Set("safe", "-1")       // Safe search: moderate (-1=moderate, 1=strict, -2=off)
 	params.Set("no_cache", "true") // Zero trace: prevent caching for privacy
 
-	// Set time filter if provided
-	if req.TimeFilter != "" {
-		params.Set("time", req.TimeFilter)
+	// Always use US English settings
+	switch req.Engine {
+	case EngineDuckDuckGo:
+		params.Set("kl", "us-en") // Language/locale: US English (covers region)
+		params.Set("safe", "-1")  // Safe search: moderate (-1=moderate, 1=strict, -2=off)
+		if req.TimeFilter != "" {
+			params.Set("time", req.TimeFilter)
+		}
+	case EngineGoogle:
+		params.Set("hl", "en")
+		params.Set("gl", "us")
+		if req.TimeFilter != "" {
+			params.Set("tbs", "qdr:"+req.TimeFilter) // Past day/week/month/year
+		}
+	default:
+		return "", fmt.Errorf("unsupported SerpAPI engine %q", req.Engine)
 	}
 
-	return baseURL + "?" + params.Encode(), nil
+	return s.serpAPIURL + "?" + params.Encode(), nil
 }
 
 // convertSerpAPIResponse converts SerpAPI response to standardized format.
-func (s *Service) convertSerpAPIResponse(req SearchRequest, serpResp SerpAPIDuckDuckGoResponse, processingTime time.Duration) *SearchResponse {
+func (s *Service) convertSerpAPIResponse(req SearchRequest, serpResp SerpAPIResponse, processingTime time.Duration) *SearchResponse {
 	// Convert organic results
 	results := make([]SearchResult, 0, len(serpResp.OrganicResults))
 	for _, result := range serpResp.OrganicResults {
@@ -389,7 +468,12 @@ func (s *Service) convertSerpAPIResponse(req SearchRequest, serpResp SerpAPIDuck
 	// Build response
 	engine := req.Engine
 	if engine == "" {
-		engine = "duckduckgo"
+		engine = EngineDuckDuckGo
+	}
+
+	var totalResults string
+	if serpResp.SearchInformation.TotalResults > 0 {
+		totalResults = strconv.FormatInt(serpResp.SearchInformation.TotalResults, 10)
 	}
 
 	return &SearchResponse{
@@ -398,9 +482,10 @@ func (s *Service) convertSerpAPIResponse(req SearchRequest, serpResp SerpAPIDuck
 		OrganicResults: results,
 		RelatedQueries: relatedQueries,
 		SearchMetadata: SearchMetadata{
-			Engine:    engine,
-			Status:    serpResp.SearchMetadata.Status,
-			TimeTaken: fmt.Sprintf("%.2fs", serpResp.SearchMetadata.TotalTimeTaken),
+			TotalResults: totalResults,
+			Engine:       engine,
+			Status:       serpResp.SearchMetadata.Status,
+			TimeTaken:    fmt.Sprintf("%.2fs", serpResp.SearchMetadata.TotalTimeTaken),
 		},
 		ProcessingTime: fmt.Sprintf("%.2fms", float64(processingTime.Nanoseconds())/1000000),
 	}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestBuildSerpAPIURL(t *testing.T) {
+	s := &Service{serpAPIURL: "https://serpapi.com/search.json"}
+
+	tests := []struct {
+		engine string
+		want   map[string]string
+	}{
+		{engine: EngineDuckDuckGo, want: map[string]string{"engine": "duckduckgo", "kl": "us-en", "time": "w"}},
+		{engine: EngineGoogle, want: map[string]string{"engine": "google", "gl": "us", "tbs": "qdr:w"}},
+	}
+	for _, tt := range tests {
+		raw, err := s.buildSerpAPIURL(SearchRequest{Query: "go", Engine: tt.engine, TimeFilter: "w"}, "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for param, want := range tt.want {
+			if got := u.Query().Get(param); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.engine, param, got, want)
+			}
+		}
+	}
+
+	if _, err := s.buildSerpAPIURL(SearchRequest{Query: "go", Engine: EngineBrave}, "key"); err == nil {
+		t.Error("buildSerpAPIURL accepted a non-SerpAPI engine")
+	}
+}
+
+func TestSearchBrave(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Subscription-Token") != "brave-key" || r.URL.Query().Get("freshness") != "pd" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"web":{"results":[{"title":"Go","url":"https://go.dev/doc","description":"Docs","meta_url":{"hostname":"go.dev"}}]}}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	s := &Service{httpClient: server.Client(), braveAPIKey: "brave-key", braveURL: server.URL}
+	resp, err := s.Search(context.Background(), SearchRequest{Query: "go", Engine: EngineBrave, TimeFilter: "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := SearchResult{Position: 1, Title: "Go", Link: "https://go.dev/doc", Snippet: "Docs", Source: "go.dev"}
+	if resp.Engine != EngineBrave || len(resp.OrganicResults) != 1 || resp.OrganicResults[0] != want {
+		t.Errorf("response = %+v, want brave result %+v", resp, want)
+	}
+}
+
+func TestSearchEngineNotConfigured(t *testing.T) {
+	s := &Service{serpAPIKey: "serp-key"}
+	if got := s.Engines(); len(got) != 1 || got[0] != EngineDuckDuckGo {
+		t.Errorf("engines = %v, want [duckduckgo]", got)
+	}
+	for _, engine := range []string{EngineGoogle, EngineBrave} {
+		if _, err := s.Search(context.Background(), SearchRequest{Query: "go", Engine: engine}); !errors.Is(err, ErrEngineNotConfigured) {
+			t.Errorf("%s: err = %v, want ErrEngineNotConfigured", engine, err)
+		}
+	}
+}
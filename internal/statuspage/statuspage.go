@@ -0,0 +1,240 @@
+package statuspage
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// APIVersion is the version of the public client API (the /api/v1 routes).
+const APIVersion = "v1"
+
+const (
+	// cacheTTL is how long a built feed is served before the routing table is read again.
+	// Also sent as the Cache-Control max-age so CDNs and clients can cache the feed.
+	cacheTTL = 30 * time.Second
+
+	// limiterIdleTTL is how long a client IP's limiter is kept after its last request.
+	limiterIdleTTL = 10 * time.Minute
+)
+
+// Status values used for the overall feed, providers and incidents.
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusOutage      = "outage"
+)
+
+// Feed is the GET /status.json response.
+type Feed struct {
+	Status     string           `json:"status"`
+	APIVersion string           `json:"api_version"`
+	Build      string           `json:"build,omitempty"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	Providers  []ProviderHealth `json:"providers"`
+	Incidents  []Incident       `json:"incidents"`
+}
+
+// ProviderHealth summarizes the endpoints served by one provider.
+type ProviderHealth struct {
+	Name              string `json:"name"`
+	Status            string `json:"status"`
+	ActiveEndpoints   int    `json:"active_endpoints"`
+	InactiveEndpoints int    `json:"inactive_endpoints"`
+}
+
+// Incident is a model currently affected by a deactivated endpoint.
+type Incident struct {
+	Model     string   `json:"model"`
+	Status    string   `json:"status"`
+	Providers []string `json:"providers"`
+	Message   string   `json:"message"`
+}
+
+// Handler serves the public status feed. The feed is built from the routing table (where the
+// fallback service deactivates failing endpoints), cached for cacheTTL and rate limited per IP.
+type Handler struct {
+	router *routing.ModelRouter
+	build  string
+	limit  rate.Limit
+	burst  int
+
+	mu       sync.Mutex
+	feed     *Feed
+	builtAt  time.Time
+	limiters map[string]*clientLimiter
+	swept    time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewHandler creates a status feed handler allowing perMinute requests per client IP.
+// A non-positive perMinute disables rate limiting.
+func NewHandler(router *routing.ModelRouter, perMinute int) *Handler {
+	h := &Handler{
+		router:   router,
+		build:    buildRevision(),
+		limit:    rate.Inf,
+		limiters: make(map[string]*clientLimiter),
+	}
+	if perMinute > 0 {
+		h.limit = rate.Limit(float64(perMinute) / 60)
+		h.burst = perMinute
+	}
+	return h
+}
+
+// GetStatus handles GET /status.json.
+func (h *Handler) GetStatus(c *gin.Context) {
+	now := time.Now()
+	if !h.allow(c.ClientIP(), now) {
+		c.Header("Retry-After", "60")
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many status requests"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=30")
+	c.JSON(http.StatusOK, h.current(now))
+}
+
+// current returns the cached feed, rebuilding it once it is older than cacheTTL.
+func (h *Handler) current(now time.Time) *Feed {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.feed == nil || now.Sub(h.builtAt) >= cacheTTL {
+		h.feed = buildFeed(h.router.GetRoutes(), now)
+		h.feed.Build = h.build
+		h.builtAt = now
+	}
+	return h.feed
+}
+
+// allow reports whether a request from ip is within its rate limit, evicting idle limiters
+// at most once per limiterIdleTTL.
+func (h *Handler) allow(ip string, now time.Time) bool {
+	if h.limit == rate.Inf {
+		return true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if now.Sub(h.swept) >= limiterIdleTTL {
+		for key, l := range h.limiters {
+			if now.Sub(l.lastSeen) >= limiterIdleTTL {
+				delete(h.limiters, key)
+			}
+		}
+		h.swept = now
+	}
+
+	l, ok := h.limiters[ip]
+	if !ok {
+		l = &clientLimiter{limiter: rate.NewLimiter(h.limit, h.burst)}
+		h.limiters[ip] = l
+	}
+	l.lastSeen = now
+	return l.limiter.AllowN(now, 1)
+}
+
+// buildFeed summarizes provider health from the routing table. A model with inactive
+// endpoints is an incident: degraded while other endpoints still serve it, an outage otherwise.
+func buildFeed(routes map[string]routing.ModelRoute, now time.Time) *Feed {
+	feed := &Feed{
+		Status:     StatusOperational,
+		APIVersion: APIVersion,
+		UpdatedAt:  now.UTC(),
+		Providers:  []ProviderHealth{},
+		Incidents:  []Incident{},
+	}
+
+	models := make([]string, 0, len(routes))
+	for model := range routes {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	providers := make(map[string]*ProviderHealth)
+	provider := func(name string) *ProviderHealth {
+		p, ok := providers[name]
+		if !ok {
+			p = &ProviderHealth{Name: name}
+			providers[name] = p
+		}
+		return p
+	}
+
+	for _, model := range models {
+		route := routes[model]
+		for _, endpoint := range route.ActiveEndpoints {
+			provider(endpoint.Provider.Name).ActiveEndpoints++
+		}
+		if len(route.InactiveEndpoints) == 0 {
+			continue
+		}
+
+		incident := Incident{Model: model, Status: StatusDegraded, Message: "Some providers for this model are unavailable; requests are served by the remaining ones"}
+		if len(route.ActiveEndpoints) == 0 {
+			incident.Status = StatusOutage
+			incident.Message = "This model is currently unavailable"
+		}
+		seen := make(map[string]bool)
+		for _, endpoint := range route.InactiveEndpoints {
+			provider(endpoint.Provider.Name).InactiveEndpoints++
+			if !seen[endpoint.Provider.Name] {
+				seen[endpoint.Provider.Name] = true
+				incident.Providers = append(incident.Providers, endpoint.Provider.Name)
+			}
+		}
+		feed.Incidents = append(feed.Incidents, incident)
+		feed.Status = worse(feed.Status, incident.Status)
+	}
+
+	for _, p := range providers {
+		switch {
+		case p.ActiveEndpoints == 0:
+			p.Status = StatusOutage
+		case p.InactiveEndpoints > 0:
+			p.Status = StatusDegraded
+		default:
+			p.Status = StatusOperational
+		}
+		feed.Providers = append(feed.Providers, *p)
+	}
+	sort.Slice(feed.Providers, func(i, j int) bool { return feed.Providers[i].Name < feed.Providers[j].Name })
+
+	return feed
+}
+
+// worse returns the more severe of two statuses.
+func worse(a, b string) string {
+	rank := map[string]int{StatusOperational: 0, StatusDegraded: 1, StatusOutage: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// buildRevision returns the short VCS revision the binary was built from, if recorded.
+func buildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+			return setting.Value[:12]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package statuspage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	"github.com/gin-gonic/gin"
+)
+
+func endpoint(provider string) routing.ModelEndpoint {
+	return routing.ModelEndpoint{Provider: &routing.ProviderConfig{Name: provider}}
+}
+
+func TestBuildFeed(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		routes        map[string]routing.ModelRoute
+		wantStatus    string
+		wantProviders map[string]string
+		wantIncidents map[string]string
+	}{
+		{
+			name: "all active",
+			routes: map[string]routing.ModelRoute{
+				"gpt-4.1": {ActiveEndpoints: []routing.ModelEndpoint{endpoint("OpenAI"), endpoint("OpenRouter")}},
+			},
+			wantStatus:    StatusOperational,
+			wantProviders: map[string]string{"OpenAI": StatusOperational, "OpenRouter": StatusOperational},
+			wantIncidents: map[string]string{},
+		},
+		{
+			name: "failed over",
+			routes: map[string]routing.ModelRoute{
+				"gpt-4.1": {ActiveEndpoints: []routing.ModelEndpoint{endpoint("OpenRouter")}, InactiveEndpoints: []routing.ModelEndpoint{endpoint("OpenAI")}},
+				"gpt-5":   {ActiveEndpoints: []routing.ModelEndpoint{endpoint("OpenAI")}},
+			},
+			wantStatus:    StatusDegraded,
+			wantProviders: map[string]string{"OpenAI": StatusDegraded, "OpenRouter": StatusOperational},
+			wantIncidents: map[string]string{"gpt-4.1": StatusDegraded},
+		},
+		{
+			name: "no active endpoint",
+			routes: map[string]routing.ModelRoute{
+				"glm-4.6": {InactiveEndpoints: []routing.ModelEndpoint{endpoint("Tinfoil")}},
+				"gpt-4.1": {ActiveEndpoints: []routing.ModelEndpoint{endpoint("OpenAI")}},
+			},
+			wantStatus:    StatusOutage,
+			wantProviders: map[string]string{"OpenAI": StatusOperational, "Tinfoil": StatusOutage},
+			wantIncidents: map[string]string{"glm-4.6": StatusOutage},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := buildFeed(tt.routes, now)
+
+			if feed.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", feed.Status, tt.wantStatus)
+			}
+			if feed.APIVersion != APIVersion || !feed.UpdatedAt.Equal(now) {
+				t.Errorf("unexpected header fields: %+v", feed)
+			}
+			if len(feed.Providers) != len(tt.wantProviders) {
+				t.Fatalf("providers = %+v, want %v", feed.Providers, tt.wantProviders)
+			}
+			for _, p := range feed.Providers {
+				if p.Status != tt.wantProviders[p.Name] {
+					t.Errorf("provider %s status = %s, want %s", p.Name, p.Status, tt.wantProviders[p.Name])
+				}
+			}
+			if len(feed.Incidents) != len(tt.wantIncidents) {
+				t.Fatalf("incidents = %+v, want %v", feed.Incidents, tt.wantIncidents)
+			}
+			for _, incident := range feed.Incidents {
+				if incident.Status != tt.wantIncidents[incident.Model] {
+					t.Errorf("incident %s status = %s, want %s", incident.Model, incident.Status, tt.wantIncidents[incident.Model])
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(nil, 2)
+	h.feed = &Feed{Status: StatusOperational, APIVersion: APIVersion}
+	h.builtAt = time.Now()
+
+	router := gin.New()
+	router.GET("/status.json", h.GetStatus)
+
+	get := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/status.json", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		w := get("10.0.0.1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i, w.Code)
+		}
+		if w.Header().Get("Cache-Control") != "public, max-age=30" {
+			t.Errorf("missing Cache-Control header, got %q", w.Header().Get("Cache-Control"))
+		}
+	}
+
+	if w := get("10.0.0.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429 once the limit is used up", w.Code)
+	}
+	if w := get("10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("other clients should not be limited, got %d", w.Code)
+	}
+}
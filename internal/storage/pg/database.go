@@ -3,6 +3,7 @@ package pg
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/config"
@@ -15,17 +16,31 @@ type Database struct {
 	Queries *pgdb.Queries
 }
 
-// InitDatabase initializes the database connection and runs migrations.
-func InitDatabase(databaseURL string) (*Database, error) {
+// Open opens a connection pool for a DATABASE_URL: a Postgres URL, or sqlite:<path> for a
+// SQLite file (self-hosted deployments).
+func Open(databaseURL string) (*sql.DB, error) {
+	if IsSQLite(databaseURL) {
+		return openSQLite(strings.TrimPrefix(databaseURL, sqliteScheme)), nil
+	}
+
 	db, err := sql.Open("postgres", databaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
 	db.SetMaxOpenConns(config.AppConfig.DBMaxOpenConns)
 	db.SetMaxIdleConns(config.AppConfig.DBMaxIdleConns)
 	db.SetConnMaxIdleTime(time.Duration(config.AppConfig.DBConnMaxIdleTime) * time.Minute)
 	db.SetConnMaxLifetime(time.Duration(config.AppConfig.DBConnMaxLifetime) * time.Minute)
+	return db, nil
+}
+
+// InitDatabase initializes the database connection and runs migrations.
+func InitDatabase(databaseURL string) (*Database, error) {
+	db, err := Open(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
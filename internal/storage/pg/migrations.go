@@ -9,16 +9,51 @@ import (
 	"github.com/pressly/goose/v3"
 )
 
-//go:embed migrations/*.sql
+//go:embed migrations/*.sql migrations_sqlite/*.sql
 var embedMigrations embed.FS
 
+// migrationSource returns the goose dialect and migrations directory for the database.
+func migrationSource(db *sql.DB) (dialect, dir string) {
+	if _, ok := db.Driver().(sqliteDriver); ok {
+		return "sqlite3", "migrations_sqlite"
+	}
+	return "postgres", "migrations"
+}
+
 // RunMigrations runs all pending migrations automatically.
 func RunMigrations(db *sql.DB) error {
 	goose.SetBaseFS(embedMigrations)
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	dialect, dir := migrationSource(db)
+	if err := goose.SetDialect(dialect); err != nil {
 		return fmt.Errorf("failed to set goose dialect: %w", err)
 	}
 
-	return goose.Up(db, "migrations")
+	return goose.Up(db, dir)
+}
+
+// MigrationStatus returns the database's current migration version and the latest embedded
+// migration version, without applying anything.
+func MigrationStatus(db *sql.DB) (current, latest int64, err error) {
+	goose.SetBaseFS(embedMigrations)
+
+	dialect, dir := migrationSource(db)
+	if err := goose.SetDialect(dialect); err != nil {
+		return 0, 0, fmt.Errorf("failed to set goose dialect: %w", err)
+	}
+
+	migrations, err := goose.CollectMigrations(dir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to find latest migration: %w", err)
+	}
+
+	current, err = goose.GetDBVersion(db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get database version: %w", err)
+	}
+	return current, last.Version, nil
 }
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Record the Responses API reasoning effort applied to a request (after tier ceilings).
+-- NULL for requests that don't use reasoning effort (e.g., Chat Completions models).
+ALTER TABLE request_logs
+ADD COLUMN IF NOT EXISTS reasoning_effort TEXT DEFAULT NULL;
+
+COMMENT ON COLUMN request_logs.reasoning_effort IS 'Applied reasoning effort (minimal/low/medium/high) for cost analysis';
+
+-- +goose Down
+ALTER TABLE request_logs
+DROP COLUMN IF EXISTS reasoning_effort;
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Weekly digest opt-in. next_digest_at is advanced when a digest is claimed, so
+-- multiple proxy instances never generate the same user's digest twice.
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    user_id         TEXT PRIMARY KEY,
+    enabled         BOOLEAN     NOT NULL DEFAULT TRUE,
+    email           TEXT,  -- Optional: also deliver by email
+    next_digest_at  TIMESTAMPTZ NOT NULL DEFAULT NOW() + INTERVAL '7 days',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_due
+ON digest_subscriptions (next_digest_at)
+WHERE enabled = TRUE;
+
+-- Generated digests ("your week with Enchanted"), readable in-app.
+CREATE TABLE IF NOT EXISTS user_digests (
+    id                       BIGSERIAL PRIMARY KEY,
+    user_id                  TEXT        NOT NULL,
+    period_start             TIMESTAMPTZ NOT NULL,
+    period_end               TIMESTAMPTZ NOT NULL,
+    request_count            BIGINT      NOT NULL DEFAULT 0,
+    plan_tokens              BIGINT      NOT NULL DEFAULT 0,
+    top_model                TEXT,
+    deep_research_completed  BIGINT      NOT NULL DEFAULT 0,
+    tasks_created            BIGINT      NOT NULL DEFAULT 0,
+    active_tasks             BIGINT      NOT NULL DEFAULT 0,
+    created_at               TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_digests_user_created
+ON user_digests (user_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_digests_user_created;
+DROP TABLE IF EXISTS user_digests;
+DROP INDEX IF EXISTS idx_digest_subscriptions_due;
+DROP TABLE IF EXISTS digest_subscriptions;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- One-time challenges for device attestation. Bound to the requesting user and
+-- deleted when consumed, so a captured attestation can't be replayed.
+CREATE TABLE IF NOT EXISTS attestation_challenges (
+    challenge   TEXT PRIMARY KEY,
+    user_id     TEXT        NOT NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attestation_challenges_expires_at
+ON attestation_challenges (expires_at);
+
+-- iOS App Attest keys. Registered once per install via a full attestation;
+-- later requests are verified with assertions signed by the stored public key.
+CREATE TABLE IF NOT EXISTS app_attest_keys (
+    key_id       TEXT PRIMARY KEY,              -- Base64 key identifier from DCAppAttestService
+    user_id      TEXT        NOT NULL,
+    public_key   BYTEA       NOT NULL,          -- PKIX DER from the attested credential certificate
+    sign_count   BIGINT      NOT NULL DEFAULT 0, -- Last seen assertion counter (must strictly increase)
+    environment  TEXT        NOT NULL,          -- 'production' or 'development'
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_app_attest_keys_user_id
+ON app_attest_keys (user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_app_attest_keys_user_id;
+DROP TABLE IF EXISTS app_attest_keys;
+DROP INDEX IF EXISTS idx_attestation_challenges_expires_at;
+DROP TABLE IF EXISTS attestation_challenges;
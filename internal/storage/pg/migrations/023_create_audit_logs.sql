@@ -0,0 +1,26 @@
+-- +goose Up
+-- Audit trail of policy enforcement decisions (e.g., country-based compliance blocks).
+-- Intentionally stores no IP addresses or request content.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     TEXT        NOT NULL,
+    event       TEXT        NOT NULL, -- e.g., 'compliance.country_blocked'
+    decision    TEXT        NOT NULL, -- 'allow' or 'deny'
+    country     TEXT,                 -- ISO 3166-1 alpha-2
+    rule        TEXT,                 -- Policy rule that produced the decision
+    provider    TEXT,
+    model       TEXT,
+    path        TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_created
+ON audit_logs (user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_event_created
+ON audit_logs (event, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_logs_event_created;
+DROP INDEX IF EXISTS idx_audit_logs_user_created;
+DROP TABLE IF EXISTS audit_logs;
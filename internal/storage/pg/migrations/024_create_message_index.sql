@@ -0,0 +1,31 @@
+-- +goose Up
+-- Minimal metadata for messages stored in Firestore (never content), so analytics,
+-- retention, and export can query Postgres instead of scanning Firestore collections.
+CREATE TABLE IF NOT EXISTS message_index (
+    user_id                 TEXT        NOT NULL,
+    chat_id                 TEXT        NOT NULL,
+    message_id              TEXT        NOT NULL,
+    is_from_user            BOOLEAN     NOT NULL,
+    is_error                BOOLEAN     NOT NULL DEFAULT FALSE,
+    stopped                 BOOLEAN     NOT NULL DEFAULT FALSE,
+    model                   TEXT,
+    generation_state        TEXT,
+    prompt_tokens           INTEGER,
+    completion_tokens       INTEGER,
+    message_timestamp       TIMESTAMPTZ NOT NULL, -- Firestore message timestamp
+    generation_completed_at TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, chat_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_index_user_timestamp
+ON message_index (user_id, message_timestamp DESC);
+
+CREATE INDEX IF NOT EXISTS idx_message_index_timestamp
+ON message_index (message_timestamp);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_message_index_timestamp;
+DROP INDEX IF EXISTS idx_message_index_user_timestamp;
+DROP TABLE IF EXISTS message_index;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Per-user defaults applied by the proxy when a chat completion request omits them,
+-- so thin clients (Telegram, email, browser extension) don't need model selection UI.
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id       TEXT             PRIMARY KEY,
+    default_model TEXT,
+    temperature   DOUBLE PRECISION,
+    system_prompt TEXT,
+    created_at    TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_preferences;
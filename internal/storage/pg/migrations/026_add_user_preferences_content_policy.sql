@@ -0,0 +1,8 @@
+-- +goose Up
+-- Account-level content policy: "standard" or "family" (safety instructions + stricter moderation).
+ALTER TABLE user_preferences
+ADD COLUMN IF NOT EXISTS content_policy TEXT NOT NULL DEFAULT 'standard';
+
+-- +goose Down
+ALTER TABLE user_preferences
+DROP COLUMN IF EXISTS content_policy;
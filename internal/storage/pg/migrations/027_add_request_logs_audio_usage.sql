@@ -0,0 +1,16 @@
+-- +goose Up
+-- Audio usage for /audio/* and voice requests: seconds transcribed or synthesized (speech is
+-- estimated from input length) and characters synthesized. Used for tier audio quotas.
+ALTER TABLE request_logs
+ADD COLUMN IF NOT EXISTS audio_seconds DOUBLE PRECISION,
+ADD COLUMN IF NOT EXISTS audio_characters INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_request_logs_audio_seconds
+ON request_logs (user_id, created_at)
+WHERE audio_seconds IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_request_logs_audio_seconds;
+ALTER TABLE request_logs
+DROP COLUMN IF EXISTS audio_characters,
+DROP COLUMN IF EXISTS audio_seconds;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Comma-separated anomalies detected in a completed stream (missing_done, empty_content,
+-- abnormal_finish, garbled). NULL for healthy responses.
+ALTER TABLE request_logs
+ADD COLUMN IF NOT EXISTS stream_anomalies TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_request_logs_stream_anomalies
+ON request_logs (created_at)
+WHERE stream_anomalies IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_request_logs_stream_anomalies;
+ALTER TABLE request_logs
+DROP COLUMN IF EXISTS stream_anomalies;
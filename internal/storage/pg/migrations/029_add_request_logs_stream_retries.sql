@@ -0,0 +1,8 @@
+-- +goose Up
+-- Number of times a stream that ended without output was retried before reaching the client.
+ALTER TABLE request_logs
+ADD COLUMN IF NOT EXISTS stream_retries INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE request_logs
+DROP COLUMN IF EXISTS stream_retries;
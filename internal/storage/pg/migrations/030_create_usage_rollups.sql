@@ -0,0 +1,41 @@
+-- +goose Up
+-- Nightly usage rollups per UTC day, so dashboards read a few rows instead of scanning
+-- request_logs. Rows are recomputed idempotently; daily_usage_rollups is written last and
+-- marks a day as complete.
+CREATE TABLE IF NOT EXISTS daily_usage_rollups (
+    day                  DATE        PRIMARY KEY,
+    active_users         BIGINT      NOT NULL DEFAULT 0,
+    weekly_active_users  BIGINT      NOT NULL DEFAULT 0,  -- Distinct users over the 7 days ending on day
+    requests             BIGINT      NOT NULL DEFAULT 0,
+    total_tokens         BIGINT      NOT NULL DEFAULT 0,
+    plan_tokens          BIGINT      NOT NULL DEFAULT 0,
+    deep_research_users  BIGINT      NOT NULL DEFAULT 0,
+    deep_research_runs   BIGINT      NOT NULL DEFAULT 0,
+    computed_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS daily_tier_usage_rollups (
+    day           DATE        NOT NULL,
+    tier          TEXT        NOT NULL,
+    active_users  BIGINT      NOT NULL DEFAULT 0,
+    requests      BIGINT      NOT NULL DEFAULT 0,
+    total_tokens  BIGINT      NOT NULL DEFAULT 0,
+    plan_tokens   BIGINT      NOT NULL DEFAULT 0,
+    computed_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (day, tier)
+);
+
+CREATE TABLE IF NOT EXISTS daily_provider_usage_rollups (
+    day           DATE        NOT NULL,
+    provider      TEXT        NOT NULL,
+    requests      BIGINT      NOT NULL DEFAULT 0,
+    total_tokens  BIGINT      NOT NULL DEFAULT 0,
+    plan_tokens   BIGINT      NOT NULL DEFAULT 0,
+    computed_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (day, provider)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS daily_provider_usage_rollups;
+DROP TABLE IF EXISTS daily_tier_usage_rollups;
+DROP TABLE IF EXISTS daily_usage_rollups;
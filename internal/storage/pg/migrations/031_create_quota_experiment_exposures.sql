@@ -0,0 +1,18 @@
+-- +goose Up
+-- First time a user's quota was evaluated under a quota experiment (see quota_experiments in
+-- config.yaml). Join with request_logs / deep_research_runs to compare treatment and control.
+CREATE TABLE IF NOT EXISTS quota_experiment_exposures (
+    experiment        TEXT        NOT NULL,
+    user_id           TEXT        NOT NULL,
+    variant           TEXT        NOT NULL,  -- 'treatment' or 'control'
+    tier              TEXT        NOT NULL,
+    first_exposed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (experiment, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quota_experiment_exposures_variant
+ON quota_experiment_exposures (experiment, variant);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_quota_experiment_exposures_variant;
+DROP TABLE IF EXISTS quota_experiment_exposures;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Whether the request was served in privacy mode (zero-retention providers, no server-side storage).
+ALTER TABLE request_logs
+ADD COLUMN IF NOT EXISTS privacy_mode BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE request_logs
+DROP COLUMN IF EXISTS privacy_mode;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Upstream base URLs the model router may send requests to, administered via the admin API
+-- (see internal/upstreams). api_key_env_var names the environment variable holding the key;
+-- empty keeps the key configured for the provider in config.yaml.
+CREATE TABLE IF NOT EXISTS upstream_providers (
+    id              BIGSERIAL   PRIMARY KEY,
+    base_url        TEXT        NOT NULL UNIQUE,
+    api_key_env_var TEXT        NOT NULL DEFAULT '',
+    enabled         BOOLEAN     NOT NULL DEFAULT TRUE,
+    description     TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- The base URLs previously hardcoded in cmd/server
+INSERT INTO upstream_providers (base_url, api_key_env_var, description) VALUES
+    ('https://openrouter.ai/api/v1', '', 'OpenRouter (keys resolved per platform)'),
+    ('https://api.openai.com/v1', 'OPENAI_API_KEY', 'OpenAI'),
+    ('https://inference.tinfoil.sh/v1', 'TINFOIL_API_KEY', 'Tinfoil'),
+    ('https://cloud-api.near.ai/v1', 'NEAR_API_KEY', 'NEAR AI'),
+    ('http://127.0.0.1:20001/v1', 'ETERNIS_INFERENCE_API_KEY', 'Eternis inference'),
+    ('http://34.30.193.13:8000/v1', '', 'Self-hosted Venice (GCP)')
+ON CONFLICT (base_url) DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS upstream_providers;
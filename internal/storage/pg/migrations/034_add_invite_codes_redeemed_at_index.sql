@@ -0,0 +1,9 @@
+-- +goose Up
+-- Index for GetInviteFunnel (GET /admin/v1/invites/analytics)
+-- Optimizes: WHERE deleted_at IS NULL AND redeemed_at >= $from AND redeemed_at < $to
+CREATE INDEX IF NOT EXISTS idx_invite_codes_redeemed_at
+ON invite_codes (redeemed_at)
+WHERE deleted_at IS NULL AND redeemed_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_invite_codes_redeemed_at;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Opt-in chat search index: SHA-256 digests of the keyword tokens clients upload per message
+-- (blinded by E2EE clients, plaintext keywords otherwise). Never message content.
+CREATE TABLE IF NOT EXISTS chat_search_tokens (
+    user_id    TEXT        NOT NULL,
+    chat_id    TEXT        NOT NULL,
+    message_id TEXT        NOT NULL,
+    token_hash BYTEA       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, token_hash, chat_id, message_id)
+);
+
+-- Replacing a message's tokens and removing a chat from the index
+CREATE INDEX IF NOT EXISTS idx_chat_search_tokens_message
+ON chat_search_tokens (user_id, chat_id, message_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chat_search_tokens_message;
+DROP TABLE IF EXISTS chat_search_tokens;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Thumbs up/down ratings of assistant messages, one per user and message, aggregated per model
+-- into the quality scoreboard. The model is reported by the client with the rating.
+CREATE TABLE IF NOT EXISTS message_feedback (
+    user_id    TEXT        NOT NULL,
+    chat_id    TEXT        NOT NULL,
+    message_id TEXT        NOT NULL,
+    model      TEXT        NOT NULL,
+    rating     SMALLINT    NOT NULL CHECK (rating IN (-1, 1)),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, chat_id, message_id)
+);
+
+-- Scoreboard windows
+CREATE INDEX IF NOT EXISTS idx_message_feedback_updated_at
+ON message_feedback (updated_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_message_feedback_updated_at;
+DROP TABLE IF EXISTS message_feedback;
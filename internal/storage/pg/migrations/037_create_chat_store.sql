@@ -0,0 +1,68 @@
+-- +goose Up
+-- Chats, messages and drafts for deployments that store messages in the database instead of
+-- Firestore (MESSAGE_STORAGE_BACKEND=database, see internal/messagestore). Mirrors the
+-- Firestore documents under /users/{userId}/chats/{chatId}. Content is stored as the message
+-- service wrote it: encrypted with the user's key, or plaintext when public_encryption_key
+-- is 'none'.
+CREATE TABLE IF NOT EXISTS chats (
+    user_id                     TEXT        NOT NULL,
+    chat_id                     TEXT        NOT NULL,
+    title                       TEXT,
+    encrypted_title             TEXT,
+    title_public_encryption_key TEXT,
+    language                    TEXT,
+    last_response_id            TEXT,        -- Responses API conversation state
+    pinned                      BOOLEAN     NOT NULL DEFAULT FALSE,
+    pinned_at                   TIMESTAMPTZ,
+    archived                    BOOLEAN     NOT NULL DEFAULT FALSE,
+    muted                       BOOLEAN     NOT NULL DEFAULT FALSE,
+    metadata_updated_at         TIMESTAMPTZ,
+    last_message_at             TIMESTAMPTZ,
+    created_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, chat_id)
+);
+
+CREATE TABLE IF NOT EXISTS chat_messages (
+    user_id                   TEXT        NOT NULL,
+    chat_id                   TEXT        NOT NULL,
+    message_id                TEXT        NOT NULL,
+    encrypted_content         TEXT        NOT NULL,
+    public_encryption_key     TEXT        NOT NULL,
+    is_from_user              BOOLEAN     NOT NULL,
+    is_error                  BOOLEAN     NOT NULL DEFAULT FALSE,
+    message_timestamp         TIMESTAMPTZ NOT NULL,
+    stopped                   BOOLEAN     NOT NULL DEFAULT FALSE,
+    stopped_by                TEXT        NOT NULL DEFAULT '',
+    stop_reason               TEXT        NOT NULL DEFAULT '',
+    model                     TEXT        NOT NULL DEFAULT '',
+    generation_state          TEXT        NOT NULL DEFAULT '',
+    generation_started_at     TIMESTAMPTZ,
+    generation_completed_at   TIMESTAMPTZ,
+    generation_error          TEXT        NOT NULL DEFAULT '',
+    encrypted_masked_keywords TEXT        NOT NULL DEFAULT '',
+    encrypted_reasoning       TEXT        NOT NULL DEFAULT '',
+    delivered_at              TIMESTAMPTZ,
+    read_at                   TIMESTAMPTZ,
+    created_at                TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, chat_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_chat_timestamp
+ON chat_messages (user_id, chat_id, message_timestamp);
+
+CREATE TABLE IF NOT EXISTS chat_drafts (
+    user_id               TEXT        NOT NULL,
+    chat_id               TEXT        NOT NULL,
+    encrypted_content     TEXT        NOT NULL,
+    public_encryption_key TEXT        NOT NULL,
+    updated_at            TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, chat_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS chat_drafts;
+DROP INDEX IF EXISTS idx_chat_messages_chat_timestamp;
+DROP TABLE IF EXISTS chat_messages;
+DROP TABLE IF EXISTS chats;
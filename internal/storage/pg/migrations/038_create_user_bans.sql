@@ -0,0 +1,11 @@
+-- +goose Up
+-- Users banned via the admin API. Banned users are rejected on every authenticated route;
+-- unbanning deletes the row.
+CREATE TABLE IF NOT EXISTS user_bans (
+    user_id   TEXT        PRIMARY KEY,
+    reason    TEXT        NOT NULL DEFAULT '',
+    banned_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_bans;
@@ -0,0 +1,50 @@
+-- +goose Up
+-- Renewal state reported by the subscription provider (Stripe, Google Play). auto_renew is NULL
+-- when the provider doesn't report it; entitlement upserts reset both columns.
+ALTER TABLE entitlements ADD COLUMN IF NOT EXISTS auto_renew BOOLEAN;
+ALTER TABLE entitlements ADD COLUMN IF NOT EXISTS in_grace_period BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- History of entitlement changes for the subscription status endpoint. Recorded by trigger so
+-- every writer (IAP, Stripe, crypto payments, admin grants) is covered.
+CREATE TABLE IF NOT EXISTS entitlement_events (
+    id                      BIGSERIAL   PRIMARY KEY,
+    user_id                 TEXT        NOT NULL,
+    subscription_tier       TEXT        NOT NULL,
+    subscription_provider   TEXT        NOT NULL,
+    subscription_expires_at TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_entitlement_events_user_created
+ON entitlement_events (user_id, created_at DESC);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_entitlement_event() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO entitlement_events (user_id, subscription_tier, subscription_provider, subscription_expires_at)
+    VALUES (NEW.user_id, NEW.subscription_tier, NEW.subscription_provider, NEW.subscription_expires_at);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER entitlements_insert_event
+AFTER INSERT ON entitlements
+FOR EACH ROW EXECUTE FUNCTION record_entitlement_event();
+
+-- Renewals that don't change the entitlement (same tier, provider and expiry) are not recorded
+CREATE TRIGGER entitlements_update_event
+AFTER UPDATE ON entitlements
+FOR EACH ROW
+WHEN (OLD.subscription_tier IS DISTINCT FROM NEW.subscription_tier
+   OR OLD.subscription_provider IS DISTINCT FROM NEW.subscription_provider
+   OR OLD.subscription_expires_at IS DISTINCT FROM NEW.subscription_expires_at)
+EXECUTE FUNCTION record_entitlement_event();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS entitlements_update_event ON entitlements;
+DROP TRIGGER IF EXISTS entitlements_insert_event ON entitlements;
+DROP FUNCTION IF EXISTS record_entitlement_event();
+DROP TABLE IF EXISTS entitlement_events;
+ALTER TABLE entitlements DROP COLUMN IF EXISTS in_grace_period;
+ALTER TABLE entitlements DROP COLUMN IF EXISTS auto_renew;
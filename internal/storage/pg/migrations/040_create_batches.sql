@@ -0,0 +1,31 @@
+-- +goose Up
+-- OpenAI files and batches created through the /files and /batches pass-through, by owner.
+-- Batches run on the server's OpenAI account, so every file and batch ID is checked against
+-- these tables before it is forwarded. Output and error files are recorded when a batch
+-- finishes.
+CREATE TABLE IF NOT EXISTS batch_files (
+    file_id    TEXT        PRIMARY KEY,
+    user_id    TEXT        NOT NULL,
+    purpose    TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- object is the last batch object returned by OpenAI (JSON), served by the list endpoint.
+-- usage_recorded_at is set once the usage of the batch results is logged as plan tokens.
+CREATE TABLE IF NOT EXISTS batches (
+    batch_id          TEXT        PRIMARY KEY,
+    user_id           TEXT        NOT NULL,
+    endpoint          TEXT        NOT NULL,
+    status            TEXT        NOT NULL,
+    object            TEXT        NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    usage_recorded_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_batches_user_created ON batches (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_batches_usage_pending ON batches (created_at) WHERE usage_recorded_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS batches;
+DROP TABLE IF EXISTS batch_files;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Provider and model routing entries administered via the admin API (see internal/routingconfig),
+-- applied on top of model_router in config.yaml so models can be added without a deploy.
+-- config holds one providers or models entry in the config.yaml format (YAML or JSON); an entry
+-- replaces the configured one of the same name, a disabled entry removes it.
+CREATE TABLE IF NOT EXISTS routing_entries (
+    kind        TEXT        NOT NULL CHECK (kind IN ('provider', 'model')),
+    name        TEXT        NOT NULL,
+    config      TEXT        NOT NULL,
+    enabled     BOOLEAN     NOT NULL DEFAULT TRUE,
+    description TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (kind, name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS routing_entries;
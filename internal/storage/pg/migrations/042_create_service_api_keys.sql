@@ -0,0 +1,20 @@
+-- +goose Up
+-- API keys of internal services and partners calling the proxy without a Firebase account
+-- (see auth.APIKeyValidator). Only the SHA-256 hash of a key is stored; key_prefix identifies
+-- it in listings. scopes is a comma-separated list of proxy, search and admin. Requests act as
+-- user_id, whose tier and rate limits apply.
+CREATE TABLE IF NOT EXISTS service_api_keys (
+    id          BIGSERIAL   PRIMARY KEY,
+    name        TEXT        NOT NULL UNIQUE,
+    key_hash    TEXT        NOT NULL UNIQUE,
+    key_prefix  TEXT        NOT NULL,
+    scopes      TEXT        NOT NULL,
+    user_id     TEXT        NOT NULL,
+    description TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS service_api_keys;
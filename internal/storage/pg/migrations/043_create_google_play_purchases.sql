@@ -0,0 +1,14 @@
+-- +goose Up
+-- Google Play purchase tokens and the user they were first attached to. A token attached by
+-- another user is rejected, so one purchase can't entitle several accounts.
+CREATE TABLE IF NOT EXISTS google_play_purchases (
+    purchase_token TEXT        PRIMARY KEY,
+    user_id        TEXT        NOT NULL,
+    product_id     TEXT        NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_google_play_purchases_user ON google_play_purchases (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS google_play_purchases;
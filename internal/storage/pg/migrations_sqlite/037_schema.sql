@@ -0,0 +1,446 @@
+-- +goose Up
+-- SQLite schema for self-hosted deployments (DATABASE_URL=sqlite:<path>): the schema of
+-- migrations/001-037, consolidated. Versions follow the Postgres migrations, so a change to
+-- the schema needs a migration in both directories with the same number.
+--
+-- Timestamps are UTC text in the format of strftime('%Y-%m-%d %H:%M:%f') (see sqlite.go).
+
+CREATE TABLE invite_codes (
+    id          INTEGER   PRIMARY KEY,
+    code        TEXT      NOT NULL,
+    code_hash   TEXT      NOT NULL UNIQUE,
+    bound_email TEXT,
+    created_by  BIGINT    NOT NULL,
+    is_used     BOOLEAN   NOT NULL DEFAULT FALSE,
+    redeemed_by TEXT,
+    redeemed_at TIMESTAMP,
+    expires_at  TIMESTAMP,
+    is_active   BOOLEAN   NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    deleted_at  TIMESTAMP
+);
+
+CREATE INDEX idx_invite_codes_redeemed_by ON invite_codes (redeemed_by);
+CREATE INDEX idx_invite_codes_redeemed_at ON invite_codes (redeemed_at)
+WHERE deleted_at IS NULL AND redeemed_at IS NOT NULL;
+
+CREATE TABLE request_logs (
+    id                INTEGER   PRIMARY KEY,
+    user_id           TEXT      NOT NULL,
+    endpoint          TEXT      NOT NULL,
+    model             TEXT,
+    provider          TEXT      NOT NULL,
+    created_at        TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    prompt_tokens     INTEGER,
+    completion_tokens INTEGER,
+    total_tokens      INTEGER,
+    plan_tokens       INTEGER,
+    token_multiplier  TEXT,   -- NUMERIC(8,2) in Postgres, read as a string
+    reasoning_effort  TEXT,
+    audio_seconds     DOUBLE PRECISION,
+    audio_characters  INTEGER,
+    stream_anomalies  TEXT,
+    stream_retries    INTEGER   NOT NULL DEFAULT 0,
+    privacy_mode      BOOLEAN   NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX idx_request_logs_user_created ON request_logs (user_id, created_at);
+CREATE INDEX idx_request_logs_created_at ON request_logs (created_at);
+CREATE INDEX idx_request_logs_fallback_tokens ON request_logs (user_id, model, created_at, plan_tokens)
+WHERE plan_tokens IS NOT NULL;
+
+CREATE TABLE telegram_chats (
+    id         INTEGER   PRIMARY KEY,
+    chat_id    BIGINT    NOT NULL UNIQUE,
+    chat_uuid  TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_telegram_chats_chat_uuid ON telegram_chats (chat_uuid);
+
+CREATE TABLE entitlements (
+    user_id                 TEXT      PRIMARY KEY,
+    subscription_expires_at TIMESTAMP,
+    updated_at              TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    subscription_provider   TEXT      NOT NULL DEFAULT 'apple',
+    stripe_customer_id      TEXT,
+    subscription_tier       TEXT      NOT NULL DEFAULT 'free'
+);
+
+CREATE TABLE deep_research_messages (
+    id           TEXT      PRIMARY KEY,
+    user_id      TEXT      NOT NULL,
+    chat_id      TEXT      NOT NULL,
+    session_id   TEXT      NOT NULL,
+    message      TEXT      NOT NULL,
+    message_type TEXT      NOT NULL,
+    sent         BOOLEAN   NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    sent_at      TIMESTAMP
+);
+
+CREATE INDEX idx_deep_research_messages_sent ON deep_research_messages (session_id, sent);
+CREATE INDEX idx_deep_research_messages_created_at ON deep_research_messages (session_id, created_at);
+
+CREATE TABLE tasks (
+    task_id    TEXT      PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    chat_id    TEXT      NOT NULL,
+    task_name  TEXT      NOT NULL,
+    task_text  TEXT      NOT NULL,
+    type       TEXT      NOT NULL,
+    time       TEXT      NOT NULL,
+    status     TEXT      NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_tasks_user_id ON tasks (user_id);
+CREATE INDEX idx_tasks_chat_id ON tasks (chat_id);
+CREATE INDEX idx_tasks_status ON tasks (status);
+
+CREATE TABLE deep_research_runs (
+    id                INTEGER   PRIMARY KEY,
+    user_id           TEXT      NOT NULL,
+    chat_id           TEXT      NOT NULL,
+    run_date          DATE      NOT NULL,
+    model_tokens_used INTEGER   NOT NULL DEFAULT 0,
+    plan_tokens_used  INTEGER   NOT NULL DEFAULT 0,
+    status            TEXT      NOT NULL,
+    started_at        TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    completed_at      TIMESTAMP
+);
+
+CREATE INDEX idx_deep_research_runs_user_date ON deep_research_runs (user_id, run_date);
+CREATE INDEX idx_deep_research_runs_user_lifetime ON deep_research_runs (user_id, started_at);
+
+CREATE TABLE problem_reports (
+    id                       TEXT      PRIMARY KEY,
+    user_id                  TEXT      NOT NULL,
+    problem_description      TEXT      NOT NULL,
+    device_model             TEXT,
+    device_name              TEXT,
+    system_name              TEXT,
+    system_version           TEXT,
+    app_version              TEXT,
+    build_number             TEXT,
+    locale                   TEXT,
+    timezone                 TEXT,
+    total_capacity_bytes     BIGINT,
+    available_capacity_bytes BIGINT,
+    used_capacity_bytes      BIGINT,
+    subscription_tier        TEXT,
+    contact_email            TEXT,
+    ticket_id                TEXT,
+    created_at               TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at               TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_problem_reports_user_id ON problem_reports (user_id);
+
+CREATE TABLE zcash_invoices (
+    id                TEXT             PRIMARY KEY,  -- UUID
+    user_id           TEXT             NOT NULL,
+    product_id        TEXT             NOT NULL,
+    amount_zatoshis   BIGINT           NOT NULL,
+    zec_amount        DOUBLE PRECISION NOT NULL,
+    price_usd         DOUBLE PRECISION NOT NULL,
+    receiving_address TEXT             NOT NULL,
+    status            TEXT             NOT NULL DEFAULT 'pending',
+    created_at        TIMESTAMP        NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at        TIMESTAMP        NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    paid_at           TIMESTAMP
+);
+
+CREATE INDEX idx_zcash_invoices_user_status ON zcash_invoices (user_id, status);
+CREATE INDEX idx_zcash_invoices_status_created ON zcash_invoices (status, created_at);
+
+CREATE TABLE fai_payment_intents (
+    id            TEXT             PRIMARY KEY,
+    user_id       TEXT             NOT NULL,
+    payment_id    TEXT             NOT NULL UNIQUE,
+    product_id    TEXT             NOT NULL,
+    token_address TEXT,
+    token_amount  DOUBLE PRECISION,
+    price_usd     DOUBLE PRECISION NOT NULL,
+    fai_price     DOUBLE PRECISION,
+    status        TEXT             NOT NULL DEFAULT 'pending'
+                  CHECK (status IN ('pending', 'completed', 'expired')),
+    paid_block    BIGINT           DEFAULT 0,
+    tx_hash       TEXT,
+    created_at    TIMESTAMP        NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at    TIMESTAMP        NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    paid_at       TIMESTAMP
+);
+
+CREATE INDEX idx_fai_payment_intents_user_id ON fai_payment_intents (user_id);
+CREATE INDEX idx_fai_payment_intents_status_created ON fai_payment_intents (status, created_at);
+
+CREATE TABLE digest_subscriptions (
+    user_id        TEXT      PRIMARY KEY,
+    enabled        BOOLEAN   NOT NULL DEFAULT TRUE,
+    email          TEXT,
+    next_digest_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now', '+7 days')),
+    created_at     TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at     TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_digest_subscriptions_due ON digest_subscriptions (next_digest_at)
+WHERE enabled = TRUE;
+
+CREATE TABLE user_digests (
+    id                      INTEGER   PRIMARY KEY,
+    user_id                 TEXT      NOT NULL,
+    period_start            TIMESTAMP NOT NULL,
+    period_end              TIMESTAMP NOT NULL,
+    request_count           BIGINT    NOT NULL DEFAULT 0,
+    plan_tokens             BIGINT    NOT NULL DEFAULT 0,
+    top_model               TEXT,
+    deep_research_completed BIGINT    NOT NULL DEFAULT 0,
+    tasks_created           BIGINT    NOT NULL DEFAULT 0,
+    active_tasks            BIGINT    NOT NULL DEFAULT 0,
+    created_at              TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_user_digests_user_created ON user_digests (user_id, created_at DESC);
+
+CREATE TABLE attestation_challenges (
+    challenge  TEXT      PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_attestation_challenges_expires_at ON attestation_challenges (expires_at);
+
+CREATE TABLE app_attest_keys (
+    key_id      TEXT      PRIMARY KEY,
+    user_id     TEXT      NOT NULL,
+    public_key  BLOB      NOT NULL,
+    sign_count  BIGINT    NOT NULL DEFAULT 0,
+    environment TEXT      NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_app_attest_keys_user_id ON app_attest_keys (user_id);
+
+CREATE TABLE audit_logs (
+    id         INTEGER   PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    event      TEXT      NOT NULL,
+    decision   TEXT      NOT NULL,
+    country    TEXT,
+    rule       TEXT,
+    provider   TEXT,
+    model      TEXT,
+    path       TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_audit_logs_user_created ON audit_logs (user_id, created_at DESC);
+CREATE INDEX idx_audit_logs_event_created ON audit_logs (event, created_at DESC);
+
+CREATE TABLE message_index (
+    user_id                 TEXT      NOT NULL,
+    chat_id                 TEXT      NOT NULL,
+    message_id              TEXT      NOT NULL,
+    is_from_user            BOOLEAN   NOT NULL,
+    is_error                BOOLEAN   NOT NULL DEFAULT FALSE,
+    stopped                 BOOLEAN   NOT NULL DEFAULT FALSE,
+    model                   TEXT,
+    generation_state        TEXT,
+    prompt_tokens           INTEGER,
+    completion_tokens       INTEGER,
+    message_timestamp       TIMESTAMP NOT NULL,
+    generation_completed_at TIMESTAMP,
+    created_at              TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at              TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (user_id, chat_id, message_id)
+);
+
+CREATE INDEX idx_message_index_user_timestamp ON message_index (user_id, message_timestamp DESC);
+CREATE INDEX idx_message_index_timestamp ON message_index (message_timestamp);
+
+CREATE TABLE user_preferences (
+    user_id        TEXT             PRIMARY KEY,
+    default_model  TEXT,
+    temperature    DOUBLE PRECISION,
+    system_prompt  TEXT,
+    created_at     TIMESTAMP        NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at     TIMESTAMP        NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    content_policy TEXT             NOT NULL DEFAULT 'standard'
+);
+
+CREATE TABLE daily_usage_rollups (
+    day                 DATE      PRIMARY KEY,
+    active_users        BIGINT    NOT NULL DEFAULT 0,
+    weekly_active_users BIGINT    NOT NULL DEFAULT 0,
+    requests            BIGINT    NOT NULL DEFAULT 0,
+    total_tokens        BIGINT    NOT NULL DEFAULT 0,
+    plan_tokens         BIGINT    NOT NULL DEFAULT 0,
+    deep_research_users BIGINT    NOT NULL DEFAULT 0,
+    deep_research_runs  BIGINT    NOT NULL DEFAULT 0,
+    computed_at         TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE daily_tier_usage_rollups (
+    day          DATE      NOT NULL,
+    tier         TEXT      NOT NULL,
+    active_users BIGINT    NOT NULL DEFAULT 0,
+    requests     BIGINT    NOT NULL DEFAULT 0,
+    total_tokens BIGINT    NOT NULL DEFAULT 0,
+    plan_tokens  BIGINT    NOT NULL DEFAULT 0,
+    computed_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (day, tier)
+);
+
+CREATE TABLE daily_provider_usage_rollups (
+    day          DATE      NOT NULL,
+    provider     TEXT      NOT NULL,
+    requests     BIGINT    NOT NULL DEFAULT 0,
+    total_tokens BIGINT    NOT NULL DEFAULT 0,
+    plan_tokens  BIGINT    NOT NULL DEFAULT 0,
+    computed_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (day, provider)
+);
+
+CREATE TABLE quota_experiment_exposures (
+    experiment       TEXT      NOT NULL,
+    user_id          TEXT      NOT NULL,
+    variant          TEXT      NOT NULL,
+    tier             TEXT      NOT NULL,
+    first_exposed_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (experiment, user_id)
+);
+
+CREATE INDEX idx_quota_experiment_exposures_variant ON quota_experiment_exposures (experiment, variant);
+
+CREATE TABLE upstream_providers (
+    id              INTEGER   PRIMARY KEY,
+    base_url        TEXT      NOT NULL UNIQUE,
+    api_key_env_var TEXT      NOT NULL DEFAULT '',
+    enabled         BOOLEAN   NOT NULL DEFAULT TRUE,
+    description     TEXT      NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at      TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+INSERT INTO upstream_providers (base_url, api_key_env_var, description) VALUES
+    ('https://openrouter.ai/api/v1', '', 'OpenRouter (keys resolved per platform)'),
+    ('https://api.openai.com/v1', 'OPENAI_API_KEY', 'OpenAI'),
+    ('https://inference.tinfoil.sh/v1', 'TINFOIL_API_KEY', 'Tinfoil'),
+    ('https://cloud-api.near.ai/v1', 'NEAR_API_KEY', 'NEAR AI'),
+    ('http://127.0.0.1:20001/v1', 'ETERNIS_INFERENCE_API_KEY', 'Eternis inference'),
+    ('http://34.30.193.13:8000/v1', '', 'Self-hosted Venice (GCP)');
+
+CREATE TABLE chat_search_tokens (
+    user_id    TEXT      NOT NULL,
+    chat_id    TEXT      NOT NULL,
+    message_id TEXT      NOT NULL,
+    token_hash BLOB      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (user_id, token_hash, chat_id, message_id)
+);
+
+CREATE INDEX idx_chat_search_tokens_message ON chat_search_tokens (user_id, chat_id, message_id);
+
+CREATE TABLE message_feedback (
+    user_id    TEXT      NOT NULL,
+    chat_id    TEXT      NOT NULL,
+    message_id TEXT      NOT NULL,
+    model      TEXT      NOT NULL,
+    rating     SMALLINT  NOT NULL CHECK (rating IN (-1, 1)),
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (user_id, chat_id, message_id)
+);
+
+CREATE INDEX idx_message_feedback_updated_at ON message_feedback (updated_at);
+
+CREATE TABLE chats (
+    user_id                     TEXT      NOT NULL,
+    chat_id                     TEXT      NOT NULL,
+    title                       TEXT,
+    encrypted_title             TEXT,
+    title_public_encryption_key TEXT,
+    language                    TEXT,
+    last_response_id            TEXT,
+    pinned                      BOOLEAN   NOT NULL DEFAULT FALSE,
+    pinned_at                   TIMESTAMP,
+    archived                    BOOLEAN   NOT NULL DEFAULT FALSE,
+    muted                       BOOLEAN   NOT NULL DEFAULT FALSE,
+    metadata_updated_at         TIMESTAMP,
+    last_message_at             TIMESTAMP,
+    created_at                  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at                  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (user_id, chat_id)
+);
+
+CREATE TABLE chat_messages (
+    user_id                   TEXT      NOT NULL,
+    chat_id                   TEXT      NOT NULL,
+    message_id                TEXT      NOT NULL,
+    encrypted_content         TEXT      NOT NULL,
+    public_encryption_key     TEXT      NOT NULL,
+    is_from_user              BOOLEAN   NOT NULL,
+    is_error                  BOOLEAN   NOT NULL DEFAULT FALSE,
+    message_timestamp         TIMESTAMP NOT NULL,
+    stopped                   BOOLEAN   NOT NULL DEFAULT FALSE,
+    stopped_by                TEXT      NOT NULL DEFAULT '',
+    stop_reason               TEXT      NOT NULL DEFAULT '',
+    model                     TEXT      NOT NULL DEFAULT '',
+    generation_state          TEXT      NOT NULL DEFAULT '',
+    generation_started_at     TIMESTAMP,
+    generation_completed_at   TIMESTAMP,
+    generation_error          TEXT      NOT NULL DEFAULT '',
+    encrypted_masked_keywords TEXT      NOT NULL DEFAULT '',
+    encrypted_reasoning       TEXT      NOT NULL DEFAULT '',
+    delivered_at              TIMESTAMP,
+    read_at                   TIMESTAMP,
+    created_at                TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at                TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (user_id, chat_id, message_id)
+);
+
+CREATE INDEX idx_chat_messages_chat_timestamp ON chat_messages (user_id, chat_id, message_timestamp);
+
+CREATE TABLE chat_drafts (
+    user_id               TEXT      NOT NULL,
+    chat_id               TEXT      NOT NULL,
+    encrypted_content     TEXT      NOT NULL,
+    public_encryption_key TEXT      NOT NULL,
+    updated_at            TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chat_id)
+);
+
+-- +goose Down
+DROP TABLE chat_drafts;
+DROP TABLE chat_messages;
+DROP TABLE chats;
+DROP TABLE message_feedback;
+DROP TABLE chat_search_tokens;
+DROP TABLE upstream_providers;
+DROP TABLE quota_experiment_exposures;
+DROP TABLE daily_provider_usage_rollups;
+DROP TABLE daily_tier_usage_rollups;
+DROP TABLE daily_usage_rollups;
+DROP TABLE user_preferences;
+DROP TABLE message_index;
+DROP TABLE audit_logs;
+DROP TABLE app_attest_keys;
+DROP TABLE attestation_challenges;
+DROP TABLE user_digests;
+DROP TABLE digest_subscriptions;
+DROP TABLE fai_payment_intents;
+DROP TABLE zcash_invoices;
+DROP TABLE problem_reports;
+DROP TABLE deep_research_runs;
+DROP TABLE tasks;
+DROP TABLE deep_research_messages;
+DROP TABLE entitlements;
+DROP TABLE telegram_chats;
+DROP TABLE request_logs;
+DROP TABLE invite_codes;
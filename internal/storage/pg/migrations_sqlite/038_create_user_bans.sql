@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE user_bans (
+    user_id   TEXT      PRIMARY KEY,
+    reason    TEXT      NOT NULL DEFAULT '',
+    banned_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+-- +goose Down
+DROP TABLE user_bans;
@@ -0,0 +1,42 @@
+-- +goose Up
+ALTER TABLE entitlements ADD COLUMN auto_renew BOOLEAN;
+ALTER TABLE entitlements ADD COLUMN in_grace_period BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE entitlement_events (
+    id                      INTEGER   PRIMARY KEY,
+    user_id                 TEXT      NOT NULL,
+    subscription_tier       TEXT      NOT NULL,
+    subscription_provider   TEXT      NOT NULL,
+    subscription_expires_at TIMESTAMP,
+    created_at              TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_entitlement_events_user_created ON entitlement_events (user_id, created_at DESC);
+
+-- +goose StatementBegin
+CREATE TRIGGER entitlements_insert_event
+AFTER INSERT ON entitlements
+BEGIN
+    INSERT INTO entitlement_events (user_id, subscription_tier, subscription_provider, subscription_expires_at)
+    VALUES (NEW.user_id, NEW.subscription_tier, NEW.subscription_provider, NEW.subscription_expires_at);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER entitlements_update_event
+AFTER UPDATE ON entitlements
+WHEN OLD.subscription_tier IS NOT NEW.subscription_tier
+  OR OLD.subscription_provider IS NOT NEW.subscription_provider
+  OR OLD.subscription_expires_at IS NOT NEW.subscription_expires_at
+BEGIN
+    INSERT INTO entitlement_events (user_id, subscription_tier, subscription_provider, subscription_expires_at)
+    VALUES (NEW.user_id, NEW.subscription_tier, NEW.subscription_provider, NEW.subscription_expires_at);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER entitlements_update_event;
+DROP TRIGGER entitlements_insert_event;
+DROP TABLE entitlement_events;
+ALTER TABLE entitlements DROP COLUMN in_grace_period;
+ALTER TABLE entitlements DROP COLUMN auto_renew;
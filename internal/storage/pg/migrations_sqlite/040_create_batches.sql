@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE batch_files (
+    file_id    TEXT      PRIMARY KEY,
+    user_id    TEXT      NOT NULL,
+    purpose    TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE batches (
+    batch_id          TEXT      PRIMARY KEY,
+    user_id           TEXT      NOT NULL,
+    endpoint          TEXT      NOT NULL,
+    status            TEXT      NOT NULL,
+    object            TEXT      NOT NULL,
+    created_at        TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at        TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    usage_recorded_at TIMESTAMP
+);
+
+CREATE INDEX idx_batches_user_created ON batches (user_id, created_at DESC);
+CREATE INDEX idx_batches_usage_pending ON batches (created_at) WHERE usage_recorded_at IS NULL;
+
+-- +goose Down
+DROP TABLE batches;
+DROP TABLE batch_files;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE routing_entries (
+    kind        TEXT      NOT NULL CHECK (kind IN ('provider', 'model')),
+    name        TEXT      NOT NULL,
+    config      TEXT      NOT NULL,
+    enabled     BOOLEAN   NOT NULL DEFAULT TRUE,
+    description TEXT      NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (kind, name)
+);
+
+-- +goose Down
+DROP TABLE routing_entries;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE service_api_keys (
+    id          INTEGER   PRIMARY KEY,
+    name        TEXT      NOT NULL UNIQUE,
+    key_hash    TEXT      NOT NULL UNIQUE,
+    key_prefix  TEXT      NOT NULL,
+    scopes      TEXT      NOT NULL,
+    user_id     TEXT      NOT NULL,
+    description TEXT      NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    expires_at  TIMESTAMP,
+    revoked_at  TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE service_api_keys;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE google_play_purchases (
+    purchase_token TEXT      PRIMARY KEY,
+    user_id        TEXT      NOT NULL,
+    product_id     TEXT      NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX idx_google_play_purchases_user ON google_play_purchases (user_id);
+
+-- +goose Down
+DROP TABLE google_play_purchases;
@@ -0,0 +1,34 @@
+-- name: CreateAttestationChallenge :exec
+INSERT INTO attestation_challenges (challenge, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: ConsumeAttestationChallenge :one
+-- Deletes and returns the challenge if it belongs to the user and hasn't expired.
+DELETE FROM attestation_challenges
+WHERE challenge = $1
+  AND user_id = $2
+  AND expires_at > NOW()
+RETURNING challenge;
+
+-- name: DeleteExpiredAttestationChallenges :exec
+DELETE FROM attestation_challenges
+WHERE expires_at <= NOW();
+
+-- name: CreateAppAttestKey :exec
+INSERT INTO app_attest_keys (key_id, user_id, public_key, environment)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetAppAttestKey :one
+SELECT key_id, user_id, public_key, sign_count, environment, created_at, updated_at
+FROM app_attest_keys
+WHERE key_id = $1;
+
+-- name: UpdateAppAttestKeySignCount :one
+-- Only advances the counter, so a replayed or concurrent assertion with the
+-- same counter returns no rows.
+UPDATE app_attest_keys
+SET sign_count = $2,
+    updated_at = NOW()
+WHERE key_id = $1
+  AND sign_count < $2
+RETURNING sign_count;
@@ -0,0 +1,3 @@
+-- name: CreateAuditLog :exec
+INSERT INTO audit_logs (user_id, event, decision, country, rule, provider, model, path)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
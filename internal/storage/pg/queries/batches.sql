@@ -0,0 +1,48 @@
+-- name: CreateBatchFile :exec
+INSERT INTO batch_files (file_id, user_id, purpose, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (file_id) DO NOTHING;
+
+-- name: GetBatchFile :one
+SELECT file_id, user_id, purpose, created_at
+FROM batch_files
+WHERE file_id = $1 AND user_id = $2;
+
+-- name: CreateBatch :exec
+INSERT INTO batches (batch_id, user_id, endpoint, status, object, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW(), NOW());
+
+-- name: GetBatch :one
+SELECT batch_id, user_id, endpoint, status, object, created_at, updated_at, usage_recorded_at
+FROM batches
+WHERE batch_id = $1 AND user_id = $2;
+
+-- name: UpdateBatch :exec
+UPDATE batches
+SET status = $2,
+    object = $3,
+    updated_at = NOW()
+WHERE batch_id = $1;
+
+-- name: ListBatches :many
+-- Lists a user's batches, newest first, starting after the batch ID after (empty = first page).
+SELECT batch_id, user_id, endpoint, status, object, created_at, updated_at, usage_recorded_at
+FROM batches
+WHERE user_id = $1
+  AND ($2 = '' OR created_at < (SELECT b.created_at FROM batches b WHERE b.batch_id = $2 AND b.user_id = $1))
+ORDER BY created_at DESC
+LIMIT $3;
+
+-- name: ListBatchesPendingUsage :many
+-- Batches whose usage has not been recorded yet, oldest first.
+SELECT batch_id, user_id, endpoint, status, object, created_at, updated_at, usage_recorded_at
+FROM batches
+WHERE usage_recorded_at IS NULL
+ORDER BY created_at
+LIMIT $1;
+
+-- name: ClaimBatchUsage :execrows
+-- Marks the usage of a batch as recorded. Affects no rows if another instance claimed it first.
+UPDATE batches
+SET usage_recorded_at = NOW()
+WHERE batch_id = $1 AND usage_recorded_at IS NULL;
@@ -0,0 +1,32 @@
+-- name: ReplaceChatSearchTokens :exec
+-- Replaces a message's tokens: tokens no longer present are deleted, new ones inserted.
+-- Tokens kept keep their created_at, so re-uploading a message doesn't reorder results.
+WITH removed AS (
+    DELETE FROM chat_search_tokens
+    WHERE chat_search_tokens.user_id = sqlc.arg(user_id)
+      AND chat_search_tokens.chat_id = sqlc.arg(chat_id)
+      AND chat_search_tokens.message_id = sqlc.arg(message_id)
+      AND chat_search_tokens.token_hash <> ALL(sqlc.arg(token_hashes)::bytea[])
+)
+INSERT INTO chat_search_tokens (user_id, chat_id, message_id, token_hash)
+SELECT sqlc.arg(user_id), sqlc.arg(chat_id), sqlc.arg(message_id), unnest(sqlc.arg(token_hashes)::bytea[])
+ON CONFLICT DO NOTHING;
+
+-- name: SearchChatMessages :many
+-- Messages indexed with every token, most recently indexed first.
+SELECT chat_id, message_id, MAX(created_at)::timestamptz AS indexed_at
+FROM chat_search_tokens
+WHERE user_id = sqlc.arg(user_id)
+  AND token_hash = ANY(sqlc.arg(token_hashes)::bytea[])
+GROUP BY chat_id, message_id
+HAVING COUNT(*) = sqlc.arg(token_count)::int
+ORDER BY indexed_at DESC
+LIMIT sqlc.arg(result_limit);
+
+-- name: DeleteChatSearchTokens :exec
+DELETE FROM chat_search_tokens
+WHERE user_id = $1 AND chat_id = $2;
+
+-- name: DeleteUserChatSearchTokens :exec
+DELETE FROM chat_search_tokens
+WHERE user_id = $1;
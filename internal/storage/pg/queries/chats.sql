@@ -0,0 +1,94 @@
+-- name: UpsertChatMessage :exec
+-- Messages are rewritten as generation progresses (thinking, then completed); the latest
+-- write replaces the whole message, like a Firestore Set.
+INSERT INTO chat_messages (
+    user_id, chat_id, message_id, encrypted_content, public_encryption_key, is_from_user, is_error,
+    message_timestamp, stopped, stopped_by, stop_reason, model, generation_state,
+    generation_started_at, generation_completed_at, generation_error, encrypted_masked_keywords,
+    encrypted_reasoning, delivered_at, read_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+ON CONFLICT (user_id, chat_id, message_id) DO UPDATE SET
+    encrypted_content = EXCLUDED.encrypted_content,
+    public_encryption_key = EXCLUDED.public_encryption_key,
+    is_from_user = EXCLUDED.is_from_user,
+    is_error = EXCLUDED.is_error,
+    message_timestamp = EXCLUDED.message_timestamp,
+    stopped = EXCLUDED.stopped,
+    stopped_by = EXCLUDED.stopped_by,
+    stop_reason = EXCLUDED.stop_reason,
+    model = EXCLUDED.model,
+    generation_state = EXCLUDED.generation_state,
+    generation_started_at = EXCLUDED.generation_started_at,
+    generation_completed_at = EXCLUDED.generation_completed_at,
+    generation_error = EXCLUDED.generation_error,
+    encrypted_masked_keywords = EXCLUDED.encrypted_masked_keywords,
+    encrypted_reasoning = EXCLUDED.encrypted_reasoning,
+    delivered_at = EXCLUDED.delivered_at,
+    read_at = EXCLUDED.read_at,
+    updated_at = NOW();
+
+-- name: GetChatMessage :one
+SELECT * FROM chat_messages
+WHERE user_id = $1 AND chat_id = $2 AND message_id = $3;
+
+-- name: TouchChat :exec
+-- Records a new message in a chat, creating the chat on its first message.
+INSERT INTO chats (user_id, chat_id, last_message_at, updated_at)
+VALUES (sqlc.arg(user_id), sqlc.arg(chat_id), sqlc.arg(message_timestamp)::timestamptz, sqlc.arg(message_timestamp)::timestamptz)
+ON CONFLICT (user_id, chat_id) DO UPDATE SET
+    last_message_at = EXCLUDED.last_message_at,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: GetChat :one
+SELECT * FROM chats
+WHERE user_id = $1 AND chat_id = $2;
+
+-- name: UpsertChatTitle :exec
+INSERT INTO chats (user_id, chat_id, title, encrypted_title, title_public_encryption_key, language, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id, chat_id) DO UPDATE SET
+    title = EXCLUDED.title,
+    encrypted_title = EXCLUDED.encrypted_title,
+    title_public_encryption_key = EXCLUDED.title_public_encryption_key,
+    language = COALESCE(EXCLUDED.language, chats.language),
+    updated_at = EXCLUDED.updated_at;
+
+-- name: UpsertChatResponseID :exec
+INSERT INTO chats (user_id, chat_id, last_response_id, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id, chat_id) DO UPDATE SET
+    last_response_id = EXCLUDED.last_response_id,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: UpdateChatMetadata :one
+-- Changes the given metadata fields (NULL leaves a field unchanged). pinned_at only changes
+-- when the chat becomes pinned, so clients re-sending the same state don't reorder pinned chats.
+UPDATE chats
+SET pinned_at = CASE
+        WHEN sqlc.narg(pinned)::boolean IS NULL THEN chats.pinned_at
+        WHEN NOT sqlc.narg(pinned)::boolean THEN NULL
+        WHEN chats.pinned THEN chats.pinned_at
+        ELSE sqlc.arg(now)::timestamptz
+    END,
+    pinned = COALESCE(sqlc.narg(pinned)::boolean, chats.pinned),
+    archived = COALESCE(sqlc.narg(archived)::boolean, chats.archived),
+    muted = COALESCE(sqlc.narg(muted)::boolean, chats.muted),
+    metadata_updated_at = sqlc.arg(now)::timestamptz
+WHERE user_id = sqlc.arg(user_id) AND chat_id = sqlc.arg(chat_id)
+RETURNING pinned, pinned_at, archived, muted, metadata_updated_at;
+
+-- name: UpsertChatDraft :exec
+INSERT INTO chat_drafts (user_id, chat_id, encrypted_content, public_encryption_key, updated_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, chat_id) DO UPDATE SET
+    encrypted_content = EXCLUDED.encrypted_content,
+    public_encryption_key = EXCLUDED.public_encryption_key,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: GetChatDraft :one
+SELECT * FROM chat_drafts
+WHERE user_id = $1 AND chat_id = $2;
+
+-- name: DeleteChatDraft :exec
+DELETE FROM chat_drafts
+WHERE user_id = $1 AND chat_id = $2;
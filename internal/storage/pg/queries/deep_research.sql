@@ -45,9 +45,24 @@ SELECT EXISTS(
       AND status = 'active'
 ) as has_active;
 
+-- name: CountActiveDeepResearchRuns :one
+SELECT COUNT(*) as active_runs
+FROM deep_research_runs
+WHERE user_id = $1
+  AND status = 'active';
+
 -- name: GetDeepResearchRunCountForChat :one
 SELECT COUNT(*) as run_count
 FROM deep_research_runs
 WHERE user_id = $1
   AND chat_id = $2
   AND status IN ('completed', 'active');
+
+-- name: ResetDeepResearchRuns :execrows
+-- Stops counting a user's completed runs (all of them, or today's) against the daily and
+-- lifetime limits. The runs are kept for usage reporting.
+UPDATE deep_research_runs
+SET status = 'reset'
+WHERE user_id = sqlc.arg(user_id)
+  AND status = 'completed'
+  AND (sqlc.arg(lifetime)::boolean OR run_date = CURRENT_DATE);
@@ -0,0 +1,66 @@
+-- name: GetDigestSubscription :one
+SELECT user_id, enabled, email, next_digest_at, created_at, updated_at
+FROM digest_subscriptions
+WHERE user_id = $1;
+
+-- name: UpsertDigestSubscription :one
+-- New subscriptions receive their first digest one week after opting in.
+INSERT INTO digest_subscriptions (user_id, enabled, email, next_digest_at, created_at, updated_at)
+VALUES ($1, $2, $3, NOW() + INTERVAL '7 days', NOW(), NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    email = EXCLUDED.email,
+    updated_at = NOW()
+RETURNING user_id, enabled, email, next_digest_at, created_at, updated_at;
+
+-- name: ClaimDueDigestSubscriptions :many
+-- Atomically claims due subscriptions and schedules the next digest.
+-- SKIP LOCKED lets concurrent instances claim disjoint batches.
+UPDATE digest_subscriptions
+SET next_digest_at = NOW() + INTERVAL '7 days',
+    updated_at = NOW()
+WHERE user_id IN (
+    SELECT ds.user_id
+    FROM digest_subscriptions ds
+    WHERE ds.enabled = TRUE
+      AND ds.next_digest_at <= NOW()
+    ORDER BY ds.next_digest_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING user_id, enabled, email, next_digest_at, created_at, updated_at;
+
+-- name: GetUserDigestStats :one
+-- Aggregates usage, completed deep research, and tasks for [period_start, period_end).
+SELECT
+    (SELECT COUNT(*) FROM request_logs rl
+     WHERE rl.user_id = sqlc.arg(user_id) AND rl.created_at >= sqlc.arg(period_start) AND rl.created_at < sqlc.arg(period_end))::BIGINT AS request_count,
+    (SELECT COALESCE(SUM(rl.plan_tokens), 0) FROM request_logs rl
+     WHERE rl.user_id = sqlc.arg(user_id) AND rl.created_at >= sqlc.arg(period_start) AND rl.created_at < sqlc.arg(period_end)
+       AND rl.plan_tokens IS NOT NULL)::BIGINT AS plan_tokens,
+    COALESCE((SELECT rl.model FROM request_logs rl
+     WHERE rl.user_id = sqlc.arg(user_id) AND rl.created_at >= sqlc.arg(period_start) AND rl.created_at < sqlc.arg(period_end)
+       AND rl.model IS NOT NULL
+     GROUP BY rl.model ORDER BY COUNT(*) DESC, rl.model LIMIT 1), '')::TEXT AS top_model,
+    (SELECT COUNT(*) FROM deep_research_runs dr
+     WHERE dr.user_id = sqlc.arg(user_id) AND dr.status = 'completed'
+       AND dr.completed_at >= sqlc.arg(period_start) AND dr.completed_at < sqlc.arg(period_end))::BIGINT AS deep_research_completed,
+    (SELECT COUNT(*) FROM tasks t
+     WHERE t.user_id = sqlc.arg(user_id) AND t.created_at >= sqlc.arg(period_start) AND t.created_at < sqlc.arg(period_end))::BIGINT AS tasks_created,
+    (SELECT COUNT(*) FROM tasks t
+     WHERE t.user_id = sqlc.arg(user_id) AND t.status IN ('pending', 'active'))::BIGINT AS active_tasks;
+
+-- name: CreateUserDigest :one
+INSERT INTO user_digests (
+    user_id, period_start, period_end,
+    request_count, plan_tokens, top_model,
+    deep_research_completed, tasks_created, active_tasks
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, user_id, period_start, period_end, request_count, plan_tokens, top_model, deep_research_completed, tasks_created, active_tasks, created_at;
+
+-- name: ListUserDigests :many
+SELECT id, user_id, period_start, period_end, request_count, plan_tokens, top_model, deep_research_completed, tasks_created, active_tasks, created_at
+FROM user_digests
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
@@ -15,6 +15,8 @@ ON CONFLICT (user_id) DO UPDATE SET
   subscription_expires_at = EXCLUDED.subscription_expires_at,
   subscription_provider = EXCLUDED.subscription_provider,
   stripe_customer_id = COALESCE(EXCLUDED.stripe_customer_id, entitlements.stripe_customer_id),
+  auto_renew = NULL,
+  in_grace_period = FALSE,
   updated_at = NOW();
 
 -- name: GetUserTier :one
@@ -23,7 +25,7 @@ FROM entitlements
 WHERE user_id = $1;
 
 -- name: GetEntitlement :one
-SELECT user_id, subscription_expires_at, subscription_provider, stripe_customer_id, subscription_tier, updated_at
+SELECT user_id, subscription_expires_at, subscription_provider, stripe_customer_id, subscription_tier, updated_at, auto_renew, in_grace_period
 FROM entitlements
 WHERE user_id = $1;
 
@@ -60,4 +62,19 @@ ON CONFLICT (user_id) DO UPDATE SET
     END,
   subscription_provider = sqlc.arg(subscription_provider),
   stripe_customer_id = COALESCE(sqlc.arg(stripe_customer_id), entitlements.stripe_customer_id),
-  updated_at = NOW();
\ No newline at end of file
+  auto_renew = NULL,
+  in_grace_period = FALSE,
+  updated_at = NOW();
+
+-- name: SetEntitlementRenewal :exec
+-- Records the renewal state reported by the subscription provider.
+UPDATE entitlements
+SET auto_renew = $2, in_grace_period = $3
+WHERE user_id = $1;
+
+-- name: ListEntitlementEvents :many
+SELECT id, user_id, subscription_tier, subscription_provider, subscription_expires_at, created_at
+FROM entitlement_events
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
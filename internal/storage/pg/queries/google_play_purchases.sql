@@ -0,0 +1,8 @@
+-- name: ClaimGooglePlayPurchase :one
+-- Attaches a purchase token to a user unless it is already attached, and returns the user the
+-- token belongs to.
+INSERT INTO google_play_purchases (purchase_token, user_id, product_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (purchase_token) DO UPDATE SET
+  user_id = google_play_purchases.user_id
+RETURNING user_id;
@@ -48,4 +48,37 @@ WHERE redeemed_by = $1 AND deleted_at IS NULL;
 -- name: ResetInviteCode :exec
 UPDATE invite_codes 
 SET is_used = false, redeemed_by = NULL, redeemed_at = NULL, updated_at = NOW() 
-WHERE code_hash = $1 AND deleted_at IS NULL;
\ No newline at end of file
+WHERE code_hash = $1 AND deleted_at IS NULL;
+
+-- name: GetInviteFunnel :many
+-- Funnel of invite codes redeemed in [from_time, to_time), grouped by code prefix (the whole
+-- code when prefix_length is 0): redeemers with a request after redeeming (activated), and of
+-- those whose second week after redeeming has ended by as_of, how many made a request in it.
+WITH redemptions AS (
+    SELECT
+        CASE WHEN sqlc.arg(prefix_length)::int > 0 THEN LEFT(code, sqlc.arg(prefix_length)::int) ELSE code END AS code_group,
+        redeemed_by,
+        redeemed_at
+    FROM invite_codes
+    WHERE deleted_at IS NULL
+      AND redeemed_by IS NOT NULL
+      AND redeemed_at >= sqlc.arg(from_time)::timestamptz
+      AND redeemed_at < sqlc.arg(to_time)::timestamptz
+)
+SELECT
+    r.code_group::text AS code_group,
+    COUNT(*)::bigint AS redemptions,
+    COUNT(*) FILTER (WHERE EXISTS (
+        SELECT 1 FROM request_logs l
+        WHERE l.user_id = r.redeemed_by AND l.created_at >= r.redeemed_at
+    ))::bigint AS activated,
+    COUNT(*) FILTER (WHERE r.redeemed_at + INTERVAL '14 days' <= sqlc.arg(as_of)::timestamptz)::bigint AS retention_eligible,
+    COUNT(*) FILTER (WHERE r.redeemed_at + INTERVAL '14 days' <= sqlc.arg(as_of)::timestamptz AND EXISTS (
+        SELECT 1 FROM request_logs l
+        WHERE l.user_id = r.redeemed_by
+          AND l.created_at >= r.redeemed_at + INTERVAL '7 days'
+          AND l.created_at < r.redeemed_at + INTERVAL '14 days'
+    ))::bigint AS retained
+FROM redemptions r
+GROUP BY r.code_group
+ORDER BY redemptions DESC, code_group;
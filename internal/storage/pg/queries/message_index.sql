@@ -0,0 +1,18 @@
+-- name: UpsertMessageIndexEntry :exec
+-- Messages are saved more than once (e.g., "thinking" then "completed"); the latest write wins
+-- except for token counts, which are kept when a later write doesn't carry them.
+INSERT INTO message_index (
+    user_id, chat_id, message_id, is_from_user, is_error, stopped, model, generation_state,
+    prompt_tokens, completion_tokens, message_timestamp, generation_completed_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (user_id, chat_id, message_id) DO UPDATE SET
+    is_error = EXCLUDED.is_error,
+    stopped = EXCLUDED.stopped,
+    model = COALESCE(EXCLUDED.model, message_index.model),
+    generation_state = COALESCE(EXCLUDED.generation_state, message_index.generation_state),
+    prompt_tokens = COALESCE(EXCLUDED.prompt_tokens, message_index.prompt_tokens),
+    completion_tokens = COALESCE(EXCLUDED.completion_tokens, message_index.completion_tokens),
+    message_timestamp = EXCLUDED.message_timestamp,
+    generation_completed_at = COALESCE(EXCLUDED.generation_completed_at, message_index.generation_completed_at),
+    updated_at = NOW();
+
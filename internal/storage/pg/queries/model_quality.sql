@@ -0,0 +1,48 @@
+-- name: UpsertMessageFeedback :execrows
+-- Rates an assistant message of the user, attributed to the model recorded in the message
+-- index. No row is written if the user has no such message with a known model.
+INSERT INTO message_feedback (user_id, chat_id, message_id, model, rating)
+SELECT user_id, chat_id, message_id, model, sqlc.arg(rating)
+FROM message_index
+WHERE user_id = sqlc.arg(user_id)
+  AND chat_id = sqlc.arg(chat_id)
+  AND message_id = sqlc.arg(message_id)
+  AND NOT is_from_user
+  AND model IS NOT NULL
+ON CONFLICT (user_id, chat_id, message_id) DO UPDATE SET
+    model = EXCLUDED.model,
+    rating = EXCLUDED.rating,
+    updated_at = NOW();
+
+-- name: DeleteMessageFeedback :exec
+DELETE FROM message_feedback
+WHERE user_id = $1 AND chat_id = $2 AND message_id = $3;
+
+-- name: GetModelFeedbackCounts :many
+-- Positive and negative ratings per model, by time of the latest rating in [from_time, to_time).
+SELECT
+    model,
+    COUNT(*) FILTER (WHERE rating > 0)::bigint AS positive,
+    COUNT(*) FILTER (WHERE rating < 0)::bigint AS negative
+FROM message_feedback
+WHERE updated_at >= sqlc.arg(from_time)::timestamptz
+  AND updated_at < sqlc.arg(to_time)::timestamptz
+GROUP BY model
+ORDER BY model;
+
+-- name: GetModelRequestSignals :many
+-- Requests per model and provider in [from_time, to_time): all of them, those whose stream was
+-- flagged with an anomaly, those retried after one, and those with either.
+SELECT
+    model::text AS model,
+    provider,
+    COUNT(*)::bigint AS requests,
+    COUNT(*) FILTER (WHERE stream_anomalies IS NOT NULL)::bigint AS anomalous,
+    COUNT(*) FILTER (WHERE stream_retries > 0)::bigint AS retried,
+    COUNT(*) FILTER (WHERE stream_anomalies IS NOT NULL OR stream_retries > 0)::bigint AS flagged
+FROM request_logs
+WHERE model IS NOT NULL
+  AND created_at >= sqlc.arg(from_time)::timestamptz
+  AND created_at < sqlc.arg(to_time)::timestamptz
+GROUP BY model, provider
+ORDER BY model, provider;
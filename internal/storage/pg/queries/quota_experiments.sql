@@ -0,0 +1,5 @@
+-- name: RecordQuotaExperimentExposure :exec
+-- Keeps the first exposure; a user's variant never changes during an experiment.
+INSERT INTO quota_experiment_exposures (experiment, user_id, variant, tier, first_exposed_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (experiment, user_id) DO NOTHING;
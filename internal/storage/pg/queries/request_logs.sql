@@ -1,13 +1,15 @@
 -- name: CreateRequestLog :exec
-INSERT INTO request_logs (user_id, endpoint, model, provider, prompt_tokens, completion_tokens, total_tokens) 
-VALUES ($1, $2, $3, $4, $5, $6, $7);
+INSERT INTO request_logs (user_id, endpoint, model, provider, prompt_tokens, completion_tokens, total_tokens, privacy_mode) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
 
 -- name: CreateRequestLogWithPlanTokens :exec
 INSERT INTO request_logs (
     user_id, endpoint, model, provider,
     prompt_tokens, completion_tokens, total_tokens,
-    plan_tokens, token_multiplier
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+    plan_tokens, token_multiplier, reasoning_effort,
+    audio_seconds, audio_characters, stream_anomalies, stream_retries,
+    privacy_mode
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15);
 
 -- name: GetUserPlanTokensToday :one
 -- Queries request_logs directly for real-time data (not materialized view).
@@ -38,6 +40,23 @@ WHERE user_id = $1
   AND created_at >= DATE_TRUNC('month', NOW() AT TIME ZONE 'UTC')
   AND plan_tokens IS NOT NULL;
 
+-- name: GetUserAudioSecondsToday :one
+-- Audio seconds (transcribed + synthesized) used today, for tier audio quotas.
+-- Performance: The partial idx_request_logs_audio_seconds index keeps this fast.
+SELECT COALESCE(SUM(audio_seconds), 0)::DOUBLE PRECISION as audio_seconds
+FROM request_logs
+WHERE user_id = $1
+  AND created_at >= DATE_TRUNC('day', NOW() AT TIME ZONE 'UTC')
+  AND audio_seconds IS NOT NULL;
+
+-- name: GetUserAudioSecondsThisMonth :one
+-- Audio seconds (transcribed + synthesized) used this month, for tier audio quotas.
+SELECT COALESCE(SUM(audio_seconds), 0)::DOUBLE PRECISION as audio_seconds
+FROM request_logs
+WHERE user_id = $1
+  AND created_at >= DATE_TRUNC('month', NOW() AT TIME ZONE 'UTC')
+  AND audio_seconds IS NOT NULL;
+
 -- name: GetUserFallbackPlanTokensToday :one
 -- Returns plan tokens used today on the fallback model.
 -- Used for tracking fallback quota when normal quota is exceeded.
@@ -46,4 +65,20 @@ FROM request_logs
 WHERE user_id = $1
   AND created_at >= DATE_TRUNC('day', NOW() AT TIME ZONE 'UTC')
   AND plan_tokens IS NOT NULL
-  AND model = $2;
\ No newline at end of file
+  AND model = $2;
+-- name: ListRequestLogsForPlanTokenBackfill :many
+-- Keyset-paginated scan of logs with token usage but no plan tokens (or all logs with
+-- token usage when overwrite is set). Used by cmd/backfill-plan-tokens.
+SELECT id, model, provider, total_tokens, plan_tokens
+FROM request_logs
+WHERE id > sqlc.arg(after_id)
+  AND total_tokens IS NOT NULL
+  AND (plan_tokens IS NULL OR sqlc.arg(overwrite)::boolean)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateRequestLogPlanTokens :exec
+UPDATE request_logs
+SET plan_tokens = $2,
+    token_multiplier = $3
+WHERE id = $1;
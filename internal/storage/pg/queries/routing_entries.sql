@@ -0,0 +1,19 @@
+-- name: ListRoutingEntries :many
+SELECT kind, name, config, enabled, description, created_at, updated_at
+FROM routing_entries
+ORDER BY kind, name;
+
+-- name: UpsertRoutingEntry :one
+INSERT INTO routing_entries (kind, name, config, enabled, description, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+ON CONFLICT (kind, name) DO UPDATE
+SET config = EXCLUDED.config,
+    enabled = EXCLUDED.enabled,
+    description = EXCLUDED.description,
+    updated_at = NOW()
+RETURNING kind, name, config, enabled, description, created_at, updated_at;
+
+-- name: DeleteRoutingEntry :one
+DELETE FROM routing_entries
+WHERE kind = $1 AND name = $2
+RETURNING name;
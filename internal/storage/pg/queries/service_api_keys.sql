@@ -0,0 +1,22 @@
+-- name: CreateServiceAPIKey :one
+-- Returns no rows if the name already exists.
+INSERT INTO service_api_keys (name, key_hash, key_prefix, scopes, user_id, description, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+ON CONFLICT (name) DO NOTHING
+RETURNING id, name, key_hash, key_prefix, scopes, user_id, description, created_at, expires_at, revoked_at;
+
+-- name: GetServiceAPIKeyByHash :one
+SELECT id, name, key_hash, key_prefix, scopes, user_id, description, created_at, expires_at, revoked_at
+FROM service_api_keys
+WHERE key_hash = $1;
+
+-- name: ListServiceAPIKeys :many
+SELECT id, name, key_hash, key_prefix, scopes, user_id, description, created_at, expires_at, revoked_at
+FROM service_api_keys
+ORDER BY id;
+
+-- name: RevokeServiceAPIKey :one
+UPDATE service_api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING id;
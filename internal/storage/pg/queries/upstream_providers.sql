@@ -0,0 +1,30 @@
+-- name: ListUpstreamProviders :many
+SELECT id, base_url, api_key_env_var, enabled, description, created_at, updated_at
+FROM upstream_providers
+ORDER BY id;
+
+-- name: GetUpstreamProvider :one
+SELECT id, base_url, api_key_env_var, enabled, description, created_at, updated_at
+FROM upstream_providers
+WHERE id = $1;
+
+-- name: CreateUpstreamProvider :one
+-- Returns no rows if the base URL already exists.
+INSERT INTO upstream_providers (base_url, api_key_env_var, enabled, description, created_at, updated_at)
+VALUES ($1, $2, $3, $4, NOW(), NOW())
+ON CONFLICT (base_url) DO NOTHING
+RETURNING id, base_url, api_key_env_var, enabled, description, created_at, updated_at;
+
+-- name: UpdateUpstreamProvider :one
+UPDATE upstream_providers
+SET api_key_env_var = $2,
+    enabled = $3,
+    description = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, base_url, api_key_env_var, enabled, description, created_at, updated_at;
+
+-- name: DeleteUpstreamProvider :one
+DELETE FROM upstream_providers
+WHERE id = $1
+RETURNING id;
@@ -0,0 +1,116 @@
+-- name: UpsertDailyUsageRollup :exec
+-- Rolls up one UTC day: active users that day and over the trailing 7 days, requests,
+-- tokens, and deep research adoption. Re-running a day replaces its row.
+WITH bounds AS (
+    SELECT
+        sqlc.arg(day)::DATE AS day,
+        ((sqlc.arg(day)::DATE - 6)::TIMESTAMP AT TIME ZONE 'UTC') AS week_start,
+        (sqlc.arg(day)::DATE::TIMESTAMP AT TIME ZONE 'UTC') AS day_start,
+        ((sqlc.arg(day)::DATE + 1)::TIMESTAMP AT TIME ZONE 'UTC') AS day_end
+),
+usage AS (
+    SELECT
+        COUNT(DISTINCT rl.user_id) FILTER (WHERE rl.created_at >= b.day_start) AS active_users,
+        COUNT(DISTINCT rl.user_id) AS weekly_active_users,
+        COUNT(*) FILTER (WHERE rl.created_at >= b.day_start) AS requests,
+        COALESCE(SUM(rl.total_tokens) FILTER (WHERE rl.created_at >= b.day_start), 0) AS total_tokens,
+        COALESCE(SUM(rl.plan_tokens) FILTER (WHERE rl.created_at >= b.day_start), 0) AS plan_tokens
+    FROM request_logs rl, bounds b
+    WHERE rl.created_at >= b.week_start AND rl.created_at < b.day_end
+),
+research AS (
+    SELECT COUNT(DISTINCT dr.user_id) AS users, COUNT(*) AS runs
+    FROM deep_research_runs dr, bounds b
+    WHERE dr.started_at >= b.day_start AND dr.started_at < b.day_end
+)
+INSERT INTO daily_usage_rollups (
+    day, active_users, weekly_active_users, requests, total_tokens, plan_tokens,
+    deep_research_users, deep_research_runs, computed_at
+)
+SELECT b.day, u.active_users, u.weekly_active_users, u.requests, u.total_tokens, u.plan_tokens,
+       r.users, r.runs, NOW()
+FROM bounds b, usage u, research r
+ON CONFLICT (day) DO UPDATE
+SET active_users = EXCLUDED.active_users,
+    weekly_active_users = EXCLUDED.weekly_active_users,
+    requests = EXCLUDED.requests,
+    total_tokens = EXCLUDED.total_tokens,
+    plan_tokens = EXCLUDED.plan_tokens,
+    deep_research_users = EXCLUDED.deep_research_users,
+    deep_research_runs = EXCLUDED.deep_research_runs,
+    computed_at = EXCLUDED.computed_at;
+
+-- name: UpsertDailyTierUsageRollups :exec
+-- Rolls up one UTC day of usage per subscription tier. Tiers come from the user's current
+-- entitlement; users without one, or whose grant had expired by the start of the day, are free.
+WITH bounds AS (
+    SELECT
+        sqlc.arg(day)::DATE AS day,
+        (sqlc.arg(day)::DATE::TIMESTAMP AT TIME ZONE 'UTC') AS day_start,
+        ((sqlc.arg(day)::DATE + 1)::TIMESTAMP AT TIME ZONE 'UTC') AS day_end
+)
+INSERT INTO daily_tier_usage_rollups (day, tier, active_users, requests, total_tokens, plan_tokens, computed_at)
+SELECT
+    b.day,
+    CASE WHEN e.subscription_expires_at > b.day_start THEN e.subscription_tier ELSE 'free' END AS tier,
+    COUNT(DISTINCT rl.user_id),
+    COUNT(*),
+    COALESCE(SUM(rl.total_tokens), 0),
+    COALESCE(SUM(rl.plan_tokens), 0),
+    NOW()
+FROM request_logs rl
+CROSS JOIN bounds b
+LEFT JOIN entitlements e ON e.user_id = rl.user_id
+WHERE rl.created_at >= b.day_start AND rl.created_at < b.day_end
+GROUP BY 1, 2
+ON CONFLICT (day, tier) DO UPDATE
+SET active_users = EXCLUDED.active_users,
+    requests = EXCLUDED.requests,
+    total_tokens = EXCLUDED.total_tokens,
+    plan_tokens = EXCLUDED.plan_tokens,
+    computed_at = EXCLUDED.computed_at;
+
+-- name: UpsertDailyProviderUsageRollups :exec
+-- Rolls up one UTC day of usage per upstream provider.
+WITH bounds AS (
+    SELECT
+        sqlc.arg(day)::DATE AS day,
+        (sqlc.arg(day)::DATE::TIMESTAMP AT TIME ZONE 'UTC') AS day_start,
+        ((sqlc.arg(day)::DATE + 1)::TIMESTAMP AT TIME ZONE 'UTC') AS day_end
+)
+INSERT INTO daily_provider_usage_rollups (day, provider, requests, total_tokens, plan_tokens, computed_at)
+SELECT
+    b.day,
+    rl.provider,
+    COUNT(*),
+    COALESCE(SUM(rl.total_tokens), 0),
+    COALESCE(SUM(rl.plan_tokens), 0),
+    NOW()
+FROM request_logs rl
+CROSS JOIN bounds b
+WHERE rl.created_at >= b.day_start AND rl.created_at < b.day_end
+GROUP BY 1, 2
+ON CONFLICT (day, provider) DO UPDATE
+SET requests = EXCLUDED.requests,
+    total_tokens = EXCLUDED.total_tokens,
+    plan_tokens = EXCLUDED.plan_tokens,
+    computed_at = EXCLUDED.computed_at;
+
+-- name: ListDailyUsageRollups :many
+SELECT day, active_users, weekly_active_users, requests, total_tokens, plan_tokens,
+       deep_research_users, deep_research_runs, computed_at
+FROM daily_usage_rollups
+WHERE day >= sqlc.arg(from_day) AND day <= sqlc.arg(to_day)
+ORDER BY day;
+
+-- name: ListDailyTierUsageRollups :many
+SELECT day, tier, active_users, requests, total_tokens, plan_tokens, computed_at
+FROM daily_tier_usage_rollups
+WHERE day >= sqlc.arg(from_day) AND day <= sqlc.arg(to_day)
+ORDER BY day, tier;
+
+-- name: ListDailyProviderUsageRollups :many
+SELECT day, provider, requests, total_tokens, plan_tokens, computed_at
+FROM daily_provider_usage_rollups
+WHERE day >= sqlc.arg(from_day) AND day <= sqlc.arg(to_day)
+ORDER BY day, provider;
@@ -0,0 +1,17 @@
+-- name: BanUser :one
+-- Bans a user, replacing the reason of an existing ban.
+INSERT INTO user_bans (user_id, reason, banned_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id) DO UPDATE SET
+  reason = EXCLUDED.reason,
+  banned_at = NOW()
+RETURNING user_id, reason, banned_at;
+
+-- name: GetUserBan :one
+SELECT user_id, reason, banned_at
+FROM user_bans
+WHERE user_id = $1;
+
+-- name: UnbanUser :execrows
+DELETE FROM user_bans
+WHERE user_id = $1;
@@ -0,0 +1,19 @@
+-- name: GetUserPreferences :one
+SELECT user_id, default_model, temperature, system_prompt, content_policy, created_at, updated_at
+FROM user_preferences
+WHERE user_id = $1;
+
+-- name: UpsertUserPreferences :one
+INSERT INTO user_preferences (user_id, default_model, temperature, system_prompt, content_policy, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET default_model = EXCLUDED.default_model,
+    temperature = EXCLUDED.temperature,
+    system_prompt = EXCLUDED.system_prompt,
+    content_policy = EXCLUDED.content_policy,
+    updated_at = NOW()
+RETURNING user_id, default_model, temperature, system_prompt, content_policy, created_at, updated_at;
+
+-- name: DeleteUserPreferences :exec
+DELETE FROM user_preferences
+WHERE user_id = $1;
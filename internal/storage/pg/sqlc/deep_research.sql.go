@@ -26,6 +26,20 @@ func (q *Queries) CompleteDeepResearchRun(ctx context.Context, arg CompleteDeepR
 	return err
 }
 
+const countActiveDeepResearchRuns = `-- name: CountActiveDeepResearchRuns :one
+SELECT COUNT(*) as active_runs
+FROM deep_research_runs
+WHERE user_id = $1
+  AND status = 'active'
+`
+
+func (q *Queries) CountActiveDeepResearchRuns(ctx context.Context, userID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countActiveDeepResearchRuns, userID)
+	var active_runs int64
+	err := row.Scan(&active_runs)
+	return active_runs, err
+}
+
 const createDeepResearchRun = `-- name: CreateDeepResearchRun :one
 INSERT INTO deep_research_runs (user_id, chat_id, run_date, status)
 VALUES ($1, $2, CURRENT_DATE, 'active')
@@ -136,6 +150,29 @@ func (q *Queries) HasActiveDeepResearchRun(ctx context.Context, userID string) (
 	return has_active, err
 }
 
+const resetDeepResearchRuns = `-- name: ResetDeepResearchRuns :execrows
+UPDATE deep_research_runs
+SET status = 'reset'
+WHERE user_id = $1
+  AND status = 'completed'
+  AND ($2::boolean OR run_date = CURRENT_DATE)
+`
+
+type ResetDeepResearchRunsParams struct {
+	UserID   string `json:"userId"`
+	Lifetime bool   `json:"lifetime"`
+}
+
+// Stops counting a user's completed runs (all of them, or today's) against the daily and
+// lifetime limits. The runs are kept for usage reporting.
+func (q *Queries) ResetDeepResearchRuns(ctx context.Context, arg ResetDeepResearchRunsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resetDeepResearchRuns, arg.UserID, arg.Lifetime)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateDeepResearchRunTokens = `-- name: UpdateDeepResearchRunTokens :exec
 UPDATE deep_research_runs
 SET model_tokens_used = $2,
@@ -12,7 +12,7 @@ import (
 )
 
 const getEntitlement = `-- name: GetEntitlement :one
-SELECT user_id, subscription_expires_at, subscription_provider, stripe_customer_id, subscription_tier, updated_at
+SELECT user_id, subscription_expires_at, subscription_provider, stripe_customer_id, subscription_tier, updated_at, auto_renew, in_grace_period
 FROM entitlements
 WHERE user_id = $1
 `
@@ -24,6 +24,8 @@ type GetEntitlementRow struct {
 	StripeCustomerID      *string      `json:"stripeCustomerId"`
 	SubscriptionTier      string       `json:"subscriptionTier"`
 	UpdatedAt             time.Time    `json:"updatedAt"`
+	AutoRenew             *bool        `json:"autoRenew"`
+	InGracePeriod         bool         `json:"inGracePeriod"`
 }
 
 func (q *Queries) GetEntitlement(ctx context.Context, userID string) (GetEntitlementRow, error) {
@@ -36,6 +38,8 @@ func (q *Queries) GetEntitlement(ctx context.Context, userID string) (GetEntitle
 		&i.StripeCustomerID,
 		&i.SubscriptionTier,
 		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.InGracePeriod,
 	)
 	return i, err
 }
@@ -72,6 +76,67 @@ func (q *Queries) GetUserTier(ctx context.Context, userID string) (GetUserTierRo
 	return i, err
 }
 
+const listEntitlementEvents = `-- name: ListEntitlementEvents :many
+SELECT id, user_id, subscription_tier, subscription_provider, subscription_expires_at, created_at
+FROM entitlement_events
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+`
+
+type ListEntitlementEventsParams struct {
+	UserID string `json:"userId"`
+	Limit  int32  `json:"limit"`
+}
+
+func (q *Queries) ListEntitlementEvents(ctx context.Context, arg ListEntitlementEventsParams) ([]EntitlementEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listEntitlementEvents, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EntitlementEvent{}
+	for rows.Next() {
+		var i EntitlementEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.SubscriptionTier,
+			&i.SubscriptionProvider,
+			&i.SubscriptionExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setEntitlementRenewal = `-- name: SetEntitlementRenewal :exec
+UPDATE entitlements
+SET auto_renew = $2, in_grace_period = $3
+WHERE user_id = $1
+`
+
+type SetEntitlementRenewalParams struct {
+	UserID        string `json:"userId"`
+	AutoRenew     *bool  `json:"autoRenew"`
+	InGracePeriod bool   `json:"inGracePeriod"`
+}
+
+// Records the renewal state reported by the subscription provider.
+func (q *Queries) SetEntitlementRenewal(ctx context.Context, arg SetEntitlementRenewalParams) error {
+	_, err := q.db.ExecContext(ctx, setEntitlementRenewal, arg.UserID, arg.AutoRenew, arg.InGracePeriod)
+	return err
+}
+
 const upsertEntitlement = `-- name: UpsertEntitlement :exec
 INSERT INTO entitlements (user_id, subscription_expires_at, subscription_provider, stripe_customer_id, updated_at)
 VALUES ($1, $2, $3, $4, NOW())
@@ -123,6 +188,8 @@ ON CONFLICT (user_id) DO UPDATE SET
     END,
   subscription_provider = $5,
   stripe_customer_id = COALESCE($6, entitlements.stripe_customer_id),
+  auto_renew = NULL,
+  in_grace_period = FALSE,
   updated_at = NOW()
 `
 
@@ -158,6 +225,8 @@ ON CONFLICT (user_id) DO UPDATE SET
   subscription_expires_at = EXCLUDED.subscription_expires_at,
   subscription_provider = EXCLUDED.subscription_provider,
   stripe_customer_id = COALESCE(EXCLUDED.stripe_customer_id, entitlements.stripe_customer_id),
+  auto_renew = NULL,
+  in_grace_period = FALSE,
   updated_at = NOW()
 `
 
@@ -194,6 +194,89 @@ func (q *Queries) GetInviteCodeByID(ctx context.Context, id int64) (InviteCode,
 	return i, err
 }
 
+const getInviteFunnel = `-- name: GetInviteFunnel :many
+WITH redemptions AS (
+    SELECT
+        CASE WHEN $1::int > 0 THEN LEFT(code, $1::int) ELSE code END AS code_group,
+        redeemed_by,
+        redeemed_at
+    FROM invite_codes
+    WHERE deleted_at IS NULL
+      AND redeemed_by IS NOT NULL
+      AND redeemed_at >= $2::timestamptz
+      AND redeemed_at < $3::timestamptz
+)
+SELECT
+    r.code_group::text AS code_group,
+    COUNT(*)::bigint AS redemptions,
+    COUNT(*) FILTER (WHERE EXISTS (
+        SELECT 1 FROM request_logs l
+        WHERE l.user_id = r.redeemed_by AND l.created_at >= r.redeemed_at
+    ))::bigint AS activated,
+    COUNT(*) FILTER (WHERE r.redeemed_at + INTERVAL '14 days' <= $4::timestamptz)::bigint AS retention_eligible,
+    COUNT(*) FILTER (WHERE r.redeemed_at + INTERVAL '14 days' <= $4::timestamptz AND EXISTS (
+        SELECT 1 FROM request_logs l
+        WHERE l.user_id = r.redeemed_by
+          AND l.created_at >= r.redeemed_at + INTERVAL '7 days'
+          AND l.created_at < r.redeemed_at + INTERVAL '14 days'
+    ))::bigint AS retained
+FROM redemptions r
+GROUP BY r.code_group
+ORDER BY redemptions DESC, code_group
+`
+
+type GetInviteFunnelParams struct {
+	PrefixLength int32     `json:"prefix_length"`
+	FromTime     time.Time `json:"from_time"`
+	ToTime       time.Time `json:"to_time"`
+	AsOf         time.Time `json:"as_of"`
+}
+
+type GetInviteFunnelRow struct {
+	CodeGroup         string `json:"code_group"`
+	Redemptions       int64  `json:"redemptions"`
+	Activated         int64  `json:"activated"`
+	RetentionEligible int64  `json:"retention_eligible"`
+	Retained          int64  `json:"retained"`
+}
+
+// Funnel of invite codes redeemed in [from_time, to_time), grouped by code prefix (the whole
+// code when prefix_length is 0): redeemers with a request after redeeming (activated), and of
+// those whose second week after redeeming has ended by as_of, how many made a request in it.
+func (q *Queries) GetInviteFunnel(ctx context.Context, arg GetInviteFunnelParams) ([]GetInviteFunnelRow, error) {
+	rows, err := q.db.QueryContext(ctx, getInviteFunnel,
+		arg.PrefixLength,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AsOf,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetInviteFunnelRow{}
+	for rows.Next() {
+		var i GetInviteFunnelRow
+		if err := rows.Scan(
+			&i.CodeGroup,
+			&i.Redemptions,
+			&i.Activated,
+			&i.RetentionEligible,
+			&i.Retained,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const resetInviteCode = `-- name: ResetInviteCode :exec
 UPDATE invite_codes 
 SET is_used = false, redeemed_by = NULL, redeemed_at = NULL, updated_at = NOW() 
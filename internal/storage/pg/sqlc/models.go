@@ -11,6 +11,144 @@ import (
 	"github.com/google/uuid"
 )
 
+type AppAttestKey struct {
+	KeyID       string    `json:"keyId"`
+	UserID      string    `json:"userId"`
+	PublicKey   []byte    `json:"publicKey"`
+	SignCount   int64     `json:"signCount"`
+	Environment string    `json:"environment"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type AttestationChallenge struct {
+	Challenge string    `json:"challenge"`
+	UserID    string    `json:"userId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type AuditLog struct {
+	ID        int64     `json:"id"`
+	UserID    string    `json:"userId"`
+	Event     string    `json:"event"`
+	Decision  string    `json:"decision"`
+	Country   *string   `json:"country"`
+	Rule      *string   `json:"rule"`
+	Provider  *string   `json:"provider"`
+	Model     *string   `json:"model"`
+	Path      *string   `json:"path"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Batch struct {
+	BatchID         string       `json:"batchId"`
+	UserID          string       `json:"userId"`
+	Endpoint        string       `json:"endpoint"`
+	Status          string       `json:"status"`
+	Object          string       `json:"object"`
+	CreatedAt       time.Time    `json:"createdAt"`
+	UpdatedAt       time.Time    `json:"updatedAt"`
+	UsageRecordedAt sql.NullTime `json:"usageRecordedAt"`
+}
+
+type BatchFile struct {
+	FileID    string    `json:"fileId"`
+	UserID    string    `json:"userId"`
+	Purpose   string    `json:"purpose"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Chat struct {
+	UserID                   string       `json:"userId"`
+	ChatID                   string       `json:"chatId"`
+	Title                    *string      `json:"title"`
+	EncryptedTitle           *string      `json:"encryptedTitle"`
+	TitlePublicEncryptionKey *string      `json:"titlePublicEncryptionKey"`
+	Language                 *string      `json:"language"`
+	LastResponseID           *string      `json:"lastResponseId"`
+	Pinned                   bool         `json:"pinned"`
+	PinnedAt                 sql.NullTime `json:"pinnedAt"`
+	Archived                 bool         `json:"archived"`
+	Muted                    bool         `json:"muted"`
+	MetadataUpdatedAt        sql.NullTime `json:"metadataUpdatedAt"`
+	LastMessageAt            sql.NullTime `json:"lastMessageAt"`
+	CreatedAt                time.Time    `json:"createdAt"`
+	UpdatedAt                time.Time    `json:"updatedAt"`
+}
+
+type ChatDraft struct {
+	UserID              string    `json:"userId"`
+	ChatID              string    `json:"chatId"`
+	EncryptedContent    string    `json:"encryptedContent"`
+	PublicEncryptionKey string    `json:"publicEncryptionKey"`
+	UpdatedAt           time.Time `json:"updatedAt"`
+}
+
+type ChatMessage struct {
+	UserID                  string       `json:"userId"`
+	ChatID                  string       `json:"chatId"`
+	MessageID               string       `json:"messageId"`
+	EncryptedContent        string       `json:"encryptedContent"`
+	PublicEncryptionKey     string       `json:"publicEncryptionKey"`
+	IsFromUser              bool         `json:"isFromUser"`
+	IsError                 bool         `json:"isError"`
+	MessageTimestamp        time.Time    `json:"messageTimestamp"`
+	Stopped                 bool         `json:"stopped"`
+	StoppedBy               string       `json:"stoppedBy"`
+	StopReason              string       `json:"stopReason"`
+	Model                   string       `json:"model"`
+	GenerationState         string       `json:"generationState"`
+	GenerationStartedAt     sql.NullTime `json:"generationStartedAt"`
+	GenerationCompletedAt   sql.NullTime `json:"generationCompletedAt"`
+	GenerationError         string       `json:"generationError"`
+	EncryptedMaskedKeywords string       `json:"encryptedMaskedKeywords"`
+	EncryptedReasoning      string       `json:"encryptedReasoning"`
+	DeliveredAt             sql.NullTime `json:"deliveredAt"`
+	ReadAt                  sql.NullTime `json:"readAt"`
+	CreatedAt               time.Time    `json:"createdAt"`
+	UpdatedAt               time.Time    `json:"updatedAt"`
+}
+
+type ChatSearchToken struct {
+	UserID    string    `json:"userId"`
+	ChatID    string    `json:"chatId"`
+	MessageID string    `json:"messageId"`
+	TokenHash []byte    `json:"tokenHash"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type DailyProviderUsageRollup struct {
+	Day         time.Time `json:"day"`
+	Provider    string    `json:"provider"`
+	Requests    int64     `json:"requests"`
+	TotalTokens int64     `json:"totalTokens"`
+	PlanTokens  int64     `json:"planTokens"`
+	ComputedAt  time.Time `json:"computedAt"`
+}
+
+type DailyTierUsageRollup struct {
+	Day         time.Time `json:"day"`
+	Tier        string    `json:"tier"`
+	ActiveUsers int64     `json:"activeUsers"`
+	Requests    int64     `json:"requests"`
+	TotalTokens int64     `json:"totalTokens"`
+	PlanTokens  int64     `json:"planTokens"`
+	ComputedAt  time.Time `json:"computedAt"`
+}
+
+type DailyUsageRollup struct {
+	Day               time.Time `json:"day"`
+	ActiveUsers       int64     `json:"activeUsers"`
+	WeeklyActiveUsers int64     `json:"weeklyActiveUsers"`
+	Requests          int64     `json:"requests"`
+	TotalTokens       int64     `json:"totalTokens"`
+	PlanTokens        int64     `json:"planTokens"`
+	DeepResearchUsers int64     `json:"deepResearchUsers"`
+	DeepResearchRuns  int64     `json:"deepResearchRuns"`
+	ComputedAt        time.Time `json:"computedAt"`
+}
+
 type DeepResearchMessage struct {
 	ID          string       `json:"id"`
 	UserID      string       `json:"userId"`
@@ -35,6 +173,15 @@ type DeepResearchRun struct {
 	CompletedAt     sql.NullTime `json:"completedAt"`
 }
 
+type DigestSubscription struct {
+	UserID       string    `json:"userId"`
+	Enabled      bool      `json:"enabled"`
+	Email        *string   `json:"email"`
+	NextDigestAt time.Time `json:"nextDigestAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
 type Entitlement struct {
 	UserID                string       `json:"userId"`
 	SubscriptionExpiresAt sql.NullTime `json:"subscriptionExpiresAt"`
@@ -44,6 +191,17 @@ type Entitlement struct {
 	// Stripe Customer ID for billing portal access (cus_xxx)
 	StripeCustomerID *string `json:"stripeCustomerId"`
 	SubscriptionTier string  `json:"subscriptionTier"`
+	AutoRenew        *bool   `json:"autoRenew"`
+	InGracePeriod    bool    `json:"inGracePeriod"`
+}
+
+type EntitlementEvent struct {
+	ID                    int64        `json:"id"`
+	UserID                string       `json:"userId"`
+	SubscriptionTier      string       `json:"subscriptionTier"`
+	SubscriptionProvider  string       `json:"subscriptionProvider"`
+	SubscriptionExpiresAt sql.NullTime `json:"subscriptionExpiresAt"`
+	CreatedAt             time.Time    `json:"createdAt"`
 }
 
 type FaiPaymentIntent struct {
@@ -63,6 +221,13 @@ type FaiPaymentIntent struct {
 	PaidAt       sql.NullTime    `json:"paidAt"`
 }
 
+type GooglePlayPurchase struct {
+	PurchaseToken string    `json:"purchaseToken"`
+	UserID        string    `json:"userId"`
+	ProductID     string    `json:"productId"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
 type InviteCode struct {
 	ID         int64      `json:"id"`
 	Code       string     `json:"code"`
@@ -79,6 +244,33 @@ type InviteCode struct {
 	DeletedAt  *time.Time `json:"deletedAt"`
 }
 
+type MessageFeedback struct {
+	UserID    string    `json:"userId"`
+	ChatID    string    `json:"chatId"`
+	MessageID string    `json:"messageId"`
+	Model     string    `json:"model"`
+	Rating    int16     `json:"rating"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type MessageIndex struct {
+	UserID                string        `json:"userId"`
+	ChatID                string        `json:"chatId"`
+	MessageID             string        `json:"messageId"`
+	IsFromUser            bool          `json:"isFromUser"`
+	IsError               bool          `json:"isError"`
+	Stopped               bool          `json:"stopped"`
+	Model                 *string       `json:"model"`
+	GenerationState       *string       `json:"generationState"`
+	PromptTokens          sql.NullInt32 `json:"promptTokens"`
+	CompletionTokens      sql.NullInt32 `json:"completionTokens"`
+	MessageTimestamp      time.Time     `json:"messageTimestamp"`
+	GenerationCompletedAt sql.NullTime  `json:"generationCompletedAt"`
+	CreatedAt             time.Time     `json:"createdAt"`
+	UpdatedAt             time.Time     `json:"updatedAt"`
+}
+
 type ProblemReport struct {
 	ID                     string        `json:"id"`
 	UserID                 string        `json:"userId"`
@@ -101,18 +293,55 @@ type ProblemReport struct {
 	UpdatedAt              time.Time     `json:"updatedAt"`
 }
 
+type QuotaExperimentExposure struct {
+	Experiment     string    `json:"experiment"`
+	UserID         string    `json:"userId"`
+	Variant        string    `json:"variant"`
+	Tier           string    `json:"tier"`
+	FirstExposedAt time.Time `json:"firstExposedAt"`
+}
+
 type RequestLog struct {
-	ID               int64          `json:"id"`
-	UserID           string         `json:"userId"`
-	Endpoint         string         `json:"endpoint"`
-	Model            *string        `json:"model"`
-	Provider         string         `json:"provider"`
-	CreatedAt        time.Time      `json:"createdAt"`
-	PromptTokens     sql.NullInt32  `json:"promptTokens"`
-	CompletionTokens sql.NullInt32  `json:"completionTokens"`
-	TotalTokens      sql.NullInt32  `json:"totalTokens"`
-	PlanTokens       sql.NullInt32  `json:"planTokens"`
-	TokenMultiplier  sql.NullString `json:"tokenMultiplier"`
+	ID               int64           `json:"id"`
+	UserID           string          `json:"userId"`
+	Endpoint         string          `json:"endpoint"`
+	Model            *string         `json:"model"`
+	Provider         string          `json:"provider"`
+	CreatedAt        time.Time       `json:"createdAt"`
+	PromptTokens     sql.NullInt32   `json:"promptTokens"`
+	CompletionTokens sql.NullInt32   `json:"completionTokens"`
+	TotalTokens      sql.NullInt32   `json:"totalTokens"`
+	PlanTokens       sql.NullInt32   `json:"planTokens"`
+	TokenMultiplier  sql.NullString  `json:"tokenMultiplier"`
+	ReasoningEffort  *string         `json:"reasoningEffort"`
+	AudioSeconds     sql.NullFloat64 `json:"audioSeconds"`
+	AudioCharacters  sql.NullInt32   `json:"audioCharacters"`
+	StreamAnomalies  *string         `json:"streamAnomalies"`
+	StreamRetries    int32           `json:"streamRetries"`
+	PrivacyMode      bool            `json:"privacyMode"`
+}
+
+type RoutingEntry struct {
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Config      string    `json:"config"`
+	Enabled     bool      `json:"enabled"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type ServiceApiKey struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	KeyHash     string       `json:"keyHash"`
+	KeyPrefix   string       `json:"keyPrefix"`
+	Scopes      string       `json:"scopes"`
+	UserID      string       `json:"userId"`
+	Description string       `json:"description"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	ExpiresAt   sql.NullTime `json:"expiresAt"`
+	RevokedAt   sql.NullTime `json:"revokedAt"`
 }
 
 type Task struct {
@@ -136,6 +365,46 @@ type TelegramChat struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+type UpstreamProvider struct {
+	ID           int64     `json:"id"`
+	BaseUrl      string    `json:"baseUrl"`
+	ApiKeyEnvVar string    `json:"apiKeyEnvVar"`
+	Enabled      bool      `json:"enabled"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type UserBan struct {
+	UserID   string    `json:"userId"`
+	Reason   string    `json:"reason"`
+	BannedAt time.Time `json:"bannedAt"`
+}
+
+type UserDigest struct {
+	ID                    int64     `json:"id"`
+	UserID                string    `json:"userId"`
+	PeriodStart           time.Time `json:"periodStart"`
+	PeriodEnd             time.Time `json:"periodEnd"`
+	RequestCount          int64     `json:"requestCount"`
+	PlanTokens            int64     `json:"planTokens"`
+	TopModel              *string   `json:"topModel"`
+	DeepResearchCompleted int64     `json:"deepResearchCompleted"`
+	TasksCreated          int64     `json:"tasksCreated"`
+	ActiveTasks           int64     `json:"activeTasks"`
+	CreatedAt             time.Time `json:"createdAt"`
+}
+
+type UserPreference struct {
+	UserID        string          `json:"userId"`
+	DefaultModel  *string         `json:"defaultModel"`
+	Temperature   sql.NullFloat64 `json:"temperature"`
+	SystemPrompt  *string         `json:"systemPrompt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+	ContentPolicy string          `json:"contentPolicy"`
+}
+
 type ZcashInvoice struct {
 	ID               uuid.UUID    `json:"id"`
 	UserID           string       `json:"userId"`
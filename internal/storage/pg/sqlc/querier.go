@@ -7,6 +7,7 @@ package pgdb
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,26 +15,64 @@ import (
 type Querier interface {
 	AddDeepResearchMessage(ctx context.Context, arg AddDeepResearchMessageParams) error
 	AtomicUseInviteCode(ctx context.Context, arg AtomicUseInviteCodeParams) error
+	// Bans a user, replacing the reason of an existing ban.
+	BanUser(ctx context.Context, arg BanUserParams) (UserBan, error)
+	// Marks the usage of a batch as recorded. Affects no rows if another instance claimed it first.
+	ClaimBatchUsage(ctx context.Context, batchID string) (int64, error)
+	// Atomically claims due subscriptions and schedules the next digest.
+	// SKIP LOCKED lets concurrent instances claim disjoint batches.
+	ClaimDueDigestSubscriptions(ctx context.Context, limit int32) ([]DigestSubscription, error)
+	// Attaches a purchase token to a user unless it is already attached, and returns the user the
+	// token belongs to.
+	ClaimGooglePlayPurchase(ctx context.Context, arg ClaimGooglePlayPurchaseParams) (string, error)
 	CompleteDeepResearchRun(ctx context.Context, arg CompleteDeepResearchRunParams) error
+	// Deletes and returns the challenge if it belongs to the user and hasn't expired.
+	ConsumeAttestationChallenge(ctx context.Context, arg ConsumeAttestationChallengeParams) (string, error)
+	CountActiveDeepResearchRuns(ctx context.Context, userID string) (int64, error)
 	CountInviteCodesByRedeemedBy(ctx context.Context, redeemedBy *string) (int64, error)
 	CountProblemReportsByUserID(ctx context.Context, userID string) (int64, error)
+	CreateAppAttestKey(ctx context.Context, arg CreateAppAttestKeyParams) error
+	CreateAttestationChallenge(ctx context.Context, arg CreateAttestationChallengeParams) error
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error
+	CreateBatch(ctx context.Context, arg CreateBatchParams) error
+	CreateBatchFile(ctx context.Context, arg CreateBatchFileParams) error
 	CreateDeepResearchRun(ctx context.Context, arg CreateDeepResearchRunParams) (int64, error)
 	CreateFaiPaymentIntent(ctx context.Context, arg CreateFaiPaymentIntentParams) error
 	CreateInviteCode(ctx context.Context, arg CreateInviteCodeParams) (InviteCode, error)
 	CreateProblemReport(ctx context.Context, arg CreateProblemReportParams) (ProblemReport, error)
 	CreateRequestLog(ctx context.Context, arg CreateRequestLogParams) error
 	CreateRequestLogWithPlanTokens(ctx context.Context, arg CreateRequestLogWithPlanTokensParams) error
+	// Returns no rows if the name already exists.
+	CreateServiceAPIKey(ctx context.Context, arg CreateServiceAPIKeyParams) (ServiceApiKey, error)
 	CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error)
 	CreateTelegramChat(ctx context.Context, arg CreateTelegramChatParams) (TelegramChat, error)
+	// Returns no rows if the base URL already exists.
+	CreateUpstreamProvider(ctx context.Context, arg CreateUpstreamProviderParams) (UpstreamProvider, error)
+	CreateUserDigest(ctx context.Context, arg CreateUserDigestParams) (UserDigest, error)
 	CreateZcashInvoice(ctx context.Context, arg CreateZcashInvoiceParams) error
+	DeleteChatDraft(ctx context.Context, arg DeleteChatDraftParams) error
+	DeleteChatSearchTokens(ctx context.Context, arg DeleteChatSearchTokensParams) error
+	DeleteExpiredAttestationChallenges(ctx context.Context) error
+	DeleteMessageFeedback(ctx context.Context, arg DeleteMessageFeedbackParams) error
+	DeleteRoutingEntry(ctx context.Context, arg DeleteRoutingEntryParams) (string, error)
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
 	DeleteTask(ctx context.Context, arg DeleteTaskParams) (sql.Result, error)
 	DeleteTelegramChat(ctx context.Context, chatID int64) error
+	DeleteUpstreamProvider(ctx context.Context, id int64) (int64, error)
+	DeleteUserChatSearchTokens(ctx context.Context, userID string) error
+	DeleteUserPreferences(ctx context.Context, userID string) error
 	DeleteZcashInvoice(ctx context.Context, id uuid.UUID) error
 	GetActiveDeepResearchRun(ctx context.Context, arg GetActiveDeepResearchRunParams) (GetActiveDeepResearchRunRow, error)
 	GetAllActiveTasks(ctx context.Context) ([]Task, error)
 	GetAllInviteCodes(ctx context.Context) ([]InviteCode, error)
+	GetAppAttestKey(ctx context.Context, keyID string) (AppAttestKey, error)
+	GetBatch(ctx context.Context, arg GetBatchParams) (Batch, error)
+	GetBatchFile(ctx context.Context, arg GetBatchFileParams) (BatchFile, error)
+	GetChat(ctx context.Context, arg GetChatParams) (Chat, error)
+	GetChatDraft(ctx context.Context, arg GetChatDraftParams) (ChatDraft, error)
+	GetChatMessage(ctx context.Context, arg GetChatMessageParams) (ChatMessage, error)
 	GetDeepResearchRunCountForChat(ctx context.Context, arg GetDeepResearchRunCountForChatParams) (int64, error)
+	GetDigestSubscription(ctx context.Context, userID string) (DigestSubscription, error)
 	GetEntitlement(ctx context.Context, userID string) (GetEntitlementRow, error)
 	GetExpiredPendingFaiPaymentIntents(ctx context.Context, limit int32) ([]FaiPaymentIntent, error)
 	GetExpiredPendingInvoices(ctx context.Context, limit int32) ([]ZcashInvoice, error)
@@ -41,7 +80,17 @@ type Querier interface {
 	GetFaiPaymentIntentForUser(ctx context.Context, arg GetFaiPaymentIntentForUserParams) (FaiPaymentIntent, error)
 	GetInviteCodeByCodeHash(ctx context.Context, codeHash string) (InviteCode, error)
 	GetInviteCodeByID(ctx context.Context, id int64) (InviteCode, error)
+	// Funnel of invite codes redeemed in [from_time, to_time), grouped by code prefix (the whole
+	// code when prefix_length is 0): redeemers with a request after redeeming (activated), and of
+	// those whose second week after redeeming has ended by as_of, how many made a request in it.
+	GetInviteFunnel(ctx context.Context, arg GetInviteFunnelParams) ([]GetInviteFunnelRow, error)
+	// Positive and negative ratings per model, by time of the latest rating in [from_time, to_time).
+	GetModelFeedbackCounts(ctx context.Context, arg GetModelFeedbackCountsParams) ([]GetModelFeedbackCountsRow, error)
+	// Requests per model and provider in [from_time, to_time): all of them, those whose stream was
+	// flagged with an anomaly, those retried after one, and those with either.
+	GetModelRequestSignals(ctx context.Context, arg GetModelRequestSignalsParams) ([]GetModelRequestSignalsRow, error)
 	GetProblemReportByID(ctx context.Context, id string) (ProblemReport, error)
+	GetServiceAPIKeyByHash(ctx context.Context, keyHash string) (ServiceApiKey, error)
 	GetSessionMessageCount(ctx context.Context, sessionID string) (int64, error)
 	GetSessionMessages(ctx context.Context, sessionID string) ([]DeepResearchMessage, error)
 	GetStripeCustomerID(ctx context.Context, userID string) (*string, error)
@@ -52,8 +101,17 @@ type Querier interface {
 	GetTelegramChatByChatUUID(ctx context.Context, chatUuid string) (TelegramChat, error)
 	GetUnsentMessageCount(ctx context.Context, sessionID string) (int64, error)
 	GetUnsentMessages(ctx context.Context, sessionID string) ([]DeepResearchMessage, error)
+	GetUpstreamProvider(ctx context.Context, id int64) (UpstreamProvider, error)
+	// Audio seconds (transcribed + synthesized) used this month, for tier audio quotas.
+	GetUserAudioSecondsThisMonth(ctx context.Context, userID string) (float64, error)
+	// Audio seconds (transcribed + synthesized) used today, for tier audio quotas.
+	// Performance: The partial idx_request_logs_audio_seconds index keeps this fast.
+	GetUserAudioSecondsToday(ctx context.Context, userID string) (float64, error)
+	GetUserBan(ctx context.Context, userID string) (UserBan, error)
 	GetUserDeepResearchRunsLifetime(ctx context.Context, userID string) (int64, error)
 	GetUserDeepResearchRunsToday(ctx context.Context, userID string) (int64, error)
+	// Aggregates usage, completed deep research, and tasks for [period_start, period_end).
+	GetUserDigestStats(ctx context.Context, arg GetUserDigestStatsParams) (GetUserDigestStatsRow, error)
 	// Returns plan tokens used today on the fallback model.
 	// Used for tracking fallback quota when normal quota is exceeded.
 	GetUserFallbackPlanTokensToday(ctx context.Context, arg GetUserFallbackPlanTokensTodayParams) (int64, error)
@@ -68,32 +126,97 @@ type Querier interface {
 	// Queries request_logs directly for real-time data (not materialized view).
 	// Performance: The idx_request_logs_plan_tokens index on (user_id, created_at, plan_tokens) keeps this fast.
 	GetUserPlanTokensToday(ctx context.Context, userID string) (int64, error)
+	GetUserPreferences(ctx context.Context, userID string) (UserPreference, error)
 	GetUserTier(ctx context.Context, userID string) (GetUserTierRow, error)
 	GetZcashInvoice(ctx context.Context, id uuid.UUID) (ZcashInvoice, error)
 	GetZcashInvoiceForUser(ctx context.Context, arg GetZcashInvoiceForUserParams) (ZcashInvoice, error)
 	GetZcashInvoicesByUserAndStatus(ctx context.Context, arg GetZcashInvoicesByUserAndStatusParams) ([]ZcashInvoice, error)
 	HasActiveDeepResearchRun(ctx context.Context, userID string) (bool, error)
+	// Lists a user's batches, newest first, starting after the batch ID after (empty = first page).
+	ListBatches(ctx context.Context, arg ListBatchesParams) ([]Batch, error)
+	// Batches whose usage has not been recorded yet, oldest first.
+	ListBatchesPendingUsage(ctx context.Context, limit int32) ([]Batch, error)
+	ListDailyProviderUsageRollups(ctx context.Context, arg ListDailyProviderUsageRollupsParams) ([]DailyProviderUsageRollup, error)
+	ListDailyTierUsageRollups(ctx context.Context, arg ListDailyTierUsageRollupsParams) ([]DailyTierUsageRollup, error)
+	ListDailyUsageRollups(ctx context.Context, arg ListDailyUsageRollupsParams) ([]DailyUsageRollup, error)
+	ListEntitlementEvents(ctx context.Context, arg ListEntitlementEventsParams) ([]EntitlementEvent, error)
+	// Keyset-paginated scan of logs with token usage but no plan tokens (or all logs with
+	// token usage when overwrite is set). Used by cmd/backfill-plan-tokens.
+	ListRequestLogsForPlanTokenBackfill(ctx context.Context, arg ListRequestLogsForPlanTokenBackfillParams) ([]ListRequestLogsForPlanTokenBackfillRow, error)
+	ListRoutingEntries(ctx context.Context) ([]RoutingEntry, error)
+	ListServiceAPIKeys(ctx context.Context) ([]ServiceApiKey, error)
 	ListTelegramChats(ctx context.Context) ([]TelegramChat, error)
+	ListUpstreamProviders(ctx context.Context) ([]UpstreamProvider, error)
+	ListUserDigests(ctx context.Context, arg ListUserDigestsParams) ([]UserDigest, error)
 	MarkAllMessagesAsSent(ctx context.Context, sessionID string) error
 	MarkMessageAsSent(ctx context.Context, id string) error
+	// Keeps the first exposure; a user's variant never changes during an experiment.
+	RecordQuotaExperimentExposure(ctx context.Context, arg RecordQuotaExperimentExposureParams) error
+	// Replaces a message's tokens: tokens no longer present are deleted, new ones inserted.
+	// Tokens kept keep their created_at, so re-uploading a message doesn't reorder results.
+	ReplaceChatSearchTokens(ctx context.Context, arg ReplaceChatSearchTokensParams) error
+	// Stops counting a user's completed runs (all of them, or today's) against the daily and
+	// lifetime limits. The runs are kept for usage reporting.
+	ResetDeepResearchRuns(ctx context.Context, arg ResetDeepResearchRunsParams) (int64, error)
 	ResetInviteCode(ctx context.Context, codeHash string) error
+	RevokeServiceAPIKey(ctx context.Context, id int64) (int64, error)
+	// Messages indexed with every token, most recently indexed first.
+	SearchChatMessages(ctx context.Context, arg SearchChatMessagesParams) ([]SearchChatMessagesRow, error)
+	// Records the renewal state reported by the subscription provider.
+	SetEntitlementRenewal(ctx context.Context, arg SetEntitlementRenewalParams) error
 	SoftDeleteInviteCode(ctx context.Context, id int64) error
+	// Records a new message in a chat, creating the chat on its first message.
+	TouchChat(ctx context.Context, arg TouchChatParams) error
+	UnbanUser(ctx context.Context, userID string) (int64, error)
+	// Only advances the counter, so a replayed or concurrent assertion with the
+	// same counter returns no rows.
+	UpdateAppAttestKeySignCount(ctx context.Context, arg UpdateAppAttestKeySignCountParams) (int64, error)
+	UpdateBatch(ctx context.Context, arg UpdateBatchParams) error
+	// Changes the given metadata fields (NULL leaves a field unchanged). pinned_at only changes
+	// when the chat becomes pinned, so clients re-sending the same state don't reorder pinned chats.
+	UpdateChatMetadata(ctx context.Context, arg UpdateChatMetadataParams) (UpdateChatMetadataRow, error)
 	UpdateDeepResearchRunTokens(ctx context.Context, arg UpdateDeepResearchRunTokensParams) error
 	UpdateFaiPaymentIntentToCompleted(ctx context.Context, arg UpdateFaiPaymentIntentToCompletedParams) error
 	UpdateFaiPaymentIntentToExpired(ctx context.Context, id string) error
 	UpdateInviteCodeActive(ctx context.Context, arg UpdateInviteCodeActiveParams) error
 	UpdateInviteCodeUsage(ctx context.Context, arg UpdateInviteCodeUsageParams) error
+	UpdateRequestLogPlanTokens(ctx context.Context, arg UpdateRequestLogPlanTokensParams) error
 	UpdateTaskStatus(ctx context.Context, arg UpdateTaskStatusParams) error
+	UpdateUpstreamProvider(ctx context.Context, arg UpdateUpstreamProviderParams) (UpstreamProvider, error)
 	UpdateZcashInvoiceStatus(ctx context.Context, arg UpdateZcashInvoiceStatusParams) error
 	UpdateZcashInvoiceToExpired(ctx context.Context, id uuid.UUID) error
 	UpdateZcashInvoiceToPaid(ctx context.Context, id uuid.UUID) error
 	UpdateZcashInvoiceToProcessing(ctx context.Context, id uuid.UUID) error
+	UpsertChatDraft(ctx context.Context, arg UpsertChatDraftParams) error
+	// Messages are rewritten as generation progresses (thinking, then completed); the latest
+	// write replaces the whole message, like a Firestore Set.
+	UpsertChatMessage(ctx context.Context, arg UpsertChatMessageParams) error
+	UpsertChatResponseID(ctx context.Context, arg UpsertChatResponseIDParams) error
+	UpsertChatTitle(ctx context.Context, arg UpsertChatTitleParams) error
+	// Rolls up one UTC day of usage per upstream provider.
+	UpsertDailyProviderUsageRollups(ctx context.Context, day time.Time) error
+	// Rolls up one UTC day of usage per subscription tier. Tiers come from the user's current
+	// entitlement; users without one, or whose grant had expired by the start of the day, are free.
+	UpsertDailyTierUsageRollups(ctx context.Context, day time.Time) error
+	// Rolls up one UTC day: active users that day and over the trailing 7 days, requests,
+	// tokens, and deep research adoption. Re-running a day replaces its row.
+	UpsertDailyUsageRollup(ctx context.Context, day time.Time) error
+	// New subscriptions receive their first digest one week after opting in.
+	UpsertDigestSubscription(ctx context.Context, arg UpsertDigestSubscriptionParams) (DigestSubscription, error)
 	UpsertEntitlement(ctx context.Context, arg UpsertEntitlementParams) error
 	// Grants or extends an entitlement. For same-tier renewals where the current
 	// subscription is still active (expires after invoice creation), extends from
 	// the current expiration. Otherwise starts from the provided base time.
 	UpsertEntitlementWithExtension(ctx context.Context, arg UpsertEntitlementWithExtensionParams) error
 	UpsertEntitlementWithTier(ctx context.Context, arg UpsertEntitlementWithTierParams) error
+	// Rates an assistant message of the user, attributed to the model recorded in the message
+	// index. No row is written if the user has no such message with a known model.
+	UpsertMessageFeedback(ctx context.Context, arg UpsertMessageFeedbackParams) (int64, error)
+	// Messages are saved more than once (e.g., "thinking" then "completed"); the latest write wins
+	// except for token counts, which are kept when a later write doesn't carry them.
+	UpsertMessageIndexEntry(ctx context.Context, arg UpsertMessageIndexEntryParams) error
+	UpsertRoutingEntry(ctx context.Context, arg UpsertRoutingEntryParams) (RoutingEntry, error)
+	UpsertUserPreferences(ctx context.Context, arg UpsertUserPreferencesParams) (UserPreference, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -11,8 +11,8 @@ import (
 )
 
 const createRequestLog = `-- name: CreateRequestLog :exec
-INSERT INTO request_logs (user_id, endpoint, model, provider, prompt_tokens, completion_tokens, total_tokens) 
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO request_logs (user_id, endpoint, model, provider, prompt_tokens, completion_tokens, total_tokens, privacy_mode) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 `
 
 type CreateRequestLogParams struct {
@@ -23,6 +23,7 @@ type CreateRequestLogParams struct {
 	PromptTokens     sql.NullInt32 `json:"promptTokens"`
 	CompletionTokens sql.NullInt32 `json:"completionTokens"`
 	TotalTokens      sql.NullInt32 `json:"totalTokens"`
+	PrivacyMode      bool          `json:"privacyMode"`
 }
 
 func (q *Queries) CreateRequestLog(ctx context.Context, arg CreateRequestLogParams) error {
@@ -34,6 +35,7 @@ func (q *Queries) CreateRequestLog(ctx context.Context, arg CreateRequestLogPara
 		arg.PromptTokens,
 		arg.CompletionTokens,
 		arg.TotalTokens,
+		arg.PrivacyMode,
 	)
 	return err
 }
@@ -42,20 +44,28 @@ const createRequestLogWithPlanTokens = `-- name: CreateRequestLogWithPlanTokens
 INSERT INTO request_logs (
     user_id, endpoint, model, provider,
     prompt_tokens, completion_tokens, total_tokens,
-    plan_tokens, token_multiplier
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    plan_tokens, token_multiplier, reasoning_effort,
+    audio_seconds, audio_characters, stream_anomalies, stream_retries,
+    privacy_mode
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 `
 
 type CreateRequestLogWithPlanTokensParams struct {
-	UserID           string         `json:"userId"`
-	Endpoint         string         `json:"endpoint"`
-	Model            *string        `json:"model"`
-	Provider         string         `json:"provider"`
-	PromptTokens     sql.NullInt32  `json:"promptTokens"`
-	CompletionTokens sql.NullInt32  `json:"completionTokens"`
-	TotalTokens      sql.NullInt32  `json:"totalTokens"`
-	PlanTokens       sql.NullInt32  `json:"planTokens"`
-	TokenMultiplier  sql.NullString `json:"tokenMultiplier"`
+	UserID           string          `json:"userId"`
+	Endpoint         string          `json:"endpoint"`
+	Model            *string         `json:"model"`
+	Provider         string          `json:"provider"`
+	PromptTokens     sql.NullInt32   `json:"promptTokens"`
+	CompletionTokens sql.NullInt32   `json:"completionTokens"`
+	TotalTokens      sql.NullInt32   `json:"totalTokens"`
+	PlanTokens       sql.NullInt32   `json:"planTokens"`
+	TokenMultiplier  sql.NullString  `json:"tokenMultiplier"`
+	ReasoningEffort  *string         `json:"reasoningEffort"`
+	AudioSeconds     sql.NullFloat64 `json:"audioSeconds"`
+	AudioCharacters  sql.NullInt32   `json:"audioCharacters"`
+	StreamAnomalies  *string         `json:"streamAnomalies"`
+	StreamRetries    int32           `json:"streamRetries"`
+	PrivacyMode      bool            `json:"privacyMode"`
 }
 
 func (q *Queries) CreateRequestLogWithPlanTokens(ctx context.Context, arg CreateRequestLogWithPlanTokensParams) error {
@@ -69,10 +79,49 @@ func (q *Queries) CreateRequestLogWithPlanTokens(ctx context.Context, arg Create
 		arg.TotalTokens,
 		arg.PlanTokens,
 		arg.TokenMultiplier,
+		arg.ReasoningEffort,
+		arg.AudioSeconds,
+		arg.AudioCharacters,
+		arg.StreamAnomalies,
+		arg.StreamRetries,
+		arg.PrivacyMode,
 	)
 	return err
 }
 
+const getUserAudioSecondsThisMonth = `-- name: GetUserAudioSecondsThisMonth :one
+SELECT COALESCE(SUM(audio_seconds), 0)::DOUBLE PRECISION as audio_seconds
+FROM request_logs
+WHERE user_id = $1
+  AND created_at >= DATE_TRUNC('month', NOW() AT TIME ZONE 'UTC')
+  AND audio_seconds IS NOT NULL
+`
+
+// Audio seconds (transcribed + synthesized) used this month, for tier audio quotas.
+func (q *Queries) GetUserAudioSecondsThisMonth(ctx context.Context, userID string) (float64, error) {
+	row := q.db.QueryRowContext(ctx, getUserAudioSecondsThisMonth, userID)
+	var audio_seconds float64
+	err := row.Scan(&audio_seconds)
+	return audio_seconds, err
+}
+
+const getUserAudioSecondsToday = `-- name: GetUserAudioSecondsToday :one
+SELECT COALESCE(SUM(audio_seconds), 0)::DOUBLE PRECISION as audio_seconds
+FROM request_logs
+WHERE user_id = $1
+  AND created_at >= DATE_TRUNC('day', NOW() AT TIME ZONE 'UTC')
+  AND audio_seconds IS NOT NULL
+`
+
+// Audio seconds (transcribed + synthesized) used today, for tier audio quotas.
+// Performance: The partial idx_request_logs_audio_seconds index keeps this fast.
+func (q *Queries) GetUserAudioSecondsToday(ctx context.Context, userID string) (float64, error) {
+	row := q.db.QueryRowContext(ctx, getUserAudioSecondsToday, userID)
+	var audio_seconds float64
+	err := row.Scan(&audio_seconds)
+	return audio_seconds, err
+}
+
 const getUserFallbackPlanTokensToday = `-- name: GetUserFallbackPlanTokensToday :one
 SELECT COALESCE(SUM(plan_tokens), 0)::BIGINT as plan_tokens
 FROM request_logs
@@ -148,3 +197,76 @@ func (q *Queries) GetUserPlanTokensToday(ctx context.Context, userID string) (in
 	err := row.Scan(&plan_tokens)
 	return plan_tokens, err
 }
+
+const listRequestLogsForPlanTokenBackfill = `-- name: ListRequestLogsForPlanTokenBackfill :many
+SELECT id, model, provider, total_tokens, plan_tokens
+FROM request_logs
+WHERE id > $1
+  AND total_tokens IS NOT NULL
+  AND (plan_tokens IS NULL OR $2::boolean)
+ORDER BY id
+LIMIT $3
+`
+
+type ListRequestLogsForPlanTokenBackfillParams struct {
+	AfterID   int64 `json:"afterId"`
+	Overwrite bool  `json:"overwrite"`
+	BatchSize int32 `json:"batchSize"`
+}
+
+type ListRequestLogsForPlanTokenBackfillRow struct {
+	ID          int64         `json:"id"`
+	Model       *string       `json:"model"`
+	Provider    string        `json:"provider"`
+	TotalTokens sql.NullInt32 `json:"totalTokens"`
+	PlanTokens  sql.NullInt32 `json:"planTokens"`
+}
+
+// Keyset-paginated scan of logs with token usage but no plan tokens (or all logs with
+// token usage when overwrite is set). Used by cmd/backfill-plan-tokens.
+func (q *Queries) ListRequestLogsForPlanTokenBackfill(ctx context.Context, arg ListRequestLogsForPlanTokenBackfillParams) ([]ListRequestLogsForPlanTokenBackfillRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRequestLogsForPlanTokenBackfill, arg.AfterID, arg.Overwrite, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRequestLogsForPlanTokenBackfillRow{}
+	for rows.Next() {
+		var i ListRequestLogsForPlanTokenBackfillRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Model,
+			&i.Provider,
+			&i.TotalTokens,
+			&i.PlanTokens,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateRequestLogPlanTokens = `-- name: UpdateRequestLogPlanTokens :exec
+UPDATE request_logs
+SET plan_tokens = $2,
+    token_multiplier = $3
+WHERE id = $1
+`
+
+type UpdateRequestLogPlanTokensParams struct {
+	ID              int64          `json:"id"`
+	PlanTokens      sql.NullInt32  `json:"planTokens"`
+	TokenMultiplier sql.NullString `json:"tokenMultiplier"`
+}
+
+func (q *Queries) UpdateRequestLogPlanTokens(ctx context.Context, arg UpdateRequestLogPlanTokensParams) error {
+	_, err := q.db.ExecContext(ctx, updateRequestLogPlanTokens, arg.ID, arg.PlanTokens, arg.TokenMultiplier)
+	return err
+}
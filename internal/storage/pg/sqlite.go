@@ -0,0 +1,278 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+// SQLite support for self-hosted single-binary deployments (DATABASE_URL=sqlite:<path>).
+//
+// The generated queries are written for Postgres. Instead of maintaining a second set, the
+// SQLite connections translate them on the fly: casts are dropped, NOW() and interval
+// arithmetic become strftime, DATE_TRUNC becomes strftime modifiers and row locks are removed
+// (SQLite serializes writers). Queries that can't be translated that way have a SQLite
+// version in sqliteQueries. Every generated query is checked against the SQLite schema in
+// the tests.
+//
+// Timestamps are stored as UTC text in sqliteTimeFormat, so they compare correctly as
+// strings and with the timestamps SQLite computes.
+
+// sqliteScheme prefixes DATABASE_URL for SQLite; the rest is a file path or a file: URI.
+const sqliteScheme = "sqlite:"
+
+// sqliteTimeFormat is the format of stored timestamps, the one SQLite's strftime produces
+// with '%Y-%m-%d %H:%M:%f'.
+const sqliteTimeFormat = "2006-01-02 15:04:05.000"
+
+// IsSQLite reports whether a DATABASE_URL selects SQLite.
+func IsSQLite(databaseURL string) bool {
+	return strings.HasPrefix(databaseURL, sqliteScheme)
+}
+
+// sqliteConnector opens SQLite connections that accept Postgres queries.
+type sqliteConnector struct {
+	dsn string
+}
+
+func (c *sqliteConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.Driver().Open(c.dsn)
+}
+
+func (c *sqliteConnector) Driver() driver.Driver {
+	return sqliteDriver{}
+}
+
+type sqliteDriver struct{}
+
+func (sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := (&sqlite.Driver{}).Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn: conn.(sqliteBaseConn)}, nil
+}
+
+// sqliteBaseConn is the part of the SQLite driver's connection the wrapper uses.
+type sqliteBaseConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+}
+
+type sqliteConn struct {
+	conn sqliteBaseConn
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.conn.PrepareContext(ctx, translateQuery(query))
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteStmt{Stmt: stmt}, nil
+}
+
+func (c *sqliteConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *sqliteConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.conn.BeginTx(ctx, opts)
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.conn.ExecContext(ctx, translateQuery(query), args)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.conn.QueryContext(ctx, translateQuery(query), args)
+	if err != nil {
+		return nil, err
+	}
+	return newSQLiteRows(rows), nil
+}
+
+// CheckNamedValue converts arguments to what the SQLite queries expect: timestamps to
+// sqliteTimeFormat in UTC and bytea arrays to JSON arrays of hex strings (read with
+// json_each and unhex).
+func (c *sqliteConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if array, ok := nv.Value.(*pq.ByteaArray); ok {
+		values := make([]string, len(*array))
+		for i, value := range *array {
+			values[i] = strings.ToUpper(hex.EncodeToString(value))
+		}
+		data, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		nv.Value = string(data)
+		return nil
+	}
+
+	value, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(sqliteTimeFormat)
+	}
+	nv.Value = value
+	return nil
+}
+
+type sqliteStmt struct {
+	driver.Stmt
+}
+
+func (s *sqliteStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *sqliteStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *sqliteStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.Stmt.(driver.StmtExecContext).ExecContext(ctx, args)
+}
+
+func (s *sqliteStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := s.Stmt.(driver.StmtQueryContext).QueryContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return newSQLiteRows(rows), nil
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// sqliteRows returns computed timestamps (e.g., MAX(created_at)), which SQLite types as text,
+// as time.Time. Timestamp columns are already converted by the driver.
+type sqliteRows struct {
+	driver.Rows
+	computed []bool // Columns without a declared type
+}
+
+func newSQLiteRows(rows driver.Rows) *sqliteRows {
+	r := &sqliteRows{Rows: rows, computed: make([]bool, len(rows.Columns()))}
+	if typed, ok := rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		for i := range r.computed {
+			r.computed[i] = typed.ColumnTypeDatabaseTypeName(i) == ""
+		}
+	}
+	return r
+}
+
+func (r *sqliteRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		return err
+	}
+	for i, value := range dest {
+		s, ok := value.(string)
+		if !ok || !r.computed[i] || len(s) != len(sqliteTimeFormat) {
+			continue
+		}
+		if t, err := time.Parse(sqliteTimeFormat, s); err == nil {
+			dest[i] = t
+		}
+	}
+	return nil
+}
+
+// sqliteNow is the current time in sqliteTimeFormat; modifiers (e.g., '-7 days') are appended.
+const sqliteNow = `strftime('%Y-%m-%d %H:%M:%f', 'now'`
+
+var (
+	queryNamePattern = regexp.MustCompile(`^-- name: (\w+)`)
+	castPattern      = regexp.MustCompile(`::(?i:double precision|[a-z]+)(\[\])?`)
+	dateTruncPattern = regexp.MustCompile(`(?i)DATE_TRUNC\('(day|week|month)',\s*NOW\(\)(?:\s+AT TIME ZONE 'UTC')?\)`)
+	intervalPattern  = regexp.MustCompile(`(?i)(NOW\(\)|[a-z_][\w.]*)\s*([+-])\s*INTERVAL\s*'(\d+) (\w+)'`)
+	nowPattern       = regexp.MustCompile(`(?i)\bNOW\(\)`)
+	leftPattern      = regexp.MustCompile(`(?i)\bLEFT\(([^,()]+),`)
+	lockPattern      = regexp.MustCompile(`(?i)\s+FOR UPDATE(\s+SKIP LOCKED)?`)
+)
+
+// dateTruncModifiers are the strftime modifiers of DATE_TRUNC(unit, NOW()) in UTC. Weeks
+// start on Monday, like in Postgres.
+var dateTruncModifiers = map[string]string{
+	"day":   `'start of day'`,
+	"week":  `'start of day', '-6 days', 'weekday 1'`,
+	"month": `'start of month'`,
+}
+
+// translatedQueries caches translateQuery, which runs on every statement.
+var translatedQueries sync.Map
+
+// translateQuery returns the SQLite version of a Postgres query: its entry in sqliteQueries,
+// or the query rewritten as described above.
+func translateQuery(query string) string {
+	if translated, ok := translatedQueries.Load(query); ok {
+		return translated.(string)
+	}
+
+	translated, ok := "", false
+	if match := queryNamePattern.FindStringSubmatch(query); match != nil {
+		translated, ok = sqliteQueries[match[1]]
+	}
+	if !ok {
+		translated = castPattern.ReplaceAllString(query, "")
+		translated = dateTruncPattern.ReplaceAllStringFunc(translated, func(s string) string {
+			unit := strings.ToLower(dateTruncPattern.FindStringSubmatch(s)[1])
+			return sqliteNow + ", " + dateTruncModifiers[unit] + ")"
+		})
+		translated = intervalPattern.ReplaceAllStringFunc(translated, func(s string) string {
+			match := intervalPattern.FindStringSubmatch(s)
+			base := "'now'"
+			if !strings.EqualFold(match[1], "NOW()") {
+				base = match[1]
+			}
+			return `strftime('%Y-%m-%d %H:%M:%f', ` + base + `, '` + match[2] + match[3] + " " + match[4] + `')`
+		})
+		translated = nowPattern.ReplaceAllString(translated, sqliteNow+")")
+		translated = leftPattern.ReplaceAllString(translated, "substr($1, 1,")
+		translated = lockPattern.ReplaceAllString(translated, "")
+	}
+
+	translatedQueries.Store(query, translated)
+	return translated
+}
+
+// openSQLite opens a SQLite database. Writes wait for each other instead of failing with
+// SQLITE_BUSY, and a single connection is used: SQLite serializes writers anyway, and an
+// in-memory database (:memory:) exists per connection.
+func openSQLite(path string) *sql.DB {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	dsn := path + separator + "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+
+	db := sql.OpenDB(&sqliteConnector{dsn: dsn})
+	db.SetMaxOpenConns(1)
+	return db
+}
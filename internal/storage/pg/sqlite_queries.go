@@ -0,0 +1,145 @@
+package pg
+
+// sqliteQueries are the SQLite versions of the generated queries translateQuery can't
+// rewrite, by query name. Parameters keep their Postgres numbers ($1 is the first argument);
+// bytea arrays arrive as JSON arrays of hex strings.
+var sqliteQueries = map[string]string{
+	// Data-modifying CTEs don't exist in SQLite: delete, then insert (in one Exec call)
+	"ReplaceChatSearchTokens": `
+DELETE FROM chat_search_tokens
+WHERE user_id = $1
+  AND chat_id = $2
+  AND message_id = $3
+  AND hex(token_hash) NOT IN (SELECT value FROM json_each($4));
+INSERT INTO chat_search_tokens (user_id, chat_id, message_id, token_hash)
+SELECT $1, $2, $3, unhex(value) FROM json_each($4) WHERE true
+ON CONFLICT DO NOTHING`,
+
+	"SearchChatMessages": `
+SELECT chat_id, message_id, MAX(created_at) AS indexed_at
+FROM chat_search_tokens
+WHERE user_id = $1
+  AND token_hash IN (SELECT unhex(value) FROM json_each($2))
+GROUP BY chat_id, message_id
+HAVING COUNT(*) = $3
+ORDER BY indexed_at DESC
+LIMIT $4`,
+
+	"UpsertEntitlementWithExtension": `
+INSERT INTO entitlements (user_id, subscription_tier, subscription_expires_at, subscription_provider, stripe_customer_id, updated_at)
+VALUES (
+  $1,
+  $2,
+  strftime('%Y-%m-%d %H:%M:%f', $3, '+' || $4 || ' days'),
+  $5,
+  $6,
+  strftime('%Y-%m-%d %H:%M:%f', 'now')
+)
+ON CONFLICT (user_id) DO UPDATE SET
+  subscription_tier = $2,
+  subscription_expires_at =
+    CASE
+      WHEN entitlements.subscription_tier = $2
+           AND entitlements.subscription_expires_at IS NOT NULL
+           AND entitlements.subscription_expires_at > $3
+      THEN strftime('%Y-%m-%d %H:%M:%f', entitlements.subscription_expires_at, '+' || $4 || ' days')
+      ELSE strftime('%Y-%m-%d %H:%M:%f', $3, '+' || $4 || ' days')
+    END,
+  subscription_provider = $5,
+  stripe_customer_id = COALESCE($6, entitlements.stripe_customer_id),
+  updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')`,
+
+	"UpsertDailyUsageRollup": `
+WITH bounds AS (
+    SELECT
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day') AS day,
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day', '-6 days') AS week_start,
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day') AS day_start,
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day', '+1 day') AS day_end
+),
+usage AS (
+    SELECT
+        COUNT(DISTINCT rl.user_id) FILTER (WHERE rl.created_at >= b.day_start) AS active_users,
+        COUNT(DISTINCT rl.user_id) AS weekly_active_users,
+        COUNT(*) FILTER (WHERE rl.created_at >= b.day_start) AS requests,
+        COALESCE(SUM(rl.total_tokens) FILTER (WHERE rl.created_at >= b.day_start), 0) AS total_tokens,
+        COALESCE(SUM(rl.plan_tokens) FILTER (WHERE rl.created_at >= b.day_start), 0) AS plan_tokens
+    FROM request_logs rl, bounds b
+    WHERE rl.created_at >= b.week_start AND rl.created_at < b.day_end
+),
+research AS (
+    SELECT COUNT(DISTINCT dr.user_id) AS users, COUNT(*) AS runs
+    FROM deep_research_runs dr, bounds b
+    WHERE dr.started_at >= b.day_start AND dr.started_at < b.day_end
+)
+INSERT INTO daily_usage_rollups (
+    day, active_users, weekly_active_users, requests, total_tokens, plan_tokens,
+    deep_research_users, deep_research_runs, computed_at
+)
+SELECT b.day, u.active_users, u.weekly_active_users, u.requests, u.total_tokens, u.plan_tokens,
+       r.users, r.runs, strftime('%Y-%m-%d %H:%M:%f', 'now')
+FROM bounds b, usage u, research r
+WHERE true
+ON CONFLICT (day) DO UPDATE
+SET active_users = EXCLUDED.active_users,
+    weekly_active_users = EXCLUDED.weekly_active_users,
+    requests = EXCLUDED.requests,
+    total_tokens = EXCLUDED.total_tokens,
+    plan_tokens = EXCLUDED.plan_tokens,
+    deep_research_users = EXCLUDED.deep_research_users,
+    deep_research_runs = EXCLUDED.deep_research_runs,
+    computed_at = EXCLUDED.computed_at`,
+
+	"UpsertDailyTierUsageRollups": `
+WITH bounds AS (
+    SELECT
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day') AS day,
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day') AS day_start,
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day', '+1 day') AS day_end
+)
+INSERT INTO daily_tier_usage_rollups (day, tier, active_users, requests, total_tokens, plan_tokens, computed_at)
+SELECT
+    b.day,
+    CASE WHEN e.subscription_expires_at > b.day_start THEN e.subscription_tier ELSE 'free' END AS tier,
+    COUNT(DISTINCT rl.user_id),
+    COUNT(*),
+    COALESCE(SUM(rl.total_tokens), 0),
+    COALESCE(SUM(rl.plan_tokens), 0),
+    strftime('%Y-%m-%d %H:%M:%f', 'now')
+FROM request_logs rl
+CROSS JOIN bounds b
+LEFT JOIN entitlements e ON e.user_id = rl.user_id
+WHERE rl.created_at >= b.day_start AND rl.created_at < b.day_end
+GROUP BY 1, 2
+ON CONFLICT (day, tier) DO UPDATE
+SET active_users = EXCLUDED.active_users,
+    requests = EXCLUDED.requests,
+    total_tokens = EXCLUDED.total_tokens,
+    plan_tokens = EXCLUDED.plan_tokens,
+    computed_at = EXCLUDED.computed_at`,
+
+	"UpsertDailyProviderUsageRollups": `
+WITH bounds AS (
+    SELECT
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day') AS day,
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day') AS day_start,
+        strftime('%Y-%m-%d %H:%M:%f', $1, 'start of day', '+1 day') AS day_end
+)
+INSERT INTO daily_provider_usage_rollups (day, provider, requests, total_tokens, plan_tokens, computed_at)
+SELECT
+    b.day,
+    rl.provider,
+    COUNT(*),
+    COALESCE(SUM(rl.total_tokens), 0),
+    COALESCE(SUM(rl.plan_tokens), 0),
+    strftime('%Y-%m-%d %H:%M:%f', 'now')
+FROM request_logs rl
+CROSS JOIN bounds b
+WHERE rl.created_at >= b.day_start AND rl.created_at < b.day_end
+GROUP BY 1, 2
+ON CONFLICT (day, provider) DO UPDATE
+SET requests = EXCLUDED.requests,
+    total_tokens = EXCLUDED.total_tokens,
+    plan_tokens = EXCLUDED.plan_tokens,
+    computed_at = EXCLUDED.computed_at`,
+}
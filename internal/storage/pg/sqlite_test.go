@@ -0,0 +1,310 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+func openTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db := openSQLite(":memory:")
+	t.Cleanup(func() { db.Close() })
+	if err := RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTranslateQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "SELECT COUNT(*)::int, $1::text[] FROM t",
+			want:  "SELECT COUNT(*), $1 FROM t",
+		},
+		{
+			query: "SELECT AVG(x)::double precision FROM t",
+			want:  "SELECT AVG(x) FROM t",
+		},
+		{
+			query: "WHERE created_at >= DATE_TRUNC('day', NOW() AT TIME ZONE 'UTC')",
+			want:  "WHERE created_at >= strftime('%Y-%m-%d %H:%M:%f', 'now', 'start of day')",
+		},
+		{
+			query: "WHERE created_at >= DATE_TRUNC('week', NOW())",
+			want:  "WHERE created_at >= strftime('%Y-%m-%d %H:%M:%f', 'now', 'start of day', '-6 days', 'weekday 1')",
+		},
+		{
+			query: "WHERE created_at < NOW() - INTERVAL '30 days'",
+			want:  "WHERE created_at < strftime('%Y-%m-%d %H:%M:%f', 'now', '-30 days')",
+		},
+		{
+			query: "SET next_digest_at = d.next_digest_at + INTERVAL '7 days', updated_at = NOW()",
+			want:  "SET next_digest_at = strftime('%Y-%m-%d %H:%M:%f', d.next_digest_at, '+7 days'), updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')",
+		},
+		{
+			query: "SELECT LEFT(message, 200) FROM t",
+			want:  "SELECT substr(message, 1, 200) FROM t",
+		},
+		{
+			query: "SELECT * FROM t LIMIT 10 FOR UPDATE SKIP LOCKED",
+			want:  "SELECT * FROM t LIMIT 10",
+		},
+	}
+	for _, tt := range tests {
+		if got := translateQuery(tt.query); got != tt.want {
+			t.Errorf("translateQuery(%q) =\n%q, want\n%q", tt.query, got, tt.want)
+		}
+	}
+}
+
+var parameterPattern = regexp.MustCompile(`\$(\d+)`)
+
+// generatedQueries returns the queries in the generated code, by name.
+func generatedQueries(t *testing.T) map[string]string {
+	t.Helper()
+	files, err := filepath.Glob("sqlc/*.sql.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := make(map[string]string)
+	for _, file := range files {
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			query, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match := queryNamePattern.FindStringSubmatch(query); match != nil {
+				queries[match[1]] = query
+			}
+			return true
+		})
+	}
+	return queries
+}
+
+// TestSQLiteQueries checks that every generated query, translated, compiles against the SQLite
+// schema.
+func TestSQLiteQueries(t *testing.T) {
+	db := openTestSQLite(t)
+
+	queries := generatedQueries(t)
+	if len(queries) == 0 {
+		t.Fatal("no generated queries found")
+	}
+	for name, query := range queries {
+		for _, statement := range strings.Split(translateQuery(query), ";\n") {
+			// SQLite needs a value for every parameter, even to explain a statement
+			args := make([]any, 0)
+			for _, match := range parameterPattern.FindAllStringSubmatch(statement, -1) {
+				if n, _ := strconv.Atoi(match[1]); n > len(args) {
+					args = append(args, make([]any, n-len(args))...)
+				}
+			}
+			if _, err := db.Exec("EXPLAIN "+statement, args...); err != nil {
+				t.Errorf("%s: %v\n%s", name, err, statement)
+			}
+		}
+	}
+	for name := range sqliteQueries {
+		if _, ok := queries[name]; !ok {
+			t.Errorf("sqliteQueries has %s, which is not a generated query", name)
+		}
+	}
+}
+
+// TestSQLiteSchema checks that every SQLite table has the columns of its generated model.
+func TestSQLiteSchema(t *testing.T) {
+	db := openTestSQLite(t)
+
+	models := make(map[string]string) // Sorted JSON names of the fields -> model
+	for _, model := range []any{
+		pgdb.AppAttestKey{}, pgdb.AttestationChallenge{}, pgdb.AuditLog{}, pgdb.Batch{}, pgdb.BatchFile{}, pgdb.Chat{}, pgdb.ChatDraft{},
+		pgdb.ChatMessage{}, pgdb.ChatSearchToken{}, pgdb.DailyProviderUsageRollup{}, pgdb.DailyTierUsageRollup{},
+		pgdb.DailyUsageRollup{}, pgdb.DeepResearchMessage{}, pgdb.DeepResearchRun{}, pgdb.DigestSubscription{},
+		pgdb.Entitlement{}, pgdb.EntitlementEvent{}, pgdb.FaiPaymentIntent{}, pgdb.GooglePlayPurchase{}, pgdb.InviteCode{}, pgdb.MessageFeedback{}, pgdb.MessageIndex{},
+		pgdb.ProblemReport{}, pgdb.QuotaExperimentExposure{}, pgdb.RequestLog{}, pgdb.RoutingEntry{}, pgdb.ServiceApiKey{}, pgdb.Task{}, pgdb.TelegramChat{},
+		pgdb.UpstreamProvider{}, pgdb.UserBan{}, pgdb.UserDigest{}, pgdb.UserPreference{}, pgdb.ZcashInvoice{},
+	} {
+		typ := reflect.TypeOf(model)
+		var fields []string
+		for i := 0; i < typ.NumField(); i++ {
+			fields = append(fields, typ.Field(i).Tag.Get("json"))
+		}
+		sort.Strings(fields)
+		models[strings.Join(fields, ",")] = typ.Name()
+	}
+
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'goose_%' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	if len(tables) != len(models) {
+		t.Errorf("%d tables, want %d (one per model)", len(tables), len(models))
+	}
+	for _, table := range tables {
+		columns, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields []string
+		for columns.Next() {
+			var name string
+			if err := columns.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			fields = append(fields, jsonName(name))
+		}
+		columns.Close()
+
+		sort.Strings(fields)
+		if _, ok := models[strings.Join(fields, ",")]; !ok {
+			t.Errorf("table %s (%s) doesn't match a model", table, strings.Join(fields, ", "))
+		}
+	}
+}
+
+// jsonName returns the JSON name sqlc gives a column (user_id -> userId).
+func jsonName(column string) string {
+	parts := strings.Split(column, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	db := openTestSQLite(t)
+	queries := pgdb.New(db)
+	ctx := context.Background()
+
+	// Timestamps, NOW() and computed columns
+	for _, tokens := range []int32{10, 32} {
+		if err := queries.CreateRequestLogWithPlanTokens(ctx, pgdb.CreateRequestLogWithPlanTokensParams{
+			UserID:     "user-1",
+			Endpoint:   "/chat/completions",
+			Provider:   "openai",
+			PlanTokens: sql.NullInt32{Int32: tokens, Valid: true},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	used, err := queries.GetUserPlanTokensToday(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != 42 {
+		t.Errorf("plan tokens today = %d, want 42", used)
+	}
+
+	// Rollups over the logs of a day
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if err := queries.UpsertDailyUsageRollup(ctx, today); err != nil {
+		t.Fatal(err)
+	}
+	rollups, err := queries.ListDailyUsageRollups(ctx, pgdb.ListDailyUsageRollupsParams{FromDay: today.AddDate(0, 0, -1), ToDay: today})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rollups) != 1 || !rollups[0].Day.Equal(today) || rollups[0].Requests != 2 || rollups[0].PlanTokens != 42 {
+		t.Errorf("rollups = %+v, want today's 2 requests", rollups)
+	}
+
+	// Interval arithmetic with arguments
+	start := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	for range 2 {
+		if err := queries.UpsertEntitlementWithExtension(ctx, pgdb.UpsertEntitlementWithExtensionParams{
+			UserID:               "user-1",
+			SubscriptionTier:     "pro",
+			BaseTime:             start,
+			DurationDays:         30,
+			SubscriptionProvider: "zcash",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entitlement, err := queries.GetEntitlement(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := start.AddDate(0, 0, 60); !entitlement.SubscriptionExpiresAt.Time.Equal(want) {
+		t.Errorf("expires at %v, want %v", entitlement.SubscriptionExpiresAt.Time, want)
+	}
+
+	// Entitlement history recorded by trigger, skipping unchanged renewals
+	if err := queries.SetEntitlementRenewal(ctx, pgdb.SetEntitlementRenewalParams{UserID: "user-1", InGracePeriod: true}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := queries.ListEntitlementEvents(ctx, pgdb.ListEntitlementEventsParams{UserID: "user-1", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || !events[0].SubscriptionExpiresAt.Time.Equal(start.AddDate(0, 0, 60)) || events[1].SubscriptionProvider != "zcash" {
+		t.Errorf("events = %+v, want the grant and its extension", events)
+	}
+
+	// Bytea arrays
+	hashes := [][]byte{{0x01, 0xab}, {0x02, 0xcd}}
+	if err := queries.ReplaceChatSearchTokens(ctx, pgdb.ReplaceChatSearchTokensParams{
+		UserID: "user-1", ChatID: "chat-1", MessageID: "msg-1", TokenHashes: hashes,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := queries.ReplaceChatSearchTokens(ctx, pgdb.ReplaceChatSearchTokensParams{
+		UserID: "user-1", ChatID: "chat-1", MessageID: "msg-1", TokenHashes: hashes[:1],
+	}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := queries.SearchChatMessages(ctx, pgdb.SearchChatMessagesParams{
+		UserID: "user-1", TokenHashes: hashes[:1], TokenCount: 1, ResultLimit: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].MessageID != "msg-1" || results[0].IndexedAt.IsZero() {
+		t.Errorf("results = %+v, want msg-1", results)
+	}
+	results, err = queries.SearchChatMessages(ctx, pgdb.SearchChatMessagesParams{
+		UserID: "user-1", TokenHashes: hashes[1:], TokenCount: 1, ResultLimit: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("results = %+v, want none for a replaced token", results)
+	}
+}
@@ -0,0 +1,166 @@
+package streaming
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// Anomaly is a defect detected in a completed upstream stream.
+type Anomaly string
+
+const (
+	AnomalyMissingDone    Anomaly = "missing_done"    // Stream ended without "data: [DONE]" (truncated)
+	AnomalyEmptyContent   Anomaly = "empty_content"   // No content, reasoning or tool calls
+	AnomalyAbnormalFinish Anomaly = "abnormal_finish" // finish_reason other than stop, length or tool_calls, or an upstream error chunk
+	AnomalyGarbled        Anomaly = "garbled"         // Content contains replacement characters, invalid UTF-8 or mojibake
+)
+
+// normalFinishReasons are the finish_reason values of a healthy completion.
+var normalFinishReasons = map[string]bool{
+	"stop":          true,
+	"length":        true,
+	"tool_calls":    true,
+	"function_call": true,
+}
+
+// mojibakeMarkers are common sequences produced when UTF-8 text is decoded as Windows-1252
+// (e.g. "â€™" for "’", "Ã©" for "é").
+var mojibakeMarkers = []string{"â€", "Ã©", "Ã¨", "Ã¡", "Ã³", "Ã¼", "Ã¶", "Ã¤", "Ã±", "Ã§"}
+
+const (
+	// minGarbledMarkers and garbledRatio set how much suspicious text marks content as garbled:
+	// at least 3 markers, and at least 1 per 100 characters.
+	minGarbledMarkers = 3
+	garbledRatio      = 100
+)
+
+// streamChunkData is the subset of an SSE data payload inspected for anomalies.
+type streamChunkData struct {
+	Type    string          `json:"type"` // Proxy events: "tool_notification"
+	Error   json.RawMessage `json:"error"`
+	Choices []struct {
+		Delta struct {
+			Content          string            `json:"content"`
+			Reasoning        string            `json:"reasoning"`
+			ReasoningContent string            `json:"reasoning_content"`
+			ToolCalls        []json.RawMessage `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// DetectAnomalies inspects the buffered chunks of a completed stream.
+// Returns nil for a healthy stream.
+func DetectAnomalies(chunks []StreamChunk) []Anomaly {
+	var (
+		sawDone   bool
+		hasOutput bool
+		abnormal  bool
+		content   strings.Builder
+	)
+
+	for _, chunk := range chunks {
+		if chunk.Kind == ChunkKindReasoning {
+			hasOutput = true
+			continue
+		}
+		data, ok := strings.CutPrefix(chunk.Line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			sawDone = true
+			continue
+		}
+
+		var parsed streamChunkData
+		if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+			continue
+		}
+
+		switch parsed.Type {
+		case "tool_notification":
+			hasOutput = true
+			continue
+		}
+
+		if len(parsed.Choices) == 0 && hasError(parsed.Error) {
+			abnormal = true
+			continue
+		}
+
+		for _, choice := range parsed.Choices {
+			d := choice.Delta
+			if d.Content != "" || d.Reasoning != "" || d.ReasoningContent != "" || len(d.ToolCalls) > 0 {
+				hasOutput = true
+			}
+			content.WriteString(d.Content)
+			if choice.FinishReason != nil && *choice.FinishReason != "" && !normalFinishReasons[*choice.FinishReason] {
+				abnormal = true
+			}
+		}
+	}
+
+	var anomalies []Anomaly
+	if !sawDone {
+		anomalies = append(anomalies, AnomalyMissingDone)
+	}
+	if !hasOutput {
+		anomalies = append(anomalies, AnomalyEmptyContent)
+	}
+	if abnormal {
+		anomalies = append(anomalies, AnomalyAbnormalFinish)
+	}
+	if looksGarbled(content.String()) {
+		anomalies = append(anomalies, AnomalyGarbled)
+	}
+	return anomalies
+}
+
+// IsOutputLine reports whether a raw upstream SSE line carries generated output (content,
+// reasoning or tool calls), as opposed to role preambles, usage or finish chunks.
+func IsOutputLine(line string) bool {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return false
+	}
+	return hasTokenDelta(strings.TrimSpace(data))
+}
+
+// IsErrorLine reports whether a raw upstream SSE line is an error chunk (an "error" field and
+// no choices), which some providers send with a 200 status instead of failing the request.
+func IsErrorLine(line string) bool {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return false
+	}
+	var parsed streamChunkData
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &parsed); err != nil {
+		return false
+	}
+	return len(parsed.Choices) == 0 && hasError(parsed.Error)
+}
+
+// hasError reports whether an "error" field holds an actual error.
+func hasError(raw json.RawMessage) bool {
+	value := strings.TrimSpace(string(raw))
+	return value != "" && value != "null" && value != `""`
+}
+
+// looksGarbled reports whether text shows signs of encoding corruption.
+func looksGarbled(text string) bool {
+	if text == "" {
+		return false
+	}
+	if !utf8.ValidString(text) {
+		return true
+	}
+
+	markers := strings.Count(text, "�")
+	for _, marker := range mojibakeMarkers {
+		markers += strings.Count(text, marker)
+	}
+	return markers >= minGarbledMarkers && markers*garbledRatio >= utf8.RuneCountInString(text)
+}
@@ -0,0 +1,87 @@
+package streaming
+
+import (
+	"reflect"
+	"testing"
+)
+
+func chunks(lines ...string) []StreamChunk {
+	result := make([]StreamChunk, len(lines))
+	for i, line := range lines {
+		result[i] = StreamChunk{Index: i, Line: line}
+	}
+	return result
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	const (
+		role    = `data: {"choices":[{"delta":{"role":"assistant"}}]}`
+		hello   = `data: {"choices":[{"delta":{"content":"Hello there"}}]}`
+		stop    = `data: {"choices":[{"delta":{},"finish_reason":"stop"}]}`
+		done    = `data: [DONE]`
+		garbled = `data: {"choices":[{"delta":{"content":"Itâ€™s cafÃ© â€œtimeâ€"}}]}`
+	)
+
+	tests := []struct {
+		name   string
+		chunks []StreamChunk
+		want   []Anomaly
+	}{
+		{"healthy", chunks(role, hello, stop, done), nil},
+		{"truncated", chunks(role, hello), []Anomaly{AnomalyMissingDone}},
+		{"empty", chunks(role, stop, done), []Anomaly{AnomalyEmptyContent}},
+		{"content filter", chunks(role, hello, `data: {"choices":[{"delta":{},"finish_reason":"content_filter"}]}`, done), []Anomaly{AnomalyAbnormalFinish}},
+		{"upstream error chunk", chunks(role, `data: {"error":{"message":"overloaded"}}`), []Anomaly{AnomalyMissingDone, AnomalyEmptyContent, AnomalyAbnormalFinish}},
+		{"garbled", chunks(role, garbled, stop, done), []Anomaly{AnomalyGarbled}},
+		{"tool calls only", chunks(role, `data: {"choices":[{"delta":{"tool_calls":[{"index":0}]},"finish_reason":"tool_calls"}]}`, done), nil},
+		{"separated reasoning counts as output", append([]StreamChunk{{Line: "event: reasoning\ndata: {\"content\":\"thinking\",\"type\":\"reasoning\"}\n", Kind: ChunkKindReasoning}}, chunks(stop, done)...), nil},
+		{"tool notification with empty error", chunks(`data: {"error":"","event":"started","type":"tool_notification"}`, hello, done), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectAnomalies(tt.chunks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectAnomalies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksGarbled(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"A perfectly normal answer with an accent: café.", false},
+		{"One stray Ã© in a long paragraph of otherwise normal text that goes on and on.", false},
+		{"Itâ€™s a â€œquotedâ€ cafÃ©", true},
+		{"Broken ��� bytes", true},
+		{"invalid \xff\xfe utf-8", true},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := looksGarbled(tt.text); got != tt.want {
+			t.Errorf("looksGarbled(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsErrorLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`data: {"error":{"message":"overloaded"}}`, true},
+		{`data: {"error":null,"choices":[{"delta":{"content":"hi"}}]}`, false},
+		{`data: {"choices":[{"delta":{"role":"assistant"}}]}`, false},
+		{"data: [DONE]", false},
+		{": keep-alive", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsErrorLine(tt.line); got != tt.want {
+			t.Errorf("IsErrorLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
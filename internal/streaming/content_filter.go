@@ -0,0 +1,273 @@
+package streaming
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+const (
+	// defaultRedaction replaces redacted matches without a configured replacement.
+	defaultRedaction = "[REDACTED]"
+
+	// maxHeldBackWord bounds the partial word held back by redactions; longer runs of text
+	// without whitespace are filtered as they are.
+	maxHeldBackWord = 256
+)
+
+// ContentFilter rewrites the content of a streamed completion before it is broadcast and
+// stored. Filters keep state between deltas (a match may be split across them), so every
+// completion gets its own instances.
+type ContentFilter interface {
+	// Filter returns the filtered content of the next delta. Content may be held back and
+	// returned by a later call or by Flush.
+	Filter(content string) string
+
+	// Flush returns the content held back at the end of the completion.
+	Flush() string
+}
+
+// ContentFilters are the compiled stream filters of each account content policy.
+type ContentFilters struct {
+	policies map[string]*contentFilterPolicy
+}
+
+type contentFilterPolicy struct {
+	stopSequences []string
+	redactions    []redaction
+}
+
+// redaction rewrites the matches of a pattern in a text.
+type redaction func(text string) string
+
+// NewContentFilters compiles cfg. cfg must already be validated.
+func NewContentFilters(cfg *config.StreamFiltersConfig) *ContentFilters {
+	f := &ContentFilters{policies: make(map[string]*contentFilterPolicy)}
+	if cfg == nil {
+		return f
+	}
+
+	for name, policy := range cfg.Policies {
+		p := &contentFilterPolicy{stopSequences: policy.StopSequences}
+		for _, rule := range policy.Redactions {
+			replacement := rule.Replacement
+			if replacement == "" {
+				replacement = defaultRedaction
+			}
+			re := regexp.MustCompile(rule.Pattern)
+			p.redactions = append(p.redactions, func(text string) string {
+				return re.ReplaceAllString(text, replacement)
+			})
+		}
+		if len(policy.MaskedWords) > 0 {
+			words := make([]string, len(policy.MaskedWords))
+			for i, word := range policy.MaskedWords {
+				words[i] = regexp.QuoteMeta(word)
+			}
+			re := regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b`)
+			p.redactions = append(p.redactions, func(text string) string {
+				return re.ReplaceAllStringFunc(text, func(match string) string {
+					return strings.Repeat("*", utf8.RuneCountInString(match))
+				})
+			})
+		}
+		if len(p.stopSequences) > 0 || len(p.redactions) > 0 {
+			f.policies[name] = p
+		}
+	}
+	return f
+}
+
+// NewChain returns new filters for a completion under a content policy, or nil if the policy
+// has none.
+func (f *ContentFilters) NewChain(policy string) *ContentFilterChain {
+	if f == nil {
+		return nil
+	}
+	p, ok := f.policies[policy]
+	if !ok {
+		return nil
+	}
+
+	var filters []ContentFilter
+	if len(p.stopSequences) > 0 {
+		filters = append(filters, &stopSequenceFilter{sequences: p.stopSequences})
+	}
+	if len(p.redactions) > 0 {
+		filters = append(filters, &redactionFilter{redactions: p.redactions})
+	}
+	return NewContentFilterChain(filters...)
+}
+
+// ContentFilterChain applies content filters in order to the deltas of a Chat Completions
+// stream.
+type ContentFilterChain struct {
+	filters []ContentFilter
+}
+
+// NewContentFilterChain creates a chain of filters.
+func NewContentFilterChain(filters ...ContentFilter) *ContentFilterChain {
+	return &ContentFilterChain{filters: filters}
+}
+
+// Filter passes a content delta through every filter.
+func (c *ContentFilterChain) Filter(content string) string {
+	for _, f := range c.filters {
+		content = f.Filter(content)
+	}
+	return content
+}
+
+// Flush returns the content held back by the filters, filtered by the filters after them.
+func (c *ContentFilterChain) Flush() string {
+	var content string
+	for _, f := range c.filters {
+		content = f.Filter(content) + f.Flush()
+	}
+	return content
+}
+
+// FilterSSELine filters the content delta of an SSE data line. Held-back content is flushed
+// into the chunk that carries the finish reason.
+// Returns the filtered line (or the original if unchanged) and whether it was modified.
+func (c *ContentFilterChain) FilterSSELine(line string) (string, bool) {
+	if !strings.HasPrefix(line, "data: ") {
+		return line, false
+	}
+
+	jsonData := strings.TrimPrefix(line, "data: ")
+	if jsonData == "[DONE]" {
+		return line, false
+	}
+
+	// Quick check before parsing: only content deltas and finish chunks are rewritten
+	if !strings.Contains(jsonData, `"content"`) && !strings.Contains(jsonData, `"finish_reason"`) {
+		return line, false
+	}
+
+	var chunk map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonData), &chunk); err != nil {
+		return line, false
+	}
+
+	choices, ok := chunk["choices"].([]interface{})
+	if !ok || len(choices) == 0 {
+		return line, false
+	}
+
+	choice, ok := choices[0].(map[string]interface{})
+	if !ok {
+		return line, false
+	}
+
+	delta, ok := choice["delta"].(map[string]interface{})
+	if !ok {
+		return line, false
+	}
+
+	content, hasContent := delta["content"].(string)
+	filtered := content
+	if hasContent && content != "" {
+		filtered = c.Filter(content)
+	}
+	if finishReason, _ := choice["finish_reason"].(string); finishReason != "" {
+		filtered += c.Flush()
+	}
+
+	if filtered == content {
+		return line, false
+	}
+	delta["content"] = filtered
+
+	newJSON, err := json.Marshal(chunk)
+	if err != nil {
+		return line, false
+	}
+
+	return "data: " + string(newJSON), true
+}
+
+// stopSequenceFilter ends the content at the first stop sequence. A trailing prefix of a stop
+// sequence is held back until the next delta completes or rules it out.
+type stopSequenceFilter struct {
+	sequences []string
+	pending   string
+	stopped   bool
+}
+
+func (f *stopSequenceFilter) Filter(content string) string {
+	if f.stopped {
+		return ""
+	}
+
+	text := f.pending + content
+	f.pending = ""
+
+	end := -1
+	for _, seq := range f.sequences {
+		if idx := strings.Index(text, seq); idx != -1 && (end == -1 || idx < end) {
+			end = idx
+		}
+	}
+	if end != -1 {
+		f.stopped = true
+		return text[:end]
+	}
+
+	held := 0
+	for _, seq := range f.sequences {
+		held = max(held, partialSuffixLen(text, seq))
+	}
+	f.pending = text[len(text)-held:]
+	return text[:len(text)-held]
+}
+
+func (f *stopSequenceFilter) Flush() string {
+	pending := f.pending
+	f.pending = ""
+	return pending
+}
+
+// redactionFilter replaces matches of patterns. The trailing partial word is held back so that
+// matches split across deltas are seen whole.
+type redactionFilter struct {
+	redactions []redaction
+	pending    string
+}
+
+func (f *redactionFilter) Filter(content string) string {
+	text := f.pending + content
+	f.pending = ""
+
+	if cut := strings.LastIndexFunc(text, unicode.IsSpace); cut != -1 {
+		_, size := utf8.DecodeRuneInString(text[cut:])
+		text, f.pending = text[:cut+size], text[cut+size:]
+	} else {
+		text, f.pending = "", text
+	}
+	if len(f.pending) > maxHeldBackWord {
+		text, f.pending = text+f.pending, ""
+	}
+
+	return f.redact(text)
+}
+
+func (f *redactionFilter) Flush() string {
+	pending := f.pending
+	f.pending = ""
+	return f.redact(pending)
+}
+
+func (f *redactionFilter) redact(text string) string {
+	if text == "" {
+		return ""
+	}
+	for _, redact := range f.redactions {
+		text = redact(text)
+	}
+	return text
+}
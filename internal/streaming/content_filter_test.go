@@ -0,0 +1,159 @@
+package streaming
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+)
+
+func newTestContentFilters(t testing.TB) *ContentFilters {
+	t.Helper()
+	cfg := &config.StreamFiltersConfig{Policies: map[string]config.StreamFilterPolicy{
+		"family": {
+			StopSequences: []string{"<|end|>"},
+			Redactions: []config.RedactionRule{
+				{Name: "email", Pattern: `[\w.+-]+@[\w-]+\.[\w.]+`},
+				{Name: "card", Pattern: `\b(\d{4})\d{8}(\d{4})\b`, Replacement: "$1********$2"},
+			},
+			MaskedWords: []string{"darn", "heck"},
+		},
+		"standard": {},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	return NewContentFilters(cfg)
+}
+
+// filterDeltas streams deltas through a chain, flushing at the end like a finish chunk.
+func filterDeltas(chain *ContentFilterChain, deltas ...string) string {
+	var out strings.Builder
+	for _, delta := range deltas {
+		out.WriteString(chain.Filter(delta))
+	}
+	out.WriteString(chain.Flush())
+	return out.String()
+}
+
+func TestContentFilterChain(t *testing.T) {
+	filters := newTestContentFilters(t)
+
+	tests := []struct {
+		name   string
+		deltas []string
+		want   string
+	}{
+		{name: "unchanged", deltas: []string{"Hello ", "world, how ", "are you?"}, want: "Hello world, how are you?"},
+		{name: "stop sequence split across deltas", deltas: []string{"Done.<|e", "nd|> ignored", " text"}, want: "Done."},
+		{name: "partial stop sequence released", deltas: []string{"a <|e", "xample"}, want: "a <|example"},
+		{name: "email split across deltas", deltas: []string{"Mail jane.d", "oe@example", ".com today"}, want: "Mail [REDACTED] today"},
+		{name: "replacement with submatches", deltas: []string{"Card 4111111111111111."}, want: "Card 4111********1111."},
+		{name: "masked words", deltas: []string{"Oh DAR", "N, what the heck", "! darning"}, want: "Oh ****, what the ****! darning"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterDeltas(filters.NewChain("family"), tt.deltas...); got != tt.want {
+				t.Errorf("filtered content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if chain := filters.NewChain("standard"); chain != nil {
+		t.Error("policy without filters should have no chain")
+	}
+	if chain := (*ContentFilters)(nil).NewChain("family"); chain != nil {
+		t.Error("nil filters should have no chain")
+	}
+}
+
+func TestContentFilterChain_FilterSSELine(t *testing.T) {
+	chain := newTestContentFilters(t).NewChain("family")
+
+	contentLine := func(content string) string {
+		return `data: {"id":"c1","choices":[{"index":0,"delta":{"content":` + mustJSON(content) + `},"finish_reason":null}]}`
+	}
+	deltaContent := func(line string) string {
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", line, err)
+		}
+		return chunk.Choices[0].Delta.Content
+	}
+
+	var content strings.Builder
+	for _, delta := range []string{"Write to ", "bob@exam", "ple.org"} {
+		line, _ := chain.FilterSSELine(contentLine(delta))
+		content.WriteString(deltaContent(line))
+	}
+	finish, modified := chain.FilterSSELine(`data: {"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)
+	if !modified {
+		t.Fatal("finish chunk should carry the held-back content")
+	}
+	content.WriteString(deltaContent(finish))
+
+	if got, want := content.String(), "Write to [REDACTED]"; got != want {
+		t.Errorf("streamed content = %q, want %q", got, want)
+	}
+
+	for _, line := range []string{"data: [DONE]", `data: {"usage":{"total_tokens":3}}`, ": keep-alive"} {
+		if got, modified := chain.FilterSSELine(line); modified || got != line {
+			t.Errorf("FilterSSELine(%q) = %q, %v; want unchanged", line, got, modified)
+		}
+	}
+}
+
+func TestStreamFiltersConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy config.StreamFilterPolicy
+	}{
+		{name: "empty stop sequence", policy: config.StreamFilterPolicy{StopSequences: []string{""}}},
+		{name: "long stop sequence", policy: config.StreamFilterPolicy{StopSequences: []string{strings.Repeat("x", config.MaxStopSequenceLength+1)}}},
+		{name: "unnamed redaction", policy: config.StreamFilterPolicy{Redactions: []config.RedactionRule{{Pattern: "x"}}}},
+		{name: "invalid pattern", policy: config.StreamFilterPolicy{Redactions: []config.RedactionRule{{Name: "bad", Pattern: "("}}}},
+		{name: "masked phrase", policy: config.StreamFilterPolicy{MaskedWords: []string{"two words"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.StreamFiltersConfig{Policies: map[string]config.StreamFilterPolicy{"family": tt.policy}}
+			if err := cfg.Validate(); err == nil {
+				t.Error("Validate() succeeded, want error")
+			}
+		})
+	}
+}
+
+func mustJSON(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// BenchmarkContentFilterChain_FilterSSELine measures the per-chunk cost of content filtering.
+func BenchmarkContentFilterChain_FilterSSELine(b *testing.B) {
+	filters := newTestContentFilters(b)
+	line := `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"content":"The quick brown fox jumps over "},"finish_reason":null}]}`
+
+	b.Run("filtered", func(b *testing.B) {
+		chain := filters.NewChain("family")
+		b.ReportAllocs()
+		for b.Loop() {
+			chain.FilterSSELine(line)
+		}
+	})
+	b.Run("usage chunk", func(b *testing.B) {
+		chain := filters.NewChain("family")
+		usage := `data: {"id":"chatcmpl-1","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":20,"total_tokens":30}}`
+		b.ReportAllocs()
+		for b.Loop() {
+			chain.FilterSSELine(usage)
+		}
+	})
+}
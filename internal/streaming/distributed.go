@@ -111,12 +111,12 @@ func (s *DistributedCancelService) Stop() error {
 // RequestCancel sends a cancel request to all instances and waits for a response.
 // Returns the response from the instance that owns the session, or an error if
 // no instance responds within the timeout.
-func (s *DistributedCancelService) RequestCancel(ctx context.Context, chatID, messageID, userID string) (*CancelResponse, error) {
+func (s *DistributedCancelService) RequestCancel(ctx context.Context, chatID, messageID, userID string, reason StopReason) (*CancelResponse, error) {
 	req := CancelRequest{
 		ChatID:    chatID,
 		MessageID: messageID,
 		UserID:    userID,
-		Reason:    string(StopReasonUserCancelled),
+		Reason:    string(reason),
 	}
 
 	data, err := json.Marshal(req)
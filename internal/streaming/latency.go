@@ -0,0 +1,82 @@
+package streaming
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+)
+
+// latencyTracker measures time-to-first-token and inter-chunk latency of an upstream stream
+// and reports them per provider/model.
+type latencyTracker struct {
+	provider   string
+	model      string
+	start      time.Time // When the upstream request was sent
+	firstToken bool      // Whether the first token has been seen
+	lastChunk  time.Time // Zero until the first token, and after a tool continuation
+}
+
+func newLatencyTracker(provider, model string, start time.Time) *latencyTracker {
+	return &latencyTracker{provider: provider, model: model, start: start}
+}
+
+// observe records a raw upstream SSE line received at now.
+// TTFT is recorded once, for the first chunk carrying content, reasoning or tool calls
+// (role-only preambles don't count). Every later data chunk records the gap to the previous one.
+func (t *latencyTracker) observe(line string, now time.Time) {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return
+	}
+	data = strings.TrimSpace(data)
+	if data == "[DONE]" {
+		return
+	}
+
+	if !t.firstToken {
+		if !hasTokenDelta(data) {
+			return
+		}
+		t.firstToken = true
+		t.lastChunk = now
+		metrics.RecordStreamTimeToFirstToken(t.provider, t.model, now.Sub(t.start).Seconds())
+		return
+	}
+
+	if !t.lastChunk.IsZero() {
+		metrics.RecordStreamInterChunkTime(t.provider, t.model, now.Sub(t.lastChunk).Seconds())
+	}
+	t.lastChunk = now
+}
+
+// resume resets the inter-chunk baseline so tool execution time isn't counted as upstream latency.
+func (t *latencyTracker) resume() {
+	t.lastChunk = time.Time{}
+}
+
+// hasTokenDelta reports whether a chat completions chunk carries generated output.
+func hasTokenDelta(data string) bool {
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				Content          string            `json:"content"`
+				Reasoning        string            `json:"reasoning"`
+				ReasoningContent string            `json:"reasoning_content"`
+				ToolCalls        []json.RawMessage `json:"tool_calls"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return false
+	}
+
+	for _, choice := range chunk.Choices {
+		d := choice.Delta
+		if d.Content != "" || d.Reasoning != "" || d.ReasoningContent != "" || len(d.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package streaming
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+)
+
+func TestHasTokenDelta(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{name: "role preamble", data: `{"choices":[{"delta":{"role":"assistant","content":""}}]}`, want: false},
+		{name: "content", data: `{"choices":[{"delta":{"content":"Hi"}}]}`, want: true},
+		{name: "reasoning_content", data: `{"choices":[{"delta":{"reasoning_content":"hmm"}}]}`, want: true},
+		{name: "tool call", data: `{"choices":[{"delta":{"tool_calls":[{"index":0}]}}]}`, want: true},
+		{name: "usage only", data: `{"choices":[],"usage":{"total_tokens":3}}`, want: false},
+		{name: "invalid", data: `not json`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasTokenDelta(tt.data); got != tt.want {
+				t.Errorf("hasTokenDelta() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatencyTracker(t *testing.T) {
+	start := time.Now()
+	tracker := newLatencyTracker("test-latency-provider", "m", start)
+
+	lines := []struct {
+		line string
+		at   time.Duration
+	}{
+		{`data: {"choices":[{"delta":{"role":"assistant"}}]}`, 100 * time.Millisecond},
+		{`: keep-alive`, 200 * time.Millisecond},
+		{`data: {"choices":[{"delta":{"content":"a"}}]}`, 400 * time.Millisecond}, // TTFT 400ms
+		{`data: {"choices":[{"delta":{"content":"b"}}]}`, 450 * time.Millisecond}, // +50ms
+		{`data: {"choices":[{"delta":{"content":"c"}}]}`, 550 * time.Millisecond}, // +100ms
+		{`data: [DONE]`, 600 * time.Millisecond},
+	}
+	for _, l := range lines {
+		tracker.observe(l.line, start.Add(l.at))
+	}
+
+	// Tool execution gap is not counted
+	tracker.resume()
+	tracker.observe(`data: {"choices":[{"delta":{"content":"d"}}]}`, start.Add(10*time.Second))
+	tracker.observe(`data: {"choices":[{"delta":{"content":"e"}}]}`, start.Add(10*time.Second+50*time.Millisecond))
+
+	var stats *metrics.StreamLatencyStats
+	for _, s := range metrics.StreamLatencySnapshot() {
+		if s.Provider == "test-latency-provider" {
+			stats = &s
+		}
+	}
+	if stats == nil {
+		t.Fatal("no latency stats recorded")
+	}
+	if stats.TimeToFirstToken.Samples != 1 || math.Round(stats.TimeToFirstToken.P50Ms) != 400 {
+		t.Errorf("TimeToFirstToken = %+v, want 1 sample at 400ms", stats.TimeToFirstToken)
+	}
+	if stats.InterChunk.Samples != 3 || math.Round(stats.InterChunk.P50Ms) != 50 || math.Round(stats.InterChunk.P95Ms) != 100 {
+		t.Errorf("InterChunk = %+v, want 3 samples, p50 50ms, p95 100ms", stats.InterChunk)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
 )
 
 const (
@@ -34,6 +35,7 @@ const (
 //   - Provide observability (metrics, active streams list)
 //   - Handle graceful shutdown
 //   - Coordinate distributed cancellation across instances (via NATS)
+//   - Share sessions with other instances for reconnects (via an optional SessionStore)
 //
 // Thread-safety:
 //   - All public methods are thread-safe
@@ -53,6 +55,13 @@ type StreamManager struct {
 	// distributedCancel handles cross-instance stream cancellation (optional)
 	distributedCancel *DistributedCancelService
 
+	// store shares sessions across instances for reconnects (optional)
+	store      SessionStore
+	instanceID string
+
+	// contentFilters are the stream filters per account content policy (optional)
+	contentFilters *ContentFilters
+
 	// logger for this manager
 	logger *logger.Logger
 
@@ -98,6 +107,7 @@ func NewStreamManager(messageService *messaging.Service, logger *logger.Logger)
 // GetOrCreateSession finds an existing session or creates a new one.
 //
 // Parameters:
+//   - ctx: Request context (privacy and ephemeral sessions aren't written to the session store)
 //   - chatID: Chat session identifier
 //   - messageID: AI response message identifier
 //   - upstreamBody: Response body from AI provider (only used if creating new session)
@@ -114,13 +124,13 @@ func NewStreamManager(messageService *messaging.Service, logger *logger.Logger)
 //
 // Example usage:
 //
-//	session, isNew := manager.GetOrCreateSession(chatID, messageID, upstreamBody)
+//	session, isNew := manager.GetOrCreateSession(ctx, chatID, messageID, upstreamBody)
 //	if isNew {
 //	    // First client for this response
 //	} else {
 //	    // Additional client joining existing stream
 //	}
-func (sm *StreamManager) GetOrCreateSession(chatID, messageID string, upstreamBody io.ReadCloser) (*StreamSession, bool) {
+func (sm *StreamManager) GetOrCreateSession(ctx context.Context, chatID, messageID string, upstreamBody io.ReadCloser) (*StreamSession, bool) {
 	sessionKey := sm.makeSessionKey(chatID, messageID)
 
 	// Fast path: Check if session already exists (read lock)
@@ -153,10 +163,7 @@ func (sm *StreamManager) GetOrCreateSession(chatID, messageID string, upstreamBo
 	session := NewStreamSession(chatID, messageID, upstreamBody, sm.logger)
 	sm.sessions[sessionKey] = session
 
-	// Set tool executor if available
-	if sm.toolExecutor != nil {
-		session.SetToolExecutor(sm.toolExecutor)
-	}
+	sm.attach(ctx, session)
 
 	// Start reading upstream in background
 	session.Start()
@@ -181,13 +188,14 @@ func (sm *StreamManager) GetOrCreateSession(chatID, messageID string, upstreamBo
 // ensuring the session exists if the user clicks "stop" during the initial connection phase.
 //
 // Parameters:
+//   - ctx: Request context (privacy and ephemeral sessions aren't written to the session store)
 //   - chatID: Chat session identifier
 //   - messageID: AI response message identifier
 //
 // Returns:
 //   - *StreamSession: The pending session (upstream reading NOT started yet)
 //   - bool: true if session was newly created, false if it already existed
-func (sm *StreamManager) CreatePendingSession(chatID, messageID string) (*StreamSession, bool) {
+func (sm *StreamManager) CreatePendingSession(ctx context.Context, chatID, messageID string) (*StreamSession, bool) {
 	sessionKey := sm.makeSessionKey(chatID, messageID)
 
 	// Check if session already exists
@@ -215,10 +223,7 @@ func (sm *StreamManager) CreatePendingSession(chatID, messageID string) (*Stream
 	session := NewStreamSession(chatID, messageID, nil, sm.logger)
 	sm.sessions[sessionKey] = session
 
-	// Set tool executor if available
-	if sm.toolExecutor != nil {
-		session.SetToolExecutor(sm.toolExecutor)
-	}
+	sm.attach(ctx, session)
 
 	// Update metrics
 	sm.metricsLock.Lock()
@@ -233,6 +238,18 @@ func (sm *StreamManager) CreatePendingSession(chatID, messageID string) (*Stream
 	return session, true
 }
 
+// attach sets the tool executor and session store of a new session, if available. Sessions of
+// privacy and ephemeral mode requests (ctx) never use the session store, which persists their
+// chunks and publishes them to other instances.
+func (sm *StreamManager) attach(ctx context.Context, session *StreamSession) {
+	if sm.toolExecutor != nil {
+		session.SetToolExecutor(sm.toolExecutor)
+	}
+	if sm.store != nil && !privacy.SkipStorage(ctx) {
+		session.SetSessionStore(sm.store, sm.instanceID)
+	}
+}
+
 // GetSession retrieves an existing session by chatID and messageID.
 //
 // Parameters:
@@ -252,6 +269,115 @@ func (sm *StreamManager) GetSession(chatID, messageID string) *StreamSession {
 	return sm.sessions[sessionKey]
 }
 
+// DiscardCompletedSession removes the completed session of a message so it can be generated
+// again. Returns false, leaving the session in place, if it is still in progress. The session's
+// message is not saved again.
+func (sm *StreamManager) DiscardCompletedSession(chatID, messageID string) bool {
+	sessionKey := sm.makeSessionKey(chatID, messageID)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, exists := sm.sessions[sessionKey]
+	if !exists {
+		return true
+	}
+	if !session.IsCompleted() {
+		return false
+	}
+	delete(sm.sessions, sessionKey)
+	return true
+}
+
+// JoinSession finds a session for a reconnecting client: the local session if this instance
+// reads it, otherwise a remote session mirroring the one stored by another instance (see
+// SetSessionStore). Remote sessions replay the stored chunks and follow live ones until the
+// stream completes or ctx is cancelled; they are not tracked by the manager.
+//
+// Returns:
+//   - *StreamSession: The session, or nil if not found
+//   - error: If the session store failed
+func (sm *StreamManager) JoinSession(ctx context.Context, chatID, messageID string) (*StreamSession, error) {
+	if session := sm.GetSession(chatID, messageID); session != nil {
+		return session, nil
+	}
+	if sm.store == nil {
+		return nil, nil
+	}
+
+	session, err := newRemoteSession(ctx, sm.store, chatID, messageID, sm.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stored session: %w", err)
+	}
+	if session != nil {
+		sm.logger.Info("joined stream session of another instance",
+			slog.String("chat_id", chatID),
+			slog.String("message_id", messageID),
+			slog.Bool("completed", session.IsCompleted()))
+	}
+	return session, nil
+}
+
+// StopUserSessions stops all in-progress sessions of a user with StopReasonAccessRevoked.
+// Called when the user's token is revoked or subscription lapses mid-stream.
+//
+// Returns:
+//   - int: Number of sessions stopped
+//
+// Thread-safe: Sessions are collected under read lock and stopped outside it.
+func (sm *StreamManager) StopUserSessions(userID string) int {
+	if userID == "" {
+		return 0
+	}
+
+	sm.mu.RLock()
+	var sessions []*StreamSession
+	for _, session := range sm.sessions {
+		if session.GetUserID() == userID && !session.IsCompleted() {
+			sessions = append(sessions, session)
+		}
+	}
+	sm.mu.RUnlock()
+
+	stopped := 0
+	for _, session := range sessions {
+		if err := session.Stop(StoppedBySystemRevocation, StopReasonAccessRevoked); err != nil {
+			continue // Completed or stopped meanwhile
+		}
+		stopped++
+	}
+
+	if stopped > 0 {
+		sm.logger.Info("stopped sessions of revoked user",
+			slog.String("user_id", userID),
+			slog.Int("stopped", stopped))
+	}
+
+	return stopped
+}
+
+// ActiveUsers returns the users with in-progress sessions, mapped to the start time of their
+// oldest one. Used by the revocation watcher to limit checks to users with work in flight.
+//
+// Thread-safe: Uses read lock.
+func (sm *StreamManager) ActiveUsers() map[string]time.Time {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	users := make(map[string]time.Time)
+	for _, session := range sm.sessions {
+		userID := session.GetUserID()
+		if userID == "" || session.IsCompleted() {
+			continue
+		}
+		if startedAt, ok := users[userID]; !ok || session.startTime.Before(startedAt) {
+			users[userID] = session.startTime
+		}
+	}
+
+	return users
+}
+
 // CleanupExpiredSessions removes completed sessions older than TTL.
 //
 // Parameters:
@@ -364,6 +490,54 @@ func (sm *StreamManager) GetActiveStreams() []StreamInfo {
 	return infos
 }
 
+// GetActiveStreamForChat finds a chat's in-progress stream for a reconnecting client: the
+// newest local session that hasn't completed, otherwise the session another instance reads
+// according to the session store (see SetSessionStore). Join it with JoinSession.
+//
+// Returns:
+//   - *StreamInfo: The stream, or nil if the chat has none in progress
+//   - error: If the session store failed
+func (sm *StreamManager) GetActiveStreamForChat(ctx context.Context, chatID string) (*StreamInfo, error) {
+	var active *StreamInfo
+	sm.mu.RLock()
+	for _, session := range sm.sessions {
+		if session.chatID != chatID || session.IsCompleted() {
+			continue
+		}
+		info := session.GetInfo()
+		if active == nil || info.StartTime.After(active.StartTime) {
+			active = &info
+		}
+	}
+	sm.mu.RUnlock()
+	if active != nil || sm.store == nil {
+		return active, nil
+	}
+
+	messageID, err := sm.store.ActiveSession(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stored session: %w", err)
+	}
+	if messageID == "" {
+		return nil, nil
+	}
+	meta, chunks, err := sm.store.LoadSession(ctx, chatID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stored session: %w", err)
+	}
+	if meta == nil || meta.Completed {
+		return nil, nil
+	}
+	return &StreamInfo{
+		SessionKey:     sm.makeSessionKey(chatID, messageID),
+		ChatID:         chatID,
+		MessageID:      messageID,
+		StartTime:      meta.StartTime,
+		ChunksReceived: len(chunks),
+		InstanceID:     meta.InstanceID,
+	}, nil
+}
+
 // GetMetrics returns current streaming metrics.
 // Used for monitoring and alerting.
 //
@@ -497,6 +671,25 @@ func (sm *StreamManager) SetDistributedCancel(service *DistributedCancelService)
 	sm.distributedCancel = service
 }
 
+// SetSessionStore shares sessions created from now on through store, so clients reconnecting
+// to any instance can join them with JoinSession. This should be called during
+// initialization, before any sessions are created.
+func (sm *StreamManager) SetSessionStore(store SessionStore, instanceID string) {
+	sm.store = store
+	sm.instanceID = instanceID
+}
+
+// SetContentFilters sets the stream filters of account content policies.
+func (sm *StreamManager) SetContentFilters(filters *ContentFilters) {
+	sm.contentFilters = filters
+}
+
+// NewContentFilter returns new filters for a session under an account content policy, or nil
+// if the policy has none.
+func (sm *StreamManager) NewContentFilter(policy string) *ContentFilterChain {
+	return sm.contentFilters.NewChain(policy)
+}
+
 // GetDistributedCancel returns the distributed cancel service, or nil if not configured.
 func (sm *StreamManager) GetDistributedCancel() *DistributedCancelService {
 	return sm.distributedCancel
@@ -565,6 +758,13 @@ func (sm *StreamManager) SaveCompletedSession(ctx context.Context, session *Stre
 		GenerationState:       generationState,
 		GenerationCompletedAt: &now,
 		GenerationError:       generationError,
+		Reasoning:             session.GetReasoning(),
+		DebugTrace:            session.debugTrace,
+		SpanContext:           session.spanContext,
+	}
+	if usage := session.GetTokenUsage(); usage != nil {
+		msg.PromptTokens = &usage.PromptTokens
+		msg.CompletionTokens = &usage.CompletionTokens
 	}
 
 	// Store asynchronously
@@ -0,0 +1,48 @@
+package streaming
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+func TestStreamManagerStopUserSessions(t *testing.T) {
+	sm := NewStreamManager(nil, logger.New(logger.Config{Level: slog.LevelError}))
+	defer sm.Shutdown()
+
+	revoked1, _ := sm.CreatePendingSession(context.Background(), "chat-1", "msg-1")
+	revoked1.SetUserID("user-a")
+	revoked2, _ := sm.CreatePendingSession(context.Background(), "chat-2", "msg-2")
+	revoked2.SetUserID("user-a")
+	other, _ := sm.CreatePendingSession(context.Background(), "chat-3", "msg-3")
+	other.SetUserID("user-b")
+	anonymous, _ := sm.CreatePendingSession(context.Background(), "chat-4", "msg-4")
+
+	users := sm.ActiveUsers()
+	if len(users) != 2 {
+		t.Fatalf("ActiveUsers() = %v, want user-a and user-b", users)
+	}
+
+	if stopped := sm.StopUserSessions("user-a"); stopped != 2 {
+		t.Errorf("StopUserSessions() = %d, want 2", stopped)
+	}
+	for _, session := range []*StreamSession{revoked1, revoked2} {
+		stoppedBy, reason := session.GetStopInfo()
+		if stoppedBy != StoppedBySystemRevocation || reason != StopReasonAccessRevoked {
+			t.Errorf("stop info = %q, %q; want %q, %q", stoppedBy, reason, StoppedBySystemRevocation, StopReasonAccessRevoked)
+		}
+	}
+	if other.IsStopped() || anonymous.IsStopped() {
+		t.Error("sessions of other users were stopped")
+	}
+
+	// Already stopped sessions aren't counted again
+	if stopped := sm.StopUserSessions("user-a"); stopped != 0 {
+		t.Errorf("second StopUserSessions() = %d, want 0", stopped)
+	}
+	if stopped := sm.StopUserSessions(""); stopped != 0 {
+		t.Errorf("StopUserSessions(\"\") = %d, want 0", stopped)
+	}
+}
@@ -0,0 +1,176 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisSessionStore is a SessionStore shared by all instances connected to the same Redis.
+//
+// Each session has three keys, hash-tagged so they live on the same cluster slot:
+//
+//	stream:{chatID:messageID}:meta    JSON SessionMeta
+//	stream:{chatID:messageID}:chunks  list of JSON StreamChunk, in stream order
+//	stream:{chatID:messageID}:events  pub/sub channel of JSON StoreEvent
+//
+// plus stream:{chatID}:active, the message ID of the chat's session in progress.
+//
+// Keys expire sessionTTL after the session's last write, like completed local sessions.
+type RedisSessionStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisSessionStore returns a store keeping sessions in client.
+func NewRedisSessionStore(client *redis.Client) *RedisSessionStore {
+	return &RedisSessionStore{client: client, ttl: sessionTTL}
+}
+
+func redisSessionKey(chatID, messageID, suffix string) string {
+	return fmt.Sprintf("stream:{%s:%s}:%s", chatID, messageID, suffix)
+}
+
+func redisActiveKey(chatID string) string {
+	return fmt.Sprintf("stream:{%s}:active", chatID)
+}
+
+// clearActiveScript deletes a chat's active session key if it still names the message, so
+// a session completing doesn't clear a newer one.
+var clearActiveScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (r *RedisSessionStore) SaveSession(ctx context.Context, meta SessionMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	event, err := json.Marshal(StoreEvent{Meta: &meta})
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, redisSessionKey(meta.ChatID, meta.MessageID, "meta"), data, r.ttl)
+	pipe.Expire(ctx, redisSessionKey(meta.ChatID, meta.MessageID, "chunks"), r.ttl)
+	pipe.Publish(ctx, redisSessionKey(meta.ChatID, meta.MessageID, "events"), event)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	// The active key hashes to another cluster slot, so it's written separately
+	activeKey := redisActiveKey(meta.ChatID)
+	if meta.Completed {
+		return clearActiveScript.Run(ctx, r.client, []string{activeKey}, meta.MessageID).Err()
+	}
+	return r.client.Set(ctx, activeKey, meta.MessageID, r.ttl).Err()
+}
+
+func (r *RedisSessionStore) AppendChunk(ctx context.Context, chatID, messageID string, chunk StreamChunk) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+
+	chunksKey := redisSessionKey(chatID, messageID, "chunks")
+	seq, err := r.client.RPush(ctx, chunksKey, data).Result()
+	if err != nil {
+		return err
+	}
+	event, err := json.Marshal(StoreEvent{Seq: seq, Chunk: &chunk})
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.Pipeline()
+	pipe.Expire(ctx, chunksKey, r.ttl)
+	pipe.Publish(ctx, redisSessionKey(chatID, messageID, "events"), event)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisSessionStore) LoadSession(ctx context.Context, chatID, messageID string) (*SessionMeta, []StreamChunk, error) {
+	pipe := r.client.Pipeline()
+	metaCmd := pipe.Get(ctx, redisSessionKey(chatID, messageID, "meta"))
+	chunksCmd := pipe.LRange(ctx, redisSessionKey(chatID, messageID, "chunks"), 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, nil, err
+	}
+
+	data, err := metaCmd.Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var meta SessionMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, nil, fmt.Errorf("invalid stored session: %w", err)
+	}
+
+	lines := chunksCmd.Val()
+	chunks := make([]StreamChunk, 0, len(lines))
+	for _, line := range lines {
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return nil, nil, fmt.Errorf("invalid stored chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return &meta, chunks, nil
+}
+
+func (r *RedisSessionStore) Subscribe(ctx context.Context, chatID, messageID string) (<-chan StoreEvent, error) {
+	pubsub := r.client.Subscribe(ctx, redisSessionKey(chatID, messageID, "events"))
+	// Wait for the subscription to be active, so events published after Subscribe returns
+	// are received
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan StoreEvent, storeQueueSize)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event StoreEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (r *RedisSessionStore) ActiveSession(ctx context.Context, chatID string) (string, error) {
+	messageID, err := r.client.Get(ctx, redisActiveKey(chatID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return messageID, err
+}
@@ -12,7 +12,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/eternisai/enchanted-proxy/internal/capabilities"
+	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/streamrecord"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -93,8 +97,12 @@ type StreamSession struct {
 	responseIDMu sync.RWMutex // Protects responseID
 
 	// Chunk storage (buffered for late-join replay)
-	chunks   []StreamChunk
-	chunksMu sync.RWMutex
+	chunks       []StreamChunk
+	chunksMu     sync.RWMutex
+	chunksStored chan struct{} // Closed and replaced when a chunk is stored (guarded by chunksMu)
+
+	// publishMu orders publishing chunks against subscribers joining and leaving replay
+	publishMu sync.Mutex
 
 	// Token usage (extracted from upstream response)
 	tokenUsage   *TokenUsage
@@ -106,16 +114,52 @@ type StreamSession struct {
 
 	// Tool execution
 	toolExecutor      *ToolExecutor
-	originalRequest   []byte // Original request body for continuation
-	upstreamURL       string // Provider base URL for continuation
-	upstreamAPIKey    string // Provider API key for continuation
-	continuationCount int    // Number of tool continuations executed
+	originalRequest   []byte         // Original request body for continuation
+	allowedTools      []string       // Server-side tools the user's tier may use
+	upstreamURL       string         // Provider base URL for continuation
+	upstreamAPIKey    string         // Provider API key for continuation
+	upstreamAPIType   config.APIType // Provider API format for continuation ("" = chat completions)
+	continuationCount int            // Number of tool continuations executed
 	requestMu         sync.RWMutex
 
 	// Model info (for model-specific content filtering)
 	model   string
 	modelMu sync.RWMutex
 
+	// Reasoning visibility (show/separate/strip thinking output)
+	reasoningVisibility ReasoningVisibility
+	reasoningMu         sync.RWMutex
+
+	// Content filters of the account's content policy (nil = none)
+	contentFilter   *ContentFilterChain
+	contentFilterMu sync.RWMutex
+
+	// Client capabilities (nil = legacy defaults)
+	clientCaps   *capabilities.Set
+	clientCapsMu sync.RWMutex
+
+	// Streaming latency measurement (nil = not tracked)
+	latency *latencyTracker
+
+	// Debug recording of raw upstream lines (nil = not recorded)
+	recording *streamrecord.Recording
+
+	// Debug trace token of the request that started the stream ("" = not traced)
+	debugTrace string
+
+	// Span of the request that started the stream; tool execution and message storage are
+	// traced as its children
+	spanContext trace.SpanContext
+
+	// Shared session store (nil = this instance only); the writer copies chunks to it while
+	// upstream is read, and is detached on completion (guarded by chunksMu)
+	store       SessionStore
+	instanceID  string
+	storeWriter *storeWriter
+
+	// remote sessions mirror a session read upstream by another instance (see newRemoteSession)
+	remote bool
+
 	// Logger
 	logger *logger.Logger
 }
@@ -149,6 +193,7 @@ func NewStreamSession(chatID, messageID string, upstreamBody io.ReadCloser, logg
 		stopCancel:    stopCancel,
 		completedChan: make(chan struct{}),
 		chunks:        make([]StreamChunk, 0, 100), // Pre-allocate for typical response
+		chunksStored:  make(chan struct{}),
 		subscribers:   make(map[string]*StreamSubscriber),
 		logger:        logger,
 	}
@@ -201,12 +246,77 @@ func (s *StreamSession) SetUpstreamBodyAndStart(upstreamBody io.ReadCloser) {
 	go s.readUpstream()
 }
 
+// SetDeadline stops the stream with StopReasonTimeout once the request's overall time budget
+// runs out, wherever it is: waiting for upstream, streaming or running tools. Subscribers get
+// the stream_stopped event and the content generated so far is saved like any stopped stream.
+// A zero deadline means no budget; the upstream read timeout applies either way.
+func (s *StreamSession) SetDeadline(deadline time.Time) {
+	if deadline.IsZero() {
+		return
+	}
+
+	timer := time.AfterFunc(time.Until(deadline), func() {
+		if err := s.Stop(StoppedBySystemTimeout, StopReasonTimeout); err != nil {
+			return
+		}
+		s.logger.Warn("request time budget exceeded, stream stopped",
+			slog.String("chat_id", s.chatID),
+			slog.String("message_id", s.messageID),
+			slog.Duration("elapsed", time.Since(s.startTime)))
+	})
+
+	// Release the session as soon as it completes instead of when the budget would have run out
+	go func() {
+		<-s.completedChan
+		timer.Stop()
+	}()
+}
+
 // SetToolExecutor sets the tool executor for this session.
 // Must be called before Start() if tool execution is desired.
 func (s *StreamSession) SetToolExecutor(executor *ToolExecutor) {
 	s.toolExecutor = executor
 }
 
+// SetLatencyTracking enables time-to-first-token and inter-chunk latency metrics for this
+// session, labeled with provider and model. requestStart is when the upstream request was sent.
+// Must be called before Start().
+func (s *StreamSession) SetLatencyTracking(provider, model string, requestStart time.Time) {
+	s.latency = newLatencyTracker(provider, model, requestStart)
+}
+
+// SetRecording enables debug recording of the raw upstream stream into rec.
+// Must be called before Start().
+func (s *StreamSession) SetRecording(rec *streamrecord.Recording) {
+	s.recording = rec
+}
+
+// SetSessionStore shares the session through store once upstream reading starts, so
+// clients reconnecting to other instances can replay and follow it.
+func (s *StreamSession) SetSessionStore(store SessionStore, instanceID string) {
+	s.store = store
+	s.instanceID = instanceID
+}
+
+// IsRemote returns whether the session mirrors a session read upstream by another instance.
+// Remote sessions can be subscribed to but not stopped or saved.
+func (s *StreamSession) IsRemote() bool {
+	return s.remote
+}
+
+// SetDebugTrace elevates logging of the session (including tool execution and message
+// storage) to the debug trace of the request that started it. Must be called before Start().
+func (s *StreamSession) SetDebugTrace(token string) {
+	s.debugTrace = token
+	s.logger = s.logger.WithDebugTrace(token)
+}
+
+// SetSpanContext sets the span of the request that started the session. Must be called
+// before Start().
+func (s *StreamSession) SetSpanContext(spanContext trace.SpanContext) {
+	s.spanContext = spanContext
+}
+
 // SetOriginalRequest stores the original request body for tool call continuation.
 // Must be called before Start() if tool execution is desired.
 func (s *StreamSession) SetOriginalRequest(requestBody []byte) {
@@ -215,6 +325,14 @@ func (s *StreamSession) SetOriginalRequest(requestBody []byte) {
 	s.originalRequest = requestBody
 }
 
+// SetAllowedTools stores the server-side tools the user's tier may use; tool calls of other
+// tools are refused. Must be called before Start() if tool execution is desired.
+func (s *StreamSession) SetAllowedTools(names []string) {
+	s.requestMu.Lock()
+	defer s.requestMu.Unlock()
+	s.allowedTools = names
+}
+
 // SetUpstreamURL stores the provider base URL for tool call continuation.
 // Must be called before Start() if tool execution is desired.
 func (s *StreamSession) SetUpstreamURL(url string) {
@@ -231,6 +349,14 @@ func (s *StreamSession) SetUpstreamAPIKey(apiKey string) {
 	s.upstreamAPIKey = apiKey
 }
 
+// SetUpstreamAPIType stores the provider API format for tool call continuation. Continuations
+// to providers with a native API (Anthropic, Gemini) are translated, and their streams back.
+func (s *StreamSession) SetUpstreamAPIType(apiType config.APIType) {
+	s.requestMu.Lock()
+	defer s.requestMu.Unlock()
+	s.upstreamAPIType = apiType
+}
+
 // SetUserID stores the user ID for authentication during tool execution.
 // Must be called before Start() if tool execution with authentication is desired.
 func (s *StreamSession) SetUserID(userID string) {
@@ -239,6 +365,13 @@ func (s *StreamSession) SetUserID(userID string) {
 	s.userID = userID
 }
 
+// GetUserID returns the user ID set by SetUserID ("" if not set).
+func (s *StreamSession) GetUserID() string {
+	s.userIDMu.RLock()
+	defer s.userIDMu.RUnlock()
+	return s.userID
+}
+
 // SetModel stores the model name for model-specific content filtering.
 // Must be called before Start() if GLM content filtering is desired.
 func (s *StreamSession) SetModel(model string) {
@@ -247,6 +380,50 @@ func (s *StreamSession) SetModel(model string) {
 	s.model = model
 }
 
+// SetReasoningVisibility controls how reasoning output is streamed and stored.
+// Must be called before Start(). Defaults to ReasoningVisibilityShow.
+func (s *StreamSession) SetReasoningVisibility(visibility ReasoningVisibility) {
+	s.reasoningMu.Lock()
+	defer s.reasoningMu.Unlock()
+	s.reasoningVisibility = visibility
+}
+
+// SetContentFilter sets the filters applied to content deltas before they are broadcast and
+// stored (nil = none). Must be called before Start().
+func (s *StreamSession) SetContentFilter(filter *ContentFilterChain) {
+	s.contentFilterMu.Lock()
+	defer s.contentFilterMu.Unlock()
+	s.contentFilter = filter
+}
+
+// getReasoningVisibility returns the configured reasoning visibility.
+func (s *StreamSession) getReasoningVisibility() ReasoningVisibility {
+	s.reasoningMu.RLock()
+	defer s.reasoningMu.RUnlock()
+	if s.reasoningVisibility == "" {
+		return ReasoningVisibilityShow
+	}
+	return s.reasoningVisibility
+}
+
+// SetClientCapabilities stores the capabilities negotiated via X-Client-Capabilities.
+// Must be called before Start(). Defaults to capabilities.LegacyDefaults.
+func (s *StreamSession) SetClientCapabilities(set capabilities.Set) {
+	s.clientCapsMu.Lock()
+	defer s.clientCapsMu.Unlock()
+	s.clientCaps = &set
+}
+
+// hasCapability reports whether the client that started this session supports the capability.
+func (s *StreamSession) hasCapability(c capabilities.Capability) bool {
+	s.clientCapsMu.RLock()
+	defer s.clientCapsMu.RUnlock()
+	if s.clientCaps == nil {
+		return capabilities.Parse("").Has(c)
+	}
+	return s.clientCaps.Has(c)
+}
+
 // isGLMModel returns true if the current model is a GLM model that needs content filtering.
 func (s *StreamSession) isGLMModel() bool {
 	s.modelMu.RLock()
@@ -274,6 +451,14 @@ func (s *StreamSession) getContextWithUserID() context.Context {
 		ctx = logger.WithChatID(ctx, s.chatID)
 	}
 
+	if s.debugTrace != "" {
+		ctx = logger.WithDebugTrace(ctx, s.debugTrace)
+	}
+
+	if s.spanContext.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, s.spanContext)
+	}
+
 	return ctx
 }
 
@@ -310,6 +495,19 @@ func (s *StreamSession) readUpstream() {
 		slog.String("chat_id", s.chatID),
 		slog.String("message_id", s.messageID))
 
+	if s.store != nil {
+		writer := newStoreWriter(s.store, SessionMeta{
+			ChatID:     s.chatID,
+			MessageID:  s.messageID,
+			UserID:     s.GetUserID(),
+			InstanceID: s.instanceID,
+			StartTime:  s.startTime,
+		}, s.logger)
+		s.chunksMu.Lock()
+		s.storeWriter = writer
+		s.chunksMu.Unlock()
+	}
+
 	// Create scanner for SSE lines
 	scanner := bufio.NewScanner(s.upstreamBody)
 	scanner.Buffer(make([]byte, 64*1024), maxChunkSize) // 64KB initial, 1MB max
@@ -331,6 +529,20 @@ func (s *StreamSession) readUpstream() {
 			slog.String("model", s.model))
 	}
 
+	// Thinking filter for reasoning visibility other than the default "show"
+	var thinkingFilter *ThinkingFilter
+	reasoningVisibility := s.getReasoningVisibility()
+	if reasoningVisibility != ReasoningVisibilityShow {
+		thinkingFilter = NewThinkingFilter()
+		s.logger.Debug("thinking filter enabled",
+			slog.String("reasoning_visibility", string(reasoningVisibility)))
+	}
+
+	// Content filters of the account's content policy (stop sequences, redactions)
+	s.contentFilterMu.RLock()
+	contentFilter := s.contentFilter
+	s.contentFilterMu.RUnlock()
+
 	for scanner.Scan() {
 		// Check if stop was requested
 		select {
@@ -362,6 +574,14 @@ func (s *StreamSession) readUpstream() {
 			continue
 		}
 
+		received := time.Now()
+		if s.latency != nil {
+			s.latency.observe(line, received)
+		}
+		if s.recording != nil {
+			s.recording.RecordLine(line, received)
+		}
+
 		// Apply GLM content filter if enabled
 		// This strips <tool_call> XML tags from content that GLM 4.7 outputs inline
 		if glmFilter != nil {
@@ -379,6 +599,33 @@ func (s *StreamSession) readUpstream() {
 			line = normalized
 		}
 
+		// Strip or separate reasoning (<think> segments and delta.reasoning) if requested
+		if thinkingFilter != nil {
+			filteredLine, reasoning, wasFiltered := thinkingFilter.FilterSSELine(line)
+			if wasFiltered {
+				line = filteredLine
+			}
+			if reasoning != "" && reasoningVisibility == ReasoningVisibilitySeparate {
+				if eventLine, err := reasoningEventLine(reasoning); err == nil {
+					reasoningChunk := StreamChunk{
+						Index:     chunkIndex,
+						Line:      eventLine,
+						Kind:      ChunkKindReasoning,
+						Timestamp: time.Now(),
+					}
+					s.publish(reasoningChunk)
+					chunkIndex++
+				}
+			}
+		}
+
+		// Filter visible content before it is broadcast and stored
+		if contentFilter != nil {
+			if filteredLine, wasFiltered := contentFilter.FilterSSELine(line); wasFiltered {
+				line = filteredLine
+			}
+		}
+
 		// Extract token usage if present in this chunk
 		if usage := extractTokenUsageFromLine(line); usage != nil {
 			s.tokenUsageMu.Lock()
@@ -418,8 +665,7 @@ func (s *StreamSession) readUpstream() {
 		// Store chunk (with safety limits) only if not a tool call chunk
 		// Tool call chunks are suppressed from the stream
 		if !isToolCallChunk {
-			s.storeChunk(chunk)
-			s.broadcast(chunk)
+			s.publish(chunk)
 		}
 
 		chunkIndex++
@@ -434,6 +680,14 @@ func (s *StreamSession) readUpstream() {
 				slog.String("message_id", s.messageID),
 				slog.Int("tool_call_count", len(toolCalls)))
 
+			if s.recording != nil {
+				names := make([]string, len(toolCalls))
+				for i, tc := range toolCalls {
+					names[i] = tc.Function.Name
+				}
+				s.recording.RecordEvent(streamrecord.EventToolCalls, strings.Join(names, ","), time.Now())
+			}
+
 			// Log each tool call for debugging (helps diagnose tool loops)
 			for i, tc := range toolCalls {
 				s.logger.Info("tool call details",
@@ -446,7 +700,11 @@ func (s *StreamSession) readUpstream() {
 			// Create callback to broadcast notifications in real-time
 			// This is called from tool executor goroutines as events occur
 			var chunkMu sync.Mutex
+			toolNotificationsEnabled := s.hasCapability(capabilities.ToolNotifications)
 			onNotification := func(notif ToolNotification) {
+				if !toolNotificationsEnabled {
+					return
+				}
 				notifJSON, err := json.Marshal(map[string]interface{}{
 					"type":         "tool_notification",
 					"event":        notif.Event,
@@ -474,13 +732,18 @@ func (s *StreamSession) readUpstream() {
 				chunkIndex++
 				chunkMu.Unlock()
 
-				s.storeChunk(notifChunk)
-				s.broadcast(notifChunk)
+				s.publish(notifChunk)
 			}
 
 			// Execute tools with real-time notification callback
 			// Use context with userID for authentication
-			toolResults, err := s.toolExecutor.ExecuteToolCalls(s.getContextWithUserID(), s.chatID, s.messageID, toolCalls, onNotification)
+			toolCtx := s.getContextWithUserID()
+			s.requestMu.RLock()
+			requestBody := s.originalRequest
+			allowedTools := s.allowedTools
+			s.requestMu.RUnlock()
+			toolCtx = withOfferedTools(toolCtx, requestBody, allowedTools)
+			toolResults, err := s.toolExecutor.ExecuteToolCalls(toolCtx, s.chatID, s.messageID, toolCalls, onNotification)
 			if err != nil {
 				s.logger.Error("tool execution failed",
 					slog.String("error", err.Error()),
@@ -489,11 +752,20 @@ func (s *StreamSession) readUpstream() {
 				// Continue despite error - tool results will contain error messages
 			}
 
+			// Stopped while tools ran (user, admin or time budget): no continuation
+			if s.IsStopped() {
+				s.logger.Info("stream stopped during tool execution, skipping continuation",
+					slog.String("chat_id", s.chatID),
+					slog.String("message_id", s.messageID))
+				break
+			}
+
 			// Create continuation request with tool results
 			s.requestMu.RLock()
 			originalRequest := s.originalRequest
 			upstreamURL := s.upstreamURL
 			upstreamAPIKey := s.upstreamAPIKey
+			upstreamAPIType := s.upstreamAPIType
 			continuationCount := s.continuationCount
 			s.requestMu.RUnlock()
 
@@ -518,16 +790,14 @@ func (s *StreamSession) readUpstream() {
 						IsFinal:   false,
 						IsError:   true,
 					}
-					s.storeChunk(maxDepthChunk)
-					s.broadcast(maxDepthChunk)
+					s.publish(maxDepthChunk)
 					chunkIndex++
 				}
 
 				// Send error message as content
 				errorMsg := fmt.Sprintf("I apologize, but I've reached the maximum number of tool calls (%d) for this request. Please try breaking your request into smaller parts.", maxContinuations)
 				errorContentChunk := s.createContentChunk(chunkIndex, errorMsg)
-				s.storeChunk(errorContentChunk)
-				s.broadcast(errorContentChunk)
+				s.publish(errorContentChunk)
 				chunkIndex++
 
 				// Send [DONE] to complete the stream
@@ -538,8 +808,7 @@ func (s *StreamSession) readUpstream() {
 					IsFinal:   true,
 					IsError:   false,
 				}
-				s.storeChunk(doneChunk)
-				s.broadcast(doneChunk)
+				s.publish(doneChunk)
 
 				// Exit loop to mark as completed
 				break
@@ -593,6 +862,7 @@ func (s *StreamSession) readUpstream() {
 					s.stopCtx,
 					upstreamURL,
 					upstreamAPIKey,
+					upstreamAPIType,
 					originalReq,
 					originalMessages,
 					assistantMessage,
@@ -616,16 +886,14 @@ func (s *StreamSession) readUpstream() {
 							IsFinal:   false,
 							IsError:   true,
 						}
-						s.storeChunk(errChunk)
-						s.broadcast(errChunk)
+						s.publish(errChunk)
 						chunkIndex++
 					}
 
 					// Send error message as content so stream has saveable content
 					errorMsg := fmt.Sprintf("I apologize, but I encountered an error while processing the tool results: %s", err.Error())
 					errorContentChunk := s.createContentChunk(chunkIndex, errorMsg)
-					s.storeChunk(errorContentChunk)
-					s.broadcast(errorContentChunk)
+					s.publish(errorContentChunk)
 					chunkIndex++
 
 					// Send [DONE] to complete the stream
@@ -636,8 +904,7 @@ func (s *StreamSession) readUpstream() {
 						IsFinal:   true,
 						IsError:   false,
 					}
-					s.storeChunk(doneChunk)
-					s.broadcast(doneChunk)
+					s.publish(doneChunk)
 
 					// Exit loop to mark as completed
 					break
@@ -658,6 +925,12 @@ func (s *StreamSession) readUpstream() {
 				scanner = bufio.NewScanner(s.upstreamBody)
 				scanner.Buffer(make([]byte, 64*1024), maxChunkSize)
 				toolDetector = NewToolCallDetector() // Reset for next potential tool call
+				if s.latency != nil {
+					s.latency.resume()
+				}
+				if s.recording != nil {
+					s.recording.RecordEvent(streamrecord.EventContinuation, "", time.Now())
+				}
 
 				s.logger.Info("continuation request created, resuming stream",
 					slog.String("chat_id", s.chatID),
@@ -677,8 +950,7 @@ func (s *StreamSession) readUpstream() {
 				// Send error message as content
 				errorMsg := "I apologize, but I encountered a configuration error while trying to process the tool results. Please try again."
 				errorContentChunk := s.createContentChunk(chunkIndex, errorMsg)
-				s.storeChunk(errorContentChunk)
-				s.broadcast(errorContentChunk)
+				s.publish(errorContentChunk)
 				chunkIndex++
 
 				// Send [DONE] to complete the stream
@@ -689,8 +961,7 @@ func (s *StreamSession) readUpstream() {
 					IsFinal:   true,
 					IsError:   false,
 				}
-				s.storeChunk(doneChunk)
-				s.broadcast(doneChunk)
+				s.publish(doneChunk)
 
 				// Exit loop to mark as completed
 				break
@@ -723,7 +994,9 @@ func (s *StreamSession) readUpstream() {
 		// 5. We should complete successfully with what we have, not error out
 		isContextCanceled := errors.Is(err, context.Canceled)
 
-		if isContextCanceled {
+		// A stopped stream may be cut off mid-read (e.g. the request time budget also bounds the
+		// upstream request); the stream_stopped event has already been sent.
+		if isContextCanceled || s.IsStopped() {
 			// Log as warning, not error, since this is expected behavior
 			s.logger.Warn("upstream read interrupted by context cancellation, completing with buffered data",
 				slog.String("chat_id", s.chatID),
@@ -754,8 +1027,7 @@ func (s *StreamSession) readUpstream() {
 			IsFinal:   true,
 			IsError:   true,
 		}
-		s.storeChunk(errorChunk)
-		s.broadcast(errorChunk)
+		s.publish(errorChunk)
 
 		s.markCompleted(err)
 		return
@@ -773,9 +1045,9 @@ func (s *StreamSession) readUpstream() {
 // Includes all required fields (id, object, model) for client-side parsing compatibility.
 func (s *StreamSession) createContentChunk(index int, content string) StreamChunk {
 	chunkData := map[string]interface{}{
-		"id":      fmt.Sprintf("chatcmpl-tool-%s-%d", s.messageID, index),
-		"object":  "chat.completion.chunk",
-		"model":   s.model,
+		"id":     fmt.Sprintf("chatcmpl-tool-%s-%d", s.messageID, index),
+		"object": "chat.completion.chunk",
+		"model":  s.model,
 		"choices": []map[string]interface{}{
 			{
 				"index": 0,
@@ -823,6 +1095,10 @@ func (s *StreamSession) storeChunk(chunk StreamChunk) {
 		chunk.Line = chunk.Line[:maxChunkSize]
 	}
 
+	if s.storeWriter != nil {
+		s.storeWriter.chunk(chunk)
+	}
+
 	// Safety: If buffer is full, drop oldest chunks (keep first 100 and last chunks)
 	if len(s.chunks) >= maxChunks {
 		s.logger.Warn("chunk buffer full, dropping old chunks",
@@ -835,9 +1111,21 @@ func (s *StreamSession) storeChunk(chunk StreamChunk) {
 	}
 
 	s.chunks = append(s.chunks, chunk)
+	close(s.chunksStored)
+	s.chunksStored = make(chan struct{})
+}
+
+// publish stores a chunk for replay and broadcasts it. Chunks are published one at a time,
+// so subscribers replaying from the start get every chunk once, in order.
+func (s *StreamSession) publish(chunk StreamChunk) {
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+	s.storeChunk(chunk)
+	s.broadcast(chunk)
 }
 
-// broadcast sends a chunk to all subscribers (non-blocking).
+// broadcast sends a chunk to all subscribers (non-blocking); subscribers still replaying get
+// it after the replay. Must hold publishMu.
 // Slow subscribers may miss chunks, but fast subscribers and upstream reading are not affected.
 func (s *StreamSession) broadcast(chunk StreamChunk) {
 	s.subscribersMu.RLock()
@@ -853,6 +1141,10 @@ func (s *StreamSession) broadcast(chunk StreamChunk) {
 		if sub.IsDisconnected() {
 			continue
 		}
+		if sub.replaying {
+			sub.pending = append(sub.pending, chunk)
+			continue
+		}
 
 		// Non-blocking send with timeout
 		sent := sub.Send(chunk, subscriberSendTimeout)
@@ -872,15 +1164,22 @@ func (s *StreamSession) markCompleted(err error) {
 		s.completedMu.Unlock()
 		return // Already completed
 	}
+	completedAt := time.Now()
 	s.completed = true
-	s.completedAt = time.Now()
+	s.completedAt = completedAt
 	s.err = err
 	s.completedMu.Unlock()
 
-	// Get chunk count under lock for logging
-	s.chunksMu.RLock()
+	// Get chunk count under lock for logging, detaching the store writer
+	s.chunksMu.Lock()
 	chunkCount := len(s.chunks)
-	s.chunksMu.RUnlock()
+	writer := s.storeWriter
+	s.storeWriter = nil
+	s.chunksMu.Unlock()
+
+	if writer != nil {
+		writer.complete(completedAt, err, s.GetUserID())
+	}
 
 	s.logger.Info("stream session completed",
 		slog.String("chat_id", s.chatID),
@@ -897,20 +1196,35 @@ func (s *StreamSession) markCompleted(err error) {
 }
 
 // closeAllSubscribers closes all subscriber channels.
-// Called when stream completes or is stopped.
+// Called when stream completes or is stopped. Subscribers still replaying are closed by
+// replayChunks once they have caught up.
 func (s *StreamSession) closeAllSubscribers() {
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
 	s.subscribersMu.Lock()
 	defer s.subscribersMu.Unlock()
 
 	for id, sub := range s.subscribers {
-		sub.Cancel()
-		sub.Close()
+		if sub.replaying {
+			continue
+		}
+		s.closeSubscriber(sub)
 		s.logger.Debug("closed subscriber channel",
 			slog.String("subscriber_id", id),
 			slog.String("chat_id", s.chatID))
 	}
 }
 
+// closeSubscriber cancels and closes a subscriber once. Must hold publishMu.
+func (s *StreamSession) closeSubscriber(sub *StreamSubscriber) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	sub.Cancel()
+	sub.Close()
+}
+
 // Subscribe adds a new subscriber to this stream.
 //
 // Parameters:
@@ -932,10 +1246,19 @@ func (s *StreamSession) Subscribe(ctx context.Context, subscriberID string, opts
 	// Create subscriber
 	sub := NewStreamSubscriber(ctx, subscriberID, opts)
 
-	// Add to subscribers map
+	// Add to subscribers map, taking the replay snapshot at the same point of the stream:
+	// chunks published afterwards are queued for the subscriber until replay catches up
+	s.publishMu.Lock()
+	replay := opts.ReplayFromStart || s.IsCompleted()
+	var chunks []StreamChunk
+	if replay {
+		sub.replaying = true
+		chunks = s.GetStoredChunks()
+	}
 	s.subscribersMu.Lock()
 	s.subscribers[subscriberID] = sub
 	s.subscribersMu.Unlock()
+	s.publishMu.Unlock()
 
 	s.logger.Info("new subscriber joined",
 		slog.String("subscriber_id", subscriberID),
@@ -944,42 +1267,50 @@ func (s *StreamSession) Subscribe(ctx context.Context, subscriberID string, opts
 		slog.Bool("replay_from_start", opts.ReplayFromStart))
 
 	// If replay requested or stream completed, send buffered chunks
-	if opts.ReplayFromStart || s.IsCompleted() {
-		go s.replayChunks(sub)
+	if replay {
+		go s.replayChunks(sub, chunks)
 	}
 
 	return sub, nil
 }
 
-// replayChunks sends all buffered chunks to a subscriber.
-// Used for late-joiners or when stream has completed.
+// replayChunks sends the buffered chunks, then those published since Subscribe, to a
+// subscriber and switches it to live broadcast. Used for late-joiners or when stream has completed.
 //
-// Sends are blocking to ensure the subscriber receives all chunks in order.
-func (s *StreamSession) replayChunks(sub *StreamSubscriber) {
-	s.chunksMu.RLock()
-	chunks := make([]StreamChunk, len(s.chunks))
-	copy(chunks, s.chunks)
-	s.chunksMu.RUnlock()
-
+// Sends are blocking to ensure the subscriber receives all chunks in order, exactly once.
+func (s *StreamSession) replayChunks(sub *StreamSubscriber, chunks []StreamChunk) {
 	s.logger.Debug("replaying chunks to subscriber",
 		slog.String("subscriber_id", sub.ID),
 		slog.Int("chunk_count", len(chunks)),
 		slog.String("chat_id", s.chatID))
 
-	for _, chunk := range chunks {
-		if !sub.SendBlocking(chunk) {
-			// Subscriber disconnected
-			s.logger.Debug("subscriber disconnected during replay",
-				slog.String("subscriber_id", sub.ID),
-				slog.String("chat_id", s.chatID))
-			return
+	for {
+		for _, chunk := range chunks {
+			if !sub.SendBlocking(chunk) {
+				// Subscriber disconnected
+				s.logger.Debug("subscriber disconnected during replay",
+					slog.String("subscriber_id", sub.ID),
+					slog.String("chat_id", s.chatID))
+				s.publishMu.Lock()
+				sub.replaying = false
+				sub.pending = nil
+				s.publishMu.Unlock()
+				return
+			}
 		}
-	}
 
-	// If stream is completed, close the subscriber
-	if s.IsCompleted() {
-		sub.Cancel()
-		sub.Close()
+		s.publishMu.Lock()
+		chunks, sub.pending = sub.pending, nil
+		if len(chunks) == 0 {
+			sub.replaying = false
+			// If stream is completed, close the subscriber (closeAllSubscribers skipped it)
+			if s.IsCompleted() {
+				s.closeSubscriber(sub)
+			}
+			s.publishMu.Unlock()
+			return
+		}
+		s.publishMu.Unlock()
 	}
 }
 
@@ -1059,8 +1390,7 @@ func (s *StreamSession) Stop(stoppedBy string, reason StopReason) error {
 		IsFinal:   true,
 		IsError:   false,
 	}
-	s.storeChunk(stopEvent)
-	s.broadcast(stopEvent)
+	s.publish(stopEvent)
 
 	// Give a brief moment for the stop event to be delivered before readUpstream exits
 	// readUpstream will detect stopCtx cancellation and call markCompleted, which closes channels
@@ -1138,6 +1468,39 @@ func (s *StreamSession) GetStoredChunks() []StreamChunk {
 	return chunks
 }
 
+// ChunksAfter returns the buffered chunks with an index above after (-1 for all chunks). If
+// there are none yet, it waits until a chunk is stored, the session completes or ctx is done.
+// completed reports whether the session had completed, in which case no more chunks follow.
+//
+// Used by clients that can't hold a stream open (long polling), which pass the index of the
+// last chunk they received.
+func (s *StreamSession) ChunksAfter(ctx context.Context, after int) (chunks []StreamChunk, completed bool) {
+	for {
+		// Read completion first: chunks are stored before the session completes
+		completed = s.IsCompleted()
+
+		s.chunksMu.RLock()
+		for _, chunk := range s.chunks {
+			if chunk.Index > after {
+				chunks = append(chunks, chunk)
+			}
+		}
+		stored := s.chunksStored
+		s.chunksMu.RUnlock()
+
+		if len(chunks) > 0 || completed {
+			return chunks, completed
+		}
+
+		select {
+		case <-stored:
+		case <-s.completedChan:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
 // GetContent extracts the full message content from all buffered chunks.
 // This is used when saving the complete message to Firestore.
 //
@@ -1194,6 +1557,38 @@ func (s *StreamSession) GetContent() string {
 	return content.String()
 }
 
+// GetAnomalies inspects the completed stream for truncation, empty output, abnormal finish
+// reasons and garbled text. Streams that are still running or were stopped are not checked.
+func (s *StreamSession) GetAnomalies() []Anomaly {
+	if !s.IsCompleted() || s.IsStopped() {
+		return nil
+	}
+	return DetectAnomalies(s.GetStoredChunks())
+}
+
+// GetReasoning extracts separated reasoning from buffered reasoning chunks.
+// Only populated when reasoning visibility is ReasoningVisibilitySeparate.
+//
+// Returns:
+//   - string: The complete reasoning text (empty if none was separated)
+func (s *StreamSession) GetReasoning() string {
+	s.chunksMu.RLock()
+	defer s.chunksMu.RUnlock()
+
+	var reasoning strings.Builder
+
+	for _, chunk := range s.chunks {
+		if chunk.Kind != ChunkKindReasoning {
+			continue
+		}
+		if content, ok := reasoningEventContent(chunk.Line); ok {
+			reasoning.WriteString(content)
+		}
+	}
+
+	return reasoning.String()
+}
+
 // GetInfo returns metadata about this stream session.
 // Used for observability and debugging.
 func (s *StreamSession) GetInfo() StreamInfo {
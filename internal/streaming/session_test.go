@@ -357,6 +357,67 @@ func TestStreamSessionStop(t *testing.T) {
 	}
 }
 
+func TestStreamSessionDeadline(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	longContent := make([]string, 100)
+	for i := range longContent {
+		longContent[i] = "data: {\"choices\":[{\"delta\":{\"content\":\"test\"}}]}"
+	}
+	longContent = append(longContent, "data: [DONE]")
+
+	body := newSlowMockSSEStream(longContent, 10*time.Millisecond)
+	session := NewStreamSession("chat-123", "msg-456", body, log)
+	session.SetDeadline(time.Now().Add(150 * time.Millisecond))
+	session.Start()
+
+	done := make(chan struct{})
+	go func() {
+		session.WaitForCompletion()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(1 * time.Second):
+		t.Fatal("session did not complete after the deadline")
+	}
+
+	if !session.IsStopped() {
+		t.Fatal("session should be stopped by the deadline")
+	}
+	stoppedBy, reason := session.GetStopInfo()
+	if stoppedBy != StoppedBySystemTimeout || reason != StopReasonTimeout {
+		t.Errorf("stop info = %q, %q; want %q, %q", stoppedBy, reason, StoppedBySystemTimeout, StopReasonTimeout)
+	}
+
+	content := session.GetContent()
+	if content == "" || len(content) >= 100*len("test") {
+		t.Errorf("expected partial content, got %d bytes", len(content))
+	}
+
+	chunks := session.GetStoredChunks()
+	if len(chunks) == 0 || !strings.Contains(chunks[len(chunks)-1].Line, `"reason":"timeout"`) {
+		t.Error("expected the stream to end with a timeout stop event")
+	}
+}
+
+func TestStreamSessionDeadlineAfterCompletion(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	body := newMockSSEStream([]string{
+		"data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}",
+		"data: [DONE]",
+	})
+	session := NewStreamSession("chat-123", "msg-456", body, log)
+	session.SetDeadline(time.Now().Add(50 * time.Millisecond))
+	session.Start()
+	session.WaitForCompletion()
+
+	time.Sleep(100 * time.Millisecond)
+	if session.IsStopped() {
+		t.Error("a completed session should not be stopped by its deadline")
+	}
+}
+
 func TestStreamSessionLateJoiner(t *testing.T) {
 	log := logger.New(logger.Config{Level: slog.LevelError})
 	lines := []string{
@@ -0,0 +1,201 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+const (
+	// storeQueueSize is how many chunks a session may have waiting to be written to the store
+	// Chunks beyond it are dropped from the shared copy (local subscribers still get them)
+	storeQueueSize = 1000
+
+	// storeWriteTimeout bounds each write to the store
+	storeWriteTimeout = 5 * time.Second
+)
+
+// SessionMeta is the state of a stream session shared through a SessionStore.
+type SessionMeta struct {
+	ChatID      string    `json:"chat_id"`
+	MessageID   string    `json:"message_id"`
+	UserID      string    `json:"user_id,omitempty"`
+	InstanceID  string    `json:"instance_id"` // Instance reading upstream
+	StartTime   time.Time `json:"start_time"`
+	Completed   bool      `json:"completed"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// StoreEvent is a change to a stored session published to its live subscribers: either a
+// chunk (with its 1-based position in the stored chunk list) or updated metadata.
+type StoreEvent struct {
+	Seq   int64        `json:"seq,omitempty"`
+	Chunk *StreamChunk `json:"chunk,omitempty"`
+	Meta  *SessionMeta `json:"meta,omitempty"`
+}
+
+// SessionStore persists stream sessions outside the instance reading upstream, so a client
+// reconnecting to any instance can replay the stream and follow it live.
+//
+// Implementations must keep a session's chunks in append order and expire sessions on their
+// own (the owning instance may disappear without cleaning up).
+type SessionStore interface {
+	// SaveSession creates or replaces a session's metadata and publishes it.
+	SaveSession(ctx context.Context, meta SessionMeta) error
+
+	// AppendChunk stores a chunk after the session's previous ones and publishes it.
+	AppendChunk(ctx context.Context, chatID, messageID string, chunk StreamChunk) error
+
+	// LoadSession returns a session's metadata and chunks (nil metadata if the session is
+	// unknown or expired).
+	LoadSession(ctx context.Context, chatID, messageID string) (*SessionMeta, []StreamChunk, error)
+
+	// Subscribe returns the events published for a session from now on. The channel is
+	// closed when ctx is cancelled or the subscription fails.
+	Subscribe(ctx context.Context, chatID, messageID string) (<-chan StoreEvent, error)
+
+	// ActiveSession returns the message ID of the chat's latest session that hasn't
+	// completed, or "" if there is none.
+	ActiveSession(ctx context.Context, chatID string) (string, error)
+}
+
+// storeWriter copies a session's chunks and completion to a SessionStore in order, without
+// blocking the upstream read.
+type storeWriter struct {
+	store  SessionStore
+	meta   SessionMeta
+	queue  chan StreamChunk
+	final  *SessionMeta // Set by complete before closing queue
+	logger *logger.Logger
+}
+
+func newStoreWriter(store SessionStore, meta SessionMeta, logger *logger.Logger) *storeWriter {
+	w := &storeWriter{
+		store:  store,
+		meta:   meta,
+		queue:  make(chan StreamChunk, storeQueueSize),
+		logger: logger,
+	}
+	go w.run()
+	return w
+}
+
+// chunk queues a chunk; it is dropped from the shared copy if the queue is full.
+func (w *storeWriter) chunk(chunk StreamChunk) {
+	select {
+	case w.queue <- chunk:
+	default:
+		w.logger.Warn("session store queue full, dropped chunk",
+			slog.String("chat_id", w.meta.ChatID),
+			slog.Int("chunk_index", chunk.Index))
+	}
+}
+
+// complete stops the writer; the final metadata is written after the queued chunks. No
+// chunk may be queued afterwards.
+func (w *storeWriter) complete(completedAt time.Time, err error, userID string) {
+	meta := w.meta
+	meta.UserID = userID
+	meta.Completed = true
+	meta.CompletedAt = completedAt
+	if err != nil {
+		meta.Error = err.Error()
+	}
+	w.final = &meta
+	close(w.queue)
+}
+
+func (w *storeWriter) run() {
+	w.write(func(ctx context.Context) error { return w.store.SaveSession(ctx, w.meta) })
+	for chunk := range w.queue {
+		w.write(func(ctx context.Context) error {
+			return w.store.AppendChunk(ctx, w.meta.ChatID, w.meta.MessageID, chunk)
+		})
+	}
+	w.write(func(ctx context.Context) error { return w.store.SaveSession(ctx, *w.final) })
+}
+
+func (w *storeWriter) write(op func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeWriteTimeout)
+	defer cancel()
+	if err := op(ctx); err != nil {
+		w.logger.Warn("failed to write stream session to store",
+			slog.String("error", err.Error()),
+			slog.String("chat_id", w.meta.ChatID),
+			slog.String("message_id", w.meta.MessageID))
+	}
+}
+
+// newRemoteSession returns a read-only session mirroring a session stored by another
+// instance: stored chunks are buffered for replay and live ones broadcast until the session
+// completes, ctx is cancelled or the upstream read timeout passes. Returns nil if the store
+// doesn't know the session.
+func newRemoteSession(ctx context.Context, store SessionStore, chatID, messageID string, logger *logger.Logger) (*StreamSession, error) {
+	// Subscribe before loading so no chunk falls between the two
+	followCtx, cancelFollow := context.WithCancel(ctx)
+	events, err := store.Subscribe(followCtx, chatID, messageID)
+	if err != nil {
+		cancelFollow()
+		return nil, err
+	}
+	meta, chunks, err := store.LoadSession(ctx, chatID, messageID)
+	if err != nil || meta == nil {
+		cancelFollow()
+		return nil, err
+	}
+
+	session := NewStreamSession(chatID, messageID, nil, logger)
+	session.remote = true
+	session.startTime = meta.StartTime
+	session.SetUserID(meta.UserID)
+	for _, chunk := range chunks {
+		session.storeChunk(chunk)
+	}
+
+	if meta.Completed {
+		cancelFollow()
+		session.completeRemote(meta)
+		return session, nil
+	}
+
+	go func() {
+		defer cancelFollow()
+		seq := int64(len(chunks))
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					session.completeRemote(&SessionMeta{Error: "session store subscription closed"})
+					return
+				}
+				if event.Chunk != nil && event.Seq > seq {
+					seq = event.Seq
+					session.publish(*event.Chunk)
+				}
+				if event.Meta != nil && event.Meta.Completed {
+					session.completeRemote(event.Meta)
+					return
+				}
+			case <-session.stopCtx.Done():
+				session.completeRemote(&SessionMeta{Error: session.stopCtx.Err().Error()})
+				return
+			}
+		}
+	}()
+
+	return session, nil
+}
+
+// completeRemote completes a remote session with the stored outcome.
+func (s *StreamSession) completeRemote(meta *SessionMeta) {
+	var err error
+	if meta.Error != "" {
+		err = errors.New(meta.Error)
+	}
+	s.stopCancel()
+	s.markCompleted(err)
+}
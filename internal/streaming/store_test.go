@@ -0,0 +1,262 @@
+package streaming
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+)
+
+// memorySessionStore is a SessionStore shared by managers in the same test.
+type memorySessionStore struct {
+	mu          sync.Mutex
+	meta        map[string]SessionMeta
+	chunks      map[string][]StreamChunk
+	subscribers map[string][]chan StoreEvent
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{
+		meta:        make(map[string]SessionMeta),
+		chunks:      make(map[string][]StreamChunk),
+		subscribers: make(map[string][]chan StoreEvent),
+	}
+}
+
+func (m *memorySessionStore) SaveSession(_ context.Context, meta SessionMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := meta.ChatID + ":" + meta.MessageID
+	m.meta[key] = meta
+	m.publish(key, StoreEvent{Meta: &meta})
+	return nil
+}
+
+func (m *memorySessionStore) AppendChunk(_ context.Context, chatID, messageID string, chunk StreamChunk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := chatID + ":" + messageID
+	m.chunks[key] = append(m.chunks[key], chunk)
+	m.publish(key, StoreEvent{Seq: int64(len(m.chunks[key])), Chunk: &chunk})
+	return nil
+}
+
+func (m *memorySessionStore) LoadSession(_ context.Context, chatID, messageID string) (*SessionMeta, []StreamChunk, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := chatID + ":" + messageID
+	meta, ok := m.meta[key]
+	if !ok {
+		return nil, nil, nil
+	}
+	return &meta, append([]StreamChunk(nil), m.chunks[key]...), nil
+}
+
+func (m *memorySessionStore) Subscribe(_ context.Context, chatID, messageID string) (<-chan StoreEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := chatID + ":" + messageID
+	events := make(chan StoreEvent, 100)
+	m.subscribers[key] = append(m.subscribers[key], events)
+	return events, nil
+}
+
+func (m *memorySessionStore) ActiveSession(_ context.Context, chatID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var active *SessionMeta
+	for _, meta := range m.meta {
+		if meta.ChatID == chatID && !meta.Completed && (active == nil || meta.StartTime.After(active.StartTime)) {
+			active = &meta
+		}
+	}
+	if active == nil {
+		return "", nil
+	}
+	return active.MessageID, nil
+}
+
+func (m *memorySessionStore) publish(key string, event StoreEvent) {
+	for _, events := range m.subscribers[key] {
+		events <- event
+	}
+}
+
+func TestJoinSessionAcrossInstances(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	store := newMemorySessionStore()
+
+	owner := NewStreamManager(nil, log)
+	defer owner.Shutdown()
+	owner.SetSessionStore(store, "instance-a")
+	other := NewStreamManager(nil, log)
+	defer other.Shutdown()
+	other.SetSessionStore(store, "instance-b")
+
+	if session, err := other.JoinSession(context.Background(), "chat-1", "msg-1"); err != nil || session != nil {
+		t.Fatalf("JoinSession() of an unknown session = %v, %v; want nil", session, err)
+	}
+
+	reader, writer := io.Pipe()
+	local, _ := owner.CreatePendingSession(context.Background(), "chat-1", "msg-1")
+	local.SetUserID("user-1")
+	local.SetUpstreamBodyAndStart(reader)
+
+	writeLine := func(line string) {
+		if _, err := writer.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor := func(what string, done func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	writeLine(`data: {"choices":[{"delta":{"content":"Hello"}}]}`)
+	waitFor("the first chunk to be stored", func() bool {
+		_, chunks, _ := store.LoadSession(context.Background(), "chat-1", "msg-1")
+		return len(chunks) == 1
+	})
+
+	// Joining on the other instance replays the stored chunk, then follows live ones
+	remote, err := other.JoinSession(context.Background(), "chat-1", "msg-1")
+	if err != nil || remote == nil {
+		t.Fatalf("JoinSession() = %v, %v", remote, err)
+	}
+	if !remote.IsRemote() || remote.GetUserID() != "user-1" || remote.IsCompleted() {
+		t.Errorf("remote session: remote=%v user=%q completed=%v", remote.IsRemote(), remote.GetUserID(), remote.IsCompleted())
+	}
+	if other.GetSession("chat-1", "msg-1") != nil {
+		t.Error("remote session tracked as a local session")
+	}
+	sub, err := remote.Subscribe(context.Background(), "client", SubscriberOptions{ReplayFromStart: true, BufferSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeLine(`data: {"choices":[{"delta":{"content":" world"}}]}`)
+	writeLine(`data: [DONE]`)
+	writer.Close()
+
+	var lines []string
+	for chunk := range sub.Ch {
+		lines = append(lines, chunk.Line)
+	}
+	if len(lines) != 3 || !strings.Contains(lines[1], " world") || lines[2] != "data: [DONE]" {
+		t.Errorf("remote subscriber got %q", lines)
+	}
+
+	waitFor("the remote session to complete", remote.IsCompleted)
+	if remote.GetError() != nil {
+		t.Errorf("remote session error = %v", remote.GetError())
+	}
+	if remote.GetContent() != local.GetContent() {
+		t.Errorf("remote content = %q, local content = %q", remote.GetContent(), local.GetContent())
+	}
+
+	// A completed session is replayed from the store alone
+	replayed, err := other.JoinSession(context.Background(), "chat-1", "msg-1")
+	if err != nil || replayed == nil || !replayed.IsCompleted() || len(replayed.GetStoredChunks()) != 3 {
+		t.Fatalf("JoinSession() after completion = %v, %v", replayed, err)
+	}
+}
+
+func TestJoinSessionPrefersLocal(t *testing.T) {
+	sm := NewStreamManager(nil, logger.New(logger.Config{Level: slog.LevelError}))
+	defer sm.Shutdown()
+
+	local, _ := sm.CreatePendingSession(context.Background(), "chat-1", "msg-1")
+	session, err := sm.JoinSession(context.Background(), "chat-1", "msg-1")
+	if err != nil || session != local {
+		t.Errorf("JoinSession() = %v, %v; want the local session", session, err)
+	}
+
+	// Without a store, other sessions aren't found
+	if session, err := sm.JoinSession(context.Background(), "chat-2", "msg-2"); err != nil || session != nil {
+		t.Errorf("JoinSession() of an unknown session = %v, %v; want nil", session, err)
+	}
+}
+
+func TestGetActiveStreamForChat(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	store := newMemorySessionStore()
+
+	owner := NewStreamManager(nil, log)
+	defer owner.Shutdown()
+	owner.SetSessionStore(store, "instance-a")
+	other := NewStreamManager(nil, log)
+	defer other.Shutdown()
+	other.SetSessionStore(store, "instance-b")
+
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	session, _ := owner.CreatePendingSession(context.Background(), "chat-1", "msg-1")
+	session.SetUpstreamBodyAndStart(reader)
+
+	info, err := owner.GetActiveStreamForChat(context.Background(), "chat-1")
+	if err != nil || info == nil || info.MessageID != "msg-1" || info.InstanceID != "" {
+		t.Fatalf("local GetActiveStreamForChat() = %+v, %v; want msg-1", info, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if messageID, _ := store.ActiveSession(context.Background(), "chat-1"); messageID == "msg-1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the session to be stored")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	info, err = other.GetActiveStreamForChat(context.Background(), "chat-1")
+	if err != nil || info == nil || info.MessageID != "msg-1" || info.InstanceID != "instance-a" {
+		t.Fatalf("remote GetActiveStreamForChat() = %+v, %v; want msg-1 of instance-a", info, err)
+	}
+
+	if info, err := other.GetActiveStreamForChat(context.Background(), "chat-2"); err != nil || info != nil {
+		t.Errorf("GetActiveStreamForChat() of another chat = %+v, %v; want nil", info, err)
+	}
+}
+
+func TestPrivateSessionsSkipStore(t *testing.T) {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	store := newMemorySessionStore()
+	sm := NewStreamManager(nil, log)
+	defer sm.Shutdown()
+	sm.SetSessionStore(store, "instance-a")
+
+	body := func() io.ReadCloser {
+		return io.NopCloser(strings.NewReader("data: {\"choices\":[{\"delta\":{\"content\":\"secret\"}}]}\n\ndata: [DONE]\n"))
+	}
+	pending, _ := sm.CreatePendingSession(privacy.WithContext(context.Background()), "chat-1", "private")
+	pending.SetUpstreamBodyAndStart(body())
+	ephemeral, _ := sm.GetOrCreateSession(privacy.WithEphemeral(context.Background()), "chat-1", "ephemeral", body())
+
+	for _, session := range []*StreamSession{pending, ephemeral} {
+		deadline := time.Now().Add(2 * time.Second)
+		for !session.IsCompleted() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the session to complete")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	for _, messageID := range []string{"private", "ephemeral"} {
+		if meta, chunks, _ := store.LoadSession(context.Background(), "chat-1", messageID); meta != nil || len(chunks) != 0 {
+			t.Errorf("%s session was written to the session store: %+v, %d chunks", messageID, meta, len(chunks))
+		}
+	}
+}
@@ -36,6 +36,12 @@ type StreamSubscriber struct {
 
 	// options are the subscriber's configuration
 	options SubscriberOptions
+
+	// Replay state (guarded by the session's publishMu): chunks published while the
+	// subscriber replays are queued in pending; closed prevents closing Ch twice
+	replaying bool
+	pending   []StreamChunk
+	closed    bool
 }
 
 // NewStreamSubscriber creates a new subscriber with the given context and options.
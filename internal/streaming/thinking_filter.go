@@ -0,0 +1,206 @@
+package streaming
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ReasoningVisibility controls how model reasoning ("thinking") output is delivered.
+type ReasoningVisibility string
+
+const (
+	// ReasoningVisibilityShow passes reasoning through unchanged (default, legacy behavior).
+	ReasoningVisibilityShow ReasoningVisibility = "show"
+
+	// ReasoningVisibilitySeparate removes reasoning from content and emits it as
+	// separate "reasoning" SSE events. It is stored apart from the message content.
+	ReasoningVisibilitySeparate ReasoningVisibility = "separate"
+
+	// ReasoningVisibilityStrip removes reasoning entirely (not streamed, not stored).
+	ReasoningVisibilityStrip ReasoningVisibility = "strip"
+)
+
+// ParseReasoningVisibility validates a reasoning visibility value (case-insensitive).
+// Returns false if the value is not recognized.
+func ParseReasoningVisibility(value string) (ReasoningVisibility, bool) {
+	switch v := ReasoningVisibility(strings.ToLower(strings.TrimSpace(value))); v {
+	case ReasoningVisibilityShow, ReasoningVisibilitySeparate, ReasoningVisibilityStrip:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// ThinkingFilter separates reasoning from content in a Chat Completions stream.
+//
+// Reasoning arrives in two shapes:
+//   - Inline <think>...</think> segments in delta.content (GLM and other open models)
+//   - A dedicated delta.reasoning field (after reasoning_content normalization)
+//
+// Tags may be split across chunks, so the filter keeps state between calls.
+type ThinkingFilter struct {
+	insideThink bool
+	partialTag  string
+}
+
+// NewThinkingFilter creates a new thinking filter.
+func NewThinkingFilter() *ThinkingFilter {
+	return &ThinkingFilter{}
+}
+
+// SplitContent splits a content chunk into visible content and reasoning.
+// A trailing partial tag is held back until the next chunk completes or rules it out.
+func (f *ThinkingFilter) SplitContent(content string) (visible, reasoning string) {
+	fullContent := f.partialTag + content
+	f.partialTag = ""
+
+	var visibleOut, reasoningOut strings.Builder
+	pos := 0
+
+	for pos < len(fullContent) {
+		tag := thinkOpenTag
+		out := &visibleOut
+		if f.insideThink {
+			tag = thinkCloseTag
+			out = &reasoningOut
+		}
+
+		idx := strings.Index(fullContent[pos:], tag)
+		if idx != -1 {
+			out.WriteString(fullContent[pos : pos+idx])
+			pos += idx + len(tag)
+			f.insideThink = !f.insideThink
+			continue
+		}
+
+		// No complete tag - hold back a possible partial tag at the end
+		rest := fullContent[pos:]
+		partialLen := partialSuffixLen(rest, tag)
+		out.WriteString(rest[:len(rest)-partialLen])
+		f.partialTag = rest[len(rest)-partialLen:]
+		break
+	}
+
+	return visibleOut.String(), reasoningOut.String()
+}
+
+// partialSuffixLen returns the length of the longest suffix of s that is a proper prefix of tag.
+func partialSuffixLen(s, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// FilterSSELine removes reasoning from an SSE data line.
+//
+// Returns:
+//   - string: The rewritten line (or the original if nothing was removed)
+//   - string: Reasoning text extracted from this line (may be empty)
+//   - bool: Whether the line was modified
+func (f *ThinkingFilter) FilterSSELine(line string) (string, string, bool) {
+	if !strings.HasPrefix(line, "data: ") {
+		return line, "", false
+	}
+
+	jsonData := strings.TrimPrefix(line, "data: ")
+	if jsonData == "[DONE]" {
+		return line, "", false
+	}
+
+	// Quick check before parsing: nothing to do without a possible tag, reasoning, or pending state.
+	// "<" may arrive JSON-escaped as \u003c (e.g. after re-marshaling by the GLM filter).
+	if !f.insideThink && f.partialTag == "" && !strings.Contains(jsonData, `"reasoning"`) &&
+		!strings.Contains(jsonData, "<") && !strings.Contains(jsonData, `\u003c`) {
+		return line, "", false
+	}
+
+	var chunk map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonData), &chunk); err != nil {
+		return line, "", false
+	}
+
+	choices, ok := chunk["choices"].([]interface{})
+	if !ok || len(choices) == 0 {
+		return line, "", false
+	}
+
+	choice, ok := choices[0].(map[string]interface{})
+	if !ok {
+		return line, "", false
+	}
+
+	delta, ok := choice["delta"].(map[string]interface{})
+	if !ok {
+		return line, "", false
+	}
+
+	modified := false
+	var reasoning strings.Builder
+
+	// Dedicated reasoning field (typically streamed before the answer)
+	if r, ok := delta["reasoning"]; ok {
+		if rStr, ok := r.(string); ok {
+			reasoning.WriteString(rStr)
+		}
+		delete(delta, "reasoning")
+		modified = true
+	}
+
+	// Inline <think> segments in content
+	if content, ok := delta["content"].(string); ok && content != "" {
+		visible, thinking := f.SplitContent(content)
+		if visible != content {
+			delta["content"] = visible
+			reasoning.WriteString(thinking)
+			modified = true
+		}
+	}
+
+	if !modified {
+		return line, "", false
+	}
+
+	newJSON, err := json.Marshal(chunk)
+	if err != nil {
+		return line, "", false
+	}
+
+	return "data: " + string(newJSON), reasoning.String(), true
+}
+
+// reasoningEventLine builds the SSE frame of a separate reasoning event: an "event: reasoning"
+// line and a data line with the same typed shape as tool notifications. The frame ends with a
+// blank line so that the event name doesn't apply to the content lines that follow.
+func reasoningEventLine(reasoning string) (string, error) {
+	eventJSON, err := json.Marshal(map[string]interface{}{
+		"type":    "reasoning",
+		"content": reasoning,
+	})
+	if err != nil {
+		return "", err
+	}
+	return "event: reasoning\ndata: " + string(eventJSON) + "\n", nil
+}
+
+// reasoningEventContent returns the reasoning text of a frame built by reasoningEventLine.
+func reasoningEventContent(line string) (string, bool) {
+	_, data, ok := strings.Cut(line, "data: ")
+	if !ok {
+		return "", false
+	}
+	var event struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+		return "", false
+	}
+	return event.Content, true
+}
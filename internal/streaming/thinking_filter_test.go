@@ -0,0 +1,137 @@
+package streaming
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+func TestThinkingFilter_SplitContent(t *testing.T) {
+	tests := []struct {
+		name          string
+		chunks        []string
+		wantVisible   string
+		wantReasoning string
+	}{
+		{
+			name:          "single chunk",
+			chunks:        []string{"<think>let me think</think>The answer is 4."},
+			wantVisible:   "The answer is 4.",
+			wantReasoning: "let me think",
+		},
+		{
+			name:          "tags split across chunks",
+			chunks:        []string{"<thi", "nk>step one", " step two</th", "ink>Done", "."},
+			wantVisible:   "Done.",
+			wantReasoning: "step one step two",
+		},
+		{
+			name:          "no tags",
+			chunks:        []string{"a < b", " and c > d"},
+			wantVisible:   "a < b and c > d",
+			wantReasoning: "",
+		},
+		{
+			name:          "unterminated think",
+			chunks:        []string{"<think>still thinking"},
+			wantVisible:   "",
+			wantReasoning: "still thinking",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewThinkingFilter()
+			var visible, reasoning string
+			for _, chunk := range tt.chunks {
+				v, r := filter.SplitContent(chunk)
+				visible += v
+				reasoning += r
+			}
+			if visible != tt.wantVisible {
+				t.Errorf("visible = %q, want %q", visible, tt.wantVisible)
+			}
+			if reasoning != tt.wantReasoning {
+				t.Errorf("reasoning = %q, want %q", reasoning, tt.wantReasoning)
+			}
+		})
+	}
+}
+
+func TestThinkingFilter_FilterSSELine_ReasoningField(t *testing.T) {
+	filter := NewThinkingFilter()
+
+	line, reasoning, modified := filter.FilterSSELine(`data: {"choices":[{"delta":{"reasoning":"hmm"}}]}`)
+	if !modified {
+		t.Fatal("expected line to be modified")
+	}
+	if reasoning != "hmm" {
+		t.Errorf("reasoning = %q, want %q", reasoning, "hmm")
+	}
+	if line != `data: {"choices":[{"delta":{}}]}` {
+		t.Errorf("unexpected filtered line: %s", line)
+	}
+
+	original := `data: {"choices":[{"delta":{"content":"plain"}}]}`
+	if line, _, modified := filter.FilterSSELine(original); modified || line != original {
+		t.Errorf("expected plain content line to pass through unchanged, got %s", line)
+	}
+}
+
+func TestStreamSession_ReasoningVisibility(t *testing.T) {
+	lines := []string{
+		`data: {"choices":[{"delta":{"content":"<think>reason"}}]}`,
+		`data: {"choices":[{"delta":{"content":"ing</think>Answer"}}]}`,
+		`data: [DONE]`,
+	}
+
+	tests := []struct {
+		name          string
+		visibility    ReasoningVisibility
+		wantContent   string
+		wantReasoning string
+	}{
+		{name: "show", visibility: ReasoningVisibilityShow, wantContent: "<think>reasoning</think>Answer", wantReasoning: ""},
+		{name: "separate", visibility: ReasoningVisibilitySeparate, wantContent: "Answer", wantReasoning: "reasoning"},
+		{name: "strip", visibility: ReasoningVisibilityStrip, wantContent: "Answer", wantReasoning: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.New(logger.Config{Level: slog.LevelError})
+			session := NewStreamSession("chat-123", "msg-456", newMockSSEStream(lines), log)
+			session.SetReasoningVisibility(tt.visibility)
+			session.Start()
+			session.WaitForCompletion()
+
+			if got := session.GetContent(); got != tt.wantContent {
+				t.Errorf("GetContent() = %q, want %q", got, tt.wantContent)
+			}
+			if got := session.GetReasoning(); got != tt.wantReasoning {
+				t.Errorf("GetReasoning() = %q, want %q", got, tt.wantReasoning)
+			}
+
+			// Separated reasoning is sent as "event: reasoning" frames
+			var frames []string
+			for _, chunk := range session.GetStoredChunks() {
+				if chunk.Kind == ChunkKindReasoning {
+					frames = append(frames, chunk.Line)
+				}
+			}
+			wantFrames := 0
+			if tt.wantReasoning != "" {
+				wantFrames = 2
+			}
+			if len(frames) != wantFrames {
+				t.Fatalf("reasoning frames = %q, want %d", frames, wantFrames)
+			}
+			for _, frame := range frames {
+				if !strings.HasPrefix(frame, "event: reasoning\ndata: {") || !strings.HasSuffix(frame, "}\n") {
+					t.Errorf("unexpected reasoning frame %q", frame)
+				}
+			}
+		})
+	}
+}
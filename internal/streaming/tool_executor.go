@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -12,7 +13,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/providerapi"
 	"github.com/eternisai/enchanted-proxy/internal/tools"
 )
 
@@ -45,6 +48,39 @@ func NewToolExecutor(
 	}
 }
 
+// offeredToolsKey is the context key of the names of the tools offered in a request.
+type offeredToolsKey struct{}
+
+// withOfferedTools restricts the tools executed with ctx to those offered in the request body
+// that the user's tier allows (client-defined tools may share a server-side tool's name).
+func withOfferedTools(ctx context.Context, requestBody []byte, allowed []string) context.Context {
+	offered := make(map[string]bool)
+	var req map[string]interface{}
+	if err := json.Unmarshal(requestBody, &req); err == nil {
+		requested := offeredToolNames(req)
+		for _, name := range allowed {
+			if requested[name] {
+				offered[name] = true
+			}
+		}
+	}
+	return context.WithValue(ctx, offeredToolsKey{}, offered)
+}
+
+// offeredToolNames returns the names of the function tools of a chat completion request.
+func offeredToolNames(req map[string]interface{}) map[string]bool {
+	names := make(map[string]bool)
+	requestTools, _ := req["tools"].([]interface{})
+	for _, t := range requestTools {
+		def, _ := t.(map[string]interface{})
+		function, _ := def["function"].(map[string]interface{})
+		if name, ok := function["name"].(string); ok {
+			names[name] = true
+		}
+	}
+	return names
+}
+
 // NotificationCallback is called when a tool execution event occurs.
 // This allows real-time notification broadcasting instead of batching.
 type NotificationCallback func(ToolNotification)
@@ -62,7 +98,12 @@ func (te *ToolExecutor) ExecuteToolCalls(
 		return nil, nil
 	}
 
-	te.logger.Info("executing tool calls",
+	log := te.logger
+	if token := logger.DebugTraceFromContext(ctx); token != "" {
+		log = log.WithDebugTrace(token)
+	}
+
+	log.Info("executing tool calls",
 		slog.String("chat_id", chatID),
 		slog.String("message_id", messageID),
 		slog.Int("count", len(toolCalls)))
@@ -90,7 +131,7 @@ func (te *ToolExecutor) ExecuteToolCalls(
 			// Execute tool
 			result, err := te.executeSingleTool(ctx, tc)
 			if err != nil {
-				te.logger.Error("tool execution failed",
+				log.Error("tool execution failed",
 					slog.String("tool_name", tc.Function.Name),
 					slog.String("tool_call_id", tc.ID),
 					slog.String("error", err.Error()))
@@ -109,12 +150,18 @@ func (te *ToolExecutor) ExecuteToolCalls(
 				errors = append(errors, fmt.Errorf("tool %s: %w", tc.Function.Name, err))
 				mu.Unlock()
 
-				// Return error message as tool result
+				// Return error message as tool result. Invalid arguments are described in a
+				// structured form so the model can correct the call in the continuation.
+				content := fmt.Sprintf("Error executing tool: %s", err.Error())
+				var argsErr *tools.ArgumentsError
+				if stderrors.As(err, &argsErr) {
+					content = argsErr.ToolContent()
+				}
 				result = tools.ToolResult{
 					ToolCallID: tc.ID,
 					Role:       "tool",
 					Name:       tc.Function.Name,
-					Content:    fmt.Sprintf("Error executing tool: %s", err.Error()),
+					Content:    content,
 				}
 			} else {
 				// Notify completed IMMEDIATELY via callback
@@ -136,7 +183,7 @@ func (te *ToolExecutor) ExecuteToolCalls(
 	wg.Wait()
 
 	if len(errors) > 0 {
-		te.logger.Warn("some tool calls failed",
+		log.Warn("some tool calls failed",
 			slog.Int("failed_count", len(errors)),
 			slog.Int("total_count", len(toolCalls)))
 	}
@@ -152,6 +199,16 @@ func (te *ToolExecutor) executeSingleTool(ctx context.Context, toolCall tools.To
 		return tools.ToolResult{}, fmt.Errorf("tool %s not found", toolCall.Function.Name)
 	}
 
+	// Tools not offered in the request (e.g. disabled for the user's tier) never run
+	if offered, ok := ctx.Value(offeredToolsKey{}).(map[string]bool); ok && !offered[toolCall.Function.Name] {
+		return tools.ToolResult{}, fmt.Errorf("tool %s not available", toolCall.Function.Name)
+	}
+
+	// Never execute model-emitted arguments that don't match the tool's schema
+	if err := te.registry.ValidateArguments(toolCall.Function.Name, toolCall.Function.Arguments); err != nil {
+		return tools.ToolResult{}, err
+	}
+
 	// Execute tool
 	content, err := tool.Execute(ctx, toolCall.Function.Arguments)
 	if err != nil {
@@ -185,6 +242,13 @@ func (te *ToolExecutor) extractQuery(toolName, args string) string {
 		if err := json.Unmarshal([]byte(args), &searchArgs); err == nil && len(searchArgs.Queries) > 0 {
 			return strings.Join(searchArgs.Queries, ", ")
 		}
+	case "fetch_url":
+		var fetchArgs struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal([]byte(args), &fetchArgs); err == nil && fetchArgs.URL != "" {
+			return fetchArgs.URL
+		}
 	case "search_memory":
 		var memoryArgs struct {
 			Query string `json:"query"`
@@ -198,10 +262,13 @@ func (te *ToolExecutor) extractQuery(toolName, args string) string {
 
 // CreateContinuationRequest creates a new AI request with tool results.
 // This sends the tool results back to the AI and gets a new streaming response.
+// For providers with a native API (apiType: Anthropic, Gemini) the request is translated and
+// the returned stream is translated back to chat completions chunks.
 func (te *ToolExecutor) CreateContinuationRequest(
 	ctx context.Context,
 	upstreamURL string,
 	upstreamAPIKey string,
+	apiType config.APIType,
 	originalReq map[string]interface{},
 	originalMessages []interface{},
 	assistantMessage map[string]interface{},
@@ -240,17 +307,21 @@ func (te *ToolExecutor) CreateContinuationRequest(
 	payload["messages"] = messages
 	payload["stream"] = true
 
-	// Extract model from request for capability check
-	modelID := ""
-	if modelField, ok := payload["model"].(string); ok {
-		modelID = modelField
-	}
+	modelID, _ := payload["model"].(string)
 
-	// Include tool definitions in continuation requests if model supports them
+	// Include tool definitions in continuation requests if the original request had them
+	// (they are only injected for models that support tools).
 	// This is necessary because the assistant message contains tool_calls,
 	// and the AI provider needs the tool definitions to understand the context
-	if tools.SupportsTools(modelID) {
-		toolDefs := te.registry.GetDefinitions()
+	if _, hadTools := originalReq["tools"]; hadTools {
+		// Only the tools offered originally: injection is filtered by the user's tier
+		offered := offeredToolNames(originalReq)
+		var toolDefs []tools.ToolDefinition
+		for _, def := range te.registry.GetDefinitions() {
+			if offered[def.Function.Name] {
+				toolDefs = append(toolDefs, def)
+			}
+		}
 		if len(toolDefs) > 0 {
 			payload["tools"] = toolDefs
 			te.logger.Debug("included tool definitions in continuation",
@@ -258,7 +329,7 @@ func (te *ToolExecutor) CreateContinuationRequest(
 				slog.String("model", modelID))
 		}
 	} else {
-		te.logger.Debug("skipped tool definitions in continuation for model without support",
+		te.logger.Debug("skipped tool definitions in continuation for request without tools",
 			slog.String("model", modelID))
 	}
 
@@ -293,6 +364,16 @@ func (te *ToolExecutor) CreateContinuationRequest(
 		finalURL = strings.TrimSuffix(upstreamURL, "/") + "/chat/completions"
 	}
 
+	// Providers with a native API take the continuation in their format
+	translator := providerapi.For(apiType)
+	if translator != nil {
+		var nativePath string
+		if payloadBytes, nativePath, err = translator.Request(payloadBytes); err != nil {
+			return nil, fmt.Errorf("failed to translate payload: %w", err)
+		}
+		finalURL = strings.TrimSuffix(strings.TrimSuffix(upstreamURL, "/"), "/chat/completions") + nativePath
+	}
+
 	te.logger.Debug("continuation request URL",
 		slog.String("final_url", finalURL))
 
@@ -303,7 +384,11 @@ func (te *ToolExecutor) CreateContinuationRequest(
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+upstreamAPIKey)
+	if translator != nil {
+		translator.SetHeaders(req.Header, upstreamAPIKey)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+upstreamAPIKey)
+	}
 
 	// Execute request
 	resp, err := te.httpClient.Do(req)
@@ -317,5 +402,8 @@ func (te *ToolExecutor) CreateContinuationRequest(
 		return nil, fmt.Errorf("upstream returned status %d: %s", resp.StatusCode, string(body))
 	}
 
+	if translator != nil {
+		return translator.Stream(resp.Body), nil
+	}
 	return resp.Body, nil
 }
@@ -0,0 +1,55 @@
+package streaming
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/tools"
+)
+
+type echoTool struct{ name string }
+
+func (t echoTool) Name() string { return t.name }
+
+func (t echoTool) Definition() tools.ToolDefinition {
+	return tools.ToolDefinition{Type: "function", Function: tools.FunctionDef{Name: t.name}}
+}
+
+func (t echoTool) Execute(ctx context.Context, args string) (string, error) { return t.name, nil }
+
+func TestExecuteSingleTool_AllowedTools(t *testing.T) {
+	registry := tools.NewRegistry()
+	for _, name := range []string{"web_search", "fetch_url"} {
+		if err := registry.Register(echoTool{name: name}); err != nil {
+			t.Fatalf("Register(%s) failed: %v", name, err)
+		}
+	}
+	te := NewToolExecutor(registry, logger.New(logger.Config{Level: slog.LevelError}))
+
+	body := []byte(`{"model":"m","tools":[{"type":"function","function":{"name":"web_search"}},{"type":"function","function":{"name":"fetch_url"}}]}`)
+	call := func(ctx context.Context, name string) error {
+		_, err := te.executeSingleTool(ctx, tools.ToolCall{ID: "call_1", Type: "function", Function: tools.ToolCallFunction{Name: name, Arguments: "{}"}})
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		tool    string
+		wantErr bool
+	}{
+		{name: "offered and allowed", ctx: withOfferedTools(context.Background(), body, []string{"web_search"}), tool: "web_search"},
+		{name: "offered but not allowed", ctx: withOfferedTools(context.Background(), body, []string{"web_search"}), tool: "fetch_url", wantErr: true},
+		{name: "allowed but not offered", ctx: withOfferedTools(context.Background(), []byte(`{"model":"m"}`), []string{"web_search"}), tool: "web_search", wantErr: true},
+		{name: "no tier allowlist", ctx: withOfferedTools(context.Background(), body, nil), tool: "web_search", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := call(tt.ctx, tt.tool); (err != nil) != tt.wantErr {
+				t.Errorf("executeSingleTool(%s) error = %v, wantErr %v", tt.tool, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -37,9 +37,14 @@ type StreamChunk struct {
 	// Index is the sequential position in the stream (0, 1, 2, ...)
 	Index int `json:"index"`
 
-	// Line is the raw SSE line (e.g., "data: {...}" or "event: tool_result")
+	// Line is the raw SSE line (e.g., "data: {...}" or "event: tool_result"). Proxy events
+	// may span several lines (e.g., "event: reasoning\ndata: {...}\n").
 	Line string `json:"line"`
 
+	// Kind identifies proxy-generated chunks that consumers handle apart from upstream
+	// output (empty for upstream lines)
+	Kind ChunkKind `json:"kind,omitempty"`
+
 	// Timestamp is when this chunk was received from upstream
 	Timestamp time.Time `json:"timestamp"`
 
@@ -50,6 +55,15 @@ type StreamChunk struct {
 	IsError bool `json:"is_error"`
 }
 
+// ChunkKind identifies the kind of a proxy-generated StreamChunk.
+type ChunkKind string
+
+const (
+	// ChunkKindReasoning is a separated reasoning event ("event: reasoning"), see
+	// ReasoningVisibilitySeparate.
+	ChunkKindReasoning ChunkKind = "reasoning"
+)
+
 // StreamInfo provides metadata about an active stream session.
 // Used for observability and debugging.
 type StreamInfo struct {
@@ -79,6 +93,10 @@ type StreamInfo struct {
 
 	// StoppedBy is the user ID who stopped the stream, or "system_timeout"
 	StoppedBy string `json:"stopped_by,omitempty"`
+
+	// InstanceID is the instance reading the stream upstream, set for streams of other
+	// instances found through the session store
+	InstanceID string `json:"instance_id,omitempty"`
 }
 
 // StreamMetrics provides aggregated metrics across all streams.
@@ -112,8 +130,22 @@ const (
 
 	// StopReasonSystemShutdown indicates the server is shutting down
 	StopReasonSystemShutdown StopReason = "system_shutdown"
+
+	// StopReasonAdmin indicates an operator stopped the stream via the admin API
+	StopReasonAdmin StopReason = "admin_stopped"
+
+	// StopReasonAccessRevoked indicates the user's token was revoked or subscription lapsed
+	StopReasonAccessRevoked StopReason = "access_revoked"
 )
 
+// StoppedBySystemTimeout is the stoppedBy value of streams stopped because the request's
+// time budget ran out (see StreamSession.SetDeadline).
+const StoppedBySystemTimeout = "system_timeout"
+
+// StoppedBySystemRevocation is the stoppedBy value of streams stopped because the user's access
+// was revoked (see StreamManager.StopUserSessions).
+const StoppedBySystemRevocation = "system_revocation"
+
 // SubscriberOptions configures how a subscriber receives stream data
 type SubscriberOptions struct {
 	// ReplayFromStart indicates whether to send all buffered chunks before live chunks
@@ -0,0 +1,80 @@
+package streamrecord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+// Recorder decides which streams are recorded and persists recordings.
+// Only streams of allowlisted (internal test) accounts are recorded.
+type Recorder struct {
+	store   Store
+	userIDs map[string]struct{}
+	logger  *logger.Logger
+}
+
+// NewRecorder creates a recorder for a comma-separated list of user IDs.
+func NewRecorder(store Store, userIDs string, logger *logger.Logger) *Recorder {
+	allowed := make(map[string]struct{})
+	for _, id := range strings.Split(userIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			allowed[id] = struct{}{}
+		}
+	}
+
+	return &Recorder{
+		store:   store,
+		userIDs: allowed,
+		logger:  logger,
+	}
+}
+
+// ShouldRecord reports whether streams of the user are recorded. Nil-safe.
+func (r *Recorder) ShouldRecord(userID string) bool {
+	if r == nil || userID == "" {
+		return false
+	}
+	_, ok := r.userIDs[userID]
+	return ok
+}
+
+// Save persists a completed recording.
+func (r *Recorder) Save(ctx context.Context, rec *Recording) error {
+	rec.mu.Lock()
+	data, err := json.Marshal(rec)
+	events, truncated := len(rec.Events), rec.Truncated
+	rec.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+
+	key := Key(rec.ChatID, rec.MessageID)
+	if err := r.store.Put(ctx, key, data); err != nil {
+		return err
+	}
+
+	r.logger.WithContext(ctx).Info("stream recording saved",
+		slog.String("key", key),
+		slog.Int("events", events),
+		slog.Bool("truncated", truncated))
+	return nil
+}
+
+// Load reads a recording. Returns ErrNotFound if it doesn't exist.
+func (r *Recorder) Load(ctx context.Context, chatID, messageID string) (*Recording, error) {
+	data, err := r.store.Get(ctx, Key(chatID, messageID))
+	if err != nil {
+		return nil, err
+	}
+
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode recording: %w", err)
+	}
+	return &rec, nil
+}
@@ -0,0 +1,78 @@
+package streamrecord
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types stored in a Recording.
+const (
+	EventLine         = "line"         // Raw upstream SSE line
+	EventToolCalls    = "tool_calls"   // Tool calls detected (Detail: comma-separated tool names)
+	EventContinuation = "continuation" // Upstream body replaced by a tool continuation stream
+)
+
+// maxRecordingBytes caps the recorded line bytes per stream. Later events are dropped.
+const maxRecordingBytes = 8 << 20
+
+// Event is a timestamped entry of a recorded stream.
+type Event struct {
+	OffsetMs int64  `json:"offset_ms"` // Milliseconds since Recording.StartedAt
+	Type     string `json:"type"`
+	Line     string `json:"line,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Recording is a debug capture of one upstream stream: the raw SSE lines as received, with
+// timing, plus tool events. Request bodies, headers and credentials are never recorded.
+type Recording struct {
+	ChatID    string    `json:"chat_id"`
+	MessageID string    `json:"message_id"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	StartedAt time.Time `json:"started_at"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Events    []Event   `json:"events"`
+
+	mu    sync.Mutex
+	bytes int
+}
+
+// NewRecording starts a recording. startedAt should be when the upstream request was sent.
+func NewRecording(chatID, messageID, provider, model string, startedAt time.Time) *Recording {
+	return &Recording{
+		ChatID:    chatID,
+		MessageID: messageID,
+		Provider:  provider,
+		Model:     model,
+		StartedAt: startedAt,
+		Events:    make([]Event, 0, 128),
+	}
+}
+
+// RecordLine records a raw upstream SSE line received at the given time.
+func (r *Recording) RecordLine(line string, at time.Time) {
+	r.add(Event{Type: EventLine, Line: line}, at)
+}
+
+// RecordEvent records a stream event (EventToolCalls, EventContinuation) with optional detail.
+func (r *Recording) RecordEvent(eventType, detail string, at time.Time) {
+	r.add(Event{Type: eventType, Detail: detail}, at)
+}
+
+func (r *Recording) add(event Event, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Truncated {
+		return
+	}
+	if r.bytes+len(event.Line) > maxRecordingBytes {
+		r.Truncated = true
+		return
+	}
+
+	r.bytes += len(event.Line)
+	event.OffsetMs = at.Sub(r.StartedAt).Milliseconds()
+	r.Events = append(r.Events, event)
+}
@@ -0,0 +1,41 @@
+package streamrecord
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Replay returns a reader that emits the recorded upstream lines with their original timing
+// divided by speed (speed <= 0 emits everything immediately). It stands in for an upstream
+// response body, so the recording can be re-driven through a streaming.StreamSession.
+// Tool events are not emitted: continuation streams were recorded as lines.
+func Replay(ctx context.Context, rec *Recording, speed float64) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		start := time.Now()
+		for _, event := range rec.Events {
+			if event.Type != EventLine {
+				continue
+			}
+
+			if speed > 0 {
+				due := start.Add(time.Duration(float64(event.OffsetMs)/speed) * time.Millisecond)
+				select {
+				case <-ctx.Done():
+					pw.CloseWithError(ctx.Err())
+					return
+				case <-time.After(time.Until(due)):
+				}
+			}
+
+			if _, err := io.WriteString(pw, event.Line+"\n"); err != nil {
+				return
+			}
+		}
+		pw.Close() //nolint:errcheck
+	}()
+
+	return pr
+}
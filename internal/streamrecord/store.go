@@ -0,0 +1,114 @@
+package streamrecord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// ErrNotFound is returned when a recording does not exist.
+var ErrNotFound = errors.New("recording not found")
+
+// localPrefix marks a local directory location (development only).
+const localPrefix = "file://"
+
+// Store persists recordings as objects.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Key returns the object key of a recording. IDs are escaped so they can't traverse paths.
+func Key(chatID, messageID string) string {
+	return path.Join("streams", keySegment(chatID), keySegment(messageID)+".json")
+}
+
+func keySegment(id string) string {
+	return strings.ReplaceAll(url.PathEscape(id), ".", "%2E")
+}
+
+// NewStore creates a store for location: a GCS bucket name, or "file://<dir>" for a local
+// directory. credJSON is the service account used for GCS (empty = application default).
+func NewStore(ctx context.Context, location, credJSON string) (Store, error) {
+	if dir, ok := strings.CutPrefix(location, localPrefix); ok {
+		return &FileStore{dir: dir}, nil
+	}
+
+	var opts []option.ClientOption
+	if credJSON != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(credJSON)))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return &GCSStore{bucket: client.Bucket(location)}, nil
+}
+
+// GCSStore stores recordings in a Google Cloud Storage bucket.
+type GCSStore struct {
+	bucket *storage.BucketHandle
+}
+
+// Put writes an object.
+func (s *GCSStore) Put(ctx context.Context, key string, data []byte) error {
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = "application/json"
+
+	if _, err := w.Write(data); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write object: %w", err)
+	}
+	return nil
+}
+
+// Get reads an object.
+func (s *GCSStore) Get(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.bucket.Object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	defer r.Close() //nolint:errcheck
+
+	return io.ReadAll(r)
+}
+
+// FileStore stores recordings in a local directory.
+type FileStore struct {
+	dir string
+}
+
+// Put writes a file, creating parent directories.
+func (s *FileStore) Put(_ context.Context, key string, data []byte) error {
+	filePath := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0o600)
+}
+
+// Get reads a file.
+func (s *FileStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
@@ -0,0 +1,113 @@
+package streamrecord
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		chatID    string
+		messageID string
+		want      string
+	}{
+		{"plain IDs", "chat-1", "msg-1", "streams/chat-1/msg-1.json"},
+		{"slashes escaped", "a/b", "c/d", "streams/a%2Fb/c%2Fd.json"},
+		{"traversal escaped", "..", "../../etc", "streams/%2E%2E/%2E%2E%2F%2E%2E%2Fetc.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Key(tt.chatID, tt.messageID); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecording_Truncation(t *testing.T) {
+	start := time.Now()
+	rec := NewRecording("chat", "msg", "openai", "gpt-4", start)
+
+	line := strings.Repeat("x", maxRecordingBytes/2)
+	rec.RecordLine(line, start.Add(10*time.Millisecond))
+	rec.RecordLine(line, start.Add(20*time.Millisecond))
+	rec.RecordLine("data: overflow", start.Add(30*time.Millisecond))
+	rec.RecordEvent(EventContinuation, "", start.Add(40*time.Millisecond))
+
+	if !rec.Truncated {
+		t.Fatal("expected recording to be truncated")
+	}
+	if len(rec.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(rec.Events))
+	}
+	if rec.Events[1].OffsetMs != 20 {
+		t.Errorf("expected offset 20ms, got %d", rec.Events[1].OffsetMs)
+	}
+}
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(ctx, "file://"+t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	recorder := NewRecorder(store, " user-1 , user-2,", logger.New(logger.Config{Level: slog.LevelError}))
+
+	if !recorder.ShouldRecord("user-2") || recorder.ShouldRecord("user-3") || recorder.ShouldRecord("") {
+		t.Error("ShouldRecord() doesn't match the allowlist")
+	}
+	var nilRecorder *Recorder
+	if nilRecorder.ShouldRecord("user-1") {
+		t.Error("nil recorder should not record")
+	}
+
+	start := time.Now()
+	rec := NewRecording("chat/1", "msg-1", "openai", "gpt-4", start)
+	rec.RecordLine(`data: {"choices":[{"delta":{"content":"hi"}}]}`, start.Add(5*time.Millisecond))
+	rec.RecordEvent(EventToolCalls, "web_search", start.Add(6*time.Millisecond))
+
+	if err := recorder.Save(ctx, rec); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := recorder.Load(ctx, "chat/1", "msg-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Model != "gpt-4" || len(loaded.Events) != 2 || loaded.Events[1].Detail != "web_search" {
+		t.Errorf("loaded recording doesn't match: %+v", loaded)
+	}
+
+	if _, err := recorder.Load(ctx, "chat/1", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Now()
+	rec := NewRecording("chat", "msg", "openai", "gpt-4", start)
+	rec.RecordLine("data: one", start.Add(time.Hour))
+	rec.RecordEvent(EventToolCalls, "web_search", start.Add(2*time.Hour))
+	rec.RecordLine("data: two", start.Add(3*time.Hour))
+
+	// Speed 0 ignores the recorded offsets.
+	body := Replay(context.Background(), rec, 0)
+	defer body.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if got, want := string(data), "data: one\ndata: two\n"; got != want {
+		t.Errorf("Replay() = %q, want %q", got, want)
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/config"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/revocation"
 	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
 	"github.com/stripe/stripe-go/v84"
 	portalsession "github.com/stripe/stripe-go/v84/billingportal/session"
 	"github.com/stripe/stripe-go/v84/checkout/session"
@@ -28,13 +31,41 @@ const (
 	ProductIDDev   = "prod_TWwBMYEM1V9dkc" // Development
 )
 
-// isValidProProduct checks if the given product ID is one of the allowed Pro subscription products.
-func isValidProProduct(productID string) bool {
-	return productID == ProductIDProd || productID == ProductIDStage || productID == ProductIDDev
+// defaultProductTiers are the Pro subscription products of each environment.
+var defaultProductTiers = map[string]tiers.Tier{
+	ProductIDProd:  tiers.TierPro,
+	ProductIDStage: tiers.TierPro,
+	ProductIDDev:   tiers.TierPro,
+}
+
+// parseProductTiers parses "prod_xxx=plus,prod_yyy=pro" into the tier granted by each product,
+// on top of the default Pro products. Only paid tiers can be granted.
+func parseProductTiers(value string) (map[string]tiers.Tier, error) {
+	productTiers := make(map[string]tiers.Tier, len(defaultProductTiers))
+	for productID, tier := range defaultProductTiers {
+		productTiers[productID] = tier
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		productID, tierName, ok := strings.Cut(entry, "=")
+		productID, tier := strings.TrimSpace(productID), tiers.Tier(strings.TrimSpace(tierName))
+		if !ok || productID == "" {
+			return nil, fmt.Errorf("invalid product tier %q: want product_id=tier", entry)
+		}
+		if _, known := tiers.Configs[tier]; !known || tier == tiers.TierFree {
+			return nil, fmt.Errorf("invalid product tier %q: unknown paid tier %q", entry, tier)
+		}
+		productTiers[productID] = tier
+	}
+	return productTiers, nil
 }
 
 // Service handles Stripe subscription management and webhook processing.
-// It manages the lifecycle of Pro subscriptions for web app users, including:
+// It manages the lifecycle of paid subscriptions (Pro, Plus) for web app users, including:
 // - Creating Stripe Checkout Sessions for new subscriptions
 // - Processing webhook events for subscription state changes
 // - Updating entitlements in the database based on subscription status
@@ -42,6 +73,8 @@ type Service struct {
 	queries       pgdb.Querier
 	logger        *logger.Logger
 	weeklyPriceID string // Weekly subscription price ID (eligible for 3-day free trial)
+	productTiers  map[string]tiers.Tier
+	revoker       *revocation.Service
 }
 
 // NewService creates a new Stripe service instance and configures the Stripe SDK.
@@ -79,15 +112,36 @@ func NewService(queries pgdb.Querier, logger *logger.Logger) *Service {
 		log.Info("No weekly price configured - all subscriptions will have no trial period")
 	}
 
+	// Products accepted in webhooks and the tier they grant
+	productTiers, err := parseProductTiers(config.AppConfig.StripeProductTiers)
+	if err != nil {
+		log.Error("invalid STRIPE_PRODUCT_TIERS - using the default Pro products only", "error", err)
+		productTiers, _ = parseProductTiers("")
+	}
+
 	stripe.Key = apiKey
 	return &Service{
 		queries:       queries,
 		logger:        log,
 		weeklyPriceID: weeklyPriceID,
+		productTiers:  productTiers,
 	}
 }
 
-// CreateCheckoutSession generates a Stripe Checkout Session URL for Pro subscription purchase.
+// productTier returns the tier a subscription product grants; false for products that aren't
+// ours (webhooks for them are ignored).
+func (s *Service) productTier(productID string) (tiers.Tier, bool) {
+	tier, ok := s.productTiers[productID]
+	return tier, ok
+}
+
+// SetRevoker sets the service stopping in-flight work of users whose subscription lapses.
+// Optional: without it, lapsed users keep their running streams and deep research runs.
+func (s *Service) SetRevoker(revoker *revocation.Service) {
+	s.revoker = revoker
+}
+
+// CreateCheckoutSession generates a Stripe Checkout Session URL for a subscription purchase.
 // The session includes:
 // - 3-day free trial for weekly subscriptions only (payment method required upfront)
 // - No trial for yearly/other subscriptions
@@ -316,7 +370,8 @@ func (s *Service) handleCheckoutCompleted(ctx context.Context, event stripe.Even
 	}
 
 	productID := sub.Items.Data[0].Price.Product.ID
-	if !isValidProProduct(productID) {
+	tier, ok := s.productTier(productID)
+	if !ok {
 		s.logger.Warn("ignoring checkout for unrecognized product",
 			"subscription_id", sub.ID,
 			"product_id", productID)
@@ -337,19 +392,20 @@ func (s *Service) handleCheckoutCompleted(ctx context.Context, event stripe.Even
 	customerID := sub.Customer.ID
 
 	provider := "stripe"
-	tier := "pro" // Activating subscription
 	if err := s.queries.UpsertEntitlementWithTier(ctx, pgdb.UpsertEntitlementWithTierParams{
 		UserID:                userID,
-		SubscriptionTier:      tier,
+		SubscriptionTier:      string(tier), // Activating subscription
 		SubscriptionExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
 		SubscriptionProvider:  provider,
 		StripeCustomerID:      &customerID,
 	}); err != nil {
 		return fmt.Errorf("failed to upsert entitlement: %w", err)
 	}
+	s.recordRenewal(ctx, userID, sub)
 
-	s.logger.Info("pro access granted",
+	s.logger.Info("paid access granted",
 		"user_id", userID,
+		"tier", tier,
 		"subscription_id", sub.ID,
 		"customer_id", customerID,
 		"expires_at", expiresAt,
@@ -392,7 +448,7 @@ func (s *Service) handleSubscriptionDeleted(ctx context.Context, event stripe.Ev
 	}
 
 	productID := sub.Items.Data[0].Price.Product.ID
-	if !isValidProProduct(productID) {
+	if _, ok := s.productTier(productID); !ok {
 		s.logger.Warn("ignoring subscription deletion for unrecognized product",
 			"subscription_id", sub.ID,
 			"product_id", productID)
@@ -422,6 +478,9 @@ func (s *Service) handleSubscriptionDeleted(ctx context.Context, event stripe.Ev
 		"subscription_id", sub.ID,
 		"provider", "stripe")
 
+	// Stop streams and deep research runs started under the subscription
+	s.revoker.Revoke(userID, revocation.ReasonSubscriptionLapsed)
+
 	return nil
 }
 
@@ -468,7 +527,8 @@ func (s *Service) handleSubscriptionUpdated(ctx context.Context, event stripe.Ev
 	}
 
 	productID := sub.Items.Data[0].Price.Product.ID
-	if !isValidProProduct(productID) {
+	paidTier, ok := s.productTier(productID)
+	if !ok {
 		s.logger.Warn("ignoring subscription update for unrecognized product",
 			"subscription_id", sub.ID,
 			"product_id", productID)
@@ -512,13 +572,14 @@ func (s *Service) handleSubscriptionUpdated(ctx context.Context, event stripe.Ev
 
 	provider := "stripe"
 	// Determine tier based on subscription status
-	tier := "free"
+	// (plan changes between products move the user to the new product's tier)
+	tier := tiers.TierFree
 	if proExpiresAt.Valid {
-		tier = "pro"
+		tier = paidTier
 	}
 	if err := s.queries.UpsertEntitlementWithTier(ctx, pgdb.UpsertEntitlementWithTierParams{
 		UserID:                userID,
-		SubscriptionTier:      tier,
+		SubscriptionTier:      string(tier),
 		SubscriptionExpiresAt: proExpiresAt,
 		SubscriptionProvider:  provider,
 		StripeCustomerID:      nil, // Don't overwrite existing customer ID
@@ -526,5 +587,26 @@ func (s *Service) handleSubscriptionUpdated(ctx context.Context, event stripe.Ev
 		return fmt.Errorf("failed to update entitlement: %w", err)
 	}
 
+	if !proExpiresAt.Valid {
+		s.revoker.Revoke(userID, revocation.ReasonSubscriptionLapsed)
+	} else {
+		s.recordRenewal(ctx, userID, &sub)
+	}
+
 	return nil
 }
+
+// recordRenewal records whether an active subscription renews at the end of its period, for
+// the subscription status endpoint. Failures are logged; the entitlement is already stored.
+func (s *Service) recordRenewal(ctx context.Context, userID string, sub *stripe.Subscription) {
+	autoRenew := !sub.CancelAtPeriodEnd && sub.CancelAt == 0
+	if err := s.queries.SetEntitlementRenewal(ctx, pgdb.SetEntitlementRenewalParams{
+		UserID:    userID,
+		AutoRenew: &autoRenew,
+	}); err != nil {
+		s.logger.Error("failed to record subscription renewal state",
+			"user_id", userID,
+			"subscription_id", sub.ID,
+			"error", err)
+	}
+}
@@ -0,0 +1,35 @@
+package stripe
+
+import (
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+)
+
+func TestParseProductTiers(t *testing.T) {
+	productTiers, err := parseProductTiers(" prod_plus=plus, prod_pro = pro,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]tiers.Tier{
+		"prod_plus":    tiers.TierPlus,
+		"prod_pro":     tiers.TierPro,
+		ProductIDProd:  tiers.TierPro,
+		ProductIDStage: tiers.TierPro,
+		ProductIDDev:   tiers.TierPro,
+	}
+	if len(productTiers) != len(want) {
+		t.Fatalf("product tiers = %v, want %v", productTiers, want)
+	}
+	for productID, tier := range want {
+		if productTiers[productID] != tier {
+			t.Errorf("product %s tier = %q, want %q", productID, productTiers[productID], tier)
+		}
+	}
+
+	for _, invalid := range []string{"prod_x", "=pro", "prod_x=free", "prod_x=enterprise"} {
+		if _, err := parseProductTiers(invalid); err == nil {
+			t.Errorf("parseProductTiers(%q) succeeded, want error", invalid)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package structuredoutput implements JSON mode and structured outputs for chat completions.
+//
+// A request's response_format is checked before it is forwarded: json_schema formats must carry
+// a valid name and a compilable schema (see Schema). Completions of such requests are validated
+// against the format server-side, so clients can rely on the returned content being JSON that
+// matches their schema. The proxy retries an invalid completion once with a repair prompt
+// (see RepairPrompt).
+package structuredoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Response format types that constrain the completion to JSON.
+const (
+	TypeJSONObject = "json_object"
+	TypeJSONSchema = "json_schema"
+)
+
+// schemaNamePattern is the format of json_schema names accepted by OpenAI-compatible APIs.
+var schemaNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Format is a parsed JSON response format of a chat completions request.
+type Format struct {
+	// Type is TypeJSONObject or TypeJSONSchema.
+	Type string
+
+	// Name, Strict, Schema and RawSchema are set for TypeJSONSchema only.
+	Name      string
+	Strict    bool
+	Schema    *Schema
+	RawSchema map[string]interface{}
+}
+
+// FormatError describes an invalid response_format.
+type FormatError struct {
+	Field  string // Path within response_format, e.g. "json_schema.schema/properties/age/type"
+	Reason string
+}
+
+func (e *FormatError) Error() string {
+	if e.Field == "" {
+		return e.Reason
+	}
+	return e.Field + ": " + e.Reason
+}
+
+// ParseResponseFormat parses the response_format of a chat completions request. Returns nil
+// for missing or "text" formats, which don't constrain the completion.
+func ParseResponseFormat(value interface{}) (*Format, *FormatError) {
+	if value == nil {
+		return nil, nil
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, &FormatError{Reason: "must be an object"}
+	}
+
+	formatType, ok := obj["type"].(string)
+	if !ok {
+		return nil, &FormatError{Field: "type", Reason: "is required"}
+	}
+
+	switch formatType {
+	case "text":
+		return nil, nil
+	case TypeJSONObject:
+		return &Format{Type: TypeJSONObject}, nil
+	case TypeJSONSchema:
+	default:
+		return nil, &FormatError{Field: "type", Reason: fmt.Sprintf(`must be one of "text", "json_object", "json_schema"; got %q`, formatType)}
+	}
+
+	spec, ok := obj["json_schema"].(map[string]interface{})
+	if !ok {
+		return nil, &FormatError{Field: "json_schema", Reason: "is required for json_schema formats"}
+	}
+
+	name, _ := spec["name"].(string)
+	if !schemaNamePattern.MatchString(name) {
+		return nil, &FormatError{Field: "json_schema.name", Reason: "is required and must be 1-64 letters, digits, underscores or dashes"}
+	}
+
+	var strict bool
+	if value, ok := spec["strict"]; ok && value != nil {
+		if strict, ok = value.(bool); !ok {
+			return nil, &FormatError{Field: "json_schema.strict", Reason: "must be a boolean"}
+		}
+	}
+
+	rawSchema, ok := spec["schema"].(map[string]interface{})
+	if !ok {
+		return nil, &FormatError{Field: "json_schema.schema", Reason: "is required and must be an object"}
+	}
+	schema, err := Compile(rawSchema)
+	if err != nil {
+		return nil, &FormatError{Field: "json_schema.schema", Reason: err.Error()}
+	}
+
+	return &Format{
+		Type:      TypeJSONSchema,
+		Name:      name,
+		Strict:    strict,
+		Schema:    schema,
+		RawSchema: rawSchema,
+	}, nil
+}
+
+// Validate checks that a completion's content is JSON matching the format.
+func (f *Format) Validate(content string) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &value); err != nil {
+		return fmt.Errorf("content is not valid JSON: %v", err)
+	}
+
+	if f.Type == TypeJSONObject {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("content must be a JSON object, got %s", typeName(value))
+		}
+		return nil
+	}
+
+	return f.Schema.Validate(value)
+}
+
+// Instruction returns a system prompt asking for JSON matching the schema, sent instead of
+// the response format to models without native structured output support.
+func (f *Format) Instruction() string {
+	if f.Type != TypeJSONSchema {
+		return "Respond only with a valid JSON object, without any other text or formatting."
+	}
+	return fmt.Sprintf("Respond only with JSON that matches the following JSON schema, without any other text or formatting.\n\nSchema %q:\n%s",
+		f.Name, encode(f.RawSchema))
+}
+
+// RepairPrompt returns the follow-up user message asking the model to fix a completion that
+// failed validation with err.
+func (f *Format) RepairPrompt(err error) string {
+	return fmt.Sprintf("Your previous response is invalid: %v. Respond again with only the corrected JSON, without any other text or formatting.", err)
+}
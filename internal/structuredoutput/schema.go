@@ -0,0 +1,515 @@
+package structuredoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxSchemaDepth bounds the nesting of a schema accepted by Compile.
+	maxSchemaDepth = 32
+
+	// maxValidationDepth bounds $ref expansion during validation, so self-referencing
+	// schemas that don't consume any input (e.g. {"$ref": "#"}) terminate.
+	maxValidationDepth = 256
+)
+
+// schemaTypes are the JSON Schema type names.
+var schemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// Schema is a compiled JSON Schema. The subset used for structured outputs is supported:
+// type, properties, required, additionalProperties, items, enum, const, anyOf, allOf, oneOf,
+// $ref to the root or to $defs/definitions, string length and pattern, numeric bounds and
+// array length. Other keywords (format, description, ...) are annotations and ignored.
+type Schema struct {
+	// never is set for the false schema, which no value matches.
+	never bool
+
+	types []string
+
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+
+	items    *Schema
+	minItems *int
+	maxItems *int
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+
+	enum     []interface{}
+	constVal interface{}
+	hasConst bool
+
+	anyOf []*Schema
+	allOf []*Schema
+	oneOf []*Schema
+
+	ref *Schema
+}
+
+// ValidationError describes the first part of a value that doesn't match a schema.
+type ValidationError struct {
+	Path   string // JSON path of the offending value, e.g. "$.items[2].name"
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Reason
+}
+
+// compiler holds the state of a Compile call.
+type compiler struct {
+	root map[string]interface{}
+	refs map[string]*Schema
+}
+
+// Compile checks a JSON Schema decoded into interface{} values and compiles it.
+func Compile(raw map[string]interface{}) (*Schema, error) {
+	c := &compiler{root: raw, refs: make(map[string]*Schema)}
+	root := &Schema{}
+	c.refs["#"] = root
+	if err := c.compileInto(root, raw, "#", 0); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// compile compiles a subschema: an object or a boolean.
+func (c *compiler) compile(raw interface{}, path string, depth int) (*Schema, error) {
+	switch v := raw.(type) {
+	case bool:
+		return &Schema{never: !v}, nil
+	case map[string]interface{}:
+		s := &Schema{}
+		if err := c.compileInto(s, v, path, depth); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", path)
+	}
+}
+
+func (c *compiler) compileInto(s *Schema, raw map[string]interface{}, path string, depth int) error {
+	if depth > maxSchemaDepth {
+		return fmt.Errorf("%s: schema is nested too deeply (max %d levels)", path, maxSchemaDepth)
+	}
+
+	if value, ok := raw["$ref"]; ok {
+		ref, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s/$ref: must be a string", path)
+		}
+		target, err := c.resolve(ref, depth)
+		if err != nil {
+			return fmt.Errorf("%s/$ref: %w", path, err)
+		}
+		s.ref = target
+	}
+
+	if value, ok := raw["type"]; ok {
+		switch v := value.(type) {
+		case string:
+			s.types = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				name, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("%s/type: must be a string or an array of strings", path)
+				}
+				s.types = append(s.types, name)
+			}
+		default:
+			return fmt.Errorf("%s/type: must be a string or an array of strings", path)
+		}
+		for _, name := range s.types {
+			if !slices.Contains(schemaTypes, name) {
+				return fmt.Errorf("%s/type: unknown type %q", path, name)
+			}
+		}
+	}
+
+	if value, ok := raw["properties"]; ok {
+		props, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s/properties: must be an object", path)
+		}
+		s.properties = make(map[string]*Schema, len(props))
+		for name, prop := range props {
+			compiled, err := c.compile(prop, path+"/properties/"+name, depth+1)
+			if err != nil {
+				return err
+			}
+			s.properties[name] = compiled
+		}
+	}
+
+	if value, ok := raw["required"]; ok {
+		list, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s/required: must be an array of strings", path)
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("%s/required: must be an array of strings", path)
+			}
+			s.required = append(s.required, name)
+		}
+	}
+
+	if value, ok := raw["additionalProperties"]; ok {
+		compiled, err := c.compile(value, path+"/additionalProperties", depth+1)
+		if err != nil {
+			return err
+		}
+		s.additionalProperties = compiled
+	}
+
+	if value, ok := raw["items"]; ok {
+		compiled, err := c.compile(value, path+"/items", depth+1)
+		if err != nil {
+			return err
+		}
+		s.items = compiled
+	}
+
+	for keyword, target := range map[string]**int{
+		"minItems": &s.minItems, "maxItems": &s.maxItems,
+		"minLength": &s.minLength, "maxLength": &s.maxLength,
+	} {
+		if value, ok := raw[keyword]; ok {
+			n, ok := value.(float64)
+			if !ok || n < 0 || n != math.Trunc(n) {
+				return fmt.Errorf("%s/%s: must be a non-negative integer", path, keyword)
+			}
+			count := int(n)
+			*target = &count
+		}
+	}
+
+	for keyword, target := range map[string]**float64{
+		"minimum": &s.minimum, "maximum": &s.maximum,
+		"exclusiveMinimum": &s.exclusiveMinimum, "exclusiveMaximum": &s.exclusiveMaximum,
+	} {
+		if value, ok := raw[keyword]; ok {
+			n, ok := value.(float64)
+			if !ok {
+				return fmt.Errorf("%s/%s: must be a number", path, keyword)
+			}
+			*target = &n
+		}
+	}
+
+	if value, ok := raw["pattern"]; ok {
+		pattern, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s/pattern: must be a string", path)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s/pattern: invalid regular expression: %v", path, err)
+		}
+		s.pattern = re
+	}
+
+	if value, ok := raw["enum"]; ok {
+		list, ok := value.([]interface{})
+		if !ok || len(list) == 0 {
+			return fmt.Errorf("%s/enum: must be a non-empty array", path)
+		}
+		s.enum = list
+	}
+
+	if value, ok := raw["const"]; ok {
+		s.constVal = value
+		s.hasConst = true
+	}
+
+	for keyword, target := range map[string]*[]*Schema{"anyOf": &s.anyOf, "allOf": &s.allOf, "oneOf": &s.oneOf} {
+		value, ok := raw[keyword]
+		if !ok {
+			continue
+		}
+		list, ok := value.([]interface{})
+		if !ok || len(list) == 0 {
+			return fmt.Errorf("%s/%s: must be a non-empty array of schemas", path, keyword)
+		}
+		for i, item := range list {
+			compiled, err := c.compile(item, fmt.Sprintf("%s/%s/%d", path, keyword, i), depth+1)
+			if err != nil {
+				return err
+			}
+			*target = append(*target, compiled)
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the schema a local $ref ("#", "#/$defs/name" or "#/definitions/name")
+// points to, compiling it on first use. Recursive references share the same schema.
+func (c *compiler) resolve(ref string, depth int) (*Schema, error) {
+	if s, ok := c.refs[ref]; ok {
+		return s, nil
+	}
+
+	var section, name string
+	switch {
+	case strings.HasPrefix(ref, "#/$defs/"):
+		section, name = "$defs", strings.TrimPrefix(ref, "#/$defs/")
+	case strings.HasPrefix(ref, "#/definitions/"):
+		section, name = "definitions", strings.TrimPrefix(ref, "#/definitions/")
+	default:
+		return nil, fmt.Errorf("unsupported reference %q (only local $defs references are allowed)", ref)
+	}
+
+	defs, _ := c.root[section].(map[string]interface{})
+	raw, ok := defs[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %q", ref)
+	}
+
+	switch v := raw.(type) {
+	case bool:
+		s := &Schema{never: !v}
+		c.refs[ref] = s
+		return s, nil
+	case map[string]interface{}:
+		s := &Schema{}
+		c.refs[ref] = s
+		if err := c.compileInto(s, v, ref, depth+1); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("reference %q: schema must be an object or a boolean", ref)
+	}
+}
+
+// Validate checks a value decoded into interface{} values against the schema.
+func (s *Schema) Validate(value interface{}) error {
+	if err := s.validate(value, "$", 0); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Schema) validate(value interface{}, path string, depth int) *ValidationError {
+	if depth > maxValidationDepth {
+		return &ValidationError{Path: path, Reason: "schema references are nested too deeply"}
+	}
+	if s.never {
+		return &ValidationError{Path: path, Reason: "no value is allowed"}
+	}
+
+	if s.ref != nil {
+		if err := s.ref.validate(value, path, depth+1); err != nil {
+			return err
+		}
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(value, t) }) {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be %s, got %s", strings.Join(s.types, " or "), typeName(value))}
+	}
+
+	if s.hasConst && !reflect.DeepEqual(value, s.constVal) {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be %s", encode(s.constVal))}
+	}
+	if len(s.enum) > 0 && !slices.ContainsFunc(s.enum, func(v interface{}) bool { return reflect.DeepEqual(value, v) }) {
+		return &ValidationError{Path: path, Reason: "must be one of " + encode(s.enum)}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if err := s.validateObject(v, path, depth); err != nil {
+			return err
+		}
+	case []interface{}:
+		if err := s.validateArray(v, path, depth); err != nil {
+			return err
+		}
+	case string:
+		if err := s.validateString(v, path); err != nil {
+			return err
+		}
+	case float64:
+		if err := s.validateNumber(v, path); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range s.allOf {
+		if err := sub.validate(value, path, depth+1); err != nil {
+			return err
+		}
+	}
+
+	if len(s.anyOf) > 0 {
+		var firstErr *ValidationError
+		matched := false
+		for _, sub := range s.anyOf {
+			err := sub.validate(value, path, depth+1)
+			if err == nil {
+				matched = true
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if !matched {
+			return &ValidationError{Path: path, Reason: "must match at least one schema in anyOf (first mismatch: " + firstErr.Error() + ")"}
+		}
+	}
+
+	if len(s.oneOf) > 0 {
+		matches := 0
+		for _, sub := range s.oneOf {
+			if sub.validate(value, path, depth+1) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return &ValidationError{Path: path, Reason: fmt.Sprintf("must match exactly one schema in oneOf, matched %d", matches)}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateObject(obj map[string]interface{}, path string, depth int) *ValidationError {
+	for _, name := range s.required {
+		if _, ok := obj[name]; !ok {
+			return &ValidationError{Path: path, Reason: fmt.Sprintf("missing required property %q", name)}
+		}
+	}
+
+	// Sorted for a deterministic first error
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		propPath := path + "." + name
+		if prop, ok := s.properties[name]; ok {
+			if err := prop.validate(obj[name], propPath, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.additionalProperties != nil {
+			if s.additionalProperties.never {
+				return &ValidationError{Path: propPath, Reason: "additional property is not allowed"}
+			}
+			if err := s.additionalProperties.validate(obj[name], propPath, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateArray(items []interface{}, path string, depth int) *ValidationError {
+	if s.minItems != nil && len(items) < *s.minItems {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must have at least %d items, got %d", *s.minItems, len(items))}
+	}
+	if s.maxItems != nil && len(items) > *s.maxItems {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must have at most %d items, got %d", *s.maxItems, len(items))}
+	}
+	if s.items != nil {
+		for i, item := range items {
+			if err := s.items.validate(item, fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateString(str, path string) *ValidationError {
+	length := len([]rune(str))
+	if s.minLength != nil && length < *s.minLength {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be at least %d characters long", *s.minLength)}
+	}
+	if s.maxLength != nil && length > *s.maxLength {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be at most %d characters long", *s.maxLength)}
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must match pattern %q", s.pattern.String())}
+	}
+	return nil
+}
+
+func (s *Schema) validateNumber(n float64, path string) *ValidationError {
+	if s.minimum != nil && n < *s.minimum {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be >= %v", *s.minimum)}
+	}
+	if s.maximum != nil && n > *s.maximum {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be <= %v", *s.maximum)}
+	}
+	if s.exclusiveMinimum != nil && n <= *s.exclusiveMinimum {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be > %v", *s.exclusiveMinimum)}
+	}
+	if s.exclusiveMaximum != nil && n >= *s.exclusiveMaximum {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf("must be < %v", *s.exclusiveMaximum)}
+	}
+	return nil
+}
+
+// hasType reports whether a decoded JSON value has the JSON Schema type t.
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeName(value) == t
+	}
+}
+
+// typeName names the JSON type of a decoded value.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func encode(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,138 @@
+package structuredoutput
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var value map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		t.Fatalf("invalid test JSON: %v", err)
+	}
+	return value
+}
+
+func TestParseResponseFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		wantType  string
+		wantField string
+	}{
+		{name: "text", format: `{"type":"text"}`},
+		{name: "json object", format: `{"type":"json_object"}`, wantType: TypeJSONObject},
+		{name: "json schema", format: `{"type":"json_schema","json_schema":{"name":"person","strict":true,"schema":{"type":"object"}}}`, wantType: TypeJSONSchema},
+		{name: "unknown type", format: `{"type":"xml"}`, wantField: "type"},
+		{name: "missing type", format: `{}`, wantField: "type"},
+		{name: "missing json_schema", format: `{"type":"json_schema"}`, wantField: "json_schema"},
+		{name: "invalid name", format: `{"type":"json_schema","json_schema":{"name":"a person","schema":{}}}`, wantField: "json_schema.name"},
+		{name: "invalid strict", format: `{"type":"json_schema","json_schema":{"name":"p","strict":"yes","schema":{}}}`, wantField: "json_schema.strict"},
+		{name: "missing schema", format: `{"type":"json_schema","json_schema":{"name":"p"}}`, wantField: "json_schema.schema"},
+		{name: "unknown schema type", format: `{"type":"json_schema","json_schema":{"name":"p","schema":{"type":"date"}}}`, wantField: "json_schema.schema"},
+		{name: "unresolved reference", format: `{"type":"json_schema","json_schema":{"name":"p","schema":{"$ref":"#/$defs/missing"}}}`, wantField: "json_schema.schema"},
+		{name: "remote reference", format: `{"type":"json_schema","json_schema":{"name":"p","schema":{"$ref":"https://example.com/s.json"}}}`, wantField: "json_schema.schema"},
+		{name: "invalid pattern", format: `{"type":"json_schema","json_schema":{"name":"p","schema":{"pattern":"("}}}`, wantField: "json_schema.schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := ParseResponseFormat(decode(t, tt.format))
+			if tt.wantField != "" {
+				if err == nil || err.Field != tt.wantField {
+					t.Fatalf("error = %v, want error for field %q", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantType == "" {
+				if format != nil {
+					t.Fatalf("expected no format, got %+v", format)
+				}
+				return
+			}
+			if format == nil || format.Type != tt.wantType {
+				t.Fatalf("format = %+v, want type %s", format, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestFormatValidate(t *testing.T) {
+	format, formatErr := ParseResponseFormat(decode(t, `{"type":"json_schema","json_schema":{"name":"people","schema":{
+		"type":"object",
+		"properties":{
+			"people":{"type":"array","minItems":1,"items":{"$ref":"#/$defs/person"}},
+			"source":{"anyOf":[{"type":"string","pattern":"^https://"},{"type":"null"}]}
+		},
+		"required":["people","source"],
+		"additionalProperties":false,
+		"$defs":{"person":{
+			"type":"object",
+			"properties":{
+				"name":{"type":"string","minLength":1},
+				"age":{"type":"integer","minimum":0},
+				"role":{"enum":["admin","member"]},
+				"manager":{"anyOf":[{"$ref":"#/$defs/person"},{"type":"null"}]}
+			},
+			"required":["name","age"]
+		}}
+	}}}`))
+	if formatErr != nil {
+		t.Fatalf("ParseResponseFormat failed: %v", formatErr)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "valid", content: `{"people":[{"name":"Ada","age":36,"role":"admin","manager":{"name":"Bob","age":50,"manager":null}}],"source":null}`},
+		{name: "surrounding whitespace", content: "\n {\"people\":[{\"name\":\"Ada\",\"age\":36}],\"source\":\"https://example.com\"} \n"},
+		{name: "not JSON", content: "```json\n{}\n```", wantErr: "not valid JSON"},
+		{name: "missing property", content: `{"people":[{"name":"Ada","age":36}]}`, wantErr: `missing required property "source"`},
+		{name: "additional property", content: `{"people":[{"name":"Ada","age":36}],"source":null,"extra":1}`, wantErr: "$.extra: additional property"},
+		{name: "wrong type", content: `{"people":[{"name":"Ada","age":36.5}],"source":null}`, wantErr: "$.people[0].age: must be integer"},
+		{name: "enum", content: `{"people":[{"name":"Ada","age":36,"role":"owner"}],"source":null}`, wantErr: "must be one of"},
+		{name: "recursive reference", content: `{"people":[{"name":"Ada","age":36,"manager":{"name":"","age":1}}],"source":null}`, wantErr: "$.people[0].manager"},
+		{name: "min items", content: `{"people":[],"source":null}`, wantErr: "at least 1 items"},
+		{name: "pattern", content: `{"people":[{"name":"Ada","age":1}],"source":"ftp://x"}`, wantErr: "anyOf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := format.Validate(tt.content)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	jsonObject := &Format{Type: TypeJSONObject}
+	if err := jsonObject.Validate(`{"a":1}`); err != nil {
+		t.Errorf("json_object: unexpected error: %v", err)
+	}
+	if err := jsonObject.Validate(`[1]`); err == nil {
+		t.Errorf("json_object: expected error for array content")
+	}
+}
+
+func TestSelfReferenceTerminates(t *testing.T) {
+	schema, err := Compile(map[string]interface{}{"$ref": "#"})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if err := schema.Validate(map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "nested too deeply") {
+		t.Errorf("error = %v, want nesting error", err)
+	}
+}
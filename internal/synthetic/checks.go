@@ -0,0 +1,186 @@
+package synthetic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/google/uuid"
+)
+
+const (
+	canaryPrompt = "Reply with the single word OK."
+	canaryQuery  = "current weather"
+
+	// maxErrorBody bounds the response body quoted in check errors.
+	maxErrorBody = 256
+)
+
+// checkChat streams a tiny chat completion and reads it to the end.
+func (p *Prober) checkChat(ctx context.Context) error {
+	body := map[string]any{
+		"model":      p.cfg.ChatModel,
+		"stream":     true,
+		"max_tokens": 5,
+		"messages": []map[string]string{
+			{"role": "user", "content": canaryPrompt},
+		},
+	}
+	resp, err := p.post(ctx, "/chat/completions", body, func(req *http.Request) {
+		// Ephemeral: the canary conversation is not stored
+		req.Header.Set(privacy.EphemeralHeader, "true")
+		req.Header.Set("X-Chat-ID", "synthetic-"+uuid.NewString())
+		req.Header.Set("X-Message-ID", uuid.NewString())
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			if content.Len() == 0 {
+				return errors.New("completion has no content")
+			}
+			return nil
+		}
+
+		var chunk struct {
+			Error   *json.RawMessage `json:"error"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("stream error: %s", truncate(string(*chunk.Error)))
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	return errors.New("stream ended without [DONE]")
+}
+
+// checkEmbedding embeds a short text.
+func (p *Prober) checkEmbedding(ctx context.Context) error {
+	resp, err := p.post(ctx, "/embeddings", map[string]any{
+		"model": p.cfg.EmbeddingModel,
+		"input": canaryQuery,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var result struct {
+		Data []struct {
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if len(result.Data) == 0 || len(result.Data[0].Embedding) == 0 {
+		return errors.New("response has no embedding")
+	}
+	return nil
+}
+
+// checkSearch runs a web search.
+func (p *Prober) checkSearch(ctx context.Context) error {
+	resp, err := p.post(ctx, "/api/v1/search", map[string]any{"query": canaryQuery}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var result json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// checkDeepResearch runs a deep research dry run.
+func (p *Prober) checkDeepResearch(ctx context.Context) error {
+	resp, err := p.post(ctx, "/api/v1/deepresearch/start", map[string]any{
+		"query":   canaryQuery,
+		"chat_id": "synthetic-probe",
+		"dry_run": true,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var result struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("dry run failed: %s", result.Error)
+	}
+	return nil
+}
+
+// post sends an authenticated JSON request to the proxy. Non-2xx responses are returned as
+// errors (with the body closed).
+func (p *Prober) post(ctx context.Context, path string, body any, decorate func(*http.Request)) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(p.cfg.BaseURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.cfg.Token)
+	req.Header.Set("Content-Type", "application/json")
+	if decorate != nil {
+		decorate(req)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close() //nolint:errcheck
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, truncate(string(errBody)))
+	}
+	return resp, nil
+}
+
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxErrorBody {
+		return s[:maxErrorBody] + "..."
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package synthetic
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	resultSuccess = "success"
+	resultFailed  = "failed"
+)
+
+var (
+	// checkSuccess is the outcome of the last run of each check (1 = passed, 0 = failed).
+	checkSuccess = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "synthetic_probe_success",
+			Help: "Outcome of the last synthetic check run: 1 = passed, 0 = failed.",
+		},
+		[]string{"check"},
+	)
+
+	// checkRunsTotal counts check runs by result.
+	checkRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "synthetic_probe_runs_total",
+			Help: "Total synthetic check runs, by check and result.",
+		},
+		[]string{"check", "result"},
+	)
+
+	// checkDuration observes the end-to-end duration of check runs in seconds.
+	checkDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "synthetic_probe_duration_seconds",
+			Help:    "End-to-end duration of synthetic check runs in seconds, by check.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+		},
+		[]string{"check"},
+	)
+)
+
+func recordCheck(r Result) {
+	checkDuration.WithLabelValues(r.Check).Observe(r.Duration.Seconds())
+	if r.Err != nil {
+		checkSuccess.WithLabelValues(r.Check).Set(0)
+		checkRunsTotal.WithLabelValues(r.Check, resultFailed).Inc()
+		return
+	}
+	checkSuccess.WithLabelValues(r.Check).Set(1)
+	checkRunsTotal.WithLabelValues(r.Check, resultSuccess).Inc()
+}
@@ -0,0 +1,177 @@
+// Package synthetic runs canary requests through the proxy's full public pipeline (auth, tier
+// checks, routing, upstream) as a dedicated service account, so outages show up in metrics and
+// alerts before users report them.
+//
+// Each run performs the enabled checks concurrently:
+//   - chat: a tiny streamed chat completion, read until [DONE] (ephemeral, nothing is stored)
+//   - embedding: a one-word embedding (only with an embedding model configured)
+//   - search: a web search
+//   - deep_research: a deep research dry run (access, quota and backend reachability)
+//
+// Results are recorded as synthetic_probe_* metrics. A check alerts after failureThreshold
+// consecutive failures, and again when it recovers.
+//
+// The service account (SYNTHETIC_PROBE_USER_ID) goes through the usual tier checks, so give it
+// a paid tier: canary usage would exhaust the free quotas.
+package synthetic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+const (
+	// checkTimeout bounds a single check, including the whole streamed chat completion.
+	checkTimeout = 60 * time.Second
+
+	// failureThreshold is the number of consecutive failures before a check alerts, so a
+	// single transient error doesn't page.
+	failureThreshold = 2
+)
+
+// Config configures a Prober.
+type Config struct {
+	BaseURL         string // Proxy the checks are sent to, e.g. http://127.0.0.1:8080
+	Token           string // Bearer token of the service account
+	ChatModel       string
+	EmbeddingModel  string // Empty = embedding check skipped
+	SlackWebhookURL string // Empty = no alerts
+}
+
+// Result is the outcome of a check run.
+type Result struct {
+	Check    string
+	Duration time.Duration
+	Err      error
+}
+
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Prober runs the synthetic checks.
+type Prober struct {
+	cfg    Config
+	client *http.Client
+	slack  *slackNotifier
+	logger *logger.Logger
+	checks []check
+
+	mu       sync.Mutex
+	failures map[string]int  // Consecutive failures by check
+	alerted  map[string]bool // Checks with an outstanding failure alert
+}
+
+// NewProber creates a prober for cfg.
+func NewProber(cfg Config, logger *logger.Logger) *Prober {
+	p := &Prober{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: checkTimeout},
+		logger:   logger,
+		failures: make(map[string]int),
+		alerted:  make(map[string]bool),
+	}
+	if cfg.SlackWebhookURL != "" {
+		p.slack = newSlackNotifier(cfg.SlackWebhookURL)
+	}
+
+	p.checks = append(p.checks, check{name: "chat", run: p.checkChat})
+	if cfg.EmbeddingModel != "" {
+		p.checks = append(p.checks, check{name: "embedding", run: p.checkEmbedding})
+	}
+	p.checks = append(p.checks,
+		check{name: "search", run: p.checkSearch},
+		check{name: "deep_research", run: p.checkDeepResearch},
+	)
+	return p
+}
+
+// Run runs every check once, records the results and sends alerts. Returns an error if any
+// check failed (for worker.RunPeriodic).
+func (p *Prober) Run(ctx context.Context) error {
+	results := make([]Result, len(p.checks))
+	var wg sync.WaitGroup
+	for i, c := range p.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = p.runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	var failed []string
+	for _, r := range results {
+		recordCheck(r)
+		p.report(ctx, r)
+		if r.Err != nil {
+			failed = append(failed, r.Check)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("synthetic checks failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (p *Prober) runCheck(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.run(ctx)
+	return Result{Check: c.name, Duration: time.Since(start), Err: err}
+}
+
+// report logs a result and alerts on state changes.
+func (p *Prober) report(ctx context.Context, r Result) {
+	p.mu.Lock()
+	var alert, recovered bool
+	if r.Err != nil {
+		p.failures[r.Check]++
+		if p.failures[r.Check] >= failureThreshold && !p.alerted[r.Check] {
+			p.alerted[r.Check] = true
+			alert = true
+		}
+	} else {
+		p.failures[r.Check] = 0
+		if p.alerted[r.Check] {
+			p.alerted[r.Check] = false
+			recovered = true
+		}
+	}
+	failures := p.failures[r.Check]
+	p.mu.Unlock()
+
+	if r.Err != nil {
+		p.logger.Warn("synthetic check failed",
+			slog.String("check", r.Check),
+			slog.Int("consecutive_failures", failures),
+			slog.Duration("duration", r.Duration),
+			slog.String("error", r.Err.Error()))
+	} else {
+		p.logger.Debug("synthetic check passed",
+			slog.String("check", r.Check),
+			slog.Duration("duration", r.Duration))
+	}
+	if recovered {
+		p.logger.Info("synthetic check recovered", slog.String("check", r.Check))
+	}
+
+	if p.slack == nil || (!alert && !recovered) {
+		return
+	}
+	if err := p.slack.send(ctx, r, failures); err != nil {
+		p.logger.Error("failed to send synthetic check alert",
+			slog.String("check", r.Check),
+			slog.String("error", err.Error()))
+	}
+}
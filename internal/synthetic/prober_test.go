@@ -0,0 +1,140 @@
+package synthetic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+// fakeProxy serves the endpoints the checks call. chatBroken makes the chat stream end early.
+func fakeProxy(t *testing.T, chatBroken *atomic.Bool) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Ephemeral") != "true" {
+			t.Errorf("canary chat is not ephemeral")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintln(w, `data: {"choices":[{"delta":{"content":"OK"}}]}`)
+		if chatBroken != nil && chatBroken.Load() {
+			return
+		}
+		fmt.Fprintln(w, "data: [DONE]")
+	})
+	mux.HandleFunc("POST /embeddings", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"embedding":[0.1,0.2]}]}`)
+	})
+	mux.HandleFunc("POST /api/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results":[]}`)
+	})
+	mux.HandleFunc("POST /api/v1/deepresearch/start", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["dry_run"] != true {
+			t.Errorf("deep research check is not a dry run")
+		}
+		fmt.Fprint(w, `{"success":true}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testLogger() *logger.Logger {
+	return logger.New(logger.Config{Level: slog.LevelError})
+}
+
+func TestProberRun(t *testing.T) {
+	server := fakeProxy(t, nil)
+
+	p := NewProber(Config{BaseURL: server.URL, Token: "secret", ChatModel: "m", EmbeddingModel: "e"}, testLogger())
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if len(p.checks) != 4 {
+		t.Errorf("ran %d checks, want 4", len(p.checks))
+	}
+}
+
+func TestProberSkipsEmbeddingWithoutModel(t *testing.T) {
+	p := NewProber(Config{ChatModel: "m"}, testLogger())
+	for _, c := range p.checks {
+		if c.name == "embedding" {
+			t.Fatal("embedding check enabled without an embedding model")
+		}
+	}
+}
+
+func TestProberFailures(t *testing.T) {
+	server := fakeProxy(t, nil)
+
+	p := NewProber(Config{BaseURL: server.URL, Token: "wrong", ChatModel: "m"}, testLogger())
+	err := p.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "chat") {
+		t.Fatalf("Run() = %v, want chat failure", err)
+	}
+	if p.failures["chat"] != 1 || p.failures["search"] != 0 {
+		t.Errorf("consecutive failures = %v, want chat only", p.failures)
+	}
+}
+
+func TestProberChatWithoutDone(t *testing.T) {
+	var broken atomic.Bool
+	broken.Store(true)
+	server := fakeProxy(t, &broken)
+
+	p := NewProber(Config{BaseURL: server.URL, Token: "secret", ChatModel: "m"}, testLogger())
+	if err := p.checkChat(context.Background()); err == nil || !strings.Contains(err.Error(), "[DONE]") {
+		t.Fatalf("checkChat() = %v, want missing [DONE]", err)
+	}
+}
+
+func TestProberAlerts(t *testing.T) {
+	var broken atomic.Bool
+	server := fakeProxy(t, &broken)
+
+	var mu sync.Mutex
+	var alerts []string
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		mu.Lock()
+		alerts = append(alerts, msg["text"])
+		mu.Unlock()
+	}))
+	defer slack.Close()
+
+	p := NewProber(Config{BaseURL: server.URL, Token: "secret", ChatModel: "m", SlackWebhookURL: slack.URL}, testLogger())
+	run := func() { _ = p.Run(context.Background()) }
+
+	broken.Store(true)
+	run()
+	if len(alerts) != 0 {
+		t.Fatalf("alerted after one failure: %v", alerts)
+	}
+	run()
+	run()
+	if len(alerts) != 1 || !strings.Contains(alerts[0], "`chat` failing") {
+		t.Fatalf("alerts after three failures = %v, want one failure alert", alerts)
+	}
+
+	broken.Store(false)
+	run()
+	run()
+	if len(alerts) != 2 || !strings.Contains(alerts[1], "`chat` recovered") {
+		t.Fatalf("alerts after recovery = %v, want one recovery alert", alerts)
+	}
+}
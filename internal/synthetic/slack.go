@@ -0,0 +1,55 @@
+package synthetic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// slackNotifier sends synthetic check alerts to a Slack webhook.
+type slackNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+func newSlackNotifier(webhookURL string) *slackNotifier {
+	return &slackNotifier{
+		webhookURL: webhookURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// send alerts a failing check (after failures consecutive failures) or its recovery.
+func (n *slackNotifier) send(ctx context.Context, r Result, failures int) error {
+	var text string
+	if r.Err != nil {
+		text = fmt.Sprintf("❌ Synthetic check `%s` failing (%d consecutive failures): `%s`", r.Check, failures, r.Err.Error())
+	} else {
+		text = fmt.Sprintf("✅ Synthetic check `%s` recovered (%s)", r.Check, r.Duration.Round(time.Millisecond))
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("marshal slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send slack notification: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -42,6 +42,10 @@ type Config struct {
 	FallbackDailyPlanTokens int64  `json:"fallback_daily_plan_tokens"` // 0 = no fallback (free tier)
 	FallbackModel           string `json:"fallback_model"`             // Model available in fallback mode (canonical name)
 
+	// Audio limits in minutes transcribed or synthesized (set to 0 for unlimited)
+	MonthlyAudioMinutes int64 `json:"monthly_audio_minutes"` // Resets 00:00 UTC on 1st of month
+	DailyAudioMinutes   int64 `json:"daily_audio_minutes"`   // Resets 00:00 UTC daily
+
 	// Model access (allowlist only - empty array means all models allowed)
 	AllowedModels []string `json:"allowed_models"` // Models allowed for this tier (empty = all allowed)
 
@@ -51,6 +55,26 @@ type Config struct {
 	DeepResearchTokenCap          int `json:"deep_research_token_cap"`           // Per-run token cap (GLM-4.6 tokens)
 	DeepResearchMaxActiveSessions int `json:"deep_research_max_active_sessions"` // Max concurrent deep research jobs
 
+	// Responses API reasoning effort ceiling ("minimal", "low", "medium", "high"; empty = no cap)
+	MaxReasoningEffort string `json:"max_reasoning_effort"`
+
+	// Overall time budget of a streaming request, including tool calls (0 = REQUEST_TIMEOUT_BUDGET_SECONDS)
+	MaxRequestSeconds int `json:"max_request_seconds"`
+
+	// Multiple choices per chat completion (non-streaming only; every choice is billed)
+	MaxCompletionChoices int `json:"max_completion_choices"` // Largest n (0 or 1 = a single choice)
+	BestOfMaxCandidates  int `json:"best_of_max_candidates"` // Largest best_of (0 = best-of not available)
+
+	// Share of admissions while providers are rate limited, relative to other tiers (0 = 1)
+	QueueWeight int `json:"queue_weight"`
+
+	// Serve every request in privacy mode (zero-retention providers, no server-side storage)
+	PrivacyStrict bool `json:"privacy_strict"`
+
+	// Server-side tools injected into chat completions of tool-capable models (tool names,
+	// empty = no tools)
+	AllowedTools []string `json:"allowed_tools"`
+
 	// Allowed features (features available for this tier, empty = all allowed)
 	AllowedFeatures []Feature `json:"allowed_features"` // Features allowed for this tier (empty = all allowed)
 }
@@ -70,11 +94,12 @@ const (
 // Adding a new tier is as simple as adding an entry to this map!
 var Configs = map[Tier]Config{
 	TierFree: {
-		Name:              "free",
-		DisplayName:       "Free",
-		MonthlyPlanTokens: 20_000,
-		WeeklyPlanTokens:  0, // No weekly limit
-		DailyPlanTokens:   0, // No daily limit
+		Name:                "free",
+		DisplayName:         "Free",
+		MonthlyPlanTokens:   20_000,
+		WeeklyPlanTokens:    0, // No weekly limit
+		DailyPlanTokens:     0, // No daily limit
+		MonthlyAudioMinutes: 30,
 		// AllowedModels uses canonical model names only (from config.yaml).
 		// Aliases are resolved to canonical names by the middleware before this check.
 		AllowedModels: []string{
@@ -89,6 +114,12 @@ var Configs = map[Tier]Config{
 		DeepResearchLifetimeRuns:      1, // 1 lifetime run
 		DeepResearchTokenCap:          8_000,
 		DeepResearchMaxActiveSessions: 1,
+		MaxReasoningEffort:            "medium", // High effort reserved for paid tiers
+		MaxRequestSeconds:             300,      // Paid tiers use the default budget
+		MaxCompletionChoices:          1,        // Single choice only
+		BestOfMaxCandidates:           0,        // No best-of
+		QueueWeight:                   1,
+		AllowedTools:                  []string{}, // No server-side tools
 		// Free tier does NOT have document upload feature
 		AllowedFeatures: []Feature{}, // No special features
 	},
@@ -99,12 +130,18 @@ var Configs = map[Tier]Config{
 		WeeklyPlanTokens:              0,
 		DailyPlanTokens:               40_000,
 		FallbackDailyPlanTokens:       40_000,
+		DailyAudioMinutes:             60,
 		FallbackModel:                 "Qwen/Qwen3-30B-A3B-Instruct-2507",
 		AllowedModels:                 []string{}, // All models allowed (same as Pro)
 		DeepResearchDailyRuns:         -1,         // Unlimited daily runs
 		DeepResearchLifetimeRuns:      0,          // Check daily only
 		DeepResearchTokenCap:          10_000,
 		DeepResearchMaxActiveSessions: 0, // Unlimited concurrent
+		MaxReasoningEffort:            "high",
+		MaxCompletionChoices:          4,
+		BestOfMaxCandidates:           3,
+		QueueWeight:                   2,
+		AllowedTools:                  []string{"web_search", "fetch_url", "schedule_task"},
 		AllowedFeatures:               []Feature{},
 	},
 	TierPro: {
@@ -114,12 +151,18 @@ var Configs = map[Tier]Config{
 		WeeklyPlanTokens:              0, // No weekly limit
 		DailyPlanTokens:               500_000,
 		FallbackDailyPlanTokens:       500_000,
+		DailyAudioMinutes:             240,
 		FallbackModel:                 "Qwen/Qwen3-30B-A3B-Instruct-2507",
 		AllowedModels:                 []string{}, // Empty = all models allowed
 		DeepResearchDailyRuns:         10,
 		DeepResearchLifetimeRuns:      0, // Check daily only
 		DeepResearchTokenCap:          10_000,
-		DeepResearchMaxActiveSessions: 0, // 0 = unlimited concurrent sessions
+		DeepResearchMaxActiveSessions: 3, // Overridable via DEEP_RESEARCH_PRO_MAX_ACTIVE_SESSIONS
+		MaxReasoningEffort:            "high",
+		MaxCompletionChoices:          8,
+		BestOfMaxCandidates:           5,
+		QueueWeight:                   4, // Admitted ahead of free and plus requests under load
+		AllowedTools:                  []string{"web_search", "fetch_url", "schedule_task"},
 		AllowedFeatures:               []Feature{FeatureDocumentUpload},
 	},
 }
@@ -132,8 +175,16 @@ func Get(tier Tier) (Config, error) {
 		return Config{}, fmt.Errorf("unknown tier: %s", tier)
 	}
 
+	// Both settings can be changed by a config reload
+	appConfig := config.Current()
+
+	// Pro concurrency cap is tuned to the research backend's capacity
+	if tier == TierPro && appConfig.DeepResearchProMaxActiveSessions > 0 {
+		cfg.DeepResearchMaxActiveSessions = appConfig.DeepResearchProMaxActiveSessions
+	}
+
 	// Apply soft limit multiplier (for staging/testing)
-	multiplier := config.AppConfig.RateLimitSoftMultiplier
+	multiplier := appConfig.RateLimitSoftMultiplier
 	if multiplier > 0 && multiplier != 1.0 && cfg.DailyPlanTokens > 0 {
 		cfg.DailyPlanTokens = int64(float64(cfg.DailyPlanTokens) * multiplier)
 	}
@@ -165,6 +216,17 @@ func (c Config) IsFallbackModel(modelID string) bool {
 	return c.FallbackModel != "" && c.FallbackModel == modelID
 }
 
+// IsToolAllowed checks if a server-side tool may be used by this tier.
+// Unlike models and features, empty AllowedTools means no tools are allowed.
+func (c Config) IsToolAllowed(name string) bool {
+	for _, allowed := range c.AllowedTools {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
 // IsFeatureAllowed checks if a feature is allowed for this tier.
 // Empty AllowedFeatures means all features are allowed.
 // Non-empty AllowedFeatures means only those specific features are allowed.
@@ -219,3 +281,23 @@ func (c Config) GetMonthlyResetTime() time.Time {
 	nextMonth := now.AddDate(0, 1, 0)
 	return time.Date(nextMonth.Year(), nextMonth.Month(), 1, 0, 0, 0, 0, time.UTC)
 }
+
+// GetDailyAudioResetTime returns when the daily audio quota resets (00:00 UTC daily).
+func (c Config) GetDailyAudioResetTime() time.Time {
+	if c.DailyAudioMinutes == 0 {
+		return time.Time{} // No daily audio quota
+	}
+	now := time.Now().UTC()
+	tomorrow := now.AddDate(0, 0, 1)
+	return time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetMonthlyAudioResetTime returns when the monthly audio quota resets (00:00 UTC on 1st of month).
+func (c Config) GetMonthlyAudioResetTime() time.Time {
+	if c.MonthlyAudioMinutes == 0 {
+		return time.Time{} // No monthly audio quota
+	}
+	now := time.Now().UTC()
+	nextMonth := now.AddDate(0, 1, 0)
+	return time.Date(nextMonth.Year(), nextMonth.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,171 @@
+// Package tinfoil verifies the enclave attestation of Tinfoil inference providers, so the
+// privacy claims made for models served from secure enclaves are enforced by the proxy.
+//
+// A Tinfoil enclave publishes an attestation document: an AMD SEV-SNP attestation report whose
+// report data binds the enclave's TLS key, and whose measurement identifies the enclave image.
+// The proxy fetches the document over TLS from the enclave and checks that:
+//   - the report binds the TLS key the enclave served the document with, so the document
+//     comes from the enclave the proxy is connected to;
+//   - the guest policy forbids debugging, so the host can't read enclave memory;
+//   - the launch measurement is one of the configured releases (if any are configured).
+//
+// Providers with an attestation section in config.yaml are routed to only while their
+// attestation verifies. Attestations are re-verified periodically, and every new upstream
+// connection must present the attested TLS key (Service.VerifyConnection).
+package tinfoil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	// attestationPath is where enclaves publish their attestation document.
+	attestationPath = "/.well-known/tinfoil-attestation"
+
+	// formatSEVSNP prefixes the format of AMD SEV-SNP attestation documents (versioned).
+	formatSEVSNP = "https://tinfoil.sh/predicate/sev-snp-guest/"
+
+	maxDocumentBytes = 1 << 20
+
+	// AMD SEV-SNP attestation report layout (SEV Secure Nested Paging Firmware ABI).
+	snpReportSize        = 0x4A0
+	snpPolicyOffset      = 0x08
+	snpReportDataOffset  = 0x50
+	snpMeasurementOffset = 0x90
+	snpMeasurementSize   = 48
+	snpPolicyDebug       = 1 << 19 // Guest policy bit allowing the host to debug the guest
+)
+
+// ErrAttestation is returned when an enclave's attestation doesn't verify.
+var ErrAttestation = errors.New("attestation verification failed")
+
+// Document is an enclave's attestation document.
+type Document struct {
+	Format string `json:"format"`
+	Body   string `json:"body"` // Base64 of the gzipped attestation report
+}
+
+// report holds the verified fields of an SEV-SNP attestation report.
+type report struct {
+	policy      uint64
+	reportData  [64]byte
+	measurement [snpMeasurementSize]byte
+}
+
+// Attestation is a verified enclave attestation.
+type Attestation struct {
+	Measurement       string // Hex launch measurement of the enclave image
+	TLSKeyFingerprint string // Hex SHA-256 of the enclave's TLS public key
+}
+
+// parseReport parses a raw SEV-SNP attestation report.
+func parseReport(raw []byte) (*report, error) {
+	if len(raw) < snpReportSize {
+		return nil, fmt.Errorf("%w: report is %d bytes, want %d", ErrAttestation, len(raw), snpReportSize)
+	}
+	r := &report{policy: binary.LittleEndian.Uint64(raw[snpPolicyOffset:])}
+	copy(r.reportData[:], raw[snpReportDataOffset:])
+	copy(r.measurement[:], raw[snpMeasurementOffset:])
+	return r, nil
+}
+
+// decodeDocument returns the attestation report of a document.
+func decodeDocument(doc Document) (*report, error) {
+	if !strings.HasPrefix(doc.Format, formatSEVSNP) {
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrAttestation, doc.Format)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(doc.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid body encoding", ErrAttestation)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid body compression", ErrAttestation)
+	}
+	raw, err := io.ReadAll(io.LimitReader(zr, maxDocumentBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid body compression", ErrAttestation)
+	}
+	return parseReport(raw)
+}
+
+// keyFingerprint returns the SHA-256 of a certificate's public key (DER SubjectPublicKeyInfo).
+func keyFingerprint(cert *x509.Certificate) [32]byte {
+	return sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+}
+
+// check verifies a report against the enclave's TLS certificate and the accepted measurements
+// (hex; empty = any).
+func (r *report) check(cert *x509.Certificate, measurements []string) (*Attestation, error) {
+	if r.policy&snpPolicyDebug != 0 {
+		return nil, fmt.Errorf("%w: guest policy allows debugging", ErrAttestation)
+	}
+
+	fingerprint := keyFingerprint(cert)
+	if !bytes.Equal(r.reportData[:len(fingerprint)], fingerprint[:]) {
+		return nil, fmt.Errorf("%w: report does not bind the enclave TLS key", ErrAttestation)
+	}
+
+	measurement := hex.EncodeToString(r.measurement[:])
+	if len(measurements) > 0 && !slices.ContainsFunc(measurements, func(m string) bool {
+		return strings.EqualFold(m, measurement)
+	}) {
+		return nil, fmt.Errorf("%w: unknown measurement %s", ErrAttestation, measurement)
+	}
+
+	return &Attestation{
+		Measurement:       measurement,
+		TLSKeyFingerprint: hex.EncodeToString(fingerprint[:]),
+	}, nil
+}
+
+// Verify fetches the attestation document of the enclave serving baseURL and verifies it.
+func Verify(ctx context.Context, client *http.Client, baseURL string, measurements []string) (*Attestation, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%w: base URL must be https", ErrAttestation)
+	}
+	docURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: attestationPath}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attestation: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("attestation endpoint returned status %d", resp.StatusCode)
+	}
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("%w: no enclave TLS certificate", ErrAttestation)
+	}
+
+	var doc Document
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: invalid document: %v", ErrAttestation, err)
+	}
+	r, err := decodeDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	return r.check(resp.TLS.PeerCertificates[0], measurements)
+}
@@ -0,0 +1,156 @@
+package tinfoil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+var testMeasurement = strings.Repeat("ab", snpMeasurementSize)
+
+// newEnclave starts a TLS server publishing an attestation document; build customizes the
+// report before it is bound to the server's TLS key.
+func newEnclave(t *testing.T, build func(raw []byte)) *httptest.Server {
+	t.Helper()
+	var doc []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != attestationPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(doc) //nolint:errcheck
+	}))
+	t.Cleanup(server.Close)
+
+	raw := make([]byte, snpReportSize)
+	fingerprint := keyFingerprint(server.Certificate())
+	copy(raw[snpReportDataOffset:], fingerprint[:])
+	measurement, _ := hex.DecodeString(testMeasurement)
+	copy(raw[snpMeasurementOffset:], measurement)
+	if build != nil {
+		build(raw)
+	}
+
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	zw.Write(raw) //nolint:errcheck
+	zw.Close()    //nolint:errcheck
+	doc, _ = json.Marshal(Document{
+		Format: formatSEVSNP + "v2",
+		Body:   base64.StdEncoding.EncodeToString(body.Bytes()),
+	})
+	return server
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name         string
+		build        func(raw []byte)
+		measurements []string
+		wantErr      string
+	}{
+		{name: "any measurement"},
+		{name: "pinned measurement", measurements: []string{strings.ToUpper(testMeasurement)}},
+		{name: "unknown measurement", measurements: []string{strings.Repeat("cd", 48)}, wantErr: "unknown measurement"},
+		{
+			name:    "debuggable guest",
+			build:   func(raw []byte) { binary.LittleEndian.PutUint64(raw[snpPolicyOffset:], snpPolicyDebug) },
+			wantErr: "allows debugging",
+		},
+		{
+			name:    "other TLS key",
+			build:   func(raw []byte) { raw[snpReportDataOffset] ^= 0xff },
+			wantErr: "does not bind",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newEnclave(t, tt.build)
+
+			attestation, err := Verify(context.Background(), server.Client(), server.URL+"/v1", tt.measurements)
+			if tt.wantErr != "" {
+				if err == nil || !errors.Is(err, ErrAttestation) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if attestation.Measurement != testMeasurement {
+				t.Errorf("Measurement = %s, want %s", attestation.Measurement, testMeasurement)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsPlainHTTP(t *testing.T) {
+	if _, err := Verify(context.Background(), http.DefaultClient, "http://inference.example/v1", nil); !errors.Is(err, ErrAttestation) {
+		t.Errorf("Verify(http) error = %v, want ErrAttestation", err)
+	}
+}
+
+func TestServiceAttestedAndVerifyConnection(t *testing.T) {
+	server := newEnclave(t, nil)
+	other := newEnclave(t, nil)
+
+	cfg := &config.ModelRouterConfig{Providers: []config.ModelProviderConfig{
+		{Name: "Tinfoil", BaseURL: server.URL + "/v1", Attestation: &config.AttestationConfig{Type: config.AttestationTypeTinfoil}},
+		{Name: "OpenAI", BaseURL: "https://api.openai.com/v1"},
+	}}
+	s := NewService(cfg, nil, 0, logger.New(logger.Config{Level: slog.LevelError}))
+	s.client = server.Client()
+
+	if !s.Enabled() {
+		t.Fatal("Enabled() = false with an attested provider")
+	}
+	if s.Attested("Tinfoil") {
+		t.Error("Attested(Tinfoil) before verification = true")
+	}
+	if !s.Attested("OpenAI") {
+		t.Error("Attested(OpenAI) = false for a provider without attestation")
+	}
+
+	s.CheckAll(context.Background())
+	if !s.Attested("Tinfoil") {
+		t.Fatalf("Attested(Tinfoil) = false after verification: %+v", s.Statuses())
+	}
+
+	host := func(server *httptest.Server) string {
+		u, _ := url.Parse(server.URL)
+		return u.Hostname()
+	}
+	attested := tls.ConnectionState{ServerName: host(server), PeerCertificates: []*x509.Certificate{server.Certificate()}}
+	if err := s.VerifyConnection(attested); err != nil {
+		t.Errorf("VerifyConnection(attested key) = %v", err)
+	}
+
+	// httptest servers share a certificate; another key must be refused
+	forged := *other.Certificate()
+	forged.RawSubjectPublicKeyInfo = []byte("other key")
+	rotated := tls.ConnectionState{ServerName: host(server), PeerCertificates: []*x509.Certificate{&forged}}
+	s.rechecking["Tinfoil"] = s.statuses["Tinfoil"].CheckedAt // No background re-verification
+	if err := s.VerifyConnection(rotated); !errors.Is(err, ErrAttestation) {
+		t.Errorf("VerifyConnection(unattested key) = %v, want ErrAttestation", err)
+	}
+
+	if err := s.VerifyConnection(tls.ConnectionState{ServerName: "api.openai.com"}); err != nil {
+		t.Errorf("VerifyConnection(other provider) = %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package tinfoil
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/metrics"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+const (
+	checkTimeout = 15 * time.Second
+	// minRecheckInterval spaces out re-verifications triggered by connections presenting an
+	// unattested TLS key.
+	minRecheckInterval = 30 * time.Second
+)
+
+// Status is the attestation state of an enclave provider.
+type Status struct {
+	Provider          string     `json:"provider"`
+	BaseURL           string     `json:"base_url"`
+	Verified          bool       `json:"verified"` // Routed to only while true
+	Measurement       string     `json:"measurement,omitempty"`
+	TLSKeyFingerprint string     `json:"tls_key_fingerprint,omitempty"`
+	CheckedAt         time.Time  `json:"checked_at"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"` // Last successful verification
+	Error             string     `json:"error,omitempty"`
+}
+
+// target is a provider that requires attestation.
+type target struct {
+	provider     string
+	baseURL      string
+	host         string
+	measurements []string
+}
+
+// Service verifies the attestation of enclave providers and is the router's attestation
+// policy: providers are routed to only while their attestation verifies.
+type Service struct {
+	targets  []target
+	client   *http.Client
+	router   *routing.ModelRouter
+	interval time.Duration
+	logger   *logger.Logger
+
+	mu         sync.RWMutex
+	statuses   map[string]Status    // By provider name
+	rechecking map[string]time.Time // Provider → start of the last triggered re-verification
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewService creates the attestation service for the providers of cfg with an attestation
+// section. Attestations are re-verified every interval (<= 0 = only at Start); router may be
+// nil (no routes to rebuild).
+func NewService(cfg *config.ModelRouterConfig, router *routing.ModelRouter, interval time.Duration, logger *logger.Logger) *Service {
+	s := &Service{
+		client:     &http.Client{Timeout: checkTimeout},
+		router:     router,
+		interval:   interval,
+		logger:     logger,
+		statuses:   make(map[string]Status),
+		rechecking: make(map[string]time.Time),
+		stop:       make(chan struct{}),
+	}
+	if cfg == nil {
+		return s
+	}
+	for _, provider := range cfg.Providers {
+		if provider.Attestation == nil {
+			continue
+		}
+		u, err := url.Parse(provider.BaseURL)
+		if err != nil {
+			continue
+		}
+		s.targets = append(s.targets, target{
+			provider:     provider.Name,
+			baseURL:      provider.BaseURL,
+			host:         u.Hostname(),
+			measurements: provider.Attestation.Measurements,
+		})
+	}
+	return s
+}
+
+// Enabled reports whether any provider requires attestation.
+func (s *Service) Enabled() bool {
+	return len(s.targets) > 0
+}
+
+// Start installs the service as the router's attestation policy, verifies all enclave
+// providers and starts the periodic re-verification. Providers are not routed to until their
+// first verification succeeds.
+func (s *Service) Start(ctx context.Context) {
+	if s.router != nil {
+		s.router.SetAttestationPolicy(s)
+	}
+	s.CheckAll(ctx)
+	if s.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.CheckAll(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic re-verification.
+func (s *Service) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// CheckAll verifies the attestation of every enclave provider and rebuilds the routes if a
+// provider's state changed.
+func (s *Service) CheckAll(ctx context.Context) {
+	changed := false
+	for _, t := range s.targets {
+		if s.check(ctx, t) {
+			changed = true
+		}
+	}
+	if changed && s.router != nil {
+		s.router.Rebuild()
+	}
+}
+
+// check verifies a provider's attestation and reports whether its verified state changed.
+func (s *Service) check(ctx context.Context, t target) bool {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	attestation, err := Verify(ctx, s.client, t.baseURL, t.measurements)
+	now := time.Now()
+
+	s.mu.Lock()
+	previous, seen := s.statuses[t.provider]
+	status := Status{
+		Provider:   t.provider,
+		BaseURL:    t.baseURL,
+		CheckedAt:  now,
+		VerifiedAt: previous.VerifiedAt,
+	}
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Verified = true
+		status.Measurement = attestation.Measurement
+		status.TLSKeyFingerprint = attestation.TLSKeyFingerprint
+		status.VerifiedAt = &now
+	}
+	s.statuses[t.provider] = status
+	s.mu.Unlock()
+
+	metrics.RecordProviderAttestation(t.provider, status.Verified)
+	if err != nil {
+		s.logger.Error("enclave attestation failed, provider not routed",
+			slog.String("provider", t.provider),
+			slog.String("base_url", t.baseURL),
+			slog.String("error", err.Error()))
+	} else if !previous.Verified || previous.Measurement != status.Measurement || previous.TLSKeyFingerprint != status.TLSKeyFingerprint {
+		s.logger.Info("enclave attestation verified",
+			slog.String("provider", t.provider),
+			slog.String("measurement", status.Measurement),
+			slog.String("tls_key_fingerprint", status.TLSKeyFingerprint))
+	}
+
+	return !seen || previous.Verified != status.Verified
+}
+
+// Attested implements routing.AttestationPolicy.
+func (s *Service) Attested(provider string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.targets {
+		if t.provider == provider {
+			return s.statuses[provider].Verified
+		}
+	}
+	return true
+}
+
+// VerifyConnection checks a new TLS connection to an enclave provider: it must present the TLS
+// key bound by the provider's verified attestation. A different key (e.g. a redeployed enclave)
+// triggers a re-verification; connections are refused until it succeeds. Use as
+// tls.Config.VerifyConnection of upstream transports.
+func (s *Service) VerifyConnection(cs tls.ConnectionState) error {
+	var t *target
+	for i := range s.targets {
+		if s.targets[i].host == cs.ServerName {
+			t = &s.targets[i]
+			break
+		}
+	}
+	if t == nil {
+		return nil
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("%w: no enclave TLS certificate", ErrAttestation)
+	}
+
+	fingerprint := keyFingerprint(cs.PeerCertificates[0])
+	s.mu.Lock()
+	status := s.statuses[t.provider]
+	if status.Verified && status.TLSKeyFingerprint == hex.EncodeToString(fingerprint[:]) {
+		s.mu.Unlock()
+		return nil
+	}
+	recheck := time.Since(s.rechecking[t.provider]) >= minRecheckInterval
+	if recheck {
+		s.rechecking[t.provider] = time.Now()
+	}
+	s.mu.Unlock()
+
+	if recheck {
+		go func() {
+			if s.check(context.Background(), *t) && s.router != nil {
+				s.router.Rebuild()
+			}
+		}()
+	}
+	return fmt.Errorf("%w: %s presented an unattested TLS key", ErrAttestation, t.provider)
+}
+
+// Statuses returns the attestation state of every enclave provider, by provider name.
+func (s *Service) Statuses() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.targets))
+	for _, t := range s.targets {
+		status, ok := s.statuses[t.provider]
+		if !ok {
+			status = Status{Provider: t.provider, BaseURL: t.baseURL, Error: "not verified yet"}
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Provider < statuses[j].Provider })
+	return statuses
+}
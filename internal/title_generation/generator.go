@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/providerapi"
 )
 
 const (
@@ -29,19 +30,29 @@ Second user message: %s`
 type Generator struct {
 	initialPrompt      string
 	regenerationPrompt string
+	localized          map[string]config.TitleGenerationPrompts
 }
 
 // NewGenerator creates a new title generator with prompts from config
 func NewGenerator(cfg *config.TitleGenerationConfig) *Generator {
+	localized := make(map[string]config.TitleGenerationPrompts, len(cfg.Localized))
+	for lang, prompts := range cfg.Localized {
+		localized[lang] = config.TitleGenerationPrompts{
+			InitialPrompt:      strings.TrimSpace(prompts.InitialPrompt),
+			RegenerationPrompt: strings.TrimSpace(prompts.RegenerationPrompt),
+		}
+	}
+
 	return &Generator{
 		initialPrompt:      strings.TrimSpace(cfg.InitialPrompt),
 		regenerationPrompt: strings.TrimSpace(cfg.RegenerationPrompt),
+		localized:          localized,
 	}
 }
 
 // GenerateInitial generates a title from the first user message
 func (g *Generator) GenerateInitial(ctx context.Context, req GenerateRequest) (string, error) {
-	return g.generate(ctx, g.initialPrompt, req.UserContent, req)
+	return g.generate(ctx, g.initialPromptFor(req.Language), req.UserContent, req)
 }
 
 // GenerateFromContext generates a title using conversation context
@@ -51,7 +62,23 @@ func (g *Generator) GenerateFromContext(ctx context.Context, req GenerateRequest
 		regenCtx.FirstAIResponse,
 		regenCtx.SecondUserMessage,
 	)
-	return g.generate(ctx, g.regenerationPrompt, userContent, req)
+	return g.generate(ctx, g.regenerationPromptFor(req.Language), userContent, req)
+}
+
+// initialPromptFor returns the initial prompt for a chat language, or the default one
+func (g *Generator) initialPromptFor(language string) string {
+	if prompt := g.localized[language].InitialPrompt; prompt != "" {
+		return prompt
+	}
+	return g.initialPrompt
+}
+
+// regenerationPromptFor returns the regeneration prompt for a chat language, or the default one
+func (g *Generator) regenerationPromptFor(language string) string {
+	if prompt := g.localized[language].RegenerationPrompt; prompt != "" {
+		return prompt
+	}
+	return g.regenerationPrompt
 }
 
 // generate is the core generation function with retry logic
@@ -99,14 +126,26 @@ func (g *Generator) callAI(ctx context.Context, systemPrompt, userContent string
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
+	translator := providerapi.For(req.APIType)
 	url := req.BaseURL + "/chat/completions"
+	if translator != nil {
+		var path string
+		if body, path, err = translator.Request(body); err != nil {
+			return "", fmt.Errorf("translate request: %w", err)
+		}
+		url = req.BaseURL + path
+	}
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
+	if translator != nil {
+		translator.SetHeaders(httpReq.Header, req.APIKey)
+	} else {
+		httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
+	}
 
 	client := &http.Client{Timeout: requestTimeout}
 	resp, err := client.Do(httpReq)
@@ -124,6 +163,11 @@ func (g *Generator) callAI(ctx context.Context, systemPrompt, userContent string
 		return "", fmt.Errorf("AI returned %d: %s (url: %s, model: %s)",
 			resp.StatusCode, string(respBody), url, req.Model)
 	}
+	if translator != nil {
+		if respBody, err = translator.Response(respBody); err != nil {
+			return "", fmt.Errorf("translate response: %w", err)
+		}
+	}
 
 	var result struct {
 		Choices []struct {
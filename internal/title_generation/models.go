@@ -1,11 +1,15 @@
 package title_generation
 
+import "github.com/eternisai/enchanted-proxy/internal/config"
+
 // GenerateRequest contains the common parameters for title generation
 type GenerateRequest struct {
 	Model       string
 	BaseURL     string
 	APIKey      string
-	UserContent string // The content to generate a title from
+	APIType     config.APIType // Provider API format ("" = chat completions)
+	UserContent string         // The content to generate a title from
+	Language    string         // Detected chat language (ISO 639-1), picks localized prompts; "" = default
 }
 
 // RegenerationContext contains conversation context for improved title generation
@@ -20,6 +24,7 @@ type StorageRequest struct {
 	UserID            string
 	ChatID            string
 	Title             string
+	Language          string // Detected chat language stored with the title; "" = unknown
 	Platform          string
 	EncryptionEnabled *bool
 }
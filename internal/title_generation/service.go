@@ -2,82 +2,65 @@ package title_generation
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/eternisai/enchanted-proxy/internal/language"
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/messaging"
+	"github.com/eternisai/enchanted-proxy/internal/worker"
 )
 
 // Service handles async title generation with encryption
 type Service struct {
-	logger          *logger.Logger
-	generator       *Generator
-	messageService  *messaging.Service
-	firestoreClient *messaging.FirestoreClient
-	storageChan     chan StorageRequest
-	workerPool      sync.WaitGroup
-	shutdown        chan struct{}
-	closed          atomic.Bool
+	logger         *logger.Logger
+	generator      *Generator
+	messageService *messaging.Service
+	chatStore      messaging.Store
+	pool           *worker.Pool[StorageRequest]
+	closed         atomic.Bool
 }
 
+// Storage worker pool settings.
+const (
+	workerPoolSize = 2
+	queueSize      = 100
+	storageTimeout = 60 * time.Second
+	enqueueTimeout = 35 * time.Second // How long queueStorage waits for queue space
+)
+
 // NewService creates a new title generation service
 func NewService(
 	logger *logger.Logger,
 	generator *Generator,
 	messageService *messaging.Service,
-	firestoreClient *messaging.FirestoreClient,
+	chatStore messaging.Store,
 ) *Service {
 	s := &Service{
-		logger:          logger,
-		generator:       generator,
-		messageService:  messageService,
-		firestoreClient: firestoreClient,
-		storageChan:     make(chan StorageRequest, 100),
-		shutdown:        make(chan struct{}),
+		logger:         logger,
+		generator:      generator,
+		messageService: messageService,
+		chatStore:      chatStore,
 	}
 
 	// Start worker pool for storage operations
-	const workerPoolSize = 2
-	for i := 0; i < workerPoolSize; i++ {
-		s.workerPool.Add(1)
-		go s.storageWorker()
-	}
+	s.pool = worker.NewPool(worker.Options{
+		Name:      "titles",
+		Workers:   workerPoolSize,
+		QueueSize: queueSize,
+		Timeout:   storageTimeout,
+	}, s.storeTitle, logger)
 
 	logger.Info("title generation service started", slog.Int("worker_pool_size", workerPoolSize))
 	return s
 }
 
-// storageWorker processes title storage requests
-func (s *Service) storageWorker() {
-	defer s.workerPool.Done()
-
-	for {
-		select {
-		case req := <-s.storageChan:
-			s.storeTitle(req)
-		case <-s.shutdown:
-			// Drain remaining jobs
-			for {
-				select {
-				case req := <-s.storageChan:
-					s.storeTitle(req)
-				default:
-					return
-				}
-			}
-		}
-	}
-}
-
-// storeTitle encrypts and saves a title to Firestore
-func (s *Service) storeTitle(req StorageRequest) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
+// storeTitle encrypts and saves a title to the chat store. Failures are logged here with
+// chat context; the error is nil so the pool doesn't log them again.
+func (s *Service) storeTitle(ctx context.Context, req StorageRequest) error {
 	log := s.logger.WithContext(ctx)
 
 	log.Debug("storing title",
@@ -86,28 +69,30 @@ func (s *Service) storeTitle(req StorageRequest) {
 
 	chatTitle := s.buildChatTitle(ctx, req, log)
 	if chatTitle == nil {
-		return
+		return nil
 	}
+	chatTitle.Language = req.Language
 
-	if err := s.firestoreClient.SaveChatTitle(ctx, req.UserID, req.ChatID, chatTitle); err != nil {
+	if err := s.chatStore.SaveChatTitle(ctx, req.UserID, req.ChatID, chatTitle); err != nil {
 		errMsg := err.Error()
 		if strings.Contains(errMsg, "not found") || strings.Contains(errMsg, "FailedPrecondition") {
 			log.Warn("chat document not found - client hasn't created it yet",
 				slog.String("user_id", req.UserID),
 				slog.String("chat_id", req.ChatID))
-			return
+			return nil
 		}
 		log.Error("failed to save title",
 			slog.String("user_id", req.UserID),
 			slog.String("chat_id", req.ChatID),
 			slog.String("error", err.Error()))
-		return
+		return nil
 	}
 
 	log.Info("title saved",
 		slog.String("user_id", req.UserID),
 		slog.String("chat_id", req.ChatID),
 		slog.Bool("encrypted", chatTitle.EncryptedTitle != ""))
+	return nil
 }
 
 // buildChatTitle creates a ChatTitle with proper encryption based on settings
@@ -192,34 +177,23 @@ func (s *Service) buildEncryptedTitle(ctx context.Context, req StorageRequest, l
 
 // queueStorage queues a title for encryption and storage
 func (s *Service) queueStorage(ctx context.Context, req StorageRequest) {
-	if s.closed.Load() {
-		s.logger.Warn("service shutting down, cannot queue storage")
-		return
-	}
-
 	log := s.logger.WithContext(ctx)
 
-	select {
-	case s.storageChan <- req:
+	enqueueCtx, cancel := context.WithTimeout(ctx, enqueueTimeout)
+	defer cancel()
+
+	err := s.pool.Enqueue(enqueueCtx, req)
+	switch {
+	case err == nil:
 		log.Debug("title queued for storage", slog.String("chat_id", req.ChatID))
-	case <-ctx.Done():
+	case errors.Is(err, worker.ErrClosed):
+		s.logger.Warn("service shutting down, cannot queue storage")
+	case ctx.Err() != nil:
 		log.Warn("context cancelled, cannot queue storage")
-	case <-time.After(5 * time.Second):
-		if s.closed.Load() {
-			return
-		}
-		log.Error("storage queue blocked for 5s",
+	default:
+		log.Error("storage queue blocked for 35s total, giving up",
 			slog.String("chat_id", req.ChatID),
-			slog.Int("queue_size", len(s.storageChan)))
-		// Final blocking attempt with timeout
-		select {
-		case s.storageChan <- req:
-			log.Info("title queued after blocking", slog.String("chat_id", req.ChatID))
-		case <-ctx.Done():
-			log.Error("context cancelled during blocking queue")
-		case <-time.After(30 * time.Second):
-			log.Error("storage queue blocked for 35s total, giving up")
-		}
+			slog.Int("queue_size", s.pool.Len()))
 	}
 }
 
@@ -232,9 +206,14 @@ func (s *Service) GenerateAndStore(ctx context.Context, genReq GenerateRequest,
 
 	log := s.logger.WithContext(ctx)
 
+	// The chat's language is that of its first message
+	genReq.Language = language.Detect(genReq.UserContent)
+	storeReq.Language = genReq.Language
+
 	log.Info("generating initial title",
 		slog.String("chat_id", storeReq.ChatID),
 		slog.String("model", genReq.Model),
+		slog.String("language", genReq.Language),
 		slog.Int("content_length", len(genReq.UserContent)))
 
 	title, err := s.generator.GenerateInitial(ctx, genReq)
@@ -262,9 +241,13 @@ func (s *Service) RegenerateAndStore(ctx context.Context, genReq GenerateRequest
 
 	log := s.logger.WithContext(ctx)
 
+	genReq.Language = language.Detect(regenCtx.FirstUserMessage)
+	storeReq.Language = genReq.Language
+
 	log.Info("regenerating title with context",
 		slog.String("chat_id", storeReq.ChatID),
 		slog.String("model", genReq.Model),
+		slog.String("language", genReq.Language),
 		slog.Int("first_msg_len", len(regenCtx.FirstUserMessage)),
 		slog.Int("ai_response_len", len(regenCtx.FirstAIResponse)),
 		slog.Int("second_msg_len", len(regenCtx.SecondUserMessage)))
@@ -289,8 +272,6 @@ func (s *Service) RegenerateAndStore(ctx context.Context, genReq GenerateRequest
 func (s *Service) Shutdown() {
 	s.logger.Info("shutting down title generation service")
 	s.closed.Store(true)
-	close(s.shutdown)
-	s.workerPool.Wait()
-	close(s.storageChan)
+	_ = s.pool.Shutdown(context.Background())
 	s.logger.Info("title generation service shutdown complete")
 }
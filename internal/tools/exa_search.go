@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/eternisai/enchanted-proxy/internal/logger"
 	"github.com/eternisai/enchanted-proxy/internal/search"
 )
 
-// ExaSearchTool implements web search using Exa AI API.
+// ExaSearchTool implements the web_search tool using Exa AI API. Without an Exa key, it
+// falls back to the first configured search engine (SerpAPI or Brave).
 type ExaSearchTool struct {
 	searchService *search.Service
 	logger        *logger.Logger
@@ -110,6 +112,10 @@ func (t *ExaSearchTool) Execute(ctx context.Context, args string) (string, error
 		"num_results", searchArgs.NumResults,
 		"requires_live_results", searchArgs.RequiresLiveResults)
 
+	if !t.searchService.ExaConfigured() {
+		return t.searchEngine(ctx, searchArgs)
+	}
+
 	// Call search service
 	searchReq := search.ExaSearchRequest{
 		Queries:    searchArgs.Queries,
@@ -154,3 +160,81 @@ func (t *ExaSearchTool) formatResults(resp *search.ExaSearchResponse) string {
 
 	return strings.Join(parts, "\n")
 }
+
+// searchEngine runs the queries in parallel on the first configured search engine.
+func (t *ExaSearchTool) searchEngine(ctx context.Context, args ExaSearchArgs) (string, error) {
+	engines := t.searchService.Engines()
+	if len(engines) == 0 {
+		return "", fmt.Errorf("search failed: no search engine configured")
+	}
+
+	// Fresh results are asked for with a past-day time filter
+	timeFilter := ""
+	if args.RequiresLiveResults {
+		timeFilter = "d"
+	}
+
+	responses := make([]*search.SearchResponse, len(args.Queries))
+	errs := make([]error, len(args.Queries))
+	var wg sync.WaitGroup
+	for i, query := range args.Queries {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+			responses[i], errs[i] = t.searchService.Search(ctx, search.SearchRequest{
+				Query:      query,
+				Engine:     engines[0],
+				TimeFilter: timeFilter,
+			})
+		}(i, query)
+	}
+	wg.Wait()
+
+	results := make([]*search.SearchResponse, 0, len(responses))
+	for i, resp := range responses {
+		if errs[i] != nil {
+			t.logger.Warn("web search query failed",
+				"query", args.Queries[i],
+				"engine", engines[0],
+				"error", errs[i].Error())
+			continue
+		}
+		results = append(results, resp)
+	}
+	if len(results) == 0 {
+		return "", fmt.Errorf("search failed: %w", errs[0])
+	}
+
+	return formatEngineResults(results, args.NumResults), nil
+}
+
+// formatEngineResults formats search engine results as plain text for AI consumption,
+// keeping the first numResults results of each query.
+func formatEngineResults(responses []*search.SearchResponse, numResults int) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, resp := range responses {
+		count := 0
+		for _, r := range resp.OrganicResults {
+			if count == numResults {
+				break
+			}
+			count++
+			if seen[r.Link] {
+				continue
+			}
+			seen[r.Link] = true
+
+			entry := fmt.Sprintf("- %s: %s", r.Link, r.Title)
+			if r.Snippet != "" {
+				entry += fmt.Sprintf(" — %s", r.Snippet)
+			}
+			parts = append(parts, entry)
+		}
+	}
+	if len(parts) == 0 {
+		return "No search results found."
+	}
+
+	return "Sources:\n" + strings.Join(parts, "\n")
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/search"
+)
+
+const (
+	// fetchURLTimeout bounds a fetch, including redirects and content extraction.
+	fetchURLTimeout = 30 * time.Second
+	// fetchURLMaxChars caps the page content returned to the model.
+	fetchURLMaxChars = 20_000
+)
+
+// FetchURLTool implements the fetch_url tool: it downloads a web page and returns its main
+// content as markdown. Pages are fetched with search.Service.Fetch, which only connects to
+// public addresses and caps the download size.
+type FetchURLTool struct {
+	searchService *search.Service
+	logger        *logger.Logger
+}
+
+// NewFetchURLTool creates a new URL fetch tool.
+func NewFetchURLTool(searchService *search.Service, logger *logger.Logger) *FetchURLTool {
+	return &FetchURLTool{
+		searchService: searchService,
+		logger:        logger,
+	}
+}
+
+// Name returns the tool name.
+func (t *FetchURLTool) Name() string {
+	return "fetch_url"
+}
+
+// Definition returns the OpenAI-compatible function definition.
+func (t *FetchURLTool) Definition() ToolDefinition {
+	return ToolDefinition{
+		Type: "function",
+		Function: FunctionDef{
+			Name:        "fetch_url",
+			Description: "Fetch a web page and return its main content as markdown. Use it to read a page the user links to, or a search result that needs a closer look.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Absolute http or https URL of the page",
+					},
+				},
+				"required":             []string{"url"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+// FetchURLArgs represents the arguments for a URL fetch.
+type FetchURLArgs struct {
+	URL string `json:"url"`
+}
+
+// Execute fetches the page.
+func (t *FetchURLTool) Execute(ctx context.Context, args string) (string, error) {
+	var fetchArgs FetchURLArgs
+	if err := ParseArguments(args, &fetchArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(fetchArgs.URL) == "" {
+		return "", fmt.Errorf("url is required")
+	}
+
+	t.logger.Info("executing url fetch", "url", fetchArgs.URL)
+
+	ctx, cancel := context.WithTimeout(ctx, fetchURLTimeout)
+	defer cancel()
+
+	resp, err := t.searchService.Fetch(ctx, search.FetchRequest{
+		URL:      fetchArgs.URL,
+		Format:   search.FormatMarkdown,
+		MaxChars: fetchURLMaxChars,
+	})
+	if err != nil {
+		return "", fmt.Errorf("fetch failed: %w", err)
+	}
+
+	return formatFetchResult(resp), nil
+}
+
+// formatFetchResult formats a fetched page for AI consumption.
+func formatFetchResult(resp *search.FetchResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "URL: %s\n", resp.URL)
+	if resp.Title != "" {
+		fmt.Fprintf(&b, "Title: %s\n", resp.Title)
+	}
+	b.WriteString("\n")
+	if resp.Content == "" {
+		b.WriteString("The page has no readable content.")
+	} else {
+		b.WriteString(resp.Content)
+	}
+	if resp.Truncated {
+		fmt.Fprintf(&b, "\n\n[Content truncated at %d characters]", fetchURLMaxChars)
+	}
+	return b.String()
+}
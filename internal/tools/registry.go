@@ -3,22 +3,27 @@ package tools
 import (
 	"fmt"
 	"sync"
+
+	"github.com/eternisai/enchanted-proxy/internal/structuredoutput"
 )
 
 // Registry manages available tools.
 type Registry struct {
-	tools map[string]Tool
-	mu    sync.RWMutex
+	tools   map[string]Tool
+	schemas map[string]*structuredoutput.Schema // Compiled parameter schemas (nil = no parameters)
+	mu      sync.RWMutex
 }
 
 // NewRegistry creates a new tool registry.
 func NewRegistry() *Registry {
 	return &Registry{
-		tools: make(map[string]Tool),
+		tools:   make(map[string]Tool),
+		schemas: make(map[string]*structuredoutput.Schema),
 	}
 }
 
 // Register adds a tool to the registry.
+// Fails if the tool's parameter schema is invalid.
 func (r *Registry) Register(tool Tool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -28,10 +33,29 @@ func (r *Registry) Register(tool Tool) error {
 		return fmt.Errorf("tool %s already registered", name)
 	}
 
+	schema, err := compileParameters(tool.Definition())
+	if err != nil {
+		return fmt.Errorf("invalid parameter schema for tool %s: %w", name, err)
+	}
+
 	r.tools[name] = tool
+	r.schemas[name] = schema
 	return nil
 }
 
+// ValidateArguments checks model-emitted JSON arguments against the parameter schema of a
+// registered tool. Returns an *ArgumentsError for invalid arguments.
+func (r *Registry) ValidateArguments(name, args string) error {
+	r.mu.RLock()
+	schema, exists := r.schemas[name]
+	r.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("tool %s not found", name)
+	}
+	return validateArguments(name, schema, args)
+}
+
 // Get retrieves a tool by name.
 func (r *Registry) Get(name string) (Tool, bool) {
 	r.mu.RLock()
@@ -54,6 +78,22 @@ func (r *Registry) GetDefinitions() []ToolDefinition {
 	return definitions
 }
 
+// GetDefinitionsFor returns OpenAI-compatible tool definitions for the registered tools among
+// names, in the order of names.
+func (r *Registry) GetDefinitionsFor(names []string) []ToolDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	definitions := make([]ToolDefinition, 0, len(names))
+	for _, name := range names {
+		if tool, exists := r.tools[name]; exists {
+			definitions = append(definitions, tool.Definition())
+		}
+	}
+
+	return definitions
+}
+
 // List returns names of all registered tools.
 func (r *Registry) List() []string {
 	r.mu.RLock()
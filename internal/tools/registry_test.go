@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/search"
+)
+
+type stubTool struct {
+	name   string
+	params map[string]interface{}
+}
+
+func (s *stubTool) Name() string { return s.name }
+
+func (s *stubTool) Definition() ToolDefinition {
+	return ToolDefinition{Type: "function", Function: FunctionDef{Name: s.name, Parameters: s.params}}
+}
+
+func (s *stubTool) Execute(ctx context.Context, args string) (string, error) { return "ok", nil }
+
+func TestRegistry_RegisterCompilesSchemas(t *testing.T) {
+	registry := NewRegistry()
+
+	// The built-in tools must have compilable schemas
+	for _, tool := range []Tool{&ExaSearchTool{}, &ScheduledTasksTool{}} {
+		if err := registry.Register(tool); err != nil {
+			t.Fatalf("Register(%s) failed: %v", tool.Name(), err)
+		}
+	}
+
+	invalid := &stubTool{name: "broken", params: map[string]interface{}{"type": "date"}}
+	if err := registry.Register(invalid); err == nil {
+		t.Error("expected error for invalid parameter schema")
+	}
+	if _, exists := registry.Get("broken"); exists {
+		t.Error("tool with invalid schema was registered")
+	}
+}
+
+func TestRegistry_ValidateArguments(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&ExaSearchTool{}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := registry.Register(&stubTool{name: "no_params"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     string
+		wantPath string
+	}{
+		{name: "valid", tool: "web_search", args: `{"queries":["go generics"],"numResults":5}`},
+		{name: "missing required", tool: "web_search", args: `{"numResults":5}`, wantPath: "$"},
+		{name: "empty arguments", tool: "web_search", args: "", wantPath: "$"},
+		{name: "wrong type", tool: "web_search", args: `{"queries":"go generics"}`, wantPath: "$.queries"},
+		{name: "too many items", tool: "web_search", args: `{"queries":["a","b","c","d"]}`, wantPath: "$.queries"},
+		{name: "unknown property", tool: "web_search", args: `{"queries":["a"],"query":"a"}`, wantPath: "$.query"},
+		{name: "not JSON", tool: "web_search", args: `{"queries":["a"]`, wantPath: "$"},
+		{name: "no schema", tool: "no_params", args: `{"anything":1}`},
+		{name: "no schema, not JSON", tool: "no_params", args: `nope`, wantPath: "$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.ValidateArguments(tt.tool, tt.args)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var argsErr *ArgumentsError
+			if !errors.As(err, &argsErr) {
+				t.Fatalf("error = %v, want *ArgumentsError", err)
+			}
+			if argsErr.Path != tt.wantPath || argsErr.Tool != tt.tool {
+				t.Errorf("error = %+v, want tool %s and path %s", argsErr, tt.tool, tt.wantPath)
+			}
+		})
+	}
+
+	if err := registry.ValidateArguments("missing", "{}"); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
+
+func TestArgumentsError_ToolContent(t *testing.T) {
+	err := &ArgumentsError{Tool: "web_search", Path: "$.queries", Reason: "must be array, got string"}
+
+	var content struct {
+		Error   string              `json:"error"`
+		Tool    string              `json:"tool"`
+		Details []map[string]string `json:"details"`
+		Message string              `json:"message"`
+	}
+	if jsonErr := json.Unmarshal([]byte(err.ToolContent()), &content); jsonErr != nil {
+		t.Fatalf("tool content is not JSON: %v", jsonErr)
+	}
+	if content.Error != "invalid_arguments" || content.Tool != "web_search" || len(content.Details) != 1 ||
+		content.Details[0]["path"] != "$.queries" || !strings.Contains(content.Message, "call the tool again") {
+		t.Errorf("unexpected tool content: %+v", content)
+	}
+}
+
+func TestFormatEngineResults(t *testing.T) {
+	responses := []*search.SearchResponse{
+		{OrganicResults: []search.SearchResult{
+			{Title: "Go", Link: "https://go.dev", Snippet: "The Go language"},
+			{Title: "Tour", Link: "https://go.dev/tour"},
+			{Title: "Blog", Link: "https://go.dev/blog"},
+		}},
+		{OrganicResults: []search.SearchResult{
+			{Title: "Go again", Link: "https://go.dev"},
+			{Title: "Wiki", Link: "https://en.wikipedia.org/wiki/Go"},
+		}},
+	}
+
+	got := formatEngineResults(responses, 2)
+	want := "Sources:\n- https://go.dev: Go — The Go language\n- https://go.dev/tour: Tour\n- https://en.wikipedia.org/wiki/Go: Wiki"
+	if got != want {
+		t.Errorf("formatEngineResults() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := formatEngineResults([]*search.SearchResponse{{}}, 5); got != "No search results found." {
+		t.Errorf("formatEngineResults(empty) = %q", got)
+	}
+}
+
+func TestFormatFetchResult(t *testing.T) {
+	got := formatFetchResult(&search.FetchResponse{
+		URL:       "https://go.dev/doc",
+		Title:     "Documentation",
+		Content:   "# Documentation",
+		Truncated: true,
+	})
+	want := "URL: https://go.dev/doc\nTitle: Documentation\n\n# Documentation\n\n[Content truncated at 20000 characters]"
+	if got != want {
+		t.Errorf("formatFetchResult() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFetchURLTool_BlocksInternalAddresses(t *testing.T) {
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{}
+	defer func() { config.AppConfig = previous }()
+
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	tool := NewFetchURLTool(search.NewService(log), log)
+
+	for _, url := range []string{"http://127.0.0.1:8080/admin", "http://169.254.169.254/latest/meta-data", "file:///etc/passwd", "http://localhost/"} {
+		args, _ := json.Marshal(FetchURLArgs{URL: url})
+		if _, err := tool.Execute(context.Background(), string(args)); !errors.Is(err, search.ErrFetchBlocked) {
+			t.Errorf("Execute(%s) error = %v, want ErrFetchBlocked", url, err)
+		}
+	}
+}
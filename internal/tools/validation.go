@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/structuredoutput"
+)
+
+// ArgumentsError is returned for tool call arguments that don't match the tool's parameter
+// schema. It is sent back to the model as the tool result (see ToolContent) so the model can
+// correct the call.
+type ArgumentsError struct {
+	Tool   string
+	Path   string // JSON path of the offending value, e.g. "$.queries[0]"; "$" for the whole input
+	Reason string
+}
+
+func (e *ArgumentsError) Error() string {
+	return fmt.Sprintf("invalid arguments for tool %s: %s: %s", e.Tool, e.Path, e.Reason)
+}
+
+// ToolContent returns the tool result content describing the error to the model.
+func (e *ArgumentsError) ToolContent() string {
+	content, _ := json.Marshal(map[string]interface{}{
+		"error": "invalid_arguments",
+		"tool":  e.Tool,
+		"details": []map[string]string{{
+			"path":   e.Path,
+			"reason": e.Reason,
+		}},
+		"message": "The arguments do not match the tool's parameter schema. Fix them and call the tool again.",
+	})
+	return string(content)
+}
+
+// compileParameters compiles the parameter schema of a tool definition. Definitions are built
+// from Go values, so the schema is normalized through JSON first.
+func compileParameters(def ToolDefinition) (*structuredoutput.Schema, error) {
+	if def.Function.Parameters == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(def.Function.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return structuredoutput.Compile(raw)
+}
+
+// validateArguments checks a tool call's JSON arguments against the compiled parameter schema.
+// Empty arguments are treated as an empty object.
+func validateArguments(tool string, schema *structuredoutput.Schema, args string) error {
+	if strings.TrimSpace(args) == "" {
+		args = "{}"
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(args), &value); err != nil {
+		return &ArgumentsError{Tool: tool, Path: "$", Reason: fmt.Sprintf("arguments are not valid JSON: %v", err)}
+	}
+	if schema == nil {
+		return nil
+	}
+
+	if err := schema.Validate(value); err != nil {
+		var validationErr *structuredoutput.ValidationError
+		if stderrors.As(err, &validationErr) {
+			return &ArgumentsError{Tool: tool, Path: validationErr.Path, Reason: validationErr.Reason}
+		}
+		return &ArgumentsError{Tool: tool, Path: "$", Reason: err.Error()}
+	}
+	return nil
+}
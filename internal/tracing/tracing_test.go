@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestRequestTrace(t *testing.T) {
+	if _, err := Init(context.Background(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	var queued trace.SpanContext
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.POST("/chat/completions", func(c *gin.Context) {
+		req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, upstream.URL, nil)
+		resp, err := (&http.Client{Transport: Transport(http.DefaultTransport)}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		queued = trace.SpanContextFromContext(c.Request.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/chat/completions", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Work queued by the request continues its trace after it ended
+	_, span := StartChild(context.Background(), queued, "messaging.store_message")
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want client, server and queued work", len(spans))
+	}
+	client, server, child := spans[0], spans[1], spans[2]
+	if server.Name() != "POST /chat/completions" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("server span = %s (%s)", server.Name(), server.SpanKind())
+	}
+	if got := server.SpanContext().TraceID().String(); got != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("server span trace = %s, want the caller's", got)
+	}
+	if client.Parent().SpanID() != server.SpanContext().SpanID() || child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("client and queued spans are not children of the server span")
+	}
+	if want := "00-0af7651916cd43dd8448eb211c80319c-" + client.SpanContext().SpanID().String() + "-01"; upstreamTraceparent != want {
+		t.Errorf("upstream traceparent = %q, want %q", upstreamTraceparent, want)
+	}
+}
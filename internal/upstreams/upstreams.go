@@ -0,0 +1,356 @@
+// Package upstreams maintains the allowlist of upstream base URLs the model router may send
+// requests to. Records live in Postgres (upstream_providers) and are administered via the
+// admin API, so inference endpoints can be allowed, disabled or re-keyed without a redeploy.
+//
+// Each instance refreshes the allowlist periodically (through the shared cache) and rebuilds
+// its routes when it changes. An endpoint of config.yaml is routed only if its base URL has an
+// enabled record. All configured endpoints are allowed until the first record is loaded (a new
+// deployment, or a failed initial load); after that an empty table allows nothing, and the last
+// record can't be deleted (disable it instead).
+package upstreams
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// allowlistKey is the cache key of the upstream records.
+const allowlistKey = "all"
+
+var (
+	ErrNotFound            = stderrors.New("upstream not found")
+	ErrDuplicate           = stderrors.New("an upstream with this base URL already exists")
+	ErrInvalidBaseURL      = stderrors.New("base_url must be an http(s) URL with a host")
+	ErrInvalidKeyReference = stderrors.New("api_key_env_var must be an environment variable name (A-Z, 0-9, _)")
+	ErrLastUpstream        = stderrors.New("the last upstream can't be deleted, disable it instead")
+)
+
+var envVarName = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// Upstream is an upstream base URL record.
+type Upstream struct {
+	ID           int64     `json:"id"`
+	BaseURL      string    `json:"base_url"`
+	APIKeyEnvVar string    `json:"api_key_env_var"` // Empty = the key configured for the provider
+	Enabled      bool      `json:"enabled"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// KeyConfigured reports whether the referenced API key is set on this instance.
+func (u *Upstream) KeyConfigured() bool {
+	return u.APIKeyEnvVar != "" && os.Getenv(u.APIKeyEnvVar) != ""
+}
+
+// CreateRequest is the request body for creating an upstream.
+type CreateRequest struct {
+	BaseURL      string `json:"base_url" binding:"required"`
+	APIKeyEnvVar string `json:"api_key_env_var"`
+	Enabled      *bool  `json:"enabled"` // Default true
+	Description  string `json:"description"`
+}
+
+// UpdateRequest is the request body for updating an upstream. Nil fields are unchanged; the
+// base URL can't be changed (delete and recreate instead).
+type UpdateRequest struct {
+	APIKeyEnvVar *string `json:"api_key_env_var"`
+	Enabled      *bool   `json:"enabled"`
+	Description  *string `json:"description"`
+}
+
+// Service serves the upstream allowlist to the model router.
+type Service struct {
+	queries  pgdb.Querier
+	cache    *cache.Cache
+	router   *routing.ModelRouter
+	interval time.Duration
+	logger   *logger.Logger
+
+	mu          sync.RWMutex
+	allowlist   map[string]Upstream // By normalized base URL; nil = allow all
+	fingerprint string
+	enforced    bool // A record was loaded: an empty table no longer allows all
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewService creates the upstream service. The allowlist is refreshed every interval (<= 0 =
+// loaded once at Start); router may be nil (no routes to rebuild, e.g. in tools).
+func NewService(queries pgdb.Querier, sharedCache *cache.Cache, router *routing.ModelRouter, interval time.Duration, logger *logger.Logger) *Service {
+	return &Service{
+		queries:  queries,
+		cache:    sharedCache.Namespace("upstreams", interval),
+		router:   router,
+		interval: interval,
+		logger:   logger,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start installs the service as the router's upstream policy, loads the allowlist and starts
+// the periodic refresh. A failed initial load leaves all configured endpoints allowed until the
+// next successful refresh.
+func (s *Service) Start(ctx context.Context) {
+	if s.router != nil {
+		s.router.SetUpstreamPolicy(s)
+	}
+	if err := s.Refresh(ctx); err != nil {
+		s.logger.Error("failed to load upstream allowlist, allowing all configured endpoints",
+			slog.String("error", err.Error()))
+	}
+	if s.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				refreshCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				if err := s.Refresh(refreshCtx); err != nil {
+					s.logger.Warn("failed to refresh upstream allowlist, keeping the previous one",
+						slog.String("error", err.Error()))
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic refresh.
+func (s *Service) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Resolve implements routing.UpstreamPolicy.
+func (s *Service) Resolve(baseURL string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.allowlist == nil {
+		return "", true
+	}
+	upstream, ok := s.allowlist[normalizeBaseURL(baseURL)]
+	if !ok || !upstream.Enabled {
+		return "", false
+	}
+	if upstream.APIKeyEnvVar == "" {
+		return "", true
+	}
+	return os.Getenv(upstream.APIKeyEnvVar), true
+}
+
+// Refresh reloads the allowlist and rebuilds the routes if it changed.
+func (s *Service) Refresh(ctx context.Context) error {
+	upstreams, err := cache.Fetch(ctx, s.cache, allowlistKey, s.List)
+	if err != nil {
+		return err
+	}
+
+	var allowlist map[string]Upstream
+	if len(upstreams) > 0 {
+		allowlist = make(map[string]Upstream, len(upstreams))
+		for _, upstream := range upstreams {
+			allowlist[normalizeBaseURL(upstream.BaseURL)] = upstream
+		}
+	}
+	fingerprint := allowlistFingerprint(upstreams)
+
+	s.mu.Lock()
+	if len(upstreams) > 0 {
+		s.enforced = true
+	} else if s.enforced {
+		allowlist = map[string]Upstream{}
+	}
+	changed := fingerprint != s.fingerprint
+	s.allowlist = allowlist
+	s.fingerprint = fingerprint
+	s.mu.Unlock()
+
+	if changed && s.router != nil {
+		s.router.Rebuild()
+		s.logger.Info("upstream allowlist applied",
+			slog.Int("upstreams", len(upstreams)),
+			slog.Int("route_count", len(s.router.GetRoutes())))
+	}
+	return nil
+}
+
+// List returns all upstream records from the database.
+func (s *Service) List(ctx context.Context) ([]Upstream, error) {
+	rows, err := s.queries.ListUpstreamProviders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstreams: %w", err)
+	}
+	upstreams := make([]Upstream, 0, len(rows))
+	for _, row := range rows {
+		upstreams = append(upstreams, toUpstream(row))
+	}
+	return upstreams, nil
+}
+
+// Create adds an upstream and applies the change on this instance (other instances pick it up
+// on their next refresh).
+func (s *Service) Create(ctx context.Context, req *CreateRequest) (*Upstream, error) {
+	baseURL := normalizeBaseURL(req.BaseURL)
+	if err := validateBaseURL(baseURL); err != nil {
+		return nil, err
+	}
+	keyRef := strings.TrimSpace(req.APIKeyEnvVar)
+	if keyRef != "" && !envVarName.MatchString(keyRef) {
+		return nil, ErrInvalidKeyReference
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	row, err := s.queries.CreateUpstreamProvider(ctx, pgdb.CreateUpstreamProviderParams{
+		BaseUrl:      baseURL,
+		ApiKeyEnvVar: keyRef,
+		Enabled:      enabled,
+		Description:  strings.TrimSpace(req.Description),
+	})
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upstream: %w", err)
+	}
+
+	s.applyChange(ctx)
+	upstream := toUpstream(row)
+	return &upstream, nil
+}
+
+// Update changes an upstream's key reference, enabled flag or description.
+func (s *Service) Update(ctx context.Context, id int64, req *UpdateRequest) (*Upstream, error) {
+	current, err := s.queries.GetUpstreamProvider(ctx, id)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upstream: %w", err)
+	}
+
+	params := pgdb.UpdateUpstreamProviderParams{
+		ID:           id,
+		ApiKeyEnvVar: current.ApiKeyEnvVar,
+		Enabled:      current.Enabled,
+		Description:  current.Description,
+	}
+	if req.APIKeyEnvVar != nil {
+		keyRef := strings.TrimSpace(*req.APIKeyEnvVar)
+		if keyRef != "" && !envVarName.MatchString(keyRef) {
+			return nil, ErrInvalidKeyReference
+		}
+		params.ApiKeyEnvVar = keyRef
+	}
+	if req.Enabled != nil {
+		params.Enabled = *req.Enabled
+	}
+	if req.Description != nil {
+		params.Description = strings.TrimSpace(*req.Description)
+	}
+
+	row, err := s.queries.UpdateUpstreamProvider(ctx, params)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update upstream: %w", err)
+	}
+
+	s.applyChange(ctx)
+	upstream := toUpstream(row)
+	return &upstream, nil
+}
+
+// Delete removes an upstream. Endpoints with its base URL are no longer routed. Returns
+// ErrLastUpstream for the last record.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	upstreams, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(upstreams) == 1 && upstreams[0].ID == id {
+		return ErrLastUpstream
+	}
+
+	_, err = s.queries.DeleteUpstreamProvider(ctx, id)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete upstream: %w", err)
+	}
+
+	s.applyChange(ctx)
+	return nil
+}
+
+// applyChange invalidates the cached allowlist and refreshes this instance.
+func (s *Service) applyChange(ctx context.Context) {
+	if err := s.cache.Delete(ctx, allowlistKey); err != nil {
+		s.logger.Warn("failed to invalidate cached upstream allowlist", slog.String("error", err.Error()))
+	}
+	if err := s.Refresh(ctx); err != nil {
+		s.logger.Warn("failed to refresh upstream allowlist", slog.String("error", err.Error()))
+	}
+}
+
+func toUpstream(row pgdb.UpstreamProvider) Upstream {
+	return Upstream{
+		ID:           row.ID,
+		BaseURL:      row.BaseUrl,
+		APIKeyEnvVar: row.ApiKeyEnvVar,
+		Enabled:      row.Enabled,
+		Description:  row.Description,
+		CreatedAt:    row.CreatedAt,
+		UpdatedAt:    row.UpdatedAt,
+	}
+}
+
+// normalizeBaseURL makes base URLs comparable ("https://host/v1/" matches "https://host/v1").
+func normalizeBaseURL(baseURL string) string {
+	return strings.TrimRight(strings.TrimSpace(baseURL), "/")
+}
+
+func validateBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return ErrInvalidBaseURL
+	}
+	return nil
+}
+
+// allowlistFingerprint identifies the routing-relevant state of the records.
+func allowlistFingerprint(upstreams []Upstream) string {
+	entries := make([]string, 0, len(upstreams))
+	for _, upstream := range upstreams {
+		entries = append(entries, fmt.Sprintf("%s|%s|%t", normalizeBaseURL(upstream.BaseURL), upstream.APIKeyEnvVar, upstream.Enabled))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\n")
+}
@@ -0,0 +1,209 @@
+package upstreams
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/cache"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	pgdb "github.com/eternisai/enchanted-proxy/internal/storage/pg/sqlc"
+)
+
+// upstreamStore keeps upstream records in memory; other Querier methods are not used.
+type upstreamStore struct {
+	pgdb.Querier
+	rows   []pgdb.UpstreamProvider
+	nextID int64
+	lists  int
+}
+
+func (s *upstreamStore) ListUpstreamProviders(context.Context) ([]pgdb.UpstreamProvider, error) {
+	s.lists++
+	return append([]pgdb.UpstreamProvider(nil), s.rows...), nil
+}
+
+func (s *upstreamStore) GetUpstreamProvider(_ context.Context, id int64) (pgdb.UpstreamProvider, error) {
+	for _, row := range s.rows {
+		if row.ID == id {
+			return row, nil
+		}
+	}
+	return pgdb.UpstreamProvider{}, sql.ErrNoRows
+}
+
+func (s *upstreamStore) CreateUpstreamProvider(_ context.Context, arg pgdb.CreateUpstreamProviderParams) (pgdb.UpstreamProvider, error) {
+	for _, row := range s.rows {
+		if row.BaseUrl == arg.BaseUrl {
+			return pgdb.UpstreamProvider{}, sql.ErrNoRows
+		}
+	}
+	s.nextID++
+	row := pgdb.UpstreamProvider{ID: s.nextID, BaseUrl: arg.BaseUrl, ApiKeyEnvVar: arg.ApiKeyEnvVar, Enabled: arg.Enabled, Description: arg.Description}
+	s.rows = append(s.rows, row)
+	return row, nil
+}
+
+func (s *upstreamStore) UpdateUpstreamProvider(_ context.Context, arg pgdb.UpdateUpstreamProviderParams) (pgdb.UpstreamProvider, error) {
+	for i, row := range s.rows {
+		if row.ID == arg.ID {
+			row.ApiKeyEnvVar, row.Enabled, row.Description = arg.ApiKeyEnvVar, arg.Enabled, arg.Description
+			s.rows[i] = row
+			return row, nil
+		}
+	}
+	return pgdb.UpstreamProvider{}, sql.ErrNoRows
+}
+
+func (s *upstreamStore) DeleteUpstreamProvider(_ context.Context, id int64) (int64, error) {
+	for i, row := range s.rows {
+		if row.ID == id {
+			s.rows = append(s.rows[:i], s.rows[i+1:]...)
+			return id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func newTestService(store *upstreamStore) *Service {
+	log := logger.New(logger.Config{Level: slog.LevelError})
+	return NewService(store, cache.New(cache.NewMemory(0), log), nil, time.Minute, log)
+}
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TEST_UPSTREAM_KEY", "sk-upstream")
+	store := &upstreamStore{}
+	s := newTestService(store)
+	s.Start(ctx)
+	defer s.Stop()
+
+	// No records: everything configured is allowed
+	if _, allowed := s.Resolve("https://unknown.example/v1"); !allowed {
+		t.Error("all base URLs should be allowed while there are no records")
+	}
+
+	created, err := s.Create(ctx, &CreateRequest{BaseURL: "https://api.example.com/v1/", APIKeyEnvVar: "TEST_UPSTREAM_KEY"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.BaseURL != "https://api.example.com/v1" || !created.Enabled {
+		t.Errorf("unexpected upstream: %+v", created)
+	}
+
+	tests := []struct {
+		baseURL     string
+		wantKey     string
+		wantAllowed bool
+	}{
+		{"https://api.example.com/v1", "sk-upstream", true},
+		{"https://api.example.com/v1/", "sk-upstream", true},
+		{"https://unknown.example/v1", "", false},
+	}
+	for _, tt := range tests {
+		key, allowed := s.Resolve(tt.baseURL)
+		if key != tt.wantKey || allowed != tt.wantAllowed {
+			t.Errorf("Resolve(%q) = %q, %v; want %q, %v", tt.baseURL, key, allowed, tt.wantKey, tt.wantAllowed)
+		}
+	}
+
+	disabled := false
+	if _, err := s.Update(ctx, created.ID, &UpdateRequest{Enabled: &disabled}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, allowed := s.Resolve("https://api.example.com/v1"); allowed {
+		t.Error("disabled upstream should not be allowed")
+	}
+}
+
+func TestEmptyTableAfterLoadAllowsNothing(t *testing.T) {
+	ctx := context.Background()
+	store := &upstreamStore{}
+	s := newTestService(store)
+	s.Start(ctx)
+	defer s.Stop()
+
+	first, err := s.Create(ctx, &CreateRequest{BaseURL: "https://api.example.com/v1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	second, err := s.Create(ctx, &CreateRequest{BaseURL: "https://other.example.com/v1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if err := s.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := s.Delete(ctx, second.ID); !stderrors.Is(err, ErrLastUpstream) {
+		t.Errorf("Delete of the last upstream error = %v, want ErrLastUpstream", err)
+	}
+
+	// Records removed outside the admin API don't reopen the allowlist
+	store.rows = nil
+	s.applyChange(ctx)
+	if _, allowed := s.Resolve("https://unknown.example/v1"); allowed {
+		t.Error("an empty table should allow nothing once records were loaded")
+	}
+}
+
+func TestRefreshUsesCache(t *testing.T) {
+	ctx := context.Background()
+	store := &upstreamStore{}
+	s := newTestService(store)
+
+	for i := 0; i < 3; i++ {
+		if err := s.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+	}
+	if store.lists != 1 {
+		t.Errorf("database lists = %d, want 1", store.lists)
+	}
+
+	// Changes invalidate the cached records
+	if _, err := s.Create(ctx, &CreateRequest{BaseURL: "https://api.example.com/v1"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if store.lists != 2 {
+		t.Errorf("database lists = %d, want 2", store.lists)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(&upstreamStore{})
+
+	tests := []struct {
+		name string
+		req  CreateRequest
+		want error
+	}{
+		{"no scheme", CreateRequest{BaseURL: "api.example.com/v1"}, ErrInvalidBaseURL},
+		{"unsupported scheme", CreateRequest{BaseURL: "ftp://api.example.com"}, ErrInvalidBaseURL},
+		{"bad key reference", CreateRequest{BaseURL: "https://api.example.com", APIKeyEnvVar: "sk-live-123"}, ErrInvalidKeyReference},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Create(ctx, &tt.req); !stderrors.Is(err, tt.want) {
+				t.Errorf("Create error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.Create(ctx, &CreateRequest{BaseURL: "https://api.example.com"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := s.Create(ctx, &CreateRequest{BaseURL: "https://api.example.com/"}); !stderrors.Is(err, ErrDuplicate) {
+		t.Errorf("Create error = %v, want ErrDuplicate", err)
+	}
+	if _, err := s.Update(ctx, 99, &UpdateRequest{}); !stderrors.Is(err, ErrNotFound) {
+		t.Errorf("Update error = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, 99); !stderrors.Is(err, ErrNotFound) {
+		t.Errorf("Delete error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,149 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/audiousage"
+)
+
+const (
+	defaultAudioBaseURL = "https://api.openai.com/v1"
+
+	// audioRequestTimeout bounds transcription. Speech responses are streamed and bounded by the
+	// request context instead.
+	audioRequestTimeout = 60 * time.Second
+)
+
+// AudioClient calls the OpenAI speech-to-text and text-to-speech APIs.
+type AudioClient struct {
+	httpClient         *http.Client
+	baseURL            string
+	apiKey             string
+	transcriptionModel string
+	speechModel        string
+}
+
+// NewAudioClient creates an audio client. baseURL may be empty for the OpenAI API.
+func NewAudioClient(baseURL, apiKey, transcriptionModel, speechModel string) *AudioClient {
+	if baseURL == "" {
+		baseURL = defaultAudioBaseURL
+	}
+	return &AudioClient{
+		httpClient:         &http.Client{},
+		baseURL:            baseURL,
+		apiKey:             apiKey,
+		transcriptionModel: transcriptionModel,
+		speechModel:        speechModel,
+	}
+}
+
+// Transcription is the result of a transcription call.
+type Transcription struct {
+	Text    string
+	Tokens  int     // Total tokens reported by token-billed models (0 if not reported)
+	Seconds float64 // Duration reported by duration-billed models (0 if not reported)
+}
+
+// Transcribe converts an audio clip to text.
+func (a *AudioClient) Transcribe(ctx context.Context, filename string, audio []byte) (*Transcription, error) {
+	ctx, cancel := context.WithTimeout(ctx, audioRequestTimeout)
+	defer cancel()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("model", a.transcriptionModel); err != nil {
+		return nil, fmt.Errorf("write model field: %w", err)
+	}
+	if err := form.WriteField("response_format", "json"); err != nil {
+		return nil, fmt.Errorf("write format field: %w", err)
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("create file part: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return nil, fmt.Errorf("write file part: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("close form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call transcription API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("transcription API returned %d: %s", resp.StatusCode, string(detail))
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read transcription response: %w", err)
+	}
+
+	var result struct {
+		Text  string `json:"text"`
+		Usage *struct {
+			Type        string `json:"type"`
+			TotalTokens int    `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("decode transcription response: %w", err)
+	}
+
+	transcription := &Transcription{Text: result.Text}
+	if result.Usage != nil && result.Usage.Type == "tokens" {
+		transcription.Tokens = result.Usage.TotalTokens
+	}
+	transcription.Seconds, _ = audiousage.TranscriptionSeconds(respBody)
+	return transcription, nil
+}
+
+// Speak synthesizes text to speech. The caller must close the returned stream.
+func (a *AudioClient) Speak(ctx context.Context, text, voice, format string) (io.ReadCloser, error) {
+	payload, err := json.Marshal(map[string]string{
+		"model":           a.speechModel,
+		"input":           text,
+		"voice":           voice,
+		"response_format": format,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/audio/speech", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call speech API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("speech API returned %d: %s", resp.StatusCode, string(detail))
+	}
+	return resp.Body, nil
+}
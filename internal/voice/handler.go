@@ -0,0 +1,169 @@
+package voice
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/eternisai/enchanted-proxy/internal/auth"
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/tiers"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles HTTP requests for voice conversations.
+type Handler struct {
+	service *Service
+	logger  *logger.Logger
+}
+
+// NewHandler creates a new voice handler.
+func NewHandler(service *Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Turn handles POST /api/v1/voice/turn (and /api/v2/voice/turn)
+// Accepts a multipart form with an audio "file" and optional "model", "voice", "format" and
+// "messages" (JSON array of prior {role, content} turns). Streams SSE events: transcript,
+// response, audio chunks, usage, then done (or error).
+func (h *Handler) Turn(c *gin.Context) {
+	log := h.logger.WithContext(c.Request.Context()).WithComponent("voice-handler")
+
+	userID, ok := auth.GetUserID(c)
+	if !ok {
+		errors.Unauthorized(c, "unauthorized", nil)
+		return
+	}
+
+	req, err := parseTurnRequest(c)
+	if err != nil {
+		errors.BadRequest(c, "invalid request body", map[string]interface{}{"details": err.Error()})
+		return
+	}
+
+	platform := c.GetHeader("X-Client-Platform")
+	if platform == "" {
+		platform = "mobile"
+	}
+
+	turn, err := h.service.Prepare(c.Request.Context(), userID, platform, req)
+	if err != nil {
+		errors.BadRequest(c, err.Error(), nil)
+		return
+	}
+	turn.Endpoint = c.Request.URL.Path
+
+	// Tier model access (set by the request tracking middleware when rate limiting is enabled)
+	if val, exists := c.Get("tierConfig"); exists {
+		if tierConfig, ok := val.(tiers.Config); ok && !tierConfig.IsModelAllowed(turn.Model) {
+			errors.AbortWithForbidden(c, errors.ModelNotAllowed(turn.Model, tierConfig.Name, tierConfig.DisplayName, tierConfig.AllowedModels))
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		errors.Internal(c, "Streaming not supported", nil)
+		return
+	}
+
+	emit := func(event string, data interface{}) error {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if _, err := c.Writer.WriteString("event: " + event + "\ndata: " + string(payload) + "\n\n"); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	usage, err := h.service.Run(c.Request.Context(), turn, emit)
+	if err != nil {
+		log.Error("voice turn failed",
+			slog.String("error", err.Error()),
+			slog.String("user_id", userID),
+			slog.String("model", turn.Model))
+
+		event := ErrorEvent{Message: "Voice turn failed"}
+		switch {
+		case stderrors.Is(err, ErrEmptyTranscript):
+			event.Message = err.Error()
+		case stderrors.Is(err, ErrContentPolicyViolation):
+			event.Message = "This message isn't allowed with the content settings on this account."
+			event.Reason = string(errors.ReasonContentPolicyViolation)
+		}
+		_ = emit(EventError, event)
+		return
+	}
+
+	log.Info("voice turn completed",
+		slog.String("user_id", userID),
+		slog.String("model", turn.Model),
+		slog.Int("plan_tokens", usage.PlanTokens))
+
+	_ = emit(EventDone, struct{}{})
+}
+
+// LimitRequestBody caps the request body size. Registered ahead of the request tracking
+// middleware, which buffers the body.
+func LimitRequestBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBytes)
+		c.Next()
+	}
+}
+
+// parseTurnRequest reads the multipart voice turn form.
+func parseTurnRequest(c *gin.Context) (*TurnRequest, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	if fileHeader.Size > MaxAudioBytes {
+		return nil, stderrors.New("audio file exceeds 25MB")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	audio, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &TurnRequest{
+		Audio:    audio,
+		Filename: fileHeader.Filename,
+		Model:    c.PostForm("model"),
+		Voice:    c.PostForm("voice"),
+		Format:   c.PostForm("format"),
+	}
+
+	if history := c.PostForm("messages"); history != "" {
+		if err := json.Unmarshal([]byte(history), &req.History); err != nil {
+			return nil, stderrors.New("messages must be a JSON array of {role, content}")
+		}
+		for _, m := range req.History {
+			if m.Role != "user" && m.Role != "assistant" {
+				return nil, stderrors.New("messages roles must be user or assistant")
+			}
+		}
+	}
+
+	return req, nil
+}
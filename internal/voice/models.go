@@ -0,0 +1,99 @@
+package voice
+
+import "errors"
+
+const (
+	// MaxAudioBytes caps the uploaded clip (the OpenAI transcription API limit).
+	MaxAudioBytes = 25 << 20
+
+	// maxRequestBytes leaves room for the other form fields.
+	maxRequestBytes = MaxAudioBytes + 1<<20
+
+	// MaxHistoryMessages caps the prior conversation turns a client can send.
+	MaxHistoryMessages = 50
+
+	// DefaultFormat is the audio format returned when the client doesn't pick one.
+	DefaultFormat = "mp3"
+)
+
+// SSE event names sent by POST /api/v1/voice/turn, in order.
+const (
+	EventTranscript = "transcript" // TranscriptEvent: what the user said
+	EventResponse   = "response"   // ResponseEvent: the assistant's reply text
+	EventAudio      = "audio"      // AudioEvent: a chunk of the spoken reply
+	EventUsage      = "usage"      // Usage: combined usage for the turn
+	EventError      = "error"      // ErrorEvent: the turn failed; no further events follow
+	EventDone       = "done"       // Empty: the turn completed
+)
+
+var (
+	ErrModelRequired    = errors.New("model is required (set one in the request or a default model in preferences)")
+	ErrUnsupportedModel = errors.New("model does not support voice conversations")
+	ErrInvalidFormat    = errors.New("format must be one of: mp3, opus, aac, flac, wav, pcm")
+	ErrEmptyTranscript  = errors.New("no speech detected in audio")
+)
+
+// validFormats are the audio formats supported by the speech API.
+var validFormats = map[string]bool{
+	"mp3": true, "opus": true, "aac": true, "flac": true, "wav": true, "pcm": true,
+}
+
+// HistoryMessage is a prior turn of the conversation.
+type HistoryMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// TurnRequest is a parsed POST /api/v1/voice/turn request.
+type TurnRequest struct {
+	Audio    []byte
+	Filename string
+	Model    string           // Optional: defaults to the user's preferred model
+	Voice    string           // Optional: defaults to VOICE_SPEECH_VOICE
+	Format   string           // Optional: defaults to mp3
+	History  []HistoryMessage // Optional: prior turns, oldest first
+}
+
+// TranscriptEvent is the payload of the transcript event.
+type TranscriptEvent struct {
+	Text string `json:"text"`
+}
+
+// ResponseEvent is the payload of the response event.
+type ResponseEvent struct {
+	Text  string `json:"text"`
+	Model string `json:"model"`
+}
+
+// AudioEvent is the payload of an audio event. Chunks concatenate to one audio file.
+type AudioEvent struct {
+	Index  int    `json:"index"`
+	Format string `json:"format"`
+	Data   string `json:"data"` // Base64
+}
+
+// ErrorEvent is the payload of the error event.
+type ErrorEvent struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Usage is the combined usage of a turn, logged as a single request log entry.
+type Usage struct {
+	TranscriptionTokens int     `json:"transcriptionTokens"`
+	PromptTokens        int     `json:"promptTokens"`
+	CompletionTokens    int     `json:"completionTokens"`
+	SpeechCharacters    int     `json:"speechCharacters"`
+	AudioSeconds        float64 `json:"audioSeconds"` // Transcribed plus synthesized
+	PlanTokens          int     `json:"planTokens"`
+}
+
+// speechTokens converts synthesized characters to token-equivalents (~4 characters per token).
+func speechTokens(characters int) int {
+	return (characters + 3) / 4
+}
+
+// TotalTokens returns the raw token-equivalents across all three stages.
+func (u *Usage) TotalTokens() int {
+	return u.TranscriptionTokens + u.PromptTokens + u.CompletionTokens + speechTokens(u.SpeechCharacters)
+}
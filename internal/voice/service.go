@@ -0,0 +1,375 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/audiousage"
+	"github.com/eternisai/enchanted-proxy/internal/config"
+	"github.com/eternisai/enchanted-proxy/internal/contentpolicy"
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+	"github.com/eternisai/enchanted-proxy/internal/preferences"
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/request_tracking"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+const (
+	// chatRequestTimeout bounds the chat completion stage.
+	chatRequestTimeout = 2 * time.Minute
+
+	// audioChunkSize is the size of each audio event before base64 encoding.
+	audioChunkSize = 32 << 10
+
+	// voiceInstructions keeps replies suitable for speech synthesis.
+	voiceInstructions = "Your reply will be read aloud by a text-to-speech voice. Answer conversationally and concisely, " +
+		"in plain sentences. Do not use markdown, lists, tables, code blocks, emojis, or URLs."
+)
+
+// ErrContentPolicyViolation is returned when the transcript is blocked by the account's content policy.
+var ErrContentPolicyViolation = errors.New("message blocked by content policy")
+
+// ErrPrivacyMode is returned for privacy mode requests: transcription and speech synthesis
+// use OpenAI, which is not a zero-retention provider.
+var ErrPrivacyMode = errors.New("voice turns are not available in privacy mode")
+
+// Service orchestrates voice turns: transcription, chat completion and speech synthesis.
+type Service struct {
+	audio           *AudioClient
+	modelRouter     *routing.ModelRouter
+	preferences     *preferences.Service
+	moderator       *contentpolicy.Moderator
+	trackingService *request_tracking.Service
+	defaultVoice    string
+	audioRate       int // Plan tokens per audio minute
+	chatClient      *http.Client
+	logger          *logger.Logger
+}
+
+// NewService creates a voice service.
+// preferencesService, moderator and trackingService may be nil.
+func NewService(
+	audio *AudioClient,
+	modelRouter *routing.ModelRouter,
+	preferencesService *preferences.Service,
+	moderator *contentpolicy.Moderator,
+	trackingService *request_tracking.Service,
+	defaultVoice string,
+	audioTokensPerMinute int,
+	logger *logger.Logger,
+) *Service {
+	return &Service{
+		audio:           audio,
+		modelRouter:     modelRouter,
+		preferences:     preferencesService,
+		moderator:       moderator,
+		trackingService: trackingService,
+		defaultVoice:    defaultVoice,
+		audioRate:       audioTokensPerMinute,
+		chatClient:      &http.Client{Timeout: chatRequestTimeout},
+		logger:          logger,
+	}
+}
+
+// Turn is a validated voice turn, ready to run.
+type Turn struct {
+	UserID   string
+	Model    string // Canonical model name
+	Endpoint string // Request log endpoint: the request path (/api/v1/voice/turn, /api/v2/voice/turn)
+	request  *TurnRequest
+	provider *routing.ProviderConfig
+	prefs    *preferences.Preferences
+}
+
+// Prepare resolves the chat model and voice settings. Errors are client errors, returned
+// before any response is streamed.
+func (s *Service) Prepare(ctx context.Context, userID, platform string, req *TurnRequest) (*Turn, error) {
+	if privacy.FromContext(ctx) {
+		return nil, ErrPrivacyMode
+	}
+
+	prefs := &preferences.Preferences{}
+	if s.preferences != nil {
+		loaded, err := s.preferences.GetCached(ctx, userID)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to load user preferences for voice turn",
+				slog.String("error", err.Error()),
+				slog.String("user_id", userID))
+		} else {
+			prefs = loaded
+		}
+	}
+
+	model := strings.TrimSpace(req.Model)
+	if model == "" && prefs.DefaultModel != nil {
+		model = *prefs.DefaultModel
+	}
+	if model == "" {
+		return nil, ErrModelRequired
+	}
+
+	provider, err := s.modelRouter.RouteModel(model, platform)
+	if err != nil {
+		return nil, fmt.Errorf("no provider configured for model %s: %w", model, err)
+	}
+	if provider.APIType != config.APITypeChatCompletions {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedModel, model)
+	}
+
+	if req.Format == "" {
+		req.Format = DefaultFormat
+	}
+	if !validFormats[req.Format] {
+		return nil, ErrInvalidFormat
+	}
+	if req.Voice == "" {
+		req.Voice = s.defaultVoice
+	}
+	if len(req.History) > MaxHistoryMessages {
+		req.History = req.History[len(req.History)-MaxHistoryMessages:]
+	}
+
+	return &Turn{
+		UserID:   userID,
+		Model:    s.modelRouter.ResolveAlias(model),
+		request:  req,
+		provider: provider,
+		prefs:    prefs,
+	}, nil
+}
+
+// Run executes the turn, calling emit for each SSE event (transcript, response, audio chunks,
+// usage). Usage of the completed stages is logged as one request log entry, even on failure.
+func (s *Service) Run(ctx context.Context, turn *Turn, emit func(event string, data interface{}) error) (*Usage, error) {
+	log := s.logger.WithContext(ctx)
+	usage := &Usage{}
+	defer s.logUsage(ctx, turn, usage)
+
+	// 1. Transcribe
+	transcription, err := s.audio.Transcribe(ctx, turn.request.Filename, turn.request.Audio)
+	if err != nil {
+		return usage, fmt.Errorf("transcription failed: %w", err)
+	}
+	usage.TranscriptionTokens = transcription.Tokens
+	usage.AudioSeconds = transcription.Seconds
+	if usage.AudioSeconds == 0 {
+		usage.AudioSeconds = audiousage.ForClip(turn.request.Audio).Seconds
+	}
+	transcript := strings.TrimSpace(transcription.Text)
+	if transcript == "" {
+		return usage, ErrEmptyTranscript
+	}
+	if err := emit(EventTranscript, TranscriptEvent{Text: transcript}); err != nil {
+		return usage, err
+	}
+
+	if s.moderator != nil {
+		violations, err := s.moderator.Check(ctx, turn.prefs.ContentPolicy, transcript)
+		contentpolicy.RecordCheck(turn.prefs.ContentPolicy, violations, err)
+		if err != nil {
+			log.Warn("content moderation failed", slog.String("error", err.Error()), slog.String("user_id", turn.UserID))
+		} else if len(violations) > 0 {
+			return usage, ErrContentPolicyViolation
+		}
+	}
+
+	// 2. Chat completion
+	reply, err := s.complete(ctx, turn, transcript, usage)
+	if err != nil {
+		return usage, fmt.Errorf("chat completion failed: %w", err)
+	}
+	if err := emit(EventResponse, ResponseEvent{Text: reply, Model: turn.Model}); err != nil {
+		return usage, err
+	}
+
+	// 3. Speech
+	speech := audiousage.ForSpeech(reply)
+	usage.SpeechCharacters = speech.Characters
+	usage.AudioSeconds += speech.Seconds
+	audio, err := s.audio.Speak(ctx, reply, turn.request.Voice, turn.request.Format)
+	if err != nil {
+		return usage, fmt.Errorf("speech synthesis failed: %w", err)
+	}
+	defer audio.Close()
+
+	buf := make([]byte, audioChunkSize)
+	for index := 0; ; index++ {
+		n, readErr := io.ReadFull(audio, buf)
+		if n > 0 {
+			event := AudioEvent{Index: index, Format: turn.request.Format, Data: base64.StdEncoding.EncodeToString(buf[:n])}
+			if err := emit(EventAudio, event); err != nil {
+				return usage, err
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return usage, fmt.Errorf("read speech audio: %w", readErr)
+		}
+	}
+
+	usage.PlanTokens = s.planTokens(turn, usage)
+	if err := emit(EventUsage, usage); err != nil {
+		return usage, err
+	}
+	return usage, nil
+}
+
+// complete runs a non-streaming chat completion for the transcript with the user's preferences applied.
+func (s *Service) complete(ctx context.Context, turn *Turn, transcript string, usage *Usage) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, chatRequestTimeout)
+	defer cancel()
+
+	messages := make([]map[string]string, 0, len(turn.request.History)+1)
+	for _, m := range turn.request.History {
+		messages = append(messages, map[string]string{"role": m.Role, "content": m.Content})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": transcript})
+
+	body, err := json.Marshal(map[string]interface{}{
+		"model":    turn.provider.Model,
+		"messages": messages,
+		"stream":   false,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	// Preferences add the system prompt, temperature and content policy instructions.
+	if applied, ok, err := preferences.Apply(body, turn.prefs); err == nil && ok {
+		body = applied
+	}
+	body, err = prependSystemMessage(body, voiceInstructions)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, turn.provider.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+turn.provider.APIKey)
+
+	resp, err := s.chatClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("call model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("model returned %d (model: %s)", resp.StatusCode, turn.Model)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	if result.Usage != nil {
+		usage.PromptTokens = result.Usage.PromptTokens
+		usage.CompletionTokens = result.Usage.CompletionTokens
+	}
+	if len(result.Choices) == 0 || strings.TrimSpace(result.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("empty response from model %s", turn.Model)
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
+
+// prependSystemMessage adds a system message ahead of all others in a chat completion body.
+func prependSystemMessage(body []byte, content string) ([]byte, error) {
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("parse request: %w", err)
+	}
+	var messages []json.RawMessage
+	if err := json.Unmarshal(request["messages"], &messages); err != nil {
+		return nil, fmt.Errorf("parse messages: %w", err)
+	}
+
+	system, err := json.Marshal(map[string]string{"role": "system", "content": content})
+	if err != nil {
+		return nil, fmt.Errorf("marshal system message: %w", err)
+	}
+	request["messages"], err = json.Marshal(append([]json.RawMessage{system}, messages...))
+	if err != nil {
+		return nil, fmt.Errorf("marshal messages: %w", err)
+	}
+	return json.Marshal(request)
+}
+
+// planTokens weighs chat tokens by the model multiplier; audio stages are charged by duration.
+func (s *Service) planTokens(turn *Turn, usage *Usage) int {
+	multiplier := turn.provider.TokenMultiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	chat := int(float64(usage.PromptTokens+usage.CompletionTokens) * multiplier)
+	return chat + audiousage.PlanTokens(usage.AudioSeconds, s.audioRate)
+}
+
+// logUsage records the turn as one request log entry. Transcription counts as prompt tokens
+// and speech as completion tokens.
+func (s *Service) logUsage(ctx context.Context, turn *Turn, usage *Usage) {
+	total := usage.TotalTokens()
+	if s.trackingService == nil || (total == 0 && usage.AudioSeconds == 0) {
+		return
+	}
+	if usage.PlanTokens == 0 {
+		usage.PlanTokens = s.planTokens(turn, usage)
+	}
+
+	multiplier := 1.0
+	if total > 0 {
+		multiplier = float64(usage.PlanTokens) / float64(total)
+	}
+	tokenData := &request_tracking.TokenUsageWithMultiplier{
+		PromptTokens:     usage.TranscriptionTokens + usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens + speechTokens(usage.SpeechCharacters),
+		TotalTokens:      total,
+		Multiplier:       multiplier,
+		PlanTokens:       usage.PlanTokens,
+	}
+	info := request_tracking.RequestInfo{
+		UserID:       turn.UserID,
+		Endpoint:     turn.Endpoint,
+		Model:        turn.Model,
+		Provider:     turn.provider.Name,
+		AudioSeconds: &usage.AudioSeconds,
+	}
+	if usage.SpeechCharacters > 0 {
+		info.AudioCharacters = &usage.SpeechCharacters
+	}
+
+	// The client may have disconnected; the usage must still be logged.
+	if err := s.trackingService.LogRequestWithPlanTokensAsync(context.WithoutCancel(ctx), info, tokenData); err != nil {
+		s.logger.WithContext(ctx).Error("failed to queue voice turn usage log",
+			slog.String("user_id", turn.UserID),
+			slog.String("model", turn.Model),
+			slog.Int("plan_tokens", usage.PlanTokens),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,123 @@
+package voice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eternisai/enchanted-proxy/internal/privacy"
+	"github.com/eternisai/enchanted-proxy/internal/routing"
+)
+
+func TestAudioClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audio/transcriptions":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("ParseMultipartForm() error = %v", err)
+			}
+			if got := r.FormValue("model"); got != "stt" {
+				t.Errorf("transcription model = %q, want stt", got)
+			}
+			_, _ = w.Write([]byte(`{"text":" hello ","usage":{"type":"tokens","total_tokens":42}}`))
+		case "/v2/audio/transcriptions":
+			_, _ = w.Write([]byte(`{"text":"hi","usage":{"type":"duration","seconds":7}}`))
+		case "/audio/speech":
+			var req map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req["model"] != "tts" || req["voice"] != "alloy" || req["response_format"] != "opus" {
+				t.Errorf("speech request = %v", req)
+			}
+			_, _ = w.Write([]byte("audio-bytes"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewAudioClient(server.URL, "key", "stt", "tts")
+
+	transcription, err := client.Transcribe(context.Background(), "clip.m4a", []byte("data"))
+	if err != nil {
+		t.Fatalf("Transcribe() error = %v", err)
+	}
+	if transcription.Text != " hello " || transcription.Tokens != 42 {
+		t.Errorf("Transcribe() = %+v", transcription)
+	}
+
+	durationClient := NewAudioClient(server.URL+"/v2", "key", "whisper", "tts")
+	transcription, err = durationClient.Transcribe(context.Background(), "clip.wav", []byte("data"))
+	if err != nil {
+		t.Fatalf("Transcribe() error = %v", err)
+	}
+	if transcription.Seconds != 7 || transcription.Tokens != 0 {
+		t.Errorf("Transcribe() duration-billed = %+v", transcription)
+	}
+
+	audio, err := client.Speak(context.Background(), "hi", "alloy", "opus")
+	if err != nil {
+		t.Fatalf("Speak() error = %v", err)
+	}
+	defer audio.Close()
+	if data, _ := io.ReadAll(audio); string(data) != "audio-bytes" {
+		t.Errorf("Speak() audio = %q", data)
+	}
+}
+
+func TestPlanTokens(t *testing.T) {
+	usage := &Usage{TranscriptionTokens: 10, PromptTokens: 100, CompletionTokens: 50, SpeechCharacters: 21, AudioSeconds: 90}
+	s := &Service{audioRate: 1000}
+
+	tests := []struct {
+		name       string
+		multiplier float64
+		want       int
+	}{
+		{"weighted chat", 2, 300 + 1500},
+		{"missing multiplier counts as 1x", 0, 150 + 1500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			turn := &Turn{provider: &routing.ProviderConfig{TokenMultiplier: tt.multiplier}}
+			if got := s.planTokens(turn, usage); got != tt.want {
+				t.Errorf("planTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if got := usage.TotalTokens(); got != 166 {
+		t.Errorf("TotalTokens() = %d, want 166", got)
+	}
+}
+
+func TestPrependSystemMessage(t *testing.T) {
+	body := []byte(`{"model":"m","messages":[{"role":"system","content":"user prompt"},{"role":"user","content":"hi"}]}`)
+
+	got, err := prependSystemMessage(body, "voice")
+	if err != nil {
+		t.Fatalf("prependSystemMessage() error = %v", err)
+	}
+
+	var request struct {
+		Messages []HistoryMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(got, &request); err != nil {
+		t.Fatalf("result is not valid JSON: %v", err)
+	}
+	if len(request.Messages) != 3 || request.Messages[0].Content != "voice" || request.Messages[1].Content != "user prompt" {
+		t.Errorf("messages = %+v", request.Messages)
+	}
+}
+
+func TestPreparePrivacyMode(t *testing.T) {
+	s := &Service{}
+	_, err := s.Prepare(privacy.WithContext(context.Background()), "user-1", "mobile", &TurnRequest{Model: "gpt-4.1"})
+	if !errors.Is(err, ErrPrivacyMode) {
+		t.Errorf("Prepare() error = %v, want ErrPrivacyMode", err)
+	}
+}
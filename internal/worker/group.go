@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Group tracks long-running background goroutines (one per task, e.g. a polling worker)
+// so they can be awaited on shutdown.
+type Group struct {
+	name string
+	wg   sync.WaitGroup
+}
+
+// NewGroup creates a group. name is used as metrics label.
+func NewGroup(name string) *Group {
+	activeTasks.WithLabelValues(name).Set(0)
+	return &Group{name: name}
+}
+
+// Go runs fn in a tracked goroutine.
+func (g *Group) Go(fn func()) {
+	g.wg.Add(1)
+	activeTasks.WithLabelValues(g.name).Inc()
+	go func() {
+		defer g.wg.Done()
+		defer activeTasks.WithLabelValues(g.name).Dec()
+		fn()
+	}()
+}
+
+// Wait blocks until all goroutines returned or ctx is done. Returns ctx.Err() on timeout;
+// goroutines still running are left behind, so callers should cancel them first.
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
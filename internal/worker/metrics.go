@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Job results recorded in jobsTotal.
+const (
+	resultSuccess = "success"
+	resultFailed  = "failed"
+	resultRetried = "retried"
+	resultDropped = "dropped"
+)
+
+var (
+	// queueDepth tracks the number of queued jobs per pool.
+	queueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "background_worker_queue_depth",
+			Help: "Number of jobs waiting in the queue, by pool.",
+		},
+		[]string{"pool"},
+	)
+
+	// jobsTotal counts job outcomes per pool: success, failed (after all attempts), retried
+	// (one per extra attempt) and dropped (queue full or shutting down).
+	jobsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "background_worker_jobs_total",
+			Help: "Total background jobs, by pool and result.",
+		},
+		[]string{"pool", "result"},
+	)
+
+	// jobDuration observes how long a job took, including retries.
+	jobDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "background_worker_job_duration",
+			Help:    "Background job duration in seconds, including retries, by pool.",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"pool"},
+	)
+
+	// activeTasks tracks running goroutines of a Group.
+	activeTasks = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "background_worker_active_tasks",
+			Help: "Number of running background tasks, by group.",
+		},
+		[]string{"pool"},
+	)
+)
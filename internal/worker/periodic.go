@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+// RunPeriodic calls task every interval until ctx is done. Failed runs are logged and counted;
+// the next tick runs regardless. Blocks, so start it with go.
+func RunPeriodic(ctx context.Context, name string, interval time.Duration, logger *logger.Logger, task func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			err := task(ctx)
+			jobDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+			if err != nil {
+				jobsTotal.WithLabelValues(name, resultFailed).Inc()
+				logger.Error("periodic job failed",
+					slog.String("pool", name),
+					slog.String("error", err.Error()))
+				continue
+			}
+			jobsTotal.WithLabelValues(name, resultSuccess).Inc()
+		}
+	}
+}
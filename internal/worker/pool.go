@@ -0,0 +1,296 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+var (
+	// ErrClosed is returned when enqueueing into a pool that is shutting down.
+	ErrClosed = errors.New("worker pool is shutting down")
+
+	// ErrQueueFull is returned by TryEnqueue when the queue has no space.
+	ErrQueueFull = errors.New("worker queue is full")
+)
+
+// slowEnqueueWarning is how long Enqueue waits for queue space before logging a warning.
+const slowEnqueueWarning = 5 * time.Second
+
+// Handler processes a single job. Returning an error marks the attempt as failed; the job is
+// retried according to the pool's RetryPolicy unless the error is wrapped with Permanent.
+type Handler[T any] func(ctx context.Context, job T) error
+
+// Options configures a Pool.
+type Options struct {
+	Name      string        // Pool name, used as metrics label and log attribute
+	Workers   int           // Number of worker goroutines (min 1)
+	QueueSize int           // Queue capacity
+	Timeout   time.Duration // Per-attempt timeout of the job context (0 = none)
+	Retry     RetryPolicy   // Zero value = no retries
+}
+
+// Pool processes jobs from a bounded queue with a fixed number of workers.
+//
+// Shutdown semantics are the same for every pool: stop accepting jobs, let workers drain the
+// queue, and if the caller's deadline expires, cancel in-flight jobs so shutdown always returns.
+// The queue channel is never closed, so Enqueue can't panic on a send to a closed channel.
+type Pool[T any] struct {
+	opts     Options
+	handler  Handler[T]
+	logger   *logger.Logger
+	queue    chan T
+	workers  sync.WaitGroup
+	shutdown chan struct{}
+	closed   atomic.Bool
+
+	// resizeMu guards size, the number of workers after pending retirements. A worker exits
+	// when it receives from retire.
+	resizeMu sync.Mutex
+	size     int
+	retire   chan struct{}
+
+	// ctx is the parent of every job context. Cancelled by Shutdown when the drain deadline is
+	// exceeded, which forces in-flight jobs to abort instead of holding shutdown open.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewPool creates a pool and starts its workers.
+func NewPool[T any](opts Options, handler Handler[T], logger *logger.Logger) *Pool[T] {
+	opts.Workers = max(opts.Workers, 1)
+	opts.QueueSize = max(opts.QueueSize, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[T]{
+		opts:     opts,
+		handler:  handler,
+		logger:   logger,
+		queue:    make(chan T, opts.QueueSize),
+		shutdown: make(chan struct{}),
+		size:     opts.Workers,
+		retire:   make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		p.workers.Add(1)
+		go p.run()
+	}
+
+	queueDepth.WithLabelValues(opts.Name).Set(0)
+	return p
+}
+
+// Enqueue queues a job, waiting for queue space until ctx is done or the pool shuts down.
+// A warning is logged if the queue stays full for slowEnqueueWarning.
+func (p *Pool[T]) Enqueue(ctx context.Context, job T) error {
+	if p.closed.Load() {
+		p.drop()
+		return ErrClosed
+	}
+
+	select {
+	case p.queue <- job:
+		p.updateDepth()
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(slowEnqueueWarning)
+	defer timer.Stop()
+
+	for {
+		select {
+		case p.queue <- job:
+			p.updateDepth()
+			return nil
+		case <-ctx.Done():
+			p.drop()
+			return ctx.Err()
+		case <-p.shutdown:
+			p.drop()
+			return ErrClosed
+		case <-timer.C:
+			p.logger.Warn("worker queue full, waiting for space",
+				slog.String("pool", p.opts.Name),
+				slog.Int("queue_capacity", cap(p.queue)))
+		}
+	}
+}
+
+// TryEnqueue queues a job without blocking. Returns ErrQueueFull or ErrClosed if it can't.
+func (p *Pool[T]) TryEnqueue(job T) error {
+	if p.closed.Load() {
+		p.drop()
+		return ErrClosed
+	}
+
+	select {
+	case p.queue <- job:
+		p.updateDepth()
+		return nil
+	default:
+		p.drop()
+		return ErrQueueFull
+	}
+}
+
+// Flush processes queued jobs on the calling goroutine until the queue is empty or ctx is
+// done, alongside the regular workers. Returns how many jobs were processed.
+func (p *Pool[T]) Flush(ctx context.Context) int {
+	flushed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return flushed
+		case job := <-p.queue:
+			p.process(job)
+			flushed++
+		default:
+			return flushed
+		}
+	}
+}
+
+// Len returns the number of queued jobs.
+func (p *Pool[T]) Len() int {
+	return len(p.queue)
+}
+
+// Cap returns the queue capacity.
+func (p *Pool[T]) Cap() int {
+	return cap(p.queue)
+}
+
+// Resize changes the number of workers (min 1). Workers removed finish their current job
+// first. Does nothing once the pool is shutting down.
+func (p *Pool[T]) Resize(workers int) {
+	workers = max(workers, 1)
+
+	p.resizeMu.Lock()
+	defer p.resizeMu.Unlock()
+	if p.closed.Load() || workers == p.size {
+		return
+	}
+
+	for ; p.size < workers; p.size++ {
+		p.workers.Add(1)
+		go p.run()
+	}
+	for ; p.size > workers; p.size-- {
+		go func() {
+			select {
+			case p.retire <- struct{}{}:
+			case <-p.shutdown:
+			}
+		}()
+	}
+	p.logger.Info("worker pool resized",
+		slog.String("pool", p.opts.Name),
+		slog.Int("workers", workers))
+}
+
+// Workers returns the number of workers, not counting those pending removal.
+func (p *Pool[T]) Workers() int {
+	p.resizeMu.Lock()
+	defer p.resizeMu.Unlock()
+	return p.size
+}
+
+// Shutdown stops accepting jobs and waits for the workers to drain the queue. If ctx expires
+// first, in-flight jobs are cancelled and ctx.Err() is returned once the workers exit.
+// Jobs still queued at that point are processed with a cancelled context.
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	p.resizeMu.Lock()
+	if !p.closed.CompareAndSwap(false, true) {
+		p.resizeMu.Unlock()
+		return nil
+	}
+	close(p.shutdown)
+	p.resizeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *Pool[T]) run() {
+	defer p.workers.Done()
+
+	for {
+		select {
+		case job := <-p.queue:
+			p.process(job)
+		case <-p.retire:
+			return
+		case <-p.shutdown:
+			// Drain what was queued before shutdown.
+			for {
+				select {
+				case job := <-p.queue:
+					p.process(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// process runs a job with retries and records its outcome.
+func (p *Pool[T]) process(job T) {
+	p.updateDepth()
+	start := time.Now()
+
+	err := p.opts.Retry.Do(p.ctx, func(ctx context.Context) error {
+		if p.opts.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.opts.Timeout)
+			defer cancel()
+		}
+		return p.handler(ctx, job)
+	}, func(attempt int, err error) {
+		jobsTotal.WithLabelValues(p.opts.Name, resultRetried).Inc()
+		p.logger.Warn("retrying background job",
+			slog.String("pool", p.opts.Name),
+			slog.Int("attempt", attempt),
+			slog.String("error", err.Error()))
+	})
+
+	jobDuration.WithLabelValues(p.opts.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		jobsTotal.WithLabelValues(p.opts.Name, resultFailed).Inc()
+		p.logger.Error("background job failed",
+			slog.String("pool", p.opts.Name),
+			slog.String("error", err.Error()))
+		return
+	}
+	jobsTotal.WithLabelValues(p.opts.Name, resultSuccess).Inc()
+}
+
+func (p *Pool[T]) drop() {
+	jobsTotal.WithLabelValues(p.opts.Name, resultDropped).Inc()
+}
+
+func (p *Pool[T]) updateDepth() {
+	queueDepth.WithLabelValues(p.opts.Name).Set(float64(len(p.queue)))
+}
@@ -0,0 +1,201 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eternisai/enchanted-proxy/internal/logger"
+)
+
+func testLogger() *logger.Logger {
+	return logger.New(logger.Config{Level: slog.LevelError})
+}
+
+func TestPool_DrainsQueueOnShutdown(t *testing.T) {
+	var processed atomic.Int32
+	pool := NewPool(Options{Name: "test_drain", Workers: 2, QueueSize: 100}, func(ctx context.Context, job int) error {
+		processed.Add(1)
+		return nil
+	}, testLogger())
+
+	for i := 0; i < 50; i++ {
+		if err := pool.Enqueue(context.Background(), i); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := processed.Load(); got != 50 {
+		t.Errorf("processed %d jobs, want 50", got)
+	}
+
+	if err := pool.Enqueue(context.Background(), 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Enqueue() after shutdown = %v, want ErrClosed", err)
+	}
+	if err := pool.TryEnqueue(1); !errors.Is(err, ErrClosed) {
+		t.Errorf("TryEnqueue() after shutdown = %v, want ErrClosed", err)
+	}
+}
+
+func TestPool_TryEnqueueFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	pool := NewPool(Options{Name: "test_full", Workers: 1, QueueSize: 1}, func(ctx context.Context, job int) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}, testLogger())
+
+	// First job occupies the worker, second fills the queue.
+	if err := pool.TryEnqueue(1); err != nil {
+		t.Fatalf("TryEnqueue() error = %v", err)
+	}
+	<-started
+	if err := pool.TryEnqueue(2); err != nil {
+		t.Fatalf("TryEnqueue() error = %v", err)
+	}
+	if err := pool.TryEnqueue(3); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TryEnqueue() = %v, want ErrQueueFull", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Enqueue(ctx, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Enqueue() = %v, want DeadlineExceeded", err)
+	}
+
+	close(release)
+	_ = pool.Shutdown(context.Background())
+}
+
+func TestPool_Resize(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	var processed atomic.Int32
+	pool := NewPool(Options{Name: "test_resize", Workers: 1, QueueSize: 10}, func(ctx context.Context, job int) error {
+		started <- struct{}{}
+		<-release
+		processed.Add(1)
+		return nil
+	}, testLogger())
+
+	// Three workers run three jobs at once.
+	pool.Resize(3)
+	for i := 0; i < 3; i++ {
+		if err := pool.TryEnqueue(i); err != nil {
+			t.Fatalf("TryEnqueue() error = %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatalf("%d jobs started, want 3", i)
+		}
+	}
+	close(release)
+
+	pool.Resize(0)
+	if got := pool.Workers(); got != 1 {
+		t.Errorf("Workers() = %d, want 1", got)
+	}
+	for i := 0; i < 3; i++ {
+		if err := pool.TryEnqueue(i); err != nil {
+			t.Fatalf("TryEnqueue() error = %v", err)
+		}
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := processed.Load(); got != 6 {
+		t.Errorf("processed %d jobs, want 6", got)
+	}
+	pool.Resize(2) // No-op after shutdown
+}
+
+func TestPool_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int32
+	}{
+		{"transient errors are retried", errors.New("unavailable"), 3},
+		{"permanent errors are not retried", Permanent(errors.New("invalid")), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			pool := NewPool(Options{
+				Name:      "test_retry",
+				QueueSize: 1,
+				Retry:     RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			}, func(ctx context.Context, job int) error {
+				attempts.Add(1)
+				return tt.err
+			}, testLogger())
+
+			if err := pool.Enqueue(context.Background(), 1); err != nil {
+				t.Fatalf("Enqueue() error = %v", err)
+			}
+			_ = pool.Shutdown(context.Background())
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestPool_ShutdownDeadlineCancelsJobs(t *testing.T) {
+	started := make(chan struct{})
+	pool := NewPool(Options{Name: "test_deadline", QueueSize: 1}, func(ctx context.Context, job int) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, testLogger())
+
+	if err := pool.Enqueue(context.Background(), 1); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 6, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	want := map[int]time.Duration{
+		2: 100 * time.Millisecond,
+		3: 200 * time.Millisecond,
+		4: 400 * time.Millisecond,
+		5: 800 * time.Millisecond,
+		6: time.Second,
+	}
+	for attempt, delay := range want {
+		if got := p.backoff(attempt); got != delay {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, delay)
+		}
+	}
+}
+
+func TestRetryPolicy_Jitter(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(3); got < 160*time.Millisecond || got > 240*time.Millisecond {
+			t.Fatalf("backoff(3) = %v, want within ±20%% of 200ms", got)
+		}
+	}
+}
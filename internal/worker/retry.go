@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how failed jobs are retried. The zero value disables retries.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first (<= 1 = no retries)
+	InitialBackoff time.Duration // Delay before the second attempt, doubled after each failure
+	MaxBackoff     time.Duration // Upper bound for the delay (0 = unbounded)
+	Jitter         float64       // Fraction of the delay randomized, e.g. 0.2 = ±20% (0 = none)
+}
+
+// permanentError marks an error that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the pool doesn't retry it (e.g. validation or encryption failures).
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// backoff returns the delay before the given attempt (attempt 2 is the first retry), with jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseBackoff(attempt)
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// baseBackoff returns the exponential delay before the given attempt, without jitter.
+func (p RetryPolicy) baseBackoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 2; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// Do runs fn until it succeeds, returns a permanent error, ctx is done, or attempts run out.
+// onRetry (optional) is called before each retry with the attempt number and the last error.
+// Returns the last error.
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error, onRetry func(attempt int, err error)) error {
+	attempts := max(p.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if onRetry != nil {
+				onRetry(attempt, err)
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(p.backoff(attempt)):
+			}
+		}
+
+		err = fn(ctx)
+		if err == nil || IsPermanent(err) {
+			return err
+		}
+	}
+	return err
+}
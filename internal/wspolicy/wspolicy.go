@@ -0,0 +1,163 @@
+// Package wspolicy enforces origin and subprotocol rules on websocket upgrades.
+//
+// Browsers don't apply CORS to websockets, so any page could open an authenticated connection
+// on a user's behalf. Upgrades carrying an Origin header are only accepted from the request's
+// own host or an allowlisted origin (WEBSOCKET_ALLOWED_ORIGINS). Requests without an Origin
+// header come from native clients and are accepted.
+//
+// Each endpoint declares its versioned subprotocols (e.g. "enchanted.deepr.v1"), negotiated
+// via Sec-WebSocket-Protocol. Offering only unsupported subprotocols (e.g. a newer protocol
+// version) is always rejected; offering none is rejected once WEBSOCKET_REQUIRE_SUBPROTOCOL is
+// enabled, after clients have rolled out the handshake.
+package wspolicy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/eternisai/enchanted-proxy/internal/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Subprotocols of the websocket endpoints.
+const (
+	SubprotocolDeepResearch = "enchanted.deepr.v1"
+	SubprotocolKeyShare     = "enchanted.keyshare.v1"
+	SubprotocolRealtime     = "enchanted.realtime.v1"
+)
+
+// SubprotocolsGraphQL are the GraphQL over websocket protocols implemented by gqlgen.
+var SubprotocolsGraphQL = []string{"graphql-transport-ws", "graphql-ws"}
+
+// Policy is the origin and subprotocol policy of websocket upgrades.
+type Policy struct {
+	allowAllOrigins    bool
+	allowedOrigins     map[string]bool
+	requireSubprotocol bool
+}
+
+// New creates a policy. allowedOrigins are full origins (scheme://host[:port]); "*" allows any
+// origin.
+func New(allowedOrigins []string, requireSubprotocol bool) *Policy {
+	p := &Policy{
+		allowedOrigins:     make(map[string]bool),
+		requireSubprotocol: requireSubprotocol,
+	}
+	for _, origin := range allowedOrigins {
+		origin = strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/"))
+		switch origin {
+		case "":
+		case "*":
+			p.allowAllOrigins = true
+		default:
+			p.allowedOrigins[origin] = true
+		}
+	}
+	return p
+}
+
+// CheckOrigin reports whether an upgrade request's origin is allowed. Used as the upgraders'
+// CheckOrigin.
+func (p *Policy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || p.allowAllOrigins {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return p.allowedOrigins[strings.ToLower(origin)]
+}
+
+// Upgrader returns a websocket upgrader enforcing the origin policy and negotiating the given
+// subprotocols (in order of preference).
+func (p *Policy) Upgrader(subprotocols ...string) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		CheckOrigin:     p.CheckOrigin,
+		Subprotocols:    subprotocols,
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+}
+
+// Rejection describes an upgrade request rejected by the policy.
+type Rejection struct {
+	Status       int
+	Origin       string // Set for rejected origins
+	Message      string
+	Subprotocols []string // Supported subprotocols, set for rejected handshakes
+}
+
+func (r *Rejection) Error() string {
+	return r.Message
+}
+
+// Check validates an upgrade request against the policy before upgrading, so rejections get a
+// regular HTTP error response. Returns nil if the request is accepted.
+func (p *Policy) Check(r *http.Request, subprotocols []string) *Rejection {
+	if !p.CheckOrigin(r) {
+		origin := r.Header.Get("Origin")
+		return &Rejection{Status: http.StatusForbidden, Origin: origin, Message: "websocket origin " + origin + " is not allowed"}
+	}
+
+	offered := websocket.Subprotocols(r)
+	if len(offered) == 0 {
+		if p.requireSubprotocol {
+			return &Rejection{Status: http.StatusBadRequest, Message: "a websocket subprotocol is required", Subprotocols: subprotocols}
+		}
+		return nil
+	}
+	for _, protocol := range offered {
+		for _, supported := range subprotocols {
+			if protocol == supported {
+				return nil
+			}
+		}
+	}
+	return &Rejection{Status: http.StatusBadRequest, Message: "unsupported websocket subprotocol " + strings.Join(offered, ", "), Subprotocols: subprotocols}
+}
+
+// Upgrade checks the request against the policy and upgrades it to a websocket negotiating one
+// of the given subprotocols. On error (a *Rejection for rejected requests) the error response
+// has been written.
+func (p *Policy) Upgrade(c *gin.Context, subprotocols ...string) (*websocket.Conn, error) {
+	if rejection := p.Check(c.Request, subprotocols); rejection != nil {
+		if rejection.Status == http.StatusForbidden {
+			errors.AbortWithForbidden(c, errors.OriginNotAllowed(rejection.Origin))
+		} else {
+			errors.AbortWithBadRequest(c, rejection.Message, map[string]interface{}{"supported_subprotocols": rejection.Subprotocols})
+		}
+		return nil, rejection
+	}
+
+	return p.Upgrader(subprotocols...).Upgrade(c.Writer, c.Request, nil)
+}
+
+// Middleware enforces the policy on websocket upgrade requests of a net/http handler (the GraphQL
+// server); other requests pass through.
+func (p *Policy) Middleware(subprotocols ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if websocket.IsWebSocketUpgrade(r) {
+				if rejection := p.Check(r, subprotocols); rejection != nil {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(rejection.Status)
+					_ = json.NewEncoder(w).Encode(map[string]interface{}{
+						"error":                  rejection.Message,
+						"supported_subprotocols": rejection.Subprotocols,
+					})
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package wspolicy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func upgradeRequest(origin string, protocols ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/ws", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	if len(protocols) > 0 {
+		r.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+	}
+	return r
+}
+
+func TestCheck(t *testing.T) {
+	supported := []string{SubprotocolDeepResearch}
+	tests := []struct {
+		name       string
+		policy     *Policy
+		origin     string
+		protocols  []string
+		wantStatus int
+	}{
+		{name: "native client", policy: New(nil, false)},
+		{name: "same host", policy: New(nil, false), origin: "https://api.example.com"},
+		{name: "allowlisted origin", policy: New([]string{" https://app.example.com/ "}, false), origin: "https://APP.example.com"},
+		{name: "foreign origin", policy: New([]string{"https://app.example.com"}, false), origin: "https://evil.example.net", wantStatus: http.StatusForbidden},
+		{name: "any origin", policy: New([]string{"*"}, false), origin: "https://evil.example.net"},
+		{name: "supported subprotocol", policy: New(nil, true), protocols: []string{"enchanted.deepr.v2", SubprotocolDeepResearch}},
+		{name: "unsupported version", policy: New(nil, false), protocols: []string{"enchanted.deepr.v2"}, wantStatus: http.StatusBadRequest},
+		{name: "missing subprotocol, optional", policy: New(nil, false)},
+		{name: "missing subprotocol, required", policy: New(nil, true), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rejection := tt.policy.Check(upgradeRequest(tt.origin, tt.protocols...), supported)
+			if tt.wantStatus == 0 {
+				if rejection != nil {
+					t.Fatalf("unexpected rejection: %v", rejection)
+				}
+				return
+			}
+			if rejection == nil || rejection.Status != tt.wantStatus {
+				t.Fatalf("rejection = %+v, want status %d", rejection, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUpgradeNegotiatesSubprotocol(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := New([]string{"https://app.example.com"}, true)
+
+	router := gin.New()
+	router.GET("/ws", func(c *gin.Context) {
+		conn, err := policy.Upgrade(c, SubprotocolKeyShare)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	dialer := websocket.Dialer{Subprotocols: []string{SubprotocolKeyShare}}
+
+	conn, _, err := dialer.Dial(url, http.Header{"Origin": {"https://app.example.com"}})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	if conn.Subprotocol() != SubprotocolKeyShare {
+		t.Errorf("negotiated subprotocol = %q, want %q", conn.Subprotocol(), SubprotocolKeyShare)
+	}
+	conn.Close()
+
+	_, resp, err := dialer.Dial(url, http.Header{"Origin": {"https://evil.example.net"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign origin: err = %v, response = %v; want 403", err, resp)
+	}
+
+	_, resp, err = websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing subprotocol: err = %v, response = %v; want 400", err, resp)
+	}
+}